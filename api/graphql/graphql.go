@@ -0,0 +1,12 @@
+// Package graphql embeds the query service's GraphQL schema, served at
+// /schema.graphql. The executable schema is defined in Go by the query
+// service; its tests keep this document identical to it, so the document
+// here is the contract clients generate their types from.
+package graphql
+
+import _ "embed"
+
+// Query is the query service GraphQL schema (query.graphql).
+//
+//go:embed query.graphql
+var Query []byte
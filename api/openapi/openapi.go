@@ -0,0 +1,238 @@
+// Package openapi embeds the platform's OpenAPI specifications. The services
+// serve them at /openapi.json and validate incoming requests against them, so
+// the documents here are the API contract rather than a description of it.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Ingestion is the ingestion service API (ingestion.yaml).
+//
+//go:embed ingestion.yaml
+var Ingestion []byte
+
+// Query is the query service API (query.yaml).
+//
+//go:embed query.yaml
+var Query []byte
+
+// Actions is the actions service API (actions.yaml).
+//
+//go:embed actions.yaml
+var Actions []byte
+
+// Spec is a loaded OpenAPI document.
+type Spec struct {
+	doc    map[string]any
+	json   []byte
+	routes []route
+}
+
+// route is one operation of the document, matched by method and path template.
+type route struct {
+	method   string
+	segments []string // path template split on "/"; "{name}" segments are parameters
+	literals int      // non-parameter segments, to prefer /x/batch-get over /x/{id}
+	op       map[string]any
+	params   []map[string]any // path-level and operation-level parameters
+}
+
+// Load parses a YAML OpenAPI document and checks that every $ref resolves.
+func Load(data []byte) (*Spec, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert OpenAPI document to JSON: %w", err)
+	}
+
+	s := &Spec{doc: doc, json: raw}
+	if err := s.checkRefs(doc); err != nil {
+		return nil, err
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	for template, item := range paths {
+		pathItem, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid path item %s", template)
+		}
+		shared := s.parameters(pathItem)
+		for method, op := range pathItem {
+			operation, ok := op.(map[string]any)
+			if !ok || method == "parameters" {
+				continue
+			}
+			r := route{
+				method:   strings.ToUpper(method),
+				segments: strings.Split(strings.Trim(template, "/"), "/"),
+				op:       operation,
+				params:   append(append([]map[string]any{}, shared...), s.parameters(operation)...),
+			}
+			for _, seg := range r.segments {
+				if !isParam(seg) {
+					r.literals++
+				}
+			}
+			s.routes = append(s.routes, r)
+		}
+	}
+	sort.SliceStable(s.routes, func(i, j int) bool { return s.routes[i].literals > s.routes[j].literals })
+	return s, nil
+}
+
+// MustLoad is Load for the embedded documents, which are covered by tests.
+func MustLoad(data []byte) *Spec {
+	s, err := Load(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// JSON returns the document as JSON.
+func (s *Spec) JSON() []byte {
+	return s.json
+}
+
+// ServeJSON handles GET /openapi.json.
+func (s *Spec) ServeJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(s.json)
+}
+
+// parameters returns the resolved parameter objects of a path item or operation.
+func (s *Spec) parameters(node map[string]any) []map[string]any {
+	list, _ := node["parameters"].([]any)
+	params := make([]map[string]any, 0, len(list))
+	for _, p := range list {
+		if param, ok := s.resolve(p).(map[string]any); ok {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// resolve follows a local $ref ("#/components/schemas/Name"); other nodes are
+// returned unchanged.
+func (s *Spec) resolve(node any) any {
+	for {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return node
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return node
+		}
+		target, err := s.lookup(ref)
+		if err != nil {
+			return nil
+		}
+		node = target
+	}
+}
+
+func (s *Spec) lookup(ref string) (any, error) {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are supported", ref)
+	}
+	var node any = s.doc
+	for _, key := range strings.Split(path, "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolved $ref %q", ref)
+		}
+		if node, ok = m[key]; !ok {
+			return nil, fmt.Errorf("unresolved $ref %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// checkRefs walks the document and reports the first $ref that does not resolve.
+func (s *Spec) checkRefs(node any) error {
+	switch n := node.(type) {
+	case map[string]any:
+		if ref, ok := n["$ref"].(string); ok {
+			if _, err := s.lookup(ref); err != nil {
+				return err
+			}
+		}
+		for _, v := range n {
+			if err := s.checkRefs(v); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, v := range n {
+			if err := s.checkRefs(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// match finds the operation for a request path, with the values of its path
+// parameters. pathFound reports whether any operation has this path, so callers
+// can leave unknown methods to the handler's own 405.
+func (s *Spec) match(method, path string) (r *route, params map[string]string, pathFound bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := range s.routes {
+		candidate := &s.routes[i]
+		values, ok := matchSegments(candidate.segments, segments)
+		if !ok {
+			continue
+		}
+		pathFound = true
+		if candidate.method == method {
+			return candidate, values, true
+		}
+	}
+	return nil, nil, pathFound
+}
+
+func matchSegments(template, segments []string) (map[string]string, bool) {
+	if len(template) != len(segments) {
+		return nil, false
+	}
+	values := map[string]string{}
+	for i, seg := range template {
+		if isParam(seg) {
+			if segments[i] == "" {
+				return nil, false
+			}
+			values[strings.Trim(seg, "{}")] = segments[i]
+		} else if seg != segments[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
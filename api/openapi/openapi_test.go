@@ -0,0 +1,160 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_EmbeddedSpecs(t *testing.T) {
+	for name, data := range map[string][]byte{"ingestion": Ingestion, "query": Query, "actions": Actions} {
+		t.Run(name, func(t *testing.T) {
+			s, err := Load(data)
+			require.NoError(t, err)
+
+			var doc map[string]any
+			require.NoError(t, json.Unmarshal(s.JSON(), &doc))
+			assert.Equal(t, "3.0.3", doc["openapi"])
+			assert.Contains(t, doc["paths"], "/openapi.json")
+		})
+	}
+}
+
+func TestLoad_UnresolvedRef(t *testing.T) {
+	_, err := Load([]byte(`
+openapi: 3.0.3
+paths:
+  /x:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Missing'
+`))
+	assert.ErrorContains(t, err, "unresolved $ref")
+}
+
+func TestServeJSON(t *testing.T) {
+	s := MustLoad(Ingestion)
+
+	w := httptest.NewRecorder()
+	s.ServeJSON(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(s.JSON()), w.Body.String())
+
+	w = httptest.NewRecorder()
+	s.ServeJSON(w, httptest.NewRequest(http.MethodPost, "/openapi.json", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestValidate_Query(t *testing.T) {
+	handler := MustLoad(Query).Validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		want    int
+		wantErr string
+	}{
+		{"valid list", http.MethodGet, "/api/v1/projections/sensor_state?limit=100&offset=0&deleted=1", "", http.StatusNoContent, ""},
+		{"valid get", http.MethodGet, "/api/v1/projections/user_session/user-1?units=metric", "", http.StatusNoContent, ""},
+		{"trailing slash", http.MethodGet, "/api/v1/projections/sensor_state/", "", http.StatusNoContent, ""},
+		{"unknown type", http.MethodGet, "/api/v1/projections/widget", "", http.StatusBadRequest, "invalid path parameter projection_type: must be one of sensor_state, user_session"},
+		{"limit too large", http.MethodGet, "/api/v1/projections/sensor_state?limit=500", "", http.StatusBadRequest, "invalid query parameter limit: must be at most 100"},
+		{"limit not a number", http.MethodGet, "/api/v1/projections/sensor_state?limit=ten", "", http.StatusBadRequest, "invalid query parameter limit: must be a number"},
+		{"negative offset", http.MethodGet, "/api/v1/projections/sensor_state?offset=-1", "", http.StatusBadRequest, "invalid query parameter offset: must be at least 0"},
+		{"bad boolean", http.MethodGet, "/api/v1/projections/sensor_state?deleted=maybe", "", http.StatusBadRequest, "invalid query parameter deleted: must be a boolean"},
+		{"q too long", http.MethodGet, "/api/v1/projections/sensor_state?q=" + strings.Repeat("a", 256), "", http.StatusBadRequest, "invalid query parameter q: must be at most 255 characters"},
+		{"unknown units", http.MethodGet, "/api/v1/aggregates/dev-1/projections?units=kelvin", "", http.StatusBadRequest, "invalid query parameter units: must be one of metric, imperial"},
+		{"valid batch-get", http.MethodPost, "/api/v1/projections/sensor_state/batch-get", `{"aggregate_ids": ["a", "b"]}`, http.StatusNoContent, ""},
+		{"batch-get missing body", http.MethodPost, "/api/v1/projections/sensor_state/batch-get", "", http.StatusBadRequest, "request body is required"},
+		{"batch-get invalid JSON", http.MethodPost, "/api/v1/projections/sensor_state/batch-get", `{`, http.StatusBadRequest, "invalid JSON: unexpected EOF"},
+		{"batch-get no IDs", http.MethodPost, "/api/v1/projections/sensor_state/batch-get", `{"aggregate_ids": []}`, http.StatusBadRequest, "invalid request body: aggregate_ids must contain at least 1 items"},
+		{"batch-get empty ID", http.MethodPost, "/api/v1/projections/sensor_state/batch-get", `{"aggregate_ids": ["a", ""]}`, http.StatusBadRequest, "invalid request body: aggregate_ids[1] must be at least 1 characters"},
+		{"batch-get wrong type", http.MethodPost, "/api/v1/projections/sensor_state/batch-get", `{"aggregate_ids": "a"}`, http.StatusBadRequest, "invalid request body: aggregate_ids must be an array"},
+		{"unknown path passes through", http.MethodGet, "/api/v1/nothing", "", http.StatusNoContent, ""},
+		{"unknown method passes through", http.MethodDelete, "/api/v1/events?limit=500", "", http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tt.want, w.Code, w.Body.String())
+			if tt.wantErr != "" {
+				var body map[string]string
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantErr, body["error"])
+			}
+		})
+	}
+}
+
+func TestValidate_IngestBody(t *testing.T) {
+	var received string
+	handler := MustLoad(Ingestion).Validate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	tests := []struct {
+		name    string
+		body    string
+		want    int
+		wantErr string
+	}{
+		{"valid", `{"event_type":"sensor.reading","aggregate_id":"d1","payload":{"value":1},"event_time":"2026-02-07T10:00:00Z"}`, http.StatusAccepted, ""},
+		{"missing event_type", `{"aggregate_id":"d1","payload":{}}`, http.StatusBadRequest, "invalid request body: event_type is required"},
+		{"payload not an object", `{"event_type":"a.b","aggregate_id":"d1","payload":[1]}`, http.StatusBadRequest, "invalid request body: payload must be an object"},
+		{"bad event_time", `{"event_type":"a.b","aggregate_id":"d1","payload":{},"event_time":"yesterday"}`, http.StatusBadRequest, "invalid request body: event_time must be an RFC 3339 date-time"},
+		{"null trace_id", `{"event_type":"a.b","aggregate_id":"d1","payload":{},"trace_id":null}`, http.StatusBadRequest, "invalid request body: trace_id must not be null"},
+		{"body not an object", `"event"`, http.StatusBadRequest, "invalid request body: must be an object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = ""
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			require.Equal(t, tt.want, w.Code, w.Body.String())
+			if tt.wantErr != "" {
+				var body map[string]string
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantErr, body["error"])
+				return
+			}
+			// The handler still sees the full body after validation read it.
+			assert.Equal(t, tt.body, received)
+		})
+	}
+}
+
+func TestMatch_PrefersLiteralSegments(t *testing.T) {
+	s := MustLoad(Query)
+
+	r, params, _ := s.match(http.MethodPost, "/api/v1/projections/sensor_state/batch-get")
+	require.NotNil(t, r)
+	assert.Equal(t, "batchGetProjections", r.op["operationId"])
+	assert.Equal(t, map[string]string{"projection_type": "sensor_state"}, params)
+
+	r, params, _ = s.match(http.MethodGet, "/api/v1/projections/sensor_state/batch-get")
+	require.NotNil(t, r)
+	assert.Equal(t, "batch-get", params["aggregate_id"])
+}
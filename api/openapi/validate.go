@@ -0,0 +1,286 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Validate wraps next with request validation against the document: path and
+// query parameters and JSON request bodies are checked before the handler
+// runs, and requests that do not conform get a 400 with an {"error"} body.
+// Requests for paths or methods the document does not describe are passed
+// through so the handler answers them (404, 405) as before.
+//
+// Validation covers the schema keywords the platform's documents use: type,
+// enum, required, properties, additionalProperties: false, items,
+// minItems/maxItems, minLength/maxLength, minimum/maximum, nullable and
+// format: date-time. Header parameters are not validated.
+func (s *Spec) Validate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathValues, _ := s.match(r.Method, r.URL.Path)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := s.validateParams(route, pathValues, r); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.validateBody(route, r); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Spec) validateParams(route *route, pathValues map[string]string, r *http.Request) error {
+	query := r.URL.Query()
+	for _, param := range route.params {
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+
+		var raw string
+		var present bool
+		switch in {
+		case "path":
+			raw, present = pathValues[name]
+		case "query":
+			present = query.Has(name)
+			raw = query.Get(name)
+		default:
+			continue
+		}
+
+		if !present {
+			if required, _ := param["required"].(bool); required {
+				return fmt.Errorf("missing %s parameter %s", in, name)
+			}
+			continue
+		}
+		schema, _ := s.resolve(param["schema"]).(map[string]any)
+		if schema == nil {
+			continue
+		}
+		if err := s.validateValue(paramValue(raw, schema), schema, ""); err != nil {
+			return fmt.Errorf("invalid %s parameter %s: %s", in, name, err)
+		}
+	}
+	return nil
+}
+
+// paramValue converts a parameter string to the JSON value its schema type
+// describes, leaving it a string when it does not parse (so validation
+// reports the type mismatch). Booleans accept what strconv.ParseBool accepts,
+// as the handlers do.
+func paramValue(raw string, schema map[string]any) any {
+	switch schema["type"] {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+func (s *Spec) validateBody(route *route, r *http.Request) error {
+	requestBody, _ := s.resolve(route.op["requestBody"]).(map[string]any)
+	if requestBody == nil {
+		return nil
+	}
+	content, _ := requestBody["content"].(map[string]any)
+	media, _ := content["application/json"].(map[string]any)
+	schema, _ := s.resolve(media["schema"]).(map[string]any)
+	if schema == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if required, _ := requestBody["required"].(bool); required {
+			return fmt.Errorf("request body is required")
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %s", err)
+	}
+	if err := s.validateValue(value, schema, ""); err != nil {
+		return fmt.Errorf("invalid request body: %s", err)
+	}
+	return nil
+}
+
+// validateValue checks a decoded JSON value (numbers as json.Number) against a
+// schema. path is the dot-separated location used in error messages.
+func (s *Spec) validateValue(value any, schema map[string]any, path string) error {
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable || schema["type"] == nil {
+			return nil
+		}
+		return fieldError(path, "must not be null")
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !inEnum(value, enum) {
+		allowed := make([]string, len(enum))
+		for i, e := range enum {
+			allowed[i] = fmt.Sprint(e)
+		}
+		return fieldError(path, "must be one of "+strings.Join(allowed, ", "))
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fieldError(path, "must be an object")
+		}
+		return s.validateObject(obj, schema, path)
+	case "array":
+		list, ok := value.([]any)
+		if !ok {
+			return fieldError(path, "must be an array")
+		}
+		if min, ok := intKeyword(schema, "minItems"); ok && len(list) < min {
+			return fieldError(path, fmt.Sprintf("must contain at least %d items", min))
+		}
+		if max, ok := intKeyword(schema, "maxItems"); ok && len(list) > max {
+			return fieldError(path, fmt.Sprintf("must contain at most %d items", max))
+		}
+		if items, ok := s.resolve(schema["items"]).(map[string]any); ok {
+			for i, item := range list {
+				if err := s.validateValue(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fieldError(path, "must be a string")
+		}
+		n := utf8.RuneCountInString(str)
+		if min, ok := intKeyword(schema, "minLength"); ok && n < min {
+			return fieldError(path, fmt.Sprintf("must be at least %d characters", min))
+		}
+		if max, ok := intKeyword(schema, "maxLength"); ok && n > max {
+			return fieldError(path, fmt.Sprintf("must be at most %d characters", max))
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return fieldError(path, "must be an RFC 3339 date-time")
+			}
+		}
+	case "integer", "number":
+		num, ok := value.(json.Number)
+		f, err := num.Float64()
+		if !ok || err != nil {
+			return fieldError(path, "must be a number")
+		}
+		if _, err := num.Int64(); schema["type"] == "integer" && err != nil {
+			return fieldError(path, "must be an integer")
+		}
+		if min, ok := numberKeyword(schema, "minimum"); ok && f < min {
+			return fieldError(path, fmt.Sprintf("must be at least %v", min))
+		}
+		if max, ok := numberKeyword(schema, "maximum"); ok && f > max {
+			return fieldError(path, fmt.Sprintf("must be at most %v", max))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fieldError(path, "must be a boolean")
+		}
+	}
+	return nil
+}
+
+func (s *Spec) validateObject(obj map[string]any, schema map[string]any, path string) error {
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := obj[name.(string)]; !ok {
+			return fieldError(join(path, name.(string)), "is required")
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	closed := schema["additionalProperties"] == false
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		prop, known := s.resolve(properties[key]).(map[string]any)
+		if !known {
+			if closed {
+				return fieldError(join(path, key), "is not allowed")
+			}
+			continue
+		}
+		if err := s.validateValue(obj[key], prop, join(path, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func inEnum(value any, enum []any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func intKeyword(schema map[string]any, key string) (int, bool) {
+	n, ok := schema[key].(int)
+	return n, ok
+}
+
+func numberKeyword(schema map[string]any, key string) (float64, bool) {
+	switch n := schema[key].(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func fieldError(path, message string) error {
+	if path == "" {
+		return fmt.Errorf("%s", message)
+	}
+	return fmt.Errorf("%s %s", path, message)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
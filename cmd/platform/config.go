@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cornjacket/platform-services/internal/shared/config"
+)
+
+// splitConfigFlag removes a global --config path (or --config=path) from
+// args, which may appear before or after the subcommand.
+func splitConfigFlag(args []string) (path string, rest []string, err error) {
+	rest = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--config" || arg == "-config":
+			if i+1 >= len(args) || args[i+1] == "" {
+				return "", nil, fmt.Errorf("%s requires a file path", arg)
+			}
+			path = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--config=") || strings.HasPrefix(arg, "-config="):
+			path = arg[strings.Index(arg, "=")+1:]
+			if path == "" {
+				return "", nil, fmt.Errorf("--config requires a file path")
+			}
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return path, rest, nil
+}
+
+// loadConfig loads the environment, layered over the config file if given.
+func loadConfig(path string) (*config.Config, error) {
+	if path == "" {
+		return config.Load()
+	}
+	return config.LoadFile(path)
+}
+
+// runConfig handles the `platform config` subcommand.
+func runConfig(cfg *config.Config, args []string) {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: platform [--config file] config print")
+		os.Exit(2)
+	}
+	writeSettings(os.Stdout, cfg.Settings())
+}
+
+// writeSettings prints the effective configuration as KEY=value lines, in
+// the form the environment takes, noting where non-default values came from.
+func writeSettings(w io.Writer, settings []config.Setting) {
+	for _, s := range settings {
+		if s.Source == config.SourceDefault {
+			fmt.Fprintf(w, "%s=%s\n", s.Key, s.Value)
+			continue
+		}
+		fmt.Fprintf(w, "%s=%s # %s\n", s.Key, s.Value, s.Source)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/config"
+)
+
+func TestSplitConfigFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantPath string
+		wantRest []string
+	}{
+		{"none", []string{"migrate"}, "", []string{"migrate"}},
+		{"before subcommand", []string{"--config", "platform.yaml", "config", "print"}, "platform.yaml", []string{"config", "print"}},
+		{"after subcommand", []string{"topics", "list", "--config=platform.toml"}, "platform.toml", []string{"topics", "list"}},
+		{"single dash", []string{"-config", "p.yaml"}, "p.yaml", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, rest, err := splitConfigFlag(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPath, path)
+			assert.Equal(t, tt.wantRest, rest)
+		})
+	}
+
+	_, _, err := splitConfigFlag([]string{"migrate", "--config"})
+	assert.EqualError(t, err, "--config requires a file path")
+
+	_, _, err = splitConfigFlag([]string{"--config="})
+	assert.EqualError(t, err, "--config requires a file path")
+}
+
+func TestWriteSettings(t *testing.T) {
+	var buf bytes.Buffer
+	writeSettings(&buf, []config.Setting{
+		{Key: "CJ_ACTIONS_WEBHOOK_SECRET", Value: config.Redacted, Source: config.SourceEnv},
+		{Key: "CJ_INGESTION_PORT", Value: "9090", Source: config.SourceFile},
+		{Key: "CJ_LOG_LEVEL", Value: "info", Source: config.SourceDefault},
+	})
+
+	assert.Equal(t, "CJ_ACTIONS_WEBHOOK_SECRET=<redacted> # env\n"+
+		"CJ_INGESTION_PORT=9090 # file\n"+
+		"CJ_LOG_LEVEL=info\n", buf.String())
+}
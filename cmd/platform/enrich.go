@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/cornjacket/platform-services/internal/services/ingestion"
+	"github.com/cornjacket/platform-services/internal/shared/config"
+)
+
+// eventEnrichers builds the ingestion enrichers selected by
+// CJ_INGEST_ENRICHERS, loading the device registry when one is configured.
+func eventEnrichers(cfg *config.Config, logger *slog.Logger) ([]ingestion.Enricher, error) {
+	enricherConfig := ingestion.EnricherConfig{Units: cfg.IngestUnits}
+	if cfg.DeviceRegistryFile != "" {
+		registry, err := ingestion.LoadStaticRegistry(cfg.DeviceRegistryFile)
+		if err != nil {
+			return nil, fmt.Errorf("CJ_DEVICE_REGISTRY_FILE: %w", err)
+		}
+		enricherConfig.Registry = registry
+	}
+
+	enrichers, err := ingestion.NewEnrichers(cfg.IngestEnrichers, enricherConfig)
+	if err != nil {
+		return nil, fmt.Errorf("CJ_INGEST_ENRICHERS: %w", err)
+	}
+	if len(enrichers) > 0 {
+		logger.Info("event enrichment enabled", "enrichers", cfg.IngestEnrichers)
+	}
+	return enrichers, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	ehclient "github.com/cornjacket/platform-services/internal/client/eventhandler"
+	"github.com/cornjacket/platform-services/internal/services/ingestion/importer"
+	"github.com/cornjacket/platform-services/internal/shared/config"
+	"github.com/cornjacket/platform-services/internal/shared/infra/postgres"
+	"github.com/cornjacket/platform-services/internal/shared/infra/redpanda"
+)
+
+// runImport handles the `platform import` subcommand: loads historical
+// events from a newline-delimited JSON file into the event store and
+// publishes them at a bounded rate, bypassing the outbox. It returns the
+// process exit code rather than exiting, so the producer is flushed and the
+// pool closed by the deferred calls even when the import stops.
+func runImport(cfg *config.Config, args []string) int {
+	const usage = "usage: platform import -file PATH|- [-rate N] [-topic TOPIC] [-skip N] [-dry-run]"
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("file", "", "newline-delimited JSON events to import, or - for stdin")
+	rate := fs.Float64("rate", 500, "most events published per second; 0 for no limit")
+	topic := fs.String("topic", "", "publish every event to this topic instead of its routed one")
+	skip := fs.Int("skip", 0, "lines to skip, to resume an import that stopped")
+	dryRun := fs.Bool("dry-run", false, "validate the file without storing or publishing anything")
+	fs.Parse(args)
+	if *file == "" || *rate < 0 || *skip < 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	var in io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	logger := slog.Default()
+
+	importCfg := importer.Config{
+		Rate:       *rate,
+		Topic:      *topic,
+		Skip:       *skip,
+		DryRun:     *dryRun,
+		EventTypes: eventTypePolicy(cfg),
+	}
+	piiTransformer, _, err := piiProtection(cfg, logger)
+	if err != nil {
+		slog.Error("invalid PII configuration", "error", err)
+		return 1
+	}
+	if piiTransformer != nil {
+		importCfg.Payload = piiTransformer
+	}
+	if importCfg.Signer, _, err = eventSigning(cfg, logger); err != nil {
+		slog.Error("invalid event signing configuration", "error", err)
+		return 1
+	}
+	enrichers, err := eventEnrichers(cfg, logger)
+	if err != nil {
+		slog.Error("invalid event enrichment configuration", "error", err)
+		return 1
+	}
+	for _, enricher := range enrichers {
+		importCfg.Enrichers = append(importCfg.Enrichers, enricher)
+	}
+
+	routes, err := ehclient.ParseRoutes(cfg.TopicRoutes)
+	if err != nil {
+		slog.Error("invalid CJ_TOPIC_ROUTES", "error", err)
+		return 1
+	}
+	router, err := ehclient.NewRouter(routes, cfg.TopicDefault)
+	if err != nil {
+		slog.Error("invalid topic routing configuration", "error", err)
+		return 1
+	}
+
+	var store importer.EventStoreWriter
+	var publisher importer.Publisher
+	if !*dryRun {
+		client, err := postgres.NewClient(ctx, cfg.DatabaseURLIngestion, logger)
+		if err != nil {
+			slog.Error("failed to connect to PostgreSQL (ingestion)", "error", err)
+			return 1
+		}
+		defer client.Close()
+		store = postgres.NewEventStoreRepo(client.Pool(), logger)
+
+		producer, err := redpanda.NewProducer(strings.Split(cfg.RedpandaBrokers, ","), redpanda.ProducerConfig{
+			Linger:        cfg.RedpandaLinger,
+			BatchMaxBytes: int32(cfg.RedpandaBatchMaxBytes),
+			Compression:   cfg.RedpandaCompression,
+			Acks:          cfg.RedpandaAcks,
+			Idempotent:    cfg.RedpandaIdempotent,
+			Auth:          brokerAuth(cfg, nil),
+			Tombstones:    cfg.RedpandaTombstones,
+		}, logger)
+		if err != nil {
+			slog.Error("failed to create Redpanda producer", "error", err)
+			return 1
+		}
+		defer func() {
+			// Deliver what is still buffered, also after an interrupted import
+			flushCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := producer.Flush(flushCtx); err != nil {
+				slog.Error("failed to flush Redpanda producer", "error", err)
+			}
+			producer.Close()
+		}()
+		publisher = producer
+	}
+
+	result, err := importer.New(store, publisher, router, importCfg, logger).Run(ctx, in)
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+	if err != nil {
+		slog.Error("import stopped; rerun with -skip to resume",
+			"skip", result.Lines,
+			"error", err,
+		)
+		return 1
+	}
+	return 0
+}
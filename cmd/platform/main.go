@@ -6,33 +6,100 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+
 	ehclient "github.com/cornjacket/platform-services/internal/client/eventhandler"
+	"github.com/cornjacket/platform-services/internal/sandbox"
+	"github.com/cornjacket/platform-services/internal/services/actions"
 	"github.com/cornjacket/platform-services/internal/services/eventhandler"
 	"github.com/cornjacket/platform-services/internal/services/ingestion"
 	"github.com/cornjacket/platform-services/internal/services/query"
+	"github.com/cornjacket/platform-services/internal/services/search"
+	"github.com/cornjacket/platform-services/internal/shared/audit"
+	"github.com/cornjacket/platform-services/internal/shared/auth"
 	"github.com/cornjacket/platform-services/internal/shared/config"
+	"github.com/cornjacket/platform-services/internal/shared/httpserver"
+	"github.com/cornjacket/platform-services/internal/shared/infra/opensearch"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgtrace"
 	"github.com/cornjacket/platform-services/internal/shared/infra/postgres"
 	"github.com/cornjacket/platform-services/internal/shared/infra/redpanda"
+	"github.com/cornjacket/platform-services/internal/shared/leader"
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
 	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/secrets"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
 )
 
+// version identifies this build. Set at build time with
+// -ldflags "-X main.version=<version>"; see the Makefile and Dockerfile.
+var version = "dev"
+
 func main() {
+	configPath, args, err := splitConfigFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := loadConfig(configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to load configuration: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Initialize logger
-	logger := newLogger(cfg.LogLevel, cfg.LogFormat)
+	logger, logLevel := newLogger(cfg.LogLevel, cfg.LogFormat)
 	slog.SetDefault(logger)
 
+	// Resolve secret references (config print shows the references instead)
+	var secretsResolver *secrets.Resolver
+	if len(args) == 0 || args[0] != "config" {
+		secretsResolver, err = resolveSecrets(cfg, logger)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to resolve secrets: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Subcommands
+	if len(args) > 0 {
+		switch args[0] {
+		case "sandbox":
+			runSandbox(cfg)
+			return
+		case "topics":
+			runTopics(cfg, args[1:])
+			return
+		case "acls":
+			runACLs(cfg, args[1:])
+			return
+		case "migrate":
+			runMigrate(cfg, args[1:])
+			return
+		case "projections":
+			runProjections(cfg, args[1:])
+			return
+		case "import":
+			os.Exit(runImport(cfg, args[1:]))
+		case "preflight":
+			runPreflight(cfg, args[1:])
+			return
+		case "config":
+			runConfig(cfg, args[1:])
+			return
+		}
+	}
+
 	slog.Info("starting platform services",
+		"version", version,
 		"ingestion_port", cfg.PortIngestion,
 		"query_port", cfg.PortQuery,
 	)
@@ -40,62 +107,274 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Statement metrics for every pool, served on CJ_METRICS_PORT
+	metricsRegistry := metrics.NewRegistry()
+	statementMetrics := pgtrace.NewMetrics(metricsRegistry)
+	tracer := func(database string) *pgtrace.Tracer {
+		return pgtrace.NewTracer(statementMetrics, database, cfg.DBSlowQueryThreshold, logger)
+	}
+
 	// Create DB pools (one per service, per ADR-0010)
-	ingestionPG, err := postgres.NewClient(ctx, cfg.DatabaseURLIngestion, logger)
+	ingestionPG, err := postgres.NewRotatingClient(ctx, cfg.DatabaseURLIngestion,
+		rotatingURL(cfg, secretsResolver, "CJ_INGESTION_DATABASE_URL"), tracer("ingestion"), logger)
 	if err != nil {
 		slog.Error("failed to connect to PostgreSQL (ingestion)", "error", err)
 		os.Exit(1)
 	}
 	defer ingestionPG.Close()
 
-	eventHandlerPG, err := postgres.NewClient(ctx, cfg.DatabaseURLEventHandler, logger)
+	eventHandlerPG, err := postgres.NewRotatingClient(ctx, cfg.DatabaseURLEventHandler,
+		rotatingURL(cfg, secretsResolver, "CJ_EVENTHANDLER_DATABASE_URL"), tracer("eventhandler"), logger)
 	if err != nil {
 		slog.Error("failed to connect to PostgreSQL (event handler)", "error", err)
 		os.Exit(1)
 	}
 	defer eventHandlerPG.Close()
 
-	queryPG, err := postgres.NewClient(ctx, cfg.DatabaseURLQuery, logger)
+	queryPG, err := postgres.NewRotatingClient(ctx, cfg.DatabaseURLQuery,
+		rotatingURL(cfg, secretsResolver, "CJ_QUERY_DATABASE_URL"), tracer("query"), logger)
 	if err != nil {
 		slog.Error("failed to connect to PostgreSQL (query)", "error", err)
 		os.Exit(1)
 	}
 	defer queryPG.Close()
 
-	// Run migrations (per-service, per ADR-0016)
-	slog.Info("running database migrations...")
-	if err := postgres.RunMigrations(cfg.DatabaseURLIngestion, ingestion.MigrationFS, "migrations", "goose_ingestion"); err != nil {
-		slog.Error("ingestion migration failed", "error", err)
-		os.Exit(1)
+	var actionsPG *postgres.Client
+	if cfg.EnableActions {
+		actionsPG, err = postgres.NewRotatingClient(ctx, cfg.DatabaseURLActions,
+			rotatingURL(cfg, secretsResolver, "CJ_ACTIONS_DATABASE_URL"), tracer("actions"), logger)
+		if err != nil {
+			slog.Error("failed to connect to PostgreSQL (actions)", "error", err)
+			os.Exit(1)
+		}
+		defer actionsPG.Close()
 	}
-	if err := postgres.RunMigrations(cfg.DatabaseURLEventHandler, eventhandler.MigrationFS, "migrations", "goose_eventhandler"); err != nil {
-		slog.Error("eventhandler migration failed", "error", err)
+
+	// Run migrations (per-service, per ADR-0016)
+	if err := migrateAll(cfg); err != nil {
+		slog.Error("migration failed", "error", err)
 		os.Exit(1)
 	}
-	slog.Info("database migrations complete")
 
 	// Create shared external resources
 	brokers := strings.Split(cfg.RedpandaBrokers, ",")
-	redpandaProducer, err := redpanda.NewProducer(brokers, logger)
+	redpandaAuth := brokerAuth(cfg, secretsResolver)
+	brokerOpts, err := redpandaAuth.Opts()
+	if err != nil {
+		slog.Error("invalid Redpanda auth configuration", "error", err)
+		os.Exit(1)
+	}
+	producerCfg := redpanda.ProducerConfig{
+		Linger:        cfg.RedpandaLinger,
+		BatchMaxBytes: int32(cfg.RedpandaBatchMaxBytes),
+		Compression:   cfg.RedpandaCompression,
+		Acks:          cfg.RedpandaAcks,
+		Idempotent:    cfg.RedpandaIdempotent,
+		Auth:          redpandaAuth,
+		Tombstones:    cfg.RedpandaTombstones,
+	}
+	if cfg.OutboxTransactional {
+		// Transactional ID must be unique per running instance (zombie fencing)
+		producerCfg.TransactionalID = cfg.RedpandaTransactionID
+	}
+	redpandaProducer, err := redpanda.NewProducer(brokers, producerCfg, logger)
 	if err != nil {
 		slog.Error("failed to create Redpanda producer", "error", err)
 		os.Exit(1)
 	}
 	defer redpandaProducer.Close()
 
-	eventSubmitter := ehclient.New(redpandaProducer, logger)
+	// Topic routing (event type prefix → topic)
+	topicRoutes, err := ehclient.ParseRoutes(cfg.TopicRoutes)
+	if err != nil {
+		slog.Error("invalid CJ_TOPIC_ROUTES", "error", err)
+		os.Exit(1)
+	}
+	topicRouter, err := ehclient.NewRouter(topicRoutes, cfg.TopicDefault)
+	if err != nil {
+		slog.Error("invalid topic routing configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Projection expiry (projection type → TTL)
+	projectionTTLs, err := eventhandler.ParseTTLs(cfg.ProjectionTTLs)
+	if err != nil {
+		slog.Error("invalid CJ_PROJECTION_TTLS", "error", err)
+		os.Exit(1)
+	}
+	rollups, err := eventhandler.ParseRollups(cfg.Rollups)
+	if err != nil {
+		slog.Error("invalid CJ_ROLLUPS", "error", err)
+		os.Exit(1)
+	}
+	shadows, err := eventhandler.ParseShadows(cfg.ShadowHandlers)
+	if err != nil {
+		slog.Error("invalid CJ_SHADOW_HANDLERS", "error", err)
+		os.Exit(1)
+	}
+	eventFilter, err := eventhandler.ParseEventFilter(cfg.EventHandlerAllow, cfg.EventHandlerDeny)
+	if err != nil {
+		slog.Error("invalid CJ_EVENTHANDLER_ALLOW or CJ_EVENTHANDLER_DENY", "error", err)
+		os.Exit(1)
+	}
+
+	if cfg.TopicsEnsure {
+		if _, err := ensureTopics(ctx, cfg, logger); err != nil {
+			slog.Error("failed to ensure topics", "error", err)
+			os.Exit(1)
+		}
+	}
+	missingTopics, err := redpandaProducer.MissingTopics(ctx, topicRouter.Topics())
+	if err != nil {
+		slog.Warn("could not validate routed topics", "error", err)
+	} else if len(missingTopics) > 0 {
+		// Not fatal: the producer auto-creates topics on first publish
+		slog.Warn("routed topics do not exist yet", "topics", missingTopics)
+	}
+
+	// Repositories retry transient database errors, so a brief failover does
+	// not fail ingests and projection writes
+	dbPolicy := pgretry.Policy{
+		MaxAttempts:         cfg.DBRetryMaxAttempts,
+		BaseDelay:           cfg.DBRetryBaseDelay,
+		MaxDelay:            cfg.DBRetryMaxDelay,
+		BreakerThreshold:    cfg.DBBreakerThreshold,
+		BreakerOpenDuration: cfg.DBBreakerOpen,
+	}
+	ingestionDB := pgretry.New(ingestionPG.Pool(), "ingestion", dbPolicy, logger)
+	eventHandlerDB := pgretry.New(eventHandlerPG.Pool(), "eventhandler", dbPolicy, logger)
+	queryDB := pgretry.New(queryPG.Pool(), "query", dbPolicy, logger)
+
+	eventSubmitter := ehclient.New(redpandaProducer, topicRouter, logger)
 	projectionsStore := projections.NewPostgresStore(eventHandlerPG.Pool(), logger)
+	projectionsStore.SetQuerier(eventHandlerDB)
+
+	// Aggregate registry (eventhandler DB), shared by the event handler and
+	// the rule engine; the query service reads it from its own pool
+	var aggregateRegistry registry.Store
+	if cfg.AggregateRegistry {
+		registryStore := registry.NewPostgresStore(eventHandlerPG.Pool(), logger)
+		registryStore.SetQuerier(eventHandlerDB)
+		aggregateRegistry = registryStore
+	}
+
+	// Audit records from every service go to audit_log (ingestion DB)
+	var auditLog audit.Log
+	if cfg.EnableAudit {
+		auditLog = audit.NewPostgresLog(ingestionPG.Pool(), logger)
+	}
+
+	// Singleton background work runs on one replica, elected through
+	// advisory locks in the ingestion DB
+	var elector *leader.Elector
+	if cfg.LeaderElection {
+		elector = leader.NewElector(func(name string) leader.Lock {
+			return postgres.NewAdvisoryLock(ingestionPG.Pool(), name, logger)
+		}, cfg.LeaderRetryInterval, logger)
+	}
 
 	errCh := make(chan error, 1) // Shared channel for services to report fatal errors
 
+	pools := map[string]*pgxpool.Pool{
+		"ingestion":    ingestionPG.Pool(),
+		"eventhandler": eventHandlerPG.Pool(),
+		"query":        queryPG.Pool(),
+	}
+	if actionsPG != nil {
+		pools["actions"] = actionsPG.Pool()
+	}
+	postgres.RegisterPoolMetrics(metricsRegistry, pools)
+	serverConfig := httpServerConfig(cfg)
+	metricsServer := startMetricsServer(cfg.PortMetrics, metricsRegistry, serverConfig, logger, errCh)
+
 	// Start services
+	var testAPIKeys []string
+	if cfg.IngestionTestAPIKeys != "" {
+		testAPIKeys = strings.Split(cfg.IngestionTestAPIKeys, ",")
+	}
+	var replayAPIKeys []string
+	if cfg.IngestionReplayAPIKeys != "" {
+		replayAPIKeys = strings.Split(cfg.IngestionReplayAPIKeys, ",")
+	}
+
+	piiTransformer, piiDecryptor, err := piiProtection(cfg, logger)
+	if err != nil {
+		slog.Error("invalid PII configuration", "error", err)
+		os.Exit(1)
+	}
+	eventSigner, eventVerifier, err := eventSigning(cfg, logger)
+	if err != nil {
+		slog.Error("invalid event signing configuration", "error", err)
+		os.Exit(1)
+	}
+	ingestEnrichers, err := eventEnrichers(cfg, logger)
+	if err != nil {
+		slog.Error("invalid event enrichment configuration", "error", err)
+		os.Exit(1)
+	}
+	var payloadTransformer ingestion.PayloadTransformer
+	if piiTransformer != nil {
+		payloadTransformer = piiTransformer
+	}
+
+	apiKeyPolicy, err := auth.ParsePolicy(cfg.APIKeys)
+	if err != nil {
+		slog.Error("invalid CJ_API_KEYS", "error", err)
+		os.Exit(1)
+	}
+
+	requestTimeouts, err := timeout.ParsePolicy(cfg.RequestTimeout, cfg.RequestTimeouts)
+	if err != nil {
+		slog.Error("invalid CJ_REQUEST_TIMEOUTS", "error", err)
+		os.Exit(1)
+	}
+
 	ingestionSvc, err := ingestion.Start(ctx, ingestion.Config{
-		Port:         cfg.PortIngestion,
-		WorkerCount:  cfg.OutboxWorkerCount,
-		BatchSize:    cfg.OutboxBatchSize,
-		MaxRetries:   cfg.OutboxMaxRetries,
-		PollInterval: cfg.OutboxPollInterval,
-		DatabaseURL:  cfg.DatabaseURLIngestion,
+		Port:          cfg.PortIngestion,
+		Server:        serverConfig,
+		WorkerCount:   cfg.OutboxWorkerCount,
+		BatchSize:     cfg.OutboxBatchSize,
+		MaxRetries:    cfg.OutboxMaxRetries,
+		PollInterval:  cfg.OutboxPollInterval,
+		AsyncSubmit:   cfg.OutboxAsyncSubmit,
+		Transactional: cfg.OutboxTransactional,
+		DatabaseURL:   cfg.DatabaseURLIngestion,
+
+		MaintenanceInterval: cfg.OutboxVacuumInterval,
+		VacuumMinDeadRows:   int64(cfg.OutboxVacuumMinDead),
+		ReindexInterval:     cfg.OutboxReindexInterval,
+		Archive:             cfg.OutboxArchive,
+		ArchiveRetention:    cfg.OutboxArchiveRetention,
+		TestAPIKeys:         testAPIKeys,
+		ReplayAPIKeys:       replayAPIKeys,
+		Audit:               auditLog,
+		Payload:             payloadTransformer,
+		Signer:              eventSigner,
+		Enrichers:           ingestEnrichers,
+		Policy:              apiKeyPolicy,
+		Leader:              elector,
+		DB:                  ingestionDB,
+		SyncTimeout:         cfg.IngestionSyncTimeout,
+		Timeouts:            requestTimeouts,
+		CompressionLevel:    cfg.HTTPCompressionLevel,
+		EventTime: ingestion.EventTimePolicy{
+			MaxPast:   cfg.IngestionEventTimeMaxPast,
+			MaxFuture: cfg.IngestionEventTimeMaxFuture,
+			Clamp:     cfg.IngestionEventTimePolicy == "clamp",
+		},
+		Dedup: ingestion.DedupConfig{
+			Window: cfg.IngestionDedupWindow,
+			Reject: cfg.IngestionDedupReject,
+		},
+		IdempotencyWindow: cfg.IngestionIdempotencyWindow,
+		EventTypes:        eventTypePolicy(cfg),
+		Backpressure: ingestion.BackpressureConfig{
+			MaxDepth:      int64(cfg.IngestionBackpressureMaxDepth),
+			MaxAge:        cfg.IngestionBackpressureMaxAge,
+			ResumeRatio:   cfg.IngestionBackpressureResumeRatio,
+			CheckInterval: cfg.IngestionBackpressureInterval,
+			RetryAfter:    cfg.IngestionBackpressureRetryAfter,
+		},
 	}, ingestionPG.Pool(), eventSubmitter, logger, errCh) // Pass error channel
 	if err != nil {
 		slog.Error("failed to start ingestion service", "error", err)
@@ -103,25 +382,192 @@ func main() {
 	}
 
 	ehTopics := strings.Split(cfg.EventHandlerTopics, ",")
+	for _, topic := range topicRouter.Topics() {
+		if !slices.Contains(ehTopics, topic) {
+			slog.Warn("routed topic is not consumed by the event handler", "topic", topic)
+		}
+	}
+	// Projection rebuilds read the compacted event_latest view (ingestion DB)
+	var ehEventReader eventhandler.EventReader
+	if cfg.ProjectionVerifyRebuild {
+		eventStore := postgres.NewEventStoreRepo(ingestionPG.Pool(), logger)
+		eventStore.SetQuerier(ingestionDB)
+		ehEventReader = eventStore
+	}
+
+	// Projection change feed: a producer of its own, never transactional, so
+	// changes are published as they are accepted
+	var changeProducer *redpanda.Producer
+	var changeFeed *eventhandler.ChangeFeed
+	if cfg.ProjectionChangesTopic != "" {
+		changeCfg := producerCfg
+		changeCfg.TransactionalID = ""
+		changeCfg.Tombstones = false
+		changeProducer, err = redpanda.NewProducer(brokers, changeCfg, logger)
+		if err != nil {
+			slog.Error("failed to create projection change producer", "error", err)
+			os.Exit(1)
+		}
+		defer changeProducer.Close()
+		changeFeed = eventhandler.NewChangeFeed(changeProducer, cfg.ProjectionChangesTopic, logger)
+	}
+
 	eventHandlerSvc, err := eventhandler.Start(ctx, eventhandler.Config{
-		Brokers:       brokers,
-		ConsumerGroup: cfg.EventHandlerConsumerGroup,
-		Topics:        ehTopics,
-		PollTimeout:   cfg.EventHandlerPollTimeout,
-	}, projectionsStore, logger)
+		Brokers:        brokers,
+		ConsumerGroup:  cfg.EventHandlerConsumerGroup,
+		ClientID:       consumerClientID(version),
+		Topics:         ehTopics,
+		BrokerOpts:     brokerOpts,
+		PollTimeout:    cfg.EventHandlerPollTimeout,
+		Lanes:          cfg.EventHandlerLanes,
+		QueueSize:      cfg.EventHandlerQueueSize,
+		MaxAttempts:    cfg.EventHandlerMaxAttempts,
+		RetryDelay:     cfg.EventHandlerRetryDelay,
+		Commit:         eventhandler.CommitMode(cfg.EventHandlerCommit),
+		CommitInterval: cfg.EventHandlerCommitInterval,
+
+		InstanceID:       cfg.EventHandlerInstanceID,
+		SessionTimeout:   cfg.EventHandlerSessionTimeout,
+		RebalanceTimeout: cfg.EventHandlerRebalanceTimeout,
+		Balancer:         cfg.EventHandlerBalancer,
+		Filter:           eventFilter,
+		Verifier:         eventVerifier,
+
+		AdminPort:   cfg.PortEventHandlerAdmin,
+		AdminServer: serverConfig,
+		LogLevel:    logLevel,
+		Audit:       auditLog,
+
+		VerifyInterval: cfg.ProjectionVerifyInterval,
+		VerifyLimit:    cfg.ProjectionVerifyLimit,
+		VerifyRebuild:  cfg.ProjectionVerifyRebuild,
+
+		AnomalyMaxDelta: cfg.SensorAnomalyMaxDelta,
+		AnomalyMaxGap:   cfg.SensorAnomalyMaxGap,
+
+		SensorWindow:         cfg.SensorWindow,
+		SensorWindowLateness: cfg.SensorWindowLateness,
+		Rollups:              rollups,
+		Shadows:              shadows,
+		Registry:             aggregateRegistry,
+
+		ExpiryInterval: cfg.ProjectionTTLSweepEvery,
+		ExpiryTTLs:     projectionTTLs,
+		FoldRetention:  cfg.ProjectionFoldRetention,
+		Changes:        changeFeed,
+		Leader:         elector,
+		Ready:          postgres.ReadyHandler(eventHandlerPG.Pool(), logger),
+		Metrics:        metricsRegistry,
+	}, projectionsStore, ehEventReader, logger, errCh)
 	if err != nil {
 		slog.Error("failed to start event handler service", "error", err)
 		os.Exit(1)
 	}
 
+	// Event-history fallback reads the compacted event_latest view, and the
+	// event log API reads event_store (both in the ingestion DB)
+	var queryFallbackTypes []string
+	if cfg.QueryFallbackTypes != "" {
+		queryFallbackTypes = strings.Split(cfg.QueryFallbackTypes, ",")
+	}
+	queryEventReader := postgres.NewEventStoreRepo(ingestionPG.Pool(), logger)
+	queryEventReader.SetQuerier(ingestionDB)
+
+	// Search index (optional): written by the search service, queried
+	// through /api/v1/search
+	var searchIndex *opensearch.Client
+	var querySearch query.Searcher
+	if cfg.SearchURL != "" {
+		searchIndex = opensearch.NewClient(opensearch.Config{
+			URL:         cfg.SearchURL,
+			Username:    cfg.SearchUsername,
+			Password:    cfg.SearchPassword,
+			IndexPrefix: cfg.SearchIndexPrefix,
+			Timeout:     cfg.SearchTimeout,
+		})
+		querySearch = searchIndex
+	}
+
 	querySvc, err := query.Start(ctx, query.Config{
-		Port: cfg.PortQuery,
-	}, queryPG.Pool(), logger, errCh) // Pass error channel
+		Port:          cfg.PortQuery,
+		Server:        serverConfig,
+		FallbackTypes: queryFallbackTypes,
+		FallbackHeal:  cfg.QueryFallbackHeal,
+
+		EventPollInterval: cfg.QueryEventsPollInterval,
+		EventMaxWait:      cfg.QueryEventsMaxWait,
+		Decryptor:         piiDecryptor,
+		CompressionLevel:  cfg.HTTPCompressionLevel,
+		Timeouts:          requestTimeouts,
+		Policy:            apiKeyPolicy,
+		DB:                queryDB,
+		Registry:          cfg.AggregateRegistry,
+		Search:            querySearch,
+		GraphQL:           cfg.QueryGraphQL,
+	}, queryPG.Pool(), queryEventReader, logger, errCh) // Pass error channel
 	if err != nil {
 		slog.Error("failed to start query service", "error", err)
 		os.Exit(1)
 	}
 
+	var actionsSvc *actions.RunningService
+	if cfg.EnableActions {
+		actionsSvc, err = actions.Start(ctx, actions.Config{
+			Port:          cfg.PortActions,
+			Server:        serverConfig,
+			Brokers:       brokers,
+			ConsumerGroup: cfg.ActionsConsumerGroup,
+			ClientID:      consumerClientID(version),
+			Topics:        strings.Split(cfg.ActionsTopics, ","),
+			BrokerOpts:    brokerOpts,
+			Audit:         auditLog,
+			Policy:        apiKeyPolicy,
+			Verifier:      eventVerifier,
+			Registry:      aggregateRegistry,
+
+			RuleReloadInterval: cfg.ActionsRuleReloadInterval,
+
+			Webhook: actions.WebhookConfig{
+				Secret:              cfg.ActionsWebhookSecret,
+				Timeout:             cfg.ActionsWebhookTimeout,
+				MaxAttempts:         cfg.ActionsWebhookMaxAttempts,
+				BreakerThreshold:    cfg.ActionsWebhookBreakerThreshold,
+				BreakerOpenDuration: cfg.ActionsWebhookBreakerOpen,
+			},
+			Notify: actions.NotifyConfig{
+				Timeout:      cfg.ActionsNotifyTimeout,
+				PagerDutyURL: cfg.ActionsPagerDutyURL,
+				SMTP: actions.EmailConfig{
+					Host:     cfg.ActionsSMTPHost,
+					Port:     cfg.ActionsSMTPPort,
+					Username: cfg.ActionsSMTPUsername,
+					Password: cfg.ActionsSMTPPassword,
+					From:     cfg.ActionsSMTPFrom,
+				},
+			},
+		}, actionsPG.Pool(), logger, errCh)
+		if err != nil {
+			slog.Error("failed to start actions service", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	var searchSvc *search.RunningService
+	if searchIndex != nil {
+		searchSvc, err = search.Start(ctx, search.Config{
+			Brokers:       brokers,
+			ConsumerGroup: cfg.SearchConsumerGroup,
+			ClientID:      consumerClientID(version),
+			Topic:         cfg.ProjectionChangesTopic,
+			BrokerOpts:    brokerOpts,
+			Metrics:       metricsRegistry,
+		}, searchIndex, logger)
+		if err != nil {
+			slog.Error("failed to start search service", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// Wait for shutdown signal
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -140,21 +586,115 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
 
+	if searchSvc != nil {
+		if err := searchSvc.Shutdown(shutdownCtx); err != nil {
+			slog.Error("search service shutdown error", "error", err)
+		}
+	}
+	if actionsSvc != nil {
+		if err := actionsSvc.Shutdown(shutdownCtx); err != nil {
+			slog.Error("actions service shutdown error", "error", err)
+		}
+	}
 	if err := querySvc.Shutdown(shutdownCtx); err != nil {
 		slog.Error("query service shutdown error", "error", err)
 	}
 	if err := eventHandlerSvc.Shutdown(shutdownCtx); err != nil {
 		slog.Error("event handler service shutdown error", "error", err)
 	}
+	// Deliver the changes of the last handled events
+	if changeProducer != nil {
+		if err := changeProducer.Flush(shutdownCtx); err != nil {
+			slog.Error("projection change producer flush error", "error", err)
+		}
+	}
 	if err := ingestionSvc.Shutdown(shutdownCtx); err != nil {
 		slog.Error("ingestion service shutdown error", "error", err)
 	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("metrics server shutdown error", "error", err)
+		}
+	}
+
+	// Flush producer buffers now that the ingestion worker has drained
+	if err := redpandaProducer.Flush(shutdownCtx); err != nil {
+		slog.Error("producer flush error", "error", err)
+	}
+	stats := redpandaProducer.Stats()
+	slog.Info("producer shutdown stats",
+		"records_flushed", stats.Flushed,
+		"records_abandoned", stats.Abandoned,
+	)
 
 	slog.Info("platform services stopped")
 }
 
-// newLogger creates a structured logger based on configuration.
-func newLogger(level, format string) *slog.Logger {
+// runSandbox starts all services with in-memory backends and a synthetic event
+// generator. No Postgres or Redpanda is required.
+func runSandbox(cfg *config.Config) {
+	slog.Info("starting platform sandbox",
+		"ingestion_port", cfg.PortIngestion,
+		"query_port", cfg.PortQuery,
+		"event_interval", cfg.SandboxEventInterval,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := sandbox.Run(ctx, sandbox.Config{
+		PortIngestion: cfg.PortIngestion,
+		PortQuery:     cfg.PortQuery,
+		Server:        httpServerConfig(cfg),
+		EventInterval: cfg.SandboxEventInterval,
+		Anomaly: eventhandler.AnomalyConfig{
+			MaxDelta: cfg.SensorAnomalyMaxDelta,
+			MaxGap:   cfg.SensorAnomalyMaxGap,
+		},
+	}, slog.Default()); err != nil {
+		slog.Error("sandbox failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// httpServerConfig returns the timeouts and limits shared by every HTTP server.
+func httpServerConfig(cfg *config.Config) httpserver.Config {
+	return httpserver.Config{
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
+		H2C:               cfg.HTTPH2C,
+	}
+}
+
+// eventTypePolicy is the event type registry of CJ_INGESTION_EVENT_TYPES,
+// shared by ingestion and imports.
+func eventTypePolicy(cfg *config.Config) ingestion.EventTypePolicy {
+	return ingestion.EventTypePolicy{
+		Known:    splitPatterns(cfg.IngestionEventTypes),
+		Banned:   splitPatterns(cfg.IngestionBannedEventTypes),
+		MaxDepth: cfg.IngestionEventTypeMaxDepth,
+		Strict:   cfg.IngestionEventTypeMode == "strict",
+	}
+}
+
+// splitPatterns splits a comma-separated list of event type patterns,
+// trimming entries and dropping empty ones, as config validation does.
+func splitPatterns(list string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// newLogger creates a structured logger based on configuration. The returned
+// LevelVar adjusts its level at runtime (see the event handler admin API).
+func newLogger(level, format string) (*slog.Logger, *slog.LevelVar) {
 	var logLevel slog.Level
 	switch level {
 	case "debug":
@@ -167,7 +707,9 @@ func newLogger(level, format string) *slog.Logger {
 		logLevel = slog.LevelInfo
 	}
 
-	opts := &slog.HandlerOptions{Level: logLevel}
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(logLevel)
+	opts := &slog.HandlerOptions{Level: levelVar}
 
 	var handler slog.Handler
 	if format == "text" {
@@ -176,5 +718,5 @@ func newLogger(level, format string) *slog.Logger {
 		handler = slog.NewJSONHandler(os.Stdout, opts)
 	}
 
-	return slog.New(handler)
+	return slog.New(handler), levelVar
 }
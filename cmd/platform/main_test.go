@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+func TestNewLogger(t *testing.T) {
+	ctx := context.Background()
+
+	logger, level := newLogger("warn", "text")
+	assert.IsType(t, &slog.TextHandler{}, logger.Handler())
+	assert.False(t, logger.Enabled(ctx, slog.LevelInfo))
+
+	// The level can be raised and lowered after the logger is built
+	level.Set(slog.LevelDebug)
+	assert.True(t, logger.Enabled(ctx, slog.LevelDebug))
+
+	logger, level = newLogger("", "json")
+	assert.IsType(t, &slog.JSONHandler{}, logger.Handler())
+	assert.Equal(t, slog.LevelInfo, level.Level())
+}
+
+func TestStateTransformer(t *testing.T) {
+	store := projections.NewPostgresStore(nil, slog.Default())
+	stateTransforms["celsius"] = func(state json.RawMessage) (json.RawMessage, error) { return state, nil }
+	t.Cleanup(func() { delete(stateTransforms, "celsius") })
+
+	for _, valid := range [][3]string{
+		{"temperature=temp_f", "", ""},
+		{"", "state - 'x'", ""},
+		{"", "", "celsius"},
+	} {
+		_, err := stateTransformer(store, valid[0], valid[1], valid[2])
+		assert.NoError(t, err, valid)
+	}
+	for _, invalid := range [][3]string{
+		{"", "", ""},
+		{"temperature=temp_f", "state", ""},
+		{"temperature", "", ""},
+		{"", "", "fahrenheit"},
+	} {
+		_, err := stateTransformer(store, invalid[0], invalid[1], invalid[2])
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/cornjacket/platform-services/internal/shared/httpserver"
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+)
+
+// startMetricsServer serves reg at GET /metrics on port. It returns nil when
+// port is 0 (metrics disabled).
+func startMetricsServer(port int, reg *metrics.Registry, serverConfig httpserver.Config, logger *slog.Logger, errCh chan<- error) *http.Server {
+	if port == 0 {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", reg)
+	server := httpserver.New(port, mux, serverConfig)
+
+	go func() {
+		logger.Info("starting metrics server", "port", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server error", "error", err)
+			errCh <- fmt.Errorf("metrics server failed: %w", err)
+		}
+	}()
+	return server
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/services/actions"
+	"github.com/cornjacket/platform-services/internal/services/eventhandler"
+	"github.com/cornjacket/platform-services/internal/services/ingestion"
+	"github.com/cornjacket/platform-services/internal/shared/config"
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/infra/postgres"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// serviceMigrations describes one service's embedded migrations (per ADR-0016).
+type serviceMigrations struct {
+	Service     string
+	DatabaseURL string
+	FS          fs.FS
+	Table       string
+}
+
+// platformMigrations returns the migrations of every service that owns a schema.
+func platformMigrations(cfg *config.Config) []serviceMigrations {
+	migrations := []serviceMigrations{
+		{"ingestion", cfg.DatabaseURLIngestion, ingestion.MigrationFS, "goose_ingestion"},
+		{"eventhandler", cfg.DatabaseURLEventHandler, eventhandler.MigrationFS, "goose_eventhandler"},
+	}
+	if cfg.EnableActions {
+		migrations = append(migrations, serviceMigrations{"actions", cfg.DatabaseURLActions, actions.MigrationFS, "goose_actions"})
+	}
+	return migrations
+}
+
+// runMigrate handles the `platform migrate` subcommand: applies pending
+// migrations without starting the services, so CD can migrate before rollout.
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: platform migrate")
+		os.Exit(2)
+	}
+
+	if err := migrateAll(cfg); err != nil {
+		slog.Error("migration failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+// migrateAll applies pending migrations for every service, then builds the
+// projection state indexes declared in CJ_PROJECTION_INDEXES.
+func migrateAll(cfg *config.Config) error {
+	slog.Info("running database migrations...")
+	for _, m := range platformMigrations(cfg) {
+		if err := postgres.RunMigrations(m.DatabaseURL, m.FS, "migrations", m.Table); err != nil {
+			return fmt.Errorf("%s: %w", m.Service, err)
+		}
+	}
+	if err := ensureStateIndexes(cfg); err != nil {
+		return fmt.Errorf("eventhandler: %w", err)
+	}
+	slog.Info("database migrations complete")
+	return nil
+}
+
+// stateIndexesLock serializes the reconciliation of projection state
+// indexes between replicas starting, or migrating, at the same time.
+const stateIndexesLock = "eventhandler-state-indexes"
+
+// stateIndexesLockPoll is how often an instance waiting for
+// stateIndexesLock tries to take it.
+const stateIndexesLockPoll = 2 * time.Second
+
+// ensureStateIndexes builds and drops projection state indexes to match
+// CJ_PROJECTION_INDEXES. Builds do not block writes but can take a while on
+// a large projections table. An instance waits while another reconciles,
+// then finds the indexes built.
+func ensureStateIndexes(cfg *config.Config) error {
+	indexes, err := projections.ParseStateIndexes(cfg.ProjectionIndexes)
+	if err != nil {
+		return fmt.Errorf("CJ_PROJECTION_INDEXES: %w", err)
+	}
+
+	ctx := context.Background()
+	client, err := postgres.NewClient(ctx, cfg.DatabaseURLEventHandler, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	lock := postgres.NewAdvisoryLock(client.Pool(), stateIndexesLock, slog.Default())
+	defer func() {
+		if err := lock.Release(ctx); err != nil {
+			slog.Warn("failed to release state index lock", "error", err)
+		}
+	}()
+	for {
+		acquired, err := lock.TryAcquire(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			break
+		}
+		slog.Info("waiting for another instance to finish reconciling projection state indexes")
+		if err := clock.Sleep(ctx, stateIndexesLockPoll); err != nil {
+			return err
+		}
+	}
+
+	changes, err := projections.NewPostgresStore(client.Pool(), slog.Default()).EnsureStateIndexes(ctx, indexes)
+	if err != nil {
+		return err
+	}
+	if len(changes.Created) > 0 || len(changes.Dropped) > 0 {
+		slog.Info("projection state indexes updated", "created", changes.Created, "dropped", changes.Dropped)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/cornjacket/platform-services/internal/shared/config"
+	"github.com/cornjacket/platform-services/internal/shared/pii"
+)
+
+// piiProtection builds the ingestion payload transformer and the query
+// decryptor from the CJ_PII_* settings. Either is nil when not configured.
+func piiProtection(cfg *config.Config, logger *slog.Logger) (*pii.Transformer, *pii.Decryptor, error) {
+	rules, err := pii.ParseRules(cfg.PIIRules)
+	if err != nil {
+		return nil, nil, fmt.Errorf("CJ_PII_RULES: %w", err)
+	}
+
+	var cipher *pii.Cipher
+	switch cfg.PIIKMS {
+	case "local":
+		key, err := base64.StdEncoding.DecodeString(cfg.PIILocalKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CJ_PII_LOCAL_KEY: expected base64: %w", err)
+		}
+		kms, err := pii.NewLocalKMS(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CJ_PII_LOCAL_KEY: %w", err)
+		}
+		cipher = pii.NewCipher(kms, cfg.PIIDataKeyTTL)
+	case "aws":
+		kms, err := pii.NewAWSKMS(pii.AWSKMSConfig{
+			Region:          cfg.PIIAWSRegion,
+			KeyID:           cfg.PIIAWSKeyID,
+			Endpoint:        cfg.PIIAWSEndpoint,
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("CJ_PII_KMS: %w", err)
+		}
+		cipher = pii.NewCipher(kms, cfg.PIIDataKeyTTL)
+	}
+
+	var transformer *pii.Transformer
+	if len(rules) > 0 {
+		var hashKey []byte
+		if cfg.PIIHashKey != "" {
+			hashKey = []byte(cfg.PIIHashKey)
+		}
+		if transformer, err = pii.NewTransformer(rules, hashKey, cipher); err != nil {
+			return nil, nil, fmt.Errorf("CJ_PII_RULES: %w", err)
+		}
+		logger.Info("PII protection enabled", "rules", len(rules), "kms", cfg.PIIKMS)
+	}
+
+	var decryptor *pii.Decryptor
+	if cipher != nil && cfg.PIIDecryptAPIKeys != "" {
+		decryptor = pii.NewDecryptor(cipher, strings.Split(cfg.PIIDecryptAPIKeys, ","), logger)
+	}
+	return transformer, decryptor, nil
+}
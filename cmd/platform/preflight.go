@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/config"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/infra/postgres"
+	"github.com/cornjacket/platform-services/internal/shared/infra/redpanda"
+)
+
+// clientIDPrefix prefixes the event handler's Kafka client ID, which carries
+// the build version so preflight can see what the consumer group is running.
+const clientIDPrefix = "platform/"
+
+// consumerClientID returns the Kafka client ID for a build version.
+func consumerClientID(version string) string {
+	return clientIDPrefix + version
+}
+
+// checkStatus is the outcome of one preflight check.
+type checkStatus int
+
+const (
+	checkOK checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+func (s checkStatus) String() string {
+	switch s {
+	case checkWarn:
+		return "WARN"
+	case checkFail:
+		return "FAIL"
+	default:
+		return "ok"
+	}
+}
+
+// checkResult is one line of the preflight report.
+type checkResult struct {
+	Check       string
+	Status      checkStatus
+	Detail      string
+	Remediation string // what to do before rolling out; empty when OK
+}
+
+// runPreflight handles the `platform preflight` subcommand: checks that the
+// target environment is ready for this build and exits non-zero otherwise.
+// CD runs it with the target environment's CJ_* configuration before rollout.
+func runPreflight(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("preflight", flag.ExitOnError)
+	env := fs.String("env", "", "target environment name (shown in the report)")
+	timeout := fs.Duration("timeout", time.Minute, "overall time limit for the checks")
+	fs.Parse(args)
+	if *env == "" || fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: platform preflight -env <environment> [-timeout 1m]")
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	results := preflight(ctx, cfg, slog.Default())
+	printPreflight(os.Stdout, *env, version, results)
+	if slices.ContainsFunc(results, func(r checkResult) bool { return r.Status == checkFail }) {
+		os.Exit(1)
+	}
+}
+
+// preflight runs every check. A check that cannot reach its dependency fails.
+func preflight(ctx context.Context, cfg *config.Config, logger *slog.Logger) []checkResult {
+	var results []checkResult
+
+	for _, m := range platformMigrations(cfg) {
+		name := "migrations/" + m.Service
+		status, err := postgres.CheckMigrations(ctx, m.DatabaseURL, m.FS, "migrations", m.Table)
+		if err != nil {
+			results = append(results, unreachable(name, err, "check the "+m.Service+" database URL and connectivity"))
+			continue
+		}
+		results = append(results, migrationResult(name, status))
+	}
+
+	results = append(results, checkSchemas(ctx, cfg, logger))
+
+	admin, err := redpanda.NewAdmin(strings.Split(cfg.RedpandaBrokers, ","), brokerAuth(cfg, nil), logger)
+	if err != nil {
+		return append(results, unreachable("redpanda", err, "check CJ_REDPANDA_BROKERS"))
+	}
+	defer admin.Close()
+
+	results = append(results, checkTopics(ctx, cfg, admin))
+
+	group := cfg.EventHandlerConsumerGroup
+	if desc, err := admin.DescribeGroup(ctx, group); err != nil {
+		results = append(results, unreachable("consumer-group/"+group, err, "check CJ_REDPANDA_BROKERS and the broker ACLs"))
+	} else {
+		results = append(results, groupResult(desc, version))
+	}
+
+	return results
+}
+
+func unreachable(check string, err error, remediation string) checkResult {
+	return checkResult{Check: check, Status: checkFail, Detail: err.Error(), Remediation: remediation}
+}
+
+// checkSchemas compares stored event schema versions with this build's.
+func checkSchemas(ctx context.Context, cfg *config.Config, logger *slog.Logger) checkResult {
+	const name = "event-schemas"
+
+	client, err := postgres.NewClient(ctx, cfg.DatabaseURLIngestion, logger)
+	if err != nil {
+		return unreachable(name, err, "check the ingestion database URL and connectivity")
+	}
+	defer client.Close()
+
+	versions, err := postgres.NewEventStoreRepo(client.Pool(), logger).SchemaVersions(ctx)
+	if err != nil {
+		return unreachable(name, err, "apply the ingestion migrations (platform migrate)")
+	}
+	return schemaResult(name, versions, events.CurrentSchemaVersion)
+}
+
+// checkTopics compares the cluster's topics with their configured specs.
+func checkTopics(ctx context.Context, cfg *config.Config, admin *redpanda.Admin) checkResult {
+	const name = "topics"
+
+	specs, err := platformTopicSpecs(cfg)
+	if err != nil {
+		return checkResult{Check: name, Status: checkFail, Detail: err.Error(), Remediation: "fix the topic configuration"}
+	}
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+	counts, err := admin.PartitionCounts(ctx, names)
+	if err != nil {
+		return unreachable(name, err, "check CJ_REDPANDA_BROKERS and the broker ACLs")
+	}
+	return topicResult(name, specs, counts, cfg.TopicsEnsure)
+}
+
+// migrationResult fails when the database is behind or ahead of this build.
+func migrationResult(name string, status *postgres.MigrationStatus) checkResult {
+	switch {
+	case status.Ahead():
+		return checkResult{
+			Check:  name,
+			Status: checkFail,
+			Detail: fmt.Sprintf("database is at version %d, newer than this build (%d)", status.Current, status.Latest),
+			Remediation: "this build predates the deployed schema; deploy a build that includes " +
+				fmt.Sprintf("migration %d, or restore the database", status.Current),
+		}
+	case len(status.Pending) > 0:
+		return checkResult{
+			Check:       name,
+			Status:      checkFail,
+			Detail:      fmt.Sprintf("%d pending: %s", len(status.Pending), strings.Join(status.Pending, ", ")),
+			Remediation: "run `platform migrate` with this build before rollout",
+		}
+	default:
+		return checkResult{Check: name, Status: checkOK, Detail: fmt.Sprintf("up to date (version %d)", status.Current)}
+	}
+}
+
+// schemaResult fails when events were stored with a payload schema version
+// newer than this build understands (typically a rollback past a schema bump).
+func schemaResult(name string, versions map[string]int, supported int) checkResult {
+	var newer []string
+	for eventType, v := range versions {
+		if v > supported {
+			newer = append(newer, fmt.Sprintf("%s=v%d", eventType, v))
+		}
+	}
+	sort.Strings(newer)
+
+	if len(newer) > 0 {
+		return checkResult{
+			Check:       name,
+			Status:      checkFail,
+			Detail:      fmt.Sprintf("stored events are newer than this build (v%d): %s", supported, strings.Join(newer, ", ")),
+			Remediation: "deploy a build that understands these schema versions; payloads cannot be downcast",
+		}
+	}
+	return checkResult{
+		Check:  name,
+		Status: checkOK,
+		Detail: fmt.Sprintf("%d event types, all at or below v%d", len(versions), supported),
+	}
+}
+
+// topicResult fails when a topic has fewer partitions than configured, since
+// topics are never repartitioned automatically. Missing topics only warn when
+// the platform creates them at startup (CJ_TOPICS_ENSURE).
+func topicResult(name string, specs []redpanda.TopicSpec, counts map[string]int32, ensure bool) checkResult {
+	var missing, under []string
+	for _, spec := range specs {
+		count, ok := counts[spec.Name]
+		switch {
+		case !ok:
+			missing = append(missing, spec.Name)
+		case spec.Partitions > 0 && count < spec.Partitions:
+			under = append(under, fmt.Sprintf("%s (%d < %d)", spec.Name, count, spec.Partitions))
+		}
+	}
+
+	result := checkResult{Check: name, Status: checkOK, Detail: fmt.Sprintf("%d topics present", len(specs))}
+	var details, remediations []string
+	if len(missing) > 0 {
+		details = append(details, "missing: "+strings.Join(missing, ", "))
+		if ensure {
+			result.Status = checkWarn
+			remediations = append(remediations, "missing topics are created at startup (CJ_TOPICS_ENSURE)")
+		} else {
+			result.Status = checkFail
+			remediations = append(remediations, "run `platform topics ensure`")
+		}
+	}
+	if len(under) > 0 {
+		details = append(details, "under-partitioned: "+strings.Join(under, ", "))
+		result.Status = checkFail
+		remediations = append(remediations,
+			"add partitions while the event handler is drained (per-aggregate ordering), or lower the configured partitions")
+	}
+	if len(details) > 0 {
+		result.Detail = strings.Join(details, "; ")
+		result.Remediation = strings.Join(remediations, "; ")
+	}
+	return result
+}
+
+// groupResult fails when the consumer group runs more than one build version,
+// which means an earlier rollout has not finished.
+func groupResult(desc *redpanda.GroupDescription, target string) checkResult {
+	name := "consumer-group/" + desc.Group
+	if len(desc.Members) == 0 {
+		return checkResult{Check: name, Status: checkOK, Detail: "no active members"}
+	}
+
+	var versions []string
+	for _, m := range desc.Members {
+		v, ok := strings.CutPrefix(m.ClientID, clientIDPrefix)
+		if !ok {
+			v = "unknown (client " + m.ClientID + ")"
+		}
+		if !slices.Contains(versions, v) {
+			versions = append(versions, v)
+		}
+	}
+	sort.Strings(versions)
+
+	switch {
+	case len(versions) > 1:
+		return checkResult{
+			Check:       name,
+			Status:      checkFail,
+			Detail:      fmt.Sprintf("%d members run mixed versions: %s", len(desc.Members), strings.Join(versions, ", ")),
+			Remediation: "finish or roll back the current rollout before starting another",
+		}
+	case desc.State != "Stable":
+		return checkResult{
+			Check:       name,
+			Status:      checkWarn,
+			Detail:      fmt.Sprintf("group is %s (%d members on %s)", desc.State, len(desc.Members), versions[0]),
+			Remediation: "wait for the rebalance to settle",
+		}
+	default:
+		detail := fmt.Sprintf("%d members on %s", len(desc.Members), versions[0])
+		if versions[0] != target {
+			detail += ", upgrading to " + target
+		}
+		return checkResult{Check: name, Status: checkOK, Detail: detail}
+	}
+}
+
+// printPreflight writes the report as an aligned table with remediation lines.
+func printPreflight(w io.Writer, env, build string, results []checkResult) {
+	fmt.Fprintf(w, "preflight: environment %s, build %s\n\n", env, build)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tCHECK\tDETAIL")
+	failed := 0
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Status, r.Check, r.Detail)
+		if r.Remediation != "" {
+			fmt.Fprintf(tw, "\t\t-> %s\n", r.Remediation)
+		}
+		if r.Status == checkFail {
+			failed++
+		}
+	}
+	tw.Flush()
+
+	if failed > 0 {
+		fmt.Fprintf(w, "\n%d check(s) failed; do not roll out\n", failed)
+	} else {
+		fmt.Fprintln(w, "\nready to roll out")
+	}
+}
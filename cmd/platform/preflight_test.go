@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cornjacket/platform-services/internal/shared/infra/postgres"
+	"github.com/cornjacket/platform-services/internal/shared/infra/redpanda"
+)
+
+func TestMigrationResult(t *testing.T) {
+	ok := migrationResult("migrations/ingestion", &postgres.MigrationStatus{Current: 6, Latest: 6})
+	assert.Equal(t, checkOK, ok.Status)
+
+	pending := migrationResult("migrations/ingestion", &postgres.MigrationStatus{
+		Current: 5, Latest: 6, Pending: []string{"006_add_event_store_sequences.sql"},
+	})
+	assert.Equal(t, checkFail, pending.Status)
+	assert.Contains(t, pending.Detail, "006_add_event_store_sequences.sql")
+	assert.Contains(t, pending.Remediation, "platform migrate")
+
+	ahead := migrationResult("migrations/ingestion", &postgres.MigrationStatus{Current: 7, Latest: 6})
+	assert.Equal(t, checkFail, ahead.Status)
+	assert.Contains(t, ahead.Detail, "newer than this build")
+}
+
+func TestSchemaResult(t *testing.T) {
+	ok := schemaResult("event-schemas", map[string]int{"sensor.reading": 1, "user.login": 1}, 1)
+	assert.Equal(t, checkOK, ok.Status)
+
+	newer := schemaResult("event-schemas", map[string]int{"sensor.reading": 2, "user.login": 1}, 1)
+	assert.Equal(t, checkFail, newer.Status)
+	assert.Contains(t, newer.Detail, "sensor.reading=v2")
+	assert.NotContains(t, newer.Detail, "user.login")
+}
+
+func TestTopicResult(t *testing.T) {
+	specs := []redpanda.TopicSpec{
+		{Name: "sensor-events", Partitions: 6},
+		{Name: "user-actions", Partitions: 6},
+	}
+
+	ok := topicResult("topics", specs, map[string]int32{"sensor-events": 6, "user-actions": 12}, false)
+	assert.Equal(t, checkOK, ok.Status)
+
+	missing := topicResult("topics", specs, map[string]int32{"sensor-events": 6}, false)
+	assert.Equal(t, checkFail, missing.Status)
+	assert.Contains(t, missing.Detail, "missing: user-actions")
+	assert.Contains(t, missing.Remediation, "platform topics ensure")
+
+	created := topicResult("topics", specs, map[string]int32{"sensor-events": 6}, true)
+	assert.Equal(t, checkWarn, created.Status, "missing topics are created at startup")
+
+	under := topicResult("topics", specs, map[string]int32{"sensor-events": 3, "user-actions": 6}, true)
+	assert.Equal(t, checkFail, under.Status)
+	assert.Contains(t, under.Detail, "sensor-events (3 < 6)")
+}
+
+func TestGroupResult(t *testing.T) {
+	member := func(clientID string) redpanda.GroupMember {
+		return redpanda.GroupMember{ClientID: clientID}
+	}
+
+	empty := groupResult(&redpanda.GroupDescription{Group: "event-handler", State: "Empty"}, "v2")
+	assert.Equal(t, checkOK, empty.Status)
+
+	upgrade := groupResult(&redpanda.GroupDescription{
+		Group: "event-handler", State: "Stable",
+		Members: []redpanda.GroupMember{member(consumerClientID("v1")), member(consumerClientID("v1"))},
+	}, "v2")
+	assert.Equal(t, checkOK, upgrade.Status)
+	assert.Contains(t, upgrade.Detail, "upgrading to v2")
+
+	mixed := groupResult(&redpanda.GroupDescription{
+		Group: "event-handler", State: "Stable",
+		Members: []redpanda.GroupMember{member(consumerClientID("v1")), member(consumerClientID("v2"))},
+	}, "v3")
+	assert.Equal(t, checkFail, mixed.Status)
+	assert.Contains(t, mixed.Detail, "v1, v2")
+
+	foreign := groupResult(&redpanda.GroupDescription{
+		Group: "event-handler", State: "Stable",
+		Members: []redpanda.GroupMember{member(consumerClientID("v1")), member("kgo")},
+	}, "v2")
+	assert.Equal(t, checkFail, foreign.Status, "members without a version count as another version")
+
+	rebalancing := groupResult(&redpanda.GroupDescription{
+		Group: "event-handler", State: "PreparingRebalance",
+		Members: []redpanda.GroupMember{member(consumerClientID("v1"))},
+	}, "v2")
+	assert.Equal(t, checkWarn, rebalancing.Status)
+}
+
+func TestPrintPreflight(t *testing.T) {
+	var buf bytes.Buffer
+	printPreflight(&buf, "staging", "v2", []checkResult{
+		{Check: "topics", Status: checkOK, Detail: "2 topics present"},
+		{Check: "migrations/ingestion", Status: checkFail, Detail: "1 pending", Remediation: "run `platform migrate`"},
+	})
+
+	out := buf.String()
+	assert.Contains(t, out, "environment staging, build v2")
+	assert.Contains(t, out, "FAIL")
+	assert.Contains(t, out, "-> run `platform migrate`")
+	assert.Contains(t, out, "1 check(s) failed")
+}
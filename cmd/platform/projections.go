@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/cornjacket/platform-services/internal/shared/config"
+	"github.com/cornjacket/platform-services/internal/shared/infra/postgres"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// stateTransforms are the Go projection state transforms `platform
+// projections transform -go` can run, by name. Add one here when a state
+// shape change needs more than renames or a SQL expression.
+var stateTransforms = map[string]projections.StateTransform{}
+
+// runProjections handles the `platform projections transform` subcommand:
+// migrates the stored state of one projection type to a new JSON shape.
+func runProjections(cfg *config.Config, args []string) {
+	const usage = "usage: platform projections transform -type TYPE (-rename old=new,... | -sql EXPR | -go NAME) [-batch N] [-after ID] [-dry-run]"
+	if len(args) < 1 || args[0] != "transform" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("projections transform", flag.ExitOnError)
+	projType := fs.String("type", "", "projection type to transform")
+	rename := fs.String("rename", "", "top-level fields to rename, e.g. temperature=temp_f")
+	sqlExpr := fs.String("sql", "", "jsonb expression over state, evaluated in the database")
+	goName := fs.String("go", "", "name of a Go transform registered in stateTransforms")
+	batch := fs.Int("batch", 500, "projections per batch")
+	after := fs.String("after", "", "resume after this aggregate ID")
+	dryRun := fs.Bool("dry-run", false, "report the changes without writing them")
+	fs.Parse(args[1:])
+	if *projType == "" || *batch <= 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client, err := postgres.NewClient(ctx, cfg.DatabaseURLEventHandler, slog.Default())
+	if err != nil {
+		slog.Error("failed to connect to PostgreSQL (event handler)", "error", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+	store := projections.NewPostgresStore(client.Pool(), slog.Default())
+
+	transformer, err := stateTransformer(store, *rename, *sqlExpr, *goName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	result, err := projections.RunTransform(ctx, store, transformer, projections.TransformConfig{
+		ProjectionType: *projType,
+		After:          *after,
+		BatchSize:      *batch,
+		DryRun:         *dryRun,
+	}, slog.Default())
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+	if err != nil {
+		slog.Error("projection transform stopped; rerun with -after to resume",
+			"after", result.LastAggregateID,
+			"error", err,
+		)
+		os.Exit(1)
+	}
+}
+
+// stateTransformer returns the transform selected by exactly one of the
+// -rename, -sql and -go flags.
+func stateTransformer(store *projections.PostgresStore, rename, sqlExpr, goName string) (projections.StateTransformer, error) {
+	set := 0
+	for _, flag := range []string{rename, sqlExpr, goName} {
+		if flag != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of -rename, -sql and -go is required")
+	}
+
+	switch {
+	case rename != "":
+		renames, err := projections.ParseRenames(rename)
+		if err != nil {
+			return nil, err
+		}
+		return projections.RenameFields(renames), nil
+	case sqlExpr != "":
+		return store.SQLTransform(sqlExpr), nil
+	default:
+		fn, ok := stateTransforms[goName]
+		if !ok {
+			return nil, fmt.Errorf("unknown Go transform %q (registered: %s)", goName, strings.Join(slices.Sorted(maps.Keys(stateTransforms)), ", "))
+		}
+		return fn, nil
+	}
+}
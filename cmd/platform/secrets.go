@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/config"
+	"github.com/cornjacket/platform-services/internal/shared/infra/redpanda"
+	"github.com/cornjacket/platform-services/internal/shared/secrets"
+)
+
+// secretsTimeout bounds resolving every secret reference at startup.
+const secretsTimeout = 30 * time.Second
+
+// resolveSecrets registers the configured secrets providers and replaces
+// the secret references in cfg with their values. The returned resolver
+// resolves them again for credentials that rotate (see rotatingURL and
+// brokerAuth).
+func resolveSecrets(cfg *config.Config, logger *slog.Logger) (*secrets.Resolver, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	resolver := secrets.NewResolver(cfg.SecretsRefreshInterval, logger)
+	resolver.Register(secrets.ProviderFile, secrets.FileProvider{})
+
+	if cfg.SecretsVaultAddr != "" {
+		// The token itself may come from a file, e.g. a Vault agent sink
+		token, err := resolver.Expand(ctx, cfg.SecretsVaultToken)
+		if err != nil {
+			return nil, fmt.Errorf("CJ_SECRETS_VAULT_TOKEN: %w", err)
+		}
+		vault, err := secrets.NewVaultProvider(secrets.VaultConfig{
+			Addr:      cfg.SecretsVaultAddr,
+			Token:     token,
+			Namespace: cfg.SecretsVaultNamespace,
+		})
+		if err != nil {
+			return nil, err
+		}
+		resolver.Register(secrets.ProviderVault, vault)
+	}
+
+	if cfg.SecretsAWSRegion != "" {
+		// Standard AWS variables, as set by the AWS CLI and most runtimes
+		aws, err := secrets.NewAWSProvider(secrets.AWSConfig{
+			Region:          cfg.SecretsAWSRegion,
+			Endpoint:        cfg.SecretsAWSEndpoint,
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		resolver.Register(secrets.ProviderAWS, aws)
+	}
+
+	if err := cfg.ResolveSecrets(ctx, resolver); err != nil {
+		return nil, err
+	}
+	return resolver, nil
+}
+
+// rotatingURL returns the refresh function for postgres.NewRotatingClient:
+// it resolves the setting's secret reference again. It returns nil when the
+// setting held a plain URL.
+func rotatingURL(cfg *config.Config, resolver *secrets.Resolver, key string) func(context.Context) (string, error) {
+	ref := cfg.SecretRef(key)
+	if ref == "" || resolver == nil {
+		return nil
+	}
+	return func(ctx context.Context) (string, error) {
+		return resolver.ExpandURL(ctx, ref)
+	}
+}
+
+// brokerAuth returns the Redpanda connection settings. SASL credentials
+// given as secret references are resolved again for each connection; with a
+// nil resolver the values resolved at startup are used.
+func brokerAuth(cfg *config.Config, resolver *secrets.Resolver) redpanda.Auth {
+	auth := redpanda.Auth{
+		TLS:           cfg.RedpandaTLS,
+		SASLMechanism: cfg.RedpandaSASLMechanism,
+	}
+	if auth.SASLMechanism == "" {
+		return auth
+	}
+
+	userRef := cfg.SecretRef("CJ_REDPANDA_SASL_USERNAME")
+	passRef := cfg.SecretRef("CJ_REDPANDA_SASL_PASSWORD")
+	auth.SASLCredentials = func(ctx context.Context) (string, string, error) {
+		user, pass := cfg.RedpandaSASLUsername, cfg.RedpandaSASLPassword
+		if resolver == nil {
+			return user, pass, nil
+		}
+		var err error
+		if userRef != "" {
+			if user, err = resolver.Expand(ctx, userRef); err != nil {
+				return "", "", err
+			}
+		}
+		if passRef != "" {
+			if pass, err = resolver.Expand(ctx, passRef); err != nil {
+				return "", "", err
+			}
+		}
+		return user, pass, nil
+	}
+	return auth
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/config"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestResolveSecrets_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	dbPassword := filepath.Join(dir, "db_password")
+	saslPassword := filepath.Join(dir, "sasl_password")
+	require.NoError(t, os.WriteFile(dbPassword, []byte("one"), 0o600))
+	require.NoError(t, os.WriteFile(saslPassword, []byte("first"), 0o600))
+
+	t.Setenv("CJ_QUERY_DATABASE_URL", "postgres://query:${file:"+dbPassword+"}@db:5432/query")
+	t.Setenv("CJ_REDPANDA_SASL_MECHANISM", "PLAIN")
+	t.Setenv("CJ_REDPANDA_SASL_USERNAME", "platform")
+	t.Setenv("CJ_REDPANDA_SASL_PASSWORD", "${file:"+saslPassword+"}")
+	t.Setenv("CJ_SECRETS_REFRESH_INTERVAL", "1ns") // always fetch again
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	resolver, err := resolveSecrets(cfg, testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://query:one@db:5432/query", cfg.DatabaseURLQuery)
+
+	// Rotate both secrets
+	require.NoError(t, os.WriteFile(dbPassword, []byte("two"), 0o600))
+	require.NoError(t, os.WriteFile(saslPassword, []byte("second"), 0o600))
+
+	refresh := rotatingURL(cfg, resolver, "CJ_QUERY_DATABASE_URL")
+	require.NotNil(t, refresh)
+	url, err := refresh(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://query:two@db:5432/query", url)
+	assert.Nil(t, rotatingURL(cfg, resolver, "CJ_INGESTION_DATABASE_URL"), "plain URLs do not rotate")
+
+	user, pass, err := brokerAuth(cfg, resolver).SASLCredentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "platform", user)
+	assert.Equal(t, "second", pass)
+
+	// Without a resolver, the values resolved at startup are used
+	_, pass, err = brokerAuth(cfg, nil).SASLCredentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "first", pass)
+}
+
+func TestResolveSecrets_ProviderConfig(t *testing.T) {
+	t.Setenv("CJ_SECRETS_VAULT_ADDR", "https://vault:8200")
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	_, err = resolveSecrets(cfg, testLogger())
+	assert.EqualError(t, err, "a Vault token is required")
+
+	t.Setenv("CJ_SECRETS_VAULT_ADDR", "")
+	t.Setenv("CJ_SECRETS_AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	cfg, err = config.Load()
+	require.NoError(t, err)
+
+	_, err = resolveSecrets(cfg, testLogger())
+	assert.ErrorContains(t, err, "AWS credentials are required")
+}
+
+func TestBrokerAuth_Disabled(t *testing.T) {
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	auth := brokerAuth(cfg, nil)
+	assert.False(t, auth.TLS)
+	assert.Empty(t, auth.SASLMechanism)
+	assert.Nil(t, auth.SASLCredentials)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/cornjacket/platform-services/internal/shared/config"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
+)
+
+// eventSigning builds the ingestion signer and the consumers' verifier from
+// the CJ_EVENT_* settings. Either is nil when not configured. Without
+// CJ_EVENT_VERIFY_KEYS the consumers verify with the signing key.
+func eventSigning(cfg *config.Config, logger *slog.Logger) (*signing.Signer, *signing.Verifier, error) {
+	var signer *signing.Signer
+	if cfg.EventSigningKey != "" {
+		key, err := signing.ParseSigningKey(cfg.EventSigningKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CJ_EVENT_SIGNING_KEY: %w", err)
+		}
+		if signer, err = signing.NewSigner(key); err != nil {
+			return nil, nil, fmt.Errorf("CJ_EVENT_SIGNING_KEY: %w", err)
+		}
+		logger.Info("event signing enabled", "algorithm", key.Algorithm(), "key_id", key.ID())
+	}
+
+	keys, err := signing.ParseVerifyKeys(cfg.EventVerifyKeys)
+	if err != nil {
+		return nil, nil, fmt.Errorf("CJ_EVENT_VERIFY_KEYS: %w", err)
+	}
+	if len(keys) == 0 && signer != nil {
+		keys = append(keys, signer.Key())
+	}
+	if len(keys) == 0 && !cfg.EventSignatureRequired {
+		return signer, nil, nil
+	}
+	return signer, signing.NewVerifier(keys, cfg.EventSignatureRequired), nil
+}
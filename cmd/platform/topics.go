@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	ehclient "github.com/cornjacket/platform-services/internal/client/eventhandler"
+	"github.com/cornjacket/platform-services/internal/shared/config"
+	"github.com/cornjacket/platform-services/internal/shared/infra/redpanda"
+)
+
+// runTopics handles the `platform topics <command>` subcommand.
+func runTopics(cfg *config.Config, args []string) {
+	if len(args) != 1 || args[0] != "ensure" {
+		fmt.Fprintln(os.Stderr, "usage: platform topics ensure")
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	result, err := ensureTopics(ctx, cfg, slog.Default())
+	if err != nil {
+		slog.Error("failed to ensure topics", "error", err)
+		os.Exit(1)
+	}
+	if len(result.UnderPartitioned) > 0 {
+		os.Exit(1)
+	}
+}
+
+// ensureTopics creates every topic the platform produces to or consumes from
+// that does not exist yet, using the configured partitions, replication, and
+// retention.
+func ensureTopics(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*redpanda.EnsureResult, error) {
+	specs, err := platformTopicSpecs(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := redpanda.NewAdmin(strings.Split(cfg.RedpandaBrokers, ","), brokerAuth(cfg, nil), logger)
+	if err != nil {
+		return nil, err
+	}
+	defer admin.Close()
+
+	result, err := admin.EnsureTopics(ctx, specs)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("topics ensured",
+		"created", result.Created,
+		"existing", result.Existing,
+		"under_partitioned", result.UnderPartitioned,
+	)
+	return result, nil
+}
+
+// platformTopicSpecs returns the configured spec of every platform topic.
+func platformTopicSpecs(cfg *config.Config) ([]redpanda.TopicSpec, error) {
+	topics, err := platformTopics(cfg)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := redpanda.TopicSpecs(topics, redpanda.TopicSpec{
+		Partitions:        int32(cfg.TopicPartitions),
+		ReplicationFactor: int16(cfg.TopicReplication),
+		Retention:         cfg.TopicRetention,
+	}, cfg.TopicOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CJ_TOPIC_OVERRIDES: %w", err)
+	}
+	// The change topic keeps the latest state of each projection; an
+	// existing topic's cleanup policy is left as it is
+	for i := range specs {
+		if specs[i].Name == cfg.ProjectionChangesTopic {
+			specs[i].Compacted = true
+		}
+	}
+	return specs, nil
+}
+
+// platformTopics returns the routed topics plus the event handler's topics
+// and, when configured, the projection change topic.
+func platformTopics(cfg *config.Config) ([]string, error) {
+	routes, err := ehclient.ParseRoutes(cfg.TopicRoutes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CJ_TOPIC_ROUTES: %w", err)
+	}
+	router, err := ehclient.NewRouter(routes, cfg.TopicDefault)
+	if err != nil {
+		return nil, fmt.Errorf("invalid topic routing configuration: %w", err)
+	}
+
+	topics := router.Topics()
+	for _, topic := range strings.Split(cfg.EventHandlerTopics, ",") {
+		if topic != "" && !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	if cfg.ProjectionChangesTopic != "" && !slices.Contains(topics, cfg.ProjectionChangesTopic) {
+		topics = append(topics, cfg.ProjectionChangesTopic)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
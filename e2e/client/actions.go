@@ -0,0 +1,151 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Condition is one condition of a rule's predicate on the event payload, e.g.
+// {"field": "value", "op": "gt", "value": 90}.
+type Condition struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// RuleRequest represents a request to create a rule in the actions API.
+type RuleRequest struct {
+	Name        string      `json:"name"`
+	EventType   string      `json:"event_type"`
+	Predicate   []Condition `json:"predicate"`
+	ActionType  string      `json:"action_type"`
+	Destination string      `json:"destination"`
+}
+
+// Rule represents a rule from the actions API.
+type Rule struct {
+	RuleID      string `json:"rule_id"`
+	Name        string `json:"name"`
+	EventType   string `json:"event_type"`
+	ActionType  string `json:"action_type"`
+	Destination string `json:"destination"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// Execution represents an entry of the actions log: one rule that fired for
+// an event.
+type Execution struct {
+	ExecutionID string `json:"execution_id"`
+	RuleID      string `json:"rule_id"`
+	EventID     string `json:"event_id"`
+	AggregateID string `json:"aggregate_id"`
+	ActionType  string `json:"action_type"`
+	Status      string `json:"status"` // succeeded, failed or skipped
+	Error       string `json:"error"`
+}
+
+// CreateRule creates a rule in the actions API. Rules take effect at once on
+// the instance that created them.
+func CreateRule(ctx context.Context, cfg *Config, req *RuleRequest) (*Rule, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	status, respBody, err := cfg.do(ctx, false, func() (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.ActionsURL+"/api/v1/rules", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		return httpReq, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusCreated {
+		var errResp ErrorResponse
+		json.Unmarshal(respBody, &errResp)
+		return nil, fmt.Errorf("unexpected status %d: %s", status, errResp.Error)
+	}
+
+	var rule Rule
+	if err := json.Unmarshal(respBody, &rule); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &rule, nil
+}
+
+// DeleteRule deletes a rule from the actions API. A rule already gone is not
+// an error.
+func DeleteRule(ctx context.Context, cfg *Config, ruleID string) error {
+	status, respBody, err := cfg.do(ctx, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodDelete, cfg.ActionsURL+"/api/v1/rules/"+url.PathEscape(ruleID), nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusNoContent && status != http.StatusNotFound {
+		var errResp ErrorResponse
+		json.Unmarshal(respBody, &errResp)
+		return fmt.Errorf("unexpected status %d: %s", status, errResp.Error)
+	}
+	return nil
+}
+
+// ListExecutions retrieves the actions log entries of a rule, newest first.
+func ListExecutions(ctx context.Context, cfg *Config, ruleID string) ([]Execution, error) {
+	u := cfg.ActionsURL + "/api/v1/actions/executions?rule_id=" + url.QueryEscape(ruleID)
+
+	status, respBody, err := cfg.do(ctx, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		var errResp ErrorResponse
+		json.Unmarshal(respBody, &errResp)
+		return nil, fmt.Errorf("unexpected status %d: %s", status, errResp.Error)
+	}
+
+	var list struct {
+		Executions []Execution `json:"executions"`
+	}
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return list.Executions, nil
+}
+
+// ListActionTypes returns the action types rules may use; "webhook" is only
+// available when the platform has a webhook signing secret.
+func ListActionTypes(ctx context.Context, cfg *Config) ([]string, error) {
+	status, respBody, err := cfg.do(ctx, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, cfg.ActionsURL+"/api/v1/action-types", nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		var errResp ErrorResponse
+		json.Unmarshal(respBody, &errResp)
+		return nil, fmt.Errorf("unexpected status %d: %s", status, errResp.Error)
+	}
+
+	var list struct {
+		ActionTypes []string `json:"action_types"`
+	}
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return list.ActionTypes, nil
+}
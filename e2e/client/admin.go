@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// QuarantinedEvent represents an event the event handler quarantined in its
+// DLQ after repeated failed dispatches.
+type QuarantinedEvent struct {
+	Consumer string          `json:"consumer"`
+	EventID  string          `json:"event_id"`
+	Event    json.RawMessage `json:"event"`
+	Error    string          `json:"error"`
+	Attempts int             `json:"attempts"`
+	Topic    string          `json:"topic"`
+	FailedAt string          `json:"failed_at"`
+}
+
+// ListQuarantined retrieves the events a consumer group quarantined, most
+// recently failed first, from the event handler admin API.
+func ListQuarantined(ctx context.Context, cfg *Config, consumer string) ([]QuarantinedEvent, error) {
+	u := cfg.AdminURL + "/admin/v1/dlq?limit=100&consumer=" + url.QueryEscape(consumer)
+
+	status, respBody, err := cfg.do(ctx, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		var errResp ErrorResponse
+		json.Unmarshal(respBody, &errResp)
+		return nil, fmt.Errorf("unexpected status %d: %s", status, errResp.Error)
+	}
+
+	var list struct {
+		Events []QuarantinedEvent `json:"events"`
+	}
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return list.Events, nil
+}
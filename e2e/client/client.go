@@ -5,8 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,6 +14,31 @@ import (
 type Config struct {
 	IngestionURL string
 	QueryURL     string
+	ActionsURL   string // rule management and the actions log (see CreateRule)
+	AdminURL     string // event handler admin API (see ListQuarantined)
+	// APIKey, if set, is sent with ingested events. Use a key the platform
+	// treats as test traffic (CJ_INGESTION_TEST_API_KEYS); projections are
+	// then read from the test namespace.
+	APIKey string
+
+	// Retry configures retries of connection errors and 5xx responses for
+	// requests that are safe to repeat (see RetryPolicy). The zero value
+	// uses DefaultRetryPolicy; set MaxAttempts to 1 to disable.
+	Retry RetryPolicy
+	// Breaker, if set, fails requests fast while the services keep failing.
+	// Share one breaker across Configs that talk to the same deployment.
+	Breaker *CircuitBreaker
+	// HTTPClient sends the requests (http.DefaultClient if nil).
+	HTTPClient *http.Client
+}
+
+// queryNamespace returns the query string selecting the projection namespace
+// the client's events land in.
+func (c *Config) queryNamespace() string {
+	if c.APIKey != "" {
+		return "namespace=test"
+	}
+	return ""
 }
 
 // IngestRequest represents a request to the ingestion API.
@@ -21,6 +46,21 @@ type IngestRequest struct {
 	EventType   string      `json:"event_type"`
 	AggregateID string      `json:"aggregate_id"`
 	Payload     interface{} `json:"payload"`
+
+	// IdempotencyKey, if set, sends the event through the v2 API with this
+	// key, which makes the request safe to retry: a retry of an accepted
+	// request answers status "duplicate" with the original event ID. Events
+	// without a key are sent once.
+	IdempotencyKey string `json:"-"`
+}
+
+// ingestRequestV2 is the v2 body of an IngestRequest with an idempotency key.
+type ingestRequestV2 struct {
+	EventType      string      `json:"event_type"`
+	AggregateID    string      `json:"aggregate_id"`
+	SchemaVersion  int         `json:"schema_version"`
+	IdempotencyKey string      `json:"idempotency_key"`
+	Payload        interface{} `json:"payload"`
 }
 
 // IngestResponse represents the response from the ingestion API.
@@ -53,39 +93,56 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
-// UniqueID generates a unique ID for test isolation.
+// idSeq tells apart IDs generated in the same clock tick, as concurrent
+// tests can.
+var idSeq atomic.Int64
+
+// UniqueID generates a unique ID for test isolation. IDs are unique across
+// runs and across tests running in parallel.
 func UniqueID(prefix string) string {
-	return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
+	return fmt.Sprintf("%s-%d-%d", prefix, time.Now().UnixNano(), idSeq.Add(1))
 }
 
-// IngestEvent posts an event to the ingestion API.
+// IngestEvent posts an event to the ingestion API: v1, or v2 when the
+// request carries an idempotency key.
 func IngestEvent(ctx context.Context, cfg *Config, req *IngestRequest) (*IngestResponse, error) {
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.IngestionURL+"/api/v1/events", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	path := "/api/v1/events"
+	var payload interface{} = req
+	if req.IdempotencyKey != "" {
+		path = "/api/v2/events"
+		payload = &ingestRequestV2{
+			EventType:      req.EventType,
+			AggregateID:    req.AggregateID,
+			SchemaVersion:  1,
+			IdempotencyKey: req.IdempotencyKey,
+			Payload:        req.Payload,
+		}
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(httpReq)
+	body, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	status, respBody, err := cfg.do(ctx, req.IdempotencyKey != "", func() (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.IngestionURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if cfg.APIKey != "" {
+			httpReq.Header.Set("X-API-Key", cfg.APIKey)
+		}
+		return httpReq, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusAccepted {
+	// 200 answers a repeated idempotency key (status "duplicate")
+	if status != http.StatusAccepted && !(status == http.StatusOK && req.IdempotencyKey != "") {
 		var errResp ErrorResponse
 		json.Unmarshal(respBody, &errResp)
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, errResp.Error)
+		return nil, fmt.Errorf("unexpected status %d: %s", status, errResp.Error)
 	}
 
 	var ingestResp IngestResponse
@@ -99,31 +156,25 @@ func IngestEvent(ctx context.Context, cfg *Config, req *IngestRequest) (*IngestR
 // GetProjection retrieves a projection from the query API.
 func GetProjection(ctx context.Context, cfg *Config, projectionType, aggregateID string) (*Projection, error) {
 	url := fmt.Sprintf("%s/api/v1/projections/%s/%s", cfg.QueryURL, projectionType, aggregateID)
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if ns := cfg.queryNamespace(); ns != "" {
+		url += "?" + ns
 	}
 
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
+	status, respBody, err := cfg.do(ctx, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode == http.StatusNotFound {
+	if status == http.StatusNotFound {
 		return nil, nil // Not found is not an error
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	if status != http.StatusOK {
 		var errResp ErrorResponse
 		json.Unmarshal(respBody, &errResp)
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, errResp.Error)
+		return nil, fmt.Errorf("unexpected status %d: %s", status, errResp.Error)
 	}
 
 	var projection Projection
@@ -137,27 +188,21 @@ func GetProjection(ctx context.Context, cfg *Config, projectionType, aggregateID
 // ListProjections retrieves a list of projections from the query API.
 func ListProjections(ctx context.Context, cfg *Config, projectionType string, limit, offset int) (*ProjectionList, error) {
 	url := fmt.Sprintf("%s/api/v1/projections/%s?limit=%d&offset=%d", cfg.QueryURL, projectionType, limit, offset)
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if ns := cfg.queryNamespace(); ns != "" {
+		url += "&" + ns
 	}
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	status, respBody, err := cfg.do(ctx, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
+	if status != http.StatusOK {
 		var errResp ErrorResponse
 		json.Unmarshal(respBody, &errResp)
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, errResp.Error)
+		return nil, fmt.Errorf("unexpected status %d: %s", status, errResp.Error)
 	}
 
 	var list ProjectionList
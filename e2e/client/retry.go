@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/retry"
+)
+
+// RetryPolicy configures retries of transient failures: connection errors and
+// 5xx responses. Other responses (including 4xx) are returned as-is.
+//
+// Only requests that are safe to repeat are retried: reads, deletes and
+// ingests that carry an idempotency key. A connection error after the server
+// accepted an event without a key would otherwise ingest it twice, so those
+// ingests, like rule creation, are sent once.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first; 1 disables retries
+	BaseDelay   time.Duration // delay before the first retry, doubled for each further retry
+	MaxDelay    time.Duration // upper bound on a single delay
+}
+
+// DefaultRetryPolicy is used when Config.Retry is the zero value.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+// ErrCircuitOpen is returned without sending a request while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerPolicy configures a CircuitBreaker.
+type BreakerPolicy struct {
+	FailureThreshold int           // consecutive transient failures that open the breaker
+	OpenDuration     time.Duration // how long the breaker rejects requests before letting one through
+}
+
+// CircuitBreaker stops sending requests to a service that keeps failing.
+// After FailureThreshold consecutive transient failures it opens and rejects
+// requests with ErrCircuitOpen for OpenDuration. It then lets a single trial
+// request through: success closes it, failure opens it again.
+//
+// A breaker is shared by every Config that points at it, so one failing
+// flow protects the others.
+type CircuitBreaker struct {
+	breaker *retry.Breaker
+}
+
+// NewCircuitBreaker creates a closed circuit breaker.
+func NewCircuitBreaker(policy BreakerPolicy) *CircuitBreaker {
+	return &CircuitBreaker{breaker: retry.NewBreaker(policy.FailureThreshold, policy.OpenDuration)}
+}
+
+// do sends the request built by newRequest, retrying transient failures of
+// idempotent requests according to c.Retry and tracking them in c.Breaker.
+// It returns the status and body of the last response; a final 5xx is
+// returned rather than turned into an error, so callers report it like any
+// unexpected status.
+func (c *Config) do(ctx context.Context, idempotent bool, newRequest func() (*http.Request, error)) (int, []byte, error) {
+	policy := c.Retry
+	if policy == (RetryPolicy{}) {
+		policy = DefaultRetryPolicy
+	}
+	if !idempotent {
+		policy.MaxAttempts = 1
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if c.Breaker != nil && !c.Breaker.breaker.Allow() {
+			return 0, nil, ErrCircuitOpen
+		}
+
+		status, body, err := send(httpClient, req)
+		if err != nil && ctx.Err() != nil {
+			// Cancelled by the caller: says nothing about the service.
+			if c.Breaker != nil {
+				c.Breaker.breaker.Release()
+			}
+			return 0, nil, err
+		}
+		transient := err != nil || status >= http.StatusInternalServerError
+		if c.Breaker != nil {
+			c.Breaker.breaker.Record(!transient)
+		}
+		if !transient || attempt >= policy.MaxAttempts {
+			return status, body, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		case <-time.After(retry.Backoff(policy.BaseDelay, policy.MaxDelay, attempt)):
+		}
+	}
+}
+
+// send makes a single attempt and reads the whole response body.
+func send(httpClient *http.Client, req *http.Request) (int, []byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+// flakyServer fails the first `failures` requests with status, then accepts.
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":"try again"}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"event_id":"e1","status":"accepted"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// ingest sends an event with an idempotency key, so it may be retried.
+func ingest(cfg *Config) (*IngestResponse, error) {
+	return IngestEvent(context.Background(), cfg, &IngestRequest{
+		EventType:      "sensor.reading",
+		AggregateID:    "d1",
+		Payload:        map[string]any{},
+		IdempotencyKey: "k1",
+	})
+}
+
+func TestIngestEvent_RetriesServerErrors(t *testing.T) {
+	srv, calls := flakyServer(t, 2, http.StatusServiceUnavailable)
+
+	resp, err := ingest(&Config{IngestionURL: srv.URL, Retry: fastRetry})
+
+	require.NoError(t, err)
+	assert.Equal(t, "e1", resp.EventID)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestIngestEvent_SendsWithoutKeyOnce(t *testing.T) {
+	srv, calls := flakyServer(t, 2, http.StatusServiceUnavailable)
+
+	_, err := IngestEvent(context.Background(), &Config{IngestionURL: srv.URL, Retry: fastRetry},
+		&IngestRequest{EventType: "sensor.reading", AggregateID: "d1", Payload: map[string]any{}})
+
+	assert.EqualError(t, err, "unexpected status 503: try again")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIngestEvent_IdempotencyKeyUsesV2(t *testing.T) {
+	var path string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"event_id":"e0","status":"duplicate"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	resp, err := ingest(&Config{IngestionURL: srv.URL, Retry: fastRetry})
+
+	require.NoError(t, err)
+	assert.Equal(t, &IngestResponse{EventID: "e0", Status: "duplicate"}, resp)
+	assert.Equal(t, "/api/v2/events", path)
+	assert.Equal(t, map[string]any{
+		"event_type":      "sensor.reading",
+		"aggregate_id":    "d1",
+		"schema_version":  float64(1),
+		"idempotency_key": "k1",
+		"payload":         map[string]any{},
+	}, body)
+}
+
+func TestIngestEvent_GivesUpAfterMaxAttempts(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusInternalServerError)
+
+	_, err := ingest(&Config{IngestionURL: srv.URL, Retry: fastRetry})
+
+	assert.EqualError(t, err, "unexpected status 500: try again")
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestIngestEvent_DoesNotRetryClientErrors(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusBadRequest)
+
+	_, err := ingest(&Config{IngestionURL: srv.URL, Retry: fastRetry})
+
+	assert.EqualError(t, err, "unexpected status 400: try again")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIngestEvent_RetriesConnectionErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // connections are refused
+
+	breaker := NewCircuitBreaker(BreakerPolicy{FailureThreshold: 3, OpenDuration: time.Minute})
+	cfg := &Config{IngestionURL: srv.URL, Retry: fastRetry, Breaker: breaker}
+	_, err := ingest(cfg)
+	assert.ErrorContains(t, err, "failed to send request")
+
+	// All three attempts failed, which opens the breaker
+	_, err = ingest(cfg)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestDo_StopsOnCancelledContext(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusBadGateway)
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg := &Config{
+		IngestionURL: srv.URL,
+		Retry:        RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour},
+		HTTPClient:   &http.Client{Transport: cancelAfter(cancel)},
+	}
+
+	_, err := IngestEvent(ctx, cfg, &IngestRequest{EventType: "sensor.reading", AggregateID: "d1", IdempotencyKey: "k1"})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// cancelAfter returns a transport that cancels the request's context once the
+// response has arrived.
+func cancelAfter(cancel context.CancelFunc) http.RoundTripper {
+	return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		cancel()
+		return resp, err
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestIngestEvent_FailsFastWhileBreakerOpen(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusServiceUnavailable)
+	breaker := NewCircuitBreaker(BreakerPolicy{FailureThreshold: 2, OpenDuration: time.Minute})
+	cfg := &Config{IngestionURL: srv.URL, Retry: fastRetry, Breaker: breaker}
+
+	_, err := ingest(cfg)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load(), "the third attempt is rejected by the open breaker")
+
+	_, err = ingest(cfg)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load())
+}
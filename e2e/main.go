@@ -4,34 +4,121 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/cornjacket/platform-services/e2e/runner"
 	_ "github.com/cornjacket/platform-services/e2e/tests" // Register all tests
 )
 
 func main() {
-	env := flag.String("env", "local", "Environment (local, dev, staging)")
+	env := flag.String("env", "local", "Environment: local, dev, staging, or one from -env-file")
+	envFile := flag.String("env-file", os.Getenv("E2E_ENV_FILE"), "YAML file adding or overriding environments (default $E2E_ENV_FILE)")
 	testName := flag.String("test", "", "Specific test to run (runs all if empty)")
 	list := flag.Bool("list", false, "List available tests")
+	tags := flag.String("tags", "", "Comma-separated tags to run, e.g. smoke; !TAG excludes a tag (runs all if empty)")
+	parallel := flag.Int("parallel", 1, "Number of tests to run concurrently")
+	timeout := flag.Duration("timeout", 0, "Timeout of each test attempt, for tests without their own (default the environment's, 30s)")
+	suiteTimeout := flag.Duration("suite-timeout", 0, "Deadline for the whole run; tests not finished by then fail (none if 0)")
+	retries := flag.Int("retries", 0, "Times to retry each failing test (tests may set more)")
+	quarantine := flag.String("quarantine", "", "Comma-separated tests whose failures do not fail the run (default $E2E_QUARANTINE)")
+	outputFormat := flag.String("output", "", "Machine-readable results: junit or json (none if empty)")
+	outFile := flag.String("out-file", "", "File for -output results (stdout if empty or -)")
 	flag.Parse()
 
+	tagFilter, err := runner.ParseTagFilter(*tags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -tags: %v\n", err)
+		os.Exit(2)
+	}
+	if *testName != "" && *tags != "" {
+		fmt.Fprintln(os.Stderr, "Error: -test and -tags cannot be combined")
+		os.Exit(2)
+	}
+
 	// List tests and exit
 	if *list {
-		runner.ListTests()
+		runner.ListTests(tagFilter)
 		os.Exit(0)
 	}
+	if *testName == "" && len(runner.SelectTests(tagFilter)) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no tests match -tags=%s\n", *tags)
+		os.Exit(1)
+	}
+
+	if *retries < 0 {
+		fmt.Fprintln(os.Stderr, "Error: -retries must not be negative")
+		os.Exit(2)
+	}
+
+	if *timeout < 0 || *suiteTimeout < 0 {
+		fmt.Fprintln(os.Stderr, "Error: -timeout and -suite-timeout must not be negative")
+		os.Exit(2)
+	}
+
+	if *outputFormat != "" && !runner.ValidFormat(*outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown -output %q (expected junit or json)\n", *outputFormat)
+		os.Exit(2)
+	}
+	if *outFile != "" && *outputFormat == "" {
+		fmt.Fprintln(os.Stderr, "Error: -out-file requires -output")
+		os.Exit(2)
+	}
+
+	// A report on stdout moves the progress output to stderr
+	var progress io.Writer = os.Stdout
+	if *outputFormat != "" && (*outFile == "" || *outFile == "-") {
+		progress = os.Stderr
+		runner.SetOutput(progress)
+	}
 
 	// Load configuration
-	cfg := runner.LoadConfig(*env)
+	cfg, err := runner.LoadConfig(*env, *envFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	cfg.Parallel = *parallel
+	cfg.Tags = tagFilter
+	cfg.Retries = *retries
+	if *timeout > 0 {
+		cfg.Timeout = *timeout
+	}
+	cfg.SuiteTimeout = *suiteTimeout
+	if *quarantine != "" {
+		cfg.Quarantine = runner.ParseTestNames(*quarantine)
+	}
+	for _, name := range cfg.Quarantine {
+		if _, ok := runner.GetTest(name); !ok {
+			fmt.Fprintf(os.Stderr, "Warning: quarantined test %q does not exist\n", name)
+		}
+	}
 
-	fmt.Printf("E2E Test Runner\n")
-	fmt.Printf("Environment: %s\n", cfg.Env)
-	fmt.Printf("Ingestion:   %s\n", cfg.IngestionURL)
-	fmt.Printf("Query:       %s\n", cfg.QueryURL)
-	fmt.Println("─────────────────────────────────────────")
+	fmt.Fprintf(progress, "E2E Test Runner\n")
+	fmt.Fprintf(progress, "Environment: %s\n", cfg.Env)
+	fmt.Fprintf(progress, "Ingestion:   %s\n", cfg.IngestionURL)
+	fmt.Fprintf(progress, "Query:       %s\n", cfg.QueryURL)
+	if *tags != "" {
+		fmt.Fprintf(progress, "Tags:        %s\n", cfg.Tags)
+	}
+	if cfg.Parallel > 1 {
+		fmt.Fprintf(progress, "Parallel:    %d\n", cfg.Parallel)
+	}
+	fmt.Fprintf(progress, "Timeout:     %s per test\n", cfg.Timeout)
+	if cfg.SuiteTimeout > 0 {
+		fmt.Fprintf(progress, "Suite:       %s deadline\n", cfg.SuiteTimeout)
+	}
+	if cfg.Retries > 0 {
+		fmt.Fprintf(progress, "Retries:     %d\n", cfg.Retries)
+	}
+	if len(cfg.Quarantine) > 0 {
+		fmt.Fprintf(progress, "Quarantine:  %s\n", strings.Join(cfg.Quarantine, ", "))
+	}
+	fmt.Fprintln(progress, "─────────────────────────────────────────")
 
 	// Create context with signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -41,34 +128,59 @@ func main() {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Println("\nInterrupted, stopping tests...")
+		fmt.Fprintln(progress, "\nInterrupted, stopping tests...")
 		cancel()
 	}()
 
-	var exitCode int
+	var results []*runner.Result
+	start := time.Now()
 
 	if *testName != "" {
 		// Run single test
-		result, err := runner.RunSingle(ctx, *testName, cfg)
+		var err error
+		results, err = runner.RunSingle(ctx, *testName, cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		if !result.Passed {
-			exitCode = 1
-		}
 	} else {
-		// Run all tests
-		results := runner.RunAll(ctx, cfg)
+		// Run all tests, or those selected by -tags
+		results = runner.RunAll(ctx, cfg)
 		runner.PrintSummary(results)
+	}
 
-		for _, r := range results {
-			if !r.Passed {
-				exitCode = 1
-				break
-			}
+	// Quarantined failures are reported but do not fail the run
+	var exitCode int
+	for _, r := range results {
+		if r.FailsRun() {
+			exitCode = 1
+			break
+		}
+	}
+
+	if *outputFormat != "" {
+		if err := writeReport(*outputFormat, *outFile, cfg, results, time.Since(start)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write %s results: %v\n", *outputFormat, err)
+			exitCode = 1
 		}
 	}
 
 	os.Exit(exitCode)
 }
+
+// writeReport writes the results in format to path, or to stdout when path
+// is empty or "-".
+func writeReport(format, path string, cfg *runner.Config, results []*runner.Result, elapsed time.Duration) error {
+	if path == "" || path == "-" {
+		return runner.WriteReport(os.Stdout, format, cfg, results, elapsed)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := runner.WriteReport(f, format, cfg, results, elapsed); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
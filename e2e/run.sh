@@ -3,8 +3,17 @@ set -e
 
 # Default environment
 ENV="local"
+ENV_FILE=""
 TEST=""
 LIST=""
+PARALLEL=""
+OUTPUT=""
+OUT_FILE=""
+TAGS=""
+RETRIES=""
+TIMEOUT=""
+SUITE_TIMEOUT=""
+QUARANTINE=""
 
 # Parse arguments
 while [[ $# -gt 0 ]]; do
@@ -13,6 +22,14 @@ while [[ $# -gt 0 ]]; do
             ENV="${1#*=}"
             shift
             ;;
+        -env-file=*|--env-file=*)
+            ENV_FILE="${1#*=}"
+            shift
+            ;;
+        -env-file|--env-file)
+            ENV_FILE="$2"
+            shift 2
+            ;;
         -test=*)
             TEST="${1#*=}"
             shift
@@ -21,21 +38,113 @@ while [[ $# -gt 0 ]]; do
             LIST="true"
             shift
             ;;
+        -parallel=*|--parallel=*)
+            PARALLEL="${1#*=}"
+            shift
+            ;;
+        -parallel|--parallel)
+            PARALLEL="$2"
+            shift 2
+            ;;
+        -tags=*|--tags=*)
+            TAGS="${1#*=}"
+            shift
+            ;;
+        -tags|--tags)
+            TAGS="$2"
+            shift 2
+            ;;
+        -timeout=*|--timeout=*)
+            TIMEOUT="${1#*=}"
+            shift
+            ;;
+        -timeout|--timeout)
+            TIMEOUT="$2"
+            shift 2
+            ;;
+        -suite-timeout=*|--suite-timeout=*)
+            SUITE_TIMEOUT="${1#*=}"
+            shift
+            ;;
+        -suite-timeout|--suite-timeout)
+            SUITE_TIMEOUT="$2"
+            shift 2
+            ;;
+        -retries=*|--retries=*)
+            RETRIES="${1#*=}"
+            shift
+            ;;
+        -retries|--retries)
+            RETRIES="$2"
+            shift 2
+            ;;
+        -quarantine=*|--quarantine=*)
+            QUARANTINE="${1#*=}"
+            shift
+            ;;
+        -quarantine|--quarantine)
+            QUARANTINE="$2"
+            shift 2
+            ;;
+        -output=*|--output=*)
+            OUTPUT="${1#*=}"
+            shift
+            ;;
+        -output|--output)
+            OUTPUT="$2"
+            shift 2
+            ;;
+        -out-file=*|--out-file=*)
+            OUT_FILE="${1#*=}"
+            shift
+            ;;
+        -out-file|--out-file)
+            OUT_FILE="$2"
+            shift 2
+            ;;
         -h|--help)
             echo "Usage: $0 [options]"
             echo ""
             echo "Options:"
-            echo "  -env=ENV    Environment to run against (local, dev, staging)"
+            echo "  -env=ENV    Environment to run against (local, dev, staging,"
+            echo "              or one defined in the -env-file)"
             echo "              Default: local"
+            echo "  -env-file=F YAML file adding or overriding environments"
+            echo "              Default: \$E2E_ENV_FILE"
             echo "  -test=NAME  Run a specific test by name"
             echo "              Default: run all tests"
             echo "  -list       List available tests"
+            echo "  -tags=LIST  Run tests with any of these tags; !TAG skips a tag"
+            echo "              Default: run all tests"
+            echo "  -parallel=N Run up to N tests concurrently"
+            echo "              Default: 1 (one at a time)"
+            echo "  -timeout=D  Timeout of each test attempt, e.g. 10s"
+            echo "              Default: the environment's (30s); tests may set their own"
+            echo "  -suite-timeout=D"
+            echo "              Deadline for the whole run, e.g. 15m"
+            echo "              Default: none"
+            echo "  -retries=N  Retry each failing test up to N times"
+            echo "              Default: 0 (tests may set their own)"
+            echo "  -quarantine=LIST"
+            echo "              Tests whose failures do not fail the run"
+            echo "              Default: \$E2E_QUARANTINE"
+            echo "  -output=FMT Write machine-readable results (junit, json)"
+            echo "  -out-file=F File for -output results"
+            echo "              Default: stdout, with progress on stderr"
             echo "  -h, --help  Show this help message"
             echo ""
             echo "Examples:"
             echo "  $0                      # Run all tests against local"
             echo "  $0 -env=dev             # Run all tests against dev"
+            echo "  $0 -env=preview-42 --env-file environments.yaml"
             echo "  $0 -test=ingest-event   # Run single test"
+            echo "  $0 --tags smoke         # Run the smoke tests"
+            echo "  $0 --tags '!slow'       # Run all but the slow tests"
+            echo "  $0 --tags chaos         # Run the chaos tests (local only)"
+            echo "  $0 --parallel 4         # Run all tests, four at a time"
+            echo "  $0 --tags smoke --timeout 10s --suite-timeout 2m"
+            echo "  $0 --retries 2 --quarantine full-flow"
+            echo "  $0 --output junit --out-file results.xml"
             echo "  $0 -list                # List available tests"
             exit 0
             ;;
@@ -47,25 +156,15 @@ while [[ $# -gt 0 ]]; do
     esac
 done
 
-# Set environment-specific variables
-case $ENV in
-    local)
-        export E2E_INGESTION_URL="http://localhost:8080"
-        export E2E_QUERY_URL="http://localhost:8081"
-        ;;
-    dev)
-        export E2E_INGESTION_URL="${E2E_INGESTION_URL:-https://api-dev.cornjacket.com}"
-        export E2E_QUERY_URL="${E2E_QUERY_URL:-https://query-dev.cornjacket.com}"
-        ;;
-    staging)
-        export E2E_INGESTION_URL="${E2E_INGESTION_URL:-https://api-staging.cornjacket.com}"
-        export E2E_QUERY_URL="${E2E_QUERY_URL:-https://query-staging.cornjacket.com}"
-        ;;
-    *)
-        echo "Unknown environment: $ENV"
-        echo "Valid environments: local, dev, staging"
-        exit 1
-        ;;
+# Resolve relative -out-file and -env-file paths against the caller's
+# directory before leaving it
+case $OUT_FILE in
+    ""|/*|-) ;;
+    *) OUT_FILE="$PWD/$OUT_FILE" ;;
+esac
+case $ENV_FILE in
+    ""|/*) ;;
+    *) ENV_FILE="$PWD/$ENV_FILE" ;;
 esac
 
 # Change to e2e directory
@@ -73,12 +172,39 @@ cd "$(dirname "$0")"
 
 # Build arguments
 ARGS="-env=$ENV"
+if [ -n "$ENV_FILE" ]; then
+    ARGS="$ARGS -env-file=$ENV_FILE"
+fi
 if [ -n "$TEST" ]; then
     ARGS="$ARGS -test=$TEST"
 fi
+if [ -n "$PARALLEL" ]; then
+    ARGS="$ARGS -parallel=$PARALLEL"
+fi
+if [ -n "$TIMEOUT" ]; then
+    ARGS="$ARGS -timeout=$TIMEOUT"
+fi
+if [ -n "$SUITE_TIMEOUT" ]; then
+    ARGS="$ARGS -suite-timeout=$SUITE_TIMEOUT"
+fi
+if [ -n "$RETRIES" ]; then
+    ARGS="$ARGS -retries=$RETRIES"
+fi
+if [ -n "$QUARANTINE" ]; then
+    ARGS="$ARGS -quarantine=$QUARANTINE"
+fi
+if [ -n "$OUTPUT" ]; then
+    ARGS="$ARGS -output=$OUTPUT"
+fi
+if [ -n "$OUT_FILE" ]; then
+    ARGS="$ARGS -out-file=$OUT_FILE"
+fi
 if [ -n "$LIST" ]; then
     ARGS="-list"
 fi
+if [ -n "$TAGS" ]; then
+    ARGS="$ARGS -tags=$TAGS"
+fi
 
 # Run the test runner
 exec go run . $ARGS
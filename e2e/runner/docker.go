@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Docker pauses and resumes the containers of the local docker compose
+// environment, and runs commands in them, for chaos tests. It runs the
+// docker CLI.
+type Docker struct {
+	// run executes a docker command and returns its combined output.
+	run func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// NewDocker creates a Docker using the docker CLI on the PATH.
+func NewDocker() *Docker {
+	return &Docker{run: func(ctx context.Context, args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, "docker", args...).CombinedOutput()
+	}}
+}
+
+// Available returns an error if the docker daemon cannot be reached.
+func (d *Docker) Available(ctx context.Context) error {
+	if _, err := d.docker(ctx, "version", "--format", "{{.Server.Version}}"); err != nil {
+		return fmt.Errorf("docker is not available: %w", err)
+	}
+	return nil
+}
+
+// State returns a container's status: "running", "paused", "exited" and so on.
+func (d *Docker) State(ctx context.Context, container string) (string, error) {
+	out, err := d.docker(ctx, "inspect", "--format", "{{.State.Status}}", container)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Pause freezes a running container's processes. Its connections stay open
+// but nothing answers, like a hung dependency.
+func (d *Docker) Pause(ctx context.Context, container string) error {
+	state, err := d.State(ctx, container)
+	if err != nil {
+		return err
+	}
+	if state != "running" {
+		return fmt.Errorf("cannot pause container %s: it is %s", container, state)
+	}
+	_, err = d.docker(ctx, "pause", container)
+	return err
+}
+
+// Unpause resumes a paused container. A container that is not paused is left
+// alone, so Unpause is safe in cleanups.
+func (d *Docker) Unpause(ctx context.Context, container string) error {
+	state, err := d.State(ctx, container)
+	if err != nil {
+		return err
+	}
+	if state != "paused" {
+		return nil
+	}
+	_, err = d.docker(ctx, "unpause", container)
+	return err
+}
+
+// Exec runs a command in a running container and returns its trimmed
+// output, e.g. psql in the Postgres container.
+func (d *Docker) Exec(ctx context.Context, container string, command ...string) (string, error) {
+	out, err := d.docker(ctx, append([]string{"exec", container}, command...)...)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// docker runs a docker command, returning its trimmed output. Errors carry
+// the output, which holds docker's message.
+func (d *Docker) docker(ctx context.Context, args ...string) ([]byte, error) {
+	out, err := d.run(ctx, args...)
+	out = bytes.TrimSpace(out)
+	if err != nil {
+		return nil, fmt.Errorf("docker %s: %w: %s", strings.Join(args, " "), err, out)
+	}
+	return out, nil
+}
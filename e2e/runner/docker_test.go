@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker answers inspect with state and records the other commands.
+func fakeDocker(state string, commands *[]string) *Docker {
+	return &Docker{run: func(ctx context.Context, args ...string) ([]byte, error) {
+		switch args[0] {
+		case "inspect":
+			if state == "" {
+				return []byte("Error: No such object: " + args[len(args)-1] + "\n"), errors.New("exit status 1")
+			}
+			return []byte(state + "\n"), nil
+		default:
+			*commands = append(*commands, strings.Join(args, " "))
+			return []byte(args[len(args)-1] + "\n"), nil
+		}
+	}}
+}
+
+func TestDocker_Pause(t *testing.T) {
+	var commands []string
+	require.NoError(t, fakeDocker("running", &commands).Pause(context.Background(), "cornjacket-redpanda"))
+	assert.Equal(t, []string{"pause cornjacket-redpanda"}, commands)
+
+	err := fakeDocker("exited", &commands).Pause(context.Background(), "cornjacket-redpanda")
+	assert.EqualError(t, err, "cannot pause container cornjacket-redpanda: it is exited")
+
+	err = fakeDocker("", &commands).Pause(context.Background(), "missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No such object: missing")
+}
+
+func TestDocker_Unpause(t *testing.T) {
+	var commands []string
+	require.NoError(t, fakeDocker("paused", &commands).Unpause(context.Background(), "cornjacket-postgres"))
+	require.NoError(t, fakeDocker("running", &commands).Unpause(context.Background(), "cornjacket-postgres"))
+	assert.Equal(t, []string{"unpause cornjacket-postgres"}, commands, "only a paused container is unpaused")
+}
+
+func TestDocker_Exec(t *testing.T) {
+	var commands []string
+	out, err := fakeDocker("running", &commands).Exec(context.Background(), "cornjacket-postgres", "psql", "-c", "SELECT 1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1", out)
+	assert.Equal(t, []string{"exec cornjacket-postgres psql -c SELECT 1"}, commands)
+}
@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed environments.yaml
+var builtinEnvironments []byte
+
+// Environment is an environment's entry in an environments file. Empty
+// fields keep the value of the entry it overrides.
+type Environment struct {
+	IngestionURL string        `yaml:"ingestion_url"`
+	QueryURL     string        `yaml:"query_url"`
+	ActionsURL   string        `yaml:"actions_url"`
+	AdminURL     string        `yaml:"admin_url"`
+	APIKey       string        `yaml:"api_key"` // test traffic key; reference secrets as ${VAR}
+	Timeout      time.Duration `yaml:"timeout"` // per test attempt, e.g. 45s
+	WebhookHost  string        `yaml:"webhook_host"`
+}
+
+// environmentsFile is the layout of an environments file. Keys are
+// environment names, or patterns such as "preview-*" matching any name, with
+// {env} in values replaced by the name.
+type environmentsFile struct {
+	Environments map[string]Environment `yaml:"environments"`
+}
+
+// parseEnvironments parses an environments file. Unknown keys are errors,
+// so a misspelt setting is not silently ignored.
+func parseEnvironments(data []byte, source string) (map[string]Environment, error) {
+	var f environmentsFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("invalid environments file %s: %w", source, err)
+	}
+	for name := range f.Environments {
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, fmt.Errorf("invalid environments file %s: bad pattern %q", source, name)
+		}
+	}
+	return f.Environments, nil
+}
+
+// loadEnvironments returns the built-in environments overridden field by
+// field by those in the file at filename, if any.
+func loadEnvironments(filename string) (map[string]Environment, error) {
+	envs, err := parseEnvironments(builtinEnvironments, "(built-in)")
+	if err != nil {
+		return nil, err
+	}
+	if filename == "" {
+		return envs, nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environments file: %w", err)
+	}
+	overrides, err := parseEnvironments(data, filename)
+	if err != nil {
+		return nil, err
+	}
+	for name, o := range overrides {
+		envs[name] = envs[name].merge(o)
+	}
+	return envs, nil
+}
+
+// merge returns e with the fields set in o replaced.
+func (e Environment) merge(o Environment) Environment {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&e.IngestionURL, o.IngestionURL)
+	set(&e.QueryURL, o.QueryURL)
+	set(&e.ActionsURL, o.ActionsURL)
+	set(&e.AdminURL, o.AdminURL)
+	set(&e.APIKey, o.APIKey)
+	set(&e.WebhookHost, o.WebhookHost)
+	if o.Timeout > 0 {
+		e.Timeout = o.Timeout
+	}
+	return e
+}
+
+// resolveEnvironment finds name among envs: an exact entry, or else the
+// first matching pattern in sorted order. References to environment
+// variables, and {env} in pattern entries, are expanded.
+func resolveEnvironment(envs map[string]Environment, name string) (Environment, error) {
+	e, ok := envs[name]
+	if !ok {
+		patterns := make([]string, 0, len(envs))
+		for key := range envs {
+			if strings.Contains(key, "*") {
+				patterns = append(patterns, key)
+			}
+		}
+		slices.Sort(patterns)
+		for _, p := range patterns {
+			if matched, _ := path.Match(p, name); matched {
+				e, ok = envs[p], true
+				break
+			}
+		}
+	}
+	if !ok {
+		names := make([]string, 0, len(envs))
+		for key := range envs {
+			names = append(names, key)
+		}
+		slices.Sort(names)
+		return Environment{}, fmt.Errorf("unknown environment %q (known: %s)", name, strings.Join(names, ", "))
+	}
+
+	expand := func(s string) string {
+		return os.ExpandEnv(strings.ReplaceAll(s, "{env}", name))
+	}
+	e.IngestionURL = expand(e.IngestionURL)
+	e.QueryURL = expand(e.QueryURL)
+	e.ActionsURL = expand(e.ActionsURL)
+	e.AdminURL = expand(e.AdminURL)
+	e.APIKey = expand(e.APIKey)
+	e.WebhookHost = expand(e.WebhookHost)
+	if e.IngestionURL == "" || e.QueryURL == "" {
+		return Environment{}, fmt.Errorf("environment %q has no ingestion_url or query_url", name)
+	}
+	return e, nil
+}
@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "environments.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// unsetURLOverrides keeps the caller's E2E_* variables out of the test.
+func unsetURLOverrides(t *testing.T) {
+	for _, key := range []string{"E2E_INGESTION_URL", "E2E_QUERY_URL", "E2E_ACTIONS_URL", "E2E_ADMIN_URL", "E2E_API_KEY"} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestLoadConfig_Builtin(t *testing.T) {
+	unsetURLOverrides(t)
+	cfg, err := LoadConfig("staging", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api-staging.cornjacket.com", cfg.IngestionURL)
+	assert.Equal(t, "https://query-staging.cornjacket.com", cfg.QueryURL)
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+
+	cfg, err = LoadConfig("local", "")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8083", cfg.ActionsURL)
+	assert.Equal(t, "http://localhost:8084", cfg.AdminURL)
+}
+
+func TestLoadConfig_EnvFile(t *testing.T) {
+	unsetURLOverrides(t)
+	t.Setenv("PREVIEW_API_KEY", "secret")
+	path := writeEnvFile(t, `
+environments:
+  staging:
+    timeout: 45s
+  perf:
+    ingestion_url: https://api-perf.example.com
+    query_url: https://query-perf.example.com
+  preview-*:
+    ingestion_url: https://api-{env}.preview.example.com
+    query_url: https://query-{env}.preview.example.com
+    api_key: ${PREVIEW_API_KEY}
+`)
+
+	cfg, err := LoadConfig("staging", path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api-staging.cornjacket.com", cfg.IngestionURL, "unset fields keep the built-in value")
+	assert.Equal(t, 45*time.Second, cfg.Timeout)
+
+	cfg, err = LoadConfig("perf", path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://query-perf.example.com", cfg.QueryURL)
+
+	cfg, err = LoadConfig("preview-42", path)
+	require.NoError(t, err)
+	assert.Equal(t, "preview-42", cfg.Env)
+	assert.Equal(t, "https://api-preview-42.preview.example.com", cfg.IngestionURL)
+	assert.Equal(t, "https://query-preview-42.preview.example.com", cfg.QueryURL)
+	assert.Equal(t, "secret", cfg.APIKey)
+}
+
+func TestLoadConfig_EnvVarsOverride(t *testing.T) {
+	unsetURLOverrides(t)
+	t.Setenv("E2E_INGESTION_URL", "http://custom:8080")
+	cfg, err := LoadConfig("dev", "")
+	require.NoError(t, err)
+	assert.Equal(t, "http://custom:8080", cfg.IngestionURL)
+	assert.Equal(t, "https://query-dev.cornjacket.com", cfg.QueryURL)
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	_, err := LoadConfig("prod", "")
+	assert.ErrorContains(t, err, `unknown environment "prod" (known: dev, local, staging)`)
+
+	_, err = LoadConfig("local", writeEnvFile(t, "environments:\n  local:\n    ingestion: http://x\n"))
+	assert.ErrorContains(t, err, "field ingestion not found")
+
+	_, err = LoadConfig("perf", writeEnvFile(t, "environments:\n  perf:\n    query_url: http://x\n"))
+	assert.ErrorContains(t, err, `environment "perf" has no ingestion_url or query_url`)
+
+	_, err = LoadConfig("local", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read environments file")
+}
@@ -0,0 +1,225 @@
+package runner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Report formats accepted by WriteReport.
+const (
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// ValidFormat reports whether format is a WriteReport format.
+func ValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatJUnit
+}
+
+// WriteReport writes results to w in format (FormatJSON or FormatJUnit), for
+// CI systems and dashboards. elapsed is the wall-clock time of the run.
+func WriteReport(w io.Writer, format string, cfg *Config, results []*Result, elapsed time.Duration) error {
+	switch format {
+	case FormatJSON:
+		return writeJSONReport(w, cfg, results, elapsed)
+	case FormatJUnit:
+		return writeJUnitReport(w, cfg, results, elapsed)
+	default:
+		return fmt.Errorf("unknown report format %q: expected %s or %s", format, FormatJSON, FormatJUnit)
+	}
+}
+
+// JSONReport is the FormatJSON report. Failed counts the failures that fail
+// the run; quarantined failures are counted in Quarantined instead.
+type JSONReport struct {
+	Env         string       `json:"env"`
+	Passed      int          `json:"passed"`
+	Failed      int          `json:"failed"`
+	Flaky       int          `json:"flaky"`       // passed on a retry; also counted in Passed
+	Quarantined int          `json:"quarantined"` // quarantined tests that failed
+	Skipped     int          `json:"skipped"`
+	DurationMS  int64        `json:"duration_ms"` // wall-clock time of the run
+	Tests       []JSONResult `json:"tests"`
+}
+
+// JSONResult is one test's outcome in a JSONReport.
+type JSONResult struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+	Status      string   `json:"status"` // "passed", "flaky", "failed" or "skipped"
+	Quarantined bool     `json:"quarantined,omitempty"`
+	Attempts    int      `json:"attempts"`
+	DurationMS  int64    `json:"duration_ms"` // of all attempts
+	Error       string   `json:"error,omitempty"`
+
+	// RetriedErrors are the errors of the failed attempts that were retried.
+	RetriedErrors []string `json:"retried_errors,omitempty"`
+	CleanupErrors []string `json:"cleanup_errors,omitempty"`
+}
+
+func writeJSONReport(w io.Writer, cfg *Config, results []*Result, elapsed time.Duration) error {
+	report := JSONReport{Env: cfg.Env, DurationMS: elapsed.Milliseconds(), Tests: []JSONResult{}}
+	for _, r := range results {
+		result := JSONResult{
+			Name:        r.Test.Name,
+			Description: r.Test.Description,
+			Tags:        r.Test.Tags,
+			Status:      "passed",
+			Quarantined: r.Quarantined,
+			Attempts:    max(r.Attempts, 1),
+			DurationMS:  r.Duration.Milliseconds(),
+		}
+		switch {
+		case r.Skipped:
+			report.Skipped++
+			result.Status = "skipped"
+		case r.Flaky():
+			report.Passed++
+			report.Flaky++
+			result.Status = "flaky"
+		case r.Passed:
+			report.Passed++
+		case r.Quarantined:
+			report.Quarantined++
+			result.Status = "failed"
+		default:
+			report.Failed++
+			result.Status = "failed"
+		}
+		if r.Error != nil {
+			result.Error = r.Error.Error()
+		}
+		for _, err := range r.RetriedErrors {
+			result.RetriedErrors = append(result.RetriedErrors, err.Error())
+		}
+		for _, err := range r.CleanupErrors {
+			result.CleanupErrors = append(result.CleanupErrors, err.Error())
+		}
+		report.Tests = append(report.Tests, result)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// The JUnit XML elements CI systems read (Jenkins, GitLab, GitHub Actions
+// reporters). Times are in seconds. Retried attempts use Maven Surefire's
+// flakyFailure (passed on a retry) and rerunFailure (failed every attempt)
+// elements; quarantined failures are reported as skipped, like skipped tests,
+// so they do not fail the build.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+
+	FlakyFailures []junitFailure `xml:"flakyFailure"`
+	RerunFailures []junitFailure `xml:"rerunFailure"`
+	SystemErr     string         `xml:"system-err,omitempty"` // cleanup errors
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+func writeJUnitReport(w io.Writer, cfg *Config, results []*Result, elapsed time.Duration) error {
+	suite := junitTestSuite{
+		Name: "e2e",
+		Time: seconds(elapsed),
+		Properties: []junitProperty{
+			{Name: "env", Value: cfg.Env},
+			{Name: "ingestion_url", Value: cfg.IngestionURL},
+			{Name: "query_url", Value: cfg.QueryURL},
+		},
+	}
+	for _, r := range results {
+		tc := junitTestCase{Name: r.Test.Name, Classname: "e2e." + cfg.Env, Time: seconds(r.Duration)}
+		for _, err := range r.CleanupErrors {
+			tc.SystemErr += "cleanup: " + err.Error() + "\n"
+		}
+		var retried []junitFailure
+		for _, err := range r.RetriedErrors {
+			retried = append(retried, junitFailure{Message: err.Error(), Text: err.Error()})
+		}
+		switch {
+		case r.Skipped:
+			suite.Skipped++
+			tc.Skipped = &junitSkipped{Message: r.Error.Error()}
+		case r.Passed:
+			tc.FlakyFailures = retried
+		default:
+			message := "test failed"
+			if r.Error != nil {
+				message = r.Error.Error()
+			}
+			if r.Quarantined {
+				suite.Skipped++
+				tc.Skipped = &junitSkipped{Message: "quarantined: " + message}
+			} else {
+				suite.Failures++
+				tc.Failure = &junitFailure{Message: message, Text: message}
+				tc.RerunFailures = retried
+			}
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suites := junitTestSuites{
+		Name:     "e2e",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
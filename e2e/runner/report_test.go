@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reportResults() []*Result {
+	return []*Result{
+		{Test: &Test{Name: "full-flow", Description: "Complete flow"}, Passed: true, Duration: 1500 * time.Millisecond},
+		{Test: &Test{Name: "ingest-event", Description: "Ingest an event"}, Passed: false, Duration: 250 * time.Millisecond,
+			Error: errors.New(`projection not created: got status 500 <"internal">`)},
+	}
+}
+
+func TestWriteReport_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &Config{Env: "staging"}
+	require.NoError(t, WriteReport(&buf, FormatJSON, cfg, reportResults(), 2*time.Second))
+
+	var report JSONReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, "staging", report.Env)
+	assert.Equal(t, 1, report.Passed)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, int64(2000), report.DurationMS)
+	require.Len(t, report.Tests, 2)
+	assert.Equal(t, JSONResult{Name: "full-flow", Description: "Complete flow", Status: "passed", Attempts: 1, DurationMS: 1500}, report.Tests[0])
+	assert.Equal(t, "failed", report.Tests[1].Status)
+	assert.Contains(t, report.Tests[1].Error, "projection not created")
+}
+
+func TestWriteReport_JUnit(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &Config{Env: "local", IngestionURL: "http://localhost:8080", QueryURL: "http://localhost:8081"}
+	require.NoError(t, WriteReport(&buf, FormatJUnit, cfg, reportResults(), 2*time.Second))
+
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte(xml.Header)))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites), "error text must be escaped")
+	assert.Equal(t, 2, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	assert.Equal(t, "2.000", suites.Time)
+	require.Len(t, suites.Suites, 1)
+
+	suite := suites.Suites[0]
+	assert.Contains(t, suite.Properties, junitProperty{Name: "env", Value: "local"})
+	require.Len(t, suite.Cases, 2)
+	assert.Equal(t, junitTestCase{Name: "full-flow", Classname: "e2e.local", Time: "1.500"}, suite.Cases[0])
+	require.NotNil(t, suite.Cases[1].Failure)
+	assert.Equal(t, `projection not created: got status 500 <"internal">`, suite.Cases[1].Failure.Message)
+}
+
+func TestWriteReport_UnknownFormat(t *testing.T) {
+	assert.False(t, ValidFormat("tap"))
+	assert.Error(t, WriteReport(&bytes.Buffer{}, "tap", &Config{}, nil, 0))
+}
+
+func TestWriteReport_RetriesAndQuarantine(t *testing.T) {
+	retried := errors.New("projection not updated")
+	results := []*Result{
+		{Test: &Test{Name: "flaky"}, Passed: true, Attempts: 2, RetriedErrors: []error{retried}},
+		{Test: &Test{Name: "quarantined"}, Passed: false, Attempts: 1, Quarantined: true, Error: errors.New("timeout")},
+		{Test: &Test{Name: "broken"}, Passed: false, Attempts: 2, RetriedErrors: []error{retried}, Error: errors.New("still broken")},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, FormatJSON, &Config{}, results, time.Second))
+	var report JSONReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 1, report.Passed)
+	assert.Equal(t, 1, report.Flaky)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Quarantined)
+	assert.Equal(t, "flaky", report.Tests[0].Status)
+	assert.Equal(t, []string{"projection not updated"}, report.Tests[0].RetriedErrors)
+	assert.True(t, report.Tests[1].Quarantined)
+	assert.Equal(t, 2, report.Tests[2].Attempts)
+
+	buf.Reset()
+	require.NoError(t, WriteReport(&buf, FormatJUnit, &Config{Env: "dev"}, results, time.Second))
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	suite := suites.Suites[0]
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, 1, suite.Skipped)
+
+	flaky, quarantined, broken := suite.Cases[0], suite.Cases[1], suite.Cases[2]
+	assert.Nil(t, flaky.Failure)
+	require.Len(t, flaky.FlakyFailures, 1)
+	assert.Equal(t, "projection not updated", flaky.FlakyFailures[0].Message)
+	assert.Nil(t, quarantined.Failure)
+	require.NotNil(t, quarantined.Skipped)
+	assert.Equal(t, "quarantined: timeout", quarantined.Skipped.Message)
+	require.NotNil(t, broken.Failure)
+	assert.Len(t, broken.RerunFailures, 1)
+}
+
+func TestWriteReport_Skipped(t *testing.T) {
+	results := []*Result{{Test: &Test{Name: "actions-webhook"}, Skipped: true, Attempts: 1, Error: Skip("no actions service URL")}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, FormatJSON, &Config{}, results, time.Second))
+	var report JSONReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 1, report.Skipped)
+	assert.Zero(t, report.Failed)
+	assert.Equal(t, "skipped", report.Tests[0].Status)
+
+	buf.Reset()
+	require.NoError(t, WriteReport(&buf, FormatJUnit, &Config{}, results, time.Second))
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, 1, suites.Suites[0].Skipped)
+	require.NotNil(t, suites.Suites[0].Cases[0].Skipped)
+	assert.Equal(t, "skipped: no actions service URL", suites.Suites[0].Cases[0].Skipped.Message)
+}
@@ -2,9 +2,14 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,26 +18,112 @@ type Test struct {
 	Name        string
 	Description string
 	Run         func(ctx context.Context, cfg *Config) error
+	Tags        []string // e.g. TagSmoke; selected with -tags (see TagFilter)
+
+	// Timeout, if set, replaces Config.Timeout for each attempt of the test,
+	// for tests that wait out long delays on purpose.
+	Timeout time.Duration
+
+	// Retries is how many more times the test runs after failing, for tests
+	// whose eventually-consistent assertions occasionally flake on slow
+	// environments. Config.Retries raises it for every test.
+	Retries int
+
+	// Serial keeps the test from running alongside others, for tests whose
+	// assertions other tests' traffic would disturb. Serial tests run after
+	// the parallel ones, one at a time.
+	Serial bool
 }
 
 // Config holds test runner configuration.
 type Config struct {
 	IngestionURL string
 	QueryURL     string
+	ActionsURL   string
+	AdminURL     string // event handler admin API; usually reachable locally only
+	APIKey       string // marks ingested events as test traffic (see client.Config)
 	Env          string
-	Timeout      time.Duration
+	Timeout      time.Duration // per test attempt, for tests without their own (see Test.Timeout)
+	Parallel     int           // tests run at once by RunAll; 1 or less runs them one at a time
+	Tags         TagFilter     // tests RunAll runs; the zero filter runs all
+	Retries      int           // minimum retries of every failing test (see Test.Retries)
+
+	// SuiteTimeout, if set, bounds the whole run: once it passes, running
+	// tests are cancelled and the rest fail without running. Teardowns still
+	// run.
+	SuiteTimeout time.Duration
+
+	// Quarantine names tests that run and are reported, but whose failures
+	// do not fail the run: known flakes awaiting a fix.
+	Quarantine []string
+
+	// The local environment's containers, which chaos tests pause (see
+	// Docker).
+	PostgresContainer string
+	RedpandaContainer string
+
+	// Webhook receives the actions service's webhook deliveries (see
+	// WebhookReceiver).
+	Webhook WebhookConfig
+}
+
+// Quarantined reports whether the named test is in cfg.Quarantine.
+func (cfg *Config) Quarantined(name string) bool {
+	return slices.Contains(cfg.Quarantine, name)
 }
 
 // Result represents the outcome of a test run.
 type Result struct {
 	Test     *Test
-	Passed   bool
-	Duration time.Duration
-	Error    error
+	Passed   bool          // the last attempt passed
+	Duration time.Duration // of all attempts
+	Error    error         // of the last attempt
+
+	Attempts      int     // runs of the test, retries included
+	RetriedErrors []error // errors of the failed attempts that were retried
+	Quarantined   bool    // a failure does not fail the run (Config.Quarantine)
+	Skipped       bool    // the test returned Skip; Error holds the reason
+	CleanupErrors []error // of the cleanups registered with Cleanup; do not fail the test
+}
+
+// Flaky reports whether the test passed only on a retry.
+func (r *Result) Flaky() bool {
+	return r.Passed && len(r.RetriedErrors) > 0
+}
+
+// FailsRun reports whether the result fails the run: a failure of a test
+// that is neither quarantined nor skipped.
+func (r *Result) FailsRun() bool {
+	return !r.Passed && !r.Quarantined && !r.Skipped
+}
+
+// skipError is returned by Skip.
+type skipError struct {
+	reason string
+}
+
+func (e *skipError) Error() string {
+	return "skipped: " + e.reason
+}
+
+// Skip returns an error that, returned from Test.Run (directly or wrapped),
+// reports the test as skipped rather than failed: for tests the environment
+// cannot support. Skipped tests are not retried.
+func Skip(format string, args ...any) error {
+	return &skipError{reason: fmt.Sprintf(format, args...)}
 }
 
 var registry = make(map[string]*Test)
 
+// output receives the human-readable progress and summary.
+var output io.Writer = os.Stdout
+
+// SetOutput sends the human-readable progress and summary to w, such as
+// stderr when stdout carries a machine-readable report.
+func SetOutput(w io.Writer) {
+	output = w
+}
+
 // Register adds a test to the registry (called from test init()).
 func Register(t *Test) {
 	if _, exists := registry[t.Name]; exists {
@@ -59,144 +150,282 @@ func GetAllTests() []*Test {
 	return tests
 }
 
-// ListTests prints all available tests.
-func ListTests() {
-	tests := GetAllTests()
+// ListTests prints the available tests f selects, with their tags.
+func ListTests(f TagFilter) {
+	tests := SelectTests(f)
 	fmt.Println("Available tests:")
 	for _, t := range tests {
-		fmt.Printf("  %-25s %s\n", t.Name, t.Description)
+		tags := ""
+		if len(t.Tags) > 0 {
+			tags = " [" + strings.Join(t.Tags, ", ") + "]"
+		}
+		fmt.Printf("  %-25s %s%s\n", t.Name, t.Description, tags)
 	}
 }
 
-// RunTest executes a single test and returns the result.
+// RunTest executes a single test and returns the result. A failing test is
+// retried up to the larger of t.Retries and cfg.Retries times, each attempt
+// with its own timeout, unless ctx is done. The cleanups an attempt registers
+// run before the next one. A test whose ctx is done before it starts fails
+// without running.
 func RunTest(ctx context.Context, t *Test, cfg *Config) *Result {
-	start := time.Now()
+	result := &Result{Test: t, Quarantined: cfg.Quarantined(t.Name)}
+	if err := context.Cause(ctx); err != nil {
+		result.Error = fmt.Errorf("not run: %w", err)
+		return result
+	}
+	retries := max(t.Retries, cfg.Retries)
+	timeout := cfg.Timeout
+	if t.Timeout > 0 {
+		timeout = t.Timeout
+	}
 
-	// Create context with timeout
-	testCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
-	defer cancel()
+	for {
+		result.Attempts++
+		start := time.Now()
 
-	err := t.Run(testCtx, cfg)
-	duration := time.Since(start)
+		// Create context with timeout
+		c := &cleanups{}
+		testCtx, cancel := context.WithTimeout(context.WithValue(ctx, cleanupsKey{}, c), timeout)
+		err := t.Run(testCtx, cfg)
+		cancel()
+		result.CleanupErrors = append(result.CleanupErrors, c.run(ctx, cfg)...)
+		result.Duration += time.Since(start)
 
-	return &Result{
-		Test:     t,
-		Passed:   err == nil,
-		Duration: duration,
-		Error:    err,
+		if err == nil {
+			result.Passed = true
+			return result
+		}
+		if skip := (*skipError)(nil); errors.As(err, &skip) {
+			result.Skipped = true
+			result.Error = err
+			return result
+		}
+		if result.Attempts > retries || ctx.Err() != nil {
+			// Name the suite timeout rather than leave a bare deadline error
+			if cause := context.Cause(ctx); cause != nil && cause != ctx.Err() {
+				err = fmt.Errorf("%w (%w)", err, cause)
+			}
+			result.Error = err
+			return result
+		}
+		result.RetriedErrors = append(result.RetriedErrors, err)
 	}
 }
 
-// RunAll executes all registered tests and returns results.
+// RunAll executes the registered tests selected by cfg.Tags, between the
+// BeforeAll and AfterAll hooks, and returns results in name order, followed
+// by any failed teardowns.
+// With cfg.Parallel above 1, up to that many tests run concurrently; each
+// result is printed as its test finishes.
 func RunAll(ctx context.Context, cfg *Config) []*Result {
-	tests := GetAllTests()
-	results := make([]*Result, 0, len(tests))
+	return runSuite(ctx, SelectTests(cfg.Tags), cfg)
+}
 
-	for _, t := range tests {
-		result := RunTest(ctx, t, cfg)
-		results = append(results, result)
+func runTests(ctx context.Context, tests []*Test, cfg *Config) []*Result {
+	results := make([]*Result, len(tests))
+	if cfg.Parallel <= 1 {
+		for i, t := range tests {
+			results[i] = RunTest(ctx, t, cfg)
+			printResult(results[i])
+		}
+		return results
+	}
+
+	var mu sync.Mutex // keeps printed results from interleaving
+	run := func(i int) {
+		result := RunTest(ctx, tests[i], cfg)
+		mu.Lock()
+		defer mu.Unlock()
+		results[i] = result
 		printResult(result)
 	}
 
+	slots := make(chan struct{}, cfg.Parallel)
+	var wg sync.WaitGroup
+	for i, t := range tests {
+		if t.Serial {
+			continue
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			run(i)
+		}()
+	}
+	wg.Wait()
+
+	for i, t := range tests {
+		if t.Serial {
+			run(i)
+		}
+	}
 	return results
 }
 
-// RunSingle executes a single test by name.
-func RunSingle(ctx context.Context, name string, cfg *Config) (*Result, error) {
+// RunSingle executes a single test by name, between the BeforeAll and
+// AfterAll hooks. The test's result comes first, followed by any failed
+// teardowns.
+func RunSingle(ctx context.Context, name string, cfg *Config) ([]*Result, error) {
 	t, ok := GetTest(name)
 	if !ok {
 		return nil, fmt.Errorf("unknown test: %s", name)
 	}
-
-	result := RunTest(ctx, t, cfg)
-	printResult(result)
-	return result, nil
+	return runSuite(ctx, []*Test{t}, cfg), nil
 }
 
 func printResult(r *Result) {
 	status := "✓ PASS"
-	if !r.Passed {
+	switch {
+	case r.Skipped:
+		status = "- SKIP"
+	case r.Flaky():
+		status = "~ FLAKY"
+	case !r.Passed && r.Quarantined:
+		status = "○ QUARANTINED"
+	case !r.Passed:
 		status = "✗ FAIL"
 	}
 
-	fmt.Printf("%s  %-25s  (%v)\n", status, r.Test.Name, r.Duration.Round(time.Millisecond))
+	attempts := ""
+	if r.Attempts > 1 {
+		attempts = fmt.Sprintf(", %d attempts", r.Attempts)
+	}
+	fmt.Fprintf(output, "%-13s  %-25s  (%v%s)\n", status, r.Test.Name, r.Duration.Round(time.Millisecond), attempts)
 
-	if r.Error != nil {
+	for i, err := range r.RetriedErrors {
+		fmt.Fprintf(os.Stderr, "       Attempt %d: %v\n", i+1, err)
+	}
+	if r.Skipped {
+		fmt.Fprintf(output, "       %v\n", r.Error)
+	} else if r.Error != nil {
 		fmt.Fprintf(os.Stderr, "       Error: %v\n", r.Error)
 	}
+	for _, err := range r.CleanupErrors {
+		fmt.Fprintf(os.Stderr, "       Cleanup: %v\n", err)
+	}
 }
 
-// PrintSummary prints a summary of test results.
+// PrintSummary prints a summary of test results. Duration is the sum of the
+// tests' durations, which exceeds the elapsed time of a parallel run.
 func PrintSummary(results []*Result) {
 	passed := 0
 	failed := 0
+	flaky := 0
+	quarantined := 0
+	skipped := 0
 	var totalDuration time.Duration
 
 	for _, r := range results {
 		totalDuration += r.Duration
-		if r.Passed {
+		switch {
+		case r.Passed:
 			passed++
-		} else {
+			if r.Flaky() {
+				flaky++
+			}
+		case r.Skipped:
+			skipped++
+		case r.Quarantined:
+			quarantined++
+		default:
 			failed++
 		}
 	}
 
-	fmt.Println()
-	fmt.Println("─────────────────────────────────────────")
-	fmt.Printf("Total: %d  Passed: %d  Failed: %d  Duration: %v\n",
-		len(results), passed, failed, totalDuration.Round(time.Millisecond))
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, "─────────────────────────────────────────")
+	fmt.Fprintf(output, "Total: %d  Passed: %d  Failed: %d", len(results), passed, failed)
+	if flaky > 0 {
+		fmt.Fprintf(output, "  Flaky: %d", flaky)
+	}
+	if quarantined > 0 {
+		fmt.Fprintf(output, "  Quarantined: %d", quarantined)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(output, "  Skipped: %d", skipped)
+	}
+	fmt.Fprintf(output, "  Duration: %v\n", totalDuration.Round(time.Millisecond))
 
 	if failed > 0 {
-		fmt.Println("\nFailed tests:")
+		fmt.Fprintln(output, "\nFailed tests:")
+		for _, r := range results {
+			if r.FailsRun() {
+				fmt.Fprintf(output, "  - %s: %v\n", r.Test.Name, r.Error)
+			}
+		}
+	}
+	if flaky > 0 {
+		fmt.Fprintln(output, "\nFlaky tests (passed on retry):")
+		for _, r := range results {
+			if r.Flaky() {
+				fmt.Fprintf(output, "  - %s: passed on attempt %d, first failure: %v\n", r.Test.Name, r.Attempts, r.RetriedErrors[0])
+			}
+		}
+	}
+	if quarantined > 0 {
+		fmt.Fprintln(output, "\nQuarantined failures (not failing the run):")
 		for _, r := range results {
-			if !r.Passed {
-				fmt.Printf("  - %s: %v\n", r.Test.Name, r.Error)
+			if !r.Passed && !r.Skipped && r.Quarantined {
+				fmt.Fprintf(output, "  - %s: %v\n", r.Test.Name, r.Error)
 			}
 		}
 	}
 }
 
-// LoadConfig creates a Config from environment variables.
-func LoadConfig(env string) *Config {
-	cfg := &Config{
-		Env:     env,
-		Timeout: 30 * time.Second,
+// LoadConfig creates a Config for env from the built-in environments and
+// the environments file at envFile (none if empty). E2E_* environment
+// variables override the environment's settings.
+func LoadConfig(env, envFile string) (*Config, error) {
+	envs, err := loadEnvironments(envFile)
+	if err != nil {
+		return nil, err
+	}
+	e, err := resolveEnvironment(envs, env)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check for environment variable overrides first
-	if url := os.Getenv("E2E_INGESTION_URL"); url != "" {
-		cfg.IngestionURL = url
+	cfg := &Config{
+		Env:          env,
+		IngestionURL: getEnv("E2E_INGESTION_URL", e.IngestionURL),
+		QueryURL:     getEnv("E2E_QUERY_URL", e.QueryURL),
+		ActionsURL:   getEnv("E2E_ACTIONS_URL", e.ActionsURL),
+		AdminURL:     getEnv("E2E_ADMIN_URL", e.AdminURL),
+		APIKey:       getEnv("E2E_API_KEY", e.APIKey),
+		Timeout:      30 * time.Second,
+		Quarantine:   ParseTestNames(os.Getenv("E2E_QUARANTINE")),
+		Webhook: WebhookConfig{
+			ListenAddr: getEnv("E2E_WEBHOOK_LISTEN", "127.0.0.1:0"),
+			Host:       getEnv("E2E_WEBHOOK_HOST", e.WebhookHost),
+			Secret:     os.Getenv("E2E_WEBHOOK_SECRET"),
+		},
+		PostgresContainer: getEnv("E2E_POSTGRES_CONTAINER", "cornjacket-postgres"),
+		RedpandaContainer: getEnv("E2E_REDPANDA_CONTAINER", "cornjacket-redpanda"),
 	}
-	if url := os.Getenv("E2E_QUERY_URL"); url != "" {
-		cfg.QueryURL = url
+	if e.Timeout > 0 {
+		cfg.Timeout = e.Timeout
 	}
+	return cfg, nil
+}
 
-	// Apply defaults based on environment if not set
-	if cfg.IngestionURL == "" || cfg.QueryURL == "" {
-		switch env {
-		case "local":
-			if cfg.IngestionURL == "" {
-				cfg.IngestionURL = "http://localhost:8080"
-			}
-			if cfg.QueryURL == "" {
-				cfg.QueryURL = "http://localhost:8081"
-			}
-		case "dev":
-			if cfg.IngestionURL == "" {
-				cfg.IngestionURL = "https://api-dev.cornjacket.com"
-			}
-			if cfg.QueryURL == "" {
-				cfg.QueryURL = "https://query-dev.cornjacket.com"
-			}
-		case "staging":
-			if cfg.IngestionURL == "" {
-				cfg.IngestionURL = "https://api-staging.cornjacket.com"
-			}
-			if cfg.QueryURL == "" {
-				cfg.QueryURL = "https://query-staging.cornjacket.com"
-			}
+// ParseTestNames splits a comma-separated list of test names, such as a
+// -quarantine value, dropping empty entries.
+func ParseTestNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
 		}
 	}
+	return names
+}
 
-	return cfg
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
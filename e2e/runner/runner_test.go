@@ -0,0 +1,177 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trackingTests returns n tests that sleep briefly, recording the most that
+// ran at once in peak. Serial tests check that nothing else is running.
+func trackingTests(n int, serial map[int]bool, peak *atomic.Int32) []*Test {
+	var running atomic.Int32
+	tests := make([]*Test, n)
+	for i := range n {
+		tests[i] = &Test{
+			Name:   string(rune('a' + i)),
+			Serial: serial[i],
+			Run: func(ctx context.Context, cfg *Config) error {
+				now := running.Add(1)
+				defer running.Add(-1)
+				for {
+					old := peak.Load()
+					if now <= old || peak.CompareAndSwap(old, now) {
+						break
+					}
+				}
+				if serial[i] && now != 1 {
+					return errors.New("serial test ran alongside another")
+				}
+				time.Sleep(20 * time.Millisecond)
+				return nil
+			},
+		}
+	}
+	return tests
+}
+
+func TestRunTests_Sequential(t *testing.T) {
+	var peak atomic.Int32
+	results := runTests(context.Background(), trackingTests(3, nil, &peak), &Config{Timeout: time.Second})
+
+	require.Len(t, results, 3)
+	for _, r := range results {
+		assert.True(t, r.Passed)
+	}
+	assert.Equal(t, int32(1), peak.Load())
+}
+
+func TestRunTests_Parallel(t *testing.T) {
+	var peak atomic.Int32
+	tests := trackingTests(6, map[int]bool{1: true}, &peak)
+	results := runTests(context.Background(), tests, &Config{Timeout: time.Second, Parallel: 3})
+
+	require.Len(t, results, 6)
+	for i, r := range results {
+		assert.Same(t, tests[i], r.Test, "results keep the tests' order")
+		assert.True(t, r.Passed, "%s: %v", r.Test.Name, r.Error)
+	}
+	assert.Equal(t, int32(3), peak.Load(), "at most Parallel tests at once")
+}
+
+// failingTest returns a test failing its first failures runs, counting runs.
+func failingTest(failures int, runs *int) *Test {
+	return &Test{
+		Name: "flaky",
+		Run: func(ctx context.Context, cfg *Config) error {
+			*runs++
+			if *runs <= failures {
+				return fmt.Errorf("attempt %d: projection not updated", *runs)
+			}
+			return nil
+		},
+	}
+}
+
+func TestRunTest_PassesOnRetry(t *testing.T) {
+	var runs int
+	test := failingTest(2, &runs)
+	test.Retries = 2
+	r := RunTest(context.Background(), test, &Config{Timeout: time.Second})
+
+	assert.True(t, r.Passed)
+	assert.True(t, r.Flaky())
+	assert.False(t, r.FailsRun())
+	assert.Equal(t, 3, r.Attempts)
+	assert.NoError(t, r.Error)
+	require.Len(t, r.RetriedErrors, 2)
+	assert.EqualError(t, r.RetriedErrors[0], "attempt 1: projection not updated")
+}
+
+func TestRunTest_FailsAfterRetries(t *testing.T) {
+	var runs int
+	r := RunTest(context.Background(), failingTest(5, &runs), &Config{Timeout: time.Second, Retries: 1})
+
+	assert.False(t, r.Passed)
+	assert.True(t, r.FailsRun())
+	assert.Equal(t, 2, r.Attempts, "Config.Retries applies to tests without their own")
+	assert.EqualError(t, r.Error, "attempt 2: projection not updated")
+	assert.Len(t, r.RetriedErrors, 1)
+}
+
+func TestRunTest_NoRetryOnceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs int
+	test := &Test{
+		Name:    "interrupted",
+		Retries: 3,
+		Run: func(ctx context.Context, cfg *Config) error {
+			runs++
+			cancel()
+			return ctx.Err()
+		},
+	}
+	r := RunTest(ctx, test, &Config{Timeout: time.Second})
+
+	assert.False(t, r.Passed)
+	assert.Equal(t, 1, runs)
+}
+
+func TestRunTest_Timeout(t *testing.T) {
+	deadline := func(ctx context.Context, cfg *Config) error {
+		d, _ := ctx.Deadline()
+		return fmt.Errorf("%s", time.Until(d).Round(time.Minute))
+	}
+	cfg := &Config{Timeout: 5 * time.Minute}
+
+	r := RunTest(context.Background(), &Test{Name: "default", Run: deadline}, cfg)
+	assert.EqualError(t, r.Error, "5m0s")
+
+	r = RunTest(context.Background(), &Test{Name: "long", Run: deadline, Timeout: 20 * time.Minute}, cfg)
+	assert.EqualError(t, r.Error, "20m0s", "Test.Timeout replaces Config.Timeout")
+}
+
+func TestRunTest_NotRunOnceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var runs int
+	r := RunTest(ctx, failingTest(0, &runs), &Config{Timeout: time.Second})
+
+	assert.Zero(t, runs)
+	assert.EqualError(t, r.Error, "not run: context canceled")
+	assert.True(t, r.FailsRun())
+}
+
+func TestRunTest_Quarantined(t *testing.T) {
+	var runs int
+	r := RunTest(context.Background(), failingTest(1, &runs), &Config{Timeout: time.Second, Quarantine: []string{"flaky"}})
+
+	assert.False(t, r.Passed)
+	assert.True(t, r.Quarantined)
+	assert.False(t, r.FailsRun(), "quarantined failures do not fail the run")
+}
+
+func TestParseTestNames(t *testing.T) {
+	assert.Equal(t, []string{"full-flow", "ingest-event"}, ParseTestNames(" full-flow,,ingest-event "))
+	assert.Empty(t, ParseTestNames(""))
+}
+
+func TestRunTest_Skip(t *testing.T) {
+	var runs int
+	test := &Test{Name: "needs-docker", Retries: 2, Run: func(ctx context.Context, cfg *Config) error {
+		runs++
+		return fmt.Errorf("fixture docker: %w", Skip("no docker in %s", "ci"))
+	}}
+	r := RunTest(context.Background(), test, &Config{Timeout: time.Second})
+
+	assert.True(t, r.Skipped)
+	assert.False(t, r.FailsRun())
+	assert.Equal(t, 1, runs, "skipped tests are not retried")
+	assert.EqualError(t, r.Error, "fixture docker: skipped: no docker in ci")
+}
@@ -0,0 +1,208 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// hook is a suite-level setup or teardown step.
+type hook struct {
+	name string
+	run  func(ctx context.Context, cfg *Config) error
+}
+
+var beforeAll, afterAll []hook
+
+// BeforeAll registers fn to run once before the tests of a run (called from
+// init()), such as to provision data the tests share. Hooks run in
+// registration order. If one fails, no tests run; each is reported failed
+// with the hook's error.
+func BeforeAll(name string, fn func(ctx context.Context, cfg *Config) error) {
+	beforeAll = append(beforeAll, hook{name: name, run: fn})
+}
+
+// AfterAll registers fn to run once after the tests of a run, even when a
+// BeforeAll hook or tests failed or the run was interrupted. Hooks run in
+// reverse registration order. A failing hook is reported as a failed result
+// named "after-all/<name>".
+func AfterAll(name string, fn func(ctx context.Context, cfg *Config) error) {
+	afterAll = append(afterAll, hook{name: name, run: fn})
+}
+
+// suite is the state of one run: the fixtures set up so far and the
+// teardowns they registered.
+type suite struct {
+	mu        sync.Mutex
+	fixtures  map[any]*fixtureState
+	teardowns []hook
+}
+
+type fixtureState struct {
+	once  sync.Once
+	value any
+	err   error
+}
+
+type suiteKey struct{}
+
+// runSuite runs tests between the BeforeAll and AfterAll hooks, then tears
+// down the fixtures they used, within cfg.SuiteTimeout if set. Teardown
+// failures are appended to the results.
+func runSuite(ctx context.Context, tests []*Test, cfg *Config) []*Result {
+	if cfg.SuiteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, cfg.SuiteTimeout,
+			fmt.Errorf("suite timeout of %s exceeded", cfg.SuiteTimeout))
+		defer cancel()
+	}
+	s := &suite{fixtures: make(map[any]*fixtureState)}
+	ctx = context.WithValue(ctx, suiteKey{}, s)
+
+	var results []*Result
+	if err := runBeforeAll(ctx, cfg); err != nil {
+		for _, t := range tests {
+			result := &Result{Test: t, Error: err, Quarantined: cfg.Quarantined(t.Name)}
+			printResult(result)
+			results = append(results, result)
+		}
+	} else {
+		results = runTests(ctx, tests, cfg)
+	}
+
+	// Fixtures were set up after the BeforeAll hooks, so they are torn down
+	// before the AfterAll hooks run
+	s.mu.Lock()
+	teardowns := append(slices.Clone(afterAll), s.teardowns...)
+	s.mu.Unlock()
+	for _, h := range slices.Backward(teardowns) {
+		if err := runHook(ctx, h, cfg); err != nil {
+			result := &Result{
+				Test:     &Test{Name: "after-all/" + h.name, Description: "suite teardown"},
+				Error:    err,
+				Attempts: 1,
+			}
+			printResult(result)
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func runBeforeAll(ctx context.Context, cfg *Config) error {
+	for _, h := range beforeAll {
+		if err := runHook(ctx, h, cfg); err != nil {
+			return fmt.Errorf("before-all hook %s failed: %w", h.name, err)
+		}
+	}
+	return nil
+}
+
+// runHook runs h with the test timeout. Teardowns run after an interrupt
+// too, so the hook's context is not cancelled with ctx.
+func runHook(ctx context.Context, h hook, cfg *Config) error {
+	hookCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.Timeout)
+	defer cancel()
+	return h.run(hookCtx, cfg)
+}
+
+// Fixture is a value tests share within a run, such as a provisioned
+// resource. It is set up on the first Get and torn down after the run's
+// tests, so runs whose tests do not use it never set it up. Declare fixtures
+// as package-level variables.
+type Fixture[T any] struct {
+	Name  string
+	Setup func(ctx context.Context, cfg *Config) (T, error)
+
+	// Teardown, if set, releases the value after the run's tests, before the
+	// AfterAll hooks.
+	Teardown func(ctx context.Context, cfg *Config, value T) error
+}
+
+// Get returns the fixture's value, setting it up on first use in the run.
+// A failed setup is not retried; every Get in the run returns its error.
+func (f *Fixture[T]) Get(ctx context.Context, cfg *Config) (T, error) {
+	var zero T
+	s, ok := ctx.Value(suiteKey{}).(*suite)
+	if !ok {
+		return zero, fmt.Errorf("fixture %s: used outside RunAll or RunSingle", f.Name)
+	}
+
+	s.mu.Lock()
+	state, ok := s.fixtures[f]
+	if !ok {
+		state = &fixtureState{}
+		s.fixtures[f] = state
+	}
+	s.mu.Unlock()
+
+	state.once.Do(func() {
+		value, err := runFixtureSetup(ctx, f, cfg)
+		if err != nil {
+			state.err = fmt.Errorf("fixture %s: %w", f.Name, err)
+			return
+		}
+		state.value = value
+		if f.Teardown != nil {
+			s.mu.Lock()
+			s.teardowns = append(s.teardowns, hook{name: f.Name, run: func(ctx context.Context, cfg *Config) error {
+				return f.Teardown(ctx, cfg, value)
+			}})
+			s.mu.Unlock()
+		}
+	})
+	if state.err != nil {
+		return zero, state.err
+	}
+	return state.value.(T), nil
+}
+
+// runFixtureSetup sets up f with the test timeout, not bound to the calling
+// test, whose failure must not fail the setup other tests share.
+func runFixtureSetup[T any](ctx context.Context, f *Fixture[T], cfg *Config) (T, error) {
+	setupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.Timeout)
+	defer cancel()
+	return f.Setup(setupCtx, cfg)
+}
+
+// cleanups holds the cleanup functions registered by one test attempt.
+type cleanups struct {
+	mu  sync.Mutex
+	fns []func(ctx context.Context) error
+}
+
+type cleanupsKey struct{}
+
+// Cleanup registers fn to run when the current test attempt finishes,
+// whether it passed or failed, such as to remove the aggregates it created
+// from a shared environment. Cleanups run in reverse registration order, with
+// the test timeout, even after an interrupt. Their errors are reported
+// without failing the test. ctx must be the context passed to Test.Run.
+func Cleanup(ctx context.Context, fn func(ctx context.Context) error) {
+	c, ok := ctx.Value(cleanupsKey{}).(*cleanups)
+	if !ok {
+		panic("runner.Cleanup called outside a running test")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fns = append(c.fns, fn)
+}
+
+// run runs the registered cleanups, returning their errors.
+func (c *cleanups) run(ctx context.Context, cfg *Config) []error {
+	c.mu.Lock()
+	fns := c.fns
+	c.fns = nil
+	c.mu.Unlock()
+
+	var errs []error
+	for _, fn := range slices.Backward(fns) {
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.Timeout)
+		if err := fn(cleanupCtx); err != nil {
+			errs = append(errs, err)
+		}
+		cancel()
+	}
+	return errs
+}
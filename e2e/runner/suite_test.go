@@ -0,0 +1,203 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withHooks replaces the registered suite hooks for the duration of a test.
+func withHooks(t *testing.T) {
+	t.Helper()
+	savedBefore, savedAfter := beforeAll, afterAll
+	beforeAll, afterAll = nil, nil
+	t.Cleanup(func() { beforeAll, afterAll = savedBefore, savedAfter })
+}
+
+// recorder collects the steps of a run in order.
+type recorder struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (r *recorder) add(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, step)
+}
+
+func (r *recorder) hook(step string, err error) func(ctx context.Context, cfg *Config) error {
+	return func(ctx context.Context, cfg *Config) error {
+		r.add(step)
+		return err
+	}
+}
+
+func (r *recorder) cleanup(step string, err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.add(step)
+		return err
+	}
+}
+
+func TestRunSuite_HooksAndFixtures(t *testing.T) {
+	withHooks(t)
+	var rec recorder
+	BeforeAll("first", rec.hook("before first", nil))
+	BeforeAll("second", rec.hook("before second", nil))
+	AfterAll("first", rec.hook("after first", nil))
+	AfterAll("second", rec.hook("after second", errors.New("topic still in use")))
+
+	var setups atomic.Int32
+	fixture := &Fixture[string]{
+		Name: "topic",
+		Setup: func(ctx context.Context, cfg *Config) (string, error) {
+			setups.Add(1)
+			rec.add("fixture setup")
+			return "e2e-topic", nil
+		},
+		Teardown: func(ctx context.Context, cfg *Config, value string) error {
+			rec.add("fixture teardown " + value)
+			return nil
+		},
+	}
+	useFixture := func(ctx context.Context, cfg *Config) error {
+		value, err := fixture.Get(ctx, cfg)
+		if err == nil && value != "e2e-topic" {
+			return errors.New("wrong fixture value " + value)
+		}
+		return err
+	}
+	tests := []*Test{{Name: "a", Run: useFixture}, {Name: "b", Run: useFixture}, {Name: "c", Run: useFixture}}
+
+	results := runSuite(context.Background(), tests, &Config{Timeout: time.Second, Parallel: 3})
+
+	require.Len(t, results, 4, "three tests and the failed AfterAll hook")
+	for _, r := range results[:3] {
+		assert.True(t, r.Passed, "%s: %v", r.Test.Name, r.Error)
+	}
+	assert.Equal(t, "after-all/second", results[3].Test.Name)
+	assert.True(t, results[3].FailsRun())
+	assert.Equal(t, int32(1), setups.Load(), "a fixture is set up once per run")
+	assert.Equal(t, []string{
+		"before first", "before second",
+		"fixture setup",
+		"fixture teardown e2e-topic", "after second", "after first",
+	}, rec.steps)
+
+	// Fixtures are per run
+	runSuite(context.Background(), tests[:1], &Config{Timeout: time.Second})
+	assert.Equal(t, int32(2), setups.Load())
+}
+
+func TestRunSuite_BeforeAllFailure(t *testing.T) {
+	withHooks(t)
+	var rec recorder
+	BeforeAll("provision", rec.hook("before", errors.New("no topic")))
+	AfterAll("cleanup", rec.hook("after", nil))
+
+	ran := false
+	tests := []*Test{{Name: "a", Run: func(ctx context.Context, cfg *Config) error {
+		ran = true
+		return nil
+	}}}
+	results := runSuite(context.Background(), tests, &Config{Timeout: time.Second})
+
+	assert.False(t, ran)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Passed)
+	assert.EqualError(t, results[0].Error, "before-all hook provision failed: no topic")
+	assert.Equal(t, []string{"before", "after"}, rec.steps, "AfterAll hooks still run")
+}
+
+func TestRunSuite_SuiteTimeout(t *testing.T) {
+	withHooks(t)
+	var rec recorder
+	AfterAll("cleanup", rec.hook("after", nil))
+
+	tests := []*Test{
+		{Name: "slow", Timeout: time.Minute, Run: func(ctx context.Context, cfg *Config) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		{Name: "later", Run: rec.hook("later", nil)},
+	}
+	results := runSuite(context.Background(), tests, &Config{Timeout: time.Second, SuiteTimeout: 50 * time.Millisecond})
+
+	require.Len(t, results, 2)
+	assert.EqualError(t, results[0].Error, "context deadline exceeded (suite timeout of 50ms exceeded)")
+	assert.EqualError(t, results[1].Error, "not run: suite timeout of 50ms exceeded")
+	assert.Zero(t, results[1].Attempts)
+	assert.True(t, results[1].FailsRun())
+	assert.Equal(t, []string{"after"}, rec.steps, "teardowns run past the deadline")
+}
+
+func TestFixture_SetupFailure(t *testing.T) {
+	withHooks(t)
+	var setups int
+	fixture := &Fixture[int]{Name: "broken", Setup: func(ctx context.Context, cfg *Config) (int, error) {
+		setups++
+		return 0, errors.New("provisioning failed")
+	}}
+	use := func(ctx context.Context, cfg *Config) error {
+		_, err := fixture.Get(ctx, cfg)
+		return err
+	}
+	results := runSuite(context.Background(), []*Test{{Name: "a", Run: use}, {Name: "b", Run: use}}, &Config{Timeout: time.Second})
+
+	for _, r := range results {
+		assert.EqualError(t, r.Error, "fixture broken: provisioning failed")
+	}
+	assert.Equal(t, 1, setups, "a failed setup is not retried within the run")
+
+	_, err := fixture.Get(context.Background(), &Config{})
+	assert.Error(t, err, "fixtures need a suite")
+}
+
+func TestCleanup(t *testing.T) {
+	var rec recorder
+	var runs int
+	test := &Test{
+		Name:    "creates-aggregates",
+		Retries: 1,
+		Run: func(ctx context.Context, cfg *Config) error {
+			runs++
+			Cleanup(ctx, rec.cleanup("remove first", nil))
+			Cleanup(ctx, rec.cleanup("remove second", errors.New("already gone")))
+			if runs == 1 {
+				return errors.New("flaked")
+			}
+			return nil
+		},
+	}
+	r := RunTest(context.Background(), test, &Config{Timeout: time.Second})
+
+	assert.True(t, r.Passed, "cleanup errors do not fail the test")
+	assert.Equal(t, []string{"remove second", "remove first", "remove second", "remove first"}, rec.steps,
+		"each attempt's cleanups run in reverse order before the next attempt")
+	assert.Len(t, r.CleanupErrors, 2)
+
+	assert.Panics(t, func() { Cleanup(context.Background(), nil) })
+}
+
+func TestCleanup_RunsAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var cleanupErr error
+	test := &Test{Name: "interrupted", Run: func(testCtx context.Context, cfg *Config) error {
+		Cleanup(testCtx, func(ctx context.Context) error {
+			cleanupErr = ctx.Err()
+			return nil
+		})
+		cancel()
+		return testCtx.Err()
+	}}
+	RunTest(ctx, test, &Config{Timeout: time.Second})
+
+	assert.NoError(t, cleanupErr, "cleanups get a live context after an interrupt")
+}
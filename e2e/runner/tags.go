@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Conventional tags. Tests may use others.
+const (
+	TagSmoke       = "smoke"       // fast checks that a deployment works; run on every deploy
+	TagSlow        = "slow"        // waits on long pipelines; left to nightly runs
+	TagDestructive = "destructive" // changes shared state; never run against production
+	TagChaos       = "chaos"       // pauses local containers; opt-in (see optInTags)
+)
+
+// optInTags mark tests that run only when a filter includes one of their
+// opt-in tags, never as part of a plain run.
+var optInTags = []string{TagChaos}
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// TagFilter selects tests by tag. A test is selected when it has any of the
+// Include tags (or Include is empty) and none of the Exclude tags. Tests with
+// an opt-in tag, such as TagChaos, also need that tag in Include. The zero
+// TagFilter selects every test without an opt-in tag.
+type TagFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseTagFilter parses a comma-separated tag list, where a leading "!"
+// excludes a tag: "smoke" runs smoke tests, "!destructive" runs everything
+// but destructive tests, and "smoke,!slow" runs smoke tests that are not
+// slow.
+func ParseTagFilter(s string) (TagFilter, error) {
+	var f TagFilter
+	if s == "" {
+		return f, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		tag, exclude := strings.CutPrefix(strings.TrimSpace(entry), "!")
+		if !tagPattern.MatchString(tag) {
+			return TagFilter{}, fmt.Errorf("invalid tag %q: expected lowercase letters, digits, '-' and '_', optionally prefixed with '!'", entry)
+		}
+		if exclude {
+			f.Exclude = append(f.Exclude, tag)
+		} else {
+			f.Include = append(f.Include, tag)
+		}
+	}
+	return f, nil
+}
+
+// Matches reports whether f selects t.
+func (f TagFilter) Matches(t *Test) bool {
+	for _, tag := range f.Exclude {
+		if slices.Contains(t.Tags, tag) {
+			return false
+		}
+	}
+	for _, tag := range optInTags {
+		if slices.Contains(t.Tags, tag) && !slices.Contains(f.Include, tag) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, tag := range f.Include {
+		if slices.Contains(t.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the filter in ParseTagFilter syntax.
+func (f TagFilter) String() string {
+	entries := slices.Clone(f.Include)
+	for _, tag := range f.Exclude {
+		entries = append(entries, "!"+tag)
+	}
+	return strings.Join(entries, ",")
+}
+
+// SelectTests returns the registered tests f selects, sorted by name.
+func SelectTests(f TagFilter) []*Test {
+	var selected []*Test
+	for _, t := range GetAllTests() {
+		if f.Matches(t) {
+			selected = append(selected, t)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTagFilter(t *testing.T) {
+	f, err := ParseTagFilter("smoke, !slow,!destructive")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"smoke"}, f.Include)
+	assert.Equal(t, []string{"slow", "destructive"}, f.Exclude)
+	assert.Equal(t, "smoke,!slow,!destructive", f.String())
+
+	f, err = ParseTagFilter("")
+	require.NoError(t, err)
+	assert.Equal(t, TagFilter{}, f)
+
+	for _, bad := range []string{",", "smoke,", "!", "Smoke", "two words", "!!slow"} {
+		_, err := ParseTagFilter(bad)
+		assert.Error(t, err, "tags %q", bad)
+	}
+}
+
+func TestTagFilter_Matches(t *testing.T) {
+	smoke := &Test{Name: "a", Tags: []string{TagSmoke}}
+	slowSmoke := &Test{Name: "b", Tags: []string{TagSmoke, TagSlow}}
+	destructive := &Test{Name: "c", Tags: []string{TagDestructive}}
+	untagged := &Test{Name: "d"}
+	chaos := &Test{Name: "e", Tags: []string{TagChaos, TagSlow}}
+
+	tests := []struct {
+		tags string
+		want []*Test
+	}{
+		{"", []*Test{smoke, slowSmoke, destructive, untagged}},
+		{"smoke", []*Test{smoke, slowSmoke}},
+		{"slow", []*Test{slowSmoke}},
+		{"chaos", []*Test{chaos}},
+		{"chaos,!slow", nil},
+		{"smoke,destructive", []*Test{smoke, slowSmoke, destructive}},
+		{"smoke,!slow", []*Test{smoke}},
+		{"!destructive", []*Test{smoke, slowSmoke, untagged}},
+	}
+	for _, tt := range tests {
+		f, err := ParseTagFilter(tt.tags)
+		require.NoError(t, err)
+		var got []*Test
+		for _, test := range []*Test{smoke, slowSmoke, destructive, untagged, chaos} {
+			if f.Matches(test) {
+				got = append(got, test)
+			}
+		}
+		assert.Equal(t, tt.want, got, "tags %q", tt.tags)
+	}
+}
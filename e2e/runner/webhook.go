@@ -0,0 +1,166 @@
+package runner
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebhookConfig configures a WebhookReceiver.
+type WebhookConfig struct {
+	ListenAddr string // address to listen on, e.g. 127.0.0.1:0 or 0.0.0.0:9000
+
+	// Host is the host the platform reaches the receiver at, such as
+	// host.docker.internal when the platform runs in docker. Empty uses the
+	// listen address, which only a platform on the same machine can reach.
+	Host string
+
+	// Secret, if set, is the platform's CJ_ACTIONS_WEBHOOK_SECRET; deliveries
+	// with a wrong signature are then rejected with 401.
+	Secret string
+}
+
+// WebhookDelivery is a request received by a WebhookReceiver.
+type WebhookDelivery struct {
+	Path       string
+	Header     http.Header
+	Body       []byte
+	ReceivedAt time.Time
+}
+
+// WebhookReceiver is a mock webhook endpoint, so tests can assert that the
+// actions service fires their rules. Give each test its own path (URL) and
+// wait for deliveries to it (Wait).
+type WebhookReceiver struct {
+	config   WebhookConfig
+	server   *http.Server
+	baseURL  string
+	mu       sync.Mutex
+	received []WebhookDelivery
+	arrived  chan struct{} // closed and replaced on each delivery
+}
+
+// StartWebhookReceiver starts a receiver listening on cfg.ListenAddr.
+func StartWebhookReceiver(cfg WebhookConfig) (*WebhookReceiver, error) {
+	listener, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start webhook receiver: %w", err)
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	if cfg.Host != "" {
+		host = cfg.Host
+	}
+
+	r := &WebhookReceiver{
+		config:  cfg,
+		baseURL: "http://" + net.JoinHostPort(host, port),
+		arrived: make(chan struct{}),
+	}
+	r.server = &http.Server{Handler: http.HandlerFunc(r.handle), ReadHeaderTimeout: 5 * time.Second}
+	go r.server.Serve(listener)
+	return r, nil
+}
+
+// URL returns the URL of path (e.g. "/rules/e2e-123") as the platform reaches
+// it.
+func (r *WebhookReceiver) URL(path string) string {
+	return r.baseURL + path
+}
+
+// Close stops the receiver.
+func (r *WebhookReceiver) Close(ctx context.Context) error {
+	return r.server.Shutdown(ctx)
+}
+
+func (r *WebhookReceiver) handle(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if r.config.Secret != "" && !validSignature(r.config.Secret, req.Header, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	r.mu.Lock()
+	r.received = append(r.received, WebhookDelivery{
+		Path:       req.URL.Path,
+		Header:     req.Header.Clone(),
+		Body:       body,
+		ReceivedAt: time.Now(),
+	})
+	close(r.arrived)
+	r.arrived = make(chan struct{})
+	r.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Wait returns the first delivery to path, waiting for one until ctx is done.
+func (r *WebhookReceiver) Wait(ctx context.Context, path string) (WebhookDelivery, error) {
+	for {
+		r.mu.Lock()
+		for _, d := range r.received {
+			if d.Path == path {
+				r.mu.Unlock()
+				return d, nil
+			}
+		}
+		arrived := r.arrived
+		r.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return WebhookDelivery{}, fmt.Errorf("no webhook delivery to %s: %w", path, ctx.Err())
+		case <-arrived:
+		}
+	}
+}
+
+// Deliveries returns the deliveries to path received so far.
+func (r *WebhookReceiver) Deliveries(path string) []WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []WebhookDelivery
+	for _, d := range r.received {
+		if d.Path == path {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// validSignature checks the X-Cornjacket-Signature header: "sha256=" and the
+// hex HMAC-SHA256 of "<timestamp>.<body>" under secret.
+func validSignature(secret string, header http.Header, body []byte) bool {
+	timestamp, err := strconv.ParseInt(header.Get("X-Cornjacket-Timestamp"), 10, 64)
+	if err != nil {
+		return false
+	}
+	signature, ok := strings.CutPrefix(header.Get("X-Cornjacket-Signature"), "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
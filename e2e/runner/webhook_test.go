@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deliver posts body to url as the actions service would, signed with secret.
+func deliver(t *testing.T, url, secret string, body []byte) int {
+	t.Helper()
+	timestamp := time.Now().Unix()
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("X-Cornjacket-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Cornjacket-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestWebhookReceiver(t *testing.T) {
+	r, err := StartWebhookReceiver(WebhookConfig{ListenAddr: "127.0.0.1:0", Secret: "s3cret"})
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close(context.Background()) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	waited := make(chan WebhookDelivery)
+	go func() {
+		d, _ := r.Wait(ctx, "/rules/a")
+		waited <- d
+	}()
+
+	assert.Equal(t, http.StatusNoContent, deliver(t, r.URL("/rules/b"), "s3cret", []byte(`{"n":1}`)))
+	assert.Equal(t, http.StatusUnauthorized, deliver(t, r.URL("/rules/a"), "wrong", []byte(`{"n":2}`)))
+	assert.Equal(t, http.StatusNoContent, deliver(t, r.URL("/rules/a"), "s3cret", []byte(`{"n":3}`)))
+
+	d := <-waited
+	assert.Equal(t, `{"n":3}`, string(d.Body), "waits for its own path, with a valid signature")
+	assert.Len(t, r.Deliveries("/rules/b"), 1)
+
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	_, err = r.Wait(short, "/rules/none")
+	assert.Error(t, err)
+}
+
+func TestWebhookReceiver_Host(t *testing.T) {
+	r, err := StartWebhookReceiver(WebhookConfig{ListenAddr: "127.0.0.1:0", Host: "host.docker.internal"})
+	require.NoError(t, err)
+	defer r.Close(context.Background())
+	assert.Regexp(t, `^http://host\.docker\.internal:\d+/hook$`, r.URL("/hook"))
+}
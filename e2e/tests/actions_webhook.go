@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/cornjacket/platform-services/e2e/client"
+	"github.com/cornjacket/platform-services/e2e/runner"
+)
+
+func init() {
+	runner.Register(&runner.Test{
+		Name:        "actions-webhook",
+		Description: "Ingest an over-threshold reading and verify the rule's webhook fires",
+		Run:         runActionsWebhookTest,
+		Tags:        []string{runner.TagSlow},
+	})
+}
+
+// webhookReceiver receives the webhook deliveries of the tests' rules. The
+// platform must be able to reach it: on the same machine, or through
+// E2E_WEBHOOK_HOST.
+var webhookReceiver = &runner.Fixture[*runner.WebhookReceiver]{
+	Name: "webhook-receiver",
+	Setup: func(ctx context.Context, cfg *runner.Config) (*runner.WebhookReceiver, error) {
+		if cfg.Env != "local" && cfg.Webhook.Host == "" {
+			return nil, runner.Skip("the %s platform cannot reach a receiver on this machine; set E2E_WEBHOOK_HOST and E2E_WEBHOOK_LISTEN", cfg.Env)
+		}
+		return runner.StartWebhookReceiver(cfg.Webhook)
+	},
+	Teardown: func(ctx context.Context, cfg *runner.Config, r *runner.WebhookReceiver) error {
+		return r.Close(ctx)
+	},
+}
+
+func runActionsWebhookTest(ctx context.Context, cfg *runner.Config) error {
+	if cfg.ActionsURL == "" {
+		return runner.Skip("no actions service URL for %s; set E2E_ACTIONS_URL", cfg.Env)
+	}
+	c, err := apiClient.Get(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	receiver, err := webhookReceiver.Get(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	types, err := client.ListActionTypes(ctx, c)
+	if err != nil {
+		return fmt.Errorf("failed to list action types: %w", err)
+	}
+	if !slices.Contains(types, "webhook") {
+		return runner.Skip("the webhook action type is disabled; set CJ_ACTIONS_WEBHOOK_SECRET on the platform")
+	}
+
+	// Generate unique aggregate ID for test isolation
+	aggregateID := client.UniqueID("e2e-actions-sensor")
+	decommissionAfterTest(ctx, c, aggregateID)
+
+	// 1. Create a rule firing on this test's readings over 90, delivering to
+	// its own receiver path. Matching on the probe field keeps other tests'
+	// readings from firing it.
+	path := "/rules/" + aggregateID
+	rule, err := client.CreateRule(ctx, c, &client.RuleRequest{
+		Name:      aggregateID,
+		EventType: "sensor.reading",
+		Predicate: []client.Condition{
+			{Field: "value", Op: "gt", Value: 90},
+			{Field: "e2e_probe", Op: "eq", Value: aggregateID},
+		},
+		ActionType:  "webhook",
+		Destination: receiver.URL(path),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create rule: %w", err)
+	}
+	runner.Cleanup(ctx, func(ctx context.Context) error {
+		return client.DeleteRule(ctx, c, rule.RuleID)
+	})
+
+	// 2. A reading under the threshold, then one over it. Readings of one
+	// aggregate are evaluated in order, so by the time the second fires the
+	// first has been passed over.
+	for _, value := range []float64{70.0, 99.5} {
+		_, err := client.IngestEvent(ctx, c, &client.IngestRequest{
+			EventType:   "sensor.reading",
+			AggregateID: aggregateID,
+			Payload: map[string]interface{}{
+				"value":     value,
+				"unit":      "fahrenheit",
+				"e2e_probe": aggregateID,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to ingest reading %v: %w", value, err)
+		}
+	}
+
+	// 3. The webhook fires for the over-threshold reading only
+	waitCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	delivery, err := receiver.Wait(waitCtx, path)
+	if err != nil {
+		return err
+	}
+	if got := delivery.Header.Get("X-Cornjacket-Rule-ID"); got != rule.RuleID {
+		return fmt.Errorf("expected rule ID %s in the delivery, got %q", rule.RuleID, got)
+	}
+	var event struct {
+		AggregateID string `json:"aggregate_id"`
+		Payload     struct {
+			Value float64 `json:"value"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(delivery.Body, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal delivered event: %w", err)
+	}
+	if event.AggregateID != aggregateID || event.Payload.Value != 99.5 {
+		return fmt.Errorf("expected the 99.5 reading of %s, got %v of %s", aggregateID, event.Payload.Value, event.AggregateID)
+	}
+	if n := len(receiver.Deliveries(path)); n != 1 {
+		return fmt.Errorf("expected 1 delivery, got %d: the under-threshold reading fired the rule", n)
+	}
+
+	// 4. The actions log records the execution
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		executions, err := client.ListExecutions(ctx, c, rule.RuleID)
+		if err != nil {
+			return fmt.Errorf("failed to list executions: %w", err)
+		}
+		if len(executions) == 1 && executions[0].Status == "succeeded" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("expected one succeeded execution of rule %s, got %+v", rule.RuleID, executions)
+		}
+		if err := sleep(ctx, 250*time.Millisecond); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cornjacket/platform-services/e2e/client"
+	"github.com/cornjacket/platform-services/e2e/runner"
+)
+
+// chaosOutage is how long a chaos test keeps a dependency paused: several
+// outbox polls, within the outbox retry budget.
+const chaosOutage = 10 * time.Second
+
+// chaosRecovery bounds the wait for delivery once the dependency is back,
+// covering the outbox poll and retry delays.
+const chaosRecovery = 60 * time.Second
+
+func init() {
+	runner.Register(&runner.Test{
+		Name:        "chaos-broker-outage",
+		Description: "Pause Redpanda mid-flow; the outbox delivers the event once it returns",
+		Run:         runBrokerOutageTest,
+		Tags:        []string{runner.TagChaos, runner.TagSlow, runner.TagDestructive},
+		Serial:      true,
+		Timeout:     chaosOutage + chaosRecovery + 30*time.Second,
+	})
+	runner.Register(&runner.Test{
+		Name:        "chaos-database-outage",
+		Description: "Pause Postgres mid-flow; the worker and consumer recover and deliver the events",
+		Run:         runDatabaseOutageTest,
+		Tags:        []string{runner.TagChaos, runner.TagSlow, runner.TagDestructive},
+		Serial:      true,
+		Timeout:     chaosOutage + 2*chaosRecovery + 30*time.Second,
+	})
+}
+
+// localDocker controls the local docker compose environment. Chaos tests
+// fail against any other environment.
+var localDocker = &runner.Fixture[*runner.Docker]{
+	Name: "docker",
+	Setup: func(ctx context.Context, cfg *runner.Config) (*runner.Docker, error) {
+		if cfg.Env != "local" {
+			return nil, fmt.Errorf("chaos tests pause docker compose containers and only run with -env=local, not %s", cfg.Env)
+		}
+		d := runner.NewDocker()
+		if err := d.Available(ctx); err != nil {
+			return nil, err
+		}
+		return d, nil
+	},
+}
+
+// chaosClient returns a client without the shared circuit breaker, which the
+// failures the tests provoke on purpose would open for the tests after them.
+func chaosClient(cfg *runner.Config) *client.Config {
+	return &client.Config{
+		IngestionURL: cfg.IngestionURL,
+		QueryURL:     cfg.QueryURL,
+		APIKey:       cfg.APIKey,
+	}
+}
+
+func runBrokerOutageTest(ctx context.Context, cfg *runner.Config) error {
+	docker, err := localDocker.Get(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	c := chaosClient(cfg)
+	aggregateID := client.UniqueID("e2e-chaos-broker")
+	decommissionAfterTest(ctx, c, aggregateID)
+
+	// 1. Take the broker away; ingestion only needs Postgres, so it still
+	// accepts events into the outbox
+	if err := pauseContainer(ctx, docker, cfg.RedpandaContainer); err != nil {
+		return err
+	}
+	if err := ingestReading(ctx, c, aggregateID, 41.0); err != nil {
+		return fmt.Errorf("ingestion refused an event while only the broker was down: %w", err)
+	}
+
+	// 2. Nothing can be delivered meanwhile
+	if err := sleep(ctx, chaosOutage); err != nil {
+		return err
+	}
+	projection, err := client.GetProjection(ctx, c, "sensor_state", aggregateID)
+	if err != nil {
+		return fmt.Errorf("failed to query projection during the outage: %w", err)
+	}
+	if projection != nil {
+		return fmt.Errorf("event delivered while %s was paused; the outage did not take effect", cfg.RedpandaContainer)
+	}
+
+	// 3. Once the broker is back, the outbox worker's retries deliver it
+	if err := docker.Unpause(ctx, cfg.RedpandaContainer); err != nil {
+		return err
+	}
+	if err := waitForSensorValue(ctx, c, aggregateID, 41.0, chaosRecovery); err != nil {
+		return fmt.Errorf("event not delivered after the broker returned: %w", err)
+	}
+	return nil
+}
+
+func runDatabaseOutageTest(ctx context.Context, cfg *runner.Config) error {
+	docker, err := localDocker.Get(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	c := chaosClient(cfg)
+	aggregateID := client.UniqueID("e2e-chaos-database")
+	decommissionAfterTest(ctx, c, aggregateID)
+
+	// 1. Baseline: the flow works
+	if err := ingestReading(ctx, c, aggregateID, 50.0); err != nil {
+		return err
+	}
+	if err := waitForSensorValue(ctx, c, aggregateID, 50.0, chaosRecovery); err != nil {
+		return fmt.Errorf("baseline event not delivered: %w", err)
+	}
+
+	// 2. Accept an update, then take the database away while it is still in
+	// the outbox: the worker, the consumer and the projection writes all
+	// need it
+	if err := ingestReading(ctx, c, aggregateID, 51.0); err != nil {
+		return err
+	}
+	if err := pauseContainer(ctx, docker, cfg.PostgresContainer); err != nil {
+		return err
+	}
+	if err := sleep(ctx, chaosOutage); err != nil {
+		return err
+	}
+	if err := docker.Unpause(ctx, cfg.PostgresContainer); err != nil {
+		return err
+	}
+
+	// 3. The update in flight during the outage is delivered
+	if err := waitForSensorValue(ctx, c, aggregateID, 51.0, chaosRecovery); err != nil {
+		return fmt.Errorf("event accepted before the outage not delivered: %w", err)
+	}
+
+	// 4. And the services take new events again, once their pools reconnect
+	if err := ingestReading(ctx, c, aggregateID, 52.0); err != nil {
+		return fmt.Errorf("ingestion did not recover: %w", err)
+	}
+	if err := waitForSensorValue(ctx, c, aggregateID, 52.0, chaosRecovery); err != nil {
+		return fmt.Errorf("event ingested after the outage not delivered: %w", err)
+	}
+	return nil
+}
+
+// pauseContainer pauses a container and registers a cleanup resuming it, so
+// a failing test never leaves the environment down.
+func pauseContainer(ctx context.Context, docker *runner.Docker, container string) error {
+	if err := docker.Pause(ctx, container); err != nil {
+		return err
+	}
+	runner.Cleanup(ctx, func(ctx context.Context) error {
+		return docker.Unpause(ctx, container)
+	})
+	return nil
+}
+
+// ingestReading ingests with an idempotency key, so the client retries it
+// while the services recover. A retry of an accepted request is a duplicate.
+func ingestReading(ctx context.Context, c *client.Config, aggregateID string, value float64) error {
+	resp, err := client.IngestEvent(ctx, c, &client.IngestRequest{
+		EventType:   "sensor.reading",
+		AggregateID: aggregateID,
+		Payload: map[string]interface{}{
+			"value": value,
+			"unit":  "fahrenheit",
+		},
+		IdempotencyKey: client.UniqueID("reading"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ingest reading %v: %w", value, err)
+	}
+	if resp.Status != "accepted" && resp.Status != "duplicate" {
+		return fmt.Errorf("expected status 'accepted' or 'duplicate', got '%s'", resp.Status)
+	}
+	return nil
+}
+
+// waitForSensorValue polls the sensor_state projection until its value is
+// want.
+func waitForSensorValue(ctx context.Context, c *client.Config, aggregateID string, want float64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	last := "no projection"
+	for time.Now().Before(deadline) {
+		projection, err := client.GetProjection(ctx, c, "sensor_state", aggregateID)
+		switch {
+		case err != nil:
+			last = err.Error()
+		case projection != nil:
+			var state struct {
+				Value float64 `json:"value"`
+			}
+			if err := json.Unmarshal(projection.State, &state); err != nil {
+				return fmt.Errorf("failed to unmarshal state: %w", err)
+			}
+			if state.Value == want {
+				return nil
+			}
+			last = fmt.Sprintf("value %v", state.Value)
+		}
+		if err := sleep(ctx, 500*time.Millisecond); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("sensor %s did not reach value %v within %v (last: %s)", aggregateID, want, timeout, last)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/e2e/client"
+	"github.com/cornjacket/platform-services/e2e/runner"
+)
+
+// poisonConstraint is the check constraint the DLQ test adds to the
+// projections table to make the event handler fail on its aggregate.
+const poisonConstraint = "e2e_dlq_poison"
+
+// quarantineWait bounds the wait for a poison event to be quarantined: the
+// event handler's retries with backoff (CJ_EVENTHANDLER_MAX_ATTEMPTS and
+// CJ_EVENTHANDLER_RETRY_DELAY), 15s with the defaults.
+const quarantineWait = 60 * time.Second
+
+func init() {
+	runner.Register(&runner.Test{
+		Name:        "dlq-poison-event",
+		Description: "Make the event handler fail on an aggregate; its event is quarantined in the DLQ and the consumer moves on",
+		Run:         runPoisonEventTest,
+		Tags:        []string{runner.TagChaos, runner.TagSlow, runner.TagDestructive},
+		Serial:      true,
+		Timeout:     quarantineWait + chaosRecovery + 30*time.Second,
+	})
+}
+
+func runPoisonEventTest(ctx context.Context, cfg *runner.Config) error {
+	if cfg.AdminURL == "" {
+		return runner.Skip("no event handler admin URL for %s; set E2E_ADMIN_URL", cfg.Env)
+	}
+	docker, err := localDocker.Get(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	c := chaosClient(cfg)
+	c.AdminURL = cfg.AdminURL
+	aggregateID := client.UniqueID("e2e-dlq-poison")
+	decommissionAfterTest(ctx, c, aggregateID)
+
+	// 1. Make every projection write of this aggregate fail, as a bug in a
+	// handler would. NOT VALID leaves existing rows unchecked.
+	if err := psql(ctx, docker, cfg, fmt.Sprintf(
+		`ALTER TABLE projections DROP CONSTRAINT IF EXISTS %[1]s, ADD CONSTRAINT %[1]s CHECK (aggregate_id <> '%[2]s') NOT VALID`,
+		poisonConstraint, aggregateID)); err != nil {
+		return fmt.Errorf("failed to add the poison constraint: %w", err)
+	}
+	dropConstraint := func(ctx context.Context) error {
+		return psql(ctx, docker, cfg, fmt.Sprintf(`ALTER TABLE projections DROP CONSTRAINT IF EXISTS %s`, poisonConstraint))
+	}
+	runner.Cleanup(ctx, dropConstraint)
+
+	// 2. The event fails every attempt and is quarantined
+	resp, err := client.IngestEvent(ctx, c, &client.IngestRequest{
+		EventType:   "sensor.reading",
+		AggregateID: aggregateID,
+		Payload:     map[string]interface{}{"value": 1.0, "unit": "fahrenheit"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ingest the poison reading: %w", err)
+	}
+	quarantined, err := waitForQuarantine(ctx, c, resp.EventID, quarantineWait)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(quarantined.Error, poisonConstraint) {
+		return fmt.Errorf("expected the quarantined event's error to name %s, got %q", poisonConstraint, quarantined.Error)
+	}
+	if quarantined.Attempts < 1 || quarantined.Topic == "" || len(quarantined.Event) == 0 {
+		return fmt.Errorf("quarantined event is missing its attempts, topic or envelope: %+v", quarantined)
+	}
+
+	// 3. The consumer has moved on: once the fault is gone, the aggregate's
+	// next event is applied, and the quarantined one is not
+	if err := dropConstraint(ctx); err != nil {
+		return fmt.Errorf("failed to drop the poison constraint: %w", err)
+	}
+	if err := ingestReading(ctx, c, aggregateID, 2.0); err != nil {
+		return err
+	}
+	if err := waitForSensorValue(ctx, c, aggregateID, 2.0, chaosRecovery); err != nil {
+		return fmt.Errorf("event after the quarantined one not applied: %w", err)
+	}
+	return nil
+}
+
+// waitForQuarantine polls the event handler's DLQ until eventID is listed.
+func waitForQuarantine(ctx context.Context, c *client.Config, eventID string, timeout time.Duration) (*client.QuarantinedEvent, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		quarantined, err := client.ListQuarantined(ctx, c, "event-handler")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list quarantined events: %w", err)
+		}
+		for _, q := range quarantined {
+			if q.EventID == eventID {
+				return &q, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("event %s not quarantined within %s", eventID, timeout)
+		}
+		if err := sleep(ctx, time.Second); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// psql runs a statement in the local Postgres container, as the compose
+// environment's database user.
+func psql(ctx context.Context, docker *runner.Docker, cfg *runner.Config, statement string) error {
+	_, err := docker.Exec(ctx, cfg.PostgresContainer,
+		"psql", "-U", "cornjacket", "-d", "cornjacket", "-v", "ON_ERROR_STOP=1", "-c", statement)
+	return err
+}
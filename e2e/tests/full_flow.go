@@ -15,17 +15,20 @@ func init() {
 		Name:        "full-flow",
 		Description: "Complete flow: ingest event, update with newer event, verify state",
 		Run:         runFullFlowTest,
+		Tags:        []string{runner.TagSlow},
+		Retries:     1, // waits on several eventually-consistent projection updates
 	})
 }
 
 func runFullFlowTest(ctx context.Context, cfg *runner.Config) error {
-	c := &client.Config{
-		IngestionURL: cfg.IngestionURL,
-		QueryURL:     cfg.QueryURL,
+	c, err := apiClient.Get(ctx, cfg)
+	if err != nil {
+		return err
 	}
 
 	// Generate unique aggregate ID for test isolation
 	aggregateID := client.UniqueID("e2e-sensor")
+	decommissionAfterTest(ctx, c, aggregateID)
 
 	// 1. Ingest initial event
 	req1 := &client.IngestRequest{
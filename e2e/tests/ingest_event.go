@@ -15,17 +15,19 @@ func init() {
 		Name:        "ingest-event",
 		Description: "Ingest an event and verify it creates a projection",
 		Run:         runIngestEventTest,
+		Tags:        []string{runner.TagSmoke},
 	})
 }
 
 func runIngestEventTest(ctx context.Context, cfg *runner.Config) error {
-	c := &client.Config{
-		IngestionURL: cfg.IngestionURL,
-		QueryURL:     cfg.QueryURL,
+	c, err := apiClient.Get(ctx, cfg)
+	if err != nil {
+		return err
 	}
 
 	// Generate unique aggregate ID for test isolation
 	aggregateID := client.UniqueID("e2e-device")
+	decommissionAfterTest(ctx, c, aggregateID)
 
 	// 1. Ingest a sensor.reading event
 	req := &client.IngestRequest{
@@ -14,13 +14,14 @@ func init() {
 		Name:        "query-projection",
 		Description: "Query projections by type and verify list pagination",
 		Run:         runQueryProjectionTest,
+		Tags:        []string{runner.TagSmoke},
 	})
 }
 
 func runQueryProjectionTest(ctx context.Context, cfg *runner.Config) error {
-	c := &client.Config{
-		IngestionURL: cfg.IngestionURL,
-		QueryURL:     cfg.QueryURL,
+	c, err := apiClient.Get(ctx, cfg)
+	if err != nil {
+		return err
 	}
 
 	// Generate unique aggregate IDs for test isolation
@@ -45,7 +46,7 @@ func runQueryProjectionTest(ctx context.Context, cfg *runner.Config) error {
 	}
 
 	// 2. Wait for projections to be created
-	_, err := client.WaitForProjection(ctx, c, "user_session", aggregateID1, 5*time.Second)
+	_, err = client.WaitForProjection(ctx, c, "user_session", aggregateID1, 5*time.Second)
 	if err != nil {
 		return fmt.Errorf("projection 1 not created: %w", err)
 	}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cornjacket/platform-services/e2e/client"
+	"github.com/cornjacket/platform-services/e2e/runner"
+)
+
+func init() {
+	runner.BeforeAll("platform-healthy", checkPlatformHealthy)
+}
+
+// checkPlatformHealthy fails the run up front when a service is down, rather
+// than letting every test wait out its timeout.
+func checkPlatformHealthy(ctx context.Context, cfg *runner.Config) error {
+	if err := client.CheckHealth(ctx, cfg.IngestionURL); err != nil {
+		return fmt.Errorf("ingestion service at %s: %w", cfg.IngestionURL, err)
+	}
+	if err := client.CheckHealth(ctx, cfg.QueryURL); err != nil {
+		return fmt.Errorf("query service at %s: %w", cfg.QueryURL, err)
+	}
+	return nil
+}
+
+// apiClient is the client configuration the tests share. Its one circuit
+// breaker makes the remaining tests fail fast if the deployment goes down
+// mid-run.
+var apiClient = &runner.Fixture[*client.Config]{
+	Name: "api-client",
+	Setup: func(ctx context.Context, cfg *runner.Config) (*client.Config, error) {
+		return &client.Config{
+			IngestionURL: cfg.IngestionURL,
+			QueryURL:     cfg.QueryURL,
+			ActionsURL:   cfg.ActionsURL,
+			APIKey:       cfg.APIKey,
+			Breaker: client.NewCircuitBreaker(client.BreakerPolicy{
+				FailureThreshold: 5,
+				OpenDuration:     30 * time.Second,
+			}),
+		}, nil
+	},
+}
+
+// decommissionAfterTest registers a cleanup that ingests a
+// sensor.decommissioned tombstone for aggregateID, so the test's sensor
+// projection is deleted from shared environments once the test finishes.
+// Deleted projections stay listed with ?deleted=true for inspection.
+func decommissionAfterTest(ctx context.Context, c *client.Config, aggregateID string) {
+	runner.Cleanup(ctx, func(ctx context.Context) error {
+		_, err := client.IngestEvent(ctx, c, &client.IngestRequest{
+			EventType:   "sensor.decommissioned",
+			AggregateID: aggregateID,
+			Payload:     map[string]interface{}{"reason": "e2e cleanup"},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to decommission %s: %w", aggregateID, err)
+		}
+		return nil
+	})
+}
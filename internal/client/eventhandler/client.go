@@ -3,27 +3,32 @@ package eventhandler
 import (
 	"context"
 	"log/slog"
-	"strings"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/infra/redpanda"
 )
 
 // EventPublisher publishes events to the message bus.
 type EventPublisher interface {
 	Publish(ctx context.Context, topic string, event *events.Envelope) error
+	PublishAsync(ctx context.Context, topic string, event *events.Envelope, onDelivery func(error))
+	PublishTransaction(ctx context.Context, msgs []redpanda.Message) error
 }
 
 // Client provides methods for submitting events to the EventHandler service.
 // It wraps the underlying message bus (Redpanda) to provide a service-level abstraction.
 type Client struct {
 	publisher EventPublisher
+	router    *Router
 	logger    *slog.Logger
 }
 
 // New creates a new EventHandler client.
-func New(publisher EventPublisher, logger *slog.Logger) *Client {
+// The router decides which topic each event type is published to.
+func New(publisher EventPublisher, router *Router, logger *slog.Logger) *Client {
 	return &Client{
 		publisher: publisher,
+		router:    router,
 		logger:    logger.With("client", "eventhandler"),
 	}
 }
@@ -31,7 +36,7 @@ func New(publisher EventPublisher, logger *slog.Logger) *Client {
 // SubmitEvent sends an event to the EventHandler for processing.
 // The event will be routed to the appropriate topic based on its type.
 func (c *Client) SubmitEvent(ctx context.Context, event *events.Envelope) error {
-	topic := topicFromEventType(event.EventType)
+	topic := c.router.Topic(event.EventType)
 
 	if err := c.publisher.Publish(ctx, topic, event); err != nil {
 		c.logger.Error("failed to submit event",
@@ -52,14 +57,48 @@ func (c *Client) SubmitEvent(ctx context.Context, event *events.Envelope) error
 	return nil
 }
 
-// topicFromEventType derives the Redpanda topic from the event type.
-func topicFromEventType(eventType string) string {
-	switch {
-	case strings.HasPrefix(eventType, "sensor."):
-		return "sensor-events"
-	case strings.HasPrefix(eventType, "user."):
-		return "user-actions"
-	default:
-		return "system-events"
+// SubmitEventAsync queues an event for batched delivery to the EventHandler.
+// onDone is called once with the delivery result; callers must not treat the
+// event as submitted until then.
+func (c *Client) SubmitEventAsync(ctx context.Context, event *events.Envelope, onDone func(error)) {
+	topic := c.router.Topic(event.EventType)
+
+	c.publisher.PublishAsync(ctx, topic, event, func(err error) {
+		if err != nil {
+			c.logger.Error("failed to submit event",
+				"event_id", event.EventID,
+				"event_type", event.EventType,
+				"topic", topic,
+				"error", err,
+			)
+		} else {
+			c.logger.Debug("event submitted to EventHandler",
+				"event_id", event.EventID,
+				"event_type", event.EventType,
+				"topic", topic,
+			)
+		}
+		onDone(err)
+	})
+}
+
+// SubmitEventBatch sends events to the EventHandler atomically: either every
+// event becomes visible to consumers or none does. Each event is routed to its
+// own topic within the same transaction.
+func (c *Client) SubmitEventBatch(ctx context.Context, batch []*events.Envelope) error {
+	msgs := make([]redpanda.Message, len(batch))
+	for i, event := range batch {
+		msgs[i] = redpanda.Message{Topic: c.router.Topic(event.EventType), Event: event}
+	}
+
+	if err := c.publisher.PublishTransaction(ctx, msgs); err != nil {
+		c.logger.Error("failed to submit event batch",
+			"events", len(batch),
+			"error", err,
+		)
+		return err
 	}
+
+	c.logger.Debug("event batch submitted to EventHandler", "events", len(batch))
+	return nil
 }
@@ -12,17 +12,28 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/infra/redpanda"
 )
 
 // mockEventPublisher implements EventPublisher for testing.
 type mockEventPublisher struct {
-	PublishFn func(ctx context.Context, topic string, event *events.Envelope) error
+	PublishFn      func(ctx context.Context, topic string, event *events.Envelope) error
+	PublishAsyncFn func(ctx context.Context, topic string, event *events.Envelope, onDelivery func(error))
+	PublishTxFn    func(ctx context.Context, msgs []redpanda.Message) error
 }
 
 func (m *mockEventPublisher) Publish(ctx context.Context, topic string, event *events.Envelope) error {
 	return m.PublishFn(ctx, topic, event)
 }
 
+func (m *mockEventPublisher) PublishAsync(ctx context.Context, topic string, event *events.Envelope, onDelivery func(error)) {
+	m.PublishAsyncFn(ctx, topic, event, onDelivery)
+}
+
+func (m *mockEventPublisher) PublishTransaction(ctx context.Context, msgs []redpanda.Message) error {
+	return m.PublishTxFn(ctx, msgs)
+}
+
 func TestSubmitEvent_Success(t *testing.T) {
 	var capturedTopic string
 	mock := &mockEventPublisher{
@@ -31,7 +42,7 @@ func TestSubmitEvent_Success(t *testing.T) {
 			return nil
 		},
 	}
-	client := New(mock, slog.Default())
+	client := New(mock, DefaultRouter(), slog.Default())
 
 	envelope, _ := events.NewEnvelope(
 		"sensor.reading", "device-001",
@@ -50,7 +61,7 @@ func TestSubmitEvent_PublishError(t *testing.T) {
 			return fmt.Errorf("broker unavailable")
 		},
 	}
-	client := New(mock, slog.Default())
+	client := New(mock, DefaultRouter(), slog.Default())
 
 	envelope, _ := events.NewEnvelope(
 		"sensor.reading", "device-001",
@@ -62,7 +73,53 @@ func TestSubmitEvent_PublishError(t *testing.T) {
 	assert.Error(t, err)
 }
 
-func TestTopicFromEventType(t *testing.T) {
+func TestSubmitEventAsync_DeliveryResult(t *testing.T) {
+	var capturedTopic string
+	mock := &mockEventPublisher{
+		PublishAsyncFn: func(ctx context.Context, topic string, event *events.Envelope, onDelivery func(error)) {
+			capturedTopic = topic
+			onDelivery(fmt.Errorf("broker unavailable"))
+		},
+	}
+	client := New(mock, DefaultRouter(), slog.Default())
+
+	envelope, _ := events.NewEnvelope(
+		"user.login", "user-001",
+		json.RawMessage(`{"user_id": "user-001"}`),
+		events.Metadata{Source: "test"}, time.Now(),
+	)
+
+	var result error
+	client.SubmitEventAsync(context.Background(), envelope, func(err error) {
+		result = err
+	})
+	assert.Equal(t, "user-actions", capturedTopic)
+	assert.ErrorContains(t, result, "broker unavailable")
+}
+
+func TestSubmitEventBatch_RoutesEachEvent(t *testing.T) {
+	var captured []redpanda.Message
+	mock := &mockEventPublisher{
+		PublishTxFn: func(ctx context.Context, msgs []redpanda.Message) error {
+			captured = msgs
+			return nil
+		},
+	}
+	client := New(mock, DefaultRouter(), slog.Default())
+
+	sensor, _ := events.NewEnvelope("sensor.reading", "device-001",
+		json.RawMessage(`{"value": 72.5}`), events.Metadata{Source: "test"}, time.Now())
+	login, _ := events.NewEnvelope("user.login", "user-001",
+		json.RawMessage(`{"user_id": "user-001"}`), events.Metadata{Source: "test"}, time.Now())
+
+	require.NoError(t, client.SubmitEventBatch(context.Background(), []*events.Envelope{sensor, login}))
+	require.Len(t, captured, 2)
+	assert.Equal(t, "sensor-events", captured[0].Topic)
+	assert.Equal(t, "user-actions", captured[1].Topic)
+	assert.Equal(t, login.EventID, captured[1].Event.EventID)
+}
+
+func TestDefaultRouter(t *testing.T) {
 	tests := []struct {
 		eventType string
 		want      string
@@ -81,7 +138,46 @@ func TestTopicFromEventType(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.eventType, func(t *testing.T) {
-			assert.Equal(t, tt.want, topicFromEventType(tt.eventType))
+			assert.Equal(t, tt.want, DefaultRouter().Topic(tt.eventType))
 		})
 	}
 }
+
+func TestRouter_LongestPrefixWins(t *testing.T) {
+	router, err := NewRouter([]Route{
+		{Prefix: "sensor.", Topic: "sensor-events"},
+		{Prefix: "sensor.alert", Topic: "sensor-alerts"},
+	}, "system-events")
+	require.NoError(t, err)
+
+	assert.Equal(t, "sensor-alerts", router.Topic("sensor.alert.high"))
+	assert.Equal(t, "sensor-events", router.Topic("sensor.reading"))
+	assert.Equal(t, "system-events", router.Topic("billing.invoice"))
+	assert.Equal(t, []string{"sensor-alerts", "sensor-events", "system-events"}, router.Topics())
+}
+
+func TestNewRouter_Validation(t *testing.T) {
+	_, err := NewRouter(nil, "")
+	assert.ErrorContains(t, err, "default topic is required")
+
+	_, err = NewRouter([]Route{{Prefix: "sensor.", Topic: ""}}, "system-events")
+	assert.ErrorContains(t, err, "prefix and topic are required")
+
+	_, err = NewRouter([]Route{
+		{Prefix: "sensor.", Topic: "a"},
+		{Prefix: "sensor.", Topic: "b"},
+	}, "system-events")
+	assert.ErrorContains(t, err, "duplicate route prefix")
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes(" sensor.=sensor-events, billing.=billing-events ,")
+	require.NoError(t, err)
+	assert.Equal(t, []Route{
+		{Prefix: "sensor.", Topic: "sensor-events"},
+		{Prefix: "billing.", Topic: "billing-events"},
+	}, routes)
+
+	_, err = ParseRoutes("sensor.")
+	assert.ErrorContains(t, err, "expected prefix=topic")
+}
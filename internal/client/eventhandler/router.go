@@ -0,0 +1,104 @@
+package eventhandler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultTopicRoutes is the built-in prefix → topic mapping used when none is configured.
+const DefaultTopicRoutes = "sensor.=sensor-events,user.=user-actions"
+
+// DefaultTopic receives events whose type matches no route.
+const DefaultTopic = "system-events"
+
+// Route maps an event type prefix to a Redpanda topic.
+type Route struct {
+	Prefix string
+	Topic  string
+}
+
+// Router resolves the destination topic for an event type.
+// The longest matching prefix wins; unmatched types go to the default topic.
+type Router struct {
+	routes       []Route // sorted by prefix length, longest first
+	defaultTopic string
+}
+
+// NewRouter creates a Router from the given routes and default topic.
+// Returns an error if any route is incomplete or a prefix is repeated.
+func NewRouter(routes []Route, defaultTopic string) (*Router, error) {
+	if defaultTopic == "" {
+		return nil, fmt.Errorf("default topic is required")
+	}
+
+	seen := make(map[string]bool, len(routes))
+	sorted := make([]Route, 0, len(routes))
+	for _, r := range routes {
+		if r.Prefix == "" || r.Topic == "" {
+			return nil, fmt.Errorf("invalid route %q=%q: prefix and topic are required", r.Prefix, r.Topic)
+		}
+		if seen[r.Prefix] {
+			return nil, fmt.Errorf("duplicate route prefix %q", r.Prefix)
+		}
+		seen[r.Prefix] = true
+		sorted = append(sorted, r)
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+
+	return &Router{routes: sorted, defaultTopic: defaultTopic}, nil
+}
+
+// ParseRoutes parses a comma-separated "prefix=topic" list (e.g., CJ_TOPIC_ROUTES).
+func ParseRoutes(spec string) ([]Route, error) {
+	var routes []Route
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		prefix, topic, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid route %q: expected prefix=topic", pair)
+		}
+		routes = append(routes, Route{
+			Prefix: strings.TrimSpace(prefix),
+			Topic:  strings.TrimSpace(topic),
+		})
+	}
+	return routes, nil
+}
+
+// DefaultRouter returns the built-in routing (sensor.* / user.* / everything else).
+func DefaultRouter() *Router {
+	routes, _ := ParseRoutes(DefaultTopicRoutes)
+	router, _ := NewRouter(routes, DefaultTopic)
+	return router
+}
+
+// Topic returns the destination topic for the given event type.
+func (r *Router) Topic(eventType string) string {
+	for _, route := range r.routes {
+		if strings.HasPrefix(eventType, route.Prefix) {
+			return route.Topic
+		}
+	}
+	return r.defaultTopic
+}
+
+// Topics returns every distinct topic the router can emit to, including the default.
+func (r *Router) Topics() []string {
+	seen := map[string]bool{r.defaultTopic: true}
+	topics := []string{r.defaultTopic}
+	for _, route := range r.routes {
+		if !seen[route.Topic] {
+			seen[route.Topic] = true
+			topics = append(topics, route.Topic)
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
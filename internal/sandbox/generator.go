@@ -0,0 +1,111 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/services/ingestion"
+)
+
+// Ingester accepts ingestion requests.
+// This interface is satisfied by ingestion.Service.
+type Ingester interface {
+	Ingest(ctx context.Context, req *ingestion.IngestRequest) (*ingestion.IngestResponse, error)
+}
+
+// Generator produces a realistic stream of sensor and user events.
+// Sensor readings follow a bounded random walk per device; users alternate
+// between login and logout.
+type Generator struct {
+	ingester Ingester
+	interval time.Duration
+	rng      *rand.Rand
+	logger   *slog.Logger
+
+	sensors []*simSensor
+	users   []*simUser
+}
+
+type simSensor struct {
+	id    string
+	value float64
+}
+
+type simUser struct {
+	id       string
+	loggedIn bool
+}
+
+// NewGenerator creates a generator that ingests one event per interval.
+func NewGenerator(ingester Ingester, interval time.Duration, logger *slog.Logger) *Generator {
+	g := &Generator{
+		ingester: ingester,
+		interval: interval,
+		rng:      rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0)),
+		logger:   logger.With("component", "sandbox-generator"),
+	}
+	for i := 1; i <= 5; i++ {
+		g.sensors = append(g.sensors, &simSensor{id: fmt.Sprintf("sandbox-device-%03d", i), value: 68 + float64(i)})
+	}
+	for i := 1; i <= 3; i++ {
+		g.users = append(g.users, &simUser{id: fmt.Sprintf("sandbox-user-%03d", i)})
+	}
+	return g
+}
+
+// Run ingests events until ctx is cancelled.
+func (g *Generator) Run(ctx context.Context) {
+	g.logger.Info("starting synthetic event generator", "interval", g.interval)
+
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			req := g.Next()
+			if _, err := g.ingester.Ingest(ctx, req); err != nil {
+				g.logger.Error("failed to ingest generated event", "event_type", req.EventType, "error", err)
+			}
+		}
+	}
+}
+
+// Next builds the next synthetic event. Roughly four in five are sensor readings.
+func (g *Generator) Next() *ingestion.IngestRequest {
+	if g.rng.IntN(5) < 4 {
+		s := g.sensors[g.rng.IntN(len(g.sensors))]
+		s.value += g.rng.Float64()*2 - 1
+		s.value = min(max(s.value, 50), 95)
+		return g.request("sensor.reading", s.id, map[string]any{
+			"value": float64(int(s.value*10)) / 10,
+			"unit":  "fahrenheit",
+		})
+	}
+
+	u := g.users[g.rng.IntN(len(g.users))]
+	u.loggedIn = !u.loggedIn
+	eventType := "user.logout"
+	if u.loggedIn {
+		eventType = "user.login"
+	}
+	return g.request(eventType, u.id, map[string]any{
+		"user_id": u.id,
+		"ip":      fmt.Sprintf("10.0.0.%d", g.rng.IntN(254)+1),
+	})
+}
+
+func (g *Generator) request(eventType, aggregateID string, payload map[string]any) *ingestion.IngestRequest {
+	body, _ := json.Marshal(payload)
+	return &ingestion.IngestRequest{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     body,
+	}
+}
@@ -0,0 +1,95 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/services/eventhandler"
+	"github.com/cornjacket/platform-services/internal/services/ingestion"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// mockIngester implements Ingester for testing.
+type mockIngester struct {
+	mu       sync.Mutex
+	requests []*ingestion.IngestRequest
+}
+
+func (m *mockIngester) Ingest(ctx context.Context, req *ingestion.IngestRequest) (*ingestion.IngestResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, req)
+	return &ingestion.IngestResponse{Status: "accepted"}, nil
+}
+
+func (m *mockIngester) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.requests)
+}
+
+func TestGenerator_Next(t *testing.T) {
+	gen := NewGenerator(&mockIngester{}, time.Second, slog.Default())
+
+	for i := 0; i < 200; i++ {
+		req := gen.Next()
+		require.NotEmpty(t, req.AggregateID)
+
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal(req.Payload, &payload))
+
+		switch {
+		case req.EventType == "sensor.reading":
+			value := payload["value"].(float64)
+			assert.GreaterOrEqual(t, value, 50.0)
+			assert.LessOrEqual(t, value, 95.0)
+		case strings.HasPrefix(req.EventType, "user."):
+			assert.Equal(t, req.AggregateID, payload["user_id"])
+		default:
+			t.Fatalf("unexpected event type %q", req.EventType)
+		}
+	}
+}
+
+func TestGenerator_Run(t *testing.T) {
+	ingester := &mockIngester{}
+	gen := NewGenerator(ingester, 5*time.Millisecond, slog.Default())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		gen.Run(ctx)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return ingester.count() >= 3 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestDirectOutbox_ProjectsEvent(t *testing.T) {
+	store := projections.NewMemoryStore()
+	registry := eventhandler.NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", eventhandler.NewSensorHandler(store, slog.Default()))
+
+	svc := ingestion.NewService(&directOutbox{registry: registry, upcasters: events.NewUpcasters()}, slog.Default())
+	_, err := svc.Ingest(context.Background(), &ingestion.IngestRequest{
+		EventType:   "sensor.reading",
+		AggregateID: "device-001",
+		Payload:     json.RawMessage(`{"value": 71.2}`),
+	})
+	require.NoError(t, err)
+
+	p, err := store.GetProjection(context.Background(), "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value": 71.2}`, string(p.State))
+}
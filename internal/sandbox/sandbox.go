@@ -0,0 +1,114 @@
+// Package sandbox runs the platform services with in-memory backends and a
+// synthetic event generator, so frontend and rule developers can work against
+// live-looking data with no Postgres or Redpanda.
+//
+// Event flow in sandbox mode:
+//
+//	generator / HTTP → ingestion.Service → directOutbox → HandlerRegistry → MemoryStore ← query.Service
+//
+// The outbox, worker, and message bus are collapsed into a synchronous dispatch.
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/services/eventhandler"
+	"github.com/cornjacket/platform-services/internal/services/ingestion"
+	"github.com/cornjacket/platform-services/internal/services/query"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/httpserver"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// Config holds configuration for sandbox mode.
+type Config struct {
+	PortIngestion int
+	PortQuery     int
+	Server        httpserver.Config
+
+	// EventInterval is the delay between generated events. Zero disables the generator.
+	EventInterval time.Duration
+
+	// Anomaly configures sensor anomaly flags, as in the event handler.
+	Anomaly eventhandler.AnomalyConfig
+}
+
+// directOutbox implements ingestion.OutboxRepository by dispatching straight to
+// the event handler registry, standing in for outbox → worker → Redpanda → consumer.
+type directOutbox struct {
+	registry  *eventhandler.HandlerRegistry
+	upcasters *events.Upcasters
+}
+
+// Insert upcasts and dispatches the event synchronously.
+func (o *directOutbox) Insert(ctx context.Context, event *events.Envelope) error {
+	if err := o.upcasters.Upcast(event); err != nil {
+		return err
+	}
+	return o.registry.Dispatch(ctx, event)
+}
+
+// Run starts the sandbox and blocks until ctx is cancelled.
+func Run(ctx context.Context, cfg Config, logger *slog.Logger) error {
+	logger = logger.With("mode", "sandbox")
+
+	store := projections.NewMemoryStore()
+
+	// Event handler: same handlers as eventhandler.Start, writing to memory
+	registry := eventhandler.NewHandlerRegistry(logger)
+	sensorHandler := eventhandler.NewSensorHandler(store, logger)
+	sensorHandler.SetAnomalyDetection(store, cfg.Anomaly)
+	sensorHandler.SetDeleter(store)
+	registry.Register("sensor.", "sensor", sensorHandler)
+	registry.Register("user.", "user", eventhandler.NewUserHandler(store, logger))
+
+	// Ingestion → direct dispatch
+	ingestSvc := ingestion.NewService(&directOutbox{registry: registry, upcasters: events.NewUpcasters()}, logger)
+	ingestMux := http.NewServeMux()
+	ingestion.NewHandler(ingestSvc, logger).RegisterRoutes(ingestMux)
+
+	// Query ← memory store
+	queryMux := http.NewServeMux()
+	query.NewHandler(query.NewService(store, logger), logger).RegisterRoutes(queryMux)
+
+	servers := []*http.Server{
+		httpserver.New(cfg.PortIngestion, ingestMux, cfg.Server),
+		httpserver.New(cfg.PortQuery, queryMux, cfg.Server),
+	}
+
+	errCh := make(chan error, len(servers))
+	for _, server := range servers {
+		go func(server *http.Server) {
+			logger.Info("starting sandbox server", "addr", server.Addr)
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				errCh <- fmt.Errorf("sandbox server %s failed: %w", server.Addr, err)
+			}
+		}(server)
+	}
+
+	if cfg.EventInterval > 0 {
+		gen := NewGenerator(ingestSvc, cfg.EventInterval, logger)
+		go gen.Run(ctx)
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case runErr = <-errCh:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("sandbox server shutdown error", "addr", server.Addr, "error", err)
+		}
+	}
+
+	logger.Info("sandbox stopped")
+	return runErr
+}
@@ -0,0 +1,147 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cornjacket/platform-services/internal/shared/audit"
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/httpserver"
+	"github.com/cornjacket/platform-services/internal/shared/infra/postgres"
+	"github.com/cornjacket/platform-services/internal/shared/middleware"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
+)
+
+// Config holds configuration for the actions service.
+type Config struct {
+	Port          int
+	Server        httpserver.Config
+	Brokers       []string
+	ConsumerGroup string
+	ClientID      string // Kafka client ID; carries the build version (see platform preflight)
+	Topics        []string
+	BrokerOpts    []kgo.Opt         // TLS and SASL (see redpanda.Auth)
+	Audit         audit.Log         // records rule changes; nil disables auditing
+	Policy        *auth.Policy      // API key scopes for the rule API; nil allows every request
+	Verifier      *signing.Verifier // checks event signatures before rules are evaluated; nil accepts all
+	Registry      registry.Reader   // resolves rule conditions on registry fields; nil: they never hold
+
+	// RuleReloadInterval is how often the rule set is reloaded from the
+	// database, picking up changes made through other instances.
+	RuleReloadInterval time.Duration
+
+	// Webhook configures the "webhook" action type, which is only available
+	// when Webhook.Secret is set.
+	Webhook WebhookConfig
+
+	// Notify configures the notification action types.
+	Notify NotifyConfig
+}
+
+// NotifyConfig configures the notification backends. Slack and PagerDuty are
+// always available; email only when SMTP.Host is set.
+type NotifyConfig struct {
+	Timeout      time.Duration // per request (Slack, PagerDuty)
+	PagerDutyURL string        // Events API endpoint; empty uses DefaultPagerDutyURL
+	SMTP         EmailConfig
+}
+
+// RunningService represents a started actions service.
+type RunningService struct {
+	// Shutdown stops the HTTP server and consumer gracefully.
+	Shutdown func(ctx context.Context) error
+}
+
+// Start starts the actions HTTP server (rule management), the rule reloader,
+// and the event consumer that evaluates rules.
+// It creates the rules store from the provided pool and wires the service internally.
+func Start(ctx context.Context, cfg Config, pool *pgxpool.Pool, logger *slog.Logger, errorCh chan<- error) (*RunningService, error) {
+	logger = logger.With("service", "actions")
+
+	store := rules.NewPostgresStore(pool, logger)
+
+	// Wire engine with the available action types
+	engine := NewEngine(store, logger)
+	engine.SetExecutionRecorder(store)
+	if cfg.Registry != nil {
+		engine.SetRegistry(cfg.Registry)
+	}
+	engine.RegisterDispatcher(ActionLog, NewLogDispatcher(logger))
+	if cfg.Webhook.Secret != "" {
+		engine.RegisterDispatcher(ActionWebhook, NewWebhookDispatcher(cfg.Webhook, store, logger))
+	} else {
+		logger.Info("webhook action type disabled: no signing secret configured")
+	}
+	engine.RegisterDispatcher(ActionSlack, NewNotifyDispatcher(ActionSlack, NewSlackNotifier(cfg.Notify.Timeout), store, logger))
+	engine.RegisterDispatcher(ActionPagerDuty, NewNotifyDispatcher(ActionPagerDuty,
+		NewPagerDutyNotifier(cfg.Notify.PagerDutyURL, cfg.Notify.Timeout), store, logger))
+	if cfg.Notify.SMTP.Host != "" {
+		engine.RegisterDispatcher(ActionEmail, NewNotifyDispatcher(ActionEmail, NewEmailNotifier(cfg.Notify.SMTP), store, logger))
+	} else {
+		logger.Info("email action type disabled: no SMTP host configured")
+	}
+	if err := engine.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	// Wire service → handler → routes → HTTP server
+	handler := NewHandler(NewService(store, store, engine, logger), logger)
+	handler.SetPolicy(cfg.Policy)
+	recorder := audit.NewRecorder(cfg.Audit, "actions", logger)
+	recorder.SetPolicy(cfg.Policy)
+	handler.SetAudit(recorder)
+
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	mux.Handle("/readyz", postgres.ReadyHandler(pool, logger))
+
+	server := httpserver.New(cfg.Port, middleware.Wrap(mux, logger), cfg.Server)
+
+	consumer, err := NewConsumer(engine, ConsumerConfig{
+		Brokers:  cfg.Brokers,
+		GroupID:  cfg.ConsumerGroup,
+		ClientID: cfg.ClientID,
+		Topics:   cfg.Topics,
+		Opts:     cfg.BrokerOpts,
+		Verifier: cfg.Verifier,
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create actions consumer: %w", err)
+	}
+
+	// Start HTTP server
+	go func() {
+		logger.Info("starting actions server", "port", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("actions server error", "error", err)
+			errorCh <- fmt.Errorf("actions server failed: %w", err)
+		}
+	}()
+
+	// Start rule reloader and consumer
+	go engine.Run(ctx, cfg.RuleReloadInterval)
+	go func() {
+		if err := consumer.Start(ctx); err != nil {
+			logger.Error("actions consumer error", "error", err)
+		}
+	}()
+
+	return &RunningService{
+		Shutdown: func(shutdownCtx context.Context) error {
+			logger.Info("shutting down actions service")
+			err := server.Shutdown(shutdownCtx)
+			if closeErr := consumer.Close(); closeErr != nil {
+				err = closeErr
+			}
+			return err
+		},
+	}, nil
+}
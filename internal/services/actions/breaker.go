@@ -0,0 +1,44 @@
+package actions
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/retry"
+)
+
+// ErrCircuitOpen is returned without contacting a destination while its
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// breakerSet holds one circuit breaker per destination, so one failing
+// endpoint does not hold back deliveries to the others.
+type breakerSet struct {
+	threshold int
+	openFor   time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*retry.Breaker
+}
+
+func newBreakerSet(threshold int, openFor time.Duration) *breakerSet {
+	return &breakerSet{
+		threshold: threshold,
+		openFor:   openFor,
+		breakers:  make(map[string]*retry.Breaker),
+	}
+}
+
+// get returns the breaker of a destination, creating a closed one if needed.
+func (s *breakerSet) get(destination string) *retry.Breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.breakers[destination]
+	if !ok {
+		b = retry.NewBreaker(s.threshold, s.openFor)
+		s.breakers[destination] = b
+	}
+	return b
+}
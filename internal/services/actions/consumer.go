@@ -0,0 +1,154 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
+)
+
+// ConsumerConfig holds configuration for the actions event consumer.
+type ConsumerConfig struct {
+	Brokers  []string
+	GroupID  string
+	ClientID string // reported to the broker; empty uses the kgo default
+	Topics   []string
+	Opts     []kgo.Opt         // connection options such as TLS and SASL (see redpanda.Auth)
+	Verifier *signing.Verifier // rejects events with a bad signature; nil accepts all
+}
+
+// Consumer consumes events from Redpanda and evaluates them against the
+// rules. It has its own consumer group, so it reads every event independently
+// of the event handler.
+type Consumer struct {
+	client *kgo.Client
+	engine *Engine
+	config ConsumerConfig
+	logger *slog.Logger
+}
+
+// NewConsumer creates a new actions event consumer.
+func NewConsumer(engine *Engine, config ConsumerConfig, logger *slog.Logger) (*Consumer, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(config.Brokers...),
+		kgo.ConsumerGroup(config.GroupID),
+		kgo.ConsumeTopics(config.Topics...),
+		kgo.DisableAutoCommit(),
+		// Skip records from aborted outbox transactions (see redpanda.PublishTransaction)
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+	}
+	if config.ClientID != "" {
+		opts = append(opts, kgo.ClientID(config.ClientID))
+	}
+	opts = append(opts, config.Opts...)
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Consumer{
+		client: client,
+		engine: engine,
+		config: config,
+		logger: logger.With("component", "actions-consumer"),
+	}, nil
+}
+
+// Start begins consuming events and blocks until context is cancelled.
+func (c *Consumer) Start(ctx context.Context) error {
+	c.logger.Info("starting actions consumer",
+		"group_id", c.config.GroupID,
+		"topics", c.config.Topics,
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Info("actions consumer stopping")
+			return nil
+		default:
+		}
+
+		fetches := c.client.PollFetches(ctx)
+		if fetches.IsClientClosed() {
+			return nil
+		}
+		if ctx.Err() != nil {
+			continue // stopping; handled at the top of the loop
+		}
+
+		if errs := fetches.Errors(); len(errs) > 0 {
+			for _, err := range errs {
+				c.logger.Error("fetch error",
+					"topic", err.Topic,
+					"partition", err.Partition,
+					"error", err.Err,
+				)
+			}
+			continue
+		}
+
+		fetches.EachRecord(func(record *kgo.Record) {
+			c.processRecord(ctx, record)
+		})
+
+		if err := c.client.CommitUncommittedOffsets(ctx); err != nil {
+			c.logger.Error("failed to commit offsets", "error", err)
+		}
+	}
+}
+
+// processRecord evaluates a single Kafka record.
+func (c *Consumer) processRecord(ctx context.Context, record *kgo.Record) {
+	logger := c.logger.With(
+		"topic", record.Topic,
+		"partition", record.Partition,
+		"offset", record.Offset,
+	)
+
+	// Tombstones follow deletion events (see redpanda.ProducerConfig.Tombstones)
+	if record.Value == nil {
+		return
+	}
+
+	var event events.Envelope
+	if err := json.Unmarshal(record.Value, &event); err != nil {
+		logger.Error("failed to deserialize event", "error", err)
+		return
+	}
+	// A tampered event must not trigger actions
+	if err := c.config.Verifier.Verify(&event); err != nil {
+		logger.Error("event failed signature verification, skipping", "event_id", event.EventID, "error", err)
+		return
+	}
+	c.handle(ctx, &event, logger)
+}
+
+// handle evaluates one event. Replayed and backfilled events describe the
+// past, so they never trigger actions, but only when they come from a source
+// allowed to replay (events.Metadata.TrustedReplay). Otherwise any producer
+// could silence every rule by flagging its events as replays.
+func (c *Consumer) handle(ctx context.Context, event *events.Envelope, logger *slog.Logger) {
+	if event.Metadata.TrustedReplay() {
+		logger.Debug("replayed event, not evaluating rules", "event_id", event.EventID)
+		return
+	}
+	if matched := c.engine.Evaluate(ctx, event); matched > 0 {
+		logger.Debug("event matched rules",
+			"event_id", event.EventID,
+			"event_type", event.EventType,
+			"rules", matched,
+		)
+	}
+}
+
+// Close releases consumer resources.
+func (c *Consumer) Close() error {
+	c.client.Close()
+	c.logger.Info("actions consumer closed")
+	return nil
+}
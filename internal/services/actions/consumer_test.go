@@ -0,0 +1,104 @@
+package actions
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+func TestConsumer_HandleSkipsReplayedEvents(t *testing.T) {
+	rule := engineRule("record", "sensor.reading", nil)
+	lister := &mockRuleLister{
+		ListEnabledFn: func(ctx context.Context) ([]rules.Rule, error) {
+			return []rules.Rule{rule}, nil
+		},
+	}
+	dispatcher := &recordingDispatcher{}
+	engine := NewEngine(lister, slog.Default())
+	engine.RegisterDispatcher("record", dispatcher)
+	require.NoError(t, engine.Reload(context.Background()))
+	consumer := &Consumer{engine: engine, logger: slog.Default()}
+
+	for _, source := range []string{events.SourceReplay, events.SourceImport} {
+		replayed := testutil.Event().Type("sensor.reading").Payload(`{}`).Build()
+		replayed.Metadata.Replay = true
+		replayed.Metadata.Source = source
+		consumer.handle(context.Background(), replayed, slog.Default())
+		assert.Empty(t, dispatcher.calls(), source)
+	}
+
+	consumer.handle(context.Background(), testutil.Event().Type("sensor.reading").Payload(`{}`).Build(), slog.Default())
+	assert.Equal(t, []uuid.UUID{rule.RuleID}, dispatcher.calls())
+}
+
+func TestConsumer_HandleEvaluatesUntrustedReplays(t *testing.T) {
+	rule := engineRule("record", "sensor.reading", nil)
+	lister := &mockRuleLister{
+		ListEnabledFn: func(ctx context.Context) ([]rules.Rule, error) {
+			return []rules.Rule{rule}, nil
+		},
+	}
+	dispatcher := &recordingDispatcher{}
+	engine := NewEngine(lister, slog.Default())
+	engine.RegisterDispatcher("record", dispatcher)
+	require.NoError(t, engine.Reload(context.Background()))
+	consumer := &Consumer{engine: engine, logger: slog.Default()}
+
+	// A producer setting the replay flag itself, as X-Replay did before
+	// ingestion checked it, still fires rules
+	event := testutil.Event().Type("sensor.reading").Payload(`{}`).Build()
+	event.Metadata.Replay = true
+	event.Metadata.Source = events.SourceIngestion
+	consumer.handle(context.Background(), event, slog.Default())
+	assert.Equal(t, []uuid.UUID{rule.RuleID}, dispatcher.calls())
+}
+
+func TestConsumer_SkipsEventsFailingVerification(t *testing.T) {
+	rule := engineRule("record", "sensor.reading", nil)
+	lister := &mockRuleLister{
+		ListEnabledFn: func(ctx context.Context) ([]rules.Rule, error) {
+			return []rules.Rule{rule}, nil
+		},
+	}
+	dispatcher := &recordingDispatcher{}
+	engine := NewEngine(lister, slog.Default())
+	engine.RegisterDispatcher("record", dispatcher)
+	require.NoError(t, engine.Reload(context.Background()))
+
+	key, err := signing.ParseSigningKey("hmac:" + base64.StdEncoding.EncodeToString([]byte("actions-test-signing-key")))
+	require.NoError(t, err)
+	signer, err := signing.NewSigner(key)
+	require.NoError(t, err)
+	consumer := &Consumer{
+		engine: engine,
+		config: ConsumerConfig{Verifier: signing.NewVerifier([]signing.Key{signer.Key()}, false)},
+		logger: slog.Default(),
+	}
+	record := func(tamper bool) *kgo.Record {
+		event := testutil.Event().Type("sensor.reading").Payload(`{"value": 1}`).Build()
+		require.NoError(t, signer.Sign(event))
+		if tamper {
+			event.Payload = json.RawMessage(`{"value": 2}`)
+		}
+		value, err := json.Marshal(event)
+		require.NoError(t, err)
+		return &kgo.Record{Value: value}
+	}
+
+	consumer.processRecord(context.Background(), record(true))
+	assert.Empty(t, dispatcher.calls(), "a tampered event triggers nothing")
+	consumer.processRecord(context.Background(), record(false))
+	assert.Equal(t, []uuid.UUID{rule.RuleID}, dispatcher.calls())
+}
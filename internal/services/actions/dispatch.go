@@ -0,0 +1,37 @@
+package actions
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+)
+
+// ActionLog is the action type of LogDispatcher.
+const ActionLog = "log"
+
+// LogDispatcher records rule matches in the service log. Useful for trying
+// out a rule before pointing it at a real destination; the destination is
+// included in the log line as a free-form label.
+type LogDispatcher struct {
+	logger *slog.Logger
+}
+
+// NewLogDispatcher creates a dispatcher for the "log" action type.
+func NewLogDispatcher(logger *slog.Logger) *LogDispatcher {
+	return &LogDispatcher{logger: logger.With("dispatcher", ActionLog)}
+}
+
+// Dispatch logs the match.
+func (d *LogDispatcher) Dispatch(ctx context.Context, rule *rules.Rule, event *events.Envelope) error {
+	d.logger.Info("rule matched",
+		"rule_id", rule.RuleID,
+		"rule_name", rule.Name,
+		"destination", rule.Destination,
+		"event_id", event.EventID,
+		"event_type", event.EventType,
+		"aggregate_id", event.AggregateID,
+	)
+	return nil
+}
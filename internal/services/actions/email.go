@@ -0,0 +1,88 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// EmailConfig configures the SMTP server used by EmailNotifier.
+type EmailConfig struct {
+	Host     string // SMTP server; the email action type is unavailable without one
+	Port     int
+	Username string // empty disables authentication
+	Password string
+	From     string // sender address
+}
+
+// EmailNotifier sends notifications as plain-text email. The rule's
+// destination is a comma-separated list of recipient addresses; the subject
+// is the rule name.
+type EmailNotifier struct {
+	config   EmailConfig
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier creates an email notifier. The server must offer STARTTLS
+// when a username is set (see smtp.PlainAuth).
+func NewEmailNotifier(config EmailConfig) *EmailNotifier {
+	return &EmailNotifier{config: config, sendMail: smtp.SendMail}
+}
+
+// ValidateDestination requires a list of email addresses.
+func (e *EmailNotifier) ValidateDestination(destination string) error {
+	if _, err := mail.ParseAddressList(destination); err != nil {
+		return fmt.Errorf("email destination must be a comma-separated list of addresses: %w", err)
+	}
+	return nil
+}
+
+// Notify sends the email. net/smtp does not take a context, so a send in
+// progress is not interrupted by cancellation.
+func (e *EmailNotifier) Notify(ctx context.Context, destination string, n *Notification) error {
+	recipients, err := mail.ParseAddressList(destination)
+	if err != nil {
+		return fmt.Errorf("invalid recipients: %w", err)
+	}
+	to := make([]string, len(recipients))
+	header := make([]string, len(recipients))
+	for i, r := range recipients {
+		to[i] = r.Address
+		header[i] = r.String()
+	}
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	if err := e.sendMail(addr, auth, e.config.From, to, e.message(strings.Join(header, ", "), n)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// message builds the RFC 5322 message. The subject is Q-encoded and the
+// recipients re-formatted, so rule fields cannot inject headers.
+func (e *EmailNotifier) message(to string, n *Notification) []byte {
+	subject := mime.QEncoding.Encode("utf-8", "[cornjacket] "+n.Subject)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", clock.Now().Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(n.Text, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
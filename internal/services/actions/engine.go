@@ -0,0 +1,213 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+)
+
+// Engine evaluates events against the enabled rules and dispatches the
+// actions of the rules that match. The rule set is an in-memory snapshot,
+// replaced by Reload: after every change made through this instance's API,
+// and every reload interval to pick up changes made through other instances.
+type Engine struct {
+	lister      RuleLister
+	dispatchers map[string]Dispatcher
+	rules       atomic.Pointer[[]rules.Rule]
+	executions  ExecutionRecorder // optional; see SetExecutionRecorder
+	registry    registry.Reader   // optional; see SetRegistry
+	logger      *slog.Logger
+}
+
+// NewEngine creates an engine with an empty rule set and no dispatchers.
+func NewEngine(lister RuleLister, logger *slog.Logger) *Engine {
+	e := &Engine{
+		lister:      lister,
+		dispatchers: make(map[string]Dispatcher),
+		logger:      logger.With("component", "rule-engine"),
+	}
+	e.rules.Store(&[]rules.Rule{})
+	return e
+}
+
+// RegisterDispatcher makes an action type available to rules. Call before
+// the engine starts evaluating events.
+func (e *Engine) RegisterDispatcher(actionType string, d Dispatcher) {
+	e.dispatchers[actionType] = d
+}
+
+// SetExecutionRecorder records every rule that fires, with the outcome and
+// latency of its action, in the actions log. Call before the engine starts
+// evaluating events.
+func (e *Engine) SetExecutionRecorder(r ExecutionRecorder) {
+	e.executions = r
+}
+
+// SetRegistry resolves rule conditions on registry fields against the
+// registry entry of each event's aggregate. Without a registry those
+// conditions never hold. Call before the engine starts evaluating events.
+func (e *Engine) SetRegistry(reader registry.Reader) {
+	e.registry = reader
+}
+
+// ActionTypes returns the registered action types, sorted.
+func (e *Engine) ActionTypes() []string {
+	types := make([]string, 0, len(e.dispatchers))
+	for t := range e.dispatchers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// HasActionType reports whether rules may use actionType.
+func (e *Engine) HasActionType(actionType string) bool {
+	_, ok := e.dispatchers[actionType]
+	return ok
+}
+
+// ValidateDestination checks a rule's destination with its action type's
+// dispatcher, if the dispatcher implements DestinationValidator.
+func (e *Engine) ValidateDestination(actionType, destination string) error {
+	if v, ok := e.dispatchers[actionType].(DestinationValidator); ok {
+		return v.ValidateDestination(destination)
+	}
+	return nil
+}
+
+// Reload replaces the rule snapshot with the enabled rules in the store. On
+// error the previous snapshot stays in effect.
+func (e *Engine) Reload(ctx context.Context) error {
+	list, err := e.lister.ListEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load rules: %w", err)
+	}
+	e.rules.Store(&list)
+	e.logger.Debug("rules reloaded", "count", len(list))
+	return nil
+}
+
+// Rules returns the current rule snapshot. Callers must not modify it.
+func (e *Engine) Rules() []rules.Rule {
+	return *e.rules.Load()
+}
+
+// Run reloads the rules every interval until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	e.logger.Info("starting rule reloader", "interval", interval)
+
+	ticker := clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if err := e.Reload(ctx); err != nil {
+				e.logger.Error("rule reload failed, keeping previous rules", "error", err)
+			}
+		}
+	}
+}
+
+// Evaluate dispatches the actions of every rule matching the event and
+// returns how many matched. A failing action is logged and does not stop the
+// others.
+func (e *Engine) Evaluate(ctx context.Context, event *events.Envelope) int {
+	matched := 0
+	// The registry entry is read at most once per event, and only for rules
+	// that need it
+	var entry map[string]any
+	entryLoaded := false
+	for _, rule := range e.Rules() {
+		if !entryLoaded && rule.MatchesType(event.EventType) && rule.Predicate.UsesRegistry() {
+			entry, entryLoaded = e.registryEntry(ctx, event), true
+		}
+		if !rule.MatchesWithRegistry(event.EventType, event.Payload, entry) {
+			continue
+		}
+		matched++
+
+		logger := e.logger.With(
+			"rule_id", rule.RuleID,
+			"action_type", rule.ActionType,
+			"event_id", event.EventID,
+		)
+		execution := rules.Execution{
+			RuleID:      rule.RuleID,
+			EventID:     event.EventID,
+			EventType:   event.EventType,
+			AggregateID: event.AggregateID,
+			ActionType:  rule.ActionType,
+			Status:      rules.ExecutionSucceeded,
+			ExecutedAt:  clock.Now(),
+		}
+
+		dispatcher, ok := e.dispatchers[rule.ActionType]
+		if !ok {
+			// Rules are validated on write, so only a rule written by a newer
+			// build with more action types gets here.
+			logger.Warn("no dispatcher for action type, skipping rule")
+			execution.Status = rules.ExecutionSkipped
+			execution.Error = "no dispatcher for action type"
+			e.record(ctx, &execution, logger)
+			continue
+		}
+
+		start := time.Now()
+		err := dispatcher.Dispatch(ctx, &rule, event)
+		execution.LatencyMs = time.Since(start).Milliseconds()
+		if err != nil {
+			logger.Error("action failed", "error", err)
+			execution.Status = rules.ExecutionFailed
+			execution.Error = err.Error()
+		}
+		e.record(ctx, &execution, logger)
+	}
+	return matched
+}
+
+// registryEntry returns the fields of the registry entry of the event's
+// aggregate, or nil when there is no registry or no entry. A failed read is
+// logged and treated as no entry.
+func (e *Engine) registryEntry(ctx context.Context, event *events.Envelope) map[string]any {
+	if e.registry == nil {
+		return nil
+	}
+	aggregate, err := e.registry.GetAggregate(ctx, event.AggregateID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		e.logger.Warn("failed to read registry entry, registry conditions will not hold",
+			"aggregate_id", event.AggregateID,
+			"event_id", event.EventID,
+			"error", err,
+		)
+		return nil
+	}
+	return aggregate.Fields()
+}
+
+// record adds an execution to the actions log, if one is configured. A
+// failure to record is logged and does not affect evaluation.
+func (e *Engine) record(ctx context.Context, execution *rules.Execution, logger *slog.Logger) {
+	if e.executions == nil {
+		return
+	}
+	if err := e.executions.RecordExecution(ctx, execution); err != nil {
+		logger.Error("failed to record execution", "error", err)
+	}
+}
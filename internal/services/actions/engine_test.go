@@ -0,0 +1,191 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+func engineRule(actionType, eventType string, predicate rules.Predicate) rules.Rule {
+	return rules.Rule{
+		RuleID:     uuid.Must(uuid.NewV7()),
+		Name:       "test",
+		EventType:  eventType,
+		Predicate:  predicate,
+		ActionType: actionType,
+		Enabled:    true,
+	}
+}
+
+func TestEngine_EvaluateDispatchesMatchingRules(t *testing.T) {
+	hot := engineRule("record", "sensor.reading", rules.Predicate{
+		{Field: "value", Op: rules.OpGt, Value: json.RawMessage(`90`)},
+	})
+	anySensor := engineRule("record", "sensor.*", nil)
+	users := engineRule("record", "user.login", nil)
+
+	lister := &mockRuleLister{
+		ListEnabledFn: func(ctx context.Context) ([]rules.Rule, error) {
+			return []rules.Rule{hot, anySensor, users}, nil
+		},
+	}
+	dispatcher := &recordingDispatcher{}
+	engine := NewEngine(lister, slog.Default())
+	engine.RegisterDispatcher("record", dispatcher)
+	require.NoError(t, engine.Reload(context.Background()))
+
+	matched := engine.Evaluate(context.Background(), testutil.Event().Type("sensor.reading").Payload(`{"value": 95}`).Build())
+	assert.Equal(t, 2, matched)
+	assert.Equal(t, []uuid.UUID{hot.RuleID, anySensor.RuleID}, dispatcher.calls())
+
+	matched = engine.Evaluate(context.Background(), testutil.Event().Type("sensor.reading").Payload(`{"value": 50}`).Build())
+	assert.Equal(t, 1, matched)
+}
+
+// countingRegistry counts the reads of a registry.
+type countingRegistry struct {
+	*registry.MemoryStore
+	reads int
+}
+
+func (r *countingRegistry) GetAggregate(ctx context.Context, aggregateID string) (*registry.Aggregate, error) {
+	r.reads++
+	return r.MemoryStore.GetAggregate(ctx, aggregateID)
+}
+
+func TestEngine_EvaluateRegistryConditions(t *testing.T) {
+	critical := engineRule("record", "sensor.reading", rules.Predicate{
+		{Field: "registry.tags", Op: rules.OpContains, Value: json.RawMessage(`"critical"`)},
+	})
+	plantA := engineRule("record", "sensor.*", rules.Predicate{
+		{Field: "registry.site", Op: rules.OpEq, Value: json.RawMessage(`"plant-a"`)},
+	})
+	lister := &mockRuleLister{
+		ListEnabledFn: func(ctx context.Context) ([]rules.Rule, error) {
+			return []rules.Rule{critical, plantA}, nil
+		},
+	}
+	dispatcher := &recordingDispatcher{}
+	engine := NewEngine(lister, slog.Default())
+	engine.RegisterDispatcher("record", dispatcher)
+	require.NoError(t, engine.Reload(context.Background()))
+
+	event := testutil.Event().Type("sensor.reading").Aggregate("device-001").Payload(`{"value": 95}`).Build()
+	assert.Equal(t, 0, engine.Evaluate(context.Background(), event), "no registry: registry conditions do not hold")
+
+	entries := &countingRegistry{MemoryStore: registry.NewMemoryStore()}
+	_, err := entries.PutAggregate(context.Background(), &registry.Aggregate{
+		AggregateID: "device-001", Site: "plant-a", Tags: []string{"critical"},
+	})
+	require.NoError(t, err)
+	engine.SetRegistry(entries)
+
+	assert.Equal(t, 2, engine.Evaluate(context.Background(), event))
+	assert.Equal(t, 1, entries.reads, "the entry is read once per event")
+
+	unregistered := testutil.Event().Type("sensor.reading").Aggregate("device-404").Payload(`{"value": 95}`).Build()
+	assert.Equal(t, 0, engine.Evaluate(context.Background(), unregistered))
+	assert.Equal(t, 0, engine.Evaluate(context.Background(), testutil.Event().Type("user.login").Build()))
+	assert.Equal(t, 2, entries.reads, "no read for events no registry rule applies to")
+}
+
+func TestEngine_EvaluateContinuesAfterFailures(t *testing.T) {
+	unknown := engineRule("sms", "sensor.reading", nil)
+	failing := engineRule("failing", "sensor.reading", nil)
+	ok := engineRule("record", "sensor.reading", nil)
+
+	lister := &mockRuleLister{
+		ListEnabledFn: func(ctx context.Context) ([]rules.Rule, error) {
+			return []rules.Rule{unknown, failing, ok}, nil
+		},
+	}
+	failingDispatcher := &recordingDispatcher{err: errors.New("destination unreachable")}
+	dispatcher := &recordingDispatcher{}
+	engine := NewEngine(lister, slog.Default())
+	engine.RegisterDispatcher("failing", failingDispatcher)
+	engine.RegisterDispatcher("record", dispatcher)
+	require.NoError(t, engine.Reload(context.Background()))
+
+	assert.Equal(t, 3, engine.Evaluate(context.Background(), testutil.Event().Type("sensor.reading").Payload(`{}`).Build()))
+	assert.Equal(t, []uuid.UUID{failing.RuleID}, failingDispatcher.calls())
+	assert.Equal(t, []uuid.UUID{ok.RuleID}, dispatcher.calls())
+}
+
+func TestEngine_ReloadErrorKeepsPreviousRules(t *testing.T) {
+	rule := engineRule("record", "sensor.reading", nil)
+	fail := false
+	lister := &mockRuleLister{
+		ListEnabledFn: func(ctx context.Context) ([]rules.Rule, error) {
+			if fail {
+				return nil, errors.New("connection refused")
+			}
+			return []rules.Rule{rule}, nil
+		},
+	}
+	engine := NewEngine(lister, slog.Default())
+	require.NoError(t, engine.Reload(context.Background()))
+
+	fail = true
+	assert.ErrorContains(t, engine.Reload(context.Background()), "failed to load rules")
+	assert.Len(t, engine.Rules(), 1)
+}
+
+func TestEngine_ActionTypes(t *testing.T) {
+	engine := NewEngine(&mockRuleLister{}, slog.Default())
+	engine.RegisterDispatcher("webhook", &recordingDispatcher{})
+	engine.RegisterDispatcher(ActionLog, NewLogDispatcher(slog.Default()))
+
+	assert.Equal(t, []string{"log", "webhook"}, engine.ActionTypes())
+	assert.True(t, engine.HasActionType("webhook"))
+	assert.False(t, engine.HasActionType("sms"))
+}
+
+func TestEngine_RecordsExecutions(t *testing.T) {
+	ok := engineRule("record", "sensor.reading", nil)
+	failing := engineRule("failing", "sensor.reading", nil)
+	unknown := engineRule("sms", "sensor.reading", nil)
+	lister := &mockRuleLister{
+		ListEnabledFn: func(ctx context.Context) ([]rules.Rule, error) {
+			return []rules.Rule{ok, failing, unknown}, nil
+		},
+	}
+	store := rules.NewMemoryStore()
+	engine := NewEngine(lister, slog.Default())
+	engine.RegisterDispatcher("record", &recordingDispatcher{})
+	engine.RegisterDispatcher("failing", &recordingDispatcher{err: errors.New("destination unreachable")})
+	engine.SetExecutionRecorder(store)
+	require.NoError(t, engine.Reload(context.Background()))
+
+	event := testutil.Event().Type("sensor.reading").Payload(`{}`).Build()
+	engine.Evaluate(context.Background(), event)
+
+	status := map[uuid.UUID]rules.Execution{}
+	list, total, err := store.ListExecutions(context.Background(), rules.ExecutionFilter{EventID: event.EventID}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	for _, e := range list {
+		status[e.RuleID] = e
+	}
+	assert.Equal(t, rules.ExecutionSucceeded, status[ok.RuleID].Status)
+	assert.Equal(t, rules.ExecutionFailed, status[failing.RuleID].Status)
+	assert.Equal(t, "destination unreachable", status[failing.RuleID].Error)
+	assert.Equal(t, rules.ExecutionSkipped, status[unknown.RuleID].Status)
+	assert.Equal(t, "device-001", status[ok.RuleID].AggregateID)
+	assert.Equal(t, "record", status[ok.RuleID].ActionType)
+
+	// Non-matching events are not logged
+	engine.Evaluate(context.Background(), testutil.Event().Type("user.login").Payload(`{}`).Build())
+	_, total, err = store.ListExecutions(context.Background(), rules.ExecutionFilter{}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+}
@@ -0,0 +1,257 @@
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/audit"
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+)
+
+// Handler handles HTTP requests for the actions service.
+type Handler struct {
+	service *Service
+	policy  *auth.Policy    // nil allows every request
+	audit   *audit.Recorder // nil when the audit log is disabled
+	logger  *slog.Logger
+}
+
+// NewHandler creates a new actions HTTP handler.
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger.With("handler", "actions"),
+	}
+}
+
+// SetAudit records every rule change in the audit log.
+func (h *Handler) SetAudit(rec *audit.Recorder) {
+	h.audit = rec
+}
+
+// SetPolicy restricts the rule API to API keys with a rules scope: reads
+// need rules:read, changes rules:write. Requests without a known API key get
+// 401, requests outside the key's scopes get 403.
+func (h *Handler) SetPolicy(policy *auth.Policy) {
+	h.policy = policy
+}
+
+// authorize checks the request's API key against the policy. On failure it
+// writes the 401 or 403 response and returns false.
+func (h *Handler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	grant, ok := h.policy.Grant(r)
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "missing or unknown API key")
+		return false
+	}
+	access := auth.RulesWrite
+	if r.Method == http.MethodGet {
+		access = auth.RulesRead
+	}
+	if !grant.Allows(auth.Rules, access) {
+		h.writeError(w, http.StatusForbidden, "API key may not "+access+" rules")
+		return false
+	}
+	return true
+}
+
+// HandleRules handles GET /api/v1/rules (list) and POST /api/v1/rules (create).
+func (h *Handler) HandleRules(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		list, err := h.service.ListRules(r.Context())
+		if err != nil {
+			h.logger.Error("failed to list rules", "error", err)
+			h.writeError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		h.writeJSON(w, http.StatusOK, list)
+
+	case http.MethodPost:
+		var req RuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		rule, err := h.service.CreateRule(r.Context(), &req)
+		if err != nil {
+			h.writeServiceError(w, "failed to create rule", err)
+			return
+		}
+		audit.Note(r.Context(), rule.RuleID.String(), rule.Name)
+		h.writeJSON(w, http.StatusCreated, rule)
+
+	default:
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// HandleRule handles GET, PUT (replace), and DELETE /api/v1/rules/{rule_id}.
+func (h *Handler) HandleRule(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/rules/")
+	audit.Note(r.Context(), idStr, "")
+	if !h.authorize(w, r) {
+		return
+	}
+	ruleID, err := uuid.FromString(idStr)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid rule ID: "+idStr)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rule, err := h.service.GetRule(r.Context(), ruleID)
+		if err != nil {
+			h.writeServiceError(w, "failed to get rule", err)
+			return
+		}
+		h.writeJSON(w, http.StatusOK, rule)
+
+	case http.MethodPut:
+		var req RuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		rule, err := h.service.UpdateRule(r.Context(), ruleID, &req)
+		if err != nil {
+			h.writeServiceError(w, "failed to update rule", err)
+			return
+		}
+		audit.Note(r.Context(), idStr, rule.Name)
+		h.writeJSON(w, http.StatusOK, rule)
+
+	case http.MethodDelete:
+		if err := h.service.DeleteRule(r.Context(), ruleID); err != nil {
+			h.writeServiceError(w, "failed to delete rule", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// HandleActionTypes handles GET /api/v1/action-types.
+func (h *Handler) HandleActionTypes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !h.authorize(w, r) {
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string][]string{"action_types": h.service.ActionTypes()})
+}
+
+// HandleExecutions handles GET /api/v1/actions/executions, the actions log.
+// Filters: rule_id, event_id, aggregate_id, action_type, status, since
+// (inclusive) and until (exclusive); paged with limit and offset.
+func (h *Handler) HandleExecutions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !h.authorize(w, r) {
+		return
+	}
+
+	q := r.URL.Query()
+	filter := rules.ExecutionFilter{
+		AggregateID: q.Get("aggregate_id"),
+		ActionType:  q.Get("action_type"),
+		Status:      q.Get("status"),
+	}
+	for _, p := range []struct {
+		name string
+		dst  *uuid.UUID
+	}{{"rule_id", &filter.RuleID}, {"event_id", &filter.EventID}} {
+		name, dst := p.name, p.dst
+		if v := q.Get(name); v != "" {
+			id, err := uuid.FromString(v)
+			if err != nil {
+				h.writeError(w, http.StatusBadRequest, "invalid "+name+": "+v)
+				return
+			}
+			*dst = id
+		}
+	}
+	if filter.Status != "" && !rules.IsExecutionStatus(filter.Status) {
+		h.writeError(w, http.StatusBadRequest, "invalid status: "+filter.Status+" (expected succeeded, failed or skipped)")
+		return
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		name, dst := p.name, p.dst
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				h.writeError(w, http.StatusBadRequest, "invalid "+name+": "+v+" (expected RFC 3339)")
+				return
+			}
+			*dst = t
+		}
+	}
+
+	limit, offset := 20, 0
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil {
+		limit = l
+	}
+	if o, err := strconv.Atoi(q.Get("offset")); err == nil {
+		offset = o
+	}
+
+	list, err := h.service.ListExecutions(r.Context(), filter, limit, offset)
+	if err != nil {
+		h.logger.Error("failed to list executions", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+// HandleHealth handles GET /health
+func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+}
+
+// writeServiceError maps service errors to responses: validation errors are
+// 400, missing rules 404, anything else 500.
+func (h *Handler) writeServiceError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidRule):
+		h.writeError(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "no rows"):
+		h.writeError(w, http.StatusNotFound, "rule not found")
+	default:
+		h.logger.Error(msg, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, status int, message string) {
+	h.writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,256 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+const hotRuleBody = `{
+	"name": "overheating sensors",
+	"event_type": "sensor.reading",
+	"predicate": [{"field": "value", "op": "gt", "value": 90}],
+	"action_type": "log",
+	"destination": "ops-alerts"
+}`
+
+func serve(mux *http.ServeMux, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func createRule(t *testing.T, mux *http.ServeMux, body string) rules.Rule {
+	t.Helper()
+	w := serve(mux, http.MethodPost, "/api/v1/rules", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var rule rules.Rule
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&rule))
+	return rule
+}
+
+func TestRules_CreateGetList(t *testing.T) {
+	mux, _, _ := newTestRoutes()
+
+	created := createRule(t, mux, hotRuleBody)
+	assert.NotEqual(t, uuid.Nil, created.RuleID)
+	assert.Equal(t, "overheating sensors", created.Name)
+	assert.True(t, created.Enabled, "enabled defaults to true")
+
+	w := serve(mux, http.MethodGet, "/api/v1/rules/"+created.RuleID.String(), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var got rules.Rule
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, created.RuleID, got.RuleID)
+	assert.Equal(t, "ops-alerts", got.Destination)
+	require.Len(t, got.Predicate, 1)
+	assert.Equal(t, rules.OpGt, got.Predicate[0].Op)
+
+	w = serve(mux, http.MethodGet, "/api/v1/rules", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list RuleList
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Len(t, list.Rules, 1)
+}
+
+func TestRules_ChangesTakeEffectImmediately(t *testing.T) {
+	mux, engine, _ := newTestRoutes()
+	hot := testutil.Event().Type("sensor.reading").Payload(`{"value": 95}`).Build()
+
+	created := createRule(t, mux, hotRuleBody)
+	assert.Equal(t, 1, engine.Evaluate(context.Background(), hot))
+
+	disabled := `{"name": "overheating sensors", "event_type": "sensor.reading", "action_type": "log", "enabled": false}`
+	w := serve(mux, http.MethodPut, "/api/v1/rules/"+created.RuleID.String(), disabled)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 0, engine.Evaluate(context.Background(), hot))
+
+	w = serve(mux, http.MethodPut, "/api/v1/rules/"+created.RuleID.String(), hotRuleBody)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, engine.Evaluate(context.Background(), hot))
+
+	w = serve(mux, http.MethodDelete, "/api/v1/rules/"+created.RuleID.String(), "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, 0, engine.Evaluate(context.Background(), hot))
+}
+
+func TestRules_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"bad JSON", `{not json`, "invalid JSON"},
+		{"missing name", `{"event_type": "sensor.reading", "action_type": "log"}`, "name is required"},
+		{"unknown op", `{"name": "x", "event_type": "sensor.reading", "action_type": "log", "predicate": [{"field": "value", "op": "like"}]}`, "op"},
+		{"non-numeric ordering", `{"name": "x", "event_type": "sensor.reading", "action_type": "log", "predicate": [{"field": "value", "op": "gt", "value": "hot"}]}`, "requires a numeric value"},
+		{"bad event type", `{"name": "x", "event_type": "sen*sor", "action_type": "log"}`, "invalid event_type"},
+		{"invalid template", `{"name": "x", "event_type": "sensor.reading", "action_type": "log", "template": "{{.Payload.value"}`, "invalid template"},
+		{"unknown action type", `{"name": "x", "event_type": "sensor.reading", "action_type": "sms"}`, `unknown action_type "sms" (available: log)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux, _, store := newTestRoutes()
+
+			w := serve(mux, http.MethodPost, "/api/v1/rules", tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp map[string]string
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Contains(t, resp["error"], tt.wantErr)
+
+			list, err := store.ListRules(context.Background())
+			require.NoError(t, err)
+			assert.Empty(t, list)
+		})
+	}
+}
+
+func TestRule_NotFoundAndInvalidID(t *testing.T) {
+	mux, _, _ := newTestRoutes()
+	missing := "/api/v1/rules/" + uuid.Must(uuid.NewV7()).String()
+
+	assert.Equal(t, http.StatusNotFound, serve(mux, http.MethodGet, missing, "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(mux, http.MethodPut, missing, hotRuleBody).Code)
+	assert.Equal(t, http.StatusNotFound, serve(mux, http.MethodDelete, missing, "").Code)
+
+	w := serve(mux, http.MethodGet, "/api/v1/rules/not-a-uuid", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRules_Scopes(t *testing.T) {
+	store := rules.NewMemoryStore()
+	engine := NewEngine(store, slog.Default())
+	engine.RegisterDispatcher(ActionLog, NewLogDispatcher(slog.Default()))
+	policy, err := auth.ParsePolicy("ops=rules:*,viewer=rules:read,gateway=ingest:*")
+	require.NoError(t, err)
+	handler := NewHandler(NewService(store, store, engine, slog.Default()), slog.Default())
+	handler.SetPolicy(policy)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	serveAs := func(key, method, path, body string) int {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusCreated, serveAs("ops", http.MethodPost, "/api/v1/rules", hotRuleBody))
+	list, err := store.ListRules(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	rule := "/api/v1/rules/" + list[0].RuleID.String()
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"no key", "", http.MethodGet, "/api/v1/rules", "", http.StatusUnauthorized},
+		{"unknown key", "other", http.MethodGet, "/api/v1/rules", "", http.StatusUnauthorized},
+		{"no rules scope", "gateway", http.MethodGet, "/api/v1/rules", "", http.StatusForbidden},
+		{"no rules scope on executions", "gateway", http.MethodGet, "/api/v1/actions/executions", "", http.StatusForbidden},
+		{"read list", "viewer", http.MethodGet, "/api/v1/rules", "", http.StatusOK},
+		{"read rule", "viewer", http.MethodGet, rule, "", http.StatusOK},
+		{"read action types", "viewer", http.MethodGet, "/api/v1/action-types", "", http.StatusOK},
+		{"read executions", "viewer", http.MethodGet, "/api/v1/actions/executions", "", http.StatusOK},
+		{"create without write", "viewer", http.MethodPost, "/api/v1/rules", hotRuleBody, http.StatusForbidden},
+		{"replace without write", "viewer", http.MethodPut, rule, hotRuleBody, http.StatusForbidden},
+		{"delete without write", "viewer", http.MethodDelete, rule, "", http.StatusForbidden},
+		{"delete without key", "", http.MethodDelete, rule, "", http.StatusUnauthorized},
+		{"delete", "ops", http.MethodDelete, rule, "", http.StatusNoContent},
+		{"health stays open", "", http.MethodGet, "/health", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, serveAs(tt.key, tt.method, tt.path, tt.body))
+		})
+	}
+}
+
+func TestActionTypes(t *testing.T) {
+	mux, _, _ := newTestRoutes()
+
+	w := serve(mux, http.MethodGet, "/api/v1/action-types", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"action_types": ["log"]}`, w.Body.String())
+}
+
+func TestRoutes_OpenAPI(t *testing.T) {
+	mux, _, _ := newTestRoutes()
+
+	w := serve(mux, http.MethodGet, "/openapi.json", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var doc map[string]any
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&doc))
+	assert.Contains(t, doc["paths"], "/api/v1/rules")
+}
+
+func TestExecutions_List(t *testing.T) {
+	mux, engine, _ := newTestRoutes()
+	hot := createRule(t, mux, hotRuleBody)
+
+	first := testutil.Event().Type("sensor.reading").Payload(`{"value": 95}`).Build()
+	engine.Evaluate(context.Background(), first)
+	engine.Evaluate(context.Background(), testutil.Event().Type("sensor.reading").Payload(`{"value": 96}`).Build())
+	engine.Evaluate(context.Background(), testutil.Event().Type("sensor.reading").Payload(`{"value": 50}`).Build()) // no match
+
+	w := serve(mux, http.MethodGet, "/api/v1/actions/executions?rule_id="+hot.RuleID.String(), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var list ExecutionList
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, 2, list.Total)
+	assert.Equal(t, 20, list.Limit)
+	require.Len(t, list.Executions, 2)
+	assert.Equal(t, rules.ExecutionSucceeded, list.Executions[0].Status)
+
+	w = serve(mux, http.MethodGet, "/api/v1/actions/executions?event_id="+first.EventID.String()+"&status=succeeded&limit=1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	list = ExecutionList{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, 1, list.Total)
+	assert.Equal(t, first.EventID, list.Executions[0].EventID)
+
+	w = serve(mux, http.MethodGet, "/api/v1/actions/executions?status=failed", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	list = ExecutionList{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.Equal(t, 0, list.Total)
+	assert.NotNil(t, list.Executions)
+}
+
+func TestExecutions_InvalidFilters(t *testing.T) {
+	mux, _, _ := newTestRoutes()
+
+	for _, query := range []string{
+		"rule_id=not-a-uuid",
+		"event_id=42",
+		"status=pending",
+		"since=yesterday",
+		"limit=500",
+	} {
+		t.Run(query, func(t *testing.T) {
+			w := serve(mux, http.MethodGet, "/api/v1/actions/executions?"+query, "")
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+}
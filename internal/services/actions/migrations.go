@@ -0,0 +1,6 @@
+package actions
+
+import "embed"
+
+//go:embed migrations/*.sql
+var MigrationFS embed.FS
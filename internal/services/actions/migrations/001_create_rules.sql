@@ -0,0 +1,19 @@
+-- +goose Up
+-- Action rules: when an event of event_type arrives and its payload matches
+-- every condition in predicate, the Actions service runs action_type against
+-- destination. Managed through the Actions API and hot-reloaded by the consumer.
+
+CREATE TABLE IF NOT EXISTS rules (
+    rule_id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    predicate JSONB NOT NULL DEFAULT '[]',
+    action_type VARCHAR(64) NOT NULL,
+    destination TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for loading the enabled rule set in creation order
+CREATE INDEX IF NOT EXISTS idx_rules_enabled ON rules (created_at, rule_id) WHERE enabled;
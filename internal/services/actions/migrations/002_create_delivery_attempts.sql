@@ -0,0 +1,21 @@
+-- +goose Up
+-- One row per outbound delivery attempt (webhooks), for auditing. Attempts
+-- of the same delivery (one rule firing for one event) share delivery_id.
+-- No foreign key to rules: the audit trail outlives deleted rules.
+
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+    attempt_id UUID PRIMARY KEY,
+    delivery_id UUID NOT NULL,
+    rule_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    action_type VARCHAR(64) NOT NULL,
+    destination TEXT NOT NULL,
+    attempt INT NOT NULL,
+    status_code INT,                      -- NULL when no response was received
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INT NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for a rule's recent deliveries
+CREATE INDEX IF NOT EXISTS idx_delivery_attempts_rule ON delivery_attempts (rule_id, attempted_at DESC);
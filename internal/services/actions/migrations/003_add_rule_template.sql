@@ -0,0 +1,6 @@
+-- +goose Up
+-- Message template for notification actions (slack, email, pagerduty): Go
+-- text/template rendered with the rule and the triggering event. Empty uses
+-- the default message.
+
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS template TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,22 @@
+-- +goose Up
+-- One row per rule that fired for an event: the action's outcome and how long
+-- it took. Used to prove an action ran for an event, or find where one ran
+-- twice. delivery_attempts has the per-attempt detail of external deliveries.
+
+CREATE TABLE IF NOT EXISTS actions_log (
+    execution_id UUID PRIMARY KEY,
+    rule_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    action_type VARCHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL,          -- succeeded, failed, skipped
+    error TEXT NOT NULL DEFAULT '',
+    latency_ms INT NOT NULL,
+    executed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Indexes for the executions endpoint: newest first, optionally by rule or event
+CREATE INDEX IF NOT EXISTS idx_actions_log_executed_at ON actions_log (executed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_actions_log_rule ON actions_log (rule_id, executed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_actions_log_event ON actions_log (event_id);
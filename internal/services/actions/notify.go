@@ -0,0 +1,97 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+)
+
+// Notification action types.
+const (
+	ActionSlack     = "slack"
+	ActionEmail     = "email"
+	ActionPagerDuty = "pagerduty"
+)
+
+// Notification is a rendered alert for a rule match.
+type Notification struct {
+	Subject string // the rule name
+	Text    string // the rule's template rendered for the event
+	Rule    *rules.Rule
+	Event   *events.Envelope
+}
+
+// NotifyDispatcher adapts a Notifier to an action type: it renders the rule's
+// message template for the event, sends it through the notifier, and records
+// the attempt. Notifications are sent once; a failure is logged by the engine.
+type NotifyDispatcher struct {
+	actionType string
+	notifier   Notifier
+	recorder   DeliveryRecorder
+	logger     *slog.Logger
+}
+
+// NewNotifyDispatcher creates a dispatcher for actionType backed by notifier.
+func NewNotifyDispatcher(actionType string, notifier Notifier, recorder DeliveryRecorder, logger *slog.Logger) *NotifyDispatcher {
+	return &NotifyDispatcher{
+		actionType: actionType,
+		notifier:   notifier,
+		recorder:   recorder,
+		logger:     logger.With("dispatcher", actionType),
+	}
+}
+
+// ValidateDestination delegates to the notifier if it validates destinations.
+func (d *NotifyDispatcher) ValidateDestination(destination string) error {
+	if v, ok := d.notifier.(DestinationValidator); ok {
+		return v.ValidateDestination(destination)
+	}
+	return nil
+}
+
+// Dispatch renders and sends the notification.
+func (d *NotifyDispatcher) Dispatch(ctx context.Context, rule *rules.Rule, event *events.Envelope) error {
+	text, err := rule.RenderMessage(event)
+	if err != nil {
+		return err
+	}
+	deliveryID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate delivery ID: %w", err)
+	}
+
+	record := rules.DeliveryAttempt{
+		DeliveryID:  deliveryID,
+		RuleID:      rule.RuleID,
+		EventID:     event.EventID,
+		ActionType:  d.actionType,
+		Destination: rule.Destination,
+		Attempt:     1,
+		AttemptedAt: clock.Now(),
+	}
+	start := time.Now()
+	err = d.notifier.Notify(ctx, rule.Destination, &Notification{
+		Subject: rule.Name,
+		Text:    text,
+		Rule:    rule,
+		Event:   event,
+	})
+	record.Duration = time.Since(start)
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if recErr := d.recorder.RecordAttempt(ctx, &record); recErr != nil {
+		d.logger.Error("failed to record delivery attempt", "delivery_id", deliveryID, "error", recErr)
+	}
+	if err != nil {
+		return fmt.Errorf("%s notification failed: %w", d.actionType, err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+// mockNotifier is a hand-written mock for Notifier.
+type mockNotifier struct {
+	NotifyFn func(ctx context.Context, destination string, n *Notification) error
+}
+
+func (m *mockNotifier) Notify(ctx context.Context, destination string, n *Notification) error {
+	return m.NotifyFn(ctx, destination, n)
+}
+
+func notifyRule(actionType, destination, template string) *rules.Rule {
+	return &rules.Rule{
+		RuleID:      uuid.Must(uuid.NewV7()),
+		Name:        "overheating",
+		EventType:   "sensor.reading",
+		ActionType:  actionType,
+		Destination: destination,
+		Template:    template,
+		Enabled:     true,
+	}
+}
+
+func TestNotifyDispatcher_RendersAndRecords(t *testing.T) {
+	var got *Notification
+	notifier := &mockNotifier{
+		NotifyFn: func(ctx context.Context, destination string, n *Notification) error {
+			assert.Equal(t, "#ops", destination)
+			got = n
+			return nil
+		},
+	}
+	store := rules.NewMemoryStore()
+	dispatcher := NewNotifyDispatcher(ActionSlack, notifier, store, slog.Default())
+	rule := notifyRule(ActionSlack, "#ops", "{{.Event.AggregateID}} at {{.Payload.value}}")
+
+	require.NoError(t, dispatcher.Dispatch(context.Background(), rule, testutil.Event().Type("sensor.reading").Payload(`{"value": 95}`).Build()))
+	require.NotNil(t, got)
+	assert.Equal(t, "overheating", got.Subject)
+	assert.Equal(t, "device-001 at 95", got.Text)
+
+	attempts, err := store.ListAttempts(context.Background(), rule.RuleID, 10)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, ActionSlack, attempts[0].ActionType)
+	assert.Empty(t, attempts[0].Error)
+}
+
+func TestNotifyDispatcher_RecordsFailure(t *testing.T) {
+	notifier := &mockNotifier{
+		NotifyFn: func(ctx context.Context, destination string, n *Notification) error {
+			return errors.New("unexpected status 404: no_service")
+		},
+	}
+	store := rules.NewMemoryStore()
+	dispatcher := NewNotifyDispatcher(ActionSlack, notifier, store, slog.Default())
+	rule := notifyRule(ActionSlack, "https://hooks.slack.com/x", "")
+
+	err := dispatcher.Dispatch(context.Background(), rule, testutil.Event().Type("sensor.reading").Payload(`{}`).Build())
+	assert.ErrorContains(t, err, "slack notification failed")
+
+	attempts, err := store.ListAttempts(context.Background(), rule.RuleID, 10)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Contains(t, attempts[0].Error, "no_service")
+}
+
+func TestSlackNotifier(t *testing.T) {
+	server := newWebhookServer(t, http.StatusOK)
+	notifier := NewSlackNotifier(time.Second)
+
+	err := notifier.Notify(context.Background(), server.URL, &Notification{Text: "device-001 at 95"})
+	require.NoError(t, err)
+	require.Equal(t, 1, server.count())
+	assert.JSONEq(t, `{"text": "device-001 at 95"}`, string(server.bodies[0]))
+
+	assert.NoError(t, notifier.ValidateDestination("https://hooks.slack.com/services/T0/B0/x"))
+	assert.Error(t, notifier.ValidateDestination("#ops"))
+}
+
+func TestSlackNotifier_ErrorStatus(t *testing.T) {
+	server := newWebhookServer(t, http.StatusForbidden)
+
+	err := NewSlackNotifier(time.Second).Notify(context.Background(), server.URL, &Notification{Text: "x"})
+	assert.ErrorContains(t, err, "unexpected status 403")
+}
+
+func TestPagerDutyNotifier(t *testing.T) {
+	server := newWebhookServer(t, http.StatusAccepted)
+	notifier := NewPagerDutyNotifier(server.URL, time.Second)
+	rule := notifyRule(ActionPagerDuty, "R0UTINGKEY", "")
+	event := testutil.Event().Type("sensor.reading").Payload(`{"value": 95}`).Build()
+	event.EventTime = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	err := notifier.Notify(context.Background(), rule.Destination, &Notification{
+		Subject: rule.Name,
+		Text:    strings.Repeat("x", 2000),
+		Rule:    rule,
+		Event:   event,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, server.count())
+
+	var sent pagerDutyEvent
+	require.NoError(t, json.Unmarshal(server.bodies[0], &sent))
+	assert.Equal(t, "R0UTINGKEY", sent.RoutingKey)
+	assert.Equal(t, "trigger", sent.EventAction)
+	assert.Equal(t, rule.RuleID.String()+":device-001", sent.DedupKey)
+	assert.Len(t, sent.Payload.Summary, 1024)
+	assert.Equal(t, "device-001", sent.Payload.Source)
+	assert.Equal(t, "warning", sent.Payload.Severity)
+	assert.Equal(t, "2026-10-16T12:00:00Z", sent.Payload.Timestamp)
+	assert.Equal(t, "sensor.reading", sent.Payload.Component)
+	assert.JSONEq(t, `{"value": 95}`, string(sent.Payload.CustomDetails))
+
+	assert.NoError(t, notifier.ValidateDestination("R0UTINGKEY"))
+	assert.Error(t, notifier.ValidateDestination(""))
+	assert.Error(t, notifier.ValidateDestination("https://events.pagerduty.com"))
+}
+
+func TestEmailNotifier(t *testing.T) {
+	clock.Set(clock.FixedClock{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)})
+	t.Cleanup(clock.Reset)
+
+	notifier := NewEmailNotifier(EmailConfig{
+		Host:     "smtp.example.com",
+		Port:     587,
+		Username: "alerts",
+		Password: "pw",
+		From:     "alerts@example.com",
+	})
+	var addr, from string
+	var to []string
+	var msg []byte
+	var auth smtp.Auth
+	notifier.sendMail = func(a string, au smtp.Auth, f string, t []string, m []byte) error {
+		addr, auth, from, to, msg = a, au, f, t, m
+		return nil
+	}
+
+	err := notifier.Notify(context.Background(), "ops@example.com, On Call <oncall@example.com>", &Notification{
+		Subject: "hot\r\nBcc: attacker@example.com",
+		Text:    "device-001 at 95\nsecond line",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.NotNil(t, auth)
+	assert.Equal(t, "alerts@example.com", from)
+	assert.Equal(t, []string{"ops@example.com", "oncall@example.com"}, to)
+
+	headers, body, found := strings.Cut(string(msg), "\r\n\r\n")
+	require.True(t, found)
+	assert.Contains(t, headers, "From: alerts@example.com\r\n")
+	assert.Contains(t, headers, `To: <ops@example.com>, "On Call" <oncall@example.com>`)
+	assert.Contains(t, headers, "Date: Fri, 16 Oct 2026 12:00:00 +0000")
+	assert.NotContains(t, headers, "\r\nBcc:", "subject must not inject headers")
+	assert.Equal(t, "device-001 at 95\r\nsecond line\r\n", body)
+
+	assert.NoError(t, notifier.ValidateDestination("ops@example.com, oncall@example.com"))
+	assert.Error(t, notifier.ValidateDestination("ops"))
+}
+
+func TestEmailNotifier_SendError(t *testing.T) {
+	notifier := NewEmailNotifier(EmailConfig{Host: "smtp.example.com", Port: 25, From: "alerts@example.com"})
+	notifier.sendMail = func(string, smtp.Auth, string, []string, []byte) error {
+		return errors.New("554 relay denied")
+	}
+
+	err := notifier.Notify(context.Background(), "ops@example.com", &Notification{Subject: "hot", Text: "x"})
+	assert.ErrorContains(t, err, "relay denied")
+}
@@ -0,0 +1,97 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultPagerDutyURL is the PagerDuty Events API v2 endpoint.
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutyMaxSummary is the Events API limit on payload.summary.
+const pagerDutyMaxSummary = 1024
+
+// PagerDutyNotifier triggers PagerDuty incidents through the Events API v2.
+// The rule's destination is the integration's routing key.
+//
+// Alerts are deduplicated per rule and aggregate: while an incident is open,
+// further matches for the same device add to it instead of paging again.
+type PagerDutyNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewPagerDutyNotifier creates a PagerDuty notifier posting to eventsURL
+// (DefaultPagerDutyURL if empty) with a per-request timeout.
+func NewPagerDutyNotifier(eventsURL string, timeout time.Duration) *PagerDutyNotifier {
+	if eventsURL == "" {
+		eventsURL = DefaultPagerDutyURL
+	}
+	return &PagerDutyNotifier{url: eventsURL, client: &http.Client{Timeout: timeout}}
+}
+
+// ValidateDestination requires a routing key.
+func (p *PagerDutyNotifier) ValidateDestination(destination string) error {
+	if destination == "" || strings.ContainsAny(destination, " \t\r\n/") {
+		return fmt.Errorf("pagerduty destination must be an integration routing key")
+	}
+	return nil
+}
+
+// pagerDutyEvent is an Events API v2 trigger.
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string          `json:"summary"`
+	Source        string          `json:"source"`
+	Severity      string          `json:"severity"`
+	Timestamp     string          `json:"timestamp"`
+	Component     string          `json:"component"`
+	CustomDetails json.RawMessage `json:"custom_details,omitempty"`
+}
+
+// Notify triggers an incident with the notification text as its summary and
+// the event payload as its details.
+func (p *PagerDutyNotifier) Notify(ctx context.Context, destination string, n *Notification) error {
+	summary := n.Text
+	if len(summary) > pagerDutyMaxSummary {
+		summary = summary[:pagerDutyMaxSummary]
+	}
+	source := n.Event.AggregateID
+	if source == "" {
+		source = "cornjacket"
+	}
+	event := pagerDutyEvent{
+		RoutingKey:  destination,
+		EventAction: "trigger",
+		DedupKey:    n.Rule.RuleID.String() + ":" + n.Event.AggregateID,
+		Payload: pagerDutyPayload{
+			Summary:   summary,
+			Source:    source,
+			Severity:  "warning",
+			Timestamp: n.Event.EventTime.UTC().Format(time.RFC3339),
+			Component: n.Event.EventType,
+		},
+	}
+	if json.Valid(n.Event.Payload) {
+		event.Payload.CustomDetails = n.Event.Payload
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pagerduty event: %w", err)
+	}
+	if err := postJSON(ctx, p.client, p.url, body); err != nil {
+		return fmt.Errorf("pagerduty: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package actions
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+)
+
+// RuleStore manages the stored rules behind the rules API.
+// This interface is satisfied by shared/rules.Store.
+type RuleStore interface {
+	RuleLister
+
+	// CreateRule stores a new rule, assigning its ID and timestamps.
+	CreateRule(ctx context.Context, rule *rules.Rule) error
+
+	// GetRule retrieves a rule by ID.
+	GetRule(ctx context.Context, ruleID uuid.UUID) (*rules.Rule, error)
+
+	// ListRules retrieves all rules, oldest first.
+	ListRules(ctx context.Context) ([]rules.Rule, error)
+
+	// UpdateRule replaces a rule's fields, keeping its ID and creation time.
+	UpdateRule(ctx context.Context, rule *rules.Rule) error
+
+	// DeleteRule removes a rule.
+	DeleteRule(ctx context.Context, ruleID uuid.UUID) error
+}
+
+// RuleLister loads the rule set the engine evaluates.
+// This interface is satisfied by shared/rules.Store.
+type RuleLister interface {
+	// ListEnabled retrieves the enabled rules, oldest first.
+	ListEnabled(ctx context.Context) ([]rules.Rule, error)
+}
+
+// Dispatcher runs one action type for events that matched a rule.
+type Dispatcher interface {
+	// Dispatch performs the action for the event. rule.Destination says where.
+	Dispatch(ctx context.Context, rule *rules.Rule, event *events.Envelope) error
+}
+
+// Notifier sends a rendered notification to a destination. Each notification
+// backend (Slack, email, PagerDuty) implements it and is registered as an
+// action type through NotifyDispatcher.
+type Notifier interface {
+	// Notify sends the notification. destination is the rule's destination,
+	// in the backend's form (URL, addresses, routing key).
+	Notify(ctx context.Context, destination string, n *Notification) error
+}
+
+// DestinationValidator is implemented by dispatchers whose destinations have a
+// required form, such as a URL. The service checks it when a rule is written.
+type DestinationValidator interface {
+	ValidateDestination(destination string) error
+}
+
+// DeliveryRecorder records delivery attempts for auditing.
+// This interface is satisfied by shared/rules.DeliveryStore.
+type DeliveryRecorder interface {
+	// RecordAttempt stores an attempt, assigning its ID.
+	RecordAttempt(ctx context.Context, attempt *rules.DeliveryAttempt) error
+}
+
+// ExecutionRecorder records rule executions in the actions log.
+// This interface is satisfied by shared/rules.ExecutionStore.
+type ExecutionRecorder interface {
+	// RecordExecution stores an execution, assigning its ID.
+	RecordExecution(ctx context.Context, execution *rules.Execution) error
+}
+
+// ExecutionLister queries the actions log.
+// This interface is satisfied by shared/rules.ExecutionStore.
+type ExecutionLister interface {
+	// ListExecutions retrieves a page of matching executions, newest first,
+	// and the total number that match.
+	ListExecutions(ctx context.Context, filter rules.ExecutionFilter, limit, offset int) ([]rules.Execution, int, error)
+}
@@ -0,0 +1,28 @@
+package actions
+
+import (
+	"net/http"
+
+	"github.com/cornjacket/platform-services/api/openapi"
+)
+
+// spec is the actions API contract: served at /openapi.json and enforced on
+// incoming requests.
+var spec = openapi.MustLoad(openapi.Actions)
+
+// RegisterRoutes registers actions service routes on the provided mux.
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/health", h.HandleHealth)
+	mux.HandleFunc("/openapi.json", spec.ServeJSON)
+
+	// Rule management
+	//   GET, POST /api/v1/rules -> list, create
+	//   GET, PUT, DELETE /api/v1/rules/{rule_id} -> get, replace, delete
+	mux.Handle("/api/v1/rules", h.audit.Wrap("rules", spec.Validate(http.HandlerFunc(h.HandleRules))))
+	mux.Handle("/api/v1/rules/", h.audit.Wrap("rules", spec.Validate(http.HandlerFunc(h.HandleRule))))
+	mux.Handle("/api/v1/action-types", spec.Validate(http.HandlerFunc(h.HandleActionTypes)))
+
+	// Actions log
+	//   GET /api/v1/actions/executions -> executions of fired rules, newest first
+	mux.Handle("/api/v1/actions/executions", spec.Validate(http.HandlerFunc(h.HandleExecutions)))
+}
@@ -0,0 +1,188 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+)
+
+// ErrInvalidRule is wrapped by errors for rules that fail validation.
+var ErrInvalidRule = errors.New("invalid rule")
+
+// RuleRequest is the body of rule create and replace requests.
+type RuleRequest struct {
+	Name        string          `json:"name"`
+	EventType   string          `json:"event_type"`
+	Predicate   rules.Predicate `json:"predicate"`
+	ActionType  string          `json:"action_type"`
+	Destination string          `json:"destination"`
+	Template    string          `json:"template"`
+	Enabled     *bool           `json:"enabled,omitempty"` // defaults to true
+}
+
+// rule converts the request to a rule with the given ID.
+func (r *RuleRequest) rule(ruleID uuid.UUID) *rules.Rule {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	predicate := r.Predicate
+	if predicate == nil {
+		predicate = rules.Predicate{}
+	}
+	return &rules.Rule{
+		RuleID:      ruleID,
+		Name:        r.Name,
+		EventType:   r.EventType,
+		Predicate:   predicate,
+		ActionType:  r.ActionType,
+		Destination: r.Destination,
+		Template:    r.Template,
+		Enabled:     enabled,
+	}
+}
+
+// RuleList is the response of the rule list endpoint.
+type RuleList struct {
+	Rules []rules.Rule `json:"rules"`
+}
+
+// ExecutionList is the response of the executions endpoint.
+type ExecutionList struct {
+	Executions []rules.Execution `json:"executions"`
+	Total      int               `json:"total"`
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+}
+
+// Service manages rules and keeps the engine's rule set current.
+type Service struct {
+	store      RuleStore
+	executions ExecutionLister
+	engine     *Engine
+	logger     *slog.Logger
+}
+
+// NewService creates a new actions service.
+func NewService(store RuleStore, executions ExecutionLister, engine *Engine, logger *slog.Logger) *Service {
+	return &Service{
+		store:      store,
+		executions: executions,
+		engine:     engine,
+		logger:     logger.With("component", "actions-service"),
+	}
+}
+
+// CreateRule validates and stores a new rule.
+func (s *Service) CreateRule(ctx context.Context, req *RuleRequest) (*rules.Rule, error) {
+	rule := req.rule(uuid.Nil)
+	if err := s.validate(rule); err != nil {
+		return nil, err
+	}
+	if err := s.store.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.logger.Info("rule created", "rule_id", rule.RuleID, "name", rule.Name)
+	s.reload(ctx)
+	return rule, nil
+}
+
+// GetRule retrieves a rule by ID.
+func (s *Service) GetRule(ctx context.Context, ruleID uuid.UUID) (*rules.Rule, error) {
+	return s.store.GetRule(ctx, ruleID)
+}
+
+// ListRules retrieves all rules, enabled or not.
+func (s *Service) ListRules(ctx context.Context) (*RuleList, error) {
+	list, err := s.store.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RuleList{Rules: list}, nil
+}
+
+// UpdateRule validates and replaces an existing rule.
+func (s *Service) UpdateRule(ctx context.Context, ruleID uuid.UUID, req *RuleRequest) (*rules.Rule, error) {
+	rule := req.rule(ruleID)
+	if err := s.validate(rule); err != nil {
+		return nil, err
+	}
+	if err := s.store.UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.logger.Info("rule updated", "rule_id", rule.RuleID, "name", rule.Name)
+	s.reload(ctx)
+	return rule, nil
+}
+
+// DeleteRule removes a rule.
+func (s *Service) DeleteRule(ctx context.Context, ruleID uuid.UUID) error {
+	if err := s.store.DeleteRule(ctx, ruleID); err != nil {
+		return err
+	}
+	s.logger.Info("rule deleted", "rule_id", ruleID)
+	s.reload(ctx)
+	return nil
+}
+
+// ListExecutions retrieves a page of the actions log, newest first.
+func (s *Service) ListExecutions(ctx context.Context, filter rules.ExecutionFilter, limit, offset int) (*ExecutionList, error) {
+	limit, offset = normalizePage(limit, offset)
+
+	list, total, err := s.executions.ListExecutions(ctx, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &ExecutionList{
+		Executions: list,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+	}, nil
+}
+
+// ActionTypes returns the action types rules may use.
+func (s *Service) ActionTypes() []string {
+	return s.engine.ActionTypes()
+}
+
+func (s *Service) validate(rule *rules.Rule) error {
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	if !s.engine.HasActionType(rule.ActionType) {
+		return fmt.Errorf("%w: unknown action_type %q (available: %s)",
+			ErrInvalidRule, rule.ActionType, strings.Join(s.engine.ActionTypes(), ", "))
+	}
+	if err := s.engine.ValidateDestination(rule.ActionType, rule.Destination); err != nil {
+		return fmt.Errorf("%w: invalid destination: %v", ErrInvalidRule, err)
+	}
+	return nil
+}
+
+// reload applies a rule change to this instance right away. Other instances
+// pick it up on their next periodic reload; so does this one if it fails here.
+func (s *Service) reload(ctx context.Context) {
+	if err := s.engine.Reload(ctx); err != nil {
+		s.logger.Error("rule reload after change failed", "error", err)
+	}
+}
+
+func normalizePage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
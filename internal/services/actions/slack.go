@@ -0,0 +1,58 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SlackNotifier posts notifications to Slack incoming webhooks. The rule's
+// destination is the webhook URL Slack generated for the channel.
+type SlackNotifier struct {
+	client *http.Client
+}
+
+// NewSlackNotifier creates a Slack notifier with a per-request timeout.
+func NewSlackNotifier(timeout time.Duration) *SlackNotifier {
+	return &SlackNotifier{client: &http.Client{Timeout: timeout}}
+}
+
+// ValidateDestination requires an http or https URL.
+func (s *SlackNotifier) ValidateDestination(destination string) error {
+	return validateHTTPURL(destination)
+}
+
+// Notify posts the notification text to the channel.
+func (s *SlackNotifier) Notify(ctx context.Context, destination string, n *Notification) error {
+	body, err := json.Marshal(map[string]string{"text": n.Text})
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack message: %w", err)
+	}
+	return postJSON(ctx, s.client, destination, body)
+}
+
+// postJSON POSTs body and treats any non-2xx response as an error.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // allow connection reuse
+	return nil
+}
@@ -0,0 +1,55 @@
+package actions
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+)
+
+// mockRuleLister is a hand-written mock for RuleLister.
+type mockRuleLister struct {
+	ListEnabledFn func(ctx context.Context) ([]rules.Rule, error)
+}
+
+func (m *mockRuleLister) ListEnabled(ctx context.Context) ([]rules.Rule, error) {
+	return m.ListEnabledFn(ctx)
+}
+
+// recordingDispatcher records the rule IDs it dispatched and returns err.
+type recordingDispatcher struct {
+	mu         sync.Mutex
+	dispatched []uuid.UUID
+	err        error
+}
+
+func (d *recordingDispatcher) Dispatch(ctx context.Context, rule *rules.Rule, event *events.Envelope) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dispatched = append(d.dispatched, rule.RuleID)
+	return d.err
+}
+
+func (d *recordingDispatcher) calls() []uuid.UUID {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]uuid.UUID{}, d.dispatched...)
+}
+
+// newTestRoutes wires a memory store, an engine with the log action type, and
+// the service routes, as Start does.
+func newTestRoutes() (*http.ServeMux, *Engine, *rules.MemoryStore) {
+	store := rules.NewMemoryStore()
+	engine := NewEngine(store, slog.Default())
+	engine.RegisterDispatcher(ActionLog, NewLogDispatcher(slog.Default()))
+	engine.SetExecutionRecorder(store)
+
+	mux := http.NewServeMux()
+	NewHandler(NewService(store, store, engine, slog.Default()), slog.Default()).RegisterRoutes(mux)
+	return mux, engine, store
+}
@@ -0,0 +1,241 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/retry"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+)
+
+// ActionWebhook is the action type of WebhookDispatcher.
+const ActionWebhook = "webhook"
+
+// Webhook request headers. The signature is "sha256=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<body>" under the shared secret (see Sign).
+// Receivers should check it and reject stale timestamps to prevent replays.
+const (
+	HeaderDelivery  = "X-Cornjacket-Delivery" // same on every retry of a delivery, for deduplication
+	HeaderEventID   = "X-Cornjacket-Event-ID"
+	HeaderRuleID    = "X-Cornjacket-Rule-ID"
+	HeaderTimestamp = "X-Cornjacket-Timestamp" // Unix seconds at the time of the attempt
+	HeaderSignature = "X-Cornjacket-Signature"
+)
+
+// WebhookConfig configures a WebhookDispatcher. Zero durations and counts use
+// the defaults in DefaultWebhookConfig.
+type WebhookConfig struct {
+	Secret string // HMAC-SHA256 signing key shared with receivers; required
+
+	Timeout     time.Duration // per attempt
+	MaxAttempts int           // total attempts including the first; 1 disables retries
+	BaseDelay   time.Duration // delay before the first retry, doubled for each further retry
+	MaxDelay    time.Duration // upper bound on a single delay
+
+	BreakerThreshold    int           // consecutive transient failures that open an endpoint's breaker
+	BreakerOpenDuration time.Duration // how long an open breaker rejects deliveries
+}
+
+// DefaultWebhookConfig holds the defaults for unset WebhookConfig fields.
+var DefaultWebhookConfig = WebhookConfig{
+	Timeout:             5 * time.Second,
+	MaxAttempts:         3,
+	BaseDelay:           500 * time.Millisecond,
+	MaxDelay:            5 * time.Second,
+	BreakerThreshold:    5,
+	BreakerOpenDuration: time.Minute,
+}
+
+func (c WebhookConfig) withDefaults() WebhookConfig {
+	d := DefaultWebhookConfig
+	if c.Timeout <= 0 {
+		c.Timeout = d.Timeout
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = d.MaxAttempts
+	}
+	if c.BaseDelay <= 0 {
+		c.BaseDelay = d.BaseDelay
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = d.MaxDelay
+	}
+	if c.BreakerThreshold <= 0 {
+		c.BreakerThreshold = d.BreakerThreshold
+	}
+	if c.BreakerOpenDuration <= 0 {
+		c.BreakerOpenDuration = d.BreakerOpenDuration
+	}
+	return c
+}
+
+// WebhookDispatcher POSTs the triggering event envelope as JSON to the rule's
+// destination URL, signed with the shared secret.
+//
+// Connection errors, timeouts, 429 and 5xx responses are retried with
+// exponential backoff; other non-2xx responses fail the delivery at once.
+// Each destination has its own circuit breaker, and every attempt is
+// recorded for auditing. Retries run in the consumer loop, so a failing
+// endpoint delays evaluation until its breaker opens.
+type WebhookDispatcher struct {
+	config   WebhookConfig
+	client   *http.Client
+	breakers *breakerSet
+	recorder DeliveryRecorder
+	sleep    func(ctx context.Context, d time.Duration) error
+	logger   *slog.Logger
+}
+
+// NewWebhookDispatcher creates a dispatcher for the "webhook" action type.
+func NewWebhookDispatcher(config WebhookConfig, recorder DeliveryRecorder, logger *slog.Logger) *WebhookDispatcher {
+	config = config.withDefaults()
+	return &WebhookDispatcher{
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		breakers: newBreakerSet(config.BreakerThreshold, config.BreakerOpenDuration),
+		recorder: recorder,
+		sleep:    clock.Sleep,
+		logger:   logger.With("dispatcher", ActionWebhook),
+	}
+}
+
+// ValidateDestination requires an absolute http or https URL.
+func (d *WebhookDispatcher) ValidateDestination(destination string) error {
+	return validateHTTPURL(destination)
+}
+
+// Dispatch delivers the event, retrying transient failures.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context, rule *rules.Rule, event *events.Envelope) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	deliveryID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate delivery ID: %w", err)
+	}
+	breaker := d.breakers.get(rule.Destination)
+
+	for attempt := 1; ; attempt++ {
+		record := rules.DeliveryAttempt{
+			DeliveryID:  deliveryID,
+			RuleID:      rule.RuleID,
+			EventID:     event.EventID,
+			ActionType:  ActionWebhook,
+			Destination: rule.Destination,
+			Attempt:     attempt,
+			AttemptedAt: clock.Now(),
+		}
+
+		if !breaker.Allow() {
+			record.Error = ErrCircuitOpen.Error()
+			d.record(ctx, &record)
+			return fmt.Errorf("webhook delivery to %s: %w", rule.Destination, ErrCircuitOpen)
+		}
+
+		start := time.Now()
+		status, err := d.send(ctx, rule, event, deliveryID, body)
+		record.Duration = time.Since(start)
+		if err != nil && ctx.Err() != nil {
+			// Cancelled by shutdown: says nothing about the endpoint.
+			breaker.Release()
+			return ctx.Err()
+		}
+
+		record.StatusCode = status
+		if err != nil {
+			record.Error = err.Error()
+		} else if status < 200 || status > 299 {
+			record.Error = http.StatusText(status)
+		}
+		transient := err != nil || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+		breaker.Record(!transient)
+		d.record(ctx, &record)
+
+		switch {
+		case record.Error == "":
+			return nil
+		case !transient:
+			return fmt.Errorf("webhook delivery to %s rejected: status %d", rule.Destination, status)
+		case attempt >= d.config.MaxAttempts:
+			return fmt.Errorf("webhook delivery to %s failed after %d attempts: %s", rule.Destination, attempt, record.Error)
+		}
+
+		if err := d.sleep(ctx, retry.Backoff(d.config.BaseDelay, d.config.MaxDelay, attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// send makes a single signed attempt and returns the response status.
+func (d *WebhookDispatcher) send(ctx context.Context, rule *rules.Rule, event *events.Envelope, deliveryID uuid.UUID, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rule.Destination, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	timestamp := clock.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cornjacket-actions")
+	req.Header.Set(HeaderDelivery, deliveryID.String())
+	req.Header.Set(HeaderEventID, event.EventID.String())
+	req.Header.Set(HeaderRuleID, rule.RuleID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(d.config.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // allow connection reuse
+	return resp.StatusCode, nil
+}
+
+// record stores an attempt. A failure to record is logged and does not
+// affect the delivery.
+func (d *WebhookDispatcher) record(ctx context.Context, attempt *rules.DeliveryAttempt) {
+	if err := d.recorder.RecordAttempt(ctx, attempt); err != nil {
+		d.logger.Error("failed to record delivery attempt",
+			"delivery_id", attempt.DeliveryID,
+			"attempt", attempt.Attempt,
+			"error", err,
+		)
+	}
+}
+
+// Sign returns the HeaderSignature value for a body sent at timestamp (Unix
+// seconds). Receivers recompute it and compare with hmac.Equal.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateHTTPURL requires an absolute http or https URL.
+func validateHTTPURL(destination string) error {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("destination must be an http or https URL, got %q", destination)
+	}
+	return nil
+}
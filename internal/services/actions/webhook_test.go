@@ -0,0 +1,267 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/rules"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+const testSecret = "s3cret"
+
+// webhookServer responds with the given statuses in turn (the last one
+// repeats) and records the requests it received.
+type webhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		n := len(s.requests)
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+		status := s.statuses[min(n, len(s.statuses)-1)]
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+// newTestWebhook returns a dispatcher that records its backoff delays
+// instead of sleeping.
+func newTestWebhook(config WebhookConfig, store *rules.MemoryStore) (*WebhookDispatcher, *[]time.Duration) {
+	config.Secret = testSecret
+	d := NewWebhookDispatcher(config, store, slog.Default())
+	var delays []time.Duration
+	d.sleep = func(ctx context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return nil
+	}
+	return d, &delays
+}
+
+func webhookRule(destination string) *rules.Rule {
+	return &rules.Rule{
+		RuleID:      uuid.Must(uuid.NewV7()),
+		Name:        "hook",
+		EventType:   "sensor.reading",
+		ActionType:  ActionWebhook,
+		Destination: destination,
+		Enabled:     true,
+	}
+}
+
+func TestWebhook_DeliversSignedEnvelope(t *testing.T) {
+	server := newWebhookServer(t, http.StatusOK)
+	store := rules.NewMemoryStore()
+	dispatcher, _ := newTestWebhook(WebhookConfig{}, store)
+	rule := webhookRule(server.URL)
+	event := testutil.Event().Type("sensor.reading").Payload(`{"value": 95}`).Build()
+
+	require.NoError(t, dispatcher.Dispatch(context.Background(), rule, event))
+	require.Equal(t, 1, server.count())
+
+	req, body := server.requests[0], server.bodies[0]
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, event.EventID.String(), req.Header.Get(HeaderEventID))
+	assert.Equal(t, rule.RuleID.String(), req.Header.Get(HeaderRuleID))
+	assert.NotEmpty(t, req.Header.Get(HeaderDelivery))
+
+	timestamp, err := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, Sign(testSecret, timestamp, body), req.Header.Get(HeaderSignature))
+
+	var received events.Envelope
+	require.NoError(t, json.Unmarshal(body, &received))
+	assert.Equal(t, event.EventID, received.EventID)
+	assert.JSONEq(t, `{"value": 95}`, string(received.Payload))
+
+	attempts, err := store.ListAttempts(context.Background(), rule.RuleID, 10)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, http.StatusOK, attempts[0].StatusCode)
+	assert.Empty(t, attempts[0].Error)
+	assert.Equal(t, event.EventID, attempts[0].EventID)
+}
+
+func TestWebhook_RetriesTransientFailures(t *testing.T) {
+	server := newWebhookServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusNoContent)
+	store := rules.NewMemoryStore()
+	dispatcher, delays := newTestWebhook(WebhookConfig{
+		MaxAttempts: 5,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    time.Second,
+	}, store)
+	rule := webhookRule(server.URL)
+
+	require.NoError(t, dispatcher.Dispatch(context.Background(), rule, testutil.Event().Type("sensor.reading").Payload(`{}`).Build()))
+	assert.Equal(t, 3, server.count())
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, *delays)
+
+	// Retries are the same delivery
+	assert.Equal(t, server.requests[0].Header.Get(HeaderDelivery), server.requests[2].Header.Get(HeaderDelivery))
+
+	attempts, err := store.ListAttempts(context.Background(), rule.RuleID, 10)
+	require.NoError(t, err)
+	require.Len(t, attempts, 3)
+	assert.Equal(t, 3, attempts[0].Attempt)
+	assert.Equal(t, http.StatusNoContent, attempts[0].StatusCode)
+	assert.Equal(t, http.StatusServiceUnavailable, attempts[2].StatusCode)
+	assert.Equal(t, "Service Unavailable", attempts[2].Error)
+	assert.Equal(t, attempts[0].DeliveryID, attempts[2].DeliveryID)
+}
+
+func TestWebhook_GivesUpAfterMaxAttempts(t *testing.T) {
+	server := newWebhookServer(t, http.StatusBadGateway)
+	dispatcher, _ := newTestWebhook(WebhookConfig{MaxAttempts: 2, BreakerThreshold: 10}, rules.NewMemoryStore())
+
+	err := dispatcher.Dispatch(context.Background(), webhookRule(server.URL), testutil.Event().Type("sensor.reading").Payload(`{}`).Build())
+	assert.ErrorContains(t, err, "failed after 2 attempts")
+	assert.Equal(t, 2, server.count())
+}
+
+func TestWebhook_DoesNotRetryClientErrors(t *testing.T) {
+	server := newWebhookServer(t, http.StatusBadRequest)
+	dispatcher, delays := newTestWebhook(WebhookConfig{}, rules.NewMemoryStore())
+
+	err := dispatcher.Dispatch(context.Background(), webhookRule(server.URL), testutil.Event().Type("sensor.reading").Payload(`{}`).Build())
+	assert.ErrorContains(t, err, "rejected: status 400")
+	assert.Equal(t, 1, server.count())
+	assert.Empty(t, *delays)
+}
+
+func TestWebhook_RetriesConnectionErrors(t *testing.T) {
+	server := newWebhookServer(t, http.StatusOK)
+	url := server.URL
+	server.Close()
+
+	store := rules.NewMemoryStore()
+	dispatcher, _ := newTestWebhook(WebhookConfig{MaxAttempts: 2}, store)
+	rule := webhookRule(url)
+
+	err := dispatcher.Dispatch(context.Background(), rule, testutil.Event().Type("sensor.reading").Payload(`{}`).Build())
+	assert.ErrorContains(t, err, "failed after 2 attempts")
+
+	attempts, err := store.ListAttempts(context.Background(), rule.RuleID, 10)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Zero(t, attempts[0].StatusCode)
+	assert.Contains(t, attempts[0].Error, "failed to send request")
+}
+
+func TestWebhook_CircuitBreakerPerEndpoint(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	failing := newWebhookServer(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK)
+	healthy := newWebhookServer(t, http.StatusOK)
+	store := rules.NewMemoryStore()
+	dispatcher, _ := newTestWebhook(WebhookConfig{
+		MaxAttempts:         1,
+		BreakerThreshold:    2,
+		BreakerOpenDuration: time.Minute,
+	}, store)
+	failingRule := webhookRule(failing.URL)
+	event := testutil.Event().Type("sensor.reading").Payload(`{}`).Build()
+
+	assert.Error(t, dispatcher.Dispatch(context.Background(), failingRule, event))
+	assert.Error(t, dispatcher.Dispatch(context.Background(), failingRule, event))
+
+	// Open: rejected without a request, and recorded
+	err := dispatcher.Dispatch(context.Background(), failingRule, event)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 2, failing.count())
+	attempts, err := store.ListAttempts(context.Background(), failingRule.RuleID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, ErrCircuitOpen.Error(), attempts[0].Error)
+
+	// Other endpoints are unaffected
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), webhookRule(healthy.URL), event))
+
+	// After the open duration a trial delivery goes through and closes it
+	clock.Set(clock.FixedClock{Time: now.Add(time.Minute)})
+	assert.NoError(t, dispatcher.Dispatch(context.Background(), failingRule, event))
+	assert.Equal(t, 3, failing.count())
+}
+
+func TestWebhook_CancelledDuringBackoff(t *testing.T) {
+	server := newWebhookServer(t, http.StatusServiceUnavailable)
+	dispatcher := NewWebhookDispatcher(WebhookConfig{Secret: testSecret, BaseDelay: time.Hour}, rules.NewMemoryStore(), slog.Default())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for server.count() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	err := dispatcher.Dispatch(ctx, webhookRule(server.URL), testutil.Event().Type("sensor.reading").Payload(`{}`).Build())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, server.count())
+}
+
+func TestWebhook_ValidateDestination(t *testing.T) {
+	dispatcher, _ := newTestWebhook(WebhookConfig{}, rules.NewMemoryStore())
+
+	assert.NoError(t, dispatcher.ValidateDestination("https://hooks.example.com/cornjacket"))
+	assert.NoError(t, dispatcher.ValidateDestination("http://localhost:9000/hook"))
+	assert.Error(t, dispatcher.ValidateDestination(""))
+	assert.Error(t, dispatcher.ValidateDestination("ops-alerts"))
+	assert.Error(t, dispatcher.ValidateDestination("ftp://example.com/hook"))
+}
+
+func TestRules_WebhookDestinationValidated(t *testing.T) {
+	mux, engine, _ := newTestRoutes()
+	dispatcher, _ := newTestWebhook(WebhookConfig{}, rules.NewMemoryStore())
+	engine.RegisterDispatcher(ActionWebhook, dispatcher)
+
+	body := `{"name": "hook", "event_type": "sensor.reading", "action_type": "webhook", "destination": "not a url"}`
+	w := serve(mux, http.MethodPost, "/api/v1/rules", body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid destination")
+
+	body = `{"name": "hook", "event_type": "sensor.reading", "action_type": "webhook", "destination": "https://hooks.example.com/x"}`
+	w = serve(mux, http.MethodPost, "/api/v1/rules", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestSign(t *testing.T) {
+	// Receivers in other languages can check their implementation against this
+	// (same as: printf %s "1790000000.{\"event_id\":\"x\"}" | openssl dgst -sha256 -hmac s3cret).
+	assert.Equal(t,
+		"sha256=7d3de7d1afe8e3ca881fc79de2809e0a866aafe6485872cbc02739c968bc5bab",
+		Sign("s3cret", 1790000000, []byte(`{"event_id":"x"}`)))
+}
@@ -0,0 +1,872 @@
+package eventhandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/audit"
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+)
+
+// ConsumerController is the operator surface of the event consumer.
+// Satisfied by *Consumer.
+type ConsumerController interface {
+	Pause(topics ...string) error
+	Resume(topics ...string) error
+	Drain(ctx context.Context) error
+	Status(ctx context.Context) (*ConsumerStatus, error)
+	Freshness() Freshness
+}
+
+// IntegrityChecker is the operator surface of the projection verifier.
+// Satisfied by *Verifier.
+type IntegrityChecker interface {
+	Verify(ctx context.Context) (*IntegrityReport, error)
+	LastReport() *IntegrityReport
+}
+
+// HandlerRouter is the operator surface of the handler registry.
+// Satisfied by *HandlerRegistry.
+type HandlerRouter interface {
+	Routes() []HandlerRoute
+	Kinds() []string
+	Route(pattern, name, kind string) error
+	Unregister(name string) error
+}
+
+// Backfiller is the operator surface of handler backfills.
+// Satisfied by *Backfills.
+type Backfiller interface {
+	Start(name string) (BackfillStatus, error)
+	List() []BackfillStatus
+}
+
+// LogLevel is the process-wide log level, adjustable at runtime.
+// Satisfied by *slog.LevelVar.
+type LogLevel interface {
+	Level() slog.Level
+	Set(slog.Level)
+}
+
+// AdminHandler serves the event handler's admin API. Used during projection
+// schema migrations to stop applying events without killing the process.
+type AdminHandler struct {
+	consumer  ConsumerController
+	integrity IntegrityChecker // nil when verification is disabled
+	freezer   AggregateFreezer // nil when the store has no aggregate flags
+	handlers  HandlerRouter    // nil disables the handler endpoints
+	backfills Backfiller       // nil disables the backfill endpoints
+	aliases   AliasStore       // nil disables the alias endpoints
+	registry  registry.Store   // nil disables the registry endpoints
+	dlq       QuarantineStore  // nil disables the DLQ endpoint
+	audit     *audit.Recorder  // nil when the audit log is disabled
+	logger    *slog.Logger
+
+	// Runtime log level (see SetLogLevel); a temporary change is reverted
+	// to baseLevel at revertAt
+	logLevel  LogLevel
+	levelMu   sync.Mutex
+	revert    *time.Timer
+	baseLevel slog.Level
+	revertAt  time.Time
+}
+
+// NewAdminHandler creates a new admin HTTP handler.
+// integrity and freezer are optional (nil disables their endpoints).
+func NewAdminHandler(consumer ConsumerController, integrity IntegrityChecker, freezer AggregateFreezer, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		consumer:  consumer,
+		integrity: integrity,
+		freezer:   freezer,
+		logger:    logger.With("handler", "eventhandler-admin"),
+	}
+}
+
+// SetLogLevel enables GET/PUT /admin/v1/log-level for the given level.
+func (h *AdminHandler) SetLogLevel(level LogLevel) {
+	h.logLevel = level
+}
+
+// SetHandlers enables the /admin/v1/handlers endpoints, which add and remove
+// event handlers while the consumer runs.
+func (h *AdminHandler) SetHandlers(router HandlerRouter) {
+	h.handlers = router
+}
+
+// SetBackfills enables the backfill endpoints, which replay event history
+// into a registered handler.
+func (h *AdminHandler) SetBackfills(backfills Backfiller) {
+	h.backfills = backfills
+}
+
+// SetAliases enables the /admin/v1/aliases endpoints, which put shadow
+// projections into service.
+func (h *AdminHandler) SetAliases(aliases AliasStore) {
+	h.aliases = aliases
+}
+
+// SetRegistry enables the /admin/v1/registry endpoints, which manage the
+// static attributes of known aggregates.
+func (h *AdminHandler) SetRegistry(store registry.Store) {
+	h.registry = store
+}
+
+// SetQuarantine enables GET /admin/v1/dlq, which lists the events the
+// consumer quarantined after repeated failures.
+func (h *AdminHandler) SetQuarantine(store QuarantineStore) {
+	h.dlq = store
+}
+
+// SetAudit records every state-changing admin request in the audit log.
+func (h *AdminHandler) SetAudit(rec *audit.Recorder) {
+	h.audit = rec
+}
+
+// RegisterRoutes registers admin routes on the provided mux.
+func (h *AdminHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/health", h.HandleHealth)
+	mux.HandleFunc("/admin/v1/consumer", h.HandleStatus)
+	mux.Handle("/admin/v1/consumer/pause", h.audit.Wrap("consumer.pause", http.HandlerFunc(h.HandlePause)))
+	mux.Handle("/admin/v1/consumer/resume", h.audit.Wrap("consumer.resume", http.HandlerFunc(h.HandleResume)))
+	mux.Handle("/admin/v1/consumer/drain", h.audit.Wrap("consumer.drain", http.HandlerFunc(h.HandleDrain)))
+	mux.HandleFunc("/admin/v1/consumer/freshness", h.HandleFreshness)
+	mux.Handle("/admin/v1/projections/integrity", h.audit.Wrap("projections.verify", http.HandlerFunc(h.HandleIntegrity)))
+	mux.HandleFunc("/admin/v1/aggregates/frozen", h.HandleListFrozen)
+	mux.Handle("/admin/v1/aggregates/", h.audit.Wrap("aggregate.flags", http.HandlerFunc(h.HandleFreeze)))
+	mux.Handle("/admin/v1/log-level", h.audit.Wrap("log-level", http.HandlerFunc(h.HandleLogLevel)))
+	mux.Handle("/admin/v1/handlers", h.audit.Wrap("handlers.route", http.HandlerFunc(h.HandleHandlers)))
+	mux.Handle("/admin/v1/handlers/", h.audit.Wrap("handlers.unregister", http.HandlerFunc(h.HandleUnregister)))
+	mux.Handle("/admin/v1/handlers/{name}/backfill", h.audit.Wrap("handlers.backfill", http.HandlerFunc(h.HandleBackfill)))
+	mux.HandleFunc("/admin/v1/backfills", h.HandleListBackfills)
+	mux.HandleFunc("/admin/v1/aliases", h.HandleListAliases)
+	mux.Handle("/admin/v1/aliases/", h.audit.Wrap("projections.alias", http.HandlerFunc(h.HandleAlias)))
+	mux.HandleFunc("/admin/v1/registry", h.HandleListRegistry)
+	mux.Handle("/admin/v1/registry/", h.audit.Wrap("registry.aggregate", http.HandlerFunc(h.HandleRegistry)))
+	mux.HandleFunc("/admin/v1/dlq", h.HandleListQuarantined)
+}
+
+// HandleStatus handles GET /admin/v1/consumer
+func (h *AdminHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	h.writeStatus(w, r)
+}
+
+// HandlePause handles POST /admin/v1/consumer/pause?topic=a&topic=b
+// Without topic parameters, every subscribed topic is paused.
+func (h *AdminHandler) HandlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	audit.Note(r.Context(), strings.Join(r.URL.Query()["topic"], ","), "")
+	if err := h.consumer.Pause(r.URL.Query()["topic"]...); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.writeStatus(w, r)
+}
+
+// HandleResume handles POST /admin/v1/consumer/resume?topic=a&topic=b
+// Without topic parameters, every subscribed topic is resumed.
+func (h *AdminHandler) HandleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	audit.Note(r.Context(), strings.Join(r.URL.Query()["topic"], ","), "")
+	if err := h.consumer.Resume(r.URL.Query()["topic"]...); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.writeStatus(w, r)
+}
+
+// HandleDrain handles POST /admin/v1/consumer/drain
+// Finishes the in-flight batch, commits, and stops consuming. Blocks until the
+// consumer has stopped or the request is cancelled. Not reversible without a
+// restart.
+func (h *AdminHandler) HandleDrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if err := h.consumer.Drain(r.Context()); err != nil {
+		h.writeError(w, http.StatusServiceUnavailable, "drain did not complete: "+err.Error())
+		return
+	}
+	h.writeStatus(w, r)
+}
+
+// HandleFreshness handles GET /admin/v1/consumer/freshness
+// Cheaper than the full status (no offset lookups); meant for alerting, which
+// should watch the live indicators and use replaying to annotate planned rebuilds.
+func (h *AdminHandler) HandleFreshness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.consumer.Freshness())
+}
+
+// HandleIntegrity handles the projection integrity endpoint:
+//
+//	GET  /admin/v1/projections/integrity — most recent verification report
+//	POST /admin/v1/projections/integrity — run a verification now
+func (h *AdminHandler) HandleIntegrity(w http.ResponseWriter, r *http.Request) {
+	if h.integrity == nil {
+		h.writeError(w, http.StatusNotFound, "projection verification is disabled")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		report := h.integrity.LastReport()
+		if report == nil {
+			h.writeError(w, http.StatusNotFound, "no verification has run yet")
+			return
+		}
+		h.writeJSON(w, http.StatusOK, report)
+	case http.MethodPost:
+		report, err := h.integrity.Verify(r.Context())
+		if err != nil {
+			h.logger.Error("projection verification failed", "error", err)
+			h.writeError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		h.writeJSON(w, http.StatusOK, report)
+	default:
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// HandleListFrozen handles GET /admin/v1/aggregates/frozen
+func (h *AdminHandler) HandleListFrozen(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.freezer == nil {
+		h.writeError(w, http.StatusNotFound, "aggregate flags are not available")
+		return
+	}
+
+	frozen, err := h.freezer.ListFrozen(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list frozen aggregates", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"frozen": frozen})
+}
+
+// freezeRequest is the optional body of a freeze request.
+type freezeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// HandleFreeze handles the per-aggregate freeze endpoints:
+//
+//	POST /admin/v1/aggregates/{aggregate_id}/freeze   — body {"reason": "..."} (optional)
+//	POST /admin/v1/aggregates/{aggregate_id}/unfreeze
+//
+// Events for a frozen aggregate are still stored by ingestion but are not
+// applied to projections.
+func (h *AdminHandler) HandleFreeze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.freezer == nil {
+		h.writeError(w, http.StatusNotFound, "aggregate flags are not available")
+		return
+	}
+
+	// Expected path: /admin/v1/aggregates/{aggregate_id}/{action}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/v1/aggregates/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		h.writeError(w, http.StatusBadRequest, "invalid path: expected /admin/v1/aggregates/{id}/freeze or /unfreeze")
+		return
+	}
+	aggregateID, action := parts[0], parts[1]
+	audit.Note(r.Context(), aggregateID, action)
+
+	var err error
+	switch action {
+	case "freeze":
+		var req freezeRequest
+		if r.ContentLength != 0 {
+			if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+				h.writeError(w, http.StatusBadRequest, "invalid JSON: "+decodeErr.Error())
+				return
+			}
+		}
+		audit.Notef(r.Context(), aggregateID, "freeze: %s", req.Reason)
+		err = h.freezer.FreezeAggregate(r.Context(), aggregateID, req.Reason)
+		if err == nil {
+			h.logger.Warn("aggregate frozen", "aggregate_id", aggregateID, "reason", req.Reason)
+		}
+	case "unfreeze":
+		err = h.freezer.UnfreezeAggregate(r.Context(), aggregateID)
+		if err == nil {
+			h.logger.Info("aggregate unfrozen", "aggregate_id", aggregateID)
+		}
+	default:
+		h.writeError(w, http.StatusBadRequest, "unknown action: "+action)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to update aggregate flags", "aggregate_id", aggregateID, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"aggregate_id": aggregateID,
+		"frozen":       action == "freeze",
+	})
+}
+
+// routeRequest is the body of a handler registration.
+type routeRequest struct {
+	Pattern string `json:"pattern"` // prefix or glob, e.g. "sensor." or "*.deleted"
+	Name    string `json:"name"`
+	Kind    string `json:"kind"` // one of the handler kinds listed by GET
+}
+
+// handlersResponse lists the registered handlers and the kinds that can be added.
+type handlersResponse struct {
+	Handlers []HandlerRoute `json:"handlers"`
+	Kinds    []string       `json:"kinds"`
+}
+
+// HandleHandlers handles the handler registry endpoint:
+//
+//	GET  /admin/v1/handlers — registered handlers and available kinds
+//	POST /admin/v1/handlers — body {"pattern": "sensor.reading", "name": "sensor_canary", "kind": "sensor"}
+//
+// Changes take effect for the next event dispatched, without a consumer
+// restart or group rebalance. They apply to this replica only and are lost on
+// restart.
+func (h *AdminHandler) HandleHandlers(w http.ResponseWriter, r *http.Request) {
+	if h.handlers == nil {
+		h.writeError(w, http.StatusNotFound, "runtime handler changes are disabled")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.writeHandlers(w, http.StatusOK)
+	case http.MethodPost:
+		var req routeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		if req.Pattern == "" || req.Name == "" || req.Kind == "" {
+			h.writeError(w, http.StatusBadRequest, "pattern, name and kind are required")
+			return
+		}
+		audit.Notef(r.Context(), req.Name, "kind=%s pattern=%s", req.Kind, req.Pattern)
+		if err := h.handlers.Route(req.Pattern, req.Name, req.Kind); err != nil {
+			h.writeError(w, handlerErrorStatus(err), err.Error())
+			return
+		}
+		h.logger.Warn("handler registered at runtime", "handler", req.Name, "kind", req.Kind, "pattern", req.Pattern)
+		h.writeHandlers(w, http.StatusCreated)
+	default:
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// HandleUnregister handles DELETE /admin/v1/handlers/{name}
+// Handlers registered at startup can be removed too.
+func (h *AdminHandler) HandleUnregister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.handlers == nil {
+		h.writeError(w, http.StatusNotFound, "runtime handler changes are disabled")
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/admin/v1/handlers/")
+	if name == "" || strings.Contains(name, "/") {
+		h.writeError(w, http.StatusBadRequest, "invalid path: expected /admin/v1/handlers/{name}")
+		return
+	}
+	audit.Note(r.Context(), name, "")
+	if err := h.handlers.Unregister(name); err != nil {
+		h.writeError(w, handlerErrorStatus(err), err.Error())
+		return
+	}
+	h.logger.Warn("handler unregistered at runtime", "handler", name)
+	h.writeHandlers(w, http.StatusOK)
+}
+
+// handlerErrorStatus maps a handler registry error to an HTTP status.
+func handlerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrHandlerNotFound), errors.Is(err, ErrUnknownHandlerKind):
+		return http.StatusNotFound
+	case errors.Is(err, ErrHandlerExists), errors.Is(err, ErrPatternConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func (h *AdminHandler) writeHandlers(w http.ResponseWriter, status int) {
+	h.writeJSON(w, status, handlersResponse{
+		Handlers: h.handlers.Routes(),
+		Kinds:    h.handlers.Kinds(),
+	})
+}
+
+// HandleBackfill handles POST /admin/v1/handlers/{name}/backfill
+// The replay runs in the background; follow it with GET /admin/v1/backfills.
+func (h *AdminHandler) HandleBackfill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.backfills == nil {
+		h.writeError(w, http.StatusNotFound, "backfills are not available")
+		return
+	}
+
+	name := r.PathValue("name")
+	audit.Note(r.Context(), name, "")
+	run, err := h.backfills.Start(name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrHandlerNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrBackfillRunning):
+			status = http.StatusConflict
+		}
+		h.writeError(w, status, err.Error())
+		return
+	}
+	h.logger.Warn("backfill started", "handler", name, "pattern", run.Pattern)
+	h.writeJSON(w, http.StatusAccepted, run)
+}
+
+// HandleListBackfills handles GET /admin/v1/backfills
+// It lists the latest backfill of each handler since the process started.
+func (h *AdminHandler) HandleListBackfills(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.backfills == nil {
+		h.writeError(w, http.StatusNotFound, "backfills are not available")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"backfills": h.backfills.List()})
+}
+
+// HandleListAliases handles GET /admin/v1/aliases
+func (h *AdminHandler) HandleListAliases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.aliases == nil {
+		h.writeError(w, http.StatusNotFound, "projection aliases are not available")
+		return
+	}
+
+	aliases, err := h.aliases.ListAliases(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list projection aliases", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"aliases": aliases})
+}
+
+// aliasRequest is the body of an alias change.
+type aliasRequest struct {
+	ProjectionType string `json:"projection_type"`
+}
+
+// HandleAlias handles the per-alias endpoints:
+//
+//	PUT    /admin/v1/aliases/{alias} — body {"projection_type": "sensor_state_v2"}
+//	DELETE /admin/v1/aliases/{alias}
+//
+// The query service serves reads of the alias from the aliased type from
+// its next request on: a single-row change, so the cutover is atomic.
+func (h *AdminHandler) HandleAlias(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.aliases == nil {
+		h.writeError(w, http.StatusNotFound, "projection aliases are not available")
+		return
+	}
+
+	alias := strings.TrimPrefix(r.URL.Path, "/admin/v1/aliases/")
+	if alias == "" || strings.Contains(alias, "/") {
+		h.writeError(w, http.StatusBadRequest, "invalid path: expected /admin/v1/aliases/{alias}")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		audit.Note(r.Context(), alias, "")
+		if err := h.aliases.RemoveAlias(r.Context(), alias); err != nil {
+			h.logger.Error("failed to remove projection alias", "alias", alias, "error", err)
+			h.writeError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		h.logger.Warn("projection alias removed", "alias", alias)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req aliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if req.ProjectionType == "" || req.ProjectionType == alias {
+		h.writeError(w, http.StatusBadRequest, "projection_type is required and must differ from the alias")
+		return
+	}
+	audit.Note(r.Context(), alias, "-> "+req.ProjectionType)
+	if err := h.aliases.SetAlias(r.Context(), alias, req.ProjectionType); err != nil {
+		h.logger.Error("failed to set projection alias", "alias", alias, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	h.logger.Warn("projection alias set", "alias", alias, "projection_type", req.ProjectionType)
+	h.writeJSON(w, http.StatusOK, map[string]string{"alias": alias, "projection_type": req.ProjectionType})
+}
+
+// HandleListRegistry handles
+// GET /admin/v1/registry?aggregate_type=&site=&model=&owner=&tag=&limit=&offset=
+// Entries are ordered by aggregate ID; the filters are optional.
+func (h *AdminHandler) HandleListRegistry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.registry == nil {
+		h.writeError(w, http.StatusNotFound, "the aggregate registry is not available")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := registry.Filter{
+		AggregateType: q.Get("aggregate_type"),
+		Site:          q.Get("site"),
+		Model:         q.Get("model"),
+		Owner:         q.Get("owner"),
+		Tag:           q.Get("tag"),
+	}
+	limit, offset := 20, 0
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		limit = min(l, 100)
+	}
+	if o, err := strconv.Atoi(q.Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	list, total, err := h.registry.ListAggregates(r.Context(), filter, limit, offset)
+	if err != nil {
+		h.logger.Error("failed to list registry entries", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"aggregates": list,
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// HandleListQuarantined handles GET /admin/v1/dlq?consumer=&limit=&offset=
+// Quarantined events are listed most recently failed first, with the
+// envelope as consumed, the last error and where the record sits in Kafka.
+// Events still being retried are not listed.
+func (h *AdminHandler) HandleListQuarantined(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.dlq == nil {
+		h.writeError(w, http.StatusNotFound, "the DLQ is not available")
+		return
+	}
+
+	q := r.URL.Query()
+	limit, offset := 20, 0
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		limit = min(l, 100)
+	}
+	if o, err := strconv.Atoi(q.Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	list, total, err := h.dlq.ListQuarantined(r.Context(), q.Get("consumer"), limit, offset)
+	if err != nil {
+		h.logger.Error("failed to list quarantined events", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"events": list,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// registryRequest is the body of a registry entry change.
+type registryRequest struct {
+	AggregateType string   `json:"aggregate_type"`
+	Site          string   `json:"site"`
+	Model         string   `json:"model"`
+	Owner         string   `json:"owner"`
+	Tags          []string `json:"tags"`
+}
+
+// HandleRegistry handles the per-aggregate registry endpoints:
+//
+//	GET    /admin/v1/registry/{aggregate_id}
+//	PUT    /admin/v1/registry/{aggregate_id} — body {"site": "plant-a", "model": "TH-200", "owner": "facilities", "tags": ["critical"]}
+//	DELETE /admin/v1/registry/{aggregate_id}
+//
+// PUT replaces the whole entry: fields left out are cleared. It answers 201
+// when it creates the entry.
+func (h *AdminHandler) HandleRegistry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.registry == nil {
+		h.writeError(w, http.StatusNotFound, "the aggregate registry is not available")
+		return
+	}
+
+	aggregateID := strings.TrimPrefix(r.URL.Path, "/admin/v1/registry/")
+	if aggregateID == "" || strings.Contains(aggregateID, "/") {
+		h.writeError(w, http.StatusBadRequest, "invalid path: expected /admin/v1/registry/{aggregate_id}")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		entry, err := h.registry.GetAggregate(r.Context(), aggregateID)
+		if err != nil {
+			h.writeRegistryError(w, aggregateID, "failed to get registry entry", err)
+			return
+		}
+		h.writeJSON(w, http.StatusOK, entry)
+
+	case http.MethodDelete:
+		audit.Note(r.Context(), aggregateID, "")
+		if err := h.registry.DeleteAggregate(r.Context(), aggregateID); err != nil {
+			h.writeRegistryError(w, aggregateID, "failed to delete registry entry", err)
+			return
+		}
+		h.logger.Info("registry entry deleted", "aggregate_id", aggregateID)
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodPut:
+		var req registryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		entry := &registry.Aggregate{
+			AggregateID:   aggregateID,
+			AggregateType: req.AggregateType,
+			Site:          req.Site,
+			Model:         req.Model,
+			Owner:         req.Owner,
+			Tags:          req.Tags,
+		}
+		if err := entry.Validate(); err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		audit.Notef(r.Context(), aggregateID, "site=%s model=%s owner=%s tags=%s",
+			entry.Site, entry.Model, entry.Owner, strings.Join(entry.Tags, ","))
+		created, err := h.registry.PutAggregate(r.Context(), entry)
+		if err != nil {
+			h.writeRegistryError(w, aggregateID, "failed to put registry entry", err)
+			return
+		}
+		h.logger.Info("registry entry saved", "aggregate_id", aggregateID, "created", created)
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		h.writeJSON(w, status, entry)
+	}
+}
+
+// writeRegistryError maps registry store errors to responses: a missing
+// entry is 404, anything else 500.
+func (h *AdminHandler) writeRegistryError(w http.ResponseWriter, aggregateID, msg string, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.writeError(w, http.StatusNotFound, "registry entry not found")
+		return
+	}
+	h.logger.Error(msg, "aggregate_id", aggregateID, "error", err)
+	h.writeError(w, http.StatusInternalServerError, "internal server error")
+}
+
+// logLevelRequest is the body of a log level change.
+type logLevelRequest struct {
+	Level string `json:"level"`
+	// Duration, if set, reverts the change afterwards (e.g. "15m"), so a
+	// forgotten debug session does not flood the logs.
+	Duration string `json:"duration,omitempty"`
+}
+
+// logLevelResponse reports the current log level.
+type logLevelResponse struct {
+	Level    string     `json:"level"`
+	RevertTo string     `json:"revert_to,omitempty"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// HandleLogLevel handles the runtime log level endpoint:
+//
+//	GET /admin/v1/log-level — current level
+//	PUT /admin/v1/log-level — body {"level": "debug", "duration": "15m"} (duration optional)
+//
+// The level applies to every service in the process.
+func (h *AdminHandler) HandleLogLevel(w http.ResponseWriter, r *http.Request) {
+	if h.logLevel == nil {
+		h.writeError(w, http.StatusNotFound, "runtime log level changes are disabled")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req logLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid level: expected debug, info, warn or error")
+			return
+		}
+		var duration time.Duration
+		if req.Duration != "" {
+			d, err := time.ParseDuration(req.Duration)
+			if err != nil || d <= 0 {
+				h.writeError(w, http.StatusBadRequest, "invalid duration: expected a positive duration such as 15m")
+				return
+			}
+			duration = d
+		}
+		audit.Notef(r.Context(), "", "level=%s duration=%s", levelName(level), duration)
+		h.setLogLevel(level, duration)
+	default:
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.logLevelStatus())
+}
+
+// setLogLevel changes the level, replacing any pending revert. A temporary
+// change reverts to the level in force before the first temporary change.
+func (h *AdminHandler) setLogLevel(level slog.Level, duration time.Duration) {
+	h.levelMu.Lock()
+	defer h.levelMu.Unlock()
+
+	base := h.logLevel.Level()
+	if h.revert != nil {
+		h.revert.Stop()
+		h.revert = nil
+		base = h.baseLevel
+	}
+	h.logLevel.Set(level)
+
+	if duration > 0 {
+		h.baseLevel = base
+		h.revertAt = clock.Now().Add(duration)
+		var timer *time.Timer
+		timer = time.AfterFunc(duration, func() {
+			h.levelMu.Lock()
+			defer h.levelMu.Unlock()
+			if h.revert != timer {
+				return // superseded by a later change
+			}
+			h.logLevel.Set(h.baseLevel)
+			h.revert = nil
+			h.logger.Warn("log level reverted", "level", levelName(h.baseLevel))
+		})
+		h.revert = timer
+	}
+	h.logger.Warn("log level changed", "level", levelName(level), "duration", duration)
+}
+
+func (h *AdminHandler) logLevelStatus() logLevelResponse {
+	h.levelMu.Lock()
+	defer h.levelMu.Unlock()
+
+	resp := logLevelResponse{Level: levelName(h.logLevel.Level())}
+	if h.revert != nil {
+		revertAt := h.revertAt
+		resp.RevertTo = levelName(h.baseLevel)
+		resp.RevertAt = &revertAt
+	}
+	return resp
+}
+
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// HandleHealth handles GET /health
+func (h *AdminHandler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
+}
+
+func (h *AdminHandler) writeStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.consumer.Status(r.Context())
+	if err != nil {
+		h.logger.Error("failed to get consumer status", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, status)
+}
+
+func (h *AdminHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *AdminHandler) writeError(w http.ResponseWriter, status int, message string) {
+	h.writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,546 @@
+package eventhandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/audit"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+func newStatusMock() *mockConsumerController {
+	return &mockConsumerController{
+		StatusFn: func(ctx context.Context) (*ConsumerStatus, error) {
+			return &ConsumerStatus{
+				State:        ConsumerRunning,
+				Topics:       []string{"sensor-events", "user-actions"},
+				PausedTopics: []string{"sensor-events"},
+				Partitions: []PartitionStatus{
+					{Topic: "sensor-events", Partition: 0, Committed: 40, End: 42, Lag: 2},
+				},
+				TotalLag: 2,
+			}, nil
+		},
+	}
+}
+
+func serveAdmin(mock *mockConsumerController, integrity IntegrityChecker, method, target string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	NewAdminHandler(mock, integrity, nil, slog.Default()).RegisterRoutes(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestAdminStatus(t *testing.T) {
+	w := serveAdmin(newStatusMock(), nil, http.MethodGet, "/admin/v1/consumer")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var status ConsumerStatus
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&status))
+	assert.Equal(t, ConsumerRunning, status.State)
+	assert.Equal(t, []string{"sensor-events"}, status.PausedTopics)
+	assert.Equal(t, int64(2), status.TotalLag)
+}
+
+func TestAdminPause_Topics(t *testing.T) {
+	mock := newStatusMock()
+	var paused []string
+	mock.PauseFn = func(topics ...string) error {
+		paused = topics
+		return nil
+	}
+
+	w := serveAdmin(mock, nil, http.MethodPost, "/admin/v1/consumer/pause?topic=sensor-events&topic=user-actions")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"sensor-events", "user-actions"}, paused)
+}
+
+func TestAdminPause_UnknownTopic(t *testing.T) {
+	mock := newStatusMock()
+	mock.PauseFn = func(topics ...string) error {
+		return fmt.Errorf("topic %q is not consumed by the event handler", topics[0])
+	}
+
+	w := serveAdmin(mock, nil, http.MethodPost, "/admin/v1/consumer/pause?topic=billing")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAdminResume_AllTopics(t *testing.T) {
+	mock := newStatusMock()
+	var resumed []string
+	mock.ResumeFn = func(topics ...string) error {
+		resumed = topics
+		return nil
+	}
+
+	w := serveAdmin(mock, nil, http.MethodPost, "/admin/v1/consumer/resume")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, resumed, "no topic parameters should resume everything")
+}
+
+func TestAdminDrain(t *testing.T) {
+	mock := newStatusMock()
+	var drained bool
+	mock.DrainFn = func(ctx context.Context) error {
+		drained = true
+		return nil
+	}
+
+	w := serveAdmin(mock, nil, http.MethodPost, "/admin/v1/consumer/drain")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, drained)
+}
+
+func TestAdmin_MethodNotAllowed(t *testing.T) {
+	mock := newStatusMock()
+	mock.DrainFn = func(ctx context.Context) error {
+		t.Fatal("Drain should not be called on GET")
+		return nil
+	}
+
+	w := serveAdmin(mock, nil, http.MethodGet, "/admin/v1/consumer/drain")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestConsumerSubscribed(t *testing.T) {
+	c := &Consumer{config: ConsumerConfig{Topics: []string{"sensor-events", "user-actions"}}}
+
+	topics, err := c.subscribed(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sensor-events", "user-actions"}, topics, "empty list defaults to all subscribed topics")
+
+	topics, err = c.subscribed([]string{"user-actions"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-actions"}, topics)
+
+	_, err = c.subscribed([]string{"billing"})
+	assert.ErrorContains(t, err, "not consumed")
+}
+
+func TestAdminIntegrity_Disabled(t *testing.T) {
+	w := serveAdmin(newStatusMock(), nil, http.MethodGet, "/admin/v1/projections/integrity")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminIntegrity_VerifyNow(t *testing.T) {
+	integrity := &mockIntegrityChecker{
+		VerifyFn: func(ctx context.Context) (*IntegrityReport, error) {
+			return &IntegrityReport{
+				Discrepancies: []projections.Discrepancy{{ProjectionType: "sensor_state", AggregateID: "device-001"}},
+				Rebuilt:       1,
+			}, nil
+		},
+		LastReportFn: func() *IntegrityReport { return nil },
+	}
+
+	w := serveAdmin(newStatusMock(), integrity, http.MethodGet, "/admin/v1/projections/integrity")
+	assert.Equal(t, http.StatusNotFound, w.Code, "no report before the first run")
+
+	w = serveAdmin(newStatusMock(), integrity, http.MethodPost, "/admin/v1/projections/integrity")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report IntegrityReport
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	require.Len(t, report.Discrepancies, 1)
+	assert.Equal(t, "device-001", report.Discrepancies[0].AggregateID)
+	assert.Equal(t, 1, report.Rebuilt)
+}
+
+func serveFreeze(freezer AggregateFreezer, method, target, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	NewAdminHandler(newStatusMock(), nil, freezer, slog.Default()).RegisterRoutes(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+func TestAdminFreezeUnfreeze(t *testing.T) {
+	store := projections.NewMemoryStore()
+	ctx := context.Background()
+
+	w := serveFreeze(store, http.MethodPost, "/admin/v1/aggregates/device-666/freeze", `{"reason": "spamming bogus readings"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	frozen, err := store.IsFrozen(ctx, "device-666")
+	require.NoError(t, err)
+	assert.True(t, frozen)
+
+	w = serveFreeze(store, http.MethodGet, "/admin/v1/aggregates/frozen", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Frozen []projections.FrozenAggregate `json:"frozen"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	require.Len(t, list.Frozen, 1)
+	assert.Equal(t, "spamming bogus readings", list.Frozen[0].Reason)
+
+	w = serveFreeze(store, http.MethodPost, "/admin/v1/aggregates/device-666/unfreeze", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	frozen, err = store.IsFrozen(ctx, "device-666")
+	require.NoError(t, err)
+	assert.False(t, frozen)
+}
+
+func TestAdminFreeze_BadRequests(t *testing.T) {
+	store := projections.NewMemoryStore()
+
+	assert.Equal(t, http.StatusBadRequest, serveFreeze(store, http.MethodPost, "/admin/v1/aggregates/device-666/melt", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serveFreeze(store, http.MethodPost, "/admin/v1/aggregates/device-666", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serveFreeze(store, http.MethodPost, "/admin/v1/aggregates/device-666/freeze", "{").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serveFreeze(store, http.MethodGet, "/admin/v1/aggregates/device-666/freeze", "").Code)
+	assert.Equal(t, http.StatusNotFound, serveFreeze(nil, http.MethodPost, "/admin/v1/aggregates/device-666/freeze", "").Code)
+}
+
+func TestAdminFreshness(t *testing.T) {
+	watermark := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	mock := newStatusMock()
+	mock.FreshnessFn = func() Freshness {
+		return Freshness{
+			Live:      TrafficFreshness{Applied: 10, Watermark: &watermark, LagSeconds: 1.5},
+			Replay:    TrafficFreshness{Applied: 500},
+			Replaying: true,
+		}
+	}
+
+	w := serveAdmin(mock, nil, http.MethodGet, "/admin/v1/consumer/freshness")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var fr Freshness
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&fr))
+	assert.Equal(t, int64(10), fr.Live.Applied)
+	assert.Equal(t, 1.5, fr.Live.LagSeconds)
+	assert.True(t, fr.Replaying)
+
+	w = serveAdmin(mock, nil, http.MethodPost, "/admin/v1/consumer/freshness")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func serveLogLevel(admin *AdminHandler, method, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	admin.RegisterRoutes(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(method, "/admin/v1/log-level", strings.NewReader(body)))
+	return w
+}
+
+func decodeLogLevel(t *testing.T, w *httptest.ResponseRecorder) logLevelResponse {
+	t.Helper()
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp logLevelResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return resp
+}
+
+func TestAdminLogLevel(t *testing.T) {
+	level := new(slog.LevelVar)
+	admin := NewAdminHandler(newStatusMock(), nil, nil, slog.Default())
+	admin.SetLogLevel(level)
+
+	assert.Equal(t, "info", decodeLogLevel(t, serveLogLevel(admin, http.MethodGet, "")).Level)
+
+	resp := decodeLogLevel(t, serveLogLevel(admin, http.MethodPut, `{"level": "DEBUG"}`))
+	assert.Equal(t, "debug", resp.Level)
+	assert.Nil(t, resp.RevertAt)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+}
+
+func TestAdminLogLevel_TemporaryChangeReverts(t *testing.T) {
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	admin := NewAdminHandler(newStatusMock(), nil, nil, slog.Default())
+	admin.SetLogLevel(level)
+
+	resp := decodeLogLevel(t, serveLogLevel(admin, http.MethodPut, `{"level": "debug", "duration": "1h"}`))
+	assert.Equal(t, "warn", resp.RevertTo)
+	require.NotNil(t, resp.RevertAt)
+
+	// A second temporary change still reverts to the original level
+	resp = decodeLogLevel(t, serveLogLevel(admin, http.MethodPut, `{"level": "info", "duration": "20ms"}`))
+	assert.Equal(t, "info", resp.Level)
+	assert.Equal(t, "warn", resp.RevertTo)
+
+	assert.Eventually(t, func() bool { return level.Level() == slog.LevelWarn }, time.Second, 5*time.Millisecond)
+	assert.Empty(t, decodeLogLevel(t, serveLogLevel(admin, http.MethodGet, "")).RevertTo)
+}
+
+func TestAdminLogLevel_PermanentChangeCancelsRevert(t *testing.T) {
+	level := new(slog.LevelVar)
+	admin := NewAdminHandler(newStatusMock(), nil, nil, slog.Default())
+	admin.SetLogLevel(level)
+
+	decodeLogLevel(t, serveLogLevel(admin, http.MethodPut, `{"level": "debug", "duration": "20ms"}`))
+	decodeLogLevel(t, serveLogLevel(admin, http.MethodPut, `{"level": "error"}`))
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, slog.LevelError, level.Level())
+}
+
+func TestAdminLogLevel_BadRequests(t *testing.T) {
+	admin := NewAdminHandler(newStatusMock(), nil, nil, slog.Default())
+	assert.Equal(t, http.StatusNotFound, serveLogLevel(admin, http.MethodGet, "").Code)
+
+	admin.SetLogLevel(new(slog.LevelVar))
+	assert.Equal(t, http.StatusBadRequest, serveLogLevel(admin, http.MethodPut, "{").Code)
+	assert.Equal(t, http.StatusBadRequest, serveLogLevel(admin, http.MethodPut, `{"level": "verbose"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serveLogLevel(admin, http.MethodPut, `{"level": "debug", "duration": "soon"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serveLogLevel(admin, http.MethodPut, `{"level": "debug", "duration": "-1m"}`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serveLogLevel(admin, http.MethodDelete, "").Code)
+}
+
+func TestAdmin_Audit(t *testing.T) {
+	mock := newStatusMock()
+	mock.PauseFn = func(topics ...string) error { return nil }
+	log := audit.NewMemoryLog()
+	admin := NewAdminHandler(mock, nil, projections.NewMemoryStore(), slog.Default())
+	admin.SetAudit(audit.NewRecorder(log, "eventhandler", slog.Default()))
+	mux := http.NewServeMux()
+	admin.RegisterRoutes(mux)
+
+	serve := func(method, target, body string) {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, strings.NewReader(body)))
+	}
+	serve(http.MethodGet, "/admin/v1/consumer", "") // reads are not audited
+	serve(http.MethodPost, "/admin/v1/consumer/pause?topic=sensor-events", "")
+	serve(http.MethodPost, "/admin/v1/aggregates/device-666/freeze", `{"reason": "bogus readings"}`)
+
+	records := log.Records()
+	require.Len(t, records, 2)
+	assert.Equal(t, "consumer.pause", records[0].Action)
+	assert.Equal(t, "sensor-events", records[0].Target)
+	assert.Equal(t, "aggregate.flags", records[1].Action)
+	assert.Equal(t, "device-666", records[1].Target)
+	assert.Equal(t, "freeze: bogus readings", records[1].Detail)
+	assert.Equal(t, audit.OutcomeSucceeded, records[1].Outcome)
+}
+
+func TestAdminHandlers(t *testing.T) {
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", &mockEventHandler{})
+	registry.SetCatalog(map[string]EventHandler{"sensor": &mockEventHandler{}})
+	admin := NewAdminHandler(newStatusMock(), nil, nil, slog.Default())
+	admin.SetHandlers(registry)
+	mux := http.NewServeMux()
+	admin.RegisterRoutes(mux)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w
+	}
+
+	w := serve(http.MethodPost, "/admin/v1/handlers", `{"pattern": "sensor.reading", "name": "sensor_canary", "kind": "sensor"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp handlersResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, []HandlerRoute{
+		{Pattern: "sensor.", Handler: "sensor"},
+		{Pattern: "sensor.reading", Handler: "sensor_canary", Kind: "sensor"},
+	}, resp.Handlers)
+	assert.Equal(t, []string{"sensor"}, resp.Kinds)
+
+	assert.Equal(t, http.StatusConflict, serve(http.MethodPost, "/admin/v1/handlers", `{"pattern": "user.", "name": "sensor_canary", "kind": "sensor"}`).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/admin/v1/handlers", `{"pattern": "user.", "name": "billing", "kind": "billing"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/admin/v1/handlers", `{"pattern": "user."}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/admin/v1/handlers", `{`).Code)
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodDelete, "/admin/v1/handlers/sensor_canary", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/admin/v1/handlers/sensor_canary", "").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/admin/v1/handlers/sensor", "").Code)
+	assert.Len(t, registry.Routes(), 1)
+}
+
+func TestAdminHandlers_Disabled(t *testing.T) {
+	w := serveAdmin(newStatusMock(), nil, http.MethodGet, "/admin/v1/handlers")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminBackfills(t *testing.T) {
+	registry := NewHandlerRegistry(slog.Default())
+	block := make(chan struct{})
+	registry.Register("sensor.", "sensor_v2", &mockEventHandler{
+		HandleFn: func(ctx context.Context, event *events.Envelope) error {
+			<-block
+			return nil
+		},
+	})
+	admin := NewAdminHandler(newStatusMock(), nil, nil, slog.Default())
+	admin.SetHandlers(registry)
+	admin.SetBackfills(NewBackfills(context.Background(), eventLogOf(testutil.Event().Type("sensor.reading").Build()), registry, events.NewUpcasters(), slog.Default()))
+	mux := http.NewServeMux()
+	admin.RegisterRoutes(mux)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	w := serve(http.MethodPost, "/admin/v1/handlers/sensor_v2/backfill")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var run BackfillStatus
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&run))
+	assert.Equal(t, BackfillRunning, run.State)
+
+	assert.Equal(t, http.StatusConflict, serve(http.MethodPost, "/admin/v1/handlers/sensor_v2/backfill").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/admin/v1/handlers/billing/backfill").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet, "/admin/v1/handlers/sensor_v2/backfill").Code)
+	close(block)
+
+	w = serve(http.MethodGet, "/admin/v1/backfills")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"handler":"sensor_v2"`)
+
+	// Unregistering still goes to its own endpoint
+	assert.Equal(t, http.StatusOK, serve(http.MethodDelete, "/admin/v1/handlers/sensor_v2").Code)
+}
+
+func TestAdminAliases(t *testing.T) {
+	store := projections.NewMemoryStore()
+	admin := NewAdminHandler(newStatusMock(), nil, nil, slog.Default())
+	admin.SetAliases(store)
+	mux := http.NewServeMux()
+	admin.RegisterRoutes(mux)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodPut, "/admin/v1/aliases/sensor_state", `{"projection_type": "sensor_state_v2"}`).Code)
+	aliases, err := store.ListAliases(context.Background())
+	require.NoError(t, err)
+	require.Len(t, aliases, 1)
+	assert.Equal(t, "sensor_state_v2", aliases[0].ProjectionType)
+
+	w := serve(http.MethodGet, "/admin/v1/aliases", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"alias":"sensor_state"`)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/admin/v1/aliases/sensor_state", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/admin/v1/aliases/sensor_state", `{"projection_type": "sensor_state"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/admin/v1/aliases/", `{"projection_type": "x"}`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/admin/v1/aliases/sensor_state", "").Code)
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/admin/v1/aliases/sensor_state", "").Code)
+	aliases, err = store.ListAliases(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, aliases)
+}
+
+func TestAdminAliases_Disabled(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, serveAdmin(newStatusMock(), nil, http.MethodGet, "/admin/v1/aliases").Code)
+	assert.Equal(t, http.StatusNotFound, serveAdmin(newStatusMock(), nil, http.MethodGet, "/admin/v1/backfills").Code)
+}
+
+func TestAdminRegistry(t *testing.T) {
+	store := registry.NewMemoryStore()
+	admin := NewAdminHandler(newStatusMock(), nil, nil, slog.Default())
+	admin.SetRegistry(store)
+	mux := http.NewServeMux()
+	admin.RegisterRoutes(mux)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w
+	}
+
+	body := `{"aggregate_type": "device", "site": "plant-a", "model": "TH-200", "tags": ["critical", "cold-chain"]}`
+	assert.Equal(t, http.StatusCreated, serve(http.MethodPut, "/admin/v1/registry/device-001", body).Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodPut, "/admin/v1/registry/device-001", body).Code)
+	assert.Equal(t, http.StatusCreated, serve(http.MethodPut, "/admin/v1/registry/device-002", `{"site": "plant-b"}`).Code)
+
+	w := serve(http.MethodGet, "/admin/v1/registry/device-001", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var entry registry.Aggregate
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entry))
+	assert.Equal(t, "plant-a", entry.Site)
+	assert.Equal(t, []string{"cold-chain", "critical"}, entry.Tags)
+
+	w = serve(http.MethodGet, "/admin/v1/registry?site=plant-a", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"aggregate_id":"device-001"`)
+	assert.NotContains(t, w.Body.String(), `"device-002"`)
+	assert.Contains(t, w.Body.String(), `"total":1`)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/admin/v1/registry/device-001", `{"aggregate_type": "Bad Type"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/admin/v1/registry/device-001", `{"tags": [""]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/admin/v1/registry/device-001", `not json`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPut, "/admin/v1/registry/", `{}`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/admin/v1/registry/device-001", "").Code)
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/admin/v1/registry/device-001", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/admin/v1/registry/device-001", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/admin/v1/registry/device-001", "").Code)
+}
+
+func TestAdminRegistry_Disabled(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, serveAdmin(newStatusMock(), nil, http.MethodGet, "/admin/v1/registry").Code)
+	assert.Equal(t, http.StatusNotFound, serveAdmin(newStatusMock(), nil, http.MethodGet, "/admin/v1/registry/device-001").Code)
+}
+
+func TestAdminDLQ(t *testing.T) {
+	store := projections.NewMemoryStore()
+	admin := NewAdminHandler(newStatusMock(), nil, nil, slog.Default())
+	admin.SetQuarantine(store)
+	mux := http.NewServeMux()
+	admin.RegisterRoutes(mux)
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	ctx := context.Background()
+	poison := projections.DispatchFailure{
+		Consumer:  "event-handler",
+		EventID:   testutil.Event().Build().EventID,
+		Payload:   json.RawMessage(`{"event_type": "sensor.reading"}`),
+		Topic:     "sensor-events",
+		Partition: 1,
+		Offset:    7,
+		Error:     "handler sensor: boom",
+	}
+	_, _, err := store.RecordFailure(ctx, poison, 1)
+	require.NoError(t, err)
+	retrying := poison
+	retrying.EventID = testutil.Event().Build().EventID
+	_, _, err = store.RecordFailure(ctx, retrying, 5)
+	require.NoError(t, err)
+
+	w := serve(http.MethodGet, "/admin/v1/dlq?consumer=event-handler")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Events []projections.QuarantinedEvent `json:"events"`
+		Total  int                            `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, 1, body.Total)
+	require.Len(t, body.Events, 1)
+	assert.Equal(t, poison.EventID, body.Events[0].EventID)
+	assert.Equal(t, "handler sensor: boom", body.Events[0].Error)
+	assert.Equal(t, int64(7), body.Events[0].Offset)
+	assert.JSONEq(t, `{"event_type": "sensor.reading"}`, string(body.Events[0].Payload))
+
+	w = serve(http.MethodGet, "/admin/v1/dlq?consumer=search-indexer")
+	assert.Contains(t, w.Body.String(), `"events":[]`)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodDelete, "/admin/v1/dlq").Code)
+}
+
+func TestAdminDLQ_Disabled(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, serveAdmin(newStatusMock(), nil, http.MethodGet, "/admin/v1/dlq").Code)
+}
@@ -0,0 +1,83 @@
+package eventhandler
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// AnomalyConfig holds thresholds for sensor rate-of-change anomaly flags.
+// A zero threshold disables that check.
+type AnomalyConfig struct {
+	MaxDelta float64       // flag value_jump when |value - previous value| exceeds this
+	MaxGap   time.Duration // flag reporting_gap when the time since the previous event exceeds this
+}
+
+func (c AnomalyConfig) enabled() bool {
+	return c.MaxDelta > 0 || c.MaxGap > 0
+}
+
+// sensorValue is the part of a sensor payload anomaly detection reads.
+type sensorValue struct {
+	Value *float64 `json:"value"`
+}
+
+// withAnomaly returns the event payload with an "anomaly" object computed
+// against the stored projection of type projType. The first event for a device, and payloads
+// that are not JSON objects, are stored unflagged.
+func (h *SensorHandler) withAnomaly(ctx context.Context, projType string, event *events.Envelope) ([]byte, error) {
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(event.Payload, &state); err != nil {
+		return event.Payload, nil
+	}
+
+	var anomaly projections.Anomaly
+	prev, err := h.previous.GetProjection(ctx, projType, event.AggregateID)
+	switch {
+	case err == nil:
+		anomaly = h.anomaly.detect(prev, event)
+	case !strings.Contains(err.Error(), "no rows"):
+		return nil, err
+	}
+
+	raw, err := json.Marshal(anomaly)
+	if err != nil {
+		return nil, err
+	}
+	state["anomaly"] = raw
+
+	if anomaly.ValueJump || anomaly.ReportingGap {
+		h.logger.Info("sensor anomaly detected",
+			"aggregate_id", event.AggregateID,
+			"event_id", event.EventID,
+			"value_jump", anomaly.ValueJump,
+			"reporting_gap", anomaly.ReportingGap,
+		)
+	}
+	return json.Marshal(state)
+}
+
+// detect compares event against the previous projection. Events older than
+// the stored state are never written, so only forward gaps are measured.
+func (c AnomalyConfig) detect(prev *projections.Projection, event *events.Envelope) projections.Anomaly {
+	var anomaly projections.Anomaly
+
+	if gap := event.EventTime.Sub(prev.LastEventTimestamp); gap > 0 {
+		anomaly.GapSeconds = gap.Seconds()
+		anomaly.ReportingGap = c.MaxGap > 0 && gap > c.MaxGap
+	}
+
+	var before, after sensorValue
+	if json.Unmarshal(prev.State, &before) == nil && json.Unmarshal(event.Payload, &after) == nil &&
+		before.Value != nil && after.Value != nil {
+		anomaly.Delta = math.Abs(*after.Value - *before.Value)
+		anomaly.ValueJump = c.MaxDelta > 0 && anomaly.Delta > c.MaxDelta
+	}
+
+	return anomaly
+}
@@ -0,0 +1,114 @@
+package eventhandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// handleWithPrevious runs the sensor handler with anomaly detection against
+// prev (nil means no stored projection) and returns the state written.
+func handleWithPrevious(t *testing.T, cfg AnomalyConfig, prev *projections.Projection, event *events.Envelope) map[string]any {
+	t.Helper()
+
+	var written []byte
+	writer := &mockProjectionWriter{
+		WriteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+			written = state
+			return nil
+		},
+	}
+	reader := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			if prev == nil {
+				return nil, fmt.Errorf("failed to get projection: %w", pgx.ErrNoRows)
+			}
+			return prev, nil
+		},
+	}
+
+	handler := NewSensorHandler(writer, slog.Default())
+	handler.SetAnomalyDetection(reader, cfg)
+	require.NoError(t, handler.Handle(context.Background(), event))
+
+	var state map[string]any
+	require.NoError(t, json.Unmarshal(written, &state))
+	return state
+}
+
+func sensorEvent(value float64, eventTime time.Time) *events.Envelope {
+	envelope, _ := events.NewEnvelope("sensor.reading", "device-001",
+		map[string]any{"value": value}, events.Metadata{Source: "test"}, eventTime)
+	return envelope
+}
+
+func TestSensorAnomaly_ValueJumpAndGap(t *testing.T) {
+	base := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	prev := &projections.Projection{
+		State:              json.RawMessage(`{"value": 70, "anomaly": {"value_jump": false, "reporting_gap": false}}`),
+		LastEventTimestamp: base,
+	}
+	cfg := AnomalyConfig{MaxDelta: 5, MaxGap: time.Minute}
+
+	state := handleWithPrevious(t, cfg, prev, sensorEvent(80, base.Add(10*time.Minute)))
+
+	assert.Equal(t, 80.0, state["value"])
+	anomaly := state["anomaly"].(map[string]any)
+	assert.Equal(t, true, anomaly["value_jump"])
+	assert.Equal(t, true, anomaly["reporting_gap"])
+	assert.Equal(t, 10.0, anomaly["delta"])
+	assert.Equal(t, 600.0, anomaly["gap_seconds"])
+}
+
+func TestSensorAnomaly_WithinThresholds(t *testing.T) {
+	base := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	prev := &projections.Projection{
+		State:              json.RawMessage(`{"value": 70}`),
+		LastEventTimestamp: base,
+	}
+	cfg := AnomalyConfig{MaxDelta: 5, MaxGap: time.Minute}
+
+	state := handleWithPrevious(t, cfg, prev, sensorEvent(72, base.Add(30*time.Second)))
+
+	anomaly := state["anomaly"].(map[string]any)
+	assert.Equal(t, false, anomaly["value_jump"])
+	assert.Equal(t, false, anomaly["reporting_gap"])
+}
+
+func TestSensorAnomaly_FirstEvent(t *testing.T) {
+	cfg := AnomalyConfig{MaxDelta: 5, MaxGap: time.Minute}
+
+	state := handleWithPrevious(t, cfg, nil, sensorEvent(72, time.Now()))
+
+	anomaly := state["anomaly"].(map[string]any)
+	assert.Equal(t, false, anomaly["value_jump"])
+	assert.Equal(t, false, anomaly["reporting_gap"])
+}
+
+func TestSensorAnomaly_ReaderError(t *testing.T) {
+	writer := &mockProjectionWriter{
+		WriteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+			t.Fatal("projection should not be written when the previous state is unreadable")
+			return nil
+		},
+	}
+	reader := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return nil, fmt.Errorf("connection refused")
+		},
+	}
+
+	handler := NewSensorHandler(writer, slog.Default())
+	handler.SetAnomalyDetection(reader, AnomalyConfig{MaxGap: time.Minute})
+	assert.Error(t, handler.Handle(context.Background(), sensorEvent(72, time.Now())))
+}
@@ -0,0 +1,179 @@
+package eventhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
+)
+
+// ErrBackfillRunning is returned when a handler is already being backfilled.
+var ErrBackfillRunning = errors.New("backfill already running")
+
+// Backfill states.
+const (
+	BackfillRunning = "running"
+	BackfillDone    = "done"
+	BackfillFailed  = "failed"
+)
+
+// BackfillStatus reports the progress of a backfill.
+type BackfillStatus struct {
+	Handler    string     `json:"handler"`
+	Pattern    string     `json:"pattern"`
+	State      string     `json:"state"`
+	Applied    int64      `json:"applied"`  // events dispatched to the handler
+	LastSeq    int64      `json:"last_seq"` // global_seq of the last event read
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Backfills replays event history from the event store into one registered
+// handler, typically a shadow (see ShadowConfig). The handler must already be
+// routed, so events ingested during the backfill reach it live; the backfill
+// ends once it has read the whole store. Handlers that fold every event
+// count events delivered both ways twice.
+type Backfills struct {
+	ctx       context.Context // the service's; cancelling it stops every backfill
+	log       EventLog
+	registry  *HandlerRegistry
+	upcasters *events.Upcasters
+	verifier  *signing.Verifier // nil accepts all
+	batchSize int
+	logger    *slog.Logger
+
+	mu   sync.Mutex
+	runs map[string]*BackfillStatus // latest run per handler
+}
+
+// backfillBatchSize is how many events a backfill reads at a time.
+const backfillBatchSize = 500
+
+// NewBackfills creates a Backfills reading from log. Backfills stop when ctx
+// is cancelled.
+func NewBackfills(ctx context.Context, log EventLog, registry *HandlerRegistry, upcasters *events.Upcasters, logger *slog.Logger) *Backfills {
+	return &Backfills{
+		ctx:       ctx,
+		log:       log,
+		registry:  registry,
+		upcasters: upcasters,
+		batchSize: backfillBatchSize,
+		logger:    logger.With("component", "backfill"),
+		runs:      make(map[string]*BackfillStatus),
+	}
+}
+
+// SetVerifier checks the signature of every event replayed. A backfill
+// fails at the first event that does not verify.
+func (b *Backfills) SetVerifier(v *signing.Verifier) {
+	b.verifier = v
+}
+
+// Start begins backfilling the handler registered under name. It fails with
+// ErrHandlerNotFound for an unregistered handler and ErrBackfillRunning if
+// one is already in progress.
+func (b *Backfills) Start(name string) (BackfillStatus, error) {
+	route, ok := b.registry.Lookup(name)
+	if !ok {
+		return BackfillStatus{}, fmt.Errorf("%w: %q", ErrHandlerNotFound, name)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if run := b.runs[name]; run != nil && run.State == BackfillRunning {
+		return BackfillStatus{}, fmt.Errorf("%w: %q", ErrBackfillRunning, name)
+	}
+	run := &BackfillStatus{Handler: name, Pattern: route.Pattern, State: BackfillRunning, StartedAt: clock.Now()}
+	b.runs[name] = run
+	go b.run(run)
+	return *run, nil
+}
+
+// List returns the latest backfill of each handler, by handler name.
+func (b *Backfills) List() []BackfillStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	runs := make([]BackfillStatus, 0, len(b.runs))
+	for _, run := range b.runs {
+		runs = append(runs, *run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Handler < runs[j].Handler })
+	return runs
+}
+
+func (b *Backfills) run(run *BackfillStatus) {
+	logger := b.logger.With("handler", run.Handler, "pattern", run.Pattern)
+	logger.Info("backfill started")
+
+	err := b.replay(run)
+
+	b.mu.Lock()
+	finished := clock.Now()
+	run.FinishedAt = &finished
+	run.State = BackfillDone
+	if err != nil {
+		run.State = BackfillFailed
+		run.Error = err.Error()
+	}
+	applied, lastSeq := run.Applied, run.LastSeq
+	b.mu.Unlock()
+
+	if err != nil {
+		logger.Error("backfill failed", "applied", applied, "last_seq", lastSeq, "error", err)
+		return
+	}
+	logger.Info("backfill done", "applied", applied, "last_seq", lastSeq)
+}
+
+// replay dispatches the store's events matching the handler's pattern to it,
+// oldest first, until none are left.
+func (b *Backfills) replay(run *BackfillStatus) error {
+	g := glob(run.Pattern)
+	var types []string
+	if strings.Count(g, "*") == 1 && strings.HasSuffix(g, "*") {
+		types = []string{g} // the store filters prefixes itself
+	}
+
+	var after int64
+	for {
+		batch, err := b.log.FetchAfter(b.ctx, after, types, b.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		var applied int64
+		for _, event := range batch {
+			after = event.GlobalSeq
+			if !globMatch(g, event.EventType) {
+				continue
+			}
+			if err := b.verifier.Verify(event); err != nil {
+				return fmt.Errorf("event %s: %w", event.EventID, err)
+			}
+			if err := b.upcasters.Upcast(event); err != nil {
+				return fmt.Errorf("event %s: %w", event.EventID, err)
+			}
+			if err := b.registry.DispatchTo(b.ctx, run.Handler, event); err != nil {
+				return fmt.Errorf("event %s: %w", event.EventID, err)
+			}
+			applied++
+		}
+
+		b.mu.Lock()
+		run.Applied += applied
+		run.LastSeq = after
+		b.mu.Unlock()
+	}
+}
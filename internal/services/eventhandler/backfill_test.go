@@ -0,0 +1,137 @@
+package eventhandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+// eventLogOf serves history as an EventLog, with global_seq from 1.
+func eventLogOf(history ...*events.Envelope) *mockEventLog {
+	for i, event := range history {
+		event.GlobalSeq = int64(i + 1)
+	}
+	return &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			start := min(int(afterSeq), len(history))
+			return history[start:min(start+limit, len(history))], nil
+		},
+	}
+}
+
+func waitForBackfill(t *testing.T, backfills *Backfills) BackfillStatus {
+	t.Helper()
+	var run BackfillStatus
+	require.Eventually(t, func() bool {
+		runs := backfills.List()
+		if len(runs) != 1 {
+			return false
+		}
+		run = runs[0]
+		return run.State != BackfillRunning
+	}, time.Second, time.Millisecond)
+	return run
+}
+
+func TestBackfills_ReplaysIntoOneHandler(t *testing.T) {
+	var live, shadow []string
+	record := func(into *[]string) EventHandler {
+		return &mockEventHandler{
+			HandleFn: func(ctx context.Context, event *events.Envelope) error {
+				*into = append(*into, event.EventType)
+				return nil
+			},
+		}
+	}
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", record(&live))
+	registry.Register("sensor.", "sensor_v2", record(&shadow))
+
+	log := eventLogOf(
+		testutil.Event().Type("sensor.reading").Build(),
+		testutil.Event().Type("user.login").Build(),
+		testutil.Event().Type("sensor.reading").Build(),
+	)
+	var fetchedTypes []string
+	fetch := log.FetchAfterFn
+	log.FetchAfterFn = func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+		fetchedTypes = types
+		return fetch(ctx, afterSeq, types, 2)
+	}
+
+	backfills := NewBackfills(context.Background(), log, registry, events.NewUpcasters(), slog.Default())
+	started, err := backfills.Start("sensor_v2")
+	require.NoError(t, err)
+	assert.Equal(t, "sensor.", started.Pattern)
+
+	run := waitForBackfill(t, backfills)
+	assert.Equal(t, BackfillDone, run.State)
+	assert.Equal(t, int64(2), run.Applied)
+	assert.Equal(t, int64(3), run.LastSeq)
+	assert.NotNil(t, run.FinishedAt)
+	assert.Equal(t, []string{"sensor.*"}, fetchedTypes, "prefix patterns are filtered by the store")
+	assert.Equal(t, []string{"sensor.reading", "sensor.reading"}, shadow)
+	assert.Empty(t, live, "other handlers are not replayed")
+}
+
+func TestBackfills_Errors(t *testing.T) {
+	registry := NewHandlerRegistry(slog.Default())
+	block := make(chan struct{})
+	registry.Register("sensor.", "sensor_v2", &mockEventHandler{
+		HandleFn: func(ctx context.Context, event *events.Envelope) error {
+			<-block
+			return errors.New("store unavailable")
+		},
+	})
+	backfills := NewBackfills(context.Background(), eventLogOf(testutil.Event().Type("sensor.reading").Build()), registry, events.NewUpcasters(), slog.Default())
+
+	_, err := backfills.Start("billing")
+	assert.ErrorIs(t, err, ErrHandlerNotFound)
+
+	_, err = backfills.Start("sensor_v2")
+	require.NoError(t, err)
+	_, err = backfills.Start("sensor_v2")
+	assert.ErrorIs(t, err, ErrBackfillRunning)
+
+	close(block)
+	run := waitForBackfill(t, backfills)
+	assert.Equal(t, BackfillFailed, run.State)
+	assert.Contains(t, run.Error, "store unavailable")
+	assert.Zero(t, run.Applied)
+}
+
+func TestBackfills_VerifiesSignatures(t *testing.T) {
+	var applied int
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor_v2", &mockEventHandler{
+		HandleFn: func(ctx context.Context, event *events.Envelope) error {
+			applied++
+			return nil
+		},
+	})
+	signer := testSigner(t)
+	signed := testutil.Event().Type("sensor.reading").Build()
+	require.NoError(t, signer.Sign(signed))
+	tampered := testutil.Event().Type("sensor.reading").Build()
+	require.NoError(t, signer.Sign(tampered))
+	tampered.AggregateID = "device-999"
+
+	backfills := NewBackfills(context.Background(), eventLogOf(signed, tampered), registry, events.NewUpcasters(), slog.Default())
+	backfills.SetVerifier(signing.NewVerifier([]signing.Key{signer.Key()}, true))
+	_, err := backfills.Start("sensor_v2")
+	require.NoError(t, err)
+
+	run := waitForBackfill(t, backfills)
+	assert.Equal(t, BackfillFailed, run.State)
+	assert.Contains(t, run.Error, tampered.EventID.String()+": invalid event signature")
+	assert.Equal(t, 1, applied, "events before the tampered one are replayed")
+}
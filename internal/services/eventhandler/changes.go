@@ -0,0 +1,102 @@
+package eventhandler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// RecordPublisher publishes raw records to the message bus.
+// This interface is satisfied by infra/redpanda.Producer.
+type RecordPublisher interface {
+	// PublishRecordAsync buffers a record and calls onDelivery once with the
+	// result; a nil value publishes a tombstone.
+	PublishRecordAsync(ctx context.Context, topic string, key, value []byte, onDelivery func(error))
+}
+
+// ChangeNotifier reports accepted projection writes.
+// This interface is satisfied by shared/projections stores.
+type ChangeNotifier interface {
+	SetChangeListener(listener projections.ChangeListener)
+}
+
+// ChangeFeed publishes every accepted projection write to a compacted topic
+// as a projections.ChangeRecord keyed by projections.ChangeKey, so downstream
+// caches and search indexers can follow materialized state instead of
+// re-deriving it from raw events. Projections of the test namespace are not
+// published.
+//
+// Records are published asynchronously after the write is stored, so a
+// change can be lost if the process dies before delivery, or if delivery
+// fails; the next change of the projection supersedes it. Consumers that
+// must be exact resync from the projection export of the query API.
+type ChangeFeed struct {
+	publisher RecordPublisher
+	topic     string
+	changes   *metrics.Counter // nil disables metrics
+	logger    *slog.Logger
+}
+
+// NewChangeFeed creates a ChangeFeed publishing to topic.
+func NewChangeFeed(publisher RecordPublisher, topic string, logger *slog.Logger) *ChangeFeed {
+	return &ChangeFeed{
+		publisher: publisher,
+		topic:     topic,
+		logger:    logger.With("component", "change-feed", "topic", topic),
+	}
+}
+
+// SetMetrics counts published and failed change records by projection type.
+func (f *ChangeFeed) SetMetrics(reg *metrics.Registry) {
+	f.changes = reg.NewCounter("eventhandler_projection_changes_total",
+		"Projection change records by delivery result (published or failed).", "projection_type", "result")
+}
+
+// Publish publishes a change. It is a projections.ChangeListener.
+func (f *ChangeFeed) Publish(ctx context.Context, change projections.Change) {
+	if projections.IsTestType(change.ProjectionType) {
+		return
+	}
+
+	var value []byte
+	if !change.Deleted {
+		var err error
+		value, err = json.Marshal(projections.ChangeRecord{
+			ProjectionType:     change.ProjectionType,
+			AggregateID:        change.AggregateID,
+			AggregateType:      change.AggregateType,
+			State:              change.State,
+			LastEventID:        change.LastEventID,
+			LastEventTimestamp: change.LastEventTimestamp,
+		})
+		if err != nil {
+			f.failed(change, err)
+			return
+		}
+	}
+
+	// Delivery outlives the event's handling, and a shutdown flushes it
+	f.publisher.PublishRecordAsync(context.WithoutCancel(ctx), f.topic, []byte(projections.ChangeKey(change.ProjectionType, change.AggregateID)), value, func(err error) {
+		if err != nil {
+			f.failed(change, err)
+			return
+		}
+		f.count(change, "published")
+	})
+}
+
+func (f *ChangeFeed) failed(change projections.Change, err error) {
+	f.logger.Error("failed to publish projection change",
+		"projection_type", change.ProjectionType,
+		"aggregate_id", change.AggregateID,
+		"error", err,
+	)
+	f.count(change, "failed")
+}
+
+func (f *ChangeFeed) count(change projections.Change, result string) {
+	f.changes.Inc(change.ProjectionType, result)
+}
@@ -0,0 +1,84 @@
+package eventhandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/ids"
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+type changeRecord struct {
+	topic string
+	key   string
+	value []byte
+}
+
+// recordPublisher delivers records synchronously, failing them with err.
+type recordPublisher struct {
+	records []changeRecord
+	err     error
+}
+
+func (p *recordPublisher) PublishRecordAsync(ctx context.Context, topic string, key, value []byte, onDelivery func(error)) {
+	if p.err == nil {
+		p.records = append(p.records, changeRecord{topic: topic, key: string(key), value: value})
+	}
+	onDelivery(p.err)
+}
+
+func TestChangeFeed_PublishesAcceptedWrites(t *testing.T) {
+	publisher := &recordPublisher{}
+	store := projections.NewMemoryStore()
+	store.SetChangeListener(NewChangeFeed(publisher, "projections.changed", slog.Default()).Publish)
+	ctx := context.Background()
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	event := testutil.Event().Type("sensor.reading").Aggregate("device-001").AggregateType("device").At(base).Build()
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001", json.RawMessage(`{"value": 1}`), event))
+	require.NoError(t, store.DeleteProjection(ctx, "sensor_state", "device-001", nil, testutil.Event().Type("sensor.decommissioned").Aggregate("device-001").At(base.Add(time.Second)).Build()))
+	// Test projections are not published
+	require.NoError(t, store.WriteProjection(ctx, projections.TestNamespace+"sensor_state", "device-001", json.RawMessage(`{}`), event))
+
+	require.Len(t, publisher.records, 2)
+	written := publisher.records[0]
+	assert.Equal(t, "projections.changed", written.topic)
+	assert.Equal(t, "sensor_state/device-001", written.key)
+	var rec projections.ChangeRecord
+	require.NoError(t, json.Unmarshal(written.value, &rec))
+	assert.Equal(t, "sensor_state", rec.ProjectionType)
+	assert.Equal(t, "device", rec.AggregateType)
+	assert.JSONEq(t, `{"value": 1}`, string(rec.State))
+	assert.Equal(t, event.EventID, rec.LastEventID)
+
+	// A deletion is a tombstone of the same key
+	assert.Equal(t, "sensor_state/device-001", publisher.records[1].key)
+	assert.Nil(t, publisher.records[1].value)
+}
+
+func TestChangeFeed_CountsDeliveries(t *testing.T) {
+	publisher := &recordPublisher{}
+	reg := metrics.NewRegistry()
+	feed := NewChangeFeed(publisher, "projections.changed", slog.Default())
+	feed.SetMetrics(reg)
+	ctx := context.Background()
+
+	feed.Publish(ctx, projections.Change{ProjectionType: "user_session", AggregateID: "user-1", State: json.RawMessage(`{}`), LastEventID: ids.New()})
+	publisher.err = errors.New("broker unavailable")
+	feed.Publish(ctx, projections.Change{ProjectionType: "user_session", AggregateID: "user-2", State: json.RawMessage(`{}`), LastEventID: ids.New()})
+
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+	assert.Contains(t, out.String(), `eventhandler_projection_changes_total{projection_type="user_session",result="published"} 1`)
+	assert.Contains(t, out.String(), `eventhandler_projection_changes_total{projection_type="user_session",result="failed"} 1`)
+}
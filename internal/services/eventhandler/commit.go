@@ -0,0 +1,97 @@
+package eventhandler
+
+import (
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// CommitMode is when the consumer commits offsets. Committing later repeats
+// more events after a crash; committing before processing loses them instead.
+type CommitMode string
+
+const (
+	CommitBatch      CommitMode = "batch"        // after each polled batch is processed (default)
+	CommitRecord     CommitMode = "record"       // after each record is processed
+	CommitInterval   CommitMode = "interval"     // every CommitInterval, and on shutdown
+	CommitAtMostOnce CommitMode = "at-most-once" // after each poll, before processing
+)
+
+// CommitModes lists the valid commit modes.
+var CommitModes = []CommitMode{CommitBatch, CommitRecord, CommitInterval, CommitAtMostOnce}
+
+// offsetTracker finds, per partition, the newest record whose offset can be
+// committed. Lanes finish records out of order, and committing an offset
+// commits every record before it, so only a finished prefix is committable.
+// A nil offsetTracker tracks nothing.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition][]*trackedRecord
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+type trackedRecord struct {
+	record *kgo.Record
+	done   bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[topicPartition][]*trackedRecord)}
+}
+
+// add starts tracking record. Records of a partition must be added in offset
+// order, as they are polled.
+func (t *offsetTracker) add(record *kgo.Record) *trackedRecord {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := topicPartition{topic: record.Topic, partition: record.Partition}
+	r := &trackedRecord{record: record}
+	t.partitions[key] = append(t.partitions[key], r)
+	return r
+}
+
+// done marks a tracked record processed.
+func (t *offsetTracker) done(r *trackedRecord) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r.done = true
+}
+
+// committable stops tracking the processed prefix of each partition and
+// returns the last record of each prefix.
+func (t *offsetTracker) committable() []*kgo.Record {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var records []*kgo.Record
+	for key, pending := range t.partitions {
+		n := 0
+		for n < len(pending) && pending[n].done {
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		records = append(records, pending[n-1].record)
+		if n == len(pending) {
+			delete(t.partitions, key)
+		} else {
+			t.partitions[key] = pending[n:]
+		}
+	}
+	return records
+}
@@ -0,0 +1,45 @@
+package eventhandler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestOffsetTracker_CommitsProcessedPrefix(t *testing.T) {
+	tracker := newOffsetTracker()
+	var p0 []*trackedRecord
+	for offset := range int64(4) {
+		p0 = append(p0, tracker.add(&kgo.Record{Topic: "sensor-events", Partition: 0, Offset: offset}))
+	}
+	p1 := tracker.add(&kgo.Record{Topic: "sensor-events", Partition: 1, Offset: 10})
+
+	// Lanes finish out of order: offset 1 is done, but 0 is not
+	tracker.done(p0[1])
+	assert.Empty(t, tracker.committable())
+
+	tracker.done(p0[0])
+	tracker.done(p0[3])
+	tracker.done(p1)
+	assert.ElementsMatch(t, []int64{1, 10}, offsets(tracker.committable()))
+	assert.Empty(t, tracker.committable(), "committed records are no longer tracked")
+
+	tracker.done(p0[2])
+	assert.Equal(t, []int64{3}, offsets(tracker.committable()))
+}
+
+func TestOffsetTracker_Nil(t *testing.T) {
+	var tracker *offsetTracker
+	r := tracker.add(&kgo.Record{})
+	assert.NotPanics(t, func() { tracker.done(r) })
+	assert.Nil(t, tracker.committable())
+}
+
+func offsets(records []*kgo.Record) []int64 {
+	var out []int64
+	for _, r := range records {
+		out = append(out, r.Offset)
+	}
+	return out
+}
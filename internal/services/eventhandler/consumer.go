@@ -3,52 +3,191 @@ package eventhandler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
 
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/retry"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
 )
 
 // ConsumerConfig holds configuration for the event consumer.
 type ConsumerConfig struct {
-	Brokers      []string
-	GroupID      string
-	Topics       []string
-	PollTimeout  time.Duration
+	Brokers     []string
+	GroupID     string
+	ClientID    string // reported to the broker; empty uses the kgo default
+	Topics      []string
+	PollTimeout time.Duration
+	Lanes       int       // concurrent per-aggregate queues (see keyedExecutor)
+	QueueSize   int       // records buffered per lane before the poll loop blocks
+	Opts        []kgo.Opt // connection options such as TLS and SASL (see redpanda.Auth)
+
+	// MaxAttempts is how often an event is dispatched before it is
+	// quarantined, with RetryDelay before the first retry, doubling after
+	// each. Only used with a quarantine store (see SetQuarantine).
+	MaxAttempts int
+	RetryDelay  time.Duration
+
+	Commit         CommitMode    // when offsets are committed; empty means CommitBatch
+	CommitInterval time.Duration // for CommitInterval
+
+	// Group membership. A static InstanceID lets a restarted replica rejoin
+	// without a rebalance if it is back within SessionTimeout. Zero timeouts
+	// and an empty Balancer keep the kgo defaults.
+	InstanceID       string        // unique per replica; empty joins dynamically
+	SessionTimeout   time.Duration // how long the broker waits for a silent member
+	RebalanceTimeout time.Duration // how long members get to rejoin a rebalance
+	Balancer         string        // one of Balancers
+
+	Filter   *EventFilter      // event types to process; nil processes all
+	Verifier *signing.Verifier // rejects events with a bad signature (see package signing); nil accepts all
+
+	// Replay runs handlers on historical time: the consumer sets a
+	// clock.ReplayClock as the process clock while it runs and advances it
+	// to each event's IngestedAt before dispatch, so clock.Now in handlers
+	// (TTLs, staleness checks) sees the time the event was ingested. Events
+	// are then dispatched one at a time, whatever Lanes says. The clock is
+	// process-wide, so do not run other services in a replaying process.
+	Replay bool
+}
+
+// Balancers lists the partition assignment strategies by name. Cooperative
+// sticky, the default, moves only the partitions that change owner; the
+// others revoke every partition on each rebalance. Switching a running group
+// between the two kinds needs the two-step rollout of KIP-429.
+var Balancers = map[string]func() kgo.GroupBalancer{
+	"cooperative-sticky": kgo.CooperativeStickyBalancer,
+	"sticky":             kgo.StickyBalancer,
+	"range":              kgo.RangeBalancer,
+	"round-robin":        kgo.RoundRobinBalancer,
 }
 
+// maxRetryDelay caps the backoff between dispatch attempts.
+const maxRetryDelay = 30 * time.Second
+
 // Consumer consumes events from Redpanda and dispatches to handlers.
 type Consumer struct {
-	client   *kgo.Client
-	registry *HandlerRegistry
-	config   ConsumerConfig
-	logger   *slog.Logger
+	client    *kgo.Client
+	registry  *HandlerRegistry
+	upcasters *events.Upcasters
+	executor  *keyedExecutor
+	freshness freshnessTracker
+	config    ConsumerConfig
+
+	// quarantine counts failed dispatches; nil drops a failing event after
+	// one attempt
+	quarantine QuarantineStore
+
+	// replay is the process clock in replay mode; nil otherwise
+	replay *clock.ReplayClock
+
+	// offsets tracks processed records for per-record and interval commits;
+	// nil for the modes that commit whole batches
+	offsets  *offsetTracker
+	commitMu sync.Mutex // keeps commits in offset order
+
+	logger *slog.Logger
+
+	// Operator controls (see control.go)
+	state   atomic.Value  // ConsumerRunning, ConsumerDraining, or ConsumerStopped
+	drain   chan struct{} // closed by Drain to stop polling
+	stopped chan struct{} // closed when Start returns
 }
 
 // NewConsumer creates a new event consumer.
 func NewConsumer(
 	registry *HandlerRegistry,
+	upcasters *events.Upcasters,
 	config ConsumerConfig,
 	logger *slog.Logger,
 ) (*Consumer, error) {
-	client, err := kgo.NewClient(
+	opts := []kgo.Opt{
 		kgo.SeedBrokers(config.Brokers...),
 		kgo.ConsumerGroup(config.GroupID),
 		kgo.ConsumeTopics(config.Topics...),
 		kgo.DisableAutoCommit(),
-	)
+		// Skip records from aborted outbox transactions (see redpanda.PublishTransaction)
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+	}
+	if config.ClientID != "" {
+		opts = append(opts, kgo.ClientID(config.ClientID))
+	}
+	if config.InstanceID != "" {
+		opts = append(opts, kgo.InstanceID(config.InstanceID))
+	}
+	if config.SessionTimeout > 0 {
+		opts = append(opts, kgo.SessionTimeout(config.SessionTimeout))
+	}
+	if config.RebalanceTimeout > 0 {
+		opts = append(opts, kgo.RebalanceTimeout(config.RebalanceTimeout))
+	}
+	if config.Balancer != "" {
+		balancer, ok := Balancers[config.Balancer]
+		if !ok {
+			return nil, fmt.Errorf("unknown group balancer %q", config.Balancer)
+		}
+		opts = append(opts, kgo.Balancers(balancer()))
+	}
+	opts = append(opts, config.Opts...)
+	switch config.Commit {
+	case "":
+		config.Commit = CommitBatch
+	case CommitBatch, CommitRecord, CommitAtMostOnce:
+	case CommitInterval:
+		if config.CommitInterval <= 0 {
+			return nil, fmt.Errorf("commit interval must be positive, got %s", config.CommitInterval)
+		}
+	default:
+		return nil, fmt.Errorf("unknown commit mode %q", config.Commit)
+	}
+
+	client, err := kgo.NewClient(opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Consumer{
-		client:   client,
-		registry: registry,
-		config:   config,
-		logger:   logger.With("component", "event-consumer"),
-	}, nil
+	logger = logger.With("component", "event-consumer")
+
+	var replay *clock.ReplayClock
+	if config.Replay {
+		// Handlers read one clock, so one event at a time
+		config.Lanes = 1
+		replay = &clock.ReplayClock{}
+		replay.Advance(clock.Now())
+	}
+
+	c := &Consumer{
+		client:    client,
+		registry:  registry,
+		upcasters: upcasters,
+		executor:  newKeyedExecutor(config.Lanes, config.QueueSize, logger),
+		config:    config,
+		replay:    replay,
+		logger:    logger,
+		drain:     make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	if config.Commit == CommitRecord || config.Commit == CommitInterval {
+		c.offsets = newOffsetTracker()
+	}
+	c.state.Store(ConsumerRunning)
+	return c, nil
+}
+
+// SetQuarantine makes the consumer retry events whose dispatch fails, counting
+// the attempts in store so they survive restarts. After MaxAttempts the event
+// is quarantined and skipped, so an unprocessable event cannot hold up its
+// aggregate's lane.
+func (c *Consumer) SetQuarantine(store QuarantineStore) {
+	c.quarantine = store
 }
 
 // Start begins consuming events and blocks until context is cancelled.
@@ -56,20 +195,73 @@ func (c *Consumer) Start(ctx context.Context) error {
 	c.logger.Info("starting event consumer",
 		"group_id", c.config.GroupID,
 		"topics", c.config.Topics,
+		"lanes", len(c.executor.lanes),
+		"commit", c.config.Commit,
+		"instance_id", c.config.InstanceID,
+		"replay", c.replay != nil,
 	)
 
+	if c.replay != nil {
+		clock.Set(c.replay)
+		defer clock.Reset()
+	}
+
+	defer close(c.stopped)
+	defer c.state.Store(ConsumerStopped)
+
+	// Start is the executor's only submitter, so it owns shutdown of the lanes
+	defer c.executor.Close()
+
+	// Records processed since the last commit are committed on the way out
+	if c.offsets != nil {
+		commitCtx, stopCommits := context.WithCancel(ctx)
+		var committer sync.WaitGroup
+		if c.config.Commit == CommitInterval {
+			committer.Add(1)
+			go func() {
+				defer committer.Done()
+				c.commitEvery(commitCtx, c.config.CommitInterval)
+			}()
+		}
+		defer func() {
+			stopCommits()
+			committer.Wait()
+			finalCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalCommitTimeout)
+			defer cancel()
+			c.commitProcessed(finalCtx)
+		}()
+	}
+
+	// Polling gets its own context so Drain can interrupt a blocked poll
+	// without cancelling the processing of records already fetched.
+	pollCtx, cancelPoll := context.WithCancel(ctx)
+	defer cancelPoll()
+	go func() {
+		select {
+		case <-c.drain:
+			cancelPoll()
+		case <-pollCtx.Done():
+		}
+	}()
+
 	for {
 		select {
 		case <-ctx.Done():
 			c.logger.Info("event consumer stopping")
 			return nil
+		case <-c.drain:
+			c.logger.Info("event consumer drained")
+			return nil
 		default:
 		}
 
-		fetches := c.client.PollFetches(ctx)
+		fetches := c.client.PollFetches(pollCtx)
 		if fetches.IsClientClosed() {
 			return nil
 		}
+		if pollCtx.Err() != nil {
+			continue // stopping or draining; handled at the top of the loop
+		}
 
 		if errs := fetches.Errors(); len(errs) > 0 {
 			for _, err := range errs {
@@ -82,17 +274,83 @@ func (c *Consumer) Start(ctx context.Context) error {
 			continue
 		}
 
+		// At most once: commit before processing, so a crash loses the batch
+		// instead of repeating it
+		if c.config.Commit == CommitAtMostOnce {
+			if err := c.client.CommitUncommittedOffsets(ctx); err != nil {
+				c.logger.Error("failed to commit offsets, skipping batch", "error", err)
+				continue
+			}
+		}
+
+		// Fan records out to per-aggregate lanes: records for one aggregate are
+		// processed in order, different aggregates concurrently.
 		fetches.EachRecord(func(record *kgo.Record) {
-			c.processRecord(ctx, record)
+			tracked := c.offsets.add(record)
+			test, _ := headerValue(record, events.HeaderTest)
+			if err := c.executor.Submit(ctx, laneKey(record), test == "true", func() {
+				c.processRecord(ctx, record)
+				c.offsets.done(tracked)
+				if c.config.Commit == CommitRecord {
+					c.commitProcessed(ctx)
+				}
+			}); err != nil {
+				c.logger.Debug("record not submitted, consumer stopping", "offset", record.Offset)
+			}
 		})
 
+		// Offsets are only committed once the whole batch has been processed
+		c.executor.Wait()
+		if ctx.Err() != nil {
+			c.logger.Info("event consumer stopping")
+			return nil
+		}
+
 		// Commit offsets after processing batch
-		if err := c.client.CommitUncommittedOffsets(ctx); err != nil {
-			c.logger.Error("failed to commit offsets", "error", err)
+		if c.config.Commit == CommitBatch {
+			if err := c.client.CommitUncommittedOffsets(ctx); err != nil {
+				c.logger.Error("failed to commit offsets", "error", err)
+			}
+		}
+	}
+}
+
+// finalCommitTimeout bounds the commit made when the consumer stops.
+const finalCommitTimeout = 5 * time.Second
+
+// commitEvery commits processed records every interval until ctx is cancelled.
+func (c *Consumer) commitEvery(ctx context.Context, interval time.Duration) {
+	ticker := c.newTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			c.commitProcessed(ctx)
 		}
 	}
 }
 
+// commitProcessed commits, per partition, the offsets of the records
+// processed without gaps since the last commit.
+func (c *Consumer) commitProcessed(ctx context.Context) {
+	if ctx.Err() != nil {
+		return // left for the final commit
+	}
+	c.commitMu.Lock()
+	defer c.commitMu.Unlock()
+
+	records := c.offsets.committable()
+	if len(records) == 0 {
+		return
+	}
+	if err := c.client.CommitRecords(ctx, records...); err != nil {
+		c.logger.Error("failed to commit offsets", "error", err)
+	}
+}
+
 // processRecord processes a single Kafka record.
 func (c *Consumer) processRecord(ctx context.Context, record *kgo.Record) {
 	logger := c.logger.With(
@@ -101,6 +359,26 @@ func (c *Consumer) processRecord(ctx context.Context, record *kgo.Record) {
 		"offset", record.Offset,
 	)
 
+	// The deletion event before a tombstone has already been handled
+	if record.Value == nil {
+		logger.Debug("tombstone, skipping", "key", string(record.Key))
+		return
+	}
+
+	// Cheap pre-filter: skip records that are filtered out or that no handler
+	// wants without decoding the body. Records whose event type cannot be
+	// read cheaply fall through to full decode.
+	if eventType, ok := recordEventType(record); ok {
+		if !c.config.Filter.Allows(eventType) {
+			logger.Debug("event type filtered out, skipping", "event_type", eventType)
+			return
+		}
+		if !c.registry.Handles(eventType) {
+			logger.Debug("no handler for event type, skipping", "event_type", eventType)
+			return
+		}
+	}
+
 	// Deserialize event
 	var event events.Envelope
 	if err := json.Unmarshal(record.Value, &event); err != nil {
@@ -108,24 +386,164 @@ func (c *Consumer) processRecord(ctx context.Context, record *kgo.Record) {
 		return
 	}
 
+	if !c.config.Filter.Allows(event.EventType) {
+		logger.Debug("event type filtered out, skipping", "event_type", event.EventType)
+		return
+	}
+
 	logger = logger.With(
 		"event_id", event.EventID,
 		"event_type", event.EventType,
 		"aggregate_id", event.AggregateID,
+		"replay", event.Metadata.Replay,
 	)
 
-	// Dispatch to handler
-	if err := c.registry.Dispatch(ctx, &event); err != nil {
-		logger.Error("failed to handle event", "error", err)
+	// Like an undecodable record, a rejected event is logged and skipped
+	if err := c.config.Verifier.Verify(&event); err != nil {
+		logger.Error("event failed signature verification, skipping", "error", err)
+		return
+	}
+
+	// Projections fold JSON; binary payloads are kept in the event store only
+	if !event.IsJSON() {
+		logger.Debug("payload is not JSON, skipping", "content_type", event.ContentType())
 		return
 	}
 
+	// Bring payload up to the latest schema version before handlers see it
+	if err := c.upcasters.Upcast(&event); err != nil {
+		logger.Error("failed to upcast event", "error", err)
+		return
+	}
+
+	if c.replay != nil {
+		c.replay.Advance(event.IngestedAt)
+	}
+
+	if !c.dispatch(ctx, &event, record, logger) {
+		return
+	}
+	c.freshness.observe(&event)
+
 	logger.Debug("event processed successfully")
 }
 
+// dispatch runs the handlers for event and reports whether they succeeded.
+// With a quarantine store, a failing event is retried with backoff until it
+// succeeds or reaches MaxAttempts and is quarantined. Attempts interrupted by
+// shutdown are not counted; the event is redelivered on restart.
+func (c *Consumer) dispatch(ctx context.Context, event *events.Envelope, record *kgo.Record, logger *slog.Logger) bool {
+	failed := false
+	for {
+		err := c.registry.Dispatch(ctx, event)
+		if err == nil {
+			if failed {
+				if err := c.quarantine.ClearFailure(ctx, c.config.GroupID, event.EventID); err != nil {
+					logger.Warn("failed to clear dispatch failures", "error", err)
+				}
+			}
+			return true
+		}
+		if c.quarantine == nil || ctx.Err() != nil {
+			logger.Error("failed to handle event", "error", err)
+			return false
+		}
+		failed = true
+
+		attempts, quarantined, qerr := c.quarantine.RecordFailure(ctx, projections.DispatchFailure{
+			Consumer:  c.config.GroupID,
+			EventID:   event.EventID,
+			Payload:   record.Value,
+			Topic:     record.Topic,
+			Partition: record.Partition,
+			Offset:    record.Offset,
+			Error:     err.Error(),
+		}, c.config.MaxAttempts)
+		if qerr != nil {
+			logger.Error("failed to handle event", "error", err, "record_error", qerr)
+			return false
+		}
+		if quarantined {
+			logger.Error("event quarantined after repeated failures", "attempts", attempts, "error", err)
+			return false
+		}
+
+		delay := c.retryDelay(attempts)
+		logger.Warn("failed to handle event, retrying", "attempts", attempts, "retry_in", delay, "error", err)
+		if c.sleep(ctx, delay) != nil {
+			return false
+		}
+	}
+}
+
+// newTicker returns a ticker on the process clock. In replay mode the
+// consumer's own waits stay on real time: replay time moves only when an
+// event arrives, so a wait on it could outlast the stream.
+func (c *Consumer) newTicker(d time.Duration) clock.Ticker {
+	if c.replay != nil {
+		return clock.RealClock{}.NewTicker(d)
+	}
+	return clock.NewTicker(d)
+}
+
+// sleep waits for d on the process clock, or real time in replay mode (see
+// newTicker). It returns ctx.Err() if ctx is done first.
+func (c *Consumer) sleep(ctx context.Context, d time.Duration) error {
+	if c.replay == nil {
+		return clock.Sleep(ctx, d)
+	}
+	timer := clock.RealClock{}.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// retryDelay is the backoff after the given number of failed attempts.
+func (c *Consumer) retryDelay(attempts int) time.Duration {
+	return retry.Backoff(c.config.RetryDelay, maxRetryDelay, attempts)
+}
+
+// laneKey returns the executor key for a record. The producer keys records by
+// aggregate ID; unkeyed records fall back to their partition, which preserves
+// the ordering Kafka already guarantees.
+func laneKey(record *kgo.Record) string {
+	if len(record.Key) > 0 {
+		return string(record.Key)
+	}
+	return record.Topic + "/" + strconv.Itoa(int(record.Partition))
+}
+
+// headerValue returns the value of the first record header with the given key.
+func headerValue(record *kgo.Record, key string) (string, bool) {
+	for _, h := range record.Headers {
+		if h.Key == key {
+			return string(h.Value), true
+		}
+	}
+	return "", false
+}
+
+// Freshness reports how current the projections are, live and replay separately.
+func (c *Consumer) Freshness() Freshness {
+	return c.freshness.snapshot()
+}
+
+// Stats returns cumulative keyed-executor counters.
+func (c *Consumer) Stats() ExecutorStats {
+	return c.executor.Stats()
+}
+
 // Close releases consumer resources.
 func (c *Consumer) Close() error {
 	c.client.Close()
-	c.logger.Info("event consumer closed")
+	stats := c.executor.Stats()
+	c.logger.Info("event consumer closed",
+		"records_submitted", stats.Submitted,
+		"queue_overflows", stats.Overflows,
+	)
 	return nil
 }
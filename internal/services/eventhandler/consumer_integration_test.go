@@ -14,7 +14,6 @@ import (
 	"github.com/gofrs/uuid/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/twmb/franz-go/pkg/kgo"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 	"github.com/cornjacket/platform-services/internal/testutil"
@@ -29,7 +28,7 @@ func TestConsumerRoundTrip(t *testing.T) {
 	var received *events.Envelope
 
 	registry := NewHandlerRegistry(logger)
-	registry.Register("sensor.", &mockEventHandler{
+	registry.Register("sensor.", "sensor", &mockEventHandler{
 		HandleFn: func(ctx context.Context, event *events.Envelope) error {
 			mu.Lock()
 			defer mu.Unlock()
@@ -39,7 +38,7 @@ func TestConsumerRoundTrip(t *testing.T) {
 	})
 
 	// Create consumer
-	consumer, err := NewConsumer(registry, ConsumerConfig{
+	consumer, err := NewConsumer(registry, events.NewUpcasters(), ConsumerConfig{
 		Brokers:     testutil.TestBrokers(),
 		GroupID:     "test-group-" + topic, // unique group per test
 		Topics:      []string{topic},
@@ -67,22 +66,7 @@ func TestConsumerRoundTrip(t *testing.T) {
 		Metadata:    events.Metadata{Source: "integration-test", SchemaVersion: 1},
 	}
 
-	value, err := json.Marshal(env)
-	require.NoError(t, err)
-
-	producer, err := kgo.NewClient(
-		kgo.SeedBrokers(testutil.TestBrokers()...),
-		kgo.AllowAutoTopicCreation(),
-	)
-	require.NoError(t, err)
-	defer producer.Close()
-
-	results := producer.ProduceSync(context.Background(), &kgo.Record{
-		Topic: topic,
-		Key:   []byte(env.AggregateID),
-		Value: value,
-	})
-	require.NoError(t, results.FirstErr())
+	testutil.ProduceEvents(t, topic, env)
 
 	// Wait for the handler to receive the event
 	deadline := time.After(5 * time.Second)
@@ -118,3 +102,49 @@ func TestConsumerRoundTrip(t *testing.T) {
 		t.Fatal("consumer did not stop within timeout")
 	}
 }
+
+func TestConsumerDrain(t *testing.T) {
+	topic := testutil.TestTopicName(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	consumer, err := NewConsumer(NewHandlerRegistry(logger), events.NewUpcasters(), ConsumerConfig{
+		Brokers:     testutil.TestBrokers(),
+		GroupID:     "test-group-" + topic,
+		Topics:      []string{topic},
+		PollTimeout: time.Second,
+	}, logger)
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumerDone := make(chan error, 1)
+	go func() {
+		consumerDone <- consumer.Start(ctx)
+	}()
+
+	require.NoError(t, consumer.Pause(topic))
+	status, err := consumer.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ConsumerRunning, status.State)
+	assert.Equal(t, []string{topic}, status.PausedTopics)
+
+	require.Error(t, consumer.Pause("not-subscribed"))
+
+	// Drain interrupts the blocked poll without cancelling ctx
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer drainCancel()
+	require.NoError(t, consumer.Drain(drainCtx))
+
+	select {
+	case err := <-consumerDone:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer did not stop after drain")
+	}
+
+	status, err = consumer.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ConsumerStopped, status.State)
+}
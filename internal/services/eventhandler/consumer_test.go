@@ -0,0 +1,242 @@
+package eventhandler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+// newTestConsumer returns a consumer without a client, for processRecord.
+func newTestConsumer(handler EventHandler, quarantine QuarantineStore) *Consumer {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	registry := NewHandlerRegistry(logger)
+	registry.Register("sensor.", "sensor", handler)
+	c := &Consumer{
+		registry:  registry,
+		upcasters: events.NewUpcasters(),
+		config:    ConsumerConfig{GroupID: "event-handler", MaxAttempts: 3, RetryDelay: time.Millisecond},
+		logger:    logger,
+	}
+	c.SetQuarantine(quarantine)
+	return c
+}
+
+func testRecord(t *testing.T, event *events.Envelope) *kgo.Record {
+	value, err := json.Marshal(event)
+	require.NoError(t, err)
+	return &kgo.Record{Topic: "sensor-events", Partition: 1, Offset: 7, Key: []byte(event.AggregateID), Value: value}
+}
+
+func TestConsumer_QuarantinesPoisonEvent(t *testing.T) {
+	calls := 0
+	handler := &mockEventHandler{HandleFn: func(ctx context.Context, event *events.Envelope) error {
+		calls++
+		return errors.New("unprocessable")
+	}}
+	store := projections.NewMemoryStore()
+	c := newTestConsumer(handler, store)
+	event := testutil.Event().Type("sensor.reading").Build()
+
+	c.processRecord(context.Background(), testRecord(t, event))
+	assert.Equal(t, 3, calls, "dispatched MaxAttempts times")
+	assert.Zero(t, c.Freshness().Live.Applied, "a quarantined event is not applied")
+
+	// Redelivered after a restart, the event is skipped after one more attempt
+	c.processRecord(context.Background(), testRecord(t, event))
+	assert.Equal(t, 4, calls)
+	attempts, quarantined, err := store.RecordFailure(context.Background(),
+		projections.DispatchFailure{Consumer: "event-handler", EventID: event.EventID}, 3)
+	require.NoError(t, err)
+	assert.True(t, quarantined)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestConsumer_RetriesTransientFailure(t *testing.T) {
+	calls := 0
+	handler := &mockEventHandler{HandleFn: func(ctx context.Context, event *events.Envelope) error {
+		calls++
+		if calls == 1 {
+			return errors.New("connection reset")
+		}
+		return nil
+	}}
+	store := projections.NewMemoryStore()
+	c := newTestConsumer(handler, store)
+	event := testutil.Event().Type("sensor.reading").Build()
+
+	c.processRecord(context.Background(), testRecord(t, event))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, int64(1), c.Freshness().Live.Applied)
+
+	// The failure was cleared, so counting starts over
+	attempts, _, err := store.RecordFailure(context.Background(),
+		projections.DispatchFailure{Consumer: "event-handler", EventID: event.EventID}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestConsumer_WithoutQuarantineDropsFailure(t *testing.T) {
+	calls := 0
+	handler := &mockEventHandler{HandleFn: func(ctx context.Context, event *events.Envelope) error {
+		calls++
+		return errors.New("unprocessable")
+	}}
+	c := newTestConsumer(handler, nil)
+
+	c.processRecord(context.Background(), testRecord(t, testutil.Event().Type("sensor.reading").Build()))
+	assert.Equal(t, 1, calls)
+}
+
+func TestConsumer_SkipsBinaryPayload(t *testing.T) {
+	calls := 0
+	handler := &mockEventHandler{HandleFn: func(ctx context.Context, event *events.Envelope) error {
+		calls++
+		return nil
+	}}
+	c := newTestConsumer(handler, nil)
+	event := testutil.Event().Type("sensor.thumbnail").Build()
+	event.SetBinaryPayload("image/jpeg", []byte{0xff, 0xd8})
+
+	c.processRecord(context.Background(), testRecord(t, event))
+	assert.Zero(t, calls, "projections only fold JSON payloads")
+
+	c.processRecord(context.Background(), testRecord(t, testutil.Event().Type("sensor.reading").Build()))
+	assert.Equal(t, 1, calls)
+}
+
+func TestConsumer_SkipsTombstone(t *testing.T) {
+	calls := 0
+	handler := &mockEventHandler{HandleFn: func(ctx context.Context, event *events.Envelope) error {
+		calls++
+		return nil
+	}}
+	c := newTestConsumer(handler, nil)
+	record := testRecord(t, testutil.Event().Type("sensor.deleted").Build())
+
+	c.processRecord(context.Background(), record)
+	record.Value = nil
+	c.processRecord(context.Background(), record)
+	assert.Equal(t, 1, calls, "the tombstone following a deletion event is skipped")
+}
+
+// testSigner returns a signer with a fixed HMAC key.
+func testSigner(t *testing.T) *signing.Signer {
+	key, err := signing.ParseSigningKey("hmac:" + base64.StdEncoding.EncodeToString([]byte("eventhandler-test-signing-key")))
+	require.NoError(t, err)
+	signer, err := signing.NewSigner(key)
+	require.NoError(t, err)
+	return signer
+}
+
+func TestConsumer_VerifiesSignatures(t *testing.T) {
+	var handled []string
+	handler := &mockEventHandler{HandleFn: func(ctx context.Context, event *events.Envelope) error {
+		handled = append(handled, event.AggregateID)
+		return nil
+	}}
+	c := newTestConsumer(handler, nil)
+	signer := testSigner(t)
+	c.config.Verifier = signing.NewVerifier([]signing.Key{signer.Key()}, true)
+
+	signed := testutil.Event().Type("sensor.reading").Aggregate("signed").Build()
+	require.NoError(t, signer.Sign(signed))
+	tampered := testutil.Event().Type("sensor.reading").Aggregate("tampered").Build()
+	require.NoError(t, signer.Sign(tampered))
+	tampered.Payload = json.RawMessage(`{"value": 0}`)
+
+	c.processRecord(context.Background(), testRecord(t, signed))
+	c.processRecord(context.Background(), testRecord(t, tampered))
+	c.processRecord(context.Background(), testRecord(t, testutil.Event().Type("sensor.reading").Aggregate("unsigned").Build()))
+	assert.Equal(t, []string{"signed"}, handled)
+}
+
+func TestConsumer_ReplayAdvancesClock(t *testing.T) {
+	t.Cleanup(clock.Reset)
+	var seen []time.Time
+	handler := &mockEventHandler{HandleFn: func(ctx context.Context, event *events.Envelope) error {
+		seen = append(seen, clock.Now())
+		if len(seen) == 2 {
+			return errors.New("connection reset")
+		}
+		return nil
+	}}
+	c := newTestConsumer(handler, projections.NewMemoryStore())
+	c.replay = &clock.ReplayClock{}
+	clock.Set(c.replay)
+
+	first := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	c.processRecord(context.Background(), testRecord(t, testutil.Event().Type("sensor.reading").At(first).Build()))
+	// A retry waits in real time; replay time would not move until the next event
+	c.processRecord(context.Background(), testRecord(t, testutil.Event().Type("sensor.reading").At(second).Build()))
+
+	assert.Equal(t, []time.Time{first, second, second}, seen, "handlers see each event's ingestion time")
+	assert.Equal(t, second, clock.Now())
+}
+
+func TestConsumer_RetryDelayBacksOff(t *testing.T) {
+	c := &Consumer{config: ConsumerConfig{RetryDelay: time.Second}}
+	assert.Equal(t, time.Second, c.retryDelay(1))
+	assert.Equal(t, 4*time.Second, c.retryDelay(3))
+	assert.Equal(t, maxRetryDelay, c.retryDelay(100))
+}
+
+func TestNewConsumer_RejectsBadConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	base := ConsumerConfig{Brokers: []string{"localhost:9092"}, GroupID: "event-handler", Topics: []string{"sensor-events"}}
+
+	tests := []struct {
+		name   string
+		modify func(*ConsumerConfig)
+		errMsg string
+	}{
+		{"unknown balancer", func(c *ConsumerConfig) { c.Balancer = "eager" }, `unknown group balancer "eager"`},
+		{"unknown commit mode", func(c *ConsumerConfig) { c.Commit = "never" }, `unknown commit mode "never"`},
+		{"interval without interval", func(c *ConsumerConfig) { c.Commit = CommitInterval }, "commit interval must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			tt.modify(&cfg)
+			_, err := NewConsumer(NewHandlerRegistry(logger), events.NewUpcasters(), cfg, logger)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	cfg := base
+	cfg.InstanceID = "event-handler-0"
+	cfg.Balancer = "sticky"
+	cfg.SessionTimeout = 2 * time.Minute
+	c, err := NewConsumer(NewHandlerRegistry(logger), events.NewUpcasters(), cfg, logger)
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+}
+
+func TestNewConsumer_ReplayDispatchesInOrder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := ConsumerConfig{Brokers: []string{"localhost:9092"}, GroupID: "replay", Topics: []string{"sensor-events"}, Lanes: 8, Replay: true}
+
+	c, err := NewConsumer(NewHandlerRegistry(logger), events.NewUpcasters(), cfg, logger)
+	require.NoError(t, err)
+	defer c.Close()
+
+	assert.Len(t, c.executor.lanes, 1, "handlers share the replay clock")
+	require.NotNil(t, c.replay)
+	assert.False(t, c.replay.Now().IsZero(), "replay time starts at the current time")
+}
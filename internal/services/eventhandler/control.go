@@ -0,0 +1,173 @@
+package eventhandler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// Consumer run states reported by Status.
+const (
+	ConsumerRunning  = "running"
+	ConsumerDraining = "draining"
+	ConsumerStopped  = "stopped"
+)
+
+// PartitionStatus reports the committed position and lag of one assigned partition.
+type PartitionStatus struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Committed int64  `json:"committed"`
+	End       int64  `json:"end"`
+	Lag       int64  `json:"lag"`
+}
+
+// ConsumerStatus is a point-in-time view of the consumer for operators.
+type ConsumerStatus struct {
+	State        string            `json:"state"`
+	Topics       []string          `json:"topics"`
+	PausedTopics []string          `json:"paused_topics"`
+	Partitions   []PartitionStatus `json:"partitions"`
+	TotalLag     int64             `json:"total_lag"`
+	Freshness    Freshness         `json:"freshness"`
+}
+
+// Pause stops fetching the given topics until resumed. With no topics, every
+// subscribed topic is paused. Records already fetched still finish processing.
+func (c *Consumer) Pause(topics ...string) error {
+	topics, err := c.subscribed(topics)
+	if err != nil {
+		return err
+	}
+	c.client.PauseFetchTopics(topics...)
+	c.logger.Info("paused topics", "topics", topics)
+	return nil
+}
+
+// Resume resumes fetching the given topics. With no topics, every paused topic
+// is resumed.
+func (c *Consumer) Resume(topics ...string) error {
+	topics, err := c.subscribed(topics)
+	if err != nil {
+		return err
+	}
+	c.client.ResumeFetchTopics(topics...)
+	c.logger.Info("resumed topics", "topics", topics)
+	return nil
+}
+
+// Drain stops polling, lets the in-flight batch finish and commit, and waits
+// for Start to return. The process keeps running; only event application
+// stops. Returns ctx.Err() if the consumer has not stopped before ctx is done.
+func (c *Consumer) Drain(ctx context.Context) error {
+	if c.state.CompareAndSwap(ConsumerRunning, ConsumerDraining) {
+		c.logger.Info("draining event consumer")
+		close(c.drain)
+	}
+
+	select {
+	case <-c.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Status reports the run state, paused topics, and per-partition lag
+// (log end offset minus committed offset) for the current assignment.
+func (c *Consumer) Status(ctx context.Context) (*ConsumerStatus, error) {
+	status := &ConsumerStatus{
+		State:        c.state.Load().(string),
+		Topics:       c.config.Topics,
+		PausedTopics: c.client.PauseFetchTopics(),
+		Partitions:   []PartitionStatus{},
+		Freshness:    c.Freshness(),
+	}
+	sort.Strings(status.PausedTopics)
+
+	committed := c.client.CommittedOffsets()
+	if len(committed) == 0 {
+		return status, nil
+	}
+
+	ends, err := c.endOffsets(ctx, committed)
+	if err != nil {
+		return nil, err
+	}
+
+	for topic, partitions := range committed {
+		for partition, offset := range partitions {
+			ps := PartitionStatus{
+				Topic:     topic,
+				Partition: partition,
+				Committed: offset.Offset,
+				End:       ends[topic][partition],
+			}
+			if ps.Committed >= 0 && ps.End > ps.Committed {
+				ps.Lag = ps.End - ps.Committed
+			}
+			status.TotalLag += ps.Lag
+			status.Partitions = append(status.Partitions, ps)
+		}
+	}
+	sort.Slice(status.Partitions, func(i, j int) bool {
+		a, b := status.Partitions[i], status.Partitions[j]
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		return a.Partition < b.Partition
+	})
+
+	return status, nil
+}
+
+// endOffsets fetches the log end offset of each given partition.
+func (c *Consumer) endOffsets(ctx context.Context, partitions map[string]map[int32]kgo.EpochOffset) (map[string]map[int32]int64, error) {
+	req := kmsg.NewPtrListOffsetsRequest()
+	req.ReplicaID = -1
+	for topic, parts := range partitions {
+		rt := kmsg.NewListOffsetsRequestTopic()
+		rt.Topic = topic
+		for partition := range parts {
+			rp := kmsg.NewListOffsetsRequestTopicPartition()
+			rp.Partition = partition
+			rp.Timestamp = -1 // latest
+			rt.Partitions = append(rt.Partitions, rp)
+		}
+		req.Topics = append(req.Topics, rt)
+	}
+
+	resp, err := req.RequestWith(ctx, c.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list end offsets: %w", err)
+	}
+
+	ends := make(map[string]map[int32]int64)
+	for _, rt := range resp.Topics {
+		ends[rt.Topic] = make(map[int32]int64)
+		for _, rp := range rt.Partitions {
+			if rp.ErrorCode == 0 {
+				ends[rt.Topic][rp.Partition] = rp.Offset
+			}
+		}
+	}
+	return ends, nil
+}
+
+// subscribed defaults an empty topic list to all subscribed topics and rejects
+// topics the consumer does not subscribe to.
+func (c *Consumer) subscribed(topics []string) ([]string, error) {
+	if len(topics) == 0 {
+		return c.config.Topics, nil
+	}
+	for _, topic := range topics {
+		if !slices.Contains(c.config.Topics, topic) {
+			return nil, fmt.Errorf("topic %q is not consumed by the event handler", topic)
+		}
+	}
+	return topics, nil
+}
@@ -4,41 +4,220 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"strings"
 	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cornjacket/platform-services/internal/shared/audit"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/httpserver"
+	"github.com/cornjacket/platform-services/internal/shared/leader"
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
 )
 
 // Config holds configuration for the event handler service.
 type Config struct {
-	Brokers       []string
-	ConsumerGroup string
-	Topics        []string
-	PollTimeout   time.Duration
+	Brokers        []string
+	ConsumerGroup  string
+	ClientID       string // Kafka client ID; carries the build version (see platform preflight)
+	Topics         []string
+	BrokerOpts     []kgo.Opt // TLS and SASL (see redpanda.Auth)
+	PollTimeout    time.Duration
+	Lanes          int
+	QueueSize      int
+	MaxAttempts    int           // dispatches of a failing event before it is quarantined; 0 disables quarantine
+	RetryDelay     time.Duration // backoff before the first retry of a failing event
+	Commit         CommitMode    // when offsets are committed; empty means CommitBatch
+	CommitInterval time.Duration // for CommitInterval
+
+	// Consumer group membership and rebalancing (see ConsumerConfig)
+	InstanceID       string
+	SessionTimeout   time.Duration
+	RebalanceTimeout time.Duration
+	Balancer         string
+
+	Filter   *EventFilter      // event types this deployment processes (see ParseEventFilter); nil processes all
+	Verifier *signing.Verifier // checks event signatures in the consumer and backfills; nil accepts all
+	Replay   bool              // run handlers on each event's ingestion time (see ConsumerConfig.Replay); owns the process clock
+
+	AdminPort   int               // admin API (pause/resume/drain/status); 0 disables it
+	AdminServer httpserver.Config // admin server timeouts; its WriteTimeout is ignored
+	LogLevel    LogLevel          // process log level, adjustable through the admin API; nil disables that
+	Audit       audit.Log         // records state-changing admin requests; nil disables auditing
+	Ready       http.Handler      // serves GET /readyz on the admin API (see postgres.ReadyHandler); nil omits it
+	Metrics     *metrics.Registry // per-handler dispatch metrics; nil disables them
+
+	VerifyInterval time.Duration // projection checksum verification; 0 disables it
+	VerifyLimit    int           // max discrepancies handled per verification run
+	VerifyRebuild  bool          // rebuild corrupted projections from event history
+
+	AnomalyMaxDelta float64       // sensor value_jump threshold; 0 disables it
+	AnomalyMaxGap   time.Duration // sensor reporting_gap threshold; 0 disables it
+
+	SensorWindow         time.Duration // sensor_window stats per time window; 0 disables them
+	SensorWindowLateness time.Duration // how long a window accepts events after it ends
+
+	Rollups []RollupConfig // declarative count/sum/min/max projections (see ParseRollups)
+	Shadows []ShadowConfig // handler kinds building shadow projections (see ParseShadows)
+
+	// Registry serves /admin/v1/registry and resolves "registry." rollup
+	// groups; nil disables both.
+	Registry registry.Store
+
+	ExpiryInterval time.Duration            // projection TTL sweep; 0 disables it
+	ExpiryTTLs     map[string]time.Duration // per projection type (see ParseTTLs)
+	FoldRetention  time.Duration            // fold records purged by the sweep (see ExpiryConfig); 0 keeps them
+
+	// Changes publishes accepted projection writes to the change topic
+	// (needs a store that reports them); nil publishes nothing.
+	Changes *ChangeFeed
+
+	// Leader runs the verifier and TTL sweeper on one replica at a time
+	// (locks VerifierRole and ExpirerRole); nil runs them on this instance.
+	Leader *leader.Elector
 }
 
+// Lock names electing the replicas that run the periodic jobs.
+const (
+	VerifierRole = "eventhandler-verifier"
+	ExpirerRole  = "eventhandler-expirer"
+)
+
 // RunningService represents a started event handler service.
 type RunningService struct {
 	// Shutdown stops the consumer gracefully.
 	Shutdown func(ctx context.Context) error
 }
 
-// Start starts the event handler consumer.
+// Start starts the event handler consumer and, if AdminPort is set, its admin server.
 // The writer is the service's output — where projections are written for downstream consumers.
-func Start(ctx context.Context, cfg Config, writer ProjectionWriter, logger *slog.Logger) (*RunningService, error) {
+// eventReader is optional and only used to rebuild corrupted projections.
+func Start(ctx context.Context, cfg Config, writer ProjectionWriter, eventReader EventReader, logger *slog.Logger, errorCh chan<- error) (*RunningService, error) {
 	logger = logger.With("service", "eventhandler")
 
+	// Projection change feed, listening before any handler writes
+	if cfg.Changes != nil {
+		notifier, ok := writer.(ChangeNotifier)
+		if !ok {
+			return nil, fmt.Errorf("projection change feed configured but the projection store does not report changes")
+		}
+		if cfg.Metrics != nil {
+			cfg.Changes.SetMetrics(cfg.Metrics)
+		}
+		notifier.SetChangeListener(cfg.Changes.Publish)
+	}
+
 	// Wire handler registry with event-type handlers
 	registry := NewHandlerRegistry(logger)
-	registry.Register("sensor.", NewSensorHandler(writer, logger))
-	registry.Register("user.", NewUserHandler(writer, logger))
+	sensorHandler := newSensorHandler(writer, cfg, logger)
+	userHandler := NewUserHandler(writer, logger)
+	if deleter, ok := writer.(ProjectionDeleter); ok {
+		userHandler.SetDeleter(deleter)
+	}
+	registry.Register("sensor.", "sensor", sensorHandler)
+	registry.Register("user.", "user", userHandler)
+	// Handlers the admin API can route at runtime; add a new projection
+	// handler here, unrouted, to canary it without a restart
+	catalog := map[string]EventHandler{
+		"sensor": sensorHandler,
+		"user":   userHandler,
+	}
+
+	// Windowed sensor stats (optional; needs a store with window state)
+	if store, ok := writer.(WindowStore); ok && cfg.SensorWindow > 0 {
+		windows := NewWindowedHandler(store, writer, FieldStats("value"), WindowConfig{
+			Projection: "sensor_window",
+			Size:       cfg.SensorWindow,
+			Lateness:   cfg.SensorWindowLateness,
+		}, logger)
+		registry.Register("sensor.", "sensor_window", windows)
+		catalog["sensor_window"] = windows
+	}
+
+	// Declarative rollups, each on its own pattern or sharing an existing
+	// one: a partly overlapping pattern would take events from other handlers
+	for _, rollup := range cfg.Rollups {
+		if overlapping := registry.Overlapping(rollup.Events); len(overlapping) > 0 {
+			return nil, fmt.Errorf("rollup %s: pattern %q overlaps the patterns of handlers %s; use the same pattern",
+				rollup.Name, rollup.Events, strings.Join(overlapping, ", "))
+		}
+		if rollup.usesRegistry() && cfg.Registry == nil {
+			return nil, fmt.Errorf("rollup %s: group %s needs the aggregate registry", rollup.Name, rollup.Group)
+		}
+		projections.RegisterMerge(rollup.Name, rollupOps)
+		handler := NewRollupHandler(writer, rollup, logger)
+		handler.SetRegistry(cfg.Registry)
+		if err := registry.add(rollup.Events, namedHandler{name: rollup.Name, handler: handler}); err != nil {
+			return nil, fmt.Errorf("rollup %s: %w", rollup.Name, err)
+		}
+		catalog[rollup.Name] = handler
+	}
+
+	// Shadow projections: built-in handlers writing under another type,
+	// routed and backfilled through the admin API (needs a store they can
+	// read their own state from)
+	if store, ok := writer.(ShadowableStore); ok {
+		for _, shadow := range cfg.Shadows {
+			if _, exists := catalog[shadow.Name]; exists {
+				return nil, fmt.Errorf("shadow %s: handler kind already exists", shadow.Name)
+			}
+			shadowStore := NewShadowStore(store, ShadowSources[shadow.Kind], shadow.Projection)
+			switch shadow.Kind {
+			case "sensor":
+				catalog[shadow.Name] = newSensorHandler(shadowStore, cfg, logger)
+			case "user":
+				catalog[shadow.Name] = NewUserHandler(shadowStore, logger)
+			}
+		}
+	} else if len(cfg.Shadows) > 0 {
+		logger.Warn("shadow handlers configured but the projection store cannot host them")
+	}
+	registry.SetCatalog(catalog)
+	if cfg.Metrics != nil {
+		registry.SetMetrics(NewDispatchMetrics(cfg.Metrics))
+	}
+
+	// Administrative freeze (optional; needs a store with aggregate flags)
+	freezer, _ := writer.(AggregateFreezer)
+	if freezer != nil {
+		registry.SetFreezeChecker(freezer)
+	}
+
+	// Payload upcasters run before dispatch; register a transform here whenever
+	// an event type's schema version is bumped.
+	upcasters := events.NewUpcasters()
 
 	// Create consumer
 	consumer, err := NewConsumer(
 		registry,
+		upcasters,
 		ConsumerConfig{
-			Brokers:     cfg.Brokers,
-			GroupID:     cfg.ConsumerGroup,
-			Topics:      cfg.Topics,
-			PollTimeout: cfg.PollTimeout,
+			Brokers:        cfg.Brokers,
+			GroupID:        cfg.ConsumerGroup,
+			ClientID:       cfg.ClientID,
+			Topics:         cfg.Topics,
+			PollTimeout:    cfg.PollTimeout,
+			Lanes:          cfg.Lanes,
+			QueueSize:      cfg.QueueSize,
+			Opts:           cfg.BrokerOpts,
+			MaxAttempts:    cfg.MaxAttempts,
+			RetryDelay:     cfg.RetryDelay,
+			Commit:         cfg.Commit,
+			CommitInterval: cfg.CommitInterval,
+
+			InstanceID:       cfg.InstanceID,
+			SessionTimeout:   cfg.SessionTimeout,
+			RebalanceTimeout: cfg.RebalanceTimeout,
+			Balancer:         cfg.Balancer,
+
+			Filter:   cfg.Filter,
+			Verifier: cfg.Verifier,
+			Replay:   cfg.Replay,
 		},
 		logger,
 	)
@@ -46,6 +225,11 @@ func Start(ctx context.Context, cfg Config, writer ProjectionWriter, logger *slo
 		return nil, fmt.Errorf("failed to create event consumer: %w", err)
 	}
 
+	// Poison-event quarantine (optional; needs a store with a DLQ)
+	if store, ok := writer.(QuarantineStore); ok && cfg.MaxAttempts > 0 {
+		consumer.SetQuarantine(store)
+	}
+
 	// Start consumer
 	go func() {
 		if err := consumer.Start(ctx); err != nil {
@@ -53,10 +237,106 @@ func Start(ctx context.Context, cfg Config, writer ProjectionWriter, logger *slo
 		}
 	}()
 
+	// Start projection verifier (optional; needs a store that records checksums)
+	var integrity IntegrityChecker
+	if auditor, ok := writer.(ProjectionAuditor); ok && cfg.VerifyInterval > 0 {
+		if cfg.VerifyRebuild && eventReader == nil {
+			logger.Warn("projection rebuild enabled without an event reader; discrepancies will only be reported")
+		}
+		verifier := NewVerifier(auditor, eventReader, VerifierConfig{
+			Interval: cfg.VerifyInterval,
+			Limit:    cfg.VerifyLimit,
+			Rebuild:  cfg.VerifyRebuild,
+		}, logger)
+		go cfg.Leader.Run(ctx, VerifierRole, verifier.Run)
+		integrity = verifier
+	}
+
+	// Start projection TTL sweeper (optional; needs a store with soft delete)
+	if expirer, ok := writer.(ProjectionExpirer); ok && cfg.ExpiryInterval > 0 && (len(cfg.ExpiryTTLs) > 0 || cfg.FoldRetention > 0) {
+		go cfg.Leader.Run(ctx, ExpirerRole, NewExpirer(expirer, ExpiryConfig{
+			Interval:      cfg.ExpiryInterval,
+			TTLs:          cfg.ExpiryTTLs,
+			FoldRetention: cfg.FoldRetention,
+		}, logger).Run)
+	}
+
+	// Backfills replay history into a handler (optional; needs an event store
+	// that reads in global order)
+	var backfills *Backfills
+	if log, ok := eventReader.(EventLog); ok {
+		backfills = NewBackfills(ctx, log, registry, upcasters, logger)
+		backfills.SetVerifier(cfg.Verifier)
+	}
+
+	// Start admin server (optional)
+	var server *http.Server
+	if cfg.AdminPort != 0 {
+		mux := http.NewServeMux()
+		admin := NewAdminHandler(consumer, integrity, freezer, logger)
+		if cfg.LogLevel != nil {
+			admin.SetLogLevel(cfg.LogLevel)
+		}
+		admin.SetHandlers(registry)
+		if backfills != nil {
+			admin.SetBackfills(backfills)
+		}
+		if aliases, ok := writer.(AliasStore); ok {
+			admin.SetAliases(aliases)
+		}
+		if cfg.Registry != nil {
+			admin.SetRegistry(cfg.Registry)
+		}
+		if store, ok := writer.(QuarantineStore); ok {
+			admin.SetQuarantine(store)
+		}
+		admin.SetAudit(audit.NewRecorder(cfg.Audit, "eventhandler", logger))
+		admin.RegisterRoutes(mux)
+		if cfg.Ready != nil {
+			mux.Handle("/readyz", cfg.Ready)
+		}
+
+		// No WriteTimeout: drain blocks until the in-flight batch commits
+		adminServer := cfg.AdminServer
+		adminServer.WriteTimeout = 0
+		server = httpserver.New(cfg.AdminPort, mux, adminServer)
+
+		go func() {
+			logger.Info("starting eventhandler admin server", "port", cfg.AdminPort)
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("eventhandler admin server error", "error", err)
+				errorCh <- fmt.Errorf("eventhandler admin server failed: %w", err)
+			}
+		}()
+	}
+
 	return &RunningService{
 		Shutdown: func(shutdownCtx context.Context) error {
 			logger.Info("shutting down event handler service")
-			return consumer.Close()
+			var err error
+			if server != nil {
+				err = server.Shutdown(shutdownCtx)
+			}
+			if closeErr := consumer.Close(); closeErr != nil {
+				err = closeErr
+			}
+			return err
 		},
 	}, nil
 }
+
+// newSensorHandler creates a sensor handler writing to writer, with anomaly
+// detection and deletes when the store supports them.
+func newSensorHandler(writer ProjectionWriter, cfg Config, logger *slog.Logger) *SensorHandler {
+	handler := NewSensorHandler(writer, logger)
+	if reader, ok := writer.(ProjectionReader); ok {
+		handler.SetAnomalyDetection(reader, AnomalyConfig{
+			MaxDelta: cfg.AnomalyMaxDelta,
+			MaxGap:   cfg.AnomalyMaxGap,
+		})
+	}
+	if deleter, ok := writer.(ProjectionDeleter); ok {
+		handler.SetDeleter(deleter)
+	}
+	return handler
+}
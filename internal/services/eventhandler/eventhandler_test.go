@@ -13,60 +13,24 @@ import (
 	"github.com/gofrs/uuid/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/twmb/franz-go/pkg/kgo"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 	"github.com/cornjacket/platform-services/internal/testutil"
+	"github.com/cornjacket/platform-services/pkg/fakes"
 )
 
-// projectionCall captures a single call to WriteProjection.
-type projectionCall struct {
-	ProjType    string
-	AggregateID string
-	State       json.RawMessage
-	Event       *events.Envelope
-}
-
-// channelProjectionWriter captures projection writes via a channel.
-type channelProjectionWriter struct {
-	calls chan projectionCall
-}
-
-func (m *channelProjectionWriter) WriteProjection(_ context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
-	m.calls <- projectionCall{
-		ProjType:    projType,
-		AggregateID: aggregateID,
-		State:       state,
-		Event:       event,
-	}
-	return nil
-}
-
-// Compile-time check: channelProjectionWriter implements ProjectionWriter.
-var _ ProjectionWriter = (*channelProjectionWriter)(nil)
-
 func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
 }
 
-func produceEvent(t *testing.T, topic string, env *events.Envelope) {
+// waitForWrites waits up to 5s for n projection writes.
+func waitForWrites(t *testing.T, store *fakes.ProjectionStore, n int) []fakes.ProjectionWrite {
 	t.Helper()
-	value, err := json.Marshal(env)
-	require.NoError(t, err)
-
-	producer, err := kgo.NewClient(
-		kgo.SeedBrokers(testutil.TestBrokers()...),
-		kgo.AllowAutoTopicCreation(),
-	)
-	require.NoError(t, err)
-	defer producer.Close()
-
-	results := producer.ProduceSync(context.Background(), &kgo.Record{
-		Topic: topic,
-		Key:   []byte(env.AggregateID),
-		Value: value,
-	})
-	require.NoError(t, results.FirstErr())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	writes, err := store.WaitForWrites(ctx, n)
+	require.NoError(t, err, "timed out waiting for projection write")
+	return writes
 }
 
 func newComponentEnvelope(eventType, aggregateID string, payload map[string]any) *events.Envelope {
@@ -82,7 +46,7 @@ func newComponentEnvelope(eventType, aggregateID string, payload map[string]any)
 	}
 }
 
-func startEventHandler(t *testing.T, mock *channelProjectionWriter) *RunningService {
+func startEventHandler(t *testing.T, mock *fakes.ProjectionStore) *RunningService {
 	t.Helper()
 	topic := testutil.TestTopicName(t)
 	ctx := context.Background()
@@ -92,7 +56,7 @@ func startEventHandler(t *testing.T, mock *channelProjectionWriter) *RunningServ
 		ConsumerGroup: "test-group-" + topic,
 		Topics:        []string{topic},
 		PollTimeout:   time.Second,
-	}, mock, testLogger())
+	}, mock, nil, testLogger(), nil)
 	require.NoError(t, err)
 
 	// Store topic on test for event producers
@@ -105,68 +69,60 @@ func startEventHandler(t *testing.T, mock *channelProjectionWriter) *RunningServ
 
 func TestEventHandler_SensorEvent(t *testing.T) {
 	topic := testutil.TestTopicName(t)
-	mock := &channelProjectionWriter{calls: make(chan projectionCall, 10)}
+	mock := fakes.NewProjectionStore()
 
 	svc, err := Start(context.Background(), Config{
 		Brokers:       testutil.TestBrokers(),
 		ConsumerGroup: "test-group-" + topic,
 		Topics:        []string{topic},
 		PollTimeout:   time.Second,
-	}, mock, testLogger())
+	}, mock, nil, testLogger(), nil)
 	require.NoError(t, err)
 	defer svc.Shutdown(context.Background())
 
 	env := newComponentEnvelope("sensor.reading", "device-001", map[string]any{"temperature": 23.5})
-	produceEvent(t, topic, env)
-
-	select {
-	case call := <-mock.calls:
-		assert.Equal(t, "sensor_state", call.ProjType)
-		assert.Equal(t, "device-001", call.AggregateID)
-		assert.Equal(t, env.EventID, call.Event.EventID)
-		assert.Contains(t, string(call.State), "temperature")
-	case <-time.After(5 * time.Second):
-		t.Fatal("timed out waiting for projection write")
-	}
+	testutil.ProduceEvents(t, topic, env)
+
+	call := waitForWrites(t, mock, 1)[0]
+	assert.Equal(t, "sensor_state", call.ProjectionType)
+	assert.Equal(t, "device-001", call.AggregateID)
+	assert.Equal(t, env.EventID, call.Event.EventID)
+	assert.Contains(t, string(call.State), "temperature")
 }
 
 func TestEventHandler_UserEvent(t *testing.T) {
 	topic := testutil.TestTopicName(t)
-	mock := &channelProjectionWriter{calls: make(chan projectionCall, 10)}
+	mock := fakes.NewProjectionStore()
 
 	svc, err := Start(context.Background(), Config{
 		Brokers:       testutil.TestBrokers(),
 		ConsumerGroup: "test-group-" + topic,
 		Topics:        []string{topic},
 		PollTimeout:   time.Second,
-	}, mock, testLogger())
+	}, mock, nil, testLogger(), nil)
 	require.NoError(t, err)
 	defer svc.Shutdown(context.Background())
 
 	env := newComponentEnvelope("user.login", "session-abc", map[string]any{"user": "alice"})
-	produceEvent(t, topic, env)
-
-	select {
-	case call := <-mock.calls:
-		assert.Equal(t, "user_session", call.ProjType)
-		assert.Equal(t, "session-abc", call.AggregateID)
-		assert.Equal(t, env.EventID, call.Event.EventID)
-	case <-time.After(5 * time.Second):
-		t.Fatal("timed out waiting for projection write")
-	}
-	assert.Empty(t, mock.calls, "unexpected extra projection writes")
+	testutil.ProduceEvents(t, topic, env)
+
+	call := waitForWrites(t, mock, 1)[0]
+	assert.Equal(t, "user_session", call.ProjectionType)
+	assert.Equal(t, "session-abc", call.AggregateID)
+	assert.Equal(t, env.EventID, call.Event.EventID)
+	assert.Len(t, mock.Writes(), 1, "unexpected extra projection writes")
 }
 
 func TestEventHandler_UnknownEventType(t *testing.T) {
 	topic := testutil.TestTopicName(t)
-	mock := &channelProjectionWriter{calls: make(chan projectionCall, 10)}
+	mock := fakes.NewProjectionStore()
 
 	svc, err := Start(context.Background(), Config{
 		Brokers:       testutil.TestBrokers(),
 		ConsumerGroup: "test-group-" + topic,
 		Topics:        []string{topic},
 		PollTimeout:   time.Second,
-	}, mock, testLogger())
+	}, mock, nil, testLogger(), nil)
 	require.NoError(t, err)
 	defer svc.Shutdown(context.Background())
 
@@ -181,18 +137,13 @@ func TestEventHandler_UnknownEventType(t *testing.T) {
 	// the mock will not receive a projection write for "billing.charge",
 	// we must confirm the consumer actually processed it (didn't just lag).
 	unknownEnv := newComponentEnvelope("billing.charge", "invoice-99", map[string]any{"amount": 100})
-	produceEvent(t, topic, unknownEnv)
+	testutil.ProduceEvents(t, topic, unknownEnv)
 	env := newComponentEnvelope("sensor.reading", "device-001", map[string]any{"temperature": 23.5})
-	produceEvent(t, topic, env)
-
-	select {
-	case call := <-mock.calls:
-		assert.Equal(t, "sensor_state", call.ProjType)
-		assert.Equal(t, "device-001", call.AggregateID)
-		assert.Equal(t, env.EventID, call.Event.EventID)
-		assert.Contains(t, string(call.State), "temperature")
-	case <-time.After(5 * time.Second):
-		t.Fatal("timed out waiting for projection write")
-	}
+	testutil.ProduceEvents(t, topic, env)
 
+	call := waitForWrites(t, mock, 1)[0]
+	assert.Equal(t, "sensor_state", call.ProjectionType)
+	assert.Equal(t, "device-001", call.AggregateID)
+	assert.Equal(t, env.EventID, call.Event.EventID)
+	assert.Contains(t, string(call.State), "temperature")
 }
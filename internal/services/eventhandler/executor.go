@@ -0,0 +1,126 @@
+package eventhandler
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// ExecutorStats reports keyed-executor counters for observability. Test
+// traffic is not counted.
+type ExecutorStats struct {
+	// Submitted is the number of records handed to the executor.
+	Submitted int64
+	// Overflows is the number of submissions that found their queue full and
+	// had to wait for space (backpressure on the poll loop).
+	Overflows int64
+}
+
+// keyedExecutor runs tasks on a fixed set of lanes, each a bounded FIFO queue
+// drained by one goroutine. Tasks with the same key always land on the same
+// lane and run in submission order; tasks for different keys run concurrently
+// unless their keys hash to the same lane.
+//
+// Only one goroutine (the poll loop) may call Submit, Wait, and Close.
+type keyedExecutor struct {
+	lanes    []chan func()
+	running  sync.WaitGroup // lane goroutines
+	inflight sync.WaitGroup // submitted tasks not yet finished
+
+	submitted atomic.Int64
+	overflows atomic.Int64
+
+	logger *slog.Logger
+}
+
+// newKeyedExecutor starts laneCount lanes, each buffering up to queueSize tasks.
+// Non-positive values fall back to a single lane / single-slot queue.
+func newKeyedExecutor(laneCount, queueSize int, logger *slog.Logger) *keyedExecutor {
+	if laneCount < 1 {
+		laneCount = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	e := &keyedExecutor{
+		lanes:  make([]chan func(), laneCount),
+		logger: logger,
+	}
+	for i := range e.lanes {
+		lane := make(chan func(), queueSize)
+		e.lanes[i] = lane
+		e.running.Add(1)
+		go func() {
+			defer e.running.Done()
+			for task := range lane {
+				task()
+				e.inflight.Done()
+			}
+		}()
+	}
+	return e
+}
+
+// Submit queues task on the lane for key. If the lane is full, Submit counts
+// an overflow and blocks until space frees up or ctx is done. Tasks for test
+// traffic (test) are left out of the counters.
+func (e *keyedExecutor) Submit(ctx context.Context, key string, test bool, task func()) error {
+	lane := e.laneFor(key)
+	e.inflight.Add(1)
+	if !test {
+		e.submitted.Add(1)
+	}
+
+	select {
+	case e.lanes[lane] <- task:
+		return nil
+	default:
+	}
+
+	if !test {
+		e.overflows.Add(1)
+	}
+	e.logger.Debug("executor queue full, waiting for space",
+		"lane", lane,
+		"queue_size", cap(e.lanes[lane]),
+	)
+
+	select {
+	case e.lanes[lane] <- task:
+		return nil
+	case <-ctx.Done():
+		e.inflight.Done()
+		return ctx.Err()
+	}
+}
+
+// Wait blocks until every submitted task has finished.
+func (e *keyedExecutor) Wait() {
+	e.inflight.Wait()
+}
+
+// Close drains the queues and stops the lane goroutines.
+func (e *keyedExecutor) Close() {
+	for _, lane := range e.lanes {
+		close(lane)
+	}
+	e.running.Wait()
+}
+
+// Stats returns cumulative executor counters.
+func (e *keyedExecutor) Stats() ExecutorStats {
+	return ExecutorStats{
+		Submitted: e.submitted.Load(),
+		Overflows: e.overflows.Load(),
+	}
+}
+
+// laneFor maps a key to its lane index.
+func (e *keyedExecutor) laneFor(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(e.lanes)))
+}
@@ -0,0 +1,107 @@
+package eventhandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyedExecutor_PerKeyOrdering(t *testing.T) {
+	exec := newKeyedExecutor(4, 8, slog.Default())
+	defer exec.Close()
+
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+
+	for i := 0; i < 50; i++ {
+		for _, key := range []string{"device-001", "device-002", "device-003"} {
+			require.NoError(t, exec.Submit(context.Background(), key, false, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				seen[key] = append(seen[key], i)
+			}))
+		}
+	}
+	exec.Wait()
+
+	for key, order := range seen {
+		require.Len(t, order, 50, key)
+		for i, v := range order {
+			assert.Equal(t, i, v, "%s processed out of order", key)
+		}
+	}
+}
+
+func TestKeyedExecutor_KeysRunConcurrently(t *testing.T) {
+	exec := newKeyedExecutor(8, 8, slog.Default())
+	defer exec.Close()
+
+	// Find two keys on different lanes
+	blockedKey := "device-001"
+	otherKey := ""
+	for i := 2; otherKey == ""; i++ {
+		if k := fmt.Sprintf("device-%03d", i); exec.laneFor(k) != exec.laneFor(blockedKey) {
+			otherKey = k
+		}
+	}
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	require.NoError(t, exec.Submit(context.Background(), blockedKey, false, func() { <-release }))
+	require.NoError(t, exec.Submit(context.Background(), otherKey, false, func() { close(done) }))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("task for another aggregate was blocked by a slow aggregate")
+	}
+	close(release)
+	exec.Wait()
+}
+
+func TestKeyedExecutor_Overflow(t *testing.T) {
+	exec := newKeyedExecutor(1, 1, slog.Default())
+	defer exec.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	require.NoError(t, exec.Submit(context.Background(), "a", false, func() {
+		close(started)
+		<-release
+	}))
+	<-started
+
+	// Fills the single queue slot
+	require.NoError(t, exec.Submit(context.Background(), "a", false, func() {}))
+
+	// Queue is full: Submit blocks until the context expires
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := exec.Submit(ctx, "a", false, func() { t.Fatal("task should not run after a failed submit") })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	exec.Wait()
+
+	stats := exec.Stats()
+	assert.Equal(t, int64(3), stats.Submitted)
+	assert.Equal(t, int64(1), stats.Overflows)
+}
+
+func TestKeyedExecutor_TestTrafficNotCounted(t *testing.T) {
+	exec := newKeyedExecutor(1, 1, slog.Default())
+	defer exec.Close()
+
+	ran := make(chan struct{})
+	require.NoError(t, exec.Submit(context.Background(), "a", true, func() { close(ran) }))
+	<-ran
+	exec.Wait()
+
+	assert.Equal(t, ExecutorStats{}, exec.Stats())
+}
@@ -0,0 +1,132 @@
+package eventhandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// ExpiryConfig holds configuration for the projection TTL sweeper.
+type ExpiryConfig struct {
+	Interval time.Duration            // time between sweeps
+	TTLs     map[string]time.Duration // per projection type; types not listed never expire
+
+	// FoldRetention is the redelivery horizon of merged projections: how
+	// long the record of a folded event is kept to skip its redeliveries.
+	// An event redelivered later is folded again. 0 keeps records forever.
+	FoldRetention time.Duration
+}
+
+// ParseTTLs parses a comma-separated list of projection TTLs, such as
+// "sensor_state=720h,user_session=168h". Empty input yields no TTLs.
+func ParseTTLs(s string) (map[string]time.Duration, error) {
+	ttls := map[string]time.Duration{}
+	if s == "" {
+		return ttls, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		projType, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid projection TTL %q: expected type=duration", entry)
+		}
+		if _, known := projections.Sources[projType]; !known {
+			return nil, fmt.Errorf("invalid projection TTL %q: unknown projection type %s", entry, projType)
+		}
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid projection TTL %q: expected a positive duration", entry)
+		}
+		ttls[projType] = ttl
+	}
+	return ttls, nil
+}
+
+// Expirer periodically soft-deletes projections that have not been updated
+// within their type's TTL (devices that stopped reporting). Projections in the
+// test namespace expire with the same TTL as their base type. It also purges
+// fold records older than FoldRetention.
+type Expirer struct {
+	store  ProjectionExpirer
+	config ExpiryConfig
+	logger *slog.Logger
+}
+
+// NewExpirer creates a projection TTL sweeper.
+func NewExpirer(store ProjectionExpirer, config ExpiryConfig, logger *slog.Logger) *Expirer {
+	return &Expirer{
+		store:  store,
+		config: config,
+		logger: logger.With("component", "projection-expirer"),
+	}
+}
+
+// Run sweeps every Interval until ctx is cancelled.
+func (e *Expirer) Run(ctx context.Context) {
+	e.logger.Info("starting projection expirer",
+		"interval", e.config.Interval,
+		"ttls", e.config.TTLs,
+		"fold_retention", e.config.FoldRetention,
+	)
+
+	ticker := clock.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if _, err := e.Sweep(ctx); err != nil {
+				e.logger.Error("projection expiry failed", "error", err)
+			}
+		}
+	}
+}
+
+// Sweep runs one expiry pass and returns how many projections were expired,
+// then purges fold records. It stops at the first store error.
+func (e *Expirer) Sweep(ctx context.Context) (int, error) {
+	baseTypes := make([]string, 0, len(e.config.TTLs))
+	for projType := range e.config.TTLs {
+		baseTypes = append(baseTypes, projType)
+	}
+	sort.Strings(baseTypes)
+
+	now := clock.Now()
+	total := 0
+	for _, base := range baseTypes {
+		cutoff := now.Add(-e.config.TTLs[base])
+		for _, projType := range []string{projections.TypeFor(base, false), projections.TypeFor(base, true)} {
+			expired, err := e.store.ExpireProjections(ctx, projType, cutoff)
+			if err != nil {
+				return total, err
+			}
+			if expired > 0 {
+				e.logger.Info("expired stale projections",
+					"projection_type", projType,
+					"count", expired,
+					"cutoff", cutoff,
+				)
+			}
+			total += expired
+		}
+	}
+
+	if e.config.FoldRetention > 0 {
+		cutoff := now.Add(-e.config.FoldRetention)
+		purged, err := e.store.PurgeFolds(ctx, cutoff)
+		if err != nil {
+			return total, err
+		}
+		if purged > 0 {
+			e.logger.Info("purged fold records", "count", purged, "cutoff", cutoff)
+		}
+	}
+	return total, nil
+}
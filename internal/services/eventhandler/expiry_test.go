@@ -0,0 +1,111 @@
+package eventhandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+func TestParseTTLs(t *testing.T) {
+	ttls, err := ParseTTLs("sensor_state=720h, user_session=24h")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{
+		"sensor_state": 720 * time.Hour,
+		"user_session": 24 * time.Hour,
+	}, ttls)
+
+	ttls, err = ParseTTLs("")
+	require.NoError(t, err)
+	assert.Empty(t, ttls)
+}
+
+func TestParseTTLs_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"sensor_state",
+		"sensor_state=soon",
+		"sensor_state=0s",
+		"sensor_state=-1h",
+		"widget_state=1h",
+	} {
+		_, err := ParseTTLs(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestExpirer_Sweep(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	cutoffs := map[string]time.Time{}
+	store := &mockProjectionExpirer{
+		ExpireProjectionsFn: func(ctx context.Context, projType string, cutoff time.Time) (int, error) {
+			cutoffs[projType] = cutoff
+			return 2, nil
+		},
+	}
+
+	expirer := NewExpirer(store, ExpiryConfig{
+		Interval: time.Hour,
+		TTLs:     map[string]time.Duration{"sensor_state": 24 * time.Hour},
+	}, slog.Default())
+
+	expired, err := expirer.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, expired)
+	assert.Equal(t, map[string]time.Time{
+		"sensor_state":      now.Add(-24 * time.Hour),
+		"test.sensor_state": now.Add(-24 * time.Hour),
+	}, cutoffs, "test namespace expires with its base type")
+}
+
+func TestExpirer_PurgesFolds(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	var cutoff time.Time
+	store := &mockProjectionExpirer{
+		PurgeFoldsFn: func(ctx context.Context, c time.Time) (int, error) {
+			cutoff = c
+			return 3, nil
+		},
+	}
+
+	// No TTLs: the sweep only purges fold records
+	expirer := NewExpirer(store, ExpiryConfig{Interval: time.Hour, FoldRetention: 168 * time.Hour}, slog.Default())
+
+	expired, err := expirer.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, expired)
+	assert.Equal(t, now.Add(-168*time.Hour), cutoff)
+
+	store.PurgeFoldsFn = func(ctx context.Context, c time.Time) (int, error) {
+		return 0, fmt.Errorf("db error")
+	}
+	_, err = expirer.Sweep(context.Background())
+	assert.Error(t, err)
+}
+
+func TestExpirer_SweepError(t *testing.T) {
+	store := &mockProjectionExpirer{
+		ExpireProjectionsFn: func(ctx context.Context, projType string, cutoff time.Time) (int, error) {
+			return 0, fmt.Errorf("db error")
+		},
+	}
+
+	expirer := NewExpirer(store, ExpiryConfig{
+		Interval: time.Hour,
+		TTLs:     map[string]time.Duration{"sensor_state": time.Hour},
+	}, slog.Default())
+
+	_, err := expirer.Sweep(context.Background())
+	assert.Error(t, err)
+}
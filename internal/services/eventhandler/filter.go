@@ -0,0 +1,112 @@
+package eventhandler
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// EventFilter limits the event types a consumer processes, so a deployment
+// dedicated to a few types can ignore the rest of a shared topic. Patterns
+// use the handler pattern syntax (a prefix or a glob). An event type passes
+// if it matches an allow pattern, or there are none, and no deny pattern.
+// A nil EventFilter passes everything.
+type EventFilter struct {
+	allow []string // globs
+	deny  []string
+}
+
+// NewEventFilter creates an EventFilter. It returns nil when both lists are
+// empty.
+func NewEventFilter(allow, deny []string) (*EventFilter, error) {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil, nil
+	}
+	f := &EventFilter{}
+	for _, p := range allow {
+		if p == "" {
+			return nil, fmt.Errorf("empty allow pattern")
+		}
+		f.allow = append(f.allow, glob(p))
+	}
+	for _, p := range deny {
+		if p == "" {
+			return nil, fmt.Errorf("empty deny pattern")
+		}
+		f.deny = append(f.deny, glob(p))
+	}
+	return f, nil
+}
+
+// ParseEventFilter creates an EventFilter from comma-separated pattern
+// lists, as in CJ_EVENTHANDLER_ALLOW and CJ_EVENTHANDLER_DENY.
+func ParseEventFilter(allow, deny string) (*EventFilter, error) {
+	split := func(s string) []string {
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		var patterns []string
+		for _, p := range strings.Split(s, ",") {
+			patterns = append(patterns, strings.TrimSpace(p))
+		}
+		return patterns
+	}
+	return NewEventFilter(split(allow), split(deny))
+}
+
+// Allows reports whether events of the type are processed.
+func (f *EventFilter) Allows(eventType string) bool {
+	if f == nil {
+		return true
+	}
+	for _, g := range f.deny {
+		if globMatch(g, eventType) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, g := range f.allow {
+		if globMatch(g, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventTypeKey is how the envelope's event type starts in its JSON form.
+var eventTypeKey = []byte(`"event_type"`)
+
+// recordEventType returns a record's event type without deserializing it:
+// from its header, or else from a scan for the envelope's event_type field.
+// The envelope encodes event_type before the payload, so the first match is
+// the envelope's own. Values the scan cannot read plainly, such as escaped
+// strings, are reported as unknown.
+func recordEventType(record *kgo.Record) (string, bool) {
+	if eventType, ok := headerValue(record, events.HeaderEventType); ok {
+		return eventType, true
+	}
+
+	i := bytes.Index(record.Value, eventTypeKey)
+	if i < 0 {
+		return "", false
+	}
+	rest := bytes.TrimLeft(record.Value[i+len(eventTypeKey):], " \t\r\n")
+	if len(rest) == 0 || rest[0] != ':' {
+		return "", false
+	}
+	rest = bytes.TrimLeft(rest[1:], " \t\r\n")
+	if len(rest) == 0 || rest[0] != '"' {
+		return "", false
+	}
+	end := bytes.IndexAny(rest[1:], `"\`)
+	if end < 0 || rest[1+end] != '"' {
+		return "", false
+	}
+	return string(rest[1 : 1+end]), true
+}
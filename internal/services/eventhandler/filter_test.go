@@ -0,0 +1,78 @@
+package eventhandler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+func TestEventFilter_Allows(t *testing.T) {
+	f, err := ParseEventFilter("sensor., user.login", "*.debug")
+	require.NoError(t, err)
+
+	assert.True(t, f.Allows("sensor.reading"))
+	assert.True(t, f.Allows("user.login"))
+	assert.False(t, f.Allows("user.logout"), "not allowed")
+	assert.False(t, f.Allows("sensor.debug"), "deny wins over allow")
+
+	denyOnly, err := ParseEventFilter("", "system.")
+	require.NoError(t, err)
+	assert.True(t, denyOnly.Allows("user.login"))
+	assert.False(t, denyOnly.Allows("system.heartbeat"))
+
+	none, err := ParseEventFilter(" ", "")
+	require.NoError(t, err)
+	assert.Nil(t, none)
+	assert.True(t, none.Allows("anything"))
+
+	_, err = ParseEventFilter("sensor.,", "")
+	assert.ErrorContains(t, err, "empty allow pattern")
+}
+
+func TestRecordEventType(t *testing.T) {
+	tests := []struct {
+		name   string
+		record *kgo.Record
+		want   string
+		ok     bool
+	}{
+		{"header", &kgo.Record{
+			Headers: []kgo.RecordHeader{{Key: events.HeaderEventType, Value: []byte("sensor.reading")}},
+			Value:   []byte(`{"event_type":"user.login"}`),
+		}, "sensor.reading", true},
+		{"scan", &kgo.Record{Value: []byte(`{"event_id":"x", "event_type" : "user.login","payload":{"event_type":"other"}}`)}, "user.login", true},
+		{"escaped", &kgo.Record{Value: []byte(`{"event_type":"user\u002elogin"}`)}, "", false},
+		{"not a string", &kgo.Record{Value: []byte(`{"event_type":null}`)}, "", false},
+		{"missing", &kgo.Record{Value: []byte(`{"event_id":"x"}`)}, "", false},
+		{"truncated", &kgo.Record{Value: []byte(`{"event_type":"user.lo`)}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := recordEventType(tt.record)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConsumer_SkipsFilteredEvents(t *testing.T) {
+	var handled []string
+	handler := &mockEventHandler{HandleFn: func(ctx context.Context, event *events.Envelope) error {
+		handled = append(handled, event.EventType)
+		return nil
+	}}
+	c := newTestConsumer(handler, nil)
+	filter, err := NewEventFilter(nil, []string{"sensor.debug"})
+	require.NoError(t, err)
+	c.config.Filter = filter
+
+	c.processRecord(context.Background(), testRecord(t, testutil.Event().Type("sensor.debug").Build()))
+	c.processRecord(context.Background(), testRecord(t, testutil.Event().Type("sensor.reading").Build()))
+	assert.Equal(t, []string{"sensor.reading"}, handled)
+}
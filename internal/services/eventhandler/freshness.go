@@ -0,0 +1,79 @@
+package eventhandler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// ReplayWindow is how long after the last replayed event Freshness still
+// reports Replaying.
+const ReplayWindow = time.Minute
+
+// TrafficFreshness reports how current the projections are for one class of
+// traffic (live or replay).
+type TrafficFreshness struct {
+	// Applied counts events applied since the consumer started.
+	Applied int64 `json:"applied"`
+	// Watermark is the newest ingested_at applied. It never moves backwards.
+	Watermark *time.Time `json:"watermark,omitempty"`
+	// LastAppliedAt is when the most recent event was applied.
+	LastAppliedAt *time.Time `json:"last_applied_at,omitempty"`
+	// LagSeconds is the delay between ingestion and apply for the most recent event.
+	LagSeconds float64 `json:"lag_seconds"`
+}
+
+// Freshness separates live traffic from replayed events (Metadata.Replay), so
+// a backfill neither makes projections look stale (old ingested_at) nor hides
+// a stalled live pipeline (replay events still flowing). Alert on Live. Test
+// traffic (Metadata.Test) is left out, so e2e runs cannot keep a stalled
+// pipeline looking fresh.
+type Freshness struct {
+	Live      TrafficFreshness `json:"live"`
+	Replay    TrafficFreshness `json:"replay"`
+	Replaying bool             `json:"replaying"` // a replayed event was applied within ReplayWindow
+}
+
+// freshnessTracker records applied events per traffic class.
+type freshnessTracker struct {
+	mu     sync.Mutex
+	live   TrafficFreshness
+	replay TrafficFreshness
+}
+
+// observe records that event was applied now. Test events are ignored.
+func (f *freshnessTracker) observe(event *events.Envelope) {
+	if event.Metadata.Test {
+		return
+	}
+	now := clock.Now()
+	ingestedAt := event.IngestedAt
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &f.live
+	if event.Metadata.Replay {
+		t = &f.replay
+	}
+	t.Applied++
+	t.LastAppliedAt = &now
+	t.LagSeconds = max(now.Sub(ingestedAt).Seconds(), 0)
+	if t.Watermark == nil || ingestedAt.After(*t.Watermark) {
+		t.Watermark = &ingestedAt
+	}
+}
+
+// snapshot returns the current freshness.
+func (f *freshnessTracker) snapshot() Freshness {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fr := Freshness{Live: f.live, Replay: f.replay}
+	if last := f.replay.LastAppliedAt; last != nil {
+		fr.Replaying = clock.Now().Sub(*last) < ReplayWindow
+	}
+	return fr
+}
@@ -0,0 +1,74 @@
+package eventhandler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+func TestFreshness_SeparatesLiveAndReplay(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	var f freshnessTracker
+	f.observe(&events.Envelope{IngestedAt: now.Add(-2 * time.Second)})
+	f.observe(&events.Envelope{IngestedAt: now.Add(-30 * 24 * time.Hour), Metadata: events.Metadata{Replay: true}})
+
+	fr := f.snapshot()
+	assert.Equal(t, int64(1), fr.Live.Applied)
+	assert.Equal(t, 2.0, fr.Live.LagSeconds, "replayed events must not inflate live lag")
+	require.NotNil(t, fr.Live.Watermark)
+	assert.Equal(t, now.Add(-2*time.Second), *fr.Live.Watermark)
+
+	assert.Equal(t, int64(1), fr.Replay.Applied)
+	assert.Equal(t, (30 * 24 * time.Hour).Seconds(), fr.Replay.LagSeconds)
+	assert.True(t, fr.Replaying)
+}
+
+func TestFreshness_IgnoresTestTraffic(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	var f freshnessTracker
+	f.observe(&events.Envelope{IngestedAt: now, Metadata: events.Metadata{Test: true}})
+	f.observe(&events.Envelope{IngestedAt: now, Metadata: events.Metadata{Test: true, Replay: true}})
+
+	assert.Equal(t, Freshness{}, f.snapshot())
+}
+
+func TestFreshness_WatermarkNeverMovesBack(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	var f freshnessTracker
+	f.observe(&events.Envelope{IngestedAt: now.Add(-time.Second)})
+	f.observe(&events.Envelope{IngestedAt: now.Add(-time.Minute)}) // late arrival
+
+	fr := f.snapshot()
+	assert.Equal(t, now.Add(-time.Second), *fr.Live.Watermark)
+	assert.Equal(t, 60.0, fr.Live.LagSeconds)
+}
+
+func TestFreshness_ReplayingExpires(t *testing.T) {
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: start})
+	t.Cleanup(clock.Reset)
+
+	var f freshnessTracker
+	assert.False(t, f.snapshot().Replaying)
+	assert.Nil(t, f.snapshot().Live.Watermark)
+
+	f.observe(&events.Envelope{IngestedAt: start.Add(-time.Hour), Metadata: events.Metadata{Replay: true}})
+	assert.True(t, f.snapshot().Replaying)
+
+	clock.Set(clock.FixedClock{Time: start.Add(ReplayWindow)})
+	assert.False(t, f.snapshot().Replaying)
+}
@@ -2,48 +2,346 @@ package eventhandler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
-	"strings"
+	"maps"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
 )
 
-// HandlerRegistry dispatches events to appropriate handlers based on event_type prefix.
+// Errors returned when changing the registered handlers.
+var (
+	ErrHandlerExists      = errors.New("handler already registered")
+	ErrHandlerNotFound    = errors.New("handler not registered")
+	ErrUnknownHandlerKind = errors.New("unknown handler kind")
+	ErrPatternConflict    = errors.New("pattern conflicts with a registered pattern")
+)
+
+// HandlerRegistry dispatches events to handlers by event_type pattern (see
+// patterns.go). An event goes to the handlers of the most specific matching
+// pattern; registering several handlers on one pattern lets one event update
+// several projections.
+//
+// Handlers can be added and removed while events are dispatched (see Route
+// and Unregister), so a new projection handler can be canaried without
+// restarting the consumer.
 type HandlerRegistry struct {
-	handlers map[string]EventHandler
-	logger   *slog.Logger
+	mu      sync.RWMutex
+	routes  []*route                // in registration order
+	catalog map[string]EventHandler // handlers Route can add, by kind
+
+	frozen  FreezeChecker // nil disables the freeze check
+	metrics *DispatchMetrics
+	logger  *slog.Logger
+}
+
+// route is a pattern and the handlers registered on it.
+type route struct {
+	pattern     string // as registered
+	glob        string
+	specificity int
+	handlers    []namedHandler // in registration order
+}
+
+// namedHandler is a handler and the name it reports under.
+type namedHandler struct {
+	name    string
+	kind    string // catalog kind; empty for handlers registered directly
+	handler EventHandler
+}
+
+// HandlerRoute describes a registered handler.
+type HandlerRoute struct {
+	Pattern string `json:"pattern"`
+	Handler string `json:"handler"`
+	Kind    string `json:"kind,omitempty"`
 }
 
 // NewHandlerRegistry creates a new handler registry.
 func NewHandlerRegistry(logger *slog.Logger) *HandlerRegistry {
 	return &HandlerRegistry{
-		handlers: make(map[string]EventHandler),
-		logger:   logger.With("component", "handler-registry"),
+		logger: logger.With("component", "handler-registry"),
 	}
 }
 
-// Register adds a handler for events with the given prefix.
-func (r *HandlerRegistry) Register(prefix string, handler EventHandler) {
-	r.handlers[prefix] = handler
-	r.logger.Info("registered handler", "prefix", prefix)
+// Register adds a handler for events matching pattern, a prefix or a glob.
+// name identifies the handler in logs and metrics and must be unique.
+// Handlers sharing a pattern run in registration order. Register panics on a
+// duplicate name, or on a pattern that conflicts with one already registered:
+// as specific, and able to match the same event type.
+func (r *HandlerRegistry) Register(pattern, name string, handler EventHandler) {
+	if err := r.add(pattern, namedHandler{name: name, handler: handler}); err != nil {
+		panic("eventhandler: " + err.Error())
+	}
 }
 
-// Dispatch routes an event to the appropriate handler.
-func (r *HandlerRegistry) Dispatch(ctx context.Context, event *events.Envelope) error {
-	for prefix, handler := range r.handlers {
-		if strings.HasPrefix(event.EventType, prefix) {
-			return handler.Handle(ctx, event)
+// SetCatalog sets the handlers Route can add at runtime, by kind.
+func (r *HandlerRegistry) SetCatalog(catalog map[string]EventHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.catalog = catalog
+}
+
+// Kinds returns the handler kinds Route accepts, sorted.
+func (r *HandlerRegistry) Kinds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Sorted(maps.Keys(r.catalog))
+}
+
+// Route adds the catalog handler of the given kind under name while the
+// registry is in use. Events already being dispatched are not affected.
+// It fails like Register, with ErrHandlerExists or ErrPatternConflict, and
+// with ErrUnknownHandlerKind for a kind missing from the catalog.
+func (r *HandlerRegistry) Route(pattern, name, kind string) error {
+	r.mu.RLock()
+	handler, ok := r.catalog[kind]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownHandlerKind, kind)
+	}
+	return r.add(pattern, namedHandler{name: name, kind: kind, handler: handler})
+}
+
+// Unregister removes the handler registered under name. Events already being
+// dispatched may still reach it.
+func (r *HandlerRegistry) Unregister(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, rt := range r.routes {
+		j := slices.IndexFunc(rt.handlers, func(h namedHandler) bool { return h.name == name })
+		if j < 0 {
+			continue
+		}
+		// Replace rather than edit in place: Dispatch may hold the old slice
+		rt.handlers = slices.Delete(slices.Clone(rt.handlers), j, j+1)
+		if len(rt.handlers) == 0 {
+			r.routes = slices.Delete(r.routes, i, i+1)
 		}
+		r.logger.Info("unregistered handler", "pattern", rt.pattern, "handler", name)
+		return nil
 	}
-	// No handler registered - log and skip (not an error)
-	r.logger.Debug("no handler for event type", "event_type", event.EventType)
+	return fmt.Errorf("%w: %q", ErrHandlerNotFound, name)
+}
+
+// Routes lists the registered handlers, by pattern in registration order.
+func (r *HandlerRegistry) Routes() []HandlerRoute {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var routes []HandlerRoute
+	for _, rt := range r.routes {
+		for _, h := range rt.handlers {
+			routes = append(routes, HandlerRoute{Pattern: rt.pattern, Handler: h.name, Kind: h.kind})
+		}
+	}
+	return routes
+}
+
+// Overlapping returns the handlers registered on other patterns that can
+// match the same event types as pattern. Registering on pattern would take
+// some of their events, or leave them some of its own.
+func (r *HandlerRegistry) Overlapping(pattern string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	g := glob(pattern)
+	var names []string
+	for _, rt := range r.routes {
+		if rt.glob != g && overlap(rt.glob, g) {
+			for _, h := range rt.handlers {
+				names = append(names, h.name)
+			}
+		}
+	}
+	return names
+}
+
+// add registers h on pattern.
+func (r *HandlerRegistry) add(pattern string, h namedHandler) error {
+	if pattern == "" || h.name == "" {
+		return errors.New("pattern and handler name are required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rt := range r.routes {
+		for _, existing := range rt.handlers {
+			if existing.name == h.name {
+				return fmt.Errorf("%w: %q", ErrHandlerExists, h.name)
+			}
+		}
+	}
+
+	g := glob(pattern)
+	i := slices.IndexFunc(r.routes, func(rt *route) bool { return rt.glob == g })
+	if i >= 0 {
+		rt := r.routes[i]
+		// Replace rather than append in place: Dispatch may hold the old slice
+		rt.handlers = append(slices.Clip(rt.handlers), h)
+	} else {
+		rt := &route{pattern: pattern, glob: g, specificity: specificity(g), handlers: []namedHandler{h}}
+		for _, other := range r.routes {
+			if other.specificity == rt.specificity && overlap(other.glob, rt.glob) {
+				return fmt.Errorf("%w: %q and %q", ErrPatternConflict, pattern, other.pattern)
+			}
+		}
+		r.routes = append(r.routes, rt)
+	}
+	r.logger.Info("registered handler", "pattern", pattern, "handler", h.name)
 	return nil
 }
 
+// SetFreezeChecker makes Dispatch skip events for frozen aggregates.
+// Pass nil to disable.
+func (r *HandlerRegistry) SetFreezeChecker(checker FreezeChecker) {
+	r.frozen = checker
+}
+
+// SetMetrics records per-handler dispatch metrics. Pass nil to disable.
+func (r *HandlerRegistry) SetMetrics(m *DispatchMetrics) {
+	r.metrics = m
+}
+
+// Dispatch routes an event to the handlers of its most specific matching
+// pattern. A failing handler does not stop the others; the failures are
+// joined into the returned error. Events for frozen aggregates are skipped
+// (not an error).
+func (r *HandlerRegistry) Dispatch(ctx context.Context, event *events.Envelope) error {
+	handlers := r.match(event.EventType)
+	if len(handlers) == 0 {
+		// No handler registered - log and skip (not an error)
+		r.logger.Debug("no handler for event type", "event_type", event.EventType)
+		return nil
+	}
+	return r.dispatch(ctx, event, handlers)
+}
+
+// DispatchTo routes an event to the handler registered under name alone, if
+// Dispatch would route it there. Used to backfill one handler from event
+// history. It returns ErrHandlerNotFound once the handler is unregistered.
+func (r *HandlerRegistry) DispatchTo(ctx context.Context, name string, event *events.Envelope) error {
+	if _, ok := r.Lookup(name); !ok {
+		return fmt.Errorf("%w: %q", ErrHandlerNotFound, name)
+	}
+	handlers := r.match(event.EventType)
+	i := slices.IndexFunc(handlers, func(h namedHandler) bool { return h.name == name })
+	if i < 0 {
+		return nil // routed to a more specific pattern's handlers
+	}
+	return r.dispatch(ctx, event, handlers[i:i+1])
+}
+
+// Lookup returns the route of the handler registered under name.
+func (r *HandlerRegistry) Lookup(name string) (HandlerRoute, bool) {
+	for _, route := range r.Routes() {
+		if route.Handler == name {
+			return route, true
+		}
+	}
+	return HandlerRoute{}, false
+}
+
+// dispatch runs handlers for an event, unless its aggregate is frozen.
+func (r *HandlerRegistry) dispatch(ctx context.Context, event *events.Envelope, handlers []namedHandler) error {
+	if r.frozen != nil {
+		frozen, err := r.frozen.IsFrozen(ctx, event.AggregateID)
+		if err != nil {
+			return err
+		}
+		if frozen {
+			r.logger.Info("skipping event for frozen aggregate",
+				"event_id", event.EventID,
+				"event_type", event.EventType,
+				"aggregate_id", event.AggregateID,
+			)
+			return nil
+		}
+	}
+
+	var errs []error
+	for _, h := range handlers {
+		start := time.Now()
+		err := h.handler.Handle(ctx, event)
+		if !event.Metadata.Test {
+			r.metrics.observe(h.name, time.Since(start), err)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("handler %s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Handles reports whether any registered handler accepts the event type.
+// Lets the consumer skip records from headers alone, before deserializing.
+func (r *HandlerRegistry) Handles(eventType string) bool {
+	return len(r.match(eventType)) > 0
+}
+
+// match returns the handlers of the most specific route matching the event
+// type. Registration rules out ties. The slice is never modified in place,
+// so it is safe to use after the lock is released.
+func (r *HandlerRegistry) match(eventType string) []namedHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best *route
+	for _, rt := range r.routes {
+		if globMatch(rt.glob, eventType) && (best == nil || rt.specificity > best.specificity) {
+			best = rt
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.handlers
+}
+
+// DispatchMetrics are the per-handler metric families. Test traffic is not
+// observed.
+type DispatchMetrics struct {
+	duration *metrics.Histogram
+	errors   *metrics.Counter
+}
+
+// NewDispatchMetrics registers the per-handler metric families with reg.
+func NewDispatchMetrics(reg *metrics.Registry) *DispatchMetrics {
+	return &DispatchMetrics{
+		duration: reg.NewHistogram("eventhandler_handle_duration_seconds",
+			"Time a handler took to handle an event, failed or not.",
+			metrics.DurationBuckets, "handler"),
+		errors: reg.NewCounter("eventhandler_handle_errors_total",
+			"Events a handler failed to handle.", "handler"),
+	}
+}
+
+// observe records one handled event. A nil DispatchMetrics discards it.
+func (m *DispatchMetrics) observe(handler string, elapsed time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.duration.Observe(elapsed.Seconds(), handler)
+	if err != nil {
+		m.errors.Inc(handler)
+	}
+}
+
 // SensorHandler processes sensor.* events.
 type SensorHandler struct {
-	store  ProjectionWriter
-	logger *slog.Logger
+	store    ProjectionWriter
+	deleter  ProjectionDeleter // nil applies tombstones as ordinary updates
+	previous ProjectionReader  // nil disables anomaly detection
+	anomaly  AnomalyConfig
+	logger   *slog.Logger
 }
 
 // NewSensorHandler creates a new sensor event handler.
@@ -54,13 +352,42 @@ func NewSensorHandler(store ProjectionWriter, logger *slog.Logger) *SensorHandle
 	}
 }
 
-// Handle processes a sensor event and updates the sensor_state projection.
+// SetAnomalyDetection enables rate-of-change anomaly flags, computed against
+// the stored projection read through reader. Pass a nil reader to disable.
+func (h *SensorHandler) SetAnomalyDetection(reader ProjectionReader, cfg AnomalyConfig) {
+	h.previous = reader
+	h.anomaly = cfg
+}
+
+// SetDeleter enables soft deletion of the projection on tombstone events
+// (see projections.Tombstones). Pass nil to disable.
+func (h *SensorHandler) SetDeleter(deleter ProjectionDeleter) {
+	h.deleter = deleter
+}
+
+// Handle processes a sensor event and updates the sensor_state projection
+// (in the test namespace for test traffic).
 func (h *SensorHandler) Handle(ctx context.Context, event *events.Envelope) error {
-	err := h.store.WriteProjection(ctx, "sensor_state", event.AggregateID, event.Payload, event)
+	projType := projections.TypeFor("sensor_state", event.Metadata.Test)
+
+	if h.deleter != nil && projections.IsTombstone(event.EventType) {
+		return h.delete(ctx, projType, event)
+	}
+
+	state := []byte(event.Payload)
+	if h.previous != nil && h.anomaly.enabled() {
+		var err error
+		if state, err = h.withAnomaly(ctx, projType, event); err != nil {
+			return err
+		}
+	}
+
+	err := h.store.WriteProjection(ctx, projType, event.AggregateID, state, event)
 	if err != nil {
 		h.logger.Error("failed to update sensor_state projection",
 			"event_id", event.EventID,
 			"aggregate_id", event.AggregateID,
+			"projection_type", projType,
 			"error", err,
 		)
 		return err
@@ -69,14 +396,37 @@ func (h *SensorHandler) Handle(ctx context.Context, event *events.Envelope) erro
 	h.logger.Debug("updated sensor_state projection",
 		"event_id", event.EventID,
 		"aggregate_id", event.AggregateID,
+		"projection_type", projType,
+	)
+	return nil
+}
+
+// delete soft-deletes the sensor_state projection for a tombstone event.
+func (h *SensorHandler) delete(ctx context.Context, projType string, event *events.Envelope) error {
+	if err := h.deleter.DeleteProjection(ctx, projType, event.AggregateID, event.Payload, event); err != nil {
+		h.logger.Error("failed to delete sensor_state projection",
+			"event_id", event.EventID,
+			"aggregate_id", event.AggregateID,
+			"projection_type", projType,
+			"error", err,
+		)
+		return err
+	}
+
+	h.logger.Info("deleted sensor_state projection",
+		"event_id", event.EventID,
+		"event_type", event.EventType,
+		"aggregate_id", event.AggregateID,
+		"projection_type", projType,
 	)
 	return nil
 }
 
 // UserHandler processes user.* events.
 type UserHandler struct {
-	store  ProjectionWriter
-	logger *slog.Logger
+	store   ProjectionWriter
+	deleter ProjectionDeleter // nil applies tombstones as ordinary updates
+	logger  *slog.Logger
 }
 
 // NewUserHandler creates a new user event handler.
@@ -87,13 +437,42 @@ func NewUserHandler(store ProjectionWriter, logger *slog.Logger) *UserHandler {
 	}
 }
 
-// Handle processes a user event and updates the user_session projection.
+// SetDeleter enables soft deletion of the projection on tombstone events,
+// such as user.deleted (see projections.IsTombstone). Pass nil to disable.
+func (h *UserHandler) SetDeleter(deleter ProjectionDeleter) {
+	h.deleter = deleter
+}
+
+// Handle processes a user event and updates the user_session projection
+// (in the test namespace for test traffic).
 func (h *UserHandler) Handle(ctx context.Context, event *events.Envelope) error {
-	err := h.store.WriteProjection(ctx, "user_session", event.AggregateID, event.Payload, event)
+	projType := projections.TypeFor("user_session", event.Metadata.Test)
+
+	if h.deleter != nil && projections.IsTombstone(event.EventType) {
+		if err := h.deleter.DeleteProjection(ctx, projType, event.AggregateID, event.Payload, event); err != nil {
+			h.logger.Error("failed to delete user_session projection",
+				"event_id", event.EventID,
+				"aggregate_id", event.AggregateID,
+				"projection_type", projType,
+				"error", err,
+			)
+			return err
+		}
+		h.logger.Info("deleted user_session projection",
+			"event_id", event.EventID,
+			"event_type", event.EventType,
+			"aggregate_id", event.AggregateID,
+			"projection_type", projType,
+		)
+		return nil
+	}
+
+	err := h.store.WriteProjection(ctx, projType, event.AggregateID, event.Payload, event)
 	if err != nil {
 		h.logger.Error("failed to update user_session projection",
 			"event_id", event.EventID,
 			"aggregate_id", event.AggregateID,
+			"projection_type", projType,
 			"error", err,
 		)
 		return err
@@ -102,6 +481,7 @@ func (h *UserHandler) Handle(ctx context.Context, event *events.Envelope) error
 	h.logger.Debug("updated user_session projection",
 		"event_id", event.EventID,
 		"aggregate_id", event.AggregateID,
+		"projection_type", projType,
 	)
 	return nil
 }
@@ -2,27 +2,22 @@ package eventhandler
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"strings"
+	"sync"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/testutil"
 )
 
-func newTestEnvelope(eventType string) *events.Envelope {
-	envelope, _ := events.NewEnvelope(
-		eventType, "device-001",
-		json.RawMessage(`{"value": 72.5}`),
-		events.Metadata{Source: "test"}, time.Now(),
-	)
-	return envelope
-}
-
 func TestDispatch_MatchedHandler(t *testing.T) {
 	var handled bool
 	mock := &mockEventHandler{
@@ -33,9 +28,9 @@ func TestDispatch_MatchedHandler(t *testing.T) {
 	}
 
 	registry := NewHandlerRegistry(slog.Default())
-	registry.Register("sensor.", mock)
+	registry.Register("sensor.", "sensor", mock)
 
-	err := registry.Dispatch(context.Background(), newTestEnvelope("sensor.reading"))
+	err := registry.Dispatch(context.Background(), testutil.Event().Type("sensor.reading").Build())
 	require.NoError(t, err)
 	assert.True(t, handled, "handler should be called for matching prefix")
 }
@@ -43,7 +38,7 @@ func TestDispatch_MatchedHandler(t *testing.T) {
 func TestDispatch_NoHandler(t *testing.T) {
 	registry := NewHandlerRegistry(slog.Default())
 
-	err := registry.Dispatch(context.Background(), newTestEnvelope("unknown.event"))
+	err := registry.Dispatch(context.Background(), testutil.Event().Type("unknown.event").Build())
 	assert.NoError(t, err, "unmatched event should not error")
 }
 
@@ -55,12 +50,202 @@ func TestDispatch_ErrorPropagation(t *testing.T) {
 	}
 
 	registry := NewHandlerRegistry(slog.Default())
-	registry.Register("sensor.", mock)
+	registry.Register("sensor.", "sensor", mock)
 
-	err := registry.Dispatch(context.Background(), newTestEnvelope("sensor.reading"))
+	err := registry.Dispatch(context.Background(), testutil.Event().Type("sensor.reading").Build())
 	assert.Error(t, err)
 }
 
+func TestDispatch_FanOut(t *testing.T) {
+	var calls []string
+	handler := func(name string, err error) EventHandler {
+		return &mockEventHandler{
+			HandleFn: func(ctx context.Context, event *events.Envelope) error {
+				calls = append(calls, name)
+				return err
+			},
+		}
+	}
+
+	reg := metrics.NewRegistry()
+	registry := NewHandlerRegistry(slog.Default())
+	registry.SetMetrics(NewDispatchMetrics(reg))
+	registry.Register("sensor.", "sensor", handler("sensor", nil))
+	registry.Register("sensor.", "sensor_stats", handler("sensor_stats", fmt.Errorf("db error")))
+	registry.Register("sensor.", "readings", handler("readings", nil))
+	registry.Register("user.", "user", handler("user", nil))
+
+	err := registry.Dispatch(context.Background(), testutil.Event().Type("sensor.reading").Build())
+
+	// A failing handler does not stop the others
+	assert.Equal(t, []string{"sensor", "sensor_stats", "readings"}, calls)
+	require.Error(t, err)
+	assert.Equal(t, "handler sensor_stats: db error", err.Error())
+
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+	assert.Contains(t, out.String(), `eventhandler_handle_duration_seconds_count{handler="readings"} 1`)
+	assert.Contains(t, out.String(), `eventhandler_handle_errors_total{handler="sensor_stats"} 1`)
+	assert.NotContains(t, out.String(), `eventhandler_handle_errors_total{handler="sensor"}`)
+}
+
+func TestDispatch_TestTrafficNotObserved(t *testing.T) {
+	handled := 0
+	reg := metrics.NewRegistry()
+	registry := NewHandlerRegistry(slog.Default())
+	registry.SetMetrics(NewDispatchMetrics(reg))
+	registry.Register("sensor.", "sensor", &mockEventHandler{
+		HandleFn: func(ctx context.Context, event *events.Envelope) error {
+			handled++
+			return fmt.Errorf("db error")
+		},
+	})
+
+	err := registry.Dispatch(context.Background(), testutil.Event().Type("sensor.reading").Test().Build())
+
+	require.Error(t, err)
+	assert.Equal(t, 1, handled)
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+	assert.NotContains(t, out.String(), `handler="sensor"`)
+}
+
+func TestDispatch_MostSpecificPatternWins(t *testing.T) {
+	var got string
+	handler := func(name string) EventHandler {
+		return &mockEventHandler{
+			HandleFn: func(ctx context.Context, event *events.Envelope) error {
+				got = name
+				return nil
+			},
+		}
+	}
+
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", handler("sensor"))
+	registry.Register("sensor.alert.", "alert", handler("alert"))
+	registry.Register("*.deleted", "deleted", handler("deleted"))
+	registry.Register("sensor.*.high", "high", handler("high"))
+
+	tests := []struct {
+		eventType string
+		want      string
+	}{
+		{"sensor.reading", "sensor"},
+		{"sensor.alert.low", "alert"},
+		{"sensor.alert.high", "alert"}, // "sensor.alert." has 13 literal characters, "sensor.*.high" 12
+		{"sensor.battery.high", "high"},
+		{"sensor.deleted", "deleted"},
+		{"user.deleted", "deleted"},
+		{"user.login", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			got = ""
+			require.NoError(t, registry.Dispatch(context.Background(), testutil.Event().Type(tt.eventType).Build()))
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want != "", registry.Handles(tt.eventType))
+		})
+	}
+}
+
+func TestRegister_PatternConflict(t *testing.T) {
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", &mockEventHandler{})
+	registry.Register("sensor.*", "sensor_stats", &mockEventHandler{}) // same pattern as "sensor."
+	registry.Register("*.created", "created", &mockEventHandler{})
+
+	// As specific as "sensor.", and both match "sensor.x"
+	assert.Panics(t, func() { registry.Register("sensor*x", "x", &mockEventHandler{}) })
+	// As specific as "*.created", and both match "sensors.created"
+	assert.Panics(t, func() { registry.Register("*s.create*", "creates", &mockEventHandler{}) })
+	// Equally specific but disjoint
+	assert.NotPanics(t, func() { registry.Register("*.deleted", "deleted", &mockEventHandler{}) })
+}
+
+func TestRegistry_RouteAndUnregister(t *testing.T) {
+	var calls []string
+	handler := func(name string) EventHandler {
+		return &mockEventHandler{
+			HandleFn: func(ctx context.Context, event *events.Envelope) error {
+				calls = append(calls, name)
+				return nil
+			},
+		}
+	}
+
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", handler("sensor"))
+	registry.SetCatalog(map[string]EventHandler{"stats": handler("stats")})
+
+	// Canary on a more specific pattern, then next to the existing handler
+	require.NoError(t, registry.Route("sensor.reading", "stats_canary", "stats"))
+	require.NoError(t, registry.Dispatch(context.Background(), testutil.Event().Type("sensor.reading").Build()))
+	assert.Equal(t, []string{"stats"}, calls)
+
+	require.NoError(t, registry.Unregister("stats_canary"))
+	require.NoError(t, registry.Route("sensor.", "stats_canary", "stats"))
+	calls = nil
+	require.NoError(t, registry.Dispatch(context.Background(), testutil.Event().Type("sensor.reading").Build()))
+	assert.Equal(t, []string{"sensor", "stats"}, calls)
+	assert.Equal(t, []HandlerRoute{
+		{Pattern: "sensor.", Handler: "sensor"},
+		{Pattern: "sensor.", Handler: "stats_canary", Kind: "stats"},
+	}, registry.Routes())
+
+	assert.ErrorIs(t, registry.Route("user.", "user", "billing"), ErrUnknownHandlerKind)
+	assert.ErrorIs(t, registry.Route("user.", "sensor", "stats"), ErrHandlerExists)
+	assert.ErrorIs(t, registry.Route("sensor*x", "x", "stats"), ErrPatternConflict)
+	assert.ErrorIs(t, registry.Unregister("user"), ErrHandlerNotFound)
+
+	require.NoError(t, registry.Unregister("sensor"))
+	require.NoError(t, registry.Unregister("stats_canary"))
+	assert.False(t, registry.Handles("sensor.reading"))
+	assert.Empty(t, registry.Routes())
+}
+
+func TestRegistry_ConcurrentChanges(t *testing.T) {
+	noop := &mockEventHandler{HandleFn: func(ctx context.Context, event *events.Envelope) error { return nil }}
+	registry := NewHandlerRegistry(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	registry.Register("sensor.", "sensor", noop)
+	registry.SetCatalog(map[string]EventHandler{"noop": noop})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			name := fmt.Sprintf("canary-%d", i)
+			assert.NoError(t, registry.Route("sensor.", name, "noop"))
+			assert.NoError(t, registry.Unregister(name))
+		}
+	}()
+	for range 100 {
+		assert.NoError(t, registry.Dispatch(context.Background(), testutil.Event().Type("sensor.reading").Build()))
+	}
+	wg.Wait()
+	assert.Len(t, registry.Routes(), 1)
+}
+
+func TestRegistry_Overlapping(t *testing.T) {
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", &mockEventHandler{})
+	registry.Register("sensor.", "sensor_stats", &mockEventHandler{})
+	registry.Register("user.", "user", &mockEventHandler{})
+
+	assert.Empty(t, registry.Overlapping("sensor.*"), "the same pattern")
+	assert.Empty(t, registry.Overlapping("billing."))
+	assert.Equal(t, []string{"sensor", "sensor_stats"}, registry.Overlapping("sensor.reading"))
+	assert.Equal(t, []string{"sensor", "sensor_stats", "user"}, registry.Overlapping("*"))
+}
+
+func TestRegister_DuplicateName(t *testing.T) {
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", &mockEventHandler{})
+	assert.Panics(t, func() { registry.Register("user.", "sensor", &mockEventHandler{}) })
+}
+
 func TestSensorHandler_Success(t *testing.T) {
 	var capturedType, capturedAggID string
 	mock := &mockProjectionWriter{
@@ -72,7 +257,7 @@ func TestSensorHandler_Success(t *testing.T) {
 	}
 
 	handler := NewSensorHandler(mock, slog.Default())
-	err := handler.Handle(context.Background(), newTestEnvelope("sensor.reading"))
+	err := handler.Handle(context.Background(), testutil.Event().Type("sensor.reading").Build())
 
 	require.NoError(t, err)
 	assert.Equal(t, "sensor_state", capturedType)
@@ -87,7 +272,44 @@ func TestSensorHandler_StoreError(t *testing.T) {
 	}
 
 	handler := NewSensorHandler(mock, slog.Default())
-	err := handler.Handle(context.Background(), newTestEnvelope("sensor.reading"))
+	err := handler.Handle(context.Background(), testutil.Event().Type("sensor.reading").Build())
+	assert.Error(t, err)
+}
+
+func TestSensorHandler_Tombstone(t *testing.T) {
+	var deletedType, deletedAggID string
+	writer := &mockProjectionWriter{
+		WriteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+			t.Fatal("a tombstone must not be written as an update")
+			return nil
+		},
+	}
+	deleter := &mockProjectionDeleter{
+		DeleteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+			deletedType = projType
+			deletedAggID = aggregateID
+			return nil
+		},
+	}
+
+	handler := NewSensorHandler(writer, slog.Default())
+	handler.SetDeleter(deleter)
+	err := handler.Handle(context.Background(), testutil.Event().Type("sensor.decommissioned").Build())
+
+	require.NoError(t, err)
+	assert.Equal(t, "sensor_state", deletedType)
+	assert.Equal(t, "device-001", deletedAggID)
+}
+
+func TestSensorHandler_TombstoneError(t *testing.T) {
+	handler := NewSensorHandler(&mockProjectionWriter{}, slog.Default())
+	handler.SetDeleter(&mockProjectionDeleter{
+		DeleteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+			return fmt.Errorf("db error")
+		},
+	})
+
+	err := handler.Handle(context.Background(), testutil.Event().Type("sensor.decommissioned").Build())
 	assert.Error(t, err)
 }
 
@@ -101,12 +323,32 @@ func TestUserHandler_Success(t *testing.T) {
 	}
 
 	handler := NewUserHandler(mock, slog.Default())
-	err := handler.Handle(context.Background(), newTestEnvelope("user.login"))
+	err := handler.Handle(context.Background(), testutil.Event().Type("user.login").Build())
 
 	require.NoError(t, err)
 	assert.Equal(t, "user_session", capturedType)
 }
 
+func TestUserHandler_Deleted(t *testing.T) {
+	var deletedType string
+	writer := &mockProjectionWriter{
+		WriteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+			t.Fatal("user.deleted must not be written as an update")
+			return nil
+		},
+	}
+	handler := NewUserHandler(writer, slog.Default())
+	handler.SetDeleter(&mockProjectionDeleter{
+		DeleteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+			deletedType = projType
+			return nil
+		},
+	})
+
+	require.NoError(t, handler.Handle(context.Background(), testutil.Event().Type("user.deleted").Build()))
+	assert.Equal(t, "user_session", deletedType)
+}
+
 func TestUserHandler_StoreError(t *testing.T) {
 	mock := &mockProjectionWriter{
 		WriteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
@@ -115,6 +357,84 @@ func TestUserHandler_StoreError(t *testing.T) {
 	}
 
 	handler := NewUserHandler(mock, slog.Default())
-	err := handler.Handle(context.Background(), newTestEnvelope("user.login"))
+	err := handler.Handle(context.Background(), testutil.Event().Type("user.login").Build())
 	assert.Error(t, err)
 }
+
+func TestHandlers_TestTrafficNamespace(t *testing.T) {
+	var capturedTypes []string
+	mock := &mockProjectionWriter{
+		WriteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+			capturedTypes = append(capturedTypes, projType)
+			return nil
+		},
+	}
+
+	sensor := testutil.Event().Type("sensor.reading").Build()
+	sensor.Metadata.Test = true
+	user := testutil.Event().Type("user.login").Build()
+	user.Metadata.Test = true
+
+	require.NoError(t, NewSensorHandler(mock, slog.Default()).Handle(context.Background(), sensor))
+	require.NoError(t, NewUserHandler(mock, slog.Default()).Handle(context.Background(), user))
+
+	assert.Equal(t, []string{"test.sensor_state", "test.user_session"}, capturedTypes)
+}
+
+func TestHandles(t *testing.T) {
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", &mockEventHandler{})
+
+	assert.True(t, registry.Handles("sensor.reading"))
+	assert.False(t, registry.Handles("billing.invoice"))
+}
+
+func TestDispatch_FrozenAggregateSkipped(t *testing.T) {
+	var handled int
+	mock := &mockEventHandler{
+		HandleFn: func(ctx context.Context, event *events.Envelope) error {
+			handled++
+			return nil
+		},
+	}
+	store := projections.NewMemoryStore()
+	require.NoError(t, store.FreezeAggregate(context.Background(), "device-001", "incident"))
+
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", mock)
+	registry.SetFreezeChecker(store)
+
+	require.NoError(t, registry.Dispatch(context.Background(), testutil.Event().Type("sensor.reading").Build()))
+	assert.Equal(t, 0, handled, "frozen aggregate must not reach the handler")
+
+	require.NoError(t, store.UnfreezeAggregate(context.Background(), "device-001"))
+	require.NoError(t, registry.Dispatch(context.Background(), testutil.Event().Type("sensor.reading").Build()))
+	assert.Equal(t, 1, handled)
+}
+
+func TestDispatchTo(t *testing.T) {
+	var got []string
+	handler := func(name string) EventHandler {
+		return &mockEventHandler{
+			HandleFn: func(ctx context.Context, event *events.Envelope) error {
+				got = append(got, name)
+				return nil
+			},
+		}
+	}
+
+	registry := NewHandlerRegistry(slog.Default())
+	registry.Register("sensor.", "sensor", handler("sensor"))
+	registry.Register("sensor.", "sensor_v2", handler("sensor_v2"))
+	registry.Register("sensor.alert.", "alert", handler("alert"))
+
+	require.NoError(t, registry.DispatchTo(context.Background(), "sensor_v2", testutil.Event().Type("sensor.reading").Build()))
+	assert.Equal(t, []string{"sensor_v2"}, got, "only the named handler runs")
+
+	got = nil
+	require.NoError(t, registry.DispatchTo(context.Background(), "sensor_v2", testutil.Event().Type("sensor.alert.low").Build()))
+	assert.Empty(t, got, "events routed to a more specific pattern are skipped")
+
+	err := registry.DispatchTo(context.Background(), "billing", testutil.Event().Type("sensor.reading").Build())
+	assert.ErrorIs(t, err, ErrHandlerNotFound)
+}
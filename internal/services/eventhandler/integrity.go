@@ -0,0 +1,155 @@
+package eventhandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// VerifierConfig holds configuration for the projection integrity verifier.
+type VerifierConfig struct {
+	Interval time.Duration // time between background runs
+	Limit    int           // max discrepancies reported (and rebuilt) per run
+	Rebuild  bool          // rebuild corrupted rows from event history
+}
+
+// IntegrityReport is the outcome of one verification run.
+type IntegrityReport struct {
+	CheckedAt     time.Time                 `json:"checked_at"`
+	Discrepancies []projections.Discrepancy `json:"discrepancies"`
+	Rebuilt       int                       `json:"rebuilt"`
+	RebuildErrors int                       `json:"rebuild_errors"`
+}
+
+// Verifier periodically checks stored projection state against its write-time
+// checksum and optionally rebuilds corrupted rows from the latest event.
+type Verifier struct {
+	auditor ProjectionAuditor
+	events  EventReader // nil disables rebuilds
+	config  VerifierConfig
+	logger  *slog.Logger
+
+	runMu sync.Mutex // serializes background and on-demand runs
+
+	mu   sync.RWMutex
+	last *IntegrityReport
+}
+
+// NewVerifier creates a projection integrity verifier.
+// eventReader is optional; without it discrepancies are only reported.
+func NewVerifier(auditor ProjectionAuditor, eventReader EventReader, config VerifierConfig, logger *slog.Logger) *Verifier {
+	if config.Limit <= 0 {
+		config.Limit = 100
+	}
+	return &Verifier{
+		auditor: auditor,
+		events:  eventReader,
+		config:  config,
+		logger:  logger.With("component", "projection-verifier"),
+	}
+}
+
+// Run verifies projections every Interval until ctx is cancelled.
+func (v *Verifier) Run(ctx context.Context) {
+	v.logger.Info("starting projection verifier",
+		"interval", v.config.Interval,
+		"rebuild", v.rebuildEnabled(),
+	)
+
+	ticker := clock.NewTicker(v.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if _, err := v.Verify(ctx); err != nil {
+				v.logger.Error("projection verification failed", "error", err)
+			}
+		}
+	}
+}
+
+// Verify runs one verification pass, rebuilding corrupted rows if enabled.
+func (v *Verifier) Verify(ctx context.Context) (*IntegrityReport, error) {
+	v.runMu.Lock()
+	defer v.runMu.Unlock()
+
+	found, err := v.auditor.FindCorrupt(ctx, v.config.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IntegrityReport{
+		CheckedAt:     clock.Now(),
+		Discrepancies: found,
+	}
+
+	for _, d := range found {
+		v.logger.Warn("projection checksum mismatch",
+			"projection_type", d.ProjectionType,
+			"aggregate_id", d.AggregateID,
+			"last_event_id", d.LastEventID,
+		)
+		if !v.rebuildEnabled() {
+			continue
+		}
+		if err := v.rebuild(ctx, d); err != nil {
+			report.RebuildErrors++
+			v.logger.Error("failed to rebuild projection",
+				"projection_type", d.ProjectionType,
+				"aggregate_id", d.AggregateID,
+				"error", err,
+			)
+			continue
+		}
+		report.Rebuilt++
+	}
+
+	v.mu.Lock()
+	v.last = report
+	v.mu.Unlock()
+
+	return report, nil
+}
+
+// LastReport returns the most recent report, or nil if no run has completed.
+func (v *Verifier) LastReport() *IntegrityReport {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.last
+}
+
+func (v *Verifier) rebuildEnabled() bool {
+	return v.config.Rebuild && v.events != nil
+}
+
+// rebuild re-folds a projection from the aggregate's newest source event.
+// For newest-wins types the newest event's payload is the state; types that
+// fold every event (see projections.RegisterMerge) cannot be rebuilt this way.
+func (v *Verifier) rebuild(ctx context.Context, d projections.Discrepancy) error {
+	if projections.MergeFor(d.ProjectionType) != nil {
+		return fmt.Errorf("projection type %q folds every event and cannot be rebuilt from the newest one", d.ProjectionType)
+	}
+	prefix, ok := projections.Sources[projections.BaseType(d.ProjectionType)]
+	if !ok {
+		return fmt.Errorf("no event source for projection type %q", d.ProjectionType)
+	}
+
+	event, err := v.events.GetLatest(ctx, prefix, d.AggregateID)
+	if err != nil {
+		return err
+	}
+	// Test and real traffic share aggregate IDs; never cross-fill namespaces
+	if event.Metadata.Test != projections.IsTestType(d.ProjectionType) {
+		return fmt.Errorf("latest %s event for %s is from the other namespace", prefix, d.AggregateID)
+	}
+
+	return v.auditor.RepairProjection(ctx, d.ProjectionType, d.AggregateID, event.Payload, event)
+}
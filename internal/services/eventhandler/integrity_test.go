@@ -0,0 +1,123 @@
+package eventhandler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+func corruptAuditor(found []projections.Discrepancy, repaired *[]string) *mockProjectionAuditor {
+	return &mockProjectionAuditor{
+		FindCorruptFn: func(ctx context.Context, limit int) ([]projections.Discrepancy, error) {
+			return found, nil
+		},
+		RepairProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+			*repaired = append(*repaired, projType+"/"+aggregateID+"="+string(state))
+			return nil
+		},
+	}
+}
+
+func TestVerifier_ReportOnly(t *testing.T) {
+	var repaired []string
+	found := []projections.Discrepancy{{ProjectionType: "sensor_state", AggregateID: "device-001"}}
+	v := NewVerifier(corruptAuditor(found, &repaired), nil, VerifierConfig{Rebuild: true}, slog.Default())
+
+	assert.Nil(t, v.LastReport())
+
+	report, err := v.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, report.Discrepancies, 1)
+	assert.Equal(t, 0, report.Rebuilt)
+	assert.Empty(t, repaired, "rebuild needs an event reader")
+	assert.Same(t, report, v.LastReport())
+}
+
+func TestVerifier_Rebuild(t *testing.T) {
+	var repaired []string
+	found := []projections.Discrepancy{
+		{ProjectionType: "sensor_state", AggregateID: "device-001"},
+		{ProjectionType: "user_session", AggregateID: "user-404"},
+		{ProjectionType: "unknown", AggregateID: "x"},
+	}
+	reader := &mockEventReader{
+		GetLatestFn: func(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+			if aggregateID == "user-404" {
+				return nil, errors.New("not found")
+			}
+			assert.Equal(t, "sensor.", eventTypePrefix)
+			return &events.Envelope{AggregateID: aggregateID, Payload: []byte(`{"value":1}`)}, nil
+		},
+	}
+	v := NewVerifier(corruptAuditor(found, &repaired), reader, VerifierConfig{Rebuild: true}, slog.Default())
+
+	report, err := v.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Rebuilt)
+	assert.Equal(t, 2, report.RebuildErrors)
+	assert.Equal(t, []string{`sensor_state/device-001={"value":1}`}, repaired)
+}
+
+func TestVerifier_RebuildTestNamespace(t *testing.T) {
+	var repaired []string
+	found := []projections.Discrepancy{
+		{ProjectionType: "test.sensor_state", AggregateID: "device-001"},
+		{ProjectionType: "test.sensor_state", AggregateID: "device-002"},
+	}
+	reader := &mockEventReader{
+		GetLatestFn: func(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+			assert.Equal(t, "sensor.", eventTypePrefix)
+			// device-002's newest event is real traffic
+			test := aggregateID == "device-001"
+			return &events.Envelope{AggregateID: aggregateID, Payload: []byte(`{"value":1}`), Metadata: events.Metadata{Test: test}}, nil
+		},
+	}
+	v := NewVerifier(corruptAuditor(found, &repaired), reader, VerifierConfig{Rebuild: true}, slog.Default())
+
+	report, err := v.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Rebuilt)
+	assert.Equal(t, 1, report.RebuildErrors, "real events must not rebuild test projections")
+	assert.Equal(t, []string{`test.sensor_state/device-001={"value":1}`}, repaired)
+}
+
+func TestVerifier_RebuildSkipsFoldedTypes(t *testing.T) {
+	projections.RegisterMerge("sensor_state", map[string]projections.FieldOp{"count": projections.Sum})
+	t.Cleanup(func() { projections.RegisterMerge("sensor_state", nil) })
+
+	var repaired []string
+	found := []projections.Discrepancy{{ProjectionType: "sensor_state", AggregateID: "device-001"}}
+	reader := &mockEventReader{
+		GetLatestFn: func(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+			t.Fatal("folded projections are not rebuilt from the newest event")
+			return nil, nil
+		},
+	}
+	v := NewVerifier(corruptAuditor(found, &repaired), reader, VerifierConfig{Rebuild: true}, slog.Default())
+
+	report, err := v.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.RebuildErrors)
+	assert.Empty(t, repaired)
+}
+
+func TestVerifier_FindError(t *testing.T) {
+	auditor := &mockProjectionAuditor{
+		FindCorruptFn: func(ctx context.Context, limit int) ([]projections.Discrepancy, error) {
+			assert.Equal(t, 100, limit, "default limit")
+			return nil, errors.New("db down")
+		},
+	}
+	v := NewVerifier(auditor, nil, VerifierConfig{}, slog.Default())
+
+	_, err := v.Verify(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, v.LastReport())
+}
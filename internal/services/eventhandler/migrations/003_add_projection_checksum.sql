@@ -0,0 +1,12 @@
+-- +goose Up
+-- Integrity checksum for projection state.
+-- Computed by Postgres from the canonical jsonb text form, so it is stable
+-- regardless of how the writer formatted the JSON. The background verifier
+-- recomputes it and reports rows whose state no longer matches (bit rot,
+-- manual edits that bypass the writer).
+ALTER TABLE projections ADD COLUMN IF NOT EXISTS state_checksum TEXT;
+
+-- Backfill existing rows
+UPDATE projections
+SET state_checksum = encode(sha256(convert_to(state::text, 'UTF8')), 'hex')
+WHERE state_checksum IS NULL;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Per-aggregate administrative flags, consulted by the event handler before
+-- applying an event. A frozen aggregate's events are still stored by ingestion
+-- but do not update projections (incident response for compromised devices).
+
+CREATE TABLE IF NOT EXISTS aggregate_flags (
+    aggregate_id VARCHAR(255) PRIMARY KEY,
+    frozen BOOLEAN NOT NULL DEFAULT FALSE,
+    frozen_reason TEXT NOT NULL DEFAULT '',
+    frozen_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for listing frozen aggregates
+CREATE INDEX IF NOT EXISTS idx_aggregate_flags_frozen ON aggregate_flags (aggregate_id) WHERE frozen;
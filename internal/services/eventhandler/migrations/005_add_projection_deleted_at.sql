@@ -0,0 +1,11 @@
+-- +goose Up
+-- Soft delete for projections.
+-- Set when a tombstone event (e.g. sensor.decommissioned) is applied, or when
+-- the TTL sweeper expires a projection that has not been updated within its
+-- window. Deleted rows are kept for audit and excluded from queries by default;
+-- a newer event for the aggregate clears the flag again.
+ALTER TABLE projections ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Index for the TTL sweep (live rows of a type by last update)
+CREATE INDEX IF NOT EXISTS idx_projections_live_updated_at
+    ON projections (projection_type, updated_at) WHERE deleted_at IS NULL;
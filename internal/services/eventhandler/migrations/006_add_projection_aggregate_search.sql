@@ -0,0 +1,8 @@
+-- +goose Up
+-- Trigram index for substring search over aggregate IDs
+-- (GET /api/v1/projections/{type}?q=). A btree index cannot serve
+-- ILIKE '%term%'; gin_trgm_ops can, for terms of three or more characters.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_projections_aggregate_id_trgm
+    ON projections USING gin (aggregate_id gin_trgm_ops);
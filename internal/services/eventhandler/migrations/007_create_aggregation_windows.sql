@@ -0,0 +1,24 @@
+-- +goose Up
+-- State of windowed aggregations (e.g. 5-minute averages per device). Open
+-- windows are folded as events arrive and deleted once the aggregate's
+-- watermark passes their end, when their result is written as a projection.
+
+CREATE TABLE IF NOT EXISTS aggregation_windows (
+    aggregation VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    window_end TIMESTAMPTZ NOT NULL,
+    state JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (aggregation, aggregate_id, window_start)
+);
+
+-- Latest event time seen per aggregate; the watermark trails it by the
+-- aggregation's allowed lateness
+CREATE TABLE IF NOT EXISTS aggregation_watermarks (
+    aggregation VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    max_event_time TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (aggregation, aggregate_id)
+);
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Poison-event tracking on the DLQ. An event's first failed dispatch creates a
+-- 'retrying' row whose retry_count counts failed attempts across restarts; at
+-- the consumer's attempt limit the row becomes 'pending' (quarantined) and the
+-- consumer skips the event. Topic, partition and offset locate the record.
+
+ALTER TABLE dlq ADD COLUMN IF NOT EXISTS topic VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE dlq ADD COLUMN IF NOT EXISTS kafka_partition INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE dlq ADD COLUMN IF NOT EXISTS kafka_offset BIGINT NOT NULL DEFAULT 0;
+
+-- status: 'retrying', 'pending', 'replayed', 'discarded'
+ALTER TABLE dlq DROP CONSTRAINT IF EXISTS dlq_status_check;
+ALTER TABLE dlq ADD CONSTRAINT dlq_status_check CHECK (status IN ('retrying', 'pending', 'replayed', 'discarded'));
+
+-- One row per event and consumer, so attempts accumulate
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dlq_consumer_event ON dlq (consumer, event_id);
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Projection type aliases. Reads of an aliased type are served from the
+-- target type, so a shadow projection built under a new name (e.g.
+-- sensor_state_v2) replaces the live one in a single-row update (cutover).
+
+CREATE TABLE IF NOT EXISTS projection_aliases (
+    alias VARCHAR(255) PRIMARY KEY,
+    projection_type VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
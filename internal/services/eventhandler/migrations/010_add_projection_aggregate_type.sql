@@ -0,0 +1,8 @@
+-- +goose Up
+-- Aggregate type of each projection's aggregate, from the newest event that
+-- carried one, so an aggregate's projections can be filtered by type
+-- (GET /api/v1/aggregates/{id}/projections?aggregate_type=).
+
+ALTER TABLE projections ADD COLUMN IF NOT EXISTS aggregate_type VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_projections_aggregate_type ON projections (aggregate_id, aggregate_type);
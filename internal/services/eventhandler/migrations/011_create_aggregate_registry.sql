@@ -0,0 +1,18 @@
+-- +goose Up
+-- Registry of known aggregates and their static attributes (site, model,
+-- owner, tags), managed through /admin/v1/registry. Joined into query
+-- responses and read by rollup groups and rule conditions on registry fields.
+
+CREATE TABLE IF NOT EXISTS aggregate_registry (
+    aggregate_id VARCHAR(255) PRIMARY KEY,
+    aggregate_type VARCHAR(64) NOT NULL DEFAULT '',
+    site VARCHAR(255) NOT NULL DEFAULT '',
+    model VARCHAR(255) NOT NULL DEFAULT '',
+    owner VARCHAR(255) NOT NULL DEFAULT '',
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_aggregate_registry_site ON aggregate_registry (site);
+CREATE INDEX IF NOT EXISTS idx_aggregate_registry_tags ON aggregate_registry USING GIN (tags);
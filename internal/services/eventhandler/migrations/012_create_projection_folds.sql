@@ -0,0 +1,12 @@
+-- +goose Up
+-- Events folded into merged projections (see projections.RegisterMerge), one
+-- row per projection and event. A fold inserts its row first and is skipped if
+-- the row exists, so a redelivered or retried event is not counted twice.
+
+CREATE TABLE IF NOT EXISTS projection_folds (
+    projection_type VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    event_id UUID NOT NULL,
+    folded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (projection_type, aggregate_id, event_id)
+);
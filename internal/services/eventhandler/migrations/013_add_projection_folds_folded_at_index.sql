@@ -0,0 +1,5 @@
+-- +goose Up
+-- Fold records are kept only as long as their events can be redelivered: the
+-- TTL sweep purges those older than CJ_PROJECTION_FOLD_RETENTION by folded_at.
+
+CREATE INDEX IF NOT EXISTS idx_projection_folds_folded_at ON projection_folds (folded_at);
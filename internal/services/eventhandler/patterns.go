@@ -0,0 +1,76 @@
+package eventhandler
+
+import "strings"
+
+// Event type patterns select the events a handler receives. A pattern is
+// either a prefix ("sensor.") or a glob where * matches any run of
+// characters, dots included ("sensor.*.deleted", "*.deleted"). A prefix p is
+// the glob p*.
+//
+// When several patterns match an event type, the most specific one wins:
+// the one with the most literal (non-*) characters. For prefixes this is
+// longest-prefix matching. Two patterns that tie and can match the same
+// event type conflict, since neither would win deterministically.
+
+// glob returns the pattern in glob form.
+func glob(pattern string) string {
+	if strings.Contains(pattern, "*") {
+		return pattern
+	}
+	return pattern + "*"
+}
+
+// specificity is the number of literal characters in a glob.
+func specificity(glob string) int {
+	return len(glob) - strings.Count(glob, "*")
+}
+
+// globMatch reports whether glob matches the whole of s.
+func globMatch(glob, s string) bool {
+	parts := strings.Split(glob, "*")
+	if len(parts) == 1 {
+		return glob == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
+
+// overlap reports whether some string is matched by both globs.
+func overlap(a, b string) bool {
+	// seen[i][j] marks suffixes a[i:], b[j:] already known not to overlap
+	seen := make([][]bool, len(a)+1)
+	for i := range seen {
+		seen[i] = make([]bool, len(b)+1)
+	}
+	var walk func(i, j int) bool
+	walk = func(i, j int) bool {
+		if seen[i][j] {
+			return false
+		}
+		seen[i][j] = true
+		switch {
+		case i == len(a) && j == len(b):
+			return true
+		case i < len(a) && a[i] == '*':
+			// The star matches nothing, or absorbs b's next character or star
+			return walk(i+1, j) || (j < len(b) && walk(i, j+1))
+		case j < len(b) && b[j] == '*':
+			return walk(i, j+1) || (i < len(a) && walk(i+1, j))
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			return walk(i+1, j+1)
+		}
+		return false
+	}
+	return walk(0, 0)
+}
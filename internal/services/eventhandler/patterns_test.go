@@ -0,0 +1,54 @@
+package eventhandler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		eventType string
+		want      bool
+	}{
+		{"sensor.", "sensor.reading", true},
+		{"sensor.", "sensors.reading", false},
+		{"sensor.*", "sensor.alert.high", true},
+		{"*.deleted", "sensor.deleted", true},
+		{"*.deleted", "sensor.deleted.v2", false},
+		{"sensor.*.high", "sensor.alert.high", true},
+		{"sensor.*.high", "sensor.high", false},
+		{"a*a", "a", false},
+		{"a*a", "aa", true},
+		{"*", "anything", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.eventType, func(t *testing.T) {
+			assert.Equal(t, tt.want, globMatch(glob(tt.pattern), tt.eventType))
+		})
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"sensor.*", "*.deleted", true},
+		{"sensor.*", "user.*", false},
+		{"sensor.*.high", "sensor.alert.*", true},
+		{"*.deleted", "*.created", false},
+		{"sensor.reading", "sensor.*", true},
+		{"sensor.reading", "sensor.alert", false},
+		{"a*b", "b*a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, overlap(tt.a, tt.b))
+			assert.Equal(t, tt.want, overlap(tt.b, tt.a))
+		})
+	}
+}
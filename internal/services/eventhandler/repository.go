@@ -2,8 +2,12 @@ package eventhandler
 
 import (
 	"context"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
 )
 
 // ProjectionWriter writes projections to the store.
@@ -13,6 +17,74 @@ type ProjectionWriter interface {
 	WriteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error
 }
 
+// ProjectionDeleter soft-deletes projections for tombstone events.
+// This interface is satisfied by shared/projections stores.
+type ProjectionDeleter interface {
+	// DeleteProjection marks a projection deleted, only if the event is newer.
+	DeleteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error
+}
+
+// ProjectionExpirer soft-deletes projections that have gone stale.
+// This interface is satisfied by shared/projections stores.
+type ProjectionExpirer interface {
+	// ExpireProjections marks live projections of projType last updated before
+	// cutoff deleted, returning how many were expired.
+	ExpireProjections(ctx context.Context, projType string, cutoff time.Time) (int, error)
+
+	// PurgeFolds deletes the records of events folded into merged projections
+	// before cutoff, returning how many were deleted.
+	PurgeFolds(ctx context.Context, cutoff time.Time) (int, error)
+}
+
+// ProjectionReader reads the current projection, for handlers that derive
+// state from the previous value.
+// This interface is satisfied by shared/projections.Store.
+type ProjectionReader interface {
+	// GetProjection retrieves a single projection by type and aggregate ID.
+	GetProjection(ctx context.Context, projType, aggregateID string) (*projections.Projection, error)
+}
+
+// ProjectionAuditor detects and repairs projections whose state no longer
+// matches the checksum recorded at write time.
+// This interface is satisfied by shared/projections stores.
+type ProjectionAuditor interface {
+	// FindCorrupt returns up to limit projections with a checksum mismatch.
+	FindCorrupt(ctx context.Context, limit int) ([]projections.Discrepancy, error)
+
+	// RepairProjection overwrites a projection unconditionally.
+	RepairProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error
+}
+
+// EventReader reads event history for targeted projection rebuilds.
+// This interface is satisfied by infra/postgres.EventStoreRepo.
+type EventReader interface {
+	// GetLatest returns the newest event for the aggregate among event types
+	// starting with eventTypePrefix.
+	GetLatest(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error)
+}
+
+// FreezeChecker reports whether an aggregate is administratively frozen.
+// This interface is satisfied by shared/projections stores.
+type FreezeChecker interface {
+	// IsFrozen reports whether events for the aggregate must not be applied.
+	IsFrozen(ctx context.Context, aggregateID string) (bool, error)
+}
+
+// AggregateFreezer manages the frozen flag of aggregates.
+// This interface is satisfied by shared/projections stores.
+type AggregateFreezer interface {
+	FreezeChecker
+
+	// FreezeAggregate stops projection updates for the aggregate.
+	FreezeAggregate(ctx context.Context, aggregateID, reason string) error
+
+	// UnfreezeAggregate resumes projection updates for the aggregate.
+	UnfreezeAggregate(ctx context.Context, aggregateID string) error
+
+	// ListFrozen returns all frozen aggregates.
+	ListFrozen(ctx context.Context) ([]projections.FrozenAggregate, error)
+}
+
 // EventHandler processes events and updates projections.
 type EventHandler interface {
 	// Handle processes a single event.
@@ -27,3 +99,63 @@ type EventConsumer interface {
 	// Close releases consumer resources.
 	Close() error
 }
+
+// WindowStore persists the open windows of windowed aggregations.
+// This interface is satisfied by shared/projections stores.
+type WindowStore interface {
+	// AdvanceWatermark records eventTime for the aggregate and returns the
+	// latest event time seen for it.
+	AdvanceWatermark(ctx context.Context, aggregation, aggregateID string, eventTime time.Time) (time.Time, error)
+
+	// FoldWindow applies fold to the window starting at start, creating it
+	// if needed.
+	FoldWindow(ctx context.Context, aggregation, aggregateID string, start, end time.Time, fold projections.WindowFold) error
+
+	// ClosedWindows returns the aggregate's windows ending at or before
+	// watermark, oldest first, without removing them.
+	ClosedWindows(ctx context.Context, aggregation, aggregateID string, watermark time.Time) ([]projections.Window, error)
+
+	// DeleteWindows removes the aggregate's windows ending at or before
+	// through.
+	DeleteWindows(ctx context.Context, aggregation, aggregateID string, through time.Time) error
+}
+
+// QuarantineStore counts failed dispatches of events and quarantines those
+// that keep failing.
+// This interface is satisfied by shared/projections stores.
+type QuarantineStore interface {
+	// RecordFailure counts a failed dispatch and returns the number of failed
+	// attempts so far, and whether the event is now quarantined.
+	RecordFailure(ctx context.Context, failure projections.DispatchFailure, maxAttempts int) (int, bool, error)
+
+	// ClearFailure forgets the failed attempts of an event that has since
+	// been dispatched.
+	ClearFailure(ctx context.Context, consumer string, eventID uuid.UUID) error
+
+	// ListQuarantined returns a page of the quarantined events of consumer
+	// (every consumer if empty), most recently failed first, and the total.
+	ListQuarantined(ctx context.Context, consumer string, limit, offset int) ([]projections.QuarantinedEvent, int, error)
+}
+
+// EventLog reads the event store in global sequence order, for backfills.
+// This interface is satisfied by infra/postgres.EventStoreRepo.
+type EventLog interface {
+	// FetchAfter returns up to limit events with global_seq greater than
+	// afterSeq whose event_type matches one of types (exact names, or
+	// prefixes ending in "*"). Empty types matches every event.
+	FetchAfter(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error)
+}
+
+// AliasStore manages projection type aliases, which put shadow projections
+// into service.
+// This interface is satisfied by shared/projections stores.
+type AliasStore interface {
+	// SetAlias serves reads of alias from projType.
+	SetAlias(ctx context.Context, alias, projType string) error
+
+	// RemoveAlias serves alias from its own projections again.
+	RemoveAlias(ctx context.Context, alias string) error
+
+	// ListAliases returns all projection aliases.
+	ListAliases(ctx context.Context) ([]projections.Alias, error)
+}
@@ -0,0 +1,257 @@
+package eventhandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+)
+
+// Rollup periods: how events of a group are bucketed by event time.
+const (
+	PeriodNone = "none" // one bucket per group
+	PeriodHour = "hour"
+	PeriodDay  = "day"
+)
+
+// RollupConfig defines a rollup projection: counts, and optionally the sum,
+// min and max of a numeric field, of the events matching Events, grouped by a
+// payload field (or a field of the aggregate's registry entry) and bucketed
+// by Period.
+type RollupConfig struct {
+	Name   string // projection type
+	Events string // event type pattern, e.g. "sensor." (see patterns.go)
+	Group  string // dot-separated payload path of the grouping value, e.g. "location.site", or a registry field, e.g. "registry.site"
+	Period string // PeriodNone, PeriodHour or PeriodDay
+	Value  string // dot-separated payload path of a numeric value; empty counts only
+}
+
+// ParseRollups parses a comma-separated list of rollups, each
+// "name=events:pattern|group:path|period:p|value:path", such as
+// "site_daily=events:sensor.|group:site|period:day|value:value". events and
+// group are required; period defaults to none. Empty input yields no rollups.
+func ParseRollups(s string) ([]RollupConfig, error) {
+	var rollups []RollupConfig
+	if s == "" {
+		return rollups, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		name, spec, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" || spec == "" {
+			return nil, fmt.Errorf("invalid rollup %q: expected name=events:pattern|group:path|...", entry)
+		}
+		if _, builtin := projections.Sources[name]; builtin || slices.ContainsFunc(rollups, func(r RollupConfig) bool { return r.Name == name }) {
+			return nil, fmt.Errorf("invalid rollup %q: projection type %s is already in use", entry, name)
+		}
+
+		r := RollupConfig{Name: name, Period: PeriodNone}
+		for _, field := range strings.Split(spec, "|") {
+			key, value, _ := strings.Cut(field, ":")
+			switch key {
+			case "events":
+				r.Events = value
+			case "group":
+				r.Group = value
+			case "period":
+				r.Period = value
+			case "value":
+				r.Value = value
+			default:
+				return nil, fmt.Errorf("invalid rollup %q: unknown field %q (expected events, group, period or value)", entry, key)
+			}
+		}
+		switch {
+		case r.Events == "" || r.Group == "":
+			return nil, fmt.Errorf("invalid rollup %q: events and group are required", entry)
+		case r.Period != PeriodNone && r.Period != PeriodHour && r.Period != PeriodDay:
+			return nil, fmt.Errorf("invalid rollup %q: period must be none, hour or day", entry)
+		case slices.Contains(strings.Split(r.Group, "."), "") || (r.Value != "" && slices.Contains(strings.Split(r.Value, "."), "")):
+			return nil, fmt.Errorf("invalid rollup %q: empty path segment", entry)
+		}
+		rollups = append(rollups, r)
+	}
+	return rollups, nil
+}
+
+// usesRegistry reports whether the rollup groups by a registry field.
+func (c RollupConfig) usesRegistry() bool {
+	return strings.HasPrefix(c.Group, registry.FieldPrefix)
+}
+
+// rollupOps folds rollup contributions: counts and sums add up, min and
+// max combine, the group and bucket are the same on both sides.
+var rollupOps = map[string]projections.FieldOp{
+	"count": projections.Sum,
+	"sum":   projections.Sum,
+	"min":   projections.Min,
+	"max":   projections.Max,
+}
+
+// RollupHandler maintains a RollupConfig projection. Each projection is one
+// group and bucket, keyed "group" or "group/bucket start" (RFC 3339), and
+// every event is folded in, late ones included, once its type is registered
+// with rollupOps (see projections.RegisterMerge).
+// Events without the group field are skipped; events without a numeric value
+// are counted only. The store folds each event once, so redelivered and
+// retried events are not counted again.
+//
+// A "registry." group is looked up in the registry entry of the event's
+// aggregate, once per event; events of unregistered aggregates are skipped.
+type RollupHandler struct {
+	store    ProjectionWriter
+	registry registry.Reader // resolves "registry." groups; see SetRegistry
+	config   RollupConfig
+	group    []string
+	value    []string
+	logger   *slog.Logger
+}
+
+// NewRollupHandler creates a rollup handler. The rollup's projection type
+// must be registered with rollupOps, as Start does.
+func NewRollupHandler(store ProjectionWriter, config RollupConfig, logger *slog.Logger) *RollupHandler {
+	h := &RollupHandler{
+		store:  store,
+		config: config,
+		group:  strings.Split(config.Group, "."),
+		logger: logger.With("handler", config.Name),
+	}
+	if config.Value != "" {
+		h.value = strings.Split(config.Value, ".")
+	}
+	return h
+}
+
+// SetRegistry resolves "registry." group paths against reader. Required
+// for rollups grouping by a registry field.
+func (h *RollupHandler) SetRegistry(reader registry.Reader) {
+	h.registry = reader
+}
+
+// rollupState is the contribution of one event to a rollup projection.
+type rollupState struct {
+	Group  string     `json:"group"`
+	Bucket *time.Time `json:"bucket,omitempty"`
+	Count  int64      `json:"count"`
+	Sum    *float64   `json:"sum,omitempty"`
+	Min    *float64   `json:"min,omitempty"`
+	Max    *float64   `json:"max,omitempty"`
+}
+
+// Handle folds the event into its group's rollup projection.
+func (h *RollupHandler) Handle(ctx context.Context, event *events.Envelope) error {
+	var payload any
+	decoder := json.NewDecoder(bytes.NewReader(event.Payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		h.logger.Debug("skipping event without a JSON payload", "event_id", event.EventID)
+		return nil
+	}
+
+	group, ok, err := h.groupOf(ctx, event, payload)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		h.logger.Debug("skipping event without group field", "event_id", event.EventID, "group", h.config.Group)
+		return nil
+	}
+
+	state := rollupState{Group: group, Count: 1}
+	aggregateID := group
+	if bucket, ok := h.bucket(event.EventTime); ok {
+		state.Bucket = &bucket
+		aggregateID = group + "/" + bucket.Format(time.RFC3339)
+	}
+	if h.value != nil {
+		if n, ok := lookup(payload, h.value).(json.Number); ok {
+			if v, err := n.Float64(); err == nil {
+				state.Sum, state.Min, state.Max = &v, &v, &v
+			}
+		}
+	}
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	projType := projections.TypeFor(h.config.Name, event.Metadata.Test)
+	if err := h.store.WriteProjection(ctx, projType, aggregateID, encoded, event); err != nil {
+		h.logger.Error("failed to update rollup projection",
+			"event_id", event.EventID,
+			"aggregate_id", aggregateID,
+			"projection_type", projType,
+			"error", err,
+		)
+		return err
+	}
+	return nil
+}
+
+// groupOf returns the event's group value: from its aggregate's registry
+// entry for a registry group, from the payload otherwise.
+func (h *RollupHandler) groupOf(ctx context.Context, event *events.Envelope, payload any) (string, bool, error) {
+	if !h.config.usesRegistry() {
+		group, ok := groupValue(lookup(payload, h.group))
+		return group, ok, nil
+	}
+	if h.registry == nil {
+		return "", false, nil
+	}
+	entry, err := h.registry.GetAggregate(ctx, event.AggregateID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to look up registry entry: %w", err)
+	}
+	group, ok := groupValue(lookup(entry.Fields(), h.group[1:]))
+	return group, ok, nil
+}
+
+// bucket returns the start of the period containing t, in UTC.
+func (h *RollupHandler) bucket(t time.Time) (time.Time, bool) {
+	switch h.config.Period {
+	case PeriodHour:
+		return t.UTC().Truncate(time.Hour), true
+	case PeriodDay:
+		return t.UTC().Truncate(24 * time.Hour), true
+	}
+	return time.Time{}, false
+}
+
+// lookup returns the value at path in a decoded payload, or nil.
+func lookup(v any, path []string) any {
+	for _, key := range path {
+		object, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = object[key]
+	}
+	return v
+}
+
+// groupValue renders a group field value. Strings, numbers and booleans
+// group; objects, arrays and null do not.
+func groupValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, v != ""
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
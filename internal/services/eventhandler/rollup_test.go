@@ -0,0 +1,146 @@
+package eventhandler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+)
+
+func TestParseRollups(t *testing.T) {
+	rollups, err := ParseRollups("site_daily=events:sensor.|group:location.site|period:day|value:value, types=events:*|group:kind")
+	require.NoError(t, err)
+	assert.Equal(t, []RollupConfig{
+		{Name: "site_daily", Events: "sensor.", Group: "location.site", Period: PeriodDay, Value: "value"},
+		{Name: "types", Events: "*", Group: "kind", Period: PeriodNone},
+	}, rollups)
+
+	rollups, err = ParseRollups("")
+	require.NoError(t, err)
+	assert.Empty(t, rollups)
+}
+
+func TestParseRollups_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"site_daily",
+		"site_daily=",
+		"site_daily=events:sensor.",
+		"site_daily=group:site",
+		"site_daily=events:sensor.|group:site|period:week",
+		"site_daily=events:sensor.|group:site|colour:red",
+		"site_daily=events:sensor.|group:location..site",
+		"sensor_state=events:sensor.|group:site",
+		"a=events:sensor.|group:site,a=events:user.|group:site",
+	} {
+		_, err := ParseRollups(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func rollupEvent(t *testing.T, eventTime time.Time, payload string) *events.Envelope {
+	t.Helper()
+	envelope, err := events.NewEnvelope("sensor.reading", "device-001", json.RawMessage(payload),
+		events.Metadata{Source: "test"}, eventTime)
+	require.NoError(t, err)
+	return envelope
+}
+
+// registerRollup registers a rollup's merge for the test, as Start does.
+func registerRollup(t *testing.T, name string) {
+	t.Helper()
+	projections.RegisterMerge(name, rollupOps)
+	t.Cleanup(func() { projections.RegisterMerge(name, nil) })
+}
+
+func TestRollupHandler(t *testing.T) {
+	store := projections.NewMemoryStore()
+	handler := NewRollupHandler(store, RollupConfig{
+		Name: "site_daily", Events: "sensor.", Group: "location.site", Period: PeriodDay, Value: "value",
+	}, slog.Default())
+	registerRollup(t, "site_daily")
+	ctx := context.Background()
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	for _, e := range []struct {
+		at      time.Duration
+		payload string
+	}{
+		{10 * time.Hour, `{"location": {"site": "north"}, "value": 20}`},
+		{12 * time.Hour, `{"location": {"site": "north"}, "value": 30}`},
+		{9 * time.Hour, `{"location": {"site": "north"}, "value": 10}`}, // late, still folded
+		{11 * time.Hour, `{"location": {"site": "north"}}`},             // counted only
+		{26 * time.Hour, `{"location": {"site": "north"}, "value": 5}`}, // next day
+		{11 * time.Hour, `{"location": {"site": 7}, "value": 1}`},
+		{11 * time.Hour, `{"value": 1}`}, // no group: skipped
+	} {
+		require.NoError(t, handler.Handle(ctx, rollupEvent(t, day.Add(e.at), e.payload)))
+	}
+
+	p, err := store.GetProjection(ctx, "site_daily", "north/2026-10-16T00:00:00Z")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"group": "north", "bucket": "2026-10-16T00:00:00Z", "count": 4, "sum": 60, "min": 10, "max": 30}`, string(p.State))
+
+	p, err = store.GetProjection(ctx, "site_daily", "north/2026-10-17T00:00:00Z")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"group": "north", "bucket": "2026-10-17T00:00:00Z", "count": 1, "sum": 5, "min": 5, "max": 5}`, string(p.State))
+
+	p, err = store.GetProjection(ctx, "site_daily", "7/2026-10-16T00:00:00Z")
+	require.NoError(t, err)
+	assert.Contains(t, string(p.State), `"count":1`)
+
+	list, total, err := store.ListProjections(ctx, "site_daily", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, list, 3)
+}
+
+func TestRollupHandler_CountOnly(t *testing.T) {
+	store := projections.NewMemoryStore()
+	handler := NewRollupHandler(store, RollupConfig{Name: "types", Events: "*", Group: "kind", Period: PeriodNone}, slog.Default())
+	registerRollup(t, "types")
+	ctx := context.Background()
+
+	var event *events.Envelope
+	for range 3 {
+		event = rollupEvent(t, time.Now(), `{"kind": "thermo", "value": 1}`)
+		require.NoError(t, handler.Handle(ctx, event))
+	}
+	require.NoError(t, handler.Handle(ctx, event), "redelivered event is not counted again")
+	p, err := store.GetProjection(ctx, "types", "thermo")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"group": "thermo", "count": 3}`, string(p.State))
+}
+
+func TestRollupHandler_RegistryGroup(t *testing.T) {
+	store := projections.NewMemoryStore()
+	entries := registry.NewMemoryStore()
+	handler := NewRollupHandler(store, RollupConfig{Name: "site_totals", Events: "sensor.", Group: "registry.site", Period: PeriodNone, Value: "value"}, slog.Default())
+	handler.SetRegistry(entries)
+	registerRollup(t, "site_totals")
+	ctx := context.Background()
+
+	_, err := entries.PutAggregate(ctx, &registry.Aggregate{AggregateID: "device-001", Site: "north"})
+	require.NoError(t, err)
+
+	registered := rollupEvent(t, time.Now(), `{"value": 20}`)
+	unregistered := rollupEvent(t, time.Now(), `{"value": 30}`)
+	unregistered.AggregateID = "device-404"
+	require.NoError(t, handler.Handle(ctx, registered))
+	require.NoError(t, handler.Handle(ctx, unregistered)) // skipped
+
+	p, err := store.GetProjection(ctx, "site_totals", "north")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"group": "north", "count": 1, "sum": 20, "min": 20, "max": 20}`, string(p.State))
+
+	_, total, err := store.ListProjections(ctx, "site_totals", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+}
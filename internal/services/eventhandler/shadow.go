@@ -0,0 +1,101 @@
+package eventhandler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// ShadowConfig adds a catalog kind that runs a built-in handler but writes
+// its projection under another name, such as sensor_state_v2. Routed and
+// backfilled (see Backfills) while the live projection keeps serving, the
+// shadow is put into service by aliasing the live type to it.
+type ShadowConfig struct {
+	Name       string // catalog kind of the shadow handler
+	Kind       string // built-in handler it runs, a key of ShadowSources
+	Projection string // projection type it writes
+}
+
+// ShadowSources maps the built-in handler kinds that can be shadowed to the
+// projection type each writes.
+var ShadowSources = map[string]string{
+	"sensor": "sensor_state",
+	"user":   "user_session",
+}
+
+// ParseShadows parses a comma-separated list of shadows, each
+// "name=kind:projection", such as "sensor_v2=sensor:sensor_state_v2".
+// Empty input yields no shadows.
+func ParseShadows(s string) ([]ShadowConfig, error) {
+	var shadows []ShadowConfig
+	if s == "" {
+		return shadows, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		name, spec, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		kind, projection, ok2 := strings.Cut(spec, ":")
+		if !ok || !ok2 || name == "" || projection == "" {
+			return nil, fmt.Errorf("invalid shadow %q: expected name=kind:projection", entry)
+		}
+		if _, ok := ShadowSources[kind]; !ok {
+			return nil, fmt.Errorf("invalid shadow %q: kind must be one of %s", entry, strings.Join(slices.Sorted(maps.Keys(ShadowSources)), ", "))
+		}
+		if _, builtin := projections.Sources[projection]; builtin || projections.IsTestType(projection) {
+			return nil, fmt.Errorf("invalid shadow %q: projection type %s is reserved", entry, projection)
+		}
+		if slices.ContainsFunc(shadows, func(sh ShadowConfig) bool { return sh.Name == name || sh.Projection == projection }) {
+			return nil, fmt.Errorf("invalid shadow %q: name or projection type already used by another shadow", entry)
+		}
+		shadows = append(shadows, ShadowConfig{Name: name, Kind: kind, Projection: projection})
+	}
+	return shadows, nil
+}
+
+// ShadowableStore is the store a ShadowStore wraps: everything the built-in
+// handlers use.
+type ShadowableStore interface {
+	ProjectionWriter
+	ProjectionReader
+	ProjectionDeleter
+}
+
+// ShadowStore renames one projection type on its way to a store, so a
+// handler written for that type builds a shadow of it instead. The test
+// namespace is kept: test.<from> becomes test.<to>.
+type ShadowStore struct {
+	store    ShadowableStore
+	from, to string
+}
+
+// NewShadowStore creates a ShadowStore writing projections of type from to
+// type to in store.
+func NewShadowStore(store ShadowableStore, from, to string) *ShadowStore {
+	return &ShadowStore{store: store, from: from, to: to}
+}
+
+func (s *ShadowStore) rename(projType string) string {
+	if projections.BaseType(projType) != s.from {
+		return projType
+	}
+	return projections.TypeFor(s.to, projections.IsTestType(projType))
+}
+
+// WriteProjection writes the projection under the shadow type.
+func (s *ShadowStore) WriteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	return s.store.WriteProjection(ctx, s.rename(projType), aggregateID, state, event)
+}
+
+// GetProjection reads the projection from the shadow type.
+func (s *ShadowStore) GetProjection(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+	return s.store.GetProjection(ctx, s.rename(projType), aggregateID)
+}
+
+// DeleteProjection deletes the projection from the shadow type.
+func (s *ShadowStore) DeleteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	return s.store.DeleteProjection(ctx, s.rename(projType), aggregateID, state, event)
+}
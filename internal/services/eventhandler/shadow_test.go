@@ -0,0 +1,59 @@
+package eventhandler
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+func TestParseShadows(t *testing.T) {
+	shadows, err := ParseShadows("sensor_v2=sensor:sensor_state_v2, user_v2=user:user_session_v2")
+	require.NoError(t, err)
+	assert.Equal(t, []ShadowConfig{
+		{Name: "sensor_v2", Kind: "sensor", Projection: "sensor_state_v2"},
+		{Name: "user_v2", Kind: "user", Projection: "user_session_v2"},
+	}, shadows)
+
+	shadows, err = ParseShadows("")
+	require.NoError(t, err)
+	assert.Empty(t, shadows)
+
+	for _, bad := range []string{
+		"sensor_v2",
+		"sensor_v2=sensor",
+		"=sensor:sensor_state_v2",
+		"sensor_v2=billing:billing_v2",
+		"sensor_v2=sensor:sensor_state",
+		"sensor_v2=sensor:test.sensor_state_v2",
+		"a=sensor:sensor_state_v2,b=sensor:sensor_state_v2",
+	} {
+		_, err := ParseShadows(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestShadowStore_BuildsShadowProjection(t *testing.T) {
+	ctx := context.Background()
+	store := projections.NewMemoryStore()
+	handler := NewSensorHandler(NewShadowStore(store, "sensor_state", "sensor_state_v2"), slog.Default())
+
+	live := testutil.Event().Type("sensor.reading").Build()
+	test := testutil.Event().Type("sensor.reading").Build()
+	test.Metadata.Test = true
+	require.NoError(t, handler.Handle(ctx, live))
+	require.NoError(t, handler.Handle(ctx, test))
+
+	_, err := store.GetProjection(ctx, "sensor_state", "device-001")
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "the live projection is untouched")
+	_, err = store.GetProjection(ctx, "sensor_state_v2", "device-001")
+	assert.NoError(t, err)
+	_, err = store.GetProjection(ctx, "test.sensor_state_v2", "device-001")
+	assert.NoError(t, err, "test traffic keeps its namespace")
+}
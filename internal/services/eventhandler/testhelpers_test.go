@@ -2,8 +2,10 @@ package eventhandler
 
 import (
 	"context"
+	"time"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
 )
 
 // mockProjectionWriter implements ProjectionWriter for testing.
@@ -15,6 +17,29 @@ func (m *mockProjectionWriter) WriteProjection(ctx context.Context, projType, ag
 	return m.WriteProjectionFn(ctx, projType, aggregateID, state, event)
 }
 
+// mockProjectionDeleter implements ProjectionDeleter for testing.
+type mockProjectionDeleter struct {
+	DeleteProjectionFn func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error
+}
+
+func (m *mockProjectionDeleter) DeleteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	return m.DeleteProjectionFn(ctx, projType, aggregateID, state, event)
+}
+
+// mockProjectionExpirer implements ProjectionExpirer for testing.
+type mockProjectionExpirer struct {
+	ExpireProjectionsFn func(ctx context.Context, projType string, cutoff time.Time) (int, error)
+	PurgeFoldsFn        func(ctx context.Context, cutoff time.Time) (int, error)
+}
+
+func (m *mockProjectionExpirer) ExpireProjections(ctx context.Context, projType string, cutoff time.Time) (int, error) {
+	return m.ExpireProjectionsFn(ctx, projType, cutoff)
+}
+
+func (m *mockProjectionExpirer) PurgeFolds(ctx context.Context, cutoff time.Time) (int, error) {
+	return m.PurgeFoldsFn(ctx, cutoff)
+}
+
 // mockEventHandler implements EventHandler for testing.
 type mockEventHandler struct {
 	HandleFn func(ctx context.Context, event *events.Envelope) error
@@ -23,3 +48,87 @@ type mockEventHandler struct {
 func (m *mockEventHandler) Handle(ctx context.Context, event *events.Envelope) error {
 	return m.HandleFn(ctx, event)
 }
+
+// mockConsumerController implements ConsumerController for testing.
+type mockConsumerController struct {
+	PauseFn     func(topics ...string) error
+	ResumeFn    func(topics ...string) error
+	DrainFn     func(ctx context.Context) error
+	StatusFn    func(ctx context.Context) (*ConsumerStatus, error)
+	FreshnessFn func() Freshness
+}
+
+func (m *mockConsumerController) Pause(topics ...string) error {
+	return m.PauseFn(topics...)
+}
+
+func (m *mockConsumerController) Resume(topics ...string) error {
+	return m.ResumeFn(topics...)
+}
+
+func (m *mockConsumerController) Drain(ctx context.Context) error {
+	return m.DrainFn(ctx)
+}
+
+func (m *mockConsumerController) Status(ctx context.Context) (*ConsumerStatus, error) {
+	return m.StatusFn(ctx)
+}
+
+func (m *mockConsumerController) Freshness() Freshness {
+	return m.FreshnessFn()
+}
+
+// mockIntegrityChecker implements IntegrityChecker for testing.
+type mockIntegrityChecker struct {
+	VerifyFn     func(ctx context.Context) (*IntegrityReport, error)
+	LastReportFn func() *IntegrityReport
+}
+
+func (m *mockIntegrityChecker) Verify(ctx context.Context) (*IntegrityReport, error) {
+	return m.VerifyFn(ctx)
+}
+
+func (m *mockIntegrityChecker) LastReport() *IntegrityReport {
+	return m.LastReportFn()
+}
+
+// mockProjectionAuditor implements ProjectionAuditor for testing.
+type mockProjectionAuditor struct {
+	FindCorruptFn      func(ctx context.Context, limit int) ([]projections.Discrepancy, error)
+	RepairProjectionFn func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error
+}
+
+func (m *mockProjectionAuditor) FindCorrupt(ctx context.Context, limit int) ([]projections.Discrepancy, error) {
+	return m.FindCorruptFn(ctx, limit)
+}
+
+func (m *mockProjectionAuditor) RepairProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	return m.RepairProjectionFn(ctx, projType, aggregateID, state, event)
+}
+
+// mockEventReader implements EventReader for testing.
+type mockEventReader struct {
+	GetLatestFn func(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error)
+}
+
+func (m *mockEventReader) GetLatest(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+	return m.GetLatestFn(ctx, eventTypePrefix, aggregateID)
+}
+
+// mockProjectionReader implements ProjectionReader for testing.
+type mockProjectionReader struct {
+	GetProjectionFn func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error)
+}
+
+func (m *mockProjectionReader) GetProjection(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+	return m.GetProjectionFn(ctx, projType, aggregateID)
+}
+
+// mockEventLog implements EventLog for testing.
+type mockEventLog struct {
+	FetchAfterFn func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error)
+}
+
+func (m *mockEventLog) FetchAfter(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+	return m.FetchAfterFn(ctx, afterSeq, types, limit)
+}
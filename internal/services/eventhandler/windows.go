@@ -0,0 +1,199 @@
+package eventhandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// Aggregator folds events into the state of a time window and computes the
+// window's result when it closes.
+type Aggregator interface {
+	// Add folds event into state, nil for a new window. Returning nil skips
+	// the event.
+	Add(state json.RawMessage, event *events.Envelope) (json.RawMessage, error)
+
+	// Result computes the projection state of a closed window. It must be a
+	// JSON object.
+	Result(state json.RawMessage) (json.RawMessage, error)
+}
+
+// WindowConfig describes a windowed aggregation.
+type WindowConfig struct {
+	Projection string        // projection type closed windows are written to; also names the window state
+	Size       time.Duration // window length; windows are aligned to the Unix epoch
+	Lateness   time.Duration // how long after a window ends events for it are still accepted
+}
+
+// WindowedHandler maintains tumbling time-window aggregates per aggregate,
+// e.g. the 5-minute average value of each sensor. Windows are keyed by event
+// time, not arrival, and persisted in a WindowStore until they close.
+//
+// Each aggregate has a watermark: its latest event time minus Lateness. A
+// window closes once the watermark passes its end. Its result is then written
+// as the aggregate's projection, so the projection always holds the most
+// recent closed window. Events for a closed window are dropped.
+//
+// Watermarks only move with the aggregate's own events: the last window of an
+// aggregate that stops reporting stays open. Redelivered events are folded
+// again. A closed window is removed only after its result is written, so a
+// failed write is retried with the event.
+type WindowedHandler struct {
+	store      WindowStore
+	writer     ProjectionWriter
+	aggregator Aggregator
+	config     WindowConfig
+	logger     *slog.Logger
+}
+
+// NewWindowedHandler creates a windowed aggregation handler.
+func NewWindowedHandler(store WindowStore, writer ProjectionWriter, aggregator Aggregator, config WindowConfig, logger *slog.Logger) *WindowedHandler {
+	return &WindowedHandler{
+		store:      store,
+		writer:     writer,
+		aggregator: aggregator,
+		config:     config,
+		logger:     logger.With("handler", config.Projection),
+	}
+}
+
+// Handle folds the event into its window and emits the windows it closes.
+func (h *WindowedHandler) Handle(ctx context.Context, event *events.Envelope) error {
+	projType := projections.TypeFor(h.config.Projection, event.Metadata.Test)
+
+	latest, err := h.store.AdvanceWatermark(ctx, projType, event.AggregateID, event.EventTime)
+	if err != nil {
+		return err
+	}
+	watermark := latest.Add(-h.config.Lateness)
+
+	start := event.EventTime.Truncate(h.config.Size)
+	end := start.Add(h.config.Size)
+	if !end.After(watermark) {
+		h.logger.Warn("dropping event for closed window",
+			"event_id", event.EventID,
+			"aggregate_id", event.AggregateID,
+			"event_time", event.EventTime,
+			"watermark", watermark,
+		)
+		return nil
+	}
+
+	err = h.store.FoldWindow(ctx, projType, event.AggregateID, start, end, func(state json.RawMessage) (json.RawMessage, error) {
+		return h.aggregator.Add(state, event)
+	})
+	if err != nil {
+		return err
+	}
+
+	closed, err := h.store.ClosedWindows(ctx, projType, event.AggregateID, watermark)
+	if err != nil {
+		return err
+	}
+	if len(closed) == 0 {
+		return nil
+	}
+	// Only the newest closed window is kept in the projection. The windows
+	// are removed once it is written, so a failed write leaves them for the
+	// retry.
+	newest := closed[len(closed)-1]
+	if err := h.emit(ctx, projType, newest, event); err != nil {
+		return err
+	}
+	return h.store.DeleteWindows(ctx, projType, event.AggregateID, newest.End)
+}
+
+// emit writes a closed window's result as the aggregate's projection, with
+// the event that closed it.
+func (h *WindowedHandler) emit(ctx context.Context, projType string, w projections.Window, event *events.Envelope) error {
+	result, err := h.aggregator.Result(w.State)
+	if err != nil {
+		return fmt.Errorf("failed to compute window result: %w", err)
+	}
+	var state map[string]any
+	if err := json.Unmarshal(result, &state); err != nil {
+		return fmt.Errorf("window result is not a JSON object: %w", err)
+	}
+	state["window_start"] = w.Start.UTC()
+	state["window_end"] = w.End.UTC()
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := h.writer.WriteProjection(ctx, projType, event.AggregateID, encoded, event); err != nil {
+		h.logger.Error("failed to write window projection",
+			"event_id", event.EventID,
+			"aggregate_id", event.AggregateID,
+			"projection_type", projType,
+			"window_start", w.Start,
+			"error", err,
+		)
+		return err
+	}
+
+	h.logger.Debug("closed window",
+		"aggregate_id", event.AggregateID,
+		"projection_type", projType,
+		"window_start", w.Start,
+	)
+	return nil
+}
+
+// fieldStats is the window state of FieldStats.
+type fieldStats struct {
+	Count int64   `json:"count"`
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+}
+
+// FieldStats returns an Aggregator computing the count, sum, min, max and
+// average of a numeric payload field. Events without the field are skipped.
+func FieldStats(field string) Aggregator {
+	return fieldStatsAggregator{field: field}
+}
+
+type fieldStatsAggregator struct {
+	field string
+}
+
+func (a fieldStatsAggregator) Add(state json.RawMessage, event *events.Envelope) (json.RawMessage, error) {
+	var payload map[string]json.RawMessage
+	var field *float64
+	if json.Unmarshal(event.Payload, &payload) != nil || json.Unmarshal(payload[a.field], &field) != nil || field == nil {
+		return nil, nil
+	}
+	value := *field
+
+	stats := fieldStats{Min: value, Max: value}
+	if state != nil {
+		if err := json.Unmarshal(state, &stats); err != nil {
+			return nil, fmt.Errorf("invalid window state: %w", err)
+		}
+	}
+	stats.Count++
+	stats.Sum += value
+	stats.Min = min(stats.Min, value)
+	stats.Max = max(stats.Max, value)
+	return json.Marshal(stats)
+}
+
+func (a fieldStatsAggregator) Result(state json.RawMessage) (json.RawMessage, error) {
+	var stats fieldStats
+	if err := json.Unmarshal(state, &stats); err != nil {
+		return nil, fmt.Errorf("invalid window state: %w", err)
+	}
+	return json.Marshal(map[string]any{
+		"count": stats.Count,
+		"sum":   stats.Sum,
+		"min":   stats.Min,
+		"max":   stats.Max,
+		"avg":   stats.Sum / float64(stats.Count),
+	})
+}
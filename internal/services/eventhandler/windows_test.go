@@ -0,0 +1,145 @@
+package eventhandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+func windowEvent(t *testing.T, eventTime time.Time, payload string, test bool) *events.Envelope {
+	t.Helper()
+	envelope, err := events.NewEnvelope("sensor.reading", "device-001", json.RawMessage(payload),
+		events.Metadata{Source: "test", Test: test}, eventTime)
+	require.NoError(t, err)
+	return envelope
+}
+
+func newWindowTest() (*WindowedHandler, *projections.MemoryStore) {
+	store := projections.NewMemoryStore()
+	handler := NewWindowedHandler(store, store, FieldStats("value"), WindowConfig{
+		Projection: "sensor_window",
+		Size:       5 * time.Minute,
+		Lateness:   time.Minute,
+	}, slog.Default())
+	return handler, store
+}
+
+func TestWindowedHandler_ClosesWindows(t *testing.T) {
+	handler, store := newWindowTest()
+	ctx := context.Background()
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	for _, e := range []struct {
+		offset time.Duration
+		value  string
+	}{
+		{1 * time.Minute, "20"},
+		{4 * time.Minute, "30"},
+		{5*time.Minute + 30*time.Second, "99"}, // next window; the first is still within lateness
+		{3 * time.Minute, "10"},                // late, but accepted
+	} {
+		require.NoError(t, handler.Handle(ctx, windowEvent(t, base.Add(e.offset), `{"value": `+e.value+`}`, false)))
+	}
+	_, err := store.GetProjection(ctx, "sensor_window", "device-001")
+	require.Error(t, err, "no window has closed yet")
+
+	// The watermark passes 12:05 and closes the first window
+	closing := windowEvent(t, base.Add(6*time.Minute), `{"value": 50}`, false)
+	require.NoError(t, handler.Handle(ctx, closing))
+
+	p, err := store.GetProjection(ctx, "sensor_window", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"count": 3, "sum": 60, "min": 10, "max": 30, "avg": 20,
+		"window_start": "2026-10-16T12:00:00Z", "window_end": "2026-10-16T12:05:00Z"
+	}`, string(p.State))
+	assert.Equal(t, closing.EventID, p.LastEventID)
+
+	// Too late for the closed window: dropped, the projection is unchanged
+	require.NoError(t, handler.Handle(ctx, windowEvent(t, base.Add(2*time.Minute), `{"value": 1000}`, false)))
+	again, err := store.GetProjection(ctx, "sensor_window", "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, p.State, again.State)
+}
+
+func TestWindowedHandler_EmitsNewestClosedWindow(t *testing.T) {
+	handler, store := newWindowTest()
+	ctx := context.Background()
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, handler.Handle(ctx, windowEvent(t, base, `{"value": 1}`, false)))
+	require.NoError(t, handler.Handle(ctx, windowEvent(t, base.Add(5*time.Minute), `{"value": 2}`, false)))
+	// Closes both windows at once
+	require.NoError(t, handler.Handle(ctx, windowEvent(t, base.Add(time.Hour), `{"value": 3}`, false)))
+
+	p, err := store.GetProjection(ctx, "sensor_window", "device-001")
+	require.NoError(t, err)
+	assert.Contains(t, string(p.State), `"window_start":"2026-10-16T12:05:00Z"`)
+}
+
+func TestWindowedHandler_RetriesFailedWrite(t *testing.T) {
+	store := projections.NewMemoryStore()
+	failures := 1
+	writer := &mockProjectionWriter{
+		WriteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+			if failures > 0 {
+				failures--
+				return errors.New("connection reset by peer")
+			}
+			return store.WriteProjection(ctx, projType, aggregateID, state, event)
+		},
+	}
+	handler := NewWindowedHandler(store, writer, FieldStats("value"), WindowConfig{
+		Projection: "sensor_window",
+		Size:       5 * time.Minute,
+		Lateness:   time.Minute,
+	}, slog.Default())
+	ctx := context.Background()
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, handler.Handle(ctx, windowEvent(t, base, `{"value": 1}`, false)))
+	closing := windowEvent(t, base.Add(time.Hour), `{"value": 2}`, false)
+	require.Error(t, handler.Handle(ctx, closing))
+
+	// The redelivered event emits the window the failed write left behind
+	require.NoError(t, handler.Handle(ctx, closing))
+	p, err := store.GetProjection(ctx, "sensor_window", "device-001")
+	require.NoError(t, err)
+	assert.Contains(t, string(p.State), `"window_start":"2026-10-16T12:00:00Z"`)
+	assert.Contains(t, string(p.State), `"count":1`)
+
+	closed, err := store.ClosedWindows(ctx, "sensor_window", "device-001", base.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, closed, "emitted windows are removed")
+}
+
+func TestWindowedHandler_SkipsAndNamespaces(t *testing.T) {
+	handler, store := newWindowTest()
+	ctx := context.Background()
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, handler.Handle(ctx, windowEvent(t, base, `{"value": 1}`, true)))
+	require.NoError(t, handler.Handle(ctx, windowEvent(t, base.Add(time.Minute), `{"status": "ok"}`, true)))
+	require.NoError(t, handler.Handle(ctx, windowEvent(t, base.Add(2*time.Minute), `{"value": null}`, true)))
+	require.NoError(t, handler.Handle(ctx, windowEvent(t, base.Add(time.Hour), `{}`, true)))
+
+	p, err := store.GetProjection(ctx, "test.sensor_window", "device-001")
+	require.NoError(t, err)
+	assert.Contains(t, string(p.State), `"count":1`)
+	_, err = store.GetProjection(ctx, "sensor_window", "device-001")
+	assert.Error(t, err, "test traffic stays in the test namespace")
+}
+
+func TestFieldStats_InvalidState(t *testing.T) {
+	_, err := FieldStats("value").Add(json.RawMessage(`[]`), windowEvent(t, time.Now(), `{"value": 1}`, false))
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package ingestion
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// BackpressureConfig holds the outbox thresholds above which ingestion
+// sheds load. A zero threshold disables that check; with both zero,
+// backpressure is off.
+type BackpressureConfig struct {
+	MaxDepth int64         // pending outbox entries
+	MaxAge   time.Duration // age of the oldest pending entry
+
+	// ResumeRatio sets where shedding stops: once depth and age are both
+	// below this fraction of their thresholds. The gap keeps the state from
+	// flapping around a single threshold.
+	ResumeRatio float64
+
+	CheckInterval time.Duration // time between backlog checks
+	RetryAfter    time.Duration // Retry-After sent with rejected requests
+	MaxRetries    int           // entries at the worker's retry budget are not counted
+}
+
+// Enabled reports whether any threshold is set.
+func (c BackpressureConfig) Enabled() bool {
+	return c.MaxDepth > 0 || c.MaxAge > 0
+}
+
+// Backpressure tracks whether the outbox worker has fallen so far behind
+// that new events should be refused. Accepted events are only published
+// once the worker catches up; refusing them with 503 and Retry-After lets
+// clients back off instead of assuming delivery is timely. A nil
+// Backpressure never sheds.
+type Backpressure struct {
+	backlog BacklogReader
+	config  BackpressureConfig
+	logger  *slog.Logger
+
+	shedding atomic.Bool
+}
+
+// NewBackpressure creates a Backpressure. Call Run to start checking.
+func NewBackpressure(backlog BacklogReader, config BackpressureConfig, logger *slog.Logger) *Backpressure {
+	return &Backpressure{
+		backlog: backlog,
+		config:  config,
+		logger:  logger.With("component", "backpressure"),
+	}
+}
+
+// Shedding reports whether new events should be refused.
+func (b *Backpressure) Shedding() bool {
+	return b != nil && b.shedding.Load()
+}
+
+// RetryAfter is how long refused clients are asked to wait.
+func (b *Backpressure) RetryAfter() time.Duration {
+	return b.config.RetryAfter
+}
+
+// Run checks the backlog every CheckInterval until ctx is cancelled.
+func (b *Backpressure) Run(ctx context.Context) {
+	b.logger.Info("starting ingestion backpressure",
+		"max_depth", b.config.MaxDepth,
+		"max_age", b.config.MaxAge,
+		"resume_ratio", b.config.ResumeRatio,
+		"interval", b.config.CheckInterval,
+	)
+
+	ticker := clock.NewTicker(b.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		b.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// check measures the backlog and updates the shedding state. When the
+// backlog cannot be measured, the state is kept.
+func (b *Backpressure) check(ctx context.Context) {
+	backlog, err := b.backlog.Backlog(ctx, b.config.MaxDepth, b.config.MaxRetries)
+	if err != nil {
+		if ctx.Err() == nil {
+			b.logger.Warn("failed to measure outbox backlog", "error", err)
+		}
+		return
+	}
+
+	if !b.shedding.Load() {
+		if b.over(backlog.Depth, backlog.OldestAge, 1) {
+			b.shedding.Store(true)
+			b.logger.Warn("outbox backlog over threshold, refusing new events",
+				"depth", backlog.Depth,
+				"oldest_age", backlog.OldestAge,
+			)
+		}
+		return
+	}
+	if !b.over(backlog.Depth, backlog.OldestAge, b.config.ResumeRatio) {
+		b.shedding.Store(false)
+		b.logger.Info("outbox backlog recovered, accepting events again",
+			"depth", backlog.Depth,
+			"oldest_age", backlog.OldestAge,
+		)
+	}
+}
+
+// over reports whether depth or age reaches ratio times its threshold.
+func (b *Backpressure) over(depth int64, age time.Duration, ratio float64) bool {
+	if b.config.MaxDepth > 0 && float64(depth) >= ratio*float64(b.config.MaxDepth) {
+		return true
+	}
+	return b.config.MaxAge > 0 && float64(age) >= ratio*float64(b.config.MaxAge)
+}
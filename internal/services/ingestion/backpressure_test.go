@@ -0,0 +1,174 @@
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cornjacket/platform-services/internal/services/ingestion/worker"
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+func TestBackpressure_Hysteresis(t *testing.T) {
+	var backlog worker.OutboxBacklog
+	var measureErr error
+	reader := &mockBacklogReader{
+		BacklogFn: func(ctx context.Context, limit int64, maxRetries int) (*worker.OutboxBacklog, error) {
+			assert.Equal(t, int64(1000), limit)
+			assert.Equal(t, 5, maxRetries)
+			if measureErr != nil {
+				return nil, measureErr
+			}
+			return &backlog, nil
+		},
+	}
+	bp := NewBackpressure(reader, BackpressureConfig{
+		MaxDepth:    1000,
+		MaxAge:      time.Minute,
+		ResumeRatio: 0.5,
+		MaxRetries:  5,
+	}, slog.Default())
+
+	steps := []struct {
+		name     string
+		depth    int64
+		age      time.Duration
+		err      error
+		shedding bool
+	}{
+		{"below thresholds", 999, 59 * time.Second, nil, false},
+		{"depth reached", 1000, 0, nil, true},
+		{"below threshold, above resume", 600, 0, nil, true},
+		{"measure error keeps state", 0, 0, errors.New("connection refused"), true},
+		{"below resume ratio", 499, 10 * time.Second, nil, false},
+		{"above resume, below threshold", 800, 40 * time.Second, nil, false},
+		{"age reached", 10, time.Minute, nil, true},
+		{"age above resume ratio", 10, 30 * time.Second, nil, true},
+		{"both below resume ratio", 10, 29 * time.Second, nil, false},
+	}
+	for _, step := range steps {
+		backlog = worker.OutboxBacklog{Depth: step.depth, OldestAge: step.age}
+		measureErr = step.err
+		bp.check(context.Background())
+		assert.Equal(t, step.shedding, bp.Shedding(), step.name)
+	}
+}
+
+func TestBackpressure_Run(t *testing.T) {
+	c := clock.NewManualClock(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	clock.Set(c)
+	t.Cleanup(clock.Reset)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Each check asks for its depth; while it waits, the state left by the
+	// previous check is settled
+	requested := make(chan struct{})
+	depths := make(chan int64)
+	bp := NewBackpressure(&mockBacklogReader{
+		BacklogFn: func(ctx context.Context, limit int64, maxRetries int) (*worker.OutboxBacklog, error) {
+			select {
+			case requested <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			select {
+			case depth := <-depths:
+				return &worker.OutboxBacklog{Depth: depth}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}, BackpressureConfig{MaxDepth: 100, ResumeRatio: 0.5, CheckInterval: time.Second}, slog.Default())
+	done := make(chan struct{})
+	go func() {
+		bp.Run(ctx)
+		close(done)
+	}()
+
+	// The first check runs at once, later ones every CheckInterval
+	<-requested
+	depths <- 10
+	c.Add(999 * time.Millisecond)
+	select {
+	case <-requested:
+		t.Fatal("backlog checked before the interval")
+	default:
+	}
+
+	steps := []struct {
+		name     string
+		shedding bool // state before this check
+		depth    int64
+	}{
+		{"below threshold", false, 100},
+		{"depth reached", true, 60},
+		{"above the resume ratio", true, 40},
+		{"below the resume ratio", false, 0},
+	}
+	c.Add(time.Millisecond)
+	for _, step := range steps {
+		<-requested
+		assert.Equal(t, step.shedding, bp.Shedding(), step.name)
+		depths <- step.depth
+		c.Add(time.Second)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestBackpressure_DisabledThreshold(t *testing.T) {
+	bp := NewBackpressure(&mockBacklogReader{
+		BacklogFn: func(ctx context.Context, limit int64, maxRetries int) (*worker.OutboxBacklog, error) {
+			return &worker.OutboxBacklog{Depth: 1_000_000, OldestAge: 2 * time.Minute}, nil
+		},
+	}, BackpressureConfig{MaxAge: time.Hour, ResumeRatio: 0.5}, slog.Default())
+
+	// Depth alone never sheds when MaxDepth is 0
+	bp.check(context.Background())
+	assert.False(t, bp.Shedding())
+}
+
+func TestBackpressure_Nil(t *testing.T) {
+	var bp *Backpressure
+	assert.False(t, bp.Shedding())
+	assert.False(t, BackpressureConfig{ResumeRatio: 0.5}.Enabled())
+}
+
+func TestHandleIngest_Backpressure(t *testing.T) {
+	inserted := false
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			inserted = true
+			return nil
+		},
+	}
+	bp := NewBackpressure(&mockBacklogReader{
+		BacklogFn: func(ctx context.Context, limit int64, maxRetries int) (*worker.OutboxBacklog, error) {
+			return &worker.OutboxBacklog{Depth: 10}, nil
+		},
+	}, BackpressureConfig{MaxDepth: 10, ResumeRatio: 0.5, RetryAfter: 1500 * time.Millisecond}, slog.Default())
+	bp.check(context.Background())
+
+	handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+	handler.SetBackpressure(bp)
+
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{"value":72.5}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	handler.HandleIngest(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.False(t, inserted)
+}
@@ -0,0 +1,82 @@
+package ingestion
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// DedupConfig controls deduplication of re-sent events. Gateways that lose
+// acknowledgements re-send identical readings; within Window, an event with
+// the same content as an accepted one is not stored again.
+type DedupConfig struct {
+	Window time.Duration // 0 disables deduplication
+	Reject bool          // answer duplicates with ErrDuplicate instead of coalescing them
+}
+
+// ErrDuplicate is returned by Service.Ingest, with the original event's
+// response, for a duplicate when DedupConfig.Reject is set.
+var ErrDuplicate = errors.New("duplicate event")
+
+// StatusDuplicate is the IngestResponse status of a coalesced duplicate; its
+// EventID is the original event's.
+const StatusDuplicate = "duplicate"
+
+// contentHash identifies an event by its type, aggregate (ID and type), payload (with its
+// content type and schema version) and event time. Whitespace in the payload does not count; key order does. Test
+// traffic never matches live traffic.
+func contentHash(req *IngestRequest) []byte {
+	h := sha256.New()
+	field := func(b []byte) {
+		_ = binary.Write(h, binary.BigEndian, uint32(len(b)))
+		h.Write(b)
+	}
+
+	var payload bytes.Buffer
+	if err := json.Compact(&payload, req.Payload); err != nil {
+		payload.Reset()
+		payload.Write(req.Payload)
+	}
+
+	field([]byte(req.EventType))
+	field([]byte(req.AggregateID))
+	field(payload.Bytes())
+	field([]byte(req.EventTime.UTC().Format(time.RFC3339Nano)))
+	if req.Test {
+		field([]byte("test"))
+	}
+	if ct := contentType(req.ContentType); ct != "" {
+		field([]byte(ct))
+	}
+	if req.AggregateType != "" {
+		field([]byte("aggregate_type:" + req.AggregateType))
+	}
+	if version := schemaVersion(req.SchemaVersion); version != events.CurrentSchemaVersion {
+		field([]byte("schema_version:" + strconv.Itoa(version)))
+	}
+	return h.Sum(nil)
+}
+
+// idempotencyHash identifies an event by its idempotency key alone. Keys are
+// kept next to content hashes but never match one. Test traffic never
+// matches live traffic.
+func idempotencyHash(req *IngestRequest) []byte {
+	h := sha256.New()
+	field := func(b []byte) {
+		_ = binary.Write(h, binary.BigEndian, uint32(len(b)))
+		h.Write(b)
+	}
+
+	field([]byte("idempotency_key"))
+	field([]byte(req.IdempotencyKey))
+	if req.Test {
+		field([]byte("test"))
+	}
+	return h.Sum(nil)
+}
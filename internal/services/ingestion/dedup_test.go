@@ -0,0 +1,242 @@
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+func TestContentHash(t *testing.T) {
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	base := func() *IngestRequest {
+		return &IngestRequest{
+			EventType:   "sensor.reading",
+			AggregateID: "device-001",
+			Payload:     json.RawMessage(`{"value":72.5}`),
+			EventTime:   &at,
+		}
+	}
+	hash := contentHash(base())
+
+	same := []struct {
+		name   string
+		modify func(*IngestRequest)
+	}{
+		{"payload whitespace", func(r *IngestRequest) { r.Payload = json.RawMessage("{ \"value\" : 72.5 }\n") }},
+		{"event time zone", func(r *IngestRequest) { t := at.In(time.FixedZone("EST", -5*3600)); r.EventTime = &t }},
+		{"trace id", func(r *IngestRequest) { r.TraceID = "abc" }},
+		{"default content type", func(r *IngestRequest) { r.ContentType = "application/json" }},
+	}
+	for _, tt := range same {
+		req := base()
+		tt.modify(req)
+		assert.Equal(t, hash, contentHash(req), tt.name)
+	}
+
+	different := []struct {
+		name   string
+		modify func(*IngestRequest)
+	}{
+		{"event type", func(r *IngestRequest) { r.EventType = "sensor.alert" }},
+		{"aggregate", func(r *IngestRequest) { r.AggregateID = "device-002" }},
+		{"payload", func(r *IngestRequest) { r.Payload = json.RawMessage(`{"value":72.6}`) }},
+		{"event time", func(r *IngestRequest) { t := at.Add(time.Millisecond); r.EventTime = &t }},
+		{"test traffic", func(r *IngestRequest) { r.Test = true }},
+		{"content type", func(r *IngestRequest) { r.ContentType = "application/vnd.acme+json" }},
+		{"aggregate type", func(r *IngestRequest) { r.AggregateType = "device" }},
+		{"field boundaries", func(r *IngestRequest) { r.EventType = "sensor.readingdevice-001"; r.AggregateID = "" }},
+	}
+	for _, tt := range different {
+		req := base()
+		tt.modify(req)
+		assert.NotEqual(t, hash, contentHash(req), tt.name)
+	}
+}
+
+// newDedupService returns a service whose unique inserts report owner as the
+// content's event; an empty owner means the inserted event owns it.
+func newDedupService(owner string, config DedupConfig, calls *int) *Service {
+	svc := NewService(&mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error { return nil },
+	}, slog.Default())
+	svc.SetDedup(&mockUniqueOutboxRepository{
+		InsertUniqueFn: func(ctx context.Context, event *events.Envelope, hash []byte, window time.Duration) (string, error) {
+			*calls++
+			if owner == "" {
+				return event.EventID.String(), nil
+			}
+			return owner, nil
+		},
+	}, config)
+	return svc
+}
+
+func TestService_Dedup(t *testing.T) {
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	const original = "01234567-89ab-cdef-0123-456789abcdef"
+
+	tests := []struct {
+		name       string
+		owner      string
+		config     DedupConfig
+		eventTime  *time.Time
+		wantStatus string
+		wantErr    error
+		wantCalls  int
+	}{
+		{name: "first of its content", config: DedupConfig{Window: time.Minute}, eventTime: &at, wantStatus: "accepted", wantCalls: 1},
+		{name: "duplicate coalesced", owner: original, config: DedupConfig{Window: time.Minute}, eventTime: &at, wantStatus: StatusDuplicate, wantCalls: 1},
+		{name: "duplicate rejected", owner: original, config: DedupConfig{Window: time.Minute, Reject: true}, eventTime: &at, wantStatus: StatusDuplicate, wantErr: ErrDuplicate, wantCalls: 1},
+		{name: "no event time", owner: original, config: DedupConfig{Window: time.Minute}, wantStatus: "accepted"},
+		{name: "zero window", owner: original, eventTime: &at, wantStatus: "accepted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			svc := newDedupService(tt.owner, tt.config, &calls)
+
+			resp, err := svc.Ingest(context.Background(), &IngestRequest{
+				EventType:   "sensor.reading",
+				AggregateID: "device-001",
+				Payload:     json.RawMessage(`{"value":72.5}`),
+				EventTime:   tt.eventTime,
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NotNil(t, resp)
+			assert.Equal(t, tt.wantStatus, resp.Status)
+			if tt.wantStatus == StatusDuplicate {
+				assert.Equal(t, original, resp.EventID)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func TestHandleIngest_Duplicate(t *testing.T) {
+	const original = "01234567-89ab-cdef-0123-456789abcdef"
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","event_time":"2026-10-16T12:00:00Z","payload":{"value":72.5}}`
+
+	tests := []struct {
+		name       string
+		reject     bool
+		wantStatus int
+	}{
+		{name: "coalesced", wantStatus: http.StatusOK},
+		{name: "rejected", reject: true, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			svc := newDedupService(original, DedupConfig{Window: time.Minute, Reject: tt.reject}, &calls)
+			handler := NewHandler(svc, slog.Default())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			handler.HandleIngest(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			var resp map[string]string
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, original, resp["event_id"])
+		})
+	}
+}
+
+func TestContentHash_SchemaVersion(t *testing.T) {
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	req := &IngestRequest{EventType: "sensor.reading", AggregateID: "device-001", EventTime: &at, Payload: json.RawMessage(`{"value":72.5}`)}
+	hash := contentHash(req)
+
+	// The current version is the default, so existing hashes are unchanged
+	req.SchemaVersion = events.CurrentSchemaVersion
+	assert.Equal(t, hash, contentHash(req))
+}
+
+func TestIdempotencyHash(t *testing.T) {
+	req := &IngestRequest{EventType: "sensor.reading", AggregateID: "device-001", IdempotencyKey: "k1", Payload: json.RawMessage(`{"value":72.5}`)}
+	hash := idempotencyHash(req)
+
+	// Only the key and the test flag count: a retry may differ in content
+	retry := *req
+	retry.Payload = json.RawMessage(`{"value":73}`)
+	assert.Equal(t, hash, idempotencyHash(&retry))
+
+	other := *req
+	other.IdempotencyKey = "k2"
+	assert.NotEqual(t, hash, idempotencyHash(&other))
+
+	test := *req
+	test.Test = true
+	assert.NotEqual(t, hash, idempotencyHash(&test))
+}
+
+func TestService_IdempotencyKey(t *testing.T) {
+	const original = "01234567-89ab-cdef-0123-456789abcdef"
+
+	tests := []struct {
+		name       string
+		owner      string
+		key        string
+		wantStatus string
+		wantWindow time.Duration
+		wantCalls  int
+	}{
+		{name: "first use of key", key: "k1", wantStatus: "accepted", wantWindow: time.Hour, wantCalls: 1},
+		{name: "repeated key", owner: original, key: "k1", wantStatus: StatusDuplicate, wantWindow: time.Hour, wantCalls: 1},
+		{name: "no key", owner: original, wantStatus: "accepted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			var window time.Duration
+			svc := NewService(&mockOutboxRepository{
+				InsertFn: func(ctx context.Context, event *events.Envelope) error { return nil },
+			}, slog.Default())
+			unique := &mockUniqueOutboxRepository{
+				InsertUniqueFn: func(ctx context.Context, event *events.Envelope, hash []byte, w time.Duration) (string, error) {
+					calls++
+					window = w
+					if tt.owner == "" {
+						return event.EventID.String(), nil
+					}
+					return tt.owner, nil
+				},
+			}
+			svc.SetIdempotency(unique, time.Hour)
+			// A repeated key is a retry: answered even when content
+			// duplicates are rejected
+			svc.SetDedup(unique, DedupConfig{Window: time.Minute, Reject: true})
+
+			resp, err := svc.Ingest(context.Background(), &IngestRequest{
+				EventType:      "sensor.reading",
+				AggregateID:    "device-001",
+				IdempotencyKey: tt.key,
+				Payload:        json.RawMessage(`{"value":72.5}`),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.Status)
+			if tt.wantStatus == StatusDuplicate {
+				assert.Equal(t, original, resp.EventID)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantWindow, window)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/units"
+)
+
+// Enricher adds to an event before it is signed and written to the outbox:
+// attributes in Metadata.Attributes, normalized or derived payload fields.
+// Enrichers run in order on the request path, after validation and payload
+// protection. An error fails the request.
+type Enricher interface {
+	Enrich(ctx context.Context, event *events.Envelope) error
+}
+
+// EnricherFunc adapts a plain function to Enricher.
+type EnricherFunc func(ctx context.Context, event *events.Envelope) error
+
+// Enrich calls f(ctx, event).
+func (f EnricherFunc) Enrich(ctx context.Context, event *events.Envelope) error {
+	return f(ctx, event)
+}
+
+// Built-in enricher names, as listed in CJ_INGEST_ENRICHERS.
+const (
+	EnricherUnits    = "units"
+	EnricherRegistry = "registry"
+)
+
+// EnricherConfig configures the built-in enrichers.
+type EnricherConfig struct {
+	// Units is the unit system the units enricher normalizes to.
+	Units string
+	// Registry is the device registry the registry enricher reads.
+	Registry DeviceRegistry
+}
+
+// NewEnrichers builds the built-in enrichers named in names, a
+// comma-separated list, in that order. Empty names yields none.
+func NewEnrichers(names string, config EnricherConfig) ([]Enricher, error) {
+	var enrichers []Enricher
+	if names == "" {
+		return enrichers, nil
+	}
+	for _, name := range strings.Split(names, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case EnricherUnits:
+			if !units.IsValidSystem(config.Units) {
+				return nil, fmt.Errorf("units enricher: invalid unit system %q (expected %s or %s)", config.Units, units.Metric, units.Imperial)
+			}
+			enrichers = append(enrichers, &UnitNormalizer{System: config.Units})
+		case EnricherRegistry:
+			if config.Registry == nil {
+				return nil, fmt.Errorf("registry enricher: no device registry configured")
+			}
+			enrichers = append(enrichers, &RegistryEnricher{Registry: config.Registry})
+		default:
+			return nil, fmt.Errorf("unknown enricher %q (expected %s or %s)", name, EnricherUnits, EnricherRegistry)
+		}
+	}
+	return enrichers, nil
+}
+
+// UnitNormalizer converts a payload's top-level "value", measured in the
+// unit named by "unit", to System, so every reading of a quantity is stored
+// in the same unit. Payloads without both fields, with an unknown unit, or
+// that are not JSON objects are left as sent.
+type UnitNormalizer struct {
+	System string
+}
+
+// Enrich implements Enricher.
+func (n *UnitNormalizer) Enrich(ctx context.Context, event *events.Envelope) error {
+	if !event.IsJSON() {
+		return nil
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(event.Payload, &doc); err != nil {
+		return nil
+	}
+	var v float64
+	var unit string
+	if json.Unmarshal(doc["value"], &v) != nil || json.Unmarshal(doc["unit"], &unit) != nil {
+		return nil
+	}
+	v, to, ok := units.Convert(v, unit, n.System, false)
+	if !ok {
+		return nil
+	}
+
+	doc["value"], _ = json.Marshal(v)
+	doc["unit"], _ = json.Marshal(to)
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode normalized payload: %w", err)
+	}
+	event.Payload = payload
+	return nil
+}
+
+// DeviceRegistry looks up what is known about a device, such as its site and
+// location.
+type DeviceRegistry interface {
+	// Lookup returns the device's attributes, or nil for an unknown device.
+	Lookup(ctx context.Context, deviceID string) (map[string]string, error)
+}
+
+// RegistryEnricher adds the registry's attributes for the event's aggregate
+// to Metadata.Attributes. Events of an aggregate type other than "device"
+// are left alone; so are events of unknown devices.
+type RegistryEnricher struct {
+	Registry DeviceRegistry
+}
+
+// Enrich implements Enricher.
+func (r *RegistryEnricher) Enrich(ctx context.Context, event *events.Envelope) error {
+	if event.AggregateType != "" && event.AggregateType != "device" {
+		return nil
+	}
+	attrs, err := r.Registry.Lookup(ctx, event.AggregateID)
+	if err != nil {
+		return fmt.Errorf("device registry lookup: %w", err)
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+	if event.Metadata.Attributes == nil {
+		event.Metadata.Attributes = make(map[string]string, len(attrs))
+	}
+	for k, v := range attrs {
+		event.Metadata.Attributes[k] = v
+	}
+	return nil
+}
+
+// StaticRegistry is a DeviceRegistry held in memory.
+type StaticRegistry map[string]map[string]string
+
+// Lookup implements DeviceRegistry.
+func (r StaticRegistry) Lookup(ctx context.Context, deviceID string) (map[string]string, error) {
+	return r[deviceID], nil
+}
+
+// LoadStaticRegistry reads a StaticRegistry from a JSON file mapping device
+// IDs to their attributes:
+//
+//	{"device-001": {"site": "plant-a", "lat": "47.61", "lon": "-122.33"}}
+func LoadStaticRegistry(path string) (StaticRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device registry: %w", err)
+	}
+	var registry StaticRegistry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("failed to parse device registry %s: %w", path, err)
+	}
+	return registry, nil
+}
@@ -0,0 +1,103 @@
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+func TestNewEnrichers(t *testing.T) {
+	registry := StaticRegistry{}
+
+	enrichers, err := NewEnrichers("registry, units", EnricherConfig{Units: "imperial", Registry: registry})
+	require.NoError(t, err)
+	require.Len(t, enrichers, 2)
+	assert.IsType(t, &RegistryEnricher{}, enrichers[0])
+	assert.Equal(t, &UnitNormalizer{System: "imperial"}, enrichers[1])
+
+	enrichers, err = NewEnrichers("", EnricherConfig{})
+	require.NoError(t, err)
+	assert.Empty(t, enrichers)
+
+	for _, names := range []string{"geo", "units", "registry"} {
+		_, err := NewEnrichers(names, EnricherConfig{Units: "kelvin"})
+		assert.Error(t, err, names)
+	}
+}
+
+func TestUnitNormalizer(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{"converted", `{"value": 212, "unit": "Fahrenheit", "site": "a"}`, `{"value": 100, "unit": "celsius", "site": "a"}`},
+		{"already metric", `{"value": 20, "unit": "celsius"}`, `{"value": 20, "unit": "celsius"}`},
+		{"unknown unit", `{"value": 20, "unit": "F"}`, `{"value": 20, "unit": "F"}`},
+		{"no unit", `{"value": 20}`, `{"value": 20}`},
+		{"not an object", `[1, 2]`, `[1, 2]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &events.Envelope{Payload: json.RawMessage(tt.payload)}
+			require.NoError(t, (&UnitNormalizer{System: "metric"}).Enrich(context.Background(), event))
+			assert.JSONEq(t, tt.want, string(event.Payload))
+		})
+	}
+
+	binary := &events.Envelope{}
+	binary.SetBinaryPayload("image/jpeg", []byte{0xff, 0xd8})
+	payload := binary.Payload
+	require.NoError(t, (&UnitNormalizer{System: "metric"}).Enrich(context.Background(), binary))
+	assert.Equal(t, payload, binary.Payload, "binary payloads are left as sent")
+}
+
+func TestRegistryEnricher(t *testing.T) {
+	enricher := &RegistryEnricher{Registry: StaticRegistry{
+		"device-001": {"site": "plant-a", "lat": "47.61"},
+	}}
+	ctx := context.Background()
+
+	event := &events.Envelope{AggregateID: "device-001", AggregateType: "device"}
+	event.Metadata.Attributes = map[string]string{"site": "old", "batch": "7"}
+	require.NoError(t, enricher.Enrich(ctx, event))
+	assert.Equal(t, map[string]string{"site": "plant-a", "lat": "47.61", "batch": "7"}, event.Metadata.Attributes)
+
+	untyped := &events.Envelope{AggregateID: "device-001"}
+	require.NoError(t, enricher.Enrich(ctx, untyped))
+	assert.Equal(t, "plant-a", untyped.Metadata.Attributes["site"], "untyped aggregates are looked up")
+
+	session := &events.Envelope{AggregateID: "device-001", AggregateType: "session"}
+	require.NoError(t, enricher.Enrich(ctx, session))
+	assert.Nil(t, session.Metadata.Attributes, "other aggregate types are left alone")
+
+	unknown := &events.Envelope{AggregateID: "device-404"}
+	require.NoError(t, enricher.Enrich(ctx, unknown))
+	assert.Nil(t, unknown.Metadata.Attributes)
+}
+
+func TestLoadStaticRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"device-001": {"site": "plant-a"}}`), 0o600))
+
+	registry, err := LoadStaticRegistry(path)
+	require.NoError(t, err)
+	attrs, err := registry.Lookup(context.Background(), "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"site": "plant-a"}, attrs)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"device-001": {"lat": 47.61}}`), 0o600))
+	_, err = LoadStaticRegistry(path)
+	assert.Error(t, err, "attribute values are strings")
+
+	_, err = LoadStaticRegistry(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
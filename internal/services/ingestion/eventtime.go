@@ -0,0 +1,47 @@
+package ingestion
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// ErrEventTimeOutOfRange is returned by Service.Ingest for an event_time
+// outside the EventTimePolicy bounds when out-of-range times are rejected.
+var ErrEventTimeOutOfRange = errors.New("event_time out of range")
+
+// EventTimePolicy bounds the event_time producers may send. Projections keep
+// the event with the latest event time, so a device whose clock runs years
+// ahead would shadow every later reading of its aggregate.
+type EventTimePolicy struct {
+	MaxPast   time.Duration // oldest accepted event time before now; 0 disables
+	MaxFuture time.Duration // furthest accepted event time after now; 0 disables
+
+	// Clamp moves an out-of-range event time to the nearest bound and keeps
+	// the original in events.Metadata.ClampedFrom, instead of rejecting it.
+	Clamp bool
+}
+
+// check returns eventTime, or its clamped value, measured against now, for
+// an event with metadata meta. Replays are old by design, so MaxPast does
+// not apply to them, but only to trusted ones (events.Metadata.TrustedReplay):
+// a replay flag alone would let any producer send arbitrarily old events.
+func (p EventTimePolicy) check(eventTime, now time.Time, meta events.Metadata) (time.Time, bool, error) {
+	var bound time.Time
+	switch {
+	case p.MaxFuture > 0 && eventTime.After(now.Add(p.MaxFuture)):
+		bound = now.Add(p.MaxFuture)
+	case p.MaxPast > 0 && !meta.TrustedReplay() && eventTime.Before(now.Add(-p.MaxPast)):
+		bound = now.Add(-p.MaxPast)
+	default:
+		return eventTime, false, nil
+	}
+
+	if !p.Clamp {
+		return time.Time{}, false, fmt.Errorf("%w: %s is not within %s before and %s after now",
+			ErrEventTimeOutOfRange, eventTime.Format(time.RFC3339), p.MaxPast, p.MaxFuture)
+	}
+	return bound, true, nil
+}
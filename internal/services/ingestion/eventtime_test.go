@@ -0,0 +1,151 @@
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+func TestEventTimePolicy_Check(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	bounded := EventTimePolicy{MaxPast: 7 * 24 * time.Hour, MaxFuture: 5 * time.Minute}
+	clamping := bounded
+	clamping.Clamp = true
+	replay := events.Metadata{Replay: true, Source: events.SourceReplay}
+
+	tests := []struct {
+		name        string
+		policy      EventTimePolicy
+		eventTime   time.Time
+		meta        events.Metadata
+		want        time.Time
+		wantClamped bool
+		wantErr     bool
+	}{
+		{name: "within bounds", policy: bounded, eventTime: now.Add(-time.Hour), want: now.Add(-time.Hour)},
+		{name: "at the future bound", policy: bounded, eventTime: now.Add(5 * time.Minute), want: now.Add(5 * time.Minute)},
+		{name: "too far ahead", policy: bounded, eventTime: now.Add(6 * time.Minute), wantErr: true},
+		{name: "too old", policy: bounded, eventTime: now.Add(-8 * 24 * time.Hour), wantErr: true},
+		{name: "old replay", policy: bounded, eventTime: now.Add(-365 * 24 * time.Hour), meta: replay, want: now.Add(-365 * 24 * time.Hour)},
+		{name: "old import", policy: bounded, eventTime: now.Add(-365 * 24 * time.Hour), meta: events.Metadata{Replay: true, Source: events.SourceImport}, want: now.Add(-365 * 24 * time.Hour)},
+		{name: "untrusted old replay", policy: bounded, eventTime: now.Add(-365 * 24 * time.Hour), meta: events.Metadata{Replay: true, Source: events.SourceIngestion}, wantErr: true},
+		{name: "future replay", policy: bounded, eventTime: now.Add(time.Hour), meta: replay, wantErr: true},
+		{name: "clamped ahead", policy: clamping, eventTime: now.Add(24 * time.Hour), want: now.Add(5 * time.Minute), wantClamped: true},
+		{name: "clamped old", policy: clamping, eventTime: now.Add(-30 * 24 * time.Hour), want: now.Add(-7 * 24 * time.Hour), wantClamped: true},
+		{name: "no bounds", eventTime: now.Add(100 * 365 * 24 * time.Hour), want: now.Add(100 * 365 * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, clamped, err := tt.policy.check(tt.eventTime, now, tt.meta)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrEventTimeOutOfRange)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantClamped, clamped)
+		})
+	}
+}
+
+func TestIngest_EventTimePolicy(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","event_time":"2027-01-01T00:00:00Z","payload":{"value":72.5}}`
+
+	tests := []struct {
+		name       string
+		clamp      bool
+		wantStatus int
+	}{
+		{name: "rejected", wantStatus: http.StatusBadRequest},
+		{name: "clamped", clamp: true, wantStatus: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured *events.Envelope
+			mock := &mockOutboxRepository{
+				InsertFn: func(ctx context.Context, event *events.Envelope) error {
+					captured = event
+					return nil
+				},
+			}
+			svc := NewService(mock, slog.Default())
+			svc.SetEventTimePolicy(EventTimePolicy{MaxFuture: 5 * time.Minute, Clamp: tt.clamp})
+			handler := NewHandler(svc, slog.Default())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			handler.HandleIngest(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if !tt.clamp {
+				var resp map[string]string
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Contains(t, resp["error"], "event_time out of range")
+				assert.Nil(t, captured)
+				return
+			}
+			require.NotNil(t, captured)
+			assert.Equal(t, now.Add(5*time.Minute), captured.EventTime)
+			require.NotNil(t, captured.Metadata.ClampedFrom)
+			assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), *captured.Metadata.ClampedFrom)
+		})
+	}
+}
+
+func TestIngest_EventTimePolicy_Replay(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","event_time":"2025-01-01T00:00:00Z","payload":{"value":72.5}}`
+
+	tests := []struct {
+		name       string
+		key        string
+		replay     bool
+		wantStatus int
+	}{
+		{name: "live", key: "backfill-job", wantStatus: http.StatusBadRequest},
+		{name: "authorized replay", key: "backfill-job", replay: true, wantStatus: http.StatusAccepted},
+		{name: "unauthorized replay", key: "gateway", replay: true, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockOutboxRepository{
+				InsertFn: func(ctx context.Context, event *events.Envelope) error { return nil },
+			}
+			svc := NewService(mock, slog.Default())
+			svc.SetEventTimePolicy(EventTimePolicy{MaxPast: 7 * 24 * time.Hour})
+			handler := NewHandler(svc, slog.Default())
+			handler.SetReplayAPIKeys([]string{"backfill-job"})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+			req.Header.Set(APIKeyHeader, tt.key)
+			if tt.replay {
+				req.Header.Set(ReplayHeader, "true")
+			}
+			w := httptest.NewRecorder()
+			handler.HandleIngest(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package ingestion
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// ErrEventTypeRejected is returned by Service.Ingest for an event type the
+// EventTypePolicy does not accept.
+var ErrEventTypeRejected = errors.New("event type rejected")
+
+// EventTypePolicy is the registry of event types producers may send. Topic
+// routes and handlers select events by type prefix, so a misspelled type
+// ("sensr.reading") is otherwise stored, published to the default topic and
+// folded by no handler, without any error.
+//
+// Patterns are event types or prefixes ending in ".*" (see
+// events.ValidateEventTypePattern).
+type EventTypePolicy struct {
+	Known  []string // registered types; empty registers every type
+	Banned []string // types that are always rejected
+
+	// MaxDepth enables the naming rules (see events.ValidateEventType) with
+	// at most this many segments; 0 disables them.
+	MaxDepth int
+
+	// Strict rejects types that break the naming rules or are not registered.
+	// Otherwise they are accepted and logged.
+	Strict bool
+}
+
+// Check returns an error wrapping ErrEventTypeRejected if the policy rejects
+// eventType, and otherwise a description of the problem to log, if any.
+func (p EventTypePolicy) Check(eventType string) (string, error) {
+	for _, pattern := range p.Banned {
+		if events.MatchEventType(pattern, eventType) {
+			return "", fmt.Errorf("%w: %s is banned", ErrEventTypeRejected, eventType)
+		}
+	}
+
+	var problem string
+	if p.MaxDepth > 0 {
+		if err := events.ValidateEventType(eventType, p.MaxDepth); err != nil {
+			problem = err.Error()
+		}
+	}
+	if problem == "" && len(p.Known) > 0 && !p.registered(eventType) {
+		problem = fmt.Sprintf("event type %q is not registered", eventType)
+	}
+	if problem != "" && p.Strict {
+		return "", fmt.Errorf("%w: %s", ErrEventTypeRejected, problem)
+	}
+	return problem, nil
+}
+
+func (p EventTypePolicy) registered(eventType string) bool {
+	for _, pattern := range p.Known {
+		if events.MatchEventType(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+func TestEventTypePolicy_Check(t *testing.T) {
+	registry := EventTypePolicy{
+		Known:    []string{"sensor.*", "user.login"},
+		Banned:   []string{"sensor.debug.*"},
+		MaxDepth: 4,
+	}
+	strict := registry
+	strict.Strict = true
+
+	tests := []struct {
+		name        string
+		policy      EventTypePolicy
+		eventType   string
+		wantProblem string
+		wantErr     bool
+	}{
+		{name: "registered", policy: strict, eventType: "sensor.reading"},
+		{name: "registered exactly", policy: strict, eventType: "user.login"},
+		{name: "banned", policy: registry, eventType: "sensor.debug.dump", wantErr: true},
+		{name: "unregistered, permissive", policy: registry, eventType: "sensr.reading", wantProblem: "not registered"},
+		{name: "unregistered, strict", policy: strict, eventType: "sensr.reading", wantErr: true},
+		{name: "misnamed, permissive", policy: registry, eventType: "Sensor.Reading", wantProblem: "not a lowercase identifier"},
+		{name: "misnamed, strict", policy: strict, eventType: "sensor.reading.raw.x.y", wantErr: true},
+		{name: "naming rules off", policy: EventTypePolicy{Strict: true}, eventType: "Sensor-Reading"},
+		{name: "no registry", policy: EventTypePolicy{MaxDepth: 4, Strict: true}, eventType: "sensr.reading"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem, err := tt.policy.Check(tt.eventType)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrEventTypeRejected)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantProblem == "" {
+				assert.Empty(t, problem)
+			} else {
+				assert.Contains(t, problem, tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestIngest_EventTypePolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		strict     bool
+		wantStatus int
+	}{
+		{name: "permissive", wantStatus: http.StatusAccepted},
+		{name: "strict", strict: true, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured *events.Envelope
+			mock := &mockOutboxRepository{
+				InsertFn: func(ctx context.Context, event *events.Envelope) error {
+					captured = event
+					return nil
+				},
+			}
+			svc := NewService(mock, slog.Default())
+			svc.SetEventTypePolicy(EventTypePolicy{Known: []string{"sensor.*"}, MaxDepth: 4, Strict: tt.strict})
+			handler := NewHandler(svc, slog.Default())
+
+			body := `{"event_type":"sensr.reading","aggregate_id":"device-001","payload":{"value":72.5}}`
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			handler.HandleIngest(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if !tt.strict {
+				require.NotNil(t, captured)
+				return
+			}
+			var resp map[string]string
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Contains(t, resp["error"], `event type rejected: event type "sensr.reading" is not registered`)
+			assert.Nil(t, captured)
+		})
+	}
+}
@@ -1,15 +1,44 @@
 package ingestion
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/audit"
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
 )
 
+// APIKeyHeader carries the caller's API key.
+const APIKeyHeader = auth.APIKeyHeader
+
+// ReplayHeader set to "true" marks the request as a replay or backfill of
+// historical events (events.Metadata.Replay). Only API keys allowed to
+// replay may send it (see Handler.SetReplayAPIKeys).
+const ReplayHeader = "X-Replay"
+
+// ModeSync as the mode query parameter makes POST /api/v1/events wait until
+// the event is published (see Handler.SetPublishWaiter). The default mode,
+// "async", answers as soon as the event is in the outbox.
+const ModeSync = "sync"
+
 // Handler handles HTTP requests for the ingestion service.
 type Handler struct {
-	service *Service
-	logger  *slog.Logger
+	service    *Service
+	testKeys   map[string]bool // API keys whose traffic is marked as test
+	replayKeys map[string]bool // API keys allowed to send ReplayHeader
+	audit      *audit.Recorder // nil when the audit log is disabled
+	policy     *auth.Policy    // nil allows every event type
+	pressure   *Backpressure   // nil never refuses events
+	waiter     *PublishWaiter  // nil disables sync mode
+	syncWait   time.Duration   // bound on a sync request's wait
+	logger     *slog.Logger
 }
 
 // NewHandler creates a new ingestion HTTP handler.
@@ -20,29 +49,204 @@ func NewHandler(service *Service, logger *slog.Logger) *Handler {
 	}
 }
 
-// HandleIngest handles POST /api/v1/events
+// SetTestAPIKeys marks events sent with any of the given API keys as test
+// traffic (events.Metadata.Test).
+func (h *Handler) SetTestAPIKeys(keys []string) {
+	h.testKeys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		h.testKeys[key] = true
+	}
+}
+
+// SetReplayAPIKeys allows the given API keys to mark their events as
+// replays with ReplayHeader, whatever their scopes. Keys granted a replay
+// scope by the policy may also replay the event types it covers; any other
+// request carrying the header gets 403.
+func (h *Handler) SetReplayAPIKeys(keys []string) {
+	h.replayKeys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		h.replayKeys[key] = true
+	}
+}
+
+// SetAudit records every ingest request in the audit log.
+func (h *Handler) SetAudit(rec *audit.Recorder) {
+	h.audit = rec
+}
+
+// SetPolicy restricts the event types each API key may ingest.
+func (h *Handler) SetPolicy(policy *auth.Policy) {
+	h.policy = policy
+}
+
+// SetBackpressure refuses events while the outbox backlog is over its
+// thresholds.
+func (h *Handler) SetBackpressure(bp *Backpressure) {
+	h.pressure = bp
+}
+
+// SetPublishWaiter enables sync mode: requests with ?mode=sync wait up to
+// timeout for their event to be published.
+func (h *Handler) SetPublishWaiter(waiter *PublishWaiter, timeout time.Duration) {
+	h.waiter = waiter
+	h.syncWait = timeout
+}
+
+// HandleIngest handles POST /api/v1/events. The envelope-level fields added
+// in v2 (correlation_id, idempotency_key, schema_version) are ignored.
+// With a policy set, requests without a known API key get 401 and events of
+// types outside the key's ingest scopes get 403. ReplayHeader from a key not
+// allowed to replay the event type gets 403. While the outbox is
+// backlogged, requests get 503 with Retry-After. In sync mode a published
+// event gets 201 instead of 202. A coalesced duplicate gets 200 with the
+// original event's ID, a rejected one 409. An event_time outside the
+// service's EventTimePolicy gets 400 unless it is clamped, as does an
+// event_type its EventTypePolicy rejects.
 func (h *Handler) HandleIngest(w http.ResponseWriter, r *http.Request) {
+	h.serveIngest(w, r, 1)
+}
+
+// HandleIngestV2 handles POST /api/v2/events. The body is v1's plus
+// envelope-level fields: correlation_id (events.Metadata.CorrelationID),
+// idempotency_key (see Service.SetIdempotency) and a required
+// schema_version. Otherwise it answers as HandleIngest, except that invalid
+// requests get 400 rather than 500, and a repeated idempotency key gets 200
+// with the original event's ID even when duplicates are rejected.
+func (h *Handler) HandleIngestV2(w http.ResponseWriter, r *http.Request) {
+	h.serveIngest(w, r, 2)
+}
+
+// serveIngest answers an ingest request of the given API version.
+func (h *Handler) serveIngest(w http.ResponseWriter, r *http.Request, version int) {
 	if r.Method != http.MethodPost {
 		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
+	grant, ok := h.policy.Grant(r)
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "missing or unknown API key")
+		return
+	}
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "", "async":
+	case ModeSync:
+		if h.waiter == nil {
+			h.writeError(w, http.StatusBadRequest, "sync mode is disabled")
+			return
+		}
+	default:
+		h.writeError(w, http.StatusBadRequest, "invalid mode: "+mode)
+		return
+	}
+	if h.pressure.Shedding() {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(h.pressure.RetryAfter().Seconds()))))
+		h.writeError(w, http.StatusServiceUnavailable, "ingestion is backlogged, retry later")
+		return
+	}
+
 	var req IngestRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
 		return
 	}
+	switch version {
+	case 1:
+		req.CorrelationID, req.IdempotencyKey, req.SchemaVersion = "", "", 0
+	case 2:
+		if req.SchemaVersion == 0 {
+			h.writeError(w, http.StatusBadRequest, "schema_version is required")
+			return
+		}
+	}
+	if !grant.Allows(auth.Ingest, req.EventType) {
+		audit.Notef(r.Context(), "", "event_type=%s aggregate_id=%s", req.EventType, req.AggregateID)
+		h.writeError(w, http.StatusForbidden, "API key may not ingest event type: "+req.EventType)
+		return
+	}
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		req.Test = h.testKeys[key]
+	}
+	if r.Header.Get(ReplayHeader) == "true" {
+		if !h.mayReplay(r, grant, req.EventType) {
+			audit.Notef(r.Context(), "", "event_type=%s aggregate_id=%s replay", req.EventType, req.AggregateID)
+			h.writeError(w, http.StatusForbidden, "API key may not replay event type: "+req.EventType)
+			return
+		}
+		req.Replay = true
+	}
 
 	resp, err := h.service.Ingest(r.Context(), &req)
+	var eventID string
+	if resp != nil {
+		eventID = resp.EventID
+	}
+	audit.Notef(r.Context(), eventID, "event_type=%s aggregate_id=%s", req.EventType, req.AggregateID)
+	if errors.Is(err, ErrEventTimeOutOfRange) || errors.Is(err, ErrEventTypeRejected) || errors.Is(err, ErrIdempotencyDisabled) ||
+		version >= 2 && errors.Is(err, ErrValidation) {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, ErrDuplicate) {
+		h.writeJSON(w, http.StatusConflict, map[string]string{
+			"error":    "duplicate of event " + eventID,
+			"event_id": eventID,
+		})
+		return
+	}
 	if err != nil {
+		if status, message := timeout.Status(err); status != http.StatusInternalServerError {
+			h.writeError(w, status, message)
+			return
+		}
 		// TODO: Differentiate between validation errors (400) and internal errors (500)
 		h.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	if mode == ModeSync {
+		h.awaitPublish(w, r, resp)
+		return
+	}
+	if resp.Status == StatusDuplicate {
+		h.writeJSON(w, http.StatusOK, resp)
+		return
+	}
 	h.writeJSON(w, http.StatusAccepted, resp)
 }
 
+// mayReplay reports whether the request's API key may ingest eventType as a
+// replay: it is a replay key, or its grant holds a replay scope for the type.
+func (h *Handler) mayReplay(r *http.Request, grant *auth.Grant, eventType string) bool {
+	if key := r.Header.Get(APIKeyHeader); key != "" && h.replayKeys[key] {
+		return true
+	}
+	return grant.Holds(auth.Replay, eventType)
+}
+
+// awaitPublish answers a sync request: 201 once the event is published, 502
+// when it used up its publish retries. When the wait times out the event is
+// still on its way, so the answer is the usual 202.
+func (h *Handler) awaitPublish(w http.ResponseWriter, r *http.Request, resp *IngestResponse) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.syncWait)
+	defer cancel()
+
+	status, err := h.waiter.Wait(ctx, resp.EventID)
+	switch {
+	case err == nil:
+		resp.Status = "published"
+		resp.GlobalSeq = status.GlobalSeq
+		resp.AggregateSeq = status.AggregateSeq
+		h.writeJSON(w, http.StatusCreated, resp)
+	case errors.Is(err, ErrPublishFailed):
+		h.writeError(w, http.StatusBadGateway, "event "+resp.EventID+" was accepted but could not be published")
+	default:
+		h.logger.Debug("sync ingest timed out before publish", "event_id", resp.EventID)
+		h.writeJSON(w, http.StatusAccepted, resp)
+	}
+}
+
 // HandleHealth handles GET /health
 func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
@@ -9,11 +9,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/cornjacket/platform-services/internal/shared/audit"
+	"github.com/cornjacket/platform-services/internal/shared/auth"
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
 )
 
 func TestHandleIngest_Success(t *testing.T) {
@@ -81,6 +86,169 @@ func TestHandleIngest_ValidationError(t *testing.T) {
 	assert.NotEqual(t, http.StatusAccepted, w.Code)
 }
 
+func TestRoutes_ValidateAgainstSpec(t *testing.T) {
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			t.Fatal("Insert should not be called for invalid request")
+			return nil
+		},
+	}
+	mux := http.NewServeMux()
+	NewHandler(NewService(mock, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	body := `{"aggregate_id":"device-001","payload":{"value":72.5}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "invalid request body: event_type is required", resp["error"])
+}
+
+func TestRoutes_OpenAPI(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(NewService(nil, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var doc map[string]any
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&doc))
+	assert.Contains(t, doc["paths"], "/api/v1/events")
+	assert.Contains(t, doc["paths"], "/api/v2/events")
+}
+
+func TestRoutes_Versions(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(NewService(&mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error { return nil },
+	}, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	tests := []struct {
+		version string
+		path    string
+		body    string
+	}{
+		{version: "v1", path: "/api/v1/events", body: `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{"value":72.5}}`},
+		{version: "v2", path: "/api/v2/events", body: `{"event_type":"sensor.reading","aggregate_id":"device-001","schema_version":1,"payload":{"value":72.5}}`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code, tt.path)
+		assert.Equal(t, tt.version, w.Header().Get("API-Version"), tt.path)
+	}
+
+	// v2 bodies are closed: a misspelled field is not silently dropped
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","schema_version":1,"idempotencyKey":"k1","payload":{}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/events", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleIngestV2(t *testing.T) {
+	var captured *events.Envelope
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			captured = event
+			return nil
+		},
+	}
+	handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","aggregate_type":"device","schema_version":1,"correlation_id":"order-7","payload":{"value":72.5}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/events", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	handler.HandleIngestV2(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	require.NotNil(t, captured)
+	assert.Equal(t, "device", captured.AggregateType)
+	assert.Equal(t, "order-7", captured.Metadata.CorrelationID)
+	assert.Equal(t, events.CurrentSchemaVersion, captured.Metadata.SchemaVersion)
+}
+
+func TestHandleIngestV2_BadRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantError string
+	}{
+		{
+			name:      "missing schema version",
+			body:      `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{}}`,
+			wantError: "schema_version is required",
+		},
+		{
+			name:      "unsupported schema version",
+			body:      `{"event_type":"sensor.reading","aggregate_id":"device-001","schema_version":99,"payload":{}}`,
+			wantError: "schema_version",
+		},
+		{
+			name:      "validation error",
+			body:      `{"aggregate_id":"device-001","schema_version":1,"payload":{}}`,
+			wantError: "event_type is required",
+		},
+		{
+			name:      "idempotency keys disabled",
+			body:      `{"event_type":"sensor.reading","aggregate_id":"device-001","schema_version":1,"idempotency_key":"k1","payload":{}}`,
+			wantError: ErrIdempotencyDisabled.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockOutboxRepository{
+				InsertFn: func(ctx context.Context, event *events.Envelope) error {
+					t.Fatal("Insert should not be called for invalid request")
+					return nil
+				},
+			}
+			handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/events", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			handler.HandleIngestV2(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp map[string]string
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Contains(t, resp["error"], tt.wantError)
+		})
+	}
+}
+
+func TestHandleIngest_IgnoresV2Fields(t *testing.T) {
+	var captured *events.Envelope
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			captured = event
+			return nil
+		},
+	}
+	handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+
+	// An idempotency key would fail with keys disabled if v1 honoured it
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","schema_version":99,"correlation_id":"order-7","idempotency_key":"k1","payload":{}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler.HandleIngest(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	require.NotNil(t, captured)
+	assert.Empty(t, captured.Metadata.CorrelationID)
+	assert.Equal(t, events.CurrentSchemaVersion, captured.Metadata.SchemaVersion)
+}
+
 func TestHandleIngest_OutboxError(t *testing.T) {
 	mock := &mockOutboxRepository{
 		InsertFn: func(ctx context.Context, event *events.Envelope) error {
@@ -99,6 +267,39 @@ func TestHandleIngest_OutboxError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
+func TestHandleIngest_OutboxTimeout(t *testing.T) {
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			<-ctx.Done() // an insert stuck behind a lock
+			return fmt.Errorf("timeout: %w", ctx.Err())
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetTimeouts(timeout.Policy{Operations: map[string]time.Duration{"ingest": 10 * time.Millisecond}})
+	handler := NewHandler(service, slog.Default())
+
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{"value":72.5}}`
+	w := httptest.NewRecorder()
+	handler.HandleIngest(w, httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body)))
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestHandleIngest_DatabaseUnavailable(t *testing.T) {
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			return pgretry.ErrCircuitOpen
+		},
+	}
+	handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{"value":72.5}}`
+	w := httptest.NewRecorder()
+	handler.HandleIngest(w, httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body)))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
 func TestHandleIngest_MethodNotAllowed(t *testing.T) {
 	handler := NewHandler(NewService(nil, slog.Default()), slog.Default())
 
@@ -124,3 +325,176 @@ func TestHandleHealth(t *testing.T) {
 	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
 	assert.Equal(t, "healthy", resp["status"])
 }
+
+func TestHandleIngest_TestAPIKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		wantTest bool
+	}{
+		{name: "test key", key: "e2e-staging", wantTest: true},
+		{name: "other key", key: "prod-key", wantTest: false},
+		{name: "no key", key: "", wantTest: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured *events.Envelope
+			mock := &mockOutboxRepository{
+				InsertFn: func(ctx context.Context, event *events.Envelope) error {
+					captured = event
+					return nil
+				},
+			}
+			handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+			handler.SetTestAPIKeys([]string{"e2e-staging"})
+
+			// A "test" field in the body is ignored; only the key decides
+			body := `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{"value":72.5},"test":true}`
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			handler.HandleIngest(w, req)
+
+			assert.Equal(t, http.StatusAccepted, w.Code)
+			require.NotNil(t, captured)
+			assert.Equal(t, tt.wantTest, captured.Metadata.Test)
+		})
+	}
+}
+
+func TestHandleIngest_ReplayHeader(t *testing.T) {
+	policy, err := auth.ParsePolicy("backfill=ingest:*|replay:sensor.*,gateway=ingest:*")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		policy     *auth.Policy
+		key        string
+		replay     bool
+		wantStatus int
+	}{
+		{name: "no header", key: "gateway", policy: policy, wantStatus: http.StatusAccepted},
+		{name: "replay key", key: "backfill-job", replay: true, wantStatus: http.StatusAccepted},
+		{name: "replay scope", key: "backfill", policy: policy, replay: true, wantStatus: http.StatusAccepted},
+		{name: "ingest scope only", key: "gateway", policy: policy, replay: true, wantStatus: http.StatusForbidden},
+		{name: "no policy", key: "anyone", replay: true, wantStatus: http.StatusForbidden},
+		{name: "no key", replay: true, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured *events.Envelope
+			mock := &mockOutboxRepository{
+				InsertFn: func(ctx context.Context, event *events.Envelope) error {
+					captured = event
+					return nil
+				},
+			}
+			handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+			handler.SetPolicy(tt.policy)
+			handler.SetReplayAPIKeys([]string{"backfill-job"})
+
+			body := `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{"value":72.5}}`
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			if tt.replay {
+				req.Header.Set(ReplayHeader, "true")
+			}
+			w := httptest.NewRecorder()
+
+			handler.HandleIngest(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusAccepted {
+				assert.Nil(t, captured)
+				return
+			}
+			require.NotNil(t, captured)
+			assert.Equal(t, tt.replay, captured.Metadata.Replay)
+			assert.Equal(t, tt.replay, captured.Metadata.TrustedReplay())
+		})
+	}
+}
+
+func TestRoutes_Audit(t *testing.T) {
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error { return nil },
+	}
+	log := audit.NewMemoryLog()
+	handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+	handler.SetAudit(audit.NewRecorder(log, "ingestion", slog.Default()))
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{"value":72.5}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+	req.Header.Set(APIKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var resp IngestResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+	// Requests rejected by spec validation are audited too
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(`{"aggregate_id":"device-001"}`))
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	records := log.Records()
+	require.Len(t, records, 2)
+	assert.Equal(t, "ingest", records[0].Action)
+	assert.Equal(t, audit.Fingerprint("key-1"), records[0].Actor)
+	assert.Equal(t, resp.EventID, records[0].Target)
+	assert.Equal(t, "event_type=sensor.reading aggregate_id=device-001", records[0].Detail)
+	assert.Equal(t, audit.OutcomeSucceeded, records[0].Outcome)
+	assert.Equal(t, audit.OutcomeRejected, records[1].Outcome)
+	assert.Equal(t, audit.ActorAnonymous, records[1].Actor)
+}
+
+func TestHandleIngest_Policy(t *testing.T) {
+	policy, err := auth.ParsePolicy("sensors=ingest:sensor.*")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		key       string
+		eventType string
+		want      int
+	}{
+		{name: "in scope", key: "sensors", eventType: "sensor.reading", want: http.StatusAccepted},
+		{name: "out of scope", key: "sensors", eventType: "user.login", want: http.StatusForbidden},
+		{name: "unknown key", key: "other", eventType: "sensor.reading", want: http.StatusUnauthorized},
+		{name: "no key", key: "", eventType: "sensor.reading", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inserted := false
+			mock := &mockOutboxRepository{
+				InsertFn: func(ctx context.Context, event *events.Envelope) error {
+					inserted = true
+					return nil
+				},
+			}
+			handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+			handler.SetPolicy(policy)
+
+			body := fmt.Sprintf(`{"event_type":%q,"aggregate_id":"device-001","payload":{"value":72.5}}`, tt.eventType)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events", bytes.NewBufferString(body))
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			handler.HandleIngest(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, tt.want == http.StatusAccepted, inserted)
+		})
+	}
+}
@@ -0,0 +1,344 @@
+// Package importer bulk-loads historical events from newline-delimited JSON
+// files. Events are written straight to the event store and published at a
+// bounded rate, bypassing the ingestion API and the outbox, so a large
+// backfill neither queues behind nor delays live traffic. Imported events
+// are marked as replays (events.Metadata.Replay).
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
+)
+
+// Source is the events.Metadata.Source of imported events.
+const Source = events.SourceImport
+
+// MaxLineBytes is the longest line a file may contain.
+const MaxLineBytes = 4 << 20
+
+// Record is one line of an import file. EventTime is required: imports are
+// historical by definition. EventID is optional; with it, re-running an
+// import does not store events twice.
+type Record struct {
+	EventID       string          `json:"event_id,omitempty"`
+	EventType     string          `json:"event_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	AggregateType string          `json:"aggregate_type,omitempty"`
+	EventTime     *time.Time      `json:"event_time"`
+	Payload       json.RawMessage `json:"payload"`
+	TraceID       string          `json:"trace_id,omitempty"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	ContentType   string          `json:"content_type,omitempty"`
+	SchemaVersion int             `json:"schema_version,omitempty"`
+}
+
+// EventStoreWriter writes events to the event store. Inserting an event ID
+// twice fails with a unique violation, as for worker.EventStoreWriter; Get
+// then reads back the event stored first.
+type EventStoreWriter interface {
+	Insert(ctx context.Context, event *events.Envelope) error
+	Get(ctx context.Context, eventID uuid.UUID) (*events.Envelope, error)
+}
+
+// Publisher publishes events to the message bus.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, event *events.Envelope) error
+}
+
+// Router resolves the topic of an event type (see client/eventhandler.Router).
+type Router interface {
+	Topic(eventType string) string
+}
+
+// PayloadTransformer rewrites payloads before they are stored, as
+// ingestion.PayloadTransformer does for live events (PII protection).
+type PayloadTransformer interface {
+	Transform(ctx context.Context, eventType string, payload json.RawMessage) (json.RawMessage, error)
+}
+
+// EventTypeChecker checks event types against the registry, as
+// ingestion.EventTypePolicy does for live events: an error rejects the
+// type, a non-empty problem is logged.
+type EventTypeChecker interface {
+	Check(eventType string) (problem string, err error)
+}
+
+// Enricher adds to events before they are signed, as ingestion.Enricher
+// does for live events.
+type Enricher interface {
+	Enrich(ctx context.Context, event *events.Envelope) error
+}
+
+// Config controls an import.
+type Config struct {
+	// Rate is the most events published per second; 0 publishes as fast as
+	// the store and the bus allow.
+	Rate float64
+	// Topic publishes every event to this topic instead of the one its type
+	// routes to, so live consumers do not see the backfill.
+	Topic string
+	// Skip is the number of lines to pass over before importing, to resume
+	// an import that stopped (see Result.Lines).
+	Skip int
+	// DryRun validates every line without storing or publishing anything.
+	DryRun bool
+	// EventTypes, Payload, Enrichers and Signer treat events as ingestion
+	// does, so a backfill stores nothing the live API would not. Nil accepts
+	// every event type, stores payloads as sent and leaves events unsigned.
+	EventTypes EventTypeChecker
+	Payload    PayloadTransformer
+	Enrichers  []Enricher
+	Signer     *signing.Signer
+}
+
+// Result reports the progress of an import.
+type Result struct {
+	Lines    int `json:"lines"`    // lines handled, skipped ones included; pass as Skip to resume
+	Imported int `json:"imported"` // events stored and published (validated, in a dry run)
+	Existing int `json:"existing"` // of which already stored by an earlier run, published again
+}
+
+// Importer loads import files.
+type Importer struct {
+	store     EventStoreWriter
+	publisher Publisher
+	router    Router
+	config    Config
+	logger    *slog.Logger
+}
+
+// New creates an Importer. router may be nil when config.Topic is set.
+func New(store EventStoreWriter, publisher Publisher, router Router, config Config, logger *slog.Logger) *Importer {
+	return &Importer{
+		store:     store,
+		publisher: publisher,
+		router:    router,
+		config:    config,
+		logger:    logger.With("component", "importer"),
+	}
+}
+
+// Run imports the events read from r, one JSON record per line; blank lines
+// are ignored. It stops at the first line it cannot import, with Result
+// counting the lines handled before it. Each event is stored before it is
+// published, so an event is never published without being stored; a stored
+// event whose publish failed is published again when its line is re-run.
+func (im *Importer) Run(ctx context.Context, r io.Reader) (*Result, error) {
+	result := &Result{}
+	throttle := newThrottle(im.config.Rate)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), MaxLineBytes)
+	for scanner.Scan() {
+		line := result.Lines + 1
+		if line <= im.config.Skip || len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			result.Lines = line
+			continue
+		}
+
+		event, err := im.envelope(ctx, scanner.Bytes())
+		if err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+		if !im.config.DryRun {
+			if err := throttle.wait(ctx); err != nil {
+				return result, err
+			}
+			existing, err := im.importEvent(ctx, event)
+			if err != nil {
+				return result, fmt.Errorf("line %d: %w", line, err)
+			}
+			if existing {
+				result.Existing++
+			}
+		}
+		result.Imported++
+		result.Lines = line
+		if result.Imported%10000 == 0 {
+			im.logger.Info("import progress", "lines", result.Lines, "imported", result.Imported)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("line %d: %w", result.Lines+1, err)
+	}
+	return result, nil
+}
+
+// envelope decodes and validates a line and builds its event.
+func (im *Importer) envelope(ctx context.Context, line []byte) (*events.Envelope, error) {
+	var rec Record
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := validate(&rec); err != nil {
+		return nil, err
+	}
+	if im.config.EventTypes != nil {
+		problem, err := im.config.EventTypes.Check(rec.EventType)
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			im.logger.Warn("unexpected event type, accepted",
+				"event_type", rec.EventType,
+				"aggregate_id", rec.AggregateID,
+				"problem", problem,
+			)
+		}
+	}
+
+	payload := rec.Payload
+	if im.config.Payload != nil && events.IsJSONContentType(rec.ContentType) {
+		transformed, err := im.config.Payload.Transform(ctx, rec.EventType, payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform payload: %w", err)
+		}
+		payload = transformed
+	}
+
+	schemaVersion := rec.SchemaVersion
+	if schemaVersion == 0 {
+		schemaVersion = events.CurrentSchemaVersion
+	}
+	contentType := rec.ContentType
+	if contentType == events.ContentTypeJSON {
+		contentType = ""
+	}
+	event, err := events.NewEnvelope(rec.EventType, rec.AggregateID, payload, events.Metadata{
+		TraceID:       rec.TraceID,
+		CorrelationID: rec.CorrelationID,
+		Source:        Source,
+		SchemaVersion: schemaVersion,
+		ContentType:   contentType,
+		Replay:        true,
+	}, *rec.EventTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event envelope: %w", err)
+	}
+	event.AggregateType = rec.AggregateType
+	if rec.EventID != "" {
+		if event.EventID, err = uuid.FromString(rec.EventID); err != nil {
+			return nil, fmt.Errorf("invalid event_id %q: %w", rec.EventID, err)
+		}
+	}
+	for _, enricher := range im.config.Enrichers {
+		if err := enricher.Enrich(ctx, event); err != nil {
+			return nil, fmt.Errorf("failed to enrich event: %w", err)
+		}
+	}
+	if im.config.Signer != nil {
+		if err := im.config.Signer.Sign(event); err != nil {
+			return nil, fmt.Errorf("failed to sign event: %w", err)
+		}
+	}
+	return event, nil
+}
+
+// importEvent stores and publishes an event, reporting whether it was
+// already stored. An event stored by an earlier run is published as stored:
+// the envelope built by this run has its own ingestion time, signature and
+// PII ciphertext, and consumers must never see two versions of one event ID.
+func (im *Importer) importEvent(ctx context.Context, event *events.Envelope) (bool, error) {
+	var existing bool
+	if err := im.store.Insert(ctx, event); err != nil {
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+			return false, err
+		}
+		// Stored by an earlier run, which may have stopped before publishing
+		stored, err := im.store.Get(ctx, event.EventID)
+		if err != nil {
+			return false, err
+		}
+		event, existing = stored, true
+	}
+
+	topic := im.config.Topic
+	if topic == "" {
+		topic = im.router.Topic(event.EventType)
+	}
+	if err := im.publisher.Publish(ctx, topic, event); err != nil {
+		return existing, fmt.Errorf("failed to publish event %s: %w", event.EventID, err)
+	}
+	return existing, nil
+}
+
+// validate checks a record as the ingestion API checks a request, and that
+// it carries an event time.
+func validate(rec *Record) error {
+	if rec.EventType == "" {
+		return fmt.Errorf("event_type is required")
+	}
+	if rec.AggregateID == "" {
+		return fmt.Errorf("aggregate_id is required")
+	}
+	if rec.EventTime == nil {
+		return fmt.Errorf("event_time is required")
+	}
+	if err := events.ValidateAggregateType(rec.AggregateType); err != nil {
+		return err
+	}
+	if rec.SchemaVersion < 0 || rec.SchemaVersion > events.CurrentSchemaVersion {
+		return fmt.Errorf("schema_version %d is not supported (expected 1 to %d)", rec.SchemaVersion, events.CurrentSchemaVersion)
+	}
+	if len(rec.Payload) == 0 {
+		return fmt.Errorf("payload is required")
+	}
+	if err := events.ValidateContentType(rec.ContentType); err != nil {
+		return err
+	}
+	if !events.IsJSONContentType(rec.ContentType) {
+		if _, err := events.DecodeBinaryPayload(rec.Payload); err != nil {
+			return fmt.Errorf("payload of content type %s: %w", rec.ContentType, err)
+		}
+	}
+	return nil
+}
+
+// throttle spaces calls to wait at least 1/rate seconds apart, on the
+// process clock (clock.Now and clock.NewTimer).
+type throttle struct {
+	interval time.Duration // 0 does not throttle
+	next     time.Time
+}
+
+func newThrottle(rate float64) *throttle {
+	if rate <= 0 {
+		return &throttle{}
+	}
+	return &throttle{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// wait blocks until the next call is due, or ctx is done.
+func (t *throttle) wait(ctx context.Context) error {
+	if t.interval == 0 {
+		return ctx.Err()
+	}
+	now := clock.Now()
+	if t.next.After(now) {
+		timer := clock.NewTimer(t.next.Sub(now))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C():
+		}
+		now = t.next
+	}
+	t.next = now.Add(t.interval)
+	return nil
+}
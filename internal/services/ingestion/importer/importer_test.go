@@ -0,0 +1,179 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ehclient "github.com/cornjacket/platform-services/internal/client/eventhandler"
+	"github.com/cornjacket/platform-services/internal/services/ingestion"
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/pkg/fakes"
+)
+
+const file = `{"event_id":"0192a7f0-0000-7000-8000-000000000001","event_type":"sensor.reading","aggregate_id":"device-001","event_time":"2025-01-01T00:00:00Z","payload":{"value":70.1}}
+
+{"event_id":"0192a7f0-0000-7000-8000-000000000002","event_type":"user.login","aggregate_id":"user-1","aggregate_type":"user","event_time":"2025-01-01T00:05:00Z","correlation_id":"c1","payload":{}}
+`
+
+func TestRun(t *testing.T) {
+	store := fakes.NewEventStore()
+	publisher := fakes.NewPublisher()
+
+	result, err := New(store, publisher, ehclient.DefaultRouter(), Config{}, slog.Default()).Run(context.Background(), strings.NewReader(file))
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Lines: 3, Imported: 2}, result)
+
+	stored := store.Events()
+	require.Len(t, stored, 2)
+	assert.Equal(t, "0192a7f0-0000-7000-8000-000000000001", stored[0].EventID.String())
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), stored[0].EventTime.UTC())
+	assert.True(t, stored[0].Metadata.Replay)
+	assert.Equal(t, Source, stored[0].Metadata.Source)
+	assert.Equal(t, "user", stored[1].AggregateType)
+	assert.Equal(t, "c1", stored[1].Metadata.CorrelationID)
+
+	// Published to the routed topics, with the store's sequence numbers
+	sensor := publisher.Records("sensor-events")
+	require.Len(t, sensor, 1)
+	assert.Equal(t, int64(1), sensor[0].Event.GlobalSeq)
+	assert.Len(t, publisher.Records("user-actions"), 1)
+}
+
+func TestRun_DedicatedTopic(t *testing.T) {
+	publisher := fakes.NewPublisher()
+
+	_, err := New(fakes.NewEventStore(), publisher, nil, Config{Topic: "import-events"}, slog.Default()).Run(context.Background(), strings.NewReader(file))
+	require.NoError(t, err)
+	assert.Len(t, publisher.Records("import-events"), 2)
+	assert.Empty(t, publisher.Records("sensor-events"))
+}
+
+func TestRun_Rerun(t *testing.T) {
+	store := fakes.NewEventStore()
+	publisher := fakes.NewPublisher()
+	// The first run stores the second event but fails to publish it
+	publisher.Err = func(topic string, event *events.Envelope) error {
+		if event.EventType == "user.login" {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}
+	im := New(store, publisher, ehclient.DefaultRouter(), Config{}, slog.Default())
+	first := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: first})
+	t.Cleanup(clock.Reset)
+
+	result, err := im.Run(context.Background(), strings.NewReader(file))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3: failed to publish event")
+	assert.Equal(t, &Result{Lines: 2, Imported: 1}, result)
+
+	publisher.Err = nil
+	im.config.Skip = result.Lines
+	clock.Set(clock.FixedClock{Time: first.Add(time.Hour)})
+	result, err = im.Run(context.Background(), strings.NewReader(file))
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Lines: 3, Imported: 1, Existing: 1}, result)
+	assert.Len(t, store.Events(), 2)
+
+	// The event is published as the first run stored it, not as rebuilt
+	published := publisher.Records("user-actions")
+	require.Len(t, published, 1)
+	assert.Equal(t, first, published[0].Event.IngestedAt.UTC())
+	assert.Equal(t, int64(2), published[0].Event.GlobalSeq)
+}
+
+func TestRun_InvalidLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantErr string
+	}{
+		{name: "bad JSON", line: `{"event_type":`, wantErr: "invalid JSON"},
+		{name: "no event time", line: `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{}}`, wantErr: "event_time is required"},
+		{name: "bad event ID", line: `{"event_id":"42","event_type":"sensor.reading","aggregate_id":"device-001","event_time":"2025-01-01T00:00:00Z","payload":{}}`, wantErr: `invalid event_id "42"`},
+		{name: "bad aggregate type", line: `{"event_type":"sensor.reading","aggregate_id":"device-001","aggregate_type":"Device","event_time":"2025-01-01T00:00:00Z","payload":{}}`, wantErr: "invalid aggregate type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := fakes.NewEventStore()
+			input := strings.SplitN(file, "\n", 2)[0] + "\n" + tt.line + "\n"
+
+			result, err := New(store, fakes.NewPublisher(), ehclient.DefaultRouter(), Config{}, slog.Default()).Run(context.Background(), strings.NewReader(input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "line 2: "+tt.wantErr)
+			assert.Equal(t, 1, result.Lines)
+			assert.Len(t, store.Events(), 1)
+		})
+	}
+}
+
+func TestRun_IngestionRules(t *testing.T) {
+	store := fakes.NewEventStore()
+	config := Config{
+		EventTypes: ingestion.EventTypePolicy{Known: []string{"sensor.*"}, Strict: true},
+		Enrichers: []Enricher{ingestion.EnricherFunc(func(ctx context.Context, event *events.Envelope) error {
+			event.Metadata.Attributes = map[string]string{"site": "plant-a"}
+			return nil
+		})},
+	}
+
+	result, err := New(store, fakes.NewPublisher(), ehclient.DefaultRouter(), config, slog.Default()).Run(context.Background(), strings.NewReader(file))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ingestion.ErrEventTypeRejected)
+	assert.Contains(t, err.Error(), "line 3: ")
+	assert.Equal(t, 2, result.Lines)
+
+	stored := store.Events()
+	require.Len(t, stored, 1)
+	assert.Equal(t, map[string]string{"site": "plant-a"}, stored[0].Metadata.Attributes)
+}
+
+func TestRun_DryRun(t *testing.T) {
+	store := fakes.NewEventStore()
+
+	result, err := New(store, nil, nil, Config{DryRun: true}, slog.Default()).Run(context.Background(), strings.NewReader(file))
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Lines: 3, Imported: 2}, result)
+	assert.Empty(t, store.Events())
+}
+
+func TestRun_Rate(t *testing.T) {
+	c := clock.NewManualClock(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	clock.Set(c)
+	t.Cleanup(clock.Reset)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	publisher := fakes.NewPublisher()
+	line := `{"event_type":"sensor.reading","aggregate_id":"device-001","event_time":"2025-01-01T00:00:00Z","payload":{}}`
+	input := strings.Repeat(line+"\n", 5)
+
+	done := make(chan *Result, 1)
+	go func() {
+		result, err := New(fakes.NewEventStore(), publisher, ehclient.DefaultRouter(), Config{Rate: 100}, slog.Default()).Run(ctx, strings.NewReader(input))
+		assert.NoError(t, err)
+		done <- result
+	}()
+
+	// The first event is published at once, each later one 10ms after the last
+	for i := 2; i <= 5; i++ {
+		require.NoError(t, c.WaitForTimers(ctx, 1))
+		assert.Len(t, publisher.Records("sensor-events"), i-1)
+		c.Add(9 * time.Millisecond)
+		assert.Len(t, publisher.Records("sensor-events"), i-1, "published before its interval")
+		c.Add(time.Millisecond)
+	}
+	result := <-done
+	assert.Equal(t, 5, result.Imported)
+	assert.Len(t, publisher.Records("sensor-events"), 5)
+}
@@ -11,19 +11,108 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/cornjacket/platform-services/internal/services/ingestion/worker"
+	"github.com/cornjacket/platform-services/internal/shared/audit"
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/httpserver"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
 	"github.com/cornjacket/platform-services/internal/shared/infra/postgres"
+	"github.com/cornjacket/platform-services/internal/shared/leader"
+	"github.com/cornjacket/platform-services/internal/shared/middleware"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
 )
 
 // Config holds configuration for the ingestion service.
 type Config struct {
-	Port         int
-	WorkerCount  int
-	BatchSize    int
-	MaxRetries   int
-	PollInterval time.Duration
-	DatabaseURL  string // needed for dedicated LISTEN connection (separate from pool)
+	Port          int
+	Server        httpserver.Config
+	WorkerCount   int
+	BatchSize     int
+	MaxRetries    int
+	PollInterval  time.Duration
+	AsyncSubmit   bool   // submit via the async path when the submitter supports it
+	Transactional bool   // publish each batch in one Kafka transaction (see worker.ProcessorConfig)
+	DatabaseURL   string // needed for dedicated LISTEN connection (separate from pool)
+
+	// CompressionLevel compresses responses with gzip or deflate at this
+	// level, 1 to 9 (see middleware.Compress); 0 disables compression.
+	CompressionLevel int
+
+	// Outbox table maintenance (see worker.Maintenance). Zero interval disables it.
+	MaintenanceInterval time.Duration
+	VacuumMinDeadRows   int64
+	ReindexInterval     time.Duration
+
+	// Archive moves published entries to outbox_archive instead of deleting
+	// them; maintenance purges them after ArchiveRetention.
+	Archive          bool
+	ArchiveRetention time.Duration
+
+	// TestAPIKeys are API keys whose events are marked as test traffic.
+	TestAPIKeys []string
+	// ReplayAPIKeys are API keys allowed to mark events as replays, in
+	// addition to keys with a replay scope in Policy.
+	ReplayAPIKeys []string
+	// Policy restricts the event types each API key may ingest; nil allows
+	// every request.
+	Policy *auth.Policy
+	// Audit records every ingest request; nil disables auditing.
+	Audit audit.Log
+	// Payload rewrites payloads before they are stored (PII protection);
+	// nil stores them as sent.
+	Payload PayloadTransformer
+	// Signer signs every event before it is stored; nil leaves events
+	// unsigned.
+	Signer *signing.Signer
+	// Enrichers add to every event before it is signed (see Enricher).
+	Enrichers []Enricher
+
+	// Leader runs the outbox worker and maintenance on one replica at a time
+	// (lock LeaderRole); nil runs them on this instance unconditionally.
+	Leader *leader.Elector
+
+	// Backpressure refuses events while the outbox backlog is over its
+	// thresholds; a zero config accepts events regardless.
+	Backpressure BackpressureConfig
+
+	// EventTime bounds the event_time producers may send; a zero policy
+	// accepts any.
+	EventTime EventTimePolicy
+
+	// EventTypes checks event types against the naming rules and a registry
+	// of known types; a zero policy accepts any.
+	EventTypes EventTypePolicy
+
+	// Dedup stores events whose content matches an event accepted within
+	// the window only once; a zero config stores every event.
+	Dedup DedupConfig
+
+	// IdempotencyWindow is how long the idempotency keys of v2 requests are
+	// kept (see Service.SetIdempotency); 0 refuses requests with a key.
+	IdempotencyWindow time.Duration
+
+	// Timeouts bounds the store work of each ingest (see
+	// Service.SetTimeouts); zero sets no deadlines.
+	Timeouts timeout.Policy
+
+	// SyncTimeout bounds how long a ?mode=sync request waits for its event
+	// to be published; 0 disables sync mode.
+	SyncTimeout time.Duration
+
+	// DB wraps pool with retries and a circuit breaker for the outbox and
+	// event store repositories; nil queries pool directly.
+	DB *pgretry.DB
+
+	// InsertHooks run after each event is committed to the outbox (optional).
+	// Embedders use them for acceptance-time side channels, e.g. pushing an
+	// "accepted" notice to connected clients or recording local metrics.
+	InsertHooks []InsertHook
 }
 
+// LeaderRole names the lock that elects the replica running the outbox
+// worker and maintenance.
+const LeaderRole = "ingestion-outbox"
+
 // RunningService represents a started ingestion service.
 type RunningService struct {
 	// Shutdown stops the HTTP server and worker gracefully.
@@ -40,6 +129,12 @@ func Start(ctx context.Context, cfg Config, pool *pgxpool.Pool, submitter worker
 	outboxRepo := postgres.NewOutboxRepo(pool, logger)
 	eventStoreRepo := postgres.NewEventStoreRepo(pool, logger)
 	outboxReader := postgres.NewOutboxReaderAdapter(pool, logger)
+	outboxReader.SetArchive(cfg.Archive)
+	if cfg.DB != nil {
+		outboxRepo.SetQuerier(cfg.DB)
+		eventStoreRepo.SetQuerier(cfg.DB)
+		outboxReader.SetQuerier(cfg.DB)
+	}
 
 	// Create dedicated LISTEN connection (not from pool — holds connection open indefinitely)
 	listenConn, err := pgx.Connect(ctx, cfg.DatabaseURL)
@@ -49,18 +144,55 @@ func Start(ctx context.Context, cfg Config, pool *pgxpool.Pool, submitter worker
 
 	// Wire service → handler → routes → HTTP server
 	svc := NewService(outboxRepo, logger)
+	if cfg.Payload != nil {
+		svc.SetPayloadTransformer(cfg.Payload)
+	}
+	if cfg.Signer != nil {
+		svc.SetSigner(cfg.Signer)
+	}
+	svc.SetEventTimePolicy(cfg.EventTime)
+	svc.SetEventTypePolicy(cfg.EventTypes)
+	svc.SetTimeouts(cfg.Timeouts)
+	if cfg.Dedup.Window > 0 {
+		svc.SetDedup(outboxRepo, cfg.Dedup)
+	}
+	if cfg.IdempotencyWindow > 0 {
+		svc.SetIdempotency(outboxRepo, cfg.IdempotencyWindow)
+	}
+	for _, enricher := range cfg.Enrichers {
+		svc.AddEnricher(enricher)
+	}
+	for _, hook := range cfg.InsertHooks {
+		svc.AddInsertHook(hook)
+	}
 	handler := NewHandler(svc, logger)
+	handler.SetTestAPIKeys(cfg.TestAPIKeys)
+	handler.SetReplayAPIKeys(cfg.ReplayAPIKeys)
+	handler.SetPolicy(cfg.Policy)
+	recorder := audit.NewRecorder(cfg.Audit, "ingestion", logger)
+	recorder.SetPolicy(cfg.Policy)
+	handler.SetAudit(recorder)
+	var backpressure *Backpressure
+	if cfg.Backpressure.Enabled() {
+		bpConfig := cfg.Backpressure
+		bpConfig.MaxRetries = cfg.MaxRetries
+		backpressure = NewBackpressure(outboxRepo, bpConfig, logger)
+		handler.SetBackpressure(backpressure)
+	}
+	if cfg.SyncTimeout > 0 {
+		handler.SetPublishWaiter(NewPublishWaiter(outboxRepo, cfg.MaxRetries, logger), cfg.SyncTimeout)
+	}
 
 	mux := http.NewServeMux()
 	handler.RegisterRoutes(mux)
+	mux.Handle("/readyz", postgres.ReadyHandler(pool, logger))
 
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Port),
-		Handler:      mux,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	serverConfig := cfg.Server
+	if serverConfig.WriteTimeout > 0 {
+		// Sync requests wait for the publish before writing
+		serverConfig.WriteTimeout = max(serverConfig.WriteTimeout, cfg.SyncTimeout+5*time.Second)
 	}
+	server := httpserver.New(cfg.Port, middleware.Wrap(middleware.Compress(mux, cfg.CompressionLevel), logger), serverConfig)
 
 	// Wire outbox worker
 	proc := worker.NewProcessor(
@@ -69,10 +201,12 @@ func Start(ctx context.Context, cfg Config, pool *pgxpool.Pool, submitter worker
 		submitter,
 		listenConn,
 		worker.ProcessorConfig{
-			WorkerCount:  cfg.WorkerCount,
-			BatchSize:    cfg.BatchSize,
-			MaxRetries:   cfg.MaxRetries,
-			PollInterval: cfg.PollInterval,
+			WorkerCount:   cfg.WorkerCount,
+			BatchSize:     cfg.BatchSize,
+			MaxRetries:    cfg.MaxRetries,
+			PollInterval:  cfg.PollInterval,
+			AsyncSubmit:   cfg.AsyncSubmit,
+			Transactional: cfg.Transactional,
 		},
 		logger,
 	)
@@ -86,19 +220,67 @@ func Start(ctx context.Context, cfg Config, pool *pgxpool.Pool, submitter worker
 		}
 	}()
 
-	// Start outbox worker
-	go func() {
-		if err := proc.Start(ctx); err != nil {
-			logger.Error("ingestion worker error", "error", err)
-			errorCh <- fmt.Errorf("ingestion worker failed: %w", err)
+	// Outbox maintenance (stops with the worker; an interrupted VACUUM is harmless)
+	var maintenance *worker.Maintenance
+	if cfg.MaintenanceInterval > 0 {
+		maintenance = worker.NewMaintenance(outboxRepo, worker.MaintenanceConfig{
+			Interval:        cfg.MaintenanceInterval,
+			MinDeadRows:     cfg.VacuumMinDeadRows,
+			ReindexInterval: cfg.ReindexInterval,
+		}, logger)
+		if cfg.Archive {
+			maintenance.SetArchive(outboxRepo, cfg.ArchiveRetention)
+		}
+		if cfg.Dedup.Window > 0 || cfg.IdempotencyWindow > 0 {
+			maintenance.SetDedup(outboxRepo)
+		}
+	} else {
+		if cfg.Archive {
+			logger.Warn("outbox archive enabled without maintenance, archive retention is not enforced")
 		}
+		if cfg.Dedup.Window > 0 || cfg.IdempotencyWindow > 0 {
+			logger.Warn("ingestion dedup enabled without maintenance, expired hashes are not purged")
+		}
+	}
+
+	// Start outbox worker and maintenance on the leader (own context so
+	// Shutdown can drain them before returning)
+	workerCtx, workerCancel := context.WithCancel(ctx)
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		cfg.Leader.Run(workerCtx, LeaderRole, func(ctx context.Context) {
+			if maintenance != nil {
+				go maintenance.Run(ctx)
+			}
+			if err := proc.Start(ctx); err != nil {
+				logger.Error("ingestion worker error", "error", err)
+				errorCh <- fmt.Errorf("ingestion worker failed: %w", err)
+			}
+		})
 	}()
 
+	// Backpressure watches the backlog on every replica, leader or not
+	if backpressure != nil {
+		go backpressure.Run(workerCtx)
+	}
+
 	return &RunningService{
 		Shutdown: func(shutdownCtx context.Context) error {
 			logger.Info("shutting down ingestion service")
+			err := server.Shutdown(shutdownCtx)
+
+			// Stop the worker and wait for in-flight submissions to finish,
+			// so the caller can safely flush the downstream producer afterwards.
+			workerCancel()
+			select {
+			case <-workerDone:
+			case <-shutdownCtx.Done():
+				logger.Warn("ingestion worker did not drain before shutdown deadline")
+			}
+
 			listenConn.Close(shutdownCtx)
-			return server.Shutdown(shutdownCtx)
+			return err
 		},
 	}, nil
 }
@@ -25,12 +25,12 @@ import (
 var testPool *pgxpool.Pool
 
 func TestMain(m *testing.M) {
-	pool := testutil.MustNewTestPool()
-	testutil.MustDropAllTables(pool)
+	pool, drop := testutil.MustNewSchemaPool("ingestion")
 	testutil.MustRunMigrations(pool, "migrations")
 	testPool = pool
-	defer pool.Close()
-	os.Exit(m.Run())
+	code := m.Run()
+	drop()
+	os.Exit(code)
 }
 
 // channelSubmitter captures events submitted by the ingestion worker.
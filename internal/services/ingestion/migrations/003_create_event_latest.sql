@@ -0,0 +1,57 @@
+-- +goose Up
+-- Compacted "latest event per aggregate per type" view of event_store
+-- Lets a new projection handler bootstrap current state from one row per
+-- (event_type, aggregate_id) and then tail live events, instead of replaying
+-- the entire event_store history.
+--
+-- Maintained by a trigger on event_store so it can never drift from the log.
+-- Ordering matches projections: event_time, then event_id as tiebreaker.
+
+CREATE TABLE IF NOT EXISTS event_latest (
+    event_type VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    event_id UUID NOT NULL,
+    event_time TIMESTAMPTZ NOT NULL,
+    ingested_at TIMESTAMPTZ NOT NULL,
+    payload JSONB NOT NULL,
+    metadata JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Also serves keyset pagination during bootstrap
+    PRIMARY KEY (event_type, aggregate_id)
+);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION upsert_event_latest()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO event_latest (event_type, aggregate_id, event_id, event_time, ingested_at, payload, metadata, updated_at)
+    VALUES (NEW.event_type, NEW.aggregate_id, NEW.event_id, NEW.event_time, NEW.ingested_at, NEW.payload, NEW.metadata, NOW())
+    ON CONFLICT (event_type, aggregate_id) DO UPDATE
+    SET event_id = EXCLUDED.event_id,
+        event_time = EXCLUDED.event_time,
+        ingested_at = EXCLUDED.ingested_at,
+        payload = EXCLUDED.payload,
+        metadata = EXCLUDED.metadata,
+        updated_at = NOW()
+    WHERE event_latest.event_time < EXCLUDED.event_time
+       OR (event_latest.event_time = EXCLUDED.event_time
+           AND event_latest.event_id < EXCLUDED.event_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS event_store_latest_trigger ON event_store;
+CREATE TRIGGER event_store_latest_trigger
+    AFTER INSERT ON event_store
+    FOR EACH ROW
+    EXECUTE FUNCTION upsert_event_latest();
+
+-- Backfill from existing history
+INSERT INTO event_latest (event_type, aggregate_id, event_id, event_time, ingested_at, payload, metadata)
+SELECT DISTINCT ON (event_type, aggregate_id)
+       event_type, aggregate_id, event_id, event_time, ingested_at, payload, metadata
+FROM event_store
+ORDER BY event_type, aggregate_id, event_time DESC, event_id DESC
+ON CONFLICT (event_type, aggregate_id) DO NOTHING;
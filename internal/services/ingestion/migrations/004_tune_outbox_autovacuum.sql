@@ -0,0 +1,13 @@
+-- +goose Up
+-- The outbox is insert-then-delete churn: every row becomes a dead tuple within
+-- seconds. The default autovacuum trigger (20% of the table dead) is reached
+-- late on a table that is usually near-empty but turns over constantly, so
+-- vacuum on a fixed dead-row count instead of a fraction of the table.
+-- Complements the outbox maintenance runner (worker.Maintenance).
+
+ALTER TABLE outbox SET (
+    autovacuum_vacuum_scale_factor = 0,
+    autovacuum_vacuum_threshold = 1000,
+    autovacuum_analyze_scale_factor = 0,
+    autovacuum_analyze_threshold = 1000
+);
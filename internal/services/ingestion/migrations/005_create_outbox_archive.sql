@@ -0,0 +1,17 @@
+-- +goose Up
+-- Archive of published outbox entries (optional, CJ_OUTBOX_ARCHIVE).
+-- When archiving is enabled the processor moves each row here instead of
+-- deleting it, recording when it was published. published_at - created_at is
+-- the publish latency; retry_count shows how many attempts it took.
+-- Rows older than CJ_OUTBOX_ARCHIVE_RETENTION are purged by outbox maintenance.
+
+CREATE TABLE IF NOT EXISTS outbox_archive (
+    outbox_id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    published_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    event_payload JSONB NOT NULL,
+    retry_count INTEGER NOT NULL
+);
+
+-- Index for retention purges and latency queries over a time window
+CREATE INDEX IF NOT EXISTS idx_outbox_archive_published_at ON outbox_archive (published_at);
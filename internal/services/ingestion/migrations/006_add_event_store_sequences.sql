@@ -0,0 +1,75 @@
+-- +goose Up
+-- Sequence numbers on event_store for reliable catch-up reads
+-- ("everything after global_seq X") without relying on timestamps.
+--
+-- global_seq:    monotonically increasing across the whole store, in insert order
+-- aggregate_seq: 1, 2, 3, ... per aggregate_id, in insert order (not event_time order)
+-- insert_xid:    inserting transaction; readers only return rows older than every
+--                in-flight transaction, so a lower global_seq can never commit
+--                after a reader has moved past it (see EventStoreRepo.FetchAfter)
+
+CREATE SEQUENCE IF NOT EXISTS event_store_global_seq;
+
+ALTER TABLE event_store
+    ADD COLUMN IF NOT EXISTS global_seq BIGINT,
+    ADD COLUMN IF NOT EXISTS aggregate_seq BIGINT,
+    ADD COLUMN IF NOT EXISTS insert_xid xid8 NOT NULL DEFAULT '0';
+
+-- Existing rows were committed long ago; '0' keeps them visible to readers
+ALTER TABLE event_store ALTER COLUMN insert_xid SET DEFAULT pg_current_xact_id();
+
+-- Per-aggregate counters. The upsert row lock serializes concurrent inserts
+-- for one aggregate, so aggregate_seq has no duplicates; a failed insert rolls
+-- its increment back with it, so there are no gaps either.
+CREATE TABLE IF NOT EXISTS event_aggregate_seq (
+    aggregate_id VARCHAR(255) PRIMARY KEY,
+    last_seq BIGINT NOT NULL
+);
+
+-- Backfill existing history in ingestion order
+WITH numbered AS (
+    SELECT event_id,
+           row_number() OVER (ORDER BY ingested_at, event_id) AS seq,
+           row_number() OVER (PARTITION BY aggregate_id ORDER BY ingested_at, event_id) AS agg_seq
+    FROM event_store
+)
+UPDATE event_store e
+SET global_seq = n.seq, aggregate_seq = n.agg_seq
+FROM numbered n
+WHERE e.event_id = n.event_id;
+
+SELECT setval('event_store_global_seq', COALESCE(MAX(global_seq), 1), MAX(global_seq) IS NOT NULL)
+FROM event_store;
+
+INSERT INTO event_aggregate_seq (aggregate_id, last_seq)
+SELECT aggregate_id, MAX(aggregate_seq) FROM event_store GROUP BY aggregate_id
+ON CONFLICT (aggregate_id) DO NOTHING;
+
+ALTER TABLE event_store
+    ALTER COLUMN global_seq SET DEFAULT nextval('event_store_global_seq'),
+    ALTER COLUMN global_seq SET NOT NULL,
+    ALTER COLUMN aggregate_seq SET NOT NULL;
+ALTER SEQUENCE event_store_global_seq OWNED BY event_store.global_seq;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_store_global_seq ON event_store (global_seq);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_store_aggregate_seq ON event_store (aggregate_id, aggregate_seq);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION assign_aggregate_seq()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO event_aggregate_seq (aggregate_id, last_seq)
+    VALUES (NEW.aggregate_id, 1)
+    ON CONFLICT (aggregate_id) DO UPDATE
+    SET last_seq = event_aggregate_seq.last_seq + 1
+    RETURNING last_seq INTO NEW.aggregate_seq;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS event_store_aggregate_seq_trigger ON event_store;
+CREATE TRIGGER event_store_aggregate_seq_trigger
+    BEFORE INSERT ON event_store
+    FOR EACH ROW
+    EXECUTE FUNCTION assign_aggregate_seq();
@@ -0,0 +1,39 @@
+-- +goose Up
+-- Audit log: one row per write request (ingests, admin operations, rule
+-- changes) from every service, recording who made it and its outcome.
+-- Append-only: a trigger rejects UPDATE and DELETE.
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    record_id UUID PRIMARY KEY,
+    service VARCHAR(64) NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    actor VARCHAR(64) NOT NULL,           -- API key fingerprint or "anonymous"
+    method VARCHAR(16) NOT NULL,
+    path TEXT NOT NULL,
+    target TEXT NOT NULL DEFAULT '',      -- e.g. event_id, rule_id, aggregate_id
+    detail TEXT NOT NULL DEFAULT '',
+    status INT NOT NULL,
+    outcome VARCHAR(16) NOT NULL,         -- succeeded, rejected, failed
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    remote_addr VARCHAR(255) NOT NULL DEFAULT '',
+    recorded_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_recorded_at ON audit_log (recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log (target) WHERE target <> '';
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_log_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS audit_log_append_only_trigger ON audit_log;
+CREATE TRIGGER audit_log_append_only_trigger
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW
+    EXECUTE FUNCTION audit_log_append_only();
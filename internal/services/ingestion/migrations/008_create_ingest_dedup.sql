@@ -0,0 +1,15 @@
+-- +goose Up
+-- Content hashes of recently ingested events (optional, CJ_INGESTION_DEDUP_WINDOW).
+-- An event whose hash is present and unexpired is a duplicate of event_id;
+-- the claim and the outbox insert happen in one statement, so a hash never
+-- points at an event that was not accepted.
+-- Expired rows are purged by outbox maintenance.
+
+CREATE TABLE IF NOT EXISTS ingest_dedup (
+    content_hash BYTEA PRIMARY KEY,
+    event_id UUID NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Index for purging expired hashes
+CREATE INDEX IF NOT EXISTS idx_ingest_dedup_expires_at ON ingest_dedup (expires_at);
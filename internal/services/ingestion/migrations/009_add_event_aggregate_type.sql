@@ -0,0 +1,32 @@
+-- +goose Up
+-- Aggregate type (e.g. device, session) alongside aggregate_id, so aggregates
+-- of different domains that share an ID can be told apart and filtered on.
+-- Optional: events ingested without one, and all earlier history, have ''.
+
+ALTER TABLE event_store ADD COLUMN IF NOT EXISTS aggregate_type VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE event_latest ADD COLUMN IF NOT EXISTS aggregate_type VARCHAR(64) NOT NULL DEFAULT '';
+
+-- Catch-up reads of one aggregate type (GET /api/v1/events?aggregate_type=)
+CREATE INDEX IF NOT EXISTS idx_event_store_aggregate_type_seq ON event_store (aggregate_type, global_seq);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION upsert_event_latest()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO event_latest (event_type, aggregate_id, aggregate_type, event_id, event_time, ingested_at, payload, metadata, updated_at)
+    VALUES (NEW.event_type, NEW.aggregate_id, NEW.aggregate_type, NEW.event_id, NEW.event_time, NEW.ingested_at, NEW.payload, NEW.metadata, NOW())
+    ON CONFLICT (event_type, aggregate_id) DO UPDATE
+    SET aggregate_type = EXCLUDED.aggregate_type,
+        event_id = EXCLUDED.event_id,
+        event_time = EXCLUDED.event_time,
+        ingested_at = EXCLUDED.ingested_at,
+        payload = EXCLUDED.payload,
+        metadata = EXCLUDED.metadata,
+        updated_at = NOW()
+    WHERE event_latest.event_time < EXCLUDED.event_time
+       OR (event_latest.event_time = EXCLUDED.event_time
+           AND event_latest.event_id < EXCLUDED.event_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Tenant of the API key that made each audited request, from the key's
+-- tenant: entry in CJ_API_KEYS. Records of keys without one, and all earlier
+-- records, have ''.
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS tenant VARCHAR(255) NOT NULL DEFAULT '';
+
+-- A tenant's audit trail, newest first
+CREATE INDEX IF NOT EXISTS idx_audit_log_tenant ON audit_log (tenant, recorded_at DESC) WHERE tenant <> '';
@@ -2,7 +2,10 @@ package ingestion
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
+	"github.com/cornjacket/platform-services/internal/services/ingestion/worker"
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 )
 
@@ -14,3 +17,30 @@ type OutboxRepository interface {
 	// Returns the outbox entry ID on success.
 	Insert(ctx context.Context, event *events.Envelope) error
 }
+
+// UniqueOutboxRepository inserts events into the outbox unless their content
+// was already accepted within a window (see DedupConfig).
+type UniqueOutboxRepository interface {
+	// InsertUnique returns the ID of the event that owns hash: event's own
+	// when it was inserted, an earlier event's when it is a duplicate.
+	InsertUnique(ctx context.Context, event *events.Envelope, hash []byte, window time.Duration) (string, error)
+}
+
+// BacklogReader measures the unpublished outbox (see Backpressure).
+type BacklogReader interface {
+	// Backlog counts pending entries up to limit, skipping entries that used
+	// up maxRetries, and reports the age of the oldest.
+	Backlog(ctx context.Context, limit int64, maxRetries int) (*worker.OutboxBacklog, error)
+}
+
+// PayloadTransformer rewrites payloads before they are persisted, e.g. to
+// hash, redact or encrypt personal data (see shared/pii.Transformer).
+type PayloadTransformer interface {
+	Transform(ctx context.Context, eventType string, payload json.RawMessage) (json.RawMessage, error)
+}
+
+// PublishStatusReader follows an accepted event through the outbox worker
+// (see PublishWaiter).
+type PublishStatusReader interface {
+	PublishStatus(ctx context.Context, eventID string) (*worker.PublishStatus, error)
+}
@@ -1,9 +1,22 @@
 package ingestion
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/cornjacket/platform-services/api/openapi"
+	"github.com/cornjacket/platform-services/internal/shared/apiversion"
+)
+
+// spec is the ingestion API contract: served at /openapi.json and enforced on
+// incoming requests.
+var spec = openapi.MustLoad(openapi.Ingestion)
 
 // RegisterRoutes registers the ingestion service routes on the provided mux.
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/v1/events", h.HandleIngest)
+	apiversion.Register(mux, "/events",
+		apiversion.Version{Name: "v1", Handler: h.audit.Wrap("ingest", spec.Validate(http.HandlerFunc(h.HandleIngest)))},
+		apiversion.Version{Name: "v2", Handler: h.audit.Wrap("ingest", spec.Validate(http.HandlerFunc(h.HandleIngestV2)))},
+	)
 	mux.HandleFunc("/health", h.HandleHealth)
+	mux.HandleFunc("/openapi.json", spec.ServeJSON)
 }
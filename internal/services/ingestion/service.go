@@ -3,18 +3,57 @@ package ingestion
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
 )
 
+// InsertHook is notified after an event has been committed to the outbox,
+// i.e. at acceptance time. Hooks run synchronously on the request path, so
+// slow work (network pushes, etc.) should be handed off to a goroutine.
+// The event is already durable; hooks cannot reject it.
+type InsertHook interface {
+	AfterInsert(ctx context.Context, event *events.Envelope)
+}
+
+// InsertHookFunc adapts a plain function to InsertHook.
+type InsertHookFunc func(ctx context.Context, event *events.Envelope)
+
+// AfterInsert calls f(ctx, event).
+func (f InsertHookFunc) AfterInsert(ctx context.Context, event *events.Envelope) {
+	f(ctx, event)
+}
+
+// SkipTest wraps a hook so it is not called for test traffic. Use it for
+// hooks that feed usage metering or dashboards.
+func SkipTest(hook InsertHook) InsertHook {
+	return InsertHookFunc(func(ctx context.Context, event *events.Envelope) {
+		if !event.Metadata.Test {
+			hook.AfterInsert(ctx, event)
+		}
+	})
+}
+
 // Service handles event ingestion business logic.
 type Service struct {
-	outbox OutboxRepository
-	logger *slog.Logger
+	outbox      OutboxRepository
+	hooks       []InsertHook
+	enrichers   []Enricher
+	transformer PayloadTransformer     // nil stores payloads as sent
+	signer      *signing.Signer        // nil leaves events unsigned
+	unique      UniqueOutboxRepository // nil disables deduplication and idempotency keys
+	dedup       DedupConfig
+	keyWindow   time.Duration   // how long idempotency keys are kept; 0 refuses them
+	timePolicy  EventTimePolicy // zero accepts any event time
+	typePolicy  EventTypePolicy // zero accepts any event type
+	timeouts    timeout.Policy  // zero sets no deadlines
+	logger      *slog.Logger
 }
 
 // NewService creates a new ingestion service.
@@ -25,52 +64,208 @@ func NewService(outbox OutboxRepository, logger *slog.Logger) *Service {
 	}
 }
 
+// AddInsertHook registers a hook to run after each successful outbox insert.
+// Hooks run in registration order. Must be called before serving requests.
+func (s *Service) AddInsertHook(hook InsertHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// AddEnricher registers an enricher to run on each event before it is signed
+// and written to the outbox. Enrichers run in registration order. Must be
+// called before serving requests.
+func (s *Service) AddEnricher(enricher Enricher) {
+	s.enrichers = append(s.enrichers, enricher)
+}
+
 // IngestRequest represents an incoming event ingestion request.
 type IngestRequest struct {
-	EventType   string          `json:"event_type"`
-	AggregateID string          `json:"aggregate_id"`
-	Payload     json.RawMessage `json:"payload"`
-	EventTime   *time.Time      `json:"event_time,omitempty"` // optional, defaults to clock.Now()
-	TraceID     string          `json:"trace_id,omitempty"`
+	EventType     string          `json:"event_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	AggregateType string          `json:"aggregate_type,omitempty"` // optional, see events.ValidateAggregateType
+	Payload       json.RawMessage `json:"payload"`
+	EventTime     *time.Time      `json:"event_time,omitempty"` // optional, defaults to clock.Now()
+	TraceID       string          `json:"trace_id,omitempty"`
+
+	// ContentType is the payload's media type; empty means JSON. For other
+	// types the payload is a base64 string (see events.EncodeBinaryPayload).
+	ContentType string `json:"content_type,omitempty"`
+
+	// CorrelationID, IdempotencyKey and SchemaVersion are set through the v2
+	// API only (see Handler.HandleIngestV2).
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// IdempotencyKey makes retries safe: a request whose key was used within
+	// the idempotency window is not stored again (see SetIdempotency).
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// SchemaVersion is the payload's schema version; 0 means
+	// events.CurrentSchemaVersion. Consumers upcast older versions.
+	SchemaVersion int `json:"schema_version,omitempty"`
+
+	// Test marks the event as test traffic. Set by the handler from the
+	// request's API key, never from the request body.
+	Test bool `json:"-"`
+	// Replay marks the event as a replay or backfill rather than live
+	// traffic, with events.SourceReplay as its source. Set by the handler
+	// from ReplayHeader, only for API keys allowed to replay.
+	Replay bool `json:"-"`
 }
 
-// IngestResponse is returned after successful ingestion.
+// IngestResponse is returned after successful ingestion. Status is
+// "accepted", or "published" in sync mode, which adds the event store
+// sequence numbers.
 type IngestResponse struct {
-	EventID string `json:"event_id"`
-	Status  string `json:"status"`
+	EventID      string `json:"event_id"`
+	Status       string `json:"status"`
+	GlobalSeq    int64  `json:"global_seq,omitempty"`
+	AggregateSeq int64  `json:"aggregate_seq,omitempty"`
 }
 
-// Ingest validates and writes an event to the outbox.
+// SetPayloadTransformer rewrites every payload before it is written to the
+// outbox. Must be called before serving requests.
+func (s *Service) SetPayloadTransformer(t PayloadTransformer) {
+	s.transformer = t
+}
+
+// SetSigner signs every event before it is written to the outbox, so
+// consumers can verify it was not altered afterwards. Must be called before
+// serving requests.
+func (s *Service) SetSigner(signer *signing.Signer) {
+	s.signer = signer
+}
+
+// SetEventTimePolicy bounds the event_time of incoming events. Must be
+// called before serving requests.
+func (s *Service) SetEventTimePolicy(policy EventTimePolicy) {
+	s.timePolicy = policy
+}
+
+// SetEventTypePolicy checks the event_type of incoming events against the
+// naming rules and the registry of known types. Must be called before serving
+// requests.
+func (s *Service) SetEventTypePolicy(policy EventTypePolicy) {
+	s.typePolicy = policy
+}
+
+// SetTimeouts bounds the store work of each ingest (operation "ingest"):
+// payload protection, enrichment and the outbox insert. Must be called before serving
+// requests.
+func (s *Service) SetTimeouts(p timeout.Policy) {
+	s.timeouts = p
+}
+
+// SetIdempotency keeps the idempotency keys of accepted events for window,
+// using outbox for the inserts. Without it, requests with a key fail with
+// ErrIdempotencyDisabled. Must be called before serving requests.
+func (s *Service) SetIdempotency(outbox UniqueOutboxRepository, window time.Duration) {
+	s.unique = outbox
+	s.keyWindow = window
+}
+
+// SetDedup deduplicates events by content within config.Window, using
+// outbox for the inserts. Only events with an explicit event_time are
+// deduplicated: without one, a repeated reading cannot be told from a re-sent
+// one. Must be called before serving requests.
+func (s *Service) SetDedup(outbox UniqueOutboxRepository, config DedupConfig) {
+	s.unique = outbox
+	s.dedup = config
+}
+
+// ErrValidation wraps the errors of requests that fail validation.
+var ErrValidation = errors.New("validation failed")
+
+// ErrIdempotencyDisabled is returned by Ingest for a request with an
+// idempotency key when keys are not kept (see SetIdempotency).
+var ErrIdempotencyDisabled = errors.New("idempotency keys are disabled")
+
+// Ingest validates and writes an event to the outbox. A duplicate (see
+// SetDedup) is not written; the response carries the original event's ID
+// with StatusDuplicate, or comes with ErrDuplicate when duplicates are
+// rejected. A repeated idempotency key is always answered with
+// StatusDuplicate.
 func (s *Service) Ingest(ctx context.Context, req *IngestRequest) (*IngestResponse, error) {
 	// Validate request
 	if err := s.validate(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrValidation, err)
+	}
+	if req.IdempotencyKey != "" && s.keyWindow <= 0 {
+		return nil, ErrIdempotencyDisabled
+	}
+	problem, err := s.typePolicy.Check(req.EventType)
+	if err != nil {
+		return nil, err
+	}
+	if problem != "" {
+		s.logger.Warn("unexpected event type, accepted",
+			"event_type", req.EventType,
+			"aggregate_id", req.AggregateID,
+			"problem", problem,
+		)
+	}
+
+	metadata := events.Metadata{
+		TraceID:       req.TraceID,
+		CorrelationID: req.CorrelationID,
+		Source:        source(req.Replay),
+		SchemaVersion: schemaVersion(req.SchemaVersion),
+		ContentType:   contentType(req.ContentType),
+		Test:          req.Test,
+		Replay:        req.Replay,
 	}
 
 	// Determine event time: use provided time or default to clock.Now()
 	eventTime := clock.Now()
 	if req.EventTime != nil {
-		eventTime = *req.EventTime
+		checked, clamped, err := s.timePolicy.check(*req.EventTime, eventTime, metadata)
+		if err != nil {
+			return nil, err
+		}
+		if clamped {
+			metadata.ClampedFrom = req.EventTime
+			s.logger.Warn("event time out of range, clamped",
+				"event_type", req.EventType,
+				"aggregate_id", req.AggregateID,
+				"event_time", *req.EventTime,
+				"clamped_to", checked,
+			)
+		}
+		eventTime = checked
+	}
+
+	// Insert hooks keep the request's context; the deadline covers the
+	// store work only
+	storeCtx, cancel := s.timeouts.Context(ctx, "ingest")
+	defer cancel()
+
+	// Protect personal data before anything is persisted. Field rules only
+	// apply to JSON, so binary payloads are stored as sent.
+	payload := req.Payload
+	if s.transformer != nil && events.IsJSONContentType(req.ContentType) {
+		transformed, err := s.transformer.Transform(storeCtx, req.EventType, payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform payload: %w", err)
+		}
+		payload = transformed
 	}
 
 	// Create event envelope
-	envelope, err := events.NewEnvelope(
-		req.EventType,
-		req.AggregateID,
-		req.Payload,
-		events.Metadata{
-			TraceID:       req.TraceID,
-			Source:        "ingestion-api",
-			SchemaVersion: 1,
-		},
-		eventTime,
-	)
+	envelope, err := events.NewEnvelope(req.EventType, req.AggregateID, payload, metadata, eventTime)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create event envelope: %w", err)
 	}
+	envelope.AggregateType = req.AggregateType
+	for _, enricher := range s.enrichers {
+		if err := enricher.Enrich(storeCtx, envelope); err != nil {
+			return nil, fmt.Errorf("failed to enrich event: %w", err)
+		}
+	}
+	if s.signer != nil {
+		if err := s.signer.Sign(envelope); err != nil {
+			return nil, fmt.Errorf("failed to sign event: %w", err)
+		}
+	}
 
 	// Write to outbox
-	if err := s.outbox.Insert(ctx, envelope); err != nil {
+	owner, err := s.insert(storeCtx, req, envelope)
+	if err != nil {
 		s.logger.Error("failed to insert into outbox",
 			"event_id", envelope.EventID,
 			"event_type", envelope.EventType,
@@ -78,19 +273,74 @@ func (s *Service) Ingest(ctx context.Context, req *IngestRequest) (*IngestRespon
 		)
 		return nil, fmt.Errorf("failed to write to outbox: %w", err)
 	}
+	if owner != envelope.EventID.String() {
+		// A repeated idempotency key is a retry, never rejected
+		reject := s.dedup.Reject && req.IdempotencyKey == ""
+		s.logger.Info("duplicate event",
+			"original_event_id", owner,
+			"event_type", envelope.EventType,
+			"aggregate_id", envelope.AggregateID,
+			"idempotency_key", req.IdempotencyKey,
+			"rejected", reject,
+		)
+		resp := &IngestResponse{EventID: owner, Status: StatusDuplicate}
+		if reject {
+			return resp, ErrDuplicate
+		}
+		return resp, nil
+	}
 
 	s.logger.Info("event ingested",
 		"event_id", envelope.EventID,
 		"event_type", envelope.EventType,
 		"aggregate_id", envelope.AggregateID,
+		"test", envelope.Metadata.Test,
+		"replay", envelope.Metadata.Replay,
 	)
 
+	s.runInsertHooks(ctx, envelope)
+
 	return &IngestResponse{
 		EventID: envelope.EventID.String(),
 		Status:  "accepted",
 	}, nil
 }
 
+// insert writes envelope to the outbox and returns the ID of the event that
+// owns its idempotency key or content: envelope's own, or an earlier
+// duplicate's. A request with a key is deduplicated by key only.
+func (s *Service) insert(ctx context.Context, req *IngestRequest, envelope *events.Envelope) (string, error) {
+	if req.IdempotencyKey != "" {
+		return s.unique.InsertUnique(ctx, envelope, idempotencyHash(req), s.keyWindow)
+	}
+	if s.unique == nil || s.dedup.Window <= 0 || req.EventTime == nil {
+		if err := s.outbox.Insert(ctx, envelope); err != nil {
+			return "", err
+		}
+		return envelope.EventID.String(), nil
+	}
+	return s.unique.InsertUnique(ctx, envelope, contentHash(req), s.dedup.Window)
+}
+
+// runInsertHooks notifies registered hooks. A panicking hook is logged and
+// skipped so it cannot fail a request whose event is already committed.
+func (s *Service) runInsertHooks(ctx context.Context, event *events.Envelope) {
+	for i, hook := range s.hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					s.logger.Error("insert hook panicked",
+						"hook", i,
+						"event_id", event.EventID,
+						"panic", r,
+					)
+				}
+			}()
+			hook.AfterInsert(ctx, event)
+		}()
+	}
+}
+
 func (s *Service) validate(req *IngestRequest) error {
 	if req.EventType == "" {
 		return fmt.Errorf("event_type is required")
@@ -98,10 +348,32 @@ func (s *Service) validate(req *IngestRequest) error {
 	if req.AggregateID == "" {
 		return fmt.Errorf("aggregate_id is required")
 	}
+	if err := events.ValidateAggregateType(req.AggregateType); err != nil {
+		return err
+	}
+	if len(req.CorrelationID) > MaxCorrelationIDLength {
+		return fmt.Errorf("correlation_id is longer than %d bytes", MaxCorrelationIDLength)
+	}
+	if len(req.IdempotencyKey) > MaxIdempotencyKeyLength {
+		return fmt.Errorf("idempotency_key is longer than %d bytes", MaxIdempotencyKeyLength)
+	}
+	if req.SchemaVersion < 0 || req.SchemaVersion > events.CurrentSchemaVersion {
+		return fmt.Errorf("schema_version %d is not supported (expected 1 to %d)", req.SchemaVersion, events.CurrentSchemaVersion)
+	}
 	if len(req.Payload) == 0 {
 		return fmt.Errorf("payload is required")
 	}
 
+	if err := events.ValidateContentType(req.ContentType); err != nil {
+		return err
+	}
+	if !events.IsJSONContentType(req.ContentType) {
+		if _, err := events.DecodeBinaryPayload(req.Payload); err != nil {
+			return fmt.Errorf("payload of content type %s: %w", req.ContentType, err)
+		}
+		return nil
+	}
+
 	// Validate payload is valid JSON
 	var js json.RawMessage
 	if err := json.Unmarshal(req.Payload, &js); err != nil {
@@ -110,3 +382,35 @@ func (s *Service) validate(req *IngestRequest) error {
 
 	return nil
 }
+
+// Limits on the envelope-level fields of a request.
+const (
+	MaxCorrelationIDLength  = 255
+	MaxIdempotencyKeyLength = 255
+)
+
+// schemaVersion returns the schema version recorded in the envelope: the
+// requested one, or the current one when none was given.
+func schemaVersion(requested int) int {
+	if requested == 0 {
+		return events.CurrentSchemaVersion
+	}
+	return requested
+}
+
+// source returns the events.Metadata.Source of an ingested event.
+func source(replay bool) string {
+	if replay {
+		return events.SourceReplay
+	}
+	return events.SourceIngestion
+}
+
+// contentType returns the content type recorded in the envelope: empty for
+// plain JSON, the default, so JSON envelopes are unchanged.
+func contentType(requested string) string {
+	if requested == events.ContentTypeJSON {
+		return ""
+	}
+	return requested
+}
@@ -2,6 +2,7 @@ package ingestion
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
 )
 
 func TestValidate(t *testing.T) {
@@ -39,6 +41,16 @@ func TestValidate(t *testing.T) {
 			req:     &IngestRequest{EventType: "sensor.reading", Payload: json.RawMessage(`{"value": 72.5}`)},
 			wantErr: true, errMsg: "aggregate_id is required",
 		},
+		{
+			name:    "valid aggregate_type",
+			req:     &IngestRequest{EventType: "sensor.reading", AggregateID: "device-001", AggregateType: "device", Payload: json.RawMessage(`{"value": 72.5}`)},
+			wantErr: false,
+		},
+		{
+			name:    "invalid aggregate_type",
+			req:     &IngestRequest{EventType: "sensor.reading", AggregateID: "device-001", AggregateType: "Device", Payload: json.RawMessage(`{"value": 72.5}`)},
+			wantErr: true, errMsg: "invalid aggregate type",
+		},
 		{
 			name:    "missing payload",
 			req:     &IngestRequest{EventType: "sensor.reading", AggregateID: "device-001"},
@@ -64,6 +76,26 @@ func TestValidate(t *testing.T) {
 			req:     &IngestRequest{EventType: "sensor.reading", AggregateID: "device-001", Payload: json.RawMessage(`[1, 2, 3]`)},
 			wantErr: false,
 		},
+		{
+			name:    "binary payload as base64",
+			req:     &IngestRequest{EventType: "sensor.thumbnail", AggregateID: "device-001", ContentType: "image/jpeg", Payload: json.RawMessage(`"/9j/4AAQ"`)},
+			wantErr: false,
+		},
+		{
+			name:    "JSON content type with parameters",
+			req:     &IngestRequest{EventType: "sensor.reading", AggregateID: "device-001", ContentType: "application/json; charset=utf-8", Payload: json.RawMessage(`{"value": 72.5}`)},
+			wantErr: false,
+		},
+		{
+			name:    "binary payload not base64",
+			req:     &IngestRequest{EventType: "sensor.thumbnail", AggregateID: "device-001", ContentType: "application/cbor", Payload: json.RawMessage(`{"value": 72.5}`)},
+			wantErr: true, errMsg: "payload of content type application/cbor: binary payload must be a base64 string",
+		},
+		{
+			name:    "invalid content type",
+			req:     &IngestRequest{EventType: "sensor.reading", AggregateID: "device-001", ContentType: "jpeg", Payload: json.RawMessage(`"/9j/4AAQ"`)},
+			wantErr: true, errMsg: `invalid content type "jpeg"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -177,3 +209,258 @@ func TestIngest_OutboxError(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "outbox")
 }
+
+func TestIngest_InsertHooks(t *testing.T) {
+	clock.Set(clock.FixedClock{Time: time.Now()})
+	t.Cleanup(clock.Reset)
+
+	var inserted bool
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			inserted = true
+			return nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+
+	var calls []string
+	service.AddInsertHook(InsertHookFunc(func(ctx context.Context, event *events.Envelope) {
+		assert.True(t, inserted, "hook should run after the outbox insert")
+		calls = append(calls, "first:"+event.AggregateID)
+	}))
+	service.AddInsertHook(InsertHookFunc(func(ctx context.Context, event *events.Envelope) {
+		panic("broken hook")
+	}))
+	service.AddInsertHook(InsertHookFunc(func(ctx context.Context, event *events.Envelope) {
+		calls = append(calls, "third:"+event.AggregateID)
+	}))
+
+	resp, err := service.Ingest(context.Background(), &IngestRequest{
+		EventType:   "sensor.reading",
+		AggregateID: "device-001",
+		Payload:     json.RawMessage(`{"value": 72.5}`),
+	})
+	require.NoError(t, err, "a panicking hook must not fail an accepted event")
+	assert.Equal(t, "accepted", resp.Status)
+	assert.Equal(t, []string{"first:device-001", "third:device-001"}, calls)
+}
+
+func TestIngest_InsertHooksSkippedOnOutboxError(t *testing.T) {
+	clock.Set(clock.FixedClock{Time: time.Now()})
+	t.Cleanup(clock.Reset)
+
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			return fmt.Errorf("connection refused")
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.AddInsertHook(InsertHookFunc(func(ctx context.Context, event *events.Envelope) {
+		t.Fatal("hook should not run when the outbox insert fails")
+	}))
+
+	_, err := service.Ingest(context.Background(), &IngestRequest{
+		EventType:   "sensor.reading",
+		AggregateID: "device-001",
+		Payload:     json.RawMessage(`{"value": 72.5}`),
+	})
+	require.Error(t, err)
+}
+
+func TestSkipTest(t *testing.T) {
+	var seen []string
+	hook := SkipTest(InsertHookFunc(func(ctx context.Context, event *events.Envelope) {
+		seen = append(seen, event.AggregateID)
+	}))
+
+	hook.AfterInsert(context.Background(), &events.Envelope{AggregateID: "real"})
+	hook.AfterInsert(context.Background(), &events.Envelope{AggregateID: "synthetic", Metadata: events.Metadata{Test: true}})
+
+	assert.Equal(t, []string{"real"}, seen)
+}
+
+// transformerFunc adapts a function to PayloadTransformer.
+type transformerFunc func(ctx context.Context, eventType string, payload json.RawMessage) (json.RawMessage, error)
+
+func (f transformerFunc) Transform(ctx context.Context, eventType string, payload json.RawMessage) (json.RawMessage, error) {
+	return f(ctx, eventType, payload)
+}
+
+func TestIngest_PayloadTransformer(t *testing.T) {
+	var captured *events.Envelope
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			captured = event
+			return nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetPayloadTransformer(transformerFunc(func(ctx context.Context, eventType string, payload json.RawMessage) (json.RawMessage, error) {
+		assert.Equal(t, "user.signup", eventType)
+		return json.RawMessage(`{"email":"[redacted]"}`), nil
+	}))
+
+	_, err := service.Ingest(context.Background(), &IngestRequest{
+		EventType:   "user.signup",
+		AggregateID: "user-1",
+		Payload:     json.RawMessage(`{"email":"a@example.com"}`),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, captured)
+	assert.JSONEq(t, `{"email":"[redacted]"}`, string(captured.Payload))
+}
+
+func TestIngest_AggregateType(t *testing.T) {
+	var captured *events.Envelope
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			captured = event
+			return nil
+		},
+	}
+
+	_, err := NewService(mock, slog.Default()).Ingest(context.Background(), &IngestRequest{
+		EventType:     "sensor.reading",
+		AggregateID:   "device-001",
+		AggregateType: "device",
+		Payload:       json.RawMessage(`{"value": 72.5}`),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, captured)
+	assert.Equal(t, "device", captured.AggregateType)
+}
+
+func TestIngest_BinaryPayload(t *testing.T) {
+	var captured *events.Envelope
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			captured = event
+			return nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetPayloadTransformer(transformerFunc(func(ctx context.Context, eventType string, payload json.RawMessage) (json.RawMessage, error) {
+		t.Fatal("field rules do not apply to binary payloads")
+		return nil, nil
+	}))
+
+	thumbnail := []byte{0xff, 0xd8, 0xff, 0xe0}
+	_, err := service.Ingest(context.Background(), &IngestRequest{
+		EventType:   "sensor.thumbnail",
+		AggregateID: "camera-1",
+		ContentType: "image/jpeg",
+		Payload:     events.EncodeBinaryPayload(thumbnail),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, captured)
+	assert.Equal(t, "image/jpeg", captured.ContentType())
+	data, err := captured.PayloadBytes()
+	require.NoError(t, err)
+	assert.Equal(t, thumbnail, data)
+
+	// Plain JSON is the default and is not recorded
+	_, err = NewService(mock, slog.Default()).Ingest(context.Background(), &IngestRequest{
+		EventType:   "sensor.reading",
+		AggregateID: "device-001",
+		ContentType: events.ContentTypeJSON,
+		Payload:     json.RawMessage(`{"value": 72.5}`),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, captured.Metadata.ContentType)
+}
+
+func TestIngest_SignsEvents(t *testing.T) {
+	var captured *events.Envelope
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			captured = event
+			return nil
+		},
+	}
+	key, err := signing.ParseSigningKey("ed25519:" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	require.NoError(t, err)
+	signer, err := signing.NewSigner(key)
+	require.NoError(t, err)
+	service := NewService(mock, slog.Default())
+	service.SetSigner(signer)
+
+	_, err = service.Ingest(context.Background(), &IngestRequest{
+		EventType:   "sensor.reading",
+		AggregateID: "device-001",
+		Payload:     json.RawMessage(`{"value": 72.5}`),
+		TraceID:     "trace-1",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, captured)
+	assert.NotEmpty(t, captured.Metadata.Signature)
+	assert.NoError(t, signing.NewVerifier([]signing.Key{signer.Key()}, true).Verify(captured), "the signature covers the stored envelope")
+}
+
+func TestIngest_PayloadTransformerError(t *testing.T) {
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			t.Fatal("unprotected payload must not reach the outbox")
+			return nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetPayloadTransformer(transformerFunc(func(ctx context.Context, eventType string, payload json.RawMessage) (json.RawMessage, error) {
+		return nil, fmt.Errorf("KMS unavailable")
+	}))
+
+	_, err := service.Ingest(context.Background(), &IngestRequest{
+		EventType:   "user.signup",
+		AggregateID: "user-1",
+		Payload:     json.RawMessage(`{"email":"a@example.com"}`),
+	})
+	assert.ErrorContains(t, err, "failed to transform payload: KMS unavailable")
+}
+
+func TestIngest_Enrichers(t *testing.T) {
+	var captured *events.Envelope
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			captured = event
+			return nil
+		},
+	}
+	key, err := signing.ParseSigningKey("ed25519:" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	require.NoError(t, err)
+	signer, err := signing.NewSigner(key)
+	require.NoError(t, err)
+	service := NewService(mock, slog.Default())
+	service.SetSigner(signer)
+	service.AddEnricher(&UnitNormalizer{System: "metric"})
+	service.AddEnricher(&RegistryEnricher{Registry: StaticRegistry{"device-001": {"site": "plant-a"}}})
+
+	_, err = service.Ingest(context.Background(), &IngestRequest{
+		EventType:   "sensor.reading",
+		AggregateID: "device-001",
+		Payload:     json.RawMessage(`{"value": 212, "unit": "fahrenheit"}`),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, captured)
+	assert.JSONEq(t, `{"value": 100, "unit": "celsius"}`, string(captured.Payload))
+	assert.Equal(t, map[string]string{"site": "plant-a"}, captured.Metadata.Attributes)
+	assert.NoError(t, signing.NewVerifier([]signing.Key{signer.Key()}, true).Verify(captured), "enrichment happens before signing")
+}
+
+func TestIngest_EnricherError(t *testing.T) {
+	mock := &mockOutboxRepository{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error {
+			t.Fatal("an event that failed enrichment must not reach the outbox")
+			return nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.AddEnricher(EnricherFunc(func(ctx context.Context, event *events.Envelope) error {
+		return fmt.Errorf("registry unavailable")
+	}))
+
+	_, err := service.Ingest(context.Background(), &IngestRequest{
+		EventType:   "sensor.reading",
+		AggregateID: "device-001",
+		Payload:     json.RawMessage(`{"value": 72.5}`),
+	})
+	assert.ErrorContains(t, err, "failed to enrich event: registry unavailable")
+}
@@ -0,0 +1,73 @@
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/services/ingestion/worker"
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// ErrPublishFailed is returned by PublishWaiter.Wait when the event's outbox
+// entry used up its retries. The entry stays in the outbox as evidence.
+var ErrPublishFailed = errors.New("event exhausted its publish retries")
+
+// Poll intervals of PublishWaiter.Wait: the first check comes quickly, since
+// the worker is woken by the insert, and later ones back off.
+const (
+	publishPollMin = 10 * time.Millisecond
+	publishPollMax = 250 * time.Millisecond
+)
+
+// PublishWaiter waits for accepted events to be published, for the sync
+// ingestion mode. It polls the database rather than listening to the local
+// worker, since the worker may run on another replica.
+type PublishWaiter struct {
+	status     PublishStatusReader
+	maxRetries int
+	logger     *slog.Logger
+}
+
+// NewPublishWaiter creates a PublishWaiter. maxRetries is the outbox worker's
+// retry budget.
+func NewPublishWaiter(status PublishStatusReader, maxRetries int, logger *slog.Logger) *PublishWaiter {
+	return &PublishWaiter{
+		status:     status,
+		maxRetries: maxRetries,
+		logger:     logger.With("component", "publish-waiter"),
+	}
+}
+
+// Wait blocks until the event with eventID is published, its retries are
+// used up (ErrPublishFailed), or ctx is done (ctx.Err()). A failed status
+// check is logged and retried at the next poll.
+func (w *PublishWaiter) Wait(ctx context.Context, eventID string) (*worker.PublishStatus, error) {
+	interval := publishPollMin
+	timer := clock.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C():
+		}
+
+		status, err := w.status.PublishStatus(ctx, eventID)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				w.logger.Warn("failed to check publish status", "event_id", eventID, "error", err)
+			}
+		case status.Published:
+			return status, nil
+		case status.RetryCount >= w.maxRetries:
+			return nil, ErrPublishFailed
+		}
+
+		interval = min(interval*2, publishPollMax)
+		timer.Reset(interval)
+	}
+}
@@ -0,0 +1,187 @@
+package ingestion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/services/ingestion/worker"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// statusSequence returns the given results in turn, repeating the last.
+func statusSequence(results ...func() (*worker.PublishStatus, error)) *mockPublishStatusReader {
+	calls := 0
+	return &mockPublishStatusReader{
+		PublishStatusFn: func(ctx context.Context, eventID string) (*worker.PublishStatus, error) {
+			result := results[min(calls, len(results)-1)]
+			calls++
+			return result()
+		},
+	}
+}
+
+func pending(retries int) func() (*worker.PublishStatus, error) {
+	return func() (*worker.PublishStatus, error) {
+		return &worker.PublishStatus{RetryCount: retries}, nil
+	}
+}
+
+func published() (*worker.PublishStatus, error) {
+	return &worker.PublishStatus{Published: true, GlobalSeq: 42, AggregateSeq: 3}, nil
+}
+
+func failing() (*worker.PublishStatus, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestPublishWaiter_Wait(t *testing.T) {
+	tests := []struct {
+		name    string
+		reader  *mockPublishStatusReader
+		want    *worker.PublishStatus
+		wantErr error
+	}{
+		{
+			name:   "published after retries",
+			reader: statusSequence(pending(0), pending(1), published),
+			want:   &worker.PublishStatus{Published: true, GlobalSeq: 42, AggregateSeq: 3},
+		},
+		{
+			name:   "status errors are retried",
+			reader: statusSequence(failing, published),
+			want:   &worker.PublishStatus{Published: true, GlobalSeq: 42, AggregateSeq: 3},
+		},
+		{
+			name:    "retries used up",
+			reader:  statusSequence(pending(1), pending(5)),
+			wantErr: ErrPublishFailed,
+		},
+		{
+			name:    "times out while pending",
+			reader:  statusSequence(pending(0)),
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			got, err := NewPublishWaiter(tt.reader, 5, slog.Default()).Wait(ctx, "event-1")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHandleIngest_Sync(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		reader     *mockPublishStatusReader // nil leaves sync mode disabled
+		wantStatus int
+		wantBody   string
+		inserted   bool
+	}{
+		{
+			name:       "published",
+			query:      "?mode=sync",
+			reader:     statusSequence(pending(0), published),
+			wantStatus: http.StatusCreated,
+			wantBody:   `"status":"published","global_seq":42,"aggregate_seq":3`,
+			inserted:   true,
+		},
+		{
+			name:       "timeout falls back to accepted",
+			query:      "?mode=sync",
+			reader:     statusSequence(pending(0)),
+			wantStatus: http.StatusAccepted,
+			wantBody:   `"status":"accepted"}`,
+			inserted:   true,
+		},
+		{
+			name:       "retries used up",
+			query:      "?mode=sync",
+			reader:     statusSequence(pending(5)),
+			wantStatus: http.StatusBadGateway,
+			wantBody:   "could not be published",
+			inserted:   true,
+		},
+		{
+			name:       "async is the default",
+			reader:     statusSequence(published),
+			wantStatus: http.StatusAccepted,
+			wantBody:   `"status":"accepted"}`,
+			inserted:   true,
+		},
+		{
+			name:       "sync mode disabled",
+			query:      "?mode=sync",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "sync mode is disabled",
+		},
+		{
+			name:       "invalid mode",
+			query:      "?mode=eventually",
+			reader:     statusSequence(published),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid mode: eventually",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inserted := false
+			mock := &mockOutboxRepository{
+				InsertFn: func(ctx context.Context, event *events.Envelope) error {
+					inserted = true
+					return nil
+				},
+			}
+			handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+			if tt.reader != nil {
+				handler.SetPublishWaiter(NewPublishWaiter(tt.reader, 5, slog.Default()), 100*time.Millisecond)
+			}
+
+			body := `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{"value":72.5}}`
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/events"+tt.query, bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+
+			handler.HandleIngest(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			assert.Equal(t, tt.inserted, inserted)
+		})
+	}
+}
+
+func TestRoutes_SyncModeValidated(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(NewService(nil, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	body := `{"event_type":"sensor.reading","aggregate_id":"device-001","payload":{"value":72.5}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events?mode=later", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Contains(t, resp["error"], "mode")
+}
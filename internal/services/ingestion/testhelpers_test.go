@@ -2,7 +2,9 @@ package ingestion
 
 import (
 	"context"
+	"time"
 
+	"github.com/cornjacket/platform-services/internal/services/ingestion/worker"
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 )
 
@@ -14,3 +16,30 @@ type mockOutboxRepository struct {
 func (m *mockOutboxRepository) Insert(ctx context.Context, event *events.Envelope) error {
 	return m.InsertFn(ctx, event)
 }
+
+// mockBacklogReader implements BacklogReader for testing.
+type mockBacklogReader struct {
+	BacklogFn func(ctx context.Context, limit int64, maxRetries int) (*worker.OutboxBacklog, error)
+}
+
+func (m *mockBacklogReader) Backlog(ctx context.Context, limit int64, maxRetries int) (*worker.OutboxBacklog, error) {
+	return m.BacklogFn(ctx, limit, maxRetries)
+}
+
+// mockPublishStatusReader implements PublishStatusReader for testing.
+type mockPublishStatusReader struct {
+	PublishStatusFn func(ctx context.Context, eventID string) (*worker.PublishStatus, error)
+}
+
+func (m *mockPublishStatusReader) PublishStatus(ctx context.Context, eventID string) (*worker.PublishStatus, error) {
+	return m.PublishStatusFn(ctx, eventID)
+}
+
+// mockUniqueOutboxRepository implements UniqueOutboxRepository for testing.
+type mockUniqueOutboxRepository struct {
+	InsertUniqueFn func(ctx context.Context, event *events.Envelope, hash []byte, window time.Duration) (string, error)
+}
+
+func (m *mockUniqueOutboxRepository) InsertUnique(ctx context.Context, event *events.Envelope, hash []byte, window time.Duration) (string, error) {
+	return m.InsertUniqueFn(ctx, event, hash, window)
+}
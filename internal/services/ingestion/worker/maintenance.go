@@ -0,0 +1,179 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// MaintenanceConfig holds configuration for outbox table maintenance.
+type MaintenanceConfig struct {
+	Interval        time.Duration // time between stats checks
+	MinDeadRows     int64         // vacuum only once this many dead rows accumulate
+	ReindexInterval time.Duration // time between index rebuilds (0 disables)
+}
+
+// Maintenance keeps the outbox from bloating. Every row is inserted once and
+// deleted once, so dead tuples pile up faster than autovacuum's default
+// thresholds assume, and the created_at index grows with them, slowing the
+// processor's FetchPending scan. Each run logs table stats, vacuums when
+// enough dead rows have accumulated, and periodically rebuilds the indexes.
+type Maintenance struct {
+	outbox OutboxMaintainer
+	config MaintenanceConfig
+	logger *slog.Logger
+
+	lastReindex time.Time
+
+	// Optional archive of published entries (see SetArchive)
+	archive          OutboxArchive
+	archiveRetention time.Duration
+
+	dedup DedupPurger // optional, see SetDedup
+}
+
+// NewMaintenance creates an outbox maintenance runner.
+func NewMaintenance(outbox OutboxMaintainer, config MaintenanceConfig, logger *slog.Logger) *Maintenance {
+	return &Maintenance{
+		outbox:      outbox,
+		config:      config,
+		logger:      logger.With("component", "outbox-maintenance"),
+		lastReindex: clock.Now(),
+	}
+}
+
+// SetArchive enables archive upkeep: each run logs the publish latency of
+// entries archived since the previous run and purges entries published more
+// than retention ago (0 keeps them forever).
+func (m *Maintenance) SetArchive(archive OutboxArchive, retention time.Duration) {
+	m.archive = archive
+	m.archiveRetention = retention
+}
+
+// SetDedup makes each run purge expired ingestion dedup hashes.
+func (m *Maintenance) SetDedup(dedup DedupPurger) {
+	m.dedup = dedup
+}
+
+// Run performs maintenance every Interval until ctx is cancelled.
+func (m *Maintenance) Run(ctx context.Context) {
+	m.logger.Info("starting outbox maintenance",
+		"interval", m.config.Interval,
+		"min_dead_rows", m.config.MinDeadRows,
+		"reindex_interval", m.config.ReindexInterval,
+	)
+
+	ticker := clock.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			m.runOnce(ctx)
+		}
+	}
+}
+
+// runOnce performs one round of table and archive maintenance.
+func (m *Maintenance) runOnce(ctx context.Context) {
+	m.maintainTable(ctx)
+	if m.archive != nil {
+		m.maintainArchive(ctx)
+	}
+	if m.dedup != nil {
+		m.purgeDedup(ctx)
+	}
+}
+
+// maintainTable reports stats, then vacuums and reindexes if due.
+func (m *Maintenance) maintainTable(ctx context.Context) {
+	stats, err := m.outbox.Stats(ctx)
+	if err != nil {
+		m.logger.Error("failed to read outbox stats", "error", err)
+		return
+	}
+
+	m.logger.Info("outbox table stats",
+		"live_rows", stats.LiveRows,
+		"dead_rows", stats.DeadRows,
+		"dead_ratio", stats.DeadRatio(),
+		"table_bytes", stats.TableBytes,
+		"index_bytes", stats.IndexBytes,
+		"last_vacuum", stats.LastVacuum,
+		"last_autovacuum", stats.LastAutovacuum,
+	)
+
+	if stats.DeadRows > 0 && stats.DeadRows >= m.config.MinDeadRows {
+		start := time.Now()
+		if err := m.outbox.Vacuum(ctx); err != nil {
+			m.logger.Error("failed to vacuum outbox", "error", err)
+		} else {
+			m.logger.Info("vacuumed outbox",
+				"dead_rows", stats.DeadRows,
+				"duration", time.Since(start),
+			)
+		}
+	}
+
+	if m.config.ReindexInterval > 0 && clock.Now().Sub(m.lastReindex) >= m.config.ReindexInterval {
+		start := time.Now()
+		if err := m.outbox.Reindex(ctx); err != nil {
+			m.logger.Error("failed to reindex outbox", "error", err)
+			return // retried on the next run
+		}
+		m.lastReindex = clock.Now()
+		m.logger.Info("reindexed outbox",
+			"index_bytes_before", stats.IndexBytes,
+			"duration", time.Since(start),
+		)
+	}
+}
+
+// maintainArchive reports recent publish latency and enforces retention.
+func (m *Maintenance) maintainArchive(ctx context.Context) {
+	now := clock.Now()
+
+	latency, err := m.archive.PublishLatency(ctx, now.Add(-m.config.Interval))
+	if err != nil {
+		m.logger.Error("failed to read publish latency", "error", err)
+	} else if latency.Count > 0 {
+		m.logger.Info("outbox publish latency",
+			"window", m.config.Interval,
+			"published", latency.Count,
+			"p50", latency.P50,
+			"p99", latency.P99,
+			"max", latency.Max,
+		)
+	}
+
+	if m.archiveRetention <= 0 {
+		return
+	}
+	purged, err := m.archive.PurgeArchive(ctx, now.Add(-m.archiveRetention))
+	if err != nil {
+		m.logger.Error("failed to purge outbox archive", "error", err)
+		return
+	}
+	if purged > 0 {
+		m.logger.Info("purged outbox archive",
+			"rows", purged,
+			"retention", m.archiveRetention,
+		)
+	}
+}
+
+// purgeDedup deletes expired dedup hashes.
+func (m *Maintenance) purgeDedup(ctx context.Context) {
+	purged, err := m.dedup.PurgeDedup(ctx, clock.Now())
+	if err != nil {
+		m.logger.Error("failed to purge dedup hashes", "error", err)
+		return
+	}
+	if purged > 0 {
+		m.logger.Info("purged expired dedup hashes", "rows", purged)
+	}
+}
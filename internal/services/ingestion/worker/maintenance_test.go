@@ -0,0 +1,190 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+func newTestMaintenance(outbox OutboxMaintainer, config MaintenanceConfig) *Maintenance {
+	return NewMaintenance(outbox, config, slog.Default())
+}
+
+func TestMaintenance_VacuumsPastThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		deadRows   int64
+		wantVacuum bool
+	}{
+		{name: "below threshold", deadRows: 999, wantVacuum: false},
+		{name: "at threshold", deadRows: 1000, wantVacuum: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vacuumed bool
+			outbox := &mockOutboxMaintainer{
+				StatsFn: func(ctx context.Context) (*OutboxStats, error) {
+					return &OutboxStats{LiveRows: 10, DeadRows: tt.deadRows}, nil
+				},
+				VacuumFn: func(ctx context.Context) error {
+					vacuumed = true
+					return nil
+				},
+				ReindexFn: func(ctx context.Context) error {
+					t.Fatal("Reindex should not be called when disabled")
+					return nil
+				},
+			}
+
+			m := newTestMaintenance(outbox, MaintenanceConfig{MinDeadRows: 1000})
+			m.runOnce(context.Background())
+
+			assert.Equal(t, tt.wantVacuum, vacuumed)
+		})
+	}
+}
+
+func TestMaintenance_StatsError(t *testing.T) {
+	outbox := &mockOutboxMaintainer{
+		StatsFn: func(ctx context.Context) (*OutboxStats, error) {
+			return nil, errors.New("connection refused")
+		},
+		VacuumFn: func(ctx context.Context) error {
+			t.Fatal("Vacuum should not be called without stats")
+			return nil
+		},
+	}
+
+	m := newTestMaintenance(outbox, MaintenanceConfig{})
+	m.runOnce(context.Background())
+}
+
+func TestMaintenance_ReindexWhenDue(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: start})
+	t.Cleanup(clock.Reset)
+
+	reindexErr := errors.New("lock timeout")
+	var reindexed int
+	outbox := &mockOutboxMaintainer{
+		StatsFn: func(ctx context.Context) (*OutboxStats, error) {
+			return &OutboxStats{}, nil
+		},
+		ReindexFn: func(ctx context.Context) error {
+			reindexed++
+			return reindexErr
+		},
+	}
+
+	m := newTestMaintenance(outbox, MaintenanceConfig{ReindexInterval: time.Hour})
+
+	// Not due yet
+	m.runOnce(context.Background())
+	assert.Equal(t, 0, reindexed)
+
+	// Due, but fails: retried on the next run
+	clock.Set(clock.FixedClock{Time: start.Add(time.Hour)})
+	m.runOnce(context.Background())
+	m.runOnce(context.Background())
+	assert.Equal(t, 2, reindexed)
+
+	// Succeeds: not repeated until the interval elapses again
+	reindexErr = nil
+	m.runOnce(context.Background())
+	m.runOnce(context.Background())
+	assert.Equal(t, 3, reindexed)
+}
+
+func TestMaintenance_ArchiveRetention(t *testing.T) {
+	now := time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	outbox := &mockOutboxMaintainer{
+		StatsFn: func(ctx context.Context) (*OutboxStats, error) {
+			return &OutboxStats{}, nil
+		},
+	}
+
+	var since, before time.Time
+	archive := &mockOutboxArchive{
+		PublishLatencyFn: func(ctx context.Context, s time.Time) (*PublishLatency, error) {
+			since = s
+			return &PublishLatency{Count: 3, P50: 20 * time.Millisecond}, nil
+		},
+		PurgeArchiveFn: func(ctx context.Context, b time.Time) (int64, error) {
+			before = b
+			return 12, nil
+		},
+	}
+
+	m := newTestMaintenance(outbox, MaintenanceConfig{Interval: 10 * time.Minute})
+	m.SetArchive(archive, 7*24*time.Hour)
+	m.runOnce(context.Background())
+
+	assert.Equal(t, now.Add(-10*time.Minute), since, "latency covers the last interval")
+	assert.Equal(t, now.Add(-7*24*time.Hour), before, "purge cutoff is now minus retention")
+}
+
+func TestMaintenance_ArchiveKeptForever(t *testing.T) {
+	outbox := &mockOutboxMaintainer{
+		StatsFn: func(ctx context.Context) (*OutboxStats, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+	var latencyRead bool
+	archive := &mockOutboxArchive{
+		PublishLatencyFn: func(ctx context.Context, since time.Time) (*PublishLatency, error) {
+			latencyRead = true
+			return &PublishLatency{}, nil
+		},
+		PurgeArchiveFn: func(ctx context.Context, before time.Time) (int64, error) {
+			t.Fatal("PurgeArchive should not be called with zero retention")
+			return 0, nil
+		},
+	}
+
+	m := newTestMaintenance(outbox, MaintenanceConfig{Interval: time.Minute})
+	m.SetArchive(archive, 0)
+	m.runOnce(context.Background())
+
+	assert.True(t, latencyRead, "archive upkeep runs even when table stats fail")
+}
+
+func TestMaintenance_PurgesExpiredDedup(t *testing.T) {
+	now := time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	outbox := &mockOutboxMaintainer{
+		StatsFn: func(ctx context.Context) (*OutboxStats, error) {
+			return &OutboxStats{}, nil
+		},
+	}
+
+	var before time.Time
+	dedup := &mockDedupPurger{
+		PurgeDedupFn: func(ctx context.Context, b time.Time) (int64, error) {
+			before = b
+			return 40, nil
+		},
+	}
+
+	m := newTestMaintenance(outbox, MaintenanceConfig{Interval: 10 * time.Minute})
+	m.SetDedup(dedup)
+	m.runOnce(context.Background())
+
+	assert.Equal(t, now, before, "hashes expired by now are purged")
+}
+
+func TestOutboxStats_DeadRatio(t *testing.T) {
+	assert.Equal(t, 0.0, (&OutboxStats{}).DeadRatio())
+	assert.Equal(t, 0.75, (&OutboxStats{LiveRows: 1, DeadRows: 3}).DeadRatio())
+}
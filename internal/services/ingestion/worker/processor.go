@@ -9,6 +9,9 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 )
 
 // ProcessorConfig holds configuration for the worker processor.
@@ -17,6 +20,13 @@ type ProcessorConfig struct {
 	BatchSize    int
 	MaxRetries   int
 	PollInterval time.Duration
+	// AsyncSubmit hands events to the submitter without waiting for delivery;
+	// the outbox row is deleted from the delivery callback instead.
+	AsyncSubmit bool
+	// Transactional publishes each fetched batch in one Kafka transaction from
+	// the dispatcher, then deletes the batch's outbox rows. Takes precedence
+	// over AsyncSubmit.
+	Transactional bool
 }
 
 // Processor processes outbox entries and submits events to EventHandler.
@@ -24,9 +34,16 @@ type Processor struct {
 	outbox     OutboxReader
 	eventStore EventStoreWriter
 	submitter  EventSubmitter
+	async      AsyncEventSubmitter // nil unless AsyncSubmit is enabled and supported
+	batch      BatchEventSubmitter // nil unless Transactional is enabled and supported
 	listenConn *pgx.Conn
 	config     ProcessorConfig
 	logger     *slog.Logger
+
+	// Async deliveries awaiting their callback. inflight is keyed by outbox ID
+	// so the dispatcher does not refetch rows that have not been deleted yet.
+	inflight sync.Map
+	pending  sync.WaitGroup
 }
 
 // NewProcessor creates a new worker processor.
@@ -38,7 +55,7 @@ func NewProcessor(
 	config ProcessorConfig,
 	logger *slog.Logger,
 ) *Processor {
-	return &Processor{
+	p := &Processor{
 		outbox:     outbox,
 		eventStore: eventStore,
 		submitter:  submitter,
@@ -46,6 +63,24 @@ func NewProcessor(
 		config:     config,
 		logger:     logger.With("component", "ingestion-worker"),
 	}
+
+	if config.Transactional {
+		if batch, ok := submitter.(BatchEventSubmitter); ok {
+			p.batch = batch
+		} else {
+			p.logger.Warn("transactional submit requested but submitter does not support it, using per-event submit")
+		}
+	}
+
+	if config.AsyncSubmit && p.batch == nil {
+		if async, ok := submitter.(AsyncEventSubmitter); ok {
+			p.async = async
+		} else {
+			p.logger.Warn("async submit requested but submitter does not support it, using sync submit")
+		}
+	}
+
+	return p
 }
 
 // Start begins processing outbox entries.
@@ -55,6 +90,8 @@ func (p *Processor) Start(ctx context.Context) error {
 		"workers", p.config.WorkerCount,
 		"batch_size", p.config.BatchSize,
 		"poll_interval", p.config.PollInterval,
+		"async_submit", p.async != nil,
+		"transactional", p.batch != nil,
 	)
 
 	// Set up LISTEN for notifications
@@ -86,6 +123,9 @@ func (p *Processor) Start(ctx context.Context) error {
 	close(workCh)
 	wg.Wait()
 
+	// Wait for outstanding async deliveries so their outbox rows are settled
+	p.pending.Wait()
+
 	p.logger.Info("ingestion worker stopped")
 	return nil
 }
@@ -98,7 +138,7 @@ func (p *Processor) dispatcher(ctx context.Context, workCh chan<- OutboxEntry) {
 	// Start a single goroutine to listen for notifications
 	go p.notificationListener(ctx, notifyCh)
 
-	timer := time.NewTimer(p.config.PollInterval)
+	timer := clock.NewTimer(p.config.PollInterval)
 	defer timer.Stop()
 
 	// Initial fetch
@@ -112,17 +152,11 @@ func (p *Processor) dispatcher(ctx context.Context, workCh chan<- OutboxEntry) {
 		case notification := <-notifyCh:
 			if notification != nil {
 				p.logger.Debug("received NOTIFY", "payload", notification.Payload)
-				if !timer.Stop() {
-					select {
-					case <-timer.C:
-					default:
-					}
-				}
 				timer.Reset(p.config.PollInterval)
 				p.fetchAndDispatch(ctx, workCh)
 			}
 
-		case <-timer.C:
+		case <-timer.C():
 			p.logger.Debug("watchdog timer fired, polling outbox")
 			p.fetchAndDispatch(ctx, workCh)
 			timer.Reset(p.config.PollInterval)
@@ -140,9 +174,7 @@ func (p *Processor) notificationListener(ctx context.Context, notifyCh chan<- *p
 			}
 			p.logger.Error("error waiting for notification", "error", err)
 			// Brief pause before retrying to avoid tight loop
-			select {
-			case <-time.After(time.Second):
-			case <-ctx.Done():
+			if clock.Sleep(ctx, time.Second) != nil {
 				return
 			}
 			continue
@@ -169,7 +201,17 @@ func (p *Processor) fetchAndDispatch(ctx context.Context, workCh chan<- OutboxEn
 
 	p.logger.Debug("fetched entries from outbox", "count", len(entries))
 
+	// Transactional mode publishes the whole batch here instead of fanning out
+	// to workers: a producer runs one transaction at a time anyway.
+	if p.batch != nil {
+		p.processBatch(ctx, entries)
+		return
+	}
+
 	for _, entry := range entries {
+		if _, ok := p.inflight.Load(entry.OutboxID); ok {
+			continue // async delivery still pending
+		}
 		select {
 		case workCh <- entry:
 		case <-ctx.Done():
@@ -198,12 +240,43 @@ func (p *Processor) processEntry(ctx context.Context, logger *slog.Logger, entry
 		"event_type", entry.Payload.EventType,
 	)
 
+	// Check max retries and write to event store
+	if !p.storeEntry(ctx, logger, entry) {
+		return
+	}
+
+	// Step 2: Submit to EventHandler
+	if p.async != nil {
+		p.submitAsync(ctx, logger, entry)
+		return
+	}
+	err := p.submitter.SubmitEvent(ctx, entry.Payload)
+	if err != nil {
+		logger.Error("failed to submit event to EventHandler", "error", err)
+		p.outbox.IncrementRetry(ctx, entry.OutboxID)
+		return
+	}
+
+	// Step 3: Delete from outbox
+	err = p.outbox.Delete(ctx, entry.OutboxID)
+	if err != nil {
+		logger.Error("failed to delete from outbox", "error", err)
+		// Entry will be reprocessed, but idempotency handles it
+		return
+	}
+
+	logger.Info("event processed successfully")
+}
+
+// storeEntry checks the retry budget and writes the entry to the event store
+// (step 1). Returns false if the entry should not be submitted this round.
+func (p *Processor) storeEntry(ctx context.Context, logger *slog.Logger, entry OutboxEntry) bool {
 	// Check max retries
 	if entry.RetryCount >= p.config.MaxRetries {
 		logger.Error("max retries exceeded, leaving in outbox as evidence",
 			"retry_count", entry.RetryCount,
 		)
-		return
+		return false
 	}
 
 	// Step 1: Write to event store
@@ -215,27 +288,90 @@ func (p *Processor) processEntry(ctx context.Context, logger *slog.Logger, entry
 		} else {
 			logger.Error("failed to write to event store", "error", err)
 			p.outbox.IncrementRetry(ctx, entry.OutboxID)
-			return
+			return false
 		}
 	}
+	return true
+}
 
-	// Step 2: Submit to EventHandler
-	err = p.submitter.SubmitEvent(ctx, entry.Payload)
-	if err != nil {
-		logger.Error("failed to submit event to EventHandler", "error", err)
-		p.outbox.IncrementRetry(ctx, entry.OutboxID)
+// processBatch stores each entry, publishes the survivors in one transaction,
+// and deletes their outbox rows only after the commit succeeds. A failed
+// transaction is aborted as a whole, so retrying never exposes duplicates to
+// read_committed consumers. A crash between commit and delete still
+// republishes the batch on restart; consumer idempotency covers that gap.
+func (p *Processor) processBatch(ctx context.Context, entries []OutboxEntry) {
+	ready := make([]OutboxEntry, 0, len(entries))
+	batch := make([]*events.Envelope, 0, len(entries))
+	for _, entry := range entries {
+		logger := p.logger.With(
+			"outbox_id", entry.OutboxID,
+			"event_id", entry.Payload.EventID,
+			"event_type", entry.Payload.EventType,
+		)
+		if p.storeEntry(ctx, logger, entry) {
+			ready = append(ready, entry)
+			batch = append(batch, entry.Payload)
+		}
+	}
+
+	if len(batch) == 0 {
 		return
 	}
 
-	// Step 3: Delete from outbox
-	err = p.outbox.Delete(ctx, entry.OutboxID)
-	if err != nil {
-		logger.Error("failed to delete from outbox", "error", err)
-		// Entry will be reprocessed, but idempotency handles it
+	// Step 2: Submit the batch to EventHandler in one transaction
+	if err := p.batch.SubmitEventBatch(ctx, batch); err != nil {
+		p.logger.Error("failed to submit event batch to EventHandler",
+			"count", len(batch),
+			"error", err,
+		)
+		for _, entry := range ready {
+			p.outbox.IncrementRetry(ctx, entry.OutboxID)
+		}
 		return
 	}
 
-	logger.Info("event processed successfully")
+	// Step 3: Delete from outbox
+	for _, entry := range ready {
+		if err := p.outbox.Delete(ctx, entry.OutboxID); err != nil {
+			p.logger.Error("failed to delete from outbox",
+				"outbox_id", entry.OutboxID,
+				"error", err,
+			)
+			// Entry will be reprocessed, but idempotency handles it
+		}
+	}
+
+	p.logger.Info("event batch processed successfully", "count", len(batch))
+}
+
+// submitAsync hands the entry to the async submitter. The outbox row is deleted
+// (or its retry count incremented) from the delivery callback. The callback
+// outlives the worker context, so it runs on an uncancelled copy to let
+// deliveries that complete during shutdown still settle their rows.
+func (p *Processor) submitAsync(ctx context.Context, logger *slog.Logger, entry OutboxEntry) {
+	ctx = context.WithoutCancel(ctx)
+
+	p.inflight.Store(entry.OutboxID, struct{}{})
+	p.pending.Add(1)
+
+	p.async.SubmitEventAsync(ctx, entry.Payload, func(err error) {
+		defer p.pending.Done()
+		defer p.inflight.Delete(entry.OutboxID)
+
+		if err != nil {
+			logger.Error("failed to submit event to EventHandler", "error", err)
+			p.outbox.IncrementRetry(ctx, entry.OutboxID)
+			return
+		}
+
+		if err := p.outbox.Delete(ctx, entry.OutboxID); err != nil {
+			logger.Error("failed to delete from outbox", "error", err)
+			// Entry will be reprocessed, but idempotency handles it
+			return
+		}
+
+		logger.Info("event processed successfully")
+	})
 }
 
 // isDuplicateError checks if the error is a unique constraint violation.
@@ -171,6 +171,169 @@ func TestProcessEntry_DeleteError(t *testing.T) {
 	// Delete error logged but not retried — idempotency handles reprocessing
 }
 
+func TestProcessEntry_AsyncDeletesOnDelivery(t *testing.T) {
+	var deleted bool
+	var deliver func(error)
+
+	outbox := &mockOutboxReader{
+		DeleteFn: func(ctx context.Context, outboxID string) error {
+			deleted = true
+			return nil
+		},
+		IncrementRetryFn: func(ctx context.Context, outboxID string) error {
+			t.Fatal("IncrementRetry should not be called on successful delivery")
+			return nil
+		},
+	}
+	eventStore := &mockEventStoreWriter{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error { return nil },
+	}
+	async := &mockAsyncEventSubmitter{
+		SubmitEventAsyncFn: func(ctx context.Context, event *events.Envelope, onDone func(error)) {
+			deliver = onDone
+		},
+	}
+
+	p := &Processor{outbox: outbox, eventStore: eventStore, async: async, config: ProcessorConfig{MaxRetries: 5}, logger: slog.Default()}
+	p.processEntry(context.Background(), slog.Default(), newTestEntry())
+
+	assert.False(t, deleted, "outbox Delete should wait for the delivery callback")
+	_, inflight := p.inflight.Load("outbox-001")
+	assert.True(t, inflight, "entry should be tracked while delivery is pending")
+
+	deliver(nil)
+	p.pending.Wait()
+
+	assert.True(t, deleted, "outbox Delete should be called after delivery")
+	_, inflight = p.inflight.Load("outbox-001")
+	assert.False(t, inflight, "entry should no longer be tracked after delivery")
+}
+
+func TestProcessEntry_AsyncDeliveryError(t *testing.T) {
+	var retried bool
+
+	outbox := &mockOutboxReader{
+		DeleteFn: func(ctx context.Context, outboxID string) error {
+			t.Fatal("Delete should not be called on delivery error")
+			return nil
+		},
+		IncrementRetryFn: func(ctx context.Context, outboxID string) error {
+			retried = true
+			return nil
+		},
+	}
+	eventStore := &mockEventStoreWriter{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error { return nil },
+	}
+	async := &mockAsyncEventSubmitter{
+		SubmitEventAsyncFn: func(ctx context.Context, event *events.Envelope, onDone func(error)) {
+			onDone(fmt.Errorf("broker unavailable"))
+		},
+	}
+
+	p := &Processor{outbox: outbox, eventStore: eventStore, async: async, config: ProcessorConfig{MaxRetries: 5}, logger: slog.Default()}
+	p.processEntry(context.Background(), slog.Default(), newTestEntry())
+	p.pending.Wait()
+
+	assert.True(t, retried, "IncrementRetry should be called on delivery error")
+}
+
+func TestFetchAndDispatch_SkipsInflight(t *testing.T) {
+	pendingEntry := newTestEntry()
+	freshEntry := newTestEntry()
+	freshEntry.OutboxID = "outbox-002"
+
+	outbox := &mockOutboxReader{
+		FetchPendingFn: func(ctx context.Context, limit int) ([]OutboxEntry, error) {
+			return []OutboxEntry{pendingEntry, freshEntry}, nil
+		},
+	}
+
+	p := &Processor{outbox: outbox, config: ProcessorConfig{BatchSize: 10}, logger: slog.Default()}
+	p.inflight.Store(pendingEntry.OutboxID, struct{}{})
+
+	workCh := make(chan OutboxEntry, 10)
+	p.fetchAndDispatch(context.Background(), workCh)
+	close(workCh)
+
+	var dispatched []string
+	for entry := range workCh {
+		dispatched = append(dispatched, entry.OutboxID)
+	}
+	assert.Equal(t, []string{"outbox-002"}, dispatched)
+}
+
+func newTestBatch() []OutboxEntry {
+	first := newTestEntry()
+	second := newTestEntry()
+	second.OutboxID = "outbox-002"
+	exhausted := newTestEntry()
+	exhausted.OutboxID = "outbox-003"
+	exhausted.RetryCount = 5
+	return []OutboxEntry{first, second, exhausted}
+}
+
+func TestProcessBatch_CommitThenDelete(t *testing.T) {
+	var deleted []string
+	var published int
+
+	outbox := &mockOutboxReader{
+		DeleteFn: func(ctx context.Context, outboxID string) error {
+			assert.Equal(t, 1, published, "rows must be deleted only after the batch is published")
+			deleted = append(deleted, outboxID)
+			return nil
+		},
+		IncrementRetryFn: func(ctx context.Context, outboxID string) error {
+			t.Fatal("IncrementRetry should not be called on success")
+			return nil
+		},
+	}
+	eventStore := &mockEventStoreWriter{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error { return nil },
+	}
+	batch := &mockBatchEventSubmitter{
+		SubmitEventBatchFn: func(ctx context.Context, batch []*events.Envelope) error {
+			published++
+			assert.Len(t, batch, 2, "entries over the retry budget should be left out")
+			return nil
+		},
+	}
+
+	p := &Processor{outbox: outbox, eventStore: eventStore, batch: batch, config: ProcessorConfig{MaxRetries: 5}, logger: slog.Default()}
+	p.processBatch(context.Background(), newTestBatch())
+
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{"outbox-001", "outbox-002"}, deleted)
+}
+
+func TestProcessBatch_TransactionFailure(t *testing.T) {
+	var retried []string
+
+	outbox := &mockOutboxReader{
+		DeleteFn: func(ctx context.Context, outboxID string) error {
+			t.Fatal("Delete should not be called when the transaction fails")
+			return nil
+		},
+		IncrementRetryFn: func(ctx context.Context, outboxID string) error {
+			retried = append(retried, outboxID)
+			return nil
+		},
+	}
+	eventStore := &mockEventStoreWriter{
+		InsertFn: func(ctx context.Context, event *events.Envelope) error { return nil },
+	}
+	batch := &mockBatchEventSubmitter{
+		SubmitEventBatchFn: func(ctx context.Context, batch []*events.Envelope) error {
+			return fmt.Errorf("transaction aborted")
+		},
+	}
+
+	p := &Processor{outbox: outbox, eventStore: eventStore, batch: batch, config: ProcessorConfig{MaxRetries: 5}, logger: slog.Default()}
+	p.processBatch(context.Background(), newTestBatch())
+
+	assert.Equal(t, []string{"outbox-001", "outbox-002"}, retried)
+}
+
 func TestIsDuplicateError(t *testing.T) {
 	tests := []struct {
 		name string
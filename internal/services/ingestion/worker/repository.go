@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"time"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 )
@@ -20,6 +21,68 @@ type OutboxReader interface {
 	IncrementRetry(ctx context.Context, outboxID string) error
 }
 
+// OutboxStats is a snapshot of the outbox table's size and bloat.
+type OutboxStats struct {
+	LiveRows       int64
+	DeadRows       int64
+	TableBytes     int64
+	IndexBytes     int64
+	LastVacuum     *time.Time // manual or maintenance VACUUM
+	LastAutovacuum *time.Time
+}
+
+// DeadRatio is the fraction of tuples that are dead (0 when the table is empty).
+func (s *OutboxStats) DeadRatio() float64 {
+	total := s.LiveRows + s.DeadRows
+	if total == 0 {
+		return 0
+	}
+	return float64(s.DeadRows) / float64(total)
+}
+
+// OutboxMaintainer runs table maintenance on the outbox.
+type OutboxMaintainer interface {
+	Stats(ctx context.Context) (*OutboxStats, error)
+	Vacuum(ctx context.Context) error
+	Reindex(ctx context.Context) error
+}
+
+// OutboxBacklog measures the entries the worker has yet to publish.
+type OutboxBacklog struct {
+	Depth     int64         // pending entries, counted up to the limit asked for
+	OldestAge time.Duration // age of the oldest pending entry (0 when empty)
+}
+
+// PublishStatus is how far an accepted event has got. An event is published
+// once it is in the event store and its outbox entry is gone (deleted or
+// archived after a successful submit).
+type PublishStatus struct {
+	Published    bool
+	RetryCount   int   // failed attempts so far, while the entry is in the outbox
+	GlobalSeq    int64 // event store sequence numbers, once stored
+	AggregateSeq int64
+}
+
+// PublishLatency summarizes the time from outbox insert to publish for
+// archived entries.
+type PublishLatency struct {
+	Count int64
+	P50   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// OutboxArchive manages archived (published) outbox entries.
+type OutboxArchive interface {
+	PurgeArchive(ctx context.Context, before time.Time) (int64, error)
+	PublishLatency(ctx context.Context, since time.Time) (*PublishLatency, error)
+}
+
+// DedupPurger removes expired ingestion dedup hashes.
+type DedupPurger interface {
+	PurgeDedup(ctx context.Context, before time.Time) (int64, error)
+}
+
 // EventStoreWriter writes events to the event store.
 type EventStoreWriter interface {
 	Insert(ctx context.Context, event *events.Envelope) error
@@ -30,3 +93,17 @@ type EventStoreWriter interface {
 type EventSubmitter interface {
 	SubmitEvent(ctx context.Context, event *events.Envelope) error
 }
+
+// AsyncEventSubmitter submits events without waiting for delivery.
+// onDone is called once with the delivery result. Optional: the processor
+// uses it when ProcessorConfig.AsyncSubmit is set and the submitter supports it.
+type AsyncEventSubmitter interface {
+	SubmitEventAsync(ctx context.Context, event *events.Envelope, onDone func(error))
+}
+
+// BatchEventSubmitter submits a batch of events atomically (all or nothing).
+// Optional: the processor uses it when ProcessorConfig.Transactional is set
+// and the submitter supports it.
+type BatchEventSubmitter interface {
+	SubmitEventBatch(ctx context.Context, batch []*events.Envelope) error
+}
@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"time"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 )
@@ -42,3 +43,63 @@ type mockEventSubmitter struct {
 func (m *mockEventSubmitter) SubmitEvent(ctx context.Context, event *events.Envelope) error {
 	return m.SubmitEventFn(ctx, event)
 }
+
+// mockAsyncEventSubmitter implements AsyncEventSubmitter for testing.
+type mockAsyncEventSubmitter struct {
+	SubmitEventAsyncFn func(ctx context.Context, event *events.Envelope, onDone func(error))
+}
+
+func (m *mockAsyncEventSubmitter) SubmitEventAsync(ctx context.Context, event *events.Envelope, onDone func(error)) {
+	m.SubmitEventAsyncFn(ctx, event, onDone)
+}
+
+// mockBatchEventSubmitter implements BatchEventSubmitter for testing.
+type mockBatchEventSubmitter struct {
+	SubmitEventBatchFn func(ctx context.Context, batch []*events.Envelope) error
+}
+
+func (m *mockBatchEventSubmitter) SubmitEventBatch(ctx context.Context, batch []*events.Envelope) error {
+	return m.SubmitEventBatchFn(ctx, batch)
+}
+
+// mockOutboxMaintainer implements OutboxMaintainer for testing.
+type mockOutboxMaintainer struct {
+	StatsFn   func(ctx context.Context) (*OutboxStats, error)
+	VacuumFn  func(ctx context.Context) error
+	ReindexFn func(ctx context.Context) error
+}
+
+func (m *mockOutboxMaintainer) Stats(ctx context.Context) (*OutboxStats, error) {
+	return m.StatsFn(ctx)
+}
+
+func (m *mockOutboxMaintainer) Vacuum(ctx context.Context) error {
+	return m.VacuumFn(ctx)
+}
+
+func (m *mockOutboxMaintainer) Reindex(ctx context.Context) error {
+	return m.ReindexFn(ctx)
+}
+
+// mockOutboxArchive implements OutboxArchive for testing.
+type mockOutboxArchive struct {
+	PurgeArchiveFn   func(ctx context.Context, before time.Time) (int64, error)
+	PublishLatencyFn func(ctx context.Context, since time.Time) (*PublishLatency, error)
+}
+
+func (m *mockOutboxArchive) PurgeArchive(ctx context.Context, before time.Time) (int64, error) {
+	return m.PurgeArchiveFn(ctx, before)
+}
+
+func (m *mockOutboxArchive) PublishLatency(ctx context.Context, since time.Time) (*PublishLatency, error) {
+	return m.PublishLatencyFn(ctx, since)
+}
+
+// mockDedupPurger implements DedupPurger for testing.
+type mockDedupPurger struct {
+	PurgeDedupFn func(ctx context.Context, before time.Time) (int64, error)
+}
+
+func (m *mockDedupPurger) PurgeDedup(ctx context.Context, before time.Time) (int64, error) {
+	return m.PurgeDedupFn(ctx, before)
+}
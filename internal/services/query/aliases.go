@@ -0,0 +1,189 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+)
+
+// aliasRefresh is how long the aliases are cached. A cutover reaches every
+// query replica within this time.
+const aliasRefresh = 5 * time.Second
+
+// aliasedReader serves reads of an aliased projection type from its target,
+// labelled with the requested type, so clients do not see a cutover to a
+// shadow projection.
+type aliasedReader struct {
+	ProjectionReader
+	aliases AliasReader
+	logger  *slog.Logger
+
+	mu        sync.Mutex
+	cached    []projections.Alias
+	fetchedAt time.Time
+}
+
+func newAliasedReader(reader ProjectionReader, aliases AliasReader, logger *slog.Logger) *aliasedReader {
+	return &aliasedReader{ProjectionReader: reader, aliases: aliases, logger: logger}
+}
+
+// current returns the aliases, refreshing them when the cache is stale. When
+// they cannot be read, the last ones read are kept.
+func (r *aliasedReader) current(ctx context.Context) ([]projections.Alias, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.fetchedAt.IsZero() && clock.Now().Sub(r.fetchedAt) < aliasRefresh {
+		return r.cached, nil
+	}
+	aliases, err := r.aliases.ListAliases(ctx)
+	if err != nil {
+		if r.fetchedAt.IsZero() {
+			return nil, err
+		}
+		r.logger.Warn("failed to refresh projection aliases, keeping the last ones read", "error", err)
+		return r.cached, nil
+	}
+	r.cached, r.fetchedAt = aliases, clock.Now()
+	return aliases, nil
+}
+
+// resolve returns the type to read projType from.
+func (r *aliasedReader) resolve(ctx context.Context, projType string) (string, error) {
+	aliases, err := r.current(ctx)
+	if err != nil {
+		return "", err
+	}
+	return projections.Resolve(aliases, projType), nil
+}
+
+// relabel sets the type of ps back to the requested one.
+func relabel(ps []projections.Projection, projType string) []projections.Projection {
+	for i := range ps {
+		ps[i].ProjectionType = projType
+	}
+	return ps
+}
+
+func (r *aliasedReader) GetProjection(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+	target, err := r.resolve(ctx, projType)
+	if err != nil {
+		return nil, err
+	}
+	p, err := r.ProjectionReader.GetProjection(ctx, target, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	p.ProjectionType = projType
+	return p, nil
+}
+
+func (r *aliasedReader) GetDeletedProjection(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+	target, err := r.resolve(ctx, projType)
+	if err != nil {
+		return nil, err
+	}
+	p, err := r.ProjectionReader.GetDeletedProjection(ctx, target, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	p.ProjectionType = projType
+	return p, nil
+}
+
+func (r *aliasedReader) GetProjections(ctx context.Context, projType string, aggregateIDs []string) ([]projections.Projection, error) {
+	target, err := r.resolve(ctx, projType)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := r.ProjectionReader.GetProjections(ctx, target, aggregateIDs)
+	return relabel(ps, projType), err
+}
+
+// GetAggregateProjections drops the rows of aliased types and serves their
+// targets' rows under the alias instead.
+func (r *aliasedReader) GetAggregateProjections(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error) {
+	aliases, err := r.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := r.ProjectionReader.GetAggregateProjections(ctx, aggregateID, aggregateType)
+	if err != nil || len(aliases) == 0 {
+		return ps, err
+	}
+
+	byType := make(map[string]projections.Projection, len(ps))
+	for _, p := range ps {
+		byType[p.ProjectionType] = p
+	}
+	result := make([]projections.Projection, 0, len(ps))
+	for _, p := range ps {
+		if target := projections.Resolve(aliases, p.ProjectionType); target != p.ProjectionType {
+			if served, ok := byType[target]; ok {
+				served.ProjectionType = p.ProjectionType
+				result = append(result, served)
+			}
+			continue
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (r *aliasedReader) ListProjections(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+	target, err := r.resolve(ctx, projType)
+	if err != nil {
+		return nil, 0, err
+	}
+	ps, total, err := r.ProjectionReader.ListProjections(ctx, target, limit, offset)
+	return relabel(ps, projType), total, err
+}
+
+func (r *aliasedReader) ListAnomalous(ctx context.Context, projType string, flags []string, limit, offset int) ([]projections.Projection, int, error) {
+	target, err := r.resolve(ctx, projType)
+	if err != nil {
+		return nil, 0, err
+	}
+	ps, total, err := r.ProjectionReader.ListAnomalous(ctx, target, flags, limit, offset)
+	return relabel(ps, projType), total, err
+}
+
+func (r *aliasedReader) SearchProjections(ctx context.Context, projType, q string, limit, offset int) ([]projections.Projection, int, error) {
+	target, err := r.resolve(ctx, projType)
+	if err != nil {
+		return nil, 0, err
+	}
+	ps, total, err := r.ProjectionReader.SearchProjections(ctx, target, q, limit, offset)
+	return relabel(ps, projType), total, err
+}
+
+func (r *aliasedReader) ListDeleted(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+	target, err := r.resolve(ctx, projType)
+	if err != nil {
+		return nil, 0, err
+	}
+	ps, total, err := r.ProjectionReader.ListDeleted(ctx, target, limit, offset)
+	return relabel(ps, projType), total, err
+}
+
+func (r *aliasedReader) ListRegistered(ctx context.Context, projType string, filter registry.Filter, limit, offset int) ([]projections.Projection, int, error) {
+	target, err := r.resolve(ctx, projType)
+	if err != nil {
+		return nil, 0, err
+	}
+	ps, total, err := r.ProjectionReader.ListRegistered(ctx, target, filter, limit, offset)
+	return relabel(ps, projType), total, err
+}
+
+func (r *aliasedReader) ScanProjections(ctx context.Context, projType, afterAggregateID string, limit int) ([]projections.Projection, error) {
+	target, err := r.resolve(ctx, projType)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := r.ProjectionReader.ScanProjections(ctx, target, afterAggregateID, limit)
+	return relabel(ps, projType), err
+}
@@ -0,0 +1,111 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+func TestAliasedReader_ServesTargetUnderAlias(t *testing.T) {
+	var readType string
+	reader := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			readType = projType
+			return &projections.Projection{ProjectionType: projType, AggregateID: aggregateID}, nil
+		},
+		ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			readType = projType
+			return []projections.Projection{{ProjectionType: projType}}, 1, nil
+		},
+	}
+	aliases := &mockAliasReader{
+		ListAliasesFn: func(ctx context.Context) ([]projections.Alias, error) {
+			return []projections.Alias{{Alias: "sensor_state", ProjectionType: "sensor_state_v2"}}, nil
+		},
+	}
+	r := newAliasedReader(reader, aliases, slog.Default())
+
+	p, err := r.GetProjection(context.Background(), "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, "sensor_state_v2", readType)
+	assert.Equal(t, "sensor_state", p.ProjectionType)
+
+	_, err = r.GetProjection(context.Background(), "test.sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, "test.sensor_state_v2", readType)
+
+	ps, _, err := r.ListProjections(context.Background(), "user_session", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "user_session", readType, "unaliased types are read as they are")
+	assert.Equal(t, "user_session", ps[0].ProjectionType)
+}
+
+func TestAliasedReader_AggregateProjections(t *testing.T) {
+	reader := &mockProjectionReader{
+		GetAggregateProjectionsFn: func(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error) {
+			return []projections.Projection{
+				{ProjectionType: "sensor_state", State: []byte(`{"v": 1}`)},
+				{ProjectionType: "sensor_state_v2", State: []byte(`{"v": 2}`)},
+				{ProjectionType: "user_session", State: []byte(`{}`)},
+			}, nil
+		},
+	}
+	aliases := &mockAliasReader{
+		ListAliasesFn: func(ctx context.Context) ([]projections.Alias, error) {
+			return []projections.Alias{{Alias: "sensor_state", ProjectionType: "sensor_state_v2"}}, nil
+		},
+	}
+
+	ps, err := newAliasedReader(reader, aliases, slog.Default()).GetAggregateProjections(context.Background(), "device-001", "")
+	require.NoError(t, err)
+	require.Len(t, ps, 3)
+	assert.Equal(t, "sensor_state", ps[0].ProjectionType)
+	assert.JSONEq(t, `{"v": 2}`, string(ps[0].State), "the alias is served from its target")
+	assert.Equal(t, "sensor_state_v2", ps[1].ProjectionType)
+	assert.Equal(t, "user_session", ps[2].ProjectionType)
+}
+
+func TestAliasedReader_CachesAliases(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	var calls int
+	var failing bool
+	aliases := &mockAliasReader{
+		ListAliasesFn: func(ctx context.Context) ([]projections.Alias, error) {
+			calls++
+			if failing {
+				return nil, errors.New("connection refused")
+			}
+			return []projections.Alias{{Alias: "sensor_state", ProjectionType: "sensor_state_v2"}}, nil
+		},
+	}
+	r := newAliasedReader(&mockProjectionReader{}, aliases, slog.Default())
+
+	for range 3 {
+		target, err := r.resolve(context.Background(), "sensor_state")
+		require.NoError(t, err)
+		assert.Equal(t, "sensor_state_v2", target)
+	}
+	assert.Equal(t, 1, calls)
+
+	// A failed refresh keeps the last aliases read
+	failing = true
+	clock.Set(clock.FixedClock{Time: now.Add(aliasRefresh)})
+	target, err := r.resolve(context.Background(), "sensor_state")
+	require.NoError(t, err)
+	assert.Equal(t, "sensor_state_v2", target)
+	assert.Equal(t, 2, calls)
+
+	_, err = newAliasedReader(&mockProjectionReader{}, aliases, slog.Default()).resolve(context.Background(), "sensor_state")
+	assert.Error(t, err, "with no aliases read yet, the error is returned")
+}
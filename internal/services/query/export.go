@@ -0,0 +1,315 @@
+package query
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// Export formats (?format=).
+const (
+	FormatNDJSON = "ndjson" // one JSON document per line (the default)
+	FormatCSV    = "csv"    // a header row, then one row per record
+)
+
+// exportPageDeadline is how long an export may take to write each page. It
+// replaces the server's write timeout, which would cut off any export larger
+// than a few pages.
+const exportPageDeadline = time.Minute
+
+// projectionColumns and eventColumns are the CSV columns of the exports. The
+// state and payload columns hold JSON.
+var (
+	projectionColumns = []string{"projection_id", "projection_type", "aggregate_id", "aggregate_type", "last_event_id", "last_event_timestamp", "updated_at", "state"}
+	eventColumns      = []string{"global_seq", "event_id", "event_type", "aggregate_id", "aggregate_type", "aggregate_seq", "event_time", "ingested_at", "payload"}
+)
+
+// isExportPath reports whether path is one of the export endpoints, whose
+// responses are streamed rather than buffered (see Handler.SetDecryptor).
+func isExportPath(path string) bool {
+	if path == "/api/v1/events/export" {
+		return true
+	}
+	rest, ok := strings.CutPrefix(path, "/api/v1/projections/")
+	if !ok {
+		return false
+	}
+	_, view, _ := strings.Cut(strings.TrimSuffix(rest, "/"), "/")
+	return view == "export"
+}
+
+// HandleExportProjections handles GET /api/v1/projections/{projection_type}/export
+// Streams every live projection of the type in aggregate ID order, as
+// newline-delimited JSON (?format=ndjson, the default) or CSV (?format=csv).
+// The service reads ExportPageSize projections at a time and each page is
+// flushed as it is written, so an export of any size holds one page in memory.
+// ?units= and ?namespace= behave as for HandleGetProjection.
+// "export" is routed here, so an aggregate with that ID is only reachable
+// through the list and batch get.
+func (h *Handler) HandleExportProjections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Expected path: /api/v1/projections/{projection_type}/export
+	projectionType, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/projections/"), "/")
+	if !IsValidProjectionType(projectionType) {
+		h.writeError(w, http.StatusBadRequest, "invalid projection type: "+projectionType)
+		return
+	}
+	if !h.canRead(w, r, projectionType) {
+		return
+	}
+
+	format, ok := h.parseExportFormat(w, r)
+	if !ok {
+		return
+	}
+	units, ok := h.parseUnits(w, r)
+	if !ok {
+		return
+	}
+	name := projectionType
+	projectionType, ok = h.namespaced(w, r, projectionType)
+	if !ok {
+		return
+	}
+
+	export := h.newExport(w, r, format, name, projectionColumns)
+	err := h.service.ExportProjections(r.Context(), projectionType, func(page []Projection) error {
+		for i := range page {
+			p := &page[i]
+			if units != "" {
+				p.State = convertUnits(p.ProjectionType, p.State, units)
+			}
+			if format == FormatCSV {
+				export.writeRow([]string{
+					p.ProjectionID.String(),
+					p.ProjectionType,
+					p.AggregateID,
+					p.AggregateType,
+					p.LastEventID.String(),
+					p.LastEventTimestamp,
+					p.UpdatedAt,
+					string(export.decrypt(p.State)),
+				})
+				continue
+			}
+			if err := export.writeJSON(p); err != nil {
+				return err
+			}
+		}
+		return export.flush()
+	})
+	export.finish(err)
+}
+
+// HandleExportEvents handles GET /api/v1/events/export
+// Streams the event log in global_seq order, as newline-delimited JSON
+// (?format=ndjson, the default) or CSV (?format=csv), from after ?after_seq=
+// (default 0) through ?until_seq= (default: the newest event when the export
+// reaches it). ?types= and ?aggregate_type= filter as for HandleListEvents,
+// and restricted API keys are held to their scopes the same way. Pages are
+// read and flushed as for HandleExportProjections.
+func (h *Handler) HandleExportEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	grant, ok := h.grant(w, r)
+	if !ok {
+		return
+	}
+
+	afterSeq, ok := h.parseSeq(w, r, "after_seq")
+	if !ok {
+		return
+	}
+	untilSeq, ok := h.parseSeq(w, r, "until_seq")
+	if !ok {
+		return
+	}
+	types, ok := h.parseEventTypes(w, r, grant)
+	if !ok {
+		return
+	}
+	aggregateType, ok := h.parseAggregateType(w, r)
+	if !ok {
+		return
+	}
+	format, ok := h.parseExportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	export := h.newExport(w, r, format, "events", eventColumns)
+	err := h.service.ExportEvents(r.Context(), afterSeq, untilSeq, types, aggregateType, func(page []*events.Envelope) error {
+		for _, event := range page {
+			if format == FormatCSV {
+				export.writeRow([]string{
+					strconv.FormatInt(event.GlobalSeq, 10),
+					event.EventID.String(),
+					event.EventType,
+					event.AggregateID,
+					event.AggregateType,
+					strconv.FormatInt(event.AggregateSeq, 10),
+					event.EventTime.UTC().Format(time.RFC3339Nano),
+					event.IngestedAt.UTC().Format(time.RFC3339Nano),
+					string(export.decrypt(event.Payload)),
+				})
+				continue
+			}
+			if err := export.writeJSON(event); err != nil {
+				return err
+			}
+		}
+		return export.flush()
+	})
+	export.finish(err)
+}
+
+// parseExportFormat reads the optional ?format= parameter. On an invalid
+// value it writes a 400 response and returns false.
+func (h *Handler) parseExportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", FormatNDJSON:
+		return FormatNDJSON, true
+	case FormatCSV:
+		return FormatCSV, true
+	default:
+		h.writeError(w, http.StatusBadRequest, "invalid format: "+format+" (expected ndjson or csv)")
+		return "", false
+	}
+}
+
+// parseSeq reads an optional non-negative sequence number parameter. On an
+// invalid value it writes a 400 response and returns false.
+func (h *Handler) parseSeq(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return 0, true
+	}
+	seq, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || seq < 0 {
+		h.writeError(w, http.StatusBadRequest, "invalid "+name+": "+s)
+		return 0, false
+	}
+	return seq, true
+}
+
+// exportWriter streams an export response. Nothing is sent until the first
+// page is flushed, so a failure reading the first page still answers with a
+// JSON error and status. A failure after that aborts the connection, so a
+// client never mistakes a truncated export for a complete one.
+type exportWriter struct {
+	h       *Handler
+	w       http.ResponseWriter
+	r       *http.Request
+	rc      *http.ResponseController
+	format  string
+	name    string // file name, without extension
+	columns []string
+	decrypt func([]byte) []byte // decrypts PII-protected values for authorized keys
+
+	csv     *csv.Writer
+	buf     []byte // NDJSON lines of the current page
+	started bool
+}
+
+func (h *Handler) newExport(w http.ResponseWriter, r *http.Request, format, name string, columns []string) *exportWriter {
+	e := &exportWriter{
+		h:       h,
+		w:       w,
+		r:       r,
+		rc:      http.NewResponseController(w),
+		format:  format,
+		name:    name,
+		columns: columns,
+		decrypt: func(doc []byte) []byte { return doc },
+	}
+	if h.decryptor != nil && h.decryptor.Authorized(r) {
+		e.decrypt = func(doc []byte) []byte { return h.decryptor.Decrypt(r, doc) }
+	}
+	return e
+}
+
+// start sends the response headers, and for CSV the header row.
+func (e *exportWriter) start() {
+	if e.started {
+		return
+	}
+	e.started = true
+	contentType := "application/x-ndjson"
+	if e.format == FormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	e.w.Header().Set("Content-Type", contentType)
+	e.w.Header().Set("Content-Disposition", `attachment; filename="`+e.name+"."+e.format+`"`)
+	e.w.WriteHeader(http.StatusOK)
+	if e.format == FormatCSV {
+		e.csv = csv.NewWriter(e.w)
+		_ = e.csv.Write(e.columns)
+	}
+}
+
+// writeJSON adds v to the current page as an NDJSON line.
+func (e *exportWriter) writeJSON(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.buf = append(append(e.buf, e.decrypt(line)...), '\n')
+	return nil
+}
+
+// writeRow writes a CSV row.
+func (e *exportWriter) writeRow(row []string) {
+	e.start()
+	_ = e.csv.Write(row)
+}
+
+// flush sends the current page and gives the next one exportPageDeadline
+// to be written. It fails once the client has gone away.
+func (e *exportWriter) flush() error {
+	e.start()
+	if e.format == FormatCSV {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	} else if len(e.buf) > 0 {
+		if _, err := e.w.Write(e.buf); err != nil {
+			return err
+		}
+		e.buf = e.buf[:0]
+	}
+	_ = e.rc.SetWriteDeadline(time.Now().Add(exportPageDeadline))
+	return e.rc.Flush()
+}
+
+// finish completes the response after the export returned err.
+func (e *exportWriter) finish(err error) {
+	if err == nil {
+		_ = e.flush() // an empty export still sends its headers
+		return
+	}
+	if !e.started {
+		if errors.Is(err, ErrEventLogDisabled) {
+			e.h.writeError(e.w, http.StatusNotFound, err.Error())
+			return
+		}
+		e.h.writeServiceError(e.w, err)
+		return
+	}
+	if e.r.Context().Err() == nil {
+		e.h.logger.Error("export failed after streaming began", "path", e.r.URL.Path, "error", err)
+	}
+	panic(http.ErrAbortHandler)
+}
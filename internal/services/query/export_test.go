@@ -0,0 +1,310 @@
+package query
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// scanOver serves ScanProjections from ps, which must be in aggregate ID order.
+func scanOver(ps []projections.Projection, calls *int) func(ctx context.Context, projType, afterAggregateID string, limit int) ([]projections.Projection, error) {
+	return func(ctx context.Context, projType, afterAggregateID string, limit int) ([]projections.Projection, error) {
+		*calls++
+		var page []projections.Projection
+		for _, p := range ps {
+			if p.AggregateID > afterAggregateID && len(page) < limit {
+				page = append(page, p)
+			}
+		}
+		return page, nil
+	}
+}
+
+func exportRoutes(handler *Handler) *http.ServeMux {
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	return mux
+}
+
+func TestHandleExportProjections_NDJSON(t *testing.T) {
+	// More than a page, with one deleted projection to leave out
+	var stored []projections.Projection
+	for i := range ExportPageSize + 10 {
+		p := newTestProjection()
+		p.AggregateID = fmt.Sprintf("device-%04d", i)
+		stored = append(stored, *p)
+	}
+	deletedAt := time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC)
+	stored[3].DeletedAt = &deletedAt
+
+	var calls int
+	mock := &mockProjectionReader{ScanProjectionsFn: scanOver(stored, &calls)}
+	mux := exportRoutes(NewHandler(NewService(mock, slog.Default()), slog.Default()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state/export", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="sensor_state.ndjson"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, 2, calls, "a full page and a short one")
+
+	var ids []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var p Projection
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &p))
+		ids = append(ids, p.AggregateID)
+	}
+	require.Len(t, ids, ExportPageSize+9)
+	assert.Equal(t, "device-0000", ids[0])
+	assert.NotContains(t, ids, "device-0003")
+	assert.Equal(t, fmt.Sprintf("device-%04d", ExportPageSize+9), ids[len(ids)-1])
+}
+
+func TestHandleExportProjections_CSV(t *testing.T) {
+	p := newTestProjection()
+	p.AggregateType = "device"
+	var calls int
+	mock := &mockProjectionReader{ScanProjectionsFn: scanOver([]projections.Projection{*p}, &calls)}
+	mux := exportRoutes(NewHandler(NewService(mock, slog.Default()), slog.Default()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state/export?format=csv", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, projectionColumns, rows[0])
+	assert.Equal(t, []string{
+		p.ProjectionID.String(), "sensor_state", "device-001", "device", p.LastEventID.String(),
+		"2026-02-09T12:00:00.000Z", "2026-02-09T12:00:00.000Z", `{"temperature": 72.5}`,
+	}, rows[1])
+}
+
+func TestHandleExportProjections_Empty(t *testing.T) {
+	var calls int
+	mock := &mockProjectionReader{ScanProjectionsFn: scanOver(nil, &calls)}
+	mux := exportRoutes(NewHandler(NewService(mock, slog.Default()), slog.Default()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/user_session/export?format=csv", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strings.Join(projectionColumns, ",")+"\n", w.Body.String())
+}
+
+func TestHandleExportProjections_BadRequests(t *testing.T) {
+	mock := &mockProjectionReader{
+		ScanProjectionsFn: func(ctx context.Context, projType, afterAggregateID string, limit int) ([]projections.Projection, error) {
+			t.Fatal("ScanProjections should not be called for invalid request")
+			return nil, nil
+		},
+	}
+	mux := exportRoutes(NewHandler(NewService(mock, slog.Default()), slog.Default()))
+
+	for _, target := range []string{
+		"/api/v1/projections/unknown/export",
+		"/api/v1/projections/sensor_state/export?format=xml",
+		"/api/v1/projections/sensor_state/export?units=kelvin",
+		"/api/v1/projections/sensor_state/export?namespace=prod",
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
+
+func TestHandleExportProjections_StoreError(t *testing.T) {
+	mock := &mockProjectionReader{
+		ScanProjectionsFn: func(ctx context.Context, projType, afterAggregateID string, limit int) ([]projections.Projection, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+	mux := exportRoutes(NewHandler(NewService(mock, slog.Default()), slog.Default()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state/export", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	// Nothing was streamed yet, so the failure gets a status
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+}
+
+func TestHandleExportEvents(t *testing.T) {
+	var gotAfter []int64
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			gotAfter = append(gotAfter, afterSeq)
+			var page []*events.Envelope
+			for seq := afterSeq + 1; seq <= afterSeq+int64(limit); seq++ {
+				page = append(page, &events.Envelope{GlobalSeq: seq, EventType: "sensor.reading", Payload: json.RawMessage(`{}`)})
+			}
+			return page, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	mux := exportRoutes(NewHandler(service, slog.Default()))
+
+	// The log never runs dry, so until_seq ends the export partway through a page
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/events/export?after_seq=10&until_seq=%d", ExportPageSize+20), nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="events.ndjson"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, []int64{10, ExportPageSize + 10}, gotAfter)
+
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	require.Len(t, lines, ExportPageSize+10)
+	var last events.Envelope
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &last))
+	assert.Equal(t, int64(ExportPageSize+20), last.GlobalSeq)
+}
+
+func TestHandleExportEvents_CSV(t *testing.T) {
+	var gotType string
+	log := &mockEventLog{
+		FetchAfterOfAggregateTypeFn: func(ctx context.Context, afterSeq int64, aggregateType string, types []string, limit int) ([]*events.Envelope, error) {
+			gotType = aggregateType
+			if afterSeq > 0 {
+				return nil, nil
+			}
+			event := &events.Envelope{
+				GlobalSeq:     4,
+				EventType:     "sensor.reading",
+				AggregateID:   "device-001",
+				AggregateType: "device",
+				AggregateSeq:  2,
+				EventTime:     time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC),
+				IngestedAt:    time.Date(2026, 2, 9, 12, 0, 1, 0, time.UTC),
+				Payload:       json.RawMessage(`{"value":72.5}`),
+			}
+			return []*events.Envelope{event}, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	mux := exportRoutes(NewHandler(service, slog.Default()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events/export?format=csv&aggregate_type=device", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "device", gotType)
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, eventColumns, rows[0])
+	assert.Equal(t, []string{
+		"4", "00000000-0000-0000-0000-000000000000", "sensor.reading", "device-001", "device", "2",
+		"2026-02-09T12:00:00Z", "2026-02-09T12:00:01Z", `{"value":72.5}`,
+	}, rows[1])
+}
+
+func TestHandleExportEvents_BadRequests(t *testing.T) {
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(&mockEventLog{}, EventLogConfig{})
+	mux := exportRoutes(NewHandler(service, slog.Default()))
+
+	for _, target := range []string{
+		"/api/v1/events/export?after_seq=-1",
+		"/api/v1/events/export?until_seq=abc",
+		"/api/v1/events/export?types=*sensor",
+		"/api/v1/events/export?aggregate_type=Device",
+		"/api/v1/events/export?format=json",
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
+
+func TestHandleExportEvents_Disabled(t *testing.T) {
+	mux := exportRoutes(NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events/export", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleExport_Policy(t *testing.T) {
+	policy, err := auth.ParsePolicy("sensors=read:sensor_state|events:sensor.*")
+	require.NoError(t, err)
+
+	var gotTypes []string
+	var calls int
+	mock := &mockProjectionReader{ScanProjectionsFn: scanOver(nil, &calls)}
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			gotTypes = types
+			return nil, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	handler := NewHandler(service, slog.Default())
+	handler.SetPolicy(policy)
+	mux := exportRoutes(handler)
+
+	tests := []struct {
+		key    string
+		target string
+		want   int
+	}{
+		{"sensors", "/api/v1/projections/sensor_state/export", http.StatusOK},
+		{"sensors", "/api/v1/projections/user_session/export", http.StatusForbidden},
+		{"sensors", "/api/v1/events/export", http.StatusOK},
+		{"sensors", "/api/v1/events/export?types=user.*", http.StatusForbidden},
+		{"", "/api/v1/events/export", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.key != "" {
+			req.Header.Set(auth.APIKeyHeader, tt.key)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.want, w.Code, "%s %s", tt.key, tt.target)
+	}
+	assert.Equal(t, []string{"sensor.*"}, gotTypes, "unfiltered exports are limited to the key's scopes")
+}
+
+func TestIsExportPath(t *testing.T) {
+	assert.True(t, isExportPath("/api/v1/events/export"))
+	assert.True(t, isExportPath("/api/v1/projections/sensor_state/export"))
+	assert.False(t, isExportPath("/api/v1/events"))
+	assert.False(t, isExportPath("/api/v1/projections/sensor_state"))
+	assert.False(t, isExportPath("/api/v1/projections/sensor_state/device-001"))
+}
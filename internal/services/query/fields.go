@@ -0,0 +1,99 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxFields is the most paths a single ?fields= selection may list.
+const MaxFields = 32
+
+// projectionFields are the top-level fields of a Projection response; every
+// selected path must start with one of them.
+var projectionFields = map[string]bool{
+	"projection_id":        true,
+	"projection_type":      true,
+	"aggregate_id":         true,
+	"state":                true,
+	"last_event_id":        true,
+	"last_event_timestamp": true,
+	"updated_at":           true,
+	"deleted_at":           true,
+	"registry":             true, // with ?include=registry
+}
+
+// ParseFields parses a ?fields= selection such as "state.temperature,state.unit"
+// into dot-separated paths. Paths descend through JSON objects only.
+func ParseFields(s string) ([]string, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) > MaxFields {
+		return nil, fmt.Errorf("too many fields: %d (max %d)", len(fields), MaxFields)
+	}
+	for _, field := range fields {
+		top, _, _ := strings.Cut(field, ".")
+		if !projectionFields[top] || strings.Contains(field, "..") || strings.HasSuffix(field, ".") {
+			return nil, fmt.Errorf("invalid field: %q", field)
+		}
+	}
+	return fields, nil
+}
+
+// selectFields returns the projection with only the selected paths, keeping
+// their nesting (state.temperature stays under "state"). projection_type and
+// aggregate_id are always included so list entries stay identifiable. Paths
+// that do not exist in the projection are left out.
+func selectFields(p *Projection, fields []string) (json.RawMessage, error) {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	selected := map[string]any{
+		"projection_type": doc["projection_type"],
+		"aggregate_id":    doc["aggregate_id"],
+	}
+	for _, field := range fields {
+		if value, ok := getPath(doc, field); ok {
+			insertPath(selected, field, value)
+		}
+	}
+	return json.Marshal(selected)
+}
+
+// selectAllFields applies selectFields to each projection.
+func selectAllFields(ps []Projection, fields []string) ([]json.RawMessage, error) {
+	selected := make([]json.RawMessage, len(ps))
+	for i := range ps {
+		raw, err := selectFields(&ps[i], fields)
+		if err != nil {
+			return nil, err
+		}
+		selected[i] = raw
+	}
+	return selected, nil
+}
+
+// insertPath stores value at a dot-separated path, creating intermediate
+// objects. A value already selected whole (e.g. "state" alongside
+// "state.temperature") is kept whole.
+func insertPath(doc map[string]any, path string, value json.RawMessage) {
+	key, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		doc[key] = value
+		return
+	}
+
+	switch existing := doc[key].(type) {
+	case map[string]any:
+		insertPath(existing, rest, value)
+	case nil:
+		sub := map[string]any{}
+		doc[key] = sub
+		insertPath(sub, rest, value)
+	}
+}
@@ -0,0 +1,70 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields("state.temperature,state.unit,updated_at")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"state.temperature", "state.unit", "updated_at"}, fields)
+
+	for _, s := range []string{"", "temperature", "state..unit", "state.", "state,,updated_at"} {
+		_, err := ParseFields(s)
+		assert.Error(t, err, s)
+	}
+
+	tooMany := "state"
+	for i := 0; i < MaxFields; i++ {
+		tooMany += ",state"
+	}
+	_, err = ParseFields(tooMany)
+	assert.Error(t, err)
+}
+
+func TestSelectFields(t *testing.T) {
+	p := &Projection{
+		ProjectionType: "sensor_state",
+		AggregateID:    "device-001",
+		State:          json.RawMessage(`{"value": 72.5, "unit": "fahrenheit", "anomaly": {"value_jump": true, "delta": 40}}`),
+		UpdatedAt:      "2026-02-09T12:00:00.000Z",
+	}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   string
+	}{
+		{
+			name:   "nested state fields keep their nesting",
+			fields: []string{"state.value", "state.unit"},
+			want:   `{"projection_type": "sensor_state", "aggregate_id": "device-001", "state": {"value": 72.5, "unit": "fahrenheit"}}`,
+		},
+		{
+			name:   "deeply nested and top-level fields",
+			fields: []string{"state.anomaly.delta", "updated_at"},
+			want:   `{"projection_type": "sensor_state", "aggregate_id": "device-001", "state": {"anomaly": {"delta": 40}}, "updated_at": "2026-02-09T12:00:00.000Z"}`,
+		},
+		{
+			name:   "missing paths are left out",
+			fields: []string{"state.humidity", "state.value.nope", "deleted_at"},
+			want:   `{"projection_type": "sensor_state", "aggregate_id": "device-001"}`,
+		},
+		{
+			name:   "a whole object wins over its subpaths",
+			fields: []string{"state.anomaly.delta", "state.anomaly", "state.anomaly.value_jump"},
+			want:   `{"projection_type": "sensor_state", "aggregate_id": "device-001", "state": {"anomaly": {"value_jump": true, "delta": 40}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectFields(p, tt.fields)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
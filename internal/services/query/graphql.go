@@ -0,0 +1,693 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	graphqlschema "github.com/cornjacket/platform-services/api/graphql"
+	"github.com/cornjacket/platform-services/internal/services/query/graphql"
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
+	"github.com/cornjacket/platform-services/internal/shared/units"
+)
+
+// MaxGraphQLBodyBytes bounds the body of a GraphQL request.
+const MaxGraphQLBodyBytes = 1 << 20
+
+// graphQLLimits bound the queries the gateway executes. The deepest useful
+// query, aggregate { projections { registry { site } } }, has depth 4; the
+// complexity budget fits a full batch get with registry entries. Hand-written
+// queries use a few fragments; the spread limit stops generated documents
+// long before the body limit.
+var graphQLLimits = graphql.Limits{MaxDepth: 5, MaxComplexity: 10000, MaxFragmentSpreads: 500}
+
+// EnableGraphQL serves the GraphQL gateway at POST /api/v1/graphql and its
+// schema at /schema.graphql. Must be called before RegisterRoutes.
+func (h *Handler) EnableGraphQL() {
+	schema, err := graphql.NewSchema(h.graphQLQuery(), graphQLLimits)
+	if err != nil {
+		panic(err) // the schema is static
+	}
+	h.graphql = schema
+}
+
+// HandleGraphQL handles POST /api/v1/graphql with {"query", "operationName",
+// "variables"}, executing a read-only query against the schema served at
+// /schema.graphql. Resolvers call the same Service methods as the REST
+// endpoints. Requests without a known API key get 401; fields outside the
+// key's scopes fail with a FORBIDDEN error while the rest of the query is
+// answered. Documents that do not parse, validate or fit the limits get 400.
+func (h *Handler) HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	grant, ok := h.grant(w, r)
+	if !ok {
+		return
+	}
+
+	var req graphql.Request
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxGraphQLBodyBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+
+	resp := h.graphql.Execute(context.WithValue(r.Context(), grantKey{}, grant), req)
+	status := http.StatusOK
+	if !resp.Executed() {
+		status = http.StatusBadRequest
+	}
+	h.writeJSON(w, status, resp)
+}
+
+// HandleGraphQLSchema handles GET /schema.graphql, the published schema.
+func (h *Handler) HandleGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(graphqlschema.Query)
+}
+
+// grantKey carries the request's *auth.Grant to the resolvers.
+type grantKey struct{}
+
+func grantFrom(ctx context.Context) *auth.Grant {
+	grant, _ := ctx.Value(grantKey{}).(*auth.Grant)
+	return grant
+}
+
+// GraphQL scalars and enums.
+var (
+	jsonScalar = &graphql.Scalar{
+		Name:        "JSON",
+		Description: "Any JSON value, as stored.",
+	}
+	seqScalar = &graphql.Scalar{
+		Name:        "Seq",
+		Description: "An event sequence number, a 64-bit integer.",
+		ParseValue: func(v any) (any, error) {
+			if n, ok := v.(json.Number); ok {
+				if seq, err := strconv.ParseInt(n.String(), 10, 64); err == nil && seq >= 0 {
+					return seq, nil
+				}
+			}
+			return nil, fmt.Errorf("expected a non-negative 64-bit integer, found %v", v)
+		},
+	}
+	projectionTypeEnum = &graphql.Enum{
+		Name:        "ProjectionType",
+		Description: "A projection type.",
+		Values:      slices.Sorted(maps.Keys(validProjectionTypes)),
+	}
+	namespaceEnum = &graphql.Enum{
+		Name:        "Namespace",
+		Description: "Reads projections built from test traffic (test).",
+		Values:      []string{"test"},
+	}
+	unitsEnum = &graphql.Enum{
+		Name:        "Units",
+		Description: "A unit system known state fields are converted to.",
+		Values:      []string{units.Metric, units.Imperial},
+	}
+	anomalyFlagEnum = &graphql.Enum{
+		Name:        "AnomalyFlag",
+		Description: "An anomaly flag of a projection's state.",
+		Values:      projections.AnomalyFlags,
+	}
+)
+
+func nonNull(t graphql.Type) graphql.Type { return &graphql.NonNull{Of: t} }
+func listOf(t graphql.Type) graphql.Type  { return &graphql.List{Of: t} }
+
+// pageSize is the Size of fields paged by a "first" argument: the page
+// size after normalizePage.
+func pageSize(args map[string]any) int {
+	first, _ := args["first"].(int)
+	first, _ = normalizePage(first, 0)
+	return first
+}
+
+// graphQLQuery builds the root Query type. Resolvers call h.service like the
+// REST handlers, with the same validation and scope checks.
+func (h *Handler) graphQLQuery() *graphql.Object {
+	registryEntry := &graphql.Object{
+		Name:        "RegistryEntry",
+		Description: "The static attributes of an aggregate, from the aggregate registry.",
+		Fields: []*graphql.Field{
+			{Name: "aggregateType", Type: graphql.String},
+			{Name: "site", Type: graphql.String},
+			{Name: "model", Type: graphql.String},
+			{Name: "owner", Type: graphql.String},
+			{Name: "tags", Type: nonNull(listOf(nonNull(graphql.String)))},
+		},
+	}
+
+	event := &graphql.Object{
+		Name:        "Event",
+		Description: "A stored event.",
+		Fields: []*graphql.Field{
+			{Name: "eventId", Type: nonNull(graphql.ID)},
+			{Name: "eventType", Type: nonNull(graphql.String)},
+			{Name: "aggregateId", Type: nonNull(graphql.ID)},
+			{Name: "aggregateType", Type: graphql.String},
+			{Name: "eventTime", Type: nonNull(graphql.String), Description: "When the event occurred, RFC 3339."},
+			{Name: "ingestedAt", Type: nonNull(graphql.String), Description: "When the platform received the event, RFC 3339."},
+			{Name: "globalSeq", Type: nonNull(seqScalar)},
+			{Name: "aggregateSeq", Type: nonNull(seqScalar)},
+			{Name: "payload", Type: nonNull(jsonScalar)},
+			{Name: "metadata", Type: nonNull(jsonScalar)},
+		},
+	}
+	eventConnection := &graphql.Object{
+		Name:        "EventConnection",
+		Description: "A page of events.",
+		Fields: []*graphql.Field{
+			{Name: "items", Type: nonNull(listOf(nonNull(event)))},
+			{Name: "nextSeq", Type: nonNull(seqScalar), Description: "The sequence number to page from next; unchanged if the page is empty."},
+		},
+	}
+
+	projection := &graphql.Object{
+		Name:        "Projection",
+		Description: "The current state of one aggregate, for one projection type.",
+		Fields: []*graphql.Field{
+			{Name: "projectionType", Type: nonNull(graphql.String)},
+			{Name: "aggregateId", Type: nonNull(graphql.ID)},
+			{Name: "aggregateType", Type: graphql.String},
+			{Name: "state", Type: nonNull(jsonScalar)},
+			{Name: "lastEventId", Type: nonNull(graphql.ID)},
+			{Name: "lastEventTimestamp", Type: nonNull(graphql.String)},
+			{Name: "updatedAt", Type: nonNull(graphql.String)},
+			{Name: "deletedAt", Type: graphql.String, Description: "When the projection was deleted; null while it is live."},
+			{Name: "registry", Type: registryEntry, Description: "The aggregate's registry entry, if it has one."},
+			{
+				Name: "history",
+				Description: "The aggregate's events of the projection's source types, in aggregate order.\n" +
+					"Pages are read before filtering, so a page can come back short while nextSeq advances.",
+				Args: []*graphql.Arg{
+					{Name: "fromSeq", Type: seqScalar, Default: int64(1)},
+					{Name: "first", Type: graphql.Int, Default: 20},
+				},
+				Type:    nonNull(eventConnection),
+				Resolve: h.resolveHistory,
+				Size:    pageSize,
+			},
+		},
+	}
+	projectionArgs := func(args ...*graphql.Arg) []*graphql.Arg {
+		return append([]*graphql.Arg{
+			{Name: "type", Type: nonNull(projectionTypeEnum)},
+		}, append(args,
+			&graphql.Arg{Name: "namespace", Type: namespaceEnum},
+			&graphql.Arg{Name: "units", Type: unitsEnum},
+		)...)
+	}
+
+	aggregate := &graphql.Object{
+		Name:        "Aggregate",
+		Description: "Everything known about one aggregate.",
+		Fields: []*graphql.Field{
+			{Name: "id", Type: nonNull(graphql.ID)},
+			{
+				Name:        "projections",
+				Description: "The aggregate's projections of every type the API key may read.",
+				Args:        []*graphql.Arg{{Name: "units", Type: unitsEnum}},
+				Type:        nonNull(listOf(nonNull(projection))),
+				Resolve:     h.resolveAggregateProjections,
+				Size:        func(map[string]any) int { return len(validProjectionTypes) },
+			},
+			{
+				Name: "stream",
+				Description: "The aggregate's events in aggregate order, fromSeq and toSeq inclusive.\n" +
+					"Events of types the API key may not read are left out.",
+				Args: []*graphql.Arg{
+					{Name: "fromSeq", Type: seqScalar, Default: int64(1)},
+					{Name: "toSeq", Type: seqScalar},
+					{Name: "first", Type: graphql.Int, Default: 20},
+				},
+				Type:    nonNull(eventConnection),
+				Resolve: h.resolveAggregateStream,
+				Size:    pageSize,
+			},
+		},
+	}
+
+	registryFilter := &graphql.Input{
+		Name:        "RegistryFilter",
+		Description: "Matches projections of aggregates whose registry entry has every field set here.",
+		Fields: []*graphql.Arg{
+			{Name: "aggregateType", Type: graphql.String},
+			{Name: "site", Type: graphql.String},
+			{Name: "model", Type: graphql.String},
+			{Name: "owner", Type: graphql.String},
+			{Name: "tag", Type: graphql.String},
+		},
+	}
+	projectionFilter := &graphql.Input{
+		Name:        "ProjectionFilter",
+		Description: "Restricts a projection list. At most one field may be set.",
+		Fields: []*graphql.Arg{
+			{Name: "anomaly", Type: listOf(nonNull(anomalyFlagEnum)), Description: "Projections with any of these anomaly flags set; [] for any flag."},
+			{Name: "q", Type: graphql.String, Description: "Projections whose aggregate ID contains q, ignoring case."},
+			{Name: "deleted", Type: graphql.Boolean, Description: "Deleted projections instead of live ones."},
+			{Name: "registry", Type: registryFilter},
+		},
+	}
+	projectionConnection := &graphql.Object{
+		Name:        "ProjectionConnection",
+		Description: "A page of projections.",
+		Fields: []*graphql.Field{
+			{Name: "items", Type: nonNull(listOf(nonNull(projection)))},
+			{Name: "total", Type: nonNull(graphql.Int)},
+			{Name: "limit", Type: nonNull(graphql.Int)},
+			{Name: "offset", Type: nonNull(graphql.Int)},
+		},
+	}
+	projectionBatch := &graphql.Object{
+		Name:        "ProjectionBatch",
+		Description: "The projections found by a batch get, in request order, and the IDs that have none.",
+		Fields: []*graphql.Field{
+			{Name: "items", Type: nonNull(listOf(nonNull(projection)))},
+			{Name: "missing", Type: nonNull(listOf(nonNull(graphql.ID)))},
+		},
+	}
+
+	return &graphql.Object{
+		Name:        "Query",
+		Description: "Read-only queries over projections and events.",
+		Fields: []*graphql.Field{
+			{
+				Name:        "projection",
+				Description: "One projection, deleted ones included; null if there is none.",
+				Args:        projectionArgs(&graphql.Arg{Name: "aggregateId", Type: nonNull(graphql.ID)}),
+				Type:        projection,
+				Resolve:     h.resolveProjection,
+			},
+			{
+				Name:        "projections",
+				Description: "A page of the projections of one type.",
+				Args: projectionArgs(
+					&graphql.Arg{Name: "filter", Type: projectionFilter},
+					&graphql.Arg{Name: "first", Type: graphql.Int, Default: 20},
+					&graphql.Arg{Name: "offset", Type: graphql.Int, Default: 0},
+				),
+				Type:    nonNull(projectionConnection),
+				Resolve: h.resolveProjections,
+				Size:    pageSize,
+			},
+			{
+				Name:        "projectionsByIds",
+				Description: fmt.Sprintf("The projections of one type for up to %d aggregate IDs.", MaxBatchGetIDs),
+				Args:        projectionArgs(&graphql.Arg{Name: "aggregateIds", Type: nonNull(listOf(nonNull(graphql.ID)))}),
+				Type:        nonNull(projectionBatch),
+				Resolve:     h.resolveProjectionsByIDs,
+				Size: func(args map[string]any) int {
+					ids, _ := args["aggregateIds"].([]any)
+					return min(len(ids), MaxBatchGetIDs)
+				},
+			},
+			{
+				Name:        "aggregate",
+				Description: "One aggregate's projections and event stream.",
+				Args: []*graphql.Arg{
+					{Name: "id", Type: nonNull(graphql.ID)},
+					{Name: "namespace", Type: namespaceEnum},
+					{Name: "aggregateType", Type: graphql.String, Description: "Answers no projections unless the aggregate is of this type."},
+				},
+				Type:    nonNull(aggregate),
+				Resolve: h.resolveAggregate,
+			},
+			{
+				Name:        "events",
+				Description: "A page of the event log after afterSeq, oldest first.",
+				Args: []*graphql.Arg{
+					{Name: "afterSeq", Type: seqScalar, Default: int64(0)},
+					{Name: "types", Type: listOf(nonNull(graphql.String)), Description: "Event type patterns such as sensor.*; all the API key may read if null."},
+					{Name: "aggregateType", Type: graphql.String},
+					{Name: "first", Type: graphql.Int, Default: 20},
+				},
+				Type:    nonNull(eventConnection),
+				Resolve: h.resolveEvents,
+				Size:    pageSize,
+			},
+		},
+	}
+}
+
+// resolveProjection resolves Query.projection.
+func (h *Handler) resolveProjection(p graphql.ResolveParams) (any, error) {
+	projectionType, err := readableType(p)
+	if err != nil {
+		return nil, err
+	}
+	aggregateID, _ := p.Args["aggregateId"].(string)
+	ctx, err := h.readContext(p, "registry")
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := h.service.GetProjection(ctx, projectionType, aggregateID)
+	var deleted *DeletedError
+	switch {
+	case errors.As(err, &deleted):
+		found = deleted.Projection
+	case err != nil && isNotFound(err):
+		return nil, nil
+	case err != nil:
+		return nil, graphQLError(err)
+	}
+	return h.graphQLProjections(p, []Projection{*found})[0], nil
+}
+
+// resolveProjections resolves Query.projections.
+func (h *Handler) resolveProjections(p graphql.ResolveParams) (any, error) {
+	projectionType, err := readableType(p)
+	if err != nil {
+		return nil, err
+	}
+	limit, _ := p.Args["first"].(int)
+	offset, _ := p.Args["offset"].(int)
+	filter, _ := p.Args["filter"].(map[string]any)
+
+	set := 0
+	for name, v := range filter {
+		if v != nil && v != false && !(name == "q" && v == "") {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, graphql.Errorf(graphql.CodeBadRequest, "anomaly, deleted, q and registry filters cannot be combined")
+	}
+	ctx, err := h.readContext(p, "items.registry")
+	if err != nil {
+		return nil, err
+	}
+
+	var list *ProjectionList
+	switch {
+	case filter["registry"] != nil:
+		var byRegistry registry.Filter
+		if byRegistry, err = registryFilterFrom(filter["registry"].(map[string]any)); err != nil {
+			return nil, err
+		}
+		list, err = h.service.ListRegistered(ctx, projectionType, byRegistry, limit, offset)
+	case filter["q"] != nil && filter["q"] != "":
+		q := filter["q"].(string)
+		if len(q) > MaxSearchLength {
+			return nil, graphql.Errorf(graphql.CodeBadRequest, "q must be at most %d characters", MaxSearchLength)
+		}
+		list, err = h.service.SearchProjections(ctx, projectionType, q, limit, offset)
+	case filter["deleted"] == true:
+		list, err = h.service.ListDeleted(ctx, projectionType, limit, offset)
+	case filter["anomaly"] != nil:
+		var flags []string // empty means any flag
+		for _, flag := range filter["anomaly"].([]any) {
+			flags = append(flags, flag.(string))
+		}
+		list, err = h.service.ListAnomalous(ctx, projectionType, flags, limit, offset)
+	default:
+		list, err = h.service.ListProjections(ctx, projectionType, limit, offset)
+	}
+	if err != nil {
+		return nil, graphQLError(err)
+	}
+
+	items := h.graphQLProjections(p, list.Projections)
+	return map[string]any{"items": items, "total": list.Total, "limit": list.Limit, "offset": list.Offset}, nil
+}
+
+// registryFilterFrom converts a RegistryFilter input.
+func registryFilterFrom(input map[string]any) (registry.Filter, error) {
+	field := func(name string) string {
+		s, _ := input[name].(string)
+		return s
+	}
+	filter := registry.Filter{
+		AggregateType: field("aggregateType"),
+		Site:          field("site"),
+		Model:         field("model"),
+		Owner:         field("owner"),
+		Tag:           field("tag"),
+	}
+	if err := events.ValidateAggregateType(filter.AggregateType); err != nil {
+		return registry.Filter{}, graphql.Errorf(graphql.CodeBadRequest, "%s", err)
+	}
+	return filter, nil
+}
+
+// resolveProjectionsByIDs resolves Query.projectionsByIds.
+func (h *Handler) resolveProjectionsByIDs(p graphql.ResolveParams) (any, error) {
+	projectionType, err := readableType(p)
+	if err != nil {
+		return nil, err
+	}
+	ids, _ := p.Args["aggregateIds"].([]any)
+	if n := len(ids); n == 0 || n > MaxBatchGetIDs {
+		return nil, graphql.Errorf(graphql.CodeBadRequest, "aggregateIds must contain 1 to %d IDs, got %d", MaxBatchGetIDs, n)
+	}
+	aggregateIDs := make([]string, len(ids))
+	for i, id := range ids {
+		if aggregateIDs[i] = id.(string); aggregateIDs[i] == "" {
+			return nil, graphql.Errorf(graphql.CodeBadRequest, "aggregateIds must not contain empty IDs")
+		}
+	}
+
+	ctx, err := h.readContext(p, "items.registry")
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := h.service.BatchGetProjections(ctx, projectionType, aggregateIDs)
+	if err != nil {
+		return nil, graphQLError(err)
+	}
+	items := h.graphQLProjections(p, batch.Projections)
+	return map[string]any{"items": items, "missing": batch.Missing}, nil
+}
+
+// resolveAggregate resolves Query.aggregate. Its fields read the store.
+func (h *Handler) resolveAggregate(p graphql.ResolveParams) (any, error) {
+	aggregateType, _ := p.Args["aggregateType"].(string)
+	if err := events.ValidateAggregateType(aggregateType); err != nil {
+		return nil, graphql.Errorf(graphql.CodeBadRequest, "%s", err)
+	}
+	return map[string]any{
+		"id":            p.Args["id"],
+		"test":          p.Args["namespace"] == "test",
+		"aggregateType": aggregateType,
+	}, nil
+}
+
+// resolveAggregateProjections resolves Aggregate.projections, leaving out
+// projection types the API key may not read.
+func (h *Handler) resolveAggregateProjections(p graphql.ResolveParams) (any, error) {
+	source := p.Source.(map[string]any)
+	ctx, err := h.readContext(p, "registry")
+	if err != nil {
+		return nil, err
+	}
+	result, err := h.service.GetAggregateProjections(ctx, source["id"].(string), source["test"].(bool), source["aggregateType"].(string))
+	if err != nil {
+		return nil, graphQLError(err)
+	}
+	grant := grantFrom(p.Context)
+	readable := slices.DeleteFunc(result.Projections, func(pr Projection) bool {
+		return !grant.Allows(auth.Read, projections.BaseType(pr.ProjectionType))
+	})
+	return h.graphQLProjections(p, readable), nil
+}
+
+// resolveAggregateStream resolves Aggregate.stream.
+func (h *Handler) resolveAggregateStream(p graphql.ResolveParams) (any, error) {
+	fromSeq, _ := p.Args["fromSeq"].(int64)
+	toSeq, _ := p.Args["toSeq"].(int64)
+	if toSeq != 0 && toSeq < fromSeq {
+		return nil, graphql.Errorf(graphql.CodeBadRequest, "toSeq must not be less than fromSeq")
+	}
+	first, _ := p.Args["first"].(int)
+	return h.aggregateEvents(p.Context, p.Source.(map[string]any)["id"].(string), "", fromSeq, toSeq, first)
+}
+
+// resolveHistory resolves Projection.history: the aggregate stream filtered
+// to the projection's source event types (projections.Sources).
+func (h *Handler) resolveHistory(p graphql.ResolveParams) (any, error) {
+	source := p.Source.(map[string]any)
+	fromSeq, _ := p.Args["fromSeq"].(int64)
+	first, _ := p.Args["first"].(int)
+	prefix := projections.Sources[projections.BaseType(source["projectionType"].(string))]
+	if prefix == "" {
+		return nil, graphql.Errorf(graphql.CodeBadRequest, "projection type %s has no history", source["projectionType"])
+	}
+	return h.aggregateEvents(p.Context, source["aggregateId"].(string), prefix, fromSeq, 0, first)
+}
+
+// aggregateEvents reads a page of an aggregate's stream as an
+// EventConnection, keeping events whose type starts with prefix that the API
+// key may read.
+func (h *Handler) aggregateEvents(ctx context.Context, aggregateID, prefix string, fromSeq, toSeq int64, first int) (any, error) {
+	grant := grantFrom(ctx)
+	if !grant.Unrestricted(auth.Events) && len(grant.Patterns(auth.Events)) == 0 {
+		return nil, graphql.Errorf(graphql.CodeForbidden, "API key may not read events")
+	}
+	stream, err := h.service.GetAggregateStream(ctx, aggregateID, fromSeq, toSeq, first)
+	if err != nil {
+		return nil, graphQLError(err)
+	}
+	stream.Events = slices.DeleteFunc(stream.Events, func(e *events.Envelope) bool {
+		return !strings.HasPrefix(e.EventType, prefix) || !grant.Allows(auth.Events, e.EventType)
+	})
+	return graphQLEvents(stream.Events, stream.NextSeq), nil
+}
+
+// resolveEvents resolves Query.events. Restricted API keys are held to their
+// events scopes as on GET /api/v1/events.
+func (h *Handler) resolveEvents(p graphql.ResolveParams) (any, error) {
+	grant := grantFrom(p.Context)
+	var types []string
+	if patterns, ok := p.Args["types"].([]any); ok {
+		for _, v := range patterns {
+			pattern := v.(string)
+			if !IsValidEventTypePattern(pattern) {
+				return nil, graphql.Errorf(graphql.CodeBadRequest, "invalid event type pattern: %s", pattern)
+			}
+			if !grant.Covers(auth.Events, pattern) {
+				return nil, graphql.Errorf(graphql.CodeForbidden, "API key may not read event types: %s", pattern)
+			}
+			types = append(types, pattern)
+		}
+	} else if !grant.Unrestricted(auth.Events) {
+		if types = grant.Patterns(auth.Events); len(types) == 0 {
+			return nil, graphql.Errorf(graphql.CodeForbidden, "API key may not read events")
+		}
+	}
+	aggregateType, _ := p.Args["aggregateType"].(string)
+	if err := events.ValidateAggregateType(aggregateType); err != nil {
+		return nil, graphql.Errorf(graphql.CodeBadRequest, "%s", err)
+	}
+	afterSeq, _ := p.Args["afterSeq"].(int64)
+	first, _ := p.Args["first"].(int)
+
+	list, err := h.service.ListEvents(p.Context, afterSeq, types, aggregateType, first, 0)
+	if err != nil {
+		return nil, graphQLError(err)
+	}
+	return graphQLEvents(list.Events, list.NextSeq), nil
+}
+
+// readableType returns the projection type of a field's type and namespace
+// arguments, failing with FORBIDDEN if the API key may not read it.
+func readableType(p graphql.ResolveParams) (string, error) {
+	projectionType, _ := p.Args["type"].(string)
+	if !grantFrom(p.Context).Allows(auth.Read, projectionType) {
+		return "", graphql.Errorf(graphql.CodeForbidden, "API key may not read projection type: %s", projectionType)
+	}
+	return projections.TypeFor(projectionType, p.Args["namespace"] == "test"), nil
+}
+
+// readContext returns the context to read projections with: one that also
+// reads their registry entries when the selection asks for registryPath.
+func (h *Handler) readContext(p graphql.ResolveParams, registryPath string) (context.Context, error) {
+	if !p.Selects(registryPath) {
+		return p.Context, nil
+	}
+	ctx, err := h.service.WithRegistry(p.Context)
+	if err != nil {
+		return nil, graphQLError(err)
+	}
+	return ctx, nil
+}
+
+// graphQLProjections renders ps as Projection objects, with their state in
+// the units argument's system.
+func (h *Handler) graphQLProjections(p graphql.ResolveParams, ps []Projection) []any {
+	system, _ := p.Args["units"].(string)
+	items := make([]any, len(ps))
+	for i, pr := range ps {
+		if system != "" {
+			pr.State = convertUnits(pr.ProjectionType, pr.State, system)
+		}
+		obj := map[string]any{
+			"projectionType":     pr.ProjectionType,
+			"aggregateId":        pr.AggregateID,
+			"aggregateType":      optional(pr.AggregateType),
+			"state":              pr.State,
+			"lastEventId":        pr.LastEventID,
+			"lastEventTimestamp": pr.LastEventTimestamp,
+			"updatedAt":          pr.UpdatedAt,
+			"deletedAt":          optional(pr.DeletedAt),
+		}
+		if r := pr.Registry; r != nil {
+			obj["registry"] = map[string]any{
+				"aggregateType": optional(r.AggregateType),
+				"site":          optional(r.Site),
+				"model":         optional(r.Model),
+				"owner":         optional(r.Owner),
+				"tags":          append([]string{}, r.Tags...),
+			}
+		}
+		items[i] = obj
+	}
+	return items
+}
+
+// graphQLEvents renders a page of events as an EventConnection.
+func graphQLEvents(found []*events.Envelope, nextSeq int64) map[string]any {
+	items := make([]any, len(found))
+	for i, e := range found {
+		items[i] = map[string]any{
+			"eventId":       e.EventID,
+			"eventType":     e.EventType,
+			"aggregateId":   e.AggregateID,
+			"aggregateType": optional(e.AggregateType),
+			"eventTime":     e.EventTime.Format(time.RFC3339Nano),
+			"ingestedAt":    e.IngestedAt.Format(time.RFC3339Nano),
+			"globalSeq":     e.GlobalSeq,
+			"aggregateSeq":  e.AggregateSeq,
+			"payload":       e.Payload,
+			"metadata":      e.Metadata,
+		}
+	}
+	return map[string]any{"items": items, "nextSeq": nextSeq}
+}
+
+// optional returns nil for an empty string, so it is answered as null.
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// graphQLError converts a Service error for the response, with the REST
+// error messages: TIMEOUT, UNAVAILABLE or INTERNAL for store errors (logged
+// by the Service), BAD_REQUEST for a disabled registry or event log.
+func graphQLError(err error) error {
+	if errors.Is(err, ErrRegistryDisabled) || errors.Is(err, ErrEventLogDisabled) {
+		return graphql.Errorf(graphql.CodeBadRequest, "%s", err)
+	}
+	status, message := timeout.Status(err)
+	switch status {
+	case http.StatusGatewayTimeout:
+		return graphql.Errorf(graphql.CodeTimeout, "%s", message)
+	case http.StatusServiceUnavailable:
+		return graphql.Errorf(graphql.CodeUnavailable, "%s", message)
+	default:
+		return graphql.Errorf(graphql.CodeInternal, "%s", message)
+	}
+}
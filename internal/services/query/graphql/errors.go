@@ -0,0 +1,69 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error codes, reported as extensions.code.
+const (
+	CodeBadRequest  = "BAD_REQUEST" // invalid or over-limit document, variables or arguments
+	CodeForbidden   = "FORBIDDEN"   // the caller may not read the field
+	CodeTimeout     = "TIMEOUT"     // a deadline passed or the request was canceled
+	CodeUnavailable = "UNAVAILABLE" // the store is unavailable
+	CodeInternal    = "INTERNAL"    // any other failure
+)
+
+// Location is a position in a document, 1-based.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is a GraphQL error as it appears in a response.
+type Error struct {
+	Message    string         `json:"message"`
+	Locations  []Location     `json:"locations,omitempty"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Code returns the error's extensions.code.
+func (e *Error) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// Errorf returns a resolver error that is reported with its message and
+// extensions.code set to code. Other resolver errors are reported as
+// INTERNAL with a generic message, so store errors do not leak.
+func Errorf(code, format string, args ...any) error {
+	return &Error{Message: fmt.Sprintf(format, args...), Extensions: map[string]any{"code": code}}
+}
+
+// validationError reports a document that does not fit the schema.
+func validationError(loc Location, format string, args ...any) *Error {
+	return &Error{
+		Message:    fmt.Sprintf(format, args...),
+		Locations:  []Location{loc},
+		Extensions: map[string]any{"code": CodeBadRequest},
+	}
+}
+
+// fieldError converts a resolver error for the response.
+func fieldError(err error, loc Location, path []any) *Error {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = &Error{Message: "internal server error", Extensions: map[string]any{"code": CodeInternal}}
+	}
+	return &Error{
+		Message:    e.Message,
+		Locations:  []Location{loc},
+		Path:       append([]any(nil), path...),
+		Extensions: e.Extensions,
+	}
+}
@@ -0,0 +1,939 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Request is a GraphQL request as POSTed by clients.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is the result of a request. Data is absent when the request
+// failed before execution: a syntax or validation error, or a query over
+// the limits.
+type Response struct {
+	Data   any      // an object, or nil
+	Errors []*Error // in the order they occurred
+
+	executed bool
+}
+
+// MarshalJSON renders the response as {"data": ..., "errors": [...]}. An
+// executed request always has "data", null if a non-null root field failed.
+func (r *Response) MarshalJSON() ([]byte, error) {
+	doc := struct {
+		Data   *json.RawMessage `json:"data,omitempty"`
+		Errors []*Error         `json:"errors,omitempty"`
+	}{Errors: r.Errors}
+	if r.executed {
+		raw, err := json.Marshal(r.Data)
+		if err != nil {
+			return nil, err
+		}
+		doc.Data = (*json.RawMessage)(&raw)
+	}
+	return json.Marshal(doc)
+}
+
+// Executed reports whether the request got past validation.
+func (r *Response) Executed() bool {
+	return r.executed
+}
+
+// ResolveParams are the inputs of a field resolver.
+type ResolveParams struct {
+	Context context.Context
+	Source  any            // the parent object's value
+	Args    map[string]any // coerced arguments; absent ones without a default are missing
+
+	exec  *executor
+	nodes []*field
+}
+
+// Selects reports whether the field's selection set selects path, dot
+// separated field names such as "items.registry", through fragments and
+// @skip/@include. Resolvers use it to batch what nested fields would
+// otherwise fetch one by one.
+func (p ResolveParams) Selects(path string) bool {
+	nodes := p.nodes
+	for _, name := range strings.Split(path, ".") {
+		var next []*field
+		for _, group := range p.exec.collect(selectionsOf(nodes), true) {
+			for _, f := range group.nodes {
+				if f.name == name {
+					next = append(next, f)
+				}
+			}
+		}
+		if len(next) == 0 {
+			return false
+		}
+		nodes = next
+	}
+	return true
+}
+
+// object is an ordered JSON object: GraphQL responses keep the order of
+// the selection set.
+type object []entry
+
+type entry struct {
+	key   string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, e := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.key)
+		b.Write(key)
+		b.WriteByte(':')
+		value, err := json.Marshal(e.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// executor runs one request.
+type executor struct {
+	schema    *Schema
+	doc       *document
+	variables map[string]any            // raw values, defaults applied
+	args      map[*field]map[string]any // coerced arguments per field node
+	errors    []*Error
+
+	// Validation state. A fragment expands to the same fields wherever it
+	// is spread, so fragments are checked once per type and selection sets
+	// are validated once per type, depth and content: nested spreads cost
+	// their size, not the number of paths through them.
+	spreads      int                  // fragment spreads expanded
+	fragments    map[fragmentUse]bool // fragments checked per type
+	complexities map[string]int       // validated selection sets
+	halted       bool                 // a limit was hit or ctx ended
+}
+
+// fragmentUse is a fragment spread within a type.
+type fragmentUse struct {
+	fragment string
+	on       string
+}
+
+// fieldGroup is the field nodes of one response key, merged.
+type fieldGroup struct {
+	key   string
+	nodes []*field
+}
+
+// Execute parses, validates and runs a query. Errors are reported in the
+// response, never returned.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+	e := &executor{schema: s, doc: doc, args: map[*field]map[string]any{}}
+
+	op, err := e.operation(req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+	e.coerceVariables(op, req.Variables)
+	if len(e.errors) == 0 {
+		e.validate(ctx, op)
+	}
+	if len(e.errors) > 0 {
+		return &Response{Errors: e.errors}
+	}
+
+	resp := &Response{executed: true}
+	if data, failed := e.selectionSet(ctx, s.query, nil, op.selections, nil); !failed {
+		resp.Data = data
+	}
+	resp.Errors = e.errors
+	return resp
+}
+
+// asError converts a parse error to an *Error.
+func asError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return &Error{Message: err.Error(), Extensions: map[string]any{"code": CodeBadRequest}}
+}
+
+// operation selects the operation to run.
+func (e *executor) operation(name string) (*operation, error) {
+	var op *operation
+	switch {
+	case name != "":
+		for _, candidate := range e.doc.operations {
+			if candidate.name == name {
+				op = candidate
+			}
+		}
+		if op == nil {
+			return nil, validationError(Location{Line: 1, Column: 1}, "unknown operation %q", name)
+		}
+	case len(e.doc.operations) == 1:
+		op = e.doc.operations[0]
+	default:
+		return nil, validationError(e.doc.operations[1].loc, "the document has several operations: operationName is required")
+	}
+	if op.kind != "query" {
+		return nil, validationError(op.loc, "%s operations are not supported: the API is read-only", op.kind)
+	}
+	if len(op.directives) > 0 {
+		return nil, validationError(op.directives[0].loc, "directive @%s is not allowed on an operation", op.directives[0].name)
+	}
+	return op, nil
+}
+
+// coerceVariables checks the request's variables against the operation's
+// definitions and applies defaults.
+func (e *executor) coerceVariables(op *operation, given map[string]any) {
+	e.variables = map[string]any{}
+	for _, def := range op.variables {
+		t, err := e.inputType(def.typ)
+		if err != nil {
+			e.errors = append(e.errors, validationError(def.loc, "variable $%s: %v", def.name, err))
+			continue
+		}
+		v, ok := given[def.name]
+		if !ok && def.value != nil {
+			if v, err = e.raw(def.value); err != nil {
+				e.errors = append(e.errors, validationError(def.loc, "variable $%s: %v", def.name, err))
+				continue
+			}
+			ok = true
+		}
+		if !ok {
+			if isNonNull(t) {
+				e.errors = append(e.errors, validationError(def.loc, "variable $%s of type %s is required", def.name, t))
+			}
+			continue
+		}
+		if _, err := coerceInput(t, v); err != nil {
+			e.errors = append(e.errors, validationError(def.loc, "variable $%s: %v", def.name, err))
+			continue
+		}
+		e.variables[def.name] = v
+	}
+	for name := range given {
+		if !slices.ContainsFunc(op.variables, func(def *variableDef) bool { return def.name == name }) {
+			e.errors = append(e.errors, validationError(op.loc, "variable $%s is not defined by the operation", name))
+		}
+	}
+	if len(e.errors) > 0 {
+		return
+	}
+	// Variables the operation defines but the request left out are absent
+	for _, def := range op.variables {
+		if _, ok := e.variables[def.name]; !ok {
+			e.variables[def.name] = absent{}
+		}
+	}
+}
+
+// absent marks a variable that was neither given nor defaulted: an
+// argument set to it is treated as not given.
+type absent struct{}
+
+// inputType resolves a variable's type against the schema.
+func (e *executor) inputType(t *typeExpr) (Type, error) {
+	var resolved Type
+	if t.elem != nil {
+		elem, err := e.inputType(t.elem)
+		if err != nil {
+			return nil, err
+		}
+		resolved = &List{Of: elem}
+	} else {
+		named, ok := e.schema.byName[t.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown type %s", t.name)
+		}
+		if !isInputType(named) {
+			return nil, fmt.Errorf("%s is not an input type", t.name)
+		}
+		resolved = named
+	}
+	if t.nonNull {
+		resolved = &NonNull{Of: resolved}
+	}
+	return resolved, nil
+}
+
+// raw converts a literal to the form of a decoded JSON variable, with
+// variables substituted. Enum literals stay enumValue.
+func (e *executor) raw(v value) (any, error) {
+	switch v := v.(type) {
+	case variableRef:
+		value, ok := e.variables[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not defined", v)
+		}
+		return value, nil
+	case intValue:
+		return json.Number(v), nil
+	case floatValue:
+		return json.Number(v), nil
+	case nullValue:
+		return nil, nil
+	case listValue:
+		list := make([]any, len(v))
+		for i, elem := range v {
+			raw, err := e.raw(elem)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := raw.(absent); ok {
+				raw = nil
+			}
+			list[i] = raw
+		}
+		return list, nil
+	case objectValue:
+		object := make(map[string]any, len(v))
+		for _, f := range v {
+			if _, dup := object[f.name]; dup {
+				return nil, fmt.Errorf("field %q is given twice", f.name)
+			}
+			raw, err := e.raw(f.value)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := raw.(absent); !ok {
+				object[f.name] = raw
+			}
+		}
+		return object, nil
+	}
+	return v, nil // string, bool, enumValue
+}
+
+// coerceInput coerces a raw input value to type t.
+func coerceInput(t Type, v any) (any, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("expected a non-null %s", nn.Of)
+		}
+		return coerceInput(nn.Of, v)
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *Scalar:
+		if _, ok := v.(enumValue); ok {
+			return nil, fmt.Errorf("expected a %s, found %s", t.Name, describe(v))
+		}
+		return t.ParseValue(v)
+	case *Enum:
+		var s string
+		switch v := v.(type) {
+		case enumValue:
+			s = string(v)
+		case string:
+			s = v
+		default:
+			return nil, fmt.Errorf("expected a %s value, found %s", t.Name, describe(v))
+		}
+		if !slices.Contains(t.Values, s) {
+			return nil, fmt.Errorf("%q is not a %s value (expected one of %v)", s, t.Name, t.Values)
+		}
+		return s, nil
+	case *List:
+		list, ok := v.([]any)
+		if !ok {
+			list = []any{v}
+		}
+		coerced := make([]any, len(list))
+		for i, elem := range list {
+			c, err := coerceInput(t.Of, elem)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			coerced[i] = c
+		}
+		return coerced, nil
+	case *Input:
+		fields, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected a %s object, found %s", t.Name, describe(v))
+		}
+		for name := range fields {
+			if !slices.ContainsFunc(t.Fields, func(a *Arg) bool { return a.Name == name }) {
+				return nil, fmt.Errorf("%s has no field %q", t.Name, name)
+			}
+		}
+		coerced := map[string]any{}
+		for _, a := range t.Fields {
+			raw, given := fields[a.Name]
+			if !given {
+				if a.Default != nil {
+					coerced[a.Name] = a.Default
+				} else if isNonNull(a.Type) {
+					return nil, fmt.Errorf("%s.%s of type %s is required", t.Name, a.Name, a.Type)
+				}
+				continue
+			}
+			c, err := coerceInput(a.Type, raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.Name, a.Name, err)
+			}
+			coerced[a.Name] = c
+		}
+		return coerced, nil
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// coerceArgs coerces a field's or directive's arguments against defs.
+func (e *executor) coerceArgs(defs []*Arg, given []*argument, loc Location, owner string) (map[string]any, []*Error) {
+	var errs []*Error
+	for _, arg := range given {
+		if !slices.ContainsFunc(defs, func(a *Arg) bool { return a.Name == arg.name }) {
+			errs = append(errs, validationError(arg.loc, "unknown argument %q on %s", arg.name, owner))
+		}
+	}
+
+	args := map[string]any{}
+	for _, def := range defs {
+		i := slices.IndexFunc(given, func(a *argument) bool { return a.name == def.Name })
+		var raw any = absent{}
+		argLoc := loc
+		if i >= 0 {
+			argLoc = given[i].loc
+			err := checkEnumLiterals(def.Type, given[i].value)
+			if err == nil {
+				raw, err = e.raw(given[i].value)
+			}
+			if err != nil {
+				errs = append(errs, validationError(argLoc, "argument %q of %s: %v", def.Name, owner, err))
+				continue
+			}
+		}
+		if _, ok := raw.(absent); ok {
+			if def.Default != nil {
+				args[def.Name] = def.Default
+			} else if isNonNull(def.Type) {
+				errs = append(errs, validationError(argLoc, "argument %q of type %s is required on %s", def.Name, def.Type, owner))
+			}
+			continue
+		}
+		coerced, err := coerceInput(def.Type, raw)
+		if err != nil {
+			errs = append(errs, validationError(argLoc, "argument %q of %s: %v", def.Name, owner, err))
+			continue
+		}
+		args[def.Name] = coerced
+	}
+	return args, errs
+}
+
+// checkEnumLiterals refuses string literals where t expects enum values.
+// coerceInput accepts strings for enums, as JSON variables have no other
+// way to carry them, so documents are checked here.
+func checkEnumLiterals(t Type, v value) error {
+	switch t := t.(type) {
+	case *NonNull:
+		return checkEnumLiterals(t.Of, v)
+	case *List:
+		if list, ok := v.(listValue); ok {
+			for _, elem := range list {
+				if err := checkEnumLiterals(t.Of, elem); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return checkEnumLiterals(t.Of, v)
+	case *Input:
+		if object, ok := v.(objectValue); ok {
+			for _, f := range object {
+				i := slices.IndexFunc(t.Fields, func(a *Arg) bool { return a.Name == f.name })
+				if i < 0 {
+					continue // reported by coerceInput
+				}
+				if err := checkEnumLiterals(t.Fields[i].Type, f.value); err != nil {
+					return fmt.Errorf("%s.%s: %w", t.Name, f.name, err)
+				}
+			}
+		}
+	case *Enum:
+		if s, ok := v.(string); ok {
+			return fmt.Errorf("expected a %s value, found %q", t.Name, s)
+		}
+	}
+	return nil
+}
+
+// Directives that may be set on fields and fragments.
+var conditionArgs = []*Arg{{Name: "if", Type: &NonNull{Of: Boolean}}}
+
+// included evaluates @skip and @include. Directives were validated, so
+// errors cannot occur.
+func (e *executor) included(directives []*directive) bool {
+	for _, d := range directives {
+		args, _ := e.coerceArgs(conditionArgs, d.args, d.loc, "@"+d.name)
+		cond, _ := args["if"].(bool)
+		if d.name == "skip" && cond || d.name == "include" && !cond {
+			return false
+		}
+	}
+	return true
+}
+
+// collect groups the fields of a selection set by response key, in order,
+// expanding fragments. With conditional set, fields excluded by @skip or
+// @include are left out; validation collects them all.
+func (e *executor) collect(selections []selection, conditional bool) []fieldGroup {
+	var groups []fieldGroup
+	visited := map[string]bool{}
+	var walk func(selections []selection)
+	walk = func(selections []selection) {
+		for _, sel := range selections {
+			switch s := sel.(type) {
+			case *field:
+				if conditional && !e.included(s.directives) {
+					continue
+				}
+				key := s.responseKey()
+				if i := slices.IndexFunc(groups, func(g fieldGroup) bool { return g.key == key }); i >= 0 {
+					groups[i].nodes = append(groups[i].nodes, s)
+				} else {
+					groups = append(groups, fieldGroup{key: key, nodes: []*field{s}})
+				}
+			case *inlineFragment:
+				if conditional && !e.included(s.directives) {
+					continue
+				}
+				walk(s.selections)
+			case *fragmentSpread:
+				if conditional && !e.included(s.directives) {
+					continue
+				}
+				f, ok := e.doc.fragments[s.name]
+				if !ok || visited[s.name] {
+					continue // unknown ones are reported by validate
+				}
+				// A fragment adds the same fields each time: expand it once
+				visited[s.name] = true
+				walk(f.selections)
+			}
+		}
+	}
+	walk(selections)
+	return groups
+}
+
+// selectionsOf merges the selection sets of field nodes.
+func selectionsOf(nodes []*field) []selection {
+	var selections []selection
+	for _, n := range nodes {
+		selections = append(selections, n.selections...)
+	}
+	return selections
+}
+
+// validate checks the operation against the schema and the limits,
+// coercing every field's arguments. It stops at the first limit exceeded
+// or when ctx ends.
+func (e *executor) validate(ctx context.Context, op *operation) {
+	if e.validateFragmentCycles(); len(e.errors) > 0 {
+		return // the selection sets are infinite
+	}
+	e.fragments = map[fragmentUse]bool{}
+	e.complexities = map[string]int{}
+	complexity := e.validateSelections(ctx, e.schema.query, op.selections, 1)
+	if err := ctx.Err(); err != nil {
+		e.errors = append(e.errors, &Error{
+			Message:    fmt.Sprintf("request canceled: %v", err),
+			Locations:  []Location{op.loc},
+			Extensions: map[string]any{"code": CodeTimeout},
+		})
+		return
+	}
+	if e.halted {
+		return // the limit was reported
+	}
+	if max := e.schema.limits.MaxComplexity; max > 0 && complexity > max {
+		e.errors = append(e.errors, &Error{
+			Message:    fmt.Sprintf("query complexity exceeds the limit of %d: request smaller pages or fewer nested lists", max),
+			Locations:  []Location{op.loc},
+			Extensions: map[string]any{"code": CodeBadRequest},
+		})
+	}
+}
+
+// validateSelections validates a selection set of obj at depth, returning
+// its complexity. Past MaxComplexity it stops and returns MaxComplexity+1,
+// so that nested sizes cannot overflow.
+func (e *executor) validateSelections(ctx context.Context, obj *Object, selections []selection, depth int) int {
+	if e.halted || ctx.Err() != nil {
+		e.halted = true
+		return 0
+	}
+	if max := e.schema.limits.MaxDepth; max > 0 && depth > max {
+		e.errors = append(e.errors, validationError(selections[0].location(), "query depth exceeds the limit of %d", max))
+		return 0
+	}
+	key := selectionsKey(obj, selections, depth)
+	if complexity, ok := e.complexities[key]; ok {
+		return complexity // errors were reported the first time
+	}
+	complexity := e.validateFields(ctx, obj, selections, depth)
+	if max := e.schema.limits.MaxComplexity; max > 0 && complexity > max {
+		complexity = max + 1
+	}
+	e.complexities[key] = complexity
+	return complexity
+}
+
+// selectionsKey identifies a selection set of obj at depth for caching.
+// Fragment spreads without directives are identified by name, since they
+// expand the same wherever they are; other selections by node.
+func selectionsKey(obj *Object, selections []selection, depth int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s/%d", obj.Name, depth)
+	for _, sel := range selections {
+		if s, ok := sel.(*fragmentSpread); ok && len(s.directives) == 0 {
+			fmt.Fprintf(&b, " ...%s", s.name)
+		} else {
+			fmt.Fprintf(&b, " %p", sel)
+		}
+	}
+	return b.String()
+}
+
+// validateFields validates the fields of a selection set, returning its
+// complexity.
+func (e *executor) validateFields(ctx context.Context, obj *Object, selections []selection, depth int) int {
+	if e.validateFragments(obj, selections); e.halted {
+		return 0
+	}
+
+	complexity := 0
+	budget := e.schema.limits.MaxComplexity
+	for _, group := range e.collect(selections, false) {
+		if e.halted || budget > 0 && complexity > budget {
+			break
+		}
+		first := group.nodes[0]
+		for _, n := range group.nodes[1:] {
+			if n.name != first.name || argsString(n.args) != argsString(first.args) {
+				e.errors = append(e.errors, validationError(n.loc,
+					"fields %q conflict: %s and %s are different fields or have different arguments; use aliases", group.key, first.name, n.name))
+			}
+		}
+		for _, n := range group.nodes {
+			e.validateDirectives(n.directives)
+		}
+
+		if first.name == "__typename" {
+			if len(first.args) > 0 || len(first.selections) > 0 {
+				e.errors = append(e.errors, validationError(first.loc, "__typename takes no arguments or selections"))
+			}
+			complexity++
+			continue
+		}
+		if first.name == "__schema" || first.name == "__type" {
+			e.errors = append(e.errors, validationError(first.loc, "introspection is not supported: see the published schema"))
+			continue
+		}
+		def := obj.field(first.name)
+		if def == nil {
+			e.errors = append(e.errors, validationError(first.loc, "type %s has no field %q", obj.Name, first.name))
+			continue
+		}
+
+		args, errs := e.coerceArgs(def.Args, first.args, first.loc, obj.Name+"."+def.Name)
+		e.errors = append(e.errors, errs...)
+		e.args[first] = args
+		for _, n := range group.nodes[1:] {
+			e.args[n] = args
+		}
+
+		size := 1
+		if def.Size != nil && len(errs) == 0 {
+			size = max(def.Size(args), 1)
+		}
+		children := selectionsOf(group.nodes)
+		switch t := named(def.Type).(type) {
+		case *Object:
+			if len(children) == 0 {
+				e.errors = append(e.errors, validationError(first.loc, "field %q of type %s must have a selection of subfields", group.key, def.Type))
+				continue
+			}
+			complexity += 1 + size*e.validateSelections(ctx, t, children, depth+1)
+		default:
+			if len(children) > 0 {
+				e.errors = append(e.errors, validationError(first.loc, "field %q of type %s cannot have a selection of subfields", group.key, def.Type))
+				continue
+			}
+			complexity++
+		}
+	}
+	return complexity
+}
+
+// validateFragments checks the fragments of a selection set exist and
+// apply to obj, each fragment once per type. Fields inside are validated by
+// validateSelections through collect. Past MaxFragmentSpreads it halts
+// validation.
+func (e *executor) validateFragments(obj *Object, selections []selection) {
+	for _, sel := range selections {
+		if e.halted {
+			return
+		}
+		switch s := sel.(type) {
+		case *inlineFragment:
+			e.validateDirectives(s.directives)
+			if s.typeCondition != "" && s.typeCondition != obj.Name {
+				e.errors = append(e.errors, validationError(s.loc, "fragment on %s cannot be spread within %s", s.typeCondition, obj.Name))
+				continue
+			}
+			e.validateFragments(obj, s.selections)
+		case *fragmentSpread:
+			e.spreads++
+			if max := e.schema.limits.MaxFragmentSpreads; max > 0 && e.spreads > max {
+				e.errors = append(e.errors, validationError(s.loc, "query expands more than %d fragment spreads", max))
+				e.halted = true
+				return
+			}
+			e.validateDirectives(s.directives)
+			f, ok := e.doc.fragments[s.name]
+			use := fragmentUse{fragment: s.name, on: obj.Name}
+			switch {
+			case !ok:
+				e.errors = append(e.errors, validationError(s.loc, "unknown fragment %q", s.name))
+			case len(f.directives) > 0:
+				e.errors = append(e.errors, validationError(f.loc, "directive @%s is not allowed on a fragment definition", f.directives[0].name))
+			case f.typeCondition != obj.Name:
+				e.errors = append(e.errors, validationError(s.loc, "fragment %q on %s cannot be spread within %s", s.name, f.typeCondition, obj.Name))
+			case !e.fragments[use]:
+				e.fragments[use] = true
+				e.validateFragments(obj, f.selections)
+			}
+		}
+	}
+}
+
+// validateFragmentCycles reports fragments that spread themselves,
+// directly or through other fragments, at any depth.
+func (e *executor) validateFragmentCycles() {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var visit func(name string)
+	visit = func(name string) {
+		f, ok := e.doc.fragments[name]
+		if !ok || state[name] == done {
+			return
+		}
+		state[name] = visiting
+		for _, spread := range spreads(f.selections) {
+			if state[spread.name] == visiting {
+				e.errors = append(e.errors, validationError(spread.loc, "fragment %q spreads itself", spread.name))
+				continue
+			}
+			visit(spread.name)
+		}
+		state[name] = done
+	}
+	names := make([]string, 0, len(e.doc.fragments))
+	for name := range e.doc.fragments {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		visit(name)
+	}
+}
+
+// spreads returns the fragment spreads in selections, at any depth.
+func spreads(selections []selection) []*fragmentSpread {
+	var found []*fragmentSpread
+	for _, sel := range selections {
+		switch s := sel.(type) {
+		case *field:
+			found = append(found, spreads(s.selections)...)
+		case *inlineFragment:
+			found = append(found, spreads(s.selections)...)
+		case *fragmentSpread:
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// validateDirectives checks @skip and @include, the only directives.
+func (e *executor) validateDirectives(directives []*directive) {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			e.errors = append(e.errors, validationError(d.loc, "unknown directive @%s", d.name))
+			continue
+		}
+		_, errs := e.coerceArgs(conditionArgs, d.args, d.loc, "@"+d.name)
+		e.errors = append(e.errors, errs...)
+	}
+}
+
+// argsString renders arguments for comparing merged fields.
+func argsString(args []*argument) string {
+	sorted := slices.Clone(args)
+	slices.SortFunc(sorted, func(a, b *argument) int {
+		if a.name < b.name {
+			return -1
+		}
+		if a.name > b.name {
+			return 1
+		}
+		return 0
+	})
+	s := ""
+	for _, a := range sorted {
+		s += a.name + ":" + valueString(a.value) + ","
+	}
+	return s
+}
+
+// selectionSet executes a selection set of obj on source. failed reports a
+// null in a non-null field, which makes the object null.
+func (e *executor) selectionSet(ctx context.Context, obj *Object, source any, selections []selection, path []any) (object, bool) {
+	var out object
+	for _, group := range e.collect(selections, true) {
+		first := group.nodes[0]
+		fieldPath := append(slices.Clip(path), group.key)
+		if first.name == "__typename" {
+			out = append(out, entry{group.key, obj.Name})
+			continue
+		}
+
+		def := obj.field(first.name)
+		v, failed := e.field(ctx, def, source, group.nodes, fieldPath)
+		if failed {
+			if isNonNull(def.Type) {
+				return nil, true
+			}
+			v = nil
+		}
+		out = append(out, entry{group.key, v})
+	}
+	if out == nil {
+		out = object{}
+	}
+	return out, false
+}
+
+// field resolves and completes one field. failed reports that the value is
+// null because of an error, already recorded.
+func (e *executor) field(ctx context.Context, def *Field, source any, nodes []*field, path []any) (any, bool) {
+	if err := ctx.Err(); err != nil {
+		e.errors = append(e.errors, fieldError(Errorf(CodeTimeout, "request canceled: %v", err), nodes[0].loc, path))
+		return nil, true
+	}
+
+	var v any
+	var err error
+	if def.Resolve != nil {
+		v, err = def.Resolve(ResolveParams{Context: ctx, Source: source, Args: e.args[nodes[0]], exec: e, nodes: nodes})
+	} else if m, ok := source.(map[string]any); ok {
+		v = m[def.Name]
+	}
+	if err != nil {
+		e.errors = append(e.errors, fieldError(err, nodes[0].loc, path))
+		return nil, true
+	}
+	return e.complete(ctx, def.Type, nodes, v, path)
+}
+
+// complete converts a resolved value to its response form. failed reports
+// that the value is null because of an error, already recorded; a nullable
+// position takes it as null, a non-null one fails in turn.
+func (e *executor) complete(ctx context.Context, t Type, nodes []*field, v any, path []any) (any, bool) {
+	if nn, ok := t.(*NonNull); ok {
+		out, failed := e.complete(ctx, nn.Of, nodes, v, path)
+		if failed {
+			return nil, true
+		}
+		if out == nil {
+			e.errors = append(e.errors, fieldError(fmt.Errorf("null for non-null field"), nodes[0].loc, path))
+			return nil, true
+		}
+		return out, false
+	}
+	if isNil(v) {
+		return nil, false
+	}
+
+	switch t := t.(type) {
+	case *Scalar:
+		if t.Serialize == nil {
+			return v, false
+		}
+		out, err := t.Serialize(v)
+		if err != nil {
+			e.errors = append(e.errors, fieldError(err, nodes[0].loc, path))
+			return nil, true
+		}
+		return out, false
+	case *Enum:
+		s := fmt.Sprint(v)
+		if !slices.Contains(t.Values, s) {
+			e.errors = append(e.errors, fieldError(fmt.Errorf("%q is not a %s value", s, t.Name), nodes[0].loc, path))
+			return nil, true
+		}
+		return s, false
+	case *List:
+		items := reflect.ValueOf(v)
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			e.errors = append(e.errors, fieldError(fmt.Errorf("%T is not a list", v), nodes[0].loc, path))
+			return nil, true
+		}
+		out := make([]any, items.Len())
+		for i := range out {
+			item, failed := e.complete(ctx, t.Of, nodes, items.Index(i).Interface(), append(slices.Clip(path), i))
+			if failed && isNonNull(t.Of) {
+				return nil, true
+			}
+			out[i] = item
+		}
+		return out, false
+	case *Object:
+		return e.selectionSet(ctx, t, v, selectionsOf(nodes), path)
+	}
+	return nil, false
+}
+
+// isNil reports whether v is nil or a nil pointer, map or slice.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
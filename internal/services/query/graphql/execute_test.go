@@ -0,0 +1,485 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSchema serves items with numeric IDs. selected records what
+// Query.items' selection set selects, through ResolveParams.Selects.
+func testSchema(t *testing.T, selected map[string]bool) *Schema {
+	t.Helper()
+	kind := &Enum{Name: "Kind", Values: []string{"A", "B"}}
+	filter := &Input{Name: "Filter", Fields: []*Arg{
+		{Name: "prefix", Type: String},
+		{Name: "kinds", Type: &List{Of: &NonNull{Of: kind}}},
+		{Name: "limit", Type: Int, Default: 5},
+		{Name: "required", Type: &NonNull{Of: Boolean}, Default: true},
+	}}
+	item := &Object{Name: "Item", Fields: []*Field{
+		{Name: "id", Type: &NonNull{Of: ID}},
+		{Name: "name", Type: String},
+		{Name: "forbidden", Type: String, Resolve: func(ResolveParams) (any, error) {
+			return nil, Errorf(CodeForbidden, "not allowed")
+		}},
+		{Name: "broken", Type: &NonNull{Of: String}, Resolve: func(ResolveParams) (any, error) {
+			return nil, errors.New("connection reset by peer")
+		}},
+	}}
+	items := func(p ResolveParams) (any, error) {
+		first, _ := p.Args["first"].(int)
+		var list []map[string]any
+		for i := 1; i <= first; i++ {
+			list = append(list, map[string]any{"id": i, "name": "item-" + strconv.Itoa(i)})
+		}
+		return list, nil
+	}
+	item.Fields = append(item.Fields, &Field{
+		Name:    "children",
+		Args:    []*Arg{{Name: "first", Type: Int, Default: 2}},
+		Type:    &NonNull{Of: &List{Of: &NonNull{Of: item}}},
+		Resolve: items,
+		Size:    func(args map[string]any) int { return args["first"].(int) },
+	})
+
+	query := &Object{Name: "Query", Fields: []*Field{
+		{
+			Name: "item",
+			Args: []*Arg{{Name: "id", Type: &NonNull{Of: ID}}},
+			Type: item,
+			Resolve: func(p ResolveParams) (any, error) {
+				id := p.Args["id"].(string)
+				if id == "0" {
+					return nil, nil
+				}
+				return map[string]any{"id": id, "name": "item-" + id}, nil
+			},
+		},
+		{
+			Name: "items",
+			Args: []*Arg{{Name: "first", Type: Int, Default: 2}},
+			Type: &NonNull{Of: &List{Of: &NonNull{Of: item}}},
+			Resolve: func(p ResolveParams) (any, error) {
+				for _, path := range []string{"name", "children.name"} {
+					if selected != nil && p.Selects(path) {
+						selected[path] = true
+					}
+				}
+				return items(p)
+			},
+			Size: func(args map[string]any) int { return args["first"].(int) },
+		},
+		{
+			Name: "echo",
+			Args: []*Arg{{Name: "kind", Type: kind}, {Name: "filter", Type: filter}, {Name: "ids", Type: &List{Of: ID}}},
+			Type: String,
+			Resolve: func(p ResolveParams) (any, error) {
+				b, err := json.Marshal(p.Args)
+				return string(b), err
+			},
+		},
+		{
+			Name: "mustItem",
+			Type: &NonNull{Of: item},
+			Resolve: func(ResolveParams) (any, error) {
+				return map[string]any{"id": "1"}, nil
+			},
+		},
+	}}
+	s, err := NewSchema(query, Limits{MaxDepth: 4, MaxComplexity: 60, MaxFragmentSpreads: 50})
+	require.NoError(t, err)
+	return s
+}
+
+// run executes a query and returns its JSON response.
+func run(t *testing.T, s *Schema, query string, variables map[string]any) string {
+	t.Helper()
+	b, err := json.Marshal(s.Execute(context.Background(), Request{Query: query, Variables: variables}))
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestExecute_SelectionOrderAndAliases(t *testing.T) {
+	s := testSchema(t, nil)
+
+	got := run(t, s, `{ b: item(id: "2") { name id __typename } a: item(id: 1) { id } missing: item(id: 0) { id } }`, nil)
+
+	assert.JSONEq(t, `{"data": {
+		"b": {"name": "item-2", "id": "2", "__typename": "Item"},
+		"a": {"id": "1"},
+		"missing": null
+	}}`, got)
+	assert.Regexp(t, `^\{"data":\{"b":\{"name":"item-2","id":"2","__typename":"Item"\},"a"`, got)
+}
+
+func TestExecute_ListsAndNesting(t *testing.T) {
+	s := testSchema(t, nil)
+
+	got := run(t, s, `{ items(first: 2) { id children(first: 1) { name } } }`, nil)
+
+	assert.JSONEq(t, `{"data": {"items": [
+		{"id": "1", "children": [{"name": "item-1"}]},
+		{"id": "2", "children": [{"name": "item-1"}]}
+	]}}`, got)
+}
+
+func TestExecute_Variables(t *testing.T) {
+	s := testSchema(t, nil)
+	query := `query Q($id: ID!, $first: Int = 1) { item(id: $id) { id } items(first: $first) { id } }`
+
+	got := run(t, s, query, map[string]any{"id": json.Number("7")})
+	assert.JSONEq(t, `{"data": {"item": {"id": "7"}, "items": [{"id": "1"}]}}`, got)
+
+	got = run(t, s, query, map[string]any{"id": "x", "first": json.Number("2")})
+	assert.JSONEq(t, `{"data": {"item": {"id": "x"}, "items": [{"id": "1"}, {"id": "2"}]}}`, got)
+}
+
+func TestExecute_VariableErrors(t *testing.T) {
+	s := testSchema(t, nil)
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      string
+	}{
+		{"missing required", `query($id: ID!) { item(id: $id) { id } }`, nil, "variable $id of type ID! is required"},
+		{"null for non-null", `query($id: ID!) { item(id: $id) { id } }`, map[string]any{"id": nil}, "expected a non-null ID"},
+		{"wrong type", `query($n: Int) { items(first: $n) { id } }`, map[string]any{"n": "two"}, `expected a 32-bit integer, found "two"`},
+		{"out of range", `query($n: Int) { items(first: $n) { id } }`, map[string]any{"n": json.Number("3000000000")}, "expected a 32-bit integer"},
+		{"unknown type", `query($n: Count) { items(first: $n) { id } }`, nil, "unknown type Count"},
+		{"output type", `query($n: Item) { items { id } }`, nil, "Item is not an input type"},
+		{"undefined in operation", `{ items { id } }`, map[string]any{"n": json.Number("1")}, "variable $n is not defined by the operation"},
+		{"undefined in document", `{ items(first: $n) { id } }`, nil, "variable $n is not defined"},
+		{"nullable into non-null", `query($id: ID) { item(id: $id) { id } }`, map[string]any{"id": nil}, `argument "id" of Query.item: expected a non-null ID`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Execute(context.Background(), Request{Query: tt.query, Variables: tt.variables})
+
+			assert.False(t, resp.Executed())
+			require.NotEmpty(t, resp.Errors)
+			assert.Contains(t, resp.Errors[0].Message, tt.want)
+			assert.Equal(t, CodeBadRequest, resp.Errors[0].Code())
+		})
+	}
+}
+
+func TestExecute_AbsentVariableUsesArgumentDefault(t *testing.T) {
+	s := testSchema(t, nil)
+
+	got := run(t, s, `query($n: Int) { items(first: $n) { id } }`, nil)
+
+	assert.JSONEq(t, `{"data": {"items": [{"id": "1"}, {"id": "2"}]}}`, got)
+}
+
+func TestExecute_InputCoercion(t *testing.T) {
+	s := testSchema(t, nil)
+
+	got := run(t, s, `{ echo(kind: B, filter: {prefix: "dev", kinds: A}, ids: 5) }`, nil)
+	var resp struct{ Data struct{ Echo string } }
+	require.NoError(t, json.Unmarshal([]byte(got), &resp))
+	assert.JSONEq(t, `{
+		"kind": "B",
+		"filter": {"prefix": "dev", "kinds": ["A"], "limit": 5, "required": true},
+		"ids": ["5"]
+	}`, resp.Data.Echo)
+
+	got = run(t, s, `query($f: Filter) { echo(filter: $f) }`, map[string]any{
+		"f": map[string]any{"kinds": []any{"A", "B"}, "limit": json.Number("1")},
+	})
+	require.NoError(t, json.Unmarshal([]byte(got), &resp))
+	assert.JSONEq(t, `{"filter": {"kinds": ["A", "B"], "limit": 1, "required": true}}`, resp.Data.Echo)
+}
+
+func TestExecute_ValidationErrors(t *testing.T) {
+	s := testSchema(t, nil)
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"syntax", `{ item(id: 1) { id }`, "syntax error: unexpected end of document"},
+		{"unknown field", `{ item(id: 1) { size } }`, `type Item has no field "size"`},
+		{"missing argument", `{ item { id } }`, `argument "id" of type ID! is required on Query.item`},
+		{"unknown argument", `{ items(last: 1) { id } }`, `unknown argument "last" on Query.items`},
+		{"argument type", `{ items(first: "1") { id } }`, `argument "first" of Query.items: expected a 32-bit integer`},
+		{"enum value", `{ echo(kind: C) }`, `"C" is not a Kind value`},
+		{"string for enum", `{ echo(kind: "A") }`, `expected a Kind value, found "A"`},
+		{"enum for scalar", `{ item(id: one) { id } }`, "expected a ID, found enum value one"},
+		{"unknown input field", `{ echo(filter: {suffix: "x"}) }`, `Filter has no field "suffix"`},
+		{"duplicate input field", `{ echo(filter: {prefix: "a", prefix: "b"}) }`, `field "prefix" is given twice`},
+		{"subfields on leaf", `{ item(id: 1) { id { x } } }`, `field "id" of type ID! cannot have a selection of subfields`},
+		{"no subfields on object", `{ item(id: 1) }`, `field "item" of type Item must have a selection of subfields`},
+		{"conflicting aliases", `{ x: item(id: 1) { id } x: items { id } }`, `fields "x" conflict`},
+		{"conflicting arguments", `{ item(id: 1) { id } item(id: 2) { id } }`, `fields "item" conflict`},
+		{"unknown fragment", `{ item(id: 1) { ...F } }`, `unknown fragment "F"`},
+		{"fragment type", `fragment F on Query { items { id } } { item(id: 1) { ...F } }`, `fragment "F" on Query cannot be spread within Item`},
+		{"fragment cycle", `fragment F on Item { ...G } fragment G on Item { children { ...F } } { items { ...F } }`, `spreads itself`},
+		{"unknown directive", `{ items @cached { id } }`, "unknown directive @cached"},
+		{"directive argument", `{ items @skip { id } }`, `argument "if" of type Boolean! is required on @skip`},
+		{"mutation", `mutation { items { id } }`, "mutation operations are not supported"},
+		{"introspection", `{ __schema { types { name } } }`, "introspection is not supported"},
+		{"operation name required", `query A { items { id } } query B { items { id } }`, "operationName is required"},
+		{"depth", `{ items { children { children { children { id } } } } }`, "query depth exceeds the limit of 4"},
+		{"complexity", `{ items(first: 10) { children(first: 10) { id } } }`, "query complexity exceeds the limit of 60"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Execute(context.Background(), Request{Query: tt.query})
+
+			assert.False(t, resp.Executed())
+			require.NotEmpty(t, resp.Errors)
+			assert.Contains(t, resp.Errors[0].Message, tt.want)
+			assert.Equal(t, CodeBadRequest, resp.Errors[0].Code())
+			assert.NotEmpty(t, resp.Errors[0].Locations)
+
+			b, err := json.Marshal(resp)
+			require.NoError(t, err)
+			assert.NotContains(t, string(b), `"data"`)
+		})
+	}
+}
+
+func TestExecute_ErrorLocation(t *testing.T) {
+	s := testSchema(t, nil)
+
+	resp := s.Execute(context.Background(), Request{Query: "{\n  items {\n    size\n  }\n}"})
+
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, []Location{{Line: 3, Column: 5}}, resp.Errors[0].Locations)
+}
+
+func TestExecute_OperationName(t *testing.T) {
+	s := testSchema(t, nil)
+	doc := `query A { item(id: 1) { id } } query B { item(id: 2) { id } }`
+
+	resp := s.Execute(context.Background(), Request{Query: doc, OperationName: "B"})
+	b, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data": {"item": {"id": "2"}}}`, string(b))
+
+	resp = s.Execute(context.Background(), Request{Query: doc, OperationName: "C"})
+	require.NotEmpty(t, resp.Errors)
+	assert.Equal(t, `unknown operation "C"`, resp.Errors[0].Message)
+}
+
+func TestExecute_FragmentsAndDirectives(t *testing.T) {
+	s := testSchema(t, nil)
+	query := `
+		query($withName: Boolean!) {
+			item(id: 1) {
+				...Fields
+				... on Item { name @include(if: $withName) }
+				... @skip(if: true) { forbidden }
+			}
+			items(first: 1) @skip(if: true) { id }
+		}
+		fragment Fields on Item { id __typename }`
+
+	got := run(t, s, query, map[string]any{"withName": false})
+	assert.JSONEq(t, `{"data": {"item": {"id": "1", "__typename": "Item"}}}`, got)
+
+	got = run(t, s, query, map[string]any{"withName": true})
+	assert.JSONEq(t, `{"data": {"item": {"id": "1", "__typename": "Item", "name": "item-1"}}}`, got)
+}
+
+func TestExecute_FieldErrors(t *testing.T) {
+	s := testSchema(t, nil)
+
+	// A nullable field that fails is null; the rest of the object is answered
+	got := run(t, s, `{ item(id: 1) { id forbidden } }`, nil)
+	assert.JSONEq(t, `{
+		"data": {"item": {"id": "1", "forbidden": null}},
+		"errors": [{
+			"message": "not allowed",
+			"locations": [{"line": 1, "column": 20}],
+			"path": ["item", "forbidden"],
+			"extensions": {"code": "FORBIDDEN"}
+		}]
+	}`, got)
+
+	// A non-null field that fails nulls its parent; other errors are not leaked
+	got = run(t, s, `{ item(id: 1) { id broken } other: item(id: 2) { id } }`, nil)
+	assert.JSONEq(t, `{
+		"data": {"item": null, "other": {"id": "2"}},
+		"errors": [{
+			"message": "internal server error",
+			"locations": [{"line": 1, "column": 20}],
+			"path": ["item", "broken"],
+			"extensions": {"code": "INTERNAL"}
+		}]
+	}`, got)
+
+	// Up to the root, which makes data null
+	got = run(t, s, `{ mustItem { broken } }`, nil)
+	assert.JSONEq(t, `{
+		"data": null,
+		"errors": [{
+			"message": "internal server error",
+			"locations": [{"line": 1, "column": 14}],
+			"path": ["mustItem", "broken"],
+			"extensions": {"code": "INTERNAL"}
+		}]
+	}`, got)
+
+	// Through lists of non-null items
+	got = run(t, s, `{ items(first: 2) { broken } }`, nil)
+	assert.Contains(t, got, `"data":null`)
+	assert.Contains(t, got, `"path":["items",0,"broken"]`)
+}
+
+func TestExecute_CanceledContext(t *testing.T) {
+	s := testSchema(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resp := s.Execute(ctx, Request{Query: `{ item(id: 1) { id } }`})
+
+	assert.False(t, resp.Executed())
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, CodeTimeout, resp.Errors[0].Code())
+}
+
+func TestExecute_CanceledDuringExecution(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	query := &Object{Name: "Query", Fields: []*Field{
+		{Name: "cancel", Type: Boolean, Resolve: func(ResolveParams) (any, error) {
+			cancel()
+			return true, nil
+		}},
+		{Name: "after", Type: Boolean, Resolve: func(ResolveParams) (any, error) {
+			return true, nil
+		}},
+	}}
+	s, err := NewSchema(query, Limits{})
+	require.NoError(t, err)
+
+	resp := s.Execute(ctx, Request{Query: `{ cancel after }`})
+
+	assert.True(t, resp.Executed())
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, CodeTimeout, resp.Errors[0].Code())
+	assert.Equal(t, []any{"after"}, resp.Errors[0].Path)
+}
+
+func TestExecute_NestedFragmentSpreads(t *testing.T) {
+	s := testSchema(t, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Each fragment spreads the next twice: 2^24 paths through 25 fragments
+	var doc strings.Builder
+	doc.WriteString(`{ items(first: 1) { ...F0 } }`)
+	for i := 0; i < 24; i++ {
+		fmt.Fprintf(&doc, " fragment F%d on Item { id ...F%d ...F%d }", i, i+1, i+1)
+	}
+	doc.WriteString(" fragment F24 on Item { name }")
+
+	resp := s.Execute(ctx, Request{Query: doc.String()})
+	b, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data": {"items": [{"id": "1", "name": "item-1"}]}}`, string(b))
+
+	// Nested lists through fragments stop at the complexity budget
+	doc.Reset()
+	doc.WriteString(`{ items { ...G0 } }`)
+	for i := 0; i < 24; i++ {
+		fmt.Fprintf(&doc, " fragment G%d on Item { a: children { ...G%d } b: children { ...G%d } }", i, i+1, i+1)
+	}
+	doc.WriteString(" fragment G24 on Item { id }")
+	resp = s.Execute(ctx, Request{Query: doc.String()})
+	assert.False(t, resp.Executed())
+	require.NotEmpty(t, resp.Errors)
+	assert.Equal(t, CodeBadRequest, resp.Errors[0].Code())
+
+	// Long chains of spreads stop at the spread limit
+	doc.Reset()
+	doc.WriteString(`{ items { ...H0 } }`)
+	for i := 0; i < 60; i++ {
+		fmt.Fprintf(&doc, " fragment H%d on Item { ...H%d }", i, i+1)
+	}
+	doc.WriteString(" fragment H60 on Item { id }")
+	resp = s.Execute(ctx, Request{Query: doc.String()})
+	assert.False(t, resp.Executed())
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "query expands more than 50 fragment spreads", resp.Errors[0].Message)
+}
+
+func TestResolveParams_Selects(t *testing.T) {
+	selected := map[string]bool{}
+	s := testSchema(t, selected)
+
+	run(t, s, `{ items { id ...F } } fragment F on Item { children { name } }`, nil)
+	assert.Equal(t, map[string]bool{"children.name": true}, selected)
+
+	clear(selected)
+	run(t, s, `{ items { name @skip(if: true) children @include(if: false) { name } } }`, nil)
+	assert.Empty(t, selected)
+
+	clear(selected)
+	run(t, s, `{ items { ... on Item { name } } }`, nil)
+	assert.Equal(t, map[string]bool{"name": true}, selected)
+}
+
+func TestNewSchema_DuplicateTypeNames(t *testing.T) {
+	query := &Object{Name: "Query", Fields: []*Field{
+		{Name: "a", Type: &Object{Name: "T", Fields: []*Field{{Name: "x", Type: Int}}}},
+		{Name: "b", Type: &Object{Name: "T", Fields: []*Field{{Name: "y", Type: Int}}}},
+	}}
+
+	_, err := NewSchema(query, Limits{})
+
+	assert.EqualError(t, err, "graphql: two types named T")
+}
+
+func TestNewSchema_OutputTypeArgument(t *testing.T) {
+	item := &Object{Name: "Item", Fields: []*Field{{Name: "x", Type: Int}}}
+	query := &Object{Name: "Query", Fields: []*Field{
+		{Name: "a", Args: []*Arg{{Name: "in", Type: item}}, Type: item},
+	}}
+
+	_, err := NewSchema(query, Limits{})
+
+	assert.EqualError(t, err, "graphql: Query.a(in): Item is not an input type")
+}
+
+func TestSchema_SDL(t *testing.T) {
+	kind := &Enum{Name: "Kind", Description: "A kind.", Values: []string{"A", "B"}}
+	query := &Object{Name: "Query", Fields: []*Field{
+		{Name: "count", Type: &NonNull{Of: Int}},
+		{
+			Name:        "list",
+			Description: "Lists things.\nIn pages.",
+			Args:        []*Arg{{Name: "kind", Type: kind, Default: "A"}, {Name: "first", Type: Int, Default: 20}},
+			Type:        &List{Of: String},
+		},
+	}}
+	s, err := NewSchema(query, Limits{})
+	require.NoError(t, err)
+
+	assert.Equal(t, `type Query {
+  count: Int!
+
+  """
+  Lists things.
+  In pages.
+  """
+  list(kind: Kind = A, first: Int = 20): [String]
+}
+
+"A kind."
+enum Kind {
+  A
+  B
+}
+`, s.SDL())
+}
@@ -0,0 +1,300 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind is the kind of a lexical token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+// token is one lexical token of a document. Strings hold their decoded
+// value.
+type token struct {
+	kind  tokenKind
+	value string
+	loc   Location
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of document"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// lexer splits a document into tokens. Whitespace, commas and comments are
+// insignificant and skipped.
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int // 1-based column of pos
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+// syntaxError reports a syntax error at loc.
+func syntaxError(loc Location, format string, args ...any) *Error {
+	return &Error{
+		Message:    "syntax error: " + fmt.Sprintf(format, args...),
+		Locations:  []Location{loc},
+		Extensions: map[string]any{"code": CodeBadRequest},
+	}
+}
+
+// advance moves past n bytes that contain no line terminators.
+func (l *lexer) advance(n int) {
+	l.col += utf8.RuneCountInString(l.src[l.pos : l.pos+n])
+	l.pos += n
+}
+
+// newline moves past a line terminator of n bytes.
+func (l *lexer) newline(n int) {
+	l.pos += n
+	l.line++
+	l.col = 1
+}
+
+// next returns the next token.
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	loc := Location{Line: l.line, Column: l.col}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, loc: loc}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.advance(1)
+		return token{kind: tokenPunct, value: string(c), loc: loc}, nil
+	case c == '.':
+		if !strings.HasPrefix(l.src[l.pos:], "...") {
+			return token{}, syntaxError(loc, "unexpected %q", ".")
+		}
+		l.advance(3)
+		return token{kind: tokenPunct, value: "...", loc: loc}, nil
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.advance(1)
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], loc: loc}, nil
+	case c == '-' || isDigit(c):
+		return l.number(loc)
+	case c == '"':
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			return l.blockString(loc)
+		}
+		return l.string(loc)
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, syntaxError(loc, "unexpected character %q", r)
+}
+
+// skipIgnored skips whitespace, line terminators, commas, comments and a
+// byte order mark.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == ',':
+			l.advance(1)
+		case c == '\n':
+			l.newline(1)
+		case c == '\r':
+			if strings.HasPrefix(l.src[l.pos:], "\r\n") {
+				l.newline(2)
+			} else {
+				l.newline(1)
+			}
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+				l.advance(size)
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"):
+			l.advance(len("\uFEFF"))
+		default:
+			return
+		}
+	}
+}
+
+// number lexes an IntValue or FloatValue.
+func (l *lexer) number(loc Location) (token, error) {
+	start := l.pos
+	if l.src[l.pos] == '-' {
+		l.advance(1)
+	}
+	intStart := l.pos
+	digits := l.digits()
+	if digits == 0 {
+		return token{}, syntaxError(loc, "invalid number: expected a digit")
+	}
+	if digits > 1 && l.src[intStart] == '0' {
+		return token{}, syntaxError(loc, "invalid number %q: leading zero", l.src[start:l.pos])
+	}
+
+	kind := tokenInt
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.advance(1)
+		if l.digits() == 0 {
+			return token{}, syntaxError(loc, "invalid number %q: expected a digit after the point", l.src[start:l.pos])
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.advance(1)
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.advance(1)
+		}
+		if l.digits() == 0 {
+			return token{}, syntaxError(loc, "invalid number %q: expected an exponent", l.src[start:l.pos])
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == '.' || l.src[l.pos] == '_' || isLetter(l.src[l.pos])) {
+		return token{}, syntaxError(loc, "invalid number %q", l.src[start:l.pos+1])
+	}
+	return token{kind: kind, value: l.src[start:l.pos], loc: loc}, nil
+}
+
+// digits skips decimal digits and returns how many there were.
+func (l *lexer) digits() int {
+	n := 0
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.advance(1)
+		n++
+	}
+	return n
+}
+
+// string lexes a quoted string, decoding its escape sequences.
+func (l *lexer) string(loc Location) (token, error) {
+	l.advance(1)
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' || l.src[l.pos] == '\r' {
+			return token{}, syntaxError(loc, "unterminated string")
+		}
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.advance(1)
+			return token{kind: tokenString, value: b.String(), loc: loc}, nil
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, syntaxError(loc, "unterminated string")
+			}
+			escape := l.src[l.pos+1]
+			if escape == 'u' {
+				if l.pos+6 > len(l.src) {
+					return token{}, syntaxError(loc, "invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(l.src[l.pos+2:l.pos+6], 16, 16)
+				if err != nil {
+					return token{}, syntaxError(loc, "invalid unicode escape \\u%s", l.src[l.pos+2:l.pos+6])
+				}
+				b.WriteRune(rune(code))
+				l.advance(6)
+				continue
+			}
+			decoded, ok := map[byte]byte{'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t'}[escape]
+			if !ok {
+				return token{}, syntaxError(loc, "invalid escape \\%c", escape)
+			}
+			b.WriteByte(decoded)
+			l.advance(2)
+		default:
+			_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			b.WriteString(l.src[l.pos : l.pos+size])
+			l.advance(size)
+		}
+	}
+}
+
+// blockString lexes a """block string""", removing its common indentation
+// and leading and trailing blank lines.
+func (l *lexer) blockString(loc Location) (token, error) {
+	l.advance(3)
+	var raw strings.Builder
+	for {
+		switch {
+		case l.pos >= len(l.src):
+			return token{}, syntaxError(loc, "unterminated block string")
+		case strings.HasPrefix(l.src[l.pos:], `"""`):
+			l.advance(3)
+			return token{kind: tokenString, value: blockStringValue(raw.String()), loc: loc}, nil
+		case strings.HasPrefix(l.src[l.pos:], `\"""`):
+			raw.WriteString(`"""`)
+			l.advance(4)
+		case strings.HasPrefix(l.src[l.pos:], "\r\n"):
+			raw.WriteByte('\n')
+			l.newline(2)
+		case l.src[l.pos] == '\n' || l.src[l.pos] == '\r':
+			raw.WriteByte('\n')
+			l.newline(1)
+		default:
+			_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			raw.WriteString(l.src[l.pos : l.pos+size])
+			l.advance(size)
+		}
+	}
+}
+
+// blockStringValue applies the block string indentation rules to raw.
+func blockStringValue(raw string) string {
+	lines := strings.Split(raw, "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,595 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// document is a parsed executable document: operations and fragments.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	variables  []*variableDef
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+type variableDef struct {
+	name  string
+	typ   *typeExpr
+	value value // default; nil if none
+	loc   Location
+}
+
+// typeExpr is a type as written in a variable definition.
+type typeExpr struct {
+	name    string    // named type; empty for a list
+	elem    *typeExpr // list element type
+	nonNull bool
+}
+
+func (t *typeExpr) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+	loc           Location
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment.
+type selection interface {
+	location() Location
+}
+
+type field struct {
+	alias      string
+	name       string
+	args       []*argument
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+// responseKey is the field's alias, or its name.
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	loc        Location
+}
+
+type inlineFragment struct {
+	typeCondition string // empty for the enclosing type
+	directives    []*directive
+	selections    []selection
+	loc           Location
+}
+
+func (f *field) location() Location          { return f.loc }
+func (f *fragmentSpread) location() Location { return f.loc }
+func (f *inlineFragment) location() Location { return f.loc }
+
+type argument struct {
+	name  string
+	value value
+	loc   Location
+}
+
+type directive struct {
+	name string
+	args []*argument
+	loc  Location
+}
+
+// value is a literal input value: variableRef, intValue, floatValue,
+// string, bool, nullValue, enumValue, listValue or objectValue.
+type value any
+
+type (
+	variableRef string
+	intValue    string
+	floatValue  string
+	enumValue   string
+	nullValue   struct{}
+	listValue   []value
+	objectValue []objectField
+)
+
+type objectField struct {
+	name  string
+	value value
+}
+
+// parser is a recursive descent parser of executable documents.
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+// parse parses an executable document. Type system definitions are
+// refused: the schema is defined in Go.
+func parse(src string) (*document, error) {
+	p := &parser{lexer: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: map[string]*fragment{}}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"), p.peekName("query"), p.peekName("mutation"), p.peekName("subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.peekName("fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[f.name]; dup {
+				return nil, validationError(f.loc, "there can be only one fragment named %q", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, syntaxError(p.tok.loc, "the document has no operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// peek reports whether the current token is the punctuator s.
+func (p *parser) peek(s string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == s
+}
+
+// peekName reports whether the current token is the name s.
+func (p *parser) peekName(s string) bool {
+	return p.tok.kind == tokenName && p.tok.value == s
+}
+
+func (p *parser) unexpected() error {
+	return syntaxError(p.tok.loc, "unexpected %s", p.tok)
+}
+
+// expect consumes the punctuator s.
+func (p *parser) expect(s string) error {
+	if !p.peek(s) {
+		return syntaxError(p.tok.loc, "expected %q, found %s", s, p.tok)
+	}
+	return p.advance()
+}
+
+// skip consumes the punctuator s if it is next, reporting whether it was.
+func (p *parser) skip(s string) (bool, error) {
+	if !p.peek(s) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+// name consumes a name.
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", syntaxError(p.tok.loc, "expected a name, found %s", p.tok)
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: "query", loc: p.tok.loc}
+	if p.peek("{") {
+		selections, err := p.selectionSet()
+		op.selections = selections
+		return op, err
+	}
+
+	op.kind = p.tok.value
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		vars, err := p.variableDefs()
+		if err != nil {
+			return nil, err
+		}
+		op.variables = vars
+	}
+	directives, err := p.directives()
+	if err != nil {
+		return nil, err
+	}
+	op.directives = directives
+	op.selections, err = p.selectionSet()
+	return op, err
+}
+
+func (p *parser) variableDefs() ([]*variableDef, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var defs []*variableDef
+	for !p.peek(")") {
+		def := &variableDef{loc: p.tok.loc}
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		def.name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if def.typ, err = p.typeExpr(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if def.value, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek("@") {
+			return nil, syntaxError(p.tok.loc, "directives on variables are not supported")
+		}
+		defs = append(defs, def)
+	}
+	return defs, p.advance()
+}
+
+func (p *parser) typeExpr() (*typeExpr, error) {
+	t := &typeExpr{}
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		elem, err := p.typeExpr()
+		if err != nil {
+			return nil, err
+		}
+		t.elem = elem
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t.name = name
+	}
+	nonNull, err := p.skip("!")
+	t.nonNull = nonNull
+	return t, err
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	f := &fragment{loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, syntaxError(f.loc, "a fragment cannot be named \"on\"")
+	}
+	f.name = name
+	if !p.peekName("on") {
+		return nil, syntaxError(p.tok.loc, "expected \"on\", found %s", p.tok)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if f.typeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	f.selections, err = p.selectionSet()
+	return f, err
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for !p.peek("}") {
+		if p.tok.kind == tokenEOF {
+			return nil, p.unexpected()
+		}
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, s)
+	}
+	if len(selections) == 0 {
+		return nil, syntaxError(p.tok.loc, "a selection set cannot be empty")
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (selection, error) {
+	loc := p.tok.loc
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		return p.fragmentSelection(loc)
+	}
+
+	f := &field{loc: loc}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f.name = name
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		if f.args, err = p.arguments(false); err != nil {
+			return nil, err
+		}
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// fragmentSelection parses what follows "...": a fragment spread or an
+// inline fragment.
+func (p *parser) fragmentSelection(loc Location) (selection, error) {
+	if p.tok.kind == tokenName && !p.peekName("on") {
+		spread := &fragmentSpread{name: p.tok.value, loc: loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		directives, err := p.directives()
+		spread.directives = directives
+		return spread, err
+	}
+
+	inline := &inlineFragment{loc: loc}
+	if p.peekName("on") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		inline.typeCondition = name
+	}
+	var err error
+	if inline.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	inline.selections, err = p.selectionSet()
+	return inline, err
+}
+
+func (p *parser) arguments(constant bool) ([]*argument, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []*argument
+	for !p.peek(")") {
+		arg := &argument{loc: p.tok.loc}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		arg.name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if arg.value, err = p.value(constant); err != nil {
+			return nil, err
+		}
+		for _, other := range args {
+			if other.name == arg.name {
+				return nil, validationError(arg.loc, "there can be only one argument named %q", arg.name)
+			}
+		}
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		return nil, syntaxError(p.tok.loc, "an argument list cannot be empty")
+	}
+	return args, p.advance()
+}
+
+func (p *parser) directives() ([]*directive, error) {
+	var directives []*directive
+	for p.peek("@") {
+		d := &directive{loc: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		d.name = name
+		if p.peek("(") {
+			if d.args, err = p.arguments(false); err != nil {
+				return nil, err
+			}
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// value parses an input value; constant values may not refer to variables.
+func (p *parser) value(constant bool) (value, error) {
+	tok := p.tok
+	switch {
+	case p.peek("$"):
+		if constant {
+			return nil, syntaxError(tok.loc, "a default value cannot refer to a variable")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variableRef(name), err
+	case p.peek("["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := listValue{}
+		for !p.peek("]") {
+			if p.tok.kind == tokenEOF {
+				return nil, p.unexpected()
+			}
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, p.advance()
+	case p.peek("{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		object := objectValue{}
+		for !p.peek("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, objectField{name: name, value: v})
+		}
+		return object, p.advance()
+	}
+
+	var v value
+	switch tok.kind {
+	case tokenInt:
+		v = intValue(tok.value)
+	case tokenFloat:
+		v = floatValue(tok.value)
+	case tokenString:
+		v = tok.value
+	case tokenName:
+		switch tok.value {
+		case "true", "false":
+			v, _ = strconv.ParseBool(tok.value)
+		case "null":
+			v = nullValue{}
+		default:
+			v = enumValue(tok.value)
+		}
+	default:
+		return nil, syntaxError(tok.loc, "expected a value, found %s", tok)
+	}
+	return v, p.advance()
+}
+
+// valueString renders a value as it would be written in a document.
+func valueString(v value) string {
+	switch v := v.(type) {
+	case variableRef:
+		return "$" + string(v)
+	case intValue:
+		return string(v)
+	case floatValue:
+		return string(v)
+	case enumValue:
+		return string(v)
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case nullValue:
+		return "null"
+	case listValue:
+		s := "["
+		for i, elem := range v {
+			if i > 0 {
+				s += ", "
+			}
+			s += valueString(elem)
+		}
+		return s + "]"
+	case objectValue:
+		s := "{"
+		for i, f := range v {
+			if i > 0 {
+				s += ", "
+			}
+			s += f.name + ": " + valueString(f.value)
+		}
+		return s + "}"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,140 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexer_Tokens(t *testing.T) {
+	l := newLexer("\uFEFF{ a(x: -1.5e3, y: 0, s: \"q\\\"\\u00e9\\n\") # comment\n ...F }")
+
+	var got []string
+	for {
+		tok, err := l.next()
+		require.NoError(t, err)
+		if tok.kind == tokenEOF {
+			break
+		}
+		got = append(got, tok.value)
+	}
+
+	assert.Equal(t, []string{"{", "a", "(", "x", ":", "-1.5e3", "y", ":", "0", "s", ":", "q\"é\n", ")", "...", "F", "}"}, got)
+}
+
+func TestLexer_Locations(t *testing.T) {
+	l := newLexer("{\r\n  \"é\" x\n}")
+
+	var got []Location
+	for {
+		tok, err := l.next()
+		require.NoError(t, err)
+		got = append(got, tok.loc)
+		if tok.kind == tokenEOF {
+			break
+		}
+	}
+
+	assert.Equal(t, []Location{{1, 1}, {2, 3}, {2, 7}, {3, 1}, {3, 2}}, got)
+}
+
+func TestLexer_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`007`, `invalid number "007": leading zero`},
+		{`1.`, `expected a digit after the point`},
+		{`1e`, `expected an exponent`},
+		{`12abc`, `invalid number "12a"`},
+		{`-`, `expected a digit`},
+		{`"abc`, `unterminated string`},
+		{"\"a\nb\"", `unterminated string`},
+		{`"\x"`, `invalid escape \x`},
+		{`"\u12g4"`, `invalid unicode escape \u12g4`},
+		{`"""abc`, `unterminated block string`},
+		{`..`, `unexpected "."`},
+		{`%`, `unexpected character '%'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := newLexer(tt.src).next()
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestBlockStringValue(t *testing.T) {
+	tok, err := newLexer("\"\"\"\n    first\n      indented\n\n    last \\\"\"\"\n  \"\"\"").next()
+
+	require.NoError(t, err)
+	assert.Equal(t, "first\n  indented\n\nlast \"\"\"", tok.value)
+}
+
+func TestParse_Document(t *testing.T) {
+	doc, err := parse(`
+		query Q($id: ID! = "a", $list: [Int!]) @d {
+			alias: field(a: $id, b: [1, 2.5, true, null, E, {k: "v"}]) @skip(if: false) {
+				...F
+				... on T { x }
+				... @include(if: true) { y }
+			}
+		}
+		fragment F on T { z }
+	`)
+	require.NoError(t, err)
+
+	require.Len(t, doc.operations, 1)
+	op := doc.operations[0]
+	assert.Equal(t, "query", op.kind)
+	assert.Equal(t, "Q", op.name)
+	require.Len(t, op.variables, 2)
+	assert.Equal(t, "ID!", op.variables[0].typ.String())
+	assert.Equal(t, "a", op.variables[0].value)
+	assert.Equal(t, "[Int!]", op.variables[1].typ.String())
+	require.Len(t, op.directives, 1)
+
+	f := op.selections[0].(*field)
+	assert.Equal(t, "alias", f.responseKey())
+	assert.Equal(t, "field", f.name)
+	assert.Equal(t, `[1, 2.5, true, null, E, {k: "v"}]`, valueString(f.args[1].value))
+	assert.Equal(t, variableRef("id"), f.args[0].value)
+	require.Len(t, f.selections, 3)
+	assert.Equal(t, "F", f.selections[0].(*fragmentSpread).name)
+	assert.Equal(t, "T", f.selections[1].(*inlineFragment).typeCondition)
+	assert.Empty(t, f.selections[2].(*inlineFragment).typeCondition)
+
+	require.Contains(t, doc.fragments, "F")
+	assert.Equal(t, "T", doc.fragments["F"].typeCondition)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{``, "the document has no operation"},
+		{`fragment F on T { x }`, "the document has no operation"},
+		{`{ }`, "a selection set cannot be empty"},
+		{`{ a() }`, "an argument list cannot be empty"},
+		{`{ a(x: 1, x: 2) }`, `there can be only one argument named "x"`},
+		{`{ a } fragment F on T { x } fragment F on T { y }`, `there can be only one fragment named "F"`},
+		{`fragment on on T { x } { a }`, `a fragment cannot be named "on"`},
+		{`query($a: Int = $b) { a }`, "a default value cannot refer to a variable"},
+		{`query($a: Int @d) { a }`, "directives on variables are not supported"},
+		{`type Query { a: Int }`, `unexpected "type"`},
+		{`{ a(x: [1, 2) }`, `expected a value, found ")"`},
+		{`{ a`, "unexpected end of document"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := parse(tt.src)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Type is a type reference: a named type (*Scalar, *Enum, *Object or
+// *Input), or a *NonNull or *List wrapping one.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type.
+type Scalar struct {
+	Name        string
+	Description string
+	// ParseValue coerces an input value: a decoded JSON variable (string,
+	// json.Number, float64, bool, []any or map[string]any) or a literal in
+	// the same form. Nil makes the scalar output-only.
+	ParseValue func(v any) (any, error)
+	// Serialize converts a resolved value for the response; nil passes it
+	// through to the JSON encoder.
+	Serialize func(v any) (any, error)
+}
+
+// Enum is a leaf type with a fixed set of string values.
+type Enum struct {
+	Name        string
+	Description string
+	Values      []string
+}
+
+// Object is an output type with fields.
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+// Input is an input object type, used for arguments.
+type Input struct {
+	Name        string
+	Description string
+	Fields      []*Arg
+}
+
+// NonNull marks a type as never null.
+type NonNull struct{ Of Type }
+
+// List is a list of its element type.
+type List struct{ Of Type }
+
+func (t *Scalar) String() string  { return t.Name }
+func (t *Enum) String() string    { return t.Name }
+func (t *Object) String() string  { return t.Name }
+func (t *Input) String() string   { return t.Name }
+func (t *NonNull) String() string { return t.Of.String() + "!" }
+func (t *List) String() string    { return "[" + t.Of.String() + "]" }
+
+// Field is a field of an Object.
+type Field struct {
+	Name        string
+	Description string
+	Args        []*Arg
+	Type        Type
+	// Resolve returns the field's value. Nil reads the source, which must
+	// be a map[string]any, by the field's name.
+	Resolve func(p ResolveParams) (any, error)
+	// Size is the number of items the field returns for its arguments,
+	// typically its page size; its selection set counts that many times
+	// towards the complexity limit. Nil counts once.
+	Size func(args map[string]any) int
+}
+
+// Arg is an argument of a Field or a field of an Input.
+type Arg struct {
+	Name        string
+	Description string
+	Type        Type
+	// Default is the coerced value used when the argument is not given,
+	// e.g. 20 for an Int; nil for none.
+	Default any
+}
+
+// Built-in scalars.
+var (
+	Int = &Scalar{
+		Name:        "Int",
+		Description: "A signed 32-bit integer.",
+		ParseValue: func(v any) (any, error) {
+			n, err := integer(v)
+			if err != nil || n < math.MinInt32 || n > math.MaxInt32 {
+				return nil, fmt.Errorf("expected a 32-bit integer, found %s", describe(v))
+			}
+			return int(n), nil
+		},
+	}
+	Float = &Scalar{
+		Name:        "Float",
+		Description: "A double-precision floating point number.",
+		ParseValue: func(v any) (any, error) {
+			switch v := v.(type) {
+			case json.Number:
+				return v.Float64()
+			case float64:
+				return v, nil
+			case int:
+				return float64(v), nil
+			}
+			return nil, fmt.Errorf("expected a number, found %s", describe(v))
+		},
+	}
+	String = &Scalar{
+		Name:        "String",
+		Description: "A UTF-8 string.",
+		ParseValue: func(v any) (any, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("expected a string, found %s", describe(v))
+		},
+	}
+	Boolean = &Scalar{
+		Name:        "Boolean",
+		Description: "true or false.",
+		ParseValue: func(v any) (any, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("expected a boolean, found %s", describe(v))
+		},
+	}
+	ID = &Scalar{
+		Name:        "ID",
+		Description: "A unique identifier, serialized as a string.",
+		ParseValue: func(v any) (any, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case json.Number:
+				if _, err := v.Int64(); err == nil {
+					return v.String(), nil
+				}
+			}
+			return nil, fmt.Errorf("expected a string or integer ID, found %s", describe(v))
+		},
+		Serialize: func(v any) (any, error) {
+			return fmt.Sprint(v), nil
+		},
+	}
+)
+
+// builtins are the scalars every schema has; they are not printed.
+var builtins = []*Scalar{Int, Float, String, Boolean, ID}
+
+// integer converts a numeric input value to an int64, refusing fractions.
+func integer(v any) (int64, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return strconv.ParseInt(v.String(), 10, 64)
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > 1<<53 {
+			return 0, fmt.Errorf("not an integer: %v", v)
+		}
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("not an integer: %v", v)
+}
+
+// describe renders an input value for error messages.
+func describe(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case enumValue:
+		return "enum value " + string(v)
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprint(v)
+}
+
+// Limits bound the queries a schema executes. All are checked before
+// execution; zero disables a limit.
+type Limits struct {
+	// MaxDepth is the deepest nesting of fields, counting the root fields
+	// as 1.
+	MaxDepth int
+	// MaxComplexity bounds the estimated number of resolved fields: each
+	// field counts 1 plus its selection set times its Size.
+	MaxComplexity int
+	// MaxFragmentSpreads bounds the fragment spreads validation checks. A
+	// fragment's own spreads count once per type it is spread within.
+	MaxFragmentSpreads int
+}
+
+// Schema is an executable schema. Only queries are supported.
+type Schema struct {
+	query  *Object
+	limits Limits
+	types  []Type // named types in discovery order, built-ins excluded
+	byName map[string]Type
+}
+
+// NewSchema builds a schema rooted at query, checking that named types are
+// unique.
+func NewSchema(query *Object, limits Limits) (*Schema, error) {
+	s := &Schema{query: query, limits: limits, byName: map[string]Type{}}
+	for _, b := range builtins {
+		s.byName[b.Name] = b
+	}
+	if err := s.add(query); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// add registers a type and the types it refers to.
+func (s *Schema) add(t Type) error {
+	switch u := t.(type) {
+	case *NonNull:
+		return s.add(u.Of)
+	case *List:
+		return s.add(u.Of)
+	}
+	name := t.String()
+	if existing, ok := s.byName[name]; ok {
+		if existing != t {
+			return fmt.Errorf("graphql: two types named %s", name)
+		}
+		return nil
+	}
+	if strings.HasPrefix(name, "__") {
+		return fmt.Errorf("graphql: type name %s is reserved", name)
+	}
+	s.byName[name] = t
+	s.types = append(s.types, t)
+
+	switch u := t.(type) {
+	case *Object:
+		for _, f := range u.Fields {
+			if err := s.add(f.Type); err != nil {
+				return err
+			}
+			for _, a := range f.Args {
+				if err := s.addInput(a); err != nil {
+					return fmt.Errorf("graphql: %s.%s(%s): %w", u.Name, f.Name, a.Name, err)
+				}
+			}
+		}
+	case *Input:
+		for _, a := range u.Fields {
+			if err := s.addInput(a); err != nil {
+				return fmt.Errorf("graphql: %s.%s: %w", u.Name, a.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// addInput registers an argument's type, which must be an input type.
+func (s *Schema) addInput(a *Arg) error {
+	if !isInputType(a.Type) {
+		return fmt.Errorf("%s is not an input type", a.Type)
+	}
+	return s.add(a.Type)
+}
+
+// isInputType reports whether t may be used for arguments.
+func isInputType(t Type) bool {
+	switch u := t.(type) {
+	case *NonNull:
+		return isInputType(u.Of)
+	case *List:
+		return isInputType(u.Of)
+	case *Scalar:
+		return u.ParseValue != nil
+	case *Enum, *Input:
+		return true
+	}
+	return false
+}
+
+// named returns the named type inside wrappers.
+func named(t Type) Type {
+	for {
+		switch u := t.(type) {
+		case *NonNull:
+			t = u.Of
+		case *List:
+			t = u.Of
+		default:
+			return t
+		}
+	}
+}
+
+// isNonNull reports whether t is a *NonNull.
+func isNonNull(t Type) bool {
+	_, ok := t.(*NonNull)
+	return ok
+}
+
+// field returns the object's field named name, or nil.
+func (o *Object) field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// SDL renders the schema in the GraphQL schema definition language, for
+// publishing. Built-in scalars are left out.
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	for i, t := range s.types {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch u := t.(type) {
+		case *Scalar:
+			writeDescription(&b, "", u.Description)
+			fmt.Fprintf(&b, "scalar %s\n", u.Name)
+		case *Enum:
+			writeDescription(&b, "", u.Description)
+			fmt.Fprintf(&b, "enum %s {\n", u.Name)
+			for _, v := range u.Values {
+				fmt.Fprintf(&b, "  %s\n", v)
+			}
+			b.WriteString("}\n")
+		case *Object:
+			writeDescription(&b, "", u.Description)
+			fmt.Fprintf(&b, "type %s {\n", u.Name)
+			for j, f := range u.Fields {
+				if j > 0 && f.Description != "" {
+					b.WriteString("\n")
+				}
+				writeDescription(&b, "  ", f.Description)
+				fmt.Fprintf(&b, "  %s%s: %s\n", f.Name, argsSDL(f.Args), f.Type)
+			}
+			b.WriteString("}\n")
+		case *Input:
+			writeDescription(&b, "", u.Description)
+			fmt.Fprintf(&b, "input %s {\n", u.Name)
+			for j, a := range u.Fields {
+				if j > 0 && a.Description != "" {
+					b.WriteString("\n")
+				}
+				writeDescription(&b, "  ", a.Description)
+				fmt.Fprintf(&b, "  %s\n", argSDL(a))
+			}
+			b.WriteString("}\n")
+		}
+	}
+	return b.String()
+}
+
+// writeDescription writes a description as a string or block string.
+func writeDescription(b *strings.Builder, indent, description string) {
+	switch {
+	case description == "":
+	case !strings.Contains(description, "\n"):
+		fmt.Fprintf(b, "%s%s\n", indent, strconv.Quote(description))
+	default:
+		fmt.Fprintf(b, "%s\"\"\"\n", indent)
+		for _, line := range strings.Split(description, "\n") {
+			fmt.Fprintf(b, "%s%s\n", indent, strings.ReplaceAll(line, `"""`, `\"""`))
+		}
+		fmt.Fprintf(b, "%s\"\"\"\n", indent)
+	}
+}
+
+// argsSDL renders an argument list; descriptions are left to the field's.
+func argsSDL(args []*Arg) string {
+	if len(args) == 0 {
+		return ""
+	}
+	rendered := make([]string, len(args))
+	for i, a := range args {
+		rendered[i] = argSDL(a)
+	}
+	return "(" + strings.Join(rendered, ", ") + ")"
+}
+
+func argSDL(a *Arg) string {
+	s := a.Name + ": " + a.Type.String()
+	if a.Default != nil {
+		s += " = " + literal(a.Default, a.Type)
+	}
+	return s
+}
+
+// literal renders a default value as a document literal of type t.
+func literal(v any, t Type) string {
+	if _, ok := named(t).(*Enum); ok {
+		return fmt.Sprint(v)
+	}
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []string:
+		quoted := slices.Clone(v)
+		for i := range quoted {
+			quoted[i] = literal(quoted[i], named(t))
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
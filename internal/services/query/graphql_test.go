@@ -0,0 +1,429 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	graphqlschema "github.com/cornjacket/platform-services/api/graphql"
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
+)
+
+// graphQLMux serves the service's routes with the GraphQL gateway enabled.
+func graphQLMux(service *Service, policy *auth.Policy) *http.ServeMux {
+	handler := NewHandler(service, slog.Default())
+	handler.SetPolicy(policy)
+	handler.EnableGraphQL()
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	return mux
+}
+
+// postGraphQL posts a query with the given API key (none if empty) and
+// returns the response status and body.
+func postGraphQL(t *testing.T, mux http.Handler, apiKey, query string, variables map[string]any) (int, string) {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set(auth.APIKeyHeader, apiKey)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w.Code, w.Body.String()
+}
+
+func TestGraphQLSchema_MatchesPublished(t *testing.T) {
+	handler := NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default())
+	handler.EnableGraphQL()
+
+	assert.Equal(t, string(graphqlschema.Query), handler.graphql.SDL(),
+		"api/graphql/query.graphql must match the schema defined in graphql.go")
+}
+
+func TestHandleGraphQLSchema(t *testing.T) {
+	mux := graphQLMux(NewService(&mockProjectionReader{}, slog.Default()), nil)
+	w := httptest.NewRecorder()
+
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schema.graphql", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, string(graphqlschema.Query), w.Body.String())
+}
+
+func TestHandleGraphQL_Disabled(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	status, _ := postGraphQL(t, mux, "", `{ events { nextSeq } }`, nil)
+
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestHandleGraphQL_Projection(t *testing.T) {
+	var gotType, gotID string
+	mock := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			gotType, gotID = projType, aggregateID
+			p := newTestProjection()
+			p.ProjectionType = projType
+			p.State = json.RawMessage(`{"value": 100, "unit": "celsius"}`)
+			return p, nil
+		},
+	}
+	mux := graphQLMux(NewService(mock, slog.Default()), nil)
+
+	status, body := postGraphQL(t, mux, "",
+		`query($id: ID!) { projection(type: sensor_state, aggregateId: $id, namespace: test, units: imperial) { projectionType aggregateId state deletedAt } }`,
+		map[string]any{"id": "device-001"})
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, projections.TypeFor("sensor_state", true), gotType)
+	assert.Equal(t, "device-001", gotID)
+	assert.JSONEq(t, `{"data": {"projection": {
+		"projectionType": "test.sensor_state",
+		"aggregateId": "device-001",
+		"state": {"value": 212, "unit": "fahrenheit"},
+		"deletedAt": null
+	}}}`, body)
+}
+
+func TestHandleGraphQL_ProjectionMissingOrDeleted(t *testing.T) {
+	deleted := newTestProjection()
+	deleted.DeletedAt = &deleted.UpdatedAt
+	mock := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return nil, fmt.Errorf("no rows in result set")
+		},
+		GetDeletedProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			if aggregateID == "device-gone" {
+				return deleted, nil
+			}
+			return nil, fmt.Errorf("no rows in result set")
+		},
+	}
+	mux := graphQLMux(NewService(mock, slog.Default()), nil)
+
+	status, body := postGraphQL(t, mux, "", `{
+		missing: projection(type: sensor_state, aggregateId: "device-404") { aggregateId }
+		gone: projection(type: sensor_state, aggregateId: "device-gone") { deletedAt }
+	}`, nil)
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"data": {"missing": null, "gone": {"deletedAt": "2026-02-09T12:00:00.000Z"}}}`, body)
+}
+
+func TestHandleGraphQL_ProjectionsFilters(t *testing.T) {
+	var called string
+	var gotFlags []string
+	var gotLimit, gotOffset int
+	list := func(name string) func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+		return func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			called, gotLimit, gotOffset = name, limit, offset
+			return []projections.Projection{*newTestProjection()}, 41, nil
+		}
+	}
+	mock := &mockProjectionReader{
+		ListProjectionsFn: list("list"),
+		ListDeletedFn:     list("deleted"),
+		ListAnomalousFn: func(ctx context.Context, projType string, flags []string, limit, offset int) ([]projections.Projection, int, error) {
+			gotFlags = flags
+			return list("anomalous")(ctx, projType, limit, offset)
+		},
+		SearchProjectionsFn: func(ctx context.Context, projType, q string, limit, offset int) ([]projections.Projection, int, error) {
+			return list("search:"+q)(ctx, projType, limit, offset)
+		},
+	}
+	mux := graphQLMux(NewService(mock, slog.Default()), nil)
+
+	tests := []struct {
+		filter    string
+		want      string
+		wantFlags []string
+	}{
+		{``, "list", nil},
+		{`filter: {deleted: false}`, "list", nil},
+		{`filter: {deleted: true}`, "deleted", nil},
+		{`filter: {q: "dev"}`, "search:dev", nil},
+		{`filter: {anomaly: []}`, "anomalous", projections.AnomalyFlags},
+		{`filter: {anomaly: value_jump}`, "anomalous", []string{"value_jump"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			called, gotFlags = "", nil
+
+			status, body := postGraphQL(t, mux, "",
+				`{ projections(type: sensor_state, first: 500, offset: 40, `+tt.filter+`) { items { aggregateId } total limit offset } }`, nil)
+
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, tt.want, called)
+			assert.Equal(t, tt.wantFlags, gotFlags)
+			assert.Equal(t, 100, gotLimit, "pages are capped as in REST")
+			assert.Equal(t, 40, gotOffset)
+			assert.JSONEq(t, `{"data": {"projections": {
+				"items": [{"aggregateId": "device-001"}], "total": 41, "limit": 100, "offset": 40
+			}}}`, body)
+		})
+	}
+
+	status, body := postGraphQL(t, mux, "", `{ projections(type: sensor_state, filter: {q: "dev", deleted: true}) { total } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `"data":null`)
+	assert.Contains(t, body, `"message":"anomaly, deleted, q and registry filters cannot be combined"`)
+	assert.Contains(t, body, `"code":"BAD_REQUEST"`)
+}
+
+func TestHandleGraphQL_ProjectionsByIDs(t *testing.T) {
+	var gotIDs []string
+	mock := &mockProjectionReader{
+		GetProjectionsFn: func(ctx context.Context, projType string, aggregateIDs []string) ([]projections.Projection, error) {
+			gotIDs = aggregateIDs
+			return []projections.Projection{*newTestProjection()}, nil
+		},
+	}
+	mux := graphQLMux(NewService(mock, slog.Default()), nil)
+
+	status, body := postGraphQL(t, mux, "", `{ projectionsByIds(type: sensor_state, aggregateIds: ["device-001", "device-404", "device-001"]) { items { aggregateId } missing } }`, nil)
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"device-001", "device-404"}, gotIDs)
+	assert.JSONEq(t, `{"data": {"projectionsByIds": {"items": [{"aggregateId": "device-001"}], "missing": ["device-404"]}}}`, body)
+}
+
+func TestHandleGraphQL_Registry(t *testing.T) {
+	var gotFilter registry.Filter
+	mock := &mockProjectionReader{
+		GetProjectionsFn: func(ctx context.Context, projType string, aggregateIDs []string) ([]projections.Projection, error) {
+			p := newTestProjection()
+			if projections.RegistryRequested(ctx) {
+				p.Registry = &registry.Aggregate{AggregateID: "device-001", Site: "plant-7", Tags: []string{"hvac"}}
+			}
+			return []projections.Projection{*p}, nil
+		},
+		ListRegisteredFn: func(ctx context.Context, projType string, filter registry.Filter, limit, offset int) ([]projections.Projection, int, error) {
+			gotFilter = filter
+			return []projections.Projection{*newTestProjection()}, 1, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	mux := graphQLMux(service, nil)
+
+	// Without a registry, only queries that select it fail
+	status, body := postGraphQL(t, mux, "", `{ projectionsByIds(type: sensor_state, aggregateIds: ["device-001"]) { items { registry { site } } } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `"message":"aggregate registry is not available"`)
+	_, body = postGraphQL(t, mux, "", `{ projectionsByIds(type: sensor_state, aggregateIds: ["device-001", "device-404"]) { items { aggregateId } missing } }`, nil)
+	assert.JSONEq(t, `{"data": {"projectionsByIds": {"items": [{"aggregateId": "device-001"}], "missing": ["device-404"]}}}`, body)
+
+	service.SetRegistry(registry.NewMemoryStore())
+	_, body = postGraphQL(t, mux, "", `{ projectionsByIds(type: sensor_state, aggregateIds: ["device-001"]) { items { registry { site owner tags } } } }`, nil)
+	assert.JSONEq(t, `{"data": {"projectionsByIds": {"items": [{"registry": {"site": "plant-7", "owner": null, "tags": ["hvac"]}}]}}}`, body)
+
+	_, body = postGraphQL(t, mux, "", `{ projections(type: sensor_state, filter: {registry: {site: "plant-7", tag: "hvac"}}) { total } }`, nil)
+	assert.JSONEq(t, `{"data": {"projections": {"total": 1}}}`, body)
+	assert.Equal(t, registry.Filter{Site: "plant-7", Tag: "hvac"}, gotFilter)
+}
+
+func TestHandleGraphQL_Aggregate(t *testing.T) {
+	var gotType string
+	mock := &mockProjectionReader{
+		GetAggregateProjectionsFn: func(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error) {
+			gotType = aggregateType
+			sensor := newTestProjection()
+			session := newTestProjection()
+			session.ProjectionType = "user_session"
+			return []projections.Projection{*sensor, *session}, nil
+		},
+	}
+	var gotFrom, gotTo int64
+	log := &mockEventLog{
+		FetchAggregateFn: func(ctx context.Context, aggregateID string, fromSeq, toSeq int64, limit int) ([]*events.Envelope, error) {
+			gotFrom, gotTo = fromSeq, toSeq
+			return []*events.Envelope{
+				{AggregateID: aggregateID, EventType: "sensor.reading", AggregateSeq: 3, Payload: json.RawMessage(`{"value": 1}`)},
+				{AggregateID: aggregateID, EventType: "user.login", AggregateSeq: 4, Payload: json.RawMessage(`{}`)},
+			}, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	policy, err := auth.ParsePolicy("sensors=read:sensor_state|events:sensor.*")
+	require.NoError(t, err)
+	mux := graphQLMux(service, policy)
+
+	status, body := postGraphQL(t, mux, "sensors", `{ aggregate(id: "device-001", aggregateType: "device") {
+		id
+		projections { projectionType history(first: 5) { items { eventType } nextSeq } }
+		stream(fromSeq: 3, toSeq: 9) { items { eventType aggregateSeq payload } nextSeq }
+	} }`, nil)
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "device", gotType)
+	assert.Equal(t, int64(3), gotFrom)
+	assert.Equal(t, int64(9), gotTo)
+	assert.JSONEq(t, `{"data": {"aggregate": {
+		"id": "device-001",
+		"projections": [{"projectionType": "sensor_state", "history": {"items": [{"eventType": "sensor.reading"}], "nextSeq": 5}}],
+		"stream": {"items": [{"eventType": "sensor.reading", "aggregateSeq": 3, "payload": {"value": 1}}], "nextSeq": 5}
+	}}}`, body)
+
+	_, body = postGraphQL(t, mux, "sensors", `{ aggregate(id: "device-001") { stream(fromSeq: 9, toSeq: 3) { nextSeq } } }`, nil)
+	assert.Contains(t, body, `"message":"toSeq must not be less than fromSeq"`)
+}
+
+func TestHandleGraphQL_Events(t *testing.T) {
+	var gotAfter int64
+	var gotTypes []string
+	var gotAggregateType string
+	log := &mockEventLog{
+		FetchAfterOfAggregateTypeFn: func(ctx context.Context, afterSeq int64, aggregateType string, types []string, limit int) ([]*events.Envelope, error) {
+			gotAfter, gotAggregateType, gotTypes = afterSeq, aggregateType, types
+			return []*events.Envelope{{EventType: "sensor.reading", GlobalSeq: 1 << 40}}, nil
+		},
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			gotAfter, gotAggregateType, gotTypes = afterSeq, "", types
+			return nil, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	policy, err := auth.ParsePolicy("sensors=read:sensor_state|events:sensor.*,readonly=read:*")
+	require.NoError(t, err)
+	mux := graphQLMux(service, policy)
+
+	status, body := postGraphQL(t, mux, "sensors",
+		`query($after: Seq) { events(afterSeq: $after, types: ["sensor.reading"], aggregateType: "device") { items { eventType globalSeq } nextSeq } }`,
+		map[string]any{"after": 1 << 35})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(1<<35), gotAfter)
+	assert.Equal(t, "device", gotAggregateType)
+	assert.Equal(t, []string{"sensor.reading"}, gotTypes)
+	assert.JSONEq(t, `{"data": {"events": {"items": [{"eventType": "sensor.reading", "globalSeq": 1099511627776}], "nextSeq": 1099511627776}}}`, body)
+
+	// Without types, a restricted key reads its scoped types
+	_, body = postGraphQL(t, mux, "sensors", `{ events { nextSeq } }`, nil)
+	assert.Equal(t, []string{"sensor.*"}, gotTypes)
+	assert.JSONEq(t, `{"data": {"events": {"nextSeq": 0}}}`, body)
+
+	for _, tt := range []struct{ key, query, want string }{
+		{"sensors", `{ events(types: ["user.*"]) { nextSeq } }`, "API key may not read event types: user.*"},
+		{"readonly", `{ events { nextSeq } }`, "API key may not read events"},
+		{"sensors", `{ events(types: ["*.reading"]) { nextSeq } }`, "invalid event type pattern: *.reading"},
+	} {
+		_, body := postGraphQL(t, mux, tt.key, tt.query, nil)
+		assert.Contains(t, body, `"message":"`+tt.want+`"`, tt.query)
+	}
+}
+
+func TestHandleGraphQL_Scopes(t *testing.T) {
+	mock := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return newTestProjection(), nil
+		},
+	}
+	policy, err := auth.ParsePolicy("sensors=read:sensor_state")
+	require.NoError(t, err)
+	mux := graphQLMux(NewService(mock, slog.Default()), policy)
+	query := `{
+		sensor: projection(type: sensor_state, aggregateId: "device-001") { aggregateId }
+		session: projection(type: user_session, aggregateId: "session-001") { aggregateId }
+	}`
+
+	status, body := postGraphQL(t, mux, "", query, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.JSONEq(t, `{"error": "missing or unknown API key"}`, body)
+
+	status, _ = postGraphQL(t, mux, "unknown", query, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, body = postGraphQL(t, mux, "sensors", query, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{
+		"data": {"sensor": {"aggregateId": "device-001"}, "session": null},
+		"errors": [{
+			"message": "API key may not read projection type: user_session",
+			"locations": [{"line": 3, "column": 3}],
+			"path": ["session"],
+			"extensions": {"code": "FORBIDDEN"}
+		}]
+	}`, body)
+}
+
+func TestHandleGraphQL_StoreErrors(t *testing.T) {
+	mock := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			if aggregateID == "slow" {
+				return nil, fmt.Errorf("reading projection: %w", context.DeadlineExceeded)
+			}
+			return nil, fmt.Errorf("connection refused")
+		},
+	}
+	mux := graphQLMux(NewService(mock, slog.Default()), nil)
+
+	status, body := postGraphQL(t, mux, "", `{
+		slow: projection(type: sensor_state, aggregateId: "slow") { aggregateId }
+		down: projection(type: sensor_state, aggregateId: "down") { aggregateId }
+	}`, nil)
+
+	assert.Equal(t, http.StatusOK, status)
+	_, timeoutMessage := timeout.Status(context.DeadlineExceeded)
+	assert.Contains(t, body, `"message":"`+timeoutMessage+`"`)
+	assert.Contains(t, body, `"code":"TIMEOUT"`)
+	assert.Contains(t, body, `"message":"internal server error"`)
+	assert.Contains(t, body, `"code":"INTERNAL"`)
+	assert.NotContains(t, body, "connection refused")
+}
+
+func TestHandleGraphQL_BadRequests(t *testing.T) {
+	mux := graphQLMux(NewService(&mockProjectionReader{}, slog.Default()), nil)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"invalid JSON", `{"query": `, `"error"`},
+		{"no query", `{"variables": {}}`, `"error"`},
+		{"syntax error", `{"query": "{ projection("}`, `"code":"BAD_REQUEST"`},
+		{"unknown type", `{"query": "{ projection(type: device_state, aggregateId: \"x\") { state } }"}`, `"device_state\" is not a ProjectionType value`},
+		{"too complex", `{"query": "{ projections(type: sensor_state, first: 100) { items { history(first: 100) { items { eventId } } } } }"}`, `exceeds the limit of 10000`},
+		{"too many IDs", `{"query": "{ projectionsByIds(type: sensor_state, aggregateIds: []) { missing } }"}`, `aggregateIds must contain 1 to 500 IDs, got 0`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			mux.ServeHTTP(w, req)
+
+			if tt.name == "too many IDs" {
+				// Argument values the schema accepts fail in the resolver
+				assert.Equal(t, http.StatusOK, w.Code)
+			} else {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			}
+			assert.Contains(t, w.Body.String(), tt.want)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/graphql", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
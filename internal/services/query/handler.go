@@ -1,17 +1,32 @@
 package query
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/services/query/graphql"
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/pii"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
 )
 
 // Handler handles HTTP requests for the query service.
 type Handler struct {
-	service *Service
-	logger  *slog.Logger
+	service   *Service
+	policy    *auth.Policy    // nil allows every read
+	decryptor *pii.Decryptor  // nil exports stored values
+	graphql   *graphql.Schema // nil disables the GraphQL gateway
+	logger    *slog.Logger
 }
 
 // NewHandler creates a new query HTTP handler.
@@ -22,7 +37,28 @@ func NewHandler(service *Service, logger *slog.Logger) *Handler {
 	}
 }
 
+// SetPolicy restricts the projection and event types each API key may read.
+// Requests without a known API key get 401, requests outside the key's
+// scopes get 403.
+func (h *Handler) SetPolicy(policy *auth.Policy) {
+	h.policy = policy
+}
+
+// SetDecryptor decrypts PII-protected values in exports for authorized API
+// keys. Exports are streamed, so they bypass the decryptor's Wrap, which
+// buffers whole responses; the other endpoints are wrapped as before.
+func (h *Handler) SetDecryptor(decryptor *pii.Decryptor) {
+	h.decryptor = decryptor
+}
+
 // HandleGetProjection handles GET /api/v1/projections/{projection_type}/{aggregate_id}
+// With ?units=metric or ?units=imperial, known unit fields are converted (see unitFields).
+// With ?fields=state.temperature,state.unit only those paths are returned,
+// after unit conversion (see ParseFields and selectFields).
+// With ?namespace=test, the projection built from test traffic is returned instead.
+// With ?include=registry, the aggregate's registry entry is returned as "registry".
+// A deleted projection answers 410 Gone with its deleted_at and last_event_id;
+// list it with ?deleted=true on HandleListProjections.
 func (h *Handler) HandleGetProjection(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -45,21 +81,54 @@ func (h *Handler) HandleGetProjection(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusBadRequest, "invalid projection type: "+projectionType)
 		return
 	}
+	if !h.canRead(w, r, projectionType) {
+		return
+	}
+
+	view, ok := h.parseView(w, r)
+	if !ok {
+		return
+	}
+	projectionType, ok = h.namespaced(w, r, projectionType)
+	if !ok {
+		return
+	}
 
-	projection, err := h.service.GetProjection(r.Context(), projectionType, aggregateID)
+	projection, err := h.service.GetProjection(view.ctx, projectionType, aggregateID)
 	if err != nil {
+		var deleted *DeletedError
+		if errors.As(err, &deleted) {
+			h.writeJSON(w, http.StatusGone, map[string]any{
+				"error":         "projection deleted",
+				"deleted_at":    deleted.Projection.DeletedAt,
+				"last_event_id": deleted.Projection.LastEventID,
+			})
+			return
+		}
 		if strings.Contains(err.Error(), "no rows") {
 			h.writeError(w, http.StatusNotFound, "projection not found")
 			return
 		}
-		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		h.writeServiceError(w, err)
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, projection)
+	h.writeProjection(w, projection, view)
 }
 
 // HandleListProjections handles GET /api/v1/projections/{projection_type}
+// With ?anomaly=any (or a comma-separated list of flags such as
+// value_jump,reporting_gap), only projections with those anomaly flags set are returned.
+// With ?q=dev-042, only projections whose aggregate ID contains the term
+// (case-insensitive) are returned.
+// With ?deleted=true, soft-deleted projections (tombstoned or expired) are
+// returned instead of live ones.
+// With ?registry.site=plant-7 (also registry.model, registry.owner,
+// registry.aggregate_type and registry.tag), only projections of aggregates
+// whose registry entry matches are returned; the registry is joined in SQL,
+// so total and pages are exact.
+// anomaly, q, deleted and registry filters are mutually exclusive.
+// ?units=, ?fields=, ?include= and ?namespace= behave as for HandleGetProjection.
 func (h *Handler) HandleListProjections(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -79,6 +148,18 @@ func (h *Handler) HandleListProjections(w http.ResponseWriter, r *http.Request)
 		h.writeError(w, http.StatusBadRequest, "invalid projection type: "+projectionType)
 		return
 	}
+	if !h.canRead(w, r, projectionType) {
+		return
+	}
+
+	view, ok := h.parseView(w, r)
+	if !ok {
+		return
+	}
+	projectionType, ok = h.namespaced(w, r, projectionType)
+	if !ok {
+		return
+	}
 
 	// Parse query parameters
 	limit := 20
@@ -96,15 +177,558 @@ func (h *Handler) HandleListProjections(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	list, err := h.service.ListProjections(r.Context(), projectionType, limit, offset)
+	deleted := false
+	if deletedStr := r.URL.Query().Get("deleted"); deletedStr != "" {
+		var err error
+		if deleted, err = strconv.ParseBool(deletedStr); err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid deleted: "+deletedStr+" (expected true or false)")
+			return
+		}
+	}
+
+	q := r.URL.Query().Get("q")
+	if len(q) > MaxSearchLength {
+		h.writeError(w, http.StatusBadRequest, "q must be at most "+strconv.Itoa(MaxSearchLength)+" characters")
+		return
+	}
+
+	registryFilter, byRegistry, ok := h.parseRegistryFilter(w, r)
+	if !ok {
+		return
+	}
+
+	filters := 0
+	for _, set := range []bool{deleted, r.URL.Query().Get("anomaly") != "", q != "", byRegistry} {
+		if set {
+			filters++
+		}
+	}
+	if filters > 1 {
+		h.writeError(w, http.StatusBadRequest, "anomaly, deleted, q and registry filters cannot be combined")
+		return
+	}
+
+	if byRegistry {
+		list, err := h.service.ListRegistered(view.ctx, projectionType, registryFilter, limit, offset)
+		if errors.Is(err, ErrRegistryDisabled) {
+			h.writeError(w, http.StatusBadRequest, "registry filters: "+err.Error())
+			return
+		}
+		if err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+		h.writeProjections(w, list, list.Projections, view)
+		return
+	}
+
+	if q != "" {
+		list, err := h.service.SearchProjections(view.ctx, projectionType, q, limit, offset)
+		if err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+		h.writeProjections(w, list, list.Projections, view)
+		return
+	}
+
+	if deleted {
+		list, err := h.service.ListDeleted(view.ctx, projectionType, limit, offset)
+		if err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+		h.writeProjections(w, list, list.Projections, view)
+		return
+	}
+
+	if anomaly := r.URL.Query().Get("anomaly"); anomaly != "" {
+		var flags []string // empty means any flag
+		if anomaly != "any" {
+			flags = strings.Split(anomaly, ",")
+			for _, flag := range flags {
+				if !projections.IsAnomalyFlag(flag) {
+					h.writeError(w, http.StatusBadRequest, "invalid anomaly flag: "+flag)
+					return
+				}
+			}
+		}
+
+		list, err := h.service.ListAnomalous(view.ctx, projectionType, flags, limit, offset)
+		if err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+		h.writeProjections(w, list, list.Projections, view)
+		return
+	}
+
+	list, err := h.service.ListProjections(view.ctx, projectionType, limit, offset)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeProjections(w, list, list.Projections, view)
+}
+
+// BatchGetRequest is the body of a batch get.
+type BatchGetRequest struct {
+	AggregateIDs []string `json:"aggregate_ids"`
+}
+
+// HandleBatchGetProjections handles POST /api/v1/projections/{projection_type}/batch-get
+// with {"aggregate_ids": [...]}, returning the projections found and the IDs
+// that have none, so a dashboard can load many devices in one request.
+// ?units=, ?fields=, ?include= and ?namespace= behave as for HandleGetProjection.
+func (h *Handler) HandleBatchGetProjections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Expected path: /api/v1/projections/{projection_type}/batch-get
+	projectionType, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/projections/"), "/")
+	if !IsValidProjectionType(projectionType) {
+		h.writeError(w, http.StatusBadRequest, "invalid projection type: "+projectionType)
+		return
+	}
+	if !h.canRead(w, r, projectionType) {
+		return
+	}
+
+	view, ok := h.parseView(w, r)
+	if !ok {
+		return
+	}
+	projectionType, ok = h.namespaced(w, r, projectionType)
+	if !ok {
+		return
+	}
+
+	var req BatchGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if n := len(req.AggregateIDs); n == 0 || n > MaxBatchGetIDs {
+		h.writeError(w, http.StatusBadRequest,
+			"aggregate_ids must contain 1 to "+strconv.Itoa(MaxBatchGetIDs)+" IDs, got "+strconv.Itoa(n))
+		return
+	}
+	if slices.Contains(req.AggregateIDs, "") {
+		h.writeError(w, http.StatusBadRequest, "aggregate_ids must not contain empty IDs")
+		return
+	}
+
+	batch, err := h.service.BatchGetProjections(view.ctx, projectionType, req.AggregateIDs)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeProjections(w, batch, batch.Projections, view)
+}
+
+// grant returns the grant of the request's API key. Without a known key it
+// writes a 401 response and returns false.
+func (h *Handler) grant(w http.ResponseWriter, r *http.Request) (*auth.Grant, bool) {
+	grant, ok := h.policy.Grant(r)
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "missing or unknown API key")
+	}
+	return grant, ok
+}
+
+// canRead reports whether the request's API key may read projections of the
+// given type, writing a 401 or 403 response if not.
+func (h *Handler) canRead(w http.ResponseWriter, r *http.Request, projectionType string) bool {
+	grant, ok := h.grant(w, r)
+	if !ok {
+		return false
+	}
+	if !grant.Allows(auth.Read, projectionType) {
+		h.writeError(w, http.StatusForbidden, "API key may not read projection type: "+projectionType)
+		return false
+	}
+	return true
+}
+
+// parseUnits reads the optional ?units= parameter. On an invalid value it
+// writes a 400 response and returns false.
+func (h *Handler) parseUnits(w http.ResponseWriter, r *http.Request) (string, bool) {
+	units := r.URL.Query().Get("units")
+	if units != "" && !IsValidUnitSystem(units) {
+		h.writeError(w, http.StatusBadRequest, "invalid units: "+units+" (expected metric or imperial)")
+		return "", false
+	}
+	return units, true
+}
+
+// namespaced applies the optional ?namespace= parameter to a projection type.
+// On an invalid value it writes a 400 response and returns false.
+func (h *Handler) namespaced(w http.ResponseWriter, r *http.Request, projectionType string) (string, bool) {
+	test, ok := h.parseTestNamespace(w, r)
+	if !ok {
+		return "", false
+	}
+	return projections.TypeFor(projectionType, test), true
+}
+
+// parseTestNamespace reads the optional ?namespace= parameter and reports
+// whether the test namespace was requested. The only namespace is "test". On
+// an invalid value it writes a 400 response and returns false.
+func (h *Handler) parseTestNamespace(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	switch ns := r.URL.Query().Get("namespace"); ns {
+	case "":
+		return false, true
+	case "test":
+		return true, true
+	default:
+		h.writeError(w, http.StatusBadRequest, "invalid namespace: "+ns+" (expected test)")
+		return false, false
+	}
+}
+
+// parseAggregateType reads the optional ?aggregate_type= filter. On an
+// invalid value it writes a 400 response and returns false.
+func (h *Handler) parseAggregateType(w http.ResponseWriter, r *http.Request) (string, bool) {
+	aggregateType := r.URL.Query().Get("aggregate_type")
+	if err := events.ValidateAggregateType(aggregateType); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	return aggregateType, true
+}
+
+// parseRegistryFilter reads the optional ?registry.<field>= filters and
+// reports whether any was set. On an invalid value it writes a 400 response
+// and returns false.
+func (h *Handler) parseRegistryFilter(w http.ResponseWriter, r *http.Request) (registry.Filter, bool, bool) {
+	q := r.URL.Query()
+	filter := registry.Filter{
+		AggregateType: q.Get("registry.aggregate_type"),
+		Site:          q.Get("registry.site"),
+		Model:         q.Get("registry.model"),
+		Owner:         q.Get("registry.owner"),
+		Tag:           q.Get("registry.tag"),
+	}
+	if err := events.ValidateAggregateType(filter.AggregateType); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return registry.Filter{}, false, false
+	}
+	return filter, filter != registry.Filter{}, true
+}
+
+// projectionView is how projections are rendered: in a unit system (empty
+// for as stored), with only the selected fields (empty for all) and with
+// the aggregates' registry entries when asked for.
+type projectionView struct {
+	units  string
+	fields []string
+	ctx    context.Context // the request's; with ?include=registry, reads also return registry entries
+}
+
+// parseView reads the optional ?units=, ?fields= and ?include= parameters.
+// On an invalid value it writes a 400 response and returns false.
+func (h *Handler) parseView(w http.ResponseWriter, r *http.Request) (projectionView, bool) {
+	units, ok := h.parseUnits(w, r)
+	if !ok {
+		return projectionView{}, false
+	}
+	view := projectionView{units: units, ctx: r.Context()}
+	if s := r.URL.Query().Get("fields"); s != "" {
+		fields, err := ParseFields(s)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return projectionView{}, false
+		}
+		view.fields = fields
+	}
+	if s := r.URL.Query().Get("include"); s != "" {
+		for _, include := range strings.Split(s, ",") {
+			if include != "registry" {
+				h.writeError(w, http.StatusBadRequest, "invalid include: "+include+" (expected registry)")
+				return projectionView{}, false
+			}
+		}
+		ctx, err := h.service.WithRegistry(view.ctx)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "include=registry: "+err.Error())
+			return projectionView{}, false
+		}
+		view.ctx = ctx
+	}
+	return view, true
+}
+
+// writeProjection writes a single projection rendered by view.
+func (h *Handler) writeProjection(w http.ResponseWriter, p *Projection, view projectionView) {
+	if view.units != "" {
+		p.State = convertUnits(p.ProjectionType, p.State, view.units)
+	}
+	if len(view.fields) == 0 {
+		h.writeJSON(w, http.StatusOK, p)
+		return
+	}
+
+	selected, err := selectFields(p, view.fields)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, selected)
+}
+
+// writeProjections writes a response v whose "projections" field holds ps,
+// rendering each projection by view. Units are converted in place; field selection replaces the "projections" field of the
+// encoded response.
+func (h *Handler) writeProjections(w http.ResponseWriter, v any, ps []Projection, view projectionView) {
+	if view.units != "" {
+		for i := range ps {
+			p := &ps[i]
+			p.State = convertUnits(p.ProjectionType, p.State, view.units)
+		}
+	}
+	if len(view.fields) == 0 {
+		h.writeJSON(w, http.StatusOK, v)
+		return
+	}
+
+	selected, err := selectAllFields(ps, view.fields)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	raw, err := json.Marshal(v)
 	if err != nil {
 		h.writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	doc["projections"] = selected
+	h.writeJSON(w, http.StatusOK, doc)
+}
+
+// HandleListEvents handles GET /api/v1/events?after_seq={seq}&limit={n}
+// Returns stored events with global_seq greater than after_seq, oldest first.
+// Consumers catch up by passing back next_seq until a page comes back empty.
+// With ?types=sensor.*,user.login only matching event types are returned, and
+// with ?aggregate_type=device only events of that aggregate type.
+// With ?wait=30s an empty result is held open until an event arrives (long poll),
+// so a caught-up consumer can loop on next_seq without busy polling.
+// Keys restricted to some event types get 403 for ?types= patterns outside
+// their scopes; without ?types= they get only the types in their scopes.
+func (h *Handler) HandleListEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	grant, ok := h.grant(w, r)
+	if !ok {
+		return
+	}
+
+	var afterSeq int64
+	if s := r.URL.Query().Get("after_seq"); s != "" {
+		seq, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || seq < 0 {
+			h.writeError(w, http.StatusBadRequest, "invalid after_seq: "+s)
+			return
+		}
+		afterSeq = seq
+	}
+
+	types, ok := h.parseEventTypes(w, r, grant)
+	if !ok {
+		return
+	}
+
+	aggregateType, ok := h.parseAggregateType(w, r)
+	if !ok {
+		return
+	}
+
+	var wait time.Duration
+	if s := r.URL.Query().Get("wait"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			h.writeError(w, http.StatusBadRequest, "invalid wait: "+s)
+			return
+		}
+		wait = min(d, h.service.EventWaitLimit())
+		// Outlive the server's write timeout for the held request
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second))
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			limit = l
+		}
+	}
+
+	list, err := h.service.ListEvents(r.Context(), afterSeq, types, aggregateType, limit, wait)
+	if err != nil {
+		if errors.Is(err, ErrEventLogDisabled) {
+			h.writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		h.writeServiceError(w, err)
+		return
+	}
 
 	h.writeJSON(w, http.StatusOK, list)
 }
 
+// parseEventTypes reads the optional ?types= event type patterns, holding a
+// restricted API key to its events scopes: patterns outside them are refused,
+// and without ?types= the key gets only its scoped types. On an invalid or
+// refused value it writes a 400 or 403 response and returns false.
+func (h *Handler) parseEventTypes(w http.ResponseWriter, r *http.Request, grant *auth.Grant) ([]string, bool) {
+	var types []string
+	if s := r.URL.Query().Get("types"); s != "" {
+		for _, pattern := range strings.Split(s, ",") {
+			if !IsValidEventTypePattern(pattern) {
+				h.writeError(w, http.StatusBadRequest, "invalid event type pattern: "+pattern)
+				return nil, false
+			}
+			if !grant.Covers(auth.Events, pattern) {
+				h.writeError(w, http.StatusForbidden, "API key may not read event types: "+pattern)
+				return nil, false
+			}
+			types = append(types, pattern)
+		}
+	} else if !grant.Unrestricted(auth.Events) {
+		if types = grant.Patterns(auth.Events); len(types) == 0 {
+			h.writeError(w, http.StatusForbidden, "API key may not read events")
+			return nil, false
+		}
+	}
+	return types, true
+}
+
+// HandleAggregateProjections handles GET /api/v1/aggregates/{aggregate_id}/projections
+// Returns the aggregate's projections of every type in one response, for
+// support views of everything known about a device or session.
+// ?units=, ?fields= and ?namespace= behave as for HandleGetProjection;
+// ?aggregate_type=device returns nothing unless the aggregate is a device.
+// Projection types the API key may not read are left out.
+func (h *Handler) HandleAggregateProjections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Expected path: /api/v1/aggregates/{aggregate_id}/projections
+	aggregateID, rest, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/aggregates/"), "/")
+	if !ok || aggregateID == "" || rest != "projections" {
+		h.writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	grant, ok := h.grant(w, r)
+	if !ok {
+		return
+	}
+	view, ok := h.parseView(w, r)
+	if !ok {
+		return
+	}
+	test, ok := h.parseTestNamespace(w, r)
+	if !ok {
+		return
+	}
+	aggregateType, ok := h.parseAggregateType(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetAggregateProjections(view.ctx, aggregateID, test, aggregateType)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	result.Projections = slices.DeleteFunc(result.Projections, func(p Projection) bool {
+		return !grant.Allows(auth.Read, projections.BaseType(p.ProjectionType))
+	})
+
+	h.writeProjections(w, result, result.Projections, view)
+}
+
+// HandleAggregateStream handles GET /api/v1/aggregates/{aggregate_id}/stream?from_seq={n}&to_seq={m}&limit={k}
+// Returns the aggregate's stored events in aggregate_seq order, with from_seq
+// and to_seq both inclusive. Clients page by passing back next_seq as from_seq
+// until a page comes back empty or next_seq passes to_seq.
+// Events of types the API key may not read are left out, so a page can come
+// back shorter than limit (or empty) while next_seq still advances.
+func (h *Handler) HandleAggregateStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	grant, ok := h.grant(w, r)
+	if !ok {
+		return
+	}
+	if !grant.Unrestricted(auth.Events) && len(grant.Patterns(auth.Events)) == 0 {
+		h.writeError(w, http.StatusForbidden, "API key may not read events")
+		return
+	}
+
+	// Expected path: /api/v1/aggregates/{aggregate_id}/stream
+	aggregateID, rest, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/aggregates/"), "/")
+	if !ok || aggregateID == "" || rest != "stream" {
+		h.writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	var seqs [2]int64
+	for i, name := range []string{"from_seq", "to_seq"} {
+		s := r.URL.Query().Get(name)
+		if s == "" {
+			continue
+		}
+		seq, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || seq < 0 {
+			h.writeError(w, http.StatusBadRequest, "invalid "+name+": "+s)
+			return
+		}
+		seqs[i] = seq
+	}
+	fromSeq, toSeq := seqs[0], seqs[1]
+	if toSeq != 0 && toSeq < fromSeq {
+		h.writeError(w, http.StatusBadRequest, "to_seq must not be less than from_seq")
+		return
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			limit = l
+		}
+	}
+
+	stream, err := h.service.GetAggregateStream(r.Context(), aggregateID, fromSeq, toSeq, limit)
+	if err != nil {
+		if errors.Is(err, ErrEventLogDisabled) {
+			h.writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		h.writeServiceError(w, err)
+		return
+	}
+	stream.Events = slices.DeleteFunc(stream.Events, func(e *events.Envelope) bool {
+		return !grant.Allows(auth.Events, e.EventType)
+	})
+
+	h.writeJSON(w, http.StatusOK, stream)
+}
+
 // HandleHealth handles GET /health
 func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
@@ -121,3 +745,10 @@ func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
 func (h *Handler) writeError(w http.ResponseWriter, status int, message string) {
 	h.writeJSON(w, status, map[string]string{"error": message})
 }
+
+// writeServiceError answers a failed service call: 504 when the store read
+// timed out, 503 while the database is unavailable, 500 otherwise.
+func (h *Handler) writeServiceError(w http.ResponseWriter, err error) {
+	status, message := timeout.Status(err)
+	h.writeError(w, status, message)
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,7 +16,11 @@ import (
 
 	"github.com/gofrs/uuid/v5"
 
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
 	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
 )
 
 func newTestProjection() *projections.Projection {
@@ -68,6 +73,33 @@ func TestHandleGetProjection_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestHandleGetProjection_Deleted(t *testing.T) {
+	deletedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := newTestProjection()
+	p.DeletedAt = &deletedAt
+	mock := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return nil, fmt.Errorf("no rows in result set")
+		},
+		GetDeletedProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return p, nil
+		},
+	}
+	handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state/device-001", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleGetProjection(w, req)
+
+	assert.Equal(t, http.StatusGone, w.Code)
+	var resp map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "projection deleted", resp["error"])
+	assert.Equal(t, "2026-01-02T03:04:05.000Z", resp["deleted_at"])
+	assert.Equal(t, p.LastEventID.String(), resp["last_event_id"])
+}
+
 func TestHandleGetProjection_InvalidType(t *testing.T) {
 	mock := &mockProjectionReader{
 		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
@@ -176,6 +208,34 @@ func TestHandleListProjections_MethodNotAllowed(t *testing.T) {
 	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
 }
 
+func TestHandleListProjections_StoreErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"timed out", fmt.Errorf("timeout: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"circuit open", pgretry.ErrCircuitOpen, http.StatusServiceUnavailable},
+		{"other", fmt.Errorf("relation does not exist"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockProjectionReader{
+				ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+					return nil, 0, tt.err
+				},
+			}
+			handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+
+			w := httptest.NewRecorder()
+			handler.HandleListProjections(w, httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state", nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.NotContains(t, w.Body.String(), "relation", "store errors are not returned to clients")
+		})
+	}
+}
+
 func TestHandleHealth_Query(t *testing.T) {
 	handler := NewHandler(NewService(nil, slog.Default()), slog.Default())
 
@@ -190,3 +250,796 @@ func TestHandleHealth_Query(t *testing.T) {
 	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
 	assert.Equal(t, "healthy", resp["status"])
 }
+
+func TestHandleListProjections_AnomalyFilter(t *testing.T) {
+	var capturedFlags []string
+	mock := &mockProjectionReader{
+		ListAnomalousFn: func(ctx context.Context, projType string, flags []string, limit, offset int) ([]projections.Projection, int, error) {
+			capturedFlags = flags
+			return []projections.Projection{*newTestProjection()}, 1, nil
+		},
+	}
+	handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?anomaly=any", nil)
+	w := httptest.NewRecorder()
+	handler.HandleListProjections(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, projections.AnomalyFlags, capturedFlags)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?anomaly=reporting_gap", nil)
+	w = httptest.NewRecorder()
+	handler.HandleListProjections(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{projections.AnomalyReportingGap}, capturedFlags)
+}
+
+func TestHandleListProjections_InvalidAnomalyFlag(t *testing.T) {
+	handler := NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?anomaly=on_fire", nil)
+	w := httptest.NewRecorder()
+	handler.HandleListProjections(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleListProjections_Deleted(t *testing.T) {
+	called := false
+	mock := &mockProjectionReader{
+		ListDeletedFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			called = true
+			return []projections.Projection{*newTestProjection()}, 1, nil
+		},
+		ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			return []projections.Projection{}, 0, nil
+		},
+	}
+	handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?deleted=true", nil)
+	w := httptest.NewRecorder()
+	handler.HandleListProjections(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, called, "deleted=true should list deleted projections")
+
+	called = false
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?deleted=false", nil)
+	w = httptest.NewRecorder()
+	handler.HandleListProjections(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, called, "deleted=false should list live projections")
+}
+
+func TestHandleListProjections_InvalidDeleted(t *testing.T) {
+	handler := NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default())
+
+	for _, query := range []string{"deleted=maybe", "deleted=true&anomaly=any"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.HandleListProjections(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestHandleBatchGetProjections_Success(t *testing.T) {
+	var gotType string
+	var gotIDs []string
+	mock := &mockProjectionReader{
+		GetProjectionsFn: func(ctx context.Context, projType string, aggregateIDs []string) ([]projections.Projection, error) {
+			gotType = projType
+			gotIDs = aggregateIDs
+			return []projections.Projection{*newTestProjection()}, nil
+		},
+	}
+	mux := http.NewServeMux()
+	NewHandler(NewService(mock, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	body := strings.NewReader(`{"aggregate_ids": ["device-001", "device-404"]}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/projections/sensor_state/batch-get?namespace=test", body)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "test.sensor_state", gotType)
+	assert.Equal(t, []string{"device-001", "device-404"}, gotIDs)
+
+	var batch ProjectionBatch
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &batch))
+	require.Len(t, batch.Projections, 1)
+	assert.Equal(t, "device-001", batch.Projections[0].AggregateID)
+	assert.Equal(t, []string{"device-404"}, batch.Missing)
+}
+
+func TestHandleBatchGetProjections_BadRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	tooMany, _ := json.Marshal(BatchGetRequest{AggregateIDs: make([]string, MaxBatchGetIDs+1)})
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"invalid JSON", http.MethodPost, "/api/v1/projections/sensor_state/batch-get", `{`, http.StatusBadRequest},
+		{"no IDs", http.MethodPost, "/api/v1/projections/sensor_state/batch-get", `{"aggregate_ids": []}`, http.StatusBadRequest},
+		{"too many IDs", http.MethodPost, "/api/v1/projections/sensor_state/batch-get", string(tooMany), http.StatusBadRequest},
+		{"empty ID", http.MethodPost, "/api/v1/projections/sensor_state/batch-get", `{"aggregate_ids": [""]}`, http.StatusBadRequest},
+		{"invalid type", http.MethodPost, "/api/v1/projections/widget/batch-get", `{"aggregate_ids": ["a"]}`, http.StatusBadRequest},
+		{"GET", http.MethodGet, "/api/v1/projections/sensor_state/batch-get", ``, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestHandleAggregateProjections(t *testing.T) {
+	var gotID string
+	mock := &mockProjectionReader{
+		GetAggregateProjectionsFn: func(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error) {
+			gotID = aggregateID
+			return []projections.Projection{*newTestProjection()}, nil
+		},
+	}
+	mux := http.NewServeMux()
+	NewHandler(NewService(mock, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/aggregates/device-001/projections", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "device-001", gotID)
+	var result AggregateProjections
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "device-001", result.AggregateID)
+	require.Len(t, result.Projections, 1)
+	assert.Equal(t, "sensor_state", result.Projections[0].ProjectionType)
+}
+
+func TestHandleAggregateProjections_BadRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	tests := []struct {
+		method string
+		target string
+		want   int
+	}{
+		{http.MethodGet, "/api/v1/aggregates/device-001/projections?namespace=staging", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/aggregates/device-001/projections?units=kelvin", http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/aggregates/device-001/projections?aggregate_type=9lives", http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/aggregates/device-001/projections", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/v1/aggregates/device-001/projections/sensor_state", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.want, w.Code, tt.method+" "+tt.target)
+	}
+}
+
+func TestHandleProjections_Fields(t *testing.T) {
+	projection := newTestProjection()
+	projection.State = json.RawMessage(`{"value": 72.5, "unit": "fahrenheit", "location": {"site": "plant-1"}}`)
+	mock := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return projection, nil
+		},
+		ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			return []projections.Projection{*projection}, 1, nil
+		},
+	}
+	mux := http.NewServeMux()
+	NewHandler(NewService(mock, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	// Fields are selected after unit conversion
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state/device-001?fields=state.value,state.unit&units=metric", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"projection_type": "sensor_state", "aggregate_id": "device-001", "state": {"value": 22.5, "unit": "celsius"}}`, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?fields=state.location.site", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"projections": [{"projection_type": "sensor_state", "aggregate_id": "device-001", "state": {"location": {"site": "plant-1"}}}],
+		"total": 1, "limit": 20, "offset": 0
+	}`, w.Body.String())
+}
+
+func TestHandleProjections_InvalidFields(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	for _, target := range []string{
+		"/api/v1/projections/sensor_state/device-001?fields=temperature",
+		"/api/v1/projections/sensor_state?fields=state..value",
+		"/api/v1/aggregates/device-001/projections?fields=bogus",
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
+
+func TestHandleProjections_IncludeRegistry(t *testing.T) {
+	// The store joins the entry when the read asks for it
+	read := func(ctx context.Context) *projections.Projection {
+		p := newTestProjection()
+		if projections.RegistryRequested(ctx) {
+			p.Registry = &registry.Aggregate{AggregateID: "device-001", Site: "plant-7", Tags: []string{"critical"}}
+		}
+		return p
+	}
+	mock := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return read(ctx), nil
+		},
+		ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			return []projections.Projection{*read(ctx)}, 1, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetRegistry(registry.NewMemoryStore())
+	mux := http.NewServeMux()
+	NewHandler(service, slog.Default()).RegisterRoutes(mux)
+
+	// Without include the entry is left out
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state/device-001", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "plant-7")
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state/device-001?include=registry", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var got Projection
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	require.NotNil(t, got.Registry)
+	assert.Equal(t, "plant-7", got.Registry.Site)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?include=registry&fields=registry.site", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"projections": [{"projection_type": "sensor_state", "aggregate_id": "device-001", "registry": {"site": "plant-7"}}],
+		"total": 1, "limit": 20, "offset": 0
+	}`, w.Body.String())
+}
+
+func TestHandleListProjections_RegistryFilter(t *testing.T) {
+	var gotFilter registry.Filter
+	mock := &mockProjectionReader{
+		ListRegisteredFn: func(ctx context.Context, projType string, filter registry.Filter, limit, offset int) ([]projections.Projection, int, error) {
+			gotFilter = filter
+			return []projections.Projection{*newTestProjection()}, 1, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetRegistry(registry.NewMemoryStore())
+	mux := http.NewServeMux()
+	NewHandler(service, slog.Default()).RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?registry.site=plant-7&registry.tag=critical", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, registry.Filter{Site: "plant-7", Tag: "critical"}, gotFilter)
+}
+
+func TestHandleProjections_InvalidRegistry(t *testing.T) {
+	mock := &mockProjectionReader{
+		GetAggregateProjectionsFn: func(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error) {
+			return []projections.Projection{*newTestProjection()}, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	mux := http.NewServeMux()
+	NewHandler(service, slog.Default()).RegisterRoutes(mux)
+
+	for _, path := range []string{
+		"/api/v1/projections/sensor_state/device-001?include=owner",
+		"/api/v1/projections/sensor_state?registry.site=plant-7&q=dev",
+		"/api/v1/projections/sensor_state?registry.aggregate_type=Device",
+		// The registry is disabled
+		"/api/v1/projections/sensor_state?registry.site=plant-7",
+		"/api/v1/aggregates/device-001/projections?include=registry",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+}
+
+func TestHandleListProjections_Search(t *testing.T) {
+	var gotQ string
+	mock := &mockProjectionReader{
+		SearchProjectionsFn: func(ctx context.Context, projType, q string, limit, offset int) ([]projections.Projection, int, error) {
+			gotQ = q
+			return []projections.Projection{*newTestProjection()}, 1, nil
+		},
+	}
+	handler := NewHandler(NewService(mock, slog.Default()), slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?q=dev-0", nil)
+	w := httptest.NewRecorder()
+	handler.HandleListProjections(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "dev-0", gotQ)
+}
+
+func TestHandleListProjections_InvalidSearch(t *testing.T) {
+	handler := NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default())
+
+	for _, query := range []string{
+		"q=" + strings.Repeat("x", MaxSearchLength+1),
+		"q=dev&anomaly=any",
+		"q=dev&deleted=true",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.HandleListProjections(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestHandleGetProjection_Units(t *testing.T) {
+	mock := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			p := newTestProjection()
+			p.State = json.RawMessage(`{"value": 72.5, "unit": "fahrenheit"}`)
+			return p, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	handler := NewHandler(service, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state/device-001?units=metric", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleGetProjection(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp Projection
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.JSONEq(t, `{"value": 22.5, "unit": "celsius"}`, string(resp.State))
+}
+
+func TestHandleListProjections_Units(t *testing.T) {
+	mock := &mockProjectionReader{
+		ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			p := newTestProjection()
+			p.State = json.RawMessage(`{"value": 22.5, "unit": "celsius"}`)
+			return []projections.Projection{*p}, 1, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	handler := NewHandler(service, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?units=imperial", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleListProjections(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp ProjectionList
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Projections, 1)
+	assert.JSONEq(t, `{"value": 72.5, "unit": "fahrenheit"}`, string(resp.Projections[0].State))
+}
+
+func TestHandleListProjections_InvalidUnits(t *testing.T) {
+	mock := &mockProjectionReader{
+		ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			t.Fatal("store should not be called for invalid units")
+			return nil, 0, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	handler := NewHandler(service, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?units=kelvin", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleListProjections(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleGetProjection_TestNamespace(t *testing.T) {
+	var requestedType string
+	mock := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			requestedType = projType
+			p := newTestProjection()
+			p.ProjectionType = projType
+			return p, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	handler := NewHandler(service, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state/device-001?namespace=test", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleGetProjection(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "test.sensor_state", requestedType)
+}
+
+func TestHandleListProjections_InvalidNamespace(t *testing.T) {
+	mock := &mockProjectionReader{
+		ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			t.Fatal("store should not be called for invalid namespace")
+			return nil, 0, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	handler := NewHandler(service, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?namespace=staging", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleListProjections(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleListEvents_Success(t *testing.T) {
+	var gotAfter int64
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			gotAfter = afterSeq
+			return []*events.Envelope{{GlobalSeq: 8, AggregateSeq: 3}}, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	handler := NewHandler(service, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events?after_seq=7&limit=5", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleListEvents(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(7), gotAfter)
+
+	var resp EventList
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, int64(8), resp.Events[0].GlobalSeq)
+	assert.Equal(t, int64(3), resp.Events[0].AggregateSeq)
+	assert.Equal(t, int64(8), resp.NextSeq)
+}
+
+func TestHandleListEvents_InvalidAfterSeq(t *testing.T) {
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(&mockEventLog{}, EventLogConfig{})
+	handler := NewHandler(service, slog.Default())
+
+	for _, v := range []string{"abc", "-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events?after_seq="+v, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleListEvents(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "after_seq=%s", v)
+	}
+}
+
+func TestHandleListEvents_Disabled(t *testing.T) {
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	handler := NewHandler(service, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleListEvents(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandleListEvents_TypesAndWait(t *testing.T) {
+	var gotTypes []string
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			gotTypes = types
+			return []*events.Envelope{{GlobalSeq: 1, EventType: "sensor.reading"}}, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	handler := NewHandler(service, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events?types=sensor.*,user.login&wait=5s", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleListEvents(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"sensor.*", "user.login"}, gotTypes)
+}
+
+func TestHandleListEvents_AggregateType(t *testing.T) {
+	var gotType string
+	log := &mockEventLog{
+		FetchAfterOfAggregateTypeFn: func(ctx context.Context, afterSeq int64, aggregateType string, types []string, limit int) ([]*events.Envelope, error) {
+			gotType = aggregateType
+			return nil, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	handler := NewHandler(service, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events?aggregate_type=device", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleListEvents(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "device", gotType)
+}
+
+func TestHandleListEvents_InvalidTypesOrWait(t *testing.T) {
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(&mockEventLog{}, EventLogConfig{})
+	handler := NewHandler(service, slog.Default())
+
+	for _, query := range []string{"types=*.reading", "types=sensor.*,", "wait=soon", "wait=-1s", "aggregate_type=Device"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events?"+query, nil)
+		w := httptest.NewRecorder()
+
+		handler.HandleListEvents(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestHandleAggregateStream_Success(t *testing.T) {
+	var gotID string
+	var gotFrom, gotTo int64
+	var gotLimit int
+	log := &mockEventLog{
+		FetchAggregateFn: func(ctx context.Context, aggregateID string, fromSeq, toSeq int64, limit int) ([]*events.Envelope, error) {
+			gotID, gotFrom, gotTo, gotLimit = aggregateID, fromSeq, toSeq, limit
+			return []*events.Envelope{{AggregateID: aggregateID, AggregateSeq: 3}}, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	mux := http.NewServeMux()
+	NewHandler(service, slog.Default()).RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/aggregates/device-001/stream?from_seq=3&to_seq=9&limit=50", nil)
+	w := httptest.NewRecorder()
+
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "device-001", gotID)
+	assert.Equal(t, int64(3), gotFrom)
+	assert.Equal(t, int64(9), gotTo)
+	assert.Equal(t, 50, gotLimit)
+
+	var resp AggregateStream
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "device-001", resp.AggregateID)
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, int64(4), resp.NextSeq)
+}
+
+func TestHandleAggregateStream_BadRequests(t *testing.T) {
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(&mockEventLog{}, EventLogConfig{})
+	mux := http.NewServeMux()
+	NewHandler(service, slog.Default()).RegisterRoutes(mux)
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/api/v1/aggregates/device-001/stream?from_seq=abc", http.StatusBadRequest},
+		{"/api/v1/aggregates/device-001/stream?to_seq=-1", http.StatusBadRequest},
+		{"/api/v1/aggregates/device-001/stream?from_seq=5&to_seq=2", http.StatusBadRequest},
+		{"/api/v1/aggregates/device-001", http.StatusNotFound},
+		{"/api/v1/aggregates/device-001/events", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		assert.Equal(t, tt.want, w.Code, tt.target)
+	}
+}
+
+func TestHandleAggregateStream_Disabled(t *testing.T) {
+	handler := NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/aggregates/device-001/stream", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleAggregateStream(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRoutes_OpenAPI(t *testing.T) {
+	mux := http.NewServeMux()
+	NewHandler(NewService(&mockProjectionReader{}, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Contains(t, doc["paths"], "/api/v1/projections/{projection_type}")
+}
+
+func TestRoutes_ValidateAgainstSpec(t *testing.T) {
+	mock := &mockProjectionReader{
+		ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			t.Fatal("ListProjections should not be called for invalid request")
+			return nil, 0, nil
+		},
+	}
+	mux := http.NewServeMux()
+	NewHandler(NewService(mock, slog.Default()), slog.Default()).RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/projections/sensor_state?limit=500", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid query parameter limit: must be at most 100")
+}
+
+func TestRoutes_Policy(t *testing.T) {
+	policy, err := auth.ParsePolicy("sensors=read:sensor_state|events:sensor.*,readonly=read:*")
+	require.NoError(t, err)
+
+	mock := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return newTestProjection(), nil
+		},
+		ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			return []projections.Projection{*newTestProjection()}, 1, nil
+		},
+	}
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			return nil, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	handler := NewHandler(service, slog.Default())
+	handler.SetPolicy(policy)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	tests := []struct {
+		key    string
+		target string
+		want   int
+	}{
+		{"sensors", "/api/v1/projections/sensor_state/device-001", http.StatusOK},
+		{"sensors", "/api/v1/projections/sensor_state", http.StatusOK},
+		{"sensors", "/api/v1/projections/user_session/user-123", http.StatusForbidden},
+		{"sensors", "/api/v1/projections/user_session", http.StatusForbidden},
+		{"sensors", "/api/v1/events?types=sensor.reading", http.StatusOK},
+		{"sensors", "/api/v1/events?types=user.*", http.StatusForbidden},
+		{"sensors", "/api/v1/events?types=*", http.StatusForbidden},
+		{"readonly", "/api/v1/projections/user_session/user-123", http.StatusOK},
+		{"readonly", "/api/v1/events", http.StatusForbidden},
+		{"readonly", "/api/v1/aggregates/device-001/stream", http.StatusForbidden},
+		{"", "/api/v1/projections/sensor_state/device-001", http.StatusUnauthorized},
+		{"other", "/api/v1/events", http.StatusUnauthorized},
+		{"", "/health", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.key != "" {
+			req.Header.Set(auth.APIKeyHeader, tt.key)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.want, w.Code, "%s %s", tt.key, tt.target)
+	}
+}
+
+func TestRoutes_PolicyNarrowsResults(t *testing.T) {
+	policy, err := auth.ParsePolicy("sensors=read:sensor_state|events:sensor.*")
+	require.NoError(t, err)
+
+	var gotTypes []string
+	session := newTestProjection()
+	session.ProjectionType = "user_session"
+	mock := &mockProjectionReader{
+		GetAggregateProjectionsFn: func(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error) {
+			return []projections.Projection{*newTestProjection(), *session}, nil
+		},
+	}
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			gotTypes = types
+			return nil, nil
+		},
+		FetchAggregateFn: func(ctx context.Context, aggregateID string, fromSeq, toSeq int64, limit int) ([]*events.Envelope, error) {
+			return []*events.Envelope{
+				{AggregateID: aggregateID, AggregateSeq: 1, EventType: "sensor.reading"},
+				{AggregateID: aggregateID, AggregateSeq: 2, EventType: "user.login"},
+			}, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+	handler := NewHandler(service, slog.Default())
+	handler.SetPolicy(policy)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(auth.APIKeyHeader, "sensors")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, target)
+		return w
+	}
+
+	// Unfiltered event reads are limited to the key's scopes
+	get("/api/v1/events")
+	assert.Equal(t, []string{"sensor.*"}, gotTypes)
+
+	var result AggregateProjections
+	require.NoError(t, json.Unmarshal(get("/api/v1/aggregates/device-001/projections").Body.Bytes(), &result))
+	require.Len(t, result.Projections, 1)
+	assert.Equal(t, "sensor_state", result.Projections[0].ProjectionType)
+
+	// Out-of-scope events are dropped, but paging still moves past them
+	var stream AggregateStream
+	require.NoError(t, json.Unmarshal(get("/api/v1/aggregates/device-001/stream").Body.Bytes(), &stream))
+	require.Len(t, stream.Events, 1)
+	assert.Equal(t, "sensor.reading", stream.Events[0].EventType)
+	assert.Equal(t, int64(3), stream.NextSeq)
+}
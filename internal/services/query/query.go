@@ -9,12 +9,63 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/httpserver"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
+	"github.com/cornjacket/platform-services/internal/shared/infra/postgres"
+	"github.com/cornjacket/platform-services/internal/shared/middleware"
+	"github.com/cornjacket/platform-services/internal/shared/pii"
 	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
 )
 
 // Config holds configuration for the query service.
 type Config struct {
-	Port int
+	Port   int
+	Server httpserver.Config
+
+	// CompressionLevel compresses responses with gzip or deflate at this
+	// level, 1 to 9 (see middleware.Compress); 0 disables compression.
+	CompressionLevel int
+
+	// FallbackTypes lists projection types served from event history when the
+	// projection row is missing. Requires an EventReader to be passed to Start.
+	FallbackTypes []string
+	// FallbackHeal writes folded projections back to the projections table.
+	FallbackHeal bool
+
+	// EventPollInterval and EventMaxWait configure long polling on
+	// GET /api/v1/events (see EventLogConfig).
+	EventPollInterval time.Duration
+	EventMaxWait      time.Duration
+
+	// Decryptor decrypts PII-protected values in responses for authorized
+	// API keys; nil serves stored values as they are.
+	Decryptor *pii.Decryptor
+
+	// Policy restricts the projection and event types each API key may
+	// read; nil allows every request.
+	Policy *auth.Policy
+
+	// Timeouts bounds each operation's store reads (see Service.SetTimeouts);
+	// zero sets no deadlines.
+	Timeouts timeout.Policy
+
+	// DB wraps pool with retries and a circuit breaker for the projections
+	// store; nil queries pool directly.
+	DB *pgretry.DB
+
+	// Registry enables ?include=registry and the ?registry.<field>= list
+	// filters, read from pool's aggregate_registry table.
+	Registry bool
+
+	// Search serves /api/v1/search from the search index; nil answers 404.
+	Search Searcher
+
+	// GraphQL serves the read-only GraphQL gateway at /api/v1/graphql and
+	// its schema at /schema.graphql.
+	GraphQL bool
 }
 
 // RunningService represents a started query service.
@@ -25,27 +76,76 @@ type RunningService struct {
 
 // Start starts the query HTTP server.
 // It creates the projections store from the provided pool and wires the service internally.
-func Start(ctx context.Context, cfg Config, pool *pgxpool.Pool, logger *slog.Logger, errorCh chan<- error) (*RunningService, error) {
+// eventReader is optional (nil disables the event-history fallback). If it also
+// implements EventLog, GET /api/v1/events serves catch-up reads from it.
+func Start(ctx context.Context, cfg Config, pool *pgxpool.Pool, eventReader EventReader, logger *slog.Logger, errorCh chan<- error) (*RunningService, error) {
 	logger = logger.With("service", "query")
 
 	// Create projections store from pool
 	store := projections.NewPostgresStore(pool, logger)
+	if cfg.DB != nil {
+		store.SetQuerier(cfg.DB)
+	}
 
 	// Wire service → handler → routes → HTTP server
-	svc := NewService(store, logger)
+	// Reads of aliased projection types are served from their targets
+	svc := NewService(newAliasedReader(store, store, logger), logger)
+	svc.SetTimeouts(cfg.Timeouts)
+	if cfg.Search != nil {
+		svc.SetSearcher(cfg.Search)
+	}
+	if cfg.Registry {
+		entries := registry.NewPostgresStore(pool, logger)
+		if cfg.DB != nil {
+			entries.SetQuerier(cfg.DB)
+		}
+		svc.SetRegistry(entries)
+	}
+	if eventReader != nil && len(cfg.FallbackTypes) > 0 {
+		fb := &Fallback{Events: eventReader, Types: make(map[string]bool)}
+		for _, t := range cfg.FallbackTypes {
+			fb.Types[t] = true
+		}
+		if cfg.FallbackHeal {
+			fb.Healer = store
+		}
+		svc.SetFallback(fb)
+		logger.Info("event-history fallback enabled",
+			"projection_types", cfg.FallbackTypes,
+			"heal", cfg.FallbackHeal,
+		)
+	}
+	if eventLog, ok := eventReader.(EventLog); ok {
+		svc.SetEventLog(eventLog, EventLogConfig{
+			PollInterval: cfg.EventPollInterval,
+			MaxWait:      cfg.EventMaxWait,
+		})
+	}
 	handler := NewHandler(svc, logger)
+	handler.SetPolicy(cfg.Policy)
+	handler.SetDecryptor(cfg.Decryptor)
+	if cfg.GraphQL {
+		handler.EnableGraphQL()
+	}
 
 	mux := http.NewServeMux()
 	handler.RegisterRoutes(mux)
+	mux.Handle("/readyz", postgres.ReadyHandler(pool, logger))
 
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Port),
-		Handler:      mux,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	var routes http.Handler = mux
+	if cfg.Decryptor != nil {
+		decrypted := cfg.Decryptor.Wrap(mux)
+		routes = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isExportPath(r.URL.Path) {
+				mux.ServeHTTP(w, r) // decrypted by the handler as it streams
+				return
+			}
+			decrypted.ServeHTTP(w, r)
+		})
 	}
 
+	server := httpserver.New(cfg.Port, middleware.Wrap(middleware.Compress(routes, cfg.CompressionLevel), logger), cfg.Server)
+
 	// Start HTTP server
 	go func() {
 		logger.Info("starting query server", "port", cfg.Port)
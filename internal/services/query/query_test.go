@@ -26,12 +26,12 @@ import (
 var testPool *pgxpool.Pool
 
 func TestMain(m *testing.M) {
-	pool := testutil.MustNewTestPool()
-	testutil.MustDropAllTables(pool)
+	pool, drop := testutil.MustNewSchemaPool("query")
 	testutil.MustRunMigrations(pool, "../../services/eventhandler/migrations")
 	testPool = pool
-	defer pool.Close()
-	os.Exit(m.Run())
+	code := m.Run()
+	drop()
+	os.Exit(code)
 }
 
 func testLogger() *slog.Logger {
@@ -44,7 +44,7 @@ func startQuery(t *testing.T, errorCh chan<- error) *RunningService {
 	t.Helper()
 	ctx := context.Background()
 
-	svc, err := Start(ctx, Config{Port: testPort}, testPool, testLogger(), errorCh)
+	svc, err := Start(ctx, Config{Port: testPort}, testPool, nil, testLogger(), errorCh)
 	require.NoError(t, err)
 
 	// Give server time to bind
@@ -6,7 +6,9 @@ import (
 
 	"github.com/gofrs/uuid/v5"
 
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
 )
 
 // Projection represents a projection record returned by the Query Service.
@@ -15,10 +17,16 @@ type Projection struct {
 	ProjectionID       uuid.UUID       `json:"projection_id"`
 	ProjectionType     string          `json:"projection_type"`
 	AggregateID        string          `json:"aggregate_id"`
+	AggregateType      string          `json:"aggregate_type,omitempty"`
 	State              json.RawMessage `json:"state"`
 	LastEventID        uuid.UUID       `json:"last_event_id"`
 	LastEventTimestamp string          `json:"last_event_timestamp"`
 	UpdatedAt          string          `json:"updated_at"`
+	DeletedAt          string          `json:"deleted_at,omitempty"`
+
+	// Registry is the aggregate's registry entry, if it has one and it was
+	// requested (see Service.WithRegistry).
+	Registry *registry.Aggregate `json:"registry,omitempty"`
 }
 
 // ProjectionList represents a paginated list of projections.
@@ -29,26 +37,138 @@ type ProjectionList struct {
 	Offset      int          `json:"offset"`
 }
 
+// ProjectionBatch is the result of a batch get: the projections found, in
+// request order, and the requested aggregate IDs that have none.
+type ProjectionBatch struct {
+	Projections []Projection `json:"projections"`
+	Missing     []string     `json:"missing"`
+}
+
+// AggregateProjections is every projection the platform holds for one aggregate.
+type AggregateProjections struct {
+	AggregateID string       `json:"aggregate_id"`
+	Projections []Projection `json:"projections"`
+}
+
+// EventList is a page of the event log in global_seq order.
+type EventList struct {
+	Events []*events.Envelope `json:"events"`
+	// NextSeq is the after_seq to pass for the next page: the last returned
+	// event's global_seq, or the requested after_seq if there were none.
+	NextSeq int64 `json:"next_seq"`
+}
+
+// AggregateStream is a page of one aggregate's events in aggregate_seq order.
+type AggregateStream struct {
+	AggregateID string             `json:"aggregate_id"`
+	Events      []*events.Envelope `json:"events"`
+	// NextSeq is the from_seq to pass for the next page: one past the last
+	// returned event's aggregate_seq, or the requested from_seq if there were none.
+	NextSeq int64 `json:"next_seq"`
+}
+
 // ProjectionReader reads projections from the store.
 // This interface is satisfied by shared/projections.Store.
 type ProjectionReader interface {
 	// GetProjection retrieves a single projection by type and aggregate ID.
 	GetProjection(ctx context.Context, projType, aggregateID string) (*projections.Projection, error)
 
+	// GetDeletedProjection retrieves a single deleted projection by type and aggregate ID.
+	GetDeletedProjection(ctx context.Context, projType, aggregateID string) (*projections.Projection, error)
+
+	// GetProjections retrieves the projections of one type for a set of aggregate IDs.
+	GetProjections(ctx context.Context, projType string, aggregateIDs []string) ([]projections.Projection, error)
+
+	// GetAggregateProjections retrieves every projection of one aggregate, across
+	// types, optionally only those of aggregates of aggregateType.
+	GetAggregateProjections(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error)
+
 	// ListProjections retrieves projections by type with pagination.
 	ListProjections(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error)
+
+	// ListAnomalous retrieves projections by type with any of the given anomaly flags set.
+	ListAnomalous(ctx context.Context, projType string, flags []string, limit, offset int) ([]projections.Projection, int, error)
+
+	// SearchProjections retrieves projections by type whose aggregate ID contains q.
+	SearchProjections(ctx context.Context, projType, q string, limit, offset int) ([]projections.Projection, int, error)
+
+	// ListDeleted retrieves deleted projections by type with pagination.
+	ListDeleted(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error)
+
+	// ListRegistered retrieves projections by type whose aggregate's registry
+	// entry matches filter.
+	ListRegistered(ctx context.Context, projType string, filter registry.Filter, limit, offset int) ([]projections.Projection, int, error)
+
+	// ScanProjections retrieves up to limit projections by type, deleted ones
+	// included, with aggregate IDs after afterAggregateID, in aggregate ID order.
+	ScanProjections(ctx context.Context, projType, afterAggregateID string, limit int) ([]projections.Projection, error)
+}
+
+// AliasReader reads the projection type aliases set through the event
+// handler's admin API.
+// This interface is satisfied by shared/projections.Store.
+type AliasReader interface {
+	// ListAliases returns all projection aliases.
+	ListAliases(ctx context.Context) ([]projections.Alias, error)
+}
+
+// EventReader reads event history for the projection fallback.
+// This interface is satisfied by infra/postgres.EventStoreRepo.
+type EventReader interface {
+	// GetLatest returns the newest event for the aggregate among event types with the given prefix.
+	GetLatest(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error)
+}
+
+// EventLog reads the event store in global sequence order for catch-up reads.
+// This interface is satisfied by infra/postgres.EventStoreRepo.
+type EventLog interface {
+	// FetchAfter returns up to limit events with global_seq greater than afterSeq
+	// whose event_type matches one of types (see IsValidEventTypePattern).
+	// Empty types matches every event.
+	FetchAfter(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error)
+
+	// FetchAfterOfAggregateType is FetchAfter restricted to events of one
+	// aggregate type.
+	FetchAfterOfAggregateType(ctx context.Context, afterSeq int64, aggregateType string, types []string, limit int) ([]*events.Envelope, error)
+
+	// FetchAggregate returns up to limit events of one aggregate with
+	// aggregate_seq in [fromSeq, toSeq]. A toSeq of 0 means no upper bound.
+	FetchAggregate(ctx context.Context, aggregateID string, fromSeq, toSeq int64, limit int) ([]*events.Envelope, error)
+}
+
+// Searcher runs search requests against the search index of a projection
+// type, one index per type, kept up to date by the search service.
+// This interface is satisfied by infra/opensearch.Client.
+type Searcher interface {
+	// Search runs query, an OpenSearch/Elasticsearch search request body,
+	// and returns the backend's status and response body as they are.
+	Search(ctx context.Context, projType string, query []byte) (int, []byte, error)
+}
+
+// ProjectionHealer writes a folded projection back to the store.
+// This interface is satisfied by shared/projections.Store.
+type ProjectionHealer interface {
+	// WriteProjection inserts or updates a projection, only if the event is newer.
+	WriteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error
 }
 
 // fromStoreProjection converts a shared projections.Projection to query.Projection
 func fromStoreProjection(p *projections.Projection) *Projection {
+	var deletedAt string
+	if p.DeletedAt != nil {
+		deletedAt = p.DeletedAt.Format("2006-01-02T15:04:05.000Z")
+	}
 	return &Projection{
 		ProjectionID:       p.ProjectionID,
 		ProjectionType:     p.ProjectionType,
 		AggregateID:        p.AggregateID,
+		AggregateType:      p.AggregateType,
 		State:              p.State,
 		LastEventID:        p.LastEventID,
 		LastEventTimestamp: p.LastEventTimestamp.Format("2006-01-02T15:04:05.000Z"),
 		UpdatedAt:          p.UpdatedAt.Format("2006-01-02T15:04:05.000Z"),
+		DeletedAt:          deletedAt,
+		Registry:           p.Registry,
 	}
 }
 
@@ -3,18 +3,60 @@ package query
 import (
 	"net/http"
 	"strings"
+
+	"github.com/cornjacket/platform-services/api/openapi"
 )
 
+// spec is the query API contract: served at /openapi.json and enforced on
+// incoming requests.
+var spec = openapi.MustLoad(openapi.Query)
+
 // RegisterRoutes registers query service routes on the provided mux.
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	// Health check
 	mux.HandleFunc("/health", h.HandleHealth)
+	mux.HandleFunc("/openapi.json", spec.ServeJSON)
 
 	// Projection endpoints
-	// We need to handle both:
+	// We need to handle:
 	//   GET /api/v1/projections/{type} -> list
 	//   GET /api/v1/projections/{type}/{id} -> get single
-	mux.HandleFunc("/api/v1/projections/", h.routeProjections)
+	//   POST /api/v1/projections/{type}/batch-get -> get many
+	//   GET /api/v1/projections/{type}/export -> stream all
+	mux.Handle("/api/v1/projections/", spec.Validate(http.HandlerFunc(h.routeProjections)))
+
+	// Event log catch-up reads
+	mux.Handle("/api/v1/events", spec.Validate(http.HandlerFunc(h.HandleListEvents)))
+	mux.Handle("/api/v1/events/export", spec.Validate(http.HandlerFunc(h.HandleExportEvents)))
+
+	// Per-aggregate views
+	//   GET /api/v1/aggregates/{id}/stream -> event stream
+	//   GET /api/v1/aggregates/{id}/projections -> projections of every type
+	mux.Handle("/api/v1/aggregates/", spec.Validate(http.HandlerFunc(h.routeAggregates)))
+
+	// Search index queries
+	//   GET|POST /api/v1/search/{type}
+	mux.Handle("/api/v1/search/", spec.Validate(http.HandlerFunc(h.HandleSearch)))
+
+	// GraphQL gateway, when enabled
+	if h.graphql != nil {
+		mux.Handle("/api/v1/graphql", spec.Validate(http.HandlerFunc(h.HandleGraphQL)))
+		mux.HandleFunc("/schema.graphql", h.HandleGraphQLSchema)
+	}
+}
+
+// routeAggregates routes per-aggregate requests on the path segment after the ID.
+func (h *Handler) routeAggregates(w http.ResponseWriter, r *http.Request) {
+	_, view, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/aggregates/"), "/")
+
+	switch view {
+	case "stream":
+		h.HandleAggregateStream(w, r)
+	case "projections":
+		h.HandleAggregateProjections(w, r)
+	default:
+		h.writeError(w, http.StatusNotFound, "not found")
+	}
 }
 
 // routeProjections routes to either list or get based on path depth.
@@ -30,6 +72,16 @@ func (h *Handler) routeProjections(w http.ResponseWriter, r *http.Request) {
 		// /api/v1/projections/{type}
 		h.HandleListProjections(w, r)
 	case 2:
+		if parts[1] == "batch-get" {
+			// /api/v1/projections/{type}/batch-get
+			h.HandleBatchGetProjections(w, r)
+			return
+		}
+		if parts[1] == "export" && r.Method == http.MethodGet {
+			// /api/v1/projections/{type}/export
+			h.HandleExportProjections(w, r)
+			return
+		}
 		// /api/v1/projections/{type}/{id}
 		h.HandleGetProjection(w, r)
 	default:
@@ -0,0 +1,150 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MaxSearchBodyBytes is the largest search request body accepted.
+const MaxSearchBodyBytes = 64 << 10
+
+// HandleSearch handles GET and POST /api/v1/search/{projection_type}
+// Searches the projection type's search index (see the search service). A
+// POST body is an OpenSearch/Elasticsearch search request, passed through
+// as it is: queries, filters, sorting and aggregations over the indexed
+// documents, whose fields are those of the projection change records
+// (projection_type, aggregate_id, aggregate_type, state, last_event_id,
+// last_event_timestamp). GET runs a free-text query: ?q= in the syntax of
+// simple_query_string (every document without it), paged by ?limit=
+// (default 20, at most 100) and ?offset=.
+// The backend's response is returned as it is on success. A query it
+// rejects answers 400 with its reason; a missing index (nothing of the type
+// indexed yet) answers 404.
+func (h *Handler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Expected path: /api/v1/search/{projection_type}
+	projectionType := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/search/"), "/")
+	if !IsValidProjectionType(projectionType) {
+		h.writeError(w, http.StatusBadRequest, "invalid projection type: "+projectionType)
+		return
+	}
+	if !h.canRead(w, r, projectionType) {
+		return
+	}
+
+	var query []byte
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxSearchBodyBytes))
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "request body must be at most "+strconv.Itoa(MaxSearchBodyBytes)+" bytes")
+			return
+		}
+		if !json.Valid(body) {
+			h.writeError(w, http.StatusBadRequest, "invalid JSON")
+			return
+		}
+		query = body
+	} else {
+		var ok bool
+		if query, ok = h.parseTextQuery(w, r); !ok {
+			return
+		}
+	}
+
+	result, err := h.service.Search(r.Context(), projectionType, query)
+	if err != nil {
+		h.writeSearchError(w, err)
+		return
+	}
+
+	switch {
+	case result.Status == http.StatusOK:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(result.Body)
+	case result.Status == http.StatusNotFound:
+		h.writeError(w, http.StatusNotFound, "no search index for projection type: "+projectionType)
+	case result.Status == http.StatusBadRequest:
+		h.writeError(w, http.StatusBadRequest, "invalid search query: "+searchErrorReason(result.Body))
+	default:
+		h.logger.Error("search index request failed",
+			"projection_type", projectionType,
+			"status", result.Status,
+			"reason", searchErrorReason(result.Body),
+		)
+		h.writeError(w, http.StatusBadGateway, "search index unavailable")
+	}
+}
+
+// parseTextQuery builds the search request of a GET from ?q=, ?limit= and
+// ?offset=. On an invalid value it writes a 400 response and returns false.
+func (h *Handler) parseTextQuery(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	params := r.URL.Query()
+	limit, offset := 20, 0
+	if s := params.Get("limit"); s != "" {
+		l, err := strconv.Atoi(s)
+		if err != nil || l < 1 || l > 100 {
+			h.writeError(w, http.StatusBadRequest, "invalid limit: "+s+" (expected 1 to 100)")
+			return nil, false
+		}
+		limit = l
+	}
+	if s := params.Get("offset"); s != "" {
+		o, err := strconv.Atoi(s)
+		if err != nil || o < 0 {
+			h.writeError(w, http.StatusBadRequest, "invalid offset: "+s)
+			return nil, false
+		}
+		offset = o
+	}
+
+	match := map[string]any{"match_all": map[string]any{}}
+	if q := params.Get("q"); q != "" {
+		match = map[string]any{"simple_query_string": map[string]any{"query": q}}
+	}
+	query, err := json.Marshal(map[string]any{"query": match, "size": limit, "from": offset})
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, "internal server error")
+		return nil, false
+	}
+	return query, true
+}
+
+// writeSearchError answers a search the backend did not answer: 404 when
+// search is not enabled, 504 when it timed out, 502 otherwise.
+func (h *Handler) writeSearchError(w http.ResponseWriter, err error) {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrSearchDisabled):
+		h.writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		h.writeError(w, http.StatusGatewayTimeout, "timed out waiting for the search index")
+	default:
+		h.writeError(w, http.StatusBadGateway, "search index unavailable")
+	}
+}
+
+// searchErrorReason extracts the reason from a backend error response, such
+// as {"error": {"type": "parsing_exception", "reason": "..."}, "status": 400}.
+func searchErrorReason(body []byte) string {
+	var resp struct {
+		Error struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) != nil || resp.Error.Reason == "" {
+		return "rejected by the search index"
+	}
+	return resp.Error.Type + ": " + resp.Error.Reason
+}
@@ -0,0 +1,136 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+)
+
+func newSearchHandler(searcher Searcher) *Handler {
+	svc := NewService(&mockProjectionReader{}, slog.Default())
+	if searcher != nil {
+		svc.SetSearcher(searcher)
+	}
+	return NewHandler(svc, slog.Default())
+}
+
+func serveSearch(handler *Handler, method, target, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleSearch_TextQuery(t *testing.T) {
+	var gotType, gotQuery string
+	searcher := &mockSearcher{SearchFn: func(ctx context.Context, projType string, query []byte) (int, []byte, error) {
+		gotType, gotQuery = projType, string(query)
+		return http.StatusOK, []byte(`{"hits":{"total":{"value":1},"hits":[{"_id":"device-001"}]}}`), nil
+	}}
+
+	w := serveSearch(newSearchHandler(searcher), http.MethodGet, "/api/v1/search/sensor_state?q=plant-7&limit=5&offset=10", "")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "sensor_state", gotType)
+	assert.JSONEq(t, `{"query":{"simple_query_string":{"query":"plant-7"}},"size":5,"from":10}`, gotQuery)
+	assert.JSONEq(t, `{"hits":{"total":{"value":1},"hits":[{"_id":"device-001"}]}}`, w.Body.String())
+
+	// Without q, every document matches
+	serveSearch(newSearchHandler(searcher), http.MethodGet, "/api/v1/search/sensor_state", "")
+	assert.JSONEq(t, `{"query":{"match_all":{}},"size":20,"from":0}`, gotQuery)
+}
+
+func TestHandleSearch_PassesQueryThrough(t *testing.T) {
+	var gotQuery string
+	searcher := &mockSearcher{SearchFn: func(ctx context.Context, projType string, query []byte) (int, []byte, error) {
+		gotQuery = string(query)
+		return http.StatusOK, []byte(`{"hits":{"hits":[]}}`), nil
+	}}
+	query := `{"query":{"term":{"state.site_id":"plant-7"}},"aggs":{"sites":{"terms":{"field":"state.site_id"}}}}`
+
+	w := serveSearch(newSearchHandler(searcher), http.MethodPost, "/api/v1/search/sensor_state", query)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, query, gotQuery)
+}
+
+func TestHandleSearch_Errors(t *testing.T) {
+	answer := func(status int, body string, err error) Searcher {
+		return &mockSearcher{SearchFn: func(ctx context.Context, projType string, query []byte) (int, []byte, error) {
+			return status, []byte(body), err
+		}}
+	}
+	tests := []struct {
+		name       string
+		searcher   Searcher
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "disabled", method: http.MethodGet, target: "/api/v1/search/sensor_state",
+			wantStatus: http.StatusNotFound, wantError: "search is not enabled"},
+		{name: "unknown type", searcher: answer(200, `{}`, nil), method: http.MethodGet, target: "/api/v1/search/nope",
+			wantStatus: http.StatusBadRequest},
+		{name: "limit out of range", searcher: answer(200, `{}`, nil), method: http.MethodGet, target: "/api/v1/search/sensor_state?limit=500",
+			wantStatus: http.StatusBadRequest},
+		{name: "rejected query", method: http.MethodPost, target: "/api/v1/search/sensor_state", body: `{"query":{"bogus":{}}}`,
+			searcher:   answer(400, `{"error":{"type":"parsing_exception","reason":"unknown query [bogus]"},"status":400}`, nil),
+			wantStatus: http.StatusBadRequest, wantError: "invalid search query: parsing_exception: unknown query [bogus]"},
+		{name: "no index yet", searcher: answer(404, `{"error":{"type":"index_not_found_exception"}}`, nil), method: http.MethodGet, target: "/api/v1/search/user_session",
+			wantStatus: http.StatusNotFound, wantError: "no search index for projection type: user_session"},
+		{name: "backend failure", searcher: answer(503, `{}`, nil), method: http.MethodGet, target: "/api/v1/search/sensor_state",
+			wantStatus: http.StatusBadGateway, wantError: "search index unavailable"},
+		{name: "backend unreachable", searcher: answer(0, "", errors.New("connection refused")), method: http.MethodGet, target: "/api/v1/search/sensor_state",
+			wantStatus: http.StatusBadGateway, wantError: "search index unavailable"},
+		{name: "timeout", searcher: answer(0, "", context.DeadlineExceeded), method: http.MethodGet, target: "/api/v1/search/sensor_state",
+			wantStatus: http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveSearch(newSearchHandler(tt.searcher), tt.method, tt.target, tt.body)
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantError != "" {
+				assert.Contains(t, w.Body.String(), tt.wantError)
+			}
+		})
+	}
+}
+
+func TestHandleSearch_Scopes(t *testing.T) {
+	policy, err := auth.ParsePolicy("sensors=read:sensor_state")
+	require.NoError(t, err)
+	handler := newSearchHandler(&mockSearcher{SearchFn: func(ctx context.Context, projType string, query []byte) (int, []byte, error) {
+		return http.StatusOK, []byte(`{}`), nil
+	}})
+	handler.SetPolicy(policy)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	for target, want := range map[string]int{
+		"/api/v1/search/sensor_state": http.StatusOK,
+		"/api/v1/search/user_session": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(auth.APIKeyHeader, "sensors")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code, target)
+	}
+}
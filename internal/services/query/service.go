@@ -2,20 +2,60 @@ package query
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
 )
 
+// DeletedError is returned by GetProjection for a projection that was
+// deleted, by a tombstone event such as sensor.decommissioned or a
+// "<domain>.deleted" event, or by retention.
+type DeletedError struct {
+	// Projection is the deleted projection; DeletedAt is always set.
+	Projection *Projection
+}
+
+func (e *DeletedError) Error() string {
+	return fmt.Sprintf("projection %s/%s deleted at %s", e.Projection.ProjectionType, e.Projection.AggregateID, e.Projection.DeletedAt)
+}
+
 // Valid projection types
 var validProjectionTypes = map[string]bool{
 	"sensor_state": true,
 	"user_session": true,
 }
 
+// Fallback configures serving a projection from event history when its row is missing.
+// Current handlers are last-write-wins, so folding an aggregate's events reduces to
+// taking the newest matching event.
+type Fallback struct {
+	// Events reads the aggregate's event history.
+	Events EventReader
+	// Types lists the projection types the fallback is enabled for.
+	Types map[string]bool
+	// Healer, if set, writes the folded projection back so later reads hit the table.
+	Healer ProjectionHealer
+}
+
 // Service handles query business logic.
 type Service struct {
-	store  ProjectionReader
-	logger *slog.Logger
+	store    ProjectionReader
+	fallback *Fallback
+	eventLog EventLog // nil disables ListEvents
+	eventCfg EventLogConfig
+	registry registry.Reader // nil disables WithRegistry and ListRegistered
+	searcher Searcher        // nil disables Search
+	timeouts timeout.Policy  // zero sets no deadlines
+	logger   *slog.Logger
 }
 
 // NewService creates a new query service.
@@ -26,13 +66,48 @@ func NewService(store ProjectionReader, logger *slog.Logger) *Service {
 	}
 }
 
-// GetProjection retrieves a projection by type and aggregate ID.
+// SetTimeouts bounds each operation's store reads. Operations are named
+// get, batch_get, aggregate, list (also registry-filtered lists), search,
+// deleted, anomalous, events (each read of a long poll), stream, export
+// (each page read) and fulltext (Search). Must be called before serving
+// requests.
+func (s *Service) SetTimeouts(p timeout.Policy) {
+	s.timeouts = p
+}
+
+// SetSearcher enables Search. Must be called before serving requests.
+func (s *Service) SetSearcher(searcher Searcher) {
+	s.searcher = searcher
+}
+
+// SetRegistry enables WithRegistry and ListRegistered. Must be called before
+// serving requests.
+func (s *Service) SetRegistry(reader registry.Reader) {
+	s.registry = reader
+}
+
+// GetProjection retrieves a projection by type and aggregate ID. A deleted
+// projection fails with a *DeletedError.
 func (s *Service) GetProjection(ctx context.Context, projectionType, aggregateID string) (*Projection, error) {
-	if !validProjectionTypes[projectionType] {
+	if !validProjectionTypes[projections.BaseType(projectionType)] {
 		return nil, fmt.Errorf("invalid projection type: %s", projectionType)
 	}
+	ctx, cancel := s.timeouts.Context(ctx, "get")
+	defer cancel()
 
 	storeProjection, err := s.store.GetProjection(ctx, projectionType, aggregateID)
+	if err != nil && isNotFound(err) {
+		if deleted, derr := s.store.GetDeletedProjection(ctx, projectionType, aggregateID); derr == nil {
+			return nil, &DeletedError{Projection: fromStoreProjection(deleted)}
+		}
+	}
+	if err != nil && isNotFound(err) && s.fallbackEnabled(projectionType) {
+		storeProjection, err = s.foldFromEvents(ctx, projectionType, aggregateID)
+	}
+	var deletedErr *DeletedError
+	if errors.As(err, &deletedErr) {
+		return nil, err
+	}
 	if err != nil {
 		s.logger.Error("failed to get projection",
 			"projection_type", projectionType,
@@ -45,22 +120,99 @@ func (s *Service) GetProjection(ctx context.Context, projectionType, aggregateID
 	return fromStoreProjection(storeProjection), nil
 }
 
-// ListProjections retrieves projections by type with pagination.
-func (s *Service) ListProjections(ctx context.Context, projectionType string, limit, offset int) (*ProjectionList, error) {
-	if !validProjectionTypes[projectionType] {
+// MaxBatchGetIDs is the most aggregate IDs a single batch get may request.
+const MaxBatchGetIDs = 500
+
+// BatchGetProjections retrieves the projections of one type for up to
+// MaxBatchGetIDs aggregate IDs in a single store read. Duplicate IDs are
+// collapsed. The event-history fallback is not applied; IDs without a
+// projection are reported in Missing.
+func (s *Service) BatchGetProjections(ctx context.Context, projectionType string, aggregateIDs []string) (*ProjectionBatch, error) {
+	if !validProjectionTypes[projections.BaseType(projectionType)] {
 		return nil, fmt.Errorf("invalid projection type: %s", projectionType)
 	}
+	if len(aggregateIDs) == 0 || len(aggregateIDs) > MaxBatchGetIDs {
+		return nil, fmt.Errorf("invalid batch size %d: expected 1 to %d aggregate IDs", len(aggregateIDs), MaxBatchGetIDs)
+	}
 
-	// Apply defaults and limits
-	if limit <= 0 {
-		limit = 20
+	ids := make([]string, 0, len(aggregateIDs))
+	seen := make(map[string]bool, len(aggregateIDs))
+	for _, id := range aggregateIDs {
+		if id == "" {
+			return nil, fmt.Errorf("invalid aggregate ID: empty")
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
 	}
-	if limit > 100 {
-		limit = 100
+
+	ctx, cancel := s.timeouts.Context(ctx, "batch_get")
+	defer cancel()
+	storeProjections, err := s.store.GetProjections(ctx, projectionType, ids)
+	if err != nil {
+		s.logger.Error("failed to batch get projections",
+			"projection_type", projectionType,
+			"count", len(ids),
+			"error", err,
+		)
+		return nil, err
 	}
-	if offset < 0 {
-		offset = 0
+
+	byID := make(map[string]*projections.Projection, len(storeProjections))
+	for i := range storeProjections {
+		byID[storeProjections[i].AggregateID] = &storeProjections[i]
+	}
+
+	batch := &ProjectionBatch{Projections: []Projection{}, Missing: []string{}}
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			batch.Projections = append(batch.Projections, *fromStoreProjection(p))
+		} else {
+			batch.Missing = append(batch.Missing, id)
+		}
+	}
+	return batch, nil
+}
+
+// GetAggregateProjections retrieves every projection of one aggregate across
+// projection types: real ones, or those built from test traffic when test is
+// set. A non-empty aggregateType keeps only projections of aggregates of that
+// type. Types not served by the query API are left out. An aggregate with no
+// projections yields an empty list, not an error.
+func (s *Service) GetAggregateProjections(ctx context.Context, aggregateID string, test bool, aggregateType string) (*AggregateProjections, error) {
+	ctx, cancel := s.timeouts.Context(ctx, "aggregate")
+	defer cancel()
+	storeProjections, err := s.store.GetAggregateProjections(ctx, aggregateID, aggregateType)
+	if err != nil {
+		s.logger.Error("failed to get aggregate projections",
+			"aggregate_id", aggregateID,
+			"aggregate_type", aggregateType,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	result := &AggregateProjections{AggregateID: aggregateID, Projections: []Projection{}}
+	for i := range storeProjections {
+		p := &storeProjections[i]
+		if projections.IsTestType(p.ProjectionType) != test || !validProjectionTypes[projections.BaseType(p.ProjectionType)] {
+			continue
+		}
+		result.Projections = append(result.Projections, *fromStoreProjection(p))
 	}
+	return result, nil
+}
+
+// ListProjections retrieves projections by type with pagination.
+func (s *Service) ListProjections(ctx context.Context, projectionType string, limit, offset int) (*ProjectionList, error) {
+	if !validProjectionTypes[projections.BaseType(projectionType)] {
+		return nil, fmt.Errorf("invalid projection type: %s", projectionType)
+	}
+
+	limit, offset = normalizePage(limit, offset)
+	ctx, cancel := s.timeouts.Context(ctx, "list")
+	defer cancel()
 
 	storeProjections, total, err := s.store.ListProjections(ctx, projectionType, limit, offset)
 	if err != nil {
@@ -81,6 +233,510 @@ func (s *Service) ListProjections(ctx context.Context, projectionType string, li
 	}, nil
 }
 
+// MaxSearchLength is the longest aggregate ID search term accepted, matching
+// the aggregate_id column width.
+const MaxSearchLength = 255
+
+// SearchProjections retrieves projections by type whose aggregate ID contains
+// q (case-insensitive), with pagination.
+func (s *Service) SearchProjections(ctx context.Context, projectionType, q string, limit, offset int) (*ProjectionList, error) {
+	if !validProjectionTypes[projections.BaseType(projectionType)] {
+		return nil, fmt.Errorf("invalid projection type: %s", projectionType)
+	}
+	if q == "" || len(q) > MaxSearchLength {
+		return nil, fmt.Errorf("invalid search term: expected 1 to %d characters", MaxSearchLength)
+	}
+
+	limit, offset = normalizePage(limit, offset)
+	ctx, cancel := s.timeouts.Context(ctx, "search")
+	defer cancel()
+
+	storeProjections, total, err := s.store.SearchProjections(ctx, projectionType, q, limit, offset)
+	if err != nil {
+		s.logger.Error("failed to search projections",
+			"projection_type", projectionType,
+			"q", q,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return &ProjectionList{
+		Projections: fromStoreProjections(storeProjections),
+		Total:       total,
+		Limit:       limit,
+		Offset:      offset,
+	}, nil
+}
+
+// ListDeleted retrieves deleted projections by type with pagination.
+func (s *Service) ListDeleted(ctx context.Context, projectionType string, limit, offset int) (*ProjectionList, error) {
+	if !validProjectionTypes[projections.BaseType(projectionType)] {
+		return nil, fmt.Errorf("invalid projection type: %s", projectionType)
+	}
+
+	limit, offset = normalizePage(limit, offset)
+	ctx, cancel := s.timeouts.Context(ctx, "deleted")
+	defer cancel()
+
+	storeProjections, total, err := s.store.ListDeleted(ctx, projectionType, limit, offset)
+	if err != nil {
+		s.logger.Error("failed to list deleted projections",
+			"projection_type", projectionType,
+			"limit", limit,
+			"offset", offset,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return &ProjectionList{
+		Projections: fromStoreProjections(storeProjections),
+		Total:       total,
+		Limit:       limit,
+		Offset:      offset,
+	}, nil
+}
+
+// ListAnomalous retrieves projections by type that have any of the given
+// anomaly flags set, with pagination. Empty flags means any anomaly.
+func (s *Service) ListAnomalous(ctx context.Context, projectionType string, flags []string, limit, offset int) (*ProjectionList, error) {
+	if !validProjectionTypes[projections.BaseType(projectionType)] {
+		return nil, fmt.Errorf("invalid projection type: %s", projectionType)
+	}
+	if len(flags) == 0 {
+		flags = projections.AnomalyFlags
+	}
+	for _, flag := range flags {
+		if !projections.IsAnomalyFlag(flag) {
+			return nil, fmt.Errorf("invalid anomaly flag: %s", flag)
+		}
+	}
+
+	limit, offset = normalizePage(limit, offset)
+	ctx, cancel := s.timeouts.Context(ctx, "anomalous")
+	defer cancel()
+
+	storeProjections, total, err := s.store.ListAnomalous(ctx, projectionType, flags, limit, offset)
+	if err != nil {
+		s.logger.Error("failed to list anomalous projections",
+			"projection_type", projectionType,
+			"flags", flags,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return &ProjectionList{
+		Projections: fromStoreProjections(storeProjections),
+		Total:       total,
+		Limit:       limit,
+		Offset:      offset,
+	}, nil
+}
+
+// ListRegistered retrieves projections by type whose aggregate's registry
+// entry matches filter, with pagination. The filter is applied in the store
+// (a join against the registry), so totals and pages are exact.
+func (s *Service) ListRegistered(ctx context.Context, projectionType string, filter registry.Filter, limit, offset int) (*ProjectionList, error) {
+	if s.registry == nil {
+		return nil, ErrRegistryDisabled
+	}
+	if !validProjectionTypes[projections.BaseType(projectionType)] {
+		return nil, fmt.Errorf("invalid projection type: %s", projectionType)
+	}
+	if err := events.ValidateAggregateType(filter.AggregateType); err != nil {
+		return nil, err
+	}
+
+	limit, offset = normalizePage(limit, offset)
+	ctx, cancel := s.timeouts.Context(ctx, "list")
+	defer cancel()
+
+	storeProjections, total, err := s.store.ListRegistered(ctx, projectionType, filter, limit, offset)
+	if err != nil {
+		s.logger.Error("failed to list registered projections",
+			"projection_type", projectionType,
+			"filter", filter,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	return &ProjectionList{
+		Projections: fromStoreProjections(storeProjections),
+		Total:       total,
+		Limit:       limit,
+		Offset:      offset,
+	}, nil
+}
+
+// ErrRegistryDisabled is returned by WithRegistry and ListRegistered when no
+// aggregate registry is configured.
+var ErrRegistryDisabled = errors.New("aggregate registry is not available")
+
+// WithRegistry returns a context whose projection reads also return each
+// aggregate's registry entry, joined in the store's query. Aggregates without
+// an entry keep a nil Registry.
+func (s *Service) WithRegistry(ctx context.Context) (context.Context, error) {
+	if s.registry == nil {
+		return nil, ErrRegistryDisabled
+	}
+	return projections.WithRegistry(ctx), nil
+}
+
+// normalizePage applies pagination defaults and limits.
+func normalizePage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// EventLogConfig configures long polling in ListEvents.
+type EventLogConfig struct {
+	PollInterval time.Duration // how often a waiting request re-reads the event log
+	MaxWait      time.Duration // longest wait a request may ask for
+}
+
+// SetEventLog enables catch-up reads of the event store (ListEvents).
+// Pass nil to disable. Zero config fields use 500ms and 30s.
+func (s *Service) SetEventLog(log EventLog, cfg EventLogConfig) {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 500 * time.Millisecond
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = 30 * time.Second
+	}
+	s.eventLog = log
+	s.eventCfg = cfg
+}
+
+// EventWaitLimit returns the longest wait ListEvents honors.
+func (s *Service) EventWaitLimit() time.Duration {
+	return s.eventCfg.MaxWait
+}
+
+// ErrSearchDisabled is returned by Search when no search index is configured.
+var ErrSearchDisabled = errors.New("search is not enabled")
+
+// SearchResult is the search index's answer to a query.
+type SearchResult struct {
+	Status int // the backend's HTTP status
+	Body   json.RawMessage
+}
+
+// Search runs query, a search request body, against the search index of
+// projectionType (see Searcher). A backend answering at all is a result,
+// whatever its status; an error means it could not be reached in time.
+func (s *Service) Search(ctx context.Context, projectionType string, query []byte) (*SearchResult, error) {
+	if s.searcher == nil {
+		return nil, ErrSearchDisabled
+	}
+	if !validProjectionTypes[projectionType] {
+		return nil, fmt.Errorf("invalid projection type: %s", projectionType)
+	}
+
+	ctx, cancel := s.timeouts.Context(ctx, "fulltext")
+	defer cancel()
+
+	status, body, err := s.searcher.Search(ctx, projectionType, query)
+	if err != nil {
+		s.logger.Error("failed to search index",
+			"projection_type", projectionType,
+			"error", err,
+		)
+		return nil, err
+	}
+	return &SearchResult{Status: status, Body: body}, nil
+}
+
+// ErrEventLogDisabled is returned by ListEvents when no event log is configured.
+var ErrEventLogDisabled = errors.New("event log is not available")
+
+// ListEvents returns events after the given global sequence number, oldest
+// first, optionally filtered by event type patterns and aggregate type. If none
+// are available and wait is positive, it polls the event log until one arrives
+// or wait (capped at the configured MaxWait) elapses, then returns an empty
+// page.
+func (s *Service) ListEvents(ctx context.Context, afterSeq int64, types []string, aggregateType string, limit int, wait time.Duration) (*EventList, error) {
+	if s.eventLog == nil {
+		return nil, ErrEventLogDisabled
+	}
+	if afterSeq < 0 {
+		afterSeq = 0
+	}
+	limit, _ = normalizePage(limit, 0)
+	wait = min(wait, s.eventCfg.MaxWait)
+
+	var timeout <-chan time.Time
+	if wait > 0 {
+		timer := clock.NewTimer(wait)
+		defer timer.Stop()
+		timeout = timer.C()
+	}
+
+	for {
+		fetchCtx, cancel := s.timeouts.Context(ctx, "events")
+		found, err := s.fetchEvents(fetchCtx, afterSeq, types, aggregateType, limit)
+		cancel()
+		if err != nil {
+			s.logger.Error("failed to list events",
+				"after_seq", afterSeq,
+				"types", types,
+				"aggregate_type", aggregateType,
+				"limit", limit,
+				"error", err,
+			)
+			return nil, err
+		}
+		if len(found) > 0 {
+			return &EventList{Events: found, NextSeq: found[len(found)-1].GlobalSeq}, nil
+		}
+		if timeout == nil {
+			return &EventList{Events: []*events.Envelope{}, NextSeq: afterSeq}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			timeout = nil // one last read, then return
+		case <-clock.After(s.eventCfg.PollInterval):
+		}
+	}
+}
+
+// fetchEvents reads one page of the event log, of one aggregate type when
+// aggregateType is set.
+func (s *Service) fetchEvents(ctx context.Context, afterSeq int64, types []string, aggregateType string, limit int) ([]*events.Envelope, error) {
+	if aggregateType != "" {
+		return s.eventLog.FetchAfterOfAggregateType(ctx, afterSeq, aggregateType, types, limit)
+	}
+	return s.eventLog.FetchAfter(ctx, afterSeq, types, limit)
+}
+
+// GetAggregateStream returns an aggregate's events in aggregate_seq order,
+// from fromSeq (default 1) through toSeq (0 means the latest).
+func (s *Service) GetAggregateStream(ctx context.Context, aggregateID string, fromSeq, toSeq int64, limit int) (*AggregateStream, error) {
+	if s.eventLog == nil {
+		return nil, ErrEventLogDisabled
+	}
+	if fromSeq < 1 {
+		fromSeq = 1
+	}
+	limit, _ = normalizePage(limit, 0)
+	ctx, cancel := s.timeouts.Context(ctx, "stream")
+	defer cancel()
+
+	found, err := s.eventLog.FetchAggregate(ctx, aggregateID, fromSeq, toSeq, limit)
+	if err != nil {
+		s.logger.Error("failed to read aggregate stream",
+			"aggregate_id", aggregateID,
+			"from_seq", fromSeq,
+			"to_seq", toSeq,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	stream := &AggregateStream{AggregateID: aggregateID, Events: found, NextSeq: fromSeq}
+	if len(found) > 0 {
+		stream.NextSeq = found[len(found)-1].AggregateSeq + 1
+	} else {
+		stream.Events = []*events.Envelope{}
+	}
+	return stream, nil
+}
+
+// ExportPageSize is the number of rows an export reads per store query.
+const ExportPageSize = 500
+
+// ExportProjections passes every live projection of one type to fn, a page
+// at a time in aggregate ID order, until fn returns an error. Pages are read
+// by keyset (the last aggregate ID seen), so a long export neither slows down
+// with depth nor holds a transaction open; projections written while it runs
+// are included if their aggregate ID sorts after the cursor.
+func (s *Service) ExportProjections(ctx context.Context, projectionType string, fn func([]Projection) error) error {
+	if !validProjectionTypes[projections.BaseType(projectionType)] {
+		return fmt.Errorf("invalid projection type: %s", projectionType)
+	}
+
+	var after string
+	for {
+		pageCtx, cancel := s.timeouts.Context(ctx, "export")
+		page, err := s.store.ScanProjections(pageCtx, projectionType, after, ExportPageSize)
+		cancel()
+		if err != nil {
+			s.logger.Error("failed to export projections",
+				"projection_type", projectionType,
+				"after_aggregate_id", after,
+				"error", err,
+			)
+			return err
+		}
+		if len(page) == 0 {
+			return nil
+		}
+		after = page[len(page)-1].AggregateID
+
+		live := make([]Projection, 0, len(page))
+		for i := range page {
+			if page[i].DeletedAt == nil {
+				live = append(live, *fromStoreProjection(&page[i]))
+			}
+		}
+		if len(live) > 0 {
+			if err := fn(live); err != nil {
+				return err
+			}
+		}
+		if len(page) < ExportPageSize {
+			return nil
+		}
+	}
+}
+
+// ExportEvents passes the events with global_seq in (afterSeq, untilSeq] to
+// fn, a page at a time in global_seq order, until fn returns an error. An
+// untilSeq of 0 exports up to the newest event when the export reaches it.
+// types and aggregateType filter as for ListEvents.
+func (s *Service) ExportEvents(ctx context.Context, afterSeq, untilSeq int64, types []string, aggregateType string, fn func([]*events.Envelope) error) error {
+	if s.eventLog == nil {
+		return ErrEventLogDisabled
+	}
+	if afterSeq < 0 {
+		afterSeq = 0
+	}
+
+	for untilSeq == 0 || afterSeq < untilSeq {
+		pageCtx, cancel := s.timeouts.Context(ctx, "export")
+		page, err := s.fetchEvents(pageCtx, afterSeq, types, aggregateType, ExportPageSize)
+		cancel()
+		if err != nil {
+			s.logger.Error("failed to export events",
+				"after_seq", afterSeq,
+				"until_seq", untilSeq,
+				"types", types,
+				"aggregate_type", aggregateType,
+				"error", err,
+			)
+			return err
+		}
+		if len(page) == 0 {
+			return nil
+		}
+		afterSeq = page[len(page)-1].GlobalSeq
+
+		full := len(page) == ExportPageSize
+		if untilSeq > 0 {
+			for len(page) > 0 && page[len(page)-1].GlobalSeq > untilSeq {
+				page = page[:len(page)-1]
+			}
+		}
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+		if !full {
+			return nil
+		}
+	}
+	return nil
+}
+
+// IsValidEventTypePattern checks an event type filter: an exact event type
+// (sensor.reading) or a prefix ending in "*" (sensor.*). "*" may appear only
+// at the end.
+func IsValidEventTypePattern(pattern string) bool {
+	prefix, _ := strings.CutSuffix(pattern, "*")
+	return pattern != "" && !strings.Contains(prefix, "*")
+}
+
+// SetFallback enables serving missing projections from event history.
+// Pass nil to disable.
+func (s *Service) SetFallback(fb *Fallback) {
+	s.fallback = fb
+}
+
+// fallbackEnabled is false for test-namespace types: event_latest does not
+// separate test from real traffic. It is also false for types that fold
+// every event (see projections.RegisterMerge), whose state is not the newest event's.
+func (s *Service) fallbackEnabled(projectionType string) bool {
+	return s.fallback != nil && s.fallback.Events != nil && s.fallback.Types[projectionType] &&
+		projections.MergeFor(projectionType) == nil
+}
+
+// foldFromEvents builds a projection on demand from the aggregate's newest event,
+// optionally healing the projection row. An aggregate whose newest event is a
+// tombstone is reported deleted, as of that event.
+func (s *Service) foldFromEvents(ctx context.Context, projectionType, aggregateID string) (*projections.Projection, error) {
+	event, err := s.fallback.Events.GetLatest(ctx, projections.Sources[projectionType], aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	if projections.IsTombstone(event.EventType) {
+		deletedAt := event.EventTime
+		return nil, &DeletedError{Projection: fromStoreProjection(&projections.Projection{
+			ProjectionType:     projectionType,
+			AggregateID:        aggregateID,
+			LastEventID:        event.EventID,
+			LastEventTimestamp: event.EventTime,
+			UpdatedAt:          event.EventTime,
+			DeletedAt:          &deletedAt,
+		})}
+	}
+
+	s.logger.Info("serving projection from event history",
+		"projection_type", projectionType,
+		"aggregate_id", aggregateID,
+		"event_id", event.EventID,
+	)
+
+	if s.fallback.Healer != nil {
+		if err := s.fallback.Healer.WriteProjection(ctx, projectionType, aggregateID, event.Payload, event); err != nil {
+			// Healing is best-effort; the folded response is still valid
+			s.logger.Warn("failed to heal projection",
+				"projection_type", projectionType,
+				"aggregate_id", aggregateID,
+				"error", err,
+			)
+		}
+	}
+
+	folded := &projections.Projection{
+		ProjectionType:     projectionType,
+		AggregateID:        aggregateID,
+		State:              event.Payload,
+		LastEventID:        event.EventID,
+		LastEventTimestamp: event.EventTime,
+		UpdatedAt:          clock.Now(),
+	}
+	if projections.RegistryRequested(ctx) {
+		// There is no projection row to join the entry to
+		entries, err := s.registry.GetAggregates(ctx, []string{aggregateID})
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			folded.Registry = &entries[0]
+		}
+	}
+	return folded, nil
+}
+
+// isNotFound reports whether a store error means the row does not exist.
+func isNotFound(err error) bool {
+	return strings.Contains(err.Error(), "no rows")
+}
+
 // IsValidProjectionType checks if a projection type is valid.
 func IsValidProjectionType(projectionType string) bool {
 	return validProjectionTypes[projectionType]
@@ -13,7 +13,10 @@ import (
 
 	"github.com/gofrs/uuid/v5"
 
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+	"github.com/cornjacket/platform-services/internal/shared/timeout"
 )
 
 func TestGetProjection_Success(t *testing.T) {
@@ -138,3 +141,569 @@ func TestListProjections_InvalidType(t *testing.T) {
 	_, err := service.ListProjections(context.Background(), "invalid_type", 20, 0)
 	assert.Error(t, err)
 }
+
+func newFallbackEvent() *events.Envelope {
+	return &events.Envelope{
+		EventID:     uuid.Must(uuid.NewV7()),
+		EventType:   "sensor.reading",
+		AggregateID: "device-001",
+		EventTime:   time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC),
+		Payload:     json.RawMessage(`{"temperature": 70.1}`),
+	}
+}
+
+func TestGetProjection_FallbackFromEvents(t *testing.T) {
+	store := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return nil, fmt.Errorf("no rows in result set")
+		},
+	}
+	event := newFallbackEvent()
+	var capturedPrefix string
+	var healed bool
+
+	service := NewService(store, slog.Default())
+	service.SetFallback(&Fallback{
+		Events: &mockEventReader{
+			GetLatestFn: func(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+				capturedPrefix = eventTypePrefix
+				return event, nil
+			},
+		},
+		Types: map[string]bool{"sensor_state": true},
+		Healer: &mockProjectionHealer{
+			WriteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, e *events.Envelope) error {
+				healed = true
+				return nil
+			},
+		},
+	})
+
+	result, err := service.GetProjection(context.Background(), "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, "sensor.", capturedPrefix)
+	assert.Equal(t, event.EventID, result.LastEventID)
+	assert.JSONEq(t, `{"temperature": 70.1}`, string(result.State))
+	assert.True(t, healed, "projection should be healed")
+}
+
+func TestGetProjection_FallbackDisabledForType(t *testing.T) {
+	store := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return nil, fmt.Errorf("no rows in result set")
+		},
+	}
+
+	service := NewService(store, slog.Default())
+	service.SetFallback(&Fallback{
+		Events: &mockEventReader{
+			GetLatestFn: func(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+				t.Fatal("event reader should not be called for a type without fallback")
+				return nil, nil
+			},
+		},
+		Types: map[string]bool{"sensor_state": true},
+	})
+
+	_, err := service.GetProjection(context.Background(), "user_session", "user-001")
+	assert.ErrorContains(t, err, "no rows")
+}
+
+func TestGetProjection_FallbackNoEvents(t *testing.T) {
+	store := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return nil, fmt.Errorf("no rows in result set")
+		},
+	}
+
+	service := NewService(store, slog.Default())
+	service.SetFallback(&Fallback{
+		Events: &mockEventReader{
+			GetLatestFn: func(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+				return nil, fmt.Errorf("failed to get latest event: no rows in result set")
+			},
+		},
+		Types: map[string]bool{"sensor_state": true},
+	})
+
+	// Still a not-found error, so the handler maps it to 404
+	_, err := service.GetProjection(context.Background(), "sensor_state", "device-001")
+	assert.ErrorContains(t, err, "no rows")
+}
+
+func TestGetProjection_FallbackTombstone(t *testing.T) {
+	store := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return nil, fmt.Errorf("no rows in result set")
+		},
+	}
+	event := newFallbackEvent()
+	event.EventType = "sensor.decommissioned"
+
+	service := NewService(store, slog.Default())
+	service.SetFallback(&Fallback{
+		Events: &mockEventReader{
+			GetLatestFn: func(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+				return event, nil
+			},
+		},
+		Types: map[string]bool{"sensor_state": true},
+		Healer: &mockProjectionHealer{
+			WriteProjectionFn: func(ctx context.Context, projType, aggregateID string, state []byte, e *events.Envelope) error {
+				t.Fatal("a deleted projection must not be healed")
+				return nil
+			},
+		},
+	})
+
+	// A decommissioned device is reported deleted rather than being rebuilt
+	_, err := service.GetProjection(context.Background(), "sensor_state", "device-001")
+	var deleted *DeletedError
+	require.ErrorAs(t, err, &deleted)
+	assert.Equal(t, event.EventID, deleted.Projection.LastEventID)
+	assert.Equal(t, "2026-02-09T12:00:00.000Z", deleted.Projection.DeletedAt)
+}
+
+func TestGetProjection_Deleted(t *testing.T) {
+	deletedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := newTestProjection()
+	p.DeletedAt = &deletedAt
+	store := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return nil, fmt.Errorf("no rows in result set")
+		},
+		GetDeletedProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			return p, nil
+		},
+	}
+
+	_, err := NewService(store, slog.Default()).GetProjection(context.Background(), "sensor_state", "device-001")
+	var deleted *DeletedError
+	require.ErrorAs(t, err, &deleted)
+	assert.Equal(t, "2026-01-02T03:04:05.000Z", deleted.Projection.DeletedAt)
+	assert.Equal(t, p.LastEventID, deleted.Projection.LastEventID)
+	assert.NotContains(t, err.Error(), "no rows", "a deleted projection is not a missing one")
+}
+
+func TestListDeleted_Success(t *testing.T) {
+	deletedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := *newTestProjection()
+	p.DeletedAt = &deletedAt
+	var capturedType string
+	store := &mockProjectionReader{
+		ListDeletedFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			capturedType = projType
+			return []projections.Projection{p}, 1, nil
+		},
+	}
+
+	result, err := NewService(store, slog.Default()).ListDeleted(context.Background(), "sensor_state", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "sensor_state", capturedType)
+	assert.Equal(t, 1, result.Total)
+	assert.Equal(t, "2026-01-02T03:04:05.000Z", result.Projections[0].DeletedAt)
+}
+
+func TestBatchGetProjections_FoundAndMissing(t *testing.T) {
+	var gotIDs []string
+	store := &mockProjectionReader{
+		GetProjectionsFn: func(ctx context.Context, projType string, aggregateIDs []string) ([]projections.Projection, error) {
+			gotIDs = aggregateIDs
+			a, c := *newTestProjection(), *newTestProjection()
+			a.AggregateID, c.AggregateID = "a", "c"
+			return []projections.Projection{c, a}, nil // store order is arbitrary
+		},
+	}
+
+	batch, err := NewService(store, slog.Default()).BatchGetProjections(context.Background(), "sensor_state",
+		[]string{"a", "b", "c", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, gotIDs, "duplicates are collapsed")
+	require.Len(t, batch.Projections, 2)
+	assert.Equal(t, "a", batch.Projections[0].AggregateID, "results follow request order")
+	assert.Equal(t, "c", batch.Projections[1].AggregateID)
+	assert.Equal(t, []string{"b"}, batch.Missing)
+}
+
+func TestBatchGetProjections_Invalid(t *testing.T) {
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	ctx := context.Background()
+
+	_, err := service.BatchGetProjections(ctx, "widget", []string{"a"})
+	assert.Error(t, err)
+	_, err = service.BatchGetProjections(ctx, "sensor_state", nil)
+	assert.Error(t, err)
+	_, err = service.BatchGetProjections(ctx, "sensor_state", make([]string, MaxBatchGetIDs+1))
+	assert.Error(t, err)
+	_, err = service.BatchGetProjections(ctx, "sensor_state", []string{"a", ""})
+	assert.Error(t, err)
+}
+
+func TestGetAggregateProjections_Namespaces(t *testing.T) {
+	store := &mockProjectionReader{
+		GetAggregateProjectionsFn: func(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error) {
+			var ps []projections.Projection
+			for _, projType := range []string{"sensor_state", "test.sensor_state", "legacy_state", "user_session"} {
+				p := *newTestProjection()
+				p.ProjectionType = projType
+				ps = append(ps, p)
+			}
+			return ps, nil
+		},
+	}
+	service := NewService(store, slog.Default())
+
+	result, err := service.GetAggregateProjections(context.Background(), "device-001", false, "")
+	require.NoError(t, err)
+	var types []string
+	for _, p := range result.Projections {
+		types = append(types, p.ProjectionType)
+	}
+	assert.Equal(t, []string{"sensor_state", "user_session"}, types, "test namespace and unknown types are left out")
+
+	result, err = service.GetAggregateProjections(context.Background(), "device-001", true, "")
+	require.NoError(t, err)
+	require.Len(t, result.Projections, 1)
+	assert.Equal(t, "test.sensor_state", result.Projections[0].ProjectionType)
+}
+
+func TestGetAggregateProjections_AggregateType(t *testing.T) {
+	var gotType string
+	store := &mockProjectionReader{
+		GetAggregateProjectionsFn: func(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error) {
+			gotType = aggregateType
+			p := *newTestProjection()
+			p.AggregateType = aggregateType
+			return []projections.Projection{p}, nil
+		},
+	}
+
+	result, err := NewService(store, slog.Default()).GetAggregateProjections(context.Background(), "device-001", false, "device")
+	require.NoError(t, err)
+	assert.Equal(t, "device", gotType)
+	require.Len(t, result.Projections, 1)
+	assert.Equal(t, "device", result.Projections[0].AggregateType)
+}
+
+func TestGetAggregateProjections_None(t *testing.T) {
+	store := &mockProjectionReader{
+		GetAggregateProjectionsFn: func(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error) {
+			return []projections.Projection{}, nil
+		},
+	}
+
+	result, err := NewService(store, slog.Default()).GetAggregateProjections(context.Background(), "nobody", false, "")
+	require.NoError(t, err)
+	assert.NotNil(t, result.Projections)
+	assert.Empty(t, result.Projections)
+}
+
+func TestSearchProjections_Invalid(t *testing.T) {
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	ctx := context.Background()
+
+	_, err := service.SearchProjections(ctx, "widget", "dev", 10, 0)
+	assert.Error(t, err)
+	_, err = service.SearchProjections(ctx, "sensor_state", "", 10, 0)
+	assert.Error(t, err)
+}
+
+func TestListEvents_Success(t *testing.T) {
+	var gotAfter int64
+	var gotLimit int
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			gotAfter, gotLimit = afterSeq, limit
+			return []*events.Envelope{{GlobalSeq: 11}, {GlobalSeq: 12}}, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+
+	list, err := service.ListEvents(context.Background(), 10, nil, "", 500, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), gotAfter)
+	assert.Equal(t, 100, gotLimit, "limit should be capped")
+	assert.Len(t, list.Events, 2)
+	assert.Equal(t, int64(12), list.NextSeq)
+}
+
+func TestListEvents_AggregateType(t *testing.T) {
+	var gotType string
+	var gotTypes []string
+	log := &mockEventLog{
+		FetchAfterOfAggregateTypeFn: func(ctx context.Context, afterSeq int64, aggregateType string, types []string, limit int) ([]*events.Envelope, error) {
+			gotType, gotTypes = aggregateType, types
+			return []*events.Envelope{{GlobalSeq: 3, AggregateType: aggregateType}}, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+
+	list, err := service.ListEvents(context.Background(), 0, []string{"sensor.*"}, "device", 20, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "device", gotType)
+	assert.Equal(t, []string{"sensor.*"}, gotTypes)
+	assert.Equal(t, int64(3), list.NextSeq)
+}
+
+func TestListEvents_EmptyPageKeepsCursor(t *testing.T) {
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			return nil, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+
+	list, err := service.ListEvents(context.Background(), 42, nil, "", 20, 0)
+	require.NoError(t, err)
+	assert.NotNil(t, list.Events)
+	assert.Empty(t, list.Events)
+	assert.Equal(t, int64(42), list.NextSeq)
+}
+
+func TestListEvents_Disabled(t *testing.T) {
+	service := NewService(&mockProjectionReader{}, slog.Default())
+
+	_, err := service.ListEvents(context.Background(), 0, nil, "", 20, 0)
+	assert.ErrorIs(t, err, ErrEventLogDisabled)
+}
+
+func TestListEvents_LongPollReturnsWhenEventsArrive(t *testing.T) {
+	calls := 0
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			calls++
+			if calls < 3 {
+				return nil, nil
+			}
+			return []*events.Envelope{{GlobalSeq: 5}}, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{PollInterval: time.Millisecond, MaxWait: time.Minute})
+
+	list, err := service.ListEvents(context.Background(), 4, nil, "", 20, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, int64(5), list.NextSeq)
+}
+
+func TestListEvents_LongPollTimesOut(t *testing.T) {
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			return nil, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{PollInterval: time.Millisecond, MaxWait: 20 * time.Millisecond})
+
+	start := time.Now()
+	list, err := service.ListEvents(context.Background(), 4, nil, "", 20, time.Hour)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second, "wait should be capped at MaxWait")
+	assert.Empty(t, list.Events)
+	assert.Equal(t, int64(4), list.NextSeq)
+}
+
+func TestListEvents_LongPollCancelled(t *testing.T) {
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			return nil, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{PollInterval: time.Millisecond, MaxWait: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := service.ListEvents(ctx, 0, nil, "", 20, time.Minute)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestListProjections_Timeout(t *testing.T) {
+	mock := &mockProjectionReader{
+		ListProjectionsFn: func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+			<-ctx.Done() // a query that outlasts its deadline
+			return nil, 0, ctx.Err()
+		},
+	}
+	service := NewService(mock, slog.Default())
+	service.SetTimeouts(timeout.Policy{
+		Default:    time.Minute,
+		Operations: map[string]time.Duration{"list": 10 * time.Millisecond},
+	})
+
+	_, err := service.ListProjections(context.Background(), "sensor_state", 10, 0)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestListEvents_TimeoutPerRead(t *testing.T) {
+	var deadlines []time.Duration
+	log := &mockEventLog{
+		FetchAfterFn: func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			deadlines = append(deadlines, time.Until(deadline))
+			return nil, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{PollInterval: time.Millisecond, MaxWait: 50 * time.Millisecond})
+	service.SetTimeouts(timeout.Policy{Default: time.Second})
+
+	_, err := service.ListEvents(context.Background(), 0, nil, "", 20, time.Minute)
+	require.NoError(t, err)
+
+	// Each read of the long poll gets the full timeout, not what is left of one
+	require.Greater(t, len(deadlines), 1)
+	assert.Greater(t, deadlines[len(deadlines)-1], 900*time.Millisecond)
+}
+
+func TestIsValidEventTypePattern(t *testing.T) {
+	for pattern, valid := range map[string]bool{
+		"sensor.reading": true,
+		"sensor.*":       true,
+		"*":              true,
+		"":               false,
+		"sensor.*.x":     false,
+		"*.reading":      false,
+	} {
+		assert.Equal(t, valid, IsValidEventTypePattern(pattern), pattern)
+	}
+}
+
+func TestGetAggregateStream_Success(t *testing.T) {
+	var gotFrom, gotTo int64
+	log := &mockEventLog{
+		FetchAggregateFn: func(ctx context.Context, aggregateID string, fromSeq, toSeq int64, limit int) ([]*events.Envelope, error) {
+			assert.Equal(t, "device-001", aggregateID)
+			gotFrom, gotTo = fromSeq, toSeq
+			return []*events.Envelope{{AggregateSeq: 1}, {AggregateSeq: 2}}, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+
+	stream, err := service.GetAggregateStream(context.Background(), "device-001", 0, 5, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), gotFrom, "from_seq defaults to 1")
+	assert.Equal(t, int64(5), gotTo)
+	assert.Equal(t, "device-001", stream.AggregateID)
+	assert.Len(t, stream.Events, 2)
+	assert.Equal(t, int64(3), stream.NextSeq)
+}
+
+func TestGetAggregateStream_Empty(t *testing.T) {
+	log := &mockEventLog{
+		FetchAggregateFn: func(ctx context.Context, aggregateID string, fromSeq, toSeq int64, limit int) ([]*events.Envelope, error) {
+			return nil, nil
+		},
+	}
+	service := NewService(&mockProjectionReader{}, slog.Default())
+	service.SetEventLog(log, EventLogConfig{})
+
+	stream, err := service.GetAggregateStream(context.Background(), "device-001", 7, 0, 20)
+	require.NoError(t, err)
+	assert.NotNil(t, stream.Events)
+	assert.Empty(t, stream.Events)
+	assert.Equal(t, int64(7), stream.NextSeq)
+}
+
+func TestGetAggregateStream_Disabled(t *testing.T) {
+	service := NewService(&mockProjectionReader{}, slog.Default())
+
+	_, err := service.GetAggregateStream(context.Background(), "device-001", 1, 0, 20)
+	assert.ErrorIs(t, err, ErrEventLogDisabled)
+}
+
+// failingRegistry is a registry.Reader whose reads fail.
+type failingRegistry struct{}
+
+func (failingRegistry) GetAggregate(ctx context.Context, aggregateID string) (*registry.Aggregate, error) {
+	return nil, fmt.Errorf("registry unavailable")
+}
+
+func (failingRegistry) GetAggregates(ctx context.Context, aggregateIDs []string) ([]registry.Aggregate, error) {
+	return nil, fmt.Errorf("registry unavailable")
+}
+
+func TestWithRegistry(t *testing.T) {
+	entries := registry.NewMemoryStore()
+	_, err := entries.PutAggregate(context.Background(), &registry.Aggregate{AggregateID: "device-001", Owner: "facilities"})
+	require.NoError(t, err)
+	var requested bool
+	store := &mockProjectionReader{
+		GetProjectionFn: func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+			requested = projections.RegistryRequested(ctx)
+			if aggregateID == "device-404" {
+				return nil, fmt.Errorf("no rows in result set")
+			}
+			return newTestProjection(), nil
+		},
+	}
+
+	service := NewService(store, slog.Default())
+	_, err = service.WithRegistry(context.Background())
+	assert.ErrorIs(t, err, ErrRegistryDisabled)
+
+	// The store joins the entries into its read
+	service.SetRegistry(entries)
+	ctx, err := service.WithRegistry(context.Background())
+	require.NoError(t, err)
+	_, err = service.GetProjection(ctx, "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.True(t, requested)
+	_, err = service.GetProjection(context.Background(), "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.False(t, requested)
+
+	// A projection folded from events has no row to join to
+	service.SetFallback(&Fallback{
+		Events: &mockEventReader{
+			GetLatestFn: func(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+				return newFallbackEvent(), nil
+			},
+		},
+		Types: map[string]bool{"sensor_state": true},
+	})
+	_, err = entries.PutAggregate(context.Background(), &registry.Aggregate{AggregateID: "device-404", Owner: "facilities"})
+	require.NoError(t, err)
+	result, err := service.GetProjection(ctx, "sensor_state", "device-404")
+	require.NoError(t, err)
+	require.NotNil(t, result.Registry)
+	assert.Equal(t, "facilities", result.Registry.Owner)
+
+	service.SetRegistry(failingRegistry{})
+	_, err = service.GetProjection(ctx, "sensor_state", "device-404")
+	assert.Error(t, err)
+}
+
+func TestListRegistered(t *testing.T) {
+	var gotFilter registry.Filter
+	mock := &mockProjectionReader{
+		ListRegisteredFn: func(ctx context.Context, projType string, filter registry.Filter, limit, offset int) ([]projections.Projection, int, error) {
+			gotFilter = filter
+			return []projections.Projection{*newTestProjection()}, 1, nil
+		},
+	}
+	service := NewService(mock, slog.Default())
+	filter := registry.Filter{Site: "plant-7"}
+
+	_, err := service.ListRegistered(context.Background(), "sensor_state", filter, 0, 0)
+	assert.ErrorIs(t, err, ErrRegistryDisabled)
+
+	service.SetRegistry(registry.NewMemoryStore())
+	result, err := service.ListRegistered(context.Background(), "sensor_state", filter, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, filter, gotFilter)
+	assert.Equal(t, 1, result.Total)
+	assert.Equal(t, 20, result.Limit)
+
+	_, err = service.ListRegistered(context.Background(), "bogus", filter, 0, 0)
+	assert.Error(t, err)
+	_, err = service.ListRegistered(context.Background(), "sensor_state", registry.Filter{AggregateType: "Not Valid"}, 0, 0)
+	assert.Error(t, err)
+}
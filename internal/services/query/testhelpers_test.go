@@ -2,20 +2,122 @@ package query
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
 )
 
 // mockProjectionReader implements ProjectionReader for testing.
 type mockProjectionReader struct {
 	GetProjectionFn  func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error)
+	GetProjectionsFn  func(ctx context.Context, projType string, aggregateIDs []string) ([]projections.Projection, error)
+	GetAggregateProjectionsFn func(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error)
 	ListProjectionsFn func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error)
+	ListAnomalousFn   func(ctx context.Context, projType string, flags []string, limit, offset int) ([]projections.Projection, int, error)
+	SearchProjectionsFn func(ctx context.Context, projType, q string, limit, offset int) ([]projections.Projection, int, error)
+	ListDeletedFn     func(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error)
+	GetDeletedProjectionFn func(ctx context.Context, projType, aggregateID string) (*projections.Projection, error)
+	ListRegisteredFn       func(ctx context.Context, projType string, filter registry.Filter, limit, offset int) ([]projections.Projection, int, error)
+	ScanProjectionsFn      func(ctx context.Context, projType, afterAggregateID string, limit int) ([]projections.Projection, error)
 }
 
 func (m *mockProjectionReader) GetProjection(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
 	return m.GetProjectionFn(ctx, projType, aggregateID)
 }
 
+func (m *mockProjectionReader) GetProjections(ctx context.Context, projType string, aggregateIDs []string) ([]projections.Projection, error) {
+	return m.GetProjectionsFn(ctx, projType, aggregateIDs)
+}
+
+func (m *mockProjectionReader) GetAggregateProjections(ctx context.Context, aggregateID, aggregateType string) ([]projections.Projection, error) {
+	return m.GetAggregateProjectionsFn(ctx, aggregateID, aggregateType)
+}
+
 func (m *mockProjectionReader) ListProjections(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
 	return m.ListProjectionsFn(ctx, projType, limit, offset)
 }
+
+func (m *mockProjectionReader) ListAnomalous(ctx context.Context, projType string, flags []string, limit, offset int) ([]projections.Projection, int, error) {
+	return m.ListAnomalousFn(ctx, projType, flags, limit, offset)
+}
+
+func (m *mockProjectionReader) SearchProjections(ctx context.Context, projType, q string, limit, offset int) ([]projections.Projection, int, error) {
+	return m.SearchProjectionsFn(ctx, projType, q, limit, offset)
+}
+
+func (m *mockProjectionReader) ListDeleted(ctx context.Context, projType string, limit, offset int) ([]projections.Projection, int, error) {
+	return m.ListDeletedFn(ctx, projType, limit, offset)
+}
+
+func (m *mockProjectionReader) ListRegistered(ctx context.Context, projType string, filter registry.Filter, limit, offset int) ([]projections.Projection, int, error) {
+	return m.ListRegisteredFn(ctx, projType, filter, limit, offset)
+}
+
+func (m *mockProjectionReader) ScanProjections(ctx context.Context, projType, afterAggregateID string, limit int) ([]projections.Projection, error) {
+	return m.ScanProjectionsFn(ctx, projType, afterAggregateID, limit)
+}
+
+// GetDeletedProjection reports no deleted projection unless GetDeletedProjectionFn is set.
+func (m *mockProjectionReader) GetDeletedProjection(ctx context.Context, projType, aggregateID string) (*projections.Projection, error) {
+	if m.GetDeletedProjectionFn == nil {
+		return nil, fmt.Errorf("no rows in result set")
+	}
+	return m.GetDeletedProjectionFn(ctx, projType, aggregateID)
+}
+
+// mockEventReader implements EventReader for testing.
+type mockEventReader struct {
+	GetLatestFn func(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error)
+}
+
+func (m *mockEventReader) GetLatest(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+	return m.GetLatestFn(ctx, eventTypePrefix, aggregateID)
+}
+
+// mockProjectionHealer implements ProjectionHealer for testing.
+type mockProjectionHealer struct {
+	WriteProjectionFn func(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error
+}
+
+func (m *mockProjectionHealer) WriteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	return m.WriteProjectionFn(ctx, projType, aggregateID, state, event)
+}
+
+// mockEventLog implements EventLog for testing.
+type mockEventLog struct {
+	FetchAfterFn                func(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error)
+	FetchAfterOfAggregateTypeFn func(ctx context.Context, afterSeq int64, aggregateType string, types []string, limit int) ([]*events.Envelope, error)
+	FetchAggregateFn            func(ctx context.Context, aggregateID string, fromSeq, toSeq int64, limit int) ([]*events.Envelope, error)
+}
+
+func (m *mockEventLog) FetchAfter(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+	return m.FetchAfterFn(ctx, afterSeq, types, limit)
+}
+
+func (m *mockEventLog) FetchAfterOfAggregateType(ctx context.Context, afterSeq int64, aggregateType string, types []string, limit int) ([]*events.Envelope, error) {
+	return m.FetchAfterOfAggregateTypeFn(ctx, afterSeq, aggregateType, types, limit)
+}
+
+func (m *mockEventLog) FetchAggregate(ctx context.Context, aggregateID string, fromSeq, toSeq int64, limit int) ([]*events.Envelope, error) {
+	return m.FetchAggregateFn(ctx, aggregateID, fromSeq, toSeq, limit)
+}
+
+// mockAliasReader implements AliasReader for testing.
+type mockAliasReader struct {
+	ListAliasesFn func(ctx context.Context) ([]projections.Alias, error)
+}
+
+func (m *mockAliasReader) ListAliases(ctx context.Context) ([]projections.Alias, error) {
+	return m.ListAliasesFn(ctx)
+}
+
+// mockSearcher implements Searcher for testing.
+type mockSearcher struct {
+	SearchFn func(ctx context.Context, projType string, query []byte) (int, []byte, error)
+}
+
+func (m *mockSearcher) Search(ctx context.Context, projType string, query []byte) (int, []byte, error) {
+	return m.SearchFn(ctx, projType, query)
+}
@@ -0,0 +1,130 @@
+package query
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+	"github.com/cornjacket/platform-services/internal/shared/units"
+)
+
+// Unit systems accepted by the ?units= query parameter.
+const (
+	UnitsMetric   = units.Metric
+	UnitsImperial = units.Imperial
+)
+
+// IsValidUnitSystem checks if a unit system is supported.
+func IsValidUnitSystem(system string) bool {
+	return units.IsValidSystem(system)
+}
+
+// unitField is a numeric state field whose unit is named by another field.
+type unitField struct {
+	Path       string // dot-separated path to the number
+	UnitPath   string // dot-separated path to the unit name
+	Difference bool   // value is a delta between two readings (no offset)
+}
+
+// unitFields lists the convertible fields of each projection type.
+// Types without an entry are returned unchanged.
+var unitFields = map[string][]unitField{
+	"sensor_state": {
+		{Path: "value", UnitPath: "unit"},
+		{Path: "anomaly.delta", UnitPath: "unit", Difference: true},
+	},
+}
+
+// convertUnits returns state with its known fields expressed in the given unit
+// system, and their unit fields renamed to match. Fields with a missing or
+// unknown unit, or already in the requested system, are left as stored; so is
+// state that is not a JSON object. Other fields keep their original encoding.
+func convertUnits(projectionType string, state json.RawMessage, system string) json.RawMessage {
+	fields := unitFields[projections.BaseType(projectionType)]
+	if len(fields) == 0 {
+		return state
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(state, &doc); err != nil {
+		return state
+	}
+
+	// Units are renamed after all fields are converted, since several fields
+	// can share one unit field.
+	renamed := make(map[string]string)
+	for _, f := range fields {
+		var unit string
+		if raw, ok := getPath(doc, f.UnitPath); !ok || json.Unmarshal(raw, &unit) != nil {
+			continue
+		}
+		var v float64
+		if raw, ok := getPath(doc, f.Path); !ok || json.Unmarshal(raw, &v) != nil {
+			continue
+		}
+		v, to, ok := units.Convert(v, unit, system, f.Difference)
+		if !ok {
+			continue
+		}
+		if !setPath(doc, f.Path, v) {
+			return state
+		}
+		renamed[f.UnitPath] = to
+	}
+
+	if len(renamed) == 0 {
+		return state
+	}
+	for path, unit := range renamed {
+		if !setPath(doc, path, unit) {
+			return state
+		}
+	}
+
+	converted, err := json.Marshal(doc)
+	if err != nil {
+		return state
+	}
+	return converted
+}
+
+// getPath returns the raw value at a dot-separated path of nested objects.
+func getPath(doc map[string]json.RawMessage, path string) (json.RawMessage, bool) {
+	key, rest, nested := strings.Cut(path, ".")
+	raw, ok := doc[key]
+	if !ok || !nested {
+		return raw, ok
+	}
+	var sub map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &sub); err != nil {
+		return nil, false
+	}
+	return getPath(sub, rest)
+}
+
+// setPath replaces the value at a dot-separated path of existing nested objects.
+func setPath(doc map[string]json.RawMessage, path string, v any) bool {
+	key, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		doc[key] = raw
+		return true
+	}
+
+	var sub map[string]json.RawMessage
+	if err := json.Unmarshal(doc[key], &sub); err != nil {
+		return false
+	}
+	if !setPath(sub, rest, v) {
+		return false
+	}
+	raw, err := json.Marshal(sub)
+	if err != nil {
+		return false
+	}
+	doc[key] = raw
+	return true
+}
@@ -0,0 +1,117 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertUnits(t *testing.T) {
+	tests := []struct {
+		name   string
+		state  string
+		system string
+		want   string
+	}{
+		{
+			name:   "fahrenheit to metric",
+			state:  `{"value": 72.5, "unit": "fahrenheit"}`,
+			system: UnitsMetric,
+			want:   `{"value": 22.5, "unit": "celsius"}`,
+		},
+		{
+			name:   "celsius to imperial",
+			state:  `{"value": 22.5, "unit": "celsius"}`,
+			system: UnitsImperial,
+			want:   `{"value": 72.5, "unit": "fahrenheit"}`,
+		},
+		{
+			name:   "already in requested system",
+			state:  `{"value": 72.5, "unit": "fahrenheit"}`,
+			system: UnitsImperial,
+			want:   `{"value": 72.5, "unit": "fahrenheit"}`,
+		},
+		{
+			name:   "unit name is case-insensitive",
+			state:  `{"value": 10, "unit": "Feet"}`,
+			system: UnitsMetric,
+			want:   `{"value": 3.048, "unit": "meters"}`,
+		},
+		{
+			name:   "anomaly delta scales without offset",
+			state:  `{"value": 86, "unit": "fahrenheit", "anomaly": {"value_jump": true, "delta": 18}}`,
+			system: UnitsMetric,
+			want:   `{"value": 30, "unit": "celsius", "anomaly": {"value_jump": true, "delta": 10}}`,
+		},
+		{
+			name:   "other fields untouched",
+			state:  `{"value": 50, "unit": "fahrenheit", "battery": 97, "firmware": "1.2.0"}`,
+			system: UnitsMetric,
+			want:   `{"value": 10, "unit": "celsius", "battery": 97, "firmware": "1.2.0"}`,
+		},
+		{
+			name:   "unknown unit",
+			state:  `{"value": 40, "unit": "percent"}`,
+			system: UnitsMetric,
+			want:   `{"value": 40, "unit": "percent"}`,
+		},
+		{
+			name:   "no unit",
+			state:  `{"value": 40}`,
+			system: UnitsMetric,
+			want:   `{"value": 40}`,
+		},
+		{
+			name:   "non-numeric value",
+			state:  `{"value": "n/a", "unit": "fahrenheit"}`,
+			system: UnitsMetric,
+			want:   `{"value": "n/a", "unit": "fahrenheit"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := convertUnits("sensor_state", json.RawMessage(tt.state), tt.system)
+			assertStateInDelta(t, tt.want, got)
+		})
+	}
+}
+
+func TestConvertUnits_Passthrough(t *testing.T) {
+	// Types without conversion fields, and non-object state, are returned as stored
+	user := json.RawMessage(`{"user_id": "user-1", "unit": "fahrenheit", "value": 1}`)
+	assert.Equal(t, user, convertUnits("user_session", user, UnitsMetric))
+
+	notObject := json.RawMessage(`[1, 2, 3]`)
+	assert.Equal(t, notObject, convertUnits("sensor_state", notObject, UnitsMetric))
+}
+
+// assertStateInDelta compares JSON objects, allowing float rounding in numbers.
+func assertStateInDelta(t *testing.T, want string, got json.RawMessage) {
+	t.Helper()
+
+	var w, g map[string]any
+	require.NoError(t, json.Unmarshal([]byte(want), &w))
+	require.NoError(t, json.Unmarshal(got, &g))
+	assertValuesInDelta(t, w, g)
+}
+
+func assertValuesInDelta(t *testing.T, want, got any) {
+	t.Helper()
+
+	switch w := want.(type) {
+	case float64:
+		assert.InDelta(t, w, got, 1e-9)
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		require.True(t, ok, "expected object, got %v", got)
+		assert.Len(t, g, len(w))
+		for k, v := range w {
+			assertValuesInDelta(t, v, g[k])
+		}
+	default:
+		assert.Equal(t, want, got)
+	}
+}
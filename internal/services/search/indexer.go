@@ -0,0 +1,233 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// MaxBulkDocuments is the most documents sent in one bulk request.
+const MaxBulkDocuments = 1000
+
+// maxRetryDelay caps the backoff between retries of a failed bulk request.
+const maxRetryDelay = 30 * time.Second
+
+// IndexerConfig holds configuration for the indexer's consumer.
+type IndexerConfig struct {
+	Brokers  []string
+	GroupID  string
+	ClientID string // reported to the broker; empty uses the kgo default
+	Topic    string // the projection change topic
+	Opts     []kgo.Opt
+	// RetryDelay is the backoff before retrying a failed bulk request; it
+	// doubles on each further failure, up to 30s. Zero uses 1s.
+	RetryDelay time.Duration
+}
+
+// Indexer consumes the projection change topic and applies each change to
+// the search index. A batch of records is committed only once the index has
+// taken it, so an unavailable backend holds the indexer back instead of
+// losing changes; documents the backend rejects are logged and skipped.
+type Indexer struct {
+	client   *kgo.Client
+	index    Index
+	config   IndexerConfig
+	applied  *metrics.Counter // nil disables metrics
+	failures *metrics.Counter
+	logger   *slog.Logger
+}
+
+// NewIndexer creates a new indexer.
+func NewIndexer(index Index, config IndexerConfig, logger *slog.Logger) (*Indexer, error) {
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = time.Second
+	}
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(config.Brokers...),
+		kgo.ConsumerGroup(config.GroupID),
+		kgo.ConsumeTopics(config.Topic),
+		kgo.DisableAutoCommit(),
+	}
+	if config.ClientID != "" {
+		opts = append(opts, kgo.ClientID(config.ClientID))
+	}
+	opts = append(opts, config.Opts...)
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Indexer{
+		client: client,
+		index:  index,
+		config: config,
+		logger: logger.With("component", "search-indexer"),
+	}, nil
+}
+
+// SetMetrics counts documents indexed, deleted and rejected by projection
+// type, and failed bulk requests.
+func (ix *Indexer) SetMetrics(reg *metrics.Registry) {
+	ix.applied = reg.NewCounter("search_index_documents_total",
+		"Projection documents applied to the search index, by result (indexed, deleted or rejected).", "projection_type", "result")
+	ix.failures = reg.NewCounter("search_index_failures_total",
+		"Bulk requests the search index failed; the documents are retried.")
+}
+
+// Start begins consuming changes and blocks until context is cancelled.
+func (ix *Indexer) Start(ctx context.Context) error {
+	ix.logger.Info("starting search indexer",
+		"group_id", ix.config.GroupID,
+		"topic", ix.config.Topic,
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			ix.logger.Info("search indexer stopping")
+			return nil
+		default:
+		}
+
+		fetches := ix.client.PollFetches(ctx)
+		if fetches.IsClientClosed() {
+			return nil
+		}
+		if ctx.Err() != nil {
+			continue // stopping; handled at the top of the loop
+		}
+
+		if errs := fetches.Errors(); len(errs) > 0 {
+			for _, err := range errs {
+				ix.logger.Error("fetch error",
+					"topic", err.Topic,
+					"partition", err.Partition,
+					"error", err.Err,
+				)
+			}
+			continue
+		}
+
+		docs := ix.documents(fetches.Records())
+		if err := ix.apply(ctx, docs); err != nil {
+			continue // stopping; the uncommitted records are consumed again
+		}
+
+		if err := ix.client.CommitUncommittedOffsets(ctx); err != nil {
+			ix.logger.Error("failed to commit offsets", "error", err)
+		}
+	}
+}
+
+// documents converts change records to documents. Several changes of one
+// projection collapse into its last, which is what the index ends up with.
+// Records that are not changes are logged and skipped.
+func (ix *Indexer) documents(records []*kgo.Record) []Document {
+	docs := make([]Document, 0, len(records))
+	positions := make(map[string]int, len(records))
+	for _, record := range records {
+		projType, aggregateID, ok := projections.ParseChangeKey(string(record.Key))
+		if !ok {
+			ix.logger.Error("invalid change record key, skipping",
+				"partition", record.Partition,
+				"offset", record.Offset,
+				"key", string(record.Key),
+			)
+			continue
+		}
+		doc := Document{ProjectionType: projType, AggregateID: aggregateID}
+		// A tombstone deletes the projection's document
+		if record.Value != nil {
+			var change projections.ChangeRecord
+			if err := json.Unmarshal(record.Value, &change); err != nil {
+				ix.logger.Error("failed to deserialize change record, skipping",
+					"partition", record.Partition,
+					"offset", record.Offset,
+					"error", err,
+				)
+				continue
+			}
+			doc.Source = record.Value
+		}
+
+		if i, seen := positions[string(record.Key)]; seen {
+			docs[i] = doc
+			continue
+		}
+		positions[string(record.Key)] = len(docs)
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// apply writes docs to the index, MaxBulkDocuments at a time, retrying failed
+// bulk requests until they succeed or ctx is done.
+func (ix *Indexer) apply(ctx context.Context, docs []Document) error {
+	for len(docs) > 0 {
+		n := min(len(docs), MaxBulkDocuments)
+		if err := ix.bulk(ctx, docs[:n]); err != nil {
+			return err
+		}
+		docs = docs[n:]
+	}
+	return nil
+}
+
+func (ix *Indexer) bulk(ctx context.Context, docs []Document) error {
+	delay := ix.config.RetryDelay
+	for {
+		rejected, err := ix.index.Bulk(ctx, docs)
+		if err == nil {
+			ix.record(docs, rejected)
+			return nil
+		}
+		ix.logger.Error("bulk indexing failed, retrying",
+			"documents", len(docs),
+			"retry_in", delay,
+			"error", err,
+		)
+		ix.failures.Inc()
+
+		if err := clock.Sleep(ctx, delay); err != nil {
+			return err
+		}
+		delay = min(2*delay, maxRetryDelay)
+	}
+}
+
+// record logs rejected documents and counts the applied ones.
+func (ix *Indexer) record(docs []Document, rejected []Rejection) {
+	refused := make(map[string]bool, len(rejected))
+	for _, r := range rejected {
+		ix.logger.Error("search index rejected document",
+			"projection_type", r.Document.ProjectionType,
+			"aggregate_id", r.Document.AggregateID,
+			"reason", r.Reason,
+		)
+		refused[projections.ChangeKey(r.Document.ProjectionType, r.Document.AggregateID)] = true
+	}
+	for _, doc := range docs {
+		result := "indexed"
+		switch {
+		case refused[projections.ChangeKey(doc.ProjectionType, doc.AggregateID)]:
+			result = "rejected"
+		case doc.Source == nil:
+			result = "deleted"
+		}
+		ix.applied.Inc(doc.ProjectionType, result)
+	}
+}
+
+// Close releases consumer resources.
+func (ix *Indexer) Close() error {
+	ix.client.Close()
+	ix.logger.Info("search indexer closed")
+	return nil
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+)
+
+// fakeIndex records bulk requests, failing the first failures of them and
+// rejecting the documents of the aggregates in reject.
+type fakeIndex struct {
+	requests [][]Document
+	failures int
+	reject   map[string]bool
+}
+
+func (f *fakeIndex) Bulk(ctx context.Context, docs []Document) ([]Rejection, error) {
+	f.requests = append(f.requests, docs)
+	if f.failures > 0 {
+		f.failures--
+		return nil, errors.New("cluster unavailable")
+	}
+	var rejected []Rejection
+	for _, doc := range docs {
+		if f.reject[doc.AggregateID] {
+			rejected = append(rejected, Rejection{Document: doc, Reason: "mapper_parsing_exception: failed to parse field [state.value]"})
+		}
+	}
+	return rejected, nil
+}
+
+func newTestIndexer(index Index) *Indexer {
+	return &Indexer{
+		index:  index,
+		config: IndexerConfig{RetryDelay: time.Millisecond},
+		logger: slog.Default(),
+	}
+}
+
+func changeRecord(key, value string) *kgo.Record {
+	record := &kgo.Record{Key: []byte(key)}
+	if value != "" {
+		record.Value = []byte(value)
+	}
+	return record
+}
+
+func TestIndexer_Documents(t *testing.T) {
+	ix := newTestIndexer(&fakeIndex{})
+
+	docs := ix.documents([]*kgo.Record{
+		changeRecord("sensor_state/device-001", `{"projection_type":"sensor_state","aggregate_id":"device-001","state":{"value":1}}`),
+		changeRecord("sensor_state/device-002", `{"projection_type":"sensor_state","aggregate_id":"device-002","state":{"value":1}}`),
+		changeRecord("no-separator", `{}`),
+		changeRecord("sensor_state/device-003", `not json`),
+		// A later change of device-001 replaces the first
+		changeRecord("sensor_state/device-001", `{"projection_type":"sensor_state","aggregate_id":"device-001","state":{"value":2}}`),
+		changeRecord("user_session/user-1/a", ""),
+	})
+
+	require.Len(t, docs, 3)
+	assert.Equal(t, "device-001", docs[0].AggregateID)
+	assert.JSONEq(t, `{"projection_type":"sensor_state","aggregate_id":"device-001","state":{"value":2}}`, string(docs[0].Source))
+	assert.Equal(t, "device-002", docs[1].AggregateID)
+	// Tombstones delete; aggregate IDs may contain "/"
+	assert.Equal(t, Document{ProjectionType: "user_session", AggregateID: "user-1/a"}, docs[2])
+}
+
+func TestIndexer_RetriesFailedBulk(t *testing.T) {
+	index := &fakeIndex{failures: 2, reject: map[string]bool{"device-002": true}}
+	ix := newTestIndexer(index)
+	reg := metrics.NewRegistry()
+	ix.SetMetrics(reg)
+
+	docs := []Document{
+		{ProjectionType: "sensor_state", AggregateID: "device-001", Source: []byte(`{}`)},
+		{ProjectionType: "sensor_state", AggregateID: "device-002", Source: []byte(`{}`)},
+		{ProjectionType: "sensor_state", AggregateID: "device-003"},
+	}
+	require.NoError(t, ix.apply(context.Background(), docs))
+	assert.Len(t, index.requests, 3, "two failures, then success")
+
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+	assert.Contains(t, out.String(), `search_index_failures_total 2`)
+	assert.Contains(t, out.String(), `search_index_documents_total{projection_type="sensor_state",result="indexed"} 1`)
+	assert.Contains(t, out.String(), `search_index_documents_total{projection_type="sensor_state",result="rejected"} 1`)
+	assert.Contains(t, out.String(), `search_index_documents_total{projection_type="sensor_state",result="deleted"} 1`)
+}
+
+func TestIndexer_StopsRetryingWhenCancelled(t *testing.T) {
+	index := &fakeIndex{failures: 1000}
+	ix := newTestIndexer(index)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := ix.apply(ctx, []Document{{ProjectionType: "sensor_state", AggregateID: "device-001"}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestIndexer_SplitsLargeBatches(t *testing.T) {
+	index := &fakeIndex{}
+	docs := make([]Document, MaxBulkDocuments+1)
+	for i := range docs {
+		docs[i] = Document{ProjectionType: "sensor_state", AggregateID: "device"}
+	}
+
+	require.NoError(t, newTestIndexer(index).apply(context.Background(), docs))
+	require.Len(t, index.requests, 2)
+	assert.Len(t, index.requests[0], MaxBulkDocuments)
+	assert.Len(t, index.requests[1], 1)
+}
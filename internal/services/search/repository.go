@@ -0,0 +1,33 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Index is the search index the indexer writes to.
+// This interface is satisfied by infra/opensearch.Client.
+type Index interface {
+	// Bulk applies docs in order, in one request. It fails as a whole when
+	// the backend cannot be reached or cannot take the request right now,
+	// and may then be retried with the same documents. Documents the backend
+	// rejects on their own, such as a state that does not fit the index
+	// mapping, are returned instead; retrying them would fail again.
+	Bulk(ctx context.Context, docs []Document) ([]Rejection, error)
+}
+
+// Document is the indexed state of one projection. Projections of a type
+// share an index and are identified by their aggregate ID.
+type Document struct {
+	ProjectionType string
+	AggregateID    string
+	// Source is the projections.ChangeRecord to index; nil deletes the
+	// document.
+	Source json.RawMessage
+}
+
+// Rejection is a document the backend refused.
+type Rejection struct {
+	Document Document
+	Reason   string
+}
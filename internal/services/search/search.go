@@ -0,0 +1,67 @@
+// Package search keeps a search index of projection state. Its indexer
+// consumes the projection change topic (see eventhandler.ChangeFeed) and
+// writes every projection's latest state to the index, one index per
+// projection type, so the query API can serve free-text search and filters
+// that Postgres JSONB cannot serve efficiently.
+package search
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+)
+
+// Config holds configuration for the search service.
+type Config struct {
+	Brokers       []string
+	ConsumerGroup string
+	ClientID      string            // Kafka client ID; carries the build version (see platform preflight)
+	Topic         string            // the projection change topic
+	BrokerOpts    []kgo.Opt         // TLS and SASL (see redpanda.Auth)
+	RetryDelay    time.Duration     // backoff before retrying a failed bulk request; zero uses 1s
+	Metrics       *metrics.Registry // indexing metrics; nil disables them
+}
+
+// RunningService represents a started search service.
+type RunningService struct {
+	// Shutdown stops the indexer.
+	Shutdown func(ctx context.Context) error
+}
+
+// Start starts the indexer, writing to index.
+func Start(ctx context.Context, cfg Config, index Index, logger *slog.Logger) (*RunningService, error) {
+	logger = logger.With("service", "search")
+
+	indexer, err := NewIndexer(index, IndexerConfig{
+		Brokers:    cfg.Brokers,
+		GroupID:    cfg.ConsumerGroup,
+		ClientID:   cfg.ClientID,
+		Topic:      cfg.Topic,
+		Opts:       cfg.BrokerOpts,
+		RetryDelay: cfg.RetryDelay,
+	}, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create search indexer: %w", err)
+	}
+	if cfg.Metrics != nil {
+		indexer.SetMetrics(cfg.Metrics)
+	}
+
+	go func() {
+		if err := indexer.Start(ctx); err != nil {
+			logger.Error("search indexer error", "error", err)
+		}
+	}()
+
+	return &RunningService{
+		Shutdown: func(shutdownCtx context.Context) error {
+			logger.Info("shutting down search service")
+			return indexer.Close()
+		},
+	}, nil
+}
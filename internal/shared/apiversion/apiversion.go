@@ -0,0 +1,50 @@
+// Package apiversion routes the versions of an HTTP API resource, so a new
+// version of a request or response shape can be served next to the old one
+// instead of replacing it. Each version of a resource is served at
+// /api/<version><path>; responses name the version that served them in the
+// API-Version header, and deprecated versions announce it in a Deprecation
+// header (RFC 9745) so clients can find out before the version is removed.
+package apiversion
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Header names the version that served a response.
+const Header = "API-Version"
+
+// Version is one version of a resource.
+type Version struct {
+	Name       string // path segment, e.g. "v2"
+	Handler    http.Handler
+	Deprecated bool // answers carry "Deprecation: true"
+}
+
+// Path returns the route of version name for path: /api/<name><path>.
+func Path(name, path string) string {
+	return "/api/" + name + path
+}
+
+// Register serves each version of the resource at path (e.g. "/events") on
+// mux. It panics on an empty or duplicate version name, as mux does on a
+// duplicate route.
+func Register(mux *http.ServeMux, path string, versions ...Version) {
+	for _, v := range versions {
+		if v.Name == "" || strings.Contains(v.Name, "/") {
+			panic("apiversion: invalid version name " + v.Name)
+		}
+		mux.Handle(Path(v.Name, path), versioned(v))
+	}
+}
+
+// versioned sets the version headers before the version's handler runs.
+func versioned(v Version) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(Header, v.Name)
+		if v.Deprecated {
+			w.Header().Set("Deprecation", "true")
+		}
+		v.Handler.ServeHTTP(w, r)
+	})
+}
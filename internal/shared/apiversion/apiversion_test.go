@@ -0,0 +1,50 @@
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	named := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(name))
+		})
+	}
+	mux := http.NewServeMux()
+	Register(mux, "/events",
+		Version{Name: "v1", Handler: named("one"), Deprecated: true},
+		Version{Name: "v2", Handler: named("two")},
+	)
+
+	for path, want := range map[string]string{"/api/v1/events": "one", "/api/v2/events": "two"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, want, w.Body.String(), path)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/events", nil))
+	assert.Equal(t, "v1", w.Header().Get(Header))
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v2/events", nil))
+	assert.Equal(t, "v2", w.Header().Get(Header))
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v3/events", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRegister_InvalidName(t *testing.T) {
+	mux := http.NewServeMux()
+	assert.Panics(t, func() { Register(mux, "/events", Version{Handler: http.NotFoundHandler()}) })
+	assert.Panics(t, func() { Register(mux, "/events", Version{Name: "v1/x", Handler: http.NotFoundHandler()}) })
+}
@@ -0,0 +1,225 @@
+// Package audit records write operations (ingests and admin changes) in an
+// append-only log for compliance: who made the request, what it did, and
+// whether it succeeded.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/middleware"
+)
+
+// Outcomes, derived from the response status.
+const (
+	OutcomeSucceeded = "succeeded" // 1xx–3xx
+	OutcomeRejected  = "rejected"  // 4xx: invalid or refused request
+	OutcomeFailed    = "failed"    // 5xx
+)
+
+// apiKeyHeader carries the caller's API key (same header as
+// ingestion.APIKeyHeader).
+const apiKeyHeader = "X-API-Key"
+
+// ActorAnonymous is the actor of requests without an API key.
+const ActorAnonymous = "anonymous"
+
+// Record is one audited operation.
+type Record struct {
+	RecordID   uuid.UUID `json:"record_id"`
+	Service    string    `json:"service"`
+	Action     string    `json:"action"`
+	Actor      string    `json:"actor"`            // API key fingerprint or ActorAnonymous
+	Tenant     string    `json:"tenant,omitempty"` // the API key's tenant in the key policy
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Target     string    `json:"target,omitempty"` // what was changed, e.g. an event or rule ID
+	Detail     string    `json:"detail,omitempty"`
+	Status     int       `json:"status"`
+	Outcome    string    `json:"outcome"`
+	RequestID  string    `json:"request_id,omitempty"`
+	RemoteAddr string    `json:"remote_addr"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Log appends audit records. Records are never updated or deleted.
+type Log interface {
+	// Append stores a record, assigning its ID.
+	Append(ctx context.Context, record *Record) error
+}
+
+// Fingerprint identifies an API key without storing it: "key:" followed by
+// the first 16 hex digits of its SHA-256.
+func Fingerprint(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key:" + hex.EncodeToString(sum[:8])
+}
+
+// Recorder writes audit records for one service's HTTP handlers. A nil
+// Recorder records nothing, so services can hold one unconditionally.
+type Recorder struct {
+	log     Log
+	service string
+	policy  *auth.Policy // nil records no tenants
+	logger  *slog.Logger
+}
+
+// NewRecorder creates a Recorder for service. It returns nil if log is nil.
+func NewRecorder(log Log, service string, logger *slog.Logger) *Recorder {
+	if log == nil {
+		return nil
+	}
+	return &Recorder{
+		log:     log,
+		service: service,
+		logger:  logger.With("component", "audit"),
+	}
+}
+
+// SetPolicy sets the API key policy the tenant of each record is read from.
+// Must be called before serving requests.
+func (rec *Recorder) SetPolicy(policy *auth.Policy) {
+	if rec != nil {
+		rec.policy = policy
+	}
+}
+
+// Wrap records an audit entry named action for every request to next that
+// can change state (any method but GET, HEAD and OPTIONS). The entry is
+// written after next returns, including when it panics; a failure to write
+// it is logged but does not fail the request.
+func (rec *Recorder) Wrap(action string, next http.Handler) http.Handler {
+	if rec == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		note := &annotation{}
+		r = r.WithContext(context.WithValue(r.Context(), annotationKey{}, note))
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			status := sw.status
+			if v != nil {
+				status = http.StatusInternalServerError
+			}
+			rec.record(r, action, note, status)
+			if v != nil {
+				panic(v)
+			}
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+func (rec *Recorder) record(r *http.Request, action string, note *annotation, status int) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	actor := ActorAnonymous
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		actor = Fingerprint(key)
+	}
+	var tenant string
+	if grant, ok := rec.policy.Grant(r); ok {
+		tenant = grant.Tenant()
+	}
+	record := &Record{
+		Service:    rec.service,
+		Action:     action,
+		Actor:      actor,
+		Tenant:     tenant,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Target:     note.target,
+		Detail:     note.detail,
+		Status:     status,
+		Outcome:    outcome(status),
+		RequestID:  middleware.RequestID(r.Context()),
+		RemoteAddr: r.RemoteAddr,
+		RecordedAt: clock.Now(),
+	}
+	// The request may be cancelled once the response is written
+	if err := rec.log.Append(context.WithoutCancel(r.Context()), record); err != nil {
+		rec.logger.Error("failed to write audit record",
+			"error", err,
+			"action", action,
+			"actor", actor,
+			"tenant", tenant,
+			"target", note.target,
+			"status", status,
+			"request_id", record.RequestID,
+		)
+	}
+}
+
+func outcome(status int) string {
+	switch {
+	case status >= 500:
+		return OutcomeFailed
+	case status >= 400:
+		return OutcomeRejected
+	default:
+		return OutcomeSucceeded
+	}
+}
+
+type annotationKey struct{}
+
+// annotation holds what a handler adds to its request's audit record.
+type annotation struct {
+	target string
+	detail string
+}
+
+// Note sets the target and detail of the audit record for the request
+// carrying ctx. A no-op outside Recorder.Wrap.
+func Note(ctx context.Context, target, detail string) {
+	if note, ok := ctx.Value(annotationKey{}).(*annotation); ok {
+		note.target = target
+		note.detail = detail
+	}
+}
+
+// Notef is Note with a formatted detail.
+func Notef(ctx context.Context, target, format string, args ...any) {
+	Note(ctx, target, fmt.Sprintf(format, args...))
+}
+
+// statusWriter records the response status.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
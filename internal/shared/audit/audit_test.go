@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/auth"
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/middleware"
+)
+
+type failingLog struct{}
+
+func (failingLog) Append(ctx context.Context, record *Record) error {
+	return errors.New("database unavailable")
+}
+
+func serve(h http.Handler, method string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/v1/rules/r-1", nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestRecorder_Wrap(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	log := NewMemoryLog()
+	rec := NewRecorder(log, "actions", slog.Default())
+	h := middleware.Wrap(rec.Wrap("rules", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Note(r.Context(), "r-1", "hot devices")
+		w.WriteHeader(http.StatusNoContent)
+	})), slog.Default())
+
+	w := serve(h, http.MethodDelete, http.Header{"X-Api-Key": {"secret-key"}})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	records := log.Records()
+	require.Len(t, records, 1)
+	got := records[0]
+	assert.NotEmpty(t, got.RecordID)
+	assert.Equal(t, "actions", got.Service)
+	assert.Equal(t, "rules", got.Action)
+	assert.Equal(t, Fingerprint("secret-key"), got.Actor)
+	assert.NotContains(t, got.Actor, "secret-key")
+	assert.Equal(t, http.MethodDelete, got.Method)
+	assert.Equal(t, "/api/v1/rules/r-1", got.Path)
+	assert.Equal(t, "r-1", got.Target)
+	assert.Equal(t, "hot devices", got.Detail)
+	assert.Equal(t, http.StatusNoContent, got.Status)
+	assert.Equal(t, OutcomeSucceeded, got.Outcome)
+	assert.Equal(t, w.Header().Get(middleware.RequestIDHeader), got.RequestID)
+	assert.Equal(t, now, got.RecordedAt)
+}
+
+func TestRecorder_Tenant(t *testing.T) {
+	policy, err := auth.ParsePolicy("acme-key=tenant:acme|ingest:*,shared-key=ingest:*")
+	require.NoError(t, err)
+	log := NewMemoryLog()
+	rec := NewRecorder(log, "ingestion", slog.Default())
+	rec.SetPolicy(policy)
+	h := rec.Wrap("ingest", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve(h, http.MethodPost, http.Header{"X-Api-Key": {"acme-key"}})
+	serve(h, http.MethodPost, http.Header{"X-Api-Key": {"shared-key"}})
+	serve(h, http.MethodPost, http.Header{"X-Api-Key": {"unknown-key"}})
+
+	records := log.Records()
+	require.Len(t, records, 3)
+	assert.Equal(t, "acme", records[0].Tenant)
+	assert.Empty(t, records[1].Tenant, "the key has no tenant")
+	assert.Empty(t, records[2].Tenant, "the key is not in the policy")
+}
+
+func TestRecorder_Outcomes(t *testing.T) {
+	tests := []struct {
+		status  int
+		outcome string
+	}{
+		{http.StatusOK, OutcomeSucceeded},
+		{http.StatusBadRequest, OutcomeRejected},
+		{http.StatusNotFound, OutcomeRejected},
+		{http.StatusInternalServerError, OutcomeFailed},
+	}
+	for _, tt := range tests {
+		log := NewMemoryLog()
+		h := NewRecorder(log, "ingestion", slog.Default()).Wrap("ingest", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		serve(h, http.MethodPost, nil)
+
+		require.Len(t, log.Records(), 1)
+		assert.Equal(t, tt.outcome, log.Records()[0].Outcome, "status %d", tt.status)
+		assert.Equal(t, ActorAnonymous, log.Records()[0].Actor)
+	}
+}
+
+func TestRecorder_SkipsReads(t *testing.T) {
+	log := NewMemoryLog()
+	h := NewRecorder(log, "actions", slog.Default()).Wrap("rules", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve(h, http.MethodGet, nil)
+	serve(h, http.MethodHead, nil)
+	assert.Empty(t, log.Records())
+}
+
+func TestRecorder_Panic(t *testing.T) {
+	log := NewMemoryLog()
+	h := NewRecorder(log, "actions", slog.Default()).Wrap("rules", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	assert.PanicsWithValue(t, "boom", func() { serve(h, http.MethodPut, nil) })
+	require.Len(t, log.Records(), 1)
+	assert.Equal(t, OutcomeFailed, log.Records()[0].Outcome)
+}
+
+func TestRecorder_LogFailureDoesNotFailRequest(t *testing.T) {
+	h := NewRecorder(failingLog{}, "actions", slog.Default()).Wrap("rules", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	assert.Equal(t, http.StatusCreated, serve(h, http.MethodPost, nil).Code)
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	rec := NewRecorder(nil, "actions", slog.Default())
+	assert.Nil(t, rec)
+	rec.SetPolicy(nil)
+
+	called := false
+	h := rec.Wrap("rules", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Note(r.Context(), "r-1", "") // no-op without a recorder
+		called = true
+	}))
+	serve(h, http.MethodPost, nil)
+	assert.True(t, called)
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, Fingerprint("a"), Fingerprint("a"))
+	assert.NotEqual(t, Fingerprint("a"), Fingerprint("b"))
+	assert.Len(t, Fingerprint("a"), len("key:")+16)
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// MemoryLog implements Log in memory. Used by tests.
+type MemoryLog struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewMemoryLog creates an empty MemoryLog.
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{}
+}
+
+// Append stores a record, assigning its ID.
+func (l *MemoryLog) Append(ctx context.Context, record *Record) error {
+	recordID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate audit record ID: %w", err)
+	}
+	record.RecordID = recordID
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, *record)
+	return nil
+}
+
+// Records returns the stored records, oldest first.
+func (l *MemoryLog) Records() []Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Record(nil), l.records...)
+}
@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgtrace"
+)
+
+// PostgresLog implements Log using the audit_log table (ingestion database).
+type PostgresLog struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+// NewPostgresLog creates a new PostgresLog.
+func NewPostgresLog(pool *pgxpool.Pool, logger *slog.Logger) *PostgresLog {
+	return &PostgresLog{
+		pool:   pool,
+		logger: logger.With("store", "audit"),
+	}
+}
+
+// Append stores a record, assigning its ID.
+func (l *PostgresLog) Append(ctx context.Context, record *Record) error {
+	ctx = pgtrace.WithOperation(ctx, "audit", "append")
+	recordID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate audit record ID: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_log (record_id, service, action, actor, tenant, method, path, target, detail,
+		                       status, outcome, request_id, remote_addr, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+	_, err = l.pool.Exec(ctx, query,
+		recordID,
+		record.Service,
+		record.Action,
+		record.Actor,
+		record.Tenant,
+		record.Method,
+		record.Path,
+		record.Target,
+		record.Detail,
+		record.Status,
+		record.Outcome,
+		record.RequestID,
+		record.RemoteAddr,
+		record.RecordedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append audit record: %w", err)
+	}
+	record.RecordID = recordID
+	return nil
+}
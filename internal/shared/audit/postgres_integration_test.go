@@ -0,0 +1,72 @@
+//go:build integration
+
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+var testPool *pgxpool.Pool
+
+func TestMain(m *testing.M) {
+	pool, drop := testutil.MustNewSchemaPool("audit")
+	testutil.MustRunMigrations(pool, "../../services/ingestion/migrations")
+	testPool = pool
+	code := m.Run()
+	drop()
+	os.Exit(code)
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestPostgresLog_AppendOnly(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "audit_log")
+	log := NewPostgresLog(testPool, testLogger())
+	ctx := context.Background()
+
+	record := &Record{
+		Service:    "ingestion",
+		Action:     "ingest",
+		Actor:      Fingerprint("secret-key"),
+		Tenant:     "acme",
+		Method:     "POST",
+		Path:       "/api/v1/events",
+		Target:     "019a0000-0000-7000-8000-000000000001",
+		Detail:     "event_type=sensor.reading aggregate_id=device-001",
+		Status:     202,
+		Outcome:    OutcomeSucceeded,
+		RequestID:  "req-1",
+		RemoteAddr: "10.0.0.1:5000",
+		RecordedAt: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, log.Append(ctx, record))
+	assert.NotEqual(t, uuid.Nil, record.RecordID)
+
+	var actor, tenant, outcome string
+	var recordedAt time.Time
+	err := testPool.QueryRow(ctx, `SELECT actor, tenant, outcome, recorded_at FROM audit_log WHERE record_id = $1`,
+		record.RecordID).Scan(&actor, &tenant, &outcome, &recordedAt)
+	require.NoError(t, err)
+	assert.Equal(t, record.Actor, actor)
+	assert.Equal(t, "acme", tenant)
+	assert.Equal(t, OutcomeSucceeded, outcome)
+	assert.True(t, record.RecordedAt.Equal(recordedAt))
+
+	_, err = testPool.Exec(ctx, `UPDATE audit_log SET outcome = 'failed' WHERE record_id = $1`, record.RecordID)
+	assert.ErrorContains(t, err, "append-only")
+	_, err = testPool.Exec(ctx, `DELETE FROM audit_log WHERE record_id = $1`, record.RecordID)
+	assert.ErrorContains(t, err, "append-only")
+}
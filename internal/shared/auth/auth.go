@@ -0,0 +1,163 @@
+// Package auth restricts what each API key may do. A Policy maps API keys to
+// scopes: the event types a key may ingest, the projection types it may read,
+// the event types it may read from the event log, the event types it may
+// ingest as replays, and whether it may read or change rules. Handlers check
+// scopes themselves and answer 401 (no or unknown key) or 403 (out of scope).
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader carries the caller's API key.
+const APIKeyHeader = "X-API-Key"
+
+// Scope kinds.
+const (
+	Ingest = "ingest" // event types the key may ingest
+	Read   = "read"   // projection types the key may read
+	Events = "events" // event types the key may read from the event log
+	Replay = "replay" // event types the key may ingest as replays (X-Replay)
+	Rules  = "rules"  // rule API access: RulesRead, RulesWrite or "*" for both
+)
+
+// Rule API access levels, the names of the Rules scope.
+const (
+	RulesRead  = "read"  // list and get rules, action types and executions
+	RulesWrite = "write" // create, replace and delete rules
+)
+
+// Grant is what one API key may do: per scope kind, patterns that are an
+// exact name or a prefix ending in "*" ("sensor.*", or "*" for everything).
+type Grant struct {
+	scopes map[string][]string
+	tenant string
+}
+
+// unrestricted is the grant of every request when no policy is configured.
+var unrestricted = &Grant{}
+
+// Allows reports whether the grant covers the named event or projection type.
+func (g *Grant) Allows(kind, name string) bool {
+	if g == unrestricted {
+		return true
+	}
+	for _, pattern := range g.scopes[kind] {
+		if matches(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Holds reports whether the grant explicitly covers the named type. Unlike
+// Allows, the unrestricted grant of a missing policy holds nothing, so
+// privileges such as Replay must always be granted on purpose.
+func (g *Grant) Holds(kind, name string) bool {
+	return g != unrestricted && g.Allows(kind, name)
+}
+
+// Covers reports whether every name matching pattern (same syntax as the
+// scopes) is allowed, e.g. a request for "sensor.*" under scope "sensor.*" or "*".
+func (g *Grant) Covers(kind, pattern string) bool {
+	if g == unrestricted {
+		return true
+	}
+	prefix, wildcard := strings.CutSuffix(pattern, "*")
+	for _, scope := range g.scopes[kind] {
+		scopePrefix, scopeWildcard := strings.CutSuffix(scope, "*")
+		switch {
+		case scopeWildcard && strings.HasPrefix(prefix, scopePrefix):
+			return true
+		case !scopeWildcard && !wildcard && scope == pattern:
+			return true
+		}
+	}
+	return false
+}
+
+// Patterns returns the grant's patterns for kind, or nil if the grant is
+// unrestricted. Used to narrow unfiltered requests to what the key may see.
+func (g *Grant) Patterns(kind string) []string {
+	if g == unrestricted {
+		return nil
+	}
+	return append([]string{}, g.scopes[kind]...)
+}
+
+// Tenant returns the tenant the API key belongs to, or "" if the policy
+// names none.
+func (g *Grant) Tenant() string {
+	return g.tenant
+}
+
+// Unrestricted reports whether the grant allows everything of kind.
+func (g *Grant) Unrestricted(kind string) bool {
+	return g.Covers(kind, "*")
+}
+
+// Policy maps API keys to grants. A nil Policy allows every request, with or
+// without a key.
+type Policy struct {
+	keys map[string]*Grant
+}
+
+// ParsePolicy parses a comma-separated list of API keys and their scopes,
+// each "key=kind:pattern|kind:pattern", such as
+// "k1=ingest:sensor.*|read:sensor_state,k2=ingest:*|read:*|events:*". An
+// entry may also name the key's tenant once, as "tenant:acme".
+// Empty input yields a nil Policy (no restrictions).
+func ParsePolicy(s string) (*Policy, error) {
+	if s == "" {
+		return nil, nil
+	}
+	p := &Policy{keys: make(map[string]*Grant)}
+	for _, entry := range strings.Split(s, ",") {
+		key, scopes, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || key == "" || scopes == "" {
+			return nil, fmt.Errorf("invalid API key entry: expected key=kind:pattern|...")
+		}
+		if _, dup := p.keys[key]; dup {
+			return nil, fmt.Errorf("duplicate API key entry")
+		}
+		grant := &Grant{scopes: make(map[string][]string)}
+		for _, scope := range strings.Split(scopes, "|") {
+			if tenant, ok := strings.CutPrefix(scope, "tenant:"); ok {
+				if tenant == "" || grant.tenant != "" {
+					return nil, fmt.Errorf("invalid tenant %q: expected a single tenant:name", scope)
+				}
+				grant.tenant = tenant
+				continue
+			}
+			kind, pattern, ok := strings.Cut(scope, ":")
+			if !ok || (kind != Ingest && kind != Read && kind != Events && kind != Replay && kind != Rules) {
+				return nil, fmt.Errorf("invalid scope %q: expected ingest:, read:, events:, replay: or rules: followed by a pattern", scope)
+			}
+			if prefix, _ := strings.CutSuffix(pattern, "*"); pattern == "" || strings.Contains(prefix, "*") {
+				return nil, fmt.Errorf("invalid scope %q: pattern must be a name or a prefix ending in *", scope)
+			}
+			grant.scopes[kind] = append(grant.scopes[kind], pattern)
+		}
+		p.keys[key] = grant
+	}
+	return p, nil
+}
+
+// Grant returns the grant of the request's API key. ok is false if a policy
+// is configured and the request has no key or an unknown one.
+func (p *Policy) Grant(r *http.Request) (grant *Grant, ok bool) {
+	if p == nil {
+		return unrestricted, true
+	}
+	grant, ok = p.keys[r.Header.Get(APIKeyHeader)]
+	return grant, ok
+}
+
+func matches(pattern, name string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+	return pattern == name
+}
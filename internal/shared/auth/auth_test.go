@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("sensors=ingest:sensor.*|read:sensor_state, admin=ingest:*|read:*|events:*")
+	require.NoError(t, err)
+
+	sensors := p.keys["sensors"]
+	require.NotNil(t, sensors)
+	assert.True(t, sensors.Allows(Ingest, "sensor.reading"))
+	assert.False(t, sensors.Allows(Ingest, "user.login"))
+	assert.True(t, sensors.Allows(Read, "sensor_state"))
+	assert.False(t, sensors.Allows(Read, "user_session"))
+	assert.False(t, sensors.Allows(Events, "sensor.reading"))
+
+	admin := p.keys["admin"]
+	require.NotNil(t, admin)
+	assert.True(t, admin.Allows(Ingest, "user.login"))
+	assert.True(t, admin.Unrestricted(Events))
+	assert.False(t, admin.Holds(Replay, "sensor.reading"))
+
+	backfill, err := ParsePolicy("backfill=ingest:*|replay:sensor.*")
+	require.NoError(t, err)
+	assert.True(t, backfill.keys["backfill"].Holds(Replay, "sensor.reading"))
+	assert.False(t, backfill.keys["backfill"].Holds(Replay, "user.login"))
+
+	tenants, err := ParsePolicy("acme-gw=tenant:acme|ingest:*,shared=ingest:*")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", tenants.keys["acme-gw"].Tenant())
+	assert.True(t, tenants.keys["acme-gw"].Allows(Ingest, "sensor.reading"))
+	assert.Empty(t, tenants.keys["shared"].Tenant())
+
+	ops, err := ParsePolicy("ops=rules:*,viewer=rules:read")
+	require.NoError(t, err)
+	assert.True(t, ops.keys["ops"].Allows(Rules, RulesWrite))
+	assert.True(t, ops.keys["viewer"].Allows(Rules, RulesRead))
+	assert.False(t, ops.keys["viewer"].Allows(Rules, RulesWrite))
+}
+
+func TestParsePolicy_Empty(t *testing.T) {
+	p, err := ParsePolicy("")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"k1",
+		"=read:*",
+		"k1=",
+		"k1=write:*",
+		"k1=read",
+		"k1=read:",
+		"k1=read:*sensor",
+		"k1=read:*,k1=ingest:*",
+		"k1=tenant:|read:*",
+		"k1=tenant:a|tenant:b|read:*",
+	} {
+		_, err := ParsePolicy(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestPolicy_Grant(t *testing.T) {
+	p, err := ParsePolicy("k1=read:sensor_state")
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	_, ok := p.Grant(req)
+	assert.False(t, ok, "no key")
+
+	req.Header.Set(APIKeyHeader, "other")
+	_, ok = p.Grant(req)
+	assert.False(t, ok, "unknown key")
+
+	req.Header.Set(APIKeyHeader, "k1")
+	grant, ok := p.Grant(req)
+	require.True(t, ok)
+	assert.True(t, grant.Allows(Read, "sensor_state"))
+
+	// No policy: every request, keyed or not, is unrestricted
+	var none *Policy
+	grant, ok = none.Grant(httptest.NewRequest("GET", "/", nil))
+	require.True(t, ok)
+	assert.True(t, grant.Allows(Ingest, "anything"))
+	assert.True(t, grant.Unrestricted(Events))
+	assert.Nil(t, grant.Patterns(Events))
+	assert.False(t, grant.Holds(Replay, "anything"), "replays are never granted implicitly")
+}
+
+func TestGrant_Covers(t *testing.T) {
+	p, err := ParsePolicy("k1=events:sensor.*|events:user.login")
+	require.NoError(t, err)
+	grant := p.keys["k1"]
+
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"sensor.*", true},
+		{"sensor.reading", true},
+		{"sensor.temp.*", true},
+		{"user.login", true},
+		{"user.*", false},
+		{"user.logout", false},
+		{"*", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, grant.Covers(Events, tt.pattern), tt.pattern)
+	}
+	assert.False(t, grant.Unrestricted(Events))
+	assert.Equal(t, []string{"sensor.*", "user.login"}, grant.Patterns(Events))
+}
@@ -1,10 +1,9 @@
 package config
 
 import (
-	"fmt"
-	"os"
-	"strconv"
 	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 )
 
 // Default database URL for local development (all services share one DB)
@@ -17,9 +16,11 @@ type Config struct {
 	LogFormat string
 
 	// Server ports
-	PortIngestion int
-	PortQuery     int
-	PortActions   int
+	PortIngestion         int
+	PortQuery             int
+	PortActions           int
+	PortEventHandlerAdmin int
+	PortMetrics           int
 
 	// Per-service database URLs (ADR-0010)
 	DatabaseURLIngestion    string
@@ -31,109 +32,551 @@ type Config struct {
 	// Redpanda
 	RedpandaBrokers string
 
+	// Redpanda producer batching and delivery guarantees (see redpanda.ProducerConfig)
+	RedpandaLinger        time.Duration
+	RedpandaBatchMaxBytes int
+	RedpandaCompression   string
+	RedpandaAcks          string
+	RedpandaIdempotent    bool
+	RedpandaTransactionID string
+	RedpandaTombstones    bool // follow <domain>.deleted events with a tombstone
+
+	// Redpanda connection security (see redpanda.Auth)
+	RedpandaTLS           bool
+	RedpandaSASLMechanism string
+	RedpandaSASLUsername  string
+	RedpandaSASLPassword  string
+
+	// Secrets providers for ${provider:path#key} references (see ResolveSecrets)
+	SecretsRefreshInterval time.Duration
+	SecretsVaultAddr       string
+	SecretsVaultToken      string
+	SecretsVaultNamespace  string
+	SecretsAWSRegion       string
+	SecretsAWSEndpoint     string
+
+	// Topic routing (event type prefix → topic, see client/eventhandler.Router)
+	TopicRoutes  string
+	TopicDefault string
+
+	// Topic lifecycle (see redpanda.Admin)
+	TopicsEnsure     bool
+	TopicPartitions  int
+	TopicReplication int
+	TopicRetention   time.Duration
+	TopicOverrides   string
+
+	// Ingestion API keys whose events are marked as test traffic (comma-separated)
+	IngestionTestAPIKeys string
+	// Ingestion API keys allowed to send X-Replay (comma-separated), besides
+	// keys with a replay: scope in APIKeys
+	IngestionReplayAPIKeys string
+
+	// API key scopes for ingestion and query (see auth.ParsePolicy); empty
+	// allows every request
+	APIKeys string // e.g. "k1=ingest:sensor.*|read:sensor_state"
+
+	// PII protection of ingested payloads (see pii.ParseRules)
+	PIIRules          string // e.g. "user.signup:email=hash,user.signup:address=encrypt"
+	PIIHashKey        string // HMAC key for hashed fields
+	PIIKMS            string // "" (no encryption), "local" or "aws"
+	PIILocalKey       string // base64 32-byte key encryption key for the local KMS
+	PIIAWSRegion      string
+	PIIAWSKeyID       string
+	PIIAWSEndpoint    string
+	PIIDataKeyTTL     time.Duration // how long a data key encrypts before a new one is generated
+	PIIDecryptAPIKeys string        // query API keys that read decrypted values (comma-separated)
+
+	// Event signatures (see package signing)
+	EventSigningKey        string // "hmac:<base64>" or "ed25519:<base64 seed>"; empty leaves events unsigned
+	EventVerifyKeys        string // keys consumers accept (comma-separated); empty uses the signing key
+	EventSignatureRequired bool   // consumers reject unsigned events
+
+	// Ingestion enrichment (see ingestion.NewEnrichers)
+	IngestEnrichers    string // built-in enrichers in order, e.g. "units,registry"; empty disables enrichment
+	IngestUnits        string // unit system the units enricher normalizes to
+	DeviceRegistryFile string // JSON file of device attributes for the registry enricher
+
 	// Outbox processor
-	OutboxWorkerCount  int
-	OutboxBatchSize    int
-	OutboxMaxRetries   int
-	OutboxPollInterval time.Duration
+	OutboxWorkerCount   int
+	OutboxBatchSize     int
+	OutboxMaxRetries    int
+	OutboxPollInterval  time.Duration
+	OutboxAsyncSubmit   bool
+	OutboxTransactional bool
+
+	// Outbox table maintenance (see worker.Maintenance)
+	OutboxVacuumInterval  time.Duration
+	OutboxVacuumMinDead   int
+	OutboxReindexInterval time.Duration
+
+	// Outbox archive (see postgres.OutboxRepo.SetArchive)
+	OutboxArchive          bool
+	OutboxArchiveRetention time.Duration
+
+	// Leader election of singleton background work across replicas (see leader.Elector)
+	LeaderElection      bool
+	LeaderRetryInterval time.Duration
+
+	// Retries and circuit breaker for Postgres repositories (see pgretry.Policy)
+	DBRetryMaxAttempts int
+	DBRetryBaseDelay   time.Duration
+	DBRetryMaxDelay    time.Duration
+	DBBreakerThreshold int
+	DBBreakerOpen      time.Duration
+
+	// Statement metrics and slow query log (see pgtrace.Tracer)
+	DBSlowQueryThreshold time.Duration
+
+	// Response compression on the query and ingestion APIs (see middleware.Compress)
+	HTTPCompressionLevel int
+
+	// Timeouts and limits of every HTTP server (see httpserver.Config)
+	HTTPReadTimeout       time.Duration
+	HTTPReadHeaderTimeout time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	HTTPMaxHeaderBytes    int
+	HTTPH2C               bool
+
+	// Deadlines on the store work of query and ingestion requests (see timeout.Policy)
+	RequestTimeout  time.Duration
+	RequestTimeouts string
+
+	// Ingestion backpressure from the outbox backlog (see ingestion.Backpressure)
+	IngestionBackpressureMaxDepth    int
+	IngestionBackpressureMaxAge      time.Duration
+	IngestionBackpressureResumeRatio float64
+	IngestionBackpressureInterval    time.Duration
+	IngestionBackpressureRetryAfter  time.Duration
+
+	// Longest wait of a ?mode=sync ingest request for its event to be published
+	IngestionSyncTimeout time.Duration
+
+	// Bounds on producer event times (see ingestion.EventTimePolicy)
+	IngestionEventTimeMaxPast   time.Duration
+	IngestionEventTimeMaxFuture time.Duration
+	IngestionEventTimePolicy    string // "reject" or "clamp"
+
+	// Deduplication of re-sent events by content (see ingestion.DedupConfig)
+	IngestionDedupWindow time.Duration
+	IngestionDedupReject bool
+
+	// How long v2 idempotency keys are kept (see ingestion.Service.SetIdempotency)
+	IngestionIdempotencyWindow time.Duration
+
+	// Event type naming rules and registry (see ingestion.EventTypePolicy)
+	IngestionEventTypeMode     string // "permissive" or "strict"
+	IngestionEventTypes        string // comma-separated patterns of registered types; empty registers all
+	IngestionBannedEventTypes  string // comma-separated patterns of rejected types
+	IngestionEventTypeMaxDepth int    // 0 disables the naming rules
 
 	// Event handler
 	EventHandlerConsumerGroup string
 	EventHandlerTopics        string
 	EventHandlerPollTimeout   time.Duration
+	EventHandlerLanes         int
+	EventHandlerQueueSize     int
+
+	// Poison events: dispatches of a failing event before it is quarantined
+	// in the DLQ (0 drops it after one), and the backoff before the first retry
+	EventHandlerMaxAttempts int
+	EventHandlerRetryDelay  time.Duration
+
+	// Offset commits: "batch", "record", "interval" or "at-most-once" (see
+	// eventhandler.CommitMode)
+	EventHandlerCommit         string
+	EventHandlerCommitInterval time.Duration
+
+	// Consumer group membership: a static instance ID per replica avoids
+	// rebalances on rolling restarts (see eventhandler.ConsumerConfig)
+	EventHandlerInstanceID       string
+	EventHandlerSessionTimeout   time.Duration
+	EventHandlerRebalanceTimeout time.Duration
+	EventHandlerBalancer         string
+
+	// Event types the event handler processes: comma-separated prefixes or
+	// globs; deny wins over allow, and an empty allow list allows all
+	EventHandlerAllow string
+	EventHandlerDeny  string
+
+	// Actions service (rule engine)
+	ActionsConsumerGroup      string
+	ActionsTopics             string
+	ActionsRuleReloadInterval time.Duration
+
+	// Actions webhook dispatcher (disabled without a secret)
+	ActionsWebhookSecret           string
+	ActionsWebhookTimeout          time.Duration
+	ActionsWebhookMaxAttempts      int
+	ActionsWebhookBreakerThreshold int
+	ActionsWebhookBreakerOpen      time.Duration
+
+	// Actions notification backends (slack, pagerduty; email needs an SMTP host)
+	ActionsNotifyTimeout time.Duration
+	ActionsPagerDutyURL  string
+	ActionsSMTPHost      string
+	ActionsSMTPPort      int
+	ActionsSMTPUsername  string
+	ActionsSMTPPassword  string
+	ActionsSMTPFrom      string
+
+	// Projection integrity verification (event handler)
+	ProjectionVerifyInterval time.Duration
+	ProjectionVerifyLimit    int
+	ProjectionVerifyRebuild  bool
+
+	// Projection state expression indexes (built by migrate)
+	ProjectionIndexes string // e.g. "sensor_state:site_id"; see projections.ParseStateIndexes
+
+	// Projection TTL expiry (event handler)
+	ProjectionTTLs          string // e.g. "sensor_state=720h"; see eventhandler.ParseTTLs
+	ProjectionTTLSweepEvery time.Duration
+	ProjectionFoldRetention time.Duration // fold records of merged projections, purged by the sweep
+
+	// Projection change feed (event handler): compacted topic receiving
+	// every accepted projection write; empty disables it
+	ProjectionChangesTopic string
+
+	// Search service: indexes the projection change feed into OpenSearch or
+	// Elasticsearch and serves /api/v1/search; an empty URL disables both
+	SearchURL           string
+	SearchUsername      string
+	SearchPassword      string
+	SearchIndexPrefix   string // index of a projection type: prefix + type
+	SearchConsumerGroup string
+	SearchTimeout       time.Duration // per request to the search backend
+
+	// Sensor anomaly flags (event handler)
+	SensorAnomalyMaxDelta float64
+	SensorAnomalyMaxGap   time.Duration
+
+	// Windowed sensor stats (event handler)
+	SensorWindow         time.Duration
+	SensorWindowLateness time.Duration
+
+	// Declarative rollup projections (event handler)
+	Rollups string // e.g. "site_daily=events:sensor.|group:site|period:day"; see eventhandler.ParseRollups
+
+	// Shadow projection handler kinds (event handler)
+	ShadowHandlers string // e.g. "sensor_v2=sensor:sensor_state_v2"; see eventhandler.ParseShadows
+
+	// Aggregate registry (eventhandler database): admin API, rollup groups,
+	// query joins and rule conditions on registry fields
+	AggregateRegistry bool
+
+	// Query service event-history fallback
+	QueryFallbackTypes string
+	QueryFallbackHeal  bool
+
+	// Query service event log long polling (GET /api/v1/events?wait=)
+	QueryEventsPollInterval time.Duration
+	QueryEventsMaxWait      time.Duration
+
+	// Query service GraphQL gateway (POST /api/v1/graphql)
+	QueryGraphQL bool
+
+	// Sandbox mode (platform sandbox)
+	SandboxEventInterval time.Duration
 
 	// Feature flags
-	EnableTSDB bool
+	EnableTSDB    bool
+	EnableActions bool
+	EnableAudit   bool // audit_log records for ingests, admin operations and rule changes
+
+	settings   []Setting         // every value and where it came from, for Settings
+	secretRefs map[string]string // settings that held secret references, by key
 }
 
 // Load reads configuration from environment variables with sensible defaults.
 // Environment variable naming convention: CJ_[SERVICE]_[VARIABLE_NAME]
 // See design-spec.md section 12 for complete reference.
 func Load() (*Config, error) {
+	return load(&source{})
+}
+
+// LoadFile reads configuration from a YAML (.yaml, .yml) or TOML (.toml)
+// file, with environment variables taking precedence over it. File keys are
+// the environment variable names without the CJ_ prefix, either flat
+// (ingestion_port: 8080) or nested by service (ingestion: {port: 8080}).
+func LoadFile(path string) (*Config, error) {
+	values, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return load(&source{file: values, path: path})
+}
+
+func load(src *source) (*Config, error) {
 	cfg := &Config{
 		// Logging
-		LogLevel:  getEnv("CJ_LOG_LEVEL", "info"),
-		LogFormat: getEnv("CJ_LOG_FORMAT", "json"),
+		LogLevel:  src.getEnv("CJ_LOG_LEVEL", "info"),
+		LogFormat: src.getEnv("CJ_LOG_FORMAT", "json"),
 
 		// Server ports
-		PortIngestion: getEnvInt("CJ_INGESTION_PORT", 8080),
-		PortQuery:     getEnvInt("CJ_QUERY_PORT", 8081),
-		PortActions:   getEnvInt("CJ_ACTIONS_PORT", 8083), // Note: 8082 used by Redpanda Pandaproxy locally
+		PortIngestion:         src.getEnvInt("CJ_INGESTION_PORT", 8080),
+		PortQuery:             src.getEnvInt("CJ_QUERY_PORT", 8081),
+		PortActions:           src.getEnvInt("CJ_ACTIONS_PORT", 8083), // Note: 8082 used by Redpanda Pandaproxy locally
+		PortEventHandlerAdmin: src.getEnvInt("CJ_EVENTHANDLER_ADMIN_PORT", 8084),
+		PortMetrics:           src.getEnvInt("CJ_METRICS_PORT", 8085),
 
 		// Per-service database URLs
 		// In dev, all default to the same database
 		// In prod, each service gets its own database
-		DatabaseURLIngestion:    getEnv("CJ_INGESTION_DATABASE_URL", defaultDatabaseURL),
-		DatabaseURLEventHandler: getEnv("CJ_EVENTHANDLER_DATABASE_URL", defaultDatabaseURL),
-		DatabaseURLQuery:        getEnv("CJ_QUERY_DATABASE_URL", defaultDatabaseURL),
-		DatabaseURLTSDB:         getEnv("CJ_TSDB_DATABASE_URL", defaultDatabaseURL),
-		DatabaseURLActions:      getEnv("CJ_ACTIONS_DATABASE_URL", defaultDatabaseURL),
+		DatabaseURLIngestion:    src.getEnv("CJ_INGESTION_DATABASE_URL", defaultDatabaseURL),
+		DatabaseURLEventHandler: src.getEnv("CJ_EVENTHANDLER_DATABASE_URL", defaultDatabaseURL),
+		DatabaseURLQuery:        src.getEnv("CJ_QUERY_DATABASE_URL", defaultDatabaseURL),
+		DatabaseURLTSDB:         src.getEnv("CJ_TSDB_DATABASE_URL", defaultDatabaseURL),
+		DatabaseURLActions:      src.getEnv("CJ_ACTIONS_DATABASE_URL", defaultDatabaseURL),
 
 		// Redpanda
-		RedpandaBrokers: getEnv("CJ_REDPANDA_BROKERS", "localhost:9092"),
+		RedpandaBrokers: src.getEnv("CJ_REDPANDA_BROKERS", "localhost:9092"),
+
+		// Redpanda producer (defaults: no linger, acks=all, idempotent)
+		RedpandaLinger:        src.getEnvDuration("CJ_REDPANDA_LINGER", 0),
+		RedpandaBatchMaxBytes: src.getEnvInt("CJ_REDPANDA_BATCH_MAX_BYTES", 0),
+		RedpandaCompression:   src.getEnv("CJ_REDPANDA_COMPRESSION", ""),
+		RedpandaAcks:          src.getEnv("CJ_REDPANDA_ACKS", "all"),
+		RedpandaIdempotent:    src.getEnvBool("CJ_REDPANDA_IDEMPOTENT", true),
+		RedpandaTransactionID: src.getEnv("CJ_REDPANDA_TRANSACTIONAL_ID", "platform-outbox"),
+		RedpandaTombstones:    src.getEnvBool("CJ_REDPANDA_TOMBSTONES", true),
+
+		// Redpanda connection security (plaintext and unauthenticated locally)
+		RedpandaTLS:           src.getEnvBool("CJ_REDPANDA_TLS", false),
+		RedpandaSASLMechanism: src.getEnv("CJ_REDPANDA_SASL_MECHANISM", ""),
+		RedpandaSASLUsername:  src.getEnv("CJ_REDPANDA_SASL_USERNAME", ""),
+		RedpandaSASLPassword:  src.getEnv("CJ_REDPANDA_SASL_PASSWORD", ""),
+
+		// Secrets providers (file is always available; vault and aws need configuring)
+		SecretsRefreshInterval: src.getEnvDuration("CJ_SECRETS_REFRESH_INTERVAL", 5*time.Minute),
+		SecretsVaultAddr:       src.getEnv("CJ_SECRETS_VAULT_ADDR", ""),
+		SecretsVaultToken:      src.getEnv("CJ_SECRETS_VAULT_TOKEN", ""),
+		SecretsVaultNamespace:  src.getEnv("CJ_SECRETS_VAULT_NAMESPACE", ""),
+		SecretsAWSRegion:       src.getEnv("CJ_SECRETS_AWS_REGION", ""),
+		SecretsAWSEndpoint:     src.getEnv("CJ_SECRETS_AWS_ENDPOINT", ""),
+
+		// Topic routing
+		TopicRoutes:  src.getEnv("CJ_TOPIC_ROUTES", "sensor.=sensor-events,user.=user-actions"),
+		TopicDefault: src.getEnv("CJ_TOPIC_DEFAULT", "system-events"),
+
+		// Topic lifecycle (create missing topics at startup instead of relying on broker auto-creation)
+		TopicsEnsure:     src.getEnvBool("CJ_TOPICS_ENSURE", true),
+		TopicPartitions:  src.getEnvInt("CJ_TOPIC_PARTITIONS", 6),
+		TopicReplication: src.getEnvInt("CJ_TOPIC_REPLICATION", 1),
+		TopicRetention:   src.getEnvDuration("CJ_TOPIC_RETENTION", 7*24*time.Hour),
+		TopicOverrides:   src.getEnv("CJ_TOPIC_OVERRIDES", ""),
+
+		// Test traffic (e2e runs); their events go to the test projection namespace
+		IngestionTestAPIKeys: src.getEnv("CJ_INGESTION_TEST_API_KEYS", ""),
+
+		// Replays (X-Replay) are refused unless a key is allowed to send them
+		IngestionReplayAPIKeys: src.getEnv("CJ_INGESTION_REPLAY_API_KEYS", ""),
+
+		// API key scopes (no keys, so no restrictions, by default)
+		APIKeys: src.getEnv("CJ_API_KEYS", ""),
+
+		PIIRules:          src.getEnv("CJ_PII_RULES", ""),
+		PIIHashKey:        src.getEnv("CJ_PII_HASH_KEY", ""),
+		PIIKMS:            src.getEnv("CJ_PII_KMS", ""),
+		PIILocalKey:       src.getEnv("CJ_PII_LOCAL_KEY", ""),
+		PIIAWSRegion:      src.getEnv("CJ_PII_AWS_REGION", ""),
+		PIIAWSKeyID:       src.getEnv("CJ_PII_AWS_KEY_ID", ""),
+		PIIAWSEndpoint:    src.getEnv("CJ_PII_AWS_ENDPOINT", ""),
+		PIIDataKeyTTL:     src.getEnvDuration("CJ_PII_DATA_KEY_TTL", time.Hour),
+		PIIDecryptAPIKeys: src.getEnv("CJ_PII_DECRYPT_API_KEYS", ""),
+
+		EventSigningKey:        src.getEnv("CJ_EVENT_SIGNING_KEY", ""),
+		EventVerifyKeys:        src.getEnv("CJ_EVENT_VERIFY_KEYS", ""),
+		EventSignatureRequired: src.getEnvBool("CJ_EVENT_SIGNATURE_REQUIRED", false),
+
+		IngestEnrichers:    src.getEnv("CJ_INGEST_ENRICHERS", ""),
+		IngestUnits:        src.getEnv("CJ_INGEST_UNITS", "metric"),
+		DeviceRegistryFile: src.getEnv("CJ_DEVICE_REGISTRY_FILE", ""),
 
 		// Outbox processor
-		OutboxWorkerCount:  getEnvInt("CJ_OUTBOX_WORKER_COUNT", 4),
-		OutboxBatchSize:    getEnvInt("CJ_OUTBOX_BATCH_SIZE", 100),
-		OutboxMaxRetries:   getEnvInt("CJ_OUTBOX_MAX_RETRIES", 5),
-		OutboxPollInterval: getEnvDuration("CJ_OUTBOX_POLL_INTERVAL", 5*time.Second),
+		OutboxWorkerCount:   src.getEnvInt("CJ_OUTBOX_WORKER_COUNT", 4),
+		OutboxBatchSize:     src.getEnvInt("CJ_OUTBOX_BATCH_SIZE", 100),
+		OutboxMaxRetries:    src.getEnvInt("CJ_OUTBOX_MAX_RETRIES", 5),
+		OutboxPollInterval:  src.getEnvDuration("CJ_OUTBOX_POLL_INTERVAL", 5*time.Second),
+		OutboxAsyncSubmit:   src.getEnvBool("CJ_OUTBOX_ASYNC_SUBMIT", false),
+		OutboxTransactional: src.getEnvBool("CJ_OUTBOX_TRANSACTIONAL", false),
+
+		// Outbox table maintenance (0 interval disables)
+		OutboxVacuumInterval:  src.getEnvDuration("CJ_OUTBOX_VACUUM_INTERVAL", 10*time.Minute),
+		OutboxVacuumMinDead:   src.getEnvInt("CJ_OUTBOX_VACUUM_MIN_DEAD", 1000),
+		OutboxReindexInterval: src.getEnvDuration("CJ_OUTBOX_REINDEX_INTERVAL", 24*time.Hour),
+
+		// Outbox archive (published entries are deleted by default)
+		OutboxArchive:          src.getEnvBool("CJ_OUTBOX_ARCHIVE", false),
+		OutboxArchiveRetention: src.getEnvDuration("CJ_OUTBOX_ARCHIVE_RETENTION", 7*24*time.Hour),
+
+		// Leader election (off: a single instance runs all background work)
+		LeaderElection:      src.getEnvBool("CJ_LEADER_ELECTION", false),
+		LeaderRetryInterval: src.getEnvDuration("CJ_LEADER_RETRY_INTERVAL", 5*time.Second),
+
+		// Postgres retries (1 attempt disables them) and circuit breaker (0 threshold disables it)
+		DBRetryMaxAttempts: src.getEnvInt("CJ_DB_RETRY_MAX_ATTEMPTS", 4),
+		DBRetryBaseDelay:   src.getEnvDuration("CJ_DB_RETRY_BASE_DELAY", 100*time.Millisecond),
+		DBRetryMaxDelay:    src.getEnvDuration("CJ_DB_RETRY_MAX_DELAY", 2*time.Second),
+		DBBreakerThreshold: src.getEnvInt("CJ_DB_BREAKER_THRESHOLD", 10),
+		DBBreakerOpen:      src.getEnvDuration("CJ_DB_BREAKER_OPEN", 5*time.Second),
+
+		// Slow query log (0 disables)
+		DBSlowQueryThreshold: src.getEnvDuration("CJ_DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
+
+		// Response compression (0 disables)
+		HTTPCompressionLevel: src.getEnvInt("CJ_HTTP_COMPRESSION_LEVEL", 6),
+
+		// HTTP servers (0 timeouts disable them; h2c off)
+		HTTPReadTimeout:       src.getEnvDuration("CJ_HTTP_READ_TIMEOUT", 10*time.Second),
+		HTTPReadHeaderTimeout: src.getEnvDuration("CJ_HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		HTTPWriteTimeout:      src.getEnvDuration("CJ_HTTP_WRITE_TIMEOUT", 10*time.Second),
+		HTTPIdleTimeout:       src.getEnvDuration("CJ_HTTP_IDLE_TIMEOUT", 60*time.Second),
+		HTTPMaxHeaderBytes:    src.getEnvInt("CJ_HTTP_MAX_HEADER_BYTES", 1<<20),
+		HTTPH2C:               src.getEnvBool("CJ_HTTP_H2C", false),
+
+		// Request deadlines (0 disables; per-operation overrides empty)
+		RequestTimeout:  src.getEnvDuration("CJ_REQUEST_TIMEOUT", 5*time.Second),
+		RequestTimeouts: src.getEnv("CJ_REQUEST_TIMEOUTS", ""),
+
+		// Ingestion backpressure (off: both thresholds 0)
+		IngestionBackpressureMaxDepth:    src.getEnvInt("CJ_INGESTION_BACKPRESSURE_MAX_DEPTH", 0),
+		IngestionBackpressureMaxAge:      src.getEnvDuration("CJ_INGESTION_BACKPRESSURE_MAX_AGE", 0),
+		IngestionBackpressureResumeRatio: src.getEnvFloat("CJ_INGESTION_BACKPRESSURE_RESUME_RATIO", 0.5),
+		IngestionBackpressureInterval:    src.getEnvDuration("CJ_INGESTION_BACKPRESSURE_INTERVAL", 5*time.Second),
+		IngestionBackpressureRetryAfter:  src.getEnvDuration("CJ_INGESTION_BACKPRESSURE_RETRY_AFTER", 30*time.Second),
+
+		// Sync ingestion mode (0 disables)
+		IngestionSyncTimeout: src.getEnvDuration("CJ_INGESTION_SYNC_TIMEOUT", 5*time.Second),
+
+		// Event time bounds (0 disables a bound)
+		IngestionEventTimeMaxPast:   src.getEnvDuration("CJ_INGESTION_EVENT_TIME_MAX_PAST", 0),
+		IngestionEventTimeMaxFuture: src.getEnvDuration("CJ_INGESTION_EVENT_TIME_MAX_FUTURE", 0),
+		IngestionEventTimePolicy:    src.getEnv("CJ_INGESTION_EVENT_TIME_POLICY", "reject"),
+
+		// Ingestion dedup (0 window disables; duplicates are coalesced unless rejected)
+		IngestionDedupWindow: src.getEnvDuration("CJ_INGESTION_DEDUP_WINDOW", 0),
+		IngestionDedupReject: src.getEnvBool("CJ_INGESTION_DEDUP_REJECT", false),
+
+		// Idempotency keys (0 refuses requests with a key)
+		IngestionIdempotencyWindow: src.getEnvDuration("CJ_INGESTION_IDEMPOTENCY_WINDOW", 24*time.Hour),
+
+		// Event type checks (permissive logs what strict rejects)
+		IngestionEventTypeMode:     src.getEnv("CJ_INGESTION_EVENT_TYPE_MODE", "permissive"),
+		IngestionEventTypes:        src.getEnv("CJ_INGESTION_EVENT_TYPES", ""),
+		IngestionBannedEventTypes:  src.getEnv("CJ_INGESTION_BANNED_EVENT_TYPES", ""),
+		IngestionEventTypeMaxDepth: src.getEnvInt("CJ_INGESTION_EVENT_TYPE_MAX_DEPTH", events.DefaultMaxEventTypeDepth),
 
 		// Event handler
-		EventHandlerConsumerGroup: getEnv("CJ_EVENTHANDLER_CONSUMER_GROUP", "event-handler"),
-		EventHandlerTopics:        getEnv("CJ_EVENTHANDLER_TOPICS", "sensor-events,user-actions,system-events"),
-		EventHandlerPollTimeout:   getEnvDuration("CJ_EVENTHANDLER_POLL_TIMEOUT", 1*time.Second),
+		EventHandlerConsumerGroup:    src.getEnv("CJ_EVENTHANDLER_CONSUMER_GROUP", "event-handler"),
+		EventHandlerTopics:           src.getEnv("CJ_EVENTHANDLER_TOPICS", "sensor-events,user-actions,system-events"),
+		EventHandlerPollTimeout:      src.getEnvDuration("CJ_EVENTHANDLER_POLL_TIMEOUT", 1*time.Second),
+		EventHandlerLanes:            src.getEnvInt("CJ_EVENTHANDLER_LANES", 8),
+		EventHandlerQueueSize:        src.getEnvInt("CJ_EVENTHANDLER_QUEUE_SIZE", 256),
+		EventHandlerMaxAttempts:      src.getEnvInt("CJ_EVENTHANDLER_MAX_ATTEMPTS", 5),
+		EventHandlerRetryDelay:       src.getEnvDuration("CJ_EVENTHANDLER_RETRY_DELAY", 1*time.Second),
+		EventHandlerCommit:           src.getEnv("CJ_EVENTHANDLER_COMMIT", "batch"),
+		EventHandlerCommitInterval:   src.getEnvDuration("CJ_EVENTHANDLER_COMMIT_INTERVAL", 5*time.Second),
+		EventHandlerInstanceID:       src.getEnv("CJ_EVENTHANDLER_INSTANCE_ID", ""),
+		EventHandlerSessionTimeout:   src.getEnvDuration("CJ_EVENTHANDLER_SESSION_TIMEOUT", 45*time.Second),
+		EventHandlerRebalanceTimeout: src.getEnvDuration("CJ_EVENTHANDLER_REBALANCE_TIMEOUT", 60*time.Second),
+		EventHandlerBalancer:         src.getEnv("CJ_EVENTHANDLER_BALANCER", "cooperative-sticky"),
+		EventHandlerAllow:            src.getEnv("CJ_EVENTHANDLER_ALLOW", ""),
+		EventHandlerDeny:             src.getEnv("CJ_EVENTHANDLER_DENY", ""),
 
-		// Feature flags
-		EnableTSDB: getEnvBool("CJ_FEATURE_TSDB", false),
-	}
+		// Actions service (rule engine)
+		ActionsConsumerGroup:      src.getEnv("CJ_ACTIONS_CONSUMER_GROUP", "actions"),
+		ActionsTopics:             src.getEnv("CJ_ACTIONS_TOPICS", "sensor-events,user-actions,system-events"),
+		ActionsRuleReloadInterval: src.getEnvDuration("CJ_ACTIONS_RULE_RELOAD_INTERVAL", 10*time.Second),
 
-	if err := cfg.validate(); err != nil {
-		return nil, err
-	}
+		// Actions webhook dispatcher (the "webhook" action type needs a signing secret)
+		ActionsWebhookSecret:           src.getEnv("CJ_ACTIONS_WEBHOOK_SECRET", ""),
+		ActionsWebhookTimeout:          src.getEnvDuration("CJ_ACTIONS_WEBHOOK_TIMEOUT", 5*time.Second),
+		ActionsWebhookMaxAttempts:      src.getEnvInt("CJ_ACTIONS_WEBHOOK_MAX_ATTEMPTS", 3),
+		ActionsWebhookBreakerThreshold: src.getEnvInt("CJ_ACTIONS_WEBHOOK_BREAKER_THRESHOLD", 5),
+		ActionsWebhookBreakerOpen:      src.getEnvDuration("CJ_ACTIONS_WEBHOOK_BREAKER_OPEN", time.Minute),
 
-	return cfg, nil
-}
+		// Actions notification backends (the "email" action type needs an SMTP host)
+		ActionsNotifyTimeout: src.getEnvDuration("CJ_ACTIONS_NOTIFY_TIMEOUT", 10*time.Second),
+		ActionsPagerDutyURL:  src.getEnv("CJ_ACTIONS_PAGERDUTY_URL", "https://events.pagerduty.com/v2/enqueue"),
+		ActionsSMTPHost:      src.getEnv("CJ_ACTIONS_SMTP_HOST", ""),
+		ActionsSMTPPort:      src.getEnvInt("CJ_ACTIONS_SMTP_PORT", 587),
+		ActionsSMTPUsername:  src.getEnv("CJ_ACTIONS_SMTP_USERNAME", ""),
+		ActionsSMTPPassword:  src.getEnv("CJ_ACTIONS_SMTP_PASSWORD", ""),
+		ActionsSMTPFrom:      src.getEnv("CJ_ACTIONS_SMTP_FROM", "cornjacket@localhost"),
 
-func (c *Config) validate() error {
-	if c.DatabaseURLIngestion == "" {
-		return fmt.Errorf("CJ_INGESTION_DATABASE_URL is required")
-	}
-	if c.RedpandaBrokers == "" {
-		return fmt.Errorf("CJ_REDPANDA_BROKERS is required")
-	}
-	return nil
-}
+		// Projection integrity verification (report only by default)
+		ProjectionVerifyInterval: src.getEnvDuration("CJ_PROJECTION_VERIFY_INTERVAL", 1*time.Hour),
+		ProjectionVerifyLimit:    src.getEnvInt("CJ_PROJECTION_VERIFY_LIMIT", 100),
+		ProjectionVerifyRebuild:  src.getEnvBool("CJ_PROJECTION_VERIFY_REBUILD", false),
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
+		// Projection state expression indexes (none by default)
+		ProjectionIndexes: src.getEnv("CJ_PROJECTION_INDEXES", ""),
 
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if i, err := strconv.Atoi(value); err == nil {
-			return i
-		}
-	}
-	return defaultValue
-}
+		// Projection TTL expiry (no TTLs, so nothing expires, by default)
+		ProjectionTTLs:          src.getEnv("CJ_PROJECTION_TTLS", ""),
+		ProjectionTTLSweepEvery: src.getEnvDuration("CJ_PROJECTION_TTL_SWEEP_INTERVAL", 1*time.Hour),
+		// Fold records last as long as events stay on the topics
+		// (CJ_TOPIC_RETENTION), so a consumer reset cannot redeliver an
+		// event whose record was purged
+		ProjectionFoldRetention: src.getEnvDuration("CJ_PROJECTION_FOLD_RETENTION", 7*24*time.Hour),
+
+		// Projection change feed (disabled by default)
+		ProjectionChangesTopic: src.getEnv("CJ_PROJECTION_CHANGES_TOPIC", ""),
+
+		// Search service (disabled by default)
+		SearchURL:           src.getEnv("CJ_SEARCH_URL", ""),
+		SearchUsername:      src.getEnv("CJ_SEARCH_USERNAME", ""),
+		SearchPassword:      src.getEnv("CJ_SEARCH_PASSWORD", ""),
+		SearchIndexPrefix:   src.getEnv("CJ_SEARCH_INDEX_PREFIX", "projections-"),
+		SearchConsumerGroup: src.getEnv("CJ_SEARCH_CONSUMER_GROUP", "search-indexer"),
+		SearchTimeout:       src.getEnvDuration("CJ_SEARCH_TIMEOUT", 10*time.Second),
+
+		// Sensor anomaly flags (value jumps are unit-dependent, so off by default)
+		SensorAnomalyMaxDelta: src.getEnvFloat("CJ_SENSOR_ANOMALY_MAX_DELTA", 0),
+		SensorAnomalyMaxGap:   src.getEnvDuration("CJ_SENSOR_ANOMALY_MAX_GAP", 5*time.Minute),
 
-func getEnvBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if b, err := strconv.ParseBool(value); err == nil {
-			return b
-		}
+		// Windowed sensor stats (disabled by default)
+		SensorWindow:         src.getEnvDuration("CJ_SENSOR_WINDOW", 0),
+		SensorWindowLateness: src.getEnvDuration("CJ_SENSOR_WINDOW_LATENESS", 1*time.Minute),
+
+		// Declarative rollup projections (none by default)
+		Rollups: src.getEnv("CJ_ROLLUPS", ""),
+
+		// Shadow projection handler kinds (none by default)
+		ShadowHandlers: src.getEnv("CJ_SHADOW_HANDLERS", ""),
+
+		// Aggregate registry (disabled by default)
+		AggregateRegistry: src.getEnvBool("CJ_AGGREGATE_REGISTRY", false),
+
+		// Query service event-history fallback (disabled by default)
+		QueryFallbackTypes: src.getEnv("CJ_QUERY_FALLBACK_TYPES", ""),
+		QueryFallbackHeal:  src.getEnvBool("CJ_QUERY_FALLBACK_HEAL", false),
+
+		// Query service event log long polling
+		QueryEventsPollInterval: src.getEnvDuration("CJ_QUERY_EVENTS_POLL_INTERVAL", 500*time.Millisecond),
+		QueryEventsMaxWait:      src.getEnvDuration("CJ_QUERY_EVENTS_MAX_WAIT", 30*time.Second),
+
+		// Query service GraphQL gateway (disabled by default)
+		QueryGraphQL: src.getEnvBool("CJ_QUERY_GRAPHQL", false),
+
+		// Sandbox mode
+		SandboxEventInterval: src.getEnvDuration("CJ_SANDBOX_EVENT_INTERVAL", 500*time.Millisecond),
+
+		// Feature flags
+		EnableTSDB:    src.getEnvBool("CJ_FEATURE_TSDB", false),
+		EnableActions: src.getEnvBool("CJ_FEATURE_ACTIONS", true),
+		EnableAudit:   src.getEnvBool("CJ_FEATURE_AUDIT", true),
 	}
-	return defaultValue
-}
 
-func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if d, err := time.ParseDuration(value); err == nil {
-			return d
-		}
+	cfg.settings = src.settings
+
+	// Report every problem at once: values that did not parse, then
+	// settings that are invalid
+	problems := append(src.problems(), cfg.problems()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
 	}
-	return defaultValue
+
+	return cfg, nil
 }
@@ -2,7 +2,10 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -11,38 +14,45 @@ import (
 func TestValidate(t *testing.T) {
 	tests := []struct {
 		name    string
-		cfg     *Config
+		modify  func(*Config)
 		wantErr bool
 		errMsg  string
 	}{
 		{
 			name:    "valid config",
-			cfg:     &Config{DatabaseURLIngestion: "postgres://localhost/db", RedpandaBrokers: "localhost:9092"},
+			modify:  func(*Config) {},
 			wantErr: false,
 		},
 		{
 			name:    "missing database URL",
-			cfg:     &Config{DatabaseURLIngestion: "", RedpandaBrokers: "localhost:9092"},
+			modify:  func(c *Config) { c.DatabaseURLIngestion = "" },
 			wantErr: true,
 			errMsg:  "CJ_INGESTION_DATABASE_URL is required",
 		},
 		{
 			name:    "missing Redpanda brokers",
-			cfg:     &Config{DatabaseURLIngestion: "postgres://localhost/db", RedpandaBrokers: ""},
+			modify:  func(c *Config) { c.RedpandaBrokers = "" },
 			wantErr: true,
 			errMsg:  "CJ_REDPANDA_BROKERS is required",
 		},
 		{
-			name:    "both missing - first error wins",
-			cfg:     &Config{DatabaseURLIngestion: "", RedpandaBrokers: ""},
+			name: "both missing - all errors reported",
+			modify: func(c *Config) {
+				c.DatabaseURLIngestion = ""
+				c.RedpandaBrokers = ""
+			},
 			wantErr: true,
-			errMsg:  "CJ_INGESTION_DATABASE_URL is required",
+			errMsg: "2 configuration problems:\n" +
+				"  - CJ_INGESTION_DATABASE_URL is required\n" +
+				"  - CJ_REDPANDA_BROKERS is required",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.cfg.validate()
+			cfg := validConfig(t)
+			tt.modify(cfg)
+			err := cfg.validate()
 			if tt.wantErr {
 				require.Error(t, err)
 				assert.Equal(t, tt.errMsg, err.Error())
@@ -53,6 +63,108 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+// validConfig returns the default configuration, which must validate.
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := load(&source{})
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestValidate_Fields(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		errMsg string
+	}{
+		{"unknown log level", func(c *Config) { c.LogLevel = "verbose" }, "CJ_LOG_LEVEL must be debug, info, warn or error"},
+		{"unknown log format", func(c *Config) { c.LogFormat = "xml" }, "CJ_LOG_FORMAT must be json or text"},
+		{"port out of range", func(c *Config) { c.PortQuery = 70000 }, "CJ_QUERY_PORT must be between 1 and 65535"},
+		{"zero port", func(c *Config) { c.PortIngestion = 0 }, "CJ_INGESTION_PORT must be between 1 and 65535"},
+		{"duplicate ports", func(c *Config) { c.PortActions = c.PortQuery }, "CJ_QUERY_PORT and CJ_ACTIONS_PORT must differ"},
+		{"unknown acks", func(c *Config) { c.RedpandaAcks = "some" }, "CJ_REDPANDA_ACKS must be all, leader or none"},
+		{"idempotent without acks=all", func(c *Config) { c.RedpandaAcks = "leader" }, "CJ_REDPANDA_IDEMPOTENT requires CJ_REDPANDA_ACKS=all"},
+		{"unknown compression", func(c *Config) { c.RedpandaCompression = "brotli" }, "CJ_REDPANDA_COMPRESSION must be"},
+		{"zero workers", func(c *Config) { c.OutboxWorkerCount = 0 }, "CJ_OUTBOX_WORKER_COUNT must be positive"},
+		{"negative retries", func(c *Config) { c.OutboxMaxRetries = -1 }, "CJ_OUTBOX_MAX_RETRIES must not be negative"},
+		{"zero poll interval", func(c *Config) { c.OutboxPollInterval = 0 }, "CJ_OUTBOX_POLL_INTERVAL must be positive"},
+		{"negative duration", func(c *Config) { c.QueryEventsMaxWait = -time.Second }, "CJ_QUERY_EVENTS_MAX_WAIT must not be negative"},
+		{"broker without port", func(c *Config) { c.RedpandaBrokers = "redpanda" }, `CJ_REDPANDA_BROKERS: invalid broker "redpanda": expected host:port`},
+		{"broker port out of range", func(c *Config) { c.RedpandaBrokers = "a:9092,b:99999" }, `invalid broker "b:99999": port must be between 1 and 65535`},
+		{"stray comma in brokers", func(c *Config) { c.RedpandaBrokers = "a:9092," }, `invalid broker "": empty address`},
+		{"space in brokers", func(c *Config) { c.RedpandaBrokers = "a:9092, b:9092" }, `invalid broker " b:9092": surrounding whitespace`},
+		{"bad default topic", func(c *Config) { c.TopicDefault = "system events" }, `CJ_TOPIC_DEFAULT: invalid topic name "system events": only letters`},
+		{"reserved topic", func(c *Config) { c.TopicDefault = ".." }, `".." is reserved`},
+		{"long topic", func(c *Config) { c.TopicDefault = strings.Repeat("t", 250) }, "longer than 249 characters"},
+		{"route without topic", func(c *Config) { c.TopicRoutes = "sensor." }, `CJ_TOPIC_ROUTES: invalid route "sensor.": expected prefix=topic`},
+		{"route to bad topic", func(c *Config) { c.TopicRoutes = "sensor.=sensor/events" }, `CJ_TOPIC_ROUTES: invalid topic name "sensor/events"`},
+		{"override for bad topic", func(c *Config) { c.TopicOverrides = "sensor events=12" }, `CJ_TOPIC_OVERRIDES: invalid topic name "sensor events"`},
+		{"stray comma in topics", func(c *Config) { c.EventHandlerTopics = "sensor-events," }, `CJ_EVENTHANDLER_TOPICS: invalid topic name "": empty name`},
+		{"poll interval above max wait", func(c *Config) { c.QueryEventsPollInterval = time.Minute }, "CJ_QUERY_EVENTS_POLL_INTERVAL (1m0s) must not exceed CJ_QUERY_EVENTS_MAX_WAIT (30s)"},
+		{"bad change topic", func(c *Config) { c.ProjectionChangesTopic = "projections changed" }, `CJ_PROJECTION_CHANGES_TOPIC: invalid topic name "projections changed"`},
+		{"change topic consumed", func(c *Config) { c.ProjectionChangesTopic = "user-actions" }, "CJ_PROJECTION_CHANGES_TOPIC: user-actions is consumed as an event topic"},
+		{"bad search URL", func(c *Config) { c.SearchURL = "localhost:9200"; c.ProjectionChangesTopic = "projections.changed" }, `CJ_SEARCH_URL must be an http or https URL, got "localhost:9200"`},
+		{"search without change topic", func(c *Config) { c.SearchURL = "http://localhost:9200" }, "CJ_PROJECTION_CHANGES_TOPIC is not, so nothing would be indexed"},
+		{"uppercase index prefix", func(c *Config) { c.SearchURL = "http://localhost:9200"; c.SearchIndexPrefix = "Projections-" }, `CJ_SEARCH_INDEX_PREFIX: invalid prefix "Projections-": only lowercase`},
+		{"TTLs without sweep", func(c *Config) { c.ProjectionTTLs = "sensor_state=720h"; c.ProjectionTTLSweepEvery = 0 }, "so nothing would expire"},
+		{"fold retention below topic retention", func(c *Config) { c.ProjectionFoldRetention = 24 * time.Hour }, "CJ_PROJECTION_FOLD_RETENTION (24h0m0s) must not be shorter than CJ_TOPIC_RETENTION (168h0m0s)"},
+		{"unknown SASL mechanism", func(c *Config) { c.RedpandaSASLMechanism = "GSSAPI" }, "CJ_REDPANDA_SASL_MECHANISM must be PLAIN"},
+		{"SASL without credentials", func(c *Config) { c.RedpandaSASLMechanism = "SCRAM-SHA-512" }, "requires CJ_REDPANDA_SASL_USERNAME and CJ_REDPANDA_SASL_PASSWORD"},
+		{"unknown PII KMS", func(c *Config) { c.PIIKMS = "gcp" }, "CJ_PII_KMS must be local or aws"},
+		{"local PII KMS without key", func(c *Config) { c.PIIKMS = "local" }, "requires CJ_PII_LOCAL_KEY"},
+		{"AWS PII KMS without key ID", func(c *Config) { c.PIIKMS = "aws"; c.PIIAWSRegion = "eu-west-1" }, "requires CJ_PII_AWS_REGION and CJ_PII_AWS_KEY_ID"},
+		{"PII decrypt keys without KMS", func(c *Config) { c.PIIDecryptAPIKeys = "k1" }, "so nothing can be decrypted"},
+		{"required signatures without keys", func(c *Config) { c.EventSignatureRequired = true }, "CJ_EVENT_SIGNATURE_REQUIRED needs CJ_EVENT_SIGNING_KEY"},
+		{"unknown event time policy", func(c *Config) { c.IngestionEventTimePolicy = "drop" }, "CJ_INGESTION_EVENT_TIME_POLICY must be reject or clamp"},
+		{"unknown event type mode", func(c *Config) { c.IngestionEventTypeMode = "lenient" }, "CJ_INGESTION_EVENT_TYPE_MODE must be permissive or strict"},
+		{"event type depth of one", func(c *Config) { c.IngestionEventTypeMaxDepth = 1 }, "CJ_INGESTION_EVENT_TYPE_MAX_DEPTH must be 0 or at least 2"},
+		{"bad registered pattern", func(c *Config) { c.IngestionEventTypes = "sensor.reading, Sensor.*" }, `CJ_INGESTION_EVENT_TYPES: invalid event type pattern "Sensor.*"`},
+		{"bad banned pattern", func(c *Config) { c.IngestionBannedEventTypes = "sensor" }, `CJ_INGESTION_BANNED_EVENT_TYPES: invalid event type "sensor"`},
+		{"unknown balancer", func(c *Config) { c.EventHandlerBalancer = "eager" }, "CJ_EVENTHANDLER_BALANCER must be cooperative-sticky, sticky, range or round-robin"},
+		{"unknown commit mode", func(c *Config) { c.EventHandlerCommit = "never" }, "CJ_EVENTHANDLER_COMMIT must be batch, record, interval or at-most-once"},
+		{"zero header limit", func(c *Config) { c.HTTPMaxHeaderBytes = 0 }, "CJ_HTTP_MAX_HEADER_BYTES must be positive"},
+		{"compression level out of range", func(c *Config) { c.HTTPCompressionLevel = 10 }, "CJ_HTTP_COMPRESSION_LEVEL must be between 0 and 9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.modify(cfg)
+			err := cfg.validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.PortQuery = cfg.PortIngestion
+	cfg.RedpandaAcks = "some"
+	cfg.OutboxBatchSize = 0
+
+	err := cfg.validate()
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []string{
+		"CJ_INGESTION_PORT and CJ_QUERY_PORT must differ, both are 8080",
+		`CJ_REDPANDA_ACKS must be all, leader or none, got "some"`,
+		"CJ_OUTBOX_BATCH_SIZE must be positive, got 0",
+	}, verr.Problems)
+	assert.True(t, strings.HasPrefix(err.Error(), "3 configuration problems:\n  - CJ_INGESTION_PORT"))
+}
+
+func TestValidate_OptionalAdminPort(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.PortEventHandlerAdmin = 0
+	assert.NoError(t, cfg.validate())
+
+	cfg.RedpandaAcks = "none"
+	cfg.RedpandaIdempotent = false
+	cfg.TopicRetention = -1 // retain forever
+	assert.NoError(t, cfg.validate())
+}
+
 func TestLoad_Defaults(t *testing.T) {
 	cfg, err := Load()
 	require.NoError(t, err)
@@ -61,10 +173,94 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, "json", cfg.LogFormat)
 	assert.Equal(t, 8080, cfg.PortIngestion)
 	assert.Equal(t, 8081, cfg.PortQuery)
+	assert.Equal(t, 6, cfg.HTTPCompressionLevel)
+	assert.Equal(t, 10*time.Second, cfg.HTTPReadTimeout)
+	assert.Equal(t, 5*time.Second, cfg.HTTPReadHeaderTimeout)
+	assert.Equal(t, 10*time.Second, cfg.HTTPWriteTimeout)
+	assert.Equal(t, 60*time.Second, cfg.HTTPIdleTimeout)
+	assert.Equal(t, 1<<20, cfg.HTTPMaxHeaderBytes)
+	assert.False(t, cfg.HTTPH2C)
+	assert.Equal(t, 5*time.Second, cfg.RequestTimeout)
+	assert.Empty(t, cfg.RequestTimeouts)
 	assert.Equal(t, 4, cfg.OutboxWorkerCount)
 	assert.Equal(t, 100, cfg.OutboxBatchSize)
 	assert.Equal(t, 5, cfg.OutboxMaxRetries)
 	assert.Equal(t, false, cfg.EnableTSDB)
+	assert.Equal(t, "sensor.=sensor-events,user.=user-actions", cfg.TopicRoutes)
+	assert.True(t, cfg.TopicsEnsure)
+	assert.Equal(t, 6, cfg.TopicPartitions)
+	assert.Equal(t, 1, cfg.TopicReplication)
+	assert.Equal(t, 7*24*time.Hour, cfg.TopicRetention)
+	assert.Equal(t, "system-events", cfg.TopicDefault)
+	assert.Equal(t, "all", cfg.RedpandaAcks)
+	assert.Equal(t, true, cfg.RedpandaIdempotent)
+	assert.True(t, cfg.RedpandaTombstones)
+	assert.Equal(t, false, cfg.OutboxAsyncSubmit)
+	assert.Equal(t, 10*time.Minute, cfg.OutboxVacuumInterval)
+	assert.Equal(t, 1000, cfg.OutboxVacuumMinDead)
+	assert.Equal(t, 24*time.Hour, cfg.OutboxReindexInterval)
+	assert.False(t, cfg.OutboxArchive)
+	assert.Equal(t, 7*24*time.Hour, cfg.OutboxArchiveRetention)
+	assert.Equal(t, 8, cfg.EventHandlerLanes)
+	assert.Equal(t, 256, cfg.EventHandlerQueueSize)
+	assert.Equal(t, 5, cfg.EventHandlerMaxAttempts)
+	assert.Equal(t, 1*time.Second, cfg.EventHandlerRetryDelay)
+	assert.Equal(t, "batch", cfg.EventHandlerCommit)
+	assert.Equal(t, 5*time.Second, cfg.EventHandlerCommitInterval)
+	assert.Empty(t, cfg.EventHandlerInstanceID)
+	assert.Equal(t, 45*time.Second, cfg.EventHandlerSessionTimeout)
+	assert.Equal(t, 60*time.Second, cfg.EventHandlerRebalanceTimeout)
+	assert.Equal(t, "cooperative-sticky", cfg.EventHandlerBalancer)
+	assert.Empty(t, cfg.EventHandlerAllow)
+	assert.Empty(t, cfg.EventHandlerDeny)
+	assert.True(t, cfg.EnableActions)
+	assert.True(t, cfg.EnableAudit)
+	assert.Empty(t, cfg.PIIRules)
+	assert.Empty(t, cfg.PIIKMS)
+	assert.Equal(t, time.Hour, cfg.PIIDataKeyTTL)
+	assert.Empty(t, cfg.EventSigningKey)
+	assert.False(t, cfg.EventSignatureRequired)
+	assert.Empty(t, cfg.IngestEnrichers)
+	assert.Equal(t, "metric", cfg.IngestUnits)
+	assert.Empty(t, cfg.DeviceRegistryFile)
+	assert.Equal(t, "actions", cfg.ActionsConsumerGroup)
+	assert.Equal(t, "sensor-events,user-actions,system-events", cfg.ActionsTopics)
+	assert.Equal(t, 10*time.Second, cfg.ActionsRuleReloadInterval)
+	assert.Empty(t, cfg.ActionsWebhookSecret)
+	assert.Equal(t, 5*time.Second, cfg.ActionsWebhookTimeout)
+	assert.Equal(t, 3, cfg.ActionsWebhookMaxAttempts)
+	assert.Equal(t, 5, cfg.ActionsWebhookBreakerThreshold)
+	assert.Equal(t, time.Minute, cfg.ActionsWebhookBreakerOpen)
+	assert.Equal(t, 10*time.Second, cfg.ActionsNotifyTimeout)
+	assert.Equal(t, "https://events.pagerduty.com/v2/enqueue", cfg.ActionsPagerDutyURL)
+	assert.Empty(t, cfg.ActionsSMTPHost)
+	assert.Equal(t, 587, cfg.ActionsSMTPPort)
+	assert.Equal(t, "cornjacket@localhost", cfg.ActionsSMTPFrom)
+	assert.Equal(t, time.Hour, cfg.ProjectionVerifyInterval)
+	assert.Equal(t, 100, cfg.ProjectionVerifyLimit)
+	assert.False(t, cfg.ProjectionVerifyRebuild)
+	assert.Equal(t, "", cfg.ProjectionTTLs)
+	assert.Equal(t, time.Hour, cfg.ProjectionTTLSweepEvery)
+	assert.Equal(t, 7*24*time.Hour, cfg.ProjectionFoldRetention)
+	assert.Equal(t, "", cfg.ProjectionChangesTopic)
+	assert.Equal(t, "", cfg.SearchURL)
+	assert.Equal(t, "projections-", cfg.SearchIndexPrefix)
+	assert.Equal(t, "search-indexer", cfg.SearchConsumerGroup)
+	assert.Equal(t, 10*time.Second, cfg.SearchTimeout)
+	assert.Equal(t, 0.0, cfg.SensorAnomalyMaxDelta)
+	assert.Equal(t, 5*time.Minute, cfg.SensorAnomalyMaxGap)
+	assert.Zero(t, cfg.SensorWindow)
+	assert.Equal(t, time.Minute, cfg.SensorWindowLateness)
+	assert.Empty(t, cfg.Rollups)
+	assert.Empty(t, cfg.ShadowHandlers)
+	assert.False(t, cfg.AggregateRegistry)
+	assert.Equal(t, 24*time.Hour, cfg.IngestionIdempotencyWindow)
+	assert.Equal(t, "permissive", cfg.IngestionEventTypeMode)
+	assert.Equal(t, 4, cfg.IngestionEventTypeMaxDepth)
+	assert.Empty(t, cfg.ProjectionIndexes)
+	assert.Equal(t, 500*time.Millisecond, cfg.QueryEventsPollInterval)
+	assert.Equal(t, 30*time.Second, cfg.QueryEventsMaxWait)
+	assert.False(t, cfg.QueryGraphQL)
 }
 
 func TestLoad_EnvOverrides(t *testing.T) {
@@ -91,3 +287,204 @@ func TestLoad_CustomDatabaseURL(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, customURL, cfg.DatabaseURLIngestion)
 }
+
+func TestLoad_InvalidEnvValue(t *testing.T) {
+	t.Setenv("CJ_INGESTION_PORT", "eighty")
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `CJ_INGESTION_PORT: invalid value "eighty" from environment: expected an integer`)
+}
+
+func TestLoad_ReportsParseAndValidationProblems(t *testing.T) {
+	t.Setenv("CJ_OUTBOX_WORKER_COUNT", "four")
+	t.Setenv("CJ_LOG_FORMAT", "xml")
+	t.Setenv("CJ_REDPANDA_BROKERS", "redpanda")
+
+	_, err := Load()
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []string{
+		`CJ_OUTBOX_WORKER_COUNT: invalid value "four" from environment: expected an integer`,
+		`CJ_REDPANDA_BROKERS: invalid broker "redpanda": expected host:port`,
+		`CJ_LOG_FORMAT must be json or text, got "xml"`,
+	}, verr.Problems)
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadFile_YAML(t *testing.T) {
+	path := writeConfigFile(t, "platform.yaml", `
+log_level: debug
+ingestion:
+  port: 9090
+  database_url: postgres://ingest:secret@db:5432/ingestion
+outbox:
+  worker_count: 2
+  poll_interval: 250ms
+feature:
+  tsdb: true
+eventhandler_topics: [sensor-events, user-actions]
+`)
+
+	cfg, err := LoadFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, 9090, cfg.PortIngestion)
+	assert.Equal(t, "postgres://ingest:secret@db:5432/ingestion", cfg.DatabaseURLIngestion)
+	assert.Equal(t, 2, cfg.OutboxWorkerCount)
+	assert.Equal(t, 250*time.Millisecond, cfg.OutboxPollInterval)
+	assert.True(t, cfg.EnableTSDB)
+	assert.Equal(t, "sensor-events,user-actions", cfg.EventHandlerTopics)
+	assert.Equal(t, 8081, cfg.PortQuery, "unset keys keep their defaults")
+}
+
+func TestLoadFile_TOML(t *testing.T) {
+	path := writeConfigFile(t, "platform.toml", `
+# Platform configuration
+log_format = "text"
+
+[query]
+port = 9091
+events.max_wait = "10s"
+
+[sensor.anomaly]
+max_delta = 12.5
+`)
+
+	cfg, err := LoadFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "text", cfg.LogFormat)
+	assert.Equal(t, 9091, cfg.PortQuery)
+	assert.Equal(t, 10*time.Second, cfg.QueryEventsMaxWait)
+	assert.Equal(t, 12.5, cfg.SensorAnomalyMaxDelta)
+}
+
+func TestLoadFile_EnvOverridesFile(t *testing.T) {
+	path := writeConfigFile(t, "platform.yaml", "ingestion_port: 9090\nquery_port: 9091\n")
+	t.Setenv("CJ_INGESTION_PORT", "7070")
+
+	cfg, err := LoadFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 7070, cfg.PortIngestion)
+	assert.Equal(t, 9091, cfg.PortQuery)
+
+	sources := make(map[string]string)
+	for _, s := range cfg.Settings() {
+		sources[s.Key] = s.Source
+	}
+	assert.Equal(t, SourceEnv, sources["CJ_INGESTION_PORT"])
+	assert.Equal(t, SourceFile, sources["CJ_QUERY_PORT"])
+	assert.Equal(t, SourceDefault, sources["CJ_ACTIONS_PORT"])
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		errMsg  string
+	}{
+		{"unknown key", "platform.yaml", "ingestion:\n  prot: 9090\n", "unknown setting ingestion_prot"},
+		{"bad value", "platform.yaml", "query_port: lots\n", `CJ_QUERY_PORT: invalid value "lots" from`},
+		{"invalid after merge", "platform.yaml", "log_level: loud\n", "CJ_LOG_LEVEL must be"},
+		{"unsupported extension", "platform.json", "{}", `unsupported config file extension ".json"`},
+		{"malformed yaml", "platform.yaml", "ingestion: [", "failed to parse config file"},
+		{"unsupported toml", "platform.toml", "query = { port = 1 }\n", "inline tables are not supported"},
+		{"duplicate key", "platform.yaml", "query_port: 1\nquery:\n  port: 2\n", "query_port is set more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFile(writeConfigFile(t, tt.file, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	_, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read config file")
+}
+
+func TestSettings_RedactsSecrets(t *testing.T) {
+	t.Setenv("CJ_ACTIONS_WEBHOOK_SECRET", "hunter2")
+	t.Setenv("CJ_QUERY_DATABASE_URL", "postgres://query:s3cret@db:5432/query")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	values := make(map[string]string)
+	for _, s := range cfg.Settings() {
+		values[s.Key] = s.Value
+	}
+	assert.Equal(t, Redacted, values["CJ_ACTIONS_WEBHOOK_SECRET"])
+	assert.Equal(t, "", values["CJ_ACTIONS_SMTP_PASSWORD"], "unset secrets stay empty")
+	assert.Equal(t, "postgres://query:xxxxx@db:5432/query", values["CJ_QUERY_DATABASE_URL"])
+	assert.Equal(t, "8080", values["CJ_INGESTION_PORT"])
+	assert.Equal(t, "hunter2", cfg.ActionsWebhookSecret, "the config itself keeps the secret")
+}
+
+func TestParseTOML(t *testing.T) {
+	doc, err := parseTOML([]byte(`
+top = 'literal \n'
+escaped = "a\"b\tc" # trailing comment
+"quoted.key" = 1_000
+hex = 0x10
+ratio = -0.5
+on = true
+list = ["a", 'b', 3]
+empty = []
+
+[outer.inner]
+x = false
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"top":        `literal \n`,
+		"escaped":    "a\"b\tc",
+		"quoted.key": int64(1000),
+		"hex":        int64(16),
+		"ratio":      -0.5,
+		"on":         true,
+		"list":       []any{"a", "b", int64(3)},
+		"empty":      []any(nil),
+		"outer":      map[string]any{"inner": map[string]any{"x": false}},
+	}, doc)
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	tests := []struct {
+		input  string
+		errMsg string
+	}{
+		{"a = ", "line 1: expected value"},
+		{"a = 1\na = 2", "line 2: duplicate key \"a\""},
+		{"a = 1\n[a]", "line 2: key \"a\" is already a value"},
+		{"[[servers]]", "arrays of tables are not supported"},
+		{`a = """x"""`, "multi-line strings are not supported"},
+		{"a = [1,\n2]", "unterminated array"},
+		{"a = [[1]]", "nested arrays are not supported"},
+		{"a = 1979-05-27", "unsupported value"},
+		{`a = "open`, "unterminated string"},
+		{"a = 1 b", "unexpected"},
+		{"= 1", "expected key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := parseTOML([]byte(tt.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
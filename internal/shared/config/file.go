@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const envPrefix = "CJ_"
+
+// readFile parses a YAML or TOML config file into environment-style keys, so
+// that {ingestion: {port: 8080}} and ingestion_port: 8080 both become
+// CJ_INGESTION_PORT=8080.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		doc, err = parseTOML(data)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q (want .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten(values, "", doc); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, doc map[string]any) error {
+	for k, v := range doc {
+		key := strings.ToUpper(strings.ReplaceAll(k, "-", "_"))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		if nested, ok := v.(map[string]any); ok {
+			if err := flatten(values, key, nested); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := scalar(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.ToLower(key), err)
+		}
+		if !strings.HasPrefix(key, envPrefix) {
+			key = envPrefix + key
+		}
+		if _, dup := values[key]; dup {
+			return fmt.Errorf("%s is set more than once", fileKey(key))
+		}
+		values[key] = value
+	}
+	return nil
+}
+
+// scalar formats a file value the way it would be written in the
+// environment; lists become comma-separated.
+func scalar(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			if _, ok := item.(map[string]any); ok {
+				return "", fmt.Errorf("tables are not allowed in lists")
+			}
+			s, err := scalar(item)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T", v)
+	}
+}
+
+// fileKey turns CJ_INGESTION_PORT back into ingestion_port for messages
+// about the config file.
+func fileKey(key string) string {
+	return strings.ToLower(strings.TrimPrefix(key, envPrefix))
+}
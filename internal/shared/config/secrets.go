@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cornjacket/platform-services/internal/shared/secrets"
+)
+
+// secretFields returns the settings that may hold secret references.
+// References anywhere else are rejected rather than passed on verbatim.
+func (c *Config) secretFields() map[string]*string {
+	return map[string]*string{
+		"CJ_INGESTION_DATABASE_URL":    &c.DatabaseURLIngestion,
+		"CJ_EVENTHANDLER_DATABASE_URL": &c.DatabaseURLEventHandler,
+		"CJ_QUERY_DATABASE_URL":        &c.DatabaseURLQuery,
+		"CJ_TSDB_DATABASE_URL":         &c.DatabaseURLTSDB,
+		"CJ_ACTIONS_DATABASE_URL":      &c.DatabaseURLActions,
+		"CJ_REDPANDA_SASL_USERNAME":    &c.RedpandaSASLUsername,
+		"CJ_REDPANDA_SASL_PASSWORD":    &c.RedpandaSASLPassword,
+		"CJ_INGESTION_TEST_API_KEYS":   &c.IngestionTestAPIKeys,
+		"CJ_INGESTION_REPLAY_API_KEYS": &c.IngestionReplayAPIKeys,
+		"CJ_API_KEYS":                  &c.APIKeys,
+		"CJ_ACTIONS_WEBHOOK_SECRET":    &c.ActionsWebhookSecret,
+		"CJ_ACTIONS_SMTP_USERNAME":     &c.ActionsSMTPUsername,
+		"CJ_ACTIONS_SMTP_PASSWORD":     &c.ActionsSMTPPassword,
+		"CJ_SEARCH_USERNAME":           &c.SearchUsername,
+		"CJ_SEARCH_PASSWORD":           &c.SearchPassword,
+		"CJ_SECRETS_VAULT_TOKEN":       &c.SecretsVaultToken,
+		"CJ_PII_HASH_KEY":              &c.PIIHashKey,
+		"CJ_PII_LOCAL_KEY":             &c.PIILocalKey,
+		"CJ_PII_DECRYPT_API_KEYS":      &c.PIIDecryptAPIKeys,
+		"CJ_EVENT_SIGNING_KEY":         &c.EventSigningKey,
+		"CJ_EVENT_VERIFY_KEYS":         &c.EventVerifyKeys,
+	}
+}
+
+// ResolveSecrets replaces ${provider:path#key} references with the secrets
+// they name. Secrets substituted into database URLs are percent-encoded.
+// The references are kept (see SecretRef) so credentials that rotate can be
+// resolved again later.
+func (c *Config) ResolveSecrets(ctx context.Context, r *secrets.Resolver) error {
+	fields := c.secretFields()
+	for _, s := range c.settings {
+		if !secrets.HasRefs(s.Value) {
+			continue
+		}
+		field, ok := fields[s.Key]
+		if !ok {
+			return fmt.Errorf("%s does not support secret references", s.Key)
+		}
+
+		expand := r.Expand
+		if strings.HasSuffix(s.Key, "_DATABASE_URL") {
+			expand = r.ExpandURL
+		}
+		value, err := expand(ctx, s.Value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.Key, err)
+		}
+		*field = value
+
+		if c.secretRefs == nil {
+			c.secretRefs = make(map[string]string)
+		}
+		c.secretRefs[s.Key] = s.Value
+	}
+	return nil
+}
+
+// SecretRef returns the reference the setting held before ResolveSecrets,
+// or "" if it held a plain value.
+func (c *Config) SecretRef(key string) string {
+	return c.secretRefs[key]
+}
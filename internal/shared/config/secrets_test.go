@@ -0,0 +1,77 @@
+package config
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/secrets"
+)
+
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func fileResolver() *secrets.Resolver {
+	r := secrets.NewResolver(time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	r.Register(secrets.ProviderFile, secrets.FileProvider{})
+	return r
+}
+
+func TestResolveSecrets(t *testing.T) {
+	dbRef := "postgres://ingest:${file:" + writeSecret(t, "p@ss/word\n") + "}@db:5432/ingestion"
+	saslRef := "${file:" + writeSecret(t, `{"username":"platform","password":"s3cret"}`) + "#password}"
+	t.Setenv("CJ_INGESTION_DATABASE_URL", dbRef)
+	t.Setenv("CJ_REDPANDA_SASL_MECHANISM", "SCRAM-SHA-256")
+	t.Setenv("CJ_REDPANDA_SASL_USERNAME", "platform")
+	t.Setenv("CJ_REDPANDA_SASL_PASSWORD", saslRef)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.NoError(t, cfg.ResolveSecrets(context.Background(), fileResolver()))
+
+	assert.Equal(t, "postgres://ingest:p%40ss%2Fword@db:5432/ingestion", cfg.DatabaseURLIngestion)
+	assert.Equal(t, "s3cret", cfg.RedpandaSASLPassword)
+	assert.Equal(t, "platform", cfg.RedpandaSASLUsername)
+
+	assert.Equal(t, dbRef, cfg.SecretRef("CJ_INGESTION_DATABASE_URL"))
+	assert.Equal(t, saslRef, cfg.SecretRef("CJ_REDPANDA_SASL_PASSWORD"))
+	assert.Empty(t, cfg.SecretRef("CJ_REDPANDA_SASL_USERNAME"))
+
+	// Settings show the references, which name no secret
+	values := make(map[string]string)
+	for _, s := range cfg.Settings() {
+		values[s.Key] = s.Value
+	}
+	assert.Equal(t, dbRef, values["CJ_INGESTION_DATABASE_URL"])
+	assert.Equal(t, saslRef, values["CJ_REDPANDA_SASL_PASSWORD"])
+}
+
+func TestResolveSecrets_Errors(t *testing.T) {
+	t.Run("unsupported setting", func(t *testing.T) {
+		t.Setenv("CJ_ACTIONS_CONSUMER_GROUP", "${file:/run/secrets/topic}")
+		cfg, err := Load()
+		require.NoError(t, err)
+		err = cfg.ResolveSecrets(context.Background(), fileResolver())
+		assert.EqualError(t, err, "CJ_ACTIONS_CONSUMER_GROUP does not support secret references")
+	})
+
+	t.Run("unconfigured provider", func(t *testing.T) {
+		t.Setenv("CJ_ACTIONS_SMTP_PASSWORD", "${vault:secret/data/smtp#password}")
+		cfg, err := Load()
+		require.NoError(t, err)
+		err = cfg.ResolveSecrets(context.Background(), fileResolver())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `CJ_ACTIONS_SMTP_PASSWORD: failed to resolve ${vault:secret/data/smtp#password}: secrets provider "vault" is not configured`)
+	})
+}
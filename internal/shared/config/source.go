@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/secrets"
+)
+
+// Setting sources, from lowest to highest precedence.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// Setting is one configuration value as resolved by Load or LoadFile.
+type Setting struct {
+	Key    string // environment variable name, e.g. CJ_INGESTION_PORT
+	Value  string
+	Source string // SourceDefault, SourceFile or SourceEnv
+}
+
+// secretSettings hold credentials; Settings redacts them. Database URLs are
+// redacted separately, keeping everything but the password.
+var secretSettings = map[string]bool{
+	"CJ_INGESTION_TEST_API_KEYS":   true,
+	"CJ_INGESTION_REPLAY_API_KEYS": true,
+	"CJ_API_KEYS":                  true,
+	"CJ_ACTIONS_WEBHOOK_SECRET":    true,
+	"CJ_ACTIONS_SMTP_PASSWORD":     true,
+	"CJ_REDPANDA_SASL_PASSWORD":    true,
+	"CJ_SEARCH_PASSWORD":           true,
+	"CJ_SECRETS_VAULT_TOKEN":       true,
+	"CJ_PII_HASH_KEY":              true,
+	"CJ_PII_LOCAL_KEY":             true,
+	"CJ_PII_DECRYPT_API_KEYS":      true,
+	"CJ_EVENT_SIGNING_KEY":         true,
+	"CJ_EVENT_VERIFY_KEYS":         true,
+}
+
+// Redacted replaces secret values in Settings.
+const Redacted = "<redacted>"
+
+// Settings returns every configuration value, sorted by key, with secrets
+// redacted. Used by `platform config print`.
+func (c *Config) Settings() []Setting {
+	settings := make([]Setting, len(c.settings))
+	for i, s := range c.settings {
+		s.Value = redact(s.Key, s.Value)
+		settings[i] = s
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+func redact(key, value string) string {
+	// References name where a secret lives, not the secret itself
+	if value == "" || secrets.HasRefs(value) {
+		return value
+	}
+	if secretSettings[key] {
+		return Redacted
+	}
+	if strings.HasSuffix(key, "_DATABASE_URL") {
+		u, err := url.Parse(value)
+		if err != nil {
+			return Redacted
+		}
+		return u.Redacted()
+	}
+	return value
+}
+
+// source resolves settings from the environment, then the config file, then
+// the defaults, recording where each value came from and every value that
+// does not parse.
+type source struct {
+	file map[string]string // from readFile; nil without a config file
+	path string
+
+	settings []Setting
+	errs     []string
+}
+
+// lookup returns the raw value of key and its source; ok is false when
+// neither the environment nor the file sets it. An empty environment
+// variable counts as unset, as it always has.
+func (s *source) lookup(key string) (value, from string, ok bool) {
+	if v := os.Getenv(key); v != "" {
+		return v, SourceEnv, true
+	}
+	if v, found := s.file[key]; found {
+		return v, SourceFile, true
+	}
+	return "", SourceDefault, false
+}
+
+func (s *source) set(key, value, from string) {
+	s.settings = append(s.settings, Setting{Key: key, Value: value, Source: from})
+}
+
+func (s *source) invalid(key, value, from, want string) {
+	where := "environment"
+	if from == SourceFile {
+		where = s.path
+	}
+	s.errs = append(s.errs, fmt.Sprintf("%s: invalid value %q from %s: expected %s", key, value, where, want))
+}
+
+// problems reports values that did not parse and file keys that are not
+// settings.
+func (s *source) problems() []string {
+	known := make(map[string]bool, len(s.settings))
+	for _, setting := range s.settings {
+		known[setting.Key] = true
+	}
+	var unknown []string
+	for key := range s.file {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		s.errs = append(s.errs, fmt.Sprintf("%s: unknown setting %s", s.path, fileKey(key)))
+	}
+	return s.errs
+}
+
+func (s *source) getEnv(key, defaultValue string) string {
+	value, from, ok := s.lookup(key)
+	if !ok {
+		value = defaultValue
+	}
+	s.set(key, value, from)
+	return value
+}
+
+func (s *source) getEnvInt(key string, defaultValue int) int {
+	value, from, ok := s.lookup(key)
+	if !ok {
+		s.set(key, strconv.Itoa(defaultValue), from)
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		s.invalid(key, value, from, "an integer")
+		return defaultValue
+	}
+	s.set(key, value, from)
+	return i
+}
+
+func (s *source) getEnvBool(key string, defaultValue bool) bool {
+	value, from, ok := s.lookup(key)
+	if !ok {
+		s.set(key, strconv.FormatBool(defaultValue), from)
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		s.invalid(key, value, from, "true or false")
+		return defaultValue
+	}
+	s.set(key, value, from)
+	return b
+}
+
+func (s *source) getEnvFloat(key string, defaultValue float64) float64 {
+	value, from, ok := s.lookup(key)
+	if !ok {
+		s.set(key, strconv.FormatFloat(defaultValue, 'g', -1, 64), from)
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		s.invalid(key, value, from, "a number")
+		return defaultValue
+	}
+	s.set(key, value, from)
+	return f
+}
+
+func (s *source) getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, from, ok := s.lookup(key)
+	if !ok {
+		s.set(key, defaultValue.String(), from)
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		s.invalid(key, value, from, "a duration such as 30s or 5m")
+		return defaultValue
+	}
+	s.set(key, value, from)
+	return d
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parses the subset of TOML that config files need: [tables] and
+// [dotted.tables], bare, quoted and dotted keys, basic and literal strings,
+// integers, floats, booleans and single-line arrays of those. Anything else
+// (multi-line strings, inline tables, arrays of tables, dates) is an error
+// rather than being silently misread.
+func parseTOML(data []byte) (map[string]any, error) {
+	root := make(map[string]any)
+	table := root
+	for n, line := range strings.Split(string(data), "\n") {
+		p := &tomlParser{s: strings.TrimSpace(line)}
+		if err := p.line(root, &table); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+	}
+	return root, nil
+}
+
+type tomlParser struct {
+	s   string
+	pos int
+}
+
+func (p *tomlParser) line(root map[string]any, table *map[string]any) error {
+	p.skipSpace()
+	if p.done() {
+		return nil
+	}
+	if p.peek() == '[' {
+		p.pos++
+		if p.peek() == '[' {
+			return fmt.Errorf("arrays of tables are not supported")
+		}
+		keys, err := p.keys()
+		if err != nil {
+			return err
+		}
+		if !p.consume(']') {
+			return fmt.Errorf("expected ] after table name")
+		}
+		t, err := subtable(root, keys)
+		if err != nil {
+			return err
+		}
+		*table = t
+		return p.end()
+	}
+
+	keys, err := p.keys()
+	if err != nil {
+		return err
+	}
+	if !p.consume('=') {
+		return fmt.Errorf("expected = after key")
+	}
+	p.skipSpace()
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+	t, err := subtable(*table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, dup := t[last]; dup {
+		return fmt.Errorf("duplicate key %q", last)
+	}
+	t[last] = value
+	return p.end()
+}
+
+func subtable(t map[string]any, keys []string) (map[string]any, error) {
+	for _, k := range keys {
+		next, ok := t[k]
+		if !ok {
+			nt := make(map[string]any)
+			t[k] = nt
+			t = nt
+			continue
+		}
+		nt, ok := next.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("key %q is already a value", k)
+		}
+		t = nt
+	}
+	return t, nil
+}
+
+// keys parses a possibly dotted key: a.b, "a.b".c, 'x'.
+func (p *tomlParser) keys() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		var key string
+		var err error
+		switch p.peek() {
+		case '"':
+			key, err = p.basicString()
+		case '\'':
+			key, err = p.literalString()
+		default:
+			start := p.pos
+			for !p.done() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			key = p.s[start:p.pos]
+			if key == "" {
+				return nil, fmt.Errorf("expected key")
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		p.skipSpace()
+		if !p.consume('.') {
+			return keys, nil
+		}
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) value() (any, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		if strings.HasPrefix(p.s[p.pos:], `"""`) {
+			return nil, fmt.Errorf("multi-line strings are not supported")
+		}
+		return p.basicString()
+	case c == '\'':
+		if strings.HasPrefix(p.s[p.pos:], `'''`) {
+			return nil, fmt.Errorf("multi-line strings are not supported")
+		}
+		return p.literalString()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return nil, fmt.Errorf("inline tables are not supported")
+	default:
+		start := p.pos
+		for !p.done() && !strings.ContainsRune(" \t,]#", rune(p.peek())) {
+			p.pos++
+		}
+		return tomlScalar(p.s[start:p.pos])
+	}
+}
+
+func tomlScalar(tok string) (any, error) {
+	switch tok {
+	case "":
+		return nil, fmt.Errorf("expected value")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	clean := strings.ReplaceAll(tok, "_", "")
+	if i, err := strconv.ParseInt(clean, 0, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(clean, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported value %q", tok)
+}
+
+func (p *tomlParser) array() ([]any, error) {
+	p.pos++ // [
+	var items []any
+	for {
+		p.skipSpace()
+		if p.consume(']') {
+			return items, nil
+		}
+		if p.done() {
+			return nil, fmt.Errorf("unterminated array (multi-line arrays are not supported)")
+		}
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if _, nested := item.([]any); nested {
+			return nil, fmt.Errorf("nested arrays are not supported")
+		}
+		items = append(items, item)
+		p.skipSpace()
+		if !p.consume(',') && p.peek() != ']' {
+			return nil, fmt.Errorf("expected , or ] in array")
+		}
+	}
+}
+
+func (p *tomlParser) basicString() (string, error) {
+	p.pos++ // "
+	var b strings.Builder
+	for !p.done() {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.done() {
+				return "", fmt.Errorf("unterminated string")
+			}
+			esc := p.s[p.pos]
+			p.pos++
+			switch esc {
+			case '"', '\\':
+				b.WriteByte(esc)
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				return "", fmt.Errorf("unsupported escape \\%c", esc)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *tomlParser) literalString() (string, error) {
+	p.pos++ // '
+	end := strings.IndexByte(p.s[p.pos:], '\'')
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string")
+	}
+	s := p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// end accepts only trailing whitespace or a comment.
+func (p *tomlParser) end() error {
+	p.skipSpace()
+	if p.done() || p.peek() == '#' {
+		return nil
+	}
+	return fmt.Errorf("unexpected %q", p.s[p.pos:])
+}
+
+func (p *tomlParser) skipSpace() {
+	for !p.done() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+	if !p.done() && p.s[p.pos] == '#' {
+		p.pos = len(p.s)
+	}
+}
+
+func (p *tomlParser) consume(c byte) bool {
+	if !p.done() && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *tomlParser) done() bool { return p.pos >= len(p.s) }
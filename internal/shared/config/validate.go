@@ -0,0 +1,421 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// ValidationError lists every problem found in a configuration, so that
+// operators can fix them all in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0]
+	}
+	return fmt.Sprintf("%d configuration problems:\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+func (c *Config) validate() error {
+	if problems := c.problems(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// problems checks every setting, required ones first.
+func (c *Config) problems() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.DatabaseURLIngestion == "" {
+		add("CJ_INGESTION_DATABASE_URL is required")
+	}
+
+	if c.RedpandaBrokers == "" {
+		add("CJ_REDPANDA_BROKERS is required")
+	} else {
+		for _, broker := range strings.Split(c.RedpandaBrokers, ",") {
+			if err := validBroker(broker); err != nil {
+				add("CJ_REDPANDA_BROKERS: invalid broker %q: %v", broker, err)
+			}
+		}
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		add("CJ_LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+	switch c.LogFormat {
+	case "json", "text":
+	default:
+		add("CJ_LOG_FORMAT must be json or text, got %q", c.LogFormat)
+	}
+
+	ports := []struct {
+		key      string
+		port     int
+		optional bool // 0 disables the listener
+	}{
+		{"CJ_INGESTION_PORT", c.PortIngestion, false},
+		{"CJ_QUERY_PORT", c.PortQuery, false},
+		{"CJ_ACTIONS_PORT", c.PortActions, false},
+		{"CJ_EVENTHANDLER_ADMIN_PORT", c.PortEventHandlerAdmin, true},
+		{"CJ_METRICS_PORT", c.PortMetrics, true},
+	}
+	used := make(map[int]string, len(ports))
+	for _, p := range ports {
+		if p.optional && p.port == 0 {
+			continue
+		}
+		if p.port < 1 || p.port > 65535 {
+			add("%s must be between 1 and 65535, got %d", p.key, p.port)
+			continue
+		}
+		if other, ok := used[p.port]; ok {
+			add("%s and %s must differ, both are %d", other, p.key, p.port)
+		}
+		used[p.port] = p.key
+	}
+
+	// Mirrors redpanda.ProducerConfig, so a bad value fails at load time
+	// rather than when the producer starts.
+	switch c.RedpandaAcks {
+	case "all", "leader", "none":
+		if c.RedpandaIdempotent && c.RedpandaAcks != "all" {
+			add("CJ_REDPANDA_IDEMPOTENT requires CJ_REDPANDA_ACKS=all, got %q", c.RedpandaAcks)
+		}
+	default:
+		add("CJ_REDPANDA_ACKS must be all, leader or none, got %q", c.RedpandaAcks)
+	}
+	switch c.RedpandaCompression {
+	case "", "none", "gzip", "snappy", "lz4", "zstd":
+	default:
+		add("CJ_REDPANDA_COMPRESSION must be none, gzip, snappy, lz4 or zstd, got %q", c.RedpandaCompression)
+	}
+	switch c.RedpandaSASLMechanism {
+	case "":
+	case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+		if c.RedpandaSASLUsername == "" || c.RedpandaSASLPassword == "" {
+			add("CJ_REDPANDA_SASL_MECHANISM requires CJ_REDPANDA_SASL_USERNAME and CJ_REDPANDA_SASL_PASSWORD")
+		}
+	default:
+		add("CJ_REDPANDA_SASL_MECHANISM must be PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, got %q", c.RedpandaSASLMechanism)
+	}
+
+	switch c.PIIKMS {
+	case "":
+	case "local":
+		if c.PIILocalKey == "" {
+			add("CJ_PII_KMS=local requires CJ_PII_LOCAL_KEY")
+		}
+	case "aws":
+		if c.PIIAWSRegion == "" || c.PIIAWSKeyID == "" {
+			add("CJ_PII_KMS=aws requires CJ_PII_AWS_REGION and CJ_PII_AWS_KEY_ID")
+		}
+	default:
+		add("CJ_PII_KMS must be local or aws, got %q", c.PIIKMS)
+	}
+	if c.PIIKMS != "" && c.PIIDataKeyTTL <= 0 {
+		add("CJ_PII_DATA_KEY_TTL must be positive, got %s", c.PIIDataKeyTTL)
+	}
+	if c.PIIDecryptAPIKeys != "" && c.PIIKMS == "" {
+		add("CJ_PII_DECRYPT_API_KEYS is set but CJ_PII_KMS is not, so nothing can be decrypted")
+	}
+	if c.EventSignatureRequired && c.EventSigningKey == "" && c.EventVerifyKeys == "" {
+		add("CJ_EVENT_SIGNATURE_REQUIRED needs CJ_EVENT_SIGNING_KEY or CJ_EVENT_VERIFY_KEYS to verify with")
+	}
+
+	// Topic names, wherever they appear
+	checkTopic := func(key, topic string) {
+		if err := validTopic(topic); err != nil {
+			add("%s: invalid topic name %q: %v", key, topic, err)
+		}
+	}
+	checkTopic("CJ_TOPIC_DEFAULT", c.TopicDefault)
+	for _, route := range nonEmpty(c.TopicRoutes) {
+		if _, topic, ok := strings.Cut(route, "="); !ok {
+			add("CJ_TOPIC_ROUTES: invalid route %q: expected prefix=topic", route)
+		} else {
+			checkTopic("CJ_TOPIC_ROUTES", strings.TrimSpace(topic))
+		}
+	}
+	for _, override := range nonEmpty(c.TopicOverrides) {
+		if topic, _, ok := strings.Cut(override, "="); !ok {
+			add("CJ_TOPIC_OVERRIDES: invalid override %q: expected topic=partitions[:replication[:retention]]", override)
+		} else {
+			checkTopic("CJ_TOPIC_OVERRIDES", topic)
+		}
+	}
+	// Consumed topic lists are split without trimming, so every entry counts
+	for _, topic := range strings.Split(c.EventHandlerTopics, ",") {
+		checkTopic("CJ_EVENTHANDLER_TOPICS", topic)
+	}
+	for _, topic := range strings.Split(c.ActionsTopics, ",") {
+		checkTopic("CJ_ACTIONS_TOPICS", topic)
+	}
+	if c.ProjectionChangesTopic != "" {
+		checkTopic("CJ_PROJECTION_CHANGES_TOPIC", c.ProjectionChangesTopic)
+		// Change records are not events; a consumer of the topic would fail on them
+		if slices.Contains(strings.Split(c.EventHandlerTopics, ","), c.ProjectionChangesTopic) ||
+			slices.Contains(strings.Split(c.ActionsTopics, ","), c.ProjectionChangesTopic) {
+			add("CJ_PROJECTION_CHANGES_TOPIC: %s is consumed as an event topic", c.ProjectionChangesTopic)
+		}
+	}
+
+	positive := []struct {
+		key   string
+		value int
+	}{
+		{"CJ_TOPIC_PARTITIONS", c.TopicPartitions},
+		{"CJ_TOPIC_REPLICATION", c.TopicReplication},
+		{"CJ_OUTBOX_WORKER_COUNT", c.OutboxWorkerCount},
+		{"CJ_OUTBOX_BATCH_SIZE", c.OutboxBatchSize},
+		{"CJ_EVENTHANDLER_LANES", c.EventHandlerLanes},
+		{"CJ_EVENTHANDLER_QUEUE_SIZE", c.EventHandlerQueueSize},
+		{"CJ_ACTIONS_WEBHOOK_MAX_ATTEMPTS", c.ActionsWebhookMaxAttempts},
+		{"CJ_ACTIONS_WEBHOOK_BREAKER_THRESHOLD", c.ActionsWebhookBreakerThreshold},
+		{"CJ_PROJECTION_VERIFY_LIMIT", c.ProjectionVerifyLimit},
+		{"CJ_DB_RETRY_MAX_ATTEMPTS", c.DBRetryMaxAttempts},
+		{"CJ_HTTP_MAX_HEADER_BYTES", c.HTTPMaxHeaderBytes},
+	}
+	for _, p := range positive {
+		if p.value < 1 {
+			add("%s must be positive, got %d", p.key, p.value)
+		}
+	}
+	nonNegative := []struct {
+		key   string
+		value int
+	}{
+		{"CJ_REDPANDA_BATCH_MAX_BYTES", c.RedpandaBatchMaxBytes},
+		{"CJ_OUTBOX_MAX_RETRIES", c.OutboxMaxRetries},
+		{"CJ_OUTBOX_VACUUM_MIN_DEAD", c.OutboxVacuumMinDead},
+		{"CJ_DB_BREAKER_THRESHOLD", c.DBBreakerThreshold},
+		{"CJ_INGESTION_BACKPRESSURE_MAX_DEPTH", c.IngestionBackpressureMaxDepth},
+		{"CJ_EVENTHANDLER_MAX_ATTEMPTS", c.EventHandlerMaxAttempts},
+	}
+	for _, n := range nonNegative {
+		if n.value < 0 {
+			add("%s must not be negative, got %d", n.key, n.value)
+		}
+	}
+	if c.ActionsSMTPPort < 1 || c.ActionsSMTPPort > 65535 {
+		add("CJ_ACTIONS_SMTP_PORT must be between 1 and 65535, got %d", c.ActionsSMTPPort)
+	}
+	if c.HTTPCompressionLevel < 0 || c.HTTPCompressionLevel > 9 {
+		add("CJ_HTTP_COMPRESSION_LEVEL must be between 0 and 9, got %d", c.HTTPCompressionLevel)
+	}
+	if c.SensorAnomalyMaxDelta < 0 {
+		add("CJ_SENSOR_ANOMALY_MAX_DELTA must not be negative, got %g", c.SensorAnomalyMaxDelta)
+	}
+	if c.IngestionEventTimePolicy != "reject" && c.IngestionEventTimePolicy != "clamp" {
+		add("CJ_INGESTION_EVENT_TIME_POLICY must be reject or clamp, got %q", c.IngestionEventTimePolicy)
+	}
+	if c.IngestionEventTypeMode != "permissive" && c.IngestionEventTypeMode != "strict" {
+		add("CJ_INGESTION_EVENT_TYPE_MODE must be permissive or strict, got %q", c.IngestionEventTypeMode)
+	}
+	if depth := c.IngestionEventTypeMaxDepth; depth != 0 && depth < events.MinEventTypeDepth {
+		add("CJ_INGESTION_EVENT_TYPE_MAX_DEPTH must be 0 or at least %d, got %d", events.MinEventTypeDepth, depth)
+	}
+	// Registry patterns follow the naming rules even while they are off
+	patternDepth := max(c.IngestionEventTypeMaxDepth, events.DefaultMaxEventTypeDepth)
+	for _, key := range []struct{ name, list string }{
+		{"CJ_INGESTION_EVENT_TYPES", c.IngestionEventTypes},
+		{"CJ_INGESTION_BANNED_EVENT_TYPES", c.IngestionBannedEventTypes},
+	} {
+		for _, pattern := range nonEmpty(key.list) {
+			if err := events.ValidateEventTypePattern(pattern, patternDepth); err != nil {
+				add("%s: %v", key.name, err)
+			}
+		}
+	}
+	switch c.EventHandlerCommit {
+	case "batch", "record", "interval", "at-most-once":
+	default:
+		add("CJ_EVENTHANDLER_COMMIT must be batch, record, interval or at-most-once, got %q", c.EventHandlerCommit)
+	}
+	switch c.EventHandlerBalancer {
+	case "cooperative-sticky", "sticky", "range", "round-robin":
+	default:
+		add("CJ_EVENTHANDLER_BALANCER must be cooperative-sticky, sticky, range or round-robin, got %q", c.EventHandlerBalancer)
+	}
+	if c.IngestionBackpressureResumeRatio <= 0 || c.IngestionBackpressureResumeRatio > 1 {
+		add("CJ_INGESTION_BACKPRESSURE_RESUME_RATIO must be in (0, 1], got %g", c.IngestionBackpressureResumeRatio)
+	}
+
+	// Polling loops spin on a zero interval; elsewhere 0 disables a feature.
+	intervals := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"CJ_OUTBOX_POLL_INTERVAL", c.OutboxPollInterval},
+		{"CJ_EVENTHANDLER_POLL_TIMEOUT", c.EventHandlerPollTimeout},
+		{"CJ_EVENTHANDLER_COMMIT_INTERVAL", c.EventHandlerCommitInterval},
+		{"CJ_EVENTHANDLER_SESSION_TIMEOUT", c.EventHandlerSessionTimeout},
+		{"CJ_EVENTHANDLER_REBALANCE_TIMEOUT", c.EventHandlerRebalanceTimeout},
+		{"CJ_ACTIONS_RULE_RELOAD_INTERVAL", c.ActionsRuleReloadInterval},
+		{"CJ_ACTIONS_WEBHOOK_TIMEOUT", c.ActionsWebhookTimeout},
+		{"CJ_ACTIONS_NOTIFY_TIMEOUT", c.ActionsNotifyTimeout},
+		{"CJ_QUERY_EVENTS_POLL_INTERVAL", c.QueryEventsPollInterval},
+		{"CJ_SANDBOX_EVENT_INTERVAL", c.SandboxEventInterval},
+		{"CJ_LEADER_RETRY_INTERVAL", c.LeaderRetryInterval},
+		{"CJ_INGESTION_BACKPRESSURE_INTERVAL", c.IngestionBackpressureInterval},
+	}
+	for _, i := range intervals {
+		if i.value <= 0 {
+			add("%s must be positive, got %s", i.key, i.value)
+		}
+	}
+	durations := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"CJ_REDPANDA_LINGER", c.RedpandaLinger},
+		{"CJ_HTTP_READ_TIMEOUT", c.HTTPReadTimeout},
+		{"CJ_HTTP_READ_HEADER_TIMEOUT", c.HTTPReadHeaderTimeout},
+		{"CJ_HTTP_WRITE_TIMEOUT", c.HTTPWriteTimeout},
+		{"CJ_HTTP_IDLE_TIMEOUT", c.HTTPIdleTimeout},
+		{"CJ_REQUEST_TIMEOUT", c.RequestTimeout},
+		{"CJ_OUTBOX_VACUUM_INTERVAL", c.OutboxVacuumInterval},
+		{"CJ_OUTBOX_REINDEX_INTERVAL", c.OutboxReindexInterval},
+		{"CJ_OUTBOX_ARCHIVE_RETENTION", c.OutboxArchiveRetention},
+		{"CJ_ACTIONS_WEBHOOK_BREAKER_OPEN", c.ActionsWebhookBreakerOpen},
+		{"CJ_PROJECTION_VERIFY_INTERVAL", c.ProjectionVerifyInterval},
+		{"CJ_PROJECTION_TTL_SWEEP_INTERVAL", c.ProjectionTTLSweepEvery},
+		{"CJ_PROJECTION_FOLD_RETENTION", c.ProjectionFoldRetention},
+		{"CJ_SENSOR_ANOMALY_MAX_GAP", c.SensorAnomalyMaxGap},
+		{"CJ_SENSOR_WINDOW", c.SensorWindow},
+		{"CJ_SENSOR_WINDOW_LATENESS", c.SensorWindowLateness},
+		{"CJ_QUERY_EVENTS_MAX_WAIT", c.QueryEventsMaxWait},
+		{"CJ_SECRETS_REFRESH_INTERVAL", c.SecretsRefreshInterval},
+		{"CJ_DB_RETRY_BASE_DELAY", c.DBRetryBaseDelay},
+		{"CJ_DB_RETRY_MAX_DELAY", c.DBRetryMaxDelay},
+		{"CJ_DB_BREAKER_OPEN", c.DBBreakerOpen},
+		{"CJ_DB_SLOW_QUERY_THRESHOLD", c.DBSlowQueryThreshold},
+		{"CJ_INGESTION_BACKPRESSURE_MAX_AGE", c.IngestionBackpressureMaxAge},
+		{"CJ_INGESTION_BACKPRESSURE_RETRY_AFTER", c.IngestionBackpressureRetryAfter},
+		{"CJ_INGESTION_SYNC_TIMEOUT", c.IngestionSyncTimeout},
+		{"CJ_INGESTION_DEDUP_WINDOW", c.IngestionDedupWindow},
+		{"CJ_INGESTION_IDEMPOTENCY_WINDOW", c.IngestionIdempotencyWindow},
+		{"CJ_INGESTION_EVENT_TIME_MAX_PAST", c.IngestionEventTimeMaxPast},
+		{"CJ_INGESTION_EVENT_TIME_MAX_FUTURE", c.IngestionEventTimeMaxFuture},
+		{"CJ_EVENTHANDLER_RETRY_DELAY", c.EventHandlerRetryDelay},
+		{"CJ_SEARCH_TIMEOUT", c.SearchTimeout},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			add("%s must not be negative, got %s", d.key, d.value)
+		}
+	}
+
+	// Combinations that are valid one by one but do not work together
+	if c.QueryEventsMaxWait > 0 && c.QueryEventsPollInterval > c.QueryEventsMaxWait {
+		add("CJ_QUERY_EVENTS_POLL_INTERVAL (%s) must not exceed CJ_QUERY_EVENTS_MAX_WAIT (%s)",
+			c.QueryEventsPollInterval, c.QueryEventsMaxWait)
+	}
+	if c.ProjectionTTLs != "" && c.ProjectionTTLSweepEvery == 0 {
+		add("CJ_PROJECTION_TTLS is set but CJ_PROJECTION_TTL_SWEEP_INTERVAL is 0, so nothing would expire")
+	}
+	if c.ProjectionFoldRetention > 0 && c.TopicRetention > 0 && c.ProjectionFoldRetention < c.TopicRetention {
+		add("CJ_PROJECTION_FOLD_RETENTION (%s) must not be shorter than CJ_TOPIC_RETENTION (%s), or redelivered events could be folded twice",
+			c.ProjectionFoldRetention, c.TopicRetention)
+	}
+	if c.SearchURL != "" {
+		if u, err := url.Parse(c.SearchURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("CJ_SEARCH_URL must be an http or https URL, got %q", c.SearchURL)
+		}
+		if c.ProjectionChangesTopic == "" {
+			add("CJ_SEARCH_URL is set but CJ_PROJECTION_CHANGES_TOPIC is not, so nothing would be indexed")
+		}
+		if err := validIndexPrefix(c.SearchIndexPrefix); err != nil {
+			add("CJ_SEARCH_INDEX_PREFIX: invalid prefix %q: %v", c.SearchIndexPrefix, err)
+		}
+	}
+
+	return problems
+}
+
+// validBroker checks a host:port broker address.
+func validBroker(broker string) error {
+	if broker == "" {
+		return fmt.Errorf("empty address (check for a stray comma)")
+	}
+	if strings.TrimSpace(broker) != broker {
+		return fmt.Errorf("surrounding whitespace")
+	}
+	host, port, err := net.SplitHostPort(broker)
+	if err != nil {
+		return fmt.Errorf("expected host:port")
+	}
+	if host == "" {
+		return fmt.Errorf("missing host")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	return nil
+}
+
+// maxTopicLength is Kafka's limit on topic names.
+const maxTopicLength = 249
+
+// validTopic applies Kafka's topic naming rules.
+func validTopic(topic string) error {
+	switch {
+	case topic == "":
+		return fmt.Errorf("empty name (check for a stray comma)")
+	case topic == "." || topic == "..":
+		return fmt.Errorf("%q is reserved", topic)
+	case len(topic) > maxTopicLength:
+		return fmt.Errorf("longer than %d characters", maxTopicLength)
+	}
+	for _, r := range topic {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return fmt.Errorf("only letters, digits, '.', '_' and '-' are allowed")
+		}
+	}
+	return nil
+}
+
+// validIndexPrefix applies the OpenSearch and Elasticsearch index naming
+// rules to a prefix: projection types only add lowercase letters, digits
+// and '_'.
+func validIndexPrefix(prefix string) error {
+	if prefix == "" {
+		return nil
+	}
+	if strings.ContainsAny(prefix[:1], "-_+.") {
+		return fmt.Errorf("must not start with '-', '_', '+' or '.'")
+	}
+	for _, r := range prefix {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return fmt.Errorf("only lowercase letters, digits, '.', '_' and '-' are allowed")
+		}
+	}
+	return nil
+}
+
+// nonEmpty splits a comma-separated list, trimming entries and dropping
+// empty ones, as the route and override parsers do.
+func nonEmpty(list string) []string {
+	var entries []string
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
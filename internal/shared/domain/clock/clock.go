@@ -17,6 +17,10 @@
 //	replayClock := &clock.ReplayClock{}
 //	clock.Set(replayClock)
 //	replayClock.Advance(event.IngestedAt)
+//
+// Waiting goes through the clock too: clock.Sleep, clock.After,
+// clock.NewTimer and clock.NewTicker follow a ManualClock or ReplayClock
+// when one is set, and real time otherwise.
 package clock
 
 import (
@@ -73,22 +77,29 @@ func (c FixedClock) Now() time.Time {
 }
 
 // ReplayClock advances time based on events being replayed.
-// Call Advance() before processing each historical event.
+// Call Advance() before processing each historical event. Its timers and
+// tickers fire as Advance passes their deadlines, so waits keyed to event
+// time take no real time during a replay.
 type ReplayClock struct {
-	mu   sync.Mutex
-	time time.Time
+	schedule
 }
 
 // Now returns the current replay time.
 func (c *ReplayClock) Now() time.Time {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	return c.time
+	return c.current()
 }
 
-// Advance sets the replay time to the given timestamp.
+// Advance sets the replay time to the given timestamp, firing the timers due.
 func (c *ReplayClock) Advance(t time.Time) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.time = t
+	c.set(t)
+}
+
+// NewTimer returns a timer that fires once replay time reaches now+d.
+func (c *ReplayClock) NewTimer(d time.Duration) Timer {
+	return c.newTimer(d, 0)
+}
+
+// NewTicker returns a ticker that fires each time replay time passes a period.
+func (c *ReplayClock) NewTicker(d time.Duration) Ticker {
+	return c.newTicker(d)
 }
@@ -0,0 +1,320 @@
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Timers is implemented by clocks that also drive waiting. The package-level
+// NewTimer, NewTicker, After and Sleep use the active clock when it
+// implements Timers, and real time otherwise, so a FixedClock only fixes Now.
+type Timers interface {
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a single-shot timer, as time.Timer. Stop and Reset discard a
+// pending fire, so C never delivers a stale value.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker delivers ticks every period, as time.Ticker. Ticks are dropped
+// while the receiver is behind.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// timers returns the active clock's timers, or real ones.
+func timers() Timers {
+	mu.RLock()
+	defer mu.RUnlock()
+	if t, ok := current.(Timers); ok {
+		return t
+	}
+	return RealClock{}
+}
+
+// NewTimer returns a timer on the active clock that fires after d.
+func NewTimer(d time.Duration) Timer {
+	return timers().NewTimer(d)
+}
+
+// NewTicker returns a ticker on the active clock with period d. Panics if d
+// is not positive.
+func NewTicker(d time.Duration) Ticker {
+	return timers().NewTicker(d)
+}
+
+// After returns a channel that receives the time once d has passed on the
+// active clock.
+func After(d time.Duration) <-chan time.Time {
+	return NewTimer(d).C()
+}
+
+// Sleep waits for d on the active clock. It returns ctx.Err() if ctx is done
+// first, and nil otherwise.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// NewTimer returns a real timer.
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// NewTicker returns a real ticker.
+func (RealClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// schedule holds a clock's time and the timers waiting on it. Timers fire
+// only when the time is moved past their deadline.
+type schedule struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiting []*scheduledTimer
+	changed chan struct{} // closed when a timer is started
+}
+
+// set moves the time to t and fires every timer due by then, earliest first.
+func (s *schedule) set(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = t
+
+	due := s.waiting[:0:0]
+	for _, st := range s.waiting {
+		if !st.when.After(t) {
+			due = append(due, st)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].when.Before(due[j].when) })
+	for _, st := range due {
+		st.fire()
+	}
+}
+
+func (s *schedule) current() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+func (s *schedule) newTimer(d, period time.Duration) *scheduledTimer {
+	st := &scheduledTimer{s: s, c: make(chan time.Time, 1), period: period}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st.start(d)
+	return st
+}
+
+// started returns how many timers are waiting and a channel closed when
+// another starts.
+func (s *schedule) started() (int, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+	return len(s.waiting), s.changed
+}
+
+// waitForTimers blocks until at least n timers are waiting or ctx is done.
+func (s *schedule) waitForTimers(ctx context.Context, n int) error {
+	for {
+		count, changed := s.started()
+		if count >= n {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// scheduledTimer is a Timer, or a Ticker when period is set, on a schedule.
+// Its fields are guarded by the schedule's mutex.
+type scheduledTimer struct {
+	s      *schedule
+	c      chan time.Time
+	when   time.Time
+	period time.Duration
+	active bool
+}
+
+// start schedules the timer d from now, firing at once if d is not positive.
+func (st *scheduledTimer) start(d time.Duration) {
+	st.drain()
+	st.when = st.s.now.Add(d)
+	if !st.active {
+		st.active = true
+		st.s.waiting = append(st.s.waiting, st)
+	}
+	if st.s.changed != nil {
+		close(st.s.changed)
+		st.s.changed = nil
+	}
+	if d <= 0 {
+		st.fire()
+	}
+}
+
+// fire delivers the time and reschedules a ticker past now, dropping missed
+// ticks; a timer stops.
+func (st *scheduledTimer) fire() {
+	select {
+	case st.c <- st.s.now:
+	default:
+	}
+	if st.period > 0 {
+		for !st.when.After(st.s.now) {
+			st.when = st.when.Add(st.period)
+		}
+		return
+	}
+	st.remove()
+}
+
+// remove takes the timer off the schedule and reports whether it was on it.
+func (st *scheduledTimer) remove() bool {
+	if !st.active {
+		return false
+	}
+	st.active = false
+	for i, w := range st.s.waiting {
+		if w == st {
+			st.s.waiting = append(st.s.waiting[:i], st.s.waiting[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (st *scheduledTimer) drain() {
+	select {
+	case <-st.c:
+	default:
+	}
+}
+
+func (st *scheduledTimer) C() <-chan time.Time { return st.c }
+
+// Stop stops the timer and reports whether it was waiting.
+func (st *scheduledTimer) Stop() bool {
+	st.s.mu.Lock()
+	defer st.s.mu.Unlock()
+	st.drain()
+	return st.remove()
+}
+
+// Reset restarts the timer d from now and reports whether it was waiting.
+func (st *scheduledTimer) Reset(d time.Duration) bool {
+	st.s.mu.Lock()
+	defer st.s.mu.Unlock()
+	wasActive := st.active
+	st.start(d)
+	return wasActive
+}
+
+// scheduledTicker adapts a periodic scheduledTimer to Ticker.
+type scheduledTicker struct{ *scheduledTimer }
+
+func (t scheduledTicker) Stop() { t.scheduledTimer.Stop() }
+
+func (t scheduledTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+	t.period = d
+	t.start(d)
+}
+
+func (s *schedule) newTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return scheduledTicker{s.newTimer(d, d)}
+}
+
+// ManualClock is a clock for tests that moves only when told to. Its timers
+// and tickers fire as Add or Set moves it past their deadlines, so code
+// waiting on clock.Sleep or a ticker runs deterministically, without real
+// delays:
+//
+//	c := clock.NewManualClock(start)
+//	clock.Set(c)
+//	t.Cleanup(clock.Reset)
+//	go worker.Run(ctx)
+//	c.WaitForTimers(ctx, 1) // the worker's ticker is started
+//	c.Add(interval)         // and fires once
+type ManualClock struct {
+	schedule
+}
+
+// NewManualClock returns a ManualClock starting at t.
+func NewManualClock(t time.Time) *ManualClock {
+	c := &ManualClock{}
+	c.now = t
+	return c
+}
+
+// Now returns the clock's time.
+func (c *ManualClock) Now() time.Time {
+	return c.current()
+}
+
+// Add moves the clock forward by d, firing the timers due.
+func (c *ManualClock) Add(d time.Duration) {
+	c.set(c.Now().Add(d))
+}
+
+// Set moves the clock to t, firing the timers due.
+func (c *ManualClock) Set(t time.Time) {
+	c.set(t)
+}
+
+// NewTimer returns a timer that fires when the clock reaches now+d.
+func (c *ManualClock) NewTimer(d time.Duration) Timer {
+	return c.newTimer(d, 0)
+}
+
+// NewTicker returns a ticker that fires each time the clock passes a period.
+func (c *ManualClock) NewTicker(d time.Duration) Ticker {
+	return c.newTicker(d)
+}
+
+// WaitForTimers blocks until at least n timers or tickers are waiting on
+// the clock, or ctx is done. Call it before Add when the code under test
+// starts its timer in another goroutine.
+func (c *ManualClock) WaitForTimers(ctx context.Context, n int) error {
+	return c.waitForTimers(ctx, n)
+}
@@ -0,0 +1,153 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2026, 2, 7, 12, 0, 0, 0, time.UTC)
+
+// fired reports whether c has a value ready.
+func fired(c <-chan time.Time) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestManualClock_Timer(t *testing.T) {
+	c := NewManualClock(start)
+	timer := c.NewTimer(time.Minute)
+
+	c.Add(59 * time.Second)
+	assert.False(t, fired(timer.C()))
+
+	c.Add(time.Second)
+	select {
+	case got := <-timer.C():
+		assert.Equal(t, start.Add(time.Minute), got)
+	default:
+		t.Fatal("timer should fire at its deadline")
+	}
+
+	c.Add(time.Hour)
+	assert.False(t, fired(timer.C()), "a timer fires once")
+	assert.False(t, timer.Stop(), "a fired timer is not waiting")
+}
+
+func TestManualClock_TimerStopAndReset(t *testing.T) {
+	c := NewManualClock(start)
+	timer := c.NewTimer(time.Second)
+
+	assert.True(t, timer.Stop())
+	c.Add(time.Minute)
+	assert.False(t, fired(timer.C()), "a stopped timer does not fire")
+
+	assert.False(t, timer.Reset(time.Second))
+	c.Add(time.Second)
+	assert.False(t, timer.Reset(time.Second), "Reset after firing discards the stale value")
+	assert.False(t, fired(timer.C()))
+
+	timer.Reset(0)
+	assert.True(t, fired(timer.C()), "a non-positive duration fires at once")
+}
+
+func TestManualClock_Ticker(t *testing.T) {
+	c := NewManualClock(start)
+	ticker := c.NewTicker(10 * time.Second)
+
+	c.Add(10 * time.Second)
+	assert.True(t, fired(ticker.C()))
+	c.Add(5 * time.Second)
+	assert.False(t, fired(ticker.C()))
+
+	// Missed ticks are dropped, as with time.Ticker
+	c.Add(time.Minute)
+	assert.True(t, fired(ticker.C()))
+	assert.False(t, fired(ticker.C()))
+	c.Add(5 * time.Second)
+	assert.True(t, fired(ticker.C()), "the period stays aligned to the start")
+
+	ticker.Reset(time.Hour)
+	c.Add(time.Minute)
+	assert.False(t, fired(ticker.C()))
+
+	ticker.Stop()
+	c.Add(2 * time.Hour)
+	assert.False(t, fired(ticker.C()))
+
+	assert.Panics(t, func() { c.NewTicker(0) })
+}
+
+func TestManualClock_FiresInDeadlineOrder(t *testing.T) {
+	c := NewManualClock(start)
+	late := c.NewTimer(2 * time.Second)
+	early := c.NewTimer(time.Second)
+
+	c.Add(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), <-early.C(), "timers receive the time they were fired at")
+	assert.Equal(t, start.Add(time.Minute), <-late.C())
+}
+
+func TestSleep(t *testing.T) {
+	t.Cleanup(Reset)
+	c := NewManualClock(start)
+	Set(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- Sleep(ctx, time.Hour) }()
+
+	require.NoError(t, c.WaitForTimers(ctx, 1))
+	c.Add(time.Hour)
+	require.NoError(t, <-done)
+	assert.Equal(t, start.Add(time.Hour), Now())
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	assert.ErrorIs(t, Sleep(cancelled, time.Hour), context.Canceled)
+	assert.ErrorIs(t, Sleep(cancelled, 0), context.Canceled)
+	assert.NoError(t, Sleep(context.Background(), 0))
+}
+
+func TestSleep_RealTimeByDefault(t *testing.T) {
+	t.Cleanup(Reset)
+	Set(FixedClock{Time: start})
+
+	began := time.Now()
+	require.NoError(t, Sleep(context.Background(), 10*time.Millisecond))
+	assert.GreaterOrEqual(t, time.Since(began), 10*time.Millisecond, "a clock without timers waits in real time")
+
+	select {
+	case <-After(time.Millisecond):
+	case <-time.After(5 * time.Second):
+		t.Fatal("After should fire in real time")
+	}
+}
+
+func TestReplayClock_Timers(t *testing.T) {
+	c := &ReplayClock{}
+	c.Advance(start)
+	timer := c.NewTimer(time.Minute)
+	ticker := c.NewTicker(time.Minute)
+
+	c.Advance(start.Add(30 * time.Second))
+	assert.False(t, fired(timer.C()))
+
+	c.Advance(start.Add(90 * time.Second))
+	assert.True(t, fired(timer.C()), "replay time passing the deadline fires the timer")
+	assert.True(t, fired(ticker.C()))
+
+	// Out-of-order events move replay time back without firing anything
+	c.Advance(start)
+	c.Advance(start.Add(100 * time.Second))
+	assert.False(t, fired(ticker.C()))
+}
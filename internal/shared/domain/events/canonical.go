@@ -0,0 +1,67 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// canonicalEnvelope is the signed form of an envelope.
+type canonicalEnvelope struct {
+	EventID       uuid.UUID       `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	AggregateType string          `json:"aggregate_type,omitempty"`
+	EventTime     string          `json:"event_time"`
+	IngestedAt    string          `json:"ingested_at"`
+	Payload       json.RawMessage `json:"payload"`
+	Metadata      Metadata        `json:"metadata"`
+}
+
+// SigningBytes returns the canonical form of the envelope that signatures
+// cover. It is the same for an envelope read back from the outbox, the event
+// store or the bus as for the one ingested:
+//   - Metadata.Signature and the store-assigned sequence numbers are left out;
+//   - an empty AggregateType is left out, so events signed before it existed
+//     still verify;
+//   - times are in UTC at microsecond precision, as PostgreSQL keeps them;
+//   - the payload is re-encoded with sorted keys and no whitespace, as JSONB
+//     normalizes it. Numbers compare as float64.
+//
+// Upcasting rewrites the payload, so verify before upcasting.
+func (e *Envelope) SigningBytes() ([]byte, error) {
+	payload, err := canonicalJSON(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize payload: %w", err)
+	}
+	metadata := e.Metadata
+	metadata.Signature = ""
+	return json.Marshal(canonicalEnvelope{
+		EventID:       e.EventID,
+		EventType:     e.EventType,
+		AggregateID:   e.AggregateID,
+		AggregateType: e.AggregateType,
+		EventTime:     canonicalTime(e.EventTime),
+		IngestedAt:    canonicalTime(e.IngestedAt),
+		Payload:       payload,
+		Metadata:      metadata,
+	})
+}
+
+func canonicalTime(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// canonicalJSON re-encodes data; encoding/json sorts object keys.
+func canonicalJSON(data json.RawMessage) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return json.RawMessage("null"), nil
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
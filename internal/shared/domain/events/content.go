@@ -0,0 +1,88 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// ContentTypeJSON is the content type of JSON payloads, the default.
+const ContentTypeJSON = "application/json"
+
+// ErrNotJSON is returned by ParsePayload for payloads that are not JSON.
+var ErrNotJSON = errors.New("payload is not JSON")
+
+// IsJSONContentType reports whether payloads of contentType are JSON: an
+// empty type, application/json, or a +json type such as
+// application/vnd.acme+json, with any parameters.
+func IsJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// ValidateContentType checks that contentType is a media type (RFC 2045),
+// e.g. application/cbor or image/jpeg. Empty means JSON and is valid.
+func ValidateContentType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.Contains(mediaType, "/") {
+		return fmt.Errorf("invalid content type %q", contentType)
+	}
+	return nil
+}
+
+// EncodeBinaryPayload returns binary data in the form an envelope carries it:
+// a JSON string of its standard base64 encoding. Envelopes stay JSON
+// throughout, in the outbox, the event store and on the bus.
+func EncodeBinaryPayload(data []byte) json.RawMessage {
+	encoded, _ := json.Marshal(data) // []byte marshals as a base64 string
+	return encoded
+}
+
+// DecodeBinaryPayload reverses EncodeBinaryPayload.
+func DecodeBinaryPayload(payload json.RawMessage) ([]byte, error) {
+	var data []byte
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("binary payload must be a base64 string: %w", err)
+	}
+	return data, nil
+}
+
+// ContentType returns the payload's content type: Metadata.ContentType, or
+// ContentTypeJSON when unset.
+func (e *Envelope) ContentType() string {
+	if e.Metadata.ContentType == "" {
+		return ContentTypeJSON
+	}
+	return e.Metadata.ContentType
+}
+
+// IsJSON reports whether the payload is JSON.
+func (e *Envelope) IsJSON() bool {
+	return IsJSONContentType(e.Metadata.ContentType)
+}
+
+// SetBinaryPayload sets data, of contentType, as the payload.
+func (e *Envelope) SetBinaryPayload(contentType string, data []byte) {
+	e.Payload = EncodeBinaryPayload(data)
+	e.Metadata.ContentType = contentType
+}
+
+// PayloadBytes returns the payload's content: the JSON text of a JSON
+// payload, or the decoded bytes of a binary one.
+func (e *Envelope) PayloadBytes() ([]byte, error) {
+	if e.IsJSON() {
+		return e.Payload, nil
+	}
+	return DecodeBinaryPayload(e.Payload)
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsJSONContentType(t *testing.T) {
+	for _, ct := range []string{"", "application/json", "application/json; charset=utf-8", "application/vnd.acme.reading+json"} {
+		assert.True(t, IsJSONContentType(ct), ct)
+	}
+	for _, ct := range []string{"application/cbor", "application/x-protobuf", "image/jpeg", "not a type"} {
+		assert.False(t, IsJSONContentType(ct), ct)
+	}
+}
+
+func TestValidateContentType(t *testing.T) {
+	for _, ct := range []string{"", "application/cbor", "image/jpeg", "application/x-protobuf; messageType=Reading"} {
+		assert.NoError(t, ValidateContentType(ct), ct)
+	}
+	for _, ct := range []string{"jpeg", "image jpeg", "/"} {
+		assert.Error(t, ValidateContentType(ct), ct)
+	}
+}
+
+func TestEnvelope_BinaryPayload(t *testing.T) {
+	data := []byte{0x00, 0xa1, 0xff, '"'}
+	env, err := NewEnvelope("sensor.frame", "device-001", nil, Metadata{SchemaVersion: 1}, time.Now())
+	require.NoError(t, err)
+	env.SetBinaryPayload("application/cbor", data)
+
+	assert.Equal(t, `"AKH/Ig=="`, string(env.Payload), "carried as a base64 JSON string")
+	assert.Equal(t, "application/cbor", env.ContentType())
+	assert.False(t, env.IsJSON())
+
+	// Survives the JSON round trip of the outbox, event store and bus
+	encoded, err := json.Marshal(env)
+	require.NoError(t, err)
+	var decoded Envelope
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	got, err := decoded.PayloadBytes()
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.Equal(t, "application/cbor", decoded.Metadata.ContentType)
+
+	var v map[string]any
+	err = decoded.ParsePayload(&v)
+	assert.True(t, errors.Is(err, ErrNotJSON))
+	assert.EqualError(t, err, "payload is not JSON: application/cbor")
+}
+
+func TestEnvelope_JSONPayloadDefault(t *testing.T) {
+	env, err := NewEnvelope("sensor.reading", "device-001", map[string]float64{"value": 72.5}, Metadata{SchemaVersion: 1}, time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, ContentTypeJSON, env.ContentType())
+	assert.True(t, env.IsJSON())
+	got, err := env.PayloadBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value": 72.5}`, string(got))
+
+	encoded, err := json.Marshal(env.Metadata)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "content_type", "JSON envelopes are unchanged")
+}
+
+func TestDecodeBinaryPayload_Invalid(t *testing.T) {
+	for _, payload := range []string{`{"value": 1}`, `"not base64!"`, `42`} {
+		_, err := DecodeBinaryPayload(json.RawMessage(payload))
+		assert.Error(t, err, payload)
+	}
+}
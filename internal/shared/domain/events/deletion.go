@@ -0,0 +1,15 @@
+package events
+
+import "strings"
+
+// DeletedSuffix ends the event types that delete their aggregate, by
+// convention: an event of type "<domain>.deleted" (e.g. "user.deleted")
+// removes the aggregate from the platform. Handlers soft-delete its
+// projections (see projections.IsTombstone), and the producer can follow the
+// event with a Kafka tombstone for its key.
+const DeletedSuffix = ".deleted"
+
+// IsDeletion reports whether eventType follows the deletion convention.
+func IsDeletion(eventType string) bool {
+	return strings.HasSuffix(eventType, DeletedSuffix) && len(eventType) > len(DeletedSuffix)
+}
@@ -2,9 +2,11 @@ package events
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/ids"
 	"github.com/gofrs/uuid/v5"
 )
 
@@ -20,34 +22,99 @@ type Envelope struct {
 	// AggregateID groups related events (e.g., device ID, session ID)
 	AggregateID string `json:"aggregate_id"`
 
+	// AggregateType is the kind of aggregate (e.g., "device", "session"),
+	// telling apart aggregates of different domains that share an ID.
+	// Optional; see ValidateAggregateType.
+	AggregateType string `json:"aggregate_type,omitempty"`
+
 	// EventTime is when the event occurred (from the caller/producer)
 	EventTime time.Time `json:"event_time"`
 
 	// IngestedAt is when the platform received the event (set by platform clock)
 	IngestedAt time.Time `json:"ingested_at"`
 
-	// Payload contains the event-specific data
+	// Payload contains the event-specific data: JSON, or for other content
+	// types (Metadata.ContentType) a base64 JSON string (see SetBinaryPayload)
 	Payload json.RawMessage `json:"payload"`
 
 	// Metadata contains trace IDs, source info, schema version, etc.
 	Metadata Metadata `json:"metadata"`
+
+	// GlobalSeq and AggregateSeq are assigned by the event store at insert:
+	// GlobalSeq increases across all events, AggregateSeq counts 1, 2, 3, ...
+	// per aggregate. Both are in insert order. Zero until the event is stored.
+	GlobalSeq    int64 `json:"global_seq,omitempty"`
+	AggregateSeq int64 `json:"aggregate_seq,omitempty"`
 }
 
+// CurrentSchemaVersion is the payload schema version this build writes.
+// Consumers upcast older versions to it; newer versions are not understood.
+const CurrentSchemaVersion = 1
+
 // Metadata contains contextual information about the event.
 type Metadata struct {
 	// TraceID for distributed tracing (optional)
 	TraceID string `json:"trace_id,omitempty"`
 
+	// CorrelationID ties together the events of one business operation,
+	// across aggregates and requests (optional). Set by the producer.
+	CorrelationID string `json:"correlation_id,omitempty"`
+
 	// Source identifies where the event originated
 	Source string `json:"source,omitempty"`
 
+	// TenantID identifies the owning tenant (optional)
+	TenantID string `json:"tenant_id,omitempty"`
+
 	// SchemaVersion for payload evolution
 	SchemaVersion int `json:"schema_version"`
+
+	// ContentType is the payload's media type, e.g. application/cbor or
+	// image/jpeg. Empty means JSON (see Envelope.ContentType).
+	ContentType string `json:"content_type,omitempty"`
+
+	// Test marks synthetic/test traffic (e.g. e2e runs against staging).
+	// Its projections are written to the test namespace (see projections.TypeFor).
+	Test bool `json:"test,omitempty"`
+
+	// Replay marks an event re-applied from history (a replay or backfill)
+	// rather than live traffic. Projections fold it the same way; freshness is
+	// tracked separately for it (see eventhandler.Freshness). Only
+	// TrustedReplay decides whether it is exempt from live-traffic rules.
+	Replay bool `json:"replay,omitempty"`
+
+	// ClampedFrom is the producer's event_time when ingestion moved it into
+	// the accepted range (see ingestion.EventTimePolicy); EventTime then
+	// holds the nearest bound. Nil when the event time is as sent.
+	ClampedFrom *time.Time `json:"clamped_from,omitempty"`
+
+	// Attributes are facts about the event added at ingestion by enrichers
+	// (see ingestion.Enricher), e.g. a device's site from the device registry.
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// Signature is the ingestion signature over the rest of the envelope
+	// (see SigningBytes and package signing); empty when events are not signed.
+	Signature string `json:"signature,omitempty"`
+}
+
+// Sources (Metadata.Source) of the events written by the platform.
+const (
+	SourceIngestion = "ingestion-api" // live traffic through the ingestion API
+	SourceReplay    = "replay"        // replays ingested with an API key allowed to replay
+	SourceImport    = "import"        // bulk imports (platform import)
+)
+
+// TrustedReplay reports whether m marks a replay from a source allowed to
+// send one: the importer, or the ingestion API for a key allowed to replay.
+// Ingestion never writes those sources for other requests, so a replay flag
+// set by anyone else is treated as live traffic.
+func (m Metadata) TrustedReplay() bool {
+	return m.Replay && (m.Source == SourceReplay || m.Source == SourceImport)
 }
 
 // NewEnvelope creates a new event envelope.
 // eventTime is provided by the caller (when the event occurred).
-// IngestedAt is set automatically by the platform clock.
+// IngestedAt is set automatically by the platform clock, and EventID by ids.New.
 func NewEnvelope(eventType, aggregateID string, payload any, metadata Metadata, eventTime time.Time) (*Envelope, error) {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -55,7 +122,7 @@ func NewEnvelope(eventType, aggregateID string, payload any, metadata Metadata,
 	}
 
 	return &Envelope{
-		EventID:     uuid.Must(uuid.NewV7()),
+		EventID:     ids.New(),
 		EventType:   eventType,
 		AggregateID: aggregateID,
 		EventTime:   eventTime,
@@ -65,7 +132,33 @@ func NewEnvelope(eventType, aggregateID string, payload any, metadata Metadata,
 	}, nil
 }
 
-// ParsePayload unmarshals the payload into the provided type.
+// MaxAggregateTypeLength is the longest aggregate type accepted.
+const MaxAggregateTypeLength = 64
+
+// ValidateAggregateType checks that aggregateType is empty or a lowercase
+// identifier: a letter followed by letters, digits or underscores, at most
+// MaxAggregateTypeLength long.
+func ValidateAggregateType(aggregateType string) error {
+	if aggregateType == "" {
+		return nil
+	}
+	valid := len(aggregateType) <= MaxAggregateTypeLength && aggregateType[0] >= 'a' && aggregateType[0] <= 'z'
+	for _, c := range aggregateType {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			valid = false
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid aggregate type %q: expected a lowercase identifier of at most %d characters", aggregateType, MaxAggregateTypeLength)
+	}
+	return nil
+}
+
+// ParsePayload unmarshals the payload into the provided type. It fails with
+// ErrNotJSON for payloads of other content types.
 func (e *Envelope) ParsePayload(v any) error {
+	if !e.IsJSON() {
+		return fmt.Errorf("%w: %s", ErrNotJSON, e.ContentType())
+	}
 	return json.Unmarshal(e.Payload, v)
 }
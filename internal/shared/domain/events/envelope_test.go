@@ -2,6 +2,7 @@ package events
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -89,3 +90,20 @@ func TestNewEnvelope_DualTimestamps(t *testing.T) {
 	assert.Equal(t, ingestTime, envelope.IngestedAt)
 	assert.Equal(t, 15*time.Minute, envelope.IngestedAt.Sub(envelope.EventTime))
 }
+
+func TestIsDeletion(t *testing.T) {
+	assert.True(t, IsDeletion("user.deleted"))
+	assert.True(t, IsDeletion("sensor.calibration.deleted"))
+	assert.False(t, IsDeletion(".deleted"))
+	assert.False(t, IsDeletion("user.undeleted"))
+	assert.False(t, IsDeletion("sensor.decommissioned"))
+}
+
+func TestValidateAggregateType(t *testing.T) {
+	for _, valid := range []string{"", "device", "user_session", "site2"} {
+		assert.NoError(t, ValidateAggregateType(valid), valid)
+	}
+	for _, invalid := range []string{"Device", "2fa", "_x", "device-type", "a.b", strings.Repeat("a", MaxAggregateTypeLength+1)} {
+		assert.Error(t, ValidateAggregateType(invalid), invalid)
+	}
+}
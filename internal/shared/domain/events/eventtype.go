@@ -0,0 +1,81 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Event type naming rules. An event type is a dotted path of lowercase
+// segments from the domain down, e.g. "sensor.reading" or
+// "user.profile.updated". Topic routes and handler registrations match event
+// types by their leading segments, so a type outside these rules ("Sensor.Reading",
+// "sensor-reading") silently goes to the default topic and no handler.
+const (
+	// MinEventTypeDepth is the fewest segments of an event type: a domain
+	// and at least one more.
+	MinEventTypeDepth = 2
+	// DefaultMaxEventTypeDepth is the default for the most segments of an
+	// event type.
+	DefaultMaxEventTypeDepth = 4
+	// MaxEventTypeLength is the longest event type accepted.
+	MaxEventTypeLength = 255
+)
+
+// ValidateEventType checks eventType against the naming rules: between
+// MinEventTypeDepth and maxDepth dot-separated segments, each a lowercase
+// identifier (a letter followed by letters, digits or underscores), at most
+// MaxEventTypeLength long.
+func ValidateEventType(eventType string, maxDepth int) error {
+	if len(eventType) > MaxEventTypeLength {
+		return fmt.Errorf("invalid event type %q: longer than %d characters", eventType, MaxEventTypeLength)
+	}
+	segments := strings.Split(eventType, ".")
+	if len(segments) < MinEventTypeDepth || len(segments) > maxDepth {
+		return fmt.Errorf("invalid event type %q: expected %d to %d dot-separated segments", eventType, MinEventTypeDepth, maxDepth)
+	}
+	for _, segment := range segments {
+		if !isIdentifier(segment) {
+			return fmt.Errorf("invalid event type %q: segment %q is not a lowercase identifier", eventType, segment)
+		}
+	}
+	return nil
+}
+
+// ValidateEventTypePattern checks an event type pattern: an event type that
+// follows the naming rules ("sensor.reading"), or the leading segments of
+// one followed by ".*" ("sensor.*"), which matches every type below them.
+func ValidateEventTypePattern(pattern string, maxDepth int) error {
+	prefix, wildcard := strings.CutSuffix(pattern, ".*")
+	if !wildcard {
+		return ValidateEventType(pattern, maxDepth)
+	}
+	for _, segment := range strings.Split(prefix, ".") {
+		if !isIdentifier(segment) {
+			return fmt.Errorf("invalid event type pattern %q: expected an event type or leading segments followed by .*", pattern)
+		}
+	}
+	return nil
+}
+
+// MatchEventType reports whether eventType matches a pattern (see
+// ValidateEventTypePattern).
+func MatchEventType(pattern, eventType string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(eventType, prefix) && len(eventType) > len(prefix)
+	}
+	return eventType == pattern
+}
+
+// isIdentifier reports whether s is a lowercase identifier: a letter
+// followed by letters, digits or underscores.
+func isIdentifier(s string) bool {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package events
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEventType(t *testing.T) {
+	for _, valid := range []string{"sensor.reading", "user.profile.updated", "sensor.v2.reading_raw.x"} {
+		assert.NoError(t, ValidateEventType(valid, DefaultMaxEventTypeDepth), valid)
+	}
+	for _, invalid := range []string{
+		"sensor", "Sensor.reading", "sensor-reading", "sensor..reading", "sensor.reading.", ".sensor",
+		"sensor.2fa", "a.b.c.d.e", "sensor.*", "a." + strings.Repeat("b", MaxEventTypeLength),
+	} {
+		assert.Error(t, ValidateEventType(invalid, DefaultMaxEventTypeDepth), invalid)
+	}
+	assert.NoError(t, ValidateEventType("a.b.c.d.e", 5))
+}
+
+func TestValidateEventTypePattern(t *testing.T) {
+	for _, valid := range []string{"sensor.reading", "sensor.*", "user.profile.*"} {
+		assert.NoError(t, ValidateEventTypePattern(valid, DefaultMaxEventTypeDepth), valid)
+	}
+	for _, invalid := range []string{"sensor", "*", ".*", "sensor*", "Sensor.*", "*.deleted", "sensor.*.x"} {
+		assert.Error(t, ValidateEventTypePattern(invalid, DefaultMaxEventTypeDepth), invalid)
+	}
+}
+
+func TestMatchEventType(t *testing.T) {
+	assert.True(t, MatchEventType("sensor.reading", "sensor.reading"))
+	assert.False(t, MatchEventType("sensor.reading", "sensor.readings"))
+	assert.True(t, MatchEventType("sensor.*", "sensor.reading"))
+	assert.True(t, MatchEventType("sensor.*", "sensor.calibration.done"))
+	assert.False(t, MatchEventType("sensor.*", "sensor."))
+	assert.False(t, MatchEventType("sensor.*", "sensors.reading"))
+}
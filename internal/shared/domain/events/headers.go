@@ -0,0 +1,14 @@
+package events
+
+// Message header keys carried alongside the serialized envelope on the bus.
+// Consumers can route or filter on these without deserializing the payload.
+const (
+	HeaderEventID       = "event_id"
+	HeaderEventType     = "event_type"
+	HeaderSchemaVersion = "schema_version"
+	HeaderTenantID      = "tenant_id"
+	HeaderTraceID       = "trace_id"
+	HeaderCorrelationID = "correlation_id"
+	HeaderContentType   = "content_type"
+	HeaderTest          = "test" // "true" on test traffic (Metadata.Test)
+)
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// UpcastFunc transforms a payload from schema version N to version N+1.
+type UpcastFunc func(payload json.RawMessage) (json.RawMessage, error)
+
+// Upcasters holds payload transforms keyed by event type and source schema version.
+// Consumers (live and replay) run Upcast before dispatch so handlers only ever see
+// the latest payload shape, even for events stored under an older schema.
+type Upcasters struct {
+	mu  sync.RWMutex
+	fns map[string]map[int]UpcastFunc
+}
+
+// NewUpcasters creates an empty upcaster registry.
+func NewUpcasters() *Upcasters {
+	return &Upcasters{
+		fns: make(map[string]map[int]UpcastFunc),
+	}
+}
+
+// Register adds a transform for eventType from fromVersion to fromVersion+1.
+// Registering the same (eventType, fromVersion) twice replaces the earlier transform.
+func (u *Upcasters) Register(eventType string, fromVersion int, fn UpcastFunc) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.fns[eventType] == nil {
+		u.fns[eventType] = make(map[int]UpcastFunc)
+	}
+	u.fns[eventType][fromVersion] = fn
+}
+
+// Upcast applies registered transforms to the envelope in version order until no
+// transform exists for the current version. Metadata.SchemaVersion is updated to
+// the resulting version. Events with no registered transforms are left untouched.
+func (u *Upcasters) Upcast(e *Envelope) error {
+	u.mu.RLock()
+	chain := u.fns[e.EventType]
+	u.mu.RUnlock()
+
+	for {
+		fn, ok := chain[e.Metadata.SchemaVersion]
+		if !ok {
+			return nil
+		}
+
+		payload, err := fn(e.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to upcast %s from schema version %d: %w",
+				e.EventType, e.Metadata.SchemaVersion, err)
+		}
+
+		e.Payload = payload
+		e.Metadata.SchemaVersion++
+	}
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpcasters_Chain(t *testing.T) {
+	upcasters := NewUpcasters()
+
+	// v1: {"temp": 72.5} → v2: {"value": 72.5}
+	upcasters.Register("sensor.reading", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		var v1 struct {
+			Temp float64 `json:"temp"`
+		}
+		if err := json.Unmarshal(payload, &v1); err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]any{"value": v1.Temp})
+	})
+
+	// v2: {"value": 72.5} → v3: {"value": 72.5, "unit": "fahrenheit"}
+	upcasters.Register("sensor.reading", 2, func(payload json.RawMessage) (json.RawMessage, error) {
+		var v2 map[string]any
+		if err := json.Unmarshal(payload, &v2); err != nil {
+			return nil, err
+		}
+		v2["unit"] = "fahrenheit"
+		return json.Marshal(v2)
+	})
+
+	envelope := &Envelope{
+		EventType: "sensor.reading",
+		Payload:   json.RawMessage(`{"temp": 72.5}`),
+		Metadata:  Metadata{SchemaVersion: 1},
+	}
+
+	require.NoError(t, upcasters.Upcast(envelope))
+	assert.Equal(t, 3, envelope.Metadata.SchemaVersion)
+	assert.JSONEq(t, `{"value": 72.5, "unit": "fahrenheit"}`, string(envelope.Payload))
+}
+
+func TestUpcasters_NoTransform(t *testing.T) {
+	upcasters := NewUpcasters()
+	upcasters.Register("sensor.reading", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage(`{}`), nil
+	})
+
+	// Different event type — untouched
+	other := &Envelope{
+		EventType: "user.login",
+		Payload:   json.RawMessage(`{"user_id": "u-1"}`),
+		Metadata:  Metadata{SchemaVersion: 1},
+	}
+	require.NoError(t, upcasters.Upcast(other))
+	assert.Equal(t, 1, other.Metadata.SchemaVersion)
+	assert.JSONEq(t, `{"user_id": "u-1"}`, string(other.Payload))
+
+	// Already at latest version — untouched
+	latest := &Envelope{
+		EventType: "sensor.reading",
+		Payload:   json.RawMessage(`{"value": 1}`),
+		Metadata:  Metadata{SchemaVersion: 2},
+	}
+	require.NoError(t, upcasters.Upcast(latest))
+	assert.Equal(t, 2, latest.Metadata.SchemaVersion)
+	assert.JSONEq(t, `{"value": 1}`, string(latest.Payload))
+}
+
+func TestUpcasters_Error(t *testing.T) {
+	upcasters := NewUpcasters()
+	upcasters.Register("sensor.reading", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		return nil, fmt.Errorf("malformed payload")
+	})
+
+	envelope := &Envelope{
+		EventType: "sensor.reading",
+		Payload:   json.RawMessage(`{"temp": 72.5}`),
+		Metadata:  Metadata{SchemaVersion: 1},
+	}
+
+	err := upcasters.Upcast(envelope)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "schema version 1")
+	assert.Equal(t, 1, envelope.Metadata.SchemaVersion, "version should not advance on error")
+	assert.JSONEq(t, `{"temp": 72.5}`, string(envelope.Payload), "payload should not change on error")
+}
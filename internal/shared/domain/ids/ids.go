@@ -0,0 +1,91 @@
+// Package ids provides event ID generation for testability and replay.
+//
+// Instead of calling uuid.NewV7() directly for event IDs, code should call
+// ids.New(). Tests and replays can then swap in a Sequence, whose IDs are
+// reproducible, so orderings that fall back to the event ID (such as the
+// projection tiebreaker for equal event times) are too.
+//
+// Usage:
+//
+//	// Production code (random version 7 UUIDs by default)
+//	id := ids.New()
+//
+//	// Tests and replay (deterministic, increasing IDs)
+//	ids.Set(&ids.Sequence{})
+//	t.Cleanup(ids.Reset)
+package ids
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// Source generates IDs.
+type Source interface {
+	NewID() uuid.UUID
+}
+
+// Package-level source (default: random)
+var (
+	mu      sync.RWMutex
+	current Source = RandomSource{}
+)
+
+// New returns a new ID from the active source.
+func New() uuid.UUID {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.NewID()
+}
+
+// Set replaces the active source. Use for testing or replay.
+func Set(s Source) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = s
+}
+
+// Reset restores the random source. Call in test cleanup.
+func Reset() {
+	Set(RandomSource{})
+}
+
+// RandomSource generates random version 7 UUIDs.
+type RandomSource struct{}
+
+// NewID returns a random version 7 UUID.
+func (RandomSource) NewID() uuid.UUID {
+	return uuid.Must(uuid.NewV7())
+}
+
+// Sequence generates deterministic, strictly increasing version 7 UUIDs.
+// The timestamp is clock.Now's, held back to the last one if the clock goes
+// backwards, and the random bits hold a counter, so the same clock readings
+// give the same IDs. The zero value is ready to use.
+type Sequence struct {
+	mu     sync.Mutex
+	lastMs int64
+	n      uint64
+}
+
+// NewID returns the next ID in the sequence.
+func (s *Sequence) NewID() uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastMs = max(s.lastMs, clock.Now().UnixMilli())
+	s.n++
+
+	var id uuid.UUID
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(s.lastMs))
+	copy(id[:6], ms[2:])
+	// rand_b holds the low 62 bits of the counter
+	binary.BigEndian.PutUint64(id[8:], s.n)
+	id.SetVersion(uuid.V7)
+	id.SetVariant(uuid.VariantRFC9562)
+	return id
+}
@@ -0,0 +1,71 @@
+package ids
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+func TestRandomSource_NewID(t *testing.T) {
+	a, b := RandomSource{}.NewID(), RandomSource{}.NewID()
+
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, byte(uuid.V7), a.Version())
+}
+
+func TestSequence_Deterministic(t *testing.T) {
+	t.Cleanup(clock.Reset)
+	clock.Set(clock.FixedClock{Time: time.Date(2026, 2, 7, 12, 0, 0, 0, time.UTC)})
+
+	first, second := &Sequence{}, &Sequence{}
+	for range 3 {
+		assert.Equal(t, first.NewID(), second.NewID(), "same clock, same IDs")
+	}
+
+	id := (&Sequence{}).NewID()
+	assert.Equal(t, "019c37f9-2200-7000-8000-000000000001", id.String())
+	assert.Equal(t, byte(uuid.V7), id.Version())
+	assert.Equal(t, uuid.VariantRFC9562, id.Variant())
+
+	ts, err := uuid.TimestampFromV7(id)
+	assert.NoError(t, err)
+	got, err := ts.Time()
+	assert.NoError(t, err)
+	assert.Equal(t, clock.Now(), got.UTC())
+}
+
+func TestSequence_Increasing(t *testing.T) {
+	t.Cleanup(clock.Reset)
+	base := time.Date(2026, 2, 7, 12, 0, 0, 0, time.UTC)
+	replay := &clock.ReplayClock{}
+	clock.Set(replay)
+
+	s := &Sequence{}
+	var prev string
+	for _, at := range []time.Duration{time.Minute, time.Minute, 0, 2 * time.Minute} {
+		replay.Advance(base.Add(at))
+		id := s.NewID().String()
+		assert.Greater(t, id, prev, "IDs increase even when the clock goes back")
+		prev = id
+	}
+}
+
+func TestPackageLevelSource(t *testing.T) {
+	t.Cleanup(Reset)
+	t.Cleanup(clock.Reset)
+	clock.Set(clock.FixedClock{Time: time.Date(2026, 2, 7, 12, 0, 0, 0, time.UTC)})
+	assert.Equal(t, byte(uuid.V7), New().Version())
+
+	s := &Sequence{}
+	Set(s)
+	first := New()
+	assert.Equal(t, (&Sequence{}).NewID(), first, "New uses the active source")
+	assert.Greater(t, New().String(), first.String())
+
+	Reset()
+	assert.NotEqual(t, first, New())
+}
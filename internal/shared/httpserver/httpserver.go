@@ -0,0 +1,53 @@
+// Package httpserver builds the platform's HTTP servers, so every API shares
+// the same timeouts, header limits and protocol settings.
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Config holds the connection settings of a server. Zero durations mean no
+// timeout, as on http.Server.
+type Config struct {
+	ReadTimeout       time.Duration // reading a whole request, body included
+	ReadHeaderTimeout time.Duration // reading request headers; bounds slow clients holding connections
+	WriteTimeout      time.Duration // from the end of the request headers to the end of the response
+	IdleTimeout       time.Duration // keep-alive connections waiting for their next request
+	MaxHeaderBytes    int           // request line and headers
+
+	// H2C serves HTTP/2 without TLS (prior knowledge) alongside HTTP/1.1, for
+	// clients and proxies that multiplex requests over one connection.
+	H2C bool
+}
+
+// DefaultConfig suits the JSON APIs: requests are small and answered
+// quickly.
+var DefaultConfig = Config{
+	ReadTimeout:       10 * time.Second,
+	ReadHeaderTimeout: 5 * time.Second,
+	WriteTimeout:      10 * time.Second,
+	IdleTimeout:       60 * time.Second,
+	MaxHeaderBytes:    1 << 20,
+}
+
+// New returns a server for handler on port, configured by cfg. The caller
+// starts it with ListenAndServe.
+func New(port int, handler http.Handler, cfg Config) *http.Server {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	if cfg.H2C {
+		server.Protocols = new(http.Protocols)
+		server.Protocols.SetHTTP1(true)
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return server
+}
@@ -0,0 +1,56 @@
+package httpserver
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	server := New(8081, http.NotFoundHandler(), DefaultConfig)
+
+	assert.Equal(t, ":8081", server.Addr)
+	assert.Equal(t, 10*time.Second, server.ReadTimeout)
+	assert.Equal(t, 5*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 10*time.Second, server.WriteTimeout)
+	assert.Equal(t, 60*time.Second, server.IdleTimeout)
+	assert.Equal(t, 1<<20, server.MaxHeaderBytes)
+	assert.Nil(t, server.Protocols, "HTTP/1.1 and TLS HTTP/2 only by default")
+}
+
+// serve starts server on a free local port and returns its base URL.
+func serve(t *testing.T, server *http.Server) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(ln) }()
+	t.Cleanup(func() { _ = server.Close() })
+	return "http://" + ln.Addr().String()
+}
+
+func TestNew_H2C(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.H2C = true
+	protoHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	url := serve(t, New(0, protoHandler, cfg))
+
+	h2 := new(http.Protocols)
+	h2.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: h2}}
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 2, resp.ProtoMajor)
+
+	// HTTP/1.1 clients are still served
+	resp1, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp1.Body.Close()
+	assert.Equal(t, 1, resp1.ProtoMajor)
+}
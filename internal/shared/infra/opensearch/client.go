@@ -0,0 +1,168 @@
+// Package opensearch is a minimal client for OpenSearch and Elasticsearch:
+// the bulk and search APIs the search service and the query API need, over
+// plain HTTP.
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/services/search"
+)
+
+// maxResponseBytes bounds the search and bulk responses read into memory.
+const maxResponseBytes = 32 << 20
+
+// Config configures a Client.
+type Config struct {
+	URL         string // base URL, e.g. http://localhost:9200
+	Username    string // basic auth; empty sends no credentials
+	Password    string
+	IndexPrefix string        // prepended to the projection type to name its index
+	Timeout     time.Duration // per request; 0 sets none
+}
+
+// Client writes projection documents to, and searches, one index per
+// projection type.
+// It implements search.Index and query.Searcher.
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+// NewClient creates a new Client.
+func NewClient(config Config) *Client {
+	config.URL = strings.TrimSuffix(config.URL, "/")
+	return &Client{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// IndexName returns the index holding documents of projType.
+func (c *Client) IndexName(projType string) string {
+	return c.config.IndexPrefix + projType
+}
+
+// bulkAction is the action line of a bulk request item.
+type bulkAction struct {
+	Index string `json:"_index"`
+	ID    string `json:"_id"`
+}
+
+// bulkResponse is the part of a bulk response the client reads.
+type bulkResponse struct {
+	Errors bool                        `json:"errors"`
+	Items  []map[string]bulkItemResult `json:"items"`
+}
+
+type bulkItemResult struct {
+	Status int `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// Bulk indexes or deletes docs in one _bulk request. An item refused with a
+// 4xx status is a rejection, except a 429; deleting a missing document is
+// not an error. Any item refused with a 429 or a 5xx status fails the whole
+// call, so the batch is retried: index and delete by ID are idempotent.
+func (c *Client) Bulk(ctx context.Context, docs []search.Document) ([]search.Rejection, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]bulkAction{"index": {Index: c.IndexName(doc.ProjectionType), ID: doc.AggregateID}}
+		if doc.Source == nil {
+			action = map[string]bulkAction{"delete": action["index"]}
+		}
+		if err := enc.Encode(action); err != nil {
+			return nil, fmt.Errorf("failed to encode bulk action: %w", err)
+		}
+		if doc.Source != nil {
+			// The source is one line: compact it in case it was indented
+			if err := json.Compact(&body, doc.Source); err != nil {
+				return nil, fmt.Errorf("invalid document %s/%s: %w", doc.ProjectionType, doc.AggregateID, err)
+			}
+			body.WriteByte('\n')
+		}
+	}
+
+	status, resp, err := c.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("bulk request failed with status %d: %s", status, truncate(resp))
+	}
+	var result bulkResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil, nil
+	}
+	if len(result.Items) != len(docs) {
+		return nil, fmt.Errorf("bulk response has %d items for %d documents", len(result.Items), len(docs))
+	}
+
+	var rejected []search.Rejection
+	for i, item := range result.Items {
+		for op, r := range item {
+			switch {
+			case r.Error == nil, op == "delete" && r.Status == http.StatusNotFound:
+			case r.Status == http.StatusTooManyRequests || r.Status >= 500:
+				return nil, fmt.Errorf("bulk %s of %s/%s failed with status %d: %s",
+					op, docs[i].ProjectionType, docs[i].AggregateID, r.Status, r.Error.Reason)
+			default:
+				rejected = append(rejected, search.Rejection{Document: docs[i], Reason: r.Error.Type + ": " + r.Error.Reason})
+			}
+		}
+	}
+	return rejected, nil
+}
+
+// Search runs query, a search request body, against the index of projType
+// and returns the backend's status and response as they are. A missing
+// index, before any projection of the type was indexed, answers 404.
+func (c *Client) Search(ctx context.Context, projType string, query []byte) (int, []byte, error) {
+	return c.do(ctx, http.MethodPost, "/"+c.IndexName(projType)+"/_search", "application/json", query)
+}
+
+// do sends a request and reads its response.
+func (c *Client) do(ctx context.Context, method, path, contentType string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.config.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, data, nil
+}
+
+// truncate shortens a response body for an error message.
+func truncate(body []byte) string {
+	const max = 512
+	if len(body) > max {
+		return string(body[:max]) + "..."
+	}
+	return string(body)
+}
@@ -0,0 +1,108 @@
+package opensearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/services/search"
+)
+
+// backend answers every request with status and body, recording the last one.
+type backend struct {
+	status int
+	body   string
+
+	path, contentType, user, pass string
+	request                       string
+}
+
+func (b *backend) start(t *testing.T) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		b.path, b.contentType, b.request = r.URL.Path, r.Header.Get("Content-Type"), string(data)
+		b.user, b.pass, _ = r.BasicAuth()
+		w.WriteHeader(b.status)
+		io.WriteString(w, b.body)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(Config{URL: server.URL + "/", Username: "indexer", Password: "secret", IndexPrefix: "projections-"})
+}
+
+func TestClient_Bulk(t *testing.T) {
+	b := &backend{status: http.StatusOK, body: `{"errors":false,"items":[{"index":{"status":201}},{"delete":{"status":200}}]}`}
+	client := b.start(t)
+
+	rejected, err := client.Bulk(context.Background(), []search.Document{
+		{ProjectionType: "sensor_state", AggregateID: "device-001", Source: []byte("{\n  \"state\": {\"value\": 1}\n}")},
+		{ProjectionType: "user_session", AggregateID: "user-1"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, rejected)
+
+	assert.Equal(t, "/_bulk", b.path)
+	assert.Equal(t, "application/x-ndjson", b.contentType)
+	assert.Equal(t, "indexer", b.user)
+	assert.Equal(t, "secret", b.pass)
+	assert.Equal(t, `{"index":{"_index":"projections-sensor_state","_id":"device-001"}}
+{"state":{"value":1}}
+{"delete":{"_index":"projections-user_session","_id":"user-1"}}
+`, b.request)
+}
+
+func TestClient_BulkItemErrors(t *testing.T) {
+	docs := []search.Document{
+		{ProjectionType: "sensor_state", AggregateID: "device-001", Source: []byte(`{}`)},
+		{ProjectionType: "sensor_state", AggregateID: "device-002"},
+		{ProjectionType: "sensor_state", AggregateID: "device-003", Source: []byte(`{}`)},
+	}
+
+	t.Run("rejections", func(t *testing.T) {
+		b := &backend{status: http.StatusOK, body: `{"errors":true,"items":[
+			{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse field [state.value]"}}},
+			{"delete":{"status":404,"result":"not_found"}},
+			{"index":{"status":201}}]}`}
+
+		rejected, err := b.start(t).Bulk(context.Background(), docs)
+		require.NoError(t, err)
+		require.Len(t, rejected, 1, "deleting a missing document is not an error")
+		assert.Equal(t, "device-001", rejected[0].Document.AggregateID)
+		assert.Equal(t, "mapper_parsing_exception: failed to parse field [state.value]", rejected[0].Reason)
+	})
+
+	t.Run("retryable item", func(t *testing.T) {
+		b := &backend{status: http.StatusOK, body: `{"errors":true,"items":[
+			{"index":{"status":201}},
+			{"delete":{"status":200}},
+			{"index":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}}]}`}
+
+		_, err := b.start(t).Bulk(context.Background(), docs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status 429")
+	})
+
+	t.Run("failed request", func(t *testing.T) {
+		b := &backend{status: http.StatusServiceUnavailable, body: `{"error":"cluster_block_exception"}`}
+
+		_, err := b.start(t).Bulk(context.Background(), docs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status 503")
+	})
+}
+
+func TestClient_Search(t *testing.T) {
+	b := &backend{status: http.StatusBadRequest, body: `{"error":{"type":"parsing_exception"},"status":400}`}
+	client := b.start(t)
+
+	status, body, err := client.Search(context.Background(), "sensor_state", []byte(`{"query":{"bogus":{}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.JSONEq(t, b.body, string(body))
+	assert.Equal(t, "/projections-sensor_state/_search", b.path)
+	assert.Equal(t, `{"query":{"bogus":{}}}`, b.request)
+}
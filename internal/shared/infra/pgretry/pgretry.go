@@ -0,0 +1,213 @@
+// Package pgretry makes Postgres repositories ride out brief database
+// failures. DB wraps a connection pool: statements that fail with a transient
+// error (connection loss, failover, serialization failure) are retried with
+// backoff when they cannot have taken effect, and a circuit breaker fails
+// fast while the database keeps failing, instead of piling up requests that
+// wait for their own timeouts.
+package pgretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/retry"
+)
+
+// Querier is what the repositories query through: a *pgxpool.Pool or a DB.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Policy configures retries and the circuit breaker.
+type Policy struct {
+	MaxAttempts int           // total attempts including the first; 1 disables retries
+	BaseDelay   time.Duration // delay before the first retry, doubled for each further retry
+	MaxDelay    time.Duration // upper bound on a single delay
+
+	BreakerThreshold    int           // consecutive transient failures that open the breaker; 0 disables it
+	BreakerOpenDuration time.Duration // how long the open breaker fails statements before letting one through
+}
+
+// DefaultPolicy rides out a failover of a few seconds.
+var DefaultPolicy = Policy{
+	MaxAttempts:         4,
+	BaseDelay:           100 * time.Millisecond,
+	MaxDelay:            2 * time.Second,
+	BreakerThreshold:    10,
+	BreakerOpenDuration: 5 * time.Second,
+}
+
+// ErrCircuitOpen is returned without querying the database while the
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("database circuit breaker open")
+
+// transientCodes are SQLSTATEs worth retrying: the statement did not take
+// effect and may succeed on another attempt.
+var transientCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"57P01": true, // admin_shutdown (failover, restart)
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now (starting up, in recovery)
+}
+
+// IsTransient reports whether err is one the database may recover from: a
+// connection failure or reset, or a server error in transientCodes (or class
+// 08, connection exception). Context cancellation is not transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return transientCodes[pgErr.Code] || strings.HasPrefix(pgErr.Code, "08")
+	}
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return pgconn.SafeToRetry(err) ||
+		errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// notApplied reports whether a transient err shows the statement did not
+// take effect, so running it again cannot apply it twice: pgx failed before
+// sending it, the connection could not be made, or the server rejected it.
+// A connection lost after the statement was sent may hide a commit.
+func notApplied(err error) bool {
+	if !IsTransient(err) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	var connectErr *pgconn.ConnectError
+	return pgconn.SafeToRetry(err) || errors.As(err, &pgErr) || errors.As(err, &connectErr)
+}
+
+// DB is a Querier that retries transient failures of the Querier it wraps.
+// Exec and QueryRow carry writes, some of which are not idempotent (counters,
+// appends), so they are retried only when the statement did not take effect.
+// Query is retried on any transient failure only for a SELECT; other
+// statements, such as DELETE ... RETURNING, are treated as writes. Errors
+// reading rows after Query returned are not retried.
+type DB struct {
+	db      Querier
+	policy  Policy
+	breaker *retry.Breaker // nil when disabled
+	logger  *slog.Logger
+}
+
+// New wraps db. name identifies the database in logs, e.g. "ingestion".
+func New(db Querier, name string, policy Policy, logger *slog.Logger) *DB {
+	d := &DB{
+		db:     db,
+		policy: policy,
+		logger: logger.With("component", "pgretry", "database", name),
+	}
+	if policy.BreakerThreshold > 0 {
+		d.breaker = retry.NewBreaker(policy.BreakerThreshold, policy.BreakerOpenDuration)
+	}
+	return d
+}
+
+// Exec runs a statement, retrying transient failures that did not apply it.
+func (d *DB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	var tag pgconn.CommandTag
+	err := d.do(ctx, notApplied, func() error {
+		var err error
+		tag, err = d.db.Exec(ctx, sql, args...)
+		return err
+	})
+	return tag, err
+}
+
+// Query runs a query, retrying transient failures of the query itself: any
+// of them for a SELECT, only those that did not apply it otherwise.
+func (d *DB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	retryable := notApplied
+	if isSelect(sql) {
+		retryable = IsTransient
+	}
+	var rows pgx.Rows
+	err := d.do(ctx, retryable, func() error {
+		var err error
+		rows, err = d.db.Query(ctx, sql, args...)
+		return err
+	})
+	return rows, err
+}
+
+// isSelect reports whether sql is a plain SELECT, which can be run again.
+// A WITH query may modify data and is not one.
+func isSelect(sql string) bool {
+	fields := strings.Fields(sql)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "SELECT")
+}
+
+// QueryRow returns a row that runs the query when scanned, retrying
+// transient failures of the query and scan together that did not apply it.
+func (d *DB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &row{db: d, ctx: ctx, sql: sql, args: args}
+}
+
+type row struct {
+	db   *DB
+	ctx  context.Context
+	sql  string
+	args []any
+}
+
+func (r *row) Scan(dest ...any) error {
+	return r.db.do(r.ctx, notApplied, func() error {
+		return r.db.db.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+	})
+}
+
+// do runs fn until it succeeds, fails with an error retryable rejects or runs
+// out of attempts. Only transient failures count against the breaker: any
+// other outcome shows the database is up.
+func (d *DB) do(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		if d.breaker != nil && !d.breaker.Allow() {
+			return ErrCircuitOpen
+		}
+
+		err := fn()
+		if err != nil && ctx.Err() != nil {
+			// Cancelled by the caller: says nothing about the database
+			if d.breaker != nil {
+				d.breaker.Release()
+			}
+			return err
+		}
+		transient := IsTransient(err)
+		if d.breaker != nil && d.breaker.Record(!transient) {
+			d.logger.Error("database circuit breaker opened", "error", err, "open_for", d.policy.BreakerOpenDuration)
+		}
+		if !transient || !retryable(err) {
+			return err
+		}
+		if attempt >= d.policy.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		delay := retry.Backoff(d.policy.BaseDelay, d.policy.MaxDelay, attempt)
+		d.logger.Warn("retrying transient database error", "attempt", attempt, "delay", delay, "error", err)
+		if err := clock.Sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package pgretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// fakeQuerier fails with the queued errors, then succeeds.
+type fakeQuerier struct {
+	errs  []error
+	calls int
+}
+
+func (f *fakeQuerier) next() error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *fakeQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if err := f.next(); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (f *fakeQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, f.next()
+}
+
+func (f *fakeQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return fakeRow{err: f.next()}
+}
+
+type fakeRow struct{ err error }
+
+func (r fakeRow) Scan(dest ...any) error { return r.err }
+
+// unsentError is a failure pgx reports before sending the statement.
+type unsentError struct{}
+
+func (unsentError) Error() string     { return "connection busy" }
+func (unsentError) SafeToRetry() bool { return true }
+
+var fastPolicy = Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"admin shutdown", fmt.Errorf("insert: %w", &pgconn.PgError{Code: "57P01"}), true},
+		{"connection exception class", &pgconn.PgError{Code: "08006"}, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false},
+		{"connection reset", io.ErrUnexpectedEOF, true},
+		{"no rows", pgx.ErrNoRows, false},
+		{"cancelled", context.Canceled, false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestDB_RetriesTransientErrors(t *testing.T) {
+	fake := &fakeQuerier{errs: []error{&pgconn.PgError{Code: "40001"}, unsentError{}}}
+	db := New(fake, "test", fastPolicy, slog.Default())
+
+	tag, err := db.Exec(context.Background(), "INSERT")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), tag.RowsAffected())
+	assert.Equal(t, 3, fake.calls)
+}
+
+func TestDB_WritesAreNotRetriedAfterTheStatementWasSent(t *testing.T) {
+	// A connection lost mid-statement may hide a commit: running an
+	// increment again would count it twice
+	fake := &fakeQuerier{errs: []error{io.ErrUnexpectedEOF}}
+	db := New(fake, "test", fastPolicy, slog.Default())
+	_, err := db.Exec(context.Background(), "UPDATE")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, 1, fake.calls)
+
+	fake = &fakeQuerier{errs: []error{io.ErrUnexpectedEOF}}
+	db = New(fake, "test", fastPolicy, slog.Default())
+	err = db.QueryRow(context.Background(), "INSERT ... RETURNING").Scan()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, 1, fake.calls)
+
+	// So would a write that returns rows
+	for _, sql := range []string{"DELETE ... RETURNING", "WITH d AS (DELETE ...) SELECT"} {
+		fake = &fakeQuerier{errs: []error{io.ErrUnexpectedEOF}}
+		db = New(fake, "test", fastPolicy, slog.Default())
+		_, err = db.Query(context.Background(), sql)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF, sql)
+		assert.Equal(t, 1, fake.calls, sql)
+	}
+
+	// Reads can be repeated
+	fake = &fakeQuerier{errs: []error{io.ErrUnexpectedEOF}}
+	db = New(fake, "test", fastPolicy, slog.Default())
+	_, err = db.Query(context.Background(), "\n\t\tselect ...")
+	require.NoError(t, err)
+	assert.Equal(t, 2, fake.calls)
+}
+
+func TestNotApplied(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"admin shutdown", fmt.Errorf("insert: %w", &pgconn.PgError{Code: "57P01"}), true},
+		{"not sent", unsentError{}, true},
+		{"connect failure", &pgconn.ConnectError{}, true},
+		{"connection reset", io.ErrUnexpectedEOF, false},
+		{"eof", io.EOF, false},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, notApplied(tt.err))
+		})
+	}
+}
+
+func TestDB_GivesUpAfterMaxAttempts(t *testing.T) {
+	transient := &pgconn.PgError{Code: "57P01"}
+	fake := &fakeQuerier{errs: []error{transient, transient, transient, transient}}
+	db := New(fake, "test", fastPolicy, slog.Default())
+
+	_, err := db.Query(context.Background(), "SELECT")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, transient)
+	assert.Equal(t, 3, fake.calls)
+}
+
+func TestDB_DoesNotRetryOtherErrors(t *testing.T) {
+	for _, err := range []error{pgx.ErrNoRows, &pgconn.PgError{Code: "23505"}} {
+		fake := &fakeQuerier{errs: []error{err}}
+		db := New(fake, "test", fastPolicy, slog.Default())
+
+		got := db.QueryRow(context.Background(), "SELECT").Scan()
+		assert.ErrorIs(t, got, err)
+		assert.Equal(t, 1, fake.calls)
+	}
+}
+
+func TestDB_StopsRetryingWhenCancelled(t *testing.T) {
+	fake := &fakeQuerier{errs: []error{io.ErrUnexpectedEOF, io.ErrUnexpectedEOF}}
+	db := New(fake, "test", Policy{MaxAttempts: 3, BaseDelay: time.Hour}, slog.Default())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := db.Query(ctx, "SELECT")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, fake.calls)
+}
+
+func TestDB_BacksOffOnTheClock(t *testing.T) {
+	t.Cleanup(clock.Reset)
+	c := clock.NewManualClock(time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+	clock.Set(c)
+
+	fake := &fakeQuerier{errs: []error{io.ErrUnexpectedEOF, io.ErrUnexpectedEOF}}
+	db := New(fake, "test", Policy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: 2 * time.Hour}, slog.Default())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := db.Query(ctx, "SELECT")
+		done <- err
+	}()
+
+	// An hour, then two, without real waiting
+	require.NoError(t, c.WaitForTimers(ctx, 1))
+	c.Add(time.Hour)
+	require.NoError(t, c.WaitForTimers(ctx, 1))
+	c.Add(2 * time.Hour)
+	require.NoError(t, <-done)
+	assert.Equal(t, 3, fake.calls)
+}
+
+func TestDB_CircuitBreaker(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	defer clock.Reset()
+
+	transient := io.ErrUnexpectedEOF
+	fake := &fakeQuerier{errs: []error{transient, transient, transient}}
+	db := New(fake, "test", Policy{
+		MaxAttempts:         1,
+		BreakerThreshold:    2,
+		BreakerOpenDuration: 5 * time.Second,
+	}, slog.Default())
+	ctx := context.Background()
+
+	// Two consecutive transient failures open the breaker
+	_, err := db.Exec(ctx, "INSERT")
+	assert.ErrorIs(t, err, transient)
+	_, err = db.Exec(ctx, "INSERT")
+	assert.ErrorIs(t, err, transient)
+	_, err = db.Exec(ctx, "INSERT")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, fake.calls)
+
+	// After the open duration a failing trial opens it again
+	clock.Set(clock.FixedClock{Time: now.Add(5 * time.Second)})
+	_, err = db.Exec(ctx, "INSERT")
+	assert.ErrorIs(t, err, transient)
+	_, err = db.Exec(ctx, "INSERT")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// A successful trial closes it
+	clock.Set(clock.FixedClock{Time: now.Add(10 * time.Second)})
+	_, err = db.Exec(ctx, "INSERT")
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, "INSERT")
+	require.NoError(t, err)
+	assert.Equal(t, 5, fake.calls)
+}
+
+func TestDB_NonTransientErrorsKeepBreakerClosed(t *testing.T) {
+	fake := &fakeQuerier{errs: []error{io.ErrUnexpectedEOF, pgx.ErrNoRows, io.ErrUnexpectedEOF}}
+	db := New(fake, "test", Policy{MaxAttempts: 1, BreakerThreshold: 2, BreakerOpenDuration: time.Hour}, slog.Default())
+
+	for range 3 {
+		_, err := db.Exec(context.Background(), "INSERT")
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+	_, err := db.Exec(context.Background(), "INSERT")
+	require.NoError(t, err)
+}
@@ -0,0 +1,143 @@
+// Package pgtrace measures every statement run on a pgx connection: latency,
+// rows and errors per repository and operation, and a log line for slow
+// statements. Repositories name their statements with WithOperation.
+package pgtrace
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+)
+
+// untagged is the repository label of statements run without WithOperation.
+const untagged = "other"
+
+type operationKey struct{}
+
+type operation struct {
+	repository string
+	name       string
+}
+
+// WithOperation tags the statements run with ctx as operation of repository,
+// e.g. ("outbox", "fetch_pending").
+func WithOperation(ctx context.Context, repository, name string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation{repository: repository, name: name})
+}
+
+// Metrics are the statement metric families, shared by the tracers of all
+// databases.
+type Metrics struct {
+	duration *metrics.Histogram
+	rows     *metrics.Histogram
+	errors   *metrics.Counter
+}
+
+// NewMetrics registers the statement metric families with reg.
+func NewMetrics(reg *metrics.Registry) *Metrics {
+	labels := []string{"database", "repository", "operation"}
+	return &Metrics{
+		duration: reg.NewHistogram("db_query_duration_seconds",
+			"Time to run a statement, including reading its rows.",
+			metrics.DurationBuckets, labels...),
+		rows: reg.NewHistogram("db_query_rows",
+			"Rows returned or affected by a successful statement.",
+			[]float64{0, 1, 10, 100, 1000, 10000}, labels...),
+		errors: reg.NewCounter("db_query_errors_total",
+			"Statements that failed.", labels...),
+	}
+}
+
+// Tracer is a pgx.QueryTracer recording into Metrics. Set it as the
+// ConnConfig.Tracer of a pool.
+type Tracer struct {
+	database string
+	metrics  *Metrics
+	slow     time.Duration
+	logger   *slog.Logger
+}
+
+// NewTracer creates the tracer for the database called database, e.g.
+// "ingestion". Statements taking slow or longer are logged; 0 disables the
+// log.
+func NewTracer(m *Metrics, database string, slow time.Duration, logger *slog.Logger) *Tracer {
+	return &Tracer{
+		database: database,
+		metrics:  m,
+		slow:     slow,
+		logger:   logger.With("component", "pgtrace", "database", database),
+	}
+}
+
+type startKey struct{}
+
+type start struct {
+	at  time.Time
+	sql string
+}
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, startKey{}, start{at: time.Now(), sql: data.SQL})
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	s, ok := ctx.Value(startKey{}).(start)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(s.at)
+
+	op, ok := ctx.Value(operationKey{}).(operation)
+	if !ok {
+		op = operation{repository: untagged, name: verb(s.sql)}
+	}
+
+	if data.Err != nil {
+		t.metrics.errors.Inc(t.database, op.repository, op.name)
+	} else {
+		t.metrics.rows.Observe(float64(data.CommandTag.RowsAffected()), t.database, op.repository, op.name)
+	}
+	t.metrics.duration.Observe(elapsed.Seconds(), t.database, op.repository, op.name)
+
+	if t.slow > 0 && elapsed >= t.slow {
+		t.logger.Warn("slow query",
+			"repository", op.repository,
+			"operation", op.name,
+			"duration", elapsed,
+			"rows", data.CommandTag.RowsAffected(),
+			"error", data.Err,
+			"sql", compact(s.sql),
+		)
+	}
+}
+
+// verb names an untagged statement by its first keyword, e.g. "select".
+func verb(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	return strings.ToLower(fields[0])
+}
+
+// maxLoggedSQL bounds the statement text in slow query logs.
+const maxLoggedSQL = 500
+
+// compact collapses the whitespace of a statement for logging. Arguments
+// are never logged: they may hold payloads and personal data.
+func compact(sql string) string {
+	s := strings.Join(strings.Fields(sql), " ")
+	if len(s) > maxLoggedSQL {
+		s = s[:maxLoggedSQL] + "..."
+	}
+	return s
+}
+
+var _ pgx.QueryTracer = (*Tracer)(nil)
@@ -0,0 +1,73 @@
+package pgtrace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+)
+
+// run traces one statement through t.
+func run(ctx context.Context, t *Tracer, sql, tag string, err error) {
+	ctx = t.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql})
+	t.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag(tag), Err: err})
+}
+
+func scrape(t *testing.T, reg *metrics.Registry) string {
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+	return out.String()
+}
+
+func TestTracer_RecordsPerOperation(t *testing.T) {
+	reg := metrics.NewRegistry()
+	tracer := NewTracer(NewMetrics(reg), "ingestion", 0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx := WithOperation(context.Background(), "outbox", "fetch_pending")
+	run(ctx, tracer, "SELECT * FROM outbox", "SELECT 25", nil)
+	run(ctx, tracer, "SELECT * FROM outbox", "", errors.New("connection reset"))
+	run(context.Background(), tracer, "\n\t\tDELETE FROM outbox_archive", "DELETE 3", nil)
+
+	out := scrape(t, reg)
+	assert.Contains(t, out, `db_query_duration_seconds_count{database="ingestion",repository="outbox",operation="fetch_pending"} 2`)
+	assert.Contains(t, out, `db_query_rows_sum{database="ingestion",repository="outbox",operation="fetch_pending"} 25`)
+	assert.Contains(t, out, `db_query_rows_count{database="ingestion",repository="outbox",operation="fetch_pending"} 1`)
+	assert.Contains(t, out, `db_query_errors_total{database="ingestion",repository="outbox",operation="fetch_pending"} 1`)
+	// Untagged statements are named by their verb
+	assert.Contains(t, out, `db_query_rows_sum{database="ingestion",repository="other",operation="delete"} 3`)
+}
+
+func TestTracer_LogsSlowQueries(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	ctx := WithOperation(context.Background(), "projections", "write_projection")
+
+	// A threshold every statement reaches
+	slow := NewTracer(NewMetrics(metrics.NewRegistry()), "eventhandler", 1, logger)
+	run(ctx, slow, "INSERT INTO projections\n    VALUES ($1, $2)", "INSERT 0 1", nil)
+	assert.Contains(t, buf.String(), "slow query")
+	assert.Contains(t, buf.String(), "operation=write_projection")
+	assert.Contains(t, buf.String(), `sql="INSERT INTO projections VALUES ($1, $2)"`)
+
+	buf.Reset()
+	disabled := NewTracer(NewMetrics(metrics.NewRegistry()), "eventhandler", 0, logger)
+	run(ctx, disabled, "INSERT INTO projections", "INSERT 0 1", nil)
+	assert.Empty(t, buf.String())
+}
+
+func TestCompact_Truncates(t *testing.T) {
+	long := "SELECT " + strings.Repeat("x, ", 400)
+	got := compact(long)
+	assert.Len(t, got, maxLoggedSQL+len("..."))
+	assert.True(t, strings.HasSuffix(got, "..."))
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLock is a leader.Lock backed by a session-level Postgres advisory
+// lock. The lock is held on a connection taken out of the pool for as long
+// as the instance competes for it. Postgres releases the lock when that
+// session ends, so a crashed leader's lock frees up once its connection is
+// gone.
+type AdvisoryLock struct {
+	pool   *pgxpool.Pool
+	name   string
+	key    int64
+	conn   *pgx.Conn // nil until TryAcquire; owned by the lock, not the pool
+	held   bool
+	logger *slog.Logger
+}
+
+// NewAdvisoryLock creates the advisory lock called name. Every instance must
+// use the same database and name to compete for the same lock.
+func NewAdvisoryLock(pool *pgxpool.Pool, name string, logger *slog.Logger) *AdvisoryLock {
+	return &AdvisoryLock{
+		pool:   pool,
+		name:   name,
+		key:    AdvisoryLockKey(name),
+		logger: logger.With("component", "advisory-lock", "lock", name),
+	}
+}
+
+// AdvisoryLockKey maps a lock name to the 64-bit key Postgres locks on.
+func AdvisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("platform-services/" + name))
+	return int64(h.Sum64())
+}
+
+// TryAcquire takes the lock if no other session holds it.
+func (l *AdvisoryLock) TryAcquire(ctx context.Context) (bool, error) {
+	if l.conn == nil || l.conn.IsClosed() {
+		pooled, err := l.pool.Acquire(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to acquire connection for lock %s: %w", l.name, err)
+		}
+		l.conn = pooled.Hijack()
+	}
+
+	if err := l.conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&l.held); err != nil {
+		l.closeConn(ctx)
+		return false, fmt.Errorf("failed to try lock %s: %w", l.name, err)
+	}
+	return l.held, nil
+}
+
+// Check pings the session holding the lock. A session that still answers
+// still holds the lock; a new one would not.
+func (l *AdvisoryLock) Check(ctx context.Context) error {
+	if !l.held || l.conn == nil || l.conn.IsClosed() {
+		return fmt.Errorf("lock %s is not held", l.name)
+	}
+	if err := l.conn.Ping(ctx); err != nil {
+		l.closeConn(ctx)
+		return fmt.Errorf("lost connection holding lock %s: %w", l.name, err)
+	}
+	return nil
+}
+
+// Release unlocks the lock if held and closes its connection.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+	var err error
+	if l.held && !l.conn.IsClosed() {
+		if _, execErr := l.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", l.key); execErr != nil {
+			// Closing the session below releases it anyway
+			err = fmt.Errorf("failed to unlock %s: %w", l.name, execErr)
+		}
+	}
+	l.closeConn(ctx)
+	return err
+}
+
+func (l *AdvisoryLock) closeConn(ctx context.Context) {
+	if err := l.conn.Close(ctx); err != nil {
+		l.logger.Debug("failed to close lock connection", "error", err)
+	}
+	l.conn = nil
+	l.held = false
+}
@@ -0,0 +1,61 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+func TestAdvisoryLock_Exclusive(t *testing.T) {
+	ctx := context.Background()
+	pool := testutil.NewTestPool(t)
+	name := "test-" + t.Name()
+
+	first := NewAdvisoryLock(pool, name, testLogger())
+	second := NewAdvisoryLock(pool, name, testLogger())
+	defer first.Release(ctx)
+	defer second.Release(ctx)
+
+	ok, err := first.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.NoError(t, first.Check(ctx))
+
+	ok, err = second.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok, "lock is held by another session")
+
+	require.NoError(t, first.Release(ctx))
+	ok, err = second.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok, "released lock can be taken over")
+	assert.Error(t, first.Check(ctx))
+}
+
+func TestAdvisoryLock_FreedWhenSessionEnds(t *testing.T) {
+	ctx := context.Background()
+	pool := testutil.NewTestPool(t)
+	name := "test-" + t.Name()
+
+	leader := NewAdvisoryLock(pool, name, testLogger())
+	follower := NewAdvisoryLock(pool, name, testLogger())
+	defer follower.Release(ctx)
+
+	ok, err := leader.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The leader dies: its session ends without unlocking
+	require.NoError(t, leader.conn.Close(ctx))
+	assert.Error(t, leader.Check(ctx))
+
+	ok, err = follower.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,10 +18,38 @@ type Client struct {
 
 // NewClient creates a new PostgreSQL client with a connection pool.
 func NewClient(ctx context.Context, databaseURL string, logger *slog.Logger) (*Client, error) {
+	return NewRotatingClient(ctx, databaseURL, nil, nil, logger)
+}
+
+// NewRotatingClient is NewClient for credentials that rotate: before every
+// new connection it calls refresh and connects with the user and password
+// of the URL it returns. Open connections keep their credentials until they
+// reach MaxConnLifetime. A nil refresh behaves like NewClient. tracer, if
+// not nil, observes every statement (see pgtrace.Tracer).
+func NewRotatingClient(ctx context.Context, databaseURL string, refresh func(context.Context) (string, error), tracer pgx.QueryTracer, logger *slog.Logger) (*Client, error) {
 	config, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database URL: %w", err)
 	}
+	if refresh != nil {
+		config.BeforeConnect = func(ctx context.Context, conn *pgx.ConnConfig) error {
+			current, err := refresh(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to refresh database credentials: %w", err)
+			}
+			parsed, err := pgx.ParseConfig(current)
+			if err != nil {
+				return fmt.Errorf("failed to parse refreshed database URL: %w", err)
+			}
+			conn.User = parsed.User
+			conn.Password = parsed.Password
+			return nil
+		}
+	}
+
+	if tracer != nil {
+		config.ConnConfig.Tracer = tracer
+	}
 
 	// Configure pool settings
 	config.MaxConns = 10
@@ -2,46 +2,69 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgtrace"
 )
 
 // EventStoreRepo implements outbox.EventStoreWriter using PostgreSQL.
 type EventStoreRepo struct {
-	pool   *pgxpool.Pool
+	db     pgretry.Querier // the pool, or a pgretry.DB wrapping it
 	logger *slog.Logger
 }
 
 // NewEventStoreRepo creates a new EventStoreRepo.
 func NewEventStoreRepo(pool *pgxpool.Pool, logger *slog.Logger) *EventStoreRepo {
 	return &EventStoreRepo{
-		pool:   pool,
+		db:     pool,
 		logger: logger.With("repository", "event_store"),
 	}
 }
 
-// Insert adds an event to the event store.
-// Returns an error if the event_id already exists (unique constraint).
+// SetQuerier routes the repository's statements through q, typically a
+// pgretry.DB wrapping the pool.
+func (r *EventStoreRepo) SetQuerier(q pgretry.Querier) {
+	r.db = q
+}
+
+// Insert adds an event to the event store and sets its assigned GlobalSeq and
+// AggregateSeq, so they travel with the event when it is published.
+// Returns an error if the event_id already exists (unique constraint); the
+// event still receives the sequence numbers assigned on the first insert.
 func (r *EventStoreRepo) Insert(ctx context.Context, event *events.Envelope) error {
+	ctx = pgtrace.WithOperation(ctx, "event_store", "insert")
 	query := `
-		INSERT INTO event_store (event_id, event_type, aggregate_id, event_time, ingested_at, payload, metadata)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO event_store (event_id, event_type, aggregate_id, aggregate_type, event_time, ingested_at, payload, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING global_seq, aggregate_seq
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	err := r.db.QueryRow(ctx, query,
 		event.EventID,
 		event.EventType,
 		event.AggregateID,
+		event.AggregateType,
 		event.EventTime,
 		event.IngestedAt,
 		event.Payload,
 		event.Metadata,
-	)
+	).Scan(&event.GlobalSeq, &event.AggregateSeq)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			// Retried outbox entry: republish with the original sequence numbers
+			r.loadSeq(ctx, event)
+		}
 		return fmt.Errorf("failed to insert into event_store: %w", err)
 	}
 
@@ -52,3 +75,282 @@ func (r *EventStoreRepo) Insert(ctx context.Context, event *events.Envelope) err
 
 	return nil
 }
+
+// Get returns a stored event by ID, with its sequence numbers, or an error
+// wrapping pgx.ErrNoRows when there is none.
+func (r *EventStoreRepo) Get(ctx context.Context, eventID uuid.UUID) (*events.Envelope, error) {
+	ctx = pgtrace.WithOperation(ctx, "event_store", "get")
+	query := `
+		SELECT event_id, event_type, aggregate_id, aggregate_type, event_time, ingested_at, payload, metadata,
+		       global_seq, aggregate_seq
+		FROM event_store
+		WHERE event_id = $1
+	`
+
+	event, err := scanSequencedEvent(r.db.QueryRow(ctx, query, eventID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event %s: %w", eventID, err)
+	}
+	return event, nil
+}
+
+// loadSeq sets the sequence numbers of an already stored event (best-effort).
+func (r *EventStoreRepo) loadSeq(ctx context.Context, event *events.Envelope) {
+	query := `SELECT global_seq, aggregate_seq FROM event_store WHERE event_id = $1`
+
+	if err := r.db.QueryRow(ctx, query, event.EventID).Scan(&event.GlobalSeq, &event.AggregateSeq); err != nil {
+		r.logger.Warn("failed to load sequence numbers of stored event",
+			"event_id", event.EventID,
+			"error", err,
+		)
+	}
+}
+
+// FetchAfter returns events with global_seq greater than afterSeq, in
+// global_seq order. Pass the last returned event's GlobalSeq as the next
+// afterSeq to catch up without gaps or duplicates. types filters by event
+// type: exact names, or prefixes ending in "*" (sensor.*); empty matches all.
+//
+// Sequence numbers are drawn at insert but become visible at commit, so a
+// lower number can briefly commit after a higher one. Rows from transactions
+// that may still be in flight are therefore withheld until every older
+// transaction has finished; a reader can never move past a gap that later fills.
+func (r *EventStoreRepo) FetchAfter(ctx context.Context, afterSeq int64, types []string, limit int) ([]*events.Envelope, error) {
+	return r.fetchAfter(ctx, afterSeq, "", types, limit)
+}
+
+// FetchAfterOfAggregateType is FetchAfter restricted to events of one
+// aggregate type (see events.Envelope.AggregateType).
+func (r *EventStoreRepo) FetchAfterOfAggregateType(ctx context.Context, afterSeq int64, aggregateType string, types []string, limit int) ([]*events.Envelope, error) {
+	return r.fetchAfter(ctx, afterSeq, aggregateType, types, limit)
+}
+
+// fetchAfter implements FetchAfter; an empty aggregateType matches every event.
+func (r *EventStoreRepo) fetchAfter(ctx context.Context, afterSeq int64, aggregateType string, types []string, limit int) ([]*events.Envelope, error) {
+	ctx = pgtrace.WithOperation(ctx, "event_store", "fetch_after")
+	query := `
+		SELECT event_id, event_type, aggregate_id, aggregate_type, event_time, ingested_at, payload, metadata,
+		       global_seq, aggregate_seq
+		FROM event_store
+		WHERE global_seq > $1
+		  AND insert_xid < pg_snapshot_xmin(pg_current_snapshot())
+		  AND (cardinality($2::text[]) = 0 OR event_type LIKE ANY($2))
+		  AND ($3 = '' OR aggregate_type = $3)
+		ORDER BY global_seq
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(ctx, query, afterSeq, likePatterns(types), aggregateType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event_store: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*events.Envelope
+	for rows.Next() {
+		e, err := scanSequencedEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event_store rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// FetchAggregate returns up to limit events of one aggregate with aggregate_seq
+// in [fromSeq, toSeq], in aggregate_seq order. A toSeq of 0 means no upper
+// bound. Unlike FetchAfter no rows are withheld: the per-aggregate counter row
+// lock serializes inserts, so an aggregate's events commit in sequence order.
+func (r *EventStoreRepo) FetchAggregate(ctx context.Context, aggregateID string, fromSeq, toSeq int64, limit int) ([]*events.Envelope, error) {
+	ctx = pgtrace.WithOperation(ctx, "event_store", "fetch_aggregate")
+	query := `
+		SELECT event_id, event_type, aggregate_id, aggregate_type, event_time, ingested_at, payload, metadata,
+		       global_seq, aggregate_seq
+		FROM event_store
+		WHERE aggregate_id = $1
+		  AND aggregate_seq >= $2
+		  AND ($3 = 0 OR aggregate_seq <= $3)
+		ORDER BY aggregate_seq
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(ctx, query, aggregateID, fromSeq, toSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event_store: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*events.Envelope
+	for rows.Next() {
+		e, err := scanSequencedEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event_store rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// scanSequencedEvent scans an event_store row selected with its sequence numbers.
+func scanSequencedEvent(row pgx.Row) (*events.Envelope, error) {
+	var e events.Envelope
+	if err := row.Scan(
+		&e.EventID,
+		&e.EventType,
+		&e.AggregateID,
+		&e.AggregateType,
+		&e.EventTime,
+		&e.IngestedAt,
+		&e.Payload,
+		&e.Metadata,
+		&e.GlobalSeq,
+		&e.AggregateSeq,
+	); err != nil {
+		return nil, fmt.Errorf("failed to scan event_store row: %w", err)
+	}
+	return &e, nil
+}
+
+// LatestCursor marks a position in event_latest for keyset pagination.
+// The zero value starts from the beginning.
+type LatestCursor struct {
+	EventType   string
+	AggregateID string
+}
+
+// FetchLatest returns the latest event per (event_type, aggregate_id) for event
+// types starting with eventTypePrefix, ordered by event_type then aggregate_id.
+// Used to bootstrap a new projection from compacted state before tailing live events.
+// Pass the last returned envelope's type and aggregate as the next cursor.
+func (r *EventStoreRepo) FetchLatest(ctx context.Context, eventTypePrefix string, after LatestCursor, limit int) ([]*events.Envelope, error) {
+	ctx = pgtrace.WithOperation(ctx, "event_store", "fetch_latest")
+	query := `
+		SELECT event_id, event_type, aggregate_id, aggregate_type, event_time, ingested_at, payload, metadata
+		FROM event_latest
+		WHERE starts_with(event_type, $1)
+		  AND (event_type, aggregate_id) > ($2, $3)
+		ORDER BY event_type, aggregate_id
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(ctx, query, eventTypePrefix, after.EventType, after.AggregateID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event_latest: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*events.Envelope
+	for rows.Next() {
+		var e events.Envelope
+		if err := rows.Scan(
+			&e.EventID,
+			&e.EventType,
+			&e.AggregateID,
+			&e.AggregateType,
+			&e.EventTime,
+			&e.IngestedAt,
+			&e.Payload,
+			&e.Metadata,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan event_latest row: %w", err)
+		}
+		result = append(result, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event_latest rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetLatest returns the newest event for the aggregate among event types starting
+// with eventTypePrefix. Returns an error wrapping pgx.ErrNoRows if there is none.
+func (r *EventStoreRepo) GetLatest(ctx context.Context, eventTypePrefix, aggregateID string) (*events.Envelope, error) {
+	ctx = pgtrace.WithOperation(ctx, "event_store", "get_latest")
+	query := `
+		SELECT event_id, event_type, aggregate_id, aggregate_type, event_time, ingested_at, payload, metadata
+		FROM event_latest
+		WHERE starts_with(event_type, $1) AND aggregate_id = $2
+		ORDER BY event_time DESC, event_id DESC
+		LIMIT 1
+	`
+
+	var e events.Envelope
+	err := r.db.QueryRow(ctx, query, eventTypePrefix, aggregateID).Scan(
+		&e.EventID,
+		&e.EventType,
+		&e.AggregateID,
+		&e.AggregateType,
+		&e.EventTime,
+		&e.IngestedAt,
+		&e.Payload,
+		&e.Metadata,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest event: %w", err)
+	}
+
+	return &e, nil
+}
+
+// SchemaVersions returns the highest payload schema version stored for each
+// event type. It reads event_latest rather than scanning event_store, so only
+// each aggregate's latest event is considered.
+func (r *EventStoreRepo) SchemaVersions(ctx context.Context) (map[string]int, error) {
+	ctx = pgtrace.WithOperation(ctx, "event_store", "schema_versions")
+	query := `
+		SELECT event_type, MAX(COALESCE((metadata->>'schema_version')::int, 0))
+		FROM event_latest
+		GROUP BY event_type
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[string]int)
+	for rows.Next() {
+		var eventType string
+		var version int
+		if err := rows.Scan(&eventType, &version); err != nil {
+			return nil, fmt.Errorf("failed to scan schema version: %w", err)
+		}
+		versions[eventType] = version
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schema versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+// likePatterns converts event type patterns to LIKE patterns: a trailing "*"
+// becomes "%", and LIKE wildcards in the names are escaped. Never returns nil.
+func likePatterns(types []string) []string {
+	patterns := make([]string, 0, len(types))
+	for _, t := range types {
+		prefix, wildcard := strings.CutSuffix(t, "*")
+		p := likeEscaper.Replace(prefix)
+		if wildcard {
+			p += "%"
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -4,16 +4,19 @@ package postgres
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/signing"
 	"github.com/cornjacket/platform-services/internal/testutil"
 )
 
@@ -24,7 +27,7 @@ func TestEventStoreInsert(t *testing.T) {
 	testutil.TruncateTables(t, testPool, "event_store")
 	repo := NewEventStoreRepo(testPool, testLogger())
 
-	env := testEnvelope(t)
+	env := testutil.Event().Build()
 	err := repo.Insert(context.Background(), env)
 	require.NoError(t, err)
 
@@ -59,7 +62,7 @@ func TestEventStoreInsert_Duplicate(t *testing.T) {
 	testutil.TruncateTables(t, testPool, "event_store")
 	repo := NewEventStoreRepo(testPool, testLogger())
 
-	env := testEnvelope(t)
+	env := testutil.Event().Build()
 	require.NoError(t, repo.Insert(context.Background(), env))
 
 	// Second insert with same event_id should fail
@@ -72,11 +75,29 @@ func TestEventStoreInsert_Duplicate(t *testing.T) {
 	assert.Equal(t, "23505", pgErr.Code)
 }
 
+func TestEventStoreGet(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "event_store")
+	repo := NewEventStoreRepo(testPool, testLogger())
+
+	env := testutil.Event().Build()
+	require.NoError(t, repo.Insert(context.Background(), env))
+
+	got, err := repo.Get(context.Background(), env.EventID)
+	require.NoError(t, err)
+	assert.Equal(t, env.EventID, got.EventID)
+	assert.True(t, env.IngestedAt.Equal(got.IngestedAt))
+	assert.JSONEq(t, string(env.Payload), string(got.Payload))
+	assert.Equal(t, env.GlobalSeq, got.GlobalSeq)
+
+	_, err = repo.Get(context.Background(), testutil.Event().Build().EventID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
 func TestEventStoreInsertRoundTrip(t *testing.T) {
 	testutil.TruncateTables(t, testPool, "event_store")
 	repo := NewEventStoreRepo(testPool, testLogger())
 
-	env := testEnvelope(t)
+	env := testutil.Event().Build()
 	require.NoError(t, repo.Insert(context.Background(), env))
 
 	// Query back and verify JSONB + TIMESTAMPTZ fidelity
@@ -100,3 +121,271 @@ func TestEventStoreInsertRoundTrip(t *testing.T) {
 	// JSONB round-trip
 	assert.JSONEq(t, string(env.Payload), string(payload))
 }
+
+func TestEventStoreRoundTrip_KeepsSignature(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "event_store")
+	repo := NewEventStoreRepo(testPool, testLogger())
+
+	key, err := signing.ParseSigningKey("ed25519:" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	require.NoError(t, err)
+	signer, err := signing.NewSigner(key)
+	require.NoError(t, err)
+
+	// Nanosecond times and a payload JSONB rewrites
+	env := testutil.Event().
+		AggregateType("device").
+		At(time.Date(2026, 2, 7, 12, 0, 0, 123456789, time.UTC)).
+		Payload(`{"z": 1.50, "a": {"y": 1e3, "b": "<x>"}}`).
+		Build()
+	require.NoError(t, signer.Sign(env))
+	require.NoError(t, repo.Insert(context.Background(), env))
+
+	stored, err := repo.FetchAfter(context.Background(), 0, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.NoError(t, signing.NewVerifier([]signing.Key{signer.Key()}, true).Verify(stored[0]))
+}
+
+func TestEventStoreFetchLatest(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "event_store", "event_latest")
+	repo := NewEventStoreRepo(testPool, testLogger())
+	ctx := context.Background()
+
+	base := time.Now().UTC().Truncate(time.Microsecond)
+
+	// Two events for device-001 (second is newer), one for device-002,
+	// and one user event that should be excluded by the prefix filter.
+	older := testutil.Event().Build()
+	older.EventTime = base
+	older.Payload = json.RawMessage(`{"temperature": 20}`)
+
+	newer := testutil.Event().Build()
+	newer.EventTime = base.Add(time.Second)
+	newer.Payload = json.RawMessage(`{"temperature": 25}`)
+
+	other := testutil.Event().Build()
+	other.AggregateID = "device-002"
+
+	user := testutil.Event().Build()
+	user.EventType = "user.login"
+	user.AggregateID = "user-001"
+
+	// Insert newest first to prove ordering is by event_time, not arrival
+	for _, env := range []*events.Envelope{newer, older, other, user} {
+		require.NoError(t, repo.Insert(ctx, env))
+	}
+
+	latest, err := repo.FetchLatest(ctx, "sensor.", LatestCursor{}, 10)
+	require.NoError(t, err)
+	require.Len(t, latest, 2)
+
+	assert.Equal(t, "device-001", latest[0].AggregateID)
+	assert.Equal(t, newer.EventID, latest[0].EventID)
+	assert.JSONEq(t, `{"temperature": 25}`, string(latest[0].Payload))
+	assert.Equal(t, "device-002", latest[1].AggregateID)
+
+	// Keyset pagination: resume after the first row
+	page, err := repo.FetchLatest(ctx, "sensor.", LatestCursor{
+		EventType:   latest[0].EventType,
+		AggregateID: latest[0].AggregateID,
+	}, 10)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "device-002", page[0].AggregateID)
+}
+
+func TestEventStoreGetLatest(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "event_store", "event_latest")
+	repo := NewEventStoreRepo(testPool, testLogger())
+	ctx := context.Background()
+
+	older := testutil.Event().Build()
+	newer := testutil.Event().Build()
+	newer.EventType = "sensor.alert"
+	newer.EventTime = older.EventTime.Add(time.Second)
+	require.NoError(t, repo.Insert(ctx, older))
+	require.NoError(t, repo.Insert(ctx, newer))
+
+	// Newest across all sensor.* types for the aggregate
+	got, err := repo.GetLatest(ctx, "sensor.", "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, newer.EventID, got.EventID)
+
+	_, err = repo.GetLatest(ctx, "sensor.", "missing-device")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no rows")
+}
+
+func TestEventStoreInsert_AssignsSequences(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "event_store", "event_aggregate_seq")
+	repo := NewEventStoreRepo(testPool, testLogger())
+	ctx := context.Background()
+
+	a1 := testutil.Event().Build()
+	b1 := testutil.Event().Build()
+	b1.AggregateID = "device-002"
+	a2 := testutil.Event().Build()
+	for _, env := range []*events.Envelope{a1, b1, a2} {
+		require.NoError(t, repo.Insert(ctx, env))
+	}
+
+	// global_seq increases across aggregates
+	assert.Greater(t, a1.GlobalSeq, int64(0))
+	assert.Greater(t, b1.GlobalSeq, a1.GlobalSeq)
+	assert.Greater(t, a2.GlobalSeq, b1.GlobalSeq)
+
+	// aggregate_seq counts per aggregate from 1
+	assert.Equal(t, int64(1), a1.AggregateSeq)
+	assert.Equal(t, int64(1), b1.AggregateSeq)
+	assert.Equal(t, int64(2), a2.AggregateSeq)
+
+	// A duplicate insert still fails but reports the original numbers
+	dup := *a1
+	dup.GlobalSeq, dup.AggregateSeq = 0, 0
+	require.Error(t, repo.Insert(ctx, &dup))
+	assert.Equal(t, a1.GlobalSeq, dup.GlobalSeq)
+	assert.Equal(t, a1.AggregateSeq, dup.AggregateSeq)
+}
+
+func TestEventStoreFetchAfter(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "event_store", "event_aggregate_seq")
+	repo := NewEventStoreRepo(testPool, testLogger())
+	ctx := context.Background()
+
+	var inserted []*events.Envelope
+	for i := 0; i < 5; i++ {
+		env := testutil.Event().Build()
+		require.NoError(t, repo.Insert(ctx, env))
+		inserted = append(inserted, env)
+	}
+
+	// Page through in twos from the start
+	var seen []*events.Envelope
+	var after int64
+	for {
+		page, err := repo.FetchAfter(ctx, after, nil, 2)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		assert.LessOrEqual(t, len(page), 2)
+		seen = append(seen, page...)
+		after = page[len(page)-1].GlobalSeq
+	}
+
+	require.Len(t, seen, len(inserted))
+	for i, env := range seen {
+		assert.Equal(t, inserted[i].EventID, env.EventID)
+		assert.Equal(t, inserted[i].GlobalSeq, env.GlobalSeq)
+		assert.Equal(t, inserted[i].AggregateSeq, env.AggregateSeq)
+	}
+
+	// Reading after the last event returns nothing
+	page, err := repo.FetchAfter(ctx, inserted[4].GlobalSeq, nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func TestEventStoreFetchAfter_Types(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "event_store", "event_aggregate_seq")
+	repo := NewEventStoreRepo(testPool, testLogger())
+	ctx := context.Background()
+
+	for _, eventType := range []string{"sensor.reading", "user.login", "sensor.calibrated", "sensorxreading"} {
+		env := testutil.Event().Build()
+		env.EventType = eventType
+		require.NoError(t, repo.Insert(ctx, env))
+	}
+
+	typesOf := func(found []*events.Envelope) []string {
+		var types []string
+		for _, e := range found {
+			types = append(types, e.EventType)
+		}
+		return types
+	}
+
+	found, err := repo.FetchAfter(ctx, 0, []string{"sensor.*"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sensor.reading", "sensor.calibrated"}, typesOf(found))
+
+	// "_" and "%" are literal, not LIKE wildcards
+	found, err = repo.FetchAfter(ctx, 0, []string{"user.login", "sensor_reading"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user.login"}, typesOf(found))
+
+	found, err = repo.FetchAfter(ctx, 0, []string{}, 10)
+	require.NoError(t, err)
+	assert.Len(t, found, 4)
+}
+
+func TestEventStoreFetchAfterOfAggregateType(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "event_store", "event_aggregate_seq", "event_latest")
+	repo := NewEventStoreRepo(testPool, testLogger())
+	ctx := context.Background()
+
+	// The same ID in two domains
+	device := testutil.Event().Aggregate("42").AggregateType("device").Build()
+	session := testutil.Event().Type("user.login").Aggregate("42").AggregateType("session").Build()
+	untyped := testutil.Event().Aggregate("42").Build()
+	for _, env := range []*events.Envelope{device, session, untyped} {
+		require.NoError(t, repo.Insert(ctx, env))
+	}
+
+	found, err := repo.FetchAfterOfAggregateType(ctx, 0, "device", nil, 10)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, device.EventID, found[0].EventID)
+	assert.Equal(t, "device", found[0].AggregateType)
+
+	found, err = repo.FetchAfterOfAggregateType(ctx, 0, "session", []string{"sensor.*"}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	found, err = repo.FetchAfter(ctx, 0, nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, found, 3)
+
+	latest, err := repo.GetLatest(ctx, "user.", "42")
+	require.NoError(t, err)
+	assert.Equal(t, "session", latest.AggregateType, "event_latest keeps the aggregate type")
+}
+
+func TestEventStoreFetchAggregate(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "event_store", "event_aggregate_seq")
+	repo := NewEventStoreRepo(testPool, testLogger())
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		env := testutil.Event().Build()
+		require.NoError(t, repo.Insert(ctx, env))
+		other := testutil.Event().Build()
+		other.AggregateID = "device-002"
+		require.NoError(t, repo.Insert(ctx, other))
+	}
+
+	seqsOf := func(found []*events.Envelope) []int64 {
+		var seqs []int64
+		for _, e := range found {
+			assert.Equal(t, "device-001", e.AggregateID)
+			seqs = append(seqs, e.AggregateSeq)
+		}
+		return seqs
+	}
+
+	found, err := repo.FetchAggregate(ctx, "device-001", 1, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, seqsOf(found))
+
+	found, err = repo.FetchAggregate(ctx, "device-001", 2, 4, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 3, 4}, seqsOf(found))
+
+	found, err = repo.FetchAggregate(ctx, "device-001", 2, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, seqsOf(found))
+
+	found, err = repo.FetchAggregate(ctx, "device-999", 1, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
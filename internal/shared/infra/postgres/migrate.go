@@ -1,9 +1,11 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io/fs"
+	"path"
 
 	_ "github.com/jackc/pgx/v5/stdlib" // registers "pgx" driver for database/sql
 	"github.com/pressly/goose/v3"
@@ -35,3 +37,55 @@ func RunMigrations(databaseURL string, fsys fs.FS, subdir, tableName string) err
 
 	return nil
 }
+
+// MigrationStatus reports how a database's schema compares to the migrations
+// embedded in this build.
+type MigrationStatus struct {
+	Current int64    // highest version applied to the database
+	Latest  int64    // highest version embedded in this build
+	Pending []string // embedded migrations not yet applied, oldest first
+}
+
+// Ahead reports whether the database has migrations this build does not know
+// about (it was migrated by a newer build).
+func (s *MigrationStatus) Ahead() bool {
+	return s.Current > s.Latest
+}
+
+// CheckMigrations compares the migrations in fsys/subdir against those recorded
+// in tableName without applying anything. The version table is created if the
+// database has never been migrated.
+func CheckMigrations(ctx context.Context, databaseURL string, fsys fs.FS, subdir, tableName string) (*MigrationStatus, error) {
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database for migration check: %w", err)
+	}
+	defer db.Close()
+
+	sub, err := fs.Sub(fsys, subdir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations directory: %w", err)
+	}
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, sub, goose.WithTableName(tableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	results, err := provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration status: %w", err)
+	}
+	current, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database version: %w", err)
+	}
+
+	status := &MigrationStatus{Current: current}
+	for _, r := range results {
+		status.Latest = max(status.Latest, r.Source.Version)
+		if r.State == goose.StatePending {
+			status.Pending = append(status.Pending, path.Base(r.Source.Path))
+		}
+	}
+	return status, nil
+}
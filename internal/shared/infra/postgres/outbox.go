@@ -5,29 +5,46 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/cornjacket/platform-services/internal/services/ingestion/worker"
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgtrace"
 )
 
 // OutboxRepo implements ingestion.OutboxRepository using PostgreSQL.
 type OutboxRepo struct {
-	pool   *pgxpool.Pool
-	logger *slog.Logger
+	db      pgretry.Querier // the pool, or a pgretry.DB wrapping it
+	logger  *slog.Logger
+	archive bool // move processed entries to outbox_archive instead of deleting
 }
 
 // NewOutboxRepo creates a new OutboxRepo.
 func NewOutboxRepo(pool *pgxpool.Pool, logger *slog.Logger) *OutboxRepo {
 	return &OutboxRepo{
-		pool:   pool,
+		db:     pool,
 		logger: logger.With("repository", "outbox"),
 	}
 }
 
+// SetArchive makes Delete move processed entries to outbox_archive, stamped
+// with their publish time, instead of discarding them.
+func (r *OutboxRepo) SetArchive(enabled bool) {
+	r.archive = enabled
+}
+
+// SetQuerier routes the repository's statements through q, typically a
+// pgretry.DB wrapping the pool.
+func (r *OutboxRepo) SetQuerier(q pgretry.Querier) {
+	r.db = q
+}
+
 // Insert adds an event to the outbox table.
 func (r *OutboxRepo) Insert(ctx context.Context, event *events.Envelope) error {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "insert")
 	// Serialize the entire event envelope as the payload
 	payload, err := json.Marshal(event)
 	if err != nil {
@@ -37,9 +54,11 @@ func (r *OutboxRepo) Insert(ctx context.Context, event *events.Envelope) error {
 	query := `
 		INSERT INTO outbox (outbox_id, event_payload, created_at)
 		VALUES ($1, $2, $3)
+		ON CONFLICT (outbox_id) DO NOTHING
 	`
 
-	_, err = r.pool.Exec(ctx, query, event.EventID, payload, event.IngestedAt)
+	// ON CONFLICT makes a retried insert whose first attempt did commit a no-op
+	_, err = r.db.Exec(ctx, query, event.EventID, payload, event.IngestedAt)
 	if err != nil {
 		return fmt.Errorf("failed to insert into outbox: %w", err)
 	}
@@ -52,6 +71,63 @@ func (r *OutboxRepo) Insert(ctx context.Context, event *events.Envelope) error {
 	return nil
 }
 
+// InsertUnique adds an event to the outbox unless an event with the same
+// content hash was accepted within the last window. It returns the ID of the
+// event the hash belongs to: event's own ID when it was inserted, the earlier
+// event's otherwise. Claiming the hash and inserting the entry is one
+// statement, so both commit or neither does.
+func (r *OutboxRepo) InsertUnique(ctx context.Context, event *events.Envelope, hash []byte, window time.Duration) (string, error) {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "insert_unique")
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	// An expired claim is taken over; a live one is returned unchanged. A
+	// retried statement whose first attempt committed finds its own claim.
+	query := `
+		WITH claim AS (
+			INSERT INTO ingest_dedup (content_hash, event_id, expires_at)
+			VALUES ($4, $1, NOW() + $5 * INTERVAL '1 microsecond')
+			ON CONFLICT (content_hash) DO UPDATE SET
+				event_id = CASE WHEN ingest_dedup.expires_at <= NOW()
+					THEN EXCLUDED.event_id ELSE ingest_dedup.event_id END,
+				expires_at = CASE WHEN ingest_dedup.expires_at <= NOW()
+					THEN EXCLUDED.expires_at ELSE ingest_dedup.expires_at END
+			RETURNING event_id
+		), entry AS (
+			INSERT INTO outbox (outbox_id, event_payload, created_at)
+			SELECT $1, $2, $3 FROM claim WHERE claim.event_id = $1
+			ON CONFLICT (outbox_id) DO NOTHING
+		)
+		SELECT event_id::text FROM claim
+	`
+
+	var owner string
+	err = r.db.QueryRow(ctx, query, event.EventID, payload, event.IngestedAt, hash, window.Microseconds()).Scan(&owner)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert into outbox: %w", err)
+	}
+
+	if owner == event.EventID.String() {
+		r.logger.Debug("event inserted into outbox",
+			"event_id", event.EventID,
+			"event_type", event.EventType,
+		)
+	}
+	return owner, nil
+}
+
+// PurgeDedup deletes content hashes that expired before the cutoff.
+func (r *OutboxRepo) PurgeDedup(ctx context.Context, before time.Time) (int64, error) {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "purge_dedup")
+	result, err := r.db.Exec(ctx, `DELETE FROM ingest_dedup WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dedup hashes: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
 // OutboxEntry represents a row in the outbox table (used by the processor).
 type OutboxEntry struct {
 	OutboxID   string
@@ -62,6 +138,7 @@ type OutboxEntry struct {
 // FetchPending retrieves unprocessed outbox entries.
 // Used by the outbox processor.
 func (r *OutboxRepo) FetchPending(ctx context.Context, limit int) ([]OutboxEntry, error) {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "fetch_pending")
 	query := `
 		SELECT outbox_id, event_payload, retry_count
 		FROM outbox
@@ -69,7 +146,7 @@ func (r *OutboxRepo) FetchPending(ctx context.Context, limit int) ([]OutboxEntry
 		LIMIT $1
 	`
 
-	rows, err := r.pool.Query(ctx, query, limit)
+	rows, err := r.db.Query(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query outbox: %w", err)
 	}
@@ -100,11 +177,25 @@ func (r *OutboxRepo) FetchPending(ctx context.Context, limit int) ([]OutboxEntry
 	return entries, nil
 }
 
-// Delete removes a processed entry from the outbox.
+// Delete removes a processed entry from the outbox. With archiving enabled the
+// row is moved to outbox_archive in the same statement.
 func (r *OutboxRepo) Delete(ctx context.Context, outboxID string) error {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "delete")
 	query := `DELETE FROM outbox WHERE outbox_id = $1`
+	if r.archive {
+		// ON CONFLICT: an entry republished after a crash keeps its first publish time
+		query = `
+			WITH moved AS (
+				DELETE FROM outbox WHERE outbox_id = $1
+				RETURNING outbox_id, created_at, event_payload, retry_count
+			)
+			INSERT INTO outbox_archive (outbox_id, created_at, event_payload, retry_count)
+			SELECT outbox_id, created_at, event_payload, retry_count FROM moved
+			ON CONFLICT (outbox_id) DO NOTHING
+		`
+	}
 
-	result, err := r.pool.Exec(ctx, query, outboxID)
+	result, err := r.db.Exec(ctx, query, outboxID)
 	if err != nil {
 		return fmt.Errorf("failed to delete from outbox: %w", err)
 	}
@@ -118,9 +209,10 @@ func (r *OutboxRepo) Delete(ctx context.Context, outboxID string) error {
 
 // IncrementRetry increments the retry count for an outbox entry.
 func (r *OutboxRepo) IncrementRetry(ctx context.Context, outboxID string) error {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "increment_retry")
 	query := `UPDATE outbox SET retry_count = retry_count + 1 WHERE outbox_id = $1`
 
-	_, err := r.pool.Exec(ctx, query, outboxID)
+	_, err := r.db.Exec(ctx, query, outboxID)
 	if err != nil {
 		return fmt.Errorf("failed to increment retry count: %w", err)
 	}
@@ -128,6 +220,154 @@ func (r *OutboxRepo) IncrementRetry(ctx context.Context, outboxID string) error
 	return nil
 }
 
+// PurgeArchive deletes archived entries published before the cutoff.
+func (r *OutboxRepo) PurgeArchive(ctx context.Context, before time.Time) (int64, error) {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "purge_archive")
+	query := `DELETE FROM outbox_archive WHERE published_at < $1`
+
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox archive: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// PublishLatency summarizes insert-to-publish latency for entries archived
+// since the given time.
+func (r *OutboxRepo) PublishLatency(ctx context.Context, since time.Time) (*worker.PublishLatency, error) {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "publish_latency")
+	query := `
+		WITH latency AS (
+			SELECT EXTRACT(EPOCH FROM published_at - created_at)::float8 AS seconds
+			FROM outbox_archive
+			WHERE published_at >= $1
+		)
+		SELECT COUNT(*),
+		       COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY seconds), 0),
+		       COALESCE(percentile_cont(0.99) WITHIN GROUP (ORDER BY seconds), 0),
+		       COALESCE(MAX(seconds), 0)
+		FROM latency
+	`
+
+	var count int64
+	var p50, p99, maxSeconds float64
+	if err := r.db.QueryRow(ctx, query, since).Scan(&count, &p50, &p99, &maxSeconds); err != nil {
+		return nil, fmt.Errorf("failed to query publish latency: %w", err)
+	}
+
+	return &worker.PublishLatency{
+		Count: count,
+		P50:   seconds(p50),
+		P99:   seconds(p99),
+		Max:   seconds(maxSeconds),
+	}, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Stats reports the outbox's size and dead tuple count from the statistics
+// collector. Counts are estimates and lag recent activity slightly.
+func (r *OutboxRepo) Stats(ctx context.Context) (*worker.OutboxStats, error) {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "stats")
+	query := `
+		SELECT n_live_tup, n_dead_tup,
+		       pg_table_size(relid), pg_indexes_size(relid),
+		       last_vacuum, last_autovacuum
+		FROM pg_stat_user_tables
+		WHERE relid = 'outbox'::regclass
+	`
+
+	var stats worker.OutboxStats
+	err := r.db.QueryRow(ctx, query).Scan(
+		&stats.LiveRows, &stats.DeadRows,
+		&stats.TableBytes, &stats.IndexBytes,
+		&stats.LastVacuum, &stats.LastAutovacuum,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// Backlog counts pending entries, up to limit so that a huge backlog stays
+// cheap to measure, and reports the age of the oldest. Entries that used up
+// their maxRetries are left in the outbox as evidence and are not counted.
+func (r *OutboxRepo) Backlog(ctx context.Context, limit int64, maxRetries int) (*worker.OutboxBacklog, error) {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "backlog")
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM (
+				SELECT 1 FROM outbox WHERE retry_count < $2 LIMIT $1
+			) AS capped),
+			COALESCE(EXTRACT(EPOCH FROM NOW() - (
+				SELECT created_at FROM outbox WHERE retry_count < $2
+				ORDER BY created_at ASC LIMIT 1
+			)), 0)::float8
+	`
+
+	var backlog worker.OutboxBacklog
+	var ageSeconds float64
+	if err := r.db.QueryRow(ctx, query, limit, maxRetries).Scan(&backlog.Depth, &ageSeconds); err != nil {
+		return nil, fmt.Errorf("failed to query outbox backlog: %w", err)
+	}
+	backlog.OldestAge = seconds(ageSeconds)
+
+	return &backlog, nil
+}
+
+// PublishStatus reports how far the event with eventID has got through the
+// outbox worker. Outbox entries are keyed by their event's ID.
+func (r *OutboxRepo) PublishStatus(ctx context.Context, eventID string) (*worker.PublishStatus, error) {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "publish_status")
+	query := `
+		SELECT o.retry_count, e.global_seq, e.aggregate_seq
+		FROM (SELECT $1::uuid AS event_id) AS k
+		LEFT JOIN outbox o ON o.outbox_id = k.event_id
+		LEFT JOIN event_store e ON e.event_id = k.event_id
+	`
+
+	var retryCount *int
+	var globalSeq, aggregateSeq *int64
+	if err := r.db.QueryRow(ctx, query, eventID).Scan(&retryCount, &globalSeq, &aggregateSeq); err != nil {
+		return nil, fmt.Errorf("failed to query publish status: %w", err)
+	}
+
+	var status worker.PublishStatus
+	if retryCount != nil {
+		status.RetryCount = *retryCount
+	}
+	if globalSeq != nil {
+		status.GlobalSeq = *globalSeq
+		status.AggregateSeq = *aggregateSeq
+		status.Published = retryCount == nil
+	}
+	return &status, nil
+}
+
+// Vacuum reclaims dead tuples left by processed (deleted) entries and
+// refreshes planner statistics. Plain VACUUM does not block inserts or deletes.
+func (r *OutboxRepo) Vacuum(ctx context.Context) error {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "vacuum")
+	if _, err := r.db.Exec(ctx, `VACUUM (ANALYZE) outbox`); err != nil {
+		return fmt.Errorf("failed to vacuum outbox: %w", err)
+	}
+	return nil
+}
+
+// Reindex rebuilds the outbox indexes without blocking writes. VACUUM does not
+// shrink an index whose pages were emptied by deletes; this does.
+func (r *OutboxRepo) Reindex(ctx context.Context) error {
+	ctx = pgtrace.WithOperation(ctx, "outbox", "reindex")
+	if _, err := r.db.Exec(ctx, `REINDEX TABLE CONCURRENTLY outbox`); err != nil {
+		return fmt.Errorf("failed to reindex outbox: %w", err)
+	}
+	return nil
+}
+
 // OutboxReaderAdapter adapts OutboxRepo to the worker.OutboxReader interface.
 type OutboxReaderAdapter struct {
 	repo *OutboxRepo
@@ -140,6 +380,16 @@ func NewOutboxReaderAdapter(pool *pgxpool.Pool, logger *slog.Logger) *OutboxRead
 	}
 }
 
+// SetArchive enables archiving of processed entries (see OutboxRepo.SetArchive).
+func (a *OutboxReaderAdapter) SetArchive(enabled bool) {
+	a.repo.SetArchive(enabled)
+}
+
+// SetQuerier routes the adapter's statements through q.
+func (a *OutboxReaderAdapter) SetQuerier(q pgretry.Querier) {
+	a.repo.SetQuerier(q)
+}
+
 // FetchPending implements worker.OutboxReader.
 func (a *OutboxReaderAdapter) FetchPending(ctx context.Context, limit int) ([]worker.OutboxEntry, error) {
 	entries, err := a.repo.FetchPending(ctx, limit)
@@ -171,3 +421,9 @@ func (a *OutboxReaderAdapter) IncrementRetry(ctx context.Context, outboxID strin
 
 // Ensure OutboxReaderAdapter implements worker.OutboxReader
 var _ worker.OutboxReader = (*OutboxReaderAdapter)(nil)
+
+// Ensure OutboxRepo implements worker.OutboxMaintainer and worker.OutboxArchive
+var (
+	_ worker.OutboxMaintainer = (*OutboxRepo)(nil)
+	_ worker.OutboxArchive    = (*OutboxRepo)(nil)
+)
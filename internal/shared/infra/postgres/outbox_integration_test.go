@@ -22,36 +22,23 @@ import (
 var testPool *pgxpool.Pool
 
 func TestMain(m *testing.M) {
-	pool := testutil.MustNewTestPool()
-	testutil.MustDropAllTables(pool)
+	pool, drop := testutil.MustNewSchemaPool("infra_postgres")
 	testutil.MustRunMigrations(pool, "../../../services/ingestion/migrations")
 	testPool = pool
-	defer pool.Close()
-	os.Exit(m.Run())
+	code := m.Run()
+	drop()
+	os.Exit(code)
 }
 
 func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
 }
 
-func testEnvelope(t *testing.T) *events.Envelope {
-	t.Helper()
-	return &events.Envelope{
-		EventID:     uuid.Must(uuid.NewV7()),
-		EventType:   "sensor.reading",
-		AggregateID: "device-001",
-		EventTime:   time.Now().UTC().Truncate(time.Microsecond),
-		IngestedAt:  time.Now().UTC().Truncate(time.Microsecond),
-		Payload:     json.RawMessage(`{"temperature": 22.5}`),
-		Metadata:    events.Metadata{Source: "test", SchemaVersion: 1},
-	}
-}
-
 func TestOutboxInsert(t *testing.T) {
 	testutil.TruncateTables(t, testPool, "outbox")
 	repo := NewOutboxRepo(testPool, testLogger())
 
-	env := testEnvelope(t)
+	env := testutil.Event().Build()
 	err := repo.Insert(context.Background(), env)
 	require.NoError(t, err)
 
@@ -79,7 +66,7 @@ func TestOutboxFetchPending(t *testing.T) {
 
 	// Insert 3 events with staggered created_at
 	for i := 0; i < 3; i++ {
-		env := testEnvelope(t)
+		env := testutil.Event().Build()
 		require.NoError(t, repo.Insert(context.Background(), env))
 		time.Sleep(2 * time.Millisecond) // ensure distinct created_at
 	}
@@ -108,7 +95,7 @@ func TestOutboxDelete(t *testing.T) {
 	testutil.TruncateTables(t, testPool, "outbox")
 	repo := NewOutboxRepo(testPool, testLogger())
 
-	env := testEnvelope(t)
+	env := testutil.Event().Build()
 	require.NoError(t, repo.Insert(context.Background(), env))
 
 	// Delete the entry
@@ -134,7 +121,7 @@ func TestOutboxIncrementRetry(t *testing.T) {
 	testutil.TruncateTables(t, testPool, "outbox")
 	repo := NewOutboxRepo(testPool, testLogger())
 
-	env := testEnvelope(t)
+	env := testutil.Event().Build()
 	require.NoError(t, repo.Insert(context.Background(), env))
 
 	// Increment twice: 0 → 1 → 2
@@ -151,11 +138,135 @@ func TestOutboxIncrementRetry(t *testing.T) {
 	assert.Equal(t, 2, retryCount)
 }
 
+func TestOutboxBacklog(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "outbox")
+	repo := NewOutboxRepo(testPool, testLogger())
+	ctx := context.Background()
+
+	// Empty outbox
+	backlog, err := repo.Backlog(ctx, 100, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), backlog.Depth)
+	assert.Equal(t, time.Duration(0), backlog.OldestAge)
+
+	envs := make([]*events.Envelope, 3)
+	for i := range envs {
+		envs[i] = testutil.Event().Build()
+		require.NoError(t, repo.Insert(ctx, envs[i]))
+	}
+	_, err = testPool.Exec(ctx, "UPDATE outbox SET created_at = NOW() - INTERVAL '10 minutes' WHERE outbox_id = $1", envs[0].EventID)
+	require.NoError(t, err)
+
+	backlog, err = repo.Backlog(ctx, 100, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), backlog.Depth)
+	assert.GreaterOrEqual(t, backlog.OldestAge, 10*time.Minute)
+
+	// The count stops at the limit
+	backlog, err = repo.Backlog(ctx, 2, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), backlog.Depth)
+
+	// Entries out of retries are not backlog
+	_, err = testPool.Exec(ctx, "UPDATE outbox SET retry_count = 5 WHERE outbox_id = $1", envs[0].EventID)
+	require.NoError(t, err)
+	backlog, err = repo.Backlog(ctx, 100, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), backlog.Depth)
+	assert.Less(t, backlog.OldestAge, 10*time.Minute)
+}
+
+func TestOutboxPublishStatus(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "outbox", "outbox_archive", "event_store", "event_aggregate_seq")
+	repo := NewOutboxRepo(testPool, testLogger())
+	repo.SetArchive(true)
+	eventStore := NewEventStoreRepo(testPool, testLogger())
+	ctx := context.Background()
+
+	env := testutil.Event().Build()
+	id := env.EventID.String()
+	require.NoError(t, repo.Insert(ctx, env))
+
+	// Pending, then failed once
+	status, err := repo.PublishStatus(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, status.Published)
+	require.NoError(t, repo.IncrementRetry(ctx, id))
+	status, err = repo.PublishStatus(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.RetryCount)
+
+	// Stored but not yet submitted
+	require.NoError(t, eventStore.Insert(ctx, env))
+	status, err = repo.PublishStatus(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, status.Published)
+	assert.Positive(t, status.GlobalSeq)
+
+	// Submitted: archived out of the outbox
+	require.NoError(t, repo.Delete(ctx, id))
+	status, err = repo.PublishStatus(ctx, id)
+	require.NoError(t, err)
+	assert.True(t, status.Published)
+	assert.Equal(t, int64(1), status.AggregateSeq)
+
+	// Unknown events are not published
+	status, err = repo.PublishStatus(ctx, testutil.Event().Build().EventID.String())
+	require.NoError(t, err)
+	assert.False(t, status.Published)
+}
+
+func TestOutboxInsertUnique(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "outbox", "ingest_dedup")
+	repo := NewOutboxRepo(testPool, testLogger())
+	ctx := context.Background()
+	hash := []byte("content-hash-1")
+
+	first := testutil.Event().Build()
+	owner, err := repo.InsertUnique(ctx, first, hash, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, first.EventID.String(), owner)
+
+	// A retried statement finds its own claim
+	owner, err = repo.InsertUnique(ctx, first, hash, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, first.EventID.String(), owner)
+
+	// Same content within the window: not inserted
+	dup := testutil.Event().Build()
+	owner, err = repo.InsertUnique(ctx, dup, hash, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, first.EventID.String(), owner)
+
+	var count int
+	require.NoError(t, testPool.QueryRow(ctx, "SELECT COUNT(*) FROM outbox").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	// Once the claim expires, the content is accepted again
+	_, err = testPool.Exec(ctx, "UPDATE ingest_dedup SET expires_at = NOW() - INTERVAL '1 second'")
+	require.NoError(t, err)
+	owner, err = repo.InsertUnique(ctx, dup, hash, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, dup.EventID.String(), owner)
+	require.NoError(t, testPool.QueryRow(ctx, "SELECT COUNT(*) FROM outbox").Scan(&count))
+	assert.Equal(t, 2, count)
+
+	// Purge removes only expired claims
+	other := testutil.Event().Build()
+	_, err = repo.InsertUnique(ctx, other, []byte("content-hash-2"), time.Minute)
+	require.NoError(t, err)
+	_, err = testPool.Exec(ctx, "UPDATE ingest_dedup SET expires_at = NOW() - INTERVAL '1 second' WHERE event_id = $1", other.EventID)
+	require.NoError(t, err)
+	purged, err := repo.PurgeDedup(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
+
 func TestOutboxInsertFetchRoundTrip(t *testing.T) {
 	testutil.TruncateTables(t, testPool, "outbox")
 	repo := NewOutboxRepo(testPool, testLogger())
 
-	env := testEnvelope(t)
+	env := testutil.Event().Build()
 	require.NoError(t, repo.Insert(context.Background(), env))
 
 	entries, err := repo.FetchPending(context.Background(), 1)
@@ -186,7 +297,7 @@ func TestOutboxNotifyTrigger(t *testing.T) {
 	require.NoError(t, err)
 
 	// Insert an event (triggers NOTIFY)
-	env := testEnvelope(t)
+	env := testutil.Event().Build()
 	require.NoError(t, repo.Insert(context.Background(), env))
 
 	// Wait for notification with timeout
@@ -198,3 +309,66 @@ func TestOutboxNotifyTrigger(t *testing.T) {
 	assert.Equal(t, "outbox_insert", notification.Channel)
 	assert.Equal(t, env.EventID.String(), notification.Payload)
 }
+
+func TestOutboxMaintenance(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "outbox")
+	repo := NewOutboxRepo(testPool, testLogger())
+	ctx := context.Background()
+
+	// Churn some rows so there is something to reclaim
+	for i := 0; i < 10; i++ {
+		env := testutil.Event().Build()
+		require.NoError(t, repo.Insert(ctx, env))
+		require.NoError(t, repo.Delete(ctx, env.EventID.String()))
+	}
+
+	require.NoError(t, repo.Vacuum(ctx))
+	require.NoError(t, repo.Reindex(ctx))
+
+	stats, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, stats.IndexBytes)
+	assert.NotNil(t, stats.LastVacuum, "manual VACUUM should be recorded")
+}
+
+func TestOutboxArchive(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "outbox", "outbox_archive")
+	repo := NewOutboxRepo(testPool, testLogger())
+	repo.SetArchive(true)
+	ctx := context.Background()
+
+	env := testutil.Event().Build()
+	require.NoError(t, repo.Insert(ctx, env))
+	require.NoError(t, repo.IncrementRetry(ctx, env.EventID.String()))
+	require.NoError(t, repo.Delete(ctx, env.EventID.String()))
+
+	// Moved out of the outbox, with its publish time and retry count
+	var outboxCount int
+	require.NoError(t, testPool.QueryRow(ctx, `SELECT COUNT(*) FROM outbox`).Scan(&outboxCount))
+	assert.Equal(t, 0, outboxCount)
+
+	var createdAt, publishedAt time.Time
+	var retryCount int
+	err := testPool.QueryRow(ctx,
+		`SELECT created_at, published_at, retry_count FROM outbox_archive WHERE outbox_id = $1`,
+		env.EventID,
+	).Scan(&createdAt, &publishedAt, &retryCount)
+	require.NoError(t, err)
+	assert.True(t, createdAt.Equal(env.IngestedAt))
+	assert.False(t, publishedAt.Before(createdAt))
+	assert.Equal(t, 1, retryCount)
+
+	latency, err := repo.PublishLatency(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), latency.Count)
+	assert.Equal(t, latency.Max, latency.P50)
+
+	// Retention purge
+	purged, err := repo.PurgeArchive(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), purged, "recent entries are kept")
+
+	purged, err = repo.PurgeArchive(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
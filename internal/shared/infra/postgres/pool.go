@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+)
+
+// PoolStats is a snapshot of a connection pool. Waits are acquires that
+// found no idle connection; when they grow while AcquiredConns sits at
+// MaxConns, the pool is exhausted and requests queue for connections.
+type PoolStats struct {
+	MaxConns      int32 `json:"max_conns"`
+	TotalConns    int32 `json:"total_conns"`
+	AcquiredConns int32 `json:"acquired_conns"`
+	IdleConns     int32 `json:"idle_conns"`
+
+	AcquireCount         int64   `json:"acquire_count"`
+	CanceledAcquireCount int64   `json:"canceled_acquire_count"`
+	WaitCount            int64   `json:"wait_count"`
+	WaitDurationMS       float64 `json:"wait_duration_ms"` // total since start
+	Saturated            bool    `json:"saturated"`        // every connection is in use
+}
+
+// StatsOf returns the current statistics of pool.
+func StatsOf(pool *pgxpool.Pool) PoolStats {
+	s := pool.Stat()
+	return PoolStats{
+		MaxConns:             s.MaxConns(),
+		TotalConns:           s.TotalConns(),
+		AcquiredConns:        s.AcquiredConns(),
+		IdleConns:            s.IdleConns(),
+		AcquireCount:         s.AcquireCount(),
+		CanceledAcquireCount: s.CanceledAcquireCount(),
+		WaitCount:            s.EmptyAcquireCount(),
+		WaitDurationMS:       float64(s.EmptyAcquireWaitTime()) / float64(time.Millisecond),
+		Saturated:            s.AcquiredConns() >= s.MaxConns(),
+	}
+}
+
+// RegisterPoolMetrics registers gauges and counters for pools, keyed by
+// database name, with reg. They are read from the pools on each scrape.
+func RegisterPoolMetrics(reg *metrics.Registry, pools map[string]*pgxpool.Pool) {
+	labels := []string{"database"}
+	gauge := func(name, help string, value func(*pgxpool.Stat) float64) {
+		reg.NewGaugeFunc(name, help, labels, func(observe func(float64, ...string)) {
+			for database, pool := range pools {
+				observe(value(pool.Stat()), database)
+			}
+		})
+	}
+	counter := func(name, help string, value func(*pgxpool.Stat) float64) {
+		reg.NewCounterFunc(name, help, labels, func(observe func(float64, ...string)) {
+			for database, pool := range pools {
+				observe(value(pool.Stat()), database)
+			}
+		})
+	}
+
+	gauge("db_pool_max_conns", "Maximum size of the connection pool.",
+		func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) })
+	gauge("db_pool_total_conns", "Open connections, in use or idle.",
+		func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) })
+	gauge("db_pool_acquired_conns", "Connections in use.",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) })
+	gauge("db_pool_idle_conns", "Idle connections.",
+		func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) })
+	counter("db_pool_acquires_total", "Connections acquired from the pool.",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) })
+	counter("db_pool_acquire_waits_total", "Acquires that waited because no connection was idle.",
+		func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) })
+	counter("db_pool_acquire_wait_seconds_total", "Time spent waiting for a connection.",
+		func(s *pgxpool.Stat) float64 { return s.EmptyAcquireWaitTime().Seconds() })
+	counter("db_pool_canceled_acquires_total", "Acquires cancelled by their context, typically a timeout while waiting.",
+		func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) })
+}
+
+// ReadyTimeout bounds the readiness check. An exhausted pool fails it, since
+// the check has to acquire a connection like any request.
+const ReadyTimeout = 2 * time.Second
+
+// ReadyHandler serves GET /readyz for a service backed by pool: 200 when a
+// connection can be acquired and pinged within ReadyTimeout, 503 otherwise.
+// Both report the pool statistics; the cause of a failure is only logged,
+// since it can name hosts and users.
+func ReadyHandler(pool *pgxpool.Pool, logger *slog.Logger) http.Handler {
+	return readyHandler(pool.Ping, func() PoolStats { return StatsOf(pool) }, ReadyTimeout, logger)
+}
+
+// ReadyResponse is the body of GET /readyz.
+type ReadyResponse struct {
+	Status string    `json:"status"` // "ready" or "not_ready"
+	Error  string    `json:"error,omitempty"`
+	Pool   PoolStats `json:"pool"`
+}
+
+func readyHandler(ping func(context.Context) error, stats func() PoolStats, timeout time.Duration, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		resp := ReadyResponse{Status: "ready"}
+		status := http.StatusOK
+		resp.Pool = stats()
+		if err := ping(ctx); err != nil {
+			resp.Status = "not_ready"
+			resp.Error = "database unavailable"
+			if ctx.Err() != nil && r.Context().Err() == nil {
+				resp.Error = "timed out waiting for a database connection"
+			}
+			status = http.StatusServiceUnavailable
+			logger.Warn("readiness check failed", "error", err, "acquired_conns", resp.Pool.AcquiredConns, "max_conns", resp.Pool.MaxConns)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
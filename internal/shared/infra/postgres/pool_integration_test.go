@@ -0,0 +1,45 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/metrics"
+)
+
+func TestPoolStatsAndMetrics(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewClient(ctx, integrationDBURL(), testLogger())
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := client.Pool().Acquire(ctx)
+	require.NoError(t, err)
+	defer conn.Release()
+
+	stats := StatsOf(client.Pool())
+	assert.Equal(t, int32(10), stats.MaxConns)
+	assert.GreaterOrEqual(t, stats.AcquiredConns, int32(1))
+	assert.False(t, stats.Saturated)
+
+	reg := metrics.NewRegistry()
+	RegisterPoolMetrics(reg, map[string]*pgxpool.Pool{"ingestion": client.Pool()})
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+	assert.Contains(t, out.String(), `db_pool_max_conns{database="ingestion"} 10`)
+	assert.Contains(t, out.String(), `db_pool_acquired_conns{database="ingestion"} 1`)
+
+	w := httptest.NewRecorder()
+	ReadyHandler(client.Pool(), testLogger()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"ready"`)
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyHandler(t *testing.T) {
+	stats := PoolStats{MaxConns: 10, TotalConns: 10, AcquiredConns: 10, WaitCount: 7, Saturated: true}
+	tests := []struct {
+		name       string
+		ping       func(ctx context.Context) error
+		wantStatus int
+		wantBody   ReadyResponse
+	}{
+		{
+			name:       "ready",
+			ping:       func(ctx context.Context) error { return nil },
+			wantStatus: http.StatusOK,
+			wantBody:   ReadyResponse{Status: "ready", Pool: stats},
+		},
+		{
+			name:       "database down",
+			ping:       func(ctx context.Context) error { return errors.New("dial tcp db.internal:5432: connection refused") },
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   ReadyResponse{Status: "not_ready", Error: "database unavailable", Pool: stats},
+		},
+		{
+			name: "pool exhausted",
+			ping: func(ctx context.Context) error {
+				<-ctx.Done() // no connection frees up before the deadline
+				return ctx.Err()
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   ReadyResponse{Status: "not_ready", Error: "timed out waiting for a database connection", Pool: stats},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := readyHandler(tt.ping, func() PoolStats { return stats }, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			var got ReadyResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, tt.wantBody, got)
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package redpanda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// ACLConfig declares who may access the platform topics. Loaded from YAML
+// (see config/redpanda-acls.yaml) and expanded into bindings by Bindings.
+type ACLConfig struct {
+	// Producer is the principal the platform publishes as. Write-only.
+	Producer ACLProducer `yaml:"producer"`
+	// Teams are downstream consumers. Read-only on their topics.
+	Teams []ACLTeam `yaml:"teams"`
+}
+
+// ACLProducer grants write access to every platform topic.
+type ACLProducer struct {
+	Principal string `yaml:"principal"`
+	// TransactionalID is granted when the outbox publishes transactionally.
+	TransactionalID string `yaml:"transactional_id"`
+}
+
+// ACLTeam grants one consumer team read access to a set of topics.
+type ACLTeam struct {
+	Name      string `yaml:"name"`
+	Principal string `yaml:"principal"`
+	// GroupPrefix scopes the team's consumer groups (prefixed match).
+	GroupPrefix string `yaml:"group_prefix"`
+	// Topics the team may read. Each must be a platform topic.
+	Topics []string `yaml:"topics"`
+}
+
+// ACLBinding is one allow rule. Only allow rules are managed.
+type ACLBinding struct {
+	Principal    string
+	ResourceType kmsg.ACLResourceType
+	ResourceName string
+	PatternType  kmsg.ACLResourcePatternType
+	Operation    kmsg.ACLOperation
+}
+
+// String renders the binding for logs and CLI output.
+func (b ACLBinding) String() string {
+	return fmt.Sprintf("%s %s %s:%s(%s)",
+		b.Principal,
+		strings.ToLower(b.Operation.String()),
+		strings.ToLower(b.ResourceType.String()),
+		b.ResourceName,
+		strings.ToLower(b.PatternType.String()),
+	)
+}
+
+// ACLResult reports what ApplyACLs did.
+type ACLResult struct {
+	Created  []ACLBinding
+	Deleted  []ACLBinding
+	Existing int
+}
+
+// Bindings expands the config into ACL bindings for the given platform topics.
+func (c ACLConfig) Bindings(topics []string) ([]ACLBinding, error) {
+	if c.Producer.Principal == "" {
+		return nil, errors.New("producer principal is required")
+	}
+
+	var bindings []ACLBinding
+	for _, topic := range topics {
+		for _, op := range []kmsg.ACLOperation{kmsg.ACLOperationWrite, kmsg.ACLOperationDescribe} {
+			bindings = append(bindings, literal(c.Producer.Principal, kmsg.ACLResourceTypeTopic, topic, op))
+		}
+	}
+	if id := c.Producer.TransactionalID; id != "" {
+		for _, op := range []kmsg.ACLOperation{kmsg.ACLOperationWrite, kmsg.ACLOperationDescribe} {
+			bindings = append(bindings, literal(c.Producer.Principal, kmsg.ACLResourceTypeTransactionalId, id, op))
+		}
+	}
+
+	for _, team := range c.Teams {
+		if team.Name == "" || team.Principal == "" || team.GroupPrefix == "" {
+			return nil, fmt.Errorf("team %q: name, principal, and group_prefix are required", team.Name)
+		}
+		if team.Principal == c.Producer.Principal {
+			return nil, fmt.Errorf("team %q: principal must differ from the producer principal", team.Name)
+		}
+		for _, topic := range team.Topics {
+			if !slices.Contains(topics, topic) {
+				return nil, fmt.Errorf("team %q: %q is not a platform topic", team.Name, topic)
+			}
+			for _, op := range []kmsg.ACLOperation{kmsg.ACLOperationRead, kmsg.ACLOperationDescribe} {
+				bindings = append(bindings, literal(team.Principal, kmsg.ACLResourceTypeTopic, topic, op))
+			}
+		}
+		bindings = append(bindings, ACLBinding{
+			Principal:    team.Principal,
+			ResourceType: kmsg.ACLResourceTypeGroup,
+			ResourceName: team.GroupPrefix,
+			PatternType:  kmsg.ACLResourcePatternTypePrefixed,
+			Operation:    kmsg.ACLOperationRead,
+		})
+	}
+
+	return bindings, nil
+}
+
+func literal(principal string, resourceType kmsg.ACLResourceType, name string, op kmsg.ACLOperation) ACLBinding {
+	return ACLBinding{
+		Principal:    principal,
+		ResourceType: resourceType,
+		ResourceName: name,
+		PatternType:  kmsg.ACLResourcePatternTypeLiteral,
+		Operation:    op,
+	}
+}
+
+// ApplyACLs creates the desired bindings that do not exist. With prune, allow
+// rules held by the same principals but absent from desired are deleted, so
+// the cluster converges on the declared config. Principals not mentioned in
+// desired are never touched.
+func (a *Admin) ApplyACLs(ctx context.Context, desired []ACLBinding, prune bool) (*ACLResult, error) {
+	want := make(map[ACLBinding]bool, len(desired))
+	var principals []string
+	for _, b := range desired {
+		want[b] = true
+		if !slices.Contains(principals, b.Principal) {
+			principals = append(principals, b.Principal)
+		}
+	}
+
+	have := make(map[ACLBinding]bool)
+	for _, principal := range principals {
+		existing, err := a.describeACLs(ctx, principal)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range existing {
+			have[b] = true
+		}
+	}
+
+	result := &ACLResult{}
+	var missing, stale []ACLBinding
+	for _, b := range desired {
+		if have[b] {
+			result.Existing++
+		} else {
+			missing = append(missing, b)
+		}
+	}
+	if prune {
+		for b := range have {
+			if !want[b] {
+				stale = append(stale, b)
+			}
+		}
+		sortBindings(stale)
+	}
+
+	if err := a.createACLs(ctx, missing); err != nil {
+		return result, err
+	}
+	result.Created = missing
+	for _, b := range missing {
+		a.logger.Info("created ACL", "acl", b.String())
+	}
+
+	if err := a.deleteACLs(ctx, stale); err != nil {
+		return result, err
+	}
+	result.Deleted = stale
+	for _, b := range stale {
+		a.logger.Warn("deleted ACL not in config", "acl", b.String())
+	}
+
+	return result, nil
+}
+
+// describeACLs returns the allow rules held by principal on any host.
+func (a *Admin) describeACLs(ctx context.Context, principal string) ([]ACLBinding, error) {
+	req := kmsg.NewPtrDescribeACLsRequest()
+	req.ResourceType = kmsg.ACLResourceTypeAny
+	req.ResourcePatternType = kmsg.ACLResourcePatternTypeAny
+	req.Principal = kmsg.StringPtr(principal)
+	req.Operation = kmsg.ACLOperationAny
+	req.PermissionType = kmsg.ACLPermissionTypeAllow
+
+	resp, err := req.RequestWith(ctx, a.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe ACLs: %w", err)
+	}
+	if err := kerr.ErrorForCode(resp.ErrorCode); err != nil {
+		return nil, fmt.Errorf("failed to describe ACLs for %s: %w", principal, err)
+	}
+
+	var bindings []ACLBinding
+	for _, r := range resp.Resources {
+		for _, acl := range r.ACLs {
+			if acl.Host != "*" {
+				continue // host-restricted rules are not managed here
+			}
+			bindings = append(bindings, ACLBinding{
+				Principal:    acl.Principal,
+				ResourceType: r.ResourceType,
+				ResourceName: r.ResourceName,
+				PatternType:  r.ResourcePatternType,
+				Operation:    acl.Operation,
+			})
+		}
+	}
+	return bindings, nil
+}
+
+func (a *Admin) createACLs(ctx context.Context, bindings []ACLBinding) error {
+	if len(bindings) == 0 {
+		return nil
+	}
+
+	req := kmsg.NewPtrCreateACLsRequest()
+	for _, b := range bindings {
+		c := kmsg.NewCreateACLsRequestCreation()
+		c.Principal = b.Principal
+		c.Host = "*"
+		c.ResourceType = b.ResourceType
+		c.ResourceName = b.ResourceName
+		c.ResourcePatternType = b.PatternType
+		c.Operation = b.Operation
+		c.PermissionType = kmsg.ACLPermissionTypeAllow
+		req.Creations = append(req.Creations, c)
+	}
+
+	resp, err := req.RequestWith(ctx, a.client)
+	if err != nil {
+		return fmt.Errorf("failed to create ACLs: %w", err)
+	}
+	var errs []error
+	for i, r := range resp.Results {
+		if err := kerr.ErrorForCode(r.ErrorCode); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", bindings[i], err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to create ACLs: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (a *Admin) deleteACLs(ctx context.Context, bindings []ACLBinding) error {
+	if len(bindings) == 0 {
+		return nil
+	}
+
+	req := kmsg.NewPtrDeleteACLsRequest()
+	for _, b := range bindings {
+		f := kmsg.NewDeleteACLsRequestFilter()
+		f.Principal = kmsg.StringPtr(b.Principal)
+		f.Host = kmsg.StringPtr("*")
+		f.ResourceType = b.ResourceType
+		f.ResourceName = kmsg.StringPtr(b.ResourceName)
+		f.ResourcePatternType = b.PatternType
+		f.Operation = b.Operation
+		f.PermissionType = kmsg.ACLPermissionTypeAllow
+		req.Filters = append(req.Filters, f)
+	}
+
+	resp, err := req.RequestWith(ctx, a.client)
+	if err != nil {
+		return fmt.Errorf("failed to delete ACLs: %w", err)
+	}
+	var errs []error
+	for i, r := range resp.Results {
+		if err := kerr.ErrorForCode(r.ErrorCode); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", bindings[i], err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to delete ACLs: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func sortBindings(bindings []ACLBinding) {
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].String() < bindings[j].String()
+	})
+}
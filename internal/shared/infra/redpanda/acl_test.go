@@ -0,0 +1,110 @@
+package redpanda
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"gopkg.in/yaml.v3"
+)
+
+var platformTopics = []string{"sensor-events", "system-events", "user-actions"}
+
+func TestACLConfigBindings(t *testing.T) {
+	cfg := ACLConfig{
+		Producer: ACLProducer{Principal: "User:platform", TransactionalID: "platform-outbox"},
+		Teams: []ACLTeam{
+			{Name: "analytics", Principal: "User:analytics", GroupPrefix: "analytics-", Topics: []string{"sensor-events"}},
+		},
+	}
+
+	bindings, err := cfg.Bindings(platformTopics)
+	require.NoError(t, err)
+
+	var producer, team []string
+	for _, b := range bindings {
+		switch b.Principal {
+		case "User:platform":
+			producer = append(producer, b.String())
+		case "User:analytics":
+			team = append(team, b.String())
+		}
+	}
+
+	// Producer: write + describe on every topic and its transactional ID, never read
+	assert.Len(t, producer, 2*len(platformTopics)+2)
+	assert.Contains(t, producer, "User:platform write topic:user-actions(literal)")
+	assert.Contains(t, producer, "User:platform write transactional_id:platform-outbox(literal)")
+	for _, b := range producer {
+		assert.NotContains(t, b, " read ")
+	}
+
+	// Team: read + describe on its topics, read on its group prefix, never write
+	assert.ElementsMatch(t, []string{
+		"User:analytics read topic:sensor-events(literal)",
+		"User:analytics describe topic:sensor-events(literal)",
+		"User:analytics read group:analytics-(prefixed)",
+	}, team)
+}
+
+func TestACLConfigBindings_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ACLConfig
+		wantErr string
+	}{
+		{name: "no producer", cfg: ACLConfig{}, wantErr: "producer principal is required"},
+		{
+			name: "unknown topic",
+			cfg: ACLConfig{
+				Producer: ACLProducer{Principal: "User:platform"},
+				Teams:    []ACLTeam{{Name: "a", Principal: "User:a", GroupPrefix: "a-", Topics: []string{"billing"}}},
+			},
+			wantErr: "not a platform topic",
+		},
+		{
+			name: "missing group prefix",
+			cfg: ACLConfig{
+				Producer: ACLProducer{Principal: "User:platform"},
+				Teams:    []ACLTeam{{Name: "a", Principal: "User:a"}},
+			},
+			wantErr: "group_prefix are required",
+		},
+		{
+			name: "team reuses producer principal",
+			cfg: ACLConfig{
+				Producer: ACLProducer{Principal: "User:platform"},
+				Teams:    []ACLTeam{{Name: "a", Principal: "User:platform", GroupPrefix: "a-"}},
+			},
+			wantErr: "must differ from the producer principal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cfg.Bindings(platformTopics)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+// The checked-in example must stay valid against the default topics.
+func TestACLConfig_Example(t *testing.T) {
+	data, err := os.ReadFile("../../../../config/redpanda-acls.yaml")
+	require.NoError(t, err)
+
+	var cfg ACLConfig
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+
+	bindings, err := cfg.Bindings(platformTopics)
+	require.NoError(t, err)
+	assert.Contains(t, bindings, ACLBinding{
+		Principal:    "User:platform-eventhandler",
+		ResourceType: kmsg.ACLResourceTypeGroup,
+		ResourceName: "event-handler",
+		PatternType:  kmsg.ACLResourcePatternTypePrefixed,
+		Operation:    kmsg.ACLOperationRead,
+	})
+}
@@ -0,0 +1,258 @@
+package redpanda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// TopicSpec describes how a platform topic should be created.
+type TopicSpec struct {
+	Name              string
+	Partitions        int32
+	ReplicationFactor int16
+	// Retention sets retention.ms. Zero uses the broker default; negative
+	// retains forever.
+	Retention time.Duration
+	// Compacted sets cleanup.policy=compact, keeping the latest record of
+	// each key instead of deleting records by age.
+	Compacted bool
+}
+
+// EnsureResult reports what EnsureTopics did.
+type EnsureResult struct {
+	Created  []string
+	Existing []string
+	// UnderPartitioned lists existing topics with fewer partitions than their
+	// spec. They are not altered: adding partitions remaps keys to partitions
+	// and breaks per-aggregate ordering for in-flight data.
+	UnderPartitioned []string
+}
+
+// Admin manages topic lifecycle on the cluster.
+type Admin struct {
+	client *kgo.Client
+	logger *slog.Logger
+}
+
+// NewAdmin creates a new Redpanda admin client.
+func NewAdmin(brokers []string, auth Auth, logger *slog.Logger) (*Admin, error) {
+	opts, err := auth.Opts()
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redpanda auth: %w", err)
+	}
+	client, err := kgo.NewClient(append(opts, kgo.SeedBrokers(brokers...))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Redpanda admin client: %w", err)
+	}
+
+	return &Admin{
+		client: client,
+		logger: logger.With("component", "redpanda-admin"),
+	}, nil
+}
+
+// EnsureTopics creates the topics that do not exist yet, using their specs.
+// Existing topics are left untouched.
+func (a *Admin) EnsureTopics(ctx context.Context, specs []TopicSpec) (*EnsureResult, error) {
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+
+	partitions, err := a.PartitionCounts(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EnsureResult{}
+	req := kmsg.NewPtrCreateTopicsRequest()
+	req.TimeoutMillis = 30000
+	for _, spec := range specs {
+		if count, ok := partitions[spec.Name]; ok {
+			result.Existing = append(result.Existing, spec.Name)
+			if spec.Partitions > 0 && count < spec.Partitions {
+				result.UnderPartitioned = append(result.UnderPartitioned, spec.Name)
+				a.logger.Warn("topic has fewer partitions than configured",
+					"topic", spec.Name,
+					"partitions", count,
+					"configured", spec.Partitions,
+				)
+			}
+			continue
+		}
+		req.Topics = append(req.Topics, spec.request())
+	}
+
+	if len(req.Topics) == 0 {
+		return result, nil
+	}
+
+	resp, err := req.RequestWith(ctx, a.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create topics: %w", err)
+	}
+
+	var errs []error
+	for _, t := range resp.Topics {
+		switch err := kerr.ErrorForCode(t.ErrorCode); {
+		case err == nil:
+			result.Created = append(result.Created, t.Topic)
+			a.logger.Info("created topic", "topic", t.Topic)
+		case errors.Is(err, kerr.TopicAlreadyExists):
+			// Created concurrently (another instance, or auto-creation)
+			result.Existing = append(result.Existing, t.Topic)
+		default:
+			errs = append(errs, fmt.Errorf("topic %s: %w", t.Topic, err))
+		}
+	}
+	sort.Strings(result.Created)
+	sort.Strings(result.Existing)
+
+	if len(errs) > 0 {
+		return result, fmt.Errorf("failed to create topics: %w", errors.Join(errs...))
+	}
+	return result, nil
+}
+
+// PartitionCounts returns the partition count of each given topic that exists.
+func (a *Admin) PartitionCounts(ctx context.Context, topics []string) (map[string]int32, error) {
+	req := kmsg.NewPtrMetadataRequest()
+	for _, topic := range topics {
+		t := kmsg.NewMetadataRequestTopic()
+		t.Topic = kmsg.StringPtr(topic)
+		req.Topics = append(req.Topics, t)
+	}
+	req.AllowAutoTopicCreation = false
+
+	resp, err := req.RequestWith(ctx, a.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch topic metadata: %w", err)
+	}
+
+	counts := make(map[string]int32)
+	for _, t := range resp.Topics {
+		if t.ErrorCode == 0 && t.Topic != nil {
+			counts[*t.Topic] = int32(len(t.Partitions))
+		}
+	}
+	return counts, nil
+}
+
+// Close releases the admin client.
+func (a *Admin) Close() {
+	a.client.Close()
+}
+
+// request builds the CreateTopics entry for the spec. Non-positive partition
+// and replication values use the broker defaults.
+func (s TopicSpec) request() kmsg.CreateTopicsRequestTopic {
+	t := kmsg.NewCreateTopicsRequestTopic()
+	t.Topic = s.Name
+	t.NumPartitions = -1
+	t.ReplicationFactor = -1
+	if s.Partitions > 0 {
+		t.NumPartitions = s.Partitions
+	}
+	if s.ReplicationFactor > 0 {
+		t.ReplicationFactor = s.ReplicationFactor
+	}
+	if s.Retention != 0 {
+		retention := int64(-1)
+		if s.Retention > 0 {
+			retention = s.Retention.Milliseconds()
+		}
+		c := kmsg.NewCreateTopicsRequestTopicConfig()
+		c.Name = "retention.ms"
+		c.Value = kmsg.StringPtr(strconv.FormatInt(retention, 10))
+		t.Configs = append(t.Configs, c)
+	}
+	if s.Compacted {
+		c := kmsg.NewCreateTopicsRequestTopicConfig()
+		c.Name = "cleanup.policy"
+		c.Value = kmsg.StringPtr("compact")
+		t.Configs = append(t.Configs, c)
+	}
+	return t
+}
+
+// TopicSpecs builds a spec for each topic from defaults, applying overrides.
+// overrides is a comma-separated list of topic=partitions[:replication[:retention]]
+// entries, e.g. "sensor-events=12:3:720h"; omitted fields keep the defaults and
+// a retention of -1 retains forever.
+func TopicSpecs(topics []string, defaults TopicSpec, overrides string) ([]TopicSpec, error) {
+	specs := make(map[string]TopicSpec, len(topics))
+	for _, topic := range topics {
+		spec := defaults
+		spec.Name = topic
+		specs[topic] = spec
+	}
+
+	for _, entry := range strings.Split(overrides, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		topic, fields, ok := strings.Cut(entry, "=")
+		if !ok || topic == "" || fields == "" {
+			return nil, fmt.Errorf("invalid topic override %q: expected topic=partitions[:replication[:retention]]", entry)
+		}
+
+		spec, ok := specs[topic]
+		if !ok {
+			spec = defaults
+			spec.Name = topic
+		}
+		if err := spec.apply(strings.Split(fields, ":")); err != nil {
+			return nil, fmt.Errorf("invalid topic override %q: %w", entry, err)
+		}
+		specs[topic] = spec
+	}
+
+	result := make([]TopicSpec, 0, len(specs))
+	for _, spec := range specs {
+		result = append(result, spec)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// apply sets partitions, replication, and retention from override fields.
+func (s *TopicSpec) apply(fields []string) error {
+	if len(fields) > 3 {
+		return errors.New("too many fields")
+	}
+	if fields[0] != "" {
+		p, err := strconv.ParseInt(fields[0], 10, 32)
+		if err != nil || p <= 0 {
+			return fmt.Errorf("partitions must be a positive integer, got %q", fields[0])
+		}
+		s.Partitions = int32(p)
+	}
+	if len(fields) > 1 && fields[1] != "" {
+		r, err := strconv.ParseInt(fields[1], 10, 16)
+		if err != nil || r <= 0 {
+			return fmt.Errorf("replication must be a positive integer, got %q", fields[1])
+		}
+		s.ReplicationFactor = int16(r)
+	}
+	if len(fields) > 2 && fields[2] == "-1" {
+		s.Retention = -1 // retain forever, as in Kafka's retention.ms=-1
+	} else if len(fields) > 2 && fields[2] != "" {
+		d, err := time.ParseDuration(fields[2])
+		if err != nil {
+			return fmt.Errorf("retention: %w", err)
+		}
+		s.Retention = d
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package redpanda
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicSpecs(t *testing.T) {
+	defaults := TopicSpec{Partitions: 6, ReplicationFactor: 1, Retention: 168 * time.Hour}
+
+	specs, err := TopicSpecs([]string{"user-actions", "sensor-events"}, defaults,
+		"sensor-events=12:3:720h, audit-events=1::-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, []TopicSpec{
+		{Name: "audit-events", Partitions: 1, ReplicationFactor: 1, Retention: -1},
+		{Name: "sensor-events", Partitions: 12, ReplicationFactor: 3, Retention: 720 * time.Hour},
+		{Name: "user-actions", Partitions: 6, ReplicationFactor: 1, Retention: 168 * time.Hour},
+	}, specs)
+}
+
+func TestTopicSpecs_InvalidOverrides(t *testing.T) {
+	for _, overrides := range []string{
+		"sensor-events",
+		"=12",
+		"sensor-events=zero",
+		"sensor-events=0",
+		"sensor-events=12:-1",
+		"sensor-events=12:3:forever",
+		"sensor-events=12:3:1h:extra",
+	} {
+		t.Run(overrides, func(t *testing.T) {
+			_, err := TopicSpecs([]string{"sensor-events"}, TopicSpec{}, overrides)
+			assert.ErrorContains(t, err, "invalid topic override")
+		})
+	}
+}
+
+func TestTopicSpecRequest(t *testing.T) {
+	req := TopicSpec{Name: "sensor-events", Partitions: 12, ReplicationFactor: 3, Retention: time.Hour}.request()
+	assert.Equal(t, "sensor-events", req.Topic)
+	assert.Equal(t, int32(12), req.NumPartitions)
+	assert.Equal(t, int16(3), req.ReplicationFactor)
+	require.Len(t, req.Configs, 1)
+	assert.Equal(t, "retention.ms", req.Configs[0].Name)
+	assert.Equal(t, "3600000", *req.Configs[0].Value)
+
+	// Zero values defer to the broker
+	req = TopicSpec{Name: "system-events"}.request()
+	assert.Equal(t, int32(-1), req.NumPartitions)
+	assert.Equal(t, int16(-1), req.ReplicationFactor)
+	assert.Empty(t, req.Configs)
+}
+
+func TestTopicSpecRequest_Compacted(t *testing.T) {
+	req := TopicSpec{Name: "projections.changed", Compacted: true}.request()
+	require.Len(t, req.Configs, 1)
+	assert.Equal(t, "cleanup.policy", req.Configs[0].Name)
+	assert.Equal(t, "compact", *req.Configs[0].Value)
+}
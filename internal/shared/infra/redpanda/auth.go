@@ -0,0 +1,71 @@
+package redpanda
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// SASL mechanisms supported by Auth.
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// Auth configures how clients connect to the brokers. The zero value is a
+// plaintext, unauthenticated connection, as used locally.
+type Auth struct {
+	// TLS encrypts broker connections, verifying certificates against the
+	// system roots.
+	TLS bool
+	// SASLMechanism is PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512; empty
+	// disables SASL.
+	SASLMechanism string
+	// SASLCredentials returns the username and password. It is called for
+	// every new broker connection, so rotated credentials are picked up
+	// without a restart.
+	SASLCredentials func(ctx context.Context) (username, password string, err error)
+}
+
+// Opts translates the auth settings into franz-go client options.
+func (a Auth) Opts() ([]kgo.Opt, error) {
+	var opts []kgo.Opt
+	if a.TLS {
+		opts = append(opts, kgo.DialTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+	}
+	if a.SASLMechanism == "" {
+		return opts, nil
+	}
+	if a.SASLCredentials == nil {
+		return nil, fmt.Errorf("SASL mechanism %s requires credentials", a.SASLMechanism)
+	}
+
+	var mechanism sasl.Mechanism
+	switch a.SASLMechanism {
+	case SASLPlain:
+		mechanism = plain.Plain(func(ctx context.Context) (plain.Auth, error) {
+			user, pass, err := a.SASLCredentials(ctx)
+			return plain.Auth{User: user, Pass: pass}, err
+		})
+	case SASLScramSHA256, SASLScramSHA512:
+		fn := func(ctx context.Context) (scram.Auth, error) {
+			user, pass, err := a.SASLCredentials(ctx)
+			return scram.Auth{User: user, Pass: pass}, err
+		}
+		if a.SASLMechanism == SASLScramSHA256 {
+			mechanism = scram.Sha256(fn)
+		} else {
+			mechanism = scram.Sha512(fn)
+		}
+	default:
+		return nil, fmt.Errorf("unknown SASL mechanism %q (want %s, %s, or %s)",
+			a.SASLMechanism, SASLPlain, SASLScramSHA256, SASLScramSHA512)
+	}
+	return append(opts, kgo.SASL(mechanism)), nil
+}
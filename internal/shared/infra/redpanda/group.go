@@ -0,0 +1,53 @@
+package redpanda
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// GroupDescription is the state of a consumer group and its current members.
+type GroupDescription struct {
+	Group   string
+	State   string // e.g. Stable, PreparingRebalance, Empty, Dead
+	Members []GroupMember
+}
+
+// GroupMember is one consumer in a group.
+type GroupMember struct {
+	MemberID string
+	ClientID string
+	Host     string
+}
+
+// DescribeGroup returns the state and members of a consumer group. A group
+// that has never been joined is reported with state Dead and no members.
+func (a *Admin) DescribeGroup(ctx context.Context, group string) (*GroupDescription, error) {
+	req := kmsg.NewPtrDescribeGroupsRequest()
+	req.Groups = []string{group}
+
+	resp, err := req.RequestWith(ctx, a.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe group %s: %w", group, err)
+	}
+	if len(resp.Groups) != 1 {
+		return nil, fmt.Errorf("failed to describe group %s: got %d groups in response", group, len(resp.Groups))
+	}
+
+	g := resp.Groups[0]
+	if err := kerr.ErrorForCode(g.ErrorCode); err != nil {
+		return nil, fmt.Errorf("failed to describe group %s: %w", group, err)
+	}
+
+	desc := &GroupDescription{Group: g.Group, State: g.State}
+	for _, m := range g.Members {
+		desc.Members = append(desc.Members, GroupMember{
+			MemberID: m.MemberID,
+			ClientID: m.ClientID,
+			Host:     m.ClientHost,
+		})
+	}
+	return desc, nil
+}
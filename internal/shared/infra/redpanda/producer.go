@@ -5,8 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 )
@@ -15,39 +20,171 @@ import (
 type Producer struct {
 	client *kgo.Client
 	logger *slog.Logger
+
+	// Transactional producers publish only inside transactions, one at a time
+	transactional bool
+	txMu          sync.Mutex
+
+	tombstones bool // follow deletion events with a tombstone (see ProducerConfig)
+
+	// Shutdown flush counters (see Flush)
+	flushed   atomic.Int64
+	abandoned atomic.Int64
+}
+
+// ProducerStats reports producer record counts for shutdown observability.
+type ProducerStats struct {
+	// Flushed is the number of buffered records delivered by Flush.
+	Flushed int64
+	// Abandoned is the number of buffered records still undelivered when Flush gave up.
+	Abandoned int64
+}
+
+// ProducerConfig controls batching and delivery guarantees.
+// Zero values fall back to franz-go defaults, except where noted.
+type ProducerConfig struct {
+	// Linger is how long a partition batch waits for more records before
+	// being sent. Zero sends as soon as possible.
+	Linger time.Duration
+	// BatchMaxBytes caps the size of a single partition batch. Zero uses the
+	// franz-go default (~1MB).
+	BatchMaxBytes int32
+	// Compression is the batch codec: none, gzip, snappy, lz4, or zstd.
+	// Empty uses the franz-go default (snappy, falling back to none).
+	Compression string
+	// Acks is the required acknowledgement level: all, leader, or none.
+	// Empty means all.
+	Acks string
+	// Idempotent enables the idempotent producer. Requires Acks=all.
+	Idempotent bool
+	// TransactionalID makes the producer transactional (see PublishTransaction).
+	// Must be unique per running instance; requires Idempotent.
+	TransactionalID string
+	// Auth sets TLS and SASL for broker connections.
+	Auth Auth
+	// Tombstones follows each deletion event (see events.IsDeletion) with a
+	// tombstone: a record with the event's key and headers and a null value,
+	// so compacted topics drop the aggregate's records. Every consumer of
+	// the topic must skip records without a value.
+	Tombstones bool
+}
+
+// DefaultProducerConfig returns the safest settings: acks=all with idempotence.
+func DefaultProducerConfig() ProducerConfig {
+	return ProducerConfig{Acks: "all", Idempotent: true}
+}
+
+// options translates the config into franz-go producer options.
+func (c ProducerConfig) options() ([]kgo.Opt, error) {
+	opts, err := c.Auth.Opts()
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.Acks {
+	case "", "all":
+		opts = append(opts, kgo.RequiredAcks(kgo.AllISRAcks()))
+	case "leader":
+		opts = append(opts, kgo.RequiredAcks(kgo.LeaderAck()))
+	case "none":
+		opts = append(opts, kgo.RequiredAcks(kgo.NoAck()))
+	default:
+		return nil, fmt.Errorf("unknown acks level %q (want all, leader, or none)", c.Acks)
+	}
+
+	if c.Idempotent {
+		if c.Acks != "" && c.Acks != "all" {
+			return nil, fmt.Errorf("idempotent producer requires acks=all, got %q", c.Acks)
+		}
+	} else {
+		opts = append(opts, kgo.DisableIdempotentWrite())
+	}
+
+	switch c.Compression {
+	case "":
+	case "none":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.NoCompression()))
+	case "gzip":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.GzipCompression()))
+	case "snappy":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.SnappyCompression()))
+	case "lz4":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.Lz4Compression()))
+	case "zstd":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.ZstdCompression()))
+	default:
+		return nil, fmt.Errorf("unknown compression codec %q", c.Compression)
+	}
+
+	if c.Linger < 0 {
+		return nil, fmt.Errorf("linger must not be negative, got %s", c.Linger)
+	}
+	if c.Linger > 0 {
+		opts = append(opts, kgo.ProducerLinger(c.Linger))
+	}
+
+	if c.BatchMaxBytes < 0 {
+		return nil, fmt.Errorf("batch max bytes must not be negative, got %d", c.BatchMaxBytes)
+	}
+	if c.BatchMaxBytes > 0 {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(c.BatchMaxBytes))
+	}
+
+	if c.TransactionalID != "" {
+		if !c.Idempotent {
+			return nil, fmt.Errorf("transactional producer requires idempotence")
+		}
+		opts = append(opts, kgo.TransactionalID(c.TransactionalID))
+	}
+
+	return opts, nil
 }
 
 // NewProducer creates a new Redpanda producer.
-func NewProducer(brokers []string, logger *slog.Logger) (*Producer, error) {
-	client, err := kgo.NewClient(
+func NewProducer(brokers []string, cfg ProducerConfig, logger *slog.Logger) (*Producer, error) {
+	producerOpts, err := cfg.options()
+	if err != nil {
+		return nil, fmt.Errorf("invalid producer config: %w", err)
+	}
+
+	opts := append([]kgo.Opt{
 		kgo.SeedBrokers(brokers...),
 		kgo.AllowAutoTopicCreation(),
-	)
+	}, producerOpts...)
+
+	client, err := kgo.NewClient(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Redpanda client: %w", err)
 	}
 
 	return &Producer{
-		client: client,
-		logger: logger.With("component", "redpanda-producer"),
+		client:        client,
+		logger:        logger.With("component", "redpanda-producer"),
+		transactional: cfg.TransactionalID != "",
+		tombstones:    cfg.Tombstones,
 	}, nil
 }
 
-// Publish sends an event to the specified topic.
+// Message is an event addressed to a topic, for batch publishing.
+type Message struct {
+	Topic string
+	Event *events.Envelope
+}
+
+// Publish sends an event to the specified topic and waits for delivery.
+// A transactional producer wraps the event in a single-record transaction.
 func (p *Producer) Publish(ctx context.Context, topic string, event *events.Envelope) error {
-	value, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+	if p.transactional {
+		return p.PublishTransaction(ctx, []Message{{Topic: topic, Event: event}})
 	}
 
-	record := &kgo.Record{
-		Topic: topic,
-		Key:   []byte(event.AggregateID), // Partition by aggregate for ordering
-		Value: value,
+	records, err := p.newRecords(topic, event)
+	if err != nil {
+		return err
 	}
 
 	// Synchronous produce
-	results := p.client.ProduceSync(ctx, record)
+	results := p.client.ProduceSync(ctx, records...)
 	if err := results.FirstErr(); err != nil {
 		return fmt.Errorf("failed to publish to %s: %w", topic, err)
 	}
@@ -61,6 +198,234 @@ func (p *Producer) Publish(ctx context.Context, topic string, event *events.Enve
 	return nil
 }
 
+// PublishAsync buffers an event for batched delivery and returns immediately.
+// onDelivery is called exactly once with the delivery result, from a producer
+// goroutine; it must not block for long since it holds up later callbacks.
+// Not supported by transactional producers.
+func (p *Producer) PublishAsync(ctx context.Context, topic string, event *events.Envelope, onDelivery func(error)) {
+	if p.transactional {
+		onDelivery(fmt.Errorf("async publish is not supported by a transactional producer"))
+		return
+	}
+
+	records, err := p.newRecords(topic, event)
+	if err != nil {
+		onDelivery(err)
+		return
+	}
+
+	// With a tombstone, delivery is reported once both records are delivered
+	var once sync.Once
+	var remaining atomic.Int32
+	remaining.Store(int32(len(records)))
+	for _, record := range records {
+		p.client.Produce(ctx, record, func(_ *kgo.Record, err error) {
+			if err != nil {
+				once.Do(func() { onDelivery(fmt.Errorf("failed to publish to %s: %w", topic, err)) })
+				return
+			}
+			if remaining.Add(-1) > 0 {
+				return
+			}
+
+			p.logger.Debug("event published to Redpanda",
+				"topic", topic,
+				"event_id", event.EventID,
+				"event_type", event.EventType,
+			)
+			once.Do(func() { onDelivery(nil) })
+		})
+	}
+}
+
+// PublishRecordAsync buffers a record with the given key and value for batched
+// delivery and returns immediately; a nil value publishes a tombstone. It is
+// for topics that carry something other than events, such as projection
+// changes. onDelivery is called as for PublishAsync. Not supported by
+// transactional producers.
+func (p *Producer) PublishRecordAsync(ctx context.Context, topic string, key, value []byte, onDelivery func(error)) {
+	if p.transactional {
+		onDelivery(fmt.Errorf("async publish is not supported by a transactional producer"))
+		return
+	}
+	p.client.Produce(ctx, &kgo.Record{Topic: topic, Key: key, Value: value}, func(_ *kgo.Record, err error) {
+		if err != nil {
+			onDelivery(fmt.Errorf("failed to publish to %s: %w", topic, err))
+			return
+		}
+		onDelivery(nil)
+	})
+}
+
+// PublishTransaction publishes all messages atomically in one Kafka transaction:
+// read_committed consumers see either every record or none. On any produce
+// failure the transaction is aborted and an error returned, so the caller can
+// retry the whole batch without exposing duplicates.
+func (p *Producer) PublishTransaction(ctx context.Context, msgs []Message) error {
+	if !p.transactional {
+		return fmt.Errorf("producer is not transactional (no transactional ID configured)")
+	}
+
+	records := make([]*kgo.Record, 0, len(msgs))
+	for _, msg := range msgs {
+		msgRecords, err := p.newRecords(msg.Topic, msg.Event)
+		if err != nil {
+			return err
+		}
+		records = append(records, msgRecords...)
+	}
+
+	p.txMu.Lock()
+	defer p.txMu.Unlock()
+
+	if err := p.client.BeginTransaction(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Ending a transaction must not be interrupted: a cancelled EndTransaction
+	// leaves its outcome unknown and the client in an unusable state.
+	endCtx := context.WithoutCancel(ctx)
+
+	if err := p.client.ProduceSync(ctx, records...).FirstErr(); err != nil {
+		p.abortTransaction(endCtx)
+		return fmt.Errorf("failed to publish transaction of %d records: %w", len(records), err)
+	}
+
+	if err := p.client.EndTransaction(endCtx, kgo.TryCommit); err != nil {
+		p.abortTransaction(endCtx)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	p.logger.Debug("transaction published to Redpanda", "records", len(records))
+	return nil
+}
+
+// abortTransaction discards buffered records and aborts the open transaction.
+func (p *Producer) abortTransaction(ctx context.Context) {
+	if err := p.client.AbortBufferedRecords(ctx); err != nil {
+		p.logger.Warn("failed to abort buffered records", "error", err)
+	}
+	if err := p.client.EndTransaction(ctx, kgo.TryAbort); err != nil {
+		p.logger.Error("failed to abort transaction", "error", err)
+	}
+}
+
+// newRecord builds the Kafka record for an event.
+func newRecord(topic string, event *events.Envelope) (*kgo.Record, error) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return &kgo.Record{
+		Topic:   topic,
+		Key:     []byte(event.AggregateID), // Partition by aggregate for ordering
+		Value:   value,
+		Headers: recordHeaders(event),
+	}, nil
+}
+
+// newRecords builds the records for an event: its record, followed by a
+// tombstone for a deletion event when tombstones are enabled. Both have the
+// same key, so they land on the same partition in order.
+func (p *Producer) newRecords(topic string, event *events.Envelope) ([]*kgo.Record, error) {
+	record, err := newRecord(topic, event)
+	if err != nil {
+		return nil, err
+	}
+	if !p.tombstones || !events.IsDeletion(event.EventType) {
+		return []*kgo.Record{record}, nil
+	}
+	tombstone := &kgo.Record{Topic: topic, Key: record.Key, Headers: record.Headers}
+	return []*kgo.Record{record, tombstone}, nil
+}
+
+// recordHeaders builds the metadata headers published with every event.
+// Optional fields (tenant, trace, correlation, content type, test) are omitted when empty.
+func recordHeaders(event *events.Envelope) []kgo.RecordHeader {
+	headers := []kgo.RecordHeader{
+		{Key: events.HeaderEventID, Value: []byte(event.EventID.String())},
+		{Key: events.HeaderEventType, Value: []byte(event.EventType)},
+		{Key: events.HeaderSchemaVersion, Value: []byte(strconv.Itoa(event.Metadata.SchemaVersion))},
+	}
+	if event.Metadata.TenantID != "" {
+		headers = append(headers, kgo.RecordHeader{Key: events.HeaderTenantID, Value: []byte(event.Metadata.TenantID)})
+	}
+	if event.Metadata.TraceID != "" {
+		headers = append(headers, kgo.RecordHeader{Key: events.HeaderTraceID, Value: []byte(event.Metadata.TraceID)})
+	}
+	if event.Metadata.CorrelationID != "" {
+		headers = append(headers, kgo.RecordHeader{Key: events.HeaderCorrelationID, Value: []byte(event.Metadata.CorrelationID)})
+	}
+	if event.Metadata.ContentType != "" {
+		headers = append(headers, kgo.RecordHeader{Key: events.HeaderContentType, Value: []byte(event.Metadata.ContentType)})
+	}
+	if event.Metadata.Test {
+		headers = append(headers, kgo.RecordHeader{Key: events.HeaderTest, Value: []byte("true")})
+	}
+	return headers
+}
+
+// MissingTopics returns the subset of topics that do not exist on the cluster.
+// Used at startup to validate configured topic routes.
+func (p *Producer) MissingTopics(ctx context.Context, topics []string) ([]string, error) {
+	req := kmsg.NewPtrMetadataRequest()
+	for _, topic := range topics {
+		t := kmsg.NewMetadataRequestTopic()
+		t.Topic = kmsg.StringPtr(topic)
+		req.Topics = append(req.Topics, t)
+	}
+	req.AllowAutoTopicCreation = false
+
+	resp, err := req.RequestWith(ctx, p.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch topic metadata: %w", err)
+	}
+
+	var missing []string
+	for _, t := range resp.Topics {
+		if t.ErrorCode != 0 && t.Topic != nil {
+			missing = append(missing, *t.Topic)
+		}
+	}
+	return missing, nil
+}
+
+// Flush blocks until all buffered records are delivered or ctx is done.
+// Call after upstream workers have drained so no new records arrive mid-flush.
+// Returns an error if any records were abandoned because ctx expired.
+func (p *Producer) Flush(ctx context.Context) error {
+	buffered := p.client.BufferedProduceRecords()
+
+	err := p.client.Flush(ctx)
+
+	remaining := p.client.BufferedProduceRecords()
+	flushed := buffered - remaining
+	if flushed < 0 {
+		flushed = 0
+	}
+	p.flushed.Add(flushed)
+	p.abandoned.Add(remaining)
+
+	p.logger.Info("Redpanda producer flushed",
+		"records_flushed", flushed,
+		"records_abandoned", remaining,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to flush producer (%d records abandoned): %w", remaining, err)
+	}
+	return nil
+}
+
+// Stats returns cumulative flush counters.
+func (p *Producer) Stats() ProducerStats {
+	return ProducerStats{
+		Flushed:   p.flushed.Load(),
+		Abandoned: p.abandoned.Load(),
+	}
+}
+
 // Close closes the producer connection.
 func (p *Producer) Close() {
 	p.client.Close()
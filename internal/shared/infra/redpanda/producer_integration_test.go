@@ -10,10 +10,10 @@ import (
 	"testing"
 	"time"
 
-	"github.com/gofrs/uuid/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 	"github.com/cornjacket/platform-services/internal/testutil"
@@ -23,48 +23,18 @@ func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
 }
 
-func testEnvelope(t *testing.T) *events.Envelope {
-	t.Helper()
-	return &events.Envelope{
-		EventID:     uuid.Must(uuid.NewV7()),
-		EventType:   "sensor.reading",
-		AggregateID: "device-001",
-		EventTime:   time.Now().UTC().Truncate(time.Microsecond),
-		IngestedAt:  time.Now().UTC().Truncate(time.Microsecond),
-		Payload:     json.RawMessage(`{"temperature": 22.5}`),
-		Metadata:    events.Metadata{Source: "test", SchemaVersion: 1},
-	}
-}
-
 func TestProducerPublish(t *testing.T) {
 	topic := testutil.TestTopicName(t)
-	producer, err := NewProducer(testutil.TestBrokers(), testLogger())
+	producer, err := NewProducer(testutil.TestBrokers(), DefaultProducerConfig(), testLogger())
 	require.NoError(t, err)
 	defer producer.Close()
 
-	env := testEnvelope(t)
+	env := testutil.Event().Build()
 	err = producer.Publish(context.Background(), topic, env)
 	require.NoError(t, err)
 
 	// Consume the message to verify it was produced
-	consumer, err := kgo.NewClient(
-		kgo.SeedBrokers(testutil.TestBrokers()...),
-		kgo.ConsumeTopics(topic),
-		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
-	)
-	require.NoError(t, err)
-	defer consumer.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	fetches := consumer.PollFetches(ctx)
-	require.Empty(t, fetches.Errors(), "fetch errors")
-
-	var records []*kgo.Record
-	fetches.EachRecord(func(r *kgo.Record) {
-		records = append(records, r)
-	})
+	records := testutil.ConsumeN(t, topic, 1, 5*time.Second)
 	require.Len(t, records, 1)
 
 	// Verify the message content
@@ -79,46 +49,191 @@ func TestProducerPublish(t *testing.T) {
 }
 
 func TestProducerPartitionKey(t *testing.T) {
-	topic := testutil.TestTopicName(t)
-	producer, err := NewProducer(testutil.TestBrokers(), testLogger())
+	topic := testutil.CreateTestTopic(t, 3)
+	producer, err := NewProducer(testutil.TestBrokers(), DefaultProducerConfig(), testLogger())
 	require.NoError(t, err)
 	defer producer.Close()
 
 	// Publish 3 events with the same aggregate_id
 	for i := 0; i < 3; i++ {
-		env := testEnvelope(t)
+		env := testutil.Event().Build()
 		env.AggregateID = "same-device"
 		require.NoError(t, producer.Publish(context.Background(), topic, env))
 	}
 
-	// Consume all messages
-	consumer, err := kgo.NewClient(
-		kgo.SeedBrokers(testutil.TestBrokers()...),
-		kgo.ConsumeTopics(topic),
-		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
-	)
+	records := testutil.ConsumeN(t, topic, 3, 5*time.Second)
+	require.Len(t, records, 3)
+
+	// All messages with the same key should land on the same partition
+	partition := records[0].Partition
+	for _, r := range records[1:] {
+		assert.Equal(t, partition, r.Partition,
+			"same aggregate_id should route to same partition")
+	}
+}
+
+func TestProducerFlush(t *testing.T) {
+	topic := testutil.TestTopicName(t)
+	producer, err := NewProducer(testutil.TestBrokers(), DefaultProducerConfig(), testLogger())
 	require.NoError(t, err)
-	defer consumer.Close()
+	defer producer.Close()
+
+	require.NoError(t, producer.Publish(context.Background(), topic, testutil.Event().Build()))
 
+	// Publish is synchronous, so nothing is left buffered for Flush
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	require.NoError(t, producer.Flush(ctx))
 
-	var records []*kgo.Record
-	for len(records) < 3 {
-		fetches := consumer.PollFetches(ctx)
-		if ctx.Err() != nil {
-			break
-		}
-		fetches.EachRecord(func(r *kgo.Record) {
-			records = append(records, r)
+	stats := producer.Stats()
+	assert.Equal(t, int64(0), stats.Abandoned)
+}
+
+func TestProducerHeaders(t *testing.T) {
+	topic := testutil.TestTopicName(t)
+	producer, err := NewProducer(testutil.TestBrokers(), DefaultProducerConfig(), testLogger())
+	require.NoError(t, err)
+	defer producer.Close()
+
+	env := testutil.Event().Build()
+	env.Metadata.TenantID = "tenant-42"
+	env.Metadata.TraceID = "trace-abc"
+	env.Metadata.CorrelationID = "order-7"
+	env.Metadata.Test = true
+	env.SetBinaryPayload("application/cbor", []byte{0xa1})
+	require.NoError(t, producer.Publish(context.Background(), topic, env))
+
+	records := testutil.ConsumeN(t, topic, 1, 5*time.Second)
+	require.Len(t, records, 1)
+
+	headers := make(map[string]string)
+	for _, h := range records[0].Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	assert.Equal(t, env.EventID.String(), headers[events.HeaderEventID])
+	assert.Equal(t, env.EventType, headers[events.HeaderEventType])
+	assert.Equal(t, "1", headers[events.HeaderSchemaVersion])
+	assert.Equal(t, "tenant-42", headers[events.HeaderTenantID])
+	assert.Equal(t, "trace-abc", headers[events.HeaderTraceID])
+	assert.Equal(t, "order-7", headers[events.HeaderCorrelationID])
+	assert.Equal(t, "application/cbor", headers[events.HeaderContentType])
+	assert.Equal(t, "true", headers[events.HeaderTest])
+}
+
+func TestProducerTombstones(t *testing.T) {
+	topic := testutil.TestTopicName(t)
+	cfg := DefaultProducerConfig()
+	cfg.Tombstones = true
+	producer, err := NewProducer(testutil.TestBrokers(), cfg, testLogger())
+	require.NoError(t, err)
+	defer producer.Close()
+
+	env := testutil.Event().Build()
+	env.EventType = "sensor.deleted"
+	require.NoError(t, producer.Publish(context.Background(), topic, env))
+
+	records := testutil.ConsumeN(t, topic, 2, 5*time.Second)
+	require.Len(t, records, 2)
+	assert.NotEmpty(t, records[0].Value)
+	assert.Nil(t, records[1].Value, "the deletion event is followed by a tombstone")
+	assert.Equal(t, env.AggregateID, string(records[1].Key))
+}
+
+func TestProducerPublishAsync(t *testing.T) {
+	topic := testutil.TestTopicName(t)
+	producer, err := NewProducer(testutil.TestBrokers(), ProducerConfig{
+		Linger:      20 * time.Millisecond,
+		Compression: "zstd",
+		Acks:        "all",
+		Idempotent:  true,
+	}, testLogger())
+	require.NoError(t, err)
+	defer producer.Close()
+
+	const count = 5
+	results := make(chan error, count)
+	for i := 0; i < count; i++ {
+		producer.PublishAsync(context.Background(), topic, testutil.Event().Build(), func(err error) {
+			results <- err
 		})
 	}
-	require.Len(t, records, 3)
 
-	// All messages with the same key should land on the same partition
-	partition := records[0].Partition
-	for _, r := range records[1:] {
-		assert.Equal(t, partition, r.Partition,
-			"same aggregate_id should route to same partition")
+	for i := 0; i < count; i++ {
+		select {
+		case err := <-results:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for delivery callback")
+		}
+	}
+}
+
+func TestProducerPublishTransaction(t *testing.T) {
+	topic := testutil.TestTopicName(t)
+	cfg := DefaultProducerConfig()
+	cfg.TransactionalID = "test-txn-" + topic
+	producer, err := NewProducer(testutil.TestBrokers(), cfg, testLogger())
+	require.NoError(t, err)
+	defer producer.Close()
+
+	msgs := []Message{
+		{Topic: topic, Event: testutil.Event().Build()},
+		{Topic: topic, Event: testutil.Event().Build()},
 	}
+	require.NoError(t, producer.PublishTransaction(context.Background(), msgs))
+
+	records := testutil.ConsumeN(t, topic, len(msgs), 5*time.Second, kgo.FetchIsolationLevel(kgo.ReadCommitted()))
+	assert.Len(t, records, 2, "committed transaction should be visible to read_committed consumers")
+}
+
+func TestAdminEnsureTopics(t *testing.T) {
+	topic := testutil.TestTopicName(t)
+	admin, err := NewAdmin(testutil.TestBrokers(), Auth{}, testLogger())
+	require.NoError(t, err)
+	defer admin.Close()
+
+	ctx := context.Background()
+	specs := []TopicSpec{{Name: topic, Partitions: 3, ReplicationFactor: 1, Retention: time.Hour}}
+
+	result, err := admin.EnsureTopics(ctx, specs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{topic}, result.Created)
+
+	counts, err := admin.PartitionCounts(ctx, []string{topic})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), counts[topic])
+
+	// Second run is a no-op; a larger spec is reported, not applied
+	specs[0].Partitions = 6
+	result, err = admin.EnsureTopics(ctx, specs)
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Equal(t, []string{topic}, result.Existing)
+	assert.Equal(t, []string{topic}, result.UnderPartitioned)
+}
+
+func TestAdminApplyACLs(t *testing.T) {
+	admin, err := NewAdmin(testutil.TestBrokers(), Auth{}, testLogger())
+	require.NoError(t, err)
+	defer admin.Close()
+
+	ctx := context.Background()
+	principal := "User:" + testutil.TestTopicName(t)
+	keep := literal(principal, kmsg.ACLResourceTypeTopic, "sensor-events", kmsg.ACLOperationRead)
+	drop := literal(principal, kmsg.ACLResourceTypeTopic, "user-actions", kmsg.ACLOperationRead)
+
+	result, err := admin.ApplyACLs(ctx, []ACLBinding{keep, drop}, false)
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 2)
+
+	// Re-applying a smaller config with prune removes the extra rule
+	result, err = admin.ApplyACLs(ctx, []ACLBinding{keep}, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Equal(t, 1, result.Existing)
+	assert.Equal(t, []ACLBinding{drop}, result.Deleted)
+
+	// Clean up
+	_, err = admin.ApplyACLs(ctx, nil, true)
+	require.NoError(t, err)
 }
@@ -0,0 +1,102 @@
+package redpanda
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+func TestProducerConfigOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ProducerConfig
+		wantErr string
+	}{
+		{name: "defaults", cfg: DefaultProducerConfig()},
+		{name: "zero value", cfg: ProducerConfig{}},
+		{
+			name: "batched leader acks",
+			cfg:  ProducerConfig{Linger: 10 * time.Millisecond, BatchMaxBytes: 256 << 10, Compression: "lz4", Acks: "leader"},
+		},
+		{name: "unknown acks", cfg: ProducerConfig{Acks: "some"}, wantErr: "unknown acks level"},
+		{name: "unknown codec", cfg: ProducerConfig{Compression: "brotli"}, wantErr: "unknown compression codec"},
+		{name: "idempotent without all acks", cfg: ProducerConfig{Acks: "leader", Idempotent: true}, wantErr: "requires acks=all"},
+		{name: "negative linger", cfg: ProducerConfig{Linger: -time.Second}, wantErr: "linger must not be negative"},
+		{name: "transactional", cfg: ProducerConfig{Acks: "all", Idempotent: true, TransactionalID: "outbox-1"}},
+		{name: "transactional without idempotence", cfg: ProducerConfig{TransactionalID: "outbox-1"}, wantErr: "requires idempotence"},
+		{name: "negative batch size", cfg: ProducerConfig{BatchMaxBytes: -1}, wantErr: "batch max bytes must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cfg.options()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthOpts(t *testing.T) {
+	creds := func(context.Context) (string, string, error) { return "platform", "s3cret", nil }
+
+	tests := []struct {
+		name     string
+		auth     Auth
+		wantOpts int
+		wantErr  string
+	}{
+		{name: "zero value", auth: Auth{}, wantOpts: 0},
+		{name: "tls only", auth: Auth{TLS: true}, wantOpts: 1},
+		{name: "plain", auth: Auth{SASLMechanism: SASLPlain, SASLCredentials: creds}, wantOpts: 1},
+		{name: "scram over tls", auth: Auth{TLS: true, SASLMechanism: SASLScramSHA512, SASLCredentials: creds}, wantOpts: 2},
+		{name: "scram-sha-256", auth: Auth{SASLMechanism: SASLScramSHA256, SASLCredentials: creds}, wantOpts: 1},
+		{name: "missing credentials", auth: Auth{SASLMechanism: SASLPlain}, wantErr: "requires credentials"},
+		{name: "unknown mechanism", auth: Auth{SASLMechanism: "GSSAPI", SASLCredentials: creds}, wantErr: `unknown SASL mechanism "GSSAPI"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := tt.auth.Opts()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, opts, tt.wantOpts)
+		})
+	}
+
+	_, err := ProducerConfig{Auth: Auth{SASLMechanism: "GSSAPI", SASLCredentials: creds}}.options()
+	assert.ErrorContains(t, err, "unknown SASL mechanism")
+}
+
+func TestNewRecords_Tombstones(t *testing.T) {
+	deleted := testutil.Event().Build()
+	deleted.EventType = "user.deleted"
+	reading := testutil.Event().Build()
+
+	p := &Producer{tombstones: true}
+	records, err := p.newRecords("events", deleted)
+	require.NoError(t, err)
+	require.Len(t, records, 2, "a deletion event is followed by a tombstone")
+	assert.NotNil(t, records[0].Value)
+	assert.Nil(t, records[1].Value)
+	assert.Equal(t, records[0].Key, records[1].Key)
+	assert.Equal(t, records[0].Headers, records[1].Headers)
+
+	records, err = p.newRecords("events", reading)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	records, err = (&Producer{}).newRecords("events", deleted)
+	require.NoError(t, err)
+	assert.Len(t, records, 1, "tombstones are opt-in")
+}
@@ -0,0 +1,149 @@
+// Package leader runs singleton background work, such as the outbox
+// processor and periodic sweeps, on one replica at a time. Each kind of work
+// has its own named lock. The replica holding it leads; the others retry
+// until the lock frees up, which happens when the leader stops or dies.
+package leader
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// Lock is a cluster-wide lock for one kind of singleton work. It must be
+// released automatically if its holder dies (see postgres.AdvisoryLock).
+type Lock interface {
+	// TryAcquire takes the lock if it is free and reports whether it did.
+	TryAcquire(ctx context.Context) (bool, error)
+	// Check returns an error if the lock may no longer be held, for example
+	// because the connection holding it was lost.
+	Check(ctx context.Context) error
+	// Release gives up the lock if held and frees its resources. TryAcquire
+	// may be called again afterwards.
+	Release(ctx context.Context) error
+}
+
+// releaseTimeout bounds Release after the run's context is done.
+const releaseTimeout = 5 * time.Second
+
+// Elector runs singleton work under named locks. A nil Elector runs work
+// directly, for single-instance deployments, so services can hold one
+// unconditionally.
+type Elector struct {
+	newLock       func(name string) Lock
+	retryInterval time.Duration
+	logger        *slog.Logger
+
+	mu      sync.Mutex
+	leading map[string]bool
+}
+
+// NewElector creates an Elector. newLock creates the lock for a kind of
+// work; retryInterval is how often followers try to take over and the
+// leader checks that it still holds its lock.
+func NewElector(newLock func(name string) Lock, retryInterval time.Duration, logger *slog.Logger) *Elector {
+	return &Elector{
+		newLock:       newLock,
+		retryInterval: retryInterval,
+		logger:        logger.With("component", "leader-election"),
+		leading:       make(map[string]bool),
+	}
+}
+
+// Run runs work whenever this instance holds the lock called name, and
+// blocks until ctx is done. work's context is cancelled when leadership is
+// lost; work must return promptly then. If work returns on its own, the
+// lock is released so another instance can take over.
+func (e *Elector) Run(ctx context.Context, name string, work func(ctx context.Context)) {
+	if e == nil {
+		work(ctx)
+		return
+	}
+
+	logger := e.logger.With("role", name)
+	lock := e.newLock(name)
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+		defer cancel()
+		if err := lock.Release(releaseCtx); err != nil {
+			logger.Warn("failed to release leader lock", "error", err)
+		}
+	}()
+
+	for {
+		acquired, err := lock.TryAcquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Warn("failed to acquire leader lock", "error", err)
+		}
+		if acquired {
+			logger.Info("acquired leadership")
+			e.setLeading(name, true)
+			e.lead(ctx, lock, logger, work)
+			e.setLeading(name, false)
+			if ctx.Err() == nil {
+				// Let another instance take over while this one backs off
+				if err := lock.Release(ctx); err != nil {
+					logger.Warn("failed to release leader lock", "error", err)
+				}
+			}
+		}
+
+		if clock.Sleep(ctx, e.retryInterval) != nil {
+			return
+		}
+	}
+}
+
+// lead runs work until it returns, ctx is done or the lock check fails.
+func (e *Elector) lead(ctx context.Context, lock Lock, logger *slog.Logger, work func(ctx context.Context)) {
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		work(workCtx)
+	}()
+
+	ticker := clock.NewTicker(e.retryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			if ctx.Err() == nil {
+				logger.Warn("singleton work stopped while leading, releasing leadership")
+			}
+			return
+		case <-ctx.Done():
+			<-done
+			logger.Info("stepped down")
+			return
+		case <-ticker.C():
+			if err := lock.Check(ctx); err != nil {
+				logger.Warn("lost leadership", "error", err)
+				cancel()
+				<-done
+				return
+			}
+		}
+	}
+}
+
+// Leading reports whether this instance currently runs the work called name.
+// A nil Elector always leads.
+func (e *Elector) Leading(name string) bool {
+	if e == nil {
+		return true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading[name]
+}
+
+func (e *Elector) setLeading(name string, leading bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.leading[name] = leading
+}
@@ -0,0 +1,187 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLocks is an in-process lock table shared by fake replicas.
+type memoryLocks struct {
+	mu      sync.Mutex
+	holders map[string]*fakeLock
+}
+
+func (m *memoryLocks) lock(name string) Lock {
+	return &fakeLock{table: m, name: name}
+}
+
+// fakeLock is one replica's handle on a named lock. Setting lost simulates
+// the holding session dying: Check fails and the lock frees up.
+type fakeLock struct {
+	table *memoryLocks
+	name  string
+
+	mu       sync.Mutex
+	lost     bool
+	released int
+}
+
+func (l *fakeLock) TryAcquire(ctx context.Context) (bool, error) {
+	l.table.mu.Lock()
+	defer l.table.mu.Unlock()
+	if holder := l.table.holders[l.name]; holder != nil && holder != l {
+		return false, nil
+	}
+	l.table.holders[l.name] = l
+	l.mu.Lock()
+	l.lost = false
+	l.mu.Unlock()
+	return true, nil
+}
+
+func (l *fakeLock) Check(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lost {
+		return errors.New("connection lost")
+	}
+	return nil
+}
+
+func (l *fakeLock) Release(ctx context.Context) error {
+	l.table.mu.Lock()
+	defer l.table.mu.Unlock()
+	if l.table.holders[l.name] == l {
+		delete(l.table.holders, l.name)
+	}
+	l.mu.Lock()
+	l.released++
+	l.mu.Unlock()
+	return nil
+}
+
+// die frees the lock as if the holder's session had ended.
+func (l *fakeLock) die() {
+	l.table.mu.Lock()
+	delete(l.table.holders, l.name)
+	l.table.mu.Unlock()
+	l.mu.Lock()
+	l.lost = true
+	l.mu.Unlock()
+}
+
+func TestElector_Nil(t *testing.T) {
+	var e *Elector
+	ran := false
+	e.Run(context.Background(), "outbox", func(ctx context.Context) { ran = true })
+	assert.True(t, ran)
+	assert.True(t, e.Leading("outbox"))
+}
+
+func TestElector_OneLeaderWithFailover(t *testing.T) {
+	table := &memoryLocks{holders: make(map[string]*fakeLock)}
+	var locksMu sync.Mutex
+	locks := map[*Elector]*fakeLock{}
+	newElector := func() *Elector {
+		var e *Elector
+		e = NewElector(func(name string) Lock {
+			l := table.lock(name).(*fakeLock)
+			locksMu.Lock()
+			locks[e] = l
+			locksMu.Unlock()
+			return l
+		}, 5*time.Millisecond, slog.Default())
+		return e
+	}
+	a, b := newElector(), newElector()
+
+	var mu sync.Mutex
+	running := 0
+	maxRunning := 0
+	work := func(ctx context.Context) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		<-ctx.Done()
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}
+	runningNow := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return running
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, e := range []*Elector{a, b} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.Run(ctx, "outbox", work)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return a.Leading("outbox") != b.Leading("outbox") }, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond) // several retry intervals
+	mu.Lock()
+	assert.Equal(t, 1, maxRunning, "work ran on two replicas at once")
+	mu.Unlock()
+
+	leaderElector, follower := a, b
+	if b.Leading("outbox") {
+		leaderElector, follower = b, a
+	}
+
+	// The leader's session dies: it stops its work and the follower takes over
+	locksMu.Lock()
+	leaderLock := locks[leaderElector]
+	locksMu.Unlock()
+	leaderLock.die()
+	require.Eventually(t, func() bool {
+		return follower.Leading("outbox") && !leaderElector.Leading("outbox") && runningNow() == 1
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	wg.Wait()
+	assert.Zero(t, runningNow())
+	assert.False(t, a.Leading("outbox"))
+	assert.False(t, b.Leading("outbox"))
+	assert.Empty(t, table.holders)
+}
+
+func TestElector_ReleasesWhenWorkStops(t *testing.T) {
+	table := &memoryLocks{holders: make(map[string]*fakeLock)}
+	var lock *fakeLock
+	e := NewElector(func(name string) Lock {
+		lock = table.lock(name).(*fakeLock)
+		return lock
+	}, 5*time.Millisecond, slog.Default())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runs := make(chan struct{}, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Run(ctx, "sweeper", func(ctx context.Context) { runs <- struct{}{} })
+	}()
+
+	// Work that returns on its own gives up the lock and is retried later
+	<-runs
+	<-runs
+	cancel()
+	<-done
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	assert.GreaterOrEqual(t, lock.released, 2)
+}
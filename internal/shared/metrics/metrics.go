@@ -0,0 +1,331 @@
+// Package metrics keeps in-process counters and histograms and serves them
+// in the Prometheus text exposition format, so any Prometheus-compatible
+// scraper can collect them from GET /metrics.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DurationBuckets are histogram bounds in seconds for request and query
+// latencies, from 1ms to 10s.
+var DurationBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds the metric families served by its handler. Families are
+// written in registration order.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+	names    map[string]bool
+}
+
+type family interface {
+	write(w io.Writer) error
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// ServeHTTP writes every family in the text exposition format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.Write(w) // fails only when the scraper went away
+}
+
+// Write writes every family in the text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	for _, f := range families {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// series holds the per-label-set values of a family.
+type series[T any] struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*T // keyed by joined label values
+	keys   map[string][]string
+}
+
+func newSeries[T any](name, help, kind string, labels []string) *series[T] {
+	return &series[T]{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		values: make(map[string]*T),
+		keys:   make(map[string][]string),
+	}
+}
+
+// get returns the value for labelValues, creating it with init. Callers
+// hold s.mu.
+func (s *series[T]) get(labelValues []string, init func() *T) *T {
+	if len(labelValues) != len(s.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", s.name, len(s.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	v, ok := s.values[key]
+	if !ok {
+		v = init()
+		s.values[key] = v
+		s.keys[key] = slices.Clone(labelValues)
+	}
+	return v
+}
+
+// sorted returns the series keys in a stable order. Callers hold s.mu.
+func (s *series[T]) sorted() []string {
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (s *series[T]) header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", s.name, escapeHelp(s.help), s.name, s.kind)
+	return err
+}
+
+// Counter is a family of monotonically increasing values. A nil Counter
+// discards updates.
+type Counter struct {
+	s *series[float64]
+}
+
+// NewCounter registers a counter family with the given label names.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{s: newSeries[float64](name, help, "counter", labels)}
+	r.register(name, c)
+	return c
+}
+
+// Inc adds 1 to the series for labelValues.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series for labelValues.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if c == nil {
+		return
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	*c.s.get(labelValues, func() *float64 { return new(float64) }) += v
+}
+
+func (c *Counter) write(w io.Writer) error {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	if err := c.s.header(w); err != nil {
+		return err
+	}
+	for _, key := range c.s.sorted() {
+		if err := writeSample(w, c.s.name, c.s.labels, c.s.keys[key], "", "", *c.s.values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram is a family of distributions over fixed buckets. A nil
+// Histogram discards observations.
+type Histogram struct {
+	s       *series[histogramValue]
+	buckets []float64
+}
+
+type histogramValue struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram registers a histogram family. buckets are the upper bounds,
+// in increasing order; +Inf is implicit.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if !slices.IsSorted(buckets) {
+		panic(fmt.Sprintf("metrics: %s buckets are not sorted", name))
+	}
+	h := &Histogram{s: newSeries[histogramValue](name, help, "histogram", labels), buckets: slices.Clone(buckets)}
+	r.register(name, h)
+	return h
+}
+
+// Observe records v in the series for labelValues.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	if h == nil {
+		return
+	}
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	hv := h.s.get(labelValues, func() *histogramValue {
+		return &histogramValue{counts: make([]uint64, len(h.buckets))}
+	})
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		hv.counts[i]++
+	}
+	hv.count++
+	hv.sum += v
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	if err := h.s.header(w); err != nil {
+		return err
+	}
+	for _, key := range h.s.sorted() {
+		hv, labelValues := h.s.values[key], h.s.keys[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += hv.counts[i]
+			if err := writeSample(w, h.s.name+"_bucket", h.s.labels, labelValues, "le", formatFloat(bound), float64(cumulative)); err != nil {
+				return err
+			}
+		}
+		if err := writeSample(w, h.s.name+"_bucket", h.s.labels, labelValues, "le", "+Inf", float64(hv.count)); err != nil {
+			return err
+		}
+		if err := writeSample(w, h.s.name+"_sum", h.s.labels, labelValues, "", "", hv.sum); err != nil {
+			return err
+		}
+		if err := writeSample(w, h.s.name+"_count", h.s.labels, labelValues, "", "", float64(hv.count)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// funcFamily reads its samples from a callback at scrape time, for values
+// kept elsewhere such as connection pool statistics.
+type funcFamily struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	collect func(observe func(v float64, labelValues ...string))
+}
+
+// NewGaugeFunc registers a gauge family whose samples collect reports on
+// each scrape, calling observe once per label set.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func(observe func(v float64, labelValues ...string))) {
+	r.register(name, &funcFamily{name: name, help: help, kind: "gauge", labels: labels, collect: collect})
+}
+
+// NewCounterFunc is NewGaugeFunc for values that only increase.
+func (r *Registry) NewCounterFunc(name, help string, labels []string, collect func(observe func(v float64, labelValues ...string))) {
+	r.register(name, &funcFamily{name: name, help: help, kind: "counter", labels: labels, collect: collect})
+}
+
+func (f *funcFamily) write(w io.Writer) error {
+	type sample struct {
+		labelValues []string
+		v           float64
+	}
+	var samples []sample
+	f.collect(func(v float64, labelValues ...string) {
+		if len(labelValues) != len(f.labels) {
+			panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+		}
+		samples = append(samples, sample{labelValues: slices.Clone(labelValues), v: v})
+	})
+	slices.SortFunc(samples, func(a, b sample) int { return slices.Compare(a.labelValues, b.labelValues) })
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind); err != nil {
+		return err
+	}
+	for _, s := range samples {
+		if err := writeSample(w, f.name, f.labels, s.labelValues, "", "", s.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSample writes one sample line, with an extra label when extraName is
+// set (le for histogram buckets).
+func writeSample(w io.Writer, name string, labels, labelValues []string, extraName, extraValue string, v float64) error {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		b.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=%q", label, escapeLabel(labelValues[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=%q", extraName, extraValue)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(v))
+	b.WriteByte('\n')
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeLabel prepares a label value for %q, which escapes backslashes,
+// quotes and newlines the way the format expects. Other control characters
+// would get Go escapes the format does not know, so they are replaced.
+func escapeLabel(v string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' && r != '\n' {
+			return '_'
+		}
+		return r
+	}, v)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_TextFormat(t *testing.T) {
+	reg := NewRegistry()
+	requests := reg.NewCounter("requests_total", "Requests served.", "path")
+	latency := reg.NewHistogram("latency_seconds", "Request latency.", []float64{0.1, 1}, "path")
+
+	requests.Inc("/b")
+	requests.Add(2, "/a")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.1, "/a") // bounds are inclusive
+	latency.Observe(3, "/a")
+
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+	assert.Equal(t, `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{path="/a"} 2
+requests_total{path="/b"} 1
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{path="/a",le="0.1"} 2
+latency_seconds_bucket{path="/a",le="1"} 2
+latency_seconds_bucket{path="/a",le="+Inf"} 3
+latency_seconds_sum{path="/a"} 3.15
+latency_seconds_count{path="/a"} 3
+`, out.String())
+}
+
+func TestRegistry_EscapesLabelValues(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounter("c", "Help with \\ and\nnewline.", "v")
+	c.Inc("a\"b\\c\nd\te")
+
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+	assert.Contains(t, out.String(), `# HELP c Help with \\ and\nnewline.`)
+	assert.Contains(t, out.String(), `c{v="a\"b\\c\nd_e"} 1`)
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounter("up", "Always 1.").Inc()
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "version=0.0.4")
+	assert.Contains(t, rec.Body.String(), "up 1\n")
+}
+
+func TestRegistry_FuncFamilies(t *testing.T) {
+	reg := NewRegistry()
+	conns := map[string]float64{"query": 3, "ingestion": 7}
+	reg.NewGaugeFunc("conns", "Open connections.", []string{"database"}, func(observe func(float64, ...string)) {
+		for db, n := range conns {
+			observe(n, db)
+		}
+	})
+	reg.NewCounterFunc("acquires_total", "Acquires.", nil, func(observe func(float64, ...string)) {
+		observe(42)
+	})
+
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+	assert.Equal(t, `# HELP conns Open connections.
+# TYPE conns gauge
+conns{database="ingestion"} 7
+conns{database="query"} 3
+# HELP acquires_total Acquires.
+# TYPE acquires_total counter
+acquires_total 42
+`, out.String())
+
+	// Values are read on every scrape
+	conns["query"] = 4
+	out.Reset()
+	require.NoError(t, reg.Write(&out))
+	assert.Contains(t, out.String(), `conns{database="query"} 4`)
+}
+
+func TestRegistry_Misuse(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounter("c", "", "a", "b")
+	assert.Panics(t, func() { reg.NewCounter("c", "") }, "duplicate name")
+	assert.Panics(t, func() { c.Inc("only-one") }, "wrong label count")
+	assert.Panics(t, func() { reg.NewHistogram("h", "", []float64{1, 0.5}) }, "unsorted buckets")
+}
+
+func TestNilMetricsDiscard(t *testing.T) {
+	var c *Counter
+	var h *Histogram
+	assert.NotPanics(t, func() {
+		c.Inc("x")
+		h.Observe(1, "x")
+	})
+}
@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MinCompressSize is the smallest response Compress compresses; below it the
+// encoding overhead outweighs the saving.
+const MinCompressSize = 1024
+
+// compressible lists the media types worth compressing; everything the
+// APIs serve is JSON, and images or archives are already compressed.
+var compressible = map[string]bool{
+	"application/json":     true,
+	"application/x-ndjson": true,
+	"text/plain":           true,
+	"text/csv":             true,
+}
+
+// Compress compresses the responses of next with gzip or deflate, as
+// negotiated through Accept-Encoding, at the given compress/flate level
+// (1 fastest to 9 smallest). Level 0 returns next unchanged. Responses under
+// MinCompressSize, of other media types, or already encoded are sent as is.
+func Compress(next http.Handler, level int) http.Handler {
+	if level == 0 {
+		return next
+	}
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			zw, _ := gzip.NewWriterLevel(io.Discard, level)
+			return zw
+		}},
+		"deflate": {New: func() any {
+			zw, _ := flate.NewWriter(io.Discard, level)
+			return zw
+		}},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Not deferred: after a panic, nothing buffered is sent, so Wrap can
+		// still answer with a 500
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, pool: pools[encoding]}
+		next.ServeHTTP(cw, r)
+		cw.close()
+	})
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header,
+// preferring gzip at equal weight, or "" for identity.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if name == "*" {
+			name = "gzip"
+		}
+		if (name != "gzip" && name != "deflate") || q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "gzip") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// resetWriter is satisfied by *gzip.Writer and *flate.Writer.
+type resetWriter interface {
+	io.WriteCloser
+	Reset(io.Writer)
+	Flush() error
+}
+
+// compressWriter buffers the start of a response until it knows whether to
+// compress it: once MinCompressSize bytes are written, on Flush, or when the
+// handler returns. Unwrap lets http.ResponseController reach the underlying
+// writer (write deadlines).
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	pool     *sync.Pool
+
+	status  int
+	buf     []byte
+	decided bool
+	zw      resetWriter // nil when sending uncompressed
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < MinCompressSize {
+			return len(b), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.zw != nil {
+		return w.zw.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide sends the headers, compressed if the buffered response qualifies,
+// then the buffered bytes.
+func (w *compressWriter) decide() error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	h := w.Header()
+	if len(w.buf) >= MinCompressSize && h.Get("Content-Encoding") == "" && w.compressibleType() &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		w.zw = w.pool.Get().(resetWriter)
+		w.zw.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.zw != nil {
+		_, err = w.zw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressWriter) compressibleType() bool {
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	return err == nil && compressible[mediaType]
+}
+
+// Flush sends what has been written so far, compressed if the response
+// already qualifies.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(); err != nil {
+			return
+		}
+	}
+	if w.zw != nil {
+		_ = w.zw.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// close completes the response: it sends a response still buffered and ends
+// the compressed stream. A handler that wrote nothing gets nothing sent, so
+// an outer middleware can still write its own response.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.status == 0 {
+			return
+		}
+		_ = w.decide()
+	}
+	if w.zw != nil {
+		_ = w.zw.Close()
+		w.zw.Reset(io.Discard)
+		w.pool.Put(w.zw)
+		w.zw = nil
+	}
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonHandler serves body as JSON with an explicit Content-Length.
+func jsonHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "0") // stale once compressed
+		_, _ = io.WriteString(w, body)
+	})
+}
+
+func compressRequest(t *testing.T, h http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/projections", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"deflate, gzip", "gzip"},
+		{"GZIP;q=0.5, deflate", "deflate"},
+		{"gzip;q=0, deflate;q=0.1", "deflate"},
+		{"br, *", "gzip"},
+		{"gzip;q=0", ""},
+		{"gzip;q=bad", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, negotiateEncoding(tt.header), "Accept-Encoding %q", tt.header)
+	}
+}
+
+func TestCompress_Gzip(t *testing.T) {
+	body := `{"items":[` + strings.Repeat(`{"state":"ok"},`, 200) + `{}]}`
+	w := compressRequest(t, Compress(jsonHandler(body), 6), "gzip, deflate")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Empty(t, w.Header().Get("Content-Length"), "the handler's length no longer applies")
+	assert.Less(t, w.Body.Len(), len(body))
+
+	zr, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	got, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(got))
+}
+
+func TestCompress_Deflate(t *testing.T) {
+	body := strings.Repeat("a", 4*MinCompressSize)
+	h := Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		// Written in pieces straddling MinCompressSize
+		for i := 0; i < len(body); i += 300 {
+			_, _ = io.WriteString(w, body[i:min(i+300, len(body))])
+		}
+	}), 1)
+	w := compressRequest(t, h, "deflate")
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+	got, err := io.ReadAll(flate.NewReader(w.Body))
+	require.NoError(t, err)
+	assert.Equal(t, body, string(got))
+}
+
+func TestCompress_LeavesResponsesAlone(t *testing.T) {
+	large := strings.Repeat("x", 2*MinCompressSize)
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		acceptEncoding string
+		body           string
+	}{
+		{"client without gzip", jsonHandler(large), "", large},
+		{"small response", jsonHandler(`{"status":"ok"}`), "gzip", `{"status":"ok"}`},
+		{"not compressible", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = io.WriteString(w, large)
+		}), "gzip", large},
+		{"already encoded", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = io.WriteString(w, large)
+		}), "gzip", large},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := compressRequest(t, Compress(tt.handler, 6), tt.acceptEncoding)
+			assert.NotEqual(t, "gzip", w.Header().Get("Content-Encoding"))
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
+
+func TestCompress_LevelZeroDisables(t *testing.T) {
+	h := jsonHandler(`{}`)
+	w := compressRequest(t, Compress(h, 0), "gzip")
+	assert.Empty(t, w.Header().Get("Vary"))
+}
+
+func TestCompress_Flush(t *testing.T) {
+	first := `{"event":1}` + "\n"
+	w := httptest.NewRecorder()
+	var afterFlush string
+	h := Compress(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = io.WriteString(rw, first)
+		_ = http.NewResponseController(rw).Flush()
+		afterFlush = w.Body.String()
+		_, _ = io.WriteString(rw, strings.Repeat(first, 100))
+	}), 6)
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	h.ServeHTTP(w, r)
+
+	// A flush before MinCompressSize sends the response uncompressed, so
+	// streamed lines reach the client without waiting for more
+	assert.Equal(t, first, afterFlush)
+	assert.True(t, w.Flushed)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, first+strings.Repeat(first, 100), w.Body.String())
+}
+
+func TestCompress_PanicStillAnswers500(t *testing.T) {
+	var buf bytes.Buffer
+	h := Wrap(Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"partial":`)
+		panic("boom")
+	}), 6), newTestLogger(&buf))
+	w := compressRequest(t, h, "gzip")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.JSONEq(t, `{"error":"internal server error"}`, w.Body.String())
+}
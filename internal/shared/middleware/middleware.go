@@ -0,0 +1,142 @@
+// Package middleware provides the HTTP middleware shared by the platform's
+// API servers: request IDs, access logging, panic recovery and response
+// compression.
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// RequestIDHeader carries the request ID. An incoming value is kept so a
+// request can be followed across services; otherwise one is generated.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds accepted incoming request IDs; longer or
+// non-printable values are replaced rather than logged.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID returns the request ID stored in ctx by Wrap, or "" if none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Wrap applies the standard middleware to next: it assigns a request ID,
+// recovers panics into a 500 response, and writes an access log line with
+// status, response size and latency for every request.
+func Wrap(next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := clock.Now()
+
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.Must(uuid.NewV7()).String()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				logger.Error("panic in HTTP handler",
+					"panic", fmt.Sprint(v),
+					"method", r.Method,
+					"path", r.URL.Path,
+					"request_id", id,
+					"stack", string(debug.Stack()),
+				)
+				if rw.status != 0 {
+					// Part of the response is already sent; abort the
+					// connection so the client cannot mistake it for a
+					// complete one.
+					logAccess(logger, r, rw, id, start)
+					panic(http.ErrAbortHandler)
+				}
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusInternalServerError)
+				_ = json.NewEncoder(rw).Encode(map[string]string{"error": "internal server error"})
+			}
+			logAccess(logger, r, rw, id, start)
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// logAccess writes the access log line. Health and readiness checks are
+// logged at DEBUG so load balancer probes do not drown out real traffic.
+func logAccess(logger *slog.Logger, r *http.Request, rw *responseWriter, id string, start time.Time) {
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK // handler wrote nothing
+	}
+	level := slog.LevelInfo
+	if r.URL.Path == "/health" || r.URL.Path == "/readyz" {
+		level = slog.LevelDebug
+	}
+	logger.Log(r.Context(), level, "http request",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", status,
+		"bytes", rw.bytes,
+		"duration_ms", float64(clock.Now().Sub(start).Microseconds())/1000,
+		"request_id", id,
+		"remote_addr", r.RemoteAddr,
+	)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// responseWriter records the status code and body size. Unwrap lets
+// http.ResponseController reach the underlying writer (write deadlines,
+// flushing).
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// logLines decodes the JSON log lines written to buf.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestWrap_AccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	var seenID string
+	h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenID = RequestID(r.Context())
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}), newTestLogger(&buf))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/events", nil))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	require.NotEmpty(t, seenID)
+	assert.Equal(t, seenID, w.Header().Get(RequestIDHeader))
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "INFO", lines[0]["level"])
+	assert.Equal(t, "POST", lines[0]["method"])
+	assert.Equal(t, "/api/v1/events", lines[0]["path"])
+	assert.Equal(t, float64(http.StatusCreated), lines[0]["status"])
+	assert.Equal(t, float64(5), lines[0]["bytes"])
+	assert.Equal(t, seenID, lines[0]["request_id"])
+	assert.Contains(t, lines[0], "duration_ms")
+}
+
+func TestWrap_RequestIDFromHeader(t *testing.T) {
+	var buf bytes.Buffer
+	h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), newTestLogger(&buf))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, "req-123", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, float64(http.StatusOK), logLines(t, &buf)[0]["status"])
+
+	// Unprintable or oversized IDs are replaced
+	for _, bad := range []string{"has space", strings.Repeat("x", maxRequestIDLength+1)} {
+		req.Header.Set(RequestIDHeader, bad)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.NotEqual(t, bad, w.Header().Get(RequestIDHeader))
+		assert.NotEmpty(t, w.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestWrap_HealthLoggedAtDebug(t *testing.T) {
+	for _, path := range []string{"/health", "/readyz"} {
+		var buf bytes.Buffer
+		h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), newTestLogger(&buf))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, "DEBUG", logLines(t, &buf)[0]["level"], path)
+	}
+}
+
+func TestWrap_RecoversPanic(t *testing.T) {
+	var buf bytes.Buffer
+	h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), newTestLogger(&buf))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/projections/x", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error": "internal server error"}`, w.Body.String())
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "ERROR", lines[0]["level"])
+	assert.Equal(t, "boom", lines[0]["panic"])
+	assert.Contains(t, lines[0]["stack"], "middleware")
+	assert.Equal(t, w.Header().Get(RequestIDHeader), lines[0]["request_id"])
+	assert.Equal(t, float64(http.StatusInternalServerError), lines[1]["status"])
+}
+
+func TestWrap_PanicAfterWriteAborts(t *testing.T) {
+	var buf bytes.Buffer
+	h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	}), newTestLogger(&buf))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/events", nil))
+	})
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "boom", lines[0]["panic"])
+	assert.Equal(t, float64(7), lines[1]["bytes"])
+}
+
+func TestWrap_Unwrap(t *testing.T) {
+	var buf bytes.Buffer
+	h := Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, http.NewResponseController(w).Flush())
+	}), newTestLogger(&buf))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/events", nil))
+	assert.True(t, w.Flushed)
+}
@@ -0,0 +1,132 @@
+package pii
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/secrets"
+)
+
+// defaultKMSTimeout bounds each AWS KMS call.
+const defaultKMSTimeout = 10 * time.Second
+
+// maxKMSResponseBytes caps AWS KMS response bodies.
+const maxKMSResponseBytes = 64 << 10
+
+// AWSKMSConfig configures AWSKMS.
+type AWSKMSConfig struct {
+	Region string
+	KeyID  string // key ID, ARN or alias of the key encryption key
+	// Endpoint overrides https://kms.<region>.amazonaws.com, for VPC
+	// endpoints and local emulators.
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // for temporary credentials; optional
+	Timeout         time.Duration
+}
+
+// AWSKMS wraps data keys with an AWS KMS key (GenerateDataKey and Decrypt),
+// using static credentials.
+type AWSKMS struct {
+	config AWSKMSConfig
+	client *http.Client
+}
+
+// NewAWSKMS creates an AWSKMS.
+func NewAWSKMS(config AWSKMSConfig) (*AWSKMS, error) {
+	if config.Region == "" {
+		return nil, fmt.Errorf("an AWS region is required")
+	}
+	if config.KeyID == "" {
+		return nil, fmt.Errorf("a KMS key ID is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials are required (AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://kms." + config.Region + ".amazonaws.com"
+	}
+	if u, err := url.Parse(config.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid AWS KMS endpoint %q: expected an http(s) URL", config.Endpoint)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultKMSTimeout
+	}
+	return &AWSKMS{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// GenerateDataKey implements KMS.
+func (k *AWSKMS) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	var resp struct {
+		CiphertextBlob []byte `json:"CiphertextBlob"` // base64 in JSON
+		Plaintext      []byte `json:"Plaintext"`
+	}
+	if err := k.call(ctx, "GenerateDataKey", map[string]string{"KeyId": k.config.KeyID, "KeySpec": "AES_256"}, &resp); err != nil {
+		return nil, nil, err
+	}
+	return resp.Plaintext, resp.CiphertextBlob, nil
+}
+
+// Decrypt implements KMS.
+func (k *AWSKMS) Decrypt(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	req := map[string]any{"KeyId": k.config.KeyID, "CiphertextBlob": wrapped}
+	if err := k.call(ctx, "Decrypt", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
+
+// call invokes a KMS JSON API operation.
+func (k *AWSKMS) call(ctx context.Context, operation string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build KMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+operation)
+	if k.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", k.config.SessionToken)
+	}
+	secrets.SignV4(req, body, k.config.AccessKeyID, k.config.SecretAccessKey, k.config.Region, "kms", clock.Now())
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach KMS: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxKMSResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read KMS response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &failure)
+		return fmt.Errorf("KMS %s returned status %d: %s: %s", operation, resp.StatusCode, failure.Type, failure.Message)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode KMS response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package pii
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+func TestAWSKMS(t *testing.T) {
+	clock.Set(clock.FixedClock{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)})
+	t.Cleanup(clock.Reset)
+
+	plaintext := strings.Repeat("k", 32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20261016/eu-west-1/kms/aws4_request, "), auth)
+
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]string
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, "alias/platform-pii", req["KeyId"])
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GenerateDataKey":
+			assert.Equal(t, "AES_256", req["KeySpec"])
+			_ = json.NewEncoder(w).Encode(map[string][]byte{"CiphertextBlob": []byte("wrapped"), "Plaintext": []byte(plaintext)})
+		case "TrentService.Decrypt":
+			if req["CiphertextBlob"] != base64.StdEncoding.EncodeToString([]byte("wrapped")) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"__type":"InvalidCiphertextException","message":"bad blob"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string][]byte{"Plaintext": []byte(plaintext)})
+		default:
+			t.Errorf("unexpected target %s", r.Header.Get("X-Amz-Target"))
+		}
+	}))
+	defer srv.Close()
+
+	kms, err := NewAWSKMS(AWSKMSConfig{
+		Region: "eu-west-1", KeyID: "alias/platform-pii", Endpoint: srv.URL,
+		AccessKeyID: "AKID", SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	key, wrapped, err := kms.GenerateDataKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, string(key))
+	assert.Equal(t, "wrapped", string(wrapped))
+
+	key, err = kms.Decrypt(ctx, wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, string(key))
+
+	_, err = kms.Decrypt(ctx, []byte("other"))
+	assert.ErrorContains(t, err, "status 400: InvalidCiphertextException: bad blob")
+}
+
+func TestNewAWSKMS_Validation(t *testing.T) {
+	_, err := NewAWSKMS(AWSKMSConfig{KeyID: "k", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	assert.ErrorContains(t, err, "an AWS region is required")
+
+	_, err = NewAWSKMS(AWSKMSConfig{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	assert.ErrorContains(t, err, "a KMS key ID is required")
+
+	_, err = NewAWSKMS(AWSKMSConfig{Region: "eu-west-1", KeyID: "k"})
+	assert.ErrorContains(t, err, "AWS credentials are required")
+
+	kms, err := NewAWSKMS(AWSKMSConfig{Region: "eu-west-1", KeyID: "k", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://kms.eu-west-1.amazonaws.com", kms.config.Endpoint)
+}
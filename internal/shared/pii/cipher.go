@@ -0,0 +1,226 @@
+package pii
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// encryptedPrefix starts every encrypted value:
+// "enc:v1:<base64 wrapped data key>:<base64 nonce+ciphertext>".
+const encryptedPrefix = "enc:v1:"
+
+// maxCachedKeys bounds the unwrapped data keys kept for decryption.
+const maxCachedKeys = 1024
+
+// KMS wraps data keys with a key encryption key it manages.
+type KMS interface {
+	// GenerateDataKey returns a new 256-bit data key and its wrapped form.
+	GenerateDataKey(ctx context.Context) (plaintext, wrapped []byte, err error)
+	// Decrypt unwraps a data key.
+	Decrypt(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// IsEncrypted reports whether s is a value encrypted by Cipher.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, encryptedPrefix)
+}
+
+// Cipher encrypts values with AES-256-GCM under data keys from a KMS. A data
+// key is reused for keyTTL to keep KMS calls off the ingest path; each value
+// carries its wrapped data key, so decryption needs nothing but the KMS.
+// Safe for concurrent use.
+type Cipher struct {
+	kms    KMS
+	keyTTL time.Duration
+
+	mu        sync.Mutex
+	current   *dataKey
+	unwrapped map[string]cipher.AEAD // by wrapped key
+}
+
+type dataKey struct {
+	aead    cipher.AEAD
+	wrapped string // base64
+	created time.Time
+}
+
+// NewCipher creates a Cipher. keyTTL is how long a data key is used for
+// encryption before a new one is generated.
+func NewCipher(kms KMS, keyTTL time.Duration) *Cipher {
+	return &Cipher{
+		kms:       kms,
+		keyTTL:    keyTTL,
+		unwrapped: make(map[string]cipher.AEAD),
+	}
+}
+
+// Encrypt encrypts plaintext into a self-describing string value.
+func (c *Cipher) Encrypt(ctx context.Context, plaintext []byte) (string, error) {
+	key, err := c.currentKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := key.aead.Seal(nonce, nonce, plaintext, nil)
+	return encryptedPrefix + key.wrapped + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt.
+func (c *Cipher) Decrypt(ctx context.Context, value string) ([]byte, error) {
+	wrapped, sealedB64, ok := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !IsEncrypted(value) || !ok {
+		return nil, fmt.Errorf("not an encrypted value")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(sealedB64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext encoding: %w", err)
+	}
+	aead, err := c.unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return plaintext, nil
+}
+
+// DecryptJSON returns doc with every encrypted string replaced by the value
+// it encrypts. Documents without encrypted values are returned unchanged.
+func (c *Cipher) DecryptJSON(ctx context.Context, doc []byte) ([]byte, error) {
+	if !bytes.Contains(doc, []byte(encryptedPrefix)) {
+		return doc, nil
+	}
+	v, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+	if v, err = c.decryptValue(ctx, v); err != nil {
+		return nil, err
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	return out, nil
+}
+
+func (c *Cipher) decryptValue(ctx context.Context, v any) (any, error) {
+	switch node := v.(type) {
+	case string:
+		if !IsEncrypted(node) {
+			return node, nil
+		}
+		plaintext, err := c.Decrypt(ctx, node)
+		if err != nil {
+			return nil, err
+		}
+		return decode(plaintext)
+	case map[string]any:
+		for key, child := range node {
+			replaced, err := c.decryptValue(ctx, child)
+			if err != nil {
+				return nil, err
+			}
+			node[key] = replaced
+		}
+	case []any:
+		for i, child := range node {
+			replaced, err := c.decryptValue(ctx, child)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = replaced
+		}
+	}
+	return v, nil
+}
+
+// currentKey returns the data key for encryption, generating a new one
+// when the current key is older than keyTTL.
+func (c *Cipher) currentKey(ctx context.Context) (*dataKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.current != nil && clock.Now().Sub(c.current.created) < c.keyTTL {
+		return c.current, nil
+	}
+	plaintext, wrapped, err := c.kms.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	aead, err := newAEAD(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	c.current = &dataKey{
+		aead:    aead,
+		wrapped: base64.StdEncoding.EncodeToString(wrapped),
+		created: clock.Now(),
+	}
+	c.cacheLocked(c.current.wrapped, aead)
+	return c.current, nil
+}
+
+// unwrap returns the AEAD for a wrapped data key, asking the KMS on a miss.
+func (c *Cipher) unwrap(ctx context.Context, wrappedB64 string) (cipher.AEAD, error) {
+	c.mu.Lock()
+	aead, ok := c.unwrapped[wrappedB64]
+	c.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(wrappedB64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key encoding: %w", err)
+	}
+	plaintext, err := c.kms.Decrypt(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	if aead, err = newAEAD(plaintext); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.cacheLocked(wrappedB64, aead)
+	c.mu.Unlock()
+	return aead, nil
+}
+
+func (c *Cipher) cacheLocked(wrapped string, aead cipher.AEAD) {
+	if len(c.unwrapped) >= maxCachedKeys {
+		clear(c.unwrapped)
+	}
+	c.unwrapped[wrapped] = aead
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("data key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
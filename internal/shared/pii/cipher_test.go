@@ -0,0 +1,117 @@
+package pii
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// countingKMS counts calls to a LocalKMS.
+type countingKMS struct {
+	*LocalKMS
+	generated, decrypted int
+}
+
+func (k *countingKMS) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	k.generated++
+	return k.LocalKMS.GenerateDataKey(ctx)
+}
+
+func (k *countingKMS) Decrypt(ctx context.Context, wrapped []byte) ([]byte, error) {
+	k.decrypted++
+	return k.LocalKMS.Decrypt(ctx, wrapped)
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c := NewCipher(testKMS(t), time.Hour)
+	ctx := context.Background()
+
+	value, err := c.Encrypt(ctx, []byte(`"secret"`))
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(value))
+	assert.NotContains(t, value, "secret")
+
+	plaintext, err := c.Decrypt(ctx, value)
+	require.NoError(t, err)
+	assert.Equal(t, `"secret"`, string(plaintext))
+
+	// Another instance with the same KMS (e.g. the query service) decrypts too
+	other := NewCipher(testKMS(t), time.Hour)
+	plaintext, err = other.Decrypt(ctx, value)
+	require.NoError(t, err)
+	assert.Equal(t, `"secret"`, string(plaintext))
+}
+
+func TestCipher_Tampered(t *testing.T) {
+	c := NewCipher(testKMS(t), time.Hour)
+	ctx := context.Background()
+	value, err := c.Encrypt(ctx, []byte(`1`))
+	require.NoError(t, err)
+
+	// Flip a character of the ciphertext
+	i := len(value) - 4
+	flipped := value[:i] + string(rune(value[i]^1)) + value[i+1:]
+	_, err = c.Decrypt(ctx, flipped)
+	assert.Error(t, err)
+
+	_, err = c.Decrypt(ctx, "plain")
+	assert.ErrorContains(t, err, "not an encrypted value")
+
+	wrongKey, err := NewLocalKMS([]byte(strings.Repeat("x", 32)))
+	require.NoError(t, err)
+	_, err = NewCipher(wrongKey, time.Hour).Decrypt(ctx, value)
+	assert.ErrorContains(t, err, "failed to unwrap data key")
+}
+
+func TestCipher_DataKeyReuseAndRotation(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	kms := &countingKMS{LocalKMS: testKMS(t)}
+	c := NewCipher(kms, time.Hour)
+	ctx := context.Background()
+
+	first, err := c.Encrypt(ctx, []byte(`1`))
+	require.NoError(t, err)
+	_, err = c.Encrypt(ctx, []byte(`2`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, kms.generated)
+
+	clock.Set(clock.FixedClock{Time: now.Add(time.Hour)})
+	second, err := c.Encrypt(ctx, []byte(`3`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, kms.generated)
+	assert.NotEqual(t, strings.Split(first, ":")[2], strings.Split(second, ":")[2], "new wrapped data key")
+
+	// Keys this cipher generated are cached, so decrypting needs no KMS call
+	_, err = c.Decrypt(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, 0, kms.decrypted)
+
+	reader := NewCipher(kms, time.Hour)
+	for range 3 {
+		_, err = reader.Decrypt(ctx, first)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, kms.decrypted)
+}
+
+func TestCipher_DecryptJSON_NoEncryptedValues(t *testing.T) {
+	c := NewCipher(testKMS(t), time.Hour)
+	doc := []byte(`{"b": 1, "a": 2}`)
+	out, err := c.DecryptJSON(context.Background(), doc)
+	require.NoError(t, err)
+	assert.Equal(t, doc, out, "returned unchanged, not re-encoded")
+}
+
+func TestNewLocalKMS_KeySize(t *testing.T) {
+	_, err := NewLocalKMS([]byte("short"))
+	assert.ErrorContains(t, err, "must be 32 bytes")
+}
@@ -0,0 +1,101 @@
+package pii
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// apiKeyHeader carries the caller's API key (same header as
+// ingestion.APIKeyHeader).
+const apiKeyHeader = "X-API-Key"
+
+// Decryptor decrypts encrypted values in JSON responses for callers
+// authorized to read personal data.
+type Decryptor struct {
+	cipher *Cipher
+	keys   map[string]bool // API keys allowed to read decrypted values
+	logger *slog.Logger
+}
+
+// NewDecryptor creates a Decryptor that decrypts responses for requests
+// carrying one of apiKeys in X-API-Key.
+func NewDecryptor(cipher *Cipher, apiKeys []string, logger *slog.Logger) *Decryptor {
+	keys := make(map[string]bool, len(apiKeys))
+	for _, key := range apiKeys {
+		keys[key] = true
+	}
+	return &Decryptor{
+		cipher: cipher,
+		keys:   keys,
+		logger: logger.With("component", "pii-decryptor"),
+	}
+}
+
+// Wrap decrypts the JSON responses of next for authorized requests; other
+// requests get the stored (encrypted) values. Authorized responses are
+// buffered. If decryption fails the response is sent still encrypted and the
+// failure is logged, so a KMS outage does not take reads down.
+func (d *Decryptor) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !d.Authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+
+		body := d.Decrypt(r, bw.body.Bytes())
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(bw.status)
+		_, _ = w.Write(body)
+	})
+}
+
+// Authorized reports whether r carries an API key allowed to read decrypted
+// values.
+func (d *Decryptor) Authorized(r *http.Request) bool {
+	key := r.Header.Get(apiKeyHeader)
+	return key != "" && d.keys[key]
+}
+
+// Decrypt returns the JSON document doc, part of the response to r, with its
+// encrypted values decrypted. Handlers that stream their responses, which Wrap
+// would buffer whole, call it on each document they write instead, after
+// checking Authorized. As with Wrap, a failure is logged and doc is returned
+// still encrypted.
+func (d *Decryptor) Decrypt(r *http.Request, doc []byte) []byte {
+	decrypted, err := d.cipher.DecryptJSON(r.Context(), doc)
+	if err != nil {
+		d.logger.Error("failed to decrypt response", "path", r.URL.Path, "error", err)
+		return doc
+	}
+	return decrypted
+}
+
+// bufferedWriter holds the response until the handler returns. Unwrap lets
+// http.ResponseController reach the underlying writer (write deadlines).
+type bufferedWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,78 @@
+package pii
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type brokenKMS struct{ *LocalKMS }
+
+func (brokenKMS) Decrypt(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return nil, errors.New("KMS unavailable")
+}
+
+func TestDecryptor(t *testing.T) {
+	ctx := context.Background()
+	writer := NewCipher(testKMS(t), time.Hour)
+	value, err := writer.Encrypt(ctx, []byte(`"a@example.com"`))
+	require.NoError(t, err)
+	body := `{"events":[{"payload":{"email":"` + value + `"}}]}`
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(body))
+	})
+	serve := func(d *Decryptor, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		d.Wrap(handler).ServeHTTP(w, req)
+		return w
+	}
+
+	// A separate cipher over the same KMS, as in the query service
+	d := NewDecryptor(NewCipher(testKMS(t), time.Hour), []string{"auditor-key"}, slog.Default())
+
+	w := serve(d, "auditor-key")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"events":[{"payload":{"email":"a@example.com"}}]}`, w.Body.String())
+
+	for _, key := range []string{"", "other-key"} {
+		w = serve(d, key)
+		assert.Equal(t, body, w.Body.String(), "unauthorized callers get stored values")
+	}
+
+	// A KMS failure serves the stored values rather than failing the read
+	broken := NewDecryptor(NewCipher(brokenKMS{testKMS(t)}, time.Hour), []string{"auditor-key"}, slog.Default())
+	w = serve(broken, "auditor-key")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+}
+
+func TestDecryptor_Decrypt(t *testing.T) {
+	ctx := context.Background()
+	value, err := NewCipher(testKMS(t), time.Hour).Encrypt(ctx, []byte(`"a@example.com"`))
+	require.NoError(t, err)
+	line := []byte(`{"payload":{"email":"` + value + `"}}`)
+
+	d := NewDecryptor(NewCipher(testKMS(t), time.Hour), []string{"auditor-key"}, slog.Default())
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events/export", nil)
+	assert.False(t, d.Authorized(req))
+	req.Header.Set("X-API-Key", "auditor-key")
+	assert.True(t, d.Authorized(req))
+	assert.JSONEq(t, `{"payload":{"email":"a@example.com"}}`, string(d.Decrypt(req, line)))
+
+	broken := NewDecryptor(NewCipher(brokenKMS{testKMS(t)}, time.Hour), []string{"auditor-key"}, slog.Default())
+	assert.Equal(t, line, broken.Decrypt(req, line), "a KMS failure leaves the document encrypted")
+}
@@ -0,0 +1,57 @@
+package pii
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+// LocalKMS wraps data keys with a key encryption key held in process
+// memory. For development and tests; production should use a managed KMS so
+// the key encryption key never leaves it.
+type LocalKMS struct {
+	kek cipher.AEAD
+}
+
+// NewLocalKMS creates a LocalKMS from a 32-byte key encryption key.
+func NewLocalKMS(key []byte) (*LocalKMS, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("local KMS key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	kek, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return &LocalKMS{kek: kek}, nil
+}
+
+// GenerateDataKey implements KMS.
+func (k *LocalKMS) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	plaintext := make([]byte, 32)
+	nonce := make([]byte, k.kek.NonceSize())
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return plaintext, k.kek.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt implements KMS.
+func (k *LocalKMS) Decrypt(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < k.kek.NonceSize() {
+		return nil, fmt.Errorf("wrapped data key too short")
+	}
+	plaintext, err := k.kek.Open(nil, wrapped[:k.kek.NonceSize()], wrapped[k.kek.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,205 @@
+// Package pii protects personal data in event payloads before they are
+// persisted: configured JSON paths are hashed, redacted, or encrypted with
+// envelope encryption (data keys wrapped by a KMS-managed key). Encrypted
+// values are self-describing strings, so authorized readers can decrypt any
+// document without knowing which rules produced it.
+package pii
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Rule actions.
+const (
+	ActionHash    = "hash"    // HMAC-SHA256 (SHA-256 without a hash key); deterministic, so values stay joinable
+	ActionRedact  = "redact"  // replaced with Redacted
+	ActionEncrypt = "encrypt" // replaced with an encrypted string (see Cipher)
+)
+
+// Redacted replaces redacted values.
+const Redacted = "[redacted]"
+
+// Rule protects the value at Path in payloads of EventType.
+type Rule struct {
+	EventType string
+	// Path is a dot-separated path into the payload, such as "owner.email".
+	// A "*" segment matches every element of an array or member of an object.
+	Path   string
+	Action string
+}
+
+// ParseRules parses a comma-separated list of rules, each
+// "event_type:path=action", such as
+// "user.signup:email=hash,user.signup:address.*=encrypt". Empty input
+// yields no rules.
+func ParseRules(s string) ([]Rule, error) {
+	var rules []Rule
+	if s == "" {
+		return rules, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		target, action, ok := strings.Cut(entry, "=")
+		eventType, path, ok2 := strings.Cut(target, ":")
+		if !ok || !ok2 || eventType == "" || path == "" {
+			return nil, fmt.Errorf("invalid PII rule %q: expected event_type:path=action", entry)
+		}
+		if action != ActionHash && action != ActionRedact && action != ActionEncrypt {
+			return nil, fmt.Errorf("invalid PII rule %q: action must be hash, redact or encrypt", entry)
+		}
+		if slices.Contains(strings.Split(path, "."), "") {
+			return nil, fmt.Errorf("invalid PII rule %q: empty path segment", entry)
+		}
+		rules = append(rules, Rule{EventType: eventType, Path: path, Action: action})
+	}
+	return rules, nil
+}
+
+// rule is a Rule with its path split into segments.
+type rule struct {
+	path   []string
+	action string
+}
+
+// Transformer applies rules to payloads. Safe for concurrent use.
+type Transformer struct {
+	rules   map[string][]rule // by event type
+	hashKey []byte
+	cipher  *Cipher // nil when no KMS is configured
+}
+
+// NewTransformer creates a Transformer. hashKey keys ActionHash (nil falls
+// back to plain SHA-256, which is open to dictionary attacks on low-entropy
+// values such as emails). cipher is required if any rule encrypts.
+func NewTransformer(rules []Rule, hashKey []byte, cipher *Cipher) (*Transformer, error) {
+	t := &Transformer{
+		rules:   make(map[string][]rule),
+		hashKey: hashKey,
+		cipher:  cipher,
+	}
+	for _, r := range rules {
+		if r.Action == ActionEncrypt && cipher == nil {
+			return nil, fmt.Errorf("PII rule %s:%s encrypts, but no KMS is configured", r.EventType, r.Path)
+		}
+		t.rules[r.EventType] = append(t.rules[r.EventType], rule{path: strings.Split(r.Path, "."), action: r.Action})
+	}
+	return t, nil
+}
+
+// Transform returns payload with the rules for eventType applied. Payloads
+// of event types without rules are returned unchanged; others are
+// re-encoded, which sorts object keys.
+func (t *Transformer) Transform(ctx context.Context, eventType string, payload json.RawMessage) (json.RawMessage, error) {
+	rules := t.rules[eventType]
+	if len(rules) == 0 {
+		return payload, nil
+	}
+
+	doc, err := decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	for _, r := range rules {
+		apply := func(v any) (any, error) { return t.protect(ctx, r.action, v) }
+		if doc, err = walk(doc, r.path, apply); err != nil {
+			return nil, fmt.Errorf("failed to %s %s: %w", r.action, strings.Join(r.path, "."), err)
+		}
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	return out, nil
+}
+
+func (t *Transformer) protect(ctx context.Context, action string, v any) (any, error) {
+	switch action {
+	case ActionRedact:
+		return Redacted, nil
+	case ActionHash:
+		return t.hash(v)
+	default:
+		if s, ok := v.(string); ok && IsEncrypted(s) {
+			return s, nil // already protected (e.g. a replayed event)
+		}
+		plaintext, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return t.cipher.Encrypt(ctx, plaintext)
+	}
+}
+
+// hash hashes a string's text, or the JSON encoding of any other value.
+func (t *Transformer) hash(v any) (string, error) {
+	var data []byte
+	if s, ok := v.(string); ok {
+		data = []byte(s)
+	} else {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		data = encoded
+	}
+	if t.hashKey == nil {
+		sum := sha256.Sum256(data)
+		return "sha256:" + hex.EncodeToString(sum[:]), nil
+	}
+	mac := hmac.New(sha256.New, t.hashKey)
+	mac.Write(data)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// walk applies fn to the values at path in v and returns v with them
+// replaced. Missing paths are left alone.
+func walk(v any, path []string, fn func(any) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return fn(v)
+	}
+	seg, rest := path[0], path[1:]
+	switch node := v.(type) {
+	case map[string]any:
+		for key, child := range node {
+			if seg != "*" && key != seg {
+				continue
+			}
+			replaced, err := walk(child, rest, fn)
+			if err != nil {
+				return nil, err
+			}
+			node[key] = replaced
+		}
+	case []any:
+		if seg != "*" {
+			return v, nil
+		}
+		for i, child := range node {
+			replaced, err := walk(child, rest, fn)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = replaced
+		}
+	}
+	return v, nil
+}
+
+// decode parses JSON keeping numbers exact.
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
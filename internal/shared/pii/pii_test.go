@@ -0,0 +1,99 @@
+package pii
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKMS(t *testing.T) *LocalKMS {
+	t.Helper()
+	kms, err := NewLocalKMS(bytes.Repeat([]byte{7}, 32))
+	require.NoError(t, err)
+	return kms
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("user.signup:email=hash, user.signup:address.*=encrypt,user.login:ip=redact")
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{EventType: "user.signup", Path: "email", Action: ActionHash},
+		{EventType: "user.signup", Path: "address.*", Action: ActionEncrypt},
+		{EventType: "user.login", Path: "ip", Action: ActionRedact},
+	}, rules)
+
+	rules, err = ParseRules("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for _, bad := range []string{"email=hash", "user.signup:email", "user.signup:email=shred", "user.signup:a..b=hash", ":email=hash"} {
+		_, err := ParseRules(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestTransformer(t *testing.T) {
+	rules, err := ParseRules("user.signup:email=hash,user.signup:name=redact,user.signup:address=encrypt," +
+		"user.signup:contacts.*.phone=redact,user.signup:missing.path=redact")
+	require.NoError(t, err)
+	cipher := NewCipher(testKMS(t), 0)
+	tr, err := NewTransformer(rules, []byte("hash-key"), cipher)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	payload := json.RawMessage(`{"email":"a@example.com","name":"Ada","address":{"city":"London","zip":12345},` +
+		`"contacts":[{"phone":"555-1234","kind":"home"}],"plan":"pro"}`)
+	out, err := tr.Transform(ctx, "user.signup", payload)
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(out, &doc))
+	assert.Regexp(t, `^hmac-sha256:[0-9a-f]{64}$`, doc["email"])
+	assert.Equal(t, Redacted, doc["name"])
+	assert.True(t, IsEncrypted(doc["address"].(string)))
+	assert.Equal(t, Redacted, doc["contacts"].([]any)[0].(map[string]any)["phone"])
+	assert.Equal(t, "home", doc["contacts"].([]any)[0].(map[string]any)["kind"])
+	assert.Equal(t, "pro", doc["plan"])
+	assert.NotContains(t, string(out), "London")
+
+	// Hashing is deterministic, so hashed values stay joinable
+	again, err := tr.Transform(ctx, "user.signup", payload)
+	require.NoError(t, err)
+	var doc2 map[string]any
+	require.NoError(t, json.Unmarshal(again, &doc2))
+	assert.Equal(t, doc["email"], doc2["email"])
+
+	// Authorized readers get the original values back, types included
+	decrypted, err := cipher.DecryptJSON(ctx, out)
+	require.NoError(t, err)
+	assert.Contains(t, string(decrypted), `"address":{"city":"London","zip":12345}`)
+
+	// Other event types pass through untouched
+	other := json.RawMessage(`{"email": "a@example.com"}`)
+	out, err = tr.Transform(ctx, "user.login", other)
+	require.NoError(t, err)
+	assert.Equal(t, other, out)
+}
+
+func TestTransformer_PlainHashWithoutKey(t *testing.T) {
+	tr, err := NewTransformer([]Rule{{EventType: "e", Path: "id", Action: ActionHash}}, nil, nil)
+	require.NoError(t, err)
+	out, err := tr.Transform(context.Background(), "e", json.RawMessage(`{"id":42}`))
+	require.NoError(t, err)
+	// SHA-256 of the JSON encoding "42"
+	assert.JSONEq(t, `{"id":"sha256:73475cb40a568e8da8a045ced110137e159f890ac4da883b6b17dc651b3a8049"}`, string(out))
+}
+
+func TestTransformer_Errors(t *testing.T) {
+	_, err := NewTransformer([]Rule{{EventType: "e", Path: "ssn", Action: ActionEncrypt}}, nil, nil)
+	assert.ErrorContains(t, err, "no KMS is configured")
+
+	tr, err := NewTransformer([]Rule{{EventType: "e", Path: "ssn", Action: ActionRedact}}, nil, nil)
+	require.NoError(t, err)
+	_, err = tr.Transform(context.Background(), "e", json.RawMessage(`{not json`))
+	assert.ErrorContains(t, err, "failed to decode payload")
+}
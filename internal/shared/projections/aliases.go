@@ -0,0 +1,25 @@
+package projections
+
+import "time"
+
+// Alias serves reads of one projection type from another, typically a
+// shadow projection built under a new name. Aliases apply to the test
+// namespace too: test.<alias> is served from test.<target>.
+type Alias struct {
+	Alias          string    `json:"alias"`
+	ProjectionType string    `json:"projection_type"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Resolve returns the projection type reads of projType are served from:
+// the target of its alias, in the same namespace, or projType itself.
+// Aliases are not followed transitively.
+func Resolve(aliases []Alias, projType string) string {
+	base := BaseType(projType)
+	for _, a := range aliases {
+		if a.Alias == base {
+			return TypeFor(a.ProjectionType, IsTestType(projType))
+		}
+	}
+	return projType
+}
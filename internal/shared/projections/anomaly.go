@@ -0,0 +1,47 @@
+package projections
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Anomaly flag names. Each is a boolean field of the "anomaly" object that the
+// sensor handler embeds in sensor_state projections.
+const (
+	AnomalyValueJump    = "value_jump"
+	AnomalyReportingGap = "reporting_gap"
+)
+
+// AnomalyFlags lists every anomaly flag, in display order.
+var AnomalyFlags = []string{AnomalyValueJump, AnomalyReportingGap}
+
+// Anomaly holds rate-of-change indicators computed against the previous state.
+// Stored under the "anomaly" key of the projection state.
+type Anomaly struct {
+	ValueJump    bool    `json:"value_jump"`
+	ReportingGap bool    `json:"reporting_gap"`
+	Delta        float64 `json:"delta,omitempty"`       // |value - previous value|
+	GapSeconds   float64 `json:"gap_seconds,omitempty"` // seconds since the previous event
+}
+
+// IsAnomalyFlag reports whether flag names a known anomaly flag.
+func IsAnomalyFlag(flag string) bool {
+	return slices.Contains(AnomalyFlags, flag)
+}
+
+// hasAnomaly reports whether state carries any of the given anomaly flags set.
+// Mirrors the jsonb predicate used by PostgresStore.ListAnomalous.
+func hasAnomaly(state []byte, flags []string) bool {
+	var s struct {
+		Anomaly map[string]any `json:"anomaly"`
+	}
+	if err := json.Unmarshal(state, &s); err != nil {
+		return false
+	}
+	for _, flag := range flags {
+		if set, _ := s.Anomaly[flag].(bool); set {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// Change is an accepted projection write: an upsert or fold that changed the
+// stored projection, or a deletion. Writes of events older than the stored
+// projection, which change nothing, are not reported.
+type Change struct {
+	ProjectionType     string
+	AggregateID        string
+	AggregateType      string          // of the written event; empty if it carried none
+	State              json.RawMessage // the stored state; nil for a deletion
+	LastEventID        uuid.UUID       // the newest event applied, as stored
+	LastEventTimestamp time.Time
+	Deleted            bool
+}
+
+// ChangeListener is called with each accepted write, after it is stored and
+// before the write returns. It must not call back into the store.
+//
+// TTL expiry, checksum repairs and state migrations are not reported: they
+// rewrite projections outside event handling, in bulk.
+type ChangeListener func(ctx context.Context, change Change)
+
+// ChangeRecord is the value of a record on the projection change topic: the
+// projection as stored after an accepted write. A deletion is published as a
+// tombstone (no value) instead, so compaction drops the projection's records.
+type ChangeRecord struct {
+	ProjectionType     string          `json:"projection_type"`
+	AggregateID        string          `json:"aggregate_id"`
+	AggregateType      string          `json:"aggregate_type,omitempty"`
+	State              json.RawMessage `json:"state"`
+	LastEventID        uuid.UUID       `json:"last_event_id"`
+	LastEventTimestamp time.Time       `json:"last_event_timestamp"`
+}
+
+// ChangeKey returns the record key of a projection on the change topic,
+// "type/aggregate_id".
+func ChangeKey(projType, aggregateID string) string {
+	return projType + "/" + aggregateID
+}
+
+// ParseChangeKey splits a change record key. Projection types cannot contain
+// "/", so the key splits at its first one.
+func ParseChangeKey(key string) (projType, aggregateID string, ok bool) {
+	projType, aggregateID, ok = strings.Cut(key, "/")
+	return projType, aggregateID, ok && projType != "" && aggregateID != ""
+}
@@ -0,0 +1,11 @@
+package projections
+
+import "time"
+
+// FrozenAggregate is an aggregate whose events are administratively excluded
+// from projection updates.
+type FrozenAggregate struct {
+	AggregateID string    `json:"aggregate_id"`
+	Reason      string    `json:"reason"`
+	FrozenAt    time.Time `json:"frozen_at"`
+}
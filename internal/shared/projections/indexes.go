@@ -0,0 +1,178 @@
+package projections
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgtrace"
+)
+
+// StateIndex declares an expression index on one field of a projection
+// type's state, so queries filtering on it do not scan every projection.
+type StateIndex struct {
+	ProjectionType string
+	Field          string // dotted path into state, e.g. "site_id" or "location.region"
+}
+
+// stateIndexPrefix names the indexes EnsureStateIndexes manages. Indexes with
+// the prefix that are no longer declared are dropped.
+const stateIndexPrefix = "idx_state_"
+
+var (
+	indexTypePattern  = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	indexFieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// ParseStateIndexes parses a comma-separated list of state indexes, each
+// "type:field", such as "sensor_state:site_id,site_daily:location.region".
+// Empty input yields no indexes.
+func ParseStateIndexes(s string) ([]StateIndex, error) {
+	var indexes []StateIndex
+	if s == "" {
+		return indexes, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		projType, field, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || !indexTypePattern.MatchString(projType) {
+			return nil, fmt.Errorf("invalid state index %q: expected type:field", entry)
+		}
+		for _, segment := range strings.Split(field, ".") {
+			if !indexFieldPattern.MatchString(segment) {
+				return nil, fmt.Errorf("invalid state index %q: field segments must be letters, digits and underscores", entry)
+			}
+		}
+		index := StateIndex{ProjectionType: projType, Field: field}
+		if slices.Contains(indexes, index) {
+			return nil, fmt.Errorf("invalid state index %q: declared twice", entry)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// Name returns the index name: readable where it fits Postgres's 63-byte
+// limit, made unique by a hash of the declaration.
+func (i StateIndex) Name() string {
+	sum := sha256.Sum256([]byte(i.ProjectionType + ":" + i.Field))
+	readable := strings.ReplaceAll(i.ProjectionType+"_"+i.Field, ".", "_")
+	return stateIndexPrefix + readable[:min(len(readable), 40)] + "_" + hex.EncodeToString(sum[:4])
+}
+
+// Expression returns the indexed expression, which a query must repeat to use
+// the index: state->>'site_id', or state->'location'->>'region'.
+func (i StateIndex) Expression() string {
+	segments := strings.Split(i.Field, ".")
+	var b strings.Builder
+	b.WriteString("state")
+	for _, s := range segments[:len(segments)-1] {
+		fmt.Fprintf(&b, "->'%s'", s)
+	}
+	fmt.Fprintf(&b, "->>'%s'", segments[len(segments)-1])
+	return b.String()
+}
+
+// createSQL builds the index on the type and the expression. Rows without the
+// field, which includes every other type's rows, are left out; an equality
+// filter on the expression implies it is not null, so the planner can use it.
+func (i StateIndex) createSQL() string {
+	return fmt.Sprintf(`CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON projections (projection_type, (%s)) WHERE (%s) IS NOT NULL`,
+		i.Name(), i.Expression(), i.Expression())
+}
+
+// IndexChanges reports what EnsureStateIndexes did, by index name.
+type IndexChanges struct {
+	Created []string
+	Dropped []string
+}
+
+// EnsureStateIndexes makes the managed state indexes on projections match
+// indexes: it builds missing ones, rebuilds ones left invalid by an
+// interrupted build, and drops ones no longer declared. Indexes are built and
+// dropped concurrently, so writes continue meanwhile.
+//
+// An invalid index another session is still building (it shows in
+// pg_stat_progress_create_index) is left alone. Callers running on several
+// instances serialize calls with a lock, so a build is not raced.
+func (s *PostgresStore) EnsureStateIndexes(ctx context.Context, indexes []StateIndex) (*IndexChanges, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "ensure_state_indexes")
+	rows, err := s.db.Query(ctx, `
+		SELECT c.relname, i.indisvalid,
+		       EXISTS (SELECT 1 FROM pg_stat_progress_create_index p WHERE p.index_relid = i.indexrelid)
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		WHERE i.indrelid = 'projections'::regclass AND c.relname LIKE $1
+	`, stateIndexPrefix+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list state indexes: %w", err)
+	}
+	existing := make(map[string]bool) // name -> valid
+	building := make(map[string]bool) // being built by another session
+	for rows.Next() {
+		var name string
+		var valid, inProgress bool
+		if err := rows.Scan(&name, &valid, &inProgress); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan state index: %w", err)
+		}
+		existing[name] = valid
+		building[name] = inProgress
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating state indexes: %w", err)
+	}
+
+	changes := &IndexChanges{}
+	declared := make(map[string]bool, len(indexes))
+	for _, index := range indexes {
+		name := index.Name()
+		declared[name] = true
+		valid, ok := existing[name]
+		if ok && valid {
+			continue
+		}
+		if building[name] {
+			s.logger.Info("state index is being built by another session", "index", name)
+			continue
+		}
+		if ok {
+			if err := s.dropIndex(ctx, name); err != nil {
+				return changes, err
+			}
+		}
+		s.logger.Info("building state index", "index", name, "projection_type", index.ProjectionType, "field", index.Field)
+		if _, err := s.db.Exec(ctx, index.createSQL()); err != nil {
+			return changes, fmt.Errorf("failed to build state index %s: %w", name, err)
+		}
+		changes.Created = append(changes.Created, name)
+	}
+
+	for name := range existing {
+		if declared[name] {
+			continue
+		}
+		if building[name] {
+			s.logger.Info("undeclared state index is being built by another session, not dropping it", "index", name)
+			continue
+		}
+		if err := s.dropIndex(ctx, name); err != nil {
+			return changes, err
+		}
+		changes.Dropped = append(changes.Dropped, name)
+	}
+	slices.Sort(changes.Dropped)
+	return changes, nil
+}
+
+func (s *PostgresStore) dropIndex(ctx context.Context, name string) error {
+	s.logger.Info("dropping state index", "index", name)
+	if _, err := s.db.Exec(ctx, fmt.Sprintf(`DROP INDEX CONCURRENTLY IF EXISTS %s`, name)); err != nil {
+		return fmt.Errorf("failed to drop state index %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package projections
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStateIndexes(t *testing.T) {
+	indexes, err := ParseStateIndexes("sensor_state:site_id, site_daily:location.region")
+	require.NoError(t, err)
+	assert.Equal(t, []StateIndex{
+		{ProjectionType: "sensor_state", Field: "site_id"},
+		{ProjectionType: "site_daily", Field: "location.region"},
+	}, indexes)
+
+	indexes, err = ParseStateIndexes("")
+	require.NoError(t, err)
+	assert.Empty(t, indexes)
+
+	for _, bad := range []string{
+		"sensor_state",
+		"sensor_state:",
+		"test.sensor_state:site_id",
+		"sensor_state:site-id",
+		"sensor_state:location..region",
+		"sensor_state:x'); DROP TABLE projections; --",
+		"sensor_state:site_id,sensor_state:site_id",
+	} {
+		_, err := ParseStateIndexes(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestStateIndex_SQL(t *testing.T) {
+	site := StateIndex{ProjectionType: "sensor_state", Field: "site_id"}
+	region := StateIndex{ProjectionType: "sensor_state", Field: "location.region"}
+
+	assert.Equal(t, "state->>'site_id'", site.Expression())
+	assert.Equal(t, "state->'location'->>'region'", region.Expression())
+	assert.True(t, strings.HasPrefix(site.Name(), "idx_state_sensor_state_site_id_"))
+	assert.NotEqual(t, site.Name(), region.Name())
+	assert.Equal(t,
+		"CREATE INDEX CONCURRENTLY IF NOT EXISTS "+site.Name()+" ON projections (projection_type, (state->>'site_id')) WHERE (state->>'site_id') IS NOT NULL",
+		site.createSQL())
+
+	long := StateIndex{ProjectionType: strings.Repeat("a", 200), Field: strings.Repeat("b", 200)}
+	assert.LessOrEqual(t, len(long.Name()), 63, "Postgres truncates longer names")
+}
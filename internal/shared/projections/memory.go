@@ -0,0 +1,787 @@
+package projections
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+)
+
+// MemoryStore implements Store in memory.
+// Used by sandbox mode; mirrors PostgresStore semantics (newer-event-wins, ErrNoRows on miss).
+type MemoryStore struct {
+	mu          sync.RWMutex
+	projections map[memoryKey]Projection
+	checksums   map[memoryKey]string
+	frozen      map[string]FrozenAggregate
+	windows     map[windowKey]Window
+	watermarks  map[memoryKey]time.Time // keyed by aggregation and aggregate
+	failures    map[failureKey]failureRecord
+	aliases     map[string]Alias
+	folds       map[foldKey]time.Time // events folded into merged projections, with when
+	registry    registry.Reader       // for ListRegistered and WithRegistry reads; nil matches nothing
+	listener    ChangeListener        // nil reports no changes
+}
+
+type failureKey struct {
+	consumer string
+	eventID  uuid.UUID
+}
+
+type failureRecord struct {
+	failure     DispatchFailure
+	attempts    int
+	quarantined bool
+	failedAt    time.Time
+}
+
+type windowKey struct {
+	aggregation string
+	aggregateID string
+	start       time.Time
+}
+
+type memoryKey struct {
+	projType    string
+	aggregateID string
+}
+
+type foldKey struct {
+	memoryKey
+	eventID uuid.UUID
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		projections: make(map[memoryKey]Projection),
+		checksums:   make(map[memoryKey]string),
+		frozen:      make(map[string]FrozenAggregate),
+		windows:     make(map[windowKey]Window),
+		watermarks:  make(map[memoryKey]time.Time),
+		failures:    make(map[failureKey]failureRecord),
+		aliases:     make(map[string]Alias),
+		folds:       make(map[foldKey]time.Time),
+	}
+}
+
+// SetRegistry sets the registry ListRegistered and WithRegistry reads take
+// entries from, standing in for the joins PostgresStore makes against
+// aggregate_registry.
+func (s *MemoryStore) SetRegistry(reader registry.Reader) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registry = reader
+}
+
+// SetChangeListener reports every accepted projection write and deletion to
+// listener, as PostgresStore does. The listener is called with the store
+// locked.
+func (s *MemoryStore) SetChangeListener(listener ChangeListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listener = listener
+}
+
+// changed reports the accepted write of key by event to the listener, if
+// any. Callers hold s.mu.
+func (s *MemoryStore) changed(ctx context.Context, key memoryKey, event *events.Envelope) {
+	if s.listener == nil {
+		return
+	}
+	p := s.projections[key]
+	change := Change{
+		ProjectionType:     key.projType,
+		AggregateID:        key.aggregateID,
+		AggregateType:      event.AggregateType,
+		State:              p.State,
+		LastEventID:        p.LastEventID,
+		LastEventTimestamp: p.LastEventTimestamp,
+		Deleted:            p.DeletedAt != nil,
+	}
+	if change.Deleted {
+		change.State = nil
+	}
+	s.listener(ctx, change)
+}
+
+// WriteProjection inserts or updates a projection, only if the event is newer.
+// A newer event also clears a previous deletion. Types with a Merge (see
+// RegisterMerge) fold every event into the stored state instead, once per
+// event.
+func (s *MemoryStore) WriteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memoryKey{projType: projType, aggregateID: aggregateID}
+	existing, ok := s.projections[key]
+	if merge := MergeFor(projType); merge != nil {
+		folded := foldKey{memoryKey: key, eventID: event.EventID}
+		if _, ok := s.folds[folded]; ok {
+			return nil
+		}
+		if ok {
+			if err := s.fold(key, existing, state, event, merge); err != nil {
+				return err
+			}
+		} else {
+			s.put(key, existing, ok, state, event)
+		}
+		s.folds[folded] = clock.Now()
+		s.changed(ctx, key, event)
+		return nil
+	}
+	if ok && !isNewer(event, existing) {
+		return nil
+	}
+
+	s.put(key, existing, ok, state, event)
+	s.changed(ctx, key, event)
+	return nil
+}
+
+// fold merges state into an existing projection. Callers hold s.mu.
+func (s *MemoryStore) fold(key memoryKey, existing Projection, state []byte, event *events.Envelope, merge Merge) error {
+	newer := isNewer(event, existing)
+	merged, err := merge(existing.State, state, newer)
+	if err != nil {
+		return fmt.Errorf("failed to merge projection: %w", err)
+	}
+
+	if newer {
+		s.put(key, existing, true, merged, event)
+		return nil
+	}
+	// Older event: keep tracking the newest one, and any deletion
+	existing.State = append([]byte(nil), merged...)
+	existing.AggregateType = aggregateTypeOf(existing, event)
+	existing.UpdatedAt = clock.Now()
+	s.projections[key] = existing
+	s.checksums[key] = stateChecksum(merged)
+	return nil
+}
+
+// DeleteProjection soft-deletes a projection, only if the event is newer,
+// keeping the stored state. A missing projection is created deleted.
+func (s *MemoryStore) DeleteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memoryKey{projType: projType, aggregateID: aggregateID}
+	existing, ok := s.projections[key]
+	if ok && !isNewer(event, existing) {
+		return nil
+	}
+
+	deletedAt := clock.Now()
+	if ok {
+		state = existing.State
+		if existing.DeletedAt != nil {
+			deletedAt = *existing.DeletedAt
+		}
+	}
+	s.put(key, existing, ok, state, event)
+	p := s.projections[key]
+	p.DeletedAt = &deletedAt
+	s.projections[key] = p
+	s.changed(ctx, key, event)
+	return nil
+}
+
+// ExpireProjections soft-deletes live projections of projType last updated
+// before cutoff, returning how many were expired.
+func (s *MemoryStore) ExpireProjections(ctx context.Context, projType string, cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := clock.Now()
+	expired := 0
+	for key, p := range s.projections {
+		if key.projType == projType && p.DeletedAt == nil && p.UpdatedAt.Before(cutoff) {
+			p.DeletedAt = &now
+			s.projections[key] = p
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// PurgeFolds forgets events folded into merged projections before cutoff,
+// returning how many were forgotten.
+func (s *MemoryStore) PurgeFolds(ctx context.Context, cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for key, foldedAt := range s.folds {
+		if foldedAt.Before(cutoff) {
+			delete(s.folds, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// put stores a projection and its checksum. Callers hold s.mu.
+func (s *MemoryStore) put(key memoryKey, existing Projection, exists bool, state []byte, event *events.Envelope) {
+	projectionID := existing.ProjectionID
+	if !exists {
+		projectionID = uuid.Must(uuid.NewV7())
+	}
+
+	s.projections[key] = Projection{
+		ProjectionID:       projectionID,
+		ProjectionType:     key.projType,
+		AggregateID:        key.aggregateID,
+		AggregateType:      aggregateTypeOf(existing, event),
+		State:              append([]byte(nil), state...),
+		LastEventID:        event.EventID,
+		LastEventTimestamp: event.EventTime,
+		UpdatedAt:          clock.Now(),
+	}
+	s.checksums[key] = stateChecksum(state)
+}
+
+// aggregateTypeOf returns the aggregate type a write of event records: the
+// event's, unless it has none (as aggregateTypeSQL in PostgresStore).
+func aggregateTypeOf(existing Projection, event *events.Envelope) string {
+	if event.AggregateType != "" {
+		return event.AggregateType
+	}
+	return existing.AggregateType
+}
+
+// GetProjection retrieves a single projection by type and aggregate ID.
+// Deleted projections are reported as missing.
+func (s *MemoryStore) GetProjection(ctx context.Context, projType, aggregateID string) (*Projection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.projections[memoryKey{projType: projType, aggregateID: aggregateID}]
+	if !ok || p.DeletedAt != nil {
+		return nil, fmt.Errorf("failed to get projection: %w", pgx.ErrNoRows)
+	}
+	return s.joinOne(ctx, p)
+}
+
+// GetDeletedProjection retrieves a single deleted projection by type and
+// aggregate ID. Live projections are reported as missing.
+func (s *MemoryStore) GetDeletedProjection(ctx context.Context, projType, aggregateID string) (*Projection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.projections[memoryKey{projType: projType, aggregateID: aggregateID}]
+	if !ok || p.DeletedAt == nil {
+		return nil, fmt.Errorf("failed to get deleted projection: %w", pgx.ErrNoRows)
+	}
+	return s.joinOne(ctx, p)
+}
+
+// GetProjections retrieves the live projections of one type for a set of
+// aggregate IDs, in the order of aggregateIDs. Missing and deleted projections are omitted.
+func (s *MemoryStore) GetProjections(ctx context.Context, projType string, aggregateIDs []string) ([]Projection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := []Projection{}
+	for _, id := range aggregateIDs {
+		if p, ok := s.projections[memoryKey{projType: projType, aggregateID: id}]; ok && isLive(p) {
+			found = append(found, p)
+		}
+	}
+	return found, s.joinRegistry(ctx, found)
+}
+
+// GetAggregateProjections retrieves every live projection of one aggregate,
+// across projection types, ordered by type. A non-empty aggregateType keeps
+// only projections of aggregates of that type.
+func (s *MemoryStore) GetAggregateProjections(ctx context.Context, aggregateID, aggregateType string) ([]Projection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := []Projection{}
+	for key, p := range s.projections {
+		if key.aggregateID == aggregateID && isLive(p) && (aggregateType == "" || p.AggregateType == aggregateType) {
+			found = append(found, p)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].ProjectionType < found[j].ProjectionType
+	})
+	return found, s.joinRegistry(ctx, found)
+}
+
+// ListProjections retrieves live projections by type with pagination, newest update first.
+func (s *MemoryStore) ListProjections(ctx context.Context, projType string, limit, offset int) ([]Projection, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched, total := s.page(projType, isLive, limit, offset)
+	return matched, total, s.joinRegistry(ctx, matched)
+}
+
+// SearchProjections retrieves live projections by type whose aggregate ID
+// contains q (case-insensitive), with pagination, newest update first.
+func (s *MemoryStore) SearchProjections(ctx context.Context, projType, q string, limit, offset int) ([]Projection, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	q = strings.ToLower(q)
+	match := func(p Projection) bool {
+		return isLive(p) && strings.Contains(strings.ToLower(p.AggregateID), q)
+	}
+	matched, total := s.page(projType, match, limit, offset)
+	return matched, total, s.joinRegistry(ctx, matched)
+}
+
+// ListDeleted retrieves deleted projections by type with pagination, newest update first.
+func (s *MemoryStore) ListDeleted(ctx context.Context, projType string, limit, offset int) ([]Projection, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	match := func(p Projection) bool { return !isLive(p) }
+	matched, total := s.page(projType, match, limit, offset)
+	return matched, total, s.joinRegistry(ctx, matched)
+}
+
+// ListAnomalous retrieves live projections by type that have any of the given
+// anomaly flags set, with pagination, newest update first.
+func (s *MemoryStore) ListAnomalous(ctx context.Context, projType string, flags []string, limit, offset int) ([]Projection, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	match := func(p Projection) bool { return isLive(p) && hasAnomaly(p.State, flags) }
+	matched, total := s.page(projType, match, limit, offset)
+	return matched, total, s.joinRegistry(ctx, matched)
+}
+
+// ListRegistered retrieves live projections by type whose aggregate's registry
+// entry matches filter, with pagination, newest update first.
+func (s *MemoryStore) ListRegistered(ctx context.Context, projType string, filter registry.Filter, limit, offset int) ([]Projection, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.registry == nil {
+		return []Projection{}, 0, nil
+	}
+	ids := []string{}
+	for key, p := range s.projections {
+		if key.projType == projType && isLive(p) {
+			ids = append(ids, key.aggregateID)
+		}
+	}
+	entries, err := s.registry.GetAggregates(ctx, ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list registered projections: %w", err)
+	}
+	registered := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if filter.Matches(entry) {
+			registered[entry.AggregateID] = true
+		}
+	}
+
+	match := func(p Projection) bool { return isLive(p) && registered[p.AggregateID] }
+	matched, total := s.page(projType, match, limit, offset)
+	return matched, total, s.joinRegistry(ctx, matched)
+}
+
+// joinOne is joinRegistry for a single projection.
+func (s *MemoryStore) joinOne(ctx context.Context, p Projection) (*Projection, error) {
+	found := []Projection{p}
+	if err := s.joinRegistry(ctx, found); err != nil {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+// joinRegistry sets the Registry of each projection when ctx was made by
+// WithRegistry, in a single registry read. Callers hold s.mu.
+func (s *MemoryStore) joinRegistry(ctx context.Context, ps []Projection) error {
+	if !RegistryRequested(ctx) || s.registry == nil || len(ps) == 0 {
+		return nil
+	}
+	ids := make([]string, len(ps))
+	for i, p := range ps {
+		ids[i] = p.AggregateID
+	}
+	entries, err := s.registry.GetAggregates(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to read registry entries: %w", err)
+	}
+	byID := make(map[string]*registry.Aggregate, len(entries))
+	for i := range entries {
+		byID[entries[i].AggregateID] = &entries[i]
+	}
+	for i := range ps {
+		ps[i].Registry = byID[ps[i].AggregateID]
+	}
+	return nil
+}
+
+// page returns one page of the projections of projType accepted by match (nil
+// accepts all), newest update first, and the total match count. Callers hold s.mu.
+func (s *MemoryStore) page(projType string, match func(Projection) bool, limit, offset int) ([]Projection, int) {
+	matched := []Projection{}
+	for key, p := range s.projections {
+		if key.projType == projType && (match == nil || match(p)) {
+			matched = append(matched, p)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].UpdatedAt.After(matched[j].UpdatedAt)
+	})
+
+	total := len(matched)
+	if offset >= total {
+		return []Projection{}, total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total
+}
+
+// FindCorrupt returns up to limit projections whose state no longer matches
+// the checksum recorded at write time.
+func (s *MemoryStore) FindCorrupt(ctx context.Context, limit int) ([]Discrepancy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	discrepancies := []Discrepancy{}
+	for key, p := range s.projections {
+		actual := stateChecksum(p.State)
+		if stored := s.checksums[key]; stored != actual {
+			discrepancies = append(discrepancies, Discrepancy{
+				ProjectionType: key.projType,
+				AggregateID:    key.aggregateID,
+				LastEventID:    p.LastEventID,
+				StoredChecksum: stored,
+				ActualChecksum: actual,
+			})
+		}
+	}
+
+	sort.Slice(discrepancies, func(i, j int) bool {
+		a, b := discrepancies[i], discrepancies[j]
+		if a.ProjectionType != b.ProjectionType {
+			return a.ProjectionType < b.ProjectionType
+		}
+		return a.AggregateID < b.AggregateID
+	})
+	if len(discrepancies) > limit {
+		discrepancies = discrepancies[:limit]
+	}
+	return discrepancies, nil
+}
+
+// RepairProjection overwrites a projection unconditionally, recomputing its checksum.
+func (s *MemoryStore) RepairProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memoryKey{projType: projType, aggregateID: aggregateID}
+	existing, ok := s.projections[key]
+	s.put(key, existing, ok, state, event)
+	if ok && existing.DeletedAt != nil {
+		// Repairs restore state, not liveness (as in PostgresStore)
+		p := s.projections[key]
+		p.DeletedAt = existing.DeletedAt
+		s.projections[key] = p
+	}
+	return nil
+}
+
+// FreezeAggregate marks an aggregate frozen, keeping the original frozen time
+// if it already was.
+func (s *MemoryStore) FreezeAggregate(ctx context.Context, aggregateID, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.frozen[aggregateID]
+	if !ok {
+		f = FrozenAggregate{AggregateID: aggregateID, FrozenAt: clock.Now()}
+	}
+	f.Reason = reason
+	s.frozen[aggregateID] = f
+	return nil
+}
+
+// UnfreezeAggregate clears an aggregate's frozen flag.
+func (s *MemoryStore) UnfreezeAggregate(ctx context.Context, aggregateID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.frozen, aggregateID)
+	return nil
+}
+
+// IsFrozen reports whether an aggregate is frozen.
+func (s *MemoryStore) IsFrozen(ctx context.Context, aggregateID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.frozen[aggregateID]
+	return ok, nil
+}
+
+// ListFrozen returns all frozen aggregates, most recently frozen first.
+func (s *MemoryStore) ListFrozen(ctx context.Context) ([]FrozenAggregate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	frozen := []FrozenAggregate{}
+	for _, f := range s.frozen {
+		frozen = append(frozen, f)
+	}
+	sort.Slice(frozen, func(i, j int) bool {
+		if !frozen[i].FrozenAt.Equal(frozen[j].FrozenAt) {
+			return frozen[i].FrozenAt.After(frozen[j].FrozenAt)
+		}
+		return frozen[i].AggregateID < frozen[j].AggregateID
+	})
+	return frozen, nil
+}
+
+// AdvanceWatermark records eventTime for the aggregate of an aggregation and
+// returns the latest event time seen, eventTime included.
+func (s *MemoryStore) AdvanceWatermark(ctx context.Context, aggregation, aggregateID string, eventTime time.Time) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memoryKey{projType: aggregation, aggregateID: aggregateID}
+	if latest, ok := s.watermarks[key]; ok && latest.After(eventTime) {
+		return latest, nil
+	}
+	s.watermarks[key] = eventTime
+	return eventTime, nil
+}
+
+// FoldWindow applies fold to the window of an aggregation starting at start,
+// creating it if needed.
+func (s *MemoryStore) FoldWindow(ctx context.Context, aggregation, aggregateID string, start, end time.Time, fold WindowFold) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := windowKey{aggregation: aggregation, aggregateID: aggregateID, start: start.UTC()}
+	w, ok := s.windows[key]
+	if !ok {
+		w = Window{Aggregation: aggregation, AggregateID: aggregateID, Start: start, End: end}
+	}
+	next, err := fold(w.State)
+	if err != nil {
+		return fmt.Errorf("failed to fold window: %w", err)
+	}
+	if next != nil {
+		w.State = append(json.RawMessage(nil), next...)
+		s.windows[key] = w
+	}
+	return nil
+}
+
+// ClosedWindows returns the windows of an aggregate that end at or before
+// watermark, oldest first.
+func (s *MemoryStore) ClosedWindows(ctx context.Context, aggregation, aggregateID string, watermark time.Time) ([]Window, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var closed []Window
+	for key, w := range s.windows {
+		if key.aggregation == aggregation && key.aggregateID == aggregateID && !w.End.After(watermark) {
+			closed = append(closed, w)
+		}
+	}
+	sort.Slice(closed, func(i, j int) bool { return closed[i].Start.Before(closed[j].Start) })
+	return closed, nil
+}
+
+// DeleteWindows removes the windows of an aggregate that end at or before
+// through.
+func (s *MemoryStore) DeleteWindows(ctx context.Context, aggregation, aggregateID string, through time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, w := range s.windows {
+		if key.aggregation == aggregation && key.aggregateID == aggregateID && !w.End.After(through) {
+			delete(s.windows, key)
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed dispatch of an event and reports how many
+// attempts have failed so far. The attempt reaching maxAttempts quarantines
+// the event; an event already quarantined stays so.
+func (s *MemoryStore) RecordFailure(ctx context.Context, f DispatchFailure, maxAttempts int) (int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := failureKey{consumer: f.Consumer, eventID: f.EventID}
+	r := s.failures[key]
+	if r.quarantined {
+		return r.attempts, true, nil
+	}
+	r.failure = f
+	r.attempts++
+	r.failedAt = clock.Now()
+	r.quarantined = r.attempts >= maxAttempts
+	s.failures[key] = r
+	return r.attempts, r.quarantined, nil
+}
+
+// ClearFailure forgets the failed attempts of an event that has since been
+// dispatched. Quarantined events are kept.
+func (s *MemoryStore) ClearFailure(ctx context.Context, consumer string, eventID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := failureKey{consumer: consumer, eventID: eventID}
+	if r, ok := s.failures[key]; ok && !r.quarantined {
+		delete(s.failures, key)
+	}
+	return nil
+}
+
+// ListQuarantined returns a page of the quarantined events, most recently
+// failed first, and how many there are in all. An empty consumer lists the
+// quarantined events of every consumer group.
+func (s *MemoryStore) ListQuarantined(ctx context.Context, consumer string, limit, offset int) ([]QuarantinedEvent, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	quarantined := []QuarantinedEvent{}
+	for _, r := range s.failures {
+		if !r.quarantined || (consumer != "" && r.failure.Consumer != consumer) {
+			continue
+		}
+		quarantined = append(quarantined, QuarantinedEvent{
+			Consumer:  r.failure.Consumer,
+			EventID:   r.failure.EventID,
+			Payload:   r.failure.Payload,
+			Error:     r.failure.Error,
+			Attempts:  r.attempts,
+			Topic:     r.failure.Topic,
+			Partition: r.failure.Partition,
+			Offset:    r.failure.Offset,
+			FailedAt:  r.failedAt,
+		})
+	}
+	sort.Slice(quarantined, func(i, j int) bool {
+		if !quarantined[i].FailedAt.Equal(quarantined[j].FailedAt) {
+			return quarantined[i].FailedAt.After(quarantined[j].FailedAt)
+		}
+		return quarantined[i].EventID.String() < quarantined[j].EventID.String()
+	})
+
+	total := len(quarantined)
+	if offset >= total {
+		return []QuarantinedEvent{}, total, nil
+	}
+	end := min(offset+limit, total)
+	return quarantined[offset:end], total, nil
+}
+
+// SetAlias serves reads of alias from projType.
+func (s *MemoryStore) SetAlias(ctx context.Context, alias, projType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.aliases[alias] = Alias{Alias: alias, ProjectionType: projType, UpdatedAt: clock.Now()}
+	return nil
+}
+
+// RemoveAlias serves alias from its own projections again.
+func (s *MemoryStore) RemoveAlias(ctx context.Context, alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.aliases, alias)
+	return nil
+}
+
+// ListAliases returns all projection aliases, by alias.
+func (s *MemoryStore) ListAliases(ctx context.Context) ([]Alias, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	aliases := []Alias{}
+	for _, a := range s.aliases {
+		aliases = append(aliases, a)
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Alias < aliases[j].Alias })
+	return aliases, nil
+}
+
+// ScanProjections returns up to limit projections of one type, deleted ones
+// included, with aggregate IDs after afterAggregateID, in aggregate ID order.
+func (s *MemoryStore) ScanProjections(ctx context.Context, projType, afterAggregateID string, limit int) ([]Projection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scanned := []Projection{}
+	for key, p := range s.projections {
+		if key.projType == projType && key.aggregateID > afterAggregateID {
+			scanned = append(scanned, p)
+		}
+	}
+	sort.Slice(scanned, func(i, j int) bool { return scanned[i].AggregateID < scanned[j].AggregateID })
+	return scanned[:min(limit, len(scanned))], nil
+}
+
+// ReplaceState sets a projection's state to state if it is still old,
+// keeping its event position and update time.
+func (s *MemoryStore) ReplaceState(ctx context.Context, projType, aggregateID string, old, state json.RawMessage) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memoryKey{projType: projType, aggregateID: aggregateID}
+	p, ok := s.projections[key]
+	if !ok || !bytes.Equal(p.State, old) {
+		return false, nil
+	}
+	p.State = append([]byte(nil), state...)
+	s.projections[key] = p
+	s.checksums[key] = stateChecksum(state)
+	return true, nil
+}
+
+// stateChecksum hashes the state bytes as written. Unlike Postgres (which
+// hashes canonical jsonb), the memory store keeps bytes verbatim, so the raw
+// form is already stable.
+func stateChecksum(state []byte) string {
+	sum := sha256.Sum256(state)
+	return hex.EncodeToString(sum[:])
+}
+
+// isLive reports whether a projection is not deleted.
+func isLive(p Projection) bool {
+	return p.DeletedAt == nil
+}
+
+// isNewer reports whether event should replace the stored projection,
+// using the same ordering as the Postgres upsert (event_time, then event_id).
+func isNewer(event *events.Envelope, p Projection) bool {
+	if event.EventTime.After(p.LastEventTimestamp) {
+		return true
+	}
+	return event.EventTime.Equal(p.LastEventTimestamp) &&
+		event.EventID.String() > p.LastEventID.String()
+}
+
+// Ensure MemoryStore implements Store
+var _ Store = (*MemoryStore)(nil)
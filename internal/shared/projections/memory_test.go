@@ -0,0 +1,562 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/domain/ids"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+)
+
+func memoryTestEvent(eventTime time.Time) *events.Envelope {
+	return &events.Envelope{
+		EventID:   ids.New(),
+		EventTime: eventTime,
+	}
+}
+
+func TestMemoryStore_NewerEventWins(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	base := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{"value": 2}`), memoryTestEvent(base.Add(time.Second))))
+
+	// Older event must not overwrite
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{"value": 1}`), memoryTestEvent(base)))
+
+	p, err := store.GetProjection(ctx, "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value": 2}`, string(p.State))
+}
+
+func TestMemoryStore_SameTimeIDTiebreaker(t *testing.T) {
+	ids.Set(&ids.Sequence{})
+	t.Cleanup(ids.Reset)
+	store := NewMemoryStore()
+	ctx := context.Background()
+	base := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+
+	// Sequence IDs increase, so the second event has the larger ID
+	first, second := memoryTestEvent(base), memoryTestEvent(base)
+
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{"value": 2}`), second))
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{"value": 1}`), first))
+
+	p, err := store.GetProjection(ctx, "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value": 2}`, string(p.State))
+	assert.Equal(t, second.EventID, p.LastEventID)
+}
+
+func TestMemoryStore_FoldsLateEvents(t *testing.T) {
+	RegisterMerge("reading_counts", map[string]FieldOp{"count": Sum, "high": Max})
+	t.Cleanup(func() { RegisterMerge("reading_counts", nil) })
+
+	store := NewMemoryStore()
+	ctx := context.Background()
+	base := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+
+	newest := memoryTestEvent(base.Add(time.Minute))
+	require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001",
+		json.RawMessage(`{"count":1,"high":20}`), newest))
+	// Late event: folded in, but the projection still tracks the newest event
+	require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001",
+		json.RawMessage(`{"count":1,"high":30}`), memoryTestEvent(base)))
+
+	p, err := store.GetProjection(ctx, "reading_counts", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count":2,"high":30}`, string(p.State))
+	assert.Equal(t, newest.EventID, p.LastEventID)
+
+	corrupt, err := store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, corrupt, "checksum follows the folded state")
+}
+
+func TestMemoryStore_FoldsEachEventOnce(t *testing.T) {
+	RegisterMerge("reading_counts", map[string]FieldOp{"count": Sum})
+	t.Cleanup(func() { RegisterMerge("reading_counts", nil) })
+
+	store := NewMemoryStore()
+	ctx := context.Background()
+	base := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+
+	first := memoryTestEvent(base)
+	for range 3 { // delivered, redelivered, retried
+		require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001", json.RawMessage(`{"count":1}`), first))
+	}
+	require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001",
+		json.RawMessage(`{"count":1}`), memoryTestEvent(base.Add(time.Minute))))
+
+	p, err := store.GetProjection(ctx, "reading_counts", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count":2}`, string(p.State))
+}
+
+func TestMemoryStore_PurgeFolds(t *testing.T) {
+	RegisterMerge("reading_counts", map[string]FieldOp{"count": Sum})
+	t.Cleanup(func() { RegisterMerge("reading_counts", nil) })
+	now := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	store := NewMemoryStore()
+	ctx := context.Background()
+	event := memoryTestEvent(now)
+	require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001", json.RawMessage(`{"count":1}`), event))
+
+	purged, err := store.PurgeFolds(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, purged, "records folded at the cutoff are kept")
+	purged, err = store.PurgeFolds(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	// Past the horizon, a redelivery is folded again
+	require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001", json.RawMessage(`{"count":1}`), event))
+	p, err := store.GetProjection(ctx, "reading_counts", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count":2}`, string(p.State))
+}
+
+func TestMemoryStore_GetMissing(t *testing.T) {
+	store := NewMemoryStore()
+
+	_, err := store.GetProjection(context.Background(), "sensor_state", "missing")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+}
+
+func TestMemoryStore_ListPagination(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, store.WriteProjection(ctx, "sensor_state", id, json.RawMessage(`{}`), memoryTestEvent(now)))
+	}
+	require.NoError(t, store.WriteProjection(ctx, "user_session", "u", json.RawMessage(`{}`), memoryTestEvent(now)))
+
+	page, total, err := store.ListProjections(ctx, "sensor_state", 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, page, 2)
+
+	page, _, err = store.ListProjections(ctx, "sensor_state", 2, 2)
+	require.NoError(t, err)
+	assert.Len(t, page, 1)
+
+	page, _, err = store.ListProjections(ctx, "sensor_state", 2, 10)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func TestMemoryStore_FindCorruptAndRepair(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	event := memoryTestEvent(time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC))
+
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001", json.RawMessage(`{"value": 1}`), event))
+
+	found, err := store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	// Simulate bit rot
+	key := memoryKey{projType: "sensor_state", aggregateID: "device-001"}
+	p := store.projections[key]
+	p.State = json.RawMessage(`{"value": 7}`)
+	store.projections[key] = p
+
+	found, err = store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "device-001", found[0].AggregateID)
+
+	require.NoError(t, store.RepairProjection(ctx, "sensor_state", "device-001", json.RawMessage(`{"value": 1}`), event))
+	found, err = store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestMemoryStore_ListAnomalous(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	states := map[string]string{
+		"calm":    `{"value": 1, "anomaly": {"value_jump": false, "reporting_gap": false}}`,
+		"jumpy":   `{"value": 9, "anomaly": {"value_jump": true, "reporting_gap": false}}`,
+		"silent":  `{"value": 2, "anomaly": {"value_jump": false, "reporting_gap": true}}`,
+		"unknown": `{"value": 3}`,
+	}
+	for id, state := range states {
+		require.NoError(t, store.WriteProjection(ctx, "sensor_state", id, json.RawMessage(state), memoryTestEvent(now)))
+	}
+
+	page, total, err := store.ListAnomalous(ctx, "sensor_state", AnomalyFlags, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, page, 2)
+
+	page, total, err = store.ListAnomalous(ctx, "sensor_state", []string{AnomalyValueJump}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "jumpy", page[0].AggregateID)
+}
+
+func TestMemoryStore_ListRegistered(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, id := range []string{"device-001", "device-002", "device-003", "device-004"} {
+		require.NoError(t, store.WriteProjection(ctx, "sensor_state", id, json.RawMessage(`{}`), memoryTestEvent(now)))
+	}
+	require.NoError(t, store.DeleteProjection(ctx, "sensor_state", "device-004", json.RawMessage(`{}`), memoryTestEvent(now.Add(time.Second))))
+
+	page, total, err := store.ListRegistered(ctx, "sensor_state", registry.Filter{}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total, "without a registry nothing is registered")
+	assert.NotNil(t, page)
+
+	reg := registry.NewMemoryStore()
+	for _, entry := range []registry.Aggregate{
+		{AggregateID: "device-001", Site: "plant-7", Tags: []string{"critical"}},
+		{AggregateID: "device-002", Site: "plant-7"},
+		{AggregateID: "device-003", Site: "plant-9"},
+		{AggregateID: "device-004", Site: "plant-7"},
+	} {
+		_, err := reg.PutAggregate(ctx, &entry)
+		require.NoError(t, err)
+	}
+	store.SetRegistry(reg)
+
+	page, total, err = store.ListRegistered(ctx, "sensor_state", registry.Filter{Site: "plant-7"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total, "deleted projections are excluded")
+	assert.Len(t, page, 2)
+
+	page, total, err = store.ListRegistered(ctx, "sensor_state", registry.Filter{Site: "plant-7", Tag: "critical"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "device-001", page[0].AggregateID)
+}
+
+func TestMemoryStore_WithRegistry(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	for _, id := range []string{"device-001", "device-002"} {
+		require.NoError(t, store.WriteProjection(ctx, "sensor_state", id, json.RawMessage(`{}`), memoryTestEvent(time.Now())))
+	}
+	reg := registry.NewMemoryStore()
+	_, err := reg.PutAggregate(ctx, &registry.Aggregate{AggregateID: "device-001", Site: "plant-7"})
+	require.NoError(t, err)
+	store.SetRegistry(reg)
+
+	p, err := store.GetProjection(ctx, "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.Nil(t, p.Registry, "entries are joined only when asked for")
+
+	p, err = store.GetProjection(WithRegistry(ctx), "sensor_state", "device-001")
+	require.NoError(t, err)
+	require.NotNil(t, p.Registry)
+	assert.Equal(t, "plant-7", p.Registry.Site)
+
+	page, _, err := store.ListProjections(WithRegistry(ctx), "sensor_state", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	for _, p := range page {
+		assert.Equal(t, p.AggregateID == "device-001", p.Registry != nil, p.AggregateID)
+	}
+}
+
+func TestMemoryStore_FreezeAggregate(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	require.NoError(t, store.FreezeAggregate(ctx, "device-001", "first"))
+	first, err := store.ListFrozen(ctx)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+
+	// Re-freezing updates the reason, not the frozen time
+	require.NoError(t, store.FreezeAggregate(ctx, "device-001", "second"))
+	again, err := store.ListFrozen(ctx)
+	require.NoError(t, err)
+	require.Len(t, again, 1)
+	assert.Equal(t, "second", again[0].Reason)
+	assert.Equal(t, first[0].FrozenAt, again[0].FrozenAt)
+
+	require.NoError(t, store.UnfreezeAggregate(ctx, "device-001"))
+	frozen, err := store.IsFrozen(ctx, "device-001")
+	require.NoError(t, err)
+	assert.False(t, frozen)
+}
+
+func TestMemoryStore_DeleteProjection(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	base := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{"value": 1}`), memoryTestEvent(base)))
+	require.NoError(t, store.DeleteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{}`), memoryTestEvent(base.Add(time.Second))))
+
+	// Deleted projections are hidden from reads but listed as deleted, state kept
+	_, err := store.GetProjection(ctx, "sensor_state", "device-001")
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+	live, total, err := store.ListProjections(ctx, "sensor_state", 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, live)
+	assert.Equal(t, 0, total)
+	deleted, total, err := store.ListDeleted(ctx, "sensor_state", 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.NotNil(t, deleted[0].DeletedAt)
+	assert.JSONEq(t, `{"value": 1}`, string(deleted[0].State))
+	gone, err := store.GetDeletedProjection(ctx, "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.NotNil(t, gone.DeletedAt)
+
+	// An older event arriving late does not bring it back
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{"value": 0}`), memoryTestEvent(base.Add(-time.Second))))
+	_, err = store.GetProjection(ctx, "sensor_state", "device-001")
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+
+	// A newer event does
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{"value": 2}`), memoryTestEvent(base.Add(2*time.Second))))
+	p, err := store.GetProjection(ctx, "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.Nil(t, p.DeletedAt)
+	assert.JSONEq(t, `{"value": 2}`, string(p.State))
+	_, err = store.GetDeletedProjection(ctx, "sensor_state", "device-001")
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+}
+
+func TestMemoryStore_ChangeListener(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	base := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+	var changes []Change
+	store.SetChangeListener(func(_ context.Context, change Change) {
+		changes = append(changes, change)
+	})
+
+	first := memoryTestEvent(base)
+	first.AggregateType = "device"
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{"value": 1}`), first))
+	// A write that is not accepted is not reported
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{"value": 0}`), memoryTestEvent(base.Add(-time.Second))))
+	require.NoError(t, store.DeleteProjection(ctx, "sensor_state", "device-001",
+		json.RawMessage(`{}`), memoryTestEvent(base.Add(time.Second))))
+
+	require.Len(t, changes, 2)
+	assert.Equal(t, "sensor_state", changes[0].ProjectionType)
+	assert.Equal(t, "device-001", changes[0].AggregateID)
+	assert.Equal(t, "device", changes[0].AggregateType)
+	assert.JSONEq(t, `{"value": 1}`, string(changes[0].State))
+	assert.Equal(t, first.EventID, changes[0].LastEventID)
+	assert.False(t, changes[0].Deleted)
+	assert.True(t, changes[1].Deleted)
+	assert.Nil(t, changes[1].State)
+}
+
+func TestIsTombstone(t *testing.T) {
+	assert.True(t, IsTombstone("sensor.decommissioned"))
+	assert.True(t, IsTombstone("user.deleted"), "<domain>.deleted events are tombstones by convention")
+	assert.False(t, IsTombstone("sensor.reading"))
+}
+
+func TestMemoryStore_ExpireProjections(t *testing.T) {
+	t.Cleanup(clock.Reset)
+	store := NewMemoryStore()
+	ctx := context.Background()
+	base := time.Date(2026, 2, 9, 12, 0, 0, 0, time.UTC)
+
+	clock.Set(clock.FixedClock{Time: base})
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "stale", json.RawMessage(`{}`), memoryTestEvent(base)))
+	require.NoError(t, store.WriteProjection(ctx, "user_session", "stale", json.RawMessage(`{}`), memoryTestEvent(base)))
+	clock.Set(clock.FixedClock{Time: base.Add(time.Hour)})
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "fresh", json.RawMessage(`{}`), memoryTestEvent(base)))
+
+	expired, err := store.ExpireProjections(ctx, "sensor_state", base.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	_, err = store.GetProjection(ctx, "sensor_state", "stale")
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+	_, err = store.GetProjection(ctx, "sensor_state", "fresh")
+	assert.NoError(t, err)
+	_, err = store.GetProjection(ctx, "user_session", "stale")
+	assert.NoError(t, err, "other projection types are not expired")
+
+	// Already expired rows are not counted again
+	expired, err = store.ExpireProjections(ctx, "sensor_state", base.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+}
+
+func TestMemoryStore_GetProjections(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, store.WriteProjection(ctx, "sensor_state", id, json.RawMessage(`{}`), memoryTestEvent(now)))
+	}
+	require.NoError(t, store.DeleteProjection(ctx, "sensor_state", "c", json.RawMessage(`{}`), memoryTestEvent(now.Add(time.Second))))
+
+	found, err := store.GetProjections(ctx, "sensor_state", []string{"a", "missing", "c"})
+	require.NoError(t, err)
+	require.Len(t, found, 1, "missing and deleted projections are omitted")
+	assert.Equal(t, "a", found[0].AggregateID)
+}
+
+func TestMemoryStore_GetAggregateProjections(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, projType := range []string{"user_session", "sensor_state", "test.sensor_state"} {
+		require.NoError(t, store.WriteProjection(ctx, projType, "agg-1", json.RawMessage(`{}`), memoryTestEvent(now)))
+	}
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "agg-2", json.RawMessage(`{}`), memoryTestEvent(now)))
+	require.NoError(t, store.DeleteProjection(ctx, "user_session", "agg-1", json.RawMessage(`{}`), memoryTestEvent(now.Add(time.Second))))
+
+	found, err := store.GetAggregateProjections(ctx, "agg-1", "")
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "sensor_state", found[0].ProjectionType)
+	assert.Equal(t, "test.sensor_state", found[1].ProjectionType)
+
+	// A newer event without an aggregate type keeps the recorded one
+	typed := memoryTestEvent(now.Add(time.Second))
+	typed.AggregateType = "device"
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "agg-1", json.RawMessage(`{}`), typed))
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "agg-1", json.RawMessage(`{}`), memoryTestEvent(now.Add(2*time.Second))))
+
+	found, err = store.GetAggregateProjections(ctx, "agg-1", "device")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "sensor_state", found[0].ProjectionType)
+	assert.Equal(t, "device", found[0].AggregateType)
+
+	found, err = store.GetAggregateProjections(ctx, "agg-1", "session")
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestMemoryStore_SearchProjections(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, id := range []string{"DEV-042", "dev-0420", "sensor-042", "dev-100"} {
+		require.NoError(t, store.WriteProjection(ctx, "sensor_state", id, json.RawMessage(`{}`), memoryTestEvent(now)))
+	}
+	require.NoError(t, store.DeleteProjection(ctx, "sensor_state", "dev-0420", json.RawMessage(`{}`), memoryTestEvent(now.Add(time.Second))))
+
+	found, total, err := store.SearchProjections(ctx, "sensor_state", "dev-04", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total, "case-insensitive, deleted rows excluded")
+	assert.Equal(t, "DEV-042", found[0].AggregateID)
+
+	_, total, err = store.SearchProjections(ctx, "sensor_state", "042", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func TestMemoryStore_RecordFailure(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	failure := DispatchFailure{Consumer: "event-handler", EventID: uuid.Must(uuid.NewV7()), Error: "boom"}
+
+	attempts, quarantined, err := store.RecordFailure(ctx, failure, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.False(t, quarantined)
+
+	attempts, quarantined, err = store.RecordFailure(ctx, failure, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.True(t, quarantined)
+
+	require.NoError(t, store.ClearFailure(ctx, failure.Consumer, failure.EventID))
+	attempts, quarantined, err = store.RecordFailure(ctx, failure, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts, "a quarantined event is kept")
+	assert.True(t, quarantined)
+
+	retrying := DispatchFailure{Consumer: "event-handler", EventID: uuid.Must(uuid.NewV7()), Error: "boom"}
+	_, _, err = store.RecordFailure(ctx, retrying, 2)
+	require.NoError(t, err)
+	other := DispatchFailure{Consumer: "search-indexer", EventID: uuid.Must(uuid.NewV7()), Error: "bang"}
+	_, _, err = store.RecordFailure(ctx, other, 1)
+	require.NoError(t, err)
+
+	listed, total, err := store.ListQuarantined(ctx, "event-handler", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total, "events still being retried are not listed")
+	require.Len(t, listed, 1)
+	assert.Equal(t, failure.EventID, listed[0].EventID)
+	assert.Equal(t, 2, listed[0].Attempts)
+	assert.Equal(t, "boom", listed[0].Error)
+
+	listed, total, err = store.ListQuarantined(ctx, "", 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, listed, 1)
+}
+
+func TestMemoryStore_Aliases(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	require.NoError(t, store.SetAlias(ctx, "sensor_state", "sensor_state_v1"))
+	require.NoError(t, store.SetAlias(ctx, "sensor_state", "sensor_state_v2"), "setting an alias again repoints it")
+	require.NoError(t, store.SetAlias(ctx, "user_session", "user_session_v2"))
+	aliases, err := store.ListAliases(ctx)
+	require.NoError(t, err)
+	require.Len(t, aliases, 2)
+	assert.Equal(t, "sensor_state_v2", aliases[0].ProjectionType)
+
+	require.NoError(t, store.RemoveAlias(ctx, "user_session"))
+	require.NoError(t, store.RemoveAlias(ctx, "user_session"), "removing a missing alias is not an error")
+	aliases, err = store.ListAliases(ctx)
+	require.NoError(t, err)
+	assert.Len(t, aliases, 1)
+}
+
+func TestResolve(t *testing.T) {
+	aliases := []Alias{
+		{Alias: "sensor_state", ProjectionType: "sensor_state_v2"},
+		{Alias: "sensor_state_v2", ProjectionType: "sensor_state_v3"},
+	}
+	assert.Equal(t, "sensor_state_v2", Resolve(aliases, "sensor_state"))
+	assert.Equal(t, "test.sensor_state_v2", Resolve(aliases, "test.sensor_state"))
+	assert.Equal(t, "user_session", Resolve(aliases, "user_session"))
+	assert.Equal(t, "sensor_state_v2", Resolve(aliases, "sensor_state"), "not followed transitively")
+	assert.Equal(t, "sensor_state", Resolve(nil, "sensor_state"))
+}
@@ -0,0 +1,148 @@
+package projections
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Merge folds the state an event contributes (incoming) into a projection's
+// stored state (current). newer reports whether the event is newer than
+// every event folded so far. Every event is folded, late ones included.
+// The stores fold an event into a projection once, keyed by event ID, so a
+// Merge does not see redelivered events again.
+type Merge func(current, incoming json.RawMessage, newer bool) (json.RawMessage, error)
+
+// merges lists the projection types whose events are folded into the
+// stored state instead of replacing it, keyed by base type, with the ops
+// of their FieldMerge. Types not listed are newest-wins: an event older
+// than the stored one is dropped, which suits state snapshots but loses
+// late events for counters and min/max aggregations.
+var (
+	mergesMu sync.RWMutex
+	merges   = map[string]map[string]FieldOp{}
+)
+
+// RegisterMerge folds the events of projType, and of its test namespace,
+// with FieldMerge(ops). The ops rather than a Merge are registered so that
+// PostgresStore can fold in SQL. Register merges at startup, before the
+// stores are used, alongside the handlers writing the type (see
+// eventhandler.Start). Nil ops make the type newest-wins again.
+func RegisterMerge(projType string, ops map[string]FieldOp) {
+	mergesMu.Lock()
+	defer mergesMu.Unlock()
+	if ops == nil {
+		delete(merges, projType)
+		return
+	}
+	merges[projType] = ops
+}
+
+// MergeFor returns the Merge of a projection type, or nil for newest-wins.
+func MergeFor(projType string) Merge {
+	ops := mergeOps(projType)
+	if ops == nil {
+		return nil
+	}
+	return FieldMerge(ops)
+}
+
+// mergeOps returns the registered ops of a projection type, or nil for
+// newest-wins.
+func mergeOps(projType string) map[string]FieldOp {
+	mergesMu.RLock()
+	defer mergesMu.RUnlock()
+	return merges[BaseType(projType)]
+}
+
+// FieldOp combines a numeric field of two states.
+type FieldOp int
+
+const (
+	Sum FieldOp = iota // add the values, e.g. event counts
+	Min                // keep the smaller value
+	Max                // keep the larger value
+)
+
+// FieldMerge returns a Merge for JSON object states. Fields in ops are
+// combined with their op; a field present on one side only is taken as is.
+// Other fields come from the newest event: they are copied from incoming
+// only when newer.
+func FieldMerge(ops map[string]FieldOp) Merge {
+	return func(current, incoming json.RawMessage, newer bool) (json.RawMessage, error) {
+		cur, err := decodeObject(current)
+		if err != nil {
+			return nil, fmt.Errorf("stored state: %w", err)
+		}
+		in, err := decodeObject(incoming)
+		if err != nil {
+			return nil, fmt.Errorf("event state: %w", err)
+		}
+
+		merged := make(map[string]any, len(cur)+len(in))
+		for k, v := range cur {
+			merged[k] = v
+		}
+		if newer {
+			for k, v := range in {
+				if _, ok := ops[k]; !ok {
+					merged[k] = v
+				}
+			}
+		}
+
+		for field, op := range ops {
+			a, aok := cur[field]
+			b, bok := in[field]
+			switch {
+			case !aok && !bok:
+				continue
+			case !aok:
+				merged[field] = b
+				continue
+			case !bok:
+				merged[field] = a
+				continue
+			}
+			x, err := number(a)
+			if err != nil {
+				return nil, fmt.Errorf("stored field %q: %w", field, err)
+			}
+			y, err := number(b)
+			if err != nil {
+				return nil, fmt.Errorf("event field %q: %w", field, err)
+			}
+			switch op {
+			case Sum:
+				merged[field] = x + y
+			case Min:
+				merged[field] = min(x, y)
+			case Max:
+				merged[field] = max(x, y)
+			}
+		}
+
+		return json.Marshal(merged)
+	}
+}
+
+// decodeObject decodes a JSON object, keeping numbers exact.
+func decodeObject(raw json.RawMessage) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("not a JSON object: %w", err)
+	}
+	return obj, nil
+}
+
+// number converts a decoded JSON value to float64.
+func number(v any) (float64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("not a number: %v", v)
+	}
+	return n.Float64()
+}
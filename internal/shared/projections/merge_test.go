@@ -0,0 +1,68 @@
+package projections
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldMerge(t *testing.T) {
+	merge := FieldMerge(map[string]FieldOp{"count": Sum, "low": Min, "high": Max})
+
+	tests := []struct {
+		name     string
+		current  string
+		incoming string
+		newer    bool
+		want     string
+	}{
+		{
+			name:     "newer event",
+			current:  `{"count":3,"low":10,"high":20,"unit":"C"}`,
+			incoming: `{"count":1,"low":12,"high":25,"unit":"F"}`,
+			newer:    true,
+			want:     `{"count":4,"low":10,"high":25,"unit":"F"}`,
+		},
+		{
+			name:     "late event keeps other fields",
+			current:  `{"count":3,"low":10,"high":20,"unit":"C"}`,
+			incoming: `{"count":1,"low":5,"high":15,"unit":"F"}`,
+			want:     `{"count":4,"low":5,"high":20,"unit":"C"}`,
+		},
+		{
+			name:     "field on one side only",
+			current:  `{"count":3}`,
+			incoming: `{"high":7}`,
+			want:     `{"count":3,"high":7}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := merge(json.RawMessage(tt.current), json.RawMessage(tt.incoming), tt.newer)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestFieldMerge_Errors(t *testing.T) {
+	merge := FieldMerge(map[string]FieldOp{"count": Sum})
+
+	_, err := merge(json.RawMessage(`{"count":"three"}`), json.RawMessage(`{"count":1}`), true)
+	assert.ErrorContains(t, err, `stored field "count": not a number`)
+
+	_, err = merge(json.RawMessage(`{"count":1}`), json.RawMessage(`[1]`), true)
+	assert.ErrorContains(t, err, "event state: not a JSON object")
+}
+
+func TestMergeFor(t *testing.T) {
+	RegisterMerge("reading_counts", map[string]FieldOp{"count": Sum})
+	t.Cleanup(func() { RegisterMerge("reading_counts", nil) })
+
+	assert.NotNil(t, MergeFor("reading_counts"))
+	assert.NotNil(t, MergeFor(TypeFor("reading_counts", true)), "test namespace shares the merge")
+	assert.Nil(t, MergeFor("sensor_state"))
+}
@@ -0,0 +1,26 @@
+package projections
+
+import "strings"
+
+// TestNamespace prefixes the projection type of state built from test traffic
+// (events with Metadata.Test set), keeping it apart from real projections.
+const TestNamespace = "test."
+
+// TypeFor returns the projection type to write for an event: the base type,
+// or the base type in the test namespace for test traffic.
+func TypeFor(projType string, test bool) string {
+	if test {
+		return TestNamespace + projType
+	}
+	return projType
+}
+
+// BaseType strips the test namespace from a projection type.
+func BaseType(projType string) string {
+	return strings.TrimPrefix(projType, TestNamespace)
+}
+
+// IsTestType reports whether a projection type is in the test namespace.
+func IsTestType(projType string) bool {
+	return strings.HasPrefix(projType, TestNamespace)
+}
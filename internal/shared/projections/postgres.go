@@ -2,53 +2,105 @@ package projections
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgtrace"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
 )
 
 // PostgresStore implements Store using PostgreSQL.
 type PostgresStore struct {
-	pool   *pgxpool.Pool
-	logger *slog.Logger
+	db       pgretry.Querier // the pool, or a pgretry.DB wrapping it
+	logger   *slog.Logger
+	listener ChangeListener // nil reports no changes
 }
 
 // NewPostgresStore creates a new PostgresStore.
 func NewPostgresStore(pool *pgxpool.Pool, logger *slog.Logger) *PostgresStore {
 	return &PostgresStore{
-		pool:   pool,
+		db:     pool,
 		logger: logger.With("store", "projections"),
 	}
 }
 
+// SetQuerier routes the store's statements through q, typically a
+// pgretry.DB wrapping the pool.
+func (s *PostgresStore) SetQuerier(q pgretry.Querier) {
+	s.db = q
+}
+
+// SetChangeListener reports every accepted projection write and deletion to
+// listener. Call it before the store is used.
+func (s *PostgresStore) SetChangeListener(listener ChangeListener) {
+	s.listener = listener
+}
+
+// changed reports an accepted write to the listener, if any.
+func (s *PostgresStore) changed(ctx context.Context, change Change) {
+	if s.listener != nil {
+		s.listener(ctx, change)
+	}
+}
+
+// stateChecksumSQL computes the integrity checksum of a jsonb value from its
+// canonical text form. Used on write and by FindCorrupt so both agree.
+const stateChecksumSQL = `encode(sha256(convert_to(%s::text, 'UTF8')), 'hex')`
+
+// aggregateTypeSQL is the aggregate_type an upsert keeps: the incoming
+// event's, unless it has none.
+const aggregateTypeSQL = `COALESCE(NULLIF(EXCLUDED.aggregate_type, ''), projections.aggregate_type)`
+
+// newerEventSQL is the upsert condition shared by writes and deletes: the
+// incoming event (EXCLUDED) is newer than the one the row was built from.
+const newerEventSQL = `projections.last_event_timestamp < EXCLUDED.last_event_timestamp
+		   OR (projections.last_event_timestamp = EXCLUDED.last_event_timestamp
+		       AND projections.last_event_id < EXCLUDED.last_event_id)`
+
 // WriteProjection inserts or updates a projection, only if the event is newer.
+// A newer event also clears a previous deletion. Types with a Merge (see
+// RegisterMerge) fold every event into the stored state instead.
 func (s *PostgresStore) WriteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	ctx = pgtrace.WithOperation(ctx, "projections", "write_projection")
+	if ops := mergeOps(projType); ops != nil {
+		return s.foldProjection(ctx, projType, aggregateID, state, event, ops)
+	}
 	// Use ON CONFLICT to handle upsert
 	// Only update if the incoming event is newer than the stored one
-	query := `
-		INSERT INTO projections (projection_type, aggregate_id, state, last_event_id, last_event_timestamp, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW())
+	query := fmt.Sprintf(`
+		INSERT INTO projections (projection_type, aggregate_id, state, last_event_id, last_event_timestamp, updated_at, state_checksum, aggregate_type)
+		VALUES ($1, $2, $3, $4, $5, NOW(), %s, $6)
 		ON CONFLICT (projection_type, aggregate_id) DO UPDATE
 		SET state = EXCLUDED.state,
 		    last_event_id = EXCLUDED.last_event_id,
 		    last_event_timestamp = EXCLUDED.last_event_timestamp,
-		    updated_at = NOW()
-		WHERE projections.last_event_timestamp < EXCLUDED.last_event_timestamp
-		   OR (projections.last_event_timestamp = EXCLUDED.last_event_timestamp
-		       AND projections.last_event_id < EXCLUDED.last_event_id)
-	`
+		    state_checksum = EXCLUDED.state_checksum,
+		    aggregate_type = %s,
+		    updated_at = NOW(),
+		    deleted_at = NULL
+		WHERE %s
+	`, fmt.Sprintf(stateChecksumSQL, "$3::jsonb"), aggregateTypeSQL, newerEventSQL)
 
-	result, err := s.pool.Exec(ctx, query,
+	result, err := s.db.Exec(ctx, query,
 		projType,
 		aggregateID,
 		state,
 		event.EventID,
 		event.EventTime,
+		event.AggregateType,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to write projection: %w", err)
@@ -60,86 +112,320 @@ func (s *PostgresStore) WriteProjection(ctx context.Context, projType, aggregate
 			"aggregate_id", aggregateID,
 			"event_id", event.EventID,
 		)
+		return nil
 	}
 
+	s.changed(ctx, Change{
+		ProjectionType:     projType,
+		AggregateID:        aggregateID,
+		AggregateType:      event.AggregateType,
+		State:              state,
+		LastEventID:        event.EventID,
+		LastEventTimestamp: event.EventTime,
+	})
+	return nil
+}
+
+// foldProjection merges state into the stored projection, late events
+// included, in a single upsert: the fields in ops are combined with jsonb
+// arithmetic on the row as stored, so folds from different lanes (rollup
+// rows are shared by many aggregates) queue on the row lock instead of
+// racing. last_event_* keep tracking the newest event, so freshness and
+// deletion work as for newest-wins types.
+//
+// Each event is folded once: the same statement records it in
+// projection_folds, and an event already recorded there, redelivered or
+// retried after a sibling handler failed, is skipped.
+func (s *PostgresStore) foldProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope, ops map[string]FieldOp) error {
+	newer := "(" + newerEventSQL + ")"
+	merged := foldStateSQL(ops, newer)
+	query := fmt.Sprintf(`
+		WITH fold AS (
+			INSERT INTO projection_folds (projection_type, aggregate_id, event_id)
+			VALUES ($1, $2, $4)
+			ON CONFLICT DO NOTHING
+			RETURNING event_id
+		)
+		INSERT INTO projections (projection_type, aggregate_id, state, last_event_id, last_event_timestamp, updated_at, state_checksum, aggregate_type)
+		SELECT $1, $2, $3::jsonb, $4, $5::timestamptz, NOW(), %s, $6::text FROM fold
+		ON CONFLICT (projection_type, aggregate_id) DO UPDATE
+		SET state = %s,
+		    state_checksum = %s,
+		    last_event_id = CASE WHEN %s THEN EXCLUDED.last_event_id ELSE projections.last_event_id END,
+		    last_event_timestamp = CASE WHEN %s THEN EXCLUDED.last_event_timestamp ELSE projections.last_event_timestamp END,
+		    deleted_at = CASE WHEN %s THEN NULL ELSE projections.deleted_at END,
+		    aggregate_type = %s,
+		    updated_at = NOW()
+		RETURNING state, last_event_id, last_event_timestamp
+	`, fmt.Sprintf(stateChecksumSQL, "$3::jsonb"), merged, fmt.Sprintf(stateChecksumSQL, merged), newer, newer, newer, aggregateTypeSQL)
+
+	change := Change{
+		ProjectionType: projType,
+		AggregateID:    aggregateID,
+		AggregateType:  event.AggregateType,
+	}
+	err := s.db.QueryRow(ctx, query, projType, aggregateID, state, event.EventID, event.EventTime, event.AggregateType).
+		Scan(&change.State, &change.LastEventID, &change.LastEventTimestamp)
+	if errors.Is(err, pgx.ErrNoRows) {
+		s.logger.Debug("projection not updated (event already folded)",
+			"projection_type", projType,
+			"aggregate_id", aggregateID,
+			"event_id", event.EventID,
+		)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write projection: %w", err)
+	}
+	s.changed(ctx, change)
 	return nil
 }
 
+// foldStateSQL is the state a fold writes, as FieldMerge computes it: the
+// stored state, overlaid with the event's other fields when newer is true,
+// and each field in ops combined from both sides. A field on one side only
+// is taken as is. Parenthesized, so it can be cast.
+func foldStateSQL(ops map[string]FieldOp, newer string) string {
+	fields := slices.Sorted(maps.Keys(ops))
+	keys := make([]string, len(fields))
+	for i, field := range fields {
+		keys[i] = "'" + strings.ReplaceAll(field, "'", "''") + "'"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "(CASE WHEN %s THEN projections.state || (EXCLUDED.state - ARRAY[%s]::text[]) ELSE projections.state END)",
+		newer, strings.Join(keys, ", "))
+	for i, field := range fields {
+		key := keys[i]
+		stored := fmt.Sprintf("(projections.state->>%s)::numeric", key)
+		incoming := fmt.Sprintf("(EXCLUDED.state->>%s)::numeric", key)
+		var combined string
+		switch ops[field] {
+		case Sum:
+			combined = fmt.Sprintf("COALESCE(%s, 0) + COALESCE(%s, 0)", stored, incoming)
+		case Min:
+			combined = fmt.Sprintf("LEAST(%s, %s)", stored, incoming)
+		case Max:
+			combined = fmt.Sprintf("GREATEST(%s, %s)", stored, incoming)
+		}
+		fmt.Fprintf(&b, "\n\t\t    || CASE WHEN projections.state ? %[1]s OR EXCLUDED.state ? %[1]s THEN jsonb_build_object(%[1]s, %[2]s) ELSE '{}'::jsonb END",
+			key, combined)
+	}
+	return "(" + b.String() + ")"
+}
+
 // GetProjection retrieves a single projection by type and aggregate ID.
+// Deleted projections are reported as missing (pgx.ErrNoRows).
 func (s *PostgresStore) GetProjection(ctx context.Context, projType, aggregateID string) (*Projection, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "get_projection")
+	rows, err := s.db.Query(ctx, `
+		SELECT `+projectionColumns(ctx)+`
+		FROM projections
+		WHERE projection_type = $1 AND aggregate_id = $2 AND deleted_at IS NULL
+	`, projType, aggregateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projection: %w", err)
+	}
+	found, err := scanProjections(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("failed to get projection: %w", pgx.ErrNoRows)
+	}
+	return &found[0], nil
+}
+
+// GetDeletedProjection retrieves a single deleted projection by type and
+// aggregate ID. Live projections are reported as missing (pgx.ErrNoRows).
+func (s *PostgresStore) GetDeletedProjection(ctx context.Context, projType, aggregateID string) (*Projection, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "get_deleted_projection")
+	rows, err := s.db.Query(ctx, `
+		SELECT `+projectionColumns(ctx)+`
+		FROM projections
+		WHERE projection_type = $1 AND aggregate_id = $2 AND deleted_at IS NOT NULL
+	`, projType, aggregateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted projection: %w", err)
+	}
+	found, err := scanProjections(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("failed to get deleted projection: %w", pgx.ErrNoRows)
+	}
+	return &found[0], nil
+}
+
+// GetProjections retrieves the live projections of one type for a set of
+// aggregate IDs. Missing and deleted projections are omitted.
+func (s *PostgresStore) GetProjections(ctx context.Context, projType string, aggregateIDs []string) ([]Projection, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "get_projections")
 	query := `
-		SELECT projection_id, projection_type, aggregate_id, state,
-		       last_event_id, last_event_timestamp, updated_at
+		SELECT ` + projectionColumns(ctx) + `
 		FROM projections
-		WHERE projection_type = $1 AND aggregate_id = $2
+		WHERE projection_type = $1 AND aggregate_id = ANY($2) AND deleted_at IS NULL
 	`
 
-	var p Projection
-	var projID, lastEventID uuid.UUID
-	var lastEventTimestamp, updatedAt time.Time
-
-	err := s.pool.QueryRow(ctx, query, projType, aggregateID).Scan(
-		&projID,
-		&p.ProjectionType,
-		&p.AggregateID,
-		&p.State,
-		&lastEventID,
-		&lastEventTimestamp,
-		&updatedAt,
-	)
+	rows, err := s.db.Query(ctx, query, projType, aggregateIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get projection: %w", err)
+		return nil, fmt.Errorf("failed to get projections: %w", err)
 	}
+	return scanProjections(rows)
+}
 
-	p.ProjectionID = projID
-	p.LastEventID = lastEventID
-	p.LastEventTimestamp = lastEventTimestamp
-	p.UpdatedAt = updatedAt
+// GetAggregateProjections retrieves every live projection of one aggregate,
+// across projection types, ordered by type. A non-empty aggregateType keeps
+// only projections of aggregates of that type.
+func (s *PostgresStore) GetAggregateProjections(ctx context.Context, aggregateID, aggregateType string) ([]Projection, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "get_aggregate_projections")
+	query := `
+		SELECT ` + projectionColumns(ctx) + `
+		FROM projections
+		WHERE aggregate_id = $1 AND ($2 = '' OR aggregate_type = $2) AND deleted_at IS NULL
+		ORDER BY projection_type
+	`
 
-	return &p, nil
+	rows, err := s.db.Query(ctx, query, aggregateID, aggregateType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aggregate projections: %w", err)
+	}
+	return scanProjections(rows)
 }
 
-// ListProjections retrieves projections by type with pagination.
+// ListProjections retrieves live projections by type with pagination.
 func (s *PostgresStore) ListProjections(ctx context.Context, projType string, limit, offset int) ([]Projection, int, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "list_projections")
+	return s.list(ctx, `projection_type = $1 AND deleted_at IS NULL`, []any{projType}, limit, offset)
+}
+
+// SearchProjections retrieves live projections by type whose aggregate ID
+// contains q (case-insensitive), with pagination. Served by the trigram index
+// for terms of three or more characters.
+func (s *PostgresStore) SearchProjections(ctx context.Context, projType, q string, limit, offset int) ([]Projection, int, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "search_projections")
+	pattern := "%" + likeEscaper.Replace(q) + "%"
+	return s.list(ctx, `projection_type = $1 AND deleted_at IS NULL AND aggregate_id ILIKE $2`, []any{projType, pattern}, limit, offset)
+}
+
+// likeEscaper escapes LIKE wildcards so a search term matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListDeleted retrieves deleted projections by type with pagination.
+func (s *PostgresStore) ListDeleted(ctx context.Context, projType string, limit, offset int) ([]Projection, int, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "list_deleted")
+	return s.list(ctx, `projection_type = $1 AND deleted_at IS NOT NULL`, []any{projType}, limit, offset)
+}
+
+// ListAnomalous retrieves live projections by type that have any of the given
+// anomaly flags set in state->'anomaly', with pagination.
+func (s *PostgresStore) ListAnomalous(ctx context.Context, projType string, flags []string, limit, offset int) ([]Projection, int, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "list_anomalous")
+	where := `projection_type = $1
+		  AND deleted_at IS NULL
+		  AND EXISTS (
+			SELECT 1 FROM unnest($2::text[]) AS flag
+			WHERE state->'anomaly'->>flag = 'true'
+		  )`
+	return s.list(ctx, where, []any{projType, flags}, limit, offset)
+}
+
+// ListRegistered retrieves live projections by type whose aggregate's registry
+// entry matches filter, with pagination. The registry is joined in SQL (both
+// tables live in the eventhandler database), so the count and pages stay exact.
+func (s *PostgresStore) ListRegistered(ctx context.Context, projType string, filter registry.Filter, limit, offset int) ([]Projection, int, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "list_registered")
+	where := `projection_type = $1
+		  AND deleted_at IS NULL
+		  AND EXISTS (
+			SELECT 1 FROM aggregate_registry r
+			WHERE r.aggregate_id = projections.aggregate_id
+			  AND ($2 = '' OR r.aggregate_type = $2)
+			  AND ($3 = '' OR r.site = $3)
+			  AND ($4 = '' OR r.model = $4)
+			  AND ($5 = '' OR r.owner = $5)
+			  AND ($6 = '' OR $6 = ANY(r.tags))
+		  )`
+	args := []any{projType, filter.AggregateType, filter.Site, filter.Model, filter.Owner, filter.Tag}
+	return s.list(ctx, where, args, limit, offset)
+}
+
+// list runs a paginated projection query. where may reference args as $1..$n;
+// limit and offset are appended as $n+1 and $n+2.
+func (s *PostgresStore) list(ctx context.Context, where string, args []any, limit, offset int) ([]Projection, int, error) {
 	// Get total count
-	countSQL := `SELECT COUNT(*) FROM projections WHERE projection_type = $1`
+	countSQL := `SELECT COUNT(*) FROM projections WHERE ` + where
 	var total int
-	if err := s.pool.QueryRow(ctx, countSQL, projType).Scan(&total); err != nil {
+	if err := s.db.QueryRow(ctx, countSQL, args...).Scan(&total); err != nil {
 		return nil, 0, fmt.Errorf("failed to count projections: %w", err)
 	}
 
 	// Get projections with pagination
-	listSQL := `
-		SELECT projection_id, projection_type, aggregate_id, state,
-		       last_event_id, last_event_timestamp, updated_at
+	listSQL := fmt.Sprintf(`
+		SELECT %s
 		FROM projections
-		WHERE projection_type = $1
+		WHERE %s
 		ORDER BY updated_at DESC
-		LIMIT $2 OFFSET $3
-	`
+		LIMIT $%d OFFSET $%d
+	`, projectionColumns(ctx), where, len(args)+1, len(args)+2)
 
-	rows, err := s.pool.Query(ctx, listSQL, projType, limit, offset)
+	rows, err := s.db.Query(ctx, listSQL, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list projections: %w", err)
 	}
+	projections, err := scanProjections(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return projections, total, nil
+}
+
+// projectionColumns are the columns scanProjections reads. The last is the
+// aggregate's registry entry when ctx was made by WithRegistry, and NULL
+// otherwise; a correlated subquery keeps the other columns unambiguous in the
+// callers' WHERE and ORDER BY clauses.
+func projectionColumns(ctx context.Context) string {
+	registryColumn := "NULL::jsonb"
+	if RegistryRequested(ctx) {
+		registryColumn = "(SELECT to_jsonb(r) FROM aggregate_registry r WHERE r.aggregate_id = projections.aggregate_id)"
+	}
+	return `projection_id, projection_type, aggregate_id, aggregate_type, state,
+		       last_event_id, last_event_timestamp, updated_at, deleted_at, ` + registryColumn
+}
+
+// scanProjections reads projection rows selected with projectionColumns,
+// closing rows. It returns an empty slice, not nil, when there are none.
+func scanProjections(rows pgx.Rows) ([]Projection, error) {
 	defer rows.Close()
 
-	var projections []Projection
+	projections := []Projection{}
 	for rows.Next() {
 		var p Projection
 		var projID, lastEventID uuid.UUID
 		var lastEventTimestamp, updatedAt time.Time
+		var entry []byte
 
 		if err := rows.Scan(
 			&projID,
 			&p.ProjectionType,
 			&p.AggregateID,
+			&p.AggregateType,
 			&p.State,
 			&lastEventID,
 			&lastEventTimestamp,
 			&updatedAt,
+			&p.DeletedAt,
+			&entry,
 		); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan projection: %w", err)
+			return nil, fmt.Errorf("failed to scan projection: %w", err)
+		}
+		if entry != nil {
+			p.Registry = &registry.Aggregate{}
+			if err := json.Unmarshal(entry, p.Registry); err != nil {
+				return nil, fmt.Errorf("failed to decode registry entry of %s: %w", p.AggregateID, err)
+			}
 		}
 
 		p.ProjectionID = projID
@@ -150,15 +436,572 @@ func (s *PostgresStore) ListProjections(ctx context.Context, projType string, li
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating projections: %w", err)
+		return nil, fmt.Errorf("error iterating projections: %w", err)
+	}
+	return projections, nil
+}
+
+// FindCorrupt returns up to limit projections whose state no longer matches
+// the checksum stored at write time. Rows written before checksums existed are
+// backfilled by migration, so a NULL checksum also counts as a discrepancy.
+func (s *PostgresStore) FindCorrupt(ctx context.Context, limit int) ([]Discrepancy, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "find_corrupt")
+	query := fmt.Sprintf(`
+		SELECT projection_type, aggregate_id, last_event_id, stored, actual
+		FROM (
+			SELECT projection_type, aggregate_id, last_event_id,
+			       COALESCE(state_checksum, '') AS stored,
+			       %s AS actual
+			FROM projections
+		) p
+		WHERE stored <> actual
+		ORDER BY projection_type, aggregate_id
+		LIMIT $1
+	`, fmt.Sprintf(stateChecksumSQL, "state"))
+
+	rows, err := s.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify projections: %w", err)
+	}
+	defer rows.Close()
+
+	discrepancies := []Discrepancy{}
+	for rows.Next() {
+		var d Discrepancy
+		if err := rows.Scan(&d.ProjectionType, &d.AggregateID, &d.LastEventID, &d.StoredChecksum, &d.ActualChecksum); err != nil {
+			return nil, fmt.Errorf("failed to scan discrepancy: %w", err)
+		}
+		discrepancies = append(discrepancies, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating discrepancies: %w", err)
 	}
 
-	// Return empty slice instead of nil if no results
-	if projections == nil {
-		projections = []Projection{}
+	return discrepancies, nil
+}
+
+// RepairProjection overwrites a projection unconditionally (no newer-event
+// check), recomputing its checksum. Used to rebuild corrupted rows.
+func (s *PostgresStore) RepairProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	ctx = pgtrace.WithOperation(ctx, "projections", "repair_projection")
+	query := fmt.Sprintf(`
+		INSERT INTO projections (projection_type, aggregate_id, state, last_event_id, last_event_timestamp, updated_at, state_checksum, aggregate_type)
+		VALUES ($1, $2, $3, $4, $5, NOW(), %s, $6)
+		ON CONFLICT (projection_type, aggregate_id) DO UPDATE
+		SET state = EXCLUDED.state,
+		    last_event_id = EXCLUDED.last_event_id,
+		    last_event_timestamp = EXCLUDED.last_event_timestamp,
+		    state_checksum = EXCLUDED.state_checksum,
+		    aggregate_type = %s,
+		    updated_at = NOW()
+	`, fmt.Sprintf(stateChecksumSQL, "$3::jsonb"), aggregateTypeSQL)
+
+	if _, err := s.db.Exec(ctx, query, projType, aggregateID, state, event.EventID, event.EventTime, event.AggregateType); err != nil {
+		return fmt.Errorf("failed to repair projection: %w", err)
 	}
 
-	return projections, total, nil
+	s.logger.Info("projection repaired",
+		"projection_type", projType,
+		"aggregate_id", aggregateID,
+		"event_id", event.EventID,
+	)
+	return nil
+}
+
+// DeleteProjection soft-deletes a projection, only if the event is newer. The
+// stored state is kept for audit; a projection that does not exist yet is
+// created deleted, with the tombstone's payload as state, so that older events
+// arriving later cannot bring it back.
+func (s *PostgresStore) DeleteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	ctx = pgtrace.WithOperation(ctx, "projections", "delete_projection")
+	query := fmt.Sprintf(`
+		INSERT INTO projections (projection_type, aggregate_id, state, last_event_id, last_event_timestamp, updated_at, state_checksum, deleted_at, aggregate_type)
+		VALUES ($1, $2, $3, $4, $5, NOW(), %s, NOW(), $6)
+		ON CONFLICT (projection_type, aggregate_id) DO UPDATE
+		SET last_event_id = EXCLUDED.last_event_id,
+		    last_event_timestamp = EXCLUDED.last_event_timestamp,
+		    aggregate_type = %s,
+		    updated_at = NOW(),
+		    deleted_at = COALESCE(projections.deleted_at, NOW())
+		WHERE %s
+	`, fmt.Sprintf(stateChecksumSQL, "$3::jsonb"), aggregateTypeSQL, newerEventSQL)
+
+	result, err := s.db.Exec(ctx, query, projType, aggregateID, state, event.EventID, event.EventTime, event.AggregateType)
+	if err != nil {
+		return fmt.Errorf("failed to delete projection: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		s.logger.Debug("projection not deleted (event not newer)",
+			"projection_type", projType,
+			"aggregate_id", aggregateID,
+			"event_id", event.EventID,
+		)
+		return nil
+	}
+
+	s.changed(ctx, Change{
+		ProjectionType:     projType,
+		AggregateID:        aggregateID,
+		AggregateType:      event.AggregateType,
+		LastEventID:        event.EventID,
+		LastEventTimestamp: event.EventTime,
+		Deleted:            true,
+	})
+	return nil
+}
+
+// ExpireProjections soft-deletes live projections of projType last updated
+// before cutoff, returning how many were expired. updated_at is left as is so
+// the deleted row still shows when the aggregate was last seen.
+func (s *PostgresStore) ExpireProjections(ctx context.Context, projType string, cutoff time.Time) (int, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "expire_projections")
+	query := `
+		UPDATE projections
+		SET deleted_at = NOW()
+		WHERE projection_type = $1 AND deleted_at IS NULL AND updated_at < $2
+	`
+	result, err := s.db.Exec(ctx, query, projType, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire projections: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// PurgeFolds deletes the records of events folded into merged projections
+// before cutoff, returning how many were deleted. A purged event that is
+// redelivered is folded again, so cutoff must lie beyond the redelivery
+// horizon.
+func (s *PostgresStore) PurgeFolds(ctx context.Context, cutoff time.Time) (int, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "purge_folds")
+	result, err := s.db.Exec(ctx, `DELETE FROM projection_folds WHERE folded_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge fold records: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// FreezeAggregate marks an aggregate frozen. Re-freezing an already frozen
+// aggregate updates the reason but keeps the original frozen_at.
+func (s *PostgresStore) FreezeAggregate(ctx context.Context, aggregateID, reason string) error {
+	ctx = pgtrace.WithOperation(ctx, "projections", "freeze_aggregate")
+	query := `
+		INSERT INTO aggregate_flags (aggregate_id, frozen, frozen_reason, frozen_at, updated_at)
+		VALUES ($1, TRUE, $2, NOW(), NOW())
+		ON CONFLICT (aggregate_id) DO UPDATE
+		SET frozen = TRUE,
+		    frozen_reason = EXCLUDED.frozen_reason,
+		    frozen_at = CASE WHEN aggregate_flags.frozen THEN aggregate_flags.frozen_at ELSE NOW() END,
+		    updated_at = NOW()
+	`
+	if _, err := s.db.Exec(ctx, query, aggregateID, reason); err != nil {
+		return fmt.Errorf("failed to freeze aggregate: %w", err)
+	}
+	return nil
+}
+
+// UnfreezeAggregate clears an aggregate's frozen flag. Unfreezing an aggregate
+// that is not frozen is a no-op.
+func (s *PostgresStore) UnfreezeAggregate(ctx context.Context, aggregateID string) error {
+	ctx = pgtrace.WithOperation(ctx, "projections", "unfreeze_aggregate")
+	query := `
+		UPDATE aggregate_flags
+		SET frozen = FALSE, frozen_reason = '', frozen_at = NULL, updated_at = NOW()
+		WHERE aggregate_id = $1
+	`
+	if _, err := s.db.Exec(ctx, query, aggregateID); err != nil {
+		return fmt.Errorf("failed to unfreeze aggregate: %w", err)
+	}
+	return nil
+}
+
+// IsFrozen reports whether an aggregate is frozen.
+func (s *PostgresStore) IsFrozen(ctx context.Context, aggregateID string) (bool, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "is_frozen")
+	query := `SELECT EXISTS (SELECT 1 FROM aggregate_flags WHERE aggregate_id = $1 AND frozen)`
+
+	var frozen bool
+	if err := s.db.QueryRow(ctx, query, aggregateID).Scan(&frozen); err != nil {
+		return false, fmt.Errorf("failed to check aggregate flags: %w", err)
+	}
+	return frozen, nil
+}
+
+// ListFrozen returns all frozen aggregates, most recently frozen first.
+func (s *PostgresStore) ListFrozen(ctx context.Context) ([]FrozenAggregate, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "list_frozen")
+	query := `
+		SELECT aggregate_id, frozen_reason, frozen_at
+		FROM aggregate_flags
+		WHERE frozen
+		ORDER BY frozen_at DESC, aggregate_id
+	`
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list frozen aggregates: %w", err)
+	}
+	defer rows.Close()
+
+	frozen := []FrozenAggregate{}
+	for rows.Next() {
+		var f FrozenAggregate
+		if err := rows.Scan(&f.AggregateID, &f.Reason, &f.FrozenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan frozen aggregate: %w", err)
+		}
+		frozen = append(frozen, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating frozen aggregates: %w", err)
+	}
+
+	return frozen, nil
+}
+
+// AdvanceWatermark records eventTime for the aggregate of an aggregation and
+// returns the latest event time seen, eventTime included.
+func (s *PostgresStore) AdvanceWatermark(ctx context.Context, aggregation, aggregateID string, eventTime time.Time) (time.Time, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "advance_watermark")
+	query := `
+		INSERT INTO aggregation_watermarks (aggregation, aggregate_id, max_event_time)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (aggregation, aggregate_id) DO UPDATE
+		SET max_event_time = GREATEST(aggregation_watermarks.max_event_time, EXCLUDED.max_event_time),
+		    updated_at = NOW()
+		RETURNING max_event_time
+	`
+
+	var latest time.Time
+	if err := s.db.QueryRow(ctx, query, aggregation, aggregateID, eventTime).Scan(&latest); err != nil {
+		return time.Time{}, fmt.Errorf("failed to advance watermark: %w", err)
+	}
+	return latest, nil
+}
+
+// maxFoldAttempts bounds the optimistic read-merge-write cycles of a
+// window fold. Events of one aggregate are handled in order, so conflicts
+// are rare.
+const maxFoldAttempts = 5
+
+// FoldWindow applies fold to the window of an aggregation starting at start,
+// creating it if needed. The row is read with its xmin, folded in Go and
+// updated only where xmin is unchanged; a concurrent write makes the update
+// match no row, and the fold is retried on the new state.
+func (s *PostgresStore) FoldWindow(ctx context.Context, aggregation, aggregateID string, start, end time.Time, fold WindowFold) error {
+	ctx = pgtrace.WithOperation(ctx, "projections", "fold_window")
+
+	for attempt := 1; attempt <= maxFoldAttempts; attempt++ {
+		var stored []byte
+		var version string
+		err := s.db.QueryRow(ctx, `
+			SELECT state, xmin::text
+			FROM aggregation_windows
+			WHERE aggregation = $1 AND aggregate_id = $2 AND window_start = $3
+		`, aggregation, aggregateID, start).Scan(&stored, &version)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to read window: %w", err)
+		}
+		exists := err == nil
+
+		next, err := fold(stored)
+		if err != nil {
+			return fmt.Errorf("failed to fold window: %w", err)
+		}
+		if next == nil {
+			return nil
+		}
+
+		var result pgconn.CommandTag
+		if exists {
+			result, err = s.db.Exec(ctx, `
+				UPDATE aggregation_windows
+				SET state = $4, updated_at = NOW()
+				WHERE aggregation = $1 AND aggregate_id = $2 AND window_start = $3 AND xmin::text = $5
+			`, aggregation, aggregateID, start, next, version)
+		} else {
+			result, err = s.db.Exec(ctx, `
+				INSERT INTO aggregation_windows (aggregation, aggregate_id, window_start, window_end, state)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (aggregation, aggregate_id, window_start) DO NOTHING
+			`, aggregation, aggregateID, start, end, next)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write window: %w", err)
+		}
+		if result.RowsAffected() == 1 {
+			return nil
+		}
+		s.logger.Debug("window changed while folding, retrying",
+			"aggregation", aggregation,
+			"aggregate_id", aggregateID,
+			"attempt", attempt,
+		)
+	}
+	return fmt.Errorf("failed to write window: changed concurrently %d times", maxFoldAttempts)
+}
+
+// ClosedWindows returns the windows of an aggregate that end at or before
+// watermark, oldest first.
+func (s *PostgresStore) ClosedWindows(ctx context.Context, aggregation, aggregateID string, watermark time.Time) ([]Window, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "closed_windows")
+	query := `
+		SELECT window_start, window_end, state
+		FROM aggregation_windows
+		WHERE aggregation = $1 AND aggregate_id = $2 AND window_end <= $3
+		ORDER BY window_start
+	`
+
+	rows, err := s.db.Query(ctx, query, aggregation, aggregateID, watermark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read closed windows: %w", err)
+	}
+	defer rows.Close()
+
+	var closed []Window
+	for rows.Next() {
+		w := Window{Aggregation: aggregation, AggregateID: aggregateID}
+		if err := rows.Scan(&w.Start, &w.End, &w.State); err != nil {
+			return nil, fmt.Errorf("failed to scan window: %w", err)
+		}
+		closed = append(closed, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating windows: %w", err)
+	}
+	return closed, nil
+}
+
+// DeleteWindows removes the windows of an aggregate that end at or before
+// through.
+func (s *PostgresStore) DeleteWindows(ctx context.Context, aggregation, aggregateID string, through time.Time) error {
+	ctx = pgtrace.WithOperation(ctx, "projections", "delete_windows")
+	query := `
+		DELETE FROM aggregation_windows
+		WHERE aggregation = $1 AND aggregate_id = $2 AND window_end <= $3
+	`
+
+	if _, err := s.db.Exec(ctx, query, aggregation, aggregateID, through); err != nil {
+		return fmt.Errorf("failed to delete windows: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure counts a failed dispatch of an event in the DLQ and reports
+// how many attempts have failed so far. The attempt reaching maxAttempts
+// quarantines the event (status 'pending'); an event already quarantined
+// stays so and is reported as such.
+func (s *PostgresStore) RecordFailure(ctx context.Context, f DispatchFailure, maxAttempts int) (int, bool, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "record_failure")
+	query := `
+		INSERT INTO dlq (consumer, event_id, event_payload, error_message, topic, kafka_partition, kafka_offset, retry_count, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 1, CASE WHEN $8::int <= 1 THEN 'pending' ELSE 'retrying' END)
+		ON CONFLICT (consumer, event_id) DO UPDATE
+		SET error_message = EXCLUDED.error_message,
+		    topic = EXCLUDED.topic,
+		    kafka_partition = EXCLUDED.kafka_partition,
+		    kafka_offset = EXCLUDED.kafka_offset,
+		    failed_at = NOW(),
+		    retry_count = dlq.retry_count + 1,
+		    status = CASE WHEN dlq.retry_count + 1 >= $8::int THEN 'pending' ELSE 'retrying' END
+		WHERE dlq.status = 'retrying'
+		RETURNING retry_count, status
+	`
+
+	var attempts int
+	var status string
+	err := s.db.QueryRow(ctx, query,
+		f.Consumer, f.EventID, []byte(f.Payload), f.Error, f.Topic, f.Partition, f.Offset, maxAttempts,
+	).Scan(&attempts, &status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Already out of the retrying state; report the recorded attempts
+		err = s.db.QueryRow(ctx, `SELECT retry_count FROM dlq WHERE consumer = $1 AND event_id = $2`,
+			f.Consumer, f.EventID).Scan(&attempts)
+		status = "pending"
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to record dispatch failure: %w", err)
+	}
+	return attempts, status != "retrying", nil
+}
+
+// ClearFailure forgets the failed attempts of an event that has since been
+// dispatched. Quarantined events are kept.
+func (s *PostgresStore) ClearFailure(ctx context.Context, consumer string, eventID uuid.UUID) error {
+	ctx = pgtrace.WithOperation(ctx, "projections", "clear_failure")
+	query := `DELETE FROM dlq WHERE consumer = $1 AND event_id = $2 AND status = 'retrying'`
+	if _, err := s.db.Exec(ctx, query, consumer, eventID); err != nil {
+		return fmt.Errorf("failed to clear dispatch failure: %w", err)
+	}
+	return nil
+}
+
+// ListQuarantined returns a page of the events quarantined in the DLQ,
+// most recently failed first, and how many there are in all. An empty
+// consumer lists the quarantined events of every consumer group.
+func (s *PostgresStore) ListQuarantined(ctx context.Context, consumer string, limit, offset int) ([]QuarantinedEvent, int, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "list_quarantined")
+	where := `status = 'pending' AND ($1 = '' OR consumer = $1)`
+
+	var total int
+	if err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM dlq WHERE `+where, consumer).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count quarantined events: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT consumer, event_id, event_payload, error_message, retry_count, topic, kafka_partition, kafka_offset, failed_at
+		FROM dlq
+		WHERE `+where+`
+		ORDER BY failed_at DESC, event_id
+		LIMIT $2 OFFSET $3
+	`, consumer, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list quarantined events: %w", err)
+	}
+	defer rows.Close()
+
+	quarantined := []QuarantinedEvent{}
+	for rows.Next() {
+		var q QuarantinedEvent
+		if err := rows.Scan(&q.Consumer, &q.EventID, &q.Payload, &q.Error, &q.Attempts,
+			&q.Topic, &q.Partition, &q.Offset, &q.FailedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan quarantined event: %w", err)
+		}
+		quarantined = append(quarantined, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating quarantined events: %w", err)
+	}
+	return quarantined, total, nil
+}
+
+// SetAlias serves reads of alias from projType, replacing any previous
+// target in one statement.
+func (s *PostgresStore) SetAlias(ctx context.Context, alias, projType string) error {
+	ctx = pgtrace.WithOperation(ctx, "projections", "set_alias")
+	query := `
+		INSERT INTO projection_aliases (alias, projection_type)
+		VALUES ($1, $2)
+		ON CONFLICT (alias) DO UPDATE
+		SET projection_type = EXCLUDED.projection_type, updated_at = NOW()
+	`
+	if _, err := s.db.Exec(ctx, query, alias, projType); err != nil {
+		return fmt.Errorf("failed to set projection alias: %w", err)
+	}
+	return nil
+}
+
+// RemoveAlias serves alias from its own rows again. Removing a missing alias
+// is a no-op.
+func (s *PostgresStore) RemoveAlias(ctx context.Context, alias string) error {
+	ctx = pgtrace.WithOperation(ctx, "projections", "remove_alias")
+	if _, err := s.db.Exec(ctx, `DELETE FROM projection_aliases WHERE alias = $1`, alias); err != nil {
+		return fmt.Errorf("failed to remove projection alias: %w", err)
+	}
+	return nil
+}
+
+// ListAliases returns all projection aliases, by alias.
+func (s *PostgresStore) ListAliases(ctx context.Context) ([]Alias, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "list_aliases")
+	rows, err := s.db.Query(ctx, `SELECT alias, projection_type, updated_at FROM projection_aliases ORDER BY alias`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projection aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := []Alias{}
+	for rows.Next() {
+		var a Alias
+		if err := rows.Scan(&a.Alias, &a.ProjectionType, &a.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan projection alias: %w", err)
+		}
+		aliases = append(aliases, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating projection aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// ScanProjections returns up to limit projections of one type, deleted ones
+// included, with aggregate IDs after afterAggregateID, in aggregate ID order.
+func (s *PostgresStore) ScanProjections(ctx context.Context, projType, afterAggregateID string, limit int) ([]Projection, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "scan_projections")
+	rows, err := s.db.Query(ctx, `
+		SELECT `+projectionColumns(ctx)+`
+		FROM projections
+		WHERE projection_type = $1 AND aggregate_id > $2
+		ORDER BY aggregate_id
+		LIMIT $3
+	`, projType, afterAggregateID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan projections: %w", err)
+	}
+	return scanProjections(rows)
+}
+
+// ReplaceState sets a projection's state to state, recomputing its checksum,
+// if it is still old. Its event position and update time are kept, so the
+// rewrite neither blocks older events nor delays TTL expiry.
+func (s *PostgresStore) ReplaceState(ctx context.Context, projType, aggregateID string, old, state json.RawMessage) (bool, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "replace_state")
+	query := fmt.Sprintf(`
+		UPDATE projections
+		SET state = $3::jsonb, state_checksum = %s
+		WHERE projection_type = $1 AND aggregate_id = $2 AND state = $4::jsonb
+	`, fmt.Sprintf(stateChecksumSQL, "$3::jsonb"))
+
+	tag, err := s.db.Exec(ctx, query, projType, aggregateID, state, old)
+	if err != nil {
+		return false, fmt.Errorf("failed to replace projection state: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// SQLTransform returns a transformer evaluating expr, a jsonb expression over
+// the column state, in the database. For example:
+//
+//	state - 'temperature' || jsonb_build_object('temp_f', state->'temperature')
+//
+// The expression only sees the states passed to it; it runs with the store's
+// privileges, so it must come from an operator, never from a request.
+func (s *PostgresStore) SQLTransform(expr string) StateTransformer {
+	return &sqlTransform{db: s.db, expr: expr}
+}
+
+type sqlTransform struct {
+	db   pgretry.Querier
+	expr string
+}
+
+func (t *sqlTransform) TransformStates(ctx context.Context, states []json.RawMessage) ([]json.RawMessage, error) {
+	ctx = pgtrace.WithOperation(ctx, "projections", "transform_states")
+	in := make([]string, len(states))
+	for i, state := range states {
+		in[i] = string(state)
+	}
+	rows, err := t.db.Query(ctx, fmt.Sprintf(`
+		SELECT (%s)::jsonb
+		FROM unnest($1::jsonb[]) WITH ORDINALITY AS t(state, ord)
+		ORDER BY ord
+	`, t.expr), in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate transform: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]json.RawMessage, 0, len(states))
+	for rows.Next() {
+		var state json.RawMessage
+		if err := rows.Scan(&state); err != nil {
+			return nil, fmt.Errorf("failed to evaluate transform: %w", err)
+		}
+		out = append(out, state)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to evaluate transform: %w", err)
+	}
+	return out, nil
 }
 
 // Ensure PostgresStore implements Store
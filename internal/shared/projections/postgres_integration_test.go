@@ -7,51 +7,41 @@ import (
 	"encoding/json"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/domain/ids"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
 	"github.com/cornjacket/platform-services/internal/testutil"
 )
 
 var testPool *pgxpool.Pool
 
 func TestMain(m *testing.M) {
-	pool := testutil.MustNewTestPool()
-	testutil.MustDropAllTables(pool)
+	pool, drop := testutil.MustNewSchemaPool("projections")
 	testutil.MustRunMigrations(pool, "../../services/eventhandler/migrations")
 	testPool = pool
-	defer pool.Close()
-	os.Exit(m.Run())
+	code := m.Run()
+	drop()
+	os.Exit(code)
 }
 
 func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
 }
 
-func testEnvelope(t *testing.T, eventTime time.Time) *events.Envelope {
-	t.Helper()
-	return &events.Envelope{
-		EventID:     uuid.Must(uuid.NewV7()),
-		EventType:   "sensor.reading",
-		AggregateID: "device-001",
-		EventTime:   eventTime,
-		IngestedAt:  time.Now().UTC().Truncate(time.Microsecond),
-		Payload:     json.RawMessage(`{"temperature": 22.5}`),
-		Metadata:    events.Metadata{Source: "test", SchemaVersion: 1},
-	}
-}
-
 func TestWriteProjection_Insert(t *testing.T) {
 	testutil.TruncateTables(t, testPool, "projections")
 	store := NewPostgresStore(testPool, testLogger())
 
-	env := testEnvelope(t, time.Now().UTC().Truncate(time.Microsecond))
+	env := testutil.Event().At(time.Now().UTC().Truncate(time.Microsecond)).Build()
 	state := json.RawMessage(`{"status": "active"}`)
 
 	err := store.WriteProjection(context.Background(), "sensor_state", "device-001", state, env)
@@ -73,8 +63,8 @@ func TestWriteProjection_UpdateNewer(t *testing.T) {
 	oldTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
 	newTime := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
 
-	envOld := testEnvelope(t, oldTime)
-	envNew := testEnvelope(t, newTime)
+	envOld := testutil.Event().At(oldTime).Build()
+	envNew := testutil.Event().At(newTime).Build()
 
 	// Write old event first
 	require.NoError(t, store.WriteProjection(context.Background(),
@@ -97,8 +87,8 @@ func TestWriteProjection_SkipOlder(t *testing.T) {
 	oldTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
 	newTime := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
 
-	envNew := testEnvelope(t, newTime)
-	envOld := testEnvelope(t, oldTime)
+	envNew := testutil.Event().At(newTime).Build()
+	envOld := testutil.Event().At(oldTime).Build()
 
 	// Write newer event first
 	require.NoError(t, store.WriteProjection(context.Background(),
@@ -114,44 +104,128 @@ func TestWriteProjection_SkipOlder(t *testing.T) {
 	assert.Equal(t, envNew.EventID, p.LastEventID, "last_event_id should still be the newer event")
 }
 
-func TestWriteProjection_SameTimestamp_UUIDTiebreaker(t *testing.T) {
-	testutil.TruncateTables(t, testPool, "projections")
+func TestWriteProjection_FoldsLateEvents(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections", "projection_folds")
+	RegisterMerge("reading_counts", map[string]FieldOp{"count": Sum, "low": Min})
+	t.Cleanup(func() { RegisterMerge("reading_counts", nil) })
 	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
 
-	sameTime := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	oldTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newTime := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	envNew := testutil.Event().At(newTime).Build()
 
-	env1 := testEnvelope(t, sameTime)
-	env2 := testEnvelope(t, sameTime)
+	require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001",
+		json.RawMessage(`{"count": 1, "low": 12, "unit": "C"}`), envNew))
+	require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001",
+		json.RawMessage(`{"count": 1, "low": 8, "unit": "F"}`), testutil.Event().At(oldTime).Build()))
 
-	// Determine which UUID is larger (for expected winner)
-	var first, second *events.Envelope
-	var expectedState string
-	if env1.EventID.String() < env2.EventID.String() {
-		first, second = env1, env2
-		expectedState = `{"v": "second"}`
-	} else {
-		first, second = env2, env1
-		expectedState = `{"v": "first"}`
+	p, err := store.GetProjection(ctx, "reading_counts", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count": 2, "low": 8, "unit": "C"}`, string(p.State))
+	assert.Equal(t, envNew.EventID, p.LastEventID, "last_event_id tracks the newest event")
+
+	corrupt, err := store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, corrupt)
+}
+
+func TestWriteProjection_FoldsConcurrentEvents(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections", "projection_folds")
+	RegisterMerge("reading_counts", map[string]FieldOp{"count": Sum})
+	t.Cleanup(func() { RegisterMerge("reading_counts", nil) })
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	// Many lanes folding into one row, as for a rollup group
+	const writers = 20
+	errs := make(chan error, writers)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range writers {
+		go func() {
+			errs <- store.WriteProjection(ctx, "reading_counts", "device-001",
+				json.RawMessage(`{"count": 1}`), testutil.Event().At(base.Add(time.Duration(i)*time.Second)).Build())
+		}()
+	}
+	for range writers {
+		require.NoError(t, <-errs)
 	}
 
-	// Write smaller UUID first
-	require.NoError(t, store.WriteProjection(context.Background(),
-		"sensor_state", "device-001", json.RawMessage(`{"v": "first"}`), first))
+	p, err := store.GetProjection(ctx, "reading_counts", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count": 20}`, string(p.State), "no fold is lost to a concurrent write")
+}
+
+func TestWriteProjection_FoldsEachEventOnce(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections", "projection_folds")
+	RegisterMerge("reading_counts", map[string]FieldOp{"count": Sum})
+	t.Cleanup(func() { RegisterMerge("reading_counts", nil) })
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	first := testutil.Event().Build()
+	for range 3 { // delivered, redelivered, retried
+		require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001", json.RawMessage(`{"count": 1}`), first))
+	}
+	require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001", json.RawMessage(`{"count": 1}`), testutil.Event().Build()))
+	// The same event folds into another projection independently
+	require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-002", json.RawMessage(`{"count": 1}`), first))
 
-	// Write larger UUID — should win the tiebreaker
+	p, err := store.GetProjection(ctx, "reading_counts", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count": 2}`, string(p.State))
+	p, err = store.GetProjection(ctx, "reading_counts", "device-002")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count": 1}`, string(p.State))
+}
+
+func TestPurgeFolds(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections", "projection_folds")
+	RegisterMerge("reading_counts", map[string]FieldOp{"count": Sum})
+	t.Cleanup(func() { RegisterMerge("reading_counts", nil) })
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	event := testutil.Event().Build()
+	require.NoError(t, store.WriteProjection(ctx, "reading_counts", "device-001", json.RawMessage(`{"count": 1}`), event))
+
+	purged, err := store.PurgeFolds(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purged, "recent records are kept")
+	purged, err = store.PurgeFolds(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
+
+func TestWriteProjection_SameTimestamp_UUIDTiebreaker(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections")
+	store := NewPostgresStore(testPool, testLogger())
+	ids.Set(&ids.Sequence{})
+	t.Cleanup(ids.Reset)
+
+	sameTime := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	// Sequence IDs increase, so second has the larger UUID
+	first := testutil.Event().At(sameTime).Build()
+	second := testutil.Event().At(sameTime).Build()
+
+	// Larger UUID wins the tiebreaker, whichever is written first
 	require.NoError(t, store.WriteProjection(context.Background(),
 		"sensor_state", "device-001", json.RawMessage(`{"v": "second"}`), second))
+	require.NoError(t, store.WriteProjection(context.Background(),
+		"sensor_state", "device-001", json.RawMessage(`{"v": "first"}`), first))
 
 	p, err := store.GetProjection(context.Background(), "sensor_state", "device-001")
 	require.NoError(t, err)
-	assert.JSONEq(t, expectedState, string(p.State))
+	assert.JSONEq(t, `{"v": "second"}`, string(p.State))
+	assert.Equal(t, second.EventID, p.LastEventID)
 }
 
 func TestGetProjection(t *testing.T) {
 	testutil.TruncateTables(t, testPool, "projections")
 	store := NewPostgresStore(testPool, testLogger())
 
-	env := testEnvelope(t, time.Now().UTC().Truncate(time.Microsecond))
+	env := testutil.Event().At(time.Now().UTC().Truncate(time.Microsecond)).Build()
 	state := json.RawMessage(`{"temperature": 22.5}`)
 	require.NoError(t, store.WriteProjection(context.Background(),
 		"sensor_state", "device-042", state, env))
@@ -182,7 +256,7 @@ func TestListProjections(t *testing.T) {
 
 	// Insert 3 projections
 	for i := 0; i < 3; i++ {
-		env := testEnvelope(t, time.Now().UTC().Truncate(time.Microsecond))
+		env := testutil.Event().At(time.Now().UTC().Truncate(time.Microsecond)).Build()
 		env.AggregateID = "device-" + string(rune('A'+i))
 		require.NoError(t, store.WriteProjection(context.Background(),
 			"sensor_state", env.AggregateID, json.RawMessage(`{}`), env))
@@ -211,3 +285,567 @@ func TestListProjections_Empty(t *testing.T) {
 	assert.NotNil(t, results, "should return empty slice, not nil")
 	assert.Empty(t, results)
 }
+
+func TestListAnomalous(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections")
+	store := NewPostgresStore(testPool, testLogger())
+
+	states := map[string]string{
+		"calm":    `{"value": 1, "anomaly": {"value_jump": false, "reporting_gap": false}}`,
+		"jumpy":   `{"value": 9, "anomaly": {"value_jump": true, "reporting_gap": false}}`,
+		"silent":  `{"value": 2, "anomaly": {"value_jump": false, "reporting_gap": true}}`,
+		"unknown": `{"value": 3}`,
+	}
+	for id, state := range states {
+		env := testutil.Event().At(time.Now().UTC().Truncate(time.Microsecond)).Build()
+		env.AggregateID = id
+		require.NoError(t, store.WriteProjection(context.Background(),
+			"sensor_state", id, json.RawMessage(state), env))
+	}
+
+	results, total, err := store.ListAnomalous(context.Background(), "sensor_state", AnomalyFlags, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, results, 2)
+
+	results, total, err = store.ListAnomalous(context.Background(), "sensor_state", []string{AnomalyValueJump}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, results, 1)
+	assert.Equal(t, "jumpy", results[0].AggregateID)
+}
+
+func TestListRegistered(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections", "aggregate_registry")
+	store := NewPostgresStore(testPool, testLogger())
+	reg := registry.NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	for _, id := range []string{"device-001", "device-002", "device-003", "device-004"} {
+		env := testutil.Event().At(time.Now().UTC().Truncate(time.Microsecond)).Build()
+		env.AggregateID = id
+		require.NoError(t, store.WriteProjection(ctx, "sensor_state", id, json.RawMessage(`{}`), env))
+	}
+	for _, entry := range []registry.Aggregate{
+		{AggregateID: "device-001", Site: "plant-7", Model: "tx-100", Tags: []string{"critical"}},
+		{AggregateID: "device-002", Site: "plant-7", Model: "tx-200"},
+		{AggregateID: "device-003", Site: "plant-9", Model: "tx-100"},
+	} {
+		_, err := reg.PutAggregate(ctx, &entry)
+		require.NoError(t, err)
+	}
+
+	results, total, err := store.ListRegistered(ctx, "sensor_state", registry.Filter{}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total, "device-004 has no registry entry")
+	assert.Len(t, results, 3)
+
+	results, total, err = store.ListRegistered(ctx, "sensor_state", registry.Filter{Site: "plant-7"}, 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, results, 1)
+
+	results, total, err = store.ListRegistered(ctx, "sensor_state", registry.Filter{Model: "tx-100", Tag: "critical"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, results, 1)
+	assert.Equal(t, "device-001", results[0].AggregateID)
+
+	// Reads made WithRegistry join each aggregate's entry
+	results, _, err = store.ListProjections(WithRegistry(ctx), "sensor_state", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	for _, p := range results {
+		if p.AggregateID == "device-004" {
+			assert.Nil(t, p.Registry)
+			continue
+		}
+		require.NotNil(t, p.Registry, p.AggregateID)
+		assert.Equal(t, p.AggregateID, p.Registry.AggregateID)
+	}
+
+	p, err := store.GetProjection(WithRegistry(ctx), "sensor_state", "device-001")
+	require.NoError(t, err)
+	require.NotNil(t, p.Registry)
+	assert.Equal(t, "plant-7", p.Registry.Site)
+	assert.Equal(t, []string{"critical"}, p.Registry.Tags)
+
+	p, err = store.GetProjection(ctx, "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.Nil(t, p.Registry)
+}
+
+func TestFreezeAggregate(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "aggregate_flags")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	frozen, err := store.IsFrozen(ctx, "device-001")
+	require.NoError(t, err)
+	assert.False(t, frozen)
+
+	require.NoError(t, store.FreezeAggregate(ctx, "device-001", "compromised"))
+	frozen, err = store.IsFrozen(ctx, "device-001")
+	require.NoError(t, err)
+	assert.True(t, frozen)
+
+	list, err := store.ListFrozen(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "device-001", list[0].AggregateID)
+	assert.Equal(t, "compromised", list[0].Reason)
+
+	require.NoError(t, store.UnfreezeAggregate(ctx, "device-001"))
+	frozen, err = store.IsFrozen(ctx, "device-001")
+	require.NoError(t, err)
+	assert.False(t, frozen)
+
+	list, err = store.ListFrozen(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestFindCorrupt_ManualEdit(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	env := testutil.Event().At(time.Now().UTC().Truncate(time.Microsecond)).Build()
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001", json.RawMessage(`{"value": 1}`), env))
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-002", json.RawMessage(`{"value": 2}`), env))
+
+	// Freshly written rows verify clean
+	found, err := store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	// Edit state behind the writer's back
+	_, err = testPool.Exec(ctx,
+		`UPDATE projections SET state = '{"value": 999}' WHERE aggregate_id = 'device-002'`)
+	require.NoError(t, err)
+
+	found, err = store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "device-002", found[0].AggregateID)
+	assert.Equal(t, env.EventID, found[0].LastEventID)
+	assert.NotEqual(t, found[0].StoredChecksum, found[0].ActualChecksum)
+
+	// Repair rewrites state and checksum even though the event is not newer
+	require.NoError(t, store.RepairProjection(ctx, "sensor_state", "device-002", json.RawMessage(`{"value": 2}`), env))
+	found, err = store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	p, err := store.GetProjection(ctx, "sensor_state", "device-002")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value": 2}`, string(p.State))
+}
+
+func TestDeleteProjection(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001", json.RawMessage(`{"v": 1}`), testutil.Event().At(base).Build()))
+
+	tombstone := testutil.Event().At(base.Add(time.Hour)).Build()
+	tombstone.EventType = "sensor.decommissioned"
+	require.NoError(t, store.DeleteProjection(ctx, "sensor_state", "device-001", json.RawMessage(`{}`), tombstone))
+
+	// Hidden from reads, listed as deleted with its last state
+	_, err := store.GetProjection(ctx, "sensor_state", "device-001")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, total, err := store.ListProjections(ctx, "sensor_state", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	deleted, total, err := store.ListDeleted(ctx, "sensor_state", 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	require.NotNil(t, deleted[0].DeletedAt)
+	assert.JSONEq(t, `{"v": 1}`, string(deleted[0].State))
+	assert.Equal(t, tombstone.EventID, deleted[0].LastEventID)
+	gone, err := store.GetDeletedProjection(ctx, "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, tombstone.EventID, gone.LastEventID)
+	require.NotNil(t, gone.DeletedAt)
+
+	// Checksum still matches the kept state
+	found, err := store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	// A late, older event does not resurrect it; a newer one does
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001", json.RawMessage(`{"v": 0}`), testutil.Event().At(base.Add(time.Minute)).Build()))
+	_, err = store.GetProjection(ctx, "sensor_state", "device-001")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "device-001", json.RawMessage(`{"v": 2}`), testutil.Event().At(base.Add(2*time.Hour)).Build()))
+	p, err := store.GetProjection(ctx, "sensor_state", "device-001")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"v": 2}`, string(p.State))
+	_, err = store.GetDeletedProjection(ctx, "sensor_state", "device-001")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestExpireProjections(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	env := testutil.Event().At(time.Now().UTC().Truncate(time.Microsecond)).Build()
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "stale", json.RawMessage(`{}`), env))
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "fresh", json.RawMessage(`{}`), env))
+	_, err := testPool.Exec(ctx, `UPDATE projections SET updated_at = NOW() - INTERVAL '2 days' WHERE aggregate_id = 'stale'`)
+	require.NoError(t, err)
+
+	expired, err := store.ExpireProjections(ctx, "sensor_state", time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	_, err = store.GetProjection(ctx, "sensor_state", "stale")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = store.GetProjection(ctx, "sensor_state", "fresh")
+	assert.NoError(t, err)
+
+	expired, err = store.ExpireProjections(ctx, "sensor_state", time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired, "already expired rows are not counted again")
+}
+
+func TestGetProjections(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, store.WriteProjection(ctx, "sensor_state", id, json.RawMessage(`{}`), testutil.Event().At(now).Build()))
+	}
+	require.NoError(t, store.WriteProjection(ctx, "user_session", "a", json.RawMessage(`{}`), testutil.Event().At(now).Build()))
+	require.NoError(t, store.DeleteProjection(ctx, "sensor_state", "c", json.RawMessage(`{}`), testutil.Event().At(now.Add(time.Second)).Build()))
+
+	found, err := store.GetProjections(ctx, "sensor_state", []string{"a", "b", "c", "missing"})
+	require.NoError(t, err)
+	ids := make([]string, len(found))
+	for i, p := range found {
+		ids[i] = p.AggregateID
+		assert.Equal(t, "sensor_state", p.ProjectionType)
+	}
+	assert.ElementsMatch(t, []string{"a", "b"}, ids)
+
+	found, err = store.GetProjections(ctx, "sensor_state", []string{"missing"})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestGetAggregateProjections(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	for _, projType := range []string{"user_session", "sensor_state", "test.sensor_state"} {
+		require.NoError(t, store.WriteProjection(ctx, projType, "agg-1", json.RawMessage(`{}`), testutil.Event().At(now).Build()))
+	}
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "agg-2", json.RawMessage(`{}`), testutil.Event().At(now).Build()))
+	require.NoError(t, store.DeleteProjection(ctx, "user_session", "agg-1", json.RawMessage(`{}`), testutil.Event().At(now.Add(time.Second)).Build()))
+
+	found, err := store.GetAggregateProjections(ctx, "agg-1", "")
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "sensor_state", found[0].ProjectionType)
+	assert.Equal(t, "test.sensor_state", found[1].ProjectionType)
+
+	// A newer event without an aggregate type keeps the recorded one
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "agg-1", json.RawMessage(`{}`),
+		testutil.Event().AggregateType("device").At(now.Add(time.Second)).Build()))
+	require.NoError(t, store.WriteProjection(ctx, "sensor_state", "agg-1", json.RawMessage(`{}`),
+		testutil.Event().At(now.Add(2*time.Second)).Build()))
+
+	found, err = store.GetAggregateProjections(ctx, "agg-1", "device")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "sensor_state", found[0].ProjectionType)
+	assert.Equal(t, "device", found[0].AggregateType)
+
+	got, err := store.GetProjection(ctx, "sensor_state", "agg-1")
+	require.NoError(t, err)
+	assert.Equal(t, "device", got.AggregateType)
+
+	found, err = store.GetAggregateProjections(ctx, "nobody", "")
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestSearchProjections(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	for _, id := range []string{"DEV-042", "dev-0420", "sensor-042", "dev_100", "devX100"} {
+		require.NoError(t, store.WriteProjection(ctx, "sensor_state", id, json.RawMessage(`{}`), testutil.Event().At(now).Build()))
+	}
+	require.NoError(t, store.DeleteProjection(ctx, "sensor_state", "dev-0420", json.RawMessage(`{}`), testutil.Event().At(now.Add(time.Second)).Build()))
+
+	found, total, err := store.SearchProjections(ctx, "sensor_state", "dev-04", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total, "case-insensitive, deleted rows excluded")
+	assert.Equal(t, "DEV-042", found[0].AggregateID)
+
+	// LIKE wildcards in the term match literally
+	found, total, err = store.SearchProjections(ctx, "sensor_state", "dev_1", 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, "dev_100", found[0].AggregateID)
+
+	_, total, err = store.SearchProjections(ctx, "sensor_state", "%", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+}
+
+func TestWindows(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "aggregation_windows", "aggregation_watermarks")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	latest, err := store.AdvanceWatermark(ctx, "sensor_window", "device-001", base.Add(7*time.Minute))
+	require.NoError(t, err)
+	assert.True(t, latest.Equal(base.Add(7*time.Minute)))
+	latest, err = store.AdvanceWatermark(ctx, "sensor_window", "device-001", base)
+	require.NoError(t, err)
+	assert.True(t, latest.Equal(base.Add(7*time.Minute)), "an older event does not move the watermark back")
+
+	count := func(state json.RawMessage) (json.RawMessage, error) {
+		var n int
+		if state != nil {
+			require.NoError(t, json.Unmarshal(state, &n))
+		}
+		return json.Marshal(n + 1)
+	}
+	for _, start := range []time.Time{base, base, base.Add(5 * time.Minute)} {
+		require.NoError(t, store.FoldWindow(ctx, "sensor_window", "device-001", start, start.Add(5*time.Minute), count))
+	}
+	skip := func(json.RawMessage) (json.RawMessage, error) { return nil, nil }
+	require.NoError(t, store.FoldWindow(ctx, "sensor_window", "device-001", base.Add(time.Hour), base.Add(time.Hour+5*time.Minute), skip))
+
+	closed, err := store.ClosedWindows(ctx, "sensor_window", "device-001", base.Add(10*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, closed, 2, "a skipped fold creates no window")
+	assert.True(t, closed[0].Start.Equal(base))
+	assert.JSONEq(t, `2`, string(closed[0].State))
+	assert.JSONEq(t, `1`, string(closed[1].State))
+
+	again, err := store.ClosedWindows(ctx, "sensor_window", "device-001", base.Add(10*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, closed, again, "reading closed windows does not remove them")
+
+	require.NoError(t, store.DeleteWindows(ctx, "sensor_window", "device-001", closed[1].End))
+	closed, err = store.ClosedWindows(ctx, "sensor_window", "device-001", base.Add(10*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, closed, "deleted windows are removed")
+}
+
+func TestFoldWindow_Concurrent(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "aggregation_windows")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	count := func(state json.RawMessage) (json.RawMessage, error) {
+		var n int
+		if state != nil {
+			if err := json.Unmarshal(state, &n); err != nil {
+				return nil, err
+			}
+		}
+		return json.Marshal(n + 1)
+	}
+	const writers = 4
+	errs := make(chan error, writers)
+	for range writers {
+		go func() {
+			errs <- store.FoldWindow(ctx, "sensor_window", "device-001", start, start.Add(5*time.Minute), count)
+		}()
+	}
+	for range writers {
+		require.NoError(t, <-errs)
+	}
+
+	closed, err := store.ClosedWindows(ctx, "sensor_window", "device-001", start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.JSONEq(t, `4`, string(closed[0].State))
+}
+
+func TestRecordFailure(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "dlq")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	failure := DispatchFailure{
+		Consumer:  "event-handler",
+		EventID:   uuid.Must(uuid.NewV7()),
+		Payload:   json.RawMessage(`{"event_type": "sensor.reading"}`),
+		Topic:     "sensor-events",
+		Partition: 2,
+		Offset:    41,
+		Error:     "handler sensor: boom",
+	}
+	for want := 1; want <= 2; want++ {
+		attempts, quarantined, err := store.RecordFailure(ctx, failure, 3)
+		require.NoError(t, err)
+		assert.Equal(t, want, attempts)
+		assert.False(t, quarantined)
+	}
+	attempts, quarantined, err := store.RecordFailure(ctx, failure, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.True(t, quarantined)
+
+	// A quarantined event stays quarantined and is not cleared by a success
+	attempts, quarantined, err = store.RecordFailure(ctx, failure, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.True(t, quarantined)
+	require.NoError(t, store.ClearFailure(ctx, failure.Consumer, failure.EventID))
+
+	var status, message string
+	var offset int64
+	require.NoError(t, testPool.QueryRow(ctx,
+		`SELECT status, error_message, kafka_offset FROM dlq WHERE event_id = $1`, failure.EventID,
+	).Scan(&status, &message, &offset))
+	assert.Equal(t, "pending", status)
+	assert.Equal(t, "handler sensor: boom", message)
+	assert.Equal(t, int64(41), offset)
+
+	listed, total, err := store.ListQuarantined(ctx, "event-handler", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, listed, 1)
+	assert.Equal(t, failure.EventID, listed[0].EventID)
+	assert.Equal(t, 3, listed[0].Attempts)
+	assert.Equal(t, int32(2), listed[0].Partition)
+	assert.JSONEq(t, `{"event_type": "sensor.reading"}`, string(listed[0].Payload))
+	_, total, err = store.ListQuarantined(ctx, "search-indexer", 10, 0)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	// Failures of an event that later succeeds are forgotten
+	other := failure
+	other.EventID = uuid.Must(uuid.NewV7())
+	_, _, err = store.RecordFailure(ctx, other, 3)
+	require.NoError(t, err)
+	require.NoError(t, store.ClearFailure(ctx, other.Consumer, other.EventID))
+	attempts, _, err = store.RecordFailure(ctx, other, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestAliases(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projection_aliases")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	require.NoError(t, store.SetAlias(ctx, "sensor_state", "sensor_state_v1"))
+	require.NoError(t, store.SetAlias(ctx, "sensor_state", "sensor_state_v2"))
+	require.NoError(t, store.SetAlias(ctx, "user_session", "user_session_v2"))
+	aliases, err := store.ListAliases(ctx)
+	require.NoError(t, err)
+	require.Len(t, aliases, 2)
+	assert.Equal(t, "sensor_state", aliases[0].Alias)
+	assert.Equal(t, "sensor_state_v2", aliases[0].ProjectionType)
+
+	require.NoError(t, store.RemoveAlias(ctx, "user_session"))
+	require.NoError(t, store.RemoveAlias(ctx, "user_session"))
+	aliases, err = store.ListAliases(ctx)
+	require.NoError(t, err)
+	assert.Len(t, aliases, 1)
+}
+
+func TestRunTransform_SQL(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "projections")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	for _, id := range []string{"device-001", "device-002", "device-003"} {
+		require.NoError(t, store.WriteProjection(ctx, "sensor_state", id, json.RawMessage(`{"temperature": 22.5}`), testutil.Event().At(now).Build()))
+	}
+	before, err := store.GetProjection(ctx, "sensor_state", "device-002")
+	require.NoError(t, err)
+
+	rename := store.SQLTransform(`state - 'temperature' || jsonb_build_object('temp_f', state->'temperature')`)
+	result, err := RunTransform(ctx, store, rename, TransformConfig{ProjectionType: "sensor_state", BatchSize: 2, DryRun: true}, testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Changed)
+
+	result, err = RunTransform(ctx, store, rename, TransformConfig{ProjectionType: "sensor_state", BatchSize: 2}, testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Scanned)
+	assert.Equal(t, 3, result.Changed)
+	assert.Equal(t, "device-003", result.LastAggregateID)
+
+	after, err := store.GetProjection(ctx, "sensor_state", "device-002")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"temp_f": 22.5}`, string(after.State))
+	assert.Equal(t, before.LastEventID, after.LastEventID, "the event position is kept")
+	assert.Equal(t, before.UpdatedAt, after.UpdatedAt)
+	corrupt, err := store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, corrupt)
+
+	// A state changed since it was read is left alone
+	replaced, err := store.ReplaceState(ctx, "sensor_state", "device-001", json.RawMessage(`{"temperature": 22.5}`), json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.False(t, replaced)
+}
+
+func TestEnsureStateIndexes(t *testing.T) {
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+	site := StateIndex{ProjectionType: "sensor_state", Field: "site_id"}
+	region := StateIndex{ProjectionType: "sensor_state", Field: "location.region"}
+	t.Cleanup(func() {
+		_, err := store.EnsureStateIndexes(ctx, nil)
+		require.NoError(t, err)
+	})
+
+	changes, err := store.EnsureStateIndexes(ctx, []StateIndex{site, region})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{site.Name(), region.Name()}, changes.Created)
+
+	changes, err = store.EnsureStateIndexes(ctx, []StateIndex{site, region})
+	require.NoError(t, err)
+	assert.Empty(t, changes.Created, "existing indexes are kept")
+
+	// An invalid index no session is building is a failed build: rebuilt
+	_, err = testPool.Exec(ctx, `UPDATE pg_index SET indisvalid = false WHERE indexrelid = $1::regclass`, region.Name())
+	require.NoError(t, err)
+	changes, err = store.EnsureStateIndexes(ctx, []StateIndex{site, region})
+	require.NoError(t, err)
+	assert.Equal(t, []string{region.Name()}, changes.Created)
+
+	// The planner can use the index for an equality filter with parameters
+	conn, err := testPool.Acquire(ctx)
+	require.NoError(t, err)
+	defer conn.Release()
+	_, err = conn.Exec(ctx, "SET enable_seqscan = off")
+	require.NoError(t, err)
+	defer conn.Exec(ctx, "RESET enable_seqscan")
+	rows, err := conn.Query(ctx,
+		`EXPLAIN SELECT aggregate_id FROM projections WHERE projection_type = $1 AND state->>'site_id' = $2`,
+		"sensor_state", "site-1")
+	require.NoError(t, err)
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+	plan := strings.Join(lines, "\n")
+	assert.Contains(t, plan, site.Name())
+
+	changes, err = store.EnsureStateIndexes(ctx, []StateIndex{site})
+	require.NoError(t, err)
+	assert.Equal(t, []string{region.Name()}, changes.Dropped)
+}
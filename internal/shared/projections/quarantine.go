@@ -0,0 +1,35 @@
+package projections
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// DispatchFailure is a failed attempt by a consumer to dispatch an event, as
+// recorded in the DLQ. Failures of one event accumulate until the consumer's
+// attempt limit quarantines it.
+type DispatchFailure struct {
+	Consumer  string // consumer group
+	EventID   uuid.UUID
+	Payload   json.RawMessage // the event envelope as consumed
+	Topic     string
+	Partition int32
+	Offset    int64
+	Error     string
+}
+
+// QuarantinedEvent is an event a consumer gave up on after repeated failed
+// dispatches, as listed from the DLQ.
+type QuarantinedEvent struct {
+	Consumer  string          `json:"consumer"`
+	EventID   uuid.UUID       `json:"event_id"`
+	Payload   json.RawMessage `json:"event"` // the event envelope as consumed
+	Error     string          `json:"error"` // of the last attempt
+	Attempts  int             `json:"attempts"`
+	Topic     string          `json:"topic"`
+	Partition int32           `json:"partition"`
+	Offset    int64           `json:"offset"`
+	FailedAt  time.Time       `json:"failed_at"` // of the last attempt
+}
@@ -8,17 +8,65 @@ import (
 	"github.com/gofrs/uuid/v5"
 
 	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/registry"
 )
 
 // Projection represents a materialized view in the projections table.
 type Projection struct {
-	ProjectionID       uuid.UUID       `json:"projection_id"`
-	ProjectionType     string          `json:"projection_type"`
-	AggregateID        string          `json:"aggregate_id"`
-	State              json.RawMessage `json:"state"`
-	LastEventID        uuid.UUID       `json:"last_event_id"`
-	LastEventTimestamp time.Time       `json:"last_event_timestamp"`
-	UpdatedAt          time.Time       `json:"updated_at"`
+	ProjectionID       uuid.UUID           `json:"projection_id"`
+	ProjectionType     string              `json:"projection_type"`
+	AggregateID        string              `json:"aggregate_id"`
+	AggregateType      string              `json:"aggregate_type,omitempty"` // from the newest event that carried one
+	State              json.RawMessage     `json:"state"`
+	LastEventID        uuid.UUID           `json:"last_event_id"`
+	LastEventTimestamp time.Time           `json:"last_event_timestamp"`
+	UpdatedAt          time.Time           `json:"updated_at"`
+	DeletedAt          *time.Time          `json:"deleted_at,omitempty"` // set by a tombstone event or TTL expiry
+	Registry           *registry.Aggregate `json:"registry,omitempty"`   // read only with WithRegistry; nil without an entry
+}
+
+type registryKey struct{}
+
+// WithRegistry makes the reads run with ctx set each projection's Registry to
+// its aggregate's registry entry, joined in the same query.
+func WithRegistry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, registryKey{}, true)
+}
+
+// RegistryRequested reports whether ctx was made by WithRegistry.
+func RegistryRequested(ctx context.Context) bool {
+	requested, _ := ctx.Value(registryKey{}).(bool)
+	return requested
+}
+
+// Sources maps each projection type to the event type prefix it is built from.
+// Mirrors the handler registrations in eventhandler.Start.
+var Sources = map[string]string{
+	"sensor_state": "sensor.",
+	"user_session": "user.",
+}
+
+// Tombstones lists the event types, besides the "<domain>.deleted" convention
+// (see events.IsDeletion), that soft-delete their aggregate's projection
+// instead of updating it. A newer event for the aggregate clears the deletion
+// again (e.g. a decommissioned sensor that is redeployed).
+var Tombstones = map[string]bool{
+	"sensor.decommissioned": true,
+}
+
+// IsTombstone reports whether an event type deletes its aggregate's projection.
+func IsTombstone(eventType string) bool {
+	return Tombstones[eventType] || events.IsDeletion(eventType)
+}
+
+// Discrepancy is a projection whose stored state no longer matches the checksum
+// recorded when it was written (bit rot, or a manual edit bypassing the writer).
+type Discrepancy struct {
+	ProjectionType string    `json:"projection_type"`
+	AggregateID    string    `json:"aggregate_id"`
+	LastEventID    uuid.UUID `json:"last_event_id"`
+	StoredChecksum string    `json:"stored_checksum"`
+	ActualChecksum string    `json:"actual_checksum"`
 }
 
 // Store provides read and write operations for projections.
@@ -28,9 +76,40 @@ type Store interface {
 	WriteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error
 
 	// GetProjection retrieves a single projection by type and aggregate ID.
+	// Deleted projections are not returned.
 	GetProjection(ctx context.Context, projType, aggregateID string) (*Projection, error)
 
-	// ListProjections retrieves projections by type with pagination.
-	// Returns the projections, total count, and any error.
+	// GetDeletedProjection retrieves a single deleted projection by type and
+	// aggregate ID. Live projections are not returned.
+	GetDeletedProjection(ctx context.Context, projType, aggregateID string) (*Projection, error)
+
+	// GetProjections retrieves the projections of one type for a set of
+	// aggregate IDs in a single read. Missing and deleted projections are
+	// omitted; the result is in no particular order.
+	GetProjections(ctx context.Context, projType string, aggregateIDs []string) ([]Projection, error)
+
+	// GetAggregateProjections retrieves every projection of one aggregate,
+	// across projection types (test namespace included), ordered by type.
+	// Deleted projections are omitted. A non-empty aggregateType keeps only
+	// projections of aggregates of that type.
+	GetAggregateProjections(ctx context.Context, aggregateID, aggregateType string) ([]Projection, error)
+
+	// ListProjections retrieves projections by type with pagination, excluding
+	// deleted projections. Returns the projections, total count, and any error.
 	ListProjections(ctx context.Context, projType string, limit, offset int) ([]Projection, int, error)
+
+	// ListAnomalous is ListProjections restricted to projections with any of
+	// the given anomaly flags set.
+	ListAnomalous(ctx context.Context, projType string, flags []string, limit, offset int) ([]Projection, int, error)
+
+	// SearchProjections is ListProjections restricted to aggregate IDs
+	// containing q, case-insensitively.
+	SearchProjections(ctx context.Context, projType, q string, limit, offset int) ([]Projection, int, error)
+
+	// ListDeleted retrieves deleted projections by type with pagination.
+	ListDeleted(ctx context.Context, projType string, limit, offset int) ([]Projection, int, error)
+
+	// ListRegistered is ListProjections restricted to aggregates whose
+	// registry entry matches filter. Aggregates without an entry never match.
+	ListRegistered(ctx context.Context, projType string, filter registry.Filter, limit, offset int) ([]Projection, int, error)
 }
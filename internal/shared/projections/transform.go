@@ -0,0 +1,206 @@
+package projections
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// StateTransformer rewrites the state of a batch of projections, returning
+// one new state per input, in order. Used to migrate a projection type's
+// stored state to a new JSON shape.
+type StateTransformer interface {
+	TransformStates(ctx context.Context, states []json.RawMessage) ([]json.RawMessage, error)
+}
+
+// StateTransform rewrites one projection's state in Go. Returning the state
+// unchanged leaves the row alone.
+type StateTransform func(state json.RawMessage) (json.RawMessage, error)
+
+// TransformStates applies f to each state.
+func (f StateTransform) TransformStates(ctx context.Context, states []json.RawMessage) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, len(states))
+	for i, state := range states {
+		transformed, err := f(state)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = transformed
+	}
+	return out, nil
+}
+
+// RenameFields returns a transform renaming top-level state fields, old name
+// to new, such as {"temperature": "temp_f"}. A renamed field replaces a field
+// already holding the new name. States without the old fields are unchanged.
+func RenameFields(renames map[string]string) StateTransform {
+	return func(state json.RawMessage) (json.RawMessage, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(state, &fields); err != nil || fields == nil {
+			return state, nil // not an object: nothing to rename
+		}
+		changed := false
+		for from, to := range renames {
+			if value, ok := fields[from]; ok {
+				delete(fields, from)
+				fields[to] = value
+				changed = true
+			}
+		}
+		if !changed {
+			return state, nil
+		}
+		return json.Marshal(fields)
+	}
+}
+
+// ParseRenames parses "old=new" pairs, comma-separated, such as
+// "temperature=temp_f,humidity=rh".
+func ParseRenames(s string) (map[string]string, error) {
+	renames := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		from, to, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || from == "" || to == "" || from == to {
+			return nil, fmt.Errorf("invalid rename %q: expected old=new", pair)
+		}
+		if _, dup := renames[from]; dup {
+			return nil, fmt.Errorf("invalid rename %q: %s is renamed twice", pair, from)
+		}
+		renames[from] = to
+	}
+	return renames, nil
+}
+
+// TransformStore is the store a state transform runs against.
+// This interface is satisfied by PostgresStore and MemoryStore.
+type TransformStore interface {
+	// ScanProjections returns up to limit projections of one type, deleted
+	// ones included, with aggregate IDs after afterAggregateID, in aggregate
+	// ID order.
+	ScanProjections(ctx context.Context, projType, afterAggregateID string, limit int) ([]Projection, error)
+
+	// ReplaceState sets a projection's state to state if it is still old,
+	// keeping its event position. It reports false if the projection has
+	// changed or gone since it was read.
+	ReplaceState(ctx context.Context, projType, aggregateID string, old, state json.RawMessage) (bool, error)
+}
+
+// TransformConfig configures RunTransform.
+type TransformConfig struct {
+	ProjectionType string
+	After          string // resume after this aggregate ID; empty starts at the beginning
+	BatchSize      int
+	DryRun         bool // count and sample the changes without writing them
+
+	// Progress, if set, is called after each batch with the totals so far.
+	Progress func(TransformResult)
+}
+
+// TransformResult reports a transform run. LastAggregateID is where an
+// interrupted run resumes (TransformConfig.After).
+type TransformResult struct {
+	Scanned         int               `json:"scanned"`
+	Changed         int               `json:"changed"`   // rewritten, or would be on a dry run
+	Conflicts       int               `json:"conflicts"` // changed by an event while being transformed; left as written
+	LastAggregateID string            `json:"last_aggregate_id"`
+	Samples         []TransformSample `json:"samples,omitempty"`
+	DryRun          bool              `json:"dry_run"`
+}
+
+// TransformSample is one changed state, before and after.
+type TransformSample struct {
+	AggregateID string          `json:"aggregate_id"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+}
+
+// maxTransformSamples bounds TransformResult.Samples.
+const maxTransformSamples = 5
+
+// RunTransform rewrites the state of every projection of cfg.ProjectionType
+// with t, a batch at a time in aggregate ID order. Each row is replaced only
+// if no event has changed it since it was read. Handlers keep writing the
+// shape they produce, so deploy handlers writing the new shape first.
+func RunTransform(ctx context.Context, store TransformStore, t StateTransformer, cfg TransformConfig, logger *slog.Logger) (TransformResult, error) {
+	result := TransformResult{LastAggregateID: cfg.After, DryRun: cfg.DryRun}
+	logger = logger.With("projection_type", cfg.ProjectionType, "dry_run", cfg.DryRun)
+
+	for {
+		batch, err := store.ScanProjections(ctx, cfg.ProjectionType, result.LastAggregateID, cfg.BatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(batch) == 0 {
+			logger.Info("projection transform complete",
+				"scanned", result.Scanned,
+				"changed", result.Changed,
+				"conflicts", result.Conflicts,
+			)
+			return result, nil
+		}
+
+		states := make([]json.RawMessage, len(batch))
+		for i, p := range batch {
+			states[i] = p.State
+		}
+		transformed, err := t.TransformStates(ctx, states)
+		if err != nil {
+			return result, fmt.Errorf("after aggregate %q: %w", result.LastAggregateID, err)
+		}
+		if len(transformed) != len(batch) {
+			return result, fmt.Errorf("transform returned %d states for %d projections", len(transformed), len(batch))
+		}
+
+		for i, p := range batch {
+			after := transformed[i]
+			if !json.Valid(after) {
+				return result, fmt.Errorf("aggregate %q: transform returned invalid JSON", p.AggregateID)
+			}
+			if sameJSON(p.State, after) {
+				continue
+			}
+			if !cfg.DryRun {
+				replaced, err := store.ReplaceState(ctx, cfg.ProjectionType, p.AggregateID, p.State, after)
+				if err != nil {
+					return result, fmt.Errorf("aggregate %q: %w", p.AggregateID, err)
+				}
+				if !replaced {
+					result.Conflicts++
+					continue
+				}
+			}
+			result.Changed++
+			if len(result.Samples) < maxTransformSamples {
+				result.Samples = append(result.Samples, TransformSample{AggregateID: p.AggregateID, Before: p.State, After: after})
+			}
+		}
+
+		result.Scanned += len(batch)
+		result.LastAggregateID = batch[len(batch)-1].AggregateID
+		logger.Info("projection transform progress",
+			"scanned", result.Scanned,
+			"changed", result.Changed,
+			"last_aggregate_id", result.LastAggregateID,
+		)
+		if cfg.Progress != nil {
+			cfg.Progress(result)
+		}
+	}
+}
+
+// sameJSON reports whether a and b encode the same value, ignoring key order
+// and whitespace.
+func sameJSON(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,158 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedTransformStore writes n sensor_state projections, every other one in
+// the old shape.
+func seedTransformStore(t *testing.T, n int) *MemoryStore {
+	t.Helper()
+	store := NewMemoryStore()
+	for i := range n {
+		state := fmt.Sprintf(`{"temperature": %d}`, i)
+		if i%2 == 1 {
+			state = fmt.Sprintf(`{"temp_f": %d}`, i)
+		}
+		require.NoError(t, store.WriteProjection(context.Background(), "sensor_state", fmt.Sprintf("device-%03d", i),
+			json.RawMessage(state), memoryTestEvent(time.Now())))
+	}
+	return store
+}
+
+func TestRenameFields(t *testing.T) {
+	rename := RenameFields(map[string]string{"temperature": "temp_f"})
+
+	out, err := rename(json.RawMessage(`{"temperature": 72, "unit": "F"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"temp_f": 72, "unit": "F"}`, string(out))
+
+	out, err = rename(json.RawMessage(`{"temperature": 72, "temp_f": 1}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"temp_f": 72}`, string(out), "the renamed field wins")
+
+	for _, unchanged := range []string{`{"temp_f": 72}`, `[1, 2]`, `null`} {
+		out, err = rename(json.RawMessage(unchanged))
+		require.NoError(t, err)
+		assert.Equal(t, unchanged, string(out))
+	}
+}
+
+func TestParseRenames(t *testing.T) {
+	renames, err := ParseRenames("temperature=temp_f, humidity=rh")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"temperature": "temp_f", "humidity": "rh"}, renames)
+
+	for _, bad := range []string{"", "temperature", "=temp_f", "a=a", "a=b,a=c"} {
+		_, err := ParseRenames(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestRunTransform(t *testing.T) {
+	store := seedTransformStore(t, 5)
+	ctx := context.Background()
+
+	var progress []int
+	result, err := RunTransform(ctx, store, RenameFields(map[string]string{"temperature": "temp_f"}), TransformConfig{
+		ProjectionType: "sensor_state",
+		BatchSize:      2,
+		Progress:       func(r TransformResult) { progress = append(progress, r.Scanned) },
+	}, slog.Default())
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Scanned)
+	assert.Equal(t, 3, result.Changed)
+	assert.Equal(t, "device-004", result.LastAggregateID)
+	assert.Equal(t, []int{2, 4, 5}, progress)
+	require.Len(t, result.Samples, 3)
+	assert.JSONEq(t, `{"temperature": 0}`, string(result.Samples[0].Before))
+
+	p, err := store.GetProjection(ctx, "sensor_state", "device-002")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"temp_f": 2}`, string(p.State))
+	corrupt, err := store.FindCorrupt(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, corrupt, "checksums are recomputed")
+}
+
+func TestRunTransform_DryRun(t *testing.T) {
+	store := seedTransformStore(t, 4)
+	ctx := context.Background()
+
+	result, err := RunTransform(ctx, store, RenameFields(map[string]string{"temperature": "temp_f"}), TransformConfig{
+		ProjectionType: "sensor_state",
+		BatchSize:      10,
+		DryRun:         true,
+	}, slog.Default())
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Changed)
+	assert.True(t, result.DryRun)
+
+	p, err := store.GetProjection(ctx, "sensor_state", "device-000")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"temperature": 0}`, string(p.State), "nothing is written")
+}
+
+func TestRunTransform_ResumeAndFailure(t *testing.T) {
+	store := seedTransformStore(t, 4)
+	ctx := context.Background()
+
+	calls := 0
+	failing := StateTransform(func(state json.RawMessage) (json.RawMessage, error) {
+		if calls++; calls > 2 {
+			return nil, errors.New("unexpected shape")
+		}
+		return state, nil
+	})
+	result, err := RunTransform(ctx, store, failing, TransformConfig{ProjectionType: "sensor_state", BatchSize: 2}, slog.Default())
+	assert.ErrorContains(t, err, "unexpected shape")
+	assert.Equal(t, "device-001", result.LastAggregateID, "the last completed batch")
+
+	result, err = RunTransform(ctx, store, RenameFields(map[string]string{"temperature": "temp_f"}), TransformConfig{
+		ProjectionType: "sensor_state",
+		After:          result.LastAggregateID,
+		BatchSize:      2,
+	}, slog.Default())
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Scanned)
+	assert.Equal(t, 1, result.Changed)
+}
+
+// racingStore writes a new event to every projection between its scan and
+// the transform's write.
+type racingStore struct {
+	*MemoryStore
+}
+
+func (s racingStore) ReplaceState(ctx context.Context, projType, aggregateID string, old, state json.RawMessage) (bool, error) {
+	if err := s.WriteProjection(ctx, projType, aggregateID, json.RawMessage(`{"temp_f": 99}`), memoryTestEvent(time.Now().Add(time.Hour))); err != nil {
+		return false, err
+	}
+	return s.MemoryStore.ReplaceState(ctx, projType, aggregateID, old, state)
+}
+
+func TestRunTransform_ConcurrentWriteWins(t *testing.T) {
+	store := seedTransformStore(t, 2)
+	ctx := context.Background()
+
+	result, err := RunTransform(ctx, racingStore{store}, RenameFields(map[string]string{"temperature": "temp_f"}), TransformConfig{
+		ProjectionType: "sensor_state",
+		BatchSize:      10,
+	}, slog.Default())
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Changed)
+	assert.Equal(t, 1, result.Conflicts)
+
+	p, err := store.GetProjection(ctx, "sensor_state", "device-000")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"temp_f": 99}`, string(p.State))
+}
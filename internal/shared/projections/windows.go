@@ -0,0 +1,21 @@
+package projections
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Window is an open time window of a windowed aggregation (see
+// eventhandler.WindowedHandler) for one aggregate. Windows are kept until the
+// aggregate's watermark passes their end, then closed and removed.
+type Window struct {
+	Aggregation string          `json:"aggregation"`
+	AggregateID string          `json:"aggregate_id"`
+	Start       time.Time       `json:"window_start"`
+	End         time.Time       `json:"window_end"`
+	State       json.RawMessage `json:"state"`
+}
+
+// WindowFold computes a window's next state from its current one, nil for a
+// new window. Returning nil leaves the window unchanged.
+type WindowFold func(state json.RawMessage) (json.RawMessage, error)
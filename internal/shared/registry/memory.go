@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// MemoryStore implements Store in memory.
+// Used by tests and sandbox mode; mirrors PostgresStore semantics (ErrNoRows on miss).
+type MemoryStore struct {
+	mu         sync.RWMutex
+	aggregates map[string]Aggregate
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{aggregates: make(map[string]Aggregate)}
+}
+
+// PutAggregate creates or replaces an entry, keeping its creation time.
+func (s *MemoryStore) PutAggregate(ctx context.Context, aggregate *Aggregate) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := clock.Now()
+	existing, exists := s.aggregates[aggregate.AggregateID]
+	aggregate.Tags = normalizeTags(aggregate.Tags)
+	aggregate.CreatedAt = now
+	if exists {
+		aggregate.CreatedAt = existing.CreatedAt
+	}
+	aggregate.UpdatedAt = now
+	s.aggregates[aggregate.AggregateID] = copyAggregate(*aggregate)
+	return !exists, nil
+}
+
+// GetAggregate retrieves the entry of one aggregate.
+func (s *MemoryStore) GetAggregate(ctx context.Context, aggregateID string) (*Aggregate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.aggregates[aggregateID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get registry entry: %w", pgx.ErrNoRows)
+	}
+	a = copyAggregate(a)
+	return &a, nil
+}
+
+// GetAggregates retrieves the entries of several aggregates, by aggregate ID.
+func (s *MemoryStore) GetAggregates(ctx context.Context, aggregateIDs []string) ([]Aggregate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := []Aggregate{}
+	for id, a := range s.aggregates {
+		if slices.Contains(aggregateIDs, id) {
+			list = append(list, copyAggregate(a))
+		}
+	}
+	sortByID(list)
+	return list, nil
+}
+
+// ListAggregates retrieves the entries matching filter by aggregate ID, with
+// pagination.
+func (s *MemoryStore) ListAggregates(ctx context.Context, filter Filter, limit, offset int) ([]Aggregate, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := []Aggregate{}
+	for _, a := range s.aggregates {
+		if filter.Matches(a) {
+			matched = append(matched, copyAggregate(a))
+		}
+	}
+	sortByID(matched)
+
+	total := len(matched)
+	if offset >= total {
+		return []Aggregate{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
+}
+
+// DeleteAggregate removes an entry.
+func (s *MemoryStore) DeleteAggregate(ctx context.Context, aggregateID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.aggregates[aggregateID]; !ok {
+		return fmt.Errorf("failed to delete registry entry: %w", pgx.ErrNoRows)
+	}
+	delete(s.aggregates, aggregateID)
+	return nil
+}
+
+func sortByID(list []Aggregate) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].AggregateID < list[j].AggregateID
+	})
+}
+
+// copyAggregate detaches the tags so callers cannot mutate stored entries.
+func copyAggregate(a Aggregate) Aggregate {
+	a.Tags = slices.Clone(a.Tags)
+	return a
+}
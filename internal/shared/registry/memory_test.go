@@ -0,0 +1,155 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+func testAggregate(id, site string, tags ...string) *Aggregate {
+	return &Aggregate{
+		AggregateID:   id,
+		AggregateType: "device",
+		Site:          site,
+		Model:         "TH-200",
+		Owner:         "facilities",
+		Tags:          tags,
+	}
+}
+
+func TestMemoryStore_CRUD(t *testing.T) {
+	created := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: created})
+	t.Cleanup(clock.Reset)
+
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	entry := testAggregate("device-001", "plant-a", "critical", "critical", "cold-chain")
+	isNew, err := store.PutAggregate(ctx, entry)
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, []string{"cold-chain", "critical"}, entry.Tags)
+	assert.Equal(t, created, entry.CreatedAt)
+
+	updated := created.Add(time.Hour)
+	clock.Set(clock.FixedClock{Time: updated})
+	replacement := testAggregate("device-001", "plant-b")
+	isNew, err = store.PutAggregate(ctx, replacement)
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, created, replacement.CreatedAt)
+	assert.Equal(t, updated, replacement.UpdatedAt)
+
+	got, err := store.GetAggregate(ctx, "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, "plant-b", got.Site)
+	assert.Equal(t, []string{}, got.Tags)
+
+	require.NoError(t, store.DeleteAggregate(ctx, "device-001"))
+	_, err = store.GetAggregate(ctx, "device-001")
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+	assert.True(t, errors.Is(store.DeleteAggregate(ctx, "device-001"), pgx.ErrNoRows))
+}
+
+func TestMemoryStore_GetAggregates(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	for _, id := range []string{"device-002", "device-001", "device-003"} {
+		_, err := store.PutAggregate(ctx, testAggregate(id, "plant-a"))
+		require.NoError(t, err)
+	}
+
+	list, err := store.GetAggregates(ctx, []string{"device-003", "device-001", "device-404"})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "device-001", list[0].AggregateID)
+	assert.Equal(t, "device-003", list[1].AggregateID)
+}
+
+func TestMemoryStore_ListAggregates(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	for _, entry := range []*Aggregate{
+		testAggregate("device-001", "plant-a", "critical"),
+		testAggregate("device-002", "plant-a"),
+		testAggregate("device-003", "plant-b", "critical"),
+	} {
+		_, err := store.PutAggregate(ctx, entry)
+		require.NoError(t, err)
+	}
+
+	list, total, err := store.ListAggregates(ctx, Filter{}, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, list, 2)
+	assert.Equal(t, "device-001", list[0].AggregateID)
+
+	list, total, err = store.ListAggregates(ctx, Filter{Site: "plant-a"}, 10, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, list, 1)
+	assert.Equal(t, "device-002", list[0].AggregateID)
+
+	list, total, err = store.ListAggregates(ctx, Filter{Tag: "critical", AggregateType: "device"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "device-003", list[1].AggregateID)
+
+	list, total, err = store.ListAggregates(ctx, Filter{Owner: "nobody"}, 10, 0)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, list)
+}
+
+func TestMemoryStore_Isolation(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	_, err := store.PutAggregate(ctx, testAggregate("device-001", "plant-a", "critical"))
+	require.NoError(t, err)
+
+	got, err := store.GetAggregate(ctx, "device-001")
+	require.NoError(t, err)
+	got.Tags[0] = "changed"
+
+	got, err = store.GetAggregate(ctx, "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"critical"}, got.Tags)
+}
+
+func TestAggregate_Validate(t *testing.T) {
+	assert.NoError(t, testAggregate("device-001", "plant-a", "critical").Validate())
+	assert.NoError(t, (&Aggregate{AggregateID: "device-001"}).Validate())
+
+	tooManyTags := make([]string, MaxTags+1)
+	for i := range tooManyTags {
+		tooManyTags[i] = "t"
+	}
+	for name, entry := range map[string]*Aggregate{
+		"no id":         {},
+		"long id":       {AggregateID: strings.Repeat("x", MaxIDLength+1)},
+		"bad type":      {AggregateID: "device-001", AggregateType: "Not A Type"},
+		"long site":     {AggregateID: "device-001", Site: strings.Repeat("x", MaxFieldLength+1)},
+		"empty tag":     {AggregateID: "device-001", Tags: []string{""}},
+		"long tag":      {AggregateID: "device-001", Tags: []string{strings.Repeat("x", MaxTagLength+1)}},
+		"too many tags": {AggregateID: "device-001", Tags: tooManyTags},
+	} {
+		assert.Error(t, entry.Validate(), name)
+	}
+}
+
+func TestAggregate_Fields(t *testing.T) {
+	entry := &Aggregate{AggregateID: "device-001", Site: "plant-a", Tags: []string{"critical"}}
+	assert.Equal(t, map[string]any{
+		"site": "plant-a",
+		"tags": []any{"critical"},
+	}, entry.Fields())
+}
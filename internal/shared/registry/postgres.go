@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgtrace"
+)
+
+// PostgresStore implements Store using PostgreSQL (table aggregate_registry,
+// in the eventhandler database).
+type PostgresStore struct {
+	db     pgretry.Querier // the pool, or a pgretry.DB wrapping it
+	logger *slog.Logger
+}
+
+// NewPostgresStore creates a new PostgresStore.
+func NewPostgresStore(pool *pgxpool.Pool, logger *slog.Logger) *PostgresStore {
+	return &PostgresStore{
+		db:     pool,
+		logger: logger.With("store", "registry"),
+	}
+}
+
+// SetQuerier routes the store's statements through q, typically a
+// pgretry.DB wrapping the pool.
+func (s *PostgresStore) SetQuerier(q pgretry.Querier) {
+	s.db = q
+}
+
+const aggregateColumns = `aggregate_id, aggregate_type, site, model, owner, tags, created_at, updated_at`
+
+// PutAggregate creates or replaces an entry, keeping its creation time.
+func (s *PostgresStore) PutAggregate(ctx context.Context, aggregate *Aggregate) (bool, error) {
+	ctx = pgtrace.WithOperation(ctx, "registry", "put_aggregate")
+	aggregate.Tags = normalizeTags(aggregate.Tags)
+
+	// xmax is 0 only on a freshly inserted row
+	query := `
+		INSERT INTO aggregate_registry (aggregate_id, aggregate_type, site, model, owner, tags, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		ON CONFLICT (aggregate_id) DO UPDATE
+		SET aggregate_type = EXCLUDED.aggregate_type,
+		    site = EXCLUDED.site,
+		    model = EXCLUDED.model,
+		    owner = EXCLUDED.owner,
+		    tags = EXCLUDED.tags,
+		    updated_at = NOW()
+		RETURNING created_at, updated_at, xmax = 0
+	`
+	var created bool
+	err := s.db.QueryRow(ctx, query,
+		aggregate.AggregateID,
+		aggregate.AggregateType,
+		aggregate.Site,
+		aggregate.Model,
+		aggregate.Owner,
+		aggregate.Tags,
+	).Scan(&aggregate.CreatedAt, &aggregate.UpdatedAt, &created)
+	if err != nil {
+		return false, fmt.Errorf("failed to put registry entry: %w", err)
+	}
+	return created, nil
+}
+
+// GetAggregate retrieves the entry of one aggregate.
+func (s *PostgresStore) GetAggregate(ctx context.Context, aggregateID string) (*Aggregate, error) {
+	ctx = pgtrace.WithOperation(ctx, "registry", "get_aggregate")
+	query := `SELECT ` + aggregateColumns + ` FROM aggregate_registry WHERE aggregate_id = $1`
+
+	rows, err := s.db.Query(ctx, query, aggregateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry entry: %w", err)
+	}
+	list, err := scanAggregates(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("failed to get registry entry: %w", pgx.ErrNoRows)
+	}
+	return &list[0], nil
+}
+
+// GetAggregates retrieves the entries of several aggregates, by aggregate ID.
+func (s *PostgresStore) GetAggregates(ctx context.Context, aggregateIDs []string) ([]Aggregate, error) {
+	ctx = pgtrace.WithOperation(ctx, "registry", "get_aggregates")
+	query := `SELECT ` + aggregateColumns + ` FROM aggregate_registry WHERE aggregate_id = ANY($1) ORDER BY aggregate_id`
+
+	rows, err := s.db.Query(ctx, query, aggregateIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry entries: %w", err)
+	}
+	return scanAggregates(rows)
+}
+
+// filterSQL applies a Filter given as $1..$5.
+const filterSQL = `($1 = '' OR aggregate_type = $1)
+		  AND ($2 = '' OR site = $2)
+		  AND ($3 = '' OR model = $3)
+		  AND ($4 = '' OR owner = $4)
+		  AND ($5 = '' OR $5 = ANY(tags))`
+
+// ListAggregates retrieves the entries matching filter by aggregate ID, with
+// pagination. Tag filters are served by the GIN index on tags.
+func (s *PostgresStore) ListAggregates(ctx context.Context, filter Filter, limit, offset int) ([]Aggregate, int, error) {
+	ctx = pgtrace.WithOperation(ctx, "registry", "list_aggregates")
+	args := []any{filter.AggregateType, filter.Site, filter.Model, filter.Owner, filter.Tag}
+
+	var total int
+	if err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM aggregate_registry WHERE `+filterSQL, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count registry entries: %w", err)
+	}
+
+	query := `SELECT ` + aggregateColumns + ` FROM aggregate_registry WHERE ` + filterSQL + `
+		ORDER BY aggregate_id
+		LIMIT $6 OFFSET $7`
+	rows, err := s.db.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list registry entries: %w", err)
+	}
+	list, err := scanAggregates(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// DeleteAggregate removes an entry.
+func (s *PostgresStore) DeleteAggregate(ctx context.Context, aggregateID string) error {
+	ctx = pgtrace.WithOperation(ctx, "registry", "delete_aggregate")
+	tag, err := s.db.Exec(ctx, `DELETE FROM aggregate_registry WHERE aggregate_id = $1`, aggregateID)
+	if err != nil {
+		return fmt.Errorf("failed to delete registry entry: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("failed to delete registry entry: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func scanAggregates(rows pgx.Rows) ([]Aggregate, error) {
+	defer rows.Close()
+
+	list := []Aggregate{}
+	for rows.Next() {
+		var a Aggregate
+		if err := rows.Scan(
+			&a.AggregateID,
+			&a.AggregateType,
+			&a.Site,
+			&a.Model,
+			&a.Owner,
+			&a.Tags,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan registry entry: %w", err)
+		}
+		if a.Tags == nil {
+			a.Tags = []string{}
+		}
+		list = append(list, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating registry entries: %w", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,95 @@
+//go:build integration
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+var testPool *pgxpool.Pool
+
+func TestMain(m *testing.M) {
+	pool, drop := testutil.MustNewSchemaPool("registry")
+	testutil.MustRunMigrations(pool, "../../services/eventhandler/migrations")
+	testPool = pool
+	code := m.Run()
+	drop()
+	os.Exit(code)
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestPostgresStore_CRUD(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "aggregate_registry")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	entry := testAggregate("device-001", "plant-a", "critical", "critical", "cold-chain")
+	isNew, err := store.PutAggregate(ctx, entry)
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, []string{"cold-chain", "critical"}, entry.Tags)
+	assert.False(t, entry.CreatedAt.IsZero())
+
+	replacement := testAggregate("device-001", "plant-b")
+	isNew, err = store.PutAggregate(ctx, replacement)
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.True(t, replacement.CreatedAt.Equal(entry.CreatedAt))
+
+	got, err := store.GetAggregate(ctx, "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, "plant-b", got.Site)
+	assert.Equal(t, "device", got.AggregateType)
+	assert.Equal(t, []string{}, got.Tags)
+
+	require.NoError(t, store.DeleteAggregate(ctx, "device-001"))
+	_, err = store.GetAggregate(ctx, "device-001")
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+	assert.True(t, errors.Is(store.DeleteAggregate(ctx, "device-001"), pgx.ErrNoRows))
+}
+
+func TestPostgresStore_Lists(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "aggregate_registry")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+	for _, entry := range []*Aggregate{
+		testAggregate("device-001", "plant-a", "critical"),
+		testAggregate("device-002", "plant-a"),
+		testAggregate("device-003", "plant-b", "critical"),
+	} {
+		_, err := store.PutAggregate(ctx, entry)
+		require.NoError(t, err)
+	}
+
+	list, err := store.GetAggregates(ctx, []string{"device-003", "device-001", "device-404"})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "device-001", list[0].AggregateID)
+	assert.Equal(t, []string{"critical"}, list[0].Tags)
+
+	list, total, err := store.ListAggregates(ctx, Filter{Site: "plant-a"}, 10, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, list, 1)
+	assert.Equal(t, "device-002", list[0].AggregateID)
+
+	list, total, err = store.ListAggregates(ctx, Filter{Tag: "critical", AggregateType: "device"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, list, 2)
+	assert.Equal(t, "device-003", list[1].AggregateID)
+}
@@ -0,0 +1,148 @@
+// Package registry keeps the static attributes of known aggregates — the
+// site a device is installed at, its model, its owner and free-form tags —
+// so device metadata does not have to travel in every event payload or live
+// in projections. Entries are managed through the eventhandler admin API,
+// joined into query responses and available to handlers (rollup groups) and
+// to the actions rule engine (conditions on registry fields).
+package registry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// FieldPrefix marks rule condition fields and rollup group paths that
+// resolve against the aggregate's registry entry (see Aggregate.Fields)
+// rather than the event payload, e.g. "registry.site".
+const FieldPrefix = "registry."
+
+// Limits on registry entries.
+const (
+	MaxIDLength    = 255
+	MaxFieldLength = 255
+	MaxTags        = 32
+	MaxTagLength   = 64
+)
+
+// Aggregate is the registry entry of one aggregate.
+type Aggregate struct {
+	AggregateID   string    `json:"aggregate_id"`
+	AggregateType string    `json:"aggregate_type,omitempty"` // see events.ValidateAggregateType
+	Site          string    `json:"site,omitempty"`
+	Model         string    `json:"model,omitempty"`
+	Owner         string    `json:"owner,omitempty"`
+	Tags          []string  `json:"tags"` // sorted, without duplicates
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Validate checks the entry's fields against the registry limits.
+func (a *Aggregate) Validate() error {
+	if a.AggregateID == "" {
+		return fmt.Errorf("aggregate_id is required")
+	}
+	if len(a.AggregateID) > MaxIDLength {
+		return fmt.Errorf("aggregate_id is longer than %d bytes", MaxIDLength)
+	}
+	if err := events.ValidateAggregateType(a.AggregateType); err != nil {
+		return err
+	}
+	for name, value := range map[string]string{"site": a.Site, "model": a.Model, "owner": a.Owner} {
+		if len(value) > MaxFieldLength {
+			return fmt.Errorf("%s is longer than %d bytes", name, MaxFieldLength)
+		}
+	}
+	if len(a.Tags) > MaxTags {
+		return fmt.Errorf("%d tags (max %d)", len(a.Tags), MaxTags)
+	}
+	for _, tag := range a.Tags {
+		if tag == "" || len(tag) > MaxTagLength {
+			return fmt.Errorf("invalid tag %q: must be 1 to %d bytes", tag, MaxTagLength)
+		}
+	}
+	return nil
+}
+
+// Fields returns the entry as a document for rule conditions and rollup
+// groups: the aggregate type, site, model and owner when set, and the tags.
+func (a *Aggregate) Fields() map[string]any {
+	doc := map[string]any{}
+	for name, value := range map[string]string{
+		"aggregate_type": a.AggregateType,
+		"site":           a.Site,
+		"model":          a.Model,
+		"owner":          a.Owner,
+	} {
+		if value != "" {
+			doc[name] = value
+		}
+	}
+	tags := make([]any, len(a.Tags))
+	for i, tag := range a.Tags {
+		tags[i] = tag
+	}
+	doc["tags"] = tags
+	return doc
+}
+
+// normalizeTags sorts tags and drops duplicates, so stored entries compare
+// and filter the same regardless of the order they were written in.
+func normalizeTags(tags []string) []string {
+	normalized := slices.Clone(tags)
+	if normalized == nil {
+		normalized = []string{}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// Filter narrows ListAggregates, and projection lists to registered
+// aggregates. Empty fields match every entry.
+type Filter struct {
+	AggregateType string
+	Site          string
+	Model         string
+	Owner         string
+	Tag           string // entries carrying this tag
+}
+
+// Matches reports whether an entry passes the filter.
+func (f Filter) Matches(a Aggregate) bool {
+	return (f.AggregateType == "" || a.AggregateType == f.AggregateType) &&
+		(f.Site == "" || a.Site == f.Site) &&
+		(f.Model == "" || a.Model == f.Model) &&
+		(f.Owner == "" || a.Owner == f.Owner) &&
+		(f.Tag == "" || slices.Contains(a.Tags, f.Tag))
+}
+
+// Reader reads registry entries. Missing entries are reported as errors
+// wrapping pgx.ErrNoRows.
+type Reader interface {
+	// GetAggregate retrieves the entry of one aggregate.
+	GetAggregate(ctx context.Context, aggregateID string) (*Aggregate, error)
+
+	// GetAggregates retrieves the entries of several aggregates; aggregates
+	// without an entry are left out.
+	GetAggregates(ctx context.Context, aggregateIDs []string) ([]Aggregate, error)
+}
+
+// Store provides CRUD operations for registry entries.
+type Store interface {
+	Reader
+
+	// PutAggregate creates or replaces an entry, keeping its creation time,
+	// and reports whether it was created. It sets the entry's timestamps and
+	// normalizes its tags.
+	PutAggregate(ctx context.Context, aggregate *Aggregate) (bool, error)
+
+	// ListAggregates retrieves the entries matching filter by aggregate ID,
+	// with pagination, and the total number of matches.
+	ListAggregates(ctx context.Context, filter Filter, limit, offset int) ([]Aggregate, int, error)
+
+	// DeleteAggregate removes an entry.
+	DeleteAggregate(ctx context.Context, aggregateID string) error
+}
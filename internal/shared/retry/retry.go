@@ -0,0 +1,87 @@
+// Package retry holds what the platform's retry loops share: exponential
+// backoff and a circuit breaker. The loops themselves stay with their
+// callers, which decide what is worth retrying and what is safe to repeat.
+package retry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// Backoff returns the wait before retry n (1 for the first retry): base,
+// doubled for each further retry, and at most limit.
+func Backoff(base, limit time.Duration, n int) time.Duration {
+	d := base
+	for i := 1; i < n && d < limit; i++ {
+		d *= 2
+	}
+	if limit > 0 && d > limit {
+		d = limit
+	}
+	return d
+}
+
+// Breaker stops calls to a dependency that keeps failing. After threshold
+// consecutive failures it opens and rejects calls for openFor. It then lets
+// a single trial call through: success closes it, failure opens it again.
+// Time is read from the clock package.
+type Breaker struct {
+	threshold int
+	openFor   time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // zero while closed
+	trial    bool      // a trial call is in flight
+}
+
+// NewBreaker creates a closed breaker.
+func NewBreaker(threshold int, openFor time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, openFor: openFor}
+}
+
+// Allow reports whether a call may be made. A call it allows must end with
+// Record or Release.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return true
+	}
+	if b.trial || clock.Now().Sub(b.openedAt) < b.openFor {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// Record updates the breaker with the outcome of a call it allowed and
+// reports whether the breaker just opened.
+func (b *Breaker) Record(success bool) (opened bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if success {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return false
+	}
+	b.failures++
+	if !b.openedAt.IsZero() || b.failures >= b.threshold {
+		opened = b.openedAt.IsZero()
+		b.openedAt = clock.Now()
+	}
+	return opened
+}
+
+// Release ends a call the breaker allowed without recording an outcome, such
+// as one cancelled by its caller.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
@@ -0,0 +1,72 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+func TestBackoff(t *testing.T) {
+	base, limit := 100*time.Millisecond, time.Second
+
+	assert.Equal(t, 100*time.Millisecond, Backoff(base, limit, 1))
+	assert.Equal(t, 200*time.Millisecond, Backoff(base, limit, 2))
+	assert.Equal(t, 400*time.Millisecond, Backoff(base, limit, 3))
+	assert.Equal(t, 800*time.Millisecond, Backoff(base, limit, 4))
+	assert.Equal(t, time.Second, Backoff(base, limit, 5))
+	assert.Equal(t, time.Second, Backoff(base, limit, 50))
+	assert.Equal(t, time.Second, Backoff(2*time.Second, limit, 1))
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	defer clock.Reset()
+
+	b := NewBreaker(2, 30*time.Second)
+
+	// A success resets the consecutive failure count
+	assert.True(t, b.Allow())
+	assert.False(t, b.Record(false))
+	assert.True(t, b.Allow())
+	b.Record(true)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Record(false))
+	assert.True(t, b.Allow())
+	assert.True(t, b.Record(false), "two in a row open it")
+	assert.False(t, b.Allow())
+
+	// After openFor a single trial goes through
+	clock.Set(clock.FixedClock{Time: now.Add(30 * time.Second)})
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow(), "only one trial at a time")
+
+	// A failed trial reopens it for another openFor
+	assert.False(t, b.Record(false), "it was already open")
+	assert.False(t, b.Allow())
+	clock.Set(clock.FixedClock{Time: now.Add(60 * time.Second)})
+	assert.True(t, b.Allow())
+
+	// A successful trial closes it
+	b.Record(true)
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+}
+
+func TestBreaker_Release(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	defer clock.Reset()
+
+	b := NewBreaker(1, time.Minute)
+	assert.True(t, b.Allow())
+	b.Record(false)
+
+	clock.Set(clock.FixedClock{Time: now.Add(time.Minute)})
+	assert.True(t, b.Allow())
+	b.Release()
+	assert.True(t, b.Allow(), "a released trial lets another through")
+}
@@ -0,0 +1,33 @@
+package rules
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// DeliveryAttempt records one attempt to deliver an action to an external
+// destination. Retries of the same delivery share DeliveryID.
+type DeliveryAttempt struct {
+	AttemptID   uuid.UUID     `json:"attempt_id"`
+	DeliveryID  uuid.UUID     `json:"delivery_id"`
+	RuleID      uuid.UUID     `json:"rule_id"`
+	EventID     uuid.UUID     `json:"event_id"`
+	ActionType  string        `json:"action_type"`
+	Destination string        `json:"destination"`
+	Attempt     int           `json:"attempt"`               // 1 for the first attempt
+	StatusCode  int           `json:"status_code,omitempty"` // 0 when no response was received
+	Error       string        `json:"error,omitempty"`
+	Duration    time.Duration `json:"duration"`
+	AttemptedAt time.Time     `json:"attempted_at"`
+}
+
+// DeliveryStore records delivery attempts for auditing.
+type DeliveryStore interface {
+	// RecordAttempt stores an attempt, assigning its ID.
+	RecordAttempt(ctx context.Context, attempt *DeliveryAttempt) error
+
+	// ListAttempts retrieves up to limit of a rule's attempts, newest first.
+	ListAttempts(ctx context.Context, ruleID uuid.UUID, limit int) ([]DeliveryAttempt, error)
+}
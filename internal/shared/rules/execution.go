@@ -0,0 +1,66 @@
+package rules
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// Execution statuses.
+const (
+	ExecutionSucceeded = "succeeded"
+	ExecutionFailed    = "failed"
+	ExecutionSkipped   = "skipped" // no dispatcher for the rule's action type
+)
+
+// IsExecutionStatus reports whether s is an execution status.
+func IsExecutionStatus(s string) bool {
+	return s == ExecutionSucceeded || s == ExecutionFailed || s == ExecutionSkipped
+}
+
+// Execution records a rule firing for an event and the outcome of its action.
+type Execution struct {
+	ExecutionID uuid.UUID `json:"execution_id"`
+	RuleID      uuid.UUID `json:"rule_id"`
+	EventID     uuid.UUID `json:"event_id"`
+	EventType   string    `json:"event_type"`
+	AggregateID string    `json:"aggregate_id"`
+	ActionType  string    `json:"action_type"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	LatencyMs   int64     `json:"latency_ms"` // time spent in the action, including retries
+	ExecutedAt  time.Time `json:"executed_at"`
+}
+
+// ExecutionFilter selects executions. Zero fields match everything.
+type ExecutionFilter struct {
+	RuleID      uuid.UUID
+	EventID     uuid.UUID
+	AggregateID string
+	ActionType  string
+	Status      string
+	Since       time.Time // inclusive
+	Until       time.Time // exclusive
+}
+
+// matches reports whether an execution passes the filter.
+func (f *ExecutionFilter) matches(e *Execution) bool {
+	return (f.RuleID == uuid.Nil || e.RuleID == f.RuleID) &&
+		(f.EventID == uuid.Nil || e.EventID == f.EventID) &&
+		(f.AggregateID == "" || e.AggregateID == f.AggregateID) &&
+		(f.ActionType == "" || e.ActionType == f.ActionType) &&
+		(f.Status == "" || e.Status == f.Status) &&
+		(f.Since.IsZero() || !e.ExecutedAt.Before(f.Since)) &&
+		(f.Until.IsZero() || e.ExecutedAt.Before(f.Until))
+}
+
+// ExecutionStore records and queries rule executions.
+type ExecutionStore interface {
+	// RecordExecution stores an execution, assigning its ID.
+	RecordExecution(ctx context.Context, execution *Execution) error
+
+	// ListExecutions retrieves a page of matching executions, newest first,
+	// and the total number that match.
+	ListExecutions(ctx context.Context, filter ExecutionFilter, limit, offset int) ([]Execution, int, error)
+}
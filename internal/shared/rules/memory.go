@@ -0,0 +1,194 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// MemoryStore implements Store, DeliveryStore and ExecutionStore in memory.
+// Used by tests and sandbox mode; mirrors PostgresStore semantics (ErrNoRows on miss).
+type MemoryStore struct {
+	mu         sync.RWMutex
+	rules      map[uuid.UUID]Rule
+	attempts   []DeliveryAttempt
+	executions []Execution
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{rules: make(map[uuid.UUID]Rule)}
+}
+
+// CreateRule stores a new rule, assigning its ID and timestamps.
+func (s *MemoryStore) CreateRule(ctx context.Context, rule *Rule) error {
+	ruleID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate rule ID: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := clock.Now()
+	rule.RuleID = ruleID
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	s.rules[ruleID] = copyRule(*rule)
+	return nil
+}
+
+// GetRule retrieves a rule by ID.
+func (s *MemoryStore) GetRule(ctx context.Context, ruleID uuid.UUID) (*Rule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.rules[ruleID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get rule: %w", pgx.ErrNoRows)
+	}
+	r = copyRule(r)
+	return &r, nil
+}
+
+// ListRules retrieves all rules, oldest first.
+func (s *MemoryStore) ListRules(ctx context.Context) ([]Rule, error) {
+	return s.list(false), nil
+}
+
+// ListEnabled retrieves the enabled rules, oldest first.
+func (s *MemoryStore) ListEnabled(ctx context.Context) ([]Rule, error) {
+	return s.list(true), nil
+}
+
+// UpdateRule replaces a rule's fields, keeping its ID and creation time.
+func (s *MemoryStore) UpdateRule(ctx context.Context, rule *Rule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.rules[rule.RuleID]
+	if !ok {
+		return fmt.Errorf("failed to update rule: %w", pgx.ErrNoRows)
+	}
+	rule.CreatedAt = existing.CreatedAt
+	rule.UpdatedAt = clock.Now()
+	s.rules[rule.RuleID] = copyRule(*rule)
+	return nil
+}
+
+// DeleteRule removes a rule.
+func (s *MemoryStore) DeleteRule(ctx context.Context, ruleID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rules[ruleID]; !ok {
+		return fmt.Errorf("failed to delete rule: %w", pgx.ErrNoRows)
+	}
+	delete(s.rules, ruleID)
+	return nil
+}
+
+func (s *MemoryStore) list(enabledOnly bool) []Rule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := []Rule{}
+	for _, r := range s.rules {
+		if enabledOnly && !r.Enabled {
+			continue
+		}
+		list = append(list, copyRule(r))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		}
+		return list[i].RuleID.String() < list[j].RuleID.String()
+	})
+	return list
+}
+
+// copyRule detaches the predicate so callers cannot mutate stored rules.
+func copyRule(r Rule) Rule {
+	if r.Predicate == nil {
+		r.Predicate = Predicate{}
+	} else {
+		r.Predicate = append(Predicate{}, r.Predicate...)
+	}
+	return r
+}
+
+// RecordAttempt stores a delivery attempt, assigning its ID.
+func (s *MemoryStore) RecordAttempt(ctx context.Context, attempt *DeliveryAttempt) error {
+	attemptID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate attempt ID: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt.AttemptID = attemptID
+	s.attempts = append(s.attempts, *attempt)
+	return nil
+}
+
+// ListAttempts retrieves up to limit of a rule's delivery attempts, newest first.
+func (s *MemoryStore) ListAttempts(ctx context.Context, ruleID uuid.UUID, limit int) ([]DeliveryAttempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := []DeliveryAttempt{}
+	for i := len(s.attempts) - 1; i >= 0 && len(list) < limit; i-- {
+		if s.attempts[i].RuleID == ruleID {
+			list = append(list, s.attempts[i])
+		}
+	}
+	return list, nil
+}
+
+// RecordExecution stores a rule execution, assigning its ID.
+func (s *MemoryStore) RecordExecution(ctx context.Context, execution *Execution) error {
+	executionID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate execution ID: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	execution.ExecutionID = executionID
+	s.executions = append(s.executions, *execution)
+	return nil
+}
+
+// ListExecutions retrieves a page of matching executions, newest first, and
+// the total number that match.
+func (s *MemoryStore) ListExecutions(ctx context.Context, filter ExecutionFilter, limit, offset int) ([]Execution, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := []Execution{}
+	for i := range s.executions {
+		if filter.matches(&s.executions[i]) {
+			matched = append(matched, s.executions[i])
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if !matched[i].ExecutedAt.Equal(matched[j].ExecutedAt) {
+			return matched[i].ExecutedAt.After(matched[j].ExecutedAt)
+		}
+		return matched[i].ExecutionID.String() > matched[j].ExecutionID.String()
+	})
+
+	total := len(matched)
+	start := min(offset, total)
+	end := min(start+limit, total)
+	return matched[start:end], total, nil
+}
@@ -0,0 +1,177 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+func testRule(name string, enabled bool) *Rule {
+	return &Rule{
+		Name:       name,
+		EventType:  "sensor.reading",
+		Predicate:  Predicate{{Field: "value", Op: OpGt, Value: json.RawMessage(`90`)}},
+		ActionType: "log",
+		Enabled:    enabled,
+	}
+}
+
+func TestMemoryStore_CRUD(t *testing.T) {
+	created := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: created})
+	t.Cleanup(clock.Reset)
+
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	rule := testRule("hot", true)
+	require.NoError(t, store.CreateRule(ctx, rule))
+	assert.NotEqual(t, uuid.Nil, rule.RuleID)
+	assert.Equal(t, created, rule.CreatedAt)
+
+	got, err := store.GetRule(ctx, rule.RuleID)
+	require.NoError(t, err)
+	assert.Equal(t, rule, got)
+
+	updated := time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: updated})
+	replacement := testRule("very hot", false)
+	replacement.RuleID = rule.RuleID
+	require.NoError(t, store.UpdateRule(ctx, replacement))
+	assert.Equal(t, created, replacement.CreatedAt)
+	assert.Equal(t, updated, replacement.UpdatedAt)
+
+	got, err = store.GetRule(ctx, rule.RuleID)
+	require.NoError(t, err)
+	assert.Equal(t, "very hot", got.Name)
+	assert.False(t, got.Enabled)
+
+	require.NoError(t, store.DeleteRule(ctx, rule.RuleID))
+	_, err = store.GetRule(ctx, rule.RuleID)
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+}
+
+func TestMemoryStore_NotFound(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	missing := uuid.Must(uuid.NewV7())
+
+	_, err := store.GetRule(ctx, missing)
+	assert.ErrorContains(t, err, "no rows")
+
+	rule := testRule("hot", true)
+	rule.RuleID = missing
+	assert.ErrorContains(t, store.UpdateRule(ctx, rule), "no rows")
+	assert.ErrorContains(t, store.DeleteRule(ctx, missing), "no rows")
+}
+
+func TestMemoryStore_ListEnabled(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	t.Cleanup(clock.Reset)
+	for i, r := range []*Rule{testRule("first", true), testRule("disabled", false), testRule("third", true)} {
+		clock.Set(clock.FixedClock{Time: base.Add(time.Duration(i) * time.Second)})
+		require.NoError(t, store.CreateRule(ctx, r))
+	}
+
+	all, err := store.ListRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "first", all[0].Name)
+	assert.Equal(t, "third", all[2].Name)
+
+	enabled, err := store.ListEnabled(ctx)
+	require.NoError(t, err)
+	require.Len(t, enabled, 2)
+	assert.Equal(t, "first", enabled[0].Name)
+	assert.Equal(t, "third", enabled[1].Name)
+}
+
+func TestMemoryStore_ReturnsCopies(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	rule := testRule("hot", true)
+	require.NoError(t, store.CreateRule(ctx, rule))
+
+	got, err := store.GetRule(ctx, rule.RuleID)
+	require.NoError(t, err)
+	got.Predicate[0].Field = "mutated"
+
+	again, err := store.GetRule(ctx, rule.RuleID)
+	require.NoError(t, err)
+	assert.Equal(t, "value", again.Predicate[0].Field)
+}
+
+func TestMemoryStore_DeliveryAttempts(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	ruleID := uuid.Must(uuid.NewV7())
+
+	for i := 1; i <= 3; i++ {
+		attempt := &DeliveryAttempt{RuleID: ruleID, Attempt: i}
+		require.NoError(t, store.RecordAttempt(ctx, attempt))
+		assert.NotEqual(t, uuid.Nil, attempt.AttemptID)
+	}
+	require.NoError(t, store.RecordAttempt(ctx, &DeliveryAttempt{RuleID: uuid.Must(uuid.NewV7())}))
+
+	list, err := store.ListAttempts(ctx, ruleID, 2)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, 3, list[0].Attempt, "newest first")
+	assert.Equal(t, 2, list[1].Attempt)
+}
+
+func TestMemoryStore_ListExecutions(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	base := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	ruleA, ruleB := uuid.Must(uuid.NewV7()), uuid.Must(uuid.NewV7())
+
+	for i, e := range []Execution{
+		{RuleID: ruleA, AggregateID: "device-001", Status: ExecutionSucceeded},
+		{RuleID: ruleA, AggregateID: "device-002", Status: ExecutionFailed},
+		{RuleID: ruleB, AggregateID: "device-001", Status: ExecutionSucceeded},
+		{RuleID: ruleA, AggregateID: "device-001", Status: ExecutionSucceeded},
+	} {
+		e.ExecutedAt = base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, store.RecordExecution(ctx, &e))
+		assert.NotEqual(t, uuid.Nil, e.ExecutionID)
+	}
+
+	list, total, err := store.ListExecutions(ctx, ExecutionFilter{RuleID: ruleA}, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, list, 2)
+	assert.Equal(t, base.Add(3*time.Minute), list[0].ExecutedAt, "newest first")
+
+	list, _, err = store.ListExecutions(ctx, ExecutionFilter{RuleID: ruleA}, 2, 2)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, base, list[0].ExecutedAt)
+
+	_, total, err = store.ListExecutions(ctx, ExecutionFilter{AggregateID: "device-001", Status: ExecutionSucceeded}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	list, total, err = store.ListExecutions(ctx, ExecutionFilter{Since: base.Add(time.Minute), Until: base.Add(3 * time.Minute)}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, ruleB, list[0].RuleID)
+
+	list, total, err = store.ListExecutions(ctx, ExecutionFilter{}, 10, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Empty(t, list)
+}
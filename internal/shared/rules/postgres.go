@@ -0,0 +1,390 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgtrace"
+)
+
+// PostgresStore implements Store, DeliveryStore and ExecutionStore using PostgreSQL.
+type PostgresStore struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+}
+
+// NewPostgresStore creates a new PostgresStore.
+func NewPostgresStore(pool *pgxpool.Pool, logger *slog.Logger) *PostgresStore {
+	return &PostgresStore{
+		pool:   pool,
+		logger: logger.With("store", "rules"),
+	}
+}
+
+const ruleColumns = `rule_id, name, event_type, predicate, action_type, destination, template, enabled, created_at, updated_at`
+
+// CreateRule stores a new rule, assigning its ID and timestamps.
+func (s *PostgresStore) CreateRule(ctx context.Context, rule *Rule) error {
+	ctx = pgtrace.WithOperation(ctx, "rules", "create_rule")
+	predicate, err := marshalPredicate(rule.Predicate)
+	if err != nil {
+		return fmt.Errorf("failed to encode predicate: %w", err)
+	}
+	ruleID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate rule ID: %w", err)
+	}
+
+	query := `
+		INSERT INTO rules (rule_id, name, event_type, predicate, action_type, destination, template, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		RETURNING created_at, updated_at
+	`
+	err = s.pool.QueryRow(ctx, query,
+		ruleID,
+		rule.Name,
+		rule.EventType,
+		predicate,
+		rule.ActionType,
+		rule.Destination,
+		rule.Template,
+		rule.Enabled,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create rule: %w", err)
+	}
+	rule.RuleID = ruleID
+	return nil
+}
+
+// GetRule retrieves a rule by ID.
+func (s *PostgresStore) GetRule(ctx context.Context, ruleID uuid.UUID) (*Rule, error) {
+	ctx = pgtrace.WithOperation(ctx, "rules", "get_rule")
+	query := `SELECT ` + ruleColumns + ` FROM rules WHERE rule_id = $1`
+
+	rows, err := s.pool.Query(ctx, query, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule: %w", err)
+	}
+	list, err := scanRules(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("failed to get rule: %w", pgx.ErrNoRows)
+	}
+	return &list[0], nil
+}
+
+// ListRules retrieves all rules, oldest first.
+func (s *PostgresStore) ListRules(ctx context.Context) ([]Rule, error) {
+	ctx = pgtrace.WithOperation(ctx, "rules", "list_rules")
+	query := `SELECT ` + ruleColumns + ` FROM rules ORDER BY created_at, rule_id`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules: %w", err)
+	}
+	return scanRules(rows)
+}
+
+// ListEnabled retrieves the enabled rules, oldest first.
+func (s *PostgresStore) ListEnabled(ctx context.Context) ([]Rule, error) {
+	ctx = pgtrace.WithOperation(ctx, "rules", "list_enabled")
+	query := `SELECT ` + ruleColumns + ` FROM rules WHERE enabled ORDER BY created_at, rule_id`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list enabled rules: %w", err)
+	}
+	return scanRules(rows)
+}
+
+// UpdateRule replaces a rule's fields, keeping its ID and creation time.
+func (s *PostgresStore) UpdateRule(ctx context.Context, rule *Rule) error {
+	ctx = pgtrace.WithOperation(ctx, "rules", "update_rule")
+	predicate, err := marshalPredicate(rule.Predicate)
+	if err != nil {
+		return fmt.Errorf("failed to encode predicate: %w", err)
+	}
+
+	query := `
+		UPDATE rules
+		SET name = $2, event_type = $3, predicate = $4, action_type = $5,
+		    destination = $6, template = $7, enabled = $8, updated_at = NOW()
+		WHERE rule_id = $1
+		RETURNING created_at, updated_at
+	`
+	err = s.pool.QueryRow(ctx, query,
+		rule.RuleID,
+		rule.Name,
+		rule.EventType,
+		predicate,
+		rule.ActionType,
+		rule.Destination,
+		rule.Template,
+		rule.Enabled,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update rule: %w", err)
+	}
+	return nil
+}
+
+// DeleteRule removes a rule.
+func (s *PostgresStore) DeleteRule(ctx context.Context, ruleID uuid.UUID) error {
+	ctx = pgtrace.WithOperation(ctx, "rules", "delete_rule")
+	result, err := s.pool.Exec(ctx, `DELETE FROM rules WHERE rule_id = $1`, ruleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("failed to delete rule: %w", pgx.ErrNoRows)
+	}
+	return nil
+}
+
+// scanRules reads rule rows selected with ruleColumns and closes rows.
+func scanRules(rows pgx.Rows) ([]Rule, error) {
+	defer rows.Close()
+
+	list := []Rule{}
+	for rows.Next() {
+		var r Rule
+		var predicate []byte
+		if err := rows.Scan(
+			&r.RuleID,
+			&r.Name,
+			&r.EventType,
+			&predicate,
+			&r.ActionType,
+			&r.Destination,
+			&r.Template,
+			&r.Enabled,
+			&r.CreatedAt,
+			&r.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		p, err := unmarshalPredicate(predicate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode predicate of rule %s: %w", r.RuleID, err)
+		}
+		r.Predicate = p
+		list = append(list, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rules: %w", err)
+	}
+	return list, nil
+}
+
+// RecordAttempt stores a delivery attempt, assigning its ID.
+func (s *PostgresStore) RecordAttempt(ctx context.Context, attempt *DeliveryAttempt) error {
+	ctx = pgtrace.WithOperation(ctx, "rules", "record_attempt")
+	attemptID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate attempt ID: %w", err)
+	}
+
+	var statusCode *int
+	if attempt.StatusCode != 0 {
+		statusCode = &attempt.StatusCode
+	}
+	query := `
+		INSERT INTO delivery_attempts (attempt_id, delivery_id, rule_id, event_id, action_type,
+		                               destination, attempt, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err = s.pool.Exec(ctx, query,
+		attemptID,
+		attempt.DeliveryID,
+		attempt.RuleID,
+		attempt.EventID,
+		attempt.ActionType,
+		attempt.Destination,
+		attempt.Attempt,
+		statusCode,
+		attempt.Error,
+		attempt.Duration.Milliseconds(),
+		attempt.AttemptedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record delivery attempt: %w", err)
+	}
+	attempt.AttemptID = attemptID
+	return nil
+}
+
+// ListAttempts retrieves up to limit of a rule's delivery attempts, newest first.
+func (s *PostgresStore) ListAttempts(ctx context.Context, ruleID uuid.UUID, limit int) ([]DeliveryAttempt, error) {
+	ctx = pgtrace.WithOperation(ctx, "rules", "list_attempts")
+	query := `
+		SELECT attempt_id, delivery_id, rule_id, event_id, action_type, destination,
+		       attempt, status_code, error, duration_ms, attempted_at
+		FROM delivery_attempts
+		WHERE rule_id = $1
+		ORDER BY attempted_at DESC, attempt_id DESC
+		LIMIT $2
+	`
+	rows, err := s.pool.Query(ctx, query, ruleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list delivery attempts: %w", err)
+	}
+	defer rows.Close()
+
+	list := []DeliveryAttempt{}
+	for rows.Next() {
+		var a DeliveryAttempt
+		var statusCode *int
+		var durationMs int64
+		if err := rows.Scan(
+			&a.AttemptID,
+			&a.DeliveryID,
+			&a.RuleID,
+			&a.EventID,
+			&a.ActionType,
+			&a.Destination,
+			&a.Attempt,
+			&statusCode,
+			&a.Error,
+			&durationMs,
+			&a.AttemptedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan delivery attempt: %w", err)
+		}
+		if statusCode != nil {
+			a.StatusCode = *statusCode
+		}
+		a.Duration = time.Duration(durationMs) * time.Millisecond
+		list = append(list, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating delivery attempts: %w", err)
+	}
+	return list, nil
+}
+
+// RecordExecution stores a rule execution, assigning its ID.
+func (s *PostgresStore) RecordExecution(ctx context.Context, execution *Execution) error {
+	ctx = pgtrace.WithOperation(ctx, "rules", "record_execution")
+	executionID, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate execution ID: %w", err)
+	}
+
+	query := `
+		INSERT INTO actions_log (execution_id, rule_id, event_id, event_type, aggregate_id,
+		                         action_type, status, error, latency_ms, executed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err = s.pool.Exec(ctx, query,
+		executionID,
+		execution.RuleID,
+		execution.EventID,
+		execution.EventType,
+		execution.AggregateID,
+		execution.ActionType,
+		execution.Status,
+		execution.Error,
+		execution.LatencyMs,
+		execution.ExecutedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record execution: %w", err)
+	}
+	execution.ExecutionID = executionID
+	return nil
+}
+
+// ListExecutions retrieves a page of matching executions, newest first, and
+// the total number that match.
+func (s *PostgresStore) ListExecutions(ctx context.Context, filter ExecutionFilter, limit, offset int) ([]Execution, int, error) {
+	ctx = pgtrace.WithOperation(ctx, "rules", "list_executions")
+	where, args := executionWhere(filter)
+
+	var total int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM actions_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count executions: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT execution_id, rule_id, event_id, event_type, aggregate_id,
+		       action_type, status, error, latency_ms, executed_at
+		FROM actions_log%s
+		ORDER BY executed_at DESC, execution_id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+	rows, err := s.pool.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list executions: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Execution{}
+	for rows.Next() {
+		var e Execution
+		if err := rows.Scan(
+			&e.ExecutionID,
+			&e.RuleID,
+			&e.EventID,
+			&e.EventType,
+			&e.AggregateID,
+			&e.ActionType,
+			&e.Status,
+			&e.Error,
+			&e.LatencyMs,
+			&e.ExecutedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan execution: %w", err)
+		}
+		list = append(list, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating executions: %w", err)
+	}
+	return list, total, nil
+}
+
+// executionWhere builds the WHERE clause (with a leading space, or empty) and
+// its arguments for a filter.
+func executionWhere(filter ExecutionFilter) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.RuleID != uuid.Nil {
+		add("rule_id = $%d", filter.RuleID)
+	}
+	if filter.EventID != uuid.Nil {
+		add("event_id = $%d", filter.EventID)
+	}
+	if filter.AggregateID != "" {
+		add("aggregate_id = $%d", filter.AggregateID)
+	}
+	if filter.ActionType != "" {
+		add("action_type = $%d", filter.ActionType)
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if !filter.Since.IsZero() {
+		add("executed_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("executed_at < $%d", filter.Until)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
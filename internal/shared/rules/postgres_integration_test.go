@@ -0,0 +1,184 @@
+//go:build integration
+
+package rules
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+var testPool *pgxpool.Pool
+
+func TestMain(m *testing.M) {
+	pool, drop := testutil.MustNewSchemaPool("rules")
+	testutil.MustRunMigrations(pool, "../../services/actions/migrations")
+	testPool = pool
+	code := m.Run()
+	drop()
+	os.Exit(code)
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestPostgresStore_CRUD(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "rules")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	rule := testRule("hot", true)
+	require.NoError(t, store.CreateRule(ctx, rule))
+	assert.NotEqual(t, uuid.Nil, rule.RuleID)
+	assert.False(t, rule.CreatedAt.IsZero())
+
+	got, err := store.GetRule(ctx, rule.RuleID)
+	require.NoError(t, err)
+	assert.Equal(t, "hot", got.Name)
+	assert.Equal(t, rule.Predicate, got.Predicate)
+	assert.True(t, got.Enabled)
+
+	replacement := testRule("very hot", false)
+	replacement.RuleID = rule.RuleID
+	replacement.Predicate = nil
+	replacement.Template = "{{.Event.AggregateID}} at {{.Payload.value}}"
+	require.NoError(t, store.UpdateRule(ctx, replacement))
+	assert.True(t, replacement.CreatedAt.Equal(got.CreatedAt))
+
+	got, err = store.GetRule(ctx, rule.RuleID)
+	require.NoError(t, err)
+	assert.Equal(t, "very hot", got.Name)
+	assert.False(t, got.Enabled)
+	assert.Equal(t, Predicate{}, got.Predicate)
+	assert.Equal(t, replacement.Template, got.Template)
+
+	require.NoError(t, store.DeleteRule(ctx, rule.RuleID))
+	_, err = store.GetRule(ctx, rule.RuleID)
+	assert.True(t, errors.Is(err, pgx.ErrNoRows))
+}
+
+func TestPostgresStore_NotFound(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "rules")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+	missing := uuid.Must(uuid.NewV7())
+
+	rule := testRule("hot", true)
+	rule.RuleID = missing
+	assert.ErrorContains(t, store.UpdateRule(ctx, rule), "no rows")
+	assert.ErrorContains(t, store.DeleteRule(ctx, missing), "no rows")
+}
+
+func TestPostgresStore_ListEnabled(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "rules")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	for _, r := range []*Rule{testRule("first", true), testRule("disabled", false), testRule("third", true)} {
+		require.NoError(t, store.CreateRule(ctx, r))
+	}
+
+	all, err := store.ListRules(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	enabled, err := store.ListEnabled(ctx)
+	require.NoError(t, err)
+	require.Len(t, enabled, 2)
+	assert.Equal(t, "first", enabled[0].Name)
+	assert.Equal(t, "third", enabled[1].Name)
+}
+
+func TestPostgresStore_DeliveryAttempts(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "delivery_attempts")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+
+	ruleID := uuid.Must(uuid.NewV7())
+	deliveryID := uuid.Must(uuid.NewV7())
+	base := time.Now().UTC().Truncate(time.Millisecond)
+	for i, status := range []int{0, 200} {
+		attempt := &DeliveryAttempt{
+			DeliveryID:  deliveryID,
+			RuleID:      ruleID,
+			EventID:     uuid.Must(uuid.NewV7()),
+			ActionType:  "webhook",
+			Destination: "https://hooks.example.com/x",
+			Attempt:     i + 1,
+			StatusCode:  status,
+			Duration:    150 * time.Millisecond,
+			AttemptedAt: base.Add(time.Duration(i) * time.Second),
+		}
+		if status == 0 {
+			attempt.Error = "connection refused"
+		}
+		require.NoError(t, store.RecordAttempt(ctx, attempt))
+		assert.NotEqual(t, uuid.Nil, attempt.AttemptID)
+	}
+
+	list, err := store.ListAttempts(ctx, ruleID, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, 2, list[0].Attempt)
+	assert.Equal(t, 200, list[0].StatusCode)
+	assert.Equal(t, 0, list[1].StatusCode, "NULL status reads back as 0")
+	assert.Equal(t, "connection refused", list[1].Error)
+	assert.Equal(t, 150*time.Millisecond, list[1].Duration)
+
+	limited, err := store.ListAttempts(ctx, ruleID, 1)
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+}
+
+func TestPostgresStore_Executions(t *testing.T) {
+	testutil.TruncateTables(t, testPool, "actions_log")
+	store := NewPostgresStore(testPool, testLogger())
+	ctx := context.Background()
+	base := time.Now().UTC().Truncate(time.Microsecond)
+	ruleA, ruleB := uuid.Must(uuid.NewV7()), uuid.Must(uuid.NewV7())
+	eventID := uuid.Must(uuid.NewV7())
+
+	for i, e := range []Execution{
+		{RuleID: ruleA, Status: ExecutionSucceeded, LatencyMs: 12},
+		{RuleID: ruleA, Status: ExecutionFailed, Error: "status 500"},
+		{RuleID: ruleB, Status: ExecutionSucceeded},
+	} {
+		e.EventID = eventID
+		e.EventType = "sensor.reading"
+		e.AggregateID = "device-001"
+		e.ActionType = "webhook"
+		e.ExecutedAt = base.Add(time.Duration(i) * time.Second)
+		require.NoError(t, store.RecordExecution(ctx, &e))
+	}
+
+	list, total, err := store.ListExecutions(ctx, ExecutionFilter{RuleID: ruleA}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, list, 2)
+	assert.Equal(t, ExecutionFailed, list[0].Status)
+	assert.Equal(t, "status 500", list[0].Error)
+	assert.Equal(t, int64(12), list[1].LatencyMs)
+
+	_, total, err = store.ListExecutions(ctx, ExecutionFilter{EventID: eventID, Status: ExecutionSucceeded, Since: base.Add(time.Second)}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	list, total, err = store.ListExecutions(ctx, ExecutionFilter{}, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, list, 1)
+	assert.Equal(t, ruleA, list[0].RuleID)
+	assert.Equal(t, ExecutionFailed, list[0].Status)
+}
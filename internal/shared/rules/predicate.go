@@ -0,0 +1,188 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/cornjacket/platform-services/internal/shared/registry"
+)
+
+// Condition operators.
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpExists   = "exists"
+	OpContains = "contains"
+)
+
+var operators = map[string]bool{
+	OpEq: true, OpNe: true, OpGt: true, OpGte: true, OpLt: true, OpLte: true, OpExists: true, OpContains: true,
+}
+
+// Condition compares one payload field with a value, e.g.
+// {"field": "temperature", "op": "gt", "value": 90}. Fields starting with
+// registry.FieldPrefix are read from the registry entry of the event's
+// aggregate instead, e.g. {"field": "registry.tags", "op": "contains",
+// "value": "critical"}.
+type Condition struct {
+	Field string          `json:"field"`           // dot-separated path into the payload object, or registry.<field>
+	Op    string          `json:"op"`              // one of the Op constants
+	Value json.RawMessage `json:"value,omitempty"` // JSON value; numeric for gt/gte/lt/lte; unused by exists
+}
+
+// Predicate is a list of conditions that must all hold. An empty predicate
+// matches every payload.
+type Predicate []Condition
+
+// Validate checks that every condition is well formed.
+func (p Predicate) Validate() error {
+	if len(p) > MaxConditions {
+		return fmt.Errorf("predicate has %d conditions (max %d)", len(p), MaxConditions)
+	}
+	for i, c := range p {
+		if err := c.validate(); err != nil {
+			return fmt.Errorf("predicate[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (c *Condition) validate() error {
+	if c.Field == "" || strings.HasPrefix(c.Field, ".") || strings.HasSuffix(c.Field, ".") || strings.Contains(c.Field, "..") {
+		return fmt.Errorf("invalid field %q", c.Field)
+	}
+	if !operators[c.Op] {
+		return fmt.Errorf("unknown op %q: expected eq, ne, gt, gte, lt, lte, exists or contains", c.Op)
+	}
+	if c.Op == OpExists {
+		return nil
+	}
+	var value any
+	if len(c.Value) == 0 || json.Unmarshal(c.Value, &value) != nil {
+		return fmt.Errorf("op %s requires a JSON value", c.Op)
+	}
+	if _, numeric := value.(float64); isOrdering(c.Op) && !numeric {
+		return fmt.Errorf("op %s requires a numeric value", c.Op)
+	}
+	return nil
+}
+
+// Matches reports whether every condition holds for the payload. Conditions
+// on fields the payload does not have do not hold (use "exists" to test for
+// presence), and neither does any condition on a payload that is not a JSON
+// object. Conditions on registry fields do not hold either; see
+// MatchesWithRegistry.
+func (p Predicate) Matches(payload json.RawMessage) bool {
+	return p.MatchesWithRegistry(payload, nil)
+}
+
+// MatchesWithRegistry is Matches with registry fields read from entry, the
+// registry entry of the event's aggregate (see registry.Aggregate.Fields);
+// nil when it has none.
+func (p Predicate) MatchesWithRegistry(payload json.RawMessage, entry map[string]any) bool {
+	if len(p) == 0 {
+		return true
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return false
+	}
+	for i := range p {
+		if !p[i].matches(doc, entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// UsesRegistry reports whether any condition reads a registry field.
+func (p Predicate) UsesRegistry() bool {
+	return slices.ContainsFunc(p, func(c Condition) bool {
+		return strings.HasPrefix(c.Field, registry.FieldPrefix)
+	})
+}
+
+func (c *Condition) matches(doc, entry map[string]any) bool {
+	var actual any
+	var found bool
+	if field, ok := strings.CutPrefix(c.Field, registry.FieldPrefix); ok {
+		actual, found = lookup(entry, field)
+	} else {
+		actual, found = lookup(doc, c.Field)
+	}
+	if !found {
+		return false
+	}
+	if c.Op == OpExists {
+		return true
+	}
+
+	var want any
+	if err := json.Unmarshal(c.Value, &want); err != nil {
+		return false
+	}
+	switch c.Op {
+	case OpEq:
+		return reflect.DeepEqual(actual, want)
+	case OpNe:
+		return !reflect.DeepEqual(actual, want)
+	case OpContains:
+		return contains(actual, want)
+	}
+
+	a, ok1 := actual.(float64)
+	w, ok2 := want.(float64)
+	if !ok1 || !ok2 {
+		return false
+	}
+	switch c.Op {
+	case OpGt:
+		return a > w
+	case OpGte:
+		return a >= w
+	case OpLt:
+		return a < w
+	case OpLte:
+		return a <= w
+	}
+	return false
+}
+
+// lookup resolves a dot-separated path through nested objects.
+func lookup(doc map[string]any, path string) (any, bool) {
+	var node any = doc
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if node, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// contains reports whether an array has an element equal to want, or a
+// string has want as a substring.
+func contains(actual, want any) bool {
+	switch actual := actual.(type) {
+	case []any:
+		return slices.ContainsFunc(actual, func(v any) bool { return reflect.DeepEqual(v, want) })
+	case string:
+		w, ok := want.(string)
+		return ok && strings.Contains(actual, w)
+	}
+	return false
+}
+
+func isOrdering(op string) bool {
+	return op == OpGt || op == OpGte || op == OpLt || op == OpLte
+}
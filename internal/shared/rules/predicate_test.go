@@ -0,0 +1,132 @@
+package rules
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredicate_Matches(t *testing.T) {
+	payload := json.RawMessage(`{"value": 92.5, "unit": "F", "location": {"zone": "north"}, "tags": ["a"]}`)
+
+	tests := []struct {
+		name      string
+		predicate string
+		want      bool
+	}{
+		{"empty matches everything", `[]`, true},
+		{"gt holds", `[{"field":"value","op":"gt","value":90}]`, true},
+		{"gt fails", `[{"field":"value","op":"gt","value":95}]`, false},
+		{"gte boundary", `[{"field":"value","op":"gte","value":92.5}]`, true},
+		{"lt holds", `[{"field":"value","op":"lt","value":100}]`, true},
+		{"lte fails", `[{"field":"value","op":"lte","value":92}]`, false},
+		{"eq string", `[{"field":"unit","op":"eq","value":"F"}]`, true},
+		{"ne string", `[{"field":"unit","op":"ne","value":"F"}]`, false},
+		{"eq array", `[{"field":"tags","op":"eq","value":["a"]}]`, true},
+		{"nested field", `[{"field":"location.zone","op":"eq","value":"north"}]`, true},
+		{"exists", `[{"field":"location.zone","op":"exists"}]`, true},
+		{"missing field never holds", `[{"field":"humidity","op":"ne","value":1}]`, false},
+		{"path through non-object", `[{"field":"unit.x","op":"exists"}]`, false},
+		{"ordering on string field", `[{"field":"unit","op":"gt","value":1}]`, false},
+		{"all conditions must hold", `[{"field":"value","op":"gt","value":90},{"field":"unit","op":"eq","value":"C"}]`, false},
+		{"contains element", `[{"field":"tags","op":"contains","value":"a"}]`, true},
+		{"contains missing element", `[{"field":"tags","op":"contains","value":"b"}]`, false},
+		{"contains substring", `[{"field":"location.zone","op":"contains","value":"ort"}]`, true},
+		{"contains on number", `[{"field":"value","op":"contains","value":92.5}]`, false},
+		{"registry field without entry", `[{"field":"registry.site","op":"exists"}]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Predicate
+			if err := json.Unmarshal([]byte(tt.predicate), &p); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, p.Matches(payload))
+		})
+	}
+}
+
+func TestPredicate_Matches_NonObjectPayload(t *testing.T) {
+	p := Predicate{{Field: "value", Op: OpExists}}
+	assert.False(t, p.Matches(json.RawMessage(`[1, 2]`)))
+	assert.True(t, Predicate{}.Matches(json.RawMessage(`[1, 2]`)))
+}
+
+func TestPredicate_MatchesWithRegistry(t *testing.T) {
+	payload := json.RawMessage(`{"value": 92.5, "registry": {"site": "spoofed"}}`)
+	entry := map[string]any{"site": "plant-a", "tags": []any{"critical"}}
+
+	var p Predicate
+	if err := json.Unmarshal([]byte(`[{"field":"value","op":"gt","value":90},{"field":"registry.site","op":"eq","value":"plant-a"},{"field":"registry.tags","op":"contains","value":"critical"}]`), &p); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, p.UsesRegistry())
+	assert.True(t, p.MatchesWithRegistry(payload, entry))
+	assert.False(t, p.MatchesWithRegistry(payload, nil), "unregistered aggregate")
+	assert.False(t, p.Matches(payload), "registry fields are never read from the payload")
+	assert.False(t, Predicate{{Field: "value", Op: OpExists}}.UsesRegistry())
+}
+
+func TestPredicate_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cond    Condition
+		wantErr string
+	}{
+		{"valid", Condition{Field: "value", Op: OpGt, Value: json.RawMessage(`90`)}, ""},
+		{"exists needs no value", Condition{Field: "value", Op: OpExists}, ""},
+		{"contains", Condition{Field: "registry.tags", Op: OpContains, Value: json.RawMessage(`"critical"`)}, ""},
+		{"contains needs a value", Condition{Field: "tags", Op: OpContains}, "requires a JSON value"},
+		{"empty field", Condition{Field: "", Op: OpExists}, "invalid field"},
+		{"bad path", Condition{Field: "a..b", Op: OpExists}, "invalid field"},
+		{"unknown op", Condition{Field: "value", Op: "like", Value: json.RawMessage(`1`)}, "unknown op"},
+		{"missing value", Condition{Field: "value", Op: OpEq}, "requires a JSON value"},
+		{"non-numeric ordering", Condition{Field: "value", Op: OpLt, Value: json.RawMessage(`"90"`)}, "requires a numeric value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Predicate{tt.cond}.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPredicate_Validate_TooManyConditions(t *testing.T) {
+	p := make(Predicate, MaxConditions+1)
+	for i := range p {
+		p[i] = Condition{Field: "value", Op: OpExists}
+	}
+	assert.ErrorContains(t, p.Validate(), "max 16")
+}
+
+func TestRule_MatchesType(t *testing.T) {
+	exact := Rule{EventType: "sensor.reading"}
+	assert.True(t, exact.MatchesType("sensor.reading"))
+	assert.False(t, exact.MatchesType("sensor.reading.v2"))
+
+	prefix := Rule{EventType: "sensor.*"}
+	assert.True(t, prefix.MatchesType("sensor.reading"))
+	assert.False(t, prefix.MatchesType("user.login"))
+}
+
+func TestRule_Validate(t *testing.T) {
+	valid := Rule{Name: "hot", EventType: "sensor.*", ActionType: "log"}
+	assert.NoError(t, valid.Validate())
+
+	noName := valid
+	noName.Name = ""
+	assert.ErrorContains(t, noName.Validate(), "name")
+
+	badType := valid
+	badType.EventType = "sen*sor"
+	assert.ErrorContains(t, badType.Validate(), "invalid event_type")
+
+	noAction := valid
+	noAction.ActionType = ""
+	assert.ErrorContains(t, noAction.Validate(), "action_type is required")
+}
@@ -0,0 +1,127 @@
+// Package rules defines action rules: "when an event of this type arrives
+// and its payload matches this predicate, run this action". Rules are managed
+// through the Actions service API and stored in the actions database.
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// MaxNameLength and MaxEventTypeLength match the rules table columns.
+const (
+	MaxNameLength      = 255
+	MaxEventTypeLength = 255
+	MaxConditions      = 16
+	MaxTemplateLength  = 4096
+)
+
+// Rule triggers an action for events whose type and payload match.
+type Rule struct {
+	RuleID      uuid.UUID `json:"rule_id"`
+	Name        string    `json:"name"`
+	EventType   string    `json:"event_type"`  // exact type (sensor.reading) or prefix ending in "*" (sensor.*)
+	Predicate   Predicate `json:"predicate"`   // conditions on the payload; empty matches every event
+	ActionType  string    `json:"action_type"` // selects the dispatcher (see actions.Dispatcher)
+	Destination string    `json:"destination"` // dispatcher-specific target, e.g. a URL
+	Template    string    `json:"template"`    // message template for notification actions; empty uses DefaultTemplate
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Validate checks the fields a caller supplies. Action types are checked by
+// the Actions service, which knows which dispatchers are available.
+func (r *Rule) Validate() error {
+	if r.Name == "" || len(r.Name) > MaxNameLength {
+		return fmt.Errorf("name must be 1 to %d characters", MaxNameLength)
+	}
+	if len(r.EventType) > MaxEventTypeLength || !isValidEventTypePattern(r.EventType) {
+		return fmt.Errorf("invalid event_type %q: expected an event type or a prefix ending in *", r.EventType)
+	}
+	if r.ActionType == "" {
+		return fmt.Errorf("action_type is required")
+	}
+	if len(r.Template) > MaxTemplateLength {
+		return fmt.Errorf("template must be at most %d characters", MaxTemplateLength)
+	}
+	if _, err := parseTemplate(r.Template); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	return r.Predicate.Validate()
+}
+
+// MatchesType reports whether the rule applies to events of eventType.
+func (r *Rule) MatchesType(eventType string) bool {
+	if prefix, ok := strings.CutSuffix(r.EventType, "*"); ok {
+		return strings.HasPrefix(eventType, prefix)
+	}
+	return eventType == r.EventType
+}
+
+// Matches reports whether the rule fires for an event.
+func (r *Rule) Matches(eventType string, payload json.RawMessage) bool {
+	return r.MatchesType(eventType) && r.Predicate.Matches(payload)
+}
+
+// MatchesWithRegistry reports whether the rule fires for an event whose
+// aggregate has the registry entry entry (see Predicate.MatchesWithRegistry).
+func (r *Rule) MatchesWithRegistry(eventType string, payload json.RawMessage, entry map[string]any) bool {
+	return r.MatchesType(eventType) && r.Predicate.MatchesWithRegistry(payload, entry)
+}
+
+// isValidEventTypePattern mirrors the query service's event log filter: "*"
+// may appear only at the end.
+func isValidEventTypePattern(pattern string) bool {
+	prefix, _ := strings.CutSuffix(pattern, "*")
+	return pattern != "" && !strings.Contains(prefix, "*")
+}
+
+// Store provides CRUD operations for rules. Missing rules are reported as
+// errors wrapping pgx.ErrNoRows.
+type Store interface {
+	// CreateRule stores a new rule, assigning its ID and timestamps.
+	CreateRule(ctx context.Context, rule *Rule) error
+
+	// GetRule retrieves a rule by ID.
+	GetRule(ctx context.Context, ruleID uuid.UUID) (*Rule, error)
+
+	// ListRules retrieves all rules, oldest first.
+	ListRules(ctx context.Context) ([]Rule, error)
+
+	// ListEnabled retrieves the enabled rules, oldest first.
+	ListEnabled(ctx context.Context) ([]Rule, error)
+
+	// UpdateRule replaces a rule's fields, keeping its ID and creation time.
+	UpdateRule(ctx context.Context, rule *Rule) error
+
+	// DeleteRule removes a rule.
+	DeleteRule(ctx context.Context, ruleID uuid.UUID) error
+}
+
+// marshalPredicate encodes a predicate for the JSONB column; nil is stored as
+// an empty list.
+func marshalPredicate(p Predicate) ([]byte, error) {
+	if p == nil {
+		p = Predicate{}
+	}
+	return json.Marshal(p)
+}
+
+// unmarshalPredicate decodes the JSONB column.
+func unmarshalPredicate(raw []byte) (Predicate, error) {
+	p := Predicate{}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return p, nil
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
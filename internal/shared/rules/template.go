@@ -0,0 +1,49 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// DefaultTemplate is the message of rules without a template.
+const DefaultTemplate = `{{.Rule.Name}}: {{.Event.EventType}} from {{.Event.AggregateID}}`
+
+// TemplateData is what a rule's template is rendered with, e.g.
+// "{{.Event.AggregateID}} reads {{.Payload.value}}{{.Payload.unit}}".
+type TemplateData struct {
+	Rule    *Rule
+	Event   *events.Envelope
+	Payload map[string]any // the event payload; nil if it is not a JSON object
+}
+
+// parseTemplate parses a rule template; empty text parses DefaultTemplate.
+func parseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	return template.New("rule").Option("missingkey=zero").Parse(text)
+}
+
+// RenderMessage renders the rule's template for an event. Payload fields the
+// event does not have render as "<no value>".
+func (r *Rule) RenderMessage(event *events.Envelope) (string, error) {
+	tmpl, err := parseTemplate(r.Template)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+
+	data := TemplateData{Rule: r, Event: event}
+	if err := json.Unmarshal(event.Payload, &data.Payload); err != nil {
+		data.Payload = nil
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,59 @@
+package rules
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+func templateEvent(payload string) *events.Envelope {
+	return &events.Envelope{
+		EventType:   "sensor.reading",
+		AggregateID: "device-001",
+		EventTime:   time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		Payload:     json.RawMessage(payload),
+	}
+}
+
+func TestRule_RenderMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		payload  string
+		want     string
+	}{
+		{"default", "", `{}`, "hot: sensor.reading from device-001"},
+		{"payload fields", "{{.Event.AggregateID}} at {{.Payload.value}}{{.Payload.unit}}", `{"value": 95.5, "unit": "F"}`, "device-001 at 95.5F"},
+		{"nested payload", "zone {{.Payload.location.zone}}", `{"location": {"zone": "north"}}`, "zone north"},
+		{"missing field", "{{.Payload.humidity}}", `{"value": 1}`, "<no value>"},
+		{"non-object payload", "{{if .Payload}}object{{else}}other{{end}}", `[1, 2]`, "other"},
+		{"event time", `{{.Event.EventTime.Format "15:04"}}`, `{}`, "12:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := Rule{Name: "hot", Template: tt.template}
+			got, err := rule.RenderMessage(templateEvent(tt.payload))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRule_RenderMessage_ExecutionError(t *testing.T) {
+	rule := Rule{Name: "hot", Template: "{{.Payload.value.x}}"}
+	_, err := rule.RenderMessage(templateEvent(`{"value": 1}`))
+	assert.ErrorContains(t, err, "failed to render template")
+}
+
+func TestRule_Validate_Template(t *testing.T) {
+	rule := Rule{Name: "hot", EventType: "sensor.reading", ActionType: "slack", Template: "{{.Payload.value"}
+	assert.ErrorContains(t, rule.Validate(), "invalid template")
+
+	rule.Template = "{{.Payload.value}}"
+	assert.NoError(t, rule.Validate())
+}
@@ -0,0 +1,192 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// AWSConfig configures AWSProvider.
+type AWSConfig struct {
+	Region string
+	// Endpoint overrides https://secretsmanager.<region>.amazonaws.com, for
+	// VPC endpoints and local emulators.
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // for temporary credentials; optional
+	Timeout         time.Duration
+}
+
+// AWSProvider reads secrets from AWS Secrets Manager (GetSecretValue) with
+// static credentials. The path in the reference is the secret name or ARN;
+// the secret is its SecretString.
+type AWSProvider struct {
+	config AWSConfig
+	client *http.Client
+}
+
+// NewAWSProvider creates an AWSProvider.
+func NewAWSProvider(config AWSConfig) (*AWSProvider, error) {
+	if config.Region == "" {
+		return nil, fmt.Errorf("an AWS region is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials are required (AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://secretsmanager." + config.Region + ".amazonaws.com"
+	}
+	if err := validateHTTPURL(config.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid AWS endpoint: %w", err)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	return &AWSProvider{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Secret implements Provider.
+func (a *AWSProvider) Secret(ctx context.Context, path string) (string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": path})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to build Secrets Manager request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if a.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.config.SessionToken)
+	}
+	SignV4(req, body, a.config.AccessKeyID, a.config.SecretAccessKey, a.config.Region, "secretsmanager", clock.Now())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach Secrets Manager: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read Secrets Manager response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &failure)
+		return "", fmt.Errorf("secrets manager returned status %d: %s: %s", resp.StatusCode, failure.Type, failure.Message)
+	}
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(respBody, &secret); err != nil {
+		return "", fmt.Errorf("failed to decode Secrets Manager response: %w", err)
+	}
+	if secret.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no SecretString (binary secrets are not supported)", path)
+	}
+	return *secret.SecretString, nil
+}
+
+// SignV4 adds AWS Signature Version 4 headers to req, signing the host and
+// every Content-Type and X-Amz-* header already set. Also used by other AWS
+// API clients (see pii.AWSKMS).
+func SignV4(req *http.Request, body []byte, accessKeyID, secretAccessKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escapeURLComponent(k)+"="+escapeURLComponent(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
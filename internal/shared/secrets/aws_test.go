@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// TestSignV4 checks the "get-vanilla" case of the AWS Signature Version 4
+// test suite.
+func TestSignV4(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+
+	SignV4(req, nil, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, "+
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestAWSProvider(t *testing.T) {
+	clock.Set(clock.FixedClock{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)})
+	t.Cleanup(clock.Reset)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20261016/eu-west-1/secretsmanager/aws4_request, "+
+			"SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature="), auth)
+
+		body, _ := io.ReadAll(r.Body)
+		var req struct{ SecretId string }
+		require.NoError(t, json.Unmarshal(body, &req))
+		switch req.SecretId {
+		case "prod/platform/redpanda":
+			_, _ = w.Write([]byte(`{"Name":"prod/platform/redpanda","SecretString":"{\"password\":\"s3cret\"}"}`))
+		case "prod/binary":
+			_, _ = w.Write([]byte(`{"Name":"prod/binary","SecretBinary":"AAEC"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer srv.Close()
+
+	aws, err := NewAWSProvider(AWSConfig{
+		Region: "eu-west-1", Endpoint: srv.URL,
+		AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session",
+	})
+	require.NoError(t, err)
+	r := NewResolver(0, testLogger())
+	r.Register(ProviderAWS, aws)
+	ctx := context.Background()
+
+	got, err := r.Expand(ctx, "${aws:prod/platform/redpanda#password}")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", got)
+
+	_, err = r.Expand(ctx, "${aws:prod/missing}")
+	assert.ErrorContains(t, err, "status 400: ResourceNotFoundException")
+
+	_, err = r.Expand(ctx, "${aws:prod/binary}")
+	assert.ErrorContains(t, err, "binary secrets are not supported")
+}
+
+func TestNewAWSProvider_Validation(t *testing.T) {
+	_, err := NewAWSProvider(AWSConfig{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	assert.ErrorContains(t, err, "an AWS region is required")
+
+	_, err = NewAWSProvider(AWSConfig{Region: "eu-west-1"})
+	assert.ErrorContains(t, err, "AWS credentials are required")
+
+	p, err := NewAWSProvider(AWSConfig{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://secretsmanager.eu-west-1.amazonaws.com", p.config.Endpoint)
+}
@@ -0,0 +1,26 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider reads secrets from files, such as Kubernetes secret volumes,
+// Docker secrets or files written by the Vault agent. The path in the
+// reference is the file path; trailing whitespace is trimmed.
+type FileProvider struct{}
+
+// Secret implements Provider.
+func (FileProvider) Secret(_ context.Context, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("secret file path must be absolute, got %q", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), " \t\r\n"), nil
+}
@@ -0,0 +1,225 @@
+// Package secrets resolves secret references in configuration values, so
+// that database passwords and broker credentials can live in Vault, AWS
+// Secrets Manager or a mounted file instead of plaintext environment
+// variables.
+//
+// A reference has the form ${provider:path} or ${provider:path#key}:
+//
+//	postgres://ingest:${vault:secret/data/platform#db_password}@db:5432/ingestion
+//	${aws:prod/platform/redpanda#password}
+//	${file:/run/secrets/smtp_password}
+//
+// With #key the secret must be a JSON object and the reference expands to
+// that field; without it, to the whole secret.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+// Provider scheme names, as used in references.
+const (
+	ProviderVault = "vault"
+	ProviderAWS   = "aws"
+	ProviderFile  = "file"
+)
+
+// Provider fetches secrets from one backend.
+type Provider interface {
+	// Secret returns the secret stored at path.
+	Secret(ctx context.Context, path string) (string, error)
+}
+
+var refPattern = regexp.MustCompile(`\$\{([a-z]+):([^}#]+)(?:#([^}]+))?\}`)
+
+// HasRefs reports whether s contains a secret reference.
+func HasRefs(s string) bool {
+	return refPattern.MatchString(s)
+}
+
+type cached struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// Resolver expands references using its registered providers. Secrets are
+// cached for the refresh interval; after that the next expansion fetches
+// them again, which is how rotated secrets are picked up. If a refresh
+// fails, the last value is kept and the failure is logged, so a brief
+// provider outage does not break new connections.
+type Resolver struct {
+	providers map[string]Provider
+	refresh   time.Duration
+	logger    *slog.Logger
+
+	mu    sync.Mutex
+	cache map[string]cached // by provider:path
+}
+
+// NewResolver creates a Resolver with no providers. A refresh interval of
+// zero fetches secrets once and never again.
+func NewResolver(refresh time.Duration, logger *slog.Logger) *Resolver {
+	return &Resolver{
+		providers: make(map[string]Provider),
+		refresh:   refresh,
+		logger:    logger.With("component", "secrets"),
+		cache:     make(map[string]cached),
+	}
+}
+
+// Register makes p available under the given scheme.
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.providers[scheme] = p
+}
+
+// Expand replaces every reference in s with its secret.
+func (r *Resolver) Expand(ctx context.Context, s string) (string, error) {
+	return r.expand(ctx, s, func(v string) string { return v })
+}
+
+// ExpandURL is Expand for URLs: secrets are percent-encoded, so a password
+// containing '@', '/' or ':' does not change how the URL parses.
+func (r *Resolver) ExpandURL(ctx context.Context, s string) (string, error) {
+	return r.expand(ctx, s, escapeURLComponent)
+}
+
+func (r *Resolver) expand(ctx context.Context, s string, escape func(string) string) (string, error) {
+	matches := refPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, nil
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		ref := s[m[0]:m[1]]
+		scheme, path := s[m[2]:m[3]], s[m[4]:m[5]]
+		key := ""
+		if m[6] >= 0 {
+			key = s[m[6]:m[7]]
+		}
+
+		value, err := r.lookup(ctx, scheme, path, key)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+		}
+		b.WriteString(s[last:m[0]])
+		b.WriteString(escape(value))
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+func (r *Resolver) lookup(ctx context.Context, scheme, path, key string) (string, error) {
+	secret, err := r.secret(ctx, scheme, path)
+	if err != nil {
+		return "", err
+	}
+	if key == "" {
+		return secret, nil
+	}
+	return field(secret, key)
+}
+
+func (r *Resolver) secret(ctx context.Context, scheme, path string) (string, error) {
+	p, ok := r.providers[scheme]
+	if !ok {
+		return "", fmt.Errorf("secrets provider %q is not configured", scheme)
+	}
+
+	cacheKey := scheme + ":" + path
+	now := clock.Now()
+	r.mu.Lock()
+	entry, found := r.cache[cacheKey]
+	r.mu.Unlock()
+	if found && (r.refresh <= 0 || now.Sub(entry.fetchedAt) < r.refresh) {
+		return entry.value, nil
+	}
+
+	value, err := p.Secret(ctx, path)
+	if err != nil {
+		if found {
+			r.logger.Warn("failed to refresh secret, using cached value",
+				"provider", scheme, "path", path, "error", err)
+			return entry.value, nil
+		}
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.cache[cacheKey] = cached{value: value, fetchedAt: now}
+	r.mu.Unlock()
+	if found && value != entry.value {
+		r.logger.Info("secret rotated", "provider", scheme, "path", path)
+	}
+	return value, nil
+}
+
+// field extracts key from a secret holding a JSON object.
+func field(secret, key string) (string, error) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, so it has no key %q", key)
+	}
+	v, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no key %q", key)
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("secret key %q is not a string", key)
+	}
+}
+
+// escapeURLComponent percent-encodes everything but RFC 3986 unreserved
+// characters, which is safe in any part of a URL.
+func escapeURLComponent(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
+
+// Limits shared by the HTTP providers.
+const (
+	defaultTimeout   = 10 * time.Second
+	maxResponseBytes = 1 << 20
+)
+
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", raw)
+	}
+	return nil
+}
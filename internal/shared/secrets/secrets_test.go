@@ -0,0 +1,139 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// mapProvider serves secrets from a map and counts fetches.
+type mapProvider struct {
+	secrets map[string]string
+	err     error
+	calls   int
+}
+
+func (m *mapProvider) Secret(_ context.Context, path string) (string, error) {
+	m.calls++
+	if m.err != nil {
+		return "", m.err
+	}
+	s, ok := m.secrets[path]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return s, nil
+}
+
+func TestHasRefs(t *testing.T) {
+	assert.True(t, HasRefs("postgres://u:${vault:secret/data/db#password}@db/x"))
+	assert.True(t, HasRefs("${file:/run/secrets/x}"))
+	assert.False(t, HasRefs("postgres://u:p@db/x"))
+	assert.False(t, HasRefs("${}"))
+}
+
+func TestResolver_Expand(t *testing.T) {
+	r := NewResolver(time.Minute, testLogger())
+	r.Register("test", &mapProvider{secrets: map[string]string{
+		"plain": "s3cret",
+		"db":    `{"username": "ingest", "password": "p@ss/w:rd", "port": 5432}`,
+	}})
+
+	got, err := r.Expand(context.Background(), "${test:plain}")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", got)
+
+	got, err = r.Expand(context.Background(), "no references")
+	require.NoError(t, err)
+	assert.Equal(t, "no references", got)
+
+	got, err = r.Expand(context.Background(), "${test:db#username}:${test:db#port}")
+	require.NoError(t, err)
+	assert.Equal(t, "ingest:5432", got)
+
+	got, err = r.ExpandURL(context.Background(), "postgres://${test:db#username}:${test:db#password}@db:5432/ingestion")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://ingest:p%40ss%2Fw%3Ard@db:5432/ingestion", got)
+}
+
+func TestResolver_Errors(t *testing.T) {
+	r := NewResolver(time.Minute, testLogger())
+	r.Register("test", &mapProvider{secrets: map[string]string{"plain": "s3cret"}})
+
+	tests := []struct {
+		input  string
+		errMsg string
+	}{
+		{"${vault:secret/data/db#password}", `failed to resolve ${vault:secret/data/db#password}: secrets provider "vault" is not configured`},
+		{"${test:missing}", "failed to resolve ${test:missing}: not found"},
+		{"${test:plain#password}", `secret is not a JSON object, so it has no key "password"`},
+	}
+	for _, tt := range tests {
+		_, err := r.Expand(context.Background(), tt.input)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tt.errMsg)
+	}
+}
+
+func TestResolver_Refresh(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock.Set(clock.FixedClock{Time: now})
+	t.Cleanup(clock.Reset)
+
+	provider := &mapProvider{secrets: map[string]string{"pw": "one"}}
+	r := NewResolver(5*time.Minute, testLogger())
+	r.Register("test", provider)
+	ctx := context.Background()
+
+	got, err := r.Expand(ctx, "${test:pw}")
+	require.NoError(t, err)
+	assert.Equal(t, "one", got)
+
+	// Cached within the refresh interval
+	provider.secrets["pw"] = "two"
+	got, _ = r.Expand(ctx, "${test:pw}")
+	assert.Equal(t, "one", got)
+	assert.Equal(t, 1, provider.calls)
+
+	// Fetched again after it
+	clock.Set(clock.FixedClock{Time: now.Add(5 * time.Minute)})
+	got, _ = r.Expand(ctx, "${test:pw}")
+	assert.Equal(t, "two", got)
+	assert.Equal(t, 2, provider.calls)
+
+	// A failed refresh keeps the last value
+	provider.err = errors.New("vault sealed")
+	clock.Set(clock.FixedClock{Time: now.Add(10 * time.Minute)})
+	got, err = r.Expand(ctx, "${test:pw}")
+	require.NoError(t, err)
+	assert.Equal(t, "two", got)
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db_password")
+	require.NoError(t, os.WriteFile(path, []byte("hunter2\n"), 0o600))
+
+	got, err := FileProvider{}.Secret(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", got)
+
+	_, err = FileProvider{}.Secret(context.Background(), "relative/path")
+	assert.ErrorContains(t, err, "must be absolute")
+
+	_, err = FileProvider{}.Secret(context.Background(), filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorContains(t, err, "failed to read secret file")
+}
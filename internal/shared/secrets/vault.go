@@ -0,0 +1,98 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultConfig configures VaultProvider.
+type VaultConfig struct {
+	Addr      string // e.g. https://vault.internal:8200
+	Token     string
+	Namespace string // Vault Enterprise namespace; optional
+	Timeout   time.Duration
+}
+
+// VaultProvider reads secrets from Vault's HTTP API with a token. The path
+// in the reference is the API path after /v1/, so for the KV v2 engine it
+// includes "data": ${vault:secret/data/platform#db_password}. The secret is
+// the returned data as a JSON object (for KV v2, the secret's own data).
+type VaultProvider struct {
+	config VaultConfig
+	client *http.Client
+}
+
+// NewVaultProvider creates a VaultProvider.
+func NewVaultProvider(config VaultConfig) (*VaultProvider, error) {
+	if err := validateHTTPURL(config.Addr); err != nil {
+		return nil, fmt.Errorf("invalid Vault address: %w", err)
+	}
+	if config.Token == "" {
+		return nil, fmt.Errorf("a Vault token is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	return &VaultProvider{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Secret implements Provider.
+func (v *VaultProvider) Secret(ctx context.Context, path string) (string, error) {
+	url := strings.TrimRight(v.config.Addr, "/") + "/v1/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build Vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.config.Token)
+	if v.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.config.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach Vault: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read Vault response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(body, &failure)
+		if resp.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("no Vault secret at %s", path)
+		}
+		return "", fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.Join(failure.Errors, "; "))
+	}
+
+	var secret struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	data := secret.Data
+	// KV v2 nests the secret under data.data, next to data.metadata
+	if inner, ok := data["data"]; ok {
+		if _, versioned := data["metadata"]; versioned {
+			return string(inner), nil
+		}
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode Vault secret: %w", err)
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		assert.Equal(t, "platform", r.Header.Get("X-Vault-Namespace"))
+		switch r.URL.Path {
+		case "/v1/secret/data/platform": // KV v2
+			_, _ = w.Write([]byte(`{"data":{"data":{"db_password":"s3cret"},"metadata":{"version":3}}}`))
+		case "/v1/kv/platform": // KV v1
+			_, _ = w.Write([]byte(`{"data":{"db_password":"v1-secret"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	vault, err := NewVaultProvider(VaultConfig{Addr: srv.URL, Token: "root", Namespace: "platform"})
+	require.NoError(t, err)
+	r := NewResolver(0, testLogger())
+	r.Register(ProviderVault, vault)
+	ctx := context.Background()
+
+	got, err := r.Expand(ctx, "${vault:secret/data/platform#db_password}")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", got)
+
+	got, err = r.Expand(ctx, "${vault:kv/platform#db_password}")
+	require.NoError(t, err)
+	assert.Equal(t, "v1-secret", got)
+
+	_, err = r.Expand(ctx, "${vault:secret/data/missing#db_password}")
+	assert.ErrorContains(t, err, "no Vault secret at secret/data/missing")
+
+	denied, err := NewVaultProvider(VaultConfig{Addr: srv.URL, Token: "wrong", Namespace: "platform"})
+	require.NoError(t, err)
+	_, err = denied.Secret(ctx, "secret/data/platform")
+	assert.ErrorContains(t, err, "vault returned status 403: permission denied")
+}
+
+func TestNewVaultProvider_Validation(t *testing.T) {
+	_, err := NewVaultProvider(VaultConfig{Addr: "vault:8200", Token: "root"})
+	assert.ErrorContains(t, err, "invalid Vault address")
+
+	_, err = NewVaultProvider(VaultConfig{Addr: "https://vault:8200"})
+	assert.ErrorContains(t, err, "a Vault token is required")
+}
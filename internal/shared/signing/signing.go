@@ -0,0 +1,253 @@
+// Package signing signs event envelopes at ingestion and verifies them in
+// consumers, so an event altered in the outbox, the event store or on the
+// bus can be told from the one that was accepted.
+//
+// The signature covers the envelope's canonical form (see
+// events.Envelope.SigningBytes) and is stored in Metadata.Signature as
+// "<algorithm>:<key id>:<base64url signature>". Two algorithms are
+// supported:
+//   - hmac-sha256, with a secret shared by ingestion and every verifier;
+//   - ed25519, where only ingestion holds the private key and verifiers,
+//     including systems outside the platform, need just the public key.
+//
+// The key ID is derived from the key, so verifiers can hold the old and the
+// new key while keys are rotated.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// Algorithms.
+const (
+	AlgHMAC    = "hmac-sha256"
+	AlgEd25519 = "ed25519"
+)
+
+// minHMACKeyLen is the shortest HMAC secret accepted, in bytes.
+const minHMACKeyLen = 16
+
+// Verification errors.
+var (
+	ErrUnsigned         = errors.New("event is not signed")
+	ErrUnknownKey       = errors.New("event is signed with an unknown key")
+	ErrInvalidSignature = errors.New("invalid event signature")
+)
+
+// Key is a signing or verification key.
+type Key struct {
+	alg     string
+	id      string
+	secret  []byte             // hmac-sha256
+	private ed25519.PrivateKey // ed25519; nil for a verification key
+	public  ed25519.PublicKey  // ed25519
+}
+
+// ParseSigningKey parses a signing key: "hmac:<base64 secret>" (at least 16
+// bytes) or "ed25519:<base64 32-byte seed>".
+func ParseSigningKey(s string) (Key, error) {
+	kind, data, err := parseKey(s)
+	if err != nil {
+		return Key{}, err
+	}
+	if kind == "ed25519" {
+		if len(data) != ed25519.SeedSize {
+			return Key{}, fmt.Errorf("ed25519 signing key must be a %d-byte seed, got %d bytes", ed25519.SeedSize, len(data))
+		}
+		private := ed25519.NewKeyFromSeed(data)
+		return ed25519Key(private.Public().(ed25519.PublicKey), private), nil
+	}
+	return hmacKey(data)
+}
+
+// ParseVerifyKey parses a verification key: "hmac:<base64 secret>" or
+// "ed25519:<base64 32-byte public key>".
+func ParseVerifyKey(s string) (Key, error) {
+	kind, data, err := parseKey(s)
+	if err != nil {
+		return Key{}, err
+	}
+	if kind == "ed25519" {
+		if len(data) != ed25519.PublicKeySize {
+			return Key{}, fmt.Errorf("ed25519 public key must be %d bytes, got %d", ed25519.PublicKeySize, len(data))
+		}
+		return ed25519Key(ed25519.PublicKey(data), nil), nil
+	}
+	return hmacKey(data)
+}
+
+// ParseVerifyKeys parses a comma-separated list of verification keys.
+// Empty input yields no keys.
+func ParseVerifyKeys(s string) ([]Key, error) {
+	var keys []Key
+	if s == "" {
+		return keys, nil
+	}
+	for i, entry := range strings.Split(s, ",") {
+		key, err := ParseVerifyKey(strings.TrimSpace(entry))
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i+1, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func parseKey(s string) (string, []byte, error) {
+	kind, encoded, ok := strings.Cut(s, ":")
+	if !ok || (kind != "hmac" && kind != "ed25519") {
+		return "", nil, fmt.Errorf("invalid key: expected hmac:<base64> or ed25519:<base64>")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid %s key: expected base64: %w", kind, err)
+	}
+	return kind, data, nil
+}
+
+func hmacKey(secret []byte) (Key, error) {
+	if len(secret) < minHMACKeyLen {
+		return Key{}, fmt.Errorf("hmac key must be at least %d bytes, got %d", minHMACKeyLen, len(secret))
+	}
+	// The ID must not reveal the secret, so it is a MAC rather than a hash
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("key-id"))
+	return Key{alg: AlgHMAC, id: hex.EncodeToString(mac.Sum(nil)[:8]), secret: secret}, nil
+}
+
+func ed25519Key(public ed25519.PublicKey, private ed25519.PrivateKey) Key {
+	sum := sha256.Sum256(public)
+	return Key{alg: AlgEd25519, id: hex.EncodeToString(sum[:8]), private: private, public: public}
+}
+
+// Algorithm returns the key's algorithm, AlgHMAC or AlgEd25519.
+func (k Key) Algorithm() string { return k.alg }
+
+// ID returns the key ID recorded in signatures.
+func (k Key) ID() string { return k.id }
+
+// Public returns the key verifiers need: the public key of an ed25519 key,
+// the key itself for HMAC.
+func (k Key) Public() Key {
+	k.private = nil
+	return k
+}
+
+// String returns the key in ParseVerifyKey's format. HMAC keys are secret,
+// so never log them.
+func (k Key) String() string {
+	if k.alg == AlgEd25519 {
+		return "ed25519:" + base64.StdEncoding.EncodeToString(k.public)
+	}
+	return "hmac:" + base64.StdEncoding.EncodeToString(k.secret)
+}
+
+func (k Key) sign(data []byte) []byte {
+	if k.alg == AlgEd25519 {
+		return ed25519.Sign(k.private, data)
+	}
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func (k Key) verify(data, sig []byte) bool {
+	if k.alg == AlgEd25519 {
+		return ed25519.Verify(k.public, data, sig)
+	}
+	return hmac.Equal(k.sign(data), sig)
+}
+
+// Signer signs envelopes. Safe for concurrent use.
+type Signer struct {
+	key Key
+}
+
+// NewSigner creates a Signer. The key must be able to sign (see
+// ParseSigningKey).
+func NewSigner(key Key) (*Signer, error) {
+	if key.alg == "" || (key.alg == AlgEd25519 && key.private == nil) {
+		return nil, errors.New("key cannot sign: an ed25519 public key only verifies")
+	}
+	return &Signer{key: key}, nil
+}
+
+// Key returns the signing key's verification counterpart.
+func (s *Signer) Key() Key {
+	return s.key.Public()
+}
+
+// Sign sets event's Metadata.Signature. Sign last: any later change to the
+// envelope invalidates the signature.
+func (s *Signer) Sign(event *events.Envelope) error {
+	data, err := event.SigningBytes()
+	if err != nil {
+		return err
+	}
+	sig := base64.RawURLEncoding.EncodeToString(s.key.sign(data))
+	event.Metadata.Signature = s.key.alg + ":" + s.key.id + ":" + sig
+	return nil
+}
+
+// Verifier checks envelope signatures. Safe for concurrent use. A nil
+// Verifier accepts every event.
+type Verifier struct {
+	keys     map[string]Key // by algorithm and ID
+	required bool
+}
+
+// NewVerifier creates a Verifier accepting signatures by any of keys. With
+// required, unsigned events are rejected; otherwise only events with a bad
+// signature are.
+func NewVerifier(keys []Key, required bool) *Verifier {
+	v := &Verifier{keys: make(map[string]Key, len(keys)), required: required}
+	for _, k := range keys {
+		v.keys[k.alg+":"+k.id] = k.Public()
+	}
+	return v
+}
+
+// Verify checks event's signature. It fails with ErrUnsigned (only when
+// signatures are required), ErrUnknownKey or ErrInvalidSignature.
+func (v *Verifier) Verify(event *events.Envelope) error {
+	if v == nil {
+		return nil
+	}
+	signature := event.Metadata.Signature
+	if signature == "" {
+		if v.required {
+			return ErrUnsigned
+		}
+		return nil
+	}
+
+	i := strings.LastIndexByte(signature, ':')
+	if i < 0 {
+		return fmt.Errorf("%w: malformed", ErrInvalidSignature)
+	}
+	key, ok := v.keys[signature[:i]]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, signature[:i])
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature[i+1:])
+	if err != nil {
+		return fmt.Errorf("%w: malformed", ErrInvalidSignature)
+	}
+	data, err := event.SigningBytes()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if !key.verify(data, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
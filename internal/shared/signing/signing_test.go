@@ -0,0 +1,141 @@
+package signing
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/testutil"
+)
+
+var (
+	hmacSecret  = "hmac:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	ed25519Seed = "ed25519:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{9}, 32))
+)
+
+func newSigner(t *testing.T, s string) *Signer {
+	key, err := ParseSigningKey(s)
+	require.NoError(t, err)
+	signer, err := NewSigner(key)
+	require.NoError(t, err)
+	return signer
+}
+
+func TestSignVerify(t *testing.T) {
+	for _, s := range []string{hmacSecret, ed25519Seed} {
+		signer := newSigner(t, s)
+		verifier := NewVerifier([]Key{signer.Key()}, true)
+
+		event := testutil.Event().Payload(`{"value": 72.5, "unit": "F"}`).Build()
+		require.NoError(t, signer.Sign(event))
+		assert.Regexp(t, `^(hmac-sha256|ed25519):[0-9a-f]{16}:[A-Za-z0-9_-]+$`, event.Metadata.Signature)
+		assert.NoError(t, verifier.Verify(event), s)
+
+		tampered := *event
+		tampered.Payload = json.RawMessage(`{"value": 99, "unit": "F"}`)
+		assert.ErrorIs(t, verifier.Verify(&tampered), ErrInvalidSignature)
+
+		tampered = *event
+		tampered.Metadata.TenantID = "other"
+		assert.ErrorIs(t, verifier.Verify(&tampered), ErrInvalidSignature)
+
+		tampered = *event
+		tampered.AggregateType = "session"
+		assert.ErrorIs(t, verifier.Verify(&tampered), ErrInvalidSignature)
+
+		tampered = *event
+		tampered.Metadata.Attributes = map[string]string{"site": "plant-b"}
+		assert.ErrorIs(t, verifier.Verify(&tampered), ErrInvalidSignature)
+	}
+}
+
+func TestVerify_SurvivesStorage(t *testing.T) {
+	signer := newSigner(t, ed25519Seed)
+	event := testutil.Event().
+		At(time.Date(2026, 2, 7, 12, 0, 0, 123456789, time.FixedZone("CET", 3600))).
+		Payload(`{"b": 1e2, "a": [1, 2]}`).
+		Build()
+	require.NoError(t, signer.Sign(event))
+
+	// As read back from the event store: JSONB payload, microsecond UTC
+	// times, sequence numbers assigned
+	stored := *event
+	stored.Payload = json.RawMessage(`{"a": [1, 2], "b": 100}`)
+	stored.EventTime = event.EventTime.UTC().Truncate(time.Microsecond)
+	stored.IngestedAt = event.IngestedAt.UTC().Truncate(time.Microsecond)
+	stored.GlobalSeq, stored.AggregateSeq = 42, 3
+
+	assert.NoError(t, NewVerifier([]Key{signer.Key()}, true).Verify(&stored))
+}
+
+func TestVerify_Keys(t *testing.T) {
+	signer := newSigner(t, hmacSecret)
+	event := testutil.Event().Build()
+	require.NoError(t, signer.Sign(event))
+
+	other := newSigner(t, ed25519Seed)
+	assert.ErrorIs(t, NewVerifier([]Key{other.Key()}, false).Verify(event), ErrUnknownKey)
+	assert.NoError(t, NewVerifier([]Key{other.Key(), signer.Key()}, false).Verify(event), "any configured key verifies")
+
+	unsigned := testutil.Event().Build()
+	assert.NoError(t, NewVerifier([]Key{signer.Key()}, false).Verify(unsigned))
+	assert.ErrorIs(t, NewVerifier([]Key{signer.Key()}, true).Verify(unsigned), ErrUnsigned)
+
+	var none *Verifier
+	assert.NoError(t, none.Verify(event), "a nil Verifier accepts everything")
+
+	event.Metadata.Signature = "hmac-sha256:" + signer.Key().ID() + ":not base64!"
+	assert.ErrorIs(t, NewVerifier([]Key{signer.Key()}, false).Verify(event), ErrInvalidSignature)
+}
+
+func TestParseKeys(t *testing.T) {
+	signing, err := ParseSigningKey(ed25519Seed)
+	require.NoError(t, err)
+	public := signing.Public()
+	assert.Equal(t, AlgEd25519, public.Algorithm())
+
+	// The public key round-trips through ParseVerifyKey with the same ID
+	parsed, err := ParseVerifyKey(public.String())
+	require.NoError(t, err)
+	assert.Equal(t, signing.ID(), parsed.ID())
+	_, err = NewSigner(parsed)
+	assert.Error(t, err, "a public key cannot sign")
+
+	keys, err := ParseVerifyKeys(public.String() + ", " + hmacSecret)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+	keys, err = ParseVerifyKeys("")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	for _, s := range []string{
+		"rsa:AAAA",
+		"hmac:not base64!",
+		"hmac:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		"ed25519:" + base64.StdEncoding.EncodeToString([]byte("short")),
+	} {
+		_, err := ParseSigningKey(s)
+		assert.Error(t, err, s)
+	}
+	_, err = ParseVerifyKeys(hmacSecret + ",ed25519:AAAA")
+	assert.ErrorContains(t, err, "key 2:")
+}
+
+func TestSigningBytes_IgnoresSignature(t *testing.T) {
+	event := testutil.Event().Build()
+	before, err := event.SigningBytes()
+	require.NoError(t, err)
+	event.Metadata.Signature = "anything"
+	after, err := event.SigningBytes()
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	event.SetBinaryPayload("image/jpeg", []byte{0xff, 0xd8})
+	_, err = event.SigningBytes()
+	assert.NoError(t, err, "binary payloads are signed as their base64 string")
+}
@@ -0,0 +1,78 @@
+// Package timeout bounds how long service operations may wait on their
+// stores. Handlers pass the request context through with no deadline, so
+// without one a slow query holds its connection, and its client, for as long
+// as Postgres takes.
+package timeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
+)
+
+// Policy holds the timeout of each operation, such as "get" or "ingest".
+// The zero Policy sets no deadlines.
+type Policy struct {
+	Default    time.Duration            // operations not in Operations; 0 means none
+	Operations map[string]time.Duration // per operation; 0 means none
+}
+
+// ParsePolicy returns a Policy with def as its default, overridden by the
+// comma-separated "operation=duration" pairs in s, such as "search=15s,get=1s".
+func ParsePolicy(def time.Duration, s string) (Policy, error) {
+	p := Policy{Default: def, Operations: make(map[string]time.Duration)}
+	if s == "" {
+		return p, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		op, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || op == "" {
+			return Policy{}, fmt.Errorf("invalid timeout %q: expected operation=duration", pair)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return Policy{}, fmt.Errorf("invalid timeout %q: expected a non-negative duration", pair)
+		}
+		if _, dup := p.Operations[op]; dup {
+			return Policy{}, fmt.Errorf("invalid timeout %q: %s is set twice", pair, op)
+		}
+		p.Operations[op] = d
+	}
+	return p, nil
+}
+
+// For returns the timeout of op; 0 means none.
+func (p Policy) For(op string) time.Duration {
+	if d, ok := p.Operations[op]; ok {
+		return d
+	}
+	return p.Default
+}
+
+// Context returns ctx bounded by the timeout of op. The caller must call the
+// returned cancel function.
+func (p Policy) Context(ctx context.Context, op string) (context.Context, context.CancelFunc) {
+	if d := p.For(op); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Status maps the error of a failed operation to an HTTP status and a message
+// safe to return to clients: 504 when the operation ran out of time, 503
+// while the database circuit breaker is open, and 500 otherwise.
+func Status(err error) (int, string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timed out waiting for the database"
+	case errors.Is(err, pgretry.ErrCircuitOpen):
+		return http.StatusServiceUnavailable, "database unavailable, retry later"
+	default:
+		return http.StatusInternalServerError, "internal server error"
+	}
+}
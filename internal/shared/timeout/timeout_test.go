@@ -0,0 +1,71 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/infra/pgretry"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy(5*time.Second, "search=15s, events=0s")
+	require.NoError(t, err)
+
+	assert.Equal(t, 15*time.Second, p.For("search"))
+	assert.Equal(t, time.Duration(0), p.For("events"), "an explicit 0 disables the default")
+	assert.Equal(t, 5*time.Second, p.For("get"))
+
+	p, err = ParsePolicy(time.Second, "")
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, p.For("get"))
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	for _, s := range []string{"search", "=1s", "search=fast", "search=-1s", "get=1s,get=2s", "get=1s,"} {
+		_, err := ParsePolicy(time.Second, s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestPolicy_Context(t *testing.T) {
+	p := Policy{Default: time.Minute, Operations: map[string]time.Duration{"events": 0}}
+
+	ctx, cancel := p.Context(context.Background(), "get")
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+	ctx, cancel = p.Context(context.Background(), "events")
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+
+	var zero Policy
+	ctx, cancel = zero.Context(context.Background(), "get")
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
+
+func TestStatus(t *testing.T) {
+	status, _ := Status(fmt.Errorf("failed to get projection: %w", context.DeadlineExceeded))
+	assert.Equal(t, http.StatusGatewayTimeout, status)
+
+	status, _ = Status(fmt.Errorf("failed to list projections: %w", pgretry.ErrCircuitOpen))
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+
+	status, message := Status(errors.New("relation does not exist"))
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, "internal server error", message)
+
+	status, _ = Status(context.Canceled)
+	assert.Equal(t, http.StatusInternalServerError, status, "a client that went away is not a timeout")
+}
@@ -0,0 +1,60 @@
+// Package units converts measurements between the metric and imperial unit
+// systems. Unit names are as sent in event payloads ("fahrenheit",
+// "kilometers"), matched case-insensitively.
+package units
+
+import "strings"
+
+// Unit systems.
+const (
+	Metric   = "metric"
+	Imperial = "imperial"
+)
+
+// IsValidSystem checks if a unit system is supported.
+func IsValidSystem(system string) bool {
+	return system == Metric || system == Imperial
+}
+
+// conversion converts a value from one unit to its counterpart in the other
+// system.
+type conversion struct {
+	system  string                // system the source unit belongs to
+	to      string                // counterpart unit name
+	convert func(float64) float64 // absolute values
+	scale   float64               // differences (deltas) ignore offsets
+}
+
+// conversions is keyed by lowercase unit name.
+var conversions = map[string]conversion{
+	"fahrenheit":  {Imperial, "celsius", func(v float64) float64 { return (v - 32) * 5 / 9 }, 5.0 / 9},
+	"celsius":     {Metric, "fahrenheit", func(v float64) float64 { return v*9/5 + 32 }, 9.0 / 5},
+	"kelvin":      {Metric, "fahrenheit", func(v float64) float64 { return (v-273.15)*9/5 + 32 }, 9.0 / 5},
+	"feet":        {Imperial, "meters", func(v float64) float64 { return v * 0.3048 }, 0.3048},
+	"meters":      {Metric, "feet", func(v float64) float64 { return v / 0.3048 }, 1 / 0.3048},
+	"miles":       {Imperial, "kilometers", func(v float64) float64 { return v * 1.609344 }, 1.609344},
+	"kilometers":  {Metric, "miles", func(v float64) float64 { return v / 1.609344 }, 1 / 1.609344},
+	"mph":         {Imperial, "kph", func(v float64) float64 { return v * 1.609344 }, 1.609344},
+	"kph":         {Metric, "mph", func(v float64) float64 { return v / 1.609344 }, 1 / 1.609344},
+	"pounds":      {Imperial, "kilograms", func(v float64) float64 { return v * 0.45359237 }, 0.45359237},
+	"kilograms":   {Metric, "pounds", func(v float64) float64 { return v / 0.45359237 }, 1 / 0.45359237},
+	"psi":         {Imperial, "kilopascals", func(v float64) float64 { return v * 6.894757293168 }, 6.894757293168},
+	"kilopascals": {Metric, "psi", func(v float64) float64 { return v / 6.894757293168 }, 1 / 6.894757293168},
+	"gallons":     {Imperial, "liters", func(v float64) float64 { return v * 3.785411784 }, 3.785411784},
+	"liters":      {Metric, "gallons", func(v float64) float64 { return v / 3.785411784 }, 1 / 3.785411784},
+}
+
+// Convert expresses v, measured in unit, in system, returning the converted
+// value and its unit name. A difference between two readings (a delta) is
+// scaled without the offset. It returns false when unit is unknown or already
+// in system.
+func Convert(v float64, unit, system string, difference bool) (float64, string, bool) {
+	conv, ok := conversions[strings.ToLower(unit)]
+	if !ok || conv.system == system {
+		return v, unit, false
+	}
+	if difference {
+		return v * conv.scale, conv.to, true
+	}
+	return conv.convert(v), conv.to, true
+}
@@ -0,0 +1,32 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+	v, unit, ok := Convert(72.5, "Fahrenheit", Metric, false)
+	assert.True(t, ok)
+	assert.InDelta(t, 22.5, v, 1e-9)
+	assert.Equal(t, "celsius", unit)
+
+	v, unit, ok = Convert(9, "fahrenheit", Metric, true)
+	assert.True(t, ok, "differences convert without the offset")
+	assert.InDelta(t, 5, v, 1e-9)
+	assert.Equal(t, "celsius", unit)
+
+	_, unit, ok = Convert(10, "meters", Metric, false)
+	assert.False(t, ok, "already in the requested system")
+	assert.Equal(t, "meters", unit)
+
+	_, _, ok = Convert(10, "furlongs", Metric, false)
+	assert.False(t, ok)
+}
+
+func TestIsValidSystem(t *testing.T) {
+	assert.True(t, IsValidSystem(Metric))
+	assert.True(t, IsValidSystem(Imperial))
+	assert.False(t, IsValidSystem("kelvin"))
+}
@@ -0,0 +1,161 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/domain/ids"
+)
+
+// EventBuilder builds event envelopes for tests. Start one with Event; every
+// field has a valid default, so set only what the test is about:
+//
+//	env := testutil.Event().Type("user.login").Aggregate("session-1").At(t0).Payload(map[string]any{"user": "alice"}).Build()
+//
+// Build can be called repeatedly; each envelope gets a new event ID from
+// ids.New unless one is set with ID.
+type EventBuilder struct {
+	id         uuid.UUID
+	eventType  string
+	aggregate  string
+	aggType    string
+	eventTime  time.Time
+	ingestedAt time.Time
+	payload    json.RawMessage
+	metadata   events.Metadata
+}
+
+// Event starts an EventBuilder with the defaults: a sensor.reading for
+// device-001 with a temperature payload, from source "test" at schema
+// version 1, occurring and ingested at clock.Now.
+func Event() *EventBuilder {
+	return &EventBuilder{
+		eventType: "sensor.reading",
+		aggregate: "device-001",
+		payload:   json.RawMessage(`{"temperature": 22.5}`),
+		metadata:  events.Metadata{Source: "test", SchemaVersion: 1},
+	}
+}
+
+// ID sets the event ID.
+func (b *EventBuilder) ID(id uuid.UUID) *EventBuilder {
+	b.id = id
+	return b
+}
+
+// Type sets the event type.
+func (b *EventBuilder) Type(eventType string) *EventBuilder {
+	b.eventType = eventType
+	return b
+}
+
+// Aggregate sets the aggregate ID.
+func (b *EventBuilder) Aggregate(aggregateID string) *EventBuilder {
+	b.aggregate = aggregateID
+	return b
+}
+
+// AggregateType sets the aggregate type.
+func (b *EventBuilder) AggregateType(aggregateType string) *EventBuilder {
+	b.aggType = aggregateType
+	return b
+}
+
+// At sets the event time. IngestedAt defaults to it.
+func (b *EventBuilder) At(t time.Time) *EventBuilder {
+	b.eventTime = t
+	return b
+}
+
+// IngestedAt sets the ingestion time.
+func (b *EventBuilder) IngestedAt(t time.Time) *EventBuilder {
+	b.ingestedAt = t
+	return b
+}
+
+// Payload sets the payload: a string, []byte or json.RawMessage is taken as
+// JSON text, anything else is marshalled. Panics on invalid JSON, which is a
+// bug in the test.
+func (b *EventBuilder) Payload(v any) *EventBuilder {
+	var data []byte
+	switch p := v.(type) {
+	case string:
+		data = []byte(p)
+	case []byte:
+		data = p
+	case json.RawMessage:
+		data = p
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			panic(fmt.Sprintf("testutil: event payload: %v", err))
+		}
+	}
+	if !json.Valid(data) {
+		panic(fmt.Sprintf("testutil: event payload is not valid JSON: %s", data))
+	}
+	b.payload = append(json.RawMessage(nil), data...)
+	return b
+}
+
+// Metadata replaces the metadata, defaults included.
+func (b *EventBuilder) Metadata(metadata events.Metadata) *EventBuilder {
+	b.metadata = metadata
+	return b
+}
+
+// Source sets the metadata source.
+func (b *EventBuilder) Source(source string) *EventBuilder {
+	b.metadata.Source = source
+	return b
+}
+
+// Tenant sets the metadata tenant ID.
+func (b *EventBuilder) Tenant(tenantID string) *EventBuilder {
+	b.metadata.TenantID = tenantID
+	return b
+}
+
+// SchemaVersion sets the payload schema version.
+func (b *EventBuilder) SchemaVersion(version int) *EventBuilder {
+	b.metadata.SchemaVersion = version
+	return b
+}
+
+// Test marks the event as test traffic.
+func (b *EventBuilder) Test() *EventBuilder {
+	b.metadata.Test = true
+	return b
+}
+
+// Build returns a new envelope. Unset times are clock.Now, truncated to the
+// microsecond so they survive a round trip through Postgres unchanged.
+func (b *EventBuilder) Build() *events.Envelope {
+	id := b.id
+	if id == uuid.Nil {
+		id = ids.New()
+	}
+	eventTime := b.eventTime
+	if eventTime.IsZero() {
+		eventTime = clock.Now().UTC().Truncate(time.Microsecond)
+	}
+	ingestedAt := b.ingestedAt
+	if ingestedAt.IsZero() {
+		ingestedAt = eventTime
+	}
+	return &events.Envelope{
+		EventID:       id,
+		EventType:     b.eventType,
+		AggregateID:   b.aggregate,
+		AggregateType: b.aggType,
+		EventTime:     eventTime,
+		IngestedAt:    ingestedAt,
+		Payload:       append(json.RawMessage(nil), b.payload...),
+		Metadata:      b.metadata,
+	}
+}
@@ -0,0 +1,79 @@
+package testutil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+func TestEvent_Defaults(t *testing.T) {
+	env := Event().Build()
+
+	assert.NotEqual(t, uuid.Nil, env.EventID)
+	assert.Equal(t, "sensor.reading", env.EventType)
+	assert.Equal(t, "device-001", env.AggregateID)
+	assert.JSONEq(t, `{"temperature": 22.5}`, string(env.Payload))
+	assert.Equal(t, events.Metadata{Source: "test", SchemaVersion: 1}, env.Metadata)
+	assert.False(t, env.EventTime.IsZero())
+	assert.Equal(t, env.EventTime, env.IngestedAt)
+	assert.Equal(t, env.EventTime, env.EventTime.Truncate(time.Microsecond))
+}
+
+func TestEvent_Overrides(t *testing.T) {
+	eventTime := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+	id := uuid.Must(uuid.NewV7())
+
+	env := Event().ID(id).Type("user.login").Aggregate("session-1").AggregateType("session").At(eventTime).
+		Source("ingestion").Tenant("acme").SchemaVersion(2).Test().Build()
+
+	assert.Equal(t, id, env.EventID)
+	assert.Equal(t, "user.login", env.EventType)
+	assert.Equal(t, "session-1", env.AggregateID)
+	assert.Equal(t, "session", env.AggregateType)
+	assert.Equal(t, eventTime, env.EventTime)
+	assert.Equal(t, eventTime, env.IngestedAt, "ingestion time defaults to the event time")
+	assert.Equal(t, events.Metadata{Source: "ingestion", TenantID: "acme", SchemaVersion: 2, Test: true}, env.Metadata)
+
+	ingestedAt := eventTime.Add(time.Second)
+	env = Event().At(eventTime).IngestedAt(ingestedAt).Metadata(events.Metadata{TraceID: "trace-1"}).Build()
+	assert.Equal(t, ingestedAt, env.IngestedAt)
+	assert.Equal(t, events.Metadata{TraceID: "trace-1"}, env.Metadata)
+}
+
+func TestEvent_Payload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload any
+	}{
+		{"string", `{"value": 72.5}`},
+		{"bytes", []byte(`{"value": 72.5}`)},
+		{"raw message", json.RawMessage(`{"value": 72.5}`)},
+		{"marshalled", map[string]any{"value": 72.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := Event().Payload(tt.payload).Build()
+			assert.JSONEq(t, `{"value": 72.5}`, string(env.Payload))
+		})
+	}
+
+	assert.Panics(t, func() { Event().Payload(`{"value":`) })
+	assert.Panics(t, func() { Event().Payload(make(chan int)) })
+}
+
+func TestEvent_BuildIsRepeatable(t *testing.T) {
+	b := Event().Aggregate("device-7")
+	first, second := b.Build(), b.Build()
+
+	assert.NotEqual(t, first.EventID, second.EventID, "each envelope gets a new ID")
+	assert.Equal(t, "device-7", second.AggregateID)
+
+	first.Payload[0] = '['
+	require.True(t, json.Valid(second.Payload), "envelopes do not share payload bytes")
+}
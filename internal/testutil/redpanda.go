@@ -3,11 +3,19 @@
 package testutil
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
 )
 
 const defaultBrokers = "localhost:9092"
@@ -30,3 +38,124 @@ func TestTopicName(t *testing.T) string {
 	name = strings.ReplaceAll(name, " ", "-")
 	return fmt.Sprintf("test-%s-%d", name, time.Now().UnixNano())
 }
+
+// newTestClient creates a kgo client for the test brokers, closed when the
+// test ends.
+func newTestClient(t *testing.T, opts ...kgo.Opt) *kgo.Client {
+	t.Helper()
+	client, err := kgo.NewClient(append([]kgo.Opt{kgo.SeedBrokers(TestBrokers()...)}, opts...)...)
+	if err != nil {
+		t.Fatalf("failed to create kafka client: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// CreateTestTopic creates a topic named by TestTopicName with the given
+// number of partitions, and deletes it when the test ends. Use it when a
+// test depends on partitioning; auto-created topics get the broker default.
+func CreateTestTopic(t *testing.T, partitions int32) string {
+	t.Helper()
+	topic := TestTopicName(t)
+	client := newTestClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req := kmsg.NewPtrCreateTopicsRequest()
+	req.TimeoutMillis = 30000
+	rt := kmsg.NewCreateTopicsRequestTopic()
+	rt.Topic = topic
+	rt.NumPartitions = partitions
+	rt.ReplicationFactor = -1
+	req.Topics = append(req.Topics, rt)
+	resp, err := req.RequestWith(ctx, client)
+	if err != nil {
+		t.Fatalf("failed to create topic %s (is docker-compose running?): %v", topic, err)
+	}
+	for _, rt := range resp.Topics {
+		if err := kerr.ErrorForCode(rt.ErrorCode); err != nil {
+			t.Fatalf("failed to create topic %s: %v", topic, err)
+		}
+	}
+
+	// Registered after newTestClient, so it runs before the client closes
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		req := kmsg.NewPtrDeleteTopicsRequest()
+		req.TimeoutMillis = 30000
+		dt := kmsg.NewDeleteTopicsRequestTopic()
+		dt.Topic = kmsg.StringPtr(topic)
+		req.Topics = append(req.Topics, dt)
+		req.TopicNames = []string{topic}
+		if _, err := req.RequestWith(ctx, client); err != nil {
+			t.Logf("warning: failed to delete topic %s: %v", topic, err)
+		}
+	})
+	return topic
+}
+
+// ProduceRecords produces the records synchronously, auto-creating missing
+// topics, and returns them with partitions and offsets filled in.
+func ProduceRecords(t *testing.T, records ...*kgo.Record) []*kgo.Record {
+	t.Helper()
+	client := newTestClient(t, kgo.AllowAutoTopicCreation())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.ProduceSync(ctx, records...).FirstErr(); err != nil {
+		t.Fatalf("failed to produce %d records: %v", len(records), err)
+	}
+	return records
+}
+
+// ProduceEvents produces the envelopes to topic as the platform does: JSON
+// values keyed by aggregate ID, in order.
+func ProduceEvents(t *testing.T, topic string, envs ...*events.Envelope) []*kgo.Record {
+	t.Helper()
+	records := make([]*kgo.Record, len(envs))
+	for i, env := range envs {
+		value, err := json.Marshal(env)
+		if err != nil {
+			t.Fatalf("failed to marshal event %s: %v", env.EventID, err)
+		}
+		records[i] = &kgo.Record{Topic: topic, Key: []byte(env.AggregateID), Value: value}
+	}
+	return ProduceRecords(t, records...)
+}
+
+// ConsumeUntil reads topic from the start until done reports true for the
+// records read so far, and returns them. It fails the test if timeout passes
+// first or a fetch fails. opts are added to the consumer, e.g.
+// kgo.FetchIsolationLevel(kgo.ReadCommitted()).
+func ConsumeUntil(t *testing.T, topic string, timeout time.Duration, done func(records []*kgo.Record) bool, opts ...kgo.Opt) []*kgo.Record {
+	t.Helper()
+	client := newTestClient(t, append([]kgo.Opt{
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	}, opts...)...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var records []*kgo.Record
+	for !done(records) {
+		fetches := client.PollFetches(ctx)
+		if ctx.Err() != nil {
+			t.Fatalf("timed out after %s consuming %s: %d records read", timeout, topic, len(records))
+		}
+		if errs := fetches.Errors(); len(errs) > 0 {
+			t.Fatalf("failed to fetch from %s: %v", topic, errs[0].Err)
+		}
+		records = append(records, fetches.Records()...)
+	}
+	return records
+}
+
+// ConsumeN reads topic from the start until at least n records arrive; see
+// ConsumeUntil.
+func ConsumeN(t *testing.T, topic string, n int, timeout time.Duration, opts ...kgo.Opt) []*kgo.Record {
+	t.Helper()
+	return ConsumeUntil(t, topic, timeout, func(records []*kgo.Record) bool { return len(records) >= n }, opts...)
+}
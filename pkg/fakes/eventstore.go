@@ -0,0 +1,114 @@
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// EventStore is an in-memory event store, mirroring postgres.EventStoreRepo.
+// It satisfies worker.EventStoreWriter:
+//   - Insert assigns GlobalSeq across all events and AggregateSeq per
+//     aggregate, both in insert order;
+//   - inserting an event ID twice fails with a unique violation (a
+//     *pgconn.PgError with code 23505, which the worker treats as already
+//     stored), after setting the sequence numbers of the first insert.
+type EventStore struct {
+	mu         sync.Mutex
+	events     []*events.Envelope // in GlobalSeq order
+	byID       map[string]*events.Envelope
+	aggregates map[string]int64 // last AggregateSeq by aggregate
+	changed    signal
+
+	// Err, if set, is called before each insert; an error it returns fails
+	// the insert, for tests of database outages.
+	Err func(event *events.Envelope) error
+}
+
+// NewEventStore creates an empty EventStore.
+func NewEventStore() *EventStore {
+	return &EventStore{
+		byID:       make(map[string]*events.Envelope),
+		aggregates: make(map[string]int64),
+	}
+}
+
+// Insert stores event, setting its GlobalSeq and AggregateSeq.
+func (s *EventStore) Insert(ctx context.Context, event *events.Envelope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Err != nil {
+		if err := s.Err(event); err != nil {
+			return fmt.Errorf("failed to insert into event_store: %w", err)
+		}
+	}
+	if stored, ok := s.byID[event.EventID.String()]; ok {
+		// Retried outbox entry: republish with the original sequence numbers
+		event.GlobalSeq, event.AggregateSeq = stored.GlobalSeq, stored.AggregateSeq
+		return fmt.Errorf("failed to insert into event_store: %w", &pgconn.PgError{
+			Code:    "23505",
+			Message: `duplicate key value violates unique constraint "event_store_pkey"`,
+		})
+	}
+
+	clone, err := cloneEnvelope(event)
+	if err != nil {
+		return err
+	}
+	s.aggregates[event.AggregateID]++
+	clone.GlobalSeq = int64(len(s.events)) + 1
+	clone.AggregateSeq = s.aggregates[event.AggregateID]
+	event.GlobalSeq, event.AggregateSeq = clone.GlobalSeq, clone.AggregateSeq
+	s.events = append(s.events, clone)
+	s.byID[event.EventID.String()] = clone
+	s.changed.broadcast()
+	return nil
+}
+
+// Get returns a copy of a stored event, or an error wrapping pgx.ErrNoRows
+// when there is none.
+func (s *EventStore) Get(ctx context.Context, eventID uuid.UUID) (*events.Envelope, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.byID[eventID.String()]
+	if !ok {
+		return nil, fmt.Errorf("failed to get event %s: %w", eventID, pgx.ErrNoRows)
+	}
+	return cloneEnvelope(stored)
+}
+
+// Events returns the stored events in GlobalSeq order.
+func (s *EventStore) Events() []*events.Envelope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.events)
+}
+
+// Aggregate returns an aggregate's events in AggregateSeq order.
+func (s *EventStore) Aggregate(aggregateID string) []*events.Envelope {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var aggregate []*events.Envelope
+	for _, event := range s.events {
+		if event.AggregateID == aggregateID {
+			aggregate = append(aggregate, event)
+		}
+	}
+	return aggregate
+}
+
+// WaitForEvents blocks until at least n events are stored, returning them in
+// GlobalSeq order.
+func (s *EventStore) WaitForEvents(ctx context.Context, n int) ([]*events.Envelope, error) {
+	return waitFor(ctx, &s.mu, &s.changed, func() ([]*events.Envelope, bool) {
+		return slices.Clone(s.events), len(s.events) >= n
+	})
+}
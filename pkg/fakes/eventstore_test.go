@@ -0,0 +1,62 @@
+package fakes_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/services/ingestion/worker"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/pkg/fakes"
+)
+
+var _ worker.EventStoreWriter = (*fakes.EventStore)(nil)
+
+func TestEventStore_Sequences(t *testing.T) {
+	ctx := context.Background()
+	store := fakes.NewEventStore()
+	a1, b1, a2 := newEvent("a", baseTime), newEvent("b", baseTime), newEvent("a", baseTime)
+
+	for _, event := range []*events.Envelope{a1, b1, a2} {
+		require.NoError(t, store.Insert(ctx, event))
+	}
+	assert.Equal(t, [2]int64{1, 1}, [2]int64{a1.GlobalSeq, a1.AggregateSeq})
+	assert.Equal(t, [2]int64{2, 1}, [2]int64{b1.GlobalSeq, b1.AggregateSeq})
+	assert.Equal(t, [2]int64{3, 2}, [2]int64{a2.GlobalSeq, a2.AggregateSeq})
+
+	aggregate := store.Aggregate("a")
+	require.Len(t, aggregate, 2)
+	assert.Equal(t, a2.EventID, aggregate[1].EventID)
+	assert.Len(t, store.Events(), 3)
+}
+
+func TestEventStore_Duplicate(t *testing.T) {
+	ctx := context.Background()
+	store := fakes.NewEventStore()
+	event := newEvent("a", baseTime)
+	require.NoError(t, store.Insert(ctx, event))
+
+	retried := *event
+	retried.GlobalSeq, retried.AggregateSeq = 0, 0
+	err := store.Insert(ctx, &retried)
+
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr), "a unique violation, as from Postgres")
+	assert.Equal(t, "23505", pgErr.Code)
+	assert.Equal(t, int64(1), retried.GlobalSeq, "the first insert's sequence numbers")
+	assert.Len(t, store.Events(), 1)
+}
+
+func TestEventStore_Err(t *testing.T) {
+	store := fakes.NewEventStore()
+	outage := errors.New("connection refused")
+	store.Err = func(*events.Envelope) error { return outage }
+
+	err := store.Insert(context.Background(), newEvent("a", baseTime))
+	assert.ErrorIs(t, err, outage)
+	assert.Empty(t, store.Events())
+}
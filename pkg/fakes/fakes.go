@@ -0,0 +1,73 @@
+// Package fakes provides in-memory implementations of the platform's storage
+// and messaging ports, for service-level tests that need more than a mock but
+// no Postgres or Redpanda. Each fake keeps the semantics callers rely on from
+// the real adapter: ordering, idempotency and error shapes.
+//
+//	outbox := fakes.NewOutbox()          // ingestion.OutboxRepository, worker.OutboxReader
+//	store := fakes.NewEventStore()       // worker.EventStoreWriter
+//	publisher := fakes.NewPublisher()    // client/eventhandler.EventPublisher
+//	writes := fakes.NewProjectionStore() // eventhandler.ProjectionWriter over projections.MemoryStore
+//
+// Wait methods block until a condition holds, for tests of asynchronous
+// pipelines. The fakes do not import the packages whose interfaces they
+// satisfy, so those packages' own tests can use them.
+package fakes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// signal wakes waiters when a fake changes. Guarded by the fake's mutex.
+type signal struct {
+	ch chan struct{}
+}
+
+func (s *signal) broadcast() {
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
+
+// waitFor calls check under mu until it reports true, waking on each
+// broadcast of s, or returns ctx's error.
+func waitFor[T any](ctx context.Context, mu *sync.Mutex, s *signal, check func() (T, bool)) (T, error) {
+	for {
+		mu.Lock()
+		v, ok := check()
+		if s.ch == nil {
+			s.ch = make(chan struct{})
+		}
+		changed := s.ch
+		mu.Unlock()
+		if ok {
+			return v, nil
+		}
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// cloneEnvelope copies event the way a round trip through Postgres or Kafka
+// does, so a caller changing its envelope afterwards does not change what
+// was stored or published.
+func cloneEnvelope(event *events.Envelope) (*events.Envelope, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	var clone events.Envelope
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	return &clone, nil
+}
@@ -0,0 +1,218 @@
+package fakes
+
+import (
+	"context"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cornjacket/platform-services/internal/services/ingestion/worker"
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+)
+
+// Outbox is an in-memory outbox, mirroring postgres.OutboxRepo. It
+// satisfies ingestion.OutboxRepository, ingestion.UniqueOutboxRepository,
+// ingestion.BacklogReader, worker.OutboxReader and worker.DedupPurger:
+//   - entries are keyed by event ID, and inserting one already pending is a
+//     no-op;
+//   - FetchPending returns entries oldest IngestedAt first;
+//   - deleted entries are kept, in order, for Archived;
+//   - dedup windows and backlog ages are measured with clock.Now.
+type Outbox struct {
+	mu       sync.Mutex
+	pending  []worker.OutboxEntry // in insert order
+	created  map[string]time.Time // by outbox ID
+	archived []worker.OutboxEntry
+	claims   map[string]dedupClaim // by hex content hash
+	changed  signal
+}
+
+type dedupClaim struct {
+	eventID   string
+	expiresAt time.Time
+}
+
+// NewOutbox creates an empty Outbox.
+func NewOutbox() *Outbox {
+	return &Outbox{
+		created: make(map[string]time.Time),
+		claims:  make(map[string]dedupClaim),
+	}
+}
+
+// Insert adds event to the outbox unless an entry for it is pending.
+func (o *Outbox) Insert(ctx context.Context, event *events.Envelope) error {
+	clone, err := cloneEnvelope(event)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.insert(clone)
+	return nil
+}
+
+func (o *Outbox) insert(event *events.Envelope) {
+	id := event.EventID.String()
+	if _, ok := o.created[id]; ok {
+		return
+	}
+	o.pending = append(o.pending, worker.OutboxEntry{OutboxID: id, Payload: event})
+	o.created[id] = event.IngestedAt
+	o.changed.broadcast()
+}
+
+// InsertUnique adds event to the outbox unless an event with the same hash
+// was accepted within window, returning the ID of the event owning the hash.
+// An expired claim is taken over.
+func (o *Outbox) InsertUnique(ctx context.Context, event *events.Envelope, hash []byte, window time.Duration) (string, error) {
+	clone, err := cloneEnvelope(event)
+	if err != nil {
+		return "", err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key := hex.EncodeToString(hash)
+	now := clock.Now()
+	if claim, ok := o.claims[key]; ok && claim.expiresAt.After(now) {
+		return claim.eventID, nil
+	}
+	id := event.EventID.String()
+	o.claims[key] = dedupClaim{eventID: id, expiresAt: now.Add(window)}
+	o.insert(clone)
+	return id, nil
+}
+
+// PurgeDedup deletes content hashes that expired before the cutoff.
+func (o *Outbox) PurgeDedup(ctx context.Context, before time.Time) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var purged int64
+	for key, claim := range o.claims {
+		if claim.expiresAt.Before(before) {
+			delete(o.claims, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// FetchPending returns up to limit pending entries, oldest first.
+func (o *Outbox) FetchPending(ctx context.Context, limit int) ([]worker.OutboxEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.oldestFirst(limit)
+}
+
+// oldestFirst returns copies of up to limit pending entries by IngestedAt,
+// ties in insert order.
+func (o *Outbox) oldestFirst(limit int) ([]worker.OutboxEntry, error) {
+	sorted := slices.Clone(o.pending)
+	slices.SortStableFunc(sorted, func(a, b worker.OutboxEntry) int {
+		return o.created[a.OutboxID].Compare(o.created[b.OutboxID])
+	})
+	sorted = sorted[:min(limit, len(sorted))]
+
+	entries := make([]worker.OutboxEntry, len(sorted))
+	for i, entry := range sorted {
+		payload, err := cloneEnvelope(entry.Payload)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = worker.OutboxEntry{OutboxID: entry.OutboxID, Payload: payload, RetryCount: entry.RetryCount}
+	}
+	return entries, nil
+}
+
+// Delete moves a pending entry to the archive. A missing entry is ignored.
+func (o *Outbox) Delete(ctx context.Context, outboxID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	i := o.index(outboxID)
+	if i < 0 {
+		return nil
+	}
+	o.archived = append(o.archived, o.pending[i])
+	o.pending = slices.Delete(o.pending, i, i+1)
+	delete(o.created, outboxID)
+	o.changed.broadcast()
+	return nil
+}
+
+// IncrementRetry increments the retry count of a pending entry.
+func (o *Outbox) IncrementRetry(ctx context.Context, outboxID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if i := o.index(outboxID); i >= 0 {
+		o.pending[i].RetryCount++
+		o.changed.broadcast()
+	}
+	return nil
+}
+
+func (o *Outbox) index(outboxID string) int {
+	return slices.IndexFunc(o.pending, func(e worker.OutboxEntry) bool { return e.OutboxID == outboxID })
+}
+
+// Backlog counts pending entries under maxRetries, up to limit, and reports
+// the age of the oldest.
+func (o *Outbox) Backlog(ctx context.Context, limit int64, maxRetries int) (*worker.OutboxBacklog, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	backlog := &worker.OutboxBacklog{}
+	var oldest time.Time
+	for _, entry := range o.pending {
+		if entry.RetryCount >= maxRetries {
+			continue
+		}
+		if backlog.Depth < limit {
+			backlog.Depth++
+		}
+		if created := o.created[entry.OutboxID]; oldest.IsZero() || created.Before(oldest) {
+			oldest = created
+		}
+	}
+	if !oldest.IsZero() {
+		backlog.OldestAge = clock.Now().Sub(oldest)
+	}
+	return backlog, nil
+}
+
+// Pending returns the pending entries, oldest first.
+func (o *Outbox) Pending() []worker.OutboxEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	entries, _ := o.oldestFirst(len(o.pending))
+	return entries
+}
+
+// Archived returns the deleted entries, in the order they were deleted.
+func (o *Outbox) Archived() []worker.OutboxEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.archived)
+}
+
+// WaitForPending blocks until at least n entries are pending, returning them
+// oldest first.
+func (o *Outbox) WaitForPending(ctx context.Context, n int) ([]worker.OutboxEntry, error) {
+	return waitFor(ctx, &o.mu, &o.changed, func() ([]worker.OutboxEntry, bool) {
+		if len(o.pending) < n {
+			return nil, false
+		}
+		entries, _ := o.oldestFirst(len(o.pending))
+		return entries, true
+	})
+}
+
+// WaitForDrained blocks until no entry is pending.
+func (o *Outbox) WaitForDrained(ctx context.Context) error {
+	_, err := waitFor(ctx, &o.mu, &o.changed, func() (struct{}, bool) {
+		return struct{}{}, len(o.pending) == 0
+	})
+	return err
+}
@@ -0,0 +1,135 @@
+package fakes_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/services/ingestion"
+	"github.com/cornjacket/platform-services/internal/services/ingestion/worker"
+	"github.com/cornjacket/platform-services/internal/shared/domain/clock"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/pkg/fakes"
+)
+
+var (
+	_ ingestion.OutboxRepository       = (*fakes.Outbox)(nil)
+	_ ingestion.UniqueOutboxRepository = (*fakes.Outbox)(nil)
+	_ ingestion.BacklogReader          = (*fakes.Outbox)(nil)
+	_ worker.OutboxReader              = (*fakes.Outbox)(nil)
+	_ worker.DedupPurger               = (*fakes.Outbox)(nil)
+)
+
+var baseTime = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func newEvent(aggregateID string, ingestedAt time.Time) *events.Envelope {
+	return &events.Envelope{
+		EventID:     uuid.Must(uuid.NewV7()),
+		EventType:   "sensor.reading",
+		AggregateID: aggregateID,
+		EventTime:   ingestedAt,
+		IngestedAt:  ingestedAt,
+		Payload:     json.RawMessage(`{"value":1}`),
+		Metadata:    events.Metadata{Source: "test", SchemaVersion: 1},
+	}
+}
+
+func TestOutbox_InsertAndFetch(t *testing.T) {
+	ctx := context.Background()
+	outbox := fakes.NewOutbox()
+	later := newEvent("device-1", baseTime.Add(time.Second))
+	earlier := newEvent("device-2", baseTime)
+
+	require.NoError(t, outbox.Insert(ctx, later))
+	require.NoError(t, outbox.Insert(ctx, earlier))
+	require.NoError(t, outbox.Insert(ctx, later), "a retried insert is a no-op")
+	later.AggregateID = "changed"
+
+	entries, err := outbox.FetchPending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, earlier.EventID.String(), entries[0].OutboxID, "oldest first")
+	assert.Equal(t, "device-1", entries[1].Payload.AggregateID, "stored as inserted")
+
+	entries, err = outbox.FetchPending(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, outbox.IncrementRetry(ctx, earlier.EventID.String()))
+	require.NoError(t, outbox.Delete(ctx, later.EventID.String()))
+	require.NoError(t, outbox.Delete(ctx, "missing"))
+	pending := outbox.Pending()
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].RetryCount)
+	require.Len(t, outbox.Archived(), 1)
+	assert.Equal(t, later.EventID.String(), outbox.Archived()[0].OutboxID)
+}
+
+func TestOutbox_InsertUnique(t *testing.T) {
+	ctx := context.Background()
+	clock.Set(clock.FixedClock{Time: baseTime})
+	t.Cleanup(clock.Reset)
+	outbox := fakes.NewOutbox()
+	first, second := newEvent("device-1", baseTime), newEvent("device-1", baseTime)
+	hash := []byte("same content")
+
+	owner, err := outbox.InsertUnique(ctx, first, hash, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, first.EventID.String(), owner)
+
+	owner, err = outbox.InsertUnique(ctx, second, hash, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, first.EventID.String(), owner, "a duplicate within the window")
+	assert.Len(t, outbox.Pending(), 1)
+
+	clock.Set(clock.FixedClock{Time: baseTime.Add(time.Minute)})
+	owner, err = outbox.InsertUnique(ctx, second, hash, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, second.EventID.String(), owner, "an expired claim is taken over")
+	assert.Len(t, outbox.Pending(), 2)
+
+	purged, err := outbox.PurgeDedup(ctx, baseTime.Add(3*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
+
+func TestOutbox_Backlog(t *testing.T) {
+	ctx := context.Background()
+	clock.Set(clock.FixedClock{Time: baseTime.Add(time.Minute)})
+	t.Cleanup(clock.Reset)
+	outbox := fakes.NewOutbox()
+	exhausted := newEvent("device-1", baseTime)
+	require.NoError(t, outbox.Insert(ctx, exhausted))
+	require.NoError(t, outbox.IncrementRetry(ctx, exhausted.EventID.String()))
+	for i := range 3 {
+		require.NoError(t, outbox.Insert(ctx, newEvent("device-2", baseTime.Add(time.Duration(i+1)*10*time.Second))))
+	}
+
+	backlog, err := outbox.Backlog(ctx, 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), backlog.Depth, "capped at the limit")
+	assert.Equal(t, 50*time.Second, backlog.OldestAge, "exhausted entries are not counted")
+}
+
+func TestOutbox_WaitForDrained(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	outbox := fakes.NewOutbox()
+	event := newEvent("device-1", baseTime)
+	require.NoError(t, outbox.Insert(ctx, event))
+
+	go func() {
+		_ = outbox.Delete(ctx, event.EventID.String())
+	}()
+	require.NoError(t, outbox.WaitForDrained(ctx))
+
+	short, cancelShort := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancelShort()
+	_, err := outbox.WaitForPending(short, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,78 @@
+package fakes
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/projections"
+)
+
+// ProjectionWrite is a call to ProjectionStore.WriteProjection or
+// DeleteProjection.
+type ProjectionWrite struct {
+	ProjectionType string
+	AggregateID    string
+	State          json.RawMessage
+	Event          *events.Envelope
+	Delete         bool // a DeleteProjection call
+}
+
+// ProjectionStore is a projections.MemoryStore, with its newer-event-wins
+// semantics, that also records the writes it is asked for, so tests can wait
+// for a handler's output. Writes the store ignores as older are recorded too;
+// read the store for the resulting state.
+type ProjectionStore struct {
+	*projections.MemoryStore
+
+	mu      sync.Mutex
+	writes  []ProjectionWrite
+	changed signal
+}
+
+// NewProjectionStore creates a ProjectionStore over an empty MemoryStore.
+func NewProjectionStore() *ProjectionStore {
+	return &ProjectionStore{MemoryStore: projections.NewMemoryStore()}
+}
+
+// WriteProjection records the write and applies it to the store.
+func (s *ProjectionStore) WriteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	err := s.MemoryStore.WriteProjection(ctx, projType, aggregateID, state, event)
+	s.record(ProjectionWrite{ProjectionType: projType, AggregateID: aggregateID, State: state, Event: event}, err)
+	return err
+}
+
+// DeleteProjection records the deletion and applies it to the store.
+func (s *ProjectionStore) DeleteProjection(ctx context.Context, projType, aggregateID string, state []byte, event *events.Envelope) error {
+	err := s.MemoryStore.DeleteProjection(ctx, projType, aggregateID, state, event)
+	s.record(ProjectionWrite{ProjectionType: projType, AggregateID: aggregateID, State: state, Event: event, Delete: true}, err)
+	return err
+}
+
+func (s *ProjectionStore) record(w ProjectionWrite, err error) {
+	if err != nil {
+		return
+	}
+	w.State = append(json.RawMessage(nil), w.State...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes = append(s.writes, w)
+	s.changed.broadcast()
+}
+
+// Writes returns the recorded writes in call order.
+func (s *ProjectionStore) Writes() []ProjectionWrite {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.writes)
+}
+
+// WaitForWrites blocks until at least n writes are recorded, returning them
+// in call order.
+func (s *ProjectionStore) WaitForWrites(ctx context.Context, n int) ([]ProjectionWrite, error) {
+	return waitFor(ctx, &s.mu, &s.changed, func() ([]ProjectionWrite, bool) {
+		return slices.Clone(s.writes), len(s.writes) >= n
+	})
+}
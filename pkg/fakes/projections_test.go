@@ -0,0 +1,39 @@
+package fakes_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/services/eventhandler"
+	"github.com/cornjacket/platform-services/pkg/fakes"
+)
+
+var (
+	_ eventhandler.ProjectionWriter  = (*fakes.ProjectionStore)(nil)
+	_ eventhandler.ProjectionDeleter = (*fakes.ProjectionStore)(nil)
+	_ eventhandler.ProjectionReader  = (*fakes.ProjectionStore)(nil)
+)
+
+func TestProjectionStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store := fakes.NewProjectionStore()
+	newer, older := newEvent("device-1", baseTime.Add(time.Second)), newEvent("device-1", baseTime)
+
+	go func() {
+		_ = store.WriteProjection(ctx, "sensor_state", "device-1", []byte(`{"value":2}`), newer)
+		_ = store.WriteProjection(ctx, "sensor_state", "device-1", []byte(`{"value":1}`), older)
+	}()
+	writes, err := store.WaitForWrites(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, newer.EventID, writes[0].Event.EventID)
+	assert.JSONEq(t, `{"value":1}`, string(writes[1].State), "ignored writes are recorded too")
+
+	p, err := store.GetProjection(ctx, "sensor_state", "device-1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value":2}`, string(p.State), "the newer event wins")
+}
@@ -0,0 +1,148 @@
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/infra/redpanda"
+)
+
+// Record is an event published to a topic.
+type Record struct {
+	Topic  string
+	Key    string // the aggregate ID, which the producer partitions by
+	Offset int64  // position in the topic, from 0
+	Event  *events.Envelope
+}
+
+// Publisher is an in-memory message bus producer, mirroring
+// redpanda.Producer. It satisfies client/eventhandler.EventPublisher:
+//   - each topic is one ordered log, which keeps Kafka's per-key order;
+//   - PublishTransaction records every message or none;
+//   - PublishAsync calls onDelivery from another goroutine, in publish order;
+//   - as with the real producer, only a transactional Publisher (see
+//     NewTransactionalPublisher) supports PublishTransaction, and it does not
+//     support PublishAsync.
+type Publisher struct {
+	mu            sync.Mutex
+	transactional bool
+	topics        map[string][]Record
+	changed       signal
+
+	deliveries []func()
+	delivering bool
+
+	// Err, if set, is called before each message is published; an error it
+	// returns fails the publish, or the whole transaction, for tests of
+	// broker outages.
+	Err func(topic string, event *events.Envelope) error
+}
+
+// NewPublisher creates a Publisher like a producer without a transactional ID.
+func NewPublisher() *Publisher {
+	return &Publisher{topics: make(map[string][]Record)}
+}
+
+// NewTransactionalPublisher creates a Publisher like a producer with a
+// transactional ID.
+func NewTransactionalPublisher() *Publisher {
+	return &Publisher{transactional: true, topics: make(map[string][]Record)}
+}
+
+// Publish records event on topic.
+func (p *Publisher) Publish(ctx context.Context, topic string, event *events.Envelope) error {
+	if p.transactional {
+		return p.PublishTransaction(ctx, []redpanda.Message{{Topic: topic, Event: event}})
+	}
+	return p.publish([]redpanda.Message{{Topic: topic, Event: event}})
+}
+
+// PublishAsync records event on topic and calls onDelivery with the result.
+func (p *Publisher) PublishAsync(ctx context.Context, topic string, event *events.Envelope, onDelivery func(error)) {
+	err := fmt.Errorf("async publish is not supported by a transactional producer")
+	if !p.transactional {
+		err = p.publish([]redpanda.Message{{Topic: topic, Event: event}})
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.deliveries = append(p.deliveries, func() { onDelivery(err) })
+	if !p.delivering {
+		p.delivering = true
+		go p.deliver()
+	}
+}
+
+// deliver calls queued delivery callbacks in order until none is left.
+func (p *Publisher) deliver() {
+	for {
+		p.mu.Lock()
+		if len(p.deliveries) == 0 {
+			p.delivering = false
+			p.mu.Unlock()
+			return
+		}
+		next := p.deliveries[0]
+		p.deliveries = p.deliveries[1:]
+		p.mu.Unlock()
+		next()
+	}
+}
+
+// PublishTransaction records all messages, or none if any fails.
+func (p *Publisher) PublishTransaction(ctx context.Context, msgs []redpanda.Message) error {
+	if !p.transactional {
+		return fmt.Errorf("producer is not transactional (no transactional ID configured)")
+	}
+	return p.publish(msgs)
+}
+
+func (p *Publisher) publish(msgs []redpanda.Message) error {
+	clones := make([]*events.Envelope, len(msgs))
+	for i, msg := range msgs {
+		clone, err := cloneEnvelope(msg.Event)
+		if err != nil {
+			return err
+		}
+		clones[i] = clone
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		for _, msg := range msgs {
+			if err := p.Err(msg.Topic, msg.Event); err != nil {
+				return fmt.Errorf("failed to publish to %s: %w", msg.Topic, err)
+			}
+		}
+	}
+	for i, msg := range msgs {
+		log := p.topics[msg.Topic]
+		p.topics[msg.Topic] = append(log, Record{
+			Topic:  msg.Topic,
+			Key:    msg.Event.AggregateID,
+			Offset: int64(len(log)),
+			Event:  clones[i],
+		})
+	}
+	p.changed.broadcast()
+	return nil
+}
+
+// Records returns the records published to topic, in offset order.
+func (p *Publisher) Records(topic string) []Record {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.topics[topic])
+}
+
+// WaitForRecords blocks until at least n records are published to topic,
+// returning them in offset order.
+func (p *Publisher) WaitForRecords(ctx context.Context, topic string, n int) ([]Record, error) {
+	return waitFor(ctx, &p.mu, &p.changed, func() ([]Record, bool) {
+		return slices.Clone(p.topics[topic]), len(p.topics[topic]) >= n
+	})
+}
@@ -0,0 +1,88 @@
+package fakes_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cornjacket/platform-services/internal/client/eventhandler"
+	"github.com/cornjacket/platform-services/internal/shared/domain/events"
+	"github.com/cornjacket/platform-services/internal/shared/infra/redpanda"
+	"github.com/cornjacket/platform-services/pkg/fakes"
+)
+
+var _ eventhandler.EventPublisher = (*fakes.Publisher)(nil)
+
+func TestPublisher_Publish(t *testing.T) {
+	ctx := context.Background()
+	publisher := fakes.NewPublisher()
+	first, second := newEvent("a", baseTime), newEvent("b", baseTime)
+
+	require.NoError(t, publisher.Publish(ctx, "sensor-events", first))
+	require.NoError(t, publisher.Publish(ctx, "sensor-events", second))
+
+	records := publisher.Records("sensor-events")
+	require.Len(t, records, 2)
+	assert.Equal(t, first.EventID, records[0].Event.EventID)
+	assert.Equal(t, "b", records[1].Key)
+	assert.Equal(t, int64(1), records[1].Offset)
+	assert.Empty(t, publisher.Records("user-events"))
+
+	err := publisher.PublishTransaction(ctx, []redpanda.Message{{Topic: "sensor-events", Event: first}})
+	assert.ErrorContains(t, err, "not transactional")
+}
+
+func TestPublisher_PublishAsyncInOrder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	publisher := fakes.NewPublisher()
+
+	delivered := make(chan int, 10)
+	for i := range 10 {
+		publisher.PublishAsync(ctx, "sensor-events", newEvent("a", baseTime), func(err error) {
+			assert.NoError(t, err)
+			delivered <- i
+		})
+	}
+	for i := range 10 {
+		select {
+		case got := <-delivered:
+			assert.Equal(t, i, got)
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for delivery")
+		}
+	}
+}
+
+func TestPublisher_TransactionAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	publisher := fakes.NewTransactionalPublisher()
+	client := eventhandler.New(publisher, eventhandler.DefaultRouter(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	batch := []*events.Envelope{newEvent("a", baseTime), newEvent("b", baseTime)}
+
+	outage := errors.New("broker unavailable")
+	publisher.Err = func(topic string, event *events.Envelope) error {
+		if event.AggregateID == "b" {
+			return outage
+		}
+		return nil
+	}
+	assert.ErrorIs(t, client.SubmitEventBatch(ctx, batch), outage)
+	assert.Empty(t, publisher.Records(eventhandler.DefaultRouter().Topic("sensor.reading")))
+
+	publisher.Err = nil
+	require.NoError(t, client.SubmitEventBatch(ctx, batch))
+	records, err := publisher.WaitForRecords(ctx, eventhandler.DefaultRouter().Topic("sensor.reading"), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "a", records[0].Key)
+
+	done := make(chan error, 1)
+	publisher.PublishAsync(ctx, "sensor-events", batch[0], func(err error) { done <- err })
+	assert.ErrorContains(t, <-done, "not supported by a transactional producer")
+}